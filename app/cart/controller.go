@@ -0,0 +1,463 @@
+package cart
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"base/app/discounts"
+	"base/app/products"
+	"base/app/shipping"
+	"base/core/router"
+	"base/core/translation"
+	"base/core/types"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+// TokenHeader is the request header guests send their cart token in
+const TokenHeader = "X-Cart-Token"
+
+type CartController struct {
+	Service *CartService
+}
+
+func NewCartController(service *CartService) *CartController {
+	return &CartController{
+		Service: service,
+	}
+}
+
+// Routes registers the storefront cart endpoints. They are open to guests, who are known
+// by the X-Cart-Token header; a Bearer token makes them use the cart of the signed in user,
+// into which the guest cart is merged.
+func (c *CartController) Routes(router *router.RouterGroup) {
+	router.GET("/cart", c.Get)                              // Get the priced cart
+	router.DELETE("/cart", c.Clear)                         // Remove all items
+	router.POST("/cart/items", c.AddItem)                   // Add an item
+	router.PUT("/cart/items/:id", c.UpdateItem)             // Change the quantity of an item
+	router.DELETE("/cart/items/:id", c.RemoveItem)          // Remove an item
+	router.PUT("/cart/coupon", c.ApplyCoupon)               // Apply a coupon
+	router.DELETE("/cart/coupon", c.RemoveCoupon)           // Remove the coupon
+	router.PUT("/cart/destination", c.Destination)          // Set where it ships to
+	router.GET("/cart/shipping-methods", c.ShippingMethods) // Methods that ship it
+	router.PUT("/cart/shipping", c.SetShipping)             // Choose the shipping method
+	router.DELETE("/cart/shipping", c.RemoveShipping)       // Remove the shipping method
+	router.POST("/cart/checkout", c.Checkout)               // Place the order
+}
+
+// GetCart godoc
+// @Summary Get the cart
+// @Description Get the cart of the signed in user or of the guest with the X-Cart-Token header, priced from the catalog. Signing in merges the guest cart into the user's cart
+// @Tags App/Cart
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param X-Cart-Token header string false "Guest cart token"
+// @Success 200 {object} CartResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /cart [get]
+func (c *CartController) Get(ctx *router.Context) error {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusUnauthorized, types.ErrorResponse{Error: "Unauthorized: " + err.Error()})
+	}
+
+	cart, err := c.Service.Get(ctx.Request.Context(), userId, ctx.GetHeader(TokenHeader))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to get cart")
+	}
+	return c.respond(ctx, http.StatusOK, cart)
+}
+
+// ClearCart godoc
+// @Summary Clear the cart
+// @Description Remove all items and the coupon from the cart
+// @Tags App/Cart
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param X-Cart-Token header string false "Guest cart token"
+// @Success 200 {object} CartResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /cart [delete]
+func (c *CartController) Clear(ctx *router.Context) error {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusUnauthorized, types.ErrorResponse{Error: "Unauthorized: " + err.Error()})
+	}
+
+	cart, err := c.Service.Clear(ctx.Request.Context(), userId, ctx.GetHeader(TokenHeader))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to clear cart")
+	}
+	return c.respond(ctx, http.StatusOK, cart)
+}
+
+// AddCartItem godoc
+// @Summary Add an item to the cart
+// @Description Add a product, or a variant of a product with variants, to the cart. The first item creates the cart; guests get its token in the response
+// @Tags App/Cart
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Cart-Token header string false "Guest cart token"
+// @Param item body AddItemRequest true "Item to add; quantity defaults to 1"
+// @Success 200 {object} CartResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /cart/items [post]
+func (c *CartController) AddItem(ctx *router.Context) error {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusUnauthorized, types.ErrorResponse{Error: "Unauthorized: " + err.Error()})
+	}
+
+	var req AddItemRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	cart, err := c.Service.AddItem(ctx.Request.Context(), userId, ctx.GetHeader(TokenHeader), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to add item")
+	}
+	return c.respond(ctx, http.StatusOK, cart)
+}
+
+// UpdateCartItem godoc
+// @Summary Update a cart item
+// @Description Change the quantity of a cart item; a quantity of 0 removes it
+// @Tags App/Cart
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Cart-Token header string false "Guest cart token"
+// @Param id path int true "Cart item id"
+// @Param item body UpdateItemRequest true "New quantity"
+// @Success 200 {object} CartResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /cart/items/{id} [put]
+func (c *CartController) UpdateItem(ctx *router.Context) error {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusUnauthorized, types.ErrorResponse{Error: "Unauthorized: " + err.Error()})
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	var req UpdateItemRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	cart, err := c.Service.UpdateItem(ctx.Request.Context(), userId, ctx.GetHeader(TokenHeader), uint(id), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to update item")
+	}
+	return c.respond(ctx, http.StatusOK, cart)
+}
+
+// RemoveCartItem godoc
+// @Summary Remove a cart item
+// @Description Remove an item from the cart
+// @Tags App/Cart
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param X-Cart-Token header string false "Guest cart token"
+// @Param id path int true "Cart item id"
+// @Success 200 {object} CartResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /cart/items/{id} [delete]
+func (c *CartController) RemoveItem(ctx *router.Context) error {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusUnauthorized, types.ErrorResponse{Error: "Unauthorized: " + err.Error()})
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	cart, err := c.Service.RemoveItem(ctx.Request.Context(), userId, ctx.GetHeader(TokenHeader), uint(id))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to remove item")
+	}
+	return c.respond(ctx, http.StatusOK, cart)
+}
+
+// ApplyCartCoupon godoc
+// @Summary Apply a coupon to the cart
+// @Description Apply a discount code to the cart. Answers 409 with the reason when the coupon doesn't apply to it
+// @Tags App/Cart
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Cart-Token header string false "Guest cart token"
+// @Param coupon body CouponRequest true "Coupon code"
+// @Success 200 {object} CartResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 409 {object} types.ErrorResponse
+// @Router /cart/coupon [put]
+func (c *CartController) ApplyCoupon(ctx *router.Context) error {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusUnauthorized, types.ErrorResponse{Error: "Unauthorized: " + err.Error()})
+	}
+
+	var req CouponRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	cart, err := c.Service.ApplyCoupon(ctx.Request.Context(), userId, ctx.GetHeader(TokenHeader), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to apply coupon")
+	}
+	return c.respond(ctx, http.StatusOK, cart)
+}
+
+// SetCartDestination godoc
+// @Summary Set where the cart ships to
+// @Description Set the country and region the cart ships to, so its items are taxed at their rates before checkout. An empty country clears it; checkout taxes for the shipping address
+// @Tags App/Cart
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Cart-Token header string false "Guest cart token"
+// @Param destination body DestinationRequest true "Country and region"
+// @Success 200 {object} CartResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /cart/destination [put]
+func (c *CartController) Destination(ctx *router.Context) error {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusUnauthorized, types.ErrorResponse{Error: "Unauthorized: " + err.Error()})
+	}
+
+	var req DestinationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	cart, err := c.Service.SetDestination(ctx.Request.Context(), userId, ctx.GetHeader(TokenHeader), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to set destination")
+	}
+	return c.respond(ctx, http.StatusOK, cart)
+}
+
+// RemoveCartCoupon godoc
+// @Summary Remove the coupon from the cart
+// @Description Remove the discount code from the cart
+// @Tags App/Cart
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param X-Cart-Token header string false "Guest cart token"
+// @Success 200 {object} CartResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /cart/coupon [delete]
+func (c *CartController) RemoveCoupon(ctx *router.Context) error {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusUnauthorized, types.ErrorResponse{Error: "Unauthorized: " + err.Error()})
+	}
+
+	cart, err := c.Service.RemoveCoupon(ctx.Request.Context(), userId, ctx.GetHeader(TokenHeader))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to remove coupon")
+	}
+	return c.respond(ctx, http.StatusOK, cart)
+}
+
+// ListCartShippingMethods godoc
+// @Summary List the shipping methods of the cart
+// @Description Get the shipping methods that ship the cart to its destination (see PUT /cart/destination) with their prices; without a destination every method is listed
+// @Tags App/Cart
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param X-Cart-Token header string false "Guest cart token"
+// @Success 200 {array} shipping.Quote
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /cart/shipping-methods [get]
+func (c *CartController) ShippingMethods(ctx *router.Context) error {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusUnauthorized, types.ErrorResponse{Error: "Unauthorized: " + err.Error()})
+	}
+
+	quotes, err := c.Service.ShippingMethods(ctx.Request.Context(), userId, ctx.GetHeader(TokenHeader))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to get shipping methods")
+	}
+	return ctx.JSON(http.StatusOK, quotes)
+}
+
+// SetCartShipping godoc
+// @Summary Choose the shipping method of the cart
+// @Description Set the shipping method of the cart; its price is added to the total. Answers 409 when the method doesn't ship the cart
+// @Tags App/Cart
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Cart-Token header string false "Guest cart token"
+// @Param shipping body ShippingRequest true "Shipping method"
+// @Success 200 {object} CartResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 409 {object} types.ErrorResponse
+// @Router /cart/shipping [put]
+func (c *CartController) SetShipping(ctx *router.Context) error {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusUnauthorized, types.ErrorResponse{Error: "Unauthorized: " + err.Error()})
+	}
+
+	var req ShippingRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	cart, err := c.Service.SetShipping(ctx.Request.Context(), userId, ctx.GetHeader(TokenHeader), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to set shipping method")
+	}
+	return c.respond(ctx, http.StatusOK, cart)
+}
+
+// RemoveCartShipping godoc
+// @Summary Remove the shipping method from the cart
+// @Description Remove the shipping method from the cart
+// @Tags App/Cart
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param X-Cart-Token header string false "Guest cart token"
+// @Success 200 {object} CartResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /cart/shipping [delete]
+func (c *CartController) RemoveShipping(ctx *router.Context) error {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusUnauthorized, types.ErrorResponse{Error: "Unauthorized: " + err.Error()})
+	}
+
+	cart, err := c.Service.RemoveShipping(ctx.Request.Context(), userId, ctx.GetHeader(TokenHeader))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to remove shipping method")
+	}
+	return c.respond(ctx, http.StatusOK, cart)
+}
+
+// Checkout godoc
+// @Summary Check out the cart
+// @Description Turn the cart into a pending order, taxed and shipped to the shipping address: the stock is taken, the coupon redeemed and the cart emptied. A shipping method is required when one ships the cart. With a payment provider the response has the payment to complete; the order is marked paid once it succeeds. Guests must give their email
+// @Tags App/Cart
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Cart-Token header string false "Guest cart token"
+// @Param checkout body CheckoutRequest true "Checkout request"
+// @Success 201 {object} CheckoutResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 409 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /cart/checkout [post]
+func (c *CartController) Checkout(ctx *router.Context) error {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusUnauthorized, types.ErrorResponse{Error: "Unauthorized: " + err.Error()})
+	}
+
+	var req CheckoutRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	response, err := c.Service.Checkout(ctx.Request.Context(), userId, ctx.GetHeader(TokenHeader), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to check out")
+	}
+	return ctx.JSON(http.StatusCreated, response)
+}
+
+// respond writes a cart with the reason its coupon doesn't apply in the request locale
+func (c *CartController) respond(ctx *router.Context, status int, cart *CartResponse) error {
+	if cart.couponErr != nil {
+		cart.CouponError = translation.Error(ctx, cart.couponErr)
+	}
+	if cart.shippingErr != nil {
+		cart.ShippingError = translation.Error(ctx, cart.shippingErr)
+	}
+	return ctx.JSON(status, cart)
+}
+
+// fail writes the error response of a service error
+func (c *CartController) fail(ctx *router.Context, err error, message string) error {
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   translation.Error(ctx, err),
+			Details: translation.LocalizeValidation(ctx, validationErrors),
+		})
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Item not found"})
+	}
+	if errors.Is(err, discounts.ErrCouponNotFound) {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: translation.Error(ctx, err)})
+	}
+	if errors.Is(err, ErrCartEmpty) || errors.Is(err, ErrItemsUnavailable) ||
+		errors.Is(err, products.ErrInsufficientStock) || discounts.IsCouponError(err) ||
+		errors.Is(err, ErrShippingRequired) || errors.Is(err, shipping.ErrMethodUnavailable) {
+		return ctx.JSON(http.StatusConflict, types.ErrorResponse{Error: translation.Error(ctx, err)})
+	}
+	return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: message + ": " + err.Error()})
+}
+
+// currentUser returns the signed in user, 0 for guests. The cart routes skip the auth
+// middleware so guests can use them; a Bearer token sent along is checked here.
+func currentUser(ctx *router.Context) (uint, error) {
+	if userId := ctx.GetUint("user_id"); userId != 0 {
+		return userId, nil
+	}
+	header := ctx.GetHeader("Authorization")
+	if header == "" {
+		return 0, nil
+	}
+	token, found := strings.CutPrefix(header, "Bearer ")
+	if !found {
+		return 0, errors.New("invalid authorization format")
+	}
+	return types.ValidateJWT(token)
+}
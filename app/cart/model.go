@@ -0,0 +1,138 @@
+package cart
+
+import (
+	"time"
+
+	"base/app/orders"
+	"base/app/payments"
+	"base/core/types"
+)
+
+// Cart is the shopping cart of a signed in user or of a guest, who is known by its Token.
+// Items hold no prices: carts are priced from the catalog whenever they are read.
+type Cart struct {
+	Id               uint        `json:"id" gorm:"primarykey"`
+	CreatedAt        time.Time   `json:"created_at"`
+	UpdatedAt        time.Time   `json:"updated_at" gorm:"index"`
+	Token            string      `json:"-" gorm:"size:64;uniqueIndex"`
+	UserId           *uint       `json:"user_id" gorm:"uniqueIndex"` // Nil for guest carts
+	CouponCode       string      `json:"coupon_code" gorm:"size:64"`
+	Country          string      `json:"country" gorm:"size:2"`  // Where the cart ships to, which decides the tax rates
+	Region           string      `json:"region" gorm:"size:128"` // State or province of the country
+	ShippingMethodId *uint       `json:"shipping_method_id"`
+	Items            []*CartItem `json:"items,omitempty" gorm:"foreignKey:CartId"`
+}
+
+// TableName returns the table name for the Cart model
+func (m *Cart) TableName() string {
+	return "carts"
+}
+
+// CartItem is a product, or a variant of it, in a cart
+type CartItem struct {
+	Id        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	CartId    uint      `json:"cart_id" gorm:"index"`
+	ProductId uint      `json:"product_id"`
+	VariantId *uint     `json:"variant_id"`
+	Quantity  int       `json:"quantity"`
+}
+
+// TableName returns the table name for the CartItem model
+func (m *CartItem) TableName() string {
+	return "cart_items"
+}
+
+// AddItemRequest adds a product to the cart; adding one that is in the cart already adds
+// to its quantity
+type AddItemRequest struct {
+	ProductId uint  `json:"product_id" validate:"required"`
+	VariantId *uint `json:"variant_id,omitempty"`
+	Quantity  int   `json:"quantity" validate:"gte=1,lte=1000"`
+}
+
+// UpdateItemRequest changes the quantity of a cart item; 0 removes it
+type UpdateItemRequest struct {
+	Quantity int `json:"quantity" validate:"gte=0,lte=1000"`
+}
+
+// CouponRequest applies a coupon code to the cart
+type CouponRequest struct {
+	Code string `json:"code" validate:"required,max=64"`
+}
+
+// DestinationRequest sets where the cart ships to, so it is taxed at the rates of the
+// region before checkout; an empty country clears it
+type DestinationRequest struct {
+	Country string `json:"country" validate:"omitempty,iso3166_1_alpha2"`
+	Region  string `json:"region,omitempty" validate:"max=128"`
+}
+
+// ShippingRequest chooses the shipping method of the cart
+type ShippingRequest struct {
+	MethodId uint `json:"method_id" validate:"required"`
+}
+
+// CheckoutRequest turns the cart into an order. Guests give their email; signed in users
+// get the email of their account. The addresses are validated on their own, so their
+// errors are named after them.
+type CheckoutRequest struct {
+	Email           string          `json:"email,omitempty" validate:"omitempty,email,max=255"`
+	ShippingAddress orders.Address  `json:"shipping_address" validate:"-"`
+	BillingAddress  *orders.Address `json:"billing_address,omitempty" validate:"-"` // Defaults to the shipping address
+	Notes           string          `json:"notes,omitempty" validate:"max=2000"`
+}
+
+// ItemResponse is a priced cart item; amounts are in cents
+type ItemResponse struct {
+	Id          uint        `json:"id"`
+	ProductId   uint        `json:"product_id"`
+	VariantId   *uint       `json:"variant_id"`
+	Sku         string      `json:"sku"`
+	Name        string      `json:"name"`
+	VariantName string      `json:"variant_name,omitempty"`
+	Quantity    int         `json:"quantity"`
+	UnitPrice   types.Money `json:"unit_price"`
+	Subtotal    types.Money `json:"subtotal"` // UnitPrice * Quantity
+	Discount    types.Money `json:"discount"`
+	TaxRate     float64     `json:"tax_rate"` // Percent
+	Tax         types.Money `json:"tax"`
+	Total       types.Money `json:"total"`     // Subtotal - Discount + Tax
+	Available   bool        `json:"available"` // The product is still sold; unavailable items aren't counted
+	InStock     bool        `json:"in_stock"`  // The quantity is in stock
+}
+
+// CartResponse is a cart priced from the catalog; amounts are in cents. Token is the guest
+// token to send back in the X-Cart-Token header.
+type CartResponse struct {
+	Token       string          `json:"token,omitempty"`
+	Currency    string          `json:"currency"`
+	Items       []*ItemResponse `json:"items"`
+	ItemCount   int             `json:"item_count"`
+	CouponCode  string          `json:"coupon_code,omitempty"`
+	CouponError string          `json:"coupon_error,omitempty"` // Why the coupon doesn't apply anymore
+	Country     string          `json:"country,omitempty"`      // Destination the items are taxed for
+	Region      string          `json:"region,omitempty"`
+	Weight      int             `json:"weight"` // Grams
+
+	ShippingMethodId *uint  `json:"shipping_method_id,omitempty"`
+	ShippingMethod   string `json:"shipping_method,omitempty"`
+	ShippingError    string `json:"shipping_error,omitempty"` // Why the shipping method doesn't ship the cart
+
+	Subtotal types.Money `json:"subtotal"`
+	Discount types.Money `json:"discount"`
+	Tax      types.Money `json:"tax"`
+	Shipping types.Money `json:"shipping"`
+	Total    types.Money `json:"total"` // Subtotal - Discount + Tax + Shipping
+
+	couponErr   error // The error behind CouponError
+	shippingErr error // The error behind ShippingError
+}
+
+// CheckoutResponse is the placed order and, when a payment provider is configured, the
+// payment to complete with its client_secret
+type CheckoutResponse struct {
+	Order   *orders.Order             `json:"order"`
+	Payment *payments.PaymentResponse `json:"payment,omitempty"`
+}
@@ -0,0 +1,64 @@
+package cart
+
+import (
+	"base/app/discounts"
+	"base/app/orders"
+	"base/app/payments"
+	"base/app/shipping"
+	"base/app/taxes"
+	"base/core/module"
+	"base/core/router"
+
+	"gorm.io/gorm"
+)
+
+// Module provides the storefront cart: guest and user carts priced from the catalog with
+// coupons and tax, checked out into orders
+type Module struct {
+	module.DefaultModule
+	DB         *gorm.DB
+	Service    *CartService
+	Controller *CartController
+}
+
+// Init creates and initializes the cart module with all dependencies
+func Init(deps module.Dependencies) module.Module {
+	currency := deps.Config.PaymentsCurrency
+	service := NewCartService(
+		deps.DB,
+		deps.Emitter,
+		deps.Logger,
+		orders.NewOrderService(deps.DB, deps.Emitter, deps.Logger),
+		discounts.NewCouponService(deps.DB, deps.Emitter, deps.Logger, currency),
+		taxes.NewTaxService(deps.DB, deps.Emitter, deps.Logger),
+		shipping.NewShippingService(deps.DB, deps.Emitter, deps.Logger, nil, currency),
+		currency,
+	)
+
+	// Checkout creates the order payment when a payment provider is configured
+	if provider, err := payments.NewProvider(deps.Config); err == nil {
+		service.Payments = payments.NewPaymentService(deps.DB, deps.Emitter, deps.Logger, provider, currency)
+	}
+
+	return &Module{
+		DB:         deps.DB,
+		Service:    service,
+		Controller: NewCartController(service),
+	}
+}
+
+// Routes registers the cart endpoints; they are open to guests
+func (m *Module) Routes(router *router.RouterGroup) {
+	m.Controller.Routes(router)
+}
+
+func (m *Module) Migrate() error {
+	return m.DB.AutoMigrate(&Cart{}, &CartItem{})
+}
+
+func (m *Module) GetModels() []any {
+	return []any{
+		&Cart{},
+		&CartItem{},
+	}
+}
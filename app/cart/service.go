@@ -0,0 +1,765 @@
+package cart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"base/app/discounts"
+	"base/app/orders"
+	"base/app/payments"
+	"base/app/products"
+	"base/app/shipping"
+	"base/app/taxes"
+	"base/core/emitter"
+	"base/core/logger"
+	"base/core/security"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrCartEmpty is returned when checking out or applying a coupon to an empty cart
+	ErrCartEmpty = errors.New("cart is empty")
+
+	// ErrItemsUnavailable is returned when checking out a cart with items that are no
+	// longer sold
+	ErrItemsUnavailable = errors.New("cart has items that are no longer available")
+
+	// ErrShippingRequired is returned when checking out without a shipping method while
+	// there are methods that ship the cart
+	ErrShippingRequired = errors.New("a shipping method is required")
+)
+
+// CartService keeps the carts of users and guests, prices them from the catalog and turns
+// them into orders. Guest carts are found by their token and merged into the cart of the
+// user once the guest signs in.
+type CartService struct {
+	DB       *gorm.DB
+	Emitter  *emitter.Emitter
+	Logger   logger.Logger
+	Orders   *orders.OrderService
+	Coupons  *discounts.CouponService
+	Taxes    *taxes.TaxService
+	Shipping *shipping.ShippingService
+	Payments *payments.PaymentService // Nil without a payment provider
+	currency string
+}
+
+func NewCartService(db *gorm.DB, emitter *emitter.Emitter, logger logger.Logger, orderService *orders.OrderService, couponService *discounts.CouponService, taxService *taxes.TaxService, shippingService *shipping.ShippingService, currency string) *CartService {
+	return &CartService{
+		DB:       db,
+		Emitter:  emitter,
+		Logger:   logger,
+		Orders:   orderService,
+		Coupons:  couponService,
+		Taxes:    taxService,
+		Shipping: shippingService,
+		currency: currency,
+	}
+}
+
+// Get returns the priced cart of a user or guest; an empty one when there is none yet
+func (s *CartService) Get(ctx context.Context, userId uint, token string) (*CartResponse, error) {
+	cart, err := s.find(ctx, userId, token)
+	if err != nil {
+		return nil, err
+	}
+	if cart == nil {
+		return &CartResponse{Currency: s.currency, Items: []*ItemResponse{}}, nil
+	}
+	return s.Price(ctx, cart)
+}
+
+// AddItem adds a product to the cart, creating the cart on the first item
+func (s *CartService) AddItem(ctx context.Context, userId uint, token string, req *AddItemRequest) (*CartResponse, error) {
+	if err := ValidateAddItemRequest(req); err != nil {
+		return nil, err
+	}
+	if err := s.checkProduct(ctx, req.ProductId, req.VariantId); err != nil {
+		return nil, err
+	}
+
+	cart, err := s.findOrCreate(ctx, userId, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if item := cart.item(req.ProductId, req.VariantId); item != nil {
+		item.Quantity = min(item.Quantity+req.Quantity, 1000)
+		err = s.DB.WithContext(ctx).Model(item).Update("quantity", item.Quantity).Error
+	} else {
+		err = s.DB.WithContext(ctx).Create(&CartItem{CartId: cart.Id, ProductId: req.ProductId, VariantId: req.VariantId, Quantity: req.Quantity}).Error
+	}
+	if err != nil {
+		s.Logger.Error("failed to add cart item",
+			logger.String("error", err.Error()),
+			logger.Int("cart_id", int(cart.Id)))
+		return nil, err
+	}
+	return s.reload(ctx, cart)
+}
+
+// UpdateItem changes the quantity of a cart item; 0 removes it
+func (s *CartService) UpdateItem(ctx context.Context, userId uint, token string, itemId uint, req *UpdateItemRequest) (*CartResponse, error) {
+	if err := ValidateUpdateItemRequest(req); err != nil {
+		return nil, err
+	}
+
+	cart, item, err := s.findItem(ctx, userId, token, itemId)
+	if err != nil {
+		return nil, err
+	}
+	if req.Quantity == 0 {
+		err = s.DB.WithContext(ctx).Delete(item).Error
+	} else {
+		err = s.DB.WithContext(ctx).Model(item).Update("quantity", req.Quantity).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.reload(ctx, cart)
+}
+
+// RemoveItem removes an item from the cart
+func (s *CartService) RemoveItem(ctx context.Context, userId uint, token string, itemId uint) (*CartResponse, error) {
+	cart, item, err := s.findItem(ctx, userId, token, itemId)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.DB.WithContext(ctx).Delete(item).Error; err != nil {
+		return nil, err
+	}
+	return s.reload(ctx, cart)
+}
+
+// Clear removes all items and the coupon from the cart
+func (s *CartService) Clear(ctx context.Context, userId uint, token string) (*CartResponse, error) {
+	cart, err := s.find(ctx, userId, token)
+	if err != nil {
+		return nil, err
+	}
+	if cart == nil {
+		return s.Get(ctx, userId, token)
+	}
+
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("cart_id = ?", cart.Id).Delete(&CartItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&Cart{}).Where("id = ?", cart.Id).Update("coupon_code", "").Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.reload(ctx, cart)
+}
+
+// ApplyCoupon sets the coupon of the cart once it checks that the coupon applies to it
+func (s *CartService) ApplyCoupon(ctx context.Context, userId uint, token string, req *CouponRequest) (*CartResponse, error) {
+	if err := ValidateCouponRequest(req); err != nil {
+		return nil, err
+	}
+
+	cart, err := s.find(ctx, userId, token)
+	if err != nil {
+		return nil, err
+	}
+	if cart == nil || len(cart.Items) == 0 {
+		return nil, ErrCartEmpty
+	}
+
+	cart.CouponCode = discounts.NormalizeCode(req.Code)
+	response, err := s.Price(ctx, cart)
+	if err != nil {
+		return nil, err
+	}
+	if response.couponErr != nil {
+		return nil, response.couponErr
+	}
+
+	if err := s.DB.WithContext(ctx).Model(&Cart{}).Where("id = ?", cart.Id).Update("coupon_code", cart.CouponCode).Error; err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// RemoveCoupon removes the coupon from the cart
+func (s *CartService) RemoveCoupon(ctx context.Context, userId uint, token string) (*CartResponse, error) {
+	cart, err := s.find(ctx, userId, token)
+	if err != nil {
+		return nil, err
+	}
+	if cart == nil {
+		return s.Get(ctx, userId, token)
+	}
+	if err := s.DB.WithContext(ctx).Model(&Cart{}).Where("id = ?", cart.Id).Update("coupon_code", "").Error; err != nil {
+		return nil, err
+	}
+	return s.reload(ctx, cart)
+}
+
+// SetDestination sets where the cart ships to; its items are taxed at the rates of the
+// country and region from then on
+func (s *CartService) SetDestination(ctx context.Context, userId uint, token string, req *DestinationRequest) (*CartResponse, error) {
+	if err := ValidateDestinationRequest(req); err != nil {
+		return nil, err
+	}
+
+	cart, err := s.findOrCreate(ctx, userId, token)
+	if err != nil {
+		return nil, err
+	}
+	err = s.DB.WithContext(ctx).Model(&Cart{}).Where("id = ?", cart.Id).
+		Updates(map[string]any{"country": req.Country, "region": req.Region}).Error
+	if err != nil {
+		return nil, err
+	}
+	return s.reload(ctx, cart)
+}
+
+// ShippingMethods returns the shipping methods that ship the cart to its destination
+// with their prices
+func (s *CartService) ShippingMethods(ctx context.Context, userId uint, token string) ([]*shipping.Quote, error) {
+	cart, err := s.find(ctx, userId, token)
+	if err != nil {
+		return nil, err
+	}
+	if cart == nil {
+		cart = &Cart{}
+	}
+	priced, err := s.Price(ctx, cart)
+	if err != nil {
+		return nil, err
+	}
+	return s.Shipping.Quotes(ctx, shippingQuote(cart, priced))
+}
+
+// SetShipping sets the shipping method of the cart once it checks that the method ships it
+func (s *CartService) SetShipping(ctx context.Context, userId uint, token string, req *ShippingRequest) (*CartResponse, error) {
+	if err := ValidateShippingRequest(req); err != nil {
+		return nil, err
+	}
+
+	cart, err := s.find(ctx, userId, token)
+	if err != nil {
+		return nil, err
+	}
+	if cart == nil || len(cart.Items) == 0 {
+		return nil, ErrCartEmpty
+	}
+
+	cart.ShippingMethodId = &req.MethodId
+	response, err := s.Price(ctx, cart)
+	if err != nil {
+		return nil, err
+	}
+	if response.shippingErr != nil {
+		return nil, response.shippingErr
+	}
+
+	if err := s.DB.WithContext(ctx).Model(&Cart{}).Where("id = ?", cart.Id).Update("shipping_method_id", req.MethodId).Error; err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// RemoveShipping removes the shipping method from the cart
+func (s *CartService) RemoveShipping(ctx context.Context, userId uint, token string) (*CartResponse, error) {
+	cart, err := s.find(ctx, userId, token)
+	if err != nil {
+		return nil, err
+	}
+	if cart == nil {
+		return s.Get(ctx, userId, token)
+	}
+	if err := s.DB.WithContext(ctx).Model(&Cart{}).Where("id = ?", cart.Id).Update("shipping_method_id", nil).Error; err != nil {
+		return nil, err
+	}
+	return s.reload(ctx, cart)
+}
+
+// Checkout turns the cart into a pending order: the items are priced from the catalog,
+// their stock is taken and the coupon is redeemed, all or nothing. The cart is emptied
+// and, with a payment provider, a payment for the order total is created.
+func (s *CartService) Checkout(ctx context.Context, userId uint, token string, req *CheckoutRequest) (*CheckoutResponse, error) {
+	if err := ValidateCheckoutRequest(req, userId == 0); err != nil {
+		return nil, err
+	}
+
+	cart, err := s.find(ctx, userId, token)
+	if err != nil {
+		return nil, err
+	}
+	if cart == nil || len(cart.Items) == 0 {
+		return nil, ErrCartEmpty
+	}
+	// The order is taxed for where it ships to
+	cart.Country, cart.Region = strings.ToUpper(req.ShippingAddress.Country), req.ShippingAddress.Region
+	priced, err := s.Price(ctx, cart)
+	if err != nil {
+		return nil, err
+	}
+	if priced.couponErr != nil {
+		return nil, priced.couponErr
+	}
+	if priced.shippingErr != nil {
+		return nil, priced.shippingErr
+	}
+	if cart.ShippingMethodId == nil {
+		quotes, err := s.Shipping.Quotes(ctx, shippingQuote(cart, priced))
+		if err != nil {
+			return nil, err
+		}
+		if len(quotes) > 0 {
+			return nil, ErrShippingRequired
+		}
+	}
+	if slices.ContainsFunc(priced.Items, func(item *ItemResponse) bool { return !item.Available }) {
+		return nil, ErrItemsUnavailable
+	}
+	if slices.ContainsFunc(priced.Items, func(item *ItemResponse) bool { return !item.InStock }) {
+		return nil, products.ErrInsufficientStock
+	}
+
+	email := req.Email
+	if userId != 0 {
+		var account []string
+		if err := s.DB.WithContext(ctx).Table("users").Where("id = ?", userId).Limit(1).Pluck("email", &account).Error; err != nil {
+			return nil, err
+		}
+		if len(account) > 0 && account[0] != "" {
+			email = account[0]
+		}
+	}
+	billing := req.ShippingAddress
+	if req.BillingAddress != nil {
+		billing = *req.BillingAddress
+	}
+
+	order := &orders.Order{
+		UserId:           cart.UserId,
+		Email:            email,
+		Currency:         priced.Currency,
+		Subtotal:         priced.Subtotal,
+		Discount:         priced.Discount,
+		Tax:              priced.Tax,
+		Shipping:         priced.Shipping,
+		Total:            priced.Total,
+		ShippingMethodId: priced.ShippingMethodId,
+		ShippingMethod:   priced.ShippingMethod,
+		CouponCode:       priced.CouponCode,
+		ShippingAddress:  req.ShippingAddress,
+		BillingAddress:   billing,
+		Notes:            req.Notes,
+	}
+	var couponItems []discounts.CartItem
+	for _, item := range priced.Items {
+		order.Items = append(order.Items, &orders.OrderItem{
+			ProductId: item.ProductId,
+			VariantId: item.VariantId,
+			Sku:       item.Sku,
+			Name:      strings.TrimSpace(item.Name + " " + item.VariantName),
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			Subtotal:  item.Subtotal,
+			Discount:  item.Discount,
+			TaxRate:   item.TaxRate,
+			Tax:       item.Tax,
+			Total:     item.Total,
+		})
+		couponItems = append(couponItems, discounts.CartItem{ProductId: item.ProductId, VariantId: item.VariantId, Quantity: item.Quantity, UnitPrice: item.UnitPrice})
+	}
+
+	err = s.Orders.Place(ctx, order, func(tx *gorm.DB, order *orders.Order) error {
+		stock := products.NewProductService(tx, s.Emitter, nil, s.Logger)
+		for _, item := range order.Items {
+			if err := stock.AdjustStock(ctx, item.ProductId, item.VariantId, -item.Quantity); err != nil {
+				return err
+			}
+		}
+		if order.CouponCode != "" {
+			coupons := discounts.NewCouponService(tx, s.Emitter, s.Logger, s.currency)
+			_, err := coupons.Redeem(ctx, &discounts.RedeemRequest{
+				Code:    order.CouponCode,
+				OrderId: order.Id,
+				UserId:  order.UserId,
+				Cart:    discounts.Cart{Currency: order.Currency, Items: couponItems},
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("cart_id = ?", cart.Id).Delete(&CartItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(cart).Error
+	})
+	if err != nil {
+		if !errors.Is(err, products.ErrInsufficientStock) && !discounts.IsCouponError(err) {
+			s.Logger.Error("failed to check out cart",
+				logger.String("error", err.Error()),
+				logger.Int("cart_id", int(cart.Id)))
+		}
+		return nil, err
+	}
+
+	response := &CheckoutResponse{Order: order}
+	if s.Payments != nil && order.Total > 0 {
+		payment, err := s.Payments.CreatePayment(ctx, &payments.CreatePaymentRequest{
+			OrderId:     order.Id,
+			Amount:      order.Total,
+			Currency:    order.Currency,
+			Description: fmt.Sprintf("Order #%d", order.Id),
+		})
+		if err != nil {
+			// The order stays pending; an admin can create the payment later
+			s.Logger.Error("failed to create order payment",
+				logger.String("error", err.Error()),
+				logger.Int("order_id", int(order.Id)))
+		} else {
+			response.Payment = payment.ToResponse()
+		}
+	}
+	return response, nil
+}
+
+// Price prices a cart from the catalog: variant prices override product prices, the
+// coupon is applied to the available items and the tax rate of each item, for the
+// destination of the cart, to what is left. Shipping is added untaxed.
+func (s *CartService) Price(ctx context.Context, cart *Cart) (*CartResponse, error) {
+	response := &CartResponse{
+		Currency:   s.currency,
+		Items:      []*ItemResponse{},
+		CouponCode: cart.CouponCode,
+		Country:    cart.Country,
+		Region:     cart.Region,
+
+		ShippingMethodId: cart.ShippingMethodId,
+	}
+	if cart.UserId == nil {
+		response.Token = cart.Token
+	}
+
+	var productIds, variantIds []uint
+	for _, item := range cart.Items {
+		productIds = append(productIds, item.ProductId)
+		if item.VariantId != nil {
+			variantIds = append(variantIds, *item.VariantId)
+		}
+	}
+	var items []*products.Product
+	if len(productIds) > 0 {
+		if err := s.DB.WithContext(ctx).Where("id IN ?", productIds).Find(&items).Error; err != nil {
+			return nil, err
+		}
+	}
+	var variants []*products.ProductVariant
+	if len(variantIds) > 0 {
+		if err := s.DB.WithContext(ctx).Where("id IN ?", variantIds).Find(&variants).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	var priced []discounts.CartItem
+	for _, item := range cart.Items {
+		line := &ItemResponse{Id: item.Id, ProductId: item.ProductId, VariantId: item.VariantId, Quantity: item.Quantity}
+		response.Items = append(response.Items, line)
+
+		index := slices.IndexFunc(items, func(p *products.Product) bool { return p.Id == item.ProductId })
+		if index < 0 || !items[index].Active {
+			continue
+		}
+		product := items[index]
+		line.Sku, line.Name, line.UnitPrice = product.Sku, product.Name, product.Price
+		stock, weight := product.Stock, product.Weight
+		if item.VariantId != nil {
+			index = slices.IndexFunc(variants, func(v *products.ProductVariant) bool {
+				return v.Id == *item.VariantId && v.ProductId == product.Id
+			})
+			if index < 0 {
+				continue
+			}
+			variant := variants[index]
+			line.Sku, line.VariantName, stock = variant.Sku, variant.Name, variant.Stock
+			if variant.Price != nil {
+				line.UnitPrice = *variant.Price
+			}
+			if variant.Weight != nil {
+				weight = *variant.Weight
+			}
+		}
+
+		line.Available = true
+		line.InStock = !product.TrackStock || stock >= item.Quantity
+		line.Subtotal = line.UnitPrice.Times(line.Quantity)
+		response.ItemCount += line.Quantity
+		response.Weight += weight * line.Quantity
+		priced = append(priced, discounts.CartItem{ProductId: line.ProductId, VariantId: line.VariantId, Quantity: line.Quantity, UnitPrice: line.UnitPrice})
+	}
+
+	if cart.CouponCode != "" && len(priced) > 0 {
+		discount, err := s.Coupons.Apply(ctx, cart.CouponCode, &discounts.Cart{UserId: userIdOf(cart), Currency: s.currency, Items: priced})
+		switch {
+		case err == nil:
+			for _, share := range discount.Items {
+				for _, line := range response.Items {
+					if line.Available && line.ProductId == share.ProductId && sameVariant(line.VariantId, share.VariantId) {
+						line.Discount = share.Amount
+						break
+					}
+				}
+			}
+		case discounts.IsCouponError(err) || errors.Is(err, discounts.ErrCouponNotFound):
+			response.couponErr = err
+			response.CouponError = err.Error()
+		default:
+			return nil, err
+		}
+	}
+
+	rates, err := s.Taxes.Rates(ctx, taxes.Location{Country: cart.Country, Region: cart.Region}, productIds)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range response.Items {
+		if !line.Available {
+			continue
+		}
+		line.TaxRate = rates[line.ProductId]
+		line.Tax = (line.Subtotal - line.Discount).Percent(line.TaxRate)
+		line.Total = line.Subtotal - line.Discount + line.Tax
+		response.Subtotal += line.Subtotal
+		response.Discount += line.Discount
+		response.Tax += line.Tax
+		response.Total += line.Total
+	}
+
+	if cart.ShippingMethodId != nil && response.ItemCount > 0 {
+		quote, err := s.Shipping.Quote(ctx, *cart.ShippingMethodId, shippingQuote(cart, response))
+		switch {
+		case err == nil:
+			response.ShippingMethod, response.Shipping = quote.Name, quote.Price
+			response.Total += response.Shipping
+		case errors.Is(err, shipping.ErrMethodUnavailable):
+			response.shippingErr = err
+			response.ShippingError = err.Error()
+		default:
+			return nil, err
+		}
+	}
+	return response, nil
+}
+
+// find returns the cart of a user or guest, nil when there is none. A guest cart sent along
+// by a signed in user is merged into the user's cart.
+func (s *CartService) find(ctx context.Context, userId uint, token string) (*Cart, error) {
+	guest, err := s.byToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if userId == 0 {
+		return guest, nil
+	}
+
+	cart := &Cart{}
+	err = s.DB.WithContext(ctx).Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Where("user_id = ?", userId).First(cart).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		cart = nil
+	} else if err != nil {
+		return nil, err
+	}
+	if guest == nil {
+		return cart, nil
+	}
+	return s.merge(ctx, guest, cart, userId)
+}
+
+// findOrCreate returns the cart of a user or guest, creating it when there is none
+func (s *CartService) findOrCreate(ctx context.Context, userId uint, token string) (*Cart, error) {
+	cart, err := s.find(ctx, userId, token)
+	if err != nil || cart != nil {
+		return cart, err
+	}
+
+	cart = &Cart{Token: security.NewToken(24)}
+	if userId != 0 {
+		cart.UserId = &userId
+	}
+	if err := s.DB.WithContext(ctx).Create(cart).Error; err != nil {
+		s.Logger.Error("failed to create cart", logger.String("error", err.Error()))
+		return nil, err
+	}
+	return cart, nil
+}
+
+// findItem returns the cart of a user or guest with one of its items
+func (s *CartService) findItem(ctx context.Context, userId uint, token string, itemId uint) (*Cart, *CartItem, error) {
+	cart, err := s.find(ctx, userId, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cart == nil {
+		return nil, nil, gorm.ErrRecordNotFound
+	}
+	for _, item := range cart.Items {
+		if item.Id == itemId {
+			return cart, item, nil
+		}
+	}
+	return nil, nil, gorm.ErrRecordNotFound
+}
+
+// byToken returns the guest cart with the token, nil when there is none
+func (s *CartService) byToken(ctx context.Context, token string) (*Cart, error) {
+	if token == "" {
+		return nil, nil
+	}
+	cart := &Cart{}
+	err := s.DB.WithContext(ctx).Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Where("token = ? AND user_id IS NULL", token).First(cart).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+// merge moves a guest cart to a user. Without a user cart the guest cart becomes it;
+// otherwise its items are added to the user cart, which keeps its coupon if it has one.
+func (s *CartService) merge(ctx context.Context, guest, cart *Cart, userId uint) (*Cart, error) {
+	if cart == nil {
+		if err := s.DB.WithContext(ctx).Model(&Cart{}).Where("id = ?", guest.Id).Update("user_id", userId).Error; err != nil {
+			return nil, err
+		}
+		guest.UserId = &userId
+		return guest, nil
+	}
+
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, item := range guest.Items {
+			if existing := cart.item(item.ProductId, item.VariantId); existing != nil {
+				existing.Quantity = min(existing.Quantity+item.Quantity, 1000)
+				if err := tx.Model(existing).Update("quantity", existing.Quantity).Error; err != nil {
+					return err
+				}
+				if err := tx.Delete(item).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if err := tx.Model(item).Update("cart_id", cart.Id).Error; err != nil {
+				return err
+			}
+		}
+		if cart.CouponCode == "" && guest.CouponCode != "" {
+			if err := tx.Model(&Cart{}).Where("id = ?", cart.Id).Update("coupon_code", guest.CouponCode).Error; err != nil {
+				return err
+			}
+		}
+		if cart.ShippingMethodId == nil && guest.ShippingMethodId != nil {
+			if err := tx.Model(&Cart{}).Where("id = ?", cart.Id).Update("shipping_method_id", *guest.ShippingMethodId).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(guest).Error
+	})
+	if err != nil {
+		s.Logger.Error("failed to merge guest cart",
+			logger.String("error", err.Error()),
+			logger.Int("user_id", int(userId)))
+		return nil, err
+	}
+	return s.get(ctx, cart.Id)
+}
+
+// reload prices the cart with its current items
+func (s *CartService) reload(ctx context.Context, cart *Cart) (*CartResponse, error) {
+	fresh, err := s.get(ctx, cart.Id)
+	if err != nil {
+		return nil, err
+	}
+	return s.Price(ctx, fresh)
+}
+
+func (s *CartService) get(ctx context.Context, id uint) (*Cart, error) {
+	cart := &Cart{}
+	if err := s.DB.WithContext(ctx).Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).First(cart, id).Error; err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+// checkProduct checks that a product, and its variant, can be added to a cart. Products
+// with variants are sold through their variants.
+func (s *CartService) checkProduct(ctx context.Context, productId uint, variantId *uint) error {
+	product := &products.Product{}
+	if err := s.DB.WithContext(ctx).Preload("Variants").Where("active = ?", true).First(product, productId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return validator.ValidationErrors{{
+				Field:   "product_id",
+				Tag:     "exists",
+				Value:   fmt.Sprint(productId),
+				Message: fmt.Sprintf("product %d does not exist", productId),
+			}}
+		}
+		return err
+	}
+
+	if variantId == nil {
+		if len(product.Variants) > 0 {
+			return validator.ValidationErrors{{
+				Field:   "variant_id",
+				Tag:     "required",
+				Message: "variant_id is required",
+			}}
+		}
+		return nil
+	}
+	if !slices.ContainsFunc(product.Variants, func(v *products.ProductVariant) bool { return v.Id == *variantId }) {
+		return validator.ValidationErrors{{
+			Field:   "variant_id",
+			Tag:     "exists",
+			Value:   fmt.Sprint(*variantId),
+			Message: fmt.Sprintf("variant %d does not exist", *variantId),
+		}}
+	}
+	return nil
+}
+
+// item returns the item of the cart with the product and variant
+func (m *Cart) item(productId uint, variantId *uint) *CartItem {
+	for _, item := range m.Items {
+		if item.ProductId == productId && sameVariant(item.VariantId, variantId) {
+			return item
+		}
+	}
+	return nil
+}
+
+// shippingQuote asks for the shipping of the available items of a priced cart to its
+// destination
+func shippingQuote(cart *Cart, priced *CartResponse) shipping.QuoteRequest {
+	return shipping.QuoteRequest{
+		Country:  cart.Country,
+		Subtotal: priced.Subtotal - priced.Discount,
+		Weight:   priced.Weight,
+	}
+}
+
+func sameVariant(a, b *uint) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+func userIdOf(cart *Cart) uint {
+	if cart.UserId == nil {
+		return 0
+	}
+	return *cart.UserId
+}
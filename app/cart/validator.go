@@ -0,0 +1,120 @@
+package cart
+
+import (
+	"strings"
+
+	"base/app/orders"
+	"base/core/validator"
+)
+
+// Global validator instance using Base core validator wrapper
+var validate = validator.New()
+
+// ValidateAddItemRequest validates the add item request
+func ValidateAddItemRequest(req *AddItemRequest) error {
+	if req == nil {
+		return nilRequest()
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateUpdateItemRequest validates the update item request
+func ValidateUpdateItemRequest(req *UpdateItemRequest) error {
+	if req == nil {
+		return nilRequest()
+	}
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateCouponRequest validates the coupon request
+func ValidateCouponRequest(req *CouponRequest) error {
+	if req == nil {
+		return nilRequest()
+	}
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateDestinationRequest validates the destination request; a region goes with a
+// country
+func ValidateDestinationRequest(req *DestinationRequest) error {
+	if req == nil {
+		return nilRequest()
+	}
+
+	req.Country = strings.ToUpper(strings.TrimSpace(req.Country))
+	req.Region = strings.TrimSpace(req.Region)
+	if req.Country == "" {
+		req.Region = ""
+	}
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateShippingRequest validates the shipping request
+func ValidateShippingRequest(req *ShippingRequest) error {
+	if req == nil {
+		return nilRequest()
+	}
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateCheckoutRequest validates the checkout request; guests need an email
+func ValidateCheckoutRequest(req *CheckoutRequest, guest bool) error {
+	if req == nil {
+		return nilRequest()
+	}
+
+	req.Email = strings.TrimSpace(req.Email)
+	errs := validate.Validate(req)
+	if guest && req.Email == "" {
+		errs = append(errs, validator.ValidationError{
+			Field:   "email",
+			Tag:     "required",
+			Message: "email is required",
+		})
+	}
+	errs = append(errs, prefixed("shipping_address", orders.ValidateAddress(&req.ShippingAddress))...)
+	if req.BillingAddress != nil {
+		errs = append(errs, prefixed("billing_address", orders.ValidateAddress(req.BillingAddress))...)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// prefixed puts the field of nested validation errors under their parent field
+func prefixed(parent string, errs validator.ValidationErrors) validator.ValidationErrors {
+	for i := range errs {
+		errs[i].Field = parent + "." + errs[i].Field
+	}
+	return errs
+}
+
+func nilRequest() validator.ValidationErrors {
+	return validator.ValidationErrors{
+		{
+			Field:   "request",
+			Tag:     "required",
+			Value:   "nil",
+			Message: "request cannot be nil",
+		},
+	}
+}
@@ -0,0 +1,183 @@
+package currencies
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"base/core/router"
+	"base/core/translation"
+	"base/core/types"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+type ExchangeController struct {
+	Service *ExchangeService
+}
+
+func NewExchangeController(service *ExchangeService) *ExchangeController {
+	return &ExchangeController{
+		Service: service,
+	}
+}
+
+// Routes registers the exchange rate management endpoints; the group is restricted to
+// admins by the module
+func (c *ExchangeController) Routes(router *router.RouterGroup) {
+	router.GET("/exchange-rates", c.List)                    // Base currency and rates
+	router.POST("/exchange-rates/refresh", c.Refresh)        // Fetch from the provider - MUST be before /:currency
+	router.PUT("/exchange-rates/:currency", c.SetRate)       // Set a rate by hand
+	router.DELETE("/exchange-rates/:currency", c.DeleteRate) // Delete a rate
+}
+
+// CatalogRoutes registers the storefront endpoints; they are public like the catalog
+func (c *ExchangeController) CatalogRoutes(router *router.RouterGroup) {
+	router.GET("/catalog/currencies", c.List)
+	router.GET("/catalog/currencies/convert", c.Convert)
+}
+
+// ListExchangeRates godoc
+// @Summary List currencies
+// @Description Get the base currency, which prices and payments are in, and the rates of the currencies prices can be shown in (the currencies setting)
+// @Tags App/Currency
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} CurrenciesResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /catalog/currencies [get]
+// @Router /exchange-rates [get]
+func (c *ExchangeController) List(ctx *router.Context) error {
+	response, err := c.Service.List(ctx.Request.Context())
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch exchange rates: " + err.Error()})
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// ConvertAmount godoc
+// @Summary Convert an amount
+// @Description Convert an amount in minor units (cents) between currencies at the stored rates, e.g. to show prices in the currency of the visitor
+// @Tags App/Currency
+// @Security ApiKeyAuth
+// @Produce json
+// @Param amount query int true "Amount in minor units"
+// @Param from query string false "Currency of the amount, the base currency by default"
+// @Param to query string true "Currency to convert to"
+// @Success 200 {object} ConversionResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /catalog/currencies/convert [get]
+func (c *ExchangeController) Convert(ctx *router.Context) error {
+	amount, err := strconv.ParseInt(ctx.Query("amount"), 10, 64)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid amount"})
+	}
+	from := strings.ToUpper(ctx.Query("from"))
+	if from == "" {
+		from = c.Service.Base
+	}
+	to := strings.ToUpper(ctx.Query("to"))
+	if err := ValidateCurrency("from", from); err != nil {
+		return c.fail(ctx, err, "Invalid request")
+	}
+	if err := ValidateCurrency("to", to); err != nil {
+		return c.fail(ctx, err, "Invalid request")
+	}
+
+	response, err := c.Service.Convert(ctx.Request.Context(), types.Money(amount), from, to)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to convert amount")
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// RefreshExchangeRates godoc
+// @Summary Refresh exchange rates
+// @Description Fetch the rates of the currencies setting from the provider now; rates set by hand are kept (Admin only)
+// @Tags App/Currency
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} ExchangeRate
+// @Failure 502 {object} types.ErrorResponse
+// @Failure 503 {object} types.ErrorResponse
+// @Router /exchange-rates/refresh [post]
+func (c *ExchangeController) Refresh(ctx *router.Context) error {
+	rates, err := c.Service.Refresh(ctx.Request.Context())
+	if err != nil {
+		if errors.Is(err, ErrNoProvider) {
+			return ctx.JSON(http.StatusServiceUnavailable, types.ErrorResponse{Error: translation.Error(ctx, err)})
+		}
+		return ctx.JSON(http.StatusBadGateway, types.ErrorResponse{Error: "Failed to refresh exchange rates: " + err.Error()})
+	}
+	if rates == nil {
+		rates = []*ExchangeRate{}
+	}
+	return ctx.JSON(http.StatusOK, rates)
+}
+
+// SetExchangeRate godoc
+// @Summary Set an exchange rate
+// @Description Set the units of a currency one unit of the base currency buys. Rates set by hand aren't overwritten by the provider (Admin only)
+// @Tags App/Currency
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param currency path string true "Currency code"
+// @Param rate body SetRateRequest true "Rate"
+// @Success 200 {object} ExchangeRate
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /exchange-rates/{currency} [put]
+func (c *ExchangeController) SetRate(ctx *router.Context) error {
+	var req SetRateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	rate, err := c.Service.SetRate(ctx.Request.Context(), ctx.Param("currency"), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to set exchange rate")
+	}
+	return ctx.JSON(http.StatusOK, rate)
+}
+
+// DeleteExchangeRate godoc
+// @Summary Delete an exchange rate
+// @Description Delete the rate of a currency (Admin only)
+// @Tags App/Currency
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param currency path string true "Currency code"
+// @Success 204 "No Content"
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /exchange-rates/{currency} [delete]
+func (c *ExchangeController) DeleteRate(ctx *router.Context) error {
+	if err := c.Service.DeleteRate(ctx.Request.Context(), ctx.Param("currency")); err != nil {
+		return c.fail(ctx, err, "Failed to delete exchange rate")
+	}
+	ctx.Status(http.StatusNoContent)
+	return nil
+}
+
+// fail writes the error response of a service error
+func (c *ExchangeController) fail(ctx *router.Context, err error, message string) error {
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   translation.Error(ctx, err),
+			Details: translation.LocalizeValidation(ctx, validationErrors),
+		})
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Item not found"})
+	}
+	if errors.Is(err, ErrRateNotFound) {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: translation.Error(ctx, err)})
+	}
+	return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: message + ": " + err.Error()})
+}
@@ -0,0 +1,51 @@
+package currencies
+
+import (
+	"time"
+
+	"base/core/types"
+)
+
+// Sources of exchange rates
+const (
+	SourceManual = "manual"
+)
+
+// ExchangeRate is the number of units of Currency one unit of Base buys. Rates set by hand
+// (source manual) aren't overwritten by the provider.
+type ExchangeRate struct {
+	Id        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Base      string    `json:"base" gorm:"size:3;uniqueIndex:idx_exchange_rates_pair"`
+	Currency  string    `json:"currency" gorm:"size:3;uniqueIndex:idx_exchange_rates_pair"`
+	Rate      float64   `json:"rate"`
+	Source    string    `json:"source" gorm:"size:32"` // Provider name or manual
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// TableName returns the table name for the ExchangeRate model
+func (m *ExchangeRate) TableName() string {
+	return "exchange_rates"
+}
+
+// SetRateRequest sets the rate of a currency by hand
+type SetRateRequest struct {
+	Rate float64 `json:"rate" validate:"gt=0"`
+}
+
+// CurrenciesResponse lists the currencies prices can be shown in with their rates from
+// the base currency, which payments are made in
+type CurrenciesResponse struct {
+	Base       string          `json:"base"`
+	Currencies []*ExchangeRate `json:"currencies"`
+}
+
+// ConversionResponse is an amount converted between currencies; amounts are in minor units
+type ConversionResponse struct {
+	From   string      `json:"from"`
+	To     string      `json:"to"`
+	Amount types.Money `json:"amount"`
+	Result types.Money `json:"result"`
+	Rate   float64     `json:"rate"`
+}
@@ -0,0 +1,123 @@
+package currencies
+
+import (
+	"errors"
+	"time"
+
+	"base/core/app/authorization"
+	"base/core/logger"
+	"base/core/module"
+	"base/core/router"
+
+	"gorm.io/gorm"
+)
+
+// Module provides the exchange rates from the base currency (PAYMENTS_CURRENCY) to the
+// currencies prices can be shown in, refreshed from the provider (EXCHANGE_RATES_PROVIDER)
+type Module struct {
+	module.DefaultModule
+	DB         *gorm.DB
+	Service    *ExchangeService
+	Controller *ExchangeController
+	refresh    time.Duration
+}
+
+// Init creates and initializes the currencies module with all dependencies
+func Init(deps module.Dependencies) module.Module {
+	registerEvents()
+	provider, err := NewRateProvider(deps.Config)
+	if err != nil {
+		// Rates can still be set by hand
+		deps.Logger.Error("failed to create exchange rate provider", logger.String("error", err.Error()))
+	}
+	service := NewExchangeService(deps.DB, deps.Emitter, deps.Logger, provider, deps.Config.PaymentsCurrency)
+
+	return &Module{
+		DB:         deps.DB,
+		Service:    service,
+		Controller: NewExchangeController(service),
+		refresh:    deps.Config.ExchangeRatesRefresh,
+	}
+}
+
+// Routes registers the public currency endpoints and the admin routes
+func (m *Module) Routes(router *router.RouterGroup) {
+	m.Controller.CatalogRoutes(router)
+
+	group := router.Group("", authorization.RequireAdmin(authorization.NewAuthorizationService(m.DB)))
+	m.Controller.Routes(group)
+}
+
+func (m *Module) Init() error {
+	if err := m.SeedPermissions(); err != nil {
+		return err
+	}
+
+	// Refresh the rates in the background
+	m.Service.Start(m.refresh)
+	return nil
+}
+
+func (m *Module) SeedPermissions() error {
+	// Ensure permissions table exists before seeding
+	if err := m.DB.AutoMigrate(&authorization.Permission{}); err != nil {
+		return err
+	}
+
+	// Define permissions for exchange rate operations
+	permissions := []authorization.Permission{
+		{
+			Name:         "exchange rate list",
+			Description:  "View exchange rates",
+			ResourceType: "exchange_rate",
+			Action:       "list",
+		},
+		{
+			Name:         "exchange rate update",
+			Description:  "Set and refresh exchange rates",
+			ResourceType: "exchange_rate",
+			Action:       "update",
+		},
+		{
+			Name:         "exchange rate delete",
+			Description:  "Delete exchange rates",
+			ResourceType: "exchange_rate",
+			Action:       "delete",
+		},
+	}
+
+	// Upsert permissions - create or update if they exist
+	for _, permission := range permissions {
+		var existingPermission authorization.Permission
+		result := m.DB.Where("resource_type = ? AND action = ?", permission.ResourceType, permission.Action).First(&existingPermission)
+
+		if result.Error != nil && errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// Create new permission
+			if err := m.DB.Create(&permission).Error; err != nil {
+				return err
+			}
+		} else if result.Error == nil {
+			// Update existing permission
+			existingPermission.Name = permission.Name
+			existingPermission.Description = permission.Description
+			if err := m.DB.Save(&existingPermission).Error; err != nil {
+				return err
+			}
+		} else {
+			// Return any other error
+			return result.Error
+		}
+	}
+
+	return nil
+}
+
+func (m *Module) Migrate() error {
+	return m.DB.AutoMigrate(&ExchangeRate{})
+}
+
+func (m *Module) GetModels() []any {
+	return []any{
+		&ExchangeRate{},
+	}
+}
@@ -0,0 +1,105 @@
+package currencies
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"base/core/config"
+)
+
+// RateProvider fetches the latest exchange rates from a base currency
+type RateProvider interface {
+	Name() string
+	// Rates returns the units of each currency one unit of base buys; all the currencies
+	// the provider knows when currencies is empty
+	Rates(ctx context.Context, base string, currencies []string) (map[string]float64, error)
+}
+
+// NewRateProvider creates the rate provider selected by EXCHANGE_RATES_PROVIDER; nil when
+// none is selected and rates are set by hand
+func NewRateProvider(cfg *config.Config) (RateProvider, error) {
+	switch cfg.ExchangeRatesProvider {
+	case "":
+		return nil, nil
+	case "frankfurter":
+		return NewFrankfurterProvider(cfg.ExchangeRatesURL, cfg.ExchangeRatesAPIKey), nil
+	default:
+		return nil, fmt.Errorf("unsupported exchange rate provider: %s", cfg.ExchangeRatesProvider)
+	}
+}
+
+// FrankfurterProvider implements RateProvider with the Frankfurter API, which publishes
+// the reference rates of the European Central Bank. The API key, if any, is sent as a
+// Bearer token for self-hosted instances behind a gateway.
+type FrankfurterProvider struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+func NewFrankfurterProvider(baseURL, apiKey string) *FrankfurterProvider {
+	return &FrankfurterProvider{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (p *FrankfurterProvider) Name() string {
+	return "frankfurter"
+}
+
+// Rates fetches the latest rates from base
+func (p *FrankfurterProvider) Rates(ctx context.Context, base string, currencies []string) (map[string]float64, error) {
+	query := url.Values{}
+	query.Set("from", base)
+	if len(currencies) > 0 {
+		query.Set("to", strings.Join(currencies, ","))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/latest?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("exchange rate request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+			return nil, fmt.Errorf("frankfurter: %s", apiErr.Message)
+		}
+		return nil, fmt.Errorf("frankfurter: unexpected status %d", resp.StatusCode)
+	}
+
+	var latest struct {
+		Base  string             `json:"base"`
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := json.Unmarshal(body, &latest); err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(latest.Base, base) {
+		return nil, fmt.Errorf("frankfurter: rates are from %s, not %s", latest.Base, base)
+	}
+	return latest.Rates, nil
+}
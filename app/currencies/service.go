@@ -0,0 +1,281 @@
+package currencies
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"base/core/app/settings"
+	"base/core/emitter"
+	"base/core/logger"
+	"base/core/types"
+
+	"gorm.io/gorm"
+)
+
+const (
+	SetRateEvent      = "exchange_rates.set"
+	DeleteRateEvent   = "exchange_rates.delete"
+	RefreshRatesEvent = "exchange_rates.refresh"
+)
+
+// registerEvents adds the events of the module to the event catalog
+func registerEvents() {
+	emitter.RegisterEvents(
+		emitter.EventType{Name: SetRateEvent, Description: "An exchange rate was set", Payload: &ExchangeRate{}},
+		emitter.EventType{Name: DeleteRateEvent, Description: "An exchange rate was deleted", Payload: &ExchangeRate{}},
+		emitter.EventType{Name: RefreshRatesEvent, Description: "Exchange rates were refreshed from the provider", Payload: []*ExchangeRate{}},
+	)
+}
+
+// CurrenciesSetting is the setting with the currencies prices can be shown in, comma
+// separated; their rates are the ones refreshed from the provider
+const CurrenciesSetting = "currencies"
+
+var (
+	// ErrRateNotFound is returned when converting to or from a currency without a rate
+	ErrRateNotFound = errors.New("no exchange rate for the currency")
+
+	// ErrNoProvider is returned when refreshing rates without EXCHANGE_RATES_PROVIDER
+	ErrNoProvider = errors.New("no exchange rate provider is configured")
+)
+
+// ExchangeService keeps the exchange rates from the base currency (PAYMENTS_CURRENCY) and
+// converts amounts with them. Rates come from the provider, refreshed on an interval, or
+// are set by hand.
+type ExchangeService struct {
+	DB       *gorm.DB
+	Emitter  *emitter.Emitter
+	Logger   logger.Logger
+	Provider RateProvider // Nil when rates are set by hand
+	Base     string
+	once     sync.Once
+}
+
+func NewExchangeService(db *gorm.DB, emitter *emitter.Emitter, logger logger.Logger, provider RateProvider, base string) *ExchangeService {
+	return &ExchangeService{
+		DB:       db,
+		Emitter:  emitter,
+		Logger:   logger,
+		Provider: provider,
+		Base:     strings.ToUpper(base),
+	}
+}
+
+// Start refreshes the rates now and then on every interval, in the background. It does
+// nothing without a provider and can be called more than once.
+func (s *ExchangeService) Start(interval time.Duration) {
+	if s.Provider == nil || interval <= 0 {
+		return
+	}
+	s.once.Do(func() {
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+				if _, err := s.Refresh(ctx); err != nil {
+					s.Logger.Error("failed to refresh exchange rates", logger.String("error", err.Error()))
+				}
+				cancel()
+				<-ticker.C
+			}
+		}()
+	})
+}
+
+// Refresh fetches the rates of the currencies setting from the provider. Rates set by hand
+// are kept.
+func (s *ExchangeService) Refresh(ctx context.Context) ([]*ExchangeRate, error) {
+	if s.Provider == nil {
+		return nil, ErrNoProvider
+	}
+
+	currencies := s.currencies(ctx)
+	rates, err := s.Provider.Rates(ctx, s.Base, currencies)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var updated []*ExchangeRate
+	for currency, value := range rates {
+		currency = strings.ToUpper(currency)
+		if currency == s.Base || value <= 0 {
+			continue
+		}
+
+		rate, err := s.find(ctx, currency)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if rate == nil {
+			rate = &ExchangeRate{Base: s.Base, Currency: currency}
+		} else if rate.Source == SourceManual {
+			continue
+		}
+		rate.Rate = value
+		rate.Source = s.Provider.Name()
+		rate.FetchedAt = now
+		if err := s.DB.WithContext(ctx).Save(rate).Error; err != nil {
+			s.Logger.Error("failed to save exchange rate",
+				logger.String("error", err.Error()),
+				logger.String("currency", currency))
+			return nil, err
+		}
+		updated = append(updated, rate)
+	}
+
+	s.Logger.Info("exchange rates refreshed",
+		logger.String("base", s.Base),
+		logger.Int("count", len(updated)))
+
+	// Emit refresh event
+	s.Emitter.EmitContext(ctx, RefreshRatesEvent, updated)
+
+	return updated, nil
+}
+
+// List returns the base currency and the rates of the currencies setting; all the stored
+// rates when the setting is empty
+func (s *ExchangeService) List(ctx context.Context) (*CurrenciesResponse, error) {
+	query := s.DB.WithContext(ctx).Where("base = ?", s.Base)
+	if currencies := s.currencies(ctx); len(currencies) > 0 {
+		query = query.Where("currency IN ?", currencies)
+	}
+
+	var rates []*ExchangeRate
+	if err := query.Order("currency").Find(&rates).Error; err != nil {
+		s.Logger.Error("failed to get exchange rates", logger.String("error", err.Error()))
+		return nil, err
+	}
+	return &CurrenciesResponse{Base: s.Base, Currencies: rates}, nil
+}
+
+// SetRate sets the rate of a currency by hand; the provider doesn't overwrite it
+func (s *ExchangeService) SetRate(ctx context.Context, currency string, req *SetRateRequest) (*ExchangeRate, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if err := ValidateSetRateRequest(currency, s.Base, req); err != nil {
+		return nil, err
+	}
+
+	rate, err := s.find(ctx, currency)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if rate == nil {
+		rate = &ExchangeRate{Base: s.Base, Currency: currency}
+	}
+	rate.Rate = req.Rate
+	rate.Source = SourceManual
+	rate.FetchedAt = time.Now()
+	if err := s.DB.WithContext(ctx).Save(rate).Error; err != nil {
+		s.Logger.Error("failed to set exchange rate",
+			logger.String("error", err.Error()),
+			logger.String("currency", currency))
+		return nil, err
+	}
+
+	// Emit set event
+	s.Emitter.EmitContext(ctx, SetRateEvent, rate)
+
+	return rate, nil
+}
+
+// DeleteRate deletes the rate of a currency; the next refresh fetches it again when the
+// currency is in the currencies setting
+func (s *ExchangeService) DeleteRate(ctx context.Context, currency string) error {
+	rate, err := s.find(ctx, strings.ToUpper(currency))
+	if err != nil {
+		return err
+	}
+	if err := s.DB.WithContext(ctx).Delete(rate).Error; err != nil {
+		s.Logger.Error("failed to delete exchange rate",
+			logger.String("error", err.Error()),
+			logger.String("currency", rate.Currency))
+		return err
+	}
+
+	// Emit delete event
+	s.Emitter.EmitContext(ctx, DeleteRateEvent, rate)
+
+	return nil
+}
+
+// Rate returns the units of to one unit of from buys. Currencies other than the base are
+// converted through it.
+func (s *ExchangeService) Rate(ctx context.Context, from, to string) (float64, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return 1, nil
+	}
+
+	fromRate, err := s.baseRate(ctx, from)
+	if err != nil {
+		return 0, err
+	}
+	toRate, err := s.baseRate(ctx, to)
+	if err != nil {
+		return 0, err
+	}
+	return toRate / fromRate, nil
+}
+
+// Convert converts an amount in minor units between currencies, rounding to the nearest
+// minor unit of the target currency
+func (s *ExchangeService) Convert(ctx context.Context, amount types.Money, from, to string) (*ConversionResponse, error) {
+	rate, err := s.Rate(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	return &ConversionResponse{
+		From:   from,
+		To:     to,
+		Amount: amount,
+		Result: amount.Convert(from, to, rate),
+		Rate:   rate,
+	}, nil
+}
+
+// baseRate returns the rate from the base currency to a currency
+func (s *ExchangeService) baseRate(ctx context.Context, currency string) (float64, error) {
+	if currency == s.Base {
+		return 1, nil
+	}
+	rate, err := s.find(ctx, currency)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, ErrRateNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	return rate.Rate, nil
+}
+
+func (s *ExchangeService) find(ctx context.Context, currency string) (*ExchangeRate, error) {
+	rate := &ExchangeRate{}
+	if err := s.DB.WithContext(ctx).Where("base = ? AND currency = ?", s.Base, currency).First(rate).Error; err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+// currencies returns the currencies of the currencies setting other than the base
+func (s *ExchangeService) currencies(ctx context.Context) []string {
+	var setting settings.Settings
+	if err := s.DB.WithContext(ctx).Where("setting_key = ?", CurrenciesSetting).First(&setting).Error; err != nil {
+		return nil
+	}
+
+	var currencies []string
+	for _, currency := range strings.Split(setting.ValueString, ",") {
+		currency = strings.ToUpper(strings.TrimSpace(currency))
+		if currency != "" && currency != s.Base {
+			currencies = append(currencies, currency)
+		}
+	}
+	return currencies
+}
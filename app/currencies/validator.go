@@ -0,0 +1,55 @@
+package currencies
+
+import (
+	"base/core/validator"
+)
+
+// Global validator instance using Base core validator wrapper
+var validate = validator.New()
+
+// ValidateSetRateRequest validates the rate set by hand for a currency
+func ValidateSetRateRequest(currency, base string, req *SetRateRequest) error {
+	if req == nil {
+		return validator.ValidationErrors{
+			{
+				Field:   "request",
+				Tag:     "required",
+				Value:   "nil",
+				Message: "request cannot be nil",
+			},
+		}
+	}
+
+	errs := validate.Validate(req)
+	errs = append(errs, validateCurrency("currency", currency)...)
+	if currency == base {
+		errs = append(errs, validator.ValidationError{
+			Field:   "currency",
+			Tag:     "ne",
+			Value:   currency,
+			Param:   base,
+			Message: "currency must differ from the base currency",
+		})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateCurrency validates a currency code of a query parameter
+func ValidateCurrency(field, currency string) error {
+	if errs := validateCurrency(field, currency); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateCurrency(field, currency string) validator.ValidationErrors {
+	errs := validate.ValidateVar(currency, "required,iso4217")
+	for i := range errs {
+		errs[i].Field = field
+		errs[i].Message = field + " must be an ISO 4217 currency code"
+	}
+	return errs
+}
@@ -0,0 +1,327 @@
+package discounts
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"base/core/router"
+	"base/core/translation"
+	"base/core/types"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+type CouponController struct {
+	Service *CouponService
+}
+
+func NewCouponController(service *CouponService) *CouponController {
+	return &CouponController{
+		Service: service,
+	}
+}
+
+// Routes registers the coupon management endpoints; the group is restricted to admins by
+// the module
+func (c *CouponController) Routes(router *router.RouterGroup) {
+	router.GET("/coupons", c.List)                        // Paginated list
+	router.POST("/coupons", c.Create)                     // Create
+	router.POST("/coupons/redeem", c.Redeem)              // Redeem for an order - MUST be before /:id
+	router.POST("/coupons/release", c.Release)            // Release the coupons of an order
+	router.GET("/coupons/:id", c.Get)                     // Get by ID
+	router.PUT("/coupons/:id", c.Update)                  // Update
+	router.DELETE("/coupons/:id", c.Delete)               // Delete
+	router.GET("/coupons/:id/redemptions", c.Redemptions) // Redemptions of a coupon
+}
+
+// CartRoutes registers the endpoint carts check coupons with; it needs a signed in user
+func (c *CouponController) CartRoutes(router *router.RouterGroup) {
+	router.POST("/coupons/validate", c.Validate)
+}
+
+// CreateCoupon godoc
+// @Summary Create a coupon
+// @Description Create a discount code. Percentage coupons take value percent off, fixed coupons value cents; with product_ids or category_ids only those items are discounted. Limits of 0 are unlimited (Admin only)
+// @Tags App/Coupon
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param coupon body CreateCouponRequest true "Create coupon request"
+// @Success 201 {object} Coupon
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /coupons [post]
+func (c *CouponController) Create(ctx *router.Context) error {
+	var req CreateCouponRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	item, err := c.Service.Create(ctx.Request.Context(), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to create coupon")
+	}
+	return ctx.JSON(http.StatusCreated, item)
+}
+
+// GetCoupon godoc
+// @Summary Get a coupon
+// @Description Get a coupon by its id (Admin only)
+// @Tags App/Coupon
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Coupon id"
+// @Success 200 {object} Coupon
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /coupons/{id} [get]
+func (c *CouponController) Get(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	item, err := c.Service.GetById(ctx.Request.Context(), uint(id))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to get coupon")
+	}
+	return ctx.JSON(http.StatusOK, item)
+}
+
+// ListCoupons godoc
+// @Summary List coupons
+// @Description Get a page of coupons, newest first (Admin only)
+// @Tags App/Coupon
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of items per page"
+// @Param q query string false "Search the code and description"
+// @Param active query bool false "Only active or inactive coupons"
+// @Success 200 {object} types.PaginatedResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /coupons [get]
+func (c *CouponController) List(ctx *router.Context) error {
+	params, err := ctx.ListParams()
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	filter := CouponFilter{Query: ctx.Query("q")}
+	if activeStr := ctx.Query("active"); activeStr != "" {
+		active, err := strconv.ParseBool(activeStr)
+		if err != nil {
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid active value"})
+		}
+		filter.Active = &active
+	}
+
+	paginatedResponse, err := c.Service.GetAll(ctx.Request.Context(), params.Page, params.Limit, filter)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch coupons: " + err.Error()})
+	}
+	return ctx.JSON(http.StatusOK, paginatedResponse)
+}
+
+// UpdateCoupon godoc
+// @Summary Update a coupon
+// @Description Update a coupon; omitted fields are left unchanged (Admin only)
+// @Tags App/Coupon
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Coupon id"
+// @Param coupon body UpdateCouponRequest true "Update coupon request"
+// @Success 200 {object} Coupon
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /coupons/{id} [put]
+func (c *CouponController) Update(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	var req UpdateCouponRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	item, err := c.Service.Update(ctx.Request.Context(), uint(id), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to update coupon")
+	}
+	return ctx.JSON(http.StatusOK, item)
+}
+
+// DeleteCoupon godoc
+// @Summary Delete a coupon
+// @Description Delete a coupon; its redemptions are kept (Admin only)
+// @Tags App/Coupon
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Coupon id"
+// @Success 204 "Successfully deleted"
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /coupons/{id} [delete]
+func (c *CouponController) Delete(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	if err := c.Service.Delete(ctx.Request.Context(), uint(id)); err != nil {
+		return c.fail(ctx, err, "Failed to delete coupon")
+	}
+
+	ctx.Status(http.StatusNoContent)
+	return nil
+}
+
+// ListCouponRedemptions godoc
+// @Summary List the redemptions of a coupon
+// @Description Get the orders that redeemed a coupon with the discount they were given, newest first (Admin only)
+// @Tags App/Coupon
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Coupon id"
+// @Success 200 {array} Redemption
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /coupons/{id}/redemptions [get]
+func (c *CouponController) Redemptions(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	items, err := c.Service.GetRedemptions(ctx.Request.Context(), uint(id))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to get redemptions")
+	}
+	return ctx.JSON(http.StatusOK, items)
+}
+
+// ValidateCoupon godoc
+// @Summary Validate a coupon for a cart
+// @Description Check whether a coupon applies to a cart and get the discount. Items are priced from the catalog; the per-user limit is checked against the signed in user. Answers 409 with the reason when the coupon doesn't apply
+// @Tags App/Coupon
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param cart body ValidateRequest true "Coupon code and cart items"
+// @Success 200 {object} Discount
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 409 {object} types.ErrorResponse
+// @Router /coupons/validate [post]
+func (c *CouponController) Validate(ctx *router.Context) error {
+	var req ValidateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+	if err := ValidateCouponValidateRequest(&req); err != nil {
+		return c.fail(ctx, err, "Invalid request")
+	}
+
+	req.Cart.UserId = ctx.GetUint("user_id")
+	if err := c.Service.PriceCart(ctx.Request.Context(), &req.Cart); err != nil {
+		return c.fail(ctx, err, "Failed to price cart")
+	}
+	discount, err := c.Service.Apply(ctx.Request.Context(), req.Code, &req.Cart)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to validate coupon")
+	}
+	return ctx.JSON(http.StatusOK, discount)
+}
+
+// RedeemCoupon godoc
+// @Summary Redeem a coupon for an order
+// @Description Apply a coupon to the cart of an order and record its use; unit prices are taken from the request. Redeeming the same coupon for an order again returns the first redemption (Admin only)
+// @Tags App/Coupon
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param redemption body RedeemRequest true "Redeem request"
+// @Success 200 {object} Redemption
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 409 {object} types.ErrorResponse
+// @Router /coupons/redeem [post]
+func (c *CouponController) Redeem(ctx *router.Context) error {
+	var req RedeemRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	redemption, err := c.Service.Redeem(ctx.Request.Context(), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to redeem coupon")
+	}
+	return ctx.JSON(http.StatusOK, redemption)
+}
+
+// ReleaseCoupons godoc
+// @Summary Release the coupons of an order
+// @Description Delete the redemptions of an order, e.g. when it is canceled, so they count against the limits no more (Admin only)
+// @Tags App/Coupon
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param order body object{order_id=int} true "Order id"
+// @Success 200 {array} Redemption
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /coupons/release [post]
+func (c *CouponController) Release(ctx *router.Context) error {
+	var req struct {
+		OrderId uint `json:"order_id"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+	if req.OrderId == 0 {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid order_id"})
+	}
+
+	redemptions, err := c.Service.Release(ctx.Request.Context(), req.OrderId)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to release coupons")
+	}
+	return ctx.JSON(http.StatusOK, redemptions)
+}
+
+// fail writes the error response of a service error
+func (c *CouponController) fail(ctx *router.Context, err error, message string) error {
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   translation.Error(ctx, err),
+			Details: translation.LocalizeValidation(ctx, validationErrors),
+		})
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Item not found"})
+	}
+	if errors.Is(err, ErrCouponNotFound) {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: translation.Error(ctx, err)})
+	}
+	if IsCouponError(err) {
+		return ctx.JSON(http.StatusConflict, types.ErrorResponse{Error: translation.Error(ctx, err)})
+	}
+	return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: message + ": " + err.Error()})
+}
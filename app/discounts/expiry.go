@@ -0,0 +1,89 @@
+package discounts
+
+import (
+	"context"
+	"time"
+
+	"base/core/logger"
+)
+
+const (
+	ExpiringCouponEvent = "coupons.expiring" // An active coupon ends within ExpiryWarning
+	ExpireCouponEvent   = "coupons.expire"   // An active coupon ended and was deactivated
+)
+
+// ExpiryWarning is how long before an active coupon ends ExpiringCouponEvent is emitted
+var ExpiryWarning = 24 * time.Hour
+
+// expiryInterval is how often ended coupons are deactivated
+const expiryInterval = time.Minute
+
+// StartExpiring deactivates ended coupons now and then every minute, in the background. It
+// can be called more than once.
+func (s *CouponService) StartExpiring() {
+	s.expiring.Do(func() {
+		go func() {
+			ticker := time.NewTicker(expiryInterval)
+			defer ticker.Stop()
+			for {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+				if _, err := s.Expire(ctx, time.Now()); err != nil {
+					s.Logger.Error("failed to expire coupons", logger.String("error", err.Error()))
+				}
+				cancel()
+				<-ticker.C
+			}
+		}()
+	})
+}
+
+// Expire deactivates the active coupons whose EndsAt passed by now, and emits
+// ExpiringCouponEvent for those ending within ExpiryWarning. Ended coupons are already
+// refused by Apply; deactivating them shows it in the coupon list. It returns the coupons
+// it deactivated.
+func (s *CouponService) Expire(ctx context.Context, now time.Time) ([]*Coupon, error) {
+	var expiring []*Coupon
+	if err := s.DB.WithContext(ctx).
+		Where("active = ? AND ends_at > ? AND ends_at <= ? AND expiry_notified_at IS NULL", true, now, now.Add(ExpiryWarning)).
+		Order("ends_at").Find(&expiring).Error; err != nil {
+		return nil, err
+	}
+	for _, item := range expiring {
+		// Other instances warn about the same coupons; only one emits the event
+		update := s.DB.WithContext(ctx).Model(&Coupon{}).
+			Where("id = ? AND expiry_notified_at IS NULL", item.Id).
+			Update("expiry_notified_at", now)
+		if update.Error != nil {
+			return nil, update.Error
+		}
+		if update.RowsAffected > 0 {
+			item.ExpiryNotifiedAt = &now
+			s.Emitter.EmitContext(ctx, ExpiringCouponEvent, item)
+		}
+	}
+
+	var ended []*Coupon
+	if err := s.DB.WithContext(ctx).
+		Where("active = ? AND ends_at <= ?", true, now).
+		Order("ends_at").Find(&ended).Error; err != nil {
+		return nil, err
+	}
+	result := []*Coupon{}
+	for _, item := range ended {
+		update := s.DB.WithContext(ctx).Model(&Coupon{}).
+			Where("id = ? AND active = ?", item.Id, true).
+			Update("active", false)
+		if update.Error != nil {
+			return result, update.Error
+		}
+		if update.RowsAffected == 0 {
+			continue
+		}
+		item.Active = false
+		result = append(result, item)
+
+		s.Emitter.EmitContext(ctx, UpdateCouponEvent, item)
+		s.Emitter.EmitContext(ctx, ExpireCouponEvent, item)
+	}
+	return result, nil
+}
@@ -0,0 +1,171 @@
+package discounts
+
+import (
+	"time"
+
+	"base/core/types"
+
+	"gorm.io/gorm"
+)
+
+// Coupon types
+const (
+	TypePercentage = "percentage" // Value is a percent of the eligible subtotal
+	TypeFixed      = "fixed"      // Value is an amount in cents of Currency
+)
+
+// Coupon is a discount code. Coupons with products or categories only discount the cart
+// items of those products or categories; limits of 0 mean unlimited.
+type Coupon struct {
+	Id           uint           `json:"id" gorm:"primarykey"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	Code         string         `json:"code" gorm:"size:64;uniqueIndex"` // Uppercase
+	Description  string         `json:"description" gorm:"type:text"`
+	Type         string         `json:"type" gorm:"size:16"`
+	Value        int64          `json:"value"`
+	Currency     string         `json:"currency" gorm:"size:3"` // Currency of fixed coupons
+	MinSubtotal  types.Money    `json:"min_subtotal"`           // Minimum cart subtotal in cents
+	MaxDiscount  types.Money    `json:"max_discount"`           // Cap of percentage discounts in cents
+	StartsAt     *time.Time     `json:"starts_at"`              // Valid from, always when nil
+	EndsAt       *time.Time     `json:"ends_at"`                // Valid until, always when nil
+	UsageLimit   int            `json:"usage_limit"`            // Redemptions in total
+	PerUserLimit int            `json:"per_user_limit"`         // Redemptions per user
+	UsedCount    int            `json:"used_count"`             // Redemptions so far
+	ProductIds   []uint         `json:"product_ids" gorm:"type:text;serializer:json"`
+	CategoryIds  []uint         `json:"category_ids" gorm:"type:text;serializer:json"`
+	Active       bool           `json:"active" gorm:"index"` // Turned off when EndsAt passes, see Expire
+
+	// When ExpiringCouponEvent was emitted for EndsAt, see ExpiryWarning
+	ExpiryNotifiedAt *time.Time `json:"-"`
+}
+
+// TableName returns the table name for the Coupon model
+func (m *Coupon) TableName() string {
+	return "coupons"
+}
+
+// Scoped reports whether the coupon only applies to some products or categories
+func (m *Coupon) Scoped() bool {
+	return len(m.ProductIds) > 0 || len(m.CategoryIds) > 0
+}
+
+// Redemption records the use of a coupon by an order; an order redeems a coupon once
+type Redemption struct {
+	Id        uint        `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time   `json:"created_at"`
+	CouponId  uint        `json:"coupon_id" gorm:"uniqueIndex:idx_coupon_redemptions_coupon_order"`
+	OrderId   uint        `json:"order_id" gorm:"uniqueIndex:idx_coupon_redemptions_coupon_order;index"`
+	UserId    *uint       `json:"user_id" gorm:"index"`
+	Code      string      `json:"code" gorm:"size:64"`
+	Amount    types.Money `json:"amount"` // Discount given in cents
+	Currency  string      `json:"currency" gorm:"size:3"`
+}
+
+// TableName returns the table name for the Redemption model
+func (m *Redemption) TableName() string {
+	return "coupon_redemptions"
+}
+
+// CreateCouponRequest represents the request payload for creating a coupon
+type CreateCouponRequest struct {
+	Code         string      `json:"code" validate:"required,max=64"`
+	Description  string      `json:"description"`
+	Type         string      `json:"type" validate:"required,oneof=percentage fixed"`
+	Value        int64       `json:"value" validate:"gt=0"`
+	Currency     string      `json:"currency" validate:"omitempty,iso4217"` // Defaults to PAYMENTS_CURRENCY for fixed coupons
+	MinSubtotal  types.Money `json:"min_subtotal" validate:"gte=0"`
+	MaxDiscount  types.Money `json:"max_discount" validate:"gte=0"`
+	StartsAt     *time.Time  `json:"starts_at,omitempty"`
+	EndsAt       *time.Time  `json:"ends_at,omitempty"`
+	UsageLimit   int         `json:"usage_limit" validate:"gte=0"`
+	PerUserLimit int         `json:"per_user_limit" validate:"gte=0"`
+	ProductIds   []uint      `json:"product_ids,omitempty"`
+	CategoryIds  []uint      `json:"category_ids,omitempty"`
+	Active       bool        `json:"active"`
+}
+
+// UpdateCouponRequest represents the request payload for updating a coupon. Omitted fields
+// are left unchanged; product_ids and category_ids replace the scope.
+type UpdateCouponRequest struct {
+	Code         *string      `json:"code,omitempty" validate:"omitempty,min=1,max=64"`
+	Description  *string      `json:"description,omitempty"`
+	Type         *string      `json:"type,omitempty" validate:"omitempty,oneof=percentage fixed"`
+	Value        *int64       `json:"value,omitempty" validate:"omitempty,gt=0"`
+	Currency     *string      `json:"currency,omitempty" validate:"omitempty,iso4217"`
+	MinSubtotal  *types.Money `json:"min_subtotal,omitempty" validate:"omitempty,gte=0"`
+	MaxDiscount  *types.Money `json:"max_discount,omitempty" validate:"omitempty,gte=0"`
+	StartsAt     *time.Time   `json:"starts_at,omitempty"`
+	EndsAt       *time.Time   `json:"ends_at,omitempty"`
+	UsageLimit   *int         `json:"usage_limit,omitempty" validate:"omitempty,gte=0"`
+	PerUserLimit *int         `json:"per_user_limit,omitempty" validate:"omitempty,gte=0"`
+	ProductIds   *[]uint      `json:"product_ids,omitempty"`
+	CategoryIds  *[]uint      `json:"category_ids,omitempty"`
+	Active       *bool        `json:"active,omitempty"`
+}
+
+// CartItem is a line of the cart a coupon is applied to; UnitPrice is in cents
+type CartItem struct {
+	ProductId uint        `json:"product_id" validate:"required"`
+	VariantId *uint       `json:"variant_id,omitempty"`
+	Quantity  int         `json:"quantity" validate:"gte=1"`
+	UnitPrice types.Money `json:"unit_price" validate:"gte=0"`
+}
+
+// Cart is what a coupon is applied to. The validation endpoint prices the items from the
+// catalog; carts applying coupons themselves pass their own prices.
+type Cart struct {
+	UserId   uint       `json:"-"`
+	Currency string     `json:"currency" validate:"omitempty,iso4217"` // Defaults to PAYMENTS_CURRENCY
+	Items    []CartItem `json:"items" validate:"required,min=1,dive"`
+}
+
+// Subtotal is the total of the cart items before discounts
+func (c *Cart) Subtotal() types.Money {
+	var subtotal types.Money
+	for _, item := range c.Items {
+		subtotal += item.UnitPrice.Times(item.Quantity)
+	}
+	return subtotal
+}
+
+// ValidateRequest asks whether a coupon applies to a cart and what it takes off
+type ValidateRequest struct {
+	Code string `json:"code" validate:"required"`
+	Cart
+}
+
+// RedeemRequest records the use of a coupon by an order
+type RedeemRequest struct {
+	Code    string `json:"code" validate:"required"`
+	OrderId uint   `json:"order_id" validate:"required"`
+	UserId  *uint  `json:"user_id,omitempty"`
+	Cart
+}
+
+// ItemDiscount is the part of a discount that falls on a cart item
+type ItemDiscount struct {
+	ProductId uint        `json:"product_id"`
+	VariantId *uint       `json:"variant_id,omitempty"`
+	Amount    types.Money `json:"amount"`
+}
+
+// Discount is what a coupon takes off a cart; amounts are in cents
+type Discount struct {
+	CouponId         uint           `json:"coupon_id"`
+	Code             string         `json:"code"`
+	Type             string         `json:"type"`
+	Currency         string         `json:"currency"`
+	Subtotal         types.Money    `json:"subtotal"`          // Cart subtotal
+	EligibleSubtotal types.Money    `json:"eligible_subtotal"` // Subtotal of the items the coupon applies to
+	Amount           types.Money    `json:"amount"`
+	Total            types.Money    `json:"total"` // Subtotal less the discount
+	Items            []ItemDiscount `json:"items"`
+}
+
+// CouponFilter narrows coupon lists
+type CouponFilter struct {
+	Query  string // Matches the code or description
+	Active *bool
+}
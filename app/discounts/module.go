@@ -0,0 +1,144 @@
+package discounts
+
+import (
+	"errors"
+
+	"base/core/app/authorization"
+	"base/core/app/reports"
+	"base/core/module"
+	"base/core/router"
+
+	"gorm.io/gorm"
+)
+
+// Module provides discount codes: coupon management, validation against carts and
+// redemption tracking by orders
+type Module struct {
+	module.DefaultModule
+	DB         *gorm.DB
+	Service    *CouponService
+	Controller *CouponController
+}
+
+// Init creates and initializes the discounts module with all dependencies
+func Init(deps module.Dependencies) module.Module {
+	registerEvents()
+	service := NewCouponService(deps.DB, deps.Emitter, deps.Logger, deps.Config.PaymentsCurrency)
+
+	reports.RegisterEntity(reports.Entity{
+		Name:       "coupons",
+		Columns:    []string{"id", "code", "type", "value", "currency", "min_subtotal", "usage_limit", "used_count", "starts_at", "ends_at", "active", "created_at"},
+		SoftDelete: true,
+	})
+	reports.RegisterEntity(reports.Entity{
+		Name:    "coupon_redemptions",
+		Columns: []string{"id", "coupon_id", "order_id", "user_id", "code", "amount", "currency", "created_at"},
+	})
+
+	return &Module{
+		DB:         deps.DB,
+		Service:    service,
+		Controller: NewCouponController(service),
+	}
+}
+
+// Routes registers the cart validation endpoint and the admin routes
+func (m *Module) Routes(router *router.RouterGroup) {
+	m.Controller.CartRoutes(router)
+
+	group := router.Group("", authorization.RequireAdmin(authorization.NewAuthorizationService(m.DB)))
+	m.Controller.Routes(group)
+}
+
+func (m *Module) Init() error {
+	if err := m.SeedPermissions(); err != nil {
+		return err
+	}
+
+	// Deactivate ended coupons in the background
+	m.Service.StartExpiring()
+	return nil
+}
+
+func (m *Module) SeedPermissions() error {
+	// Ensure permissions table exists before seeding
+	if err := m.DB.AutoMigrate(&authorization.Permission{}); err != nil {
+		return err
+	}
+
+	// Define permissions for coupon operations
+	permissions := []authorization.Permission{
+		{
+			Name:         "coupon list",
+			Description:  "View coupon list",
+			ResourceType: "coupon",
+			Action:       "list",
+		},
+		{
+			Name:         "coupon read",
+			Description:  "View coupons and their redemptions",
+			ResourceType: "coupon",
+			Action:       "read",
+		},
+		{
+			Name:         "coupon create",
+			Description:  "Create coupons",
+			ResourceType: "coupon",
+			Action:       "create",
+		},
+		{
+			Name:         "coupon update",
+			Description:  "Update coupons",
+			ResourceType: "coupon",
+			Action:       "update",
+		},
+		{
+			Name:         "coupon delete",
+			Description:  "Delete coupons",
+			ResourceType: "coupon",
+			Action:       "delete",
+		},
+		{
+			Name:         "coupon redeem",
+			Description:  "Redeem and release coupons for orders",
+			ResourceType: "coupon",
+			Action:       "redeem",
+		},
+	}
+
+	// Upsert permissions - create or update if they exist
+	for _, permission := range permissions {
+		var existingPermission authorization.Permission
+		result := m.DB.Where("resource_type = ? AND action = ?", permission.ResourceType, permission.Action).First(&existingPermission)
+
+		if result.Error != nil && errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// Create new permission
+			if err := m.DB.Create(&permission).Error; err != nil {
+				return err
+			}
+		} else if result.Error == nil {
+			// Update existing permission
+			existingPermission.Name = permission.Name
+			existingPermission.Description = permission.Description
+			if err := m.DB.Save(&existingPermission).Error; err != nil {
+				return err
+			}
+		} else {
+			// Return any other error
+			return result.Error
+		}
+	}
+
+	return nil
+}
+
+func (m *Module) Migrate() error {
+	return m.DB.AutoMigrate(&Coupon{}, &Redemption{})
+}
+
+func (m *Module) GetModels() []any {
+	return []any{
+		&Coupon{},
+		&Redemption{},
+	}
+}
@@ -0,0 +1,658 @@
+package discounts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"base/app/products"
+	"base/core/emitter"
+	"base/core/logger"
+	"base/core/types"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+const (
+	CreateCouponEvent  = "coupons.create"
+	UpdateCouponEvent  = "coupons.update"
+	DeleteCouponEvent  = "coupons.delete"
+	RedeemCouponEvent  = "coupons.redeem"
+	ReleaseCouponEvent = "coupons.release"
+)
+
+// registerEvents adds the events of the module to the event catalog
+func registerEvents() {
+	emitter.RegisterEvents(
+		emitter.EventType{Name: CreateCouponEvent, Description: "A coupon was created", Payload: &Coupon{}},
+		emitter.EventType{Name: UpdateCouponEvent, Description: "A coupon was updated", Payload: &Coupon{}},
+		emitter.EventType{Name: DeleteCouponEvent, Description: "A coupon was deleted", Payload: &Coupon{}},
+		emitter.EventType{Name: RedeemCouponEvent, Description: "A coupon was redeemed by an order", Payload: &Redemption{}},
+		emitter.EventType{Name: ReleaseCouponEvent, Description: "The redemption of a coupon was released, e.g. by a canceled order", Payload: &Redemption{}},
+		emitter.EventType{Name: ExpiringCouponEvent, Description: "An active coupon ends soon", Payload: &Coupon{}},
+		emitter.EventType{Name: ExpireCouponEvent, Description: "An active coupon ended and was deactivated", Payload: &Coupon{}},
+	)
+}
+
+// Reasons a coupon doesn't apply to a cart
+var (
+	ErrCouponNotFound      = errors.New("coupon not found")
+	ErrCouponInactive      = errors.New("coupon is not active")
+	ErrCouponNotStarted    = errors.New("coupon is not valid yet")
+	ErrCouponExpired       = errors.New("coupon has expired")
+	ErrCouponUsedUp        = errors.New("coupon has reached its usage limit")
+	ErrCouponUserLimit     = errors.New("coupon has already been used")
+	ErrCouponMinSubtotal   = errors.New("cart subtotal is below the coupon minimum")
+	ErrCouponNotApplicable = errors.New("coupon doesn't apply to the cart items")
+	ErrCouponCurrency      = errors.New("coupon is for another currency")
+)
+
+// couponErrors are the errors of coupons that can't be applied
+var couponErrors = []error{
+	ErrCouponInactive, ErrCouponNotStarted, ErrCouponExpired, ErrCouponUsedUp, ErrCouponUserLimit,
+	ErrCouponMinSubtotal, ErrCouponNotApplicable, ErrCouponCurrency,
+}
+
+// IsCouponError reports whether err is a reason the coupon doesn't apply to the cart
+func IsCouponError(err error) bool {
+	return slices.ContainsFunc(couponErrors, func(target error) bool { return errors.Is(err, target) })
+}
+
+// CouponService manages coupons, applies them to carts and tracks their redemptions by
+// orders. Orders redeem the coupon when they are placed and release it when canceled.
+type CouponService struct {
+	DB       *gorm.DB
+	Emitter  *emitter.Emitter
+	Logger   logger.Logger
+	currency string
+	expiring sync.Once // Starts the expiry job once, see StartExpiring
+}
+
+func NewCouponService(db *gorm.DB, emitter *emitter.Emitter, logger logger.Logger, currency string) *CouponService {
+	return &CouponService{
+		DB:       db,
+		Emitter:  emitter,
+		Logger:   logger,
+		currency: currency,
+	}
+}
+
+// Create creates a coupon
+func (s *CouponService) Create(ctx context.Context, req *CreateCouponRequest) (*Coupon, error) {
+	if err := ValidateCouponCreateRequest(req); err != nil {
+		return nil, err
+	}
+
+	item := &Coupon{
+		Code:         req.Code,
+		Description:  req.Description,
+		Type:         req.Type,
+		Value:        req.Value,
+		Currency:     req.Currency,
+		MinSubtotal:  req.MinSubtotal,
+		MaxDiscount:  req.MaxDiscount,
+		StartsAt:     req.StartsAt,
+		EndsAt:       req.EndsAt,
+		UsageLimit:   req.UsageLimit,
+		PerUserLimit: req.PerUserLimit,
+		ProductIds:   req.ProductIds,
+		CategoryIds:  req.CategoryIds,
+		Active:       req.Active,
+	}
+	if item.Type == TypeFixed && item.Currency == "" {
+		item.Currency = s.currency
+	}
+	if err := s.check(ctx, item); err != nil {
+		return nil, err
+	}
+
+	if err := s.DB.WithContext(ctx).Create(item).Error; err != nil {
+		s.Logger.Error("failed to create coupon", logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	// Emit create event
+	s.Emitter.EmitContext(ctx, CreateCouponEvent, item)
+
+	return item, nil
+}
+
+// Update updates a coupon; redemptions keep the discount they were given
+func (s *CouponService) Update(ctx context.Context, id uint, req *UpdateCouponRequest) (*Coupon, error) {
+	if err := ValidateCouponUpdateRequest(req); err != nil {
+		return nil, err
+	}
+
+	item, err := s.GetById(ctx, id)
+	if err != nil {
+		s.Logger.Error("failed to find coupon for update",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return nil, err
+	}
+
+	if req.Code != nil {
+		item.Code = *req.Code
+	}
+	if req.Description != nil {
+		item.Description = *req.Description
+	}
+	if req.Type != nil {
+		item.Type = *req.Type
+	}
+	if req.Value != nil {
+		item.Value = *req.Value
+	}
+	if req.Currency != nil {
+		item.Currency = *req.Currency
+	}
+	if req.MinSubtotal != nil {
+		item.MinSubtotal = *req.MinSubtotal
+	}
+	if req.MaxDiscount != nil {
+		item.MaxDiscount = *req.MaxDiscount
+	}
+	if req.StartsAt != nil {
+		item.StartsAt = req.StartsAt
+	}
+	if req.EndsAt != nil {
+		item.EndsAt = req.EndsAt
+		item.ExpiryNotifiedAt = nil
+	}
+	if req.UsageLimit != nil {
+		item.UsageLimit = *req.UsageLimit
+	}
+	if req.PerUserLimit != nil {
+		item.PerUserLimit = *req.PerUserLimit
+	}
+	if req.ProductIds != nil {
+		item.ProductIds = *req.ProductIds
+	}
+	if req.CategoryIds != nil {
+		item.CategoryIds = *req.CategoryIds
+	}
+	if req.Active != nil {
+		item.Active = *req.Active
+	}
+	if item.Type == TypeFixed && item.Currency == "" {
+		item.Currency = s.currency
+	}
+	if err := s.check(ctx, item); err != nil {
+		return nil, err
+	}
+
+	// used_count is left out, redemptions may have changed it in the meantime
+	if err := s.DB.WithContext(ctx).Omit("used_count").Save(item).Error; err != nil {
+		s.Logger.Error("failed to update coupon",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return nil, err
+	}
+
+	// Emit update event
+	s.Emitter.EmitContext(ctx, UpdateCouponEvent, item)
+
+	return item, nil
+}
+
+// Delete deletes a coupon; its redemptions are kept
+func (s *CouponService) Delete(ctx context.Context, id uint) error {
+	item, err := s.GetById(ctx, id)
+	if err != nil {
+		s.Logger.Error("failed to find coupon for deletion",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return err
+	}
+
+	if err := s.DB.WithContext(ctx).Delete(item).Error; err != nil {
+		s.Logger.Error("failed to delete coupon",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return err
+	}
+
+	// Emit delete event
+	s.Emitter.EmitContext(ctx, DeleteCouponEvent, item)
+
+	return nil
+}
+
+// GetById returns a coupon
+func (s *CouponService) GetById(ctx context.Context, id uint) (*Coupon, error) {
+	item := &Coupon{}
+	if err := s.DB.WithContext(ctx).First(item, id).Error; err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// GetAll returns a page of coupons, newest first
+func (s *CouponService) GetAll(ctx context.Context, page *int, limit *int, filter CouponFilter) (*types.PaginatedResponse, error) {
+	var items []*Coupon
+	var total int64
+
+	query := s.DB.WithContext(ctx).Model(&Coupon{})
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		like := "%" + q + "%"
+		query = query.Where("code LIKE ? OR description LIKE ?", strings.ToUpper(like), like)
+	}
+	if filter.Active != nil {
+		query = query.Where("active = ?", *filter.Active)
+	}
+
+	// Set default values if nil
+	defaultPage := 1
+	defaultLimit := 10
+	if page == nil {
+		page = &defaultPage
+	}
+	if limit == nil {
+		limit = &defaultLimit
+	}
+
+	// Get total count
+	if err := query.Count(&total).Error; err != nil {
+		s.Logger.Error("failed to count coupons",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	offset := (*page - 1) * *limit
+	if err := query.Order("id DESC").Offset(offset).Limit(*limit).Find(&items).Error; err != nil {
+		s.Logger.Error("failed to get coupons",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	// Calculate total pages
+	totalPages := int(math.Ceil(float64(total) / float64(*limit)))
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	return &types.PaginatedResponse{
+		Data: items,
+		Pagination: types.Pagination{
+			Total:      int(total),
+			Page:       *page,
+			PageSize:   *limit,
+			TotalPages: totalPages,
+		},
+	}, nil
+}
+
+// GetRedemptions returns the redemptions of a coupon, newest first
+func (s *CouponService) GetRedemptions(ctx context.Context, id uint) ([]*Redemption, error) {
+	if _, err := s.GetById(ctx, id); err != nil {
+		return nil, err
+	}
+
+	var items []*Redemption
+	if err := s.DB.WithContext(ctx).Where("coupon_id = ?", id).Order("id DESC").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// PriceCart sets the unit prices of the cart items from the catalog: the variant price
+// when it has one, else the product price. Only active products can be priced.
+func (s *CouponService) PriceCart(ctx context.Context, cart *Cart) error {
+	if err := ValidateCart(cart); err != nil {
+		return err
+	}
+
+	var productIds, variantIds []uint
+	for _, item := range cart.Items {
+		productIds = append(productIds, item.ProductId)
+		if item.VariantId != nil {
+			variantIds = append(variantIds, *item.VariantId)
+		}
+	}
+	var items []*products.Product
+	if err := s.DB.WithContext(ctx).Where("id IN ? AND active = ?", productIds, true).Find(&items).Error; err != nil {
+		return err
+	}
+	var variants []*products.ProductVariant
+	if len(variantIds) > 0 {
+		if err := s.DB.WithContext(ctx).Where("id IN ?", variantIds).Find(&variants).Error; err != nil {
+			return err
+		}
+	}
+
+	var errs validator.ValidationErrors
+	for i := range cart.Items {
+		item := &cart.Items[i]
+		index := slices.IndexFunc(items, func(p *products.Product) bool { return p.Id == item.ProductId })
+		if index < 0 {
+			errs = append(errs, validator.ValidationError{
+				Field:   fmt.Sprintf("items[%d].product_id", i),
+				Tag:     "exists",
+				Value:   fmt.Sprint(item.ProductId),
+				Message: fmt.Sprintf("product %d does not exist", item.ProductId),
+			})
+			continue
+		}
+		item.UnitPrice = items[index].Price
+
+		if item.VariantId == nil {
+			continue
+		}
+		index = slices.IndexFunc(variants, func(v *products.ProductVariant) bool {
+			return v.Id == *item.VariantId && v.ProductId == item.ProductId
+		})
+		if index < 0 {
+			errs = append(errs, validator.ValidationError{
+				Field:   fmt.Sprintf("items[%d].variant_id", i),
+				Tag:     "exists",
+				Value:   fmt.Sprint(*item.VariantId),
+				Message: fmt.Sprintf("variant %d does not exist", *item.VariantId),
+			})
+			continue
+		}
+		if variants[index].Price != nil {
+			item.UnitPrice = *variants[index].Price
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Apply returns what the coupon with the code takes off the cart, or why it doesn't apply
+func (s *CouponService) Apply(ctx context.Context, code string, cart *Cart) (*Discount, error) {
+	if err := ValidateCart(cart); err != nil {
+		return nil, err
+	}
+
+	coupon, err := s.byCode(s.DB.WithContext(ctx), code)
+	if err != nil {
+		return nil, err
+	}
+	return s.apply(s.DB.WithContext(ctx), coupon, cart)
+}
+
+// Redeem applies a coupon to the cart of an order and records its use. Redeeming the
+// same coupon for an order again returns the first redemption.
+func (s *CouponService) Redeem(ctx context.Context, req *RedeemRequest) (*Redemption, error) {
+	if err := ValidateRedeemRequest(req); err != nil {
+		return nil, err
+	}
+	if req.UserId != nil {
+		req.Cart.UserId = *req.UserId
+	}
+
+	var redemption *Redemption
+	created := false
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		coupon, err := s.byCode(tx, req.Code)
+		if err != nil {
+			return err
+		}
+
+		existing := &Redemption{}
+		err = tx.Where("coupon_id = ? AND order_id = ?", coupon.Id, req.OrderId).First(existing).Error
+		if err == nil {
+			redemption = existing
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		discount, err := s.apply(tx, coupon, &req.Cart)
+		if err != nil {
+			return err
+		}
+
+		// The condition keeps concurrent redemptions within the usage limit
+		result := tx.Model(&Coupon{}).
+			Where("id = ? AND (usage_limit = 0 OR used_count < usage_limit)", coupon.Id).
+			Update("used_count", gorm.Expr("used_count + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrCouponUsedUp
+		}
+
+		redemption = &Redemption{
+			CouponId: coupon.Id,
+			OrderId:  req.OrderId,
+			UserId:   req.UserId,
+			Code:     coupon.Code,
+			Amount:   discount.Amount,
+			Currency: discount.Currency,
+		}
+		created = true
+		return tx.Create(redemption).Error
+	})
+	if err != nil {
+		if !IsCouponError(err) && !errors.Is(err, ErrCouponNotFound) {
+			s.Logger.Error("failed to redeem coupon",
+				logger.String("error", err.Error()),
+				logger.Int("order_id", int(req.OrderId)))
+		}
+		return nil, err
+	}
+
+	if created {
+		s.Emitter.EmitContext(ctx, RedeemCouponEvent, redemption)
+	}
+	return redemption, nil
+}
+
+// Release gives back the coupons redeemed by an order, e.g. when it is canceled
+func (s *CouponService) Release(ctx context.Context, orderId uint) ([]*Redemption, error) {
+	var redemptions []*Redemption
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("order_id = ?", orderId).Find(&redemptions).Error; err != nil {
+			return err
+		}
+		for _, redemption := range redemptions {
+			if err := tx.Delete(redemption).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&Coupon{}).Unscoped().
+				Where("id = ? AND used_count > 0", redemption.CouponId).
+				Update("used_count", gorm.Expr("used_count - 1")).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.Logger.Error("failed to release coupons",
+			logger.String("error", err.Error()),
+			logger.Int("order_id", int(orderId)))
+		return nil, err
+	}
+
+	for _, redemption := range redemptions {
+		s.Emitter.EmitContext(ctx, ReleaseCouponEvent, redemption)
+	}
+	return redemptions, nil
+}
+
+// byCode finds a coupon by its code
+func (s *CouponService) byCode(db *gorm.DB, code string) (*Coupon, error) {
+	coupon := &Coupon{}
+	if err := db.Where("code = ?", NormalizeCode(code)).First(coupon).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCouponNotFound
+		}
+		return nil, err
+	}
+	return coupon, nil
+}
+
+// apply checks that a coupon applies to a cart and computes the discount. The discount is
+// spread over the eligible items by their share of the eligible subtotal.
+func (s *CouponService) apply(db *gorm.DB, coupon *Coupon, cart *Cart) (*Discount, error) {
+	if cart.Currency == "" {
+		cart.Currency = s.currency
+	}
+
+	now := time.Now()
+	switch {
+	case !coupon.Active:
+		return nil, ErrCouponInactive
+	case coupon.StartsAt != nil && now.Before(*coupon.StartsAt):
+		return nil, ErrCouponNotStarted
+	case coupon.EndsAt != nil && !now.Before(*coupon.EndsAt):
+		return nil, ErrCouponExpired
+	case coupon.UsageLimit > 0 && coupon.UsedCount >= coupon.UsageLimit:
+		return nil, ErrCouponUsedUp
+	case coupon.Type == TypeFixed && coupon.Currency != cart.Currency:
+		return nil, ErrCouponCurrency
+	}
+	if coupon.PerUserLimit > 0 && cart.UserId != 0 {
+		var used int64
+		if err := db.Model(&Redemption{}).
+			Where("coupon_id = ? AND user_id = ?", coupon.Id, cart.UserId).
+			Count(&used).Error; err != nil {
+			return nil, err
+		}
+		if used >= int64(coupon.PerUserLimit) {
+			return nil, ErrCouponUserLimit
+		}
+	}
+
+	discount := &Discount{
+		CouponId: coupon.Id,
+		Code:     coupon.Code,
+		Type:     coupon.Type,
+		Currency: cart.Currency,
+		Subtotal: cart.Subtotal(),
+		Items:    []ItemDiscount{},
+	}
+	if discount.Subtotal < coupon.MinSubtotal {
+		return nil, ErrCouponMinSubtotal
+	}
+
+	eligible, err := s.eligible(db, coupon, cart)
+	if err != nil {
+		return nil, err
+	}
+	for i, item := range cart.Items {
+		if eligible[i] {
+			discount.EligibleSubtotal += item.UnitPrice.Times(item.Quantity)
+		}
+	}
+	if discount.EligibleSubtotal == 0 {
+		return nil, ErrCouponNotApplicable
+	}
+
+	if coupon.Type == TypePercentage {
+		discount.Amount = discount.EligibleSubtotal.Percent(float64(coupon.Value))
+		if coupon.MaxDiscount > 0 {
+			discount.Amount = min(discount.Amount, coupon.MaxDiscount)
+		}
+	} else {
+		discount.Amount = min(types.Money(coupon.Value), discount.EligibleSubtotal)
+	}
+	discount.Total = discount.Subtotal - discount.Amount
+
+	// The last eligible item takes the rounding remainder
+	left, last := discount.Amount, -1
+	for i, item := range cart.Items {
+		if !eligible[i] {
+			continue
+		}
+		amount := discount.Amount * item.UnitPrice.Times(item.Quantity) / discount.EligibleSubtotal
+		discount.Items = append(discount.Items, ItemDiscount{ProductId: item.ProductId, VariantId: item.VariantId, Amount: amount})
+		left -= amount
+		last = len(discount.Items) - 1
+	}
+	if last >= 0 {
+		discount.Items[last].Amount += left
+	}
+	return discount, nil
+}
+
+// eligible reports for each cart item whether the coupon applies to it: unscoped coupons
+// apply to all items, others to their products and the products of their categories
+func (s *CouponService) eligible(db *gorm.DB, coupon *Coupon, cart *Cart) ([]bool, error) {
+	result := make([]bool, len(cart.Items))
+	if !coupon.Scoped() {
+		for i := range result {
+			result[i] = true
+		}
+		return result, nil
+	}
+
+	var inCategories []uint
+	if len(coupon.CategoryIds) > 0 {
+		var productIds []uint
+		for _, item := range cart.Items {
+			productIds = append(productIds, item.ProductId)
+		}
+		if err := db.Table("product_category_links").
+			Where("product_id IN ? AND category_id IN ?", productIds, coupon.CategoryIds).
+			Distinct().Pluck("product_id", &inCategories).Error; err != nil {
+			return nil, err
+		}
+	}
+	for i, item := range cart.Items {
+		result[i] = slices.Contains(coupon.ProductIds, item.ProductId) || slices.Contains(inCategories, item.ProductId)
+	}
+	return result, nil
+}
+
+// check validates a coupon once a request is applied to it: the cross-field rules, a
+// unique code and existing products and categories
+func (s *CouponService) check(ctx context.Context, coupon *Coupon) error {
+	errs := validateCoupon(coupon)
+
+	var taken int64
+	if err := s.DB.WithContext(ctx).Model(&Coupon{}).Unscoped().Where("code = ? AND id <> ?", coupon.Code, coupon.Id).Count(&taken).Error; err != nil {
+		return err
+	}
+	if taken > 0 {
+		errs = append(errs, validator.ValidationError{
+			Field:   "code",
+			Tag:     "unique",
+			Value:   coupon.Code,
+			Message: fmt.Sprintf("code %s is already taken", coupon.Code),
+		})
+	}
+
+	for _, scope := range []struct {
+		field string
+		model any
+		ids   []uint
+	}{
+		{"product_ids", &products.Product{}, coupon.ProductIds},
+		{"category_ids", &products.Category{}, coupon.CategoryIds},
+	} {
+		if len(scope.ids) == 0 {
+			continue
+		}
+		var found []uint
+		if err := s.DB.WithContext(ctx).Model(scope.model).Where("id IN ?", scope.ids).Pluck("id", &found).Error; err != nil {
+			return err
+		}
+		for i, id := range scope.ids {
+			if !slices.Contains(found, id) {
+				errs = append(errs, validator.ValidationError{
+					Field:   fmt.Sprintf("%s[%d]", scope.field, i),
+					Tag:     "exists",
+					Value:   fmt.Sprint(id),
+					Message: fmt.Sprintf("%d does not exist", id),
+				})
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
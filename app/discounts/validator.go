@@ -0,0 +1,136 @@
+package discounts
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"base/core/validator"
+)
+
+// Global validator instance using Base core validator wrapper
+var validate = validator.New()
+
+// codePattern is the format of coupon codes once uppercased
+var codePattern = regexp.MustCompile(`^[A-Z0-9_-]+$`)
+
+// NormalizeCode returns a coupon code the way it is stored: trimmed and uppercase
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// ValidateCouponCreateRequest validates the create request
+func ValidateCouponCreateRequest(req *CreateCouponRequest) error {
+	if req == nil {
+		return nilRequest()
+	}
+
+	req.Code = NormalizeCode(req.Code)
+	req.Currency = strings.ToUpper(req.Currency) // Currency codes are accepted in any case
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateCouponUpdateRequest validates the update request
+func ValidateCouponUpdateRequest(req *UpdateCouponRequest) error {
+	if req == nil {
+		return nilRequest()
+	}
+
+	if req.Code != nil {
+		code := NormalizeCode(*req.Code)
+		req.Code = &code
+	}
+	if req.Currency != nil {
+		currency := strings.ToUpper(*req.Currency)
+		req.Currency = &currency
+	}
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateCart validates a cart a coupon is applied to
+func ValidateCart(cart *Cart) error {
+	if cart == nil {
+		return nilRequest()
+	}
+
+	cart.Currency = strings.ToUpper(cart.Currency)
+	if errs := validate.Validate(cart); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateCouponValidateRequest validates the request of the cart validation endpoint
+func ValidateCouponValidateRequest(req *ValidateRequest) error {
+	if req == nil {
+		return nilRequest()
+	}
+
+	req.Currency = strings.ToUpper(req.Currency)
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateRedeemRequest validates the redeem request
+func ValidateRedeemRequest(req *RedeemRequest) error {
+	if req == nil {
+		return nilRequest()
+	}
+
+	req.Currency = strings.ToUpper(req.Currency)
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateCoupon checks the rules that span fields once a request is applied to a coupon
+func validateCoupon(coupon *Coupon) validator.ValidationErrors {
+	var errs validator.ValidationErrors
+	if !codePattern.MatchString(coupon.Code) {
+		errs = append(errs, validator.ValidationError{
+			Field:   "code",
+			Tag:     "invalid",
+			Value:   coupon.Code,
+			Message: "code must contain only letters, digits, '_' or '-'",
+		})
+	}
+	if coupon.Type == TypePercentage && coupon.Value > 100 {
+		errs = append(errs, validator.ValidationError{
+			Field:   "value",
+			Tag:     "lte",
+			Value:   strconv.FormatInt(coupon.Value, 10),
+			Param:   "100",
+			Message: "value must be less than or equal to 100",
+		})
+	}
+	if coupon.StartsAt != nil && coupon.EndsAt != nil && !coupon.EndsAt.After(*coupon.StartsAt) {
+		errs = append(errs, validator.ValidationError{
+			Field:   "ends_at",
+			Tag:     "gt",
+			Value:   coupon.EndsAt.String(),
+			Param:   "starts_at",
+			Message: "ends_at must be greater than starts_at",
+		})
+	}
+	return errs
+}
+
+func nilRequest() validator.ValidationErrors {
+	return validator.ValidationErrors{
+		{
+			Field:   "request",
+			Tag:     "required",
+			Value:   "nil",
+			Message: "request cannot be nil",
+		},
+	}
+}
@@ -0,0 +1,388 @@
+package forms
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"base/core/router"
+	"base/core/translation"
+	"base/core/types"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+type FormController struct {
+	Service *FormService
+}
+
+func NewFormController(service *FormService) *FormController {
+	return &FormController{
+		Service: service,
+	}
+}
+
+// Routes registers the form management endpoints; the group is restricted to admins by
+// the module
+func (c *FormController) Routes(router *router.RouterGroup) {
+	router.GET("/forms", c.List)          // Paginated list
+	router.POST("/forms", c.Create)       // Create
+	router.GET("/forms/:id", c.Get)       // Get by ID
+	router.PUT("/forms/:id", c.Update)    // Update
+	router.DELETE("/forms/:id", c.Delete) // Delete with its submissions
+
+	router.GET("/forms/:id/submissions", c.Submissions)                        // Submissions, newest first
+	router.GET("/forms/:id/submissions/export", c.Export)                      // CSV of all submissions - MUST be before /:submission_id
+	router.GET("/forms/:id/submissions/:submission_id", c.Submission)          // Submission
+	router.DELETE("/forms/:id/submissions/:submission_id", c.DeleteSubmission) // Delete a submission
+}
+
+// PublicRoutes registers the public form endpoints. They don't need a user token (see
+// /api/public/* in MIDDLEWARE_AUTH_SKIP_PATHS).
+func (c *FormController) PublicRoutes(router *router.RouterGroup) {
+	router.GET("/public/forms/:slug", c.PublicGet)
+	router.POST("/public/forms/:slug/submissions", c.Submit)
+}
+
+// CreateForm godoc
+// @Summary Create a form
+// @Description Create a form with its fields; submissions are checked against the type and rules of each field and emailed to the recipients (Admin only)
+// @Tags App/Forms
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param form body CreateFormRequest true "Create form request"
+// @Success 201 {object} Form
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /forms [post]
+func (c *FormController) Create(ctx *router.Context) error {
+	var req CreateFormRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	item, err := c.Service.Create(ctx.Request.Context(), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to create form")
+	}
+	return ctx.JSON(http.StatusCreated, item)
+}
+
+// GetForm godoc
+// @Summary Get a form
+// @Description Get a form by its id with its fields and recipients (Admin only)
+// @Tags App/Forms
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Form id"
+// @Success 200 {object} Form
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /forms/{id} [get]
+func (c *FormController) Get(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	item, err := c.Service.GetById(ctx.Request.Context(), uint(id))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to get form")
+	}
+	return ctx.JSON(http.StatusOK, item)
+}
+
+// ListForms godoc
+// @Summary List forms
+// @Description Get a page of forms ordered by name (Admin only)
+// @Tags App/Forms
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of items per page"
+// @Success 200 {object} types.PaginatedResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /forms [get]
+func (c *FormController) List(ctx *router.Context) error {
+	params, err := ctx.ListParams()
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	paginatedResponse, err := c.Service.GetAll(ctx.Request.Context(), params.Page, params.Limit)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch forms: " + err.Error()})
+	}
+	return ctx.JSON(http.StatusOK, paginatedResponse)
+}
+
+// UpdateForm godoc
+// @Summary Update a form
+// @Description Update a form; omitted fields are left unchanged, fields and recipients replace the current ones. Stored submissions keep the values of removed fields (Admin only)
+// @Tags App/Forms
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Form id"
+// @Param form body UpdateFormRequest true "Update form request"
+// @Success 200 {object} Form
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /forms/{id} [put]
+func (c *FormController) Update(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	var req UpdateFormRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	item, err := c.Service.Update(ctx.Request.Context(), uint(id), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to update form")
+	}
+	return ctx.JSON(http.StatusOK, item)
+}
+
+// DeleteForm godoc
+// @Summary Delete a form
+// @Description Delete a form with all its submissions (Admin only)
+// @Tags App/Forms
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Form id"
+// @Success 204 "Successfully deleted"
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /forms/{id} [delete]
+func (c *FormController) Delete(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	if err := c.Service.Delete(ctx.Request.Context(), uint(id)); err != nil {
+		return c.fail(ctx, err, "Failed to delete form")
+	}
+
+	ctx.Status(http.StatusNoContent)
+	return nil
+}
+
+// ListFormSubmissions godoc
+// @Summary List form submissions
+// @Description Get a page of the submissions of a form, newest first (Admin only)
+// @Tags App/Forms
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Form id"
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of items per page"
+// @Success 200 {object} types.PaginatedResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /forms/{id}/submissions [get]
+func (c *FormController) Submissions(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+	params, err := ctx.ListParams()
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	paginatedResponse, err := c.Service.GetSubmissions(ctx.Request.Context(), uint(id), params.Page, params.Limit)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to fetch submissions")
+	}
+	return ctx.JSON(http.StatusOK, paginatedResponse)
+}
+
+// ExportFormSubmissions godoc
+// @Summary Export form submissions
+// @Description Download all submissions of a form as CSV, oldest first, with a column per field (Admin only)
+// @Tags App/Forms
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce text/csv
+// @Param id path int true "Form id"
+// @Success 200 {file} file
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /forms/{id}/submissions/export [get]
+func (c *FormController) Export(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	form, err := c.Service.GetById(ctx.Request.Context(), uint(id))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to export submissions")
+	}
+
+	// The rows are streamed, so errors after this point only cut the file short
+	filename := fmt.Sprintf("%s-submissions-%s.csv", form.Slug, time.Now().Format("2006-01-02"))
+	ctx.SetHeader("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	ctx.SetHeader("Content-Type", "text/csv; charset=utf-8")
+	ctx.Writer.WriteHeader(http.StatusOK)
+	c.Service.Export(ctx.Request.Context(), form, ctx.Writer)
+	return nil
+}
+
+// GetFormSubmission godoc
+// @Summary Get a form submission
+// @Description Get a submission of a form with its values, IP address and user agent (Admin only)
+// @Tags App/Forms
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Form id"
+// @Param submission_id path int true "Submission id"
+// @Success 200 {object} Submission
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /forms/{id}/submissions/{submission_id} [get]
+func (c *FormController) Submission(ctx *router.Context) error {
+	id, submissionId, err := submissionParams(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	item, err := c.Service.GetSubmission(ctx.Request.Context(), id, submissionId)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to get submission")
+	}
+	return ctx.JSON(http.StatusOK, item)
+}
+
+// DeleteFormSubmission godoc
+// @Summary Delete a form submission
+// @Description Delete a submission of a form, e.g. spam or at the request of the sender (Admin only)
+// @Tags App/Forms
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Form id"
+// @Param submission_id path int true "Submission id"
+// @Success 204 "Successfully deleted"
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /forms/{id}/submissions/{submission_id} [delete]
+func (c *FormController) DeleteSubmission(ctx *router.Context) error {
+	id, submissionId, err := submissionParams(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	if err := c.Service.DeleteSubmission(ctx.Request.Context(), id, submissionId); err != nil {
+		return c.fail(ctx, err, "Failed to delete submission")
+	}
+
+	ctx.Status(http.StatusNoContent)
+	return nil
+}
+
+// GetPublicForm godoc
+// @Summary Get a form
+// @Description Get an active form by its slug with its fields, for rendering it
+// @Tags App/Forms
+// @Security ApiKeyAuth
+// @Produce json
+// @Param slug path string true "Form slug"
+// @Success 200 {object} PublicFormResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /public/forms/{slug} [get]
+func (c *FormController) PublicGet(ctx *router.Context) error {
+	item, err := c.Service.GetActive(ctx.Request.Context(), ctx.Param("slug"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Form not found"})
+	}
+	return ctx.JSON(http.StatusOK, item.ToPublicResponse())
+}
+
+// SubmitForm godoc
+// @Summary Submit a form
+// @Description Submit the values of an active form by field name. The website field is a honeypot that must be left empty; an IP address can submit a form 5 times in 10 minutes
+// @Tags App/Forms
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param slug path string true "Form slug"
+// @Param submission body SubmitRequest true "Submission"
+// @Success 201 {object} SubmitResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 429 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /public/forms/{slug}/submissions [post]
+func (c *FormController) Submit(ctx *router.Context) error {
+	var req SubmitRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	form, err := c.Service.Submit(ctx.Request.Context(), ctx.Param("slug"), &req, ctx.ClientIP(), ctx.Request.UserAgent())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Form not found"})
+	}
+	if err != nil {
+		return c.fail(ctx, err, "Failed to submit form")
+	}
+
+	message := form.SuccessMessage
+	if message == "" {
+		message = "Thank you for your submission."
+	}
+	return ctx.JSON(http.StatusCreated, SubmitResponse{Message: message})
+}
+
+// fail writes the error response of a service error
+func (c *FormController) fail(ctx *router.Context, err error, message string) error {
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   translation.Error(ctx, err),
+			Details: translation.LocalizeValidation(ctx, validationErrors),
+		})
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Item not found"})
+	}
+	if errors.Is(err, ErrTooManySubmissions) {
+		return ctx.JSON(http.StatusTooManyRequests, types.ErrorResponse{Error: translation.Error(ctx, err)})
+	}
+	return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: message + ": " + err.Error()})
+}
+
+// submissionParams reads the form and submission id path parameters
+func submissionParams(ctx *router.Context) (uint, uint, error) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return 0, 0, errors.New("Invalid id format")
+	}
+	submissionId, err := strconv.ParseUint(ctx.Param("submission_id"), 10, 32)
+	if err != nil {
+		return 0, 0, errors.New("Invalid submission_id format")
+	}
+	return uint(id), uint(submissionId), nil
+}
@@ -0,0 +1,140 @@
+package forms
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Field types
+const (
+	FieldText     = "text"
+	FieldTextarea = "textarea"
+	FieldEmail    = "email"
+	FieldNumber   = "number"
+	FieldPhone    = "phone"
+	FieldUrl      = "url"
+	FieldDate     = "date" // YYYY-MM-DD
+	FieldSelect   = "select"
+	FieldCheckbox = "checkbox"
+)
+
+// Field is an input of a form. Submissions are checked against its type and rules.
+type Field struct {
+	Name        string   `json:"name" validate:"required,max=64"` // Key of the value in submissions, e.g. email
+	Label       string   `json:"label" validate:"required,max=255"`
+	Type        string   `json:"type" validate:"required,oneof=text textarea email number phone url date select checkbox"`
+	Required    bool     `json:"required"`
+	Placeholder string   `json:"placeholder,omitempty" validate:"max=255"`
+	Options     []string `json:"options,omitempty" validate:"dive,required,max=255"` // Choices of select fields
+	MinLength   *int     `json:"min_length,omitempty" validate:"omitempty,min=0"`
+	MaxLength   *int     `json:"max_length,omitempty" validate:"omitempty,min=1"`
+	Min         *float64 `json:"min,omitempty"`                        // Smallest number
+	Max         *float64 `json:"max,omitempty"`                        // Largest number
+	Pattern     string   `json:"pattern,omitempty" validate:"max=255"` // Regular expression text values must match
+}
+
+// Form is a form visitors fill in on the site, e.g. a contact form. Its submissions are
+// stored and emailed to the recipients.
+type Form struct {
+	Id             uint           `json:"id" gorm:"primarykey"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	Name           string         `json:"name" gorm:"size:255"`
+	Slug           string         `json:"slug" gorm:"size:255;index"`
+	Description    string         `json:"description" gorm:"type:text"`
+	Fields         []Field        `json:"fields" gorm:"type:text;serializer:json"`
+	Recipients     []string       `json:"recipients" gorm:"type:text;serializer:json"` // Emailed every submission
+	SuccessMessage string         `json:"success_message" gorm:"size:1024"`            // Shown after submitting
+	Active         bool           `json:"active"`                                      // Inactive forms take no submissions
+}
+
+// TableName returns the table name for the Form model
+func (m *Form) TableName() string {
+	return "forms"
+}
+
+// Field returns a field of the form by name
+func (m *Form) Field(name string) (Field, bool) {
+	for _, field := range m.Fields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return Field{}, false
+}
+
+// Submission is a filled in form
+type Submission struct {
+	Id        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time      `json:"created_at"`
+	FormId    uint           `json:"form_id" gorm:"index"`
+	Data      map[string]any `json:"data" gorm:"type:text;serializer:json"` // Values by field name
+	IpAddress string         `json:"ip_address" gorm:"size:45;index"`
+	UserAgent string         `json:"user_agent" gorm:"size:255"`
+}
+
+// TableName returns the table name for the Submission model
+func (m *Submission) TableName() string {
+	return "form_submissions"
+}
+
+// CreateFormRequest represents the request payload for creating a Form
+type CreateFormRequest struct {
+	Name           string   `json:"name" validate:"required,max=255"`
+	Slug           string   `json:"slug" validate:"omitempty,max=255"` // Generated from the name when empty
+	Description    string   `json:"description"`
+	Fields         []Field  `json:"fields" validate:"required,min=1,dive"`
+	Recipients     []string `json:"recipients" validate:"dive,email"`
+	SuccessMessage string   `json:"success_message" validate:"max=1024"`
+	Active         *bool    `json:"active,omitempty"` // Defaults to true
+}
+
+// UpdateFormRequest represents the request payload for updating a Form. Omitted fields are
+// left unchanged; fields and recipients replace the current ones.
+type UpdateFormRequest struct {
+	Name           *string   `json:"name,omitempty" validate:"omitempty,min=1,max=255"`
+	Slug           *string   `json:"slug,omitempty" validate:"omitempty,max=255"`
+	Description    *string   `json:"description,omitempty"`
+	Fields         *[]Field  `json:"fields,omitempty" validate:"omitempty,min=1,dive"`
+	Recipients     *[]string `json:"recipients,omitempty" validate:"omitempty,dive,email"`
+	SuccessMessage *string   `json:"success_message,omitempty" validate:"omitempty,max=1024"`
+	Active         *bool     `json:"active,omitempty"`
+}
+
+// SubmitRequest represents a submission of a form by a visitor
+type SubmitRequest struct {
+	Data map[string]any `json:"data"` // Values by field name
+
+	// Honeypot for spam bots: a field the form hides from people, which must stay empty
+	Website string `json:"website,omitempty"`
+}
+
+// PublicFormResponse represents a form for rendering it on the site, without its recipients
+type PublicFormResponse struct {
+	Id          uint    `json:"id"`
+	Name        string  `json:"name"`
+	Slug        string  `json:"slug"`
+	Description string  `json:"description"`
+	Fields      []Field `json:"fields"`
+}
+
+// SubmitResponse represents the response to a submission
+type SubmitResponse struct {
+	Message string `json:"message"`
+}
+
+// ToPublicResponse converts the form to a public response
+func (m *Form) ToPublicResponse() *PublicFormResponse {
+	if m == nil {
+		return nil
+	}
+	return &PublicFormResponse{
+		Id:          m.Id,
+		Name:        m.Name,
+		Slug:        m.Slug,
+		Description: m.Description,
+		Fields:      m.Fields,
+	}
+}
@@ -0,0 +1,134 @@
+package forms
+
+import (
+	"errors"
+
+	"base/core/app/authorization"
+	"base/core/app/reports"
+	"base/core/module"
+	"base/core/router"
+
+	"gorm.io/gorm"
+)
+
+// Module provides forms visitors fill in on the site, e.g. a contact form. Management
+// endpoints are restricted to admins; active forms are public and take submissions.
+type Module struct {
+	module.DefaultModule
+	DB         *gorm.DB
+	Service    *FormService
+	Controller *FormController
+}
+
+// Init creates and initializes the forms module with all dependencies
+func Init(deps module.Dependencies) module.Module {
+	registerEvents()
+	service := NewFormService(deps.DB, deps.Emitter, deps.Logger, deps.EmailSender)
+	service.From = deps.Config.EmailFromAddress
+	service.Listen()
+
+	reports.RegisterEntity(reports.Entity{
+		Name:       "forms",
+		Columns:    []string{"id", "name", "slug", "active", "created_at", "updated_at"},
+		SoftDelete: true,
+	})
+	reports.RegisterEntity(reports.Entity{
+		Name:    "form_submissions",
+		Columns: []string{"id", "form_id", "ip_address", "created_at"},
+	})
+
+	return &Module{
+		DB:         deps.DB,
+		Service:    service,
+		Controller: NewFormController(service),
+	}
+}
+
+// Routes registers the admin and the public form routes
+func (m *Module) Routes(router *router.RouterGroup) {
+	group := router.Group("", authorization.RequireAdmin(authorization.NewAuthorizationService(m.DB)))
+	m.Controller.Routes(group)
+
+	m.Controller.PublicRoutes(router)
+}
+
+func (m *Module) Init() error {
+	return m.SeedPermissions()
+}
+
+func (m *Module) SeedPermissions() error {
+	// Ensure permissions table exists before seeding
+	if err := m.DB.AutoMigrate(&authorization.Permission{}); err != nil {
+		return err
+	}
+
+	// Define permissions for form CRUD operations
+	permissions := []authorization.Permission{
+		{
+			Name:         "form list",
+			Description:  "View form list",
+			ResourceType: "form",
+			Action:       "list",
+		},
+		{
+			Name:         "form read",
+			Description:  "View forms, their submissions and exports",
+			ResourceType: "form",
+			Action:       "read",
+		},
+		{
+			Name:         "form create",
+			Description:  "Create forms",
+			ResourceType: "form",
+			Action:       "create",
+		},
+		{
+			Name:         "form update",
+			Description:  "Update forms and delete submissions",
+			ResourceType: "form",
+			Action:       "update",
+		},
+		{
+			Name:         "form delete",
+			Description:  "Delete forms with their submissions",
+			ResourceType: "form",
+			Action:       "delete",
+		},
+	}
+
+	// Upsert permissions - create or update if they exist
+	for _, permission := range permissions {
+		var existingPermission authorization.Permission
+		result := m.DB.Where("resource_type = ? AND action = ?", permission.ResourceType, permission.Action).First(&existingPermission)
+
+		if result.Error != nil && errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// Create new permission
+			if err := m.DB.Create(&permission).Error; err != nil {
+				return err
+			}
+		} else if result.Error == nil {
+			// Update existing permission
+			existingPermission.Name = permission.Name
+			existingPermission.Description = permission.Description
+			if err := m.DB.Save(&existingPermission).Error; err != nil {
+				return err
+			}
+		} else {
+			// Return any other error
+			return result.Error
+		}
+	}
+
+	return nil
+}
+
+func (m *Module) Migrate() error {
+	return m.DB.AutoMigrate(&Form{}, &Submission{})
+}
+
+func (m *Module) GetModels() []any {
+	return []any{
+		&Form{},
+		&Submission{},
+	}
+}
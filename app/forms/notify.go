@@ -0,0 +1,44 @@
+package forms
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"base/core/email"
+	"base/core/emitter"
+)
+
+// Listen emails the recipients of a form about its new submissions; emails that fail are
+// kept as event failures to retry
+func (s *FormService) Listen() {
+	emitter.Handle(s.Emitter, SubmitFormEvent, s.notify)
+}
+
+// notify emails the recipients of the form of a submission with its values
+func (s *FormService) notify(ctx context.Context, submission *Submission) error {
+	if s.EmailSender == nil {
+		return nil
+	}
+	form, err := s.GetById(ctx, submission.FormId)
+	if err != nil {
+		return err
+	}
+	if len(form.Recipients) == 0 {
+		return nil
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "New submission of the form %q:\n\n", form.Name)
+	for _, field := range form.Fields {
+		fmt.Fprintf(&body, "%s: %s\n", field.Label, formatValue(submission.Data[field.Name]))
+	}
+	fmt.Fprintf(&body, "\nSubmitted at %s from %s", submission.CreatedAt.UTC().Format("2006-01-02 15:04 MST"), submission.IpAddress)
+
+	return s.EmailSender.Send(email.Message{
+		To:      form.Recipients,
+		From:    s.From,
+		Subject: fmt.Sprintf("New submission: %s", form.Name),
+		Body:    body.String(),
+	})
+}
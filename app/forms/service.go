@@ -0,0 +1,460 @@
+package forms
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"base/core/email"
+	"base/core/emitter"
+	"base/core/helper"
+	"base/core/logger"
+	"base/core/types"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+const (
+	CreateFormEvent = "forms.create"
+	UpdateFormEvent = "forms.update"
+	DeleteFormEvent = "forms.delete"
+	SubmitFormEvent = "forms.submit" // With the stored *Submission
+)
+
+// registerEvents adds the events of the module to the event catalog
+func registerEvents() {
+	emitter.RegisterEvents(
+		emitter.EventType{Name: CreateFormEvent, Description: "A form was created", Payload: &Form{}},
+		emitter.EventType{Name: UpdateFormEvent, Description: "A form was updated", Payload: &Form{}},
+		emitter.EventType{Name: DeleteFormEvent, Description: "A form was deleted", Payload: &Form{}},
+		emitter.EventType{Name: SubmitFormEvent, Description: "A form was submitted", Payload: &Submission{}},
+	)
+}
+
+const (
+	submissionLimit  = 5                // Submissions per form and IP address in the submission window
+	submissionWindow = 10 * time.Minute // Window of the submission limit
+)
+
+// ErrTooManySubmissions is returned when an IP address submitted a form too often
+var ErrTooManySubmissions = errors.New("too many submissions, please try again later")
+
+// FormService manages forms and their submissions
+type FormService struct {
+	DB          *gorm.DB
+	Emitter     *emitter.Emitter
+	Logger      logger.Logger
+	EmailSender email.Sender // Nil to send no notifications
+	From        string
+	slugs       *helper.SlugHelper
+}
+
+func NewFormService(db *gorm.DB, emitter *emitter.Emitter, logger logger.Logger, sender email.Sender) *FormService {
+	return &FormService{
+		DB:          db,
+		Emitter:     emitter,
+		Logger:      logger,
+		EmailSender: sender,
+		slugs:       helper.NewSlugHelper(),
+	}
+}
+
+// Create creates a form
+func (s *FormService) Create(ctx context.Context, req *CreateFormRequest) (*Form, error) {
+	if err := ValidateFormCreateRequest(req); err != nil {
+		return nil, err
+	}
+
+	item := &Form{
+		Name:           req.Name,
+		Description:    req.Description,
+		Fields:         req.Fields,
+		Recipients:     req.Recipients,
+		SuccessMessage: req.SuccessMessage,
+		Active:         true,
+	}
+	if req.Active != nil {
+		item.Active = *req.Active
+	}
+	slug, errs := s.slug(ctx, req.Slug, req.Name, 0)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	item.Slug = slug
+
+	if err := s.DB.WithContext(ctx).Create(item).Error; err != nil {
+		s.Logger.Error("failed to create form", logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	// Emit create event
+	s.Emitter.EmitContext(ctx, CreateFormEvent, item)
+
+	return item, nil
+}
+
+// Update updates the fields of a form that are set in the request. Stored submissions keep
+// the values of fields that were removed.
+func (s *FormService) Update(ctx context.Context, id uint, req *UpdateFormRequest) (*Form, error) {
+	item, err := s.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateFormUpdateRequest(req); err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		item.Name = *req.Name
+	}
+	if req.Slug != nil && *req.Slug != item.Slug {
+		slug, errs := s.slug(ctx, *req.Slug, item.Name, item.Id)
+		if len(errs) > 0 {
+			return nil, errs
+		}
+		item.Slug = slug
+	}
+	if req.Description != nil {
+		item.Description = *req.Description
+	}
+	if req.Fields != nil {
+		item.Fields = *req.Fields
+	}
+	if req.Recipients != nil {
+		item.Recipients = *req.Recipients
+	}
+	if req.SuccessMessage != nil {
+		item.SuccessMessage = *req.SuccessMessage
+	}
+	if req.Active != nil {
+		item.Active = *req.Active
+	}
+
+	if err := s.DB.WithContext(ctx).Save(item).Error; err != nil {
+		s.Logger.Error("failed to update form",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return nil, err
+	}
+
+	// Emit update event
+	s.Emitter.EmitContext(ctx, UpdateFormEvent, item)
+
+	return item, nil
+}
+
+// Delete deletes a form with its submissions
+func (s *FormService) Delete(ctx context.Context, id uint) error {
+	item, err := s.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("form_id = ?", item.Id).Delete(&Submission{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(item).Error
+	})
+	if err != nil {
+		s.Logger.Error("failed to delete form",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return err
+	}
+
+	// Emit delete event
+	s.Emitter.EmitContext(ctx, DeleteFormEvent, item)
+
+	return nil
+}
+
+// GetById returns a form by id
+func (s *FormService) GetById(ctx context.Context, id uint) (*Form, error) {
+	item := &Form{}
+	if err := s.DB.WithContext(ctx).First(item, id).Error; err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// GetActive returns an active form by slug
+func (s *FormService) GetActive(ctx context.Context, slug string) (*Form, error) {
+	item := &Form{}
+	if err := s.DB.WithContext(ctx).Where("slug = ? AND active = ?", slug, true).First(item).Error; err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// GetAll returns a page of forms ordered by name
+func (s *FormService) GetAll(ctx context.Context, page *int, limit *int) (*types.PaginatedResponse, error) {
+	var items []*Form
+	var total int64
+
+	query := s.DB.WithContext(ctx).Model(&Form{})
+
+	// Set default values if nil
+	defaultPage := 1
+	defaultLimit := 10
+	if page == nil {
+		page = &defaultPage
+	}
+	if limit == nil {
+		limit = &defaultLimit
+	}
+
+	// Get total count
+	if err := query.Count(&total).Error; err != nil {
+		s.Logger.Error("failed to count forms",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	offset := (*page - 1) * *limit
+	if err := query.Order("name").Offset(offset).Limit(*limit).Find(&items).Error; err != nil {
+		s.Logger.Error("failed to get forms",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	return paginated(items, total, *page, *limit), nil
+}
+
+// Submit stores a submission of an active form by a visitor. Submissions that filled in
+// the honeypot are accepted without being stored, so spam bots don't learn about it.
+func (s *FormService) Submit(ctx context.Context, slug string, req *SubmitRequest, ipAddress, userAgent string) (*Form, error) {
+	form, err := s.GetActive(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	if req == nil {
+		return nil, nilRequest()
+	}
+	if req.Website != "" {
+		s.Logger.Info("dropped spam form submission",
+			logger.String("form", form.Slug),
+			logger.String("ip_address", ipAddress))
+		return form, nil
+	}
+
+	data, errs := validateSubmission(form, req.Data)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	var recent int64
+	err = s.DB.WithContext(ctx).Model(&Submission{}).
+		Where("form_id = ? AND ip_address = ? AND created_at > ?", form.Id, ipAddress, time.Now().Add(-submissionWindow)).
+		Count(&recent).Error
+	if err != nil {
+		return nil, err
+	}
+	if recent >= submissionLimit {
+		return nil, ErrTooManySubmissions
+	}
+
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	submission := &Submission{
+		FormId:    form.Id,
+		Data:      data,
+		IpAddress: ipAddress,
+		UserAgent: userAgent,
+	}
+	if err := s.DB.WithContext(ctx).Create(submission).Error; err != nil {
+		s.Logger.Error("failed to store form submission",
+			logger.String("error", err.Error()),
+			logger.Int("form_id", int(form.Id)))
+		return nil, err
+	}
+
+	// Emit submit event
+	s.Emitter.EmitContext(ctx, SubmitFormEvent, submission)
+
+	return form, nil
+}
+
+// GetSubmissions returns a page of the submissions of a form, newest first
+func (s *FormService) GetSubmissions(ctx context.Context, formId uint, page *int, limit *int) (*types.PaginatedResponse, error) {
+	if _, err := s.GetById(ctx, formId); err != nil {
+		return nil, err
+	}
+
+	var items []*Submission
+	var total int64
+
+	query := s.DB.WithContext(ctx).Model(&Submission{}).Where("form_id = ?", formId)
+
+	// Set default values if nil
+	defaultPage := 1
+	defaultLimit := 10
+	if page == nil {
+		page = &defaultPage
+	}
+	if limit == nil {
+		limit = &defaultLimit
+	}
+
+	// Get total count
+	if err := query.Count(&total).Error; err != nil {
+		s.Logger.Error("failed to count form submissions",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	offset := (*page - 1) * *limit
+	if err := query.Order("id DESC").Offset(offset).Limit(*limit).Find(&items).Error; err != nil {
+		s.Logger.Error("failed to get form submissions",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	return paginated(items, total, *page, *limit), nil
+}
+
+// GetSubmission returns a submission of a form
+func (s *FormService) GetSubmission(ctx context.Context, formId, id uint) (*Submission, error) {
+	item := &Submission{}
+	if err := s.DB.WithContext(ctx).Where("id = ? AND form_id = ?", id, formId).First(item).Error; err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// DeleteSubmission deletes a submission of a form
+func (s *FormService) DeleteSubmission(ctx context.Context, formId, id uint) error {
+	item, err := s.GetSubmission(ctx, formId, id)
+	if err != nil {
+		return err
+	}
+	if err := s.DB.WithContext(ctx).Delete(item).Error; err != nil {
+		s.Logger.Error("failed to delete form submission",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return err
+	}
+	return nil
+}
+
+// Export writes all submissions of a form to w as CSV, oldest first, with a column per field
+// of the form. Rows are written as each batch of submissions is loaded, so w can be the
+// response itself.
+func (s *FormService) Export(ctx context.Context, form *Form, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{"id", "submitted_at"}
+	for _, field := range form.Fields {
+		header = append(header, field.Name)
+	}
+	header = append(header, "ip_address")
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	var batch []*Submission
+	err := s.DB.WithContext(ctx).Where("form_id = ?", form.Id).Order("id").FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+		for _, submission := range batch {
+			record := []string{strconv.FormatUint(uint64(submission.Id), 10), submission.CreatedAt.UTC().Format(time.RFC3339)}
+			for _, field := range form.Fields {
+				record = append(record, formatValue(submission.Data[field.Name]))
+			}
+			record = append(record, submission.IpAddress)
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	}).Error
+	if err != nil {
+		s.Logger.Error("failed to export form submissions",
+			logger.String("error", err.Error()),
+			logger.Int("form_id", int(form.Id)))
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// slug returns the slug of a form: the requested one, or one generated from the name
+func (s *FormService) slug(ctx context.Context, requested, name string, id uint) (string, validator.ValidationErrors) {
+	exists := func(slug string) (bool, error) {
+		var count int64
+		err := s.DB.WithContext(ctx).Unscoped().Model(&Form{}).Where("slug = ? AND id <> ?", slug, id).Count(&count).Error
+		return count > 0, err
+	}
+
+	if requested == "" {
+		base := s.slugs.Normalize(name, "", "en")
+		if base == "" {
+			base = "form"
+		}
+		slug, err := s.slugs.GenerateUniqueSlug(base, exists)
+		if err != nil {
+			return "", validator.ValidationErrors{{Field: "slug", Tag: "slug", Value: base, Message: err.Error()}}
+		}
+		return slug, nil
+	}
+
+	if !slugPattern.MatchString(requested) {
+		return requested, validator.ValidationErrors{{
+			Field:   "slug",
+			Tag:     "slug",
+			Value:   requested,
+			Message: "slug must contain only lowercase letters, digits, '.', '_' or '-'",
+		}}
+	}
+	if taken, err := exists(requested); err != nil || taken {
+		return requested, validator.ValidationErrors{{
+			Field:   "slug",
+			Tag:     "unique",
+			Value:   requested,
+			Message: "slug is already taken",
+		}}
+	}
+	return requested, nil
+}
+
+// formatValue formats a submitted value for exports and emails
+func formatValue(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case bool:
+		if value {
+			return "yes"
+		}
+		return "no"
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// paginated builds a paginated response
+func paginated(data any, total int64, page, limit int) *types.PaginatedResponse {
+	// Calculate total pages
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	return &types.PaginatedResponse{
+		Data: data,
+		Pagination: types.Pagination{
+			Total:      int(total),
+			Page:       page,
+			PageSize:   limit,
+			TotalPages: totalPages,
+		},
+	}
+}
@@ -0,0 +1,271 @@
+package forms
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"base/core/validator"
+)
+
+// Global validator instance using Base core validator wrapper
+var validate = validator.New()
+
+// slugPattern matches form slugs such as "contact"
+var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,254}$`)
+
+// fieldNamePattern matches field names such as "email" or "company_size"
+var fieldNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// phonePattern matches phone numbers such as "+49 (30) 123-456"
+var phonePattern = regexp.MustCompile(`^\+?[0-9][0-9 ()./-]{3,31}$`)
+
+// maxTextLength is the longest text value of fields without a max_length
+const maxTextLength = 10000
+
+// ValidateFormCreateRequest validates the create request
+func ValidateFormCreateRequest(req *CreateFormRequest) error {
+	if req == nil {
+		return nilRequest()
+	}
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	if errs := validateFields(req.Fields); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateFormUpdateRequest validates the update request
+func ValidateFormUpdateRequest(req *UpdateFormRequest) error {
+	if req == nil {
+		return nilRequest()
+	}
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	if req.Fields != nil {
+		if errs := validateFields(*req.Fields); len(errs) > 0 {
+			return errs
+		}
+	}
+	return nil
+}
+
+// validateFields checks the rules of form fields beyond their tags
+func validateFields(fields []Field) validator.ValidationErrors {
+	var errs validator.ValidationErrors
+	names := make(map[string]bool, len(fields))
+	for i, field := range fields {
+		key := fmt.Sprintf("fields[%d]", i)
+		if !fieldNamePattern.MatchString(field.Name) {
+			errs = append(errs, validator.ValidationError{
+				Field:   key + ".name",
+				Tag:     "field_name",
+				Value:   field.Name,
+				Message: "name must start with a lowercase letter and contain only lowercase letters, digits or '_'",
+			})
+		} else if names[field.Name] {
+			errs = append(errs, validator.ValidationError{
+				Field:   key + ".name",
+				Tag:     "unique",
+				Value:   field.Name,
+				Message: "name is used by another field",
+			})
+		}
+		names[field.Name] = true
+
+		if field.Type == FieldSelect && len(field.Options) == 0 {
+			errs = append(errs, validator.ValidationError{
+				Field:   key + ".options",
+				Tag:     "required",
+				Message: "options are required for select fields",
+			})
+		}
+		if field.Pattern != "" {
+			if _, err := regexp.Compile(field.Pattern); err != nil {
+				errs = append(errs, validator.ValidationError{
+					Field:   key + ".pattern",
+					Tag:     "regexp",
+					Value:   field.Pattern,
+					Message: "pattern is not a valid regular expression: " + err.Error(),
+				})
+			}
+		}
+		if field.MinLength != nil && field.MaxLength != nil && *field.MinLength > *field.MaxLength {
+			errs = append(errs, validator.ValidationError{
+				Field:   key + ".min_length",
+				Tag:     "ltefield",
+				Value:   strconv.Itoa(*field.MinLength),
+				Message: "min_length must not be greater than max_length",
+			})
+		}
+		if field.Min != nil && field.Max != nil && *field.Min > *field.Max {
+			errs = append(errs, validator.ValidationError{
+				Field:   key + ".min",
+				Tag:     "ltefield",
+				Value:   fmt.Sprint(*field.Min),
+				Message: "min must not be greater than max",
+			})
+		}
+	}
+	return errs
+}
+
+// validateSubmission checks the values of a submission against the fields of the form and
+// returns them cleaned up: text trimmed, numbers as numbers and empty values left out
+func validateSubmission(form *Form, data map[string]any) (map[string]any, validator.ValidationErrors) {
+	var errs validator.ValidationErrors
+	for name := range data {
+		if _, ok := form.Field(name); !ok {
+			errs = append(errs, validator.ValidationError{
+				Field:   name,
+				Tag:     "unknown",
+				Message: name + " is not a field of the form",
+			})
+		}
+	}
+
+	values := make(map[string]any, len(form.Fields))
+	for _, field := range form.Fields {
+		value, err := fieldValue(field, data[field.Name])
+		if err != nil {
+			errs = append(errs, *err)
+			continue
+		}
+		if value == nil {
+			if field.Required {
+				errs = append(errs, validator.ValidationError{
+					Field:   field.Name,
+					Tag:     "required",
+					Message: field.Name + " is required",
+				})
+			}
+			continue
+		}
+		values[field.Name] = value
+	}
+	return values, errs
+}
+
+// fieldValue checks a submitted value of a field; it is nil when the value is empty
+func fieldValue(field Field, raw any) (any, *validator.ValidationError) {
+	invalid := func(tag, param, message string) *validator.ValidationError {
+		return &validator.ValidationError{
+			Field:   field.Name,
+			Tag:     tag,
+			Value:   fmt.Sprint(raw),
+			Param:   param,
+			Message: field.Name + " " + message,
+		}
+	}
+	if raw == nil {
+		return nil, nil
+	}
+
+	switch field.Type {
+	case FieldCheckbox:
+		checked, ok := raw.(bool)
+		if !ok {
+			return nil, invalid("boolean", "", "must be true or false")
+		}
+		if !checked {
+			return nil, nil
+		}
+		return true, nil
+
+	case FieldNumber:
+		var number float64
+		switch value := raw.(type) {
+		case float64:
+			number = value
+		case string:
+			if strings.TrimSpace(value) == "" {
+				return nil, nil
+			}
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				return nil, invalid("numeric", "", "must be a number")
+			}
+			number = parsed
+		default:
+			return nil, invalid("numeric", "", "must be a number")
+		}
+		if field.Min != nil && number < *field.Min {
+			return nil, invalid("gte", formatValue(*field.Min), "must be greater than or equal to "+formatValue(*field.Min))
+		}
+		if field.Max != nil && number > *field.Max {
+			return nil, invalid("lte", formatValue(*field.Max), "must be less than or equal to "+formatValue(*field.Max))
+		}
+		return number, nil
+	}
+
+	text, ok := raw.(string)
+	if !ok {
+		return nil, invalid("string", "", "must be text")
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, nil
+	}
+
+	maxLength := maxTextLength
+	if field.MaxLength != nil {
+		maxLength = *field.MaxLength
+	}
+	length := utf8.RuneCountInString(text)
+	if length > maxLength {
+		return nil, invalid("max", strconv.Itoa(maxLength), fmt.Sprintf("must be at most %d characters long", maxLength))
+	}
+	if field.MinLength != nil && length < *field.MinLength {
+		return nil, invalid("min", strconv.Itoa(*field.MinLength), fmt.Sprintf("must be at least %d characters long", *field.MinLength))
+	}
+
+	switch field.Type {
+	case FieldEmail:
+		if address, err := mail.ParseAddress(text); err != nil || address.Address != text {
+			return nil, invalid("email", "", "must be a valid email address")
+		}
+	case FieldPhone:
+		if !phonePattern.MatchString(text) {
+			return nil, invalid("phone", "", "must be a valid phone number")
+		}
+	case FieldUrl:
+		if parsed, err := url.Parse(text); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, invalid("url", "", "must be a valid URL")
+		}
+	case FieldDate:
+		if _, err := time.Parse(time.DateOnly, text); err != nil {
+			return nil, invalid("date", "", "must be a date such as 2024-12-31")
+		}
+	case FieldSelect:
+		if !slices.Contains(field.Options, text) {
+			return nil, invalid("oneof", strings.Join(field.Options, " "), "must be one of: "+strings.Join(field.Options, " "))
+		}
+	}
+	if field.Pattern != "" {
+		if pattern, err := regexp.Compile(field.Pattern); err == nil && !pattern.MatchString(text) {
+			return nil, invalid("pattern", "", "is invalid")
+		}
+	}
+	return text, nil
+}
+
+// nilRequest is the error of a missing request
+func nilRequest() error {
+	return validator.ValidationErrors{
+		{
+			Field:   "request",
+			Tag:     "required",
+			Value:   "nil",
+			Message: "request cannot be nil",
+		},
+	}
+}
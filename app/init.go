@@ -1,6 +1,19 @@
 package app
 
 import (
+	"base/app/cart"
+	"base/app/currencies"
+	"base/app/discounts"
+	"base/app/forms"
+	"base/app/invoices"
+	"base/app/menus"
+	"base/app/orders"
+	"base/app/pages"
+	"base/app/payments"
+	"base/app/products"
+	"base/app/seo"
+	"base/app/shipping"
+	"base/app/taxes"
 	"base/core/app/search"
 	"base/core/app/users"
 	"base/core/database"
@@ -21,8 +34,20 @@ func (am *AppModules) GetAppModules(deps module.Dependencies) map[string]module.
 
 	// Add your custom business logic modules here
 	// Example:
-	// modules["products"] = products.Init(deps)
-	// modules["orders"] = orders.Init(deps)
+	// modules["orders"] = orders.Init(deps.ForModule("orders"))
+	modules["products"] = products.Init(deps.ForModule("products"))
+	modules["payments"] = payments.Init(deps.ForModule("payments"))
+	modules["invoices"] = invoices.Init(deps.ForModule("invoices"))
+	modules["discounts"] = discounts.Init(deps.ForModule("discounts"))
+	modules["taxes"] = taxes.Init(deps.ForModule("taxes"))
+	modules["currencies"] = currencies.Init(deps.ForModule("currencies"))
+	modules["orders"] = orders.Init(deps.ForModule("orders"))
+	modules["shipping"] = shipping.Init(deps.ForModule("shipping"))
+	modules["cart"] = cart.Init(deps.ForModule("cart"))
+	modules["pages"] = pages.Init(deps.ForModule("pages"))
+	modules["menus"] = menus.Init(deps.ForModule("menus"))
+	modules["seo"] = seo.Init(deps.ForModule("seo"))
+	modules["forms"] = forms.Init(deps.ForModule("forms"))
 
 	return modules
 }
@@ -0,0 +1,210 @@
+package invoices
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"base/core/router"
+	"base/core/translation"
+	"base/core/types"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+type InvoiceController struct {
+	Service *InvoiceService
+}
+
+func NewInvoiceController(service *InvoiceService) *InvoiceController {
+	return &InvoiceController{
+		Service: service,
+	}
+}
+
+// Routes registers the invoice endpoints; the group is restricted to admins by the module.
+// Invoices can't be updated or deleted, that would leave gaps in the numbering.
+func (c *InvoiceController) Routes(router *router.RouterGroup) {
+	router.GET("/invoices", c.List)             // Paginated list
+	router.POST("/invoices", c.Create)          // Issue an invoice
+	router.GET("/invoices/:id", c.Get)          // Get by ID
+	router.GET("/invoices/:id/pdf", c.Download) // Download the PDF
+	router.POST("/invoices/:id/send", c.Send)   // Email to the customer
+}
+
+// CreateInvoice godoc
+// @Summary Issue an invoice
+// @Description Issue an invoice with the next number of the year and the company settings; the PDF is stored and, with send, emailed to the customer. Amounts are in cents and tax rates in percent (Admin only)
+// @Tags App/Invoice
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param invoice body CreateInvoiceRequest true "Create invoice request"
+// @Success 201 {object} Invoice
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /invoices [post]
+func (c *InvoiceController) Create(ctx *router.Context) error {
+	var req CreateInvoiceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	invoice, err := c.Service.Create(ctx.Request.Context(), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to create invoice")
+	}
+	return ctx.JSON(http.StatusCreated, invoice)
+}
+
+// GetInvoice godoc
+// @Summary Get an invoice
+// @Description Get an invoice with its lines and stored PDF (Admin only)
+// @Tags App/Invoice
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Invoice id"
+// @Success 200 {object} Invoice
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /invoices/{id} [get]
+func (c *InvoiceController) Get(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	invoice, err := c.Service.GetById(ctx.Request.Context(), uint(id))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to get invoice")
+	}
+	return ctx.JSON(http.StatusOK, invoice)
+}
+
+// ListInvoices godoc
+// @Summary List invoices
+// @Description Get a page of invoices, newest first (Admin only)
+// @Tags App/Invoice
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of items per page"
+// @Param year query int false "Only invoices of the year"
+// @Param order_id query int false "Only invoices of the order"
+// @Param user_id query int false "Only invoices of the user"
+// @Param q query string false "Search the number, customer name and email"
+// @Success 200 {object} types.PaginatedResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /invoices [get]
+func (c *InvoiceController) List(ctx *router.Context) error {
+	params, err := ctx.ListParams()
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	filter := InvoiceFilter{Query: ctx.Query("q")}
+	for name, target := range map[string]*uint{"order_id": &filter.OrderId, "user_id": &filter.UserId} {
+		if value := ctx.Query(name); value != "" {
+			id, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid " + name})
+			}
+			*target = uint(id)
+		}
+	}
+	if year := ctx.Query("year"); year != "" {
+		value, err := strconv.Atoi(year)
+		if err != nil {
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid year"})
+		}
+		filter.Year = value
+	}
+
+	paginatedResponse, err := c.Service.GetAll(ctx.Request.Context(), params.Page, params.Limit, filter)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch invoices: " + err.Error()})
+	}
+	return ctx.JSON(http.StatusOK, paginatedResponse)
+}
+
+// DownloadInvoice godoc
+// @Summary Download an invoice
+// @Description Render the PDF of an invoice (Admin only)
+// @Tags App/Invoice
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce application/pdf
+// @Param id path int true "Invoice id"
+// @Success 200 {file} file
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /invoices/{id}/pdf [get]
+func (c *InvoiceController) Download(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	invoice, err := c.Service.GetById(ctx.Request.Context(), uint(id))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to get invoice")
+	}
+	data, err := c.Service.Render(invoice)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to render invoice")
+	}
+
+	ctx.SetHeader("Content-Disposition", `attachment; filename="`+invoice.Number+`.pdf"`)
+	return ctx.Data(http.StatusOK, "application/pdf", data)
+}
+
+// SendInvoice godoc
+// @Summary Send an invoice
+// @Description Email an invoice to its customer with the PDF attached (Admin only)
+// @Tags App/Invoice
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Invoice id"
+// @Success 200 {object} Invoice
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Failure 503 {object} types.ErrorResponse
+// @Router /invoices/{id}/send [post]
+func (c *InvoiceController) Send(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	invoice, err := c.Service.Send(ctx.Request.Context(), uint(id))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to send invoice")
+	}
+	return ctx.JSON(http.StatusOK, invoice)
+}
+
+func (c *InvoiceController) fail(ctx *router.Context, err error, message string) error {
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   translation.Error(ctx, err),
+			Details: translation.LocalizeValidation(ctx, validationErrors),
+		})
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Item not found"})
+	}
+	if errors.Is(err, ErrNoCustomerEmail) {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: translation.Error(ctx, err)})
+	}
+	if errors.Is(err, ErrNoEmailSender) {
+		return ctx.JSON(http.StatusServiceUnavailable, types.ErrorResponse{Error: err.Error()})
+	}
+	return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: message + ": " + err.Error()})
+}
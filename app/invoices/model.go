@@ -0,0 +1,149 @@
+package invoices
+
+import (
+	"time"
+
+	"base/core/storage"
+	"base/core/types"
+)
+
+// Invoice is an issued invoice. Invoices are numbered per year without gaps and can't be
+// changed or deleted once issued; they keep a copy of the company and customer details
+// they were issued with. Amounts are in the minor unit of the currency (cents).
+type Invoice struct {
+	Id                uint                `json:"id" gorm:"primarykey"`
+	CreatedAt         time.Time           `json:"created_at"`
+	UpdatedAt         time.Time           `json:"updated_at"`
+	Number            string              `json:"number" gorm:"size:32;uniqueIndex"`
+	Year              int                 `json:"year" gorm:"uniqueIndex:idx_invoices_year_sequence"`
+	Sequence          int                 `json:"sequence" gorm:"uniqueIndex:idx_invoices_year_sequence"`
+	OrderId           *uint               `json:"order_id" gorm:"index"`
+	UserId            *uint               `json:"user_id" gorm:"index"`
+	CustomerName      string              `json:"customer_name" gorm:"size:255"`
+	CustomerEmail     string              `json:"customer_email" gorm:"size:255"`
+	CustomerAddress   string              `json:"customer_address" gorm:"type:text"`
+	CustomerTaxNumber string              `json:"customer_tax_number" gorm:"size:64"`
+	CompanyName       string              `json:"company_name" gorm:"size:255"`
+	CompanyAddress    string              `json:"company_address" gorm:"type:text"`
+	CompanyTaxNumber  string              `json:"company_tax_number" gorm:"size:64"`
+	CompanyEmail      string              `json:"company_email" gorm:"size:255"`
+	CompanyPhone      string              `json:"company_phone" gorm:"size:64"`
+	Currency          string              `json:"currency" gorm:"size:3"`
+	Subtotal          types.Money         `json:"subtotal"`
+	TaxTotal          types.Money         `json:"tax_total"`
+	Total             types.Money         `json:"total"`
+	Notes             string              `json:"notes" gorm:"type:text"`
+	IssuedAt          time.Time           `json:"issued_at"`
+	DueAt             *time.Time          `json:"due_at"`
+	EmailedAt         *time.Time          `json:"emailed_at"`
+	Lines             []*InvoiceLine      `json:"lines,omitempty" gorm:"foreignKey:InvoiceId"`
+	Pdf               *storage.Attachment `json:"pdf,omitempty" gorm:"-"`
+}
+
+// TableName returns the table name for the Invoice model
+func (m *Invoice) TableName() string {
+	return "invoices"
+}
+
+// GetId returns the Id of the model
+func (m *Invoice) GetId() uint {
+	return m.Id
+}
+
+// GetModelName returns the model name
+func (m *Invoice) GetModelName() string {
+	return "invoices"
+}
+
+// InvoiceLine is a line of an invoice. TaxRate is a percentage, e.g. 20 or 8.5.
+type InvoiceLine struct {
+	Id          uint        `json:"id" gorm:"primarykey"`
+	InvoiceId   uint        `json:"invoice_id" gorm:"index;not null"`
+	Position    int         `json:"position"`
+	Description string      `json:"description" gorm:"size:500"`
+	Quantity    int         `json:"quantity"`
+	UnitPrice   types.Money `json:"unit_price"`
+	TaxRate     float64     `json:"tax_rate"`
+	Subtotal    types.Money `json:"subtotal"`
+	Tax         types.Money `json:"tax"`
+	Total       types.Money `json:"total"`
+}
+
+// TableName returns the table name for the InvoiceLine model
+func (m *InvoiceLine) TableName() string {
+	return "invoice_lines"
+}
+
+// InvoiceSequence holds the last invoice number of a year
+type InvoiceSequence struct {
+	Year       int `gorm:"primaryKey;autoIncrement:false"`
+	LastNumber int
+}
+
+// TableName returns the table name for the InvoiceSequence model
+func (m *InvoiceSequence) TableName() string {
+	return "invoice_sequences"
+}
+
+// LineRequest is a line of a new invoice
+type LineRequest struct {
+	Description string      `json:"description" validate:"required,max=500"`
+	Quantity    int         `json:"quantity" validate:"gte=1"`
+	UnitPrice   types.Money `json:"unit_price" validate:"gte=0"`
+	TaxRate     float64     `json:"tax_rate" validate:"gte=0,lte=100"`
+}
+
+// CreateInvoiceRequest issues an invoice
+type CreateInvoiceRequest struct {
+	OrderId           *uint         `json:"order_id,omitempty"`
+	UserId            *uint         `json:"user_id,omitempty"`
+	CustomerName      string        `json:"customer_name" validate:"required,max=255"`
+	CustomerEmail     string        `json:"customer_email" validate:"omitempty,email"`
+	CustomerAddress   string        `json:"customer_address,omitempty"`
+	CustomerTaxNumber string        `json:"customer_tax_number,omitempty" validate:"max=64"`
+	Currency          string        `json:"currency,omitempty" validate:"omitempty,iso4217"` // Defaults to PAYMENTS_CURRENCY
+	Notes             string        `json:"notes,omitempty"`
+	DueDays           int           `json:"due_days,omitempty" validate:"gte=0,lte=365"` // Due date in days after the issue date, none when 0
+	Lines             []LineRequest `json:"lines" validate:"required,min=1,dive"`
+	Send              bool          `json:"send,omitempty"` // Email the invoice to the customer once issued
+}
+
+// InvoiceFilter narrows invoice lists
+type InvoiceFilter struct {
+	Year    int
+	OrderId uint
+	UserId  uint
+	Query   string
+}
+
+// InvoiceListResponse represents an invoice in lists
+type InvoiceListResponse struct {
+	Id            uint        `json:"id"`
+	Number        string      `json:"number"`
+	OrderId       *uint       `json:"order_id"`
+	UserId        *uint       `json:"user_id"`
+	CustomerName  string      `json:"customer_name"`
+	CustomerEmail string      `json:"customer_email"`
+	Currency      string      `json:"currency"`
+	Total         types.Money `json:"total"`
+	IssuedAt      time.Time   `json:"issued_at"`
+	DueAt         *time.Time  `json:"due_at"`
+	EmailedAt     *time.Time  `json:"emailed_at"`
+}
+
+// ToListResponse converts the model to a list item
+func (m *Invoice) ToListResponse() *InvoiceListResponse {
+	return &InvoiceListResponse{
+		Id:            m.Id,
+		Number:        m.Number,
+		OrderId:       m.OrderId,
+		UserId:        m.UserId,
+		CustomerName:  m.CustomerName,
+		CustomerEmail: m.CustomerEmail,
+		Currency:      m.Currency,
+		Total:         m.Total,
+		IssuedAt:      m.IssuedAt,
+		DueAt:         m.DueAt,
+		EmailedAt:     m.EmailedAt,
+	}
+}
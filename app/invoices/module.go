@@ -0,0 +1,135 @@
+package invoices
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"base/core/app/authorization"
+	"base/core/app/reports"
+	"base/core/logger"
+	"base/core/module"
+	"base/core/router"
+
+	"gorm.io/gorm"
+)
+
+// Module issues numbered invoices, renders them as PDFs and emails them to customers
+type Module struct {
+	module.DefaultModule
+	DB         *gorm.DB
+	Service    *InvoiceService
+	Controller *InvoiceController
+}
+
+// Init creates and initializes the invoices module with all dependencies
+func Init(deps module.Dependencies) module.Module {
+	registerEvents()
+	service := NewInvoiceService(deps.DB, deps.Emitter, deps.Storage, deps.Logger, deps.PDF, deps.EmailSender)
+	if deps.Config != nil {
+		service.From = deps.Config.EmailFromAddress
+		service.Currency = deps.Config.PaymentsCurrency
+	}
+
+	// A template file in PDF_TEMPLATES_PATH replaces the default one
+	if deps.PDF != nil {
+		if _, err := os.Stat(filepath.Join(deps.PDF.TemplatesPath, TemplateName)); err != nil {
+			if err := deps.PDF.RegisterTemplate(TemplateName, DefaultTemplate); err != nil {
+				deps.Logger.Error("failed to register invoice template", logger.String("error", err.Error()))
+			}
+		}
+	}
+
+	reports.RegisterEntity(reports.Entity{
+		Name:    "invoices",
+		Columns: []string{"id", "number", "year", "order_id", "user_id", "customer_name", "customer_email", "currency", "subtotal", "tax_total", "total", "issued_at", "due_at", "emailed_at"},
+	})
+
+	return &Module{
+		DB:         deps.DB,
+		Service:    service,
+		Controller: NewInvoiceController(service),
+	}
+}
+
+// Routes registers the admin routes
+func (m *Module) Routes(router *router.RouterGroup) {
+	group := router.Group("", authorization.RequireAdmin(authorization.NewAuthorizationService(m.DB)))
+	m.Controller.Routes(group)
+}
+
+func (m *Module) Init() error {
+	return m.SeedPermissions()
+}
+
+func (m *Module) SeedPermissions() error {
+	// Ensure permissions table exists before seeding
+	if err := m.DB.AutoMigrate(&authorization.Permission{}); err != nil {
+		return err
+	}
+
+	// Define permissions for invoice operations
+	permissions := []authorization.Permission{
+		{
+			Name:         "invoice list",
+			Description:  "View invoice list",
+			ResourceType: "invoice",
+			Action:       "list",
+		},
+		{
+			Name:         "invoice read",
+			Description:  "View and download invoices",
+			ResourceType: "invoice",
+			Action:       "read",
+		},
+		{
+			Name:         "invoice create",
+			Description:  "Issue invoices",
+			ResourceType: "invoice",
+			Action:       "create",
+		},
+		{
+			Name:         "invoice send",
+			Description:  "Email invoices to customers",
+			ResourceType: "invoice",
+			Action:       "send",
+		},
+	}
+
+	// Upsert permissions - create or update if they exist
+	for _, permission := range permissions {
+		var existingPermission authorization.Permission
+		result := m.DB.Where("resource_type = ? AND action = ?", permission.ResourceType, permission.Action).First(&existingPermission)
+
+		if result.Error != nil && errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// Create new permission
+			if err := m.DB.Create(&permission).Error; err != nil {
+				return err
+			}
+		} else if result.Error == nil {
+			// Update existing permission
+			existingPermission.Name = permission.Name
+			existingPermission.Description = permission.Description
+			if err := m.DB.Save(&existingPermission).Error; err != nil {
+				return err
+			}
+		} else {
+			// Return any other error
+			return result.Error
+		}
+	}
+
+	return nil
+}
+
+func (m *Module) Migrate() error {
+	return m.DB.AutoMigrate(&Invoice{}, &InvoiceLine{}, &InvoiceSequence{})
+}
+
+func (m *Module) GetModels() []any {
+	return []any{
+		&Invoice{},
+		&InvoiceLine{},
+		&InvoiceSequence{},
+	}
+}
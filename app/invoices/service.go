@@ -0,0 +1,389 @@
+package invoices
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"base/core/app/settings"
+	"base/core/email"
+	"base/core/emitter"
+	"base/core/logger"
+	"base/core/pdf"
+	"base/core/storage"
+	"base/core/translation"
+	"base/core/types"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	CreateInvoiceEvent = "invoices.create"
+	SendInvoiceEvent   = "invoices.send"
+)
+
+// registerEvents adds the events of the module to the event catalog
+func registerEvents() {
+	emitter.RegisterEvents(
+		emitter.EventType{Name: CreateInvoiceEvent, Description: "An invoice was created", Payload: &Invoice{}},
+		emitter.EventType{Name: SendInvoiceEvent, Description: "An invoice was emailed to its customer", Payload: &Invoice{}},
+	)
+}
+
+// NumberPrefix starts every invoice number, e.g. INV-2025-00042
+const NumberPrefix = "INV-"
+
+// MaxPdfSize is the largest invoice PDF that is stored
+const MaxPdfSize = 20 << 20 // 20MB
+
+// ErrNoCustomerEmail is returned when emailing an invoice without a customer email
+var ErrNoCustomerEmail = errors.New("invoice has no customer email")
+
+// ErrNoEmailSender is returned when emailing an invoice without an email sender
+var ErrNoEmailSender = errors.New("email sender is not configured")
+
+// Company settings copied onto invoices
+var companySettings = []string{"company_name", "company_address", "company_nui", "company_email", "company_phone"}
+
+type InvoiceService struct {
+	DB          *gorm.DB
+	Emitter     *emitter.Emitter
+	Storage     *storage.ActiveStorage
+	Logger      logger.Logger
+	PDF         *pdf.Service
+	EmailSender email.Sender
+	From        string // Sender address of invoice emails
+	Currency    string // Currency of invoices created without one
+}
+
+func NewInvoiceService(db *gorm.DB, emitter *emitter.Emitter, activeStorage *storage.ActiveStorage, logger logger.Logger, pdfService *pdf.Service, sender email.Sender) *InvoiceService {
+	if activeStorage != nil {
+		activeStorage.RegisterAttachment("invoices", storage.AttachmentConfig{
+			Field:             "pdf",
+			Path:              "invoices",
+			AllowedExtensions: []string{".pdf"},
+			MaxFileSize:       MaxPdfSize,
+		})
+	}
+
+	return &InvoiceService{
+		DB:          db,
+		Emitter:     emitter,
+		Storage:     activeStorage,
+		Logger:      logger,
+		PDF:         pdfService,
+		EmailSender: sender,
+	}
+}
+
+// Create issues an invoice: it takes the next number of the year, copies the company
+// settings, stores the PDF and emails it when req.Send is set. Failing to store or email
+// the PDF doesn't undo the invoice, the PDF can be rendered and sent again.
+func (s *InvoiceService) Create(ctx context.Context, req *CreateInvoiceRequest) (*Invoice, error) {
+	if err := ValidateInvoiceCreateRequest(req); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	invoice := &Invoice{
+		OrderId:           req.OrderId,
+		UserId:            req.UserId,
+		CustomerName:      req.CustomerName,
+		CustomerEmail:     req.CustomerEmail,
+		CustomerAddress:   req.CustomerAddress,
+		CustomerTaxNumber: req.CustomerTaxNumber,
+		Currency:          req.Currency,
+		Notes:             req.Notes,
+		IssuedAt:          now,
+		Year:              now.In(translation.GetDateDefaults().Timezone).Year(),
+	}
+	if invoice.Currency == "" {
+		invoice.Currency = s.Currency
+	}
+	if req.DueDays > 0 {
+		due := now.AddDate(0, 0, req.DueDays)
+		invoice.DueAt = &due
+	}
+	if err := s.applyCompany(ctx, invoice); err != nil {
+		return nil, err
+	}
+	for i, line := range req.Lines {
+		invoice.Lines = append(invoice.Lines, newLine(i, line))
+	}
+	invoice.computeTotals()
+
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		sequence, err := nextSequence(tx, invoice.Year)
+		if err != nil {
+			return err
+		}
+		invoice.Sequence = sequence
+		invoice.Number = fmt.Sprintf("%s%d-%05d", NumberPrefix, invoice.Year, sequence)
+		return tx.Create(invoice).Error
+	})
+	if err != nil {
+		s.Logger.Error("failed to create invoice", logger.String("error", err.Error()))
+		return nil, err
+	}
+	s.Emitter.EmitContext(ctx, CreateInvoiceEvent, invoice)
+
+	data, err := s.Render(invoice)
+	if err != nil {
+		s.Logger.Error("failed to render invoice",
+			logger.String("error", err.Error()),
+			logger.String("number", invoice.Number))
+		return invoice, nil
+	}
+	if err := s.store(ctx, invoice, data); err != nil {
+		s.Logger.Error("failed to store invoice PDF",
+			logger.String("error", err.Error()),
+			logger.String("number", invoice.Number))
+	}
+	if req.Send && invoice.CustomerEmail != "" {
+		if err := s.send(ctx, invoice, data); err != nil {
+			s.Logger.Error("failed to email invoice",
+				logger.String("error", err.Error()),
+				logger.String("number", invoice.Number))
+		}
+	}
+	return invoice, nil
+}
+
+// GetById returns an invoice with its lines and stored PDF
+func (s *InvoiceService) GetById(ctx context.Context, id uint) (*Invoice, error) {
+	invoice := &Invoice{}
+	if err := s.DB.WithContext(ctx).Preload("Lines", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	}).First(invoice, id).Error; err != nil {
+		return nil, err
+	}
+	if s.Storage != nil {
+		if attachment, err := s.Storage.LoadAttachment(ctx, invoice, "pdf"); err == nil {
+			invoice.Pdf = attachment
+		}
+	}
+	return invoice, nil
+}
+
+// GetAll returns a page of invoices, newest first
+func (s *InvoiceService) GetAll(ctx context.Context, page *int, limit *int, filter InvoiceFilter) (*types.PaginatedResponse, error) {
+	var items []*Invoice
+	var total int64
+
+	query := s.DB.WithContext(ctx).Model(&Invoice{})
+	if filter.Year != 0 {
+		query = query.Where("year = ?", filter.Year)
+	}
+	if filter.OrderId != 0 {
+		query = query.Where("order_id = ?", filter.OrderId)
+	}
+	if filter.UserId != 0 {
+		query = query.Where("user_id = ?", filter.UserId)
+	}
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		like := "%" + q + "%"
+		query = query.Where("number LIKE ? OR customer_name LIKE ? OR customer_email LIKE ?", like, like, like)
+	}
+
+	// Set default values if nil
+	defaultPage := 1
+	defaultLimit := 10
+	if page == nil {
+		page = &defaultPage
+	}
+	if limit == nil {
+		limit = &defaultLimit
+	}
+
+	// Get total count
+	if err := query.Count(&total).Error; err != nil {
+		s.Logger.Error("failed to count invoices",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	offset := (*page - 1) * *limit
+	if err := query.Order("year DESC, sequence DESC").Offset(offset).Limit(*limit).Find(&items).Error; err != nil {
+		s.Logger.Error("failed to get invoices",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	responses := make([]*InvoiceListResponse, len(items))
+	for i, item := range items {
+		responses[i] = item.ToListResponse()
+	}
+
+	// Calculate total pages
+	totalPages := int(math.Ceil(float64(total) / float64(*limit)))
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	return &types.PaginatedResponse{
+		Data: responses,
+		Pagination: types.Pagination{
+			Total:      int(total),
+			Page:       *page,
+			PageSize:   *limit,
+			TotalPages: totalPages,
+		},
+	}, nil
+}
+
+// Render renders the PDF of an invoice with the invoice template
+func (s *InvoiceService) Render(invoice *Invoice) ([]byte, error) {
+	defaults := translation.GetDateDefaults()
+	return s.PDF.RenderTemplate(TemplateName, NewDocument(invoice, defaults.Timezone, defaults.DateLayout))
+}
+
+// Send emails an invoice to its customer with the PDF attached
+func (s *InvoiceService) Send(ctx context.Context, id uint) (*Invoice, error) {
+	invoice, err := s.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.CustomerEmail == "" {
+		return nil, ErrNoCustomerEmail
+	}
+
+	data, err := s.Render(invoice)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.send(ctx, invoice, data); err != nil {
+		s.Logger.Error("failed to email invoice",
+			logger.String("error", err.Error()),
+			logger.String("number", invoice.Number))
+		return nil, err
+	}
+	return invoice, nil
+}
+
+// send emails the rendered PDF and records when it was sent
+func (s *InvoiceService) send(ctx context.Context, invoice *Invoice, data []byte) error {
+	if s.EmailSender == nil {
+		return ErrNoEmailSender
+	}
+
+	body := fmt.Sprintf("Dear %s,\n\nPlease find attached invoice %s of %s %s.", invoice.CustomerName, invoice.Number, invoice.Total.Format(invoice.Currency), invoice.Currency)
+	if invoice.DueAt != nil {
+		defaults := translation.GetDateDefaults()
+		body += fmt.Sprintf(" It is due on %s.", invoice.DueAt.In(defaults.Timezone).Format(defaults.DateLayout))
+	}
+	body += "\n\n" + invoice.CompanyName
+
+	err := s.EmailSender.Send(email.Message{
+		To:      []string{invoice.CustomerEmail},
+		From:    s.From,
+		Subject: fmt.Sprintf("Invoice %s from %s", invoice.Number, invoice.CompanyName),
+		Body:    body,
+		Attachments: []email.Attachment{{
+			Filename:    invoice.Number + ".pdf",
+			ContentType: "application/pdf",
+			Data:        data,
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	invoice.EmailedAt = &now
+	if err := s.DB.WithContext(ctx).Model(invoice).Update("emailed_at", now).Error; err != nil {
+		return err
+	}
+	s.Emitter.EmitContext(ctx, SendInvoiceEvent, invoice)
+	return nil
+}
+
+// store saves the PDF of an invoice with ActiveStorage. The file name has a random part
+// since storage URLs are public.
+func (s *InvoiceService) store(ctx context.Context, invoice *Invoice, data []byte) error {
+	if s.Storage == nil {
+		return nil
+	}
+	token := make([]byte, 8)
+	if _, err := rand.Read(token); err != nil {
+		return err
+	}
+	attachment, err := s.Storage.AttachBytes(ctx, invoice, "pdf", data, invoice.Number+"-"+hex.EncodeToString(token)+".pdf")
+	if err != nil {
+		return err
+	}
+	invoice.Pdf = attachment
+	return nil
+}
+
+// applyCompany copies the company settings onto an invoice
+func (s *InvoiceService) applyCompany(ctx context.Context, invoice *Invoice) error {
+	var items []settings.Settings
+	if err := s.DB.WithContext(ctx).Where("setting_key IN ?", companySettings).Find(&items).Error; err != nil {
+		return err
+	}
+	for _, item := range items {
+		switch item.SettingKey {
+		case "company_name":
+			invoice.CompanyName = item.ValueString
+		case "company_address":
+			invoice.CompanyAddress = item.ValueString
+		case "company_nui":
+			invoice.CompanyTaxNumber = item.ValueString
+		case "company_email":
+			invoice.CompanyEmail = item.ValueString
+		case "company_phone":
+			invoice.CompanyPhone = item.ValueString
+		}
+	}
+	return nil
+}
+
+// nextSequence takes the next number of a year. The counter row is locked by the update
+// until the transaction ends and a rolled back invoice gives its number back, so numbers
+// have no gaps.
+func nextSequence(tx *gorm.DB, year int) (int, error) {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&InvoiceSequence{Year: year}).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Model(&InvoiceSequence{}).Where("year = ?", year).
+		Update("last_number", gorm.Expr("last_number + 1")).Error; err != nil {
+		return 0, err
+	}
+	sequence := &InvoiceSequence{}
+	if err := tx.First(sequence, "year = ?", year).Error; err != nil {
+		return 0, err
+	}
+	return sequence.LastNumber, nil
+}
+
+// newLine computes the amounts of an invoice line
+func newLine(position int, req LineRequest) *InvoiceLine {
+	subtotal := req.UnitPrice.Times(req.Quantity)
+	tax := subtotal.Percent(req.TaxRate)
+	return &InvoiceLine{
+		Position:    position,
+		Description: req.Description,
+		Quantity:    req.Quantity,
+		UnitPrice:   req.UnitPrice,
+		TaxRate:     req.TaxRate,
+		Subtotal:    subtotal,
+		Tax:         tax,
+		Total:       subtotal + tax,
+	}
+}
+
+// computeTotals sums the lines of an invoice
+func (m *Invoice) computeTotals() {
+	m.Subtotal, m.TaxTotal, m.Total = 0, 0, 0
+	for _, line := range m.Lines {
+		m.Subtotal += line.Subtotal
+		m.TaxTotal += line.Tax
+		m.Total += line.Total
+	}
+}
@@ -0,0 +1,124 @@
+package invoices
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TemplateName is the PDF template of invoices. A file of that name in
+// PDF_TEMPLATES_PATH replaces DefaultTemplate.
+const TemplateName = "invoice.html"
+
+// DefaultTemplate renders an invoice with the PDF HTML subset; it gets a Document
+const DefaultTemplate = `<h1>Invoice {{.Number}}</h1>
+<table>
+<tr>
+<td><b>{{.Company.Name}}</b><br>{{range .Company.Address}}{{.}}<br>{{end}}{{if .Company.TaxNumber}}Tax number: {{.Company.TaxNumber}}<br>{{end}}{{.Company.Email}}{{if .Company.Phone}}<br>{{.Company.Phone}}{{end}}</td>
+<td align="right">Invoice date: {{.IssuedAt}}{{if .DueAt}}<br>Due date: {{.DueAt}}{{end}}{{if .OrderId}}<br>Order: #{{.OrderId}}{{end}}</td>
+</tr>
+</table>
+<h3>Bill to</h3>
+<p><b>{{.Customer.Name}}</b><br>{{range .Customer.Address}}{{.}}<br>{{end}}{{if .Customer.TaxNumber}}Tax number: {{.Customer.TaxNumber}}<br>{{end}}{{.Customer.Email}}</p>
+<table>
+<thead><tr><th>Description</th><th align="right">Quantity</th><th align="right">Unit price</th><th align="right">Tax</th><th align="right">Amount</th></tr></thead>
+{{range .Lines}}<tr><td>{{.Description}}</td><td align="right">{{.Quantity}}</td><td align="right">{{.UnitPrice}}</td><td align="right">{{.TaxRate}}</td><td align="right">{{.Subtotal}}</td></tr>
+{{end}}</table>
+<p align="right">Subtotal: {{.Subtotal}} {{.Currency}}<br>Tax: {{.TaxTotal}} {{.Currency}}<br><b>Total: {{.Total}} {{.Currency}}</b></p>
+{{if .Notes}}<hr><p>{{.Notes}}</p>{{end}}
+`
+
+// Party is the company or the customer of an invoice, as shown on the document
+type Party struct {
+	Name      string
+	Address   []string // One entry per line
+	TaxNumber string
+	Email     string
+	Phone     string
+}
+
+// DocumentLine is an invoice line with formatted amounts
+type DocumentLine struct {
+	Description string
+	Quantity    int
+	UnitPrice   string
+	TaxRate     string
+	Subtotal    string
+	Tax         string
+	Total       string
+}
+
+// Document is the data of the invoice template: the invoice with formatted dates and
+// amounts
+type Document struct {
+	Invoice  *Invoice
+	Number   string
+	IssuedAt string
+	DueAt    string
+	OrderId  uint
+	Company  Party
+	Customer Party
+	Currency string
+	Lines    []DocumentLine
+	Subtotal string
+	TaxTotal string
+	Total    string
+	Notes    string
+}
+
+// NewDocument prepares an invoice for its template; dates use the date layout and
+// timezone settings
+func NewDocument(invoice *Invoice, location *time.Location, dateLayout string) *Document {
+	doc := &Document{
+		Invoice:  invoice,
+		Number:   invoice.Number,
+		IssuedAt: invoice.IssuedAt.In(location).Format(dateLayout),
+		Company: Party{
+			Name:      invoice.CompanyName,
+			Address:   lines(invoice.CompanyAddress),
+			TaxNumber: invoice.CompanyTaxNumber,
+			Email:     invoice.CompanyEmail,
+			Phone:     invoice.CompanyPhone,
+		},
+		Customer: Party{
+			Name:      invoice.CustomerName,
+			Address:   lines(invoice.CustomerAddress),
+			TaxNumber: invoice.CustomerTaxNumber,
+			Email:     invoice.CustomerEmail,
+		},
+		Currency: invoice.Currency,
+		Subtotal: invoice.Subtotal.Format(invoice.Currency),
+		TaxTotal: invoice.TaxTotal.Format(invoice.Currency),
+		Total:    invoice.Total.Format(invoice.Currency),
+		Notes:    invoice.Notes,
+	}
+	if invoice.DueAt != nil {
+		doc.DueAt = invoice.DueAt.In(location).Format(dateLayout)
+	}
+	if invoice.OrderId != nil {
+		doc.OrderId = *invoice.OrderId
+	}
+	for _, line := range invoice.Lines {
+		doc.Lines = append(doc.Lines, DocumentLine{
+			Description: line.Description,
+			Quantity:    line.Quantity,
+			UnitPrice:   line.UnitPrice.Format(invoice.Currency),
+			TaxRate:     strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", line.TaxRate), "0"), ".") + "%",
+			Subtotal:    line.Subtotal.Format(invoice.Currency),
+			Tax:         line.Tax.Format(invoice.Currency),
+			Total:       line.Total.Format(invoice.Currency),
+		})
+	}
+	return doc
+}
+
+// lines splits a multi-line address, leaving out empty lines
+func lines(text string) []string {
+	var result []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
@@ -0,0 +1,39 @@
+package invoices
+
+import (
+	"strings"
+
+	"base/core/validator"
+)
+
+// Global validator instance using Base core validator wrapper
+var validate = validator.New()
+
+// ValidateInvoiceCreateRequest validates the create request
+func ValidateInvoiceCreateRequest(req *CreateInvoiceRequest) error {
+	if req == nil {
+		return validator.ValidationErrors{
+			{
+				Field:   "request",
+				Tag:     "required",
+				Value:   "nil",
+				Message: "request cannot be nil",
+			},
+		}
+	}
+
+	req.Currency = strings.ToUpper(req.Currency) // Currency codes are accepted in any case
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	if req.Send && req.CustomerEmail == "" {
+		return validator.ValidationErrors{
+			{
+				Field:   "customer_email",
+				Tag:     "required",
+				Message: "customer_email is required to send the invoice",
+			},
+		}
+	}
+	return nil
+}
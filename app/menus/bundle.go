@@ -0,0 +1,233 @@
+package menus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"base/core/bundle"
+	"base/core/logger"
+	"base/core/translation"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+// bundledMenu is a menu in configuration bundles, matched by its handle, with its item tree
+type bundledMenu struct {
+	Handle      string         `json:"handle"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Items       []*bundledItem `json:"items"`
+}
+
+// bundledItem is a menu item in configuration bundles, in the order of its siblings. Items
+// linking to records keep their target_id, so the records must have the same ids in the
+// environments; url items have no such dependency.
+type bundledItem struct {
+	Label    string         `json:"label"`
+	Type     string         `json:"type"`
+	TargetId *uint          `json:"target_id,omitempty"`
+	Url      string         `json:"url,omitempty"`
+	NewTab   bool           `json:"new_tab"`
+	Active   bool           `json:"active"`
+	Children []*bundledItem `json:"children,omitempty"`
+}
+
+// registerBundle adds the menus to configuration bundles. Menus whose items changed get
+// the items of the bundle, without the translations of their labels.
+func (s *MenuService) registerBundle() {
+	bundle.RegisterSection(bundle.Section{
+		Name:   "menus",
+		Export: exportMenus,
+		Import: importMenus,
+		Applied: func(ctx context.Context, changes *bundle.Changes) {
+			var menus []*Menu
+			if err := s.DB.WithContext(ctx).Where("handle IN ?", changes.Changed()).Find(&menus).Error; err != nil {
+				s.Logger.Error("failed to load imported menus", logger.String("error", err.Error()))
+				return
+			}
+			for _, menu := range menus {
+				event := UpdateMenuEvent
+				if slices.Contains(changes.Created, menu.Handle) {
+					event = CreateMenuEvent
+				}
+				s.Emitter.EmitContext(ctx, event, menu)
+				s.Emitter.EmitContext(ctx, ChangeItemsEvent, menu)
+			}
+		},
+	})
+}
+
+func exportMenus(ctx context.Context, db *gorm.DB) (any, error) {
+	var menus []*Menu
+	if err := db.Order("handle").Find(&menus).Error; err != nil {
+		return nil, err
+	}
+	records := make([]*bundledMenu, len(menus))
+	for i, menu := range menus {
+		record, err := toBundled(db, menu)
+		if err != nil {
+			return nil, err
+		}
+		records[i] = record
+	}
+	return records, nil
+}
+
+func importMenus(ctx context.Context, tx *gorm.DB, data json.RawMessage, changes *bundle.Changes) error {
+	var records []*bundledMenu
+	if err := json.Unmarshal(data, &records); err != nil {
+		return validator.ValidationErrors{{Tag: "invalid", Message: "menus must be a list of menus"}}
+	}
+
+	var errs validator.ValidationErrors
+	for i, record := range records {
+		path := fmt.Sprintf("[%d]", i)
+		var handleErrs validator.ValidationErrors
+		if errors.As(validateHandle(record.Handle), &handleErrs) {
+			errs = append(errs, prefixed(path, handleErrs)...)
+		}
+		if record.Name == "" {
+			errs = append(errs, validator.ValidationError{Field: path + ".name", Tag: "required", Message: "name is required"})
+		}
+		errs = append(errs, validateItems(path+".items", record.Items)...)
+		if record.Items == nil {
+			record.Items = []*bundledItem{}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	for _, record := range records {
+		var menu Menu
+		err := tx.Where("handle = ?", record.Handle).First(&menu).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var before *bundledMenu
+		if err == nil {
+			if before, err = toBundled(tx, &menu); err != nil {
+				return err
+			}
+		}
+		if !changes.Track(record.Handle, before, record) {
+			continue
+		}
+
+		menu.Handle = record.Handle
+		menu.Name = record.Name
+		menu.Description = record.Description
+		if err := tx.Save(&menu).Error; err != nil {
+			return err
+		}
+		if err := replaceItems(tx, menu.Id, record.Items); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// toBundled returns a menu as it is in bundles, with its item tree
+func toBundled(db *gorm.DB, menu *Menu) (*bundledMenu, error) {
+	var items []*MenuItem
+	if err := db.Where("menu_id = ?", menu.Id).Order("position, id").Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	var build func(parent uint) []*bundledItem
+	build = func(parent uint) []*bundledItem {
+		result := []*bundledItem{}
+		for _, item := range items {
+			if (item.ParentId == nil && parent == 0) || (item.ParentId != nil && *item.ParentId == parent) {
+				result = append(result, &bundledItem{
+					Label:    item.Label,
+					Type:     item.Type,
+					TargetId: item.TargetId,
+					Url:      item.Url,
+					NewTab:   item.NewTab,
+					Active:   item.Active,
+					Children: build(item.Id),
+				})
+			}
+		}
+		return result
+	}
+
+	return &bundledMenu{
+		Handle:      menu.Handle,
+		Name:        menu.Name,
+		Description: menu.Description,
+		Items:       build(0),
+	}, nil
+}
+
+// replaceItems deletes the items of a menu with their translations and creates the items of
+// a bundle instead
+func replaceItems(tx *gorm.DB, menuId uint, items []*bundledItem) error {
+	var itemIds []uint
+	if err := tx.Model(&MenuItem{}).Where("menu_id = ?", menuId).Pluck("id", &itemIds).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("menu_id = ?", menuId).Delete(&MenuItem{}).Error; err != nil {
+		return err
+	}
+	for _, itemId := range itemIds {
+		if err := translation.Fields.WithDB(tx).Delete((&MenuItem{}).TableName(), itemId); err != nil {
+			return err
+		}
+	}
+
+	var create func(parentId *uint, items []*bundledItem) error
+	create = func(parentId *uint, items []*bundledItem) error {
+		for position, record := range items {
+			item := &MenuItem{
+				MenuId:   menuId,
+				ParentId: parentId,
+				Position: position,
+				Label:    record.Label,
+				Type:     record.Type,
+				TargetId: record.TargetId,
+				Url:      record.Url,
+				NewTab:   record.NewTab,
+				Active:   record.Active,
+			}
+			if err := tx.Create(item).Error; err != nil {
+				return err
+			}
+			if err := create(&item.Id, record.Children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return create(nil, items)
+}
+
+// validateItems checks the links of an item tree of a bundle
+func validateItems(path string, items []*bundledItem) validator.ValidationErrors {
+	var errs validator.ValidationErrors
+	for i, record := range items {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		if record.Type == "" {
+			errs = append(errs, validator.ValidationError{Field: itemPath + ".type", Tag: "required", Message: "type is required"})
+			continue
+		}
+		item := &MenuItem{Type: record.Type, TargetId: record.TargetId, Url: record.Url}
+		errs = append(errs, prefixed(itemPath, validateLink(item))...)
+		errs = append(errs, validateItems(itemPath+".children", record.Children)...)
+	}
+	return errs
+}
+
+// prefixed returns validation errors with their fields under a path
+func prefixed(path string, errs validator.ValidationErrors) validator.ValidationErrors {
+	for i := range errs {
+		errs[i].Field = path + "." + errs[i].Field
+	}
+	return errs
+}
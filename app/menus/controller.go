@@ -0,0 +1,402 @@
+package menus
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"base/core/router"
+	"base/core/translation"
+	"base/core/types"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+type MenuController struct {
+	Service *MenuService
+}
+
+func NewMenuController(service *MenuService) *MenuController {
+	return &MenuController{
+		Service: service,
+	}
+}
+
+// Routes registers the menu management endpoints; the group is restricted to admins by
+// the module
+func (c *MenuController) Routes(router *router.RouterGroup) {
+	router.GET("/menus", c.List)          // Paginated list
+	router.POST("/menus", c.Create)       // Create
+	router.GET("/menus/:id", c.Get)       // Get by ID with its item tree
+	router.PUT("/menus/:id", c.Update)    // Update
+	router.DELETE("/menus/:id", c.Delete) // Delete with its items
+
+	router.POST("/menus/:id/items", c.CreateItem)            // Add an item
+	router.PUT("/menus/:id/items/:item_id", c.UpdateItem)    // Update an item
+	router.DELETE("/menus/:id/items/:item_id", c.DeleteItem) // Delete an item
+	router.PUT("/menus/:id/reorder", c.Reorder)              // Move items
+}
+
+// PublicRoutes registers the public menus. They don't need a user token (see
+// /api/public/* in MIDDLEWARE_AUTH_SKIP_PATHS) and are cached until a menu or a page changes.
+func (c *MenuController) PublicRoutes(group *router.RouterGroup) {
+	group.GET("/public/menus/:handle", c.PublicGet, router.Responses.Cache(cacheTag))
+}
+
+// CreateMenu godoc
+// @Summary Create a menu
+// @Description Create a navigation menu; frontends load it by its handle, e.g. main or footer (Admin only)
+// @Tags App/Menus
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param menu body CreateMenuRequest true "Create menu request"
+// @Success 201 {object} MenuResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /menus [post]
+func (c *MenuController) Create(ctx *router.Context) error {
+	var req CreateMenuRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	item, err := c.Service.CreateMenu(ctx.Request.Context(), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to create menu")
+	}
+	return ctx.JSON(http.StatusCreated, item.ToResponse())
+}
+
+// GetMenu godoc
+// @Summary Get a menu
+// @Description Get a menu by its id with the tree of all its items and their translations (Admin only)
+// @Tags App/Menus
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Menu id"
+// @Success 200 {object} MenuResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /menus/{id} [get]
+func (c *MenuController) Get(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	item, err := c.Service.GetMenu(ctx.Request.Context(), uint(id))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to get menu")
+	}
+	items, err := c.Service.GetItems(ctx.Request.Context(), item.Id)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to get menu items")
+	}
+	return c.respond(ctx, item, items)
+}
+
+// ListMenus godoc
+// @Summary List menus
+// @Description Get a page of menus ordered by name, without their items (Admin only)
+// @Tags App/Menus
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of items per page"
+// @Success 200 {object} types.PaginatedResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /menus [get]
+func (c *MenuController) List(ctx *router.Context) error {
+	params, err := ctx.ListParams()
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	paginatedResponse, err := c.Service.GetMenus(ctx.Request.Context(), params.Page, params.Limit)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch menus: " + err.Error()})
+	}
+	return ctx.JSON(http.StatusOK, paginatedResponse)
+}
+
+// UpdateMenu godoc
+// @Summary Update a menu
+// @Description Update the name, handle or description of a menu; omitted fields are left unchanged (Admin only)
+// @Tags App/Menus
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Menu id"
+// @Param menu body UpdateMenuRequest true "Update menu request"
+// @Success 200 {object} MenuResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /menus/{id} [put]
+func (c *MenuController) Update(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	var req UpdateMenuRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	item, err := c.Service.UpdateMenu(ctx.Request.Context(), uint(id), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to update menu")
+	}
+	items, err := c.Service.GetItems(ctx.Request.Context(), item.Id)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to get menu items")
+	}
+	return c.respond(ctx, item, items)
+}
+
+// DeleteMenu godoc
+// @Summary Delete a menu
+// @Description Delete a menu with its items (Admin only)
+// @Tags App/Menus
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Menu id"
+// @Success 204 "Successfully deleted"
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /menus/{id} [delete]
+func (c *MenuController) Delete(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	if err := c.Service.DeleteMenu(ctx.Request.Context(), uint(id)); err != nil {
+		return c.fail(ctx, err, "Failed to delete menu")
+	}
+
+	ctx.Status(http.StatusNoContent)
+	return nil
+}
+
+// CreateMenuItem godoc
+// @Summary Add a menu item
+// @Description Add an item to a menu, after its siblings unless a position is given. url items link to the url (absolute, a path, mailto: or tel:); page and category items to the record with target_id, whose title is the default label (Admin only)
+// @Tags App/Menus
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Menu id"
+// @Param item body CreateItemRequest true "Create menu item request"
+// @Success 201 {object} MenuItemResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /menus/{id}/items [post]
+func (c *MenuController) CreateItem(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	var req CreateItemRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	item, err := c.Service.CreateItem(ctx.Request.Context(), uint(id), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to create menu item")
+	}
+	return c.respondItem(ctx, http.StatusCreated, item)
+}
+
+// UpdateMenuItem godoc
+// @Summary Update a menu item
+// @Description Update a menu item; omitted fields are left unchanged and a parent_id of 0 makes it a top-level item (Admin only)
+// @Tags App/Menus
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Menu id"
+// @Param item_id path int true "Menu item id"
+// @Param item body UpdateItemRequest true "Update menu item request"
+// @Success 200 {object} MenuItemResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /menus/{id}/items/{item_id} [put]
+func (c *MenuController) UpdateItem(ctx *router.Context) error {
+	id, itemId, err := itemParams(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	var req UpdateItemRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	item, err := c.Service.UpdateItem(ctx.Request.Context(), id, itemId, &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to update menu item")
+	}
+	return c.respondItem(ctx, http.StatusOK, item)
+}
+
+// DeleteMenuItem godoc
+// @Summary Delete a menu item
+// @Description Delete a menu item; its children move up to its parent (Admin only)
+// @Tags App/Menus
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Menu id"
+// @Param item_id path int true "Menu item id"
+// @Success 204 "Successfully deleted"
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /menus/{id}/items/{item_id} [delete]
+func (c *MenuController) DeleteItem(ctx *router.Context) error {
+	id, itemId, err := itemParams(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	if err := c.Service.DeleteItem(ctx.Request.Context(), id, itemId); err != nil {
+		return c.fail(ctx, err, "Failed to delete menu item")
+	}
+
+	ctx.Status(http.StatusNoContent)
+	return nil
+}
+
+// ReorderMenu godoc
+// @Summary Reorder menu items
+// @Description Move items of a menu to new parents and positions in one go, e.g. after dragging and dropping them; items left out keep their place (Admin only)
+// @Tags App/Menus
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Menu id"
+// @Param reorder body ReorderRequest true "Reorder request"
+// @Success 200 {object} MenuResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /menus/{id}/reorder [put]
+func (c *MenuController) Reorder(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	var req ReorderRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	items, err := c.Service.Reorder(ctx.Request.Context(), uint(id), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to reorder menu items")
+	}
+	menu, err := c.Service.GetMenu(ctx.Request.Context(), uint(id))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to get menu")
+	}
+	return c.respond(ctx, menu, items)
+}
+
+// GetPublicMenu godoc
+// @Summary Get a menu
+// @Description Get the tree of the active items of a menu by its handle, in the request locale. Every item has its url resolved and the title of its target as the default label; items linking to unpublished pages or inactive categories are left out with their children
+// @Tags App/Menus
+// @Security ApiKeyAuth
+// @Produce json
+// @Param handle path string true "Menu handle"
+// @Success 200 {object} MenuResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /public/menus/{handle} [get]
+func (c *MenuController) PublicGet(ctx *router.Context) error {
+	item, err := c.Service.GetByHandle(ctx.Request.Context(), ctx.Param("handle"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Menu not found"})
+	}
+
+	items, err := c.Service.Resolve(ctx, item)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to resolve menu: " + err.Error()})
+	}
+
+	response := item.ToResponse()
+	response.Items = items
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// respond writes a menu with the tree of its items and their translations
+func (c *MenuController) respond(ctx *router.Context, menu *Menu, items []*MenuItem) error {
+	responses := make([]*MenuItemResponse, 0, len(items))
+	for _, item := range items {
+		responses = append(responses, item.ToResponse())
+	}
+
+	model := &MenuItem{}
+	if err := translation.Localize(ctx, model.TableName(), model.TranslatedFields(), responses); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to load translations: " + err.Error()})
+	}
+
+	response := menu.ToResponse()
+	response.Items = Tree(responses)
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// respondItem writes a menu item with its translations
+func (c *MenuController) respondItem(ctx *router.Context, status int, item *MenuItem) error {
+	response := item.ToResponse()
+	if err := translation.Localize(ctx, item.TableName(), item.TranslatedFields(), response); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to load translations: " + err.Error()})
+	}
+	return ctx.JSON(status, response)
+}
+
+// fail writes the error response of a service error
+func (c *MenuController) fail(ctx *router.Context, err error, message string) error {
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   translation.Error(ctx, err),
+			Details: translation.LocalizeValidation(ctx, validationErrors),
+		})
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Item not found"})
+	}
+	return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: message + ": " + err.Error()})
+}
+
+// itemParams reads the menu and item id path parameters
+func itemParams(ctx *router.Context) (uint, uint, error) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return 0, 0, errors.New("Invalid id format")
+	}
+	itemId, err := strconv.ParseUint(ctx.Param("item_id"), 10, 32)
+	if err != nil {
+		return 0, 0, errors.New("Invalid item_id format")
+	}
+	return uint(id), uint(itemId), nil
+}
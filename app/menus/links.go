@@ -0,0 +1,114 @@
+package menus
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"base/app/pages"
+	"base/app/products"
+	"base/core/translation"
+
+	"gorm.io/gorm"
+)
+
+// Link is where a menu item points to
+type Link struct {
+	Url   string
+	Title string // Used when the item has no label
+}
+
+// LinkType lets menu items link to the records of a module
+type LinkType struct {
+	// Model is the model of the linked records, to check that they exist
+	Model any
+
+	// Resolve returns the links of the records with the given ids in the locale of ctx.
+	// Records that aren't public are left out, and so are the items linking to them.
+	Resolve func(ctx context.Context, ids []uint) (map[uint]Link, error)
+}
+
+var (
+	linkTypesMu sync.RWMutex
+	linkTypes   = map[string]LinkType{}
+)
+
+// RegisterLinkType makes the records of a module linkable from menu items of the type,
+// e.g. "post". Registering a name again replaces it.
+func RegisterLinkType(name string, linkType LinkType) {
+	linkTypesMu.Lock()
+	defer linkTypesMu.Unlock()
+	linkTypes[name] = linkType
+}
+
+// getLinkType returns a registered link type
+func getLinkType(name string) (LinkType, bool) {
+	linkTypesMu.RLock()
+	defer linkTypesMu.RUnlock()
+	linkType, ok := linkTypes[name]
+	return linkType, ok
+}
+
+// LinkTypes returns the names of the types menu items can have, sorted
+func LinkTypes() []string {
+	linkTypesMu.RLock()
+	defer linkTypesMu.RUnlock()
+	names := []string{TypeUrl}
+	for name := range linkTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// registerBuiltinLinkTypes registers links to pages ("/" and their path) and product
+// categories ("/categories/" and their slug)
+func registerBuiltinLinkTypes(db *gorm.DB, pageService *pages.PageService) {
+	RegisterLinkType("page", LinkType{
+		Model: &pages.Page{},
+		Resolve: func(ctx context.Context, ids []uint) (map[uint]Link, error) {
+			items, err := pageService.GetPublishedByIds(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			responses := make([]*pages.PageListResponse, 0, len(items))
+			for _, item := range items {
+				responses = append(responses, item.ToListResponse())
+			}
+			model := &pages.Page{}
+			if err := translation.Localize(ctx, model.TableName(), model.TranslatedFields(), responses); err != nil {
+				return nil, err
+			}
+
+			links := make(map[uint]Link, len(responses))
+			for _, response := range responses {
+				links[response.Id] = Link{Url: "/" + response.Path, Title: response.Title}
+			}
+			return links, nil
+		},
+	})
+
+	RegisterLinkType("category", LinkType{
+		Model: &products.Category{},
+		Resolve: func(ctx context.Context, ids []uint) (map[uint]Link, error) {
+			var categories []*products.Category
+			if err := db.Where("id IN ? AND active = ?", ids, true).Find(&categories).Error; err != nil {
+				return nil, err
+			}
+			responses := make([]*products.CategoryResponse, 0, len(categories))
+			for _, category := range categories {
+				responses = append(responses, category.ToResponse())
+			}
+			model := &products.Category{}
+			if err := translation.Localize(ctx, model.TableName(), model.TranslatedFields(), responses); err != nil {
+				return nil, err
+			}
+
+			links := make(map[uint]Link, len(responses))
+			for _, response := range responses {
+				links[response.Id] = Link{Url: "/categories/" + response.Slug, Title: response.Name}
+			}
+			return links, nil
+		},
+	})
+}
@@ -0,0 +1,181 @@
+package menus
+
+import (
+	"time"
+
+	"base/core/translation"
+
+	"gorm.io/gorm"
+)
+
+// TypeUrl is the link type of items that link to a URL; the other types link to a record
+// (see RegisterLinkType)
+const TypeUrl = "url"
+
+// Menu is a named navigation menu of the site, e.g. the main menu or the footer links.
+// Frontends load it by its handle.
+type Menu struct {
+	Id          uint           `json:"id" gorm:"primarykey"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	Name        string         `json:"name" gorm:"size:100"`
+	Handle      string         `json:"handle" gorm:"size:64;index"` // e.g. main, footer
+	Description string         `json:"description" gorm:"size:255"`
+}
+
+// TableName returns the table name for the Menu model
+func (m *Menu) TableName() string {
+	return "menus"
+}
+
+// MenuItem is a link of a menu. Items nest through ParentId and are ordered by Position
+// among their siblings.
+type MenuItem struct {
+	Id        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	MenuId    uint      `json:"menu_id" gorm:"index"`
+	ParentId  *uint     `json:"parent_id" gorm:"index"`
+	Position  int       `json:"position"`
+	Label     string    `json:"label" gorm:"size:255"` // Defaults to the title of the target
+	Type      string    `json:"type" gorm:"size:32"`   // url, page, category, ...
+	TargetId  *uint     `json:"target_id"`             // Id of the linked record
+	Url       string    `json:"url" gorm:"size:1024"`  // url items only
+	NewTab    bool      `json:"new_tab"`               // Open the link in a new tab
+	Active    bool      `json:"active"`
+}
+
+// TableName returns the table name for the MenuItem model
+func (m *MenuItem) TableName() string {
+	return "menu_items"
+}
+
+// TranslatedFields returns the fields that can have per-locale values
+func (m *MenuItem) TranslatedFields() []string {
+	return []string{"label"}
+}
+
+// CreateMenuRequest represents the request payload for creating a Menu
+type CreateMenuRequest struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Handle      string `json:"handle" validate:"required,max=64"`
+	Description string `json:"description" validate:"max=255"`
+}
+
+// UpdateMenuRequest represents the request payload for updating a Menu. Omitted fields are
+// left unchanged.
+type UpdateMenuRequest struct {
+	Name        *string `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
+	Handle      *string `json:"handle,omitempty" validate:"omitempty,min=1,max=64"`
+	Description *string `json:"description,omitempty" validate:"omitempty,max=255"`
+}
+
+// CreateItemRequest represents the request payload for adding an item to a menu. url
+// items need the url, the other types the target_id of the linked record.
+type CreateItemRequest struct {
+	ParentId *uint  `json:"parent_id,omitempty"`
+	Position *int   `json:"position,omitempty"` // Defaults to after its siblings
+	Label    string `json:"label" validate:"max=255"`
+	Type     string `json:"type" validate:"required,max=32"`
+	TargetId *uint  `json:"target_id,omitempty"`
+	Url      string `json:"url" validate:"max=1024"`
+	NewTab   bool   `json:"new_tab"`
+	Active   *bool  `json:"active,omitempty"` // Defaults to true
+
+	// Translations of the label by locale, e.g. {"label": {"de": "..."}}
+	Translations translation.FieldValues `json:"translations,omitempty"`
+}
+
+// UpdateItemRequest represents the request payload for updating a menu item. Omitted fields
+// are left unchanged; a parent_id of 0 makes it a top-level item.
+type UpdateItemRequest struct {
+	ParentId *uint   `json:"parent_id,omitempty"`
+	Position *int    `json:"position,omitempty"`
+	Label    *string `json:"label,omitempty" validate:"omitempty,max=255"`
+	Type     *string `json:"type,omitempty" validate:"omitempty,min=1,max=32"`
+	TargetId *uint   `json:"target_id,omitempty"`
+	Url      *string `json:"url,omitempty" validate:"omitempty,max=1024"`
+	NewTab   *bool   `json:"new_tab,omitempty"`
+	Active   *bool   `json:"active,omitempty"`
+
+	// Translations of the label by locale; locales left out are unchanged
+	Translations translation.FieldValues `json:"translations,omitempty"`
+}
+
+// ReorderRequest moves menu items, e.g. after dragging and dropping them. Items left out
+// keep their place.
+type ReorderRequest struct {
+	Items []ReorderItem `json:"items" validate:"required,min=1,dive"`
+}
+
+// ReorderItem is the new place of a menu item
+type ReorderItem struct {
+	Id       uint  `json:"id" validate:"required"`
+	ParentId *uint `json:"parent_id"` // Null or 0 for the top level
+	Position int   `json:"position"`
+}
+
+// MenuResponse represents the API response for a Menu with its item tree
+type MenuResponse struct {
+	Id          uint                `json:"id"`
+	Name        string              `json:"name"`
+	Handle      string              `json:"handle"`
+	Description string              `json:"description"`
+	Items       []*MenuItemResponse `json:"items"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
+// MenuItemResponse represents a menu item with its children. In public menus the url is
+// resolved for every type and the label defaults to the title of the target.
+type MenuItemResponse struct {
+	Id       uint                `json:"id"`
+	ParentId *uint               `json:"parent_id,omitempty"`
+	Position int                 `json:"position"`
+	Label    string              `json:"label"`
+	Type     string              `json:"type"`
+	TargetId *uint               `json:"target_id,omitempty"`
+	Url      string              `json:"url"`
+	NewTab   bool                `json:"new_tab"`
+	Active   bool                `json:"active"`
+	Children []*MenuItemResponse `json:"children"`
+
+	// All translations, by field and locale
+	Translations translation.FieldValues `json:"translations,omitempty"`
+}
+
+// ToResponse converts the model to an API response, without its items
+func (m *Menu) ToResponse() *MenuResponse {
+	if m == nil {
+		return nil
+	}
+	return &MenuResponse{
+		Id:          m.Id,
+		Name:        m.Name,
+		Handle:      m.Handle,
+		Description: m.Description,
+		Items:       []*MenuItemResponse{},
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+	}
+}
+
+// ToResponse converts the model to an API response, without its children
+func (m *MenuItem) ToResponse() *MenuItemResponse {
+	if m == nil {
+		return nil
+	}
+	return &MenuItemResponse{
+		Id:       m.Id,
+		ParentId: m.ParentId,
+		Position: m.Position,
+		Label:    m.Label,
+		Type:     m.Type,
+		TargetId: m.TargetId,
+		Url:      m.Url,
+		NewTab:   m.NewTab,
+		Active:   m.Active,
+		Children: []*MenuItemResponse{},
+	}
+}
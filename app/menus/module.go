@@ -0,0 +1,138 @@
+package menus
+
+import (
+	"errors"
+
+	"base/app/pages"
+	"base/core/app/authorization"
+	"base/core/app/reports"
+	"base/core/module"
+	"base/core/router"
+
+	"gorm.io/gorm"
+)
+
+// Module provides the navigation menus of the site. Management endpoints are restricted
+// to admins; menus are public, resolved to the pages and categories their items link to.
+type Module struct {
+	module.DefaultModule
+	DB         *gorm.DB
+	Service    *MenuService
+	Controller *MenuController
+}
+
+// Init creates and initializes the menus module with all dependencies
+func Init(deps module.Dependencies) module.Module {
+	registerEvents()
+	service := NewMenuService(deps.DB, deps.Emitter, deps.Logger)
+	registerBuiltinLinkTypes(deps.DB, pages.NewPageService(deps.DB, deps.Emitter, deps.Logger))
+
+	// Menus are promoted between environments with configuration bundles
+	service.registerBundle()
+
+	reports.RegisterEntity(reports.Entity{
+		Name:       "menus",
+		Columns:    []string{"id", "name", "handle", "description", "created_at", "updated_at"},
+		SoftDelete: true,
+	})
+
+	// Cached public menus are dropped when a menu changes, or a page their items link to
+	router.Responses.InvalidateOn(deps.Emitter, cacheTag,
+		CreateMenuEvent, UpdateMenuEvent, DeleteMenuEvent, ChangeItemsEvent,
+		pages.CreatePageEvent, pages.UpdatePageEvent, pages.DeletePageEvent, pages.PublishPageEvent, pages.RestorePageEvent)
+
+	return &Module{
+		DB:         deps.DB,
+		Service:    service,
+		Controller: NewMenuController(service),
+	}
+}
+
+// Routes registers the admin and the public menu routes
+func (m *Module) Routes(router *router.RouterGroup) {
+	group := router.Group("", authorization.RequireAdmin(authorization.NewAuthorizationService(m.DB)))
+	m.Controller.Routes(group)
+
+	m.Controller.PublicRoutes(router)
+}
+
+func (m *Module) Init() error {
+	return m.SeedPermissions()
+}
+
+func (m *Module) SeedPermissions() error {
+	// Ensure permissions table exists before seeding
+	if err := m.DB.AutoMigrate(&authorization.Permission{}); err != nil {
+		return err
+	}
+
+	// Define permissions for menu CRUD operations
+	permissions := []authorization.Permission{
+		{
+			Name:         "menu list",
+			Description:  "View menu list",
+			ResourceType: "menu",
+			Action:       "list",
+		},
+		{
+			Name:         "menu read",
+			Description:  "View menus and their items",
+			ResourceType: "menu",
+			Action:       "read",
+		},
+		{
+			Name:         "menu create",
+			Description:  "Create menus",
+			ResourceType: "menu",
+			Action:       "create",
+		},
+		{
+			Name:         "menu update",
+			Description:  "Update menus and manage their items",
+			ResourceType: "menu",
+			Action:       "update",
+		},
+		{
+			Name:         "menu delete",
+			Description:  "Delete menus",
+			ResourceType: "menu",
+			Action:       "delete",
+		},
+	}
+
+	// Upsert permissions - create or update if they exist
+	for _, permission := range permissions {
+		var existingPermission authorization.Permission
+		result := m.DB.Where("resource_type = ? AND action = ?", permission.ResourceType, permission.Action).First(&existingPermission)
+
+		if result.Error != nil && errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// Create new permission
+			if err := m.DB.Create(&permission).Error; err != nil {
+				return err
+			}
+		} else if result.Error == nil {
+			// Update existing permission
+			existingPermission.Name = permission.Name
+			existingPermission.Description = permission.Description
+			if err := m.DB.Save(&existingPermission).Error; err != nil {
+				return err
+			}
+		} else {
+			// Return any other error
+			return result.Error
+		}
+	}
+
+	return nil
+}
+
+func (m *Module) Migrate() error {
+	return m.DB.AutoMigrate(&Menu{}, &MenuItem{})
+}
+
+func (m *Module) GetModels() []any {
+	return []any{
+		&Menu{},
+		&MenuItem{},
+	}
+}
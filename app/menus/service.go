@@ -0,0 +1,631 @@
+package menus
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"base/core/emitter"
+	"base/core/logger"
+	"base/core/translation"
+	"base/core/types"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+const (
+	CreateMenuEvent  = "menus.create"
+	UpdateMenuEvent  = "menus.update"
+	DeleteMenuEvent  = "menus.delete"
+	ChangeItemsEvent = "menus.items" // Items of the menu were added, changed, moved or deleted
+
+	// cacheTag tags the cached public menu responses
+	cacheTag = "menus"
+)
+
+// registerEvents adds the events of the module to the event catalog
+func registerEvents() {
+	emitter.RegisterEvents(
+		emitter.EventType{Name: CreateMenuEvent, Description: "A menu was created", Payload: &Menu{}},
+		emitter.EventType{Name: UpdateMenuEvent, Description: "A menu was updated", Payload: &Menu{}},
+		emitter.EventType{Name: DeleteMenuEvent, Description: "A menu was deleted", Payload: &Menu{}},
+		emitter.EventType{Name: ChangeItemsEvent, Description: "Items of a menu were added, changed, moved or deleted", Payload: &Menu{}},
+	)
+}
+
+// MenuService manages the navigation menus of the site and their items
+type MenuService struct {
+	DB      *gorm.DB
+	Emitter *emitter.Emitter
+	Logger  logger.Logger
+}
+
+func NewMenuService(db *gorm.DB, emitter *emitter.Emitter, logger logger.Logger) *MenuService {
+	return &MenuService{
+		DB:      db,
+		Emitter: emitter,
+		Logger:  logger,
+	}
+}
+
+// CreateMenu creates a menu
+func (s *MenuService) CreateMenu(ctx context.Context, req *CreateMenuRequest) (*Menu, error) {
+	if err := ValidateMenuCreateRequest(req); err != nil {
+		return nil, err
+	}
+	item := &Menu{
+		Name:        req.Name,
+		Handle:      req.Handle,
+		Description: req.Description,
+	}
+	if err := s.checkHandle(ctx, item); err != nil {
+		return nil, err
+	}
+
+	if err := s.DB.WithContext(ctx).Create(item).Error; err != nil {
+		s.Logger.Error("failed to create menu", logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	// Emit create event
+	s.Emitter.EmitContext(ctx, CreateMenuEvent, item)
+
+	return item, nil
+}
+
+// UpdateMenu updates the fields of a menu that are set in the request
+func (s *MenuService) UpdateMenu(ctx context.Context, id uint, req *UpdateMenuRequest) (*Menu, error) {
+	item, err := s.GetMenu(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateMenuUpdateRequest(req); err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		item.Name = *req.Name
+	}
+	if req.Handle != nil && *req.Handle != item.Handle {
+		item.Handle = *req.Handle
+		if err := s.checkHandle(ctx, item); err != nil {
+			return nil, err
+		}
+	}
+	if req.Description != nil {
+		item.Description = *req.Description
+	}
+
+	if err := s.DB.WithContext(ctx).Save(item).Error; err != nil {
+		s.Logger.Error("failed to update menu",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return nil, err
+	}
+
+	// Emit update event
+	s.Emitter.EmitContext(ctx, UpdateMenuEvent, item)
+
+	return item, nil
+}
+
+// DeleteMenu deletes a menu with its items
+func (s *MenuService) DeleteMenu(ctx context.Context, id uint) error {
+	item, err := s.GetMenu(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	var itemIds []uint
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&MenuItem{}).Where("menu_id = ?", item.Id).Pluck("id", &itemIds).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("menu_id = ?", item.Id).Delete(&MenuItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(item).Error
+	})
+	if err != nil {
+		s.Logger.Error("failed to delete menu",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return err
+	}
+	for _, itemId := range itemIds {
+		if err := translation.Fields.WithDB(s.DB.WithContext(ctx)).Delete((&MenuItem{}).TableName(), itemId); err != nil {
+			s.Logger.Warn("failed to delete menu item translations",
+				logger.String("error", err.Error()),
+				logger.Int("id", int(itemId)))
+		}
+	}
+
+	// Emit delete event
+	s.Emitter.EmitContext(ctx, DeleteMenuEvent, item)
+
+	return nil
+}
+
+// GetMenu returns a menu by id
+func (s *MenuService) GetMenu(ctx context.Context, id uint) (*Menu, error) {
+	item := &Menu{}
+	if err := s.DB.WithContext(ctx).First(item, id).Error; err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// GetByHandle returns a menu by its handle
+func (s *MenuService) GetByHandle(ctx context.Context, handle string) (*Menu, error) {
+	item := &Menu{}
+	if err := s.DB.WithContext(ctx).Where("handle = ?", handle).First(item).Error; err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// GetMenus returns a page of menus ordered by name
+func (s *MenuService) GetMenus(ctx context.Context, page *int, limit *int) (*types.PaginatedResponse, error) {
+	var items []*Menu
+	var total int64
+
+	query := s.DB.WithContext(ctx).Model(&Menu{})
+
+	// Set default values if nil
+	defaultPage := 1
+	defaultLimit := 10
+	if page == nil {
+		page = &defaultPage
+	}
+	if limit == nil {
+		limit = &defaultLimit
+	}
+
+	// Get total count
+	if err := query.Count(&total).Error; err != nil {
+		s.Logger.Error("failed to count menus",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	offset := (*page - 1) * *limit
+	if err := query.Order("name").Offset(offset).Limit(*limit).Find(&items).Error; err != nil {
+		s.Logger.Error("failed to get menus",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	// Calculate total pages
+	totalPages := int(math.Ceil(float64(total) / float64(*limit)))
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	return &types.PaginatedResponse{
+		Data: items,
+		Pagination: types.Pagination{
+			Total:      int(total),
+			Page:       *page,
+			PageSize:   *limit,
+			TotalPages: totalPages,
+		},
+	}, nil
+}
+
+// GetItems returns the items of a menu ordered by position
+func (s *MenuService) GetItems(ctx context.Context, menuId uint) ([]*MenuItem, error) {
+	var items []*MenuItem
+	if err := s.DB.WithContext(ctx).Where("menu_id = ?", menuId).Order("position, id").Find(&items).Error; err != nil {
+		s.Logger.Error("failed to get menu items",
+			logger.String("error", err.Error()),
+			logger.Int("menu_id", int(menuId)))
+		return nil, err
+	}
+	return items, nil
+}
+
+// GetItem returns an item of a menu
+func (s *MenuService) GetItem(ctx context.Context, menuId, itemId uint) (*MenuItem, error) {
+	item := &MenuItem{}
+	if err := s.DB.WithContext(ctx).Where("menu_id = ?", menuId).First(item, itemId).Error; err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// CreateItem adds an item to a menu, after its siblings unless a position is given
+func (s *MenuService) CreateItem(ctx context.Context, menuId uint, req *CreateItemRequest) (*MenuItem, error) {
+	menu, err := s.GetMenu(ctx, menuId)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateItemCreateRequest(req); err != nil {
+		return nil, err
+	}
+	if err := translation.ValidateFieldValues(req.Translations, (&MenuItem{}).TranslatedFields()); err != nil {
+		return nil, err
+	}
+
+	item := &MenuItem{
+		MenuId:   menu.Id,
+		ParentId: req.ParentId,
+		Label:    req.Label,
+		Type:     req.Type,
+		TargetId: req.TargetId,
+		Url:      req.Url,
+		NewTab:   req.NewTab,
+		Active:   req.Active == nil || *req.Active,
+	}
+	if item.ParentId != nil && *item.ParentId == 0 {
+		item.ParentId = nil
+	}
+	if err := s.checkItem(ctx, item); err != nil {
+		return nil, err
+	}
+
+	if req.Position != nil {
+		item.Position = *req.Position
+	} else {
+		var last []int
+		query := s.DB.WithContext(ctx).Model(&MenuItem{}).Where("menu_id = ?", menu.Id)
+		if item.ParentId == nil {
+			query = query.Where("parent_id IS NULL")
+		} else {
+			query = query.Where("parent_id = ?", *item.ParentId)
+		}
+		if err := query.Order("position DESC").Limit(1).Pluck("position", &last).Error; err != nil {
+			return nil, err
+		}
+		if len(last) > 0 {
+			item.Position = last[0] + 1
+		}
+	}
+
+	if err := s.DB.WithContext(ctx).Create(item).Error; err != nil {
+		s.Logger.Error("failed to create menu item",
+			logger.String("error", err.Error()),
+			logger.Int("menu_id", int(menu.Id)))
+		return nil, err
+	}
+	if err := translation.Fields.WithDB(s.DB.WithContext(ctx)).Set(item.TableName(), item.Id, req.Translations); err != nil {
+		s.Logger.Error("failed to save menu item translations", logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	s.Emitter.EmitContext(ctx, ChangeItemsEvent, menu)
+	return item, nil
+}
+
+// UpdateItem updates the fields of a menu item that are set in the request. Switching to
+// the url type drops the target and switching away from it the url.
+func (s *MenuService) UpdateItem(ctx context.Context, menuId, itemId uint, req *UpdateItemRequest) (*MenuItem, error) {
+	menu, err := s.GetMenu(ctx, menuId)
+	if err != nil {
+		return nil, err
+	}
+	item, err := s.GetItem(ctx, menu.Id, itemId)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateItemUpdateRequest(req); err != nil {
+		return nil, err
+	}
+	if err := translation.ValidateFieldValues(req.Translations, item.TranslatedFields()); err != nil {
+		return nil, err
+	}
+
+	if req.ParentId != nil {
+		item.ParentId = req.ParentId
+		if *req.ParentId == 0 {
+			item.ParentId = nil
+		}
+	}
+	if req.Position != nil {
+		item.Position = *req.Position
+	}
+	if req.Label != nil {
+		item.Label = *req.Label
+	}
+	if req.Type != nil {
+		item.Type = *req.Type
+	}
+	if req.TargetId != nil {
+		item.TargetId = req.TargetId
+	}
+	if req.Url != nil {
+		item.Url = *req.Url
+	}
+	if req.NewTab != nil {
+		item.NewTab = *req.NewTab
+	}
+	if req.Active != nil {
+		item.Active = *req.Active
+	}
+	if err := s.checkItem(ctx, item); err != nil {
+		return nil, err
+	}
+
+	if err := s.DB.WithContext(ctx).Save(item).Error; err != nil {
+		s.Logger.Error("failed to update menu item",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(itemId)))
+		return nil, err
+	}
+	if err := translation.Fields.WithDB(s.DB.WithContext(ctx)).Set(item.TableName(), item.Id, req.Translations); err != nil {
+		s.Logger.Error("failed to save menu item translations",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(itemId)))
+		return nil, err
+	}
+
+	s.Emitter.EmitContext(ctx, ChangeItemsEvent, menu)
+	return item, nil
+}
+
+// DeleteItem deletes a menu item; its children move up to its parent
+func (s *MenuService) DeleteItem(ctx context.Context, menuId, itemId uint) error {
+	menu, err := s.GetMenu(ctx, menuId)
+	if err != nil {
+		return err
+	}
+	item, err := s.GetItem(ctx, menu.Id, itemId)
+	if err != nil {
+		return err
+	}
+
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&MenuItem{}).Where("parent_id = ?", item.Id).Update("parent_id", item.ParentId).Error; err != nil {
+			return err
+		}
+		return tx.Delete(item).Error
+	})
+	if err != nil {
+		s.Logger.Error("failed to delete menu item",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(itemId)))
+		return err
+	}
+	if err := translation.Fields.WithDB(s.DB.WithContext(ctx)).Delete(item.TableName(), item.Id); err != nil {
+		s.Logger.Warn("failed to delete menu item translations",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(itemId)))
+	}
+
+	s.Emitter.EmitContext(ctx, ChangeItemsEvent, menu)
+	return nil
+}
+
+// Reorder moves items of a menu to new parents and positions in one transaction
+func (s *MenuService) Reorder(ctx context.Context, menuId uint, req *ReorderRequest) ([]*MenuItem, error) {
+	menu, err := s.GetMenu(ctx, menuId)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateReorderRequest(req); err != nil {
+		return nil, err
+	}
+
+	items, err := s.GetItems(ctx, menu.Id)
+	if err != nil {
+		return nil, err
+	}
+	byId := make(map[uint]*MenuItem, len(items))
+	parents := make(map[uint]*uint, len(items))
+	for _, item := range items {
+		byId[item.Id] = item
+		parents[item.Id] = item.ParentId
+	}
+
+	var errs validator.ValidationErrors
+	for i, move := range req.Items {
+		if byId[move.Id] == nil {
+			errs = append(errs, validator.ValidationError{
+				Field:   fmt.Sprintf("items[%d].id", i),
+				Tag:     "exists",
+				Value:   fmt.Sprint(move.Id),
+				Message: fmt.Sprintf("item %d is not in the menu", move.Id),
+			})
+			continue
+		}
+		parentId := move.ParentId
+		if parentId != nil && *parentId == 0 {
+			parentId = nil
+		}
+		if parentId != nil && byId[*parentId] == nil {
+			errs = append(errs, validator.ValidationError{
+				Field:   fmt.Sprintf("items[%d].parent_id", i),
+				Tag:     "exists",
+				Value:   fmt.Sprint(*parentId),
+				Message: fmt.Sprintf("item %d is not in the menu", *parentId),
+			})
+			continue
+		}
+		parents[move.Id] = parentId
+	}
+	for i, move := range req.Items {
+		if byId[move.Id] != nil && hasCycle(parents, move.Id) {
+			errs = append(errs, validator.ValidationError{
+				Field:   fmt.Sprintf("items[%d].parent_id", i),
+				Tag:     "invalid",
+				Message: "an item can't be moved into itself or one of its children",
+			})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, move := range req.Items {
+			updates := map[string]any{"parent_id": parents[move.Id], "position": move.Position}
+			if err := tx.Model(&MenuItem{}).Where("id = ?", move.Id).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.Logger.Error("failed to reorder menu items",
+			logger.String("error", err.Error()),
+			logger.Int("menu_id", int(menu.Id)))
+		return nil, err
+	}
+
+	s.Emitter.EmitContext(ctx, ChangeItemsEvent, menu)
+	return s.GetItems(ctx, menu.Id)
+}
+
+// Resolve returns the public item tree of a menu in the locale of ctx: active items with
+// the url of their target and its title as the default label. Items whose target isn't
+// public are left out with their children.
+func (s *MenuService) Resolve(ctx context.Context, menu *Menu) ([]*MenuItemResponse, error) {
+	var items []*MenuItem
+	if err := s.DB.WithContext(ctx).Where("menu_id = ? AND active = ?", menu.Id, true).Order("position, id").Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	targets := map[string][]uint{}
+	for _, item := range items {
+		if item.Type != TypeUrl && item.TargetId != nil {
+			targets[item.Type] = append(targets[item.Type], *item.TargetId)
+		}
+	}
+	links := map[string]map[uint]Link{}
+	for name, ids := range targets {
+		linkType, ok := getLinkType(name)
+		if !ok {
+			continue
+		}
+		resolved, err := linkType.Resolve(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		links[name] = resolved
+	}
+
+	responses := make([]*MenuItemResponse, 0, len(items))
+	for _, item := range items {
+		response := item.ToResponse()
+		if item.Type != TypeUrl {
+			if item.TargetId == nil {
+				continue
+			}
+			link, ok := links[item.Type][*item.TargetId]
+			if !ok {
+				continue
+			}
+			response.Url = link.Url
+			if response.Label == "" {
+				response.Label = link.Title
+			}
+		}
+		responses = append(responses, response)
+	}
+
+	model := &MenuItem{}
+	if err := translation.Localize(ctx, model.TableName(), model.TranslatedFields(), responses); err != nil {
+		return nil, err
+	}
+	for _, response := range responses {
+		response.Translations = nil
+	}
+	return Tree(responses), nil
+}
+
+// Tree nests item responses under their parents and returns the top-level ones. Items
+// whose parent isn't among them are left out.
+func Tree(items []*MenuItemResponse) []*MenuItemResponse {
+	byId := make(map[uint]*MenuItemResponse, len(items))
+	for _, item := range items {
+		byId[item.Id] = item
+	}
+
+	roots := []*MenuItemResponse{}
+	for _, item := range items {
+		if item.ParentId == nil {
+			roots = append(roots, item)
+		} else if parent := byId[*item.ParentId]; parent != nil {
+			parent.Children = append(parent.Children, item)
+		}
+	}
+	return roots
+}
+
+// checkItem checks the link of an item, that its target exists and that its parent is an
+// item of the same menu other than itself or one of its children
+func (s *MenuService) checkItem(ctx context.Context, item *MenuItem) error {
+	if item.Type == TypeUrl {
+		item.TargetId = nil
+	} else {
+		item.Url = ""
+	}
+	if errs := validateLink(item); len(errs) > 0 {
+		return errs
+	}
+
+	if item.Type != TypeUrl {
+		linkType, _ := getLinkType(item.Type)
+		var count int64
+		if err := s.DB.WithContext(ctx).Model(linkType.Model).Where("id = ?", *item.TargetId).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return validator.ValidationErrors{{
+				Field:   "target_id",
+				Tag:     "exists",
+				Value:   fmt.Sprint(*item.TargetId),
+				Message: fmt.Sprintf("%s %d does not exist", item.Type, *item.TargetId),
+			}}
+		}
+	}
+
+	if item.ParentId == nil {
+		return nil
+	}
+	var siblings []*MenuItem
+	if err := s.DB.WithContext(ctx).Select("id, parent_id").Where("menu_id = ?", item.MenuId).Find(&siblings).Error; err != nil {
+		return err
+	}
+	parents := make(map[uint]*uint, len(siblings))
+	for _, sibling := range siblings {
+		parents[sibling.Id] = sibling.ParentId
+	}
+	if _, ok := parents[*item.ParentId]; !ok {
+		return validator.ValidationErrors{{
+			Field:   "parent_id",
+			Tag:     "exists",
+			Value:   fmt.Sprint(*item.ParentId),
+			Message: fmt.Sprintf("item %d is not in the menu", *item.ParentId),
+		}}
+	}
+	if item.Id != 0 {
+		parents[item.Id] = item.ParentId
+		if hasCycle(parents, item.Id) {
+			return validator.ValidationErrors{{
+				Field:   "parent_id",
+				Tag:     "invalid",
+				Value:   fmt.Sprint(*item.ParentId),
+				Message: "parent_id can't be the item or one of its children",
+			}}
+		}
+	}
+	return nil
+}
+
+// checkHandle checks that no other menu has the handle of a menu
+func (s *MenuService) checkHandle(ctx context.Context, item *Menu) error {
+	var count int64
+	if err := s.DB.WithContext(ctx).Model(&Menu{}).Where("handle = ? AND id <> ?", item.Handle, item.Id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return validator.ValidationErrors{{
+			Field:   "handle",
+			Tag:     "unique",
+			Value:   item.Handle,
+			Message: "handle is already taken",
+		}}
+	}
+	return nil
+}
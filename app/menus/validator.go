@@ -0,0 +1,162 @@
+package menus
+
+import (
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"base/core/validator"
+)
+
+// Global validator instance using Base core validator wrapper
+var validate = validator.New()
+
+// handlePattern matches menu handles such as "main" or "footer-legal"
+var handlePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+// ValidateMenuCreateRequest validates the create request
+func ValidateMenuCreateRequest(req *CreateMenuRequest) error {
+	if req == nil {
+		return nilRequest()
+	}
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	return validateHandle(req.Handle)
+}
+
+// ValidateMenuUpdateRequest validates the update request
+func ValidateMenuUpdateRequest(req *UpdateMenuRequest) error {
+	if req == nil {
+		return nilRequest()
+	}
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	if req.Handle != nil {
+		return validateHandle(*req.Handle)
+	}
+	return nil
+}
+
+// ValidateItemCreateRequest validates the item create request
+func ValidateItemCreateRequest(req *CreateItemRequest) error {
+	if req == nil {
+		return nilRequest()
+	}
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateItemUpdateRequest validates the item update request
+func ValidateItemUpdateRequest(req *UpdateItemRequest) error {
+	if req == nil {
+		return nilRequest()
+	}
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateReorderRequest validates the reorder request
+func ValidateReorderRequest(req *ReorderRequest) error {
+	if req == nil {
+		return nilRequest()
+	}
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateLink checks that an item links to a URL or to a record of a registered type
+func validateLink(item *MenuItem) validator.ValidationErrors {
+	if item.Type == TypeUrl {
+		if !validUrl(item.Url) {
+			return validator.ValidationErrors{{
+				Field:   "url",
+				Tag:     "url",
+				Value:   item.Url,
+				Message: "url must be an absolute URL, a path starting with '/', or a mailto: or tel: link",
+			}}
+		}
+		return nil
+	}
+
+	if _, ok := getLinkType(item.Type); !ok {
+		return validator.ValidationErrors{{
+			Field:   "type",
+			Tag:     "oneof",
+			Value:   item.Type,
+			Param:   strings.Join(LinkTypes(), " "),
+			Message: "type must be one of: " + strings.Join(LinkTypes(), " "),
+		}}
+	}
+	if item.TargetId == nil || *item.TargetId == 0 {
+		return validator.ValidationErrors{{
+			Field:   "target_id",
+			Tag:     "required",
+			Message: "target_id is required for " + item.Type + " items",
+		}}
+	}
+	return nil
+}
+
+// validUrl reports whether a url item can link to the value
+func validUrl(value string) bool {
+	if strings.HasPrefix(value, "/") || strings.HasPrefix(value, "#") {
+		return true
+	}
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+	switch parsed.Scheme {
+	case "http", "https":
+		return parsed.Host != ""
+	case "mailto", "tel":
+		return parsed.Opaque != ""
+	}
+	return false
+}
+
+// validateHandle checks the format of a menu handle
+func validateHandle(handle string) error {
+	if !handlePattern.MatchString(handle) {
+		return validator.ValidationErrors{{
+			Field:   "handle",
+			Tag:     "slug",
+			Value:   handle,
+			Message: "handle must contain only lowercase letters, digits, '.', '_' or '-'",
+		}}
+	}
+	return nil
+}
+
+// nilRequest is the error of a missing request
+func nilRequest() error {
+	return validator.ValidationErrors{
+		{
+			Field:   "request",
+			Tag:     "required",
+			Value:   "nil",
+			Message: "request cannot be nil",
+		},
+	}
+}
+
+// hasCycle reports whether following the parents from id leads back to it
+func hasCycle(parents map[uint]*uint, id uint) bool {
+	seen := []uint{id}
+	for parent := parents[id]; parent != nil; parent = parents[*parent] {
+		if slices.Contains(seen, *parent) {
+			return true
+		}
+		seen = append(seen, *parent)
+	}
+	return false
+}
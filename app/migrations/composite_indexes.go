@@ -0,0 +1,74 @@
+package migrations
+
+import (
+	"base/core/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// compositeIndex is an index over several columns, in the order of the query shapes it serves:
+// equality filters first, then the sort column
+type compositeIndex struct {
+	Table   string
+	Name    string
+	Columns []string
+}
+
+// compositeIndexes match the filters of the activity, media and notification lists. The
+// models declare the same indexes, so databases set up with AutoMigrate already have them.
+var compositeIndexes = []compositeIndex{
+	{Table: "activities", Name: "idx_activities_user_created", Columns: []string{"user_id", "created_at"}},
+	{Table: "activities", Name: "idx_activities_entity_created", Columns: []string{"entity_type", "entity_id", "created_at"}},
+	{Table: "media", Name: "idx_media_parent_type", Columns: []string{"parent_id", "type"}},
+	{Table: "media", Name: "idx_media_author_parent", Columns: []string{"author_id", "parent_id"}},
+	{Table: "notifications", Name: "idx_notifications_user_read_created", Columns: []string{"user_id", "read", "created_at"}},
+}
+
+func init() {
+	err := database.RegisterMigration(database.Migration{
+		Version: "20261016000000",
+		Name:    "add_composite_indexes",
+		Up:      createCompositeIndexes,
+		Down:    dropCompositeIndexes,
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// createCompositeIndexes creates the indexes that don't exist yet, skipping the tables of
+// disabled modules
+func createCompositeIndexes(tx *gorm.DB) error {
+	migrator := tx.Migrator()
+	for _, index := range compositeIndexes {
+		if !migrator.HasTable(index.Table) || migrator.HasIndex(index.Table, index.Name) {
+			continue
+		}
+
+		columns := make([]any, len(index.Columns))
+		for i, column := range index.Columns {
+			columns[i] = clause.Column{Name: column}
+		}
+		err := tx.Exec("CREATE INDEX ? ON ? ?",
+			clause.Column{Name: index.Name}, clause.Table{Name: index.Table}, columns).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropCompositeIndexes drops the indexes created by createCompositeIndexes
+func dropCompositeIndexes(tx *gorm.DB) error {
+	migrator := tx.Migrator()
+	for _, index := range compositeIndexes {
+		if !migrator.HasIndex(index.Table, index.Name) {
+			continue
+		}
+		if err := migrator.DropIndex(index.Table, index.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
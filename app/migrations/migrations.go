@@ -0,0 +1,19 @@
+package migrations
+
+import (
+	"base/core/database"
+	"embed"
+)
+
+// sqlFiles holds the application's versioned SQL migrations.
+// Add pairs named <version>_<name>.up.sql and <version>_<name>.down.sql to the sql directory,
+// or register Go migrations with database.RegisterMigration from an init() function.
+//
+//go:embed sql
+var sqlFiles embed.FS
+
+func init() {
+	if err := database.RegisterSQLMigrations(sqlFiles, "sql"); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,189 @@
+package orders
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"base/core/router"
+	"base/core/translation"
+	"base/core/types"
+
+	"gorm.io/gorm"
+)
+
+type OrderController struct {
+	Service *OrderService
+}
+
+func NewOrderController(service *OrderService) *OrderController {
+	return &OrderController{
+		Service: service,
+	}
+}
+
+// Routes registers the order management endpoints; the group is restricted to admins by
+// the module. Orders are placed through the cart checkout.
+func (c *OrderController) Routes(router *router.RouterGroup) {
+	router.GET("/orders", c.List)               // Paginated list
+	router.GET("/orders/:id", c.Get)            // Get by ID
+	router.POST("/orders/:id/cancel", c.Cancel) // Cancel a pending order
+}
+
+// ProfileRoutes registers the orders of the signed in user
+func (c *OrderController) ProfileRoutes(router *router.RouterGroup) {
+	router.GET("/profile/orders", c.ListMine)
+	router.GET("/profile/orders/:id", c.GetMine)
+}
+
+// GetOrder godoc
+// @Summary Get an order
+// @Description Get an order with its items (Admin only)
+// @Tags App/Order
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Order id"
+// @Success 200 {object} Order
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /orders/{id} [get]
+func (c *OrderController) Get(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	order, err := c.Service.GetById(ctx.Request.Context(), uint(id))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to get order")
+	}
+	return ctx.JSON(http.StatusOK, order)
+}
+
+// ListOrders godoc
+// @Summary List orders
+// @Description Get a page of orders, newest first (Admin only)
+// @Tags App/Order
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of items per page"
+// @Param status query string false "Only orders with the status (pending, paid, canceled, refunded)"
+// @Param fulfillment query string false "Only orders with the fulfillment status (unfulfilled, shipped, delivered)"
+// @Param user_id query int false "Only orders of the user"
+// @Param q query string false "Search the email"
+// @Success 200 {object} types.PaginatedResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /orders [get]
+func (c *OrderController) List(ctx *router.Context) error {
+	params, err := ctx.ListParams()
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	filter := OrderFilter{Status: ctx.Query("status"), Fulfillment: ctx.Query("fulfillment"), Query: ctx.Query("q")}
+	if value := ctx.Query("user_id"); value != "" {
+		userId, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid user_id"})
+		}
+		filter.UserId = uint(userId)
+	}
+
+	paginatedResponse, err := c.Service.GetAll(ctx.Request.Context(), params.Page, params.Limit, filter)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch orders: " + err.Error()})
+	}
+	return ctx.JSON(http.StatusOK, paginatedResponse)
+}
+
+// CancelOrder godoc
+// @Summary Cancel an order
+// @Description Cancel a pending order; its stock and coupons are given back (Admin only)
+// @Tags App/Order
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Order id"
+// @Success 200 {object} Order
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 409 {object} types.ErrorResponse
+// @Router /orders/{id}/cancel [post]
+func (c *OrderController) Cancel(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	order, err := c.Service.Cancel(ctx.Request.Context(), uint(id))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to cancel order")
+	}
+	return ctx.JSON(http.StatusOK, order)
+}
+
+// ListMyOrders godoc
+// @Summary List my orders
+// @Description Get a page of the orders of the signed in user, newest first
+// @Tags App/Order
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of items per page"
+// @Success 200 {object} types.PaginatedResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /profile/orders [get]
+func (c *OrderController) ListMine(ctx *router.Context) error {
+	params, err := ctx.ListParams()
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	paginatedResponse, err := c.Service.GetAll(ctx.Request.Context(), params.Page, params.Limit, OrderFilter{UserId: ctx.GetUint("user_id")})
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch orders: " + err.Error()})
+	}
+	return ctx.JSON(http.StatusOK, paginatedResponse)
+}
+
+// GetMyOrder godoc
+// @Summary Get my order
+// @Description Get an order of the signed in user with its items
+// @Tags App/Order
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Order id"
+// @Success 200 {object} Order
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /profile/orders/{id} [get]
+func (c *OrderController) GetMine(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	order, err := c.Service.GetForUser(ctx.Request.Context(), ctx.GetUint("user_id"), uint(id))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to get order")
+	}
+	return ctx.JSON(http.StatusOK, order)
+}
+
+// fail writes the error response of a service error
+func (c *OrderController) fail(ctx *router.Context, err error, message string) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Item not found"})
+	}
+	if errors.Is(err, ErrNotCancelable) {
+		return ctx.JSON(http.StatusConflict, types.ErrorResponse{Error: translation.Error(ctx, err)})
+	}
+	return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: message + ": " + err.Error()})
+}
@@ -0,0 +1,130 @@
+package orders
+
+import (
+	"time"
+
+	"base/core/types"
+)
+
+// Order statuses
+const (
+	StatusPending  = "pending"  // Placed, waiting for the payment
+	StatusPaid     = "paid"     // Paid in full
+	StatusCanceled = "canceled" // Canceled before it was paid; stock and coupons are given back
+	StatusRefunded = "refunded" // Paid and refunded in full
+)
+
+// Fulfillment statuses, kept up to date from the shipments of the order
+const (
+	FulfillmentUnfulfilled = "unfulfilled" // Nothing shipped yet
+	FulfillmentShipped     = "shipped"     // Handed to the carrier, not all delivered yet
+	FulfillmentDelivered   = "delivered"   // Every shipment delivered
+)
+
+// Order is a placed cart. Amounts are in cents and copied from the cart at checkout, so
+// later price changes don't affect it.
+type Order struct {
+	Id                uint         `json:"id" gorm:"primarykey"`
+	CreatedAt         time.Time    `json:"created_at"`
+	UpdatedAt         time.Time    `json:"updated_at"`
+	UserId            *uint        `json:"user_id" gorm:"index"` // Nil for guest orders
+	Email             string       `json:"email" gorm:"size:255;index"`
+	Status            string       `json:"status" gorm:"size:32;index"`
+	Currency          string       `json:"currency" gorm:"size:3"`
+	Subtotal          types.Money  `json:"subtotal"`
+	Discount          types.Money  `json:"discount"`
+	Tax               types.Money  `json:"tax"`
+	Shipping          types.Money  `json:"shipping"`
+	Total             types.Money  `json:"total"` // Subtotal - Discount + Tax + Shipping
+	ShippingMethodId  *uint        `json:"shipping_method_id"`
+	ShippingMethod    string       `json:"shipping_method" gorm:"size:100"` // Name of the method when the order was placed
+	FulfillmentStatus string       `json:"fulfillment_status" gorm:"size:32;index;default:unfulfilled"`
+	CouponCode        string       `json:"coupon_code" gorm:"size:64"`
+	ShippingAddress   Address      `json:"shipping_address" gorm:"embedded;embeddedPrefix:shipping_"`
+	BillingAddress    Address      `json:"billing_address" gorm:"embedded;embeddedPrefix:billing_"`
+	Notes             string       `json:"notes" gorm:"type:text"`
+	PaidAt            *time.Time   `json:"paid_at"`
+	CanceledAt        *time.Time   `json:"canceled_at"`
+	Items             []*OrderItem `json:"items,omitempty" gorm:"foreignKey:OrderId"`
+}
+
+// TableName returns the table name for the Order model
+func (m *Order) TableName() string {
+	return "orders"
+}
+
+// GetId returns the Id of the model
+func (m *Order) GetId() uint {
+	return m.Id
+}
+
+// Address is a postal address of an order
+type Address struct {
+	Name       string `json:"name" gorm:"size:255" validate:"required,max=255"`
+	Company    string `json:"company,omitempty" gorm:"size:255" validate:"max=255"`
+	Line1      string `json:"line1" gorm:"size:255" validate:"required,max=255"`
+	Line2      string `json:"line2,omitempty" gorm:"size:255" validate:"max=255"`
+	City       string `json:"city" gorm:"size:128" validate:"required,max=128"`
+	PostalCode string `json:"postal_code" gorm:"size:32" validate:"max=32"`
+	Region     string `json:"region,omitempty" gorm:"size:128" validate:"max=128"` // State or province
+	Country    string `json:"country" gorm:"size:2" validate:"required,iso3166_1_alpha2"`
+	Phone      string `json:"phone,omitempty" gorm:"size:64" validate:"max=64"`
+}
+
+// OrderItem is a line of an order with the product as it was when the order was placed
+type OrderItem struct {
+	Id        uint        `json:"id" gorm:"primarykey"`
+	OrderId   uint        `json:"order_id" gorm:"index"`
+	ProductId uint        `json:"product_id" gorm:"index"`
+	VariantId *uint       `json:"variant_id"`
+	Sku       string      `json:"sku" gorm:"size:64"`
+	Name      string      `json:"name" gorm:"size:255"`
+	Quantity  int         `json:"quantity"`
+	UnitPrice types.Money `json:"unit_price"`
+	Subtotal  types.Money `json:"subtotal"` // UnitPrice * Quantity
+	Discount  types.Money `json:"discount"`
+	TaxRate   float64     `json:"tax_rate"` // Percent
+	Tax       types.Money `json:"tax"`
+	Total     types.Money `json:"total"` // Subtotal - Discount + Tax
+}
+
+// TableName returns the table name for the OrderItem model
+func (m *OrderItem) TableName() string {
+	return "order_items"
+}
+
+// OrderFilter narrows order lists
+type OrderFilter struct {
+	Status      string
+	Fulfillment string
+	UserId      uint
+	Query       string // Matches the email
+}
+
+// OrderListResponse represents an order in lists, without its items
+type OrderListResponse struct {
+	Id                uint        `json:"id"`
+	CreatedAt         time.Time   `json:"created_at"`
+	UserId            *uint       `json:"user_id"`
+	Email             string      `json:"email"`
+	Status            string      `json:"status"`
+	FulfillmentStatus string      `json:"fulfillment_status"`
+	Currency          string      `json:"currency"`
+	Total             types.Money `json:"total"`
+	CouponCode        string      `json:"coupon_code"`
+}
+
+// ToListResponse converts the model to a list response
+func (m *Order) ToListResponse() *OrderListResponse {
+	return &OrderListResponse{
+		Id:                m.Id,
+		CreatedAt:         m.CreatedAt,
+		UserId:            m.UserId,
+		Email:             m.Email,
+		Status:            m.Status,
+		FulfillmentStatus: m.FulfillmentStatus,
+		Currency:          m.Currency,
+		Total:             m.Total,
+		CouponCode:        m.CouponCode,
+	}
+}
@@ -0,0 +1,126 @@
+package orders
+
+import (
+	"errors"
+
+	"base/core/app/activities"
+	"base/core/app/authorization"
+	"base/core/app/reports"
+	"base/core/module"
+	"base/core/router"
+
+	"gorm.io/gorm"
+)
+
+// Module keeps the orders placed through the cart checkout and follows their payments
+type Module struct {
+	module.DefaultModule
+	DB         *gorm.DB
+	Service    *OrderService
+	Controller *OrderController
+}
+
+// Init creates and initializes the orders module with all dependencies
+func Init(deps module.Dependencies) module.Module {
+	registerEvents()
+	service := NewOrderService(deps.DB, deps.Emitter, deps.Logger)
+	service.Listen()
+
+	reports.RegisterEntity(reports.Entity{
+		Name:    "orders",
+		Columns: []string{"id", "user_id", "email", "status", "currency", "subtotal", "discount", "tax", "shipping", "total", "shipping_method", "fulfillment_status", "coupon_code", "shipping_country", "paid_at", "canceled_at", "created_at"},
+	})
+
+	activities.RegisterWatchable(activities.Watchable{
+		Name: "orders",
+		Path: "/orders",
+		Events: map[string]string{
+			PaidOrderEvent:     "was paid",
+			CancelOrderEvent:   "was canceled",
+			RefundedOrderEvent: "was refunded",
+		},
+	})
+
+	return &Module{
+		DB:         deps.DB,
+		Service:    service,
+		Controller: NewOrderController(service),
+	}
+}
+
+// Routes registers the orders of the signed in user and the admin routes
+func (m *Module) Routes(router *router.RouterGroup) {
+	m.Controller.ProfileRoutes(router)
+
+	group := router.Group("", authorization.RequireAdmin(authorization.NewAuthorizationService(m.DB)))
+	m.Controller.Routes(group)
+}
+
+func (m *Module) Init() error {
+	return m.SeedPermissions()
+}
+
+func (m *Module) SeedPermissions() error {
+	// Ensure permissions table exists before seeding
+	if err := m.DB.AutoMigrate(&authorization.Permission{}); err != nil {
+		return err
+	}
+
+	// Define permissions for order operations
+	permissions := []authorization.Permission{
+		{
+			Name:         "order list",
+			Description:  "View order list",
+			ResourceType: "order",
+			Action:       "list",
+		},
+		{
+			Name:         "order read",
+			Description:  "View orders",
+			ResourceType: "order",
+			Action:       "read",
+		},
+		{
+			Name:         "order cancel",
+			Description:  "Cancel pending orders",
+			ResourceType: "order",
+			Action:       "cancel",
+		},
+	}
+
+	// Upsert permissions - create or update if they exist
+	for _, permission := range permissions {
+		var existingPermission authorization.Permission
+		result := m.DB.Where("resource_type = ? AND action = ?", permission.ResourceType, permission.Action).First(&existingPermission)
+
+		if result.Error != nil && errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// Create new permission
+			if err := m.DB.Create(&permission).Error; err != nil {
+				return err
+			}
+		} else if result.Error == nil {
+			// Update existing permission
+			existingPermission.Name = permission.Name
+			existingPermission.Description = permission.Description
+			if err := m.DB.Save(&existingPermission).Error; err != nil {
+				return err
+			}
+		} else {
+			// Return any other error
+			return result.Error
+		}
+	}
+
+	return nil
+}
+
+func (m *Module) Migrate() error {
+	return m.DB.AutoMigrate(&Order{}, &OrderItem{})
+}
+
+func (m *Module) GetModels() []any {
+	return []any{
+		&Order{},
+		&OrderItem{},
+	}
+}
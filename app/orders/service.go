@@ -0,0 +1,248 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"base/app/discounts"
+	"base/app/payments"
+	"base/app/products"
+	"base/core/emitter"
+	"base/core/logger"
+	"base/core/types"
+
+	"gorm.io/gorm"
+)
+
+const (
+	CreateOrderEvent   = "orders.create"
+	PaidOrderEvent     = "orders.paid"
+	CancelOrderEvent   = "orders.cancel"
+	RefundedOrderEvent = "orders.refunded"
+)
+
+// registerEvents adds the events of the module to the event catalog
+func registerEvents() {
+	emitter.RegisterEvents(
+		emitter.EventType{Name: CreateOrderEvent, Description: "An order was placed", Payload: &Order{}},
+		emitter.EventType{Name: PaidOrderEvent, Description: "An order was paid", Payload: &Order{}},
+		emitter.EventType{Name: CancelOrderEvent, Description: "An order was canceled", Payload: &Order{}},
+		emitter.EventType{Name: RefundedOrderEvent, Description: "An order was fully refunded", Payload: &Order{}},
+	)
+}
+
+// ErrNotCancelable is returned when canceling an order that isn't pending
+var ErrNotCancelable = errors.New("only pending orders can be canceled")
+
+// OrderService places orders and follows them through payment and cancellation
+type OrderService struct {
+	DB      *gorm.DB
+	Emitter *emitter.Emitter
+	Logger  logger.Logger
+}
+
+func NewOrderService(db *gorm.DB, emitter *emitter.Emitter, logger logger.Logger) *OrderService {
+	return &OrderService{
+		DB:      db,
+		Emitter: emitter,
+		Logger:  logger,
+	}
+}
+
+// Listen keeps orders in sync with their payments: a succeeded payment marks its order
+// paid and a full refund marks it refunded
+func (s *OrderService) Listen() {
+	emitter.Listen(s.Emitter, payments.SucceededPaymentEvent, func(ctx context.Context, payment *payments.Payment) {
+		s.setStatus(ctx, payment.OrderId, StatusPaid, []string{StatusPending}, PaidOrderEvent)
+	})
+	emitter.Listen(s.Emitter, payments.RefundedPaymentEvent, func(ctx context.Context, payment *payments.Payment) {
+		if payment.Status == payments.StatusRefunded {
+			s.setStatus(ctx, payment.OrderId, StatusRefunded, []string{StatusPaid}, RefundedOrderEvent)
+		}
+	})
+}
+
+// Place creates a pending order with its items. reserve runs in the same transaction once
+// the order has its id, e.g. to take the stock and redeem the coupon; the order isn't
+// placed when it fails.
+func (s *OrderService) Place(ctx context.Context, order *Order, reserve func(tx *gorm.DB, order *Order) error) error {
+	order.Status = StatusPending
+	order.FulfillmentStatus = FulfillmentUnfulfilled
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(order).Error; err != nil {
+			return err
+		}
+		if reserve != nil {
+			return reserve(tx, order)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Emit create event
+	s.Emitter.EmitContext(ctx, CreateOrderEvent, order)
+
+	return nil
+}
+
+// Cancel cancels a pending order and gives back its stock and coupons
+func (s *OrderService) Cancel(ctx context.Context, id uint) (*Order, error) {
+	order, err := s.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != StatusPending {
+		return nil, ErrNotCancelable
+	}
+
+	now := time.Now()
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Order{}).Where("id = ? AND status = ?", order.Id, StatusPending).
+			Updates(map[string]any{"status": StatusCanceled, "canceled_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotCancelable
+		}
+
+		stock := products.NewProductService(tx, s.Emitter, nil, s.Logger)
+		for _, item := range order.Items {
+			if err := stock.AdjustStock(ctx, item.ProductId, item.VariantId, item.Quantity); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
+		if order.CouponCode != "" {
+			coupons := discounts.NewCouponService(tx, s.Emitter, s.Logger, order.Currency)
+			if _, err := coupons.Release(ctx, order.Id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, ErrNotCancelable) {
+			s.Logger.Error("failed to cancel order",
+				logger.String("error", err.Error()),
+				logger.Int("id", int(id)))
+		}
+		return nil, err
+	}
+
+	order.Status = StatusCanceled
+	order.CanceledAt = &now
+	s.Emitter.EmitContext(ctx, CancelOrderEvent, order)
+	return order, nil
+}
+
+// GetById returns an order with its items
+func (s *OrderService) GetById(ctx context.Context, id uint) (*Order, error) {
+	order := &Order{}
+	if err := s.DB.WithContext(ctx).Preload("Items").First(order, id).Error; err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// GetForUser returns an order of a user with its items
+func (s *OrderService) GetForUser(ctx context.Context, userId, id uint) (*Order, error) {
+	order := &Order{}
+	if err := s.DB.WithContext(ctx).Preload("Items").Where("user_id = ?", userId).First(order, id).Error; err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// GetAll returns a page of orders, newest first
+func (s *OrderService) GetAll(ctx context.Context, page *int, limit *int, filter OrderFilter) (*types.PaginatedResponse, error) {
+	var items []*Order
+	var total int64
+
+	query := s.DB.WithContext(ctx).Model(&Order{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Fulfillment != "" {
+		query = query.Where("fulfillment_status = ?", filter.Fulfillment)
+	}
+	if filter.UserId != 0 {
+		query = query.Where("user_id = ?", filter.UserId)
+	}
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		query = query.Where("email LIKE ?", "%"+q+"%")
+	}
+
+	// Set default values if nil
+	defaultPage := 1
+	defaultLimit := 10
+	if page == nil {
+		page = &defaultPage
+	}
+	if limit == nil {
+		limit = &defaultLimit
+	}
+
+	// Get total count
+	if err := query.Count(&total).Error; err != nil {
+		s.Logger.Error("failed to count orders",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	offset := (*page - 1) * *limit
+	if err := query.Order("id DESC").Offset(offset).Limit(*limit).Find(&items).Error; err != nil {
+		s.Logger.Error("failed to get orders",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	responses := make([]*OrderListResponse, len(items))
+	for i, item := range items {
+		responses[i] = item.ToListResponse()
+	}
+
+	// Calculate total pages
+	totalPages := int(math.Ceil(float64(total) / float64(*limit)))
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	return &types.PaginatedResponse{
+		Data: responses,
+		Pagination: types.Pagination{
+			Total:      int(total),
+			Page:       *page,
+			PageSize:   *limit,
+			TotalPages: totalPages,
+		},
+	}, nil
+}
+
+// setStatus moves an order to status when it is in one of the from statuses
+func (s *OrderService) setStatus(ctx context.Context, id uint, status string, from []string, event string) {
+	updates := map[string]any{"status": status}
+	if status == StatusPaid {
+		updates["paid_at"] = time.Now()
+	}
+
+	result := s.DB.WithContext(ctx).Model(&Order{}).Where("id = ? AND status IN ?", id, from).Updates(updates)
+	if result.Error != nil {
+		s.Logger.Error("failed to update order status",
+			logger.String("error", result.Error.Error()),
+			logger.Int("id", int(id)),
+			logger.String("status", status))
+		return
+	}
+	if result.RowsAffected == 0 {
+		return
+	}
+
+	if order, err := s.GetById(ctx, id); err == nil {
+		s.Emitter.EmitContext(ctx, event, order)
+	}
+}
@@ -0,0 +1,16 @@
+package orders
+
+import (
+	"strings"
+
+	"base/core/validator"
+)
+
+// Global validator instance using Base core validator wrapper
+var validate = validator.New()
+
+// ValidateAddress validates an address; the country code is accepted in any case
+func ValidateAddress(address *Address) validator.ValidationErrors {
+	address.Country = strings.ToUpper(strings.TrimSpace(address.Country))
+	return validate.Validate(address)
+}
@@ -0,0 +1,705 @@
+package pages
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"base/app/seo"
+	"base/core/crud"
+	"base/core/fieldset"
+	"base/core/locks"
+	"base/core/router"
+	"base/core/translation"
+	"base/core/types"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+type PageController struct {
+	Service *PageService
+
+	// PreviewURL is followed by the preview token in the URLs of previews, e.g.
+	// https://api.example.com/api/public/page-preview/
+	PreviewURL string
+
+	// listFields are the fields and includes clients can choose in the list
+	listFields *fieldset.Spec
+}
+
+func NewPageController(service *PageService) *PageController {
+	return &PageController{
+		Service: service,
+		listFields: &fieldset.Spec{
+			Fields: []string{"id", "title", "slug", "path", "parent_id", "position", "status", "published_at", "archived_at", "updated_at"},
+			Includes: map[string]fieldset.Loader{
+				"blocks": fieldset.Load(func(ctx *router.Context, ids []uint) (map[uint][]Block, error) {
+					return service.BlocksOf(ctx.Request.Context(), ids)
+				}),
+				"seo": fieldset.Load(func(ctx *router.Context, ids []uint) (map[uint]*seo.Metadata, error) {
+					return seo.Records.Load(ctx, (&Page{}).TableName(), ids)
+				}),
+			},
+		},
+	}
+}
+
+// Routes registers the page management endpoints; the group is restricted to admins by
+// the module
+func (c *PageController) Routes(router *router.RouterGroup) {
+	// Pages someone else is editing can't be changed (see locks.Routes in the module)
+	guard := locks.Guard("pages")
+
+	router.GET("/pages", c.List)                 // Paginated list
+	router.POST("/pages", c.Create)              // Create
+	router.PUT("/pages/reorder", c.Reorder)      // Order sibling pages
+	router.GET("/pages/:id", c.Get)              // Get by ID
+	router.PUT("/pages/:id", c.Update, guard)    // Update, saving a revision
+	router.DELETE("/pages/:id", c.Delete, guard) // Delete
+
+	router.GET("/pages/:id/revisions", c.Revisions)                                // Revisions, newest first
+	router.GET("/pages/:id/revisions/:version", c.Revision)                        // Revision with its blocks
+	router.POST("/pages/:id/revisions/:version/restore", c.RestoreRevision, guard) // Restore a revision
+	router.POST("/pages/:id/preview-token", c.CreatePreviewToken)                  // Signed preview URL
+	router.POST("/pages/:id/duplicate", c.Duplicate)                               // Draft copy
+	router.POST("/pages/:id/archive", c.Archive, guard)                            // Hide from lists and the site
+	router.POST("/pages/:id/unarchive", c.Unarchive, guard)                        // Bring back
+
+	router.POST("/pages/:id/submit", c.Submit)   // Submit for review
+	router.POST("/pages/:id/approve", c.Approve) // Approve, by the reviewer
+	router.POST("/pages/:id/reject", c.Reject)   // Send back to draft, by the reviewer
+}
+
+// PublicRoutes registers the public, read-only pages. They don't need a user token (see
+// /api/public/* in MIDDLEWARE_AUTH_SKIP_PATHS) and are cached until a page changes.
+func (c *PageController) PublicRoutes(group *router.RouterGroup) {
+	cache := router.Responses.Cache(cacheTag)
+	group.GET("/public/pages", c.PublicList, cache)      // Published pages, for navigation
+	group.GET("/public/pages/*path", c.PublicGet, cache) // Published page by path
+	group.GET("/public/page-preview/:token", c.Preview)  // Any page, with a preview token
+}
+
+// CreatePage godoc
+// @Summary Create a page
+// @Description Create a page from content blocks; the slug is generated from the title when empty and the page starts as a draft unless status is published (Admin only)
+// @Tags App/Pages
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param page body CreatePageRequest true "Create page request"
+// @Success 201 {object} PageResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /pages [post]
+func (c *PageController) Create(ctx *router.Context) error {
+	var req CreatePageRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	item, err := c.Service.Create(ctx.Request.Context(), &req, ctx.GetUint("user_id"))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to create page")
+	}
+	return c.respond(ctx, http.StatusCreated, item)
+}
+
+// GetPage godoc
+// @Summary Get a page
+// @Description Get a page by its id with its blocks and translations (Admin only)
+// @Tags App/Pages
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Page id"
+// @Success 200 {object} PageResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /pages/{id} [get]
+func (c *PageController) Get(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	item, err := c.Service.GetById(ctx.Request.Context(), uint(id))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to get page")
+	}
+	return c.respond(ctx, http.StatusOK, item)
+}
+
+// ListPages godoc
+// @Summary List pages
+// @Description Get a page of pages without their blocks, ordered by path (Admin only)
+// @Tags App/Pages
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of items per page"
+// @Param q query string false "Search the title and path"
+// @Param status query string false "Status (draft, in_review, approved, published)"
+// @Param parent_id query int false "Only subpages of the page, 0 for top-level pages"
+// @Param reviewer_id query int false "Only pages the user reviews"
+// @Param archived query string false "true for archived pages only, all for both; archived pages are left out by default"
+// @Param fields query string false "Fields of the items, e.g. id,title,status (id is always included)"
+// @Param include query string false "Relationships to add to the items: blocks, seo"
+// @Success 200 {object} types.PaginatedResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /pages [get]
+func (c *PageController) List(ctx *router.Context) error {
+	params, err := ctx.ListParams()
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+	selection, err := c.listFields.Parse(ctx.Request.URL.Query())
+	if err != nil {
+		return c.fail(ctx, err, "Invalid fields")
+	}
+
+	filter := PageFilter{Query: ctx.Query("q"), Status: ctx.Query("status")}
+	if value := ctx.Query("parent_id"); value != "" {
+		parentId, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid parent_id"})
+		}
+		id := uint(parentId)
+		filter.ParentId = &id
+	}
+	if value := ctx.Query("reviewer_id"); value != "" {
+		reviewerId, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid reviewer_id"})
+		}
+		id := uint(reviewerId)
+		filter.ReviewerId = &id
+	}
+	if filter.Archived, err = crud.ParseArchived(ctx.Query("archived")); err != nil {
+		return c.fail(ctx, err, "Invalid archived")
+	}
+
+	paginatedResponse, err := c.Service.GetAll(ctx.Request.Context(), params.Page, params.Limit, filter)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch pages: " + err.Error()})
+	}
+	data, err := selection.Apply(ctx, paginatedResponse)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch pages: " + err.Error()})
+	}
+	return ctx.JSON(http.StatusOK, data)
+}
+
+// UpdatePage godoc
+// @Summary Update a page
+// @Description Update a page; omitted fields are left unchanged and blocks replaces the blocks. Changes to the content are saved as a new revision with the note; a new slug or parent moves the subpages along (Admin only)
+// @Tags App/Pages
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Page id"
+// @Param page body UpdatePageRequest true "Update page request"
+// @Success 200 {object} PageResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /pages/{id} [put]
+func (c *PageController) Update(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	var req UpdatePageRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	item, err := c.Service.Update(ctx.Request.Context(), uint(id), &req, ctx.GetUint("user_id"))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to update page")
+	}
+	return c.respond(ctx, http.StatusOK, item)
+}
+
+// DeletePage godoc
+// @Summary Delete a page
+// @Description Delete a page; pages with subpages can't be deleted (Admin only)
+// @Tags App/Pages
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Page id"
+// @Success 204 "Successfully deleted"
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 409 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /pages/{id} [delete]
+func (c *PageController) Delete(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	if err := c.Service.Delete(ctx.Request.Context(), uint(id)); err != nil {
+		return c.fail(ctx, err, "Failed to delete page")
+	}
+
+	ctx.Status(http.StatusNoContent)
+	return nil
+}
+
+// ListPageRevisions godoc
+// @Summary List page revisions
+// @Description Get the revisions of a page without their blocks, newest first (Admin only)
+// @Tags App/Pages
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Page id"
+// @Success 200 {array} PageRevision
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /pages/{id}/revisions [get]
+func (c *PageController) Revisions(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	items, err := c.Service.GetRevisions(ctx.Request.Context(), uint(id))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to fetch page revisions")
+	}
+	return ctx.JSON(http.StatusOK, items)
+}
+
+// GetPageRevision godoc
+// @Summary Get a page revision
+// @Description Get a revision of a page by its version, with its blocks (Admin only)
+// @Tags App/Pages
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Page id"
+// @Param version path int true "Version"
+// @Success 200 {object} PageRevision
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /pages/{id}/revisions/{version} [get]
+func (c *PageController) Revision(ctx *router.Context) error {
+	id, version, err := revisionParams(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	item, err := c.Service.GetRevision(ctx.Request.Context(), id, version)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to get page revision")
+	}
+	return ctx.JSON(http.StatusOK, item)
+}
+
+// RestorePageRevision godoc
+// @Summary Restore a page revision
+// @Description Put the title, blocks and SEO fields of a revision back on its page and save them as a new revision. The slug is restored when no other page under the same parent took it; the parent and status are kept (Admin only)
+// @Tags App/Pages
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Page id"
+// @Param version path int true "Version"
+// @Param restore body RestoreRequest false "Restore request"
+// @Success 200 {object} PageResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /pages/{id}/revisions/{version}/restore [post]
+func (c *PageController) RestoreRevision(ctx *router.Context) error {
+	id, version, err := revisionParams(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	var req RestoreRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+		}
+	}
+
+	item, err := c.Service.Restore(ctx.Request.Context(), id, version, &req, ctx.GetUint("user_id"))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to restore page revision")
+	}
+	return c.respond(ctx, http.StatusOK, item)
+}
+
+// SubmitPage godoc
+// @Summary Submit a page for review
+// @Description Move a draft page to in_review, assigning it to reviewer_id (or its current reviewer), who is notified (Admin only)
+// @Tags App/Pages
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Page id"
+// @Param request body SubmitPageRequest false "Reviewer and note"
+// @Success 200 {object} PageResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /pages/{id}/submit [post]
+func (c *PageController) Submit(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	var req SubmitPageRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+		}
+	}
+
+	item, err := c.Service.Submit(ctx.Request.Context(), uint(id), &req, ctx.GetUint("user_id"))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to submit page")
+	}
+	return c.respond(ctx, http.StatusOK, item)
+}
+
+// ApprovePage godoc
+// @Summary Approve a page
+// @Description Approve a page in review so it can be published; only its reviewer can, if it has one. The author is notified with the note (Admin only)
+// @Tags App/Pages
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Page id"
+// @Param request body ReviewPageRequest false "Note for the author"
+// @Success 200 {object} PageResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 403 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /pages/{id}/approve [post]
+func (c *PageController) Approve(ctx *router.Context) error {
+	return c.review(ctx, c.Service.Approve, "Failed to approve page")
+}
+
+// RejectPage godoc
+// @Summary Reject a page
+// @Description Send a page in review back to draft; only its reviewer can, if it has one. The author is notified with the note (Admin only)
+// @Tags App/Pages
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Page id"
+// @Param request body ReviewPageRequest false "What to change"
+// @Success 200 {object} PageResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 403 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /pages/{id}/reject [post]
+func (c *PageController) Reject(ctx *router.Context) error {
+	return c.review(ctx, c.Service.Reject, "Failed to reject page")
+}
+
+// review handles the approval and rejection of a page
+func (c *PageController) review(ctx *router.Context, decide func(context.Context, uint, *ReviewPageRequest, uint) (*Page, error), message string) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	var req ReviewPageRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+		}
+	}
+
+	item, err := decide(ctx.Request.Context(), uint(id), &req, ctx.GetUint("user_id"))
+	if err != nil {
+		return c.fail(ctx, err, message)
+	}
+	return c.respond(ctx, http.StatusOK, item)
+}
+
+// ListPublicPages godoc
+// @Summary List published pages
+// @Description Get the published pages without their blocks, ordered by position and title, in the request locale; parent_id links subpages
+// @Tags App/Pages
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} PageListResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /public/pages [get]
+func (c *PageController) PublicList(ctx *router.Context) error {
+	items, err := c.Service.GetPublished(ctx.Request.Context())
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch pages: " + err.Error()})
+	}
+
+	responses := make([]*PageListResponse, 0, len(items))
+	for _, item := range items {
+		responses = append(responses, item.ToListResponse())
+	}
+
+	model := &Page{}
+	if err := translation.Localize(ctx, model.TableName(), model.TranslatedFields(), responses); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to load translations: " + err.Error()})
+	}
+	return ctx.JSON(http.StatusOK, responses)
+}
+
+// GetPublicPage godoc
+// @Summary Get a published page
+// @Description Get a published page by its path, e.g. about/team, with its blocks and SEO metadata in the request locale
+// @Tags App/Pages
+// @Security ApiKeyAuth
+// @Produce json
+// @Param path path string true "Page path"
+// @Success 200 {object} PageResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /public/pages/{path} [get]
+func (c *PageController) PublicGet(ctx *router.Context) error {
+	item, err := c.Service.GetByPath(ctx.Request.Context(), strings.Trim(ctx.Param("path"), "/"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Page not found"})
+	}
+
+	return c.respondPublic(ctx, item)
+}
+
+// CreatePreviewToken godoc
+// @Summary Create a preview token
+// @Description Create a signed URL showing the page through the public API without authentication, published or not, until it expires after PREVIEW_TOKEN_TTL (Admin only)
+// @Tags App/Pages
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Page id"
+// @Success 201 {object} PreviewTokenResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /pages/{id}/preview-token [post]
+func (c *PageController) CreatePreviewToken(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	token, expires, err := c.Service.PreviewToken(ctx.Request.Context(), uint(id))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to create preview token")
+	}
+	return ctx.JSON(http.StatusCreated, &PreviewTokenResponse{
+		Token:     token,
+		URL:       c.PreviewURL + token,
+		ExpiresAt: expires,
+	})
+}
+
+// ArchivePage godoc
+// @Summary Archive a page
+// @Description Hide a page from lists and the public site without deleting it; it keeps its subpages, revisions and status. Lists show archived pages with archived=true (Admin only)
+// @Tags App/Pages
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Page id"
+// @Success 200 {object} PageResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 423 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /pages/{id}/archive [post]
+func (c *PageController) Archive(ctx *router.Context) error {
+	return c.archive(ctx, true)
+}
+
+// UnarchivePage godoc
+// @Summary Unarchive a page
+// @Description Bring an archived page back to lists, and to the public site when it is published (Admin only)
+// @Tags App/Pages
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Page id"
+// @Success 200 {object} PageResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 423 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /pages/{id}/unarchive [post]
+func (c *PageController) Unarchive(ctx *router.Context) error {
+	return c.archive(ctx, false)
+}
+
+// archive archives the page of the :id parameter or brings it back
+func (c *PageController) archive(ctx *router.Context, archived bool) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	item, err := c.Service.Archive(ctx.Request.Context(), uint(id), archived)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to archive page")
+	}
+	return c.respond(ctx, http.StatusOK, item)
+}
+
+// ReorderPages godoc
+// @Summary Reorder pages
+// @Description Put sibling pages in a manual order, e.g. to pin pages at the top of their section: the first id gets position 0, the second 1 and so on, in one transaction. The pages must share their parent; pages left out keep their position. Public lists show pages by position (Admin only)
+// @Tags App/Pages
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param reorder body crud.ReorderRequest true "Page ids in their new order"
+// @Success 200 {array} PageListResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /pages/reorder [put]
+func (c *PageController) Reorder(ctx *router.Context) error {
+	var req crud.ReorderRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	items, err := c.Service.Reorder(ctx.Request.Context(), req.Ids)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to reorder pages")
+	}
+
+	responses := make([]*PageListResponse, len(items))
+	for i, item := range items {
+		responses[i] = item.ToListResponse()
+	}
+	return ctx.JSON(http.StatusOK, responses)
+}
+
+// DuplicatePage godoc
+// @Summary Duplicate a page
+// @Description Create a draft copy of a page with its blocks, translations and SEO metadata next to it; the slug gets a -copy suffix (-copy-2... when taken). The copy belongs to you and starts its own revisions (Admin only)
+// @Tags App/Pages
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Page id"
+// @Success 201 {object} PageResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /pages/{id}/duplicate [post]
+func (c *PageController) Duplicate(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	item, err := c.Service.Duplicate(ctx.Request.Context(), uint(id), ctx.GetUint("user_id"))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to duplicate page")
+	}
+	return c.respond(ctx, http.StatusCreated, item)
+}
+
+// PreviewPage godoc
+// @Summary Preview a page
+// @Description Get a page with a preview token, published or not, with its blocks and SEO metadata in the request locale; previews aren't cached
+// @Tags App/Pages
+// @Security ApiKeyAuth
+// @Produce json
+// @Param token path string true "Preview token"
+// @Success 200 {object} PageResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /public/page-preview/{token} [get]
+func (c *PageController) Preview(ctx *router.Context) error {
+	item, err := c.Service.GetByPreviewToken(ctx.Request.Context(), ctx.Param("token"))
+	if errors.Is(err, ErrInvalidPreviewToken) {
+		return ctx.JSON(http.StatusUnauthorized, types.ErrorResponse{Error: translation.Error(ctx, err)})
+	}
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Page not found"})
+	}
+
+	// Drafts must not end up in shared caches
+	ctx.SetHeader("Cache-Control", "no-store")
+	return c.respondPublic(ctx, item)
+}
+
+// respondPublic writes a page for the frontend, in the request locale with its SEO metadata
+func (c *PageController) respondPublic(ctx *router.Context, item *Page) error {
+	response := item.ToResponse()
+	response.Blocks = renderBlocks(response.Blocks)
+	model := &Page{}
+	if err := translation.Localize(ctx, model.TableName(), model.TranslatedFields(), response); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to load translations: " + err.Error()})
+	}
+	response.Translations = nil
+
+	var err error
+	if response.Seo, err = seo.Records.Get(ctx, model.TableName(), item.Id); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to load SEO metadata: " + err.Error()})
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// respond writes a page with its translations
+func (c *PageController) respond(ctx *router.Context, status int, item *Page) error {
+	response := item.ToResponse()
+	if err := translation.Localize(ctx, item.TableName(), item.TranslatedFields(), response); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to load translations: " + err.Error()})
+	}
+	return ctx.JSON(status, response)
+}
+
+// fail writes the error response of a service error
+func (c *PageController) fail(ctx *router.Context, err error, message string) error {
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   translation.Error(ctx, err),
+			Details: translation.LocalizeValidation(ctx, validationErrors),
+		})
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Item not found"})
+	}
+	if errors.Is(err, ErrHasSubpages) {
+		return ctx.JSON(http.StatusConflict, types.ErrorResponse{Error: translation.Error(ctx, err)})
+	}
+	if errors.Is(err, ErrNotReviewer) {
+		return ctx.JSON(http.StatusForbidden, types.ErrorResponse{Error: translation.Error(ctx, err)})
+	}
+	return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: message + ": " + err.Error()})
+}
+
+// revisionParams reads the page id and version path parameters
+func revisionParams(ctx *router.Context) (uint, int, error) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return 0, 0, errors.New("Invalid id format")
+	}
+	version, err := strconv.Atoi(ctx.Param("version"))
+	if err != nil || version < 1 {
+		return 0, 0, errors.New("Invalid version")
+	}
+	return uint(id), version, nil
+}
@@ -0,0 +1,144 @@
+package pages
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"base/core/app/notifications"
+	"base/core/logger"
+	"base/core/validator"
+)
+
+const (
+	ExpiringPageEvent = "pages.expiring" // A published page expires within ExpiryWarning
+	ExpirePageEvent   = "pages.expire"   // A published page expired and went back to draft
+)
+
+// ExpiryWarning is how long before a published page expires its author is told
+var ExpiryWarning = 24 * time.Hour
+
+// expiryInterval is how often expired pages are unpublished
+const expiryInterval = time.Minute
+
+// StartExpiring unpublishes expired pages and warns the authors of pages expiring soon now
+// and then every minute, in the background. It can be called more than once.
+func (s *PageService) StartExpiring() {
+	s.expiring.Do(func() {
+		go func() {
+			ticker := time.NewTicker(expiryInterval)
+			defer ticker.Stop()
+			for {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+				if _, err := s.Expire(ctx, time.Now()); err != nil {
+					s.Logger.Error("failed to expire pages", logger.String("error", err.Error()))
+				}
+				cancel()
+				<-ticker.C
+			}
+		}()
+	})
+}
+
+// Expire moves the published pages that expired by now back to draft and removes their
+// expiry, and warns the authors of the pages expiring within ExpiryWarning. It returns the
+// pages it unpublished.
+func (s *PageService) Expire(ctx context.Context, now time.Time) ([]*Page, error) {
+	if err := s.warnExpiring(ctx, now); err != nil {
+		return nil, err
+	}
+
+	var expired []*Page
+	if err := s.DB.WithContext(ctx).Omit("blocks").
+		Where("status = ? AND expires_at <= ?", StatusPublished, now).
+		Order("expires_at").Find(&expired).Error; err != nil {
+		return nil, err
+	}
+
+	result := []*Page{}
+	for _, item := range expired {
+		// Other instances expire the same pages; only one unpublishes each
+		update := s.DB.WithContext(ctx).Model(&Page{}).
+			Where("id = ? AND status = ?", item.Id, StatusPublished).
+			Updates(map[string]any{"status": StatusDraft, "published_at": nil, "expires_at": nil, "expiry_notified_at": nil})
+		if update.Error != nil {
+			return result, update.Error
+		}
+		if update.RowsAffected == 0 {
+			continue
+		}
+
+		item, err := s.GetById(ctx, item.Id)
+		if err != nil {
+			return result, err
+		}
+		result = append(result, item)
+
+		s.Emitter.EmitContext(ctx, UpdatePageEvent, item)
+		s.Emitter.EmitContext(ctx, ExpirePageEvent, item)
+		s.notifyExpiry(ctx, item, ExpirePageEvent, fmt.Sprintf("%q expired and was unpublished", item.Title))
+	}
+	return result, nil
+}
+
+// warnExpiring tells the authors of the published pages expiring within ExpiryWarning, once
+// per expiry date
+func (s *PageService) warnExpiring(ctx context.Context, now time.Time) error {
+	var expiring []*Page
+	if err := s.DB.WithContext(ctx).Omit("blocks").
+		Where("status = ? AND expires_at > ? AND expires_at <= ? AND expiry_notified_at IS NULL",
+			StatusPublished, now, now.Add(ExpiryWarning)).
+		Order("expires_at").Find(&expiring).Error; err != nil {
+		return err
+	}
+
+	for _, item := range expiring {
+		update := s.DB.WithContext(ctx).Model(&Page{}).
+			Where("id = ? AND expiry_notified_at IS NULL", item.Id).
+			Update("expiry_notified_at", now)
+		if update.Error != nil {
+			return update.Error
+		}
+		if update.RowsAffected == 0 {
+			continue
+		}
+		item.ExpiryNotifiedAt = &now
+
+		s.Emitter.EmitContext(ctx, ExpiringPageEvent, item)
+		s.notifyExpiry(ctx, item, ExpiringPageEvent, fmt.Sprintf("%q expires on %s", item.Title,
+			item.ExpiresAt.UTC().Format("2006-01-02 15:04 MST")))
+	}
+	return nil
+}
+
+// notifyExpiry sends the author of a page the notification of an expiry event
+func (s *PageService) notifyExpiry(ctx context.Context, item *Page, event, title string) {
+	if s.Notifications == nil || item.AuthorId == nil {
+		return
+	}
+	_, err := s.Notifications.Create(ctx, &notifications.CreateNotificationRequest{
+		UserId:    *item.AuthorId,
+		Title:     title,
+		Type:      event,
+		ActionUrl: fmt.Sprintf("/pages/%d", item.Id),
+	})
+	if err != nil {
+		s.Logger.Error("failed to send page expiry notification",
+			logger.String("error", err.Error()),
+			logger.Int("page_id", int(item.Id)))
+	}
+}
+
+// checkExpiry returns the error of a page expiring before it is published
+func checkExpiry(item *Page) *validator.ValidationError {
+	if item.ExpiresAt == nil || item.PublishedAt == nil || item.ExpiresAt.After(*item.PublishedAt) {
+		return nil
+	}
+	return &validator.ValidationError{
+		Field:   "expires_at",
+		Tag:     "gt",
+		Value:   item.ExpiresAt.String(),
+		Param:   "published_at",
+		Message: "expires_at must be greater than published_at",
+	}
+}
@@ -0,0 +1,327 @@
+package pages
+
+import (
+	"maps"
+	"time"
+
+	"base/app/seo"
+	"base/core/crud"
+	"base/core/markdown"
+	"base/core/sanitize"
+	"base/core/translation"
+
+	"gorm.io/gorm"
+)
+
+// Page statuses; Workflow lists how pages go through them
+const (
+	StatusDraft     = "draft"     // Only visible to admins
+	StatusInReview  = "in_review" // Submitted to its reviewer
+	StatusApproved  = "approved"  // Approved by its reviewer, ready to be published
+	StatusPublished = "published" // Public from PublishedAt on, until ExpiresAt
+)
+
+// Block types
+const (
+	BlockHeading   = "heading"   // data: text, level (1-6)
+	BlockParagraph = "paragraph" // data: text
+	BlockHtml      = "html"      // data: html
+	BlockImage     = "image"     // data: url, alt, caption
+	BlockQuote     = "quote"     // data: text, cite
+	BlockList      = "list"      // data: items, ordered
+	BlockEmbed     = "embed"     // data: url, e.g. a video
+	BlockButton    = "button"    // data: label, url
+	BlockMarkdown  = "markdown"  // data: markdown; public responses add its html
+)
+
+// blockFields are the data fields each block type requires
+var blockFields = map[string][]string{
+	BlockHeading:   {"text"},
+	BlockParagraph: {"text"},
+	BlockHtml:      {"html"},
+	BlockImage:     {"url"},
+	BlockQuote:     {"text"},
+	BlockList:      {"items"},
+	BlockEmbed:     {"url"},
+	BlockButton:    {"label", "url"},
+	BlockMarkdown:  {"markdown"},
+}
+
+// blockURLs are the data fields of each block type that hold URLs
+var blockURLs = map[string][]string{
+	BlockImage:  {"url"},
+	BlockEmbed:  {"url"},
+	BlockButton: {"url"},
+}
+
+// Block is a piece of the content of a page; frontends render it by its type
+type Block struct {
+	Type string         `json:"type" validate:"required,oneof=heading paragraph html image quote list embed button markdown"`
+	Data map[string]any `json:"data"`
+}
+
+// sanitizeBlocks returns the blocks with the HTML of html blocks sanitized by the policy of
+// the pages.html field
+func sanitizeBlocks(blocks []Block) []Block {
+	if blocks == nil {
+		return nil
+	}
+	result := make([]Block, len(blocks))
+	for i, block := range blocks {
+		result[i] = block
+		value, ok := block.Data["html"].(string)
+		if block.Type != BlockHtml || !ok {
+			continue
+		}
+		result[i].Data = maps.Clone(block.Data)
+		result[i].Data["html"] = sanitize.HTML("pages.html", value)
+	}
+	return result
+}
+
+// renderBlocks returns the blocks with the html of markdown blocks, for public responses
+func renderBlocks(blocks []Block) []Block {
+	result := make([]Block, len(blocks))
+	for i, block := range blocks {
+		result[i] = block
+		source, ok := block.Data["markdown"].(string)
+		if block.Type != BlockMarkdown || !ok {
+			continue
+		}
+		result[i].Data = maps.Clone(block.Data)
+		result[i].Data["html"] = markdown.Render(source)
+	}
+	return result
+}
+
+// Page is a static page of the site, such as "About us". Pages nest through ParentId and
+// are served by their Path, the slugs of their ancestors and their own joined by "/".
+type Page struct {
+	Id          uint           `json:"id" gorm:"primarykey"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	Title       string         `json:"title" gorm:"size:255"`
+	Slug        string         `json:"slug" gorm:"size:255"`
+	Path        string         `json:"path" gorm:"size:1024;index"` // e.g. about/team
+	ParentId    *uint          `json:"parent_id" gorm:"index"`
+	Position    int            `json:"position"`
+	Template    string         `json:"template" gorm:"size:64"` // Layout the frontend renders the page with
+	Blocks      []Block        `json:"blocks" gorm:"type:text;serializer:json"`
+	Status      string         `json:"status" gorm:"size:16;index"`
+	PublishedAt *time.Time     `json:"published_at" gorm:"index"`
+	ExpiresAt   *time.Time     `json:"expires_at" gorm:"index"`  // Unpublished from then on, see Expire
+	Version     int            `json:"version"`                  // Version of the latest revision
+	AuthorId    *uint          `json:"author_id" gorm:"index"`   // Who created it; told about review decisions
+	ReviewerId  *uint          `json:"reviewer_id" gorm:"index"` // Who approves or rejects it
+	ArchivedAt  *time.Time     `json:"archived_at" gorm:"index"` // Hidden from lists and the public site, see crud.Archive
+
+	// When the author was told the page expires soon, see ExpiryWarning
+	ExpiryNotifiedAt *time.Time `json:"-"`
+}
+
+// TableName returns the table name for the Page model
+func (m *Page) TableName() string {
+	return "pages"
+}
+
+// GetId returns the Id of the model
+func (m *Page) GetId() uint {
+	return m.Id
+}
+
+// TranslatedFields returns the fields that can have per-locale values
+func (m *Page) TranslatedFields() []string {
+	return []string{"title"}
+}
+
+// IsPublic reports whether the page is published, its publication date has come and it
+// hasn't expired
+func (m *Page) IsPublic() bool {
+	now := time.Now()
+	return m.Status == StatusPublished && m.PublishedAt != nil && !m.PublishedAt.After(now) &&
+		(m.ExpiresAt == nil || m.ExpiresAt.After(now))
+}
+
+// PageRevision is a saved version of the content of a page. Every change to the content
+// adds one, so earlier versions can be looked at and restored.
+type PageRevision struct {
+	Id        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	PageId    uint      `json:"page_id" gorm:"uniqueIndex:idx_page_revisions_version"`
+	Version   int       `json:"version" gorm:"uniqueIndex:idx_page_revisions_version"`
+	UserId    *uint     `json:"user_id"` // Who saved it
+	Note      string    `json:"note" gorm:"size:255"`
+	Title     string    `json:"title" gorm:"size:255"`
+	Slug      string    `json:"slug" gorm:"size:255"`
+	Template  string    `json:"template" gorm:"size:64"`
+	Blocks    []Block   `json:"blocks,omitempty" gorm:"type:text;serializer:json"`
+}
+
+// TableName returns the table name for the PageRevision model
+func (m *PageRevision) TableName() string {
+	return "page_revisions"
+}
+
+// CreatePageRequest represents the request payload for creating a Page
+type CreatePageRequest struct {
+	Title       string     `json:"title" validate:"required,max=255"`
+	Slug        string     `json:"slug" validate:"omitempty,max=255"` // Generated from the title when empty
+	ParentId    *uint      `json:"parent_id,omitempty"`
+	Position    int        `json:"position"`
+	Template    string     `json:"template" validate:"max=64"`
+	Blocks      []Block    `json:"blocks" validate:"dive"`
+	Status      string     `json:"status" validate:"omitempty,oneof=draft in_review approved published"` // Defaults to draft
+	PublishedAt *time.Time `json:"published_at,omitempty"`                                               // Defaults to when it is published
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`                                                 // Unpublished from then on
+	Note        string     `json:"note" validate:"max=255"`                                              // Note of the first revision
+
+	// Translations of the translated fields by locale, e.g. {"title": {"de": "..."}}
+	Translations translation.FieldValues `json:"translations,omitempty"`
+}
+
+// UpdatePageRequest represents the request payload for updating a Page. Omitted fields
+// are left unchanged; blocks replaces the blocks and a parent_id of 0 makes it a
+// top-level page. Changes to the content are saved as a new revision with the note.
+type UpdatePageRequest struct {
+	Title       *string    `json:"title,omitempty" validate:"omitempty,min=1,max=255"`
+	Slug        *string    `json:"slug,omitempty" validate:"omitempty,max=255"`
+	ParentId    *uint      `json:"parent_id,omitempty"`
+	Position    *int       `json:"position,omitempty"`
+	Template    *string    `json:"template,omitempty" validate:"omitempty,max=64"`
+	Blocks      *[]Block   `json:"blocks,omitempty" validate:"omitempty,dive"`
+	Status      *string    `json:"status,omitempty" validate:"omitempty,oneof=draft in_review approved published"` // See Workflow
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`  // The zero time (0001-01-01T00:00:00Z) removes it
+	ReviewerId  *uint      `json:"reviewer_id,omitempty"` // 0 removes the reviewer
+	Note        string     `json:"note,omitempty" validate:"max=255"`
+
+	// Translations of the translated fields by locale; locales left out are unchanged
+	Translations translation.FieldValues `json:"translations,omitempty"`
+}
+
+// SubmitPageRequest represents the request payload for submitting a page for review
+type SubmitPageRequest struct {
+	ReviewerId *uint  `json:"reviewer_id,omitempty"` // Defaults to the current reviewer
+	Note       string `json:"note" validate:"max=1000"`
+}
+
+// ReviewPageRequest represents the request payload for approving or rejecting a page
+type ReviewPageRequest struct {
+	Note string `json:"note" validate:"max=1000"` // Told to the author, e.g. what to change
+}
+
+// RestoreRequest represents the request payload for restoring a revision
+type RestoreRequest struct {
+	Note string `json:"note,omitempty" validate:"max=255"` // Defaults to "Restored version N"
+}
+
+// PageResponse represents the API response for a Page
+type PageResponse struct {
+	Id          uint       `json:"id"`
+	Title       string     `json:"title"`
+	Slug        string     `json:"slug"`
+	Path        string     `json:"path"`
+	ParentId    *uint      `json:"parent_id"`
+	Position    int        `json:"position"`
+	Template    string     `json:"template"`
+	Blocks      []Block    `json:"blocks"`
+	Status      string     `json:"status"`
+	PublishedAt *time.Time `json:"published_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	Version     int        `json:"version"`
+	AuthorId    *uint      `json:"author_id"`
+	ReviewerId  *uint      `json:"reviewer_id"`
+	ArchivedAt  *time.Time `json:"archived_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// SEO metadata, in public responses (see seo.Records)
+	Seo *seo.Metadata `json:"seo,omitempty"`
+
+	// All translations, by field and locale
+	Translations translation.FieldValues `json:"translations,omitempty"`
+}
+
+// PreviewTokenResponse represents a preview token and the public URL showing the page
+type PreviewTokenResponse struct {
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// PageListResponse represents a page in lists, without its blocks
+type PageListResponse struct {
+	Id          uint       `json:"id"`
+	Title       string     `json:"title"`
+	Slug        string     `json:"slug"`
+	Path        string     `json:"path"`
+	ParentId    *uint      `json:"parent_id"`
+	Position    int        `json:"position"`
+	Status      string     `json:"status"`
+	PublishedAt *time.Time `json:"published_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	ReviewerId  *uint      `json:"reviewer_id"`
+	ArchivedAt  *time.Time `json:"archived_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// ToResponse converts the model to an API response
+func (m *Page) ToResponse() *PageResponse {
+	if m == nil {
+		return nil
+	}
+	blocks := m.Blocks
+	if blocks == nil {
+		blocks = []Block{}
+	}
+	return &PageResponse{
+		Id:          m.Id,
+		Title:       m.Title,
+		Slug:        m.Slug,
+		Path:        m.Path,
+		ParentId:    m.ParentId,
+		Position:    m.Position,
+		Template:    m.Template,
+		Blocks:      blocks,
+		Status:      m.Status,
+		PublishedAt: m.PublishedAt,
+		ExpiresAt:   m.ExpiresAt,
+		Version:     m.Version,
+		AuthorId:    m.AuthorId,
+		ReviewerId:  m.ReviewerId,
+		ArchivedAt:  m.ArchivedAt,
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+	}
+}
+
+// ToListResponse converts the model to a list response
+func (m *Page) ToListResponse() *PageListResponse {
+	if m == nil {
+		return nil
+	}
+	return &PageListResponse{
+		Id:          m.Id,
+		Title:       m.Title,
+		Slug:        m.Slug,
+		Path:        m.Path,
+		ParentId:    m.ParentId,
+		Position:    m.Position,
+		Status:      m.Status,
+		PublishedAt: m.PublishedAt,
+		ExpiresAt:   m.ExpiresAt,
+		ReviewerId:  m.ReviewerId,
+		ArchivedAt:  m.ArchivedAt,
+		UpdatedAt:   m.UpdatedAt,
+	}
+}
+
+// PageFilter narrows page lists
+type PageFilter struct {
+	Query      string
+	Status     string
+	ParentId   *uint         // 0 for top-level pages
+	ReviewerId *uint         // e.g. the pages waiting for a reviewer
+	Archived   crud.Archived // Archived pages are left out by default
+}
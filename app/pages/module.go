@@ -0,0 +1,207 @@
+package pages
+
+import (
+	"errors"
+
+	"base/app/seo"
+	"base/core/app/activities"
+	"base/core/app/authorization"
+	"base/core/app/media"
+	"base/core/app/notifications"
+	"base/core/app/reports"
+	"base/core/app/trash"
+	"base/core/app/users"
+	"base/core/locks"
+	"base/core/module"
+	"base/core/router"
+
+	"gorm.io/gorm"
+)
+
+// Module provides the static pages of the site, built from content blocks and kept in
+// revisions. Management endpoints are restricted to admins; published pages are public.
+type Module struct {
+	module.DefaultModule
+	DB         *gorm.DB
+	Service    *PageService
+	Controller *PageController
+}
+
+// Init creates and initializes the pages module with all dependencies
+func Init(deps module.Dependencies) module.Module {
+	registerEvents()
+	service := NewPageService(deps.DB, deps.Emitter, deps.Logger)
+	service.Notifications = notifications.NewNotificationService(deps.DB, deps.Emitter, deps.Storage, deps.Logger)
+	service.Listen()
+	controller := NewPageController(service)
+	if deps.Config != nil {
+		service.PreviewSecret = []byte(deps.Config.JWTSecret)
+		service.PreviewTTL = deps.Config.PreviewTokenTTL
+		controller.PreviewURL = deps.Config.BaseURL + "/api/public/page-preview/"
+	}
+
+	reports.RegisterEntity(reports.Entity{
+		Name:       "pages",
+		Columns:    []string{"id", "title", "slug", "path", "parent_id", "position", "template", "status", "published_at", "version", "archived_at", "created_at", "updated_at"},
+		SoftDelete: true,
+	})
+	seo.RegisterEntity(seo.Entity{
+		Name:        "pages",
+		Path:        "/pages",
+		Model:       &Page{},
+		DeleteEvent: DeletePageEvent,
+	})
+	users.RegisterOwned(users.Owned{Name: "pages", Model: &Page{}, Column: "author_id"})
+	activities.RegisterAssignable(activities.Assignable{
+		Name:   "pages",
+		Model:  &Page{},
+		Column: "reviewer_id",
+		Title:  "title",
+		Scope: func(db *gorm.DB) *gorm.DB {
+			return db.Where("status = ?", StatusInReview)
+		},
+	})
+	activities.RegisterWatchable(activities.Watchable{
+		Name: "pages",
+		Path: "/pages",
+		Events: map[string]string{
+			UpdatePageEvent:  "was updated",
+			PublishPageEvent: "was published",
+			DeletePageEvent:  "was deleted",
+			ExpirePageEvent:  "expired",
+		},
+		Title: func(record any) string {
+			if page, ok := record.(*Page); ok {
+				return page.Title
+			}
+			return ""
+		},
+	})
+	media.RegisterUsageEntity(media.UsageEntity{
+		Name:        "pages",
+		Model:       &Page{},
+		SaveEvents:  []string{CreatePageEvent, UpdatePageEvent, PublishPageEvent, RestorePageEvent},
+		DeleteEvent: DeletePageEvent,
+		Content: func(record any) (string, map[string]any) {
+			page, ok := record.(*Page)
+			if !ok {
+				return "", nil
+			}
+			return page.Title, map[string]any{"blocks": page.Blocks}
+		},
+	})
+	trash.RegisterEntity(trash.Entity{
+		Name:        "pages",
+		Model:       &Page{},
+		Title:       "title",
+		DeleteEvent: DeletePageEvent,
+		UpdateEvent: UpdatePageEvent,
+	})
+
+	// Cached public pages are dropped when a page changes
+	router.Responses.InvalidateOn(deps.Emitter, cacheTag,
+		CreatePageEvent, UpdatePageEvent, DeletePageEvent, PublishPageEvent, RestorePageEvent, ReorderPageEvent)
+
+	return &Module{
+		DB:         deps.DB,
+		Service:    service,
+		Controller: controller,
+	}
+}
+
+// Routes registers the admin and the public page routes
+func (m *Module) Routes(router *router.RouterGroup) {
+	group := router.Group("", authorization.RequireAdmin(authorization.NewAuthorizationService(m.DB)))
+	m.Controller.Routes(group)
+	seo.Routes(group, "pages")
+	locks.Routes(group, "pages")
+
+	m.Controller.PublicRoutes(router)
+}
+
+func (m *Module) Init() error {
+	if err := m.SeedPermissions(); err != nil {
+		return err
+	}
+
+	// Unpublish expired pages in the background
+	m.Service.StartExpiring()
+	return nil
+}
+
+func (m *Module) SeedPermissions() error {
+	// Ensure permissions table exists before seeding
+	if err := m.DB.AutoMigrate(&authorization.Permission{}); err != nil {
+		return err
+	}
+
+	// Define permissions for page CRUD operations
+	permissions := []authorization.Permission{
+		{
+			Name:         "page list",
+			Description:  "View page list",
+			ResourceType: "page",
+			Action:       "list",
+		},
+		{
+			Name:         "page read",
+			Description:  "View pages and their revisions",
+			ResourceType: "page",
+			Action:       "read",
+		},
+		{
+			Name:         "page create",
+			Description:  "Create pages",
+			ResourceType: "page",
+			Action:       "create",
+		},
+		{
+			Name:         "page update",
+			Description:  "Update, publish and restore pages",
+			ResourceType: "page",
+			Action:       "update",
+		},
+		{
+			Name:         "page delete",
+			Description:  "Delete pages",
+			ResourceType: "page",
+			Action:       "delete",
+		},
+	}
+
+	// Upsert permissions - create or update if they exist
+	for _, permission := range permissions {
+		var existingPermission authorization.Permission
+		result := m.DB.Where("resource_type = ? AND action = ?", permission.ResourceType, permission.Action).First(&existingPermission)
+
+		if result.Error != nil && errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// Create new permission
+			if err := m.DB.Create(&permission).Error; err != nil {
+				return err
+			}
+		} else if result.Error == nil {
+			// Update existing permission
+			existingPermission.Name = permission.Name
+			existingPermission.Description = permission.Description
+			if err := m.DB.Save(&existingPermission).Error; err != nil {
+				return err
+			}
+		} else {
+			// Return any other error
+			return result.Error
+		}
+	}
+
+	return nil
+}
+
+func (m *Module) Migrate() error {
+	return m.DB.AutoMigrate(&Page{}, &PageRevision{})
+}
+
+func (m *Module) GetModels() []any {
+	return []any{
+		&Page{},
+		&PageRevision{},
+	}
+}
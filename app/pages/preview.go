@@ -0,0 +1,77 @@
+package pages
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// previewPurpose derives the key of preview tokens from the JWT secret, so preview tokens
+// and user tokens can't be used for each other
+const previewPurpose = "pages.preview"
+
+// ErrInvalidPreviewToken is returned for preview tokens that are malformed, forged or expired
+var ErrInvalidPreviewToken = errors.New("invalid or expired preview token")
+
+// previewClaims are the claims of a preview token
+type previewClaims struct {
+	PageId uint `json:"page_id"`
+	jwt.RegisteredClaims
+}
+
+// PreviewToken returns a signed token showing a page to anyone who has it, published or
+// not, and when it expires
+func (s *PageService) PreviewToken(ctx context.Context, id uint) (string, time.Time, error) {
+	if len(s.PreviewSecret) == 0 {
+		return "", time.Time{}, errors.New("preview tokens need a JWT secret")
+	}
+	if err := s.DB.WithContext(ctx).Select("id").First(&Page{}, id).Error; err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := time.Now()
+	expires := now.Add(s.PreviewTTL).Truncate(time.Second)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, previewClaims{
+		PageId: id,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expires),
+		},
+	}).SignedString(s.previewKey())
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expires, nil
+}
+
+// GetByPreviewToken returns the page of a preview token, published or not
+func (s *PageService) GetByPreviewToken(ctx context.Context, token string) (*Page, error) {
+	if len(s.PreviewSecret) == 0 {
+		return nil, ErrInvalidPreviewToken
+	}
+
+	claims := &previewClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) {
+		return s.previewKey(), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil || claims.PageId == 0 {
+		return nil, ErrInvalidPreviewToken
+	}
+
+	item := &Page{}
+	if err := s.DB.WithContext(ctx).First(item, claims.PageId).Error; err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// previewKey returns the key preview tokens are signed with
+func (s *PageService) previewKey() []byte {
+	mac := hmac.New(sha256.New, s.PreviewSecret)
+	mac.Write([]byte(previewPurpose))
+	return mac.Sum(nil)
+}
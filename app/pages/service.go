@@ -0,0 +1,774 @@
+package pages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+	"time"
+
+	"base/core/app/notifications"
+	"base/core/crud"
+	"base/core/emitter"
+	"base/core/helper"
+	"base/core/logger"
+	"base/core/translation"
+	"base/core/types"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+const (
+	CreatePageEvent  = "pages.create"
+	UpdatePageEvent  = "pages.update"
+	DeletePageEvent  = "pages.delete"
+	PublishPageEvent = "pages.publish" // A page was published
+	RestorePageEvent = "pages.restore" // A revision of a page was restored
+	SubmitPageEvent  = "pages.submit"  // A page was submitted for review, with a Review
+	ApprovePageEvent = "pages.approve" // A page in review was approved, with a Review
+	RejectPageEvent  = "pages.reject"  // A page in review was sent back to draft, with a Review
+	ReorderPageEvent = "pages.reorder" // Sibling pages were put in a new order, with the pages
+
+	// cacheTag tags the cached public page responses
+	cacheTag = "pages"
+)
+
+// registerEvents adds the events of the module to the event catalog
+func registerEvents() {
+	emitter.RegisterEvents(
+		emitter.EventType{Name: CreatePageEvent, Description: "A page was created", Payload: &Page{}},
+		emitter.EventType{Name: UpdatePageEvent, Description: "A page was updated", Payload: &Page{}},
+		emitter.EventType{Name: DeletePageEvent, Description: "A page was deleted", Payload: &Page{}},
+		emitter.EventType{Name: PublishPageEvent, Description: "A page was published", Payload: &Page{}},
+		emitter.EventType{Name: RestorePageEvent, Description: "A revision of a page was restored", Payload: &Page{}},
+		emitter.EventType{Name: SubmitPageEvent, Description: "A page was submitted for review", Payload: &Review{}},
+		emitter.EventType{Name: ApprovePageEvent, Description: "A page in review was approved", Payload: &Review{}},
+		emitter.EventType{Name: RejectPageEvent, Description: "A page in review was sent back to draft", Payload: &Review{}},
+		emitter.EventType{Name: ReorderPageEvent, Description: "Sibling pages were put in a new order", Payload: []*Page{}},
+		emitter.EventType{Name: ExpiringPageEvent, Description: "A published page expires soon", Payload: &Page{}},
+		emitter.EventType{Name: ExpirePageEvent, Description: "A published page expired and was unpublished", Payload: &Page{}},
+	)
+}
+
+// maxRevisions is how many revisions are kept per page; older ones are deleted
+const maxRevisions = 50
+
+// ErrHasSubpages is returned when deleting a page that still has subpages
+var ErrHasSubpages = errors.New("page has subpages, move or delete them first")
+
+// PageService manages the static pages of the site and their revisions
+type PageService struct {
+	DB      *gorm.DB
+	Emitter *emitter.Emitter
+	Logger  logger.Logger
+	slugs   *helper.SlugHelper
+
+	// Notifications tells reviewers and authors about reviews, if set
+	Notifications *notifications.NotificationService
+
+	// Preview tokens: the secret their key is derived from (the JWT secret) and how long
+	// they are valid
+	PreviewSecret []byte
+	PreviewTTL    time.Duration
+
+	expiring sync.Once // Starts the expiry job once, see StartExpiring
+}
+
+func NewPageService(db *gorm.DB, emitter *emitter.Emitter, logger logger.Logger) *PageService {
+	return &PageService{
+		DB:      db,
+		Emitter: emitter,
+		Logger:  logger,
+		slugs:   helper.NewSlugHelper(),
+	}
+}
+
+// Create creates a page and its first revision
+func (s *PageService) Create(ctx context.Context, req *CreatePageRequest, userId uint) (*Page, error) {
+	if err := ValidatePageCreateRequest(req); err != nil {
+		return nil, err
+	}
+	if err := translation.ValidateFieldValues(req.Translations, (&Page{}).TranslatedFields()); err != nil {
+		return nil, err
+	}
+
+	item := &Page{
+		Title:       req.Title,
+		ParentId:    req.ParentId,
+		Position:    req.Position,
+		Template:    req.Template,
+		Blocks:      sanitizeBlocks(req.Blocks),
+		Status:      StatusDraft,
+		PublishedAt: req.PublishedAt,
+		ExpiresAt:   req.ExpiresAt,
+	}
+	if userId != 0 {
+		item.AuthorId = &userId
+	}
+	if req.Status != "" {
+		item.Status = req.Status
+	}
+	publish(item)
+
+	var errs validator.ValidationErrors
+	if err := checkTransition("", item.Status); err != nil {
+		errs = append(errs, *err)
+	}
+	if err := checkExpiry(item); err != nil {
+		errs = append(errs, *err)
+	}
+	if err := s.checkParent(ctx, item); err != nil {
+		errs = append(errs, *err)
+	}
+	slug, slugErrs := s.slug(ctx, req.Slug, req.Title, item)
+	errs = append(errs, slugErrs...)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	item.Slug = slug
+
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		path, err := s.path(tx, item)
+		if err != nil {
+			return err
+		}
+		item.Path = path
+		if err := tx.Create(item).Error; err != nil {
+			return err
+		}
+		if err := s.revise(tx, item, userId, req.Note); err != nil {
+			return err
+		}
+		return translation.Fields.WithDB(tx).Set(item.TableName(), item.Id, req.Translations)
+	})
+	if err != nil {
+		s.Logger.Error("failed to create page", logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	// Emit create event
+	s.Emitter.EmitContext(ctx, CreatePageEvent, item)
+	if item.Status == StatusPublished {
+		s.Emitter.EmitContext(ctx, PublishPageEvent, item)
+	}
+
+	return item, nil
+}
+
+// Update updates the fields of a page that are set in the request. Changes to the content
+// are saved as a new revision; moving the page or changing its slug moves its subpages.
+func (s *PageService) Update(ctx context.Context, id uint, req *UpdatePageRequest, userId uint) (*Page, error) {
+	item, err := s.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidatePageUpdateRequest(req, id); err != nil {
+		return nil, err
+	}
+	if err := translation.ValidateFieldValues(req.Translations, item.TranslatedFields()); err != nil {
+		return nil, err
+	}
+
+	before := content(item)
+	wasPublished := item.Status == StatusPublished
+	var errs validator.ValidationErrors
+
+	if req.Title != nil {
+		item.Title = *req.Title
+	}
+	if req.ParentId != nil {
+		item.ParentId = req.ParentId
+		if *req.ParentId == 0 {
+			item.ParentId = nil
+		}
+		if err := s.checkParent(ctx, item); err != nil {
+			errs = append(errs, *err)
+		}
+	}
+	if req.Slug != nil || req.ParentId != nil {
+		requested := item.Slug
+		if req.Slug != nil {
+			requested = *req.Slug
+		}
+		slug, slugErrs := s.slug(ctx, requested, item.Title, item)
+		errs = append(errs, slugErrs...)
+		item.Slug = slug
+	}
+	if req.Position != nil {
+		item.Position = *req.Position
+	}
+	if req.Template != nil {
+		item.Template = *req.Template
+	}
+	if req.Blocks != nil {
+		item.Blocks = sanitizeBlocks(*req.Blocks)
+	}
+	if req.PublishedAt != nil {
+		item.PublishedAt = req.PublishedAt
+	}
+	if req.ExpiresAt != nil {
+		item.ExpiresAt = req.ExpiresAt
+		if req.ExpiresAt.IsZero() {
+			item.ExpiresAt = nil
+		}
+		// The author is told about the new date
+		item.ExpiryNotifiedAt = nil
+	}
+	if req.Status != nil && *req.Status != item.Status {
+		if err := checkTransition(item.Status, *req.Status); err != nil {
+			errs = append(errs, *err)
+		} else if *req.Status == StatusApproved && !isReviewer(item, userId) {
+			return nil, ErrNotReviewer
+		}
+		item.Status = *req.Status
+		if item.Status == StatusDraft && req.PublishedAt == nil {
+			item.PublishedAt = nil
+		}
+	}
+	if req.ReviewerId != nil {
+		item.ReviewerId = nil
+		if *req.ReviewerId != 0 {
+			item.ReviewerId = req.ReviewerId
+			if err := s.checkReviewer(ctx, *req.ReviewerId); err != nil {
+				errs = append(errs, *err)
+			}
+		}
+	}
+	publish(item)
+	if err := checkExpiry(item); err != nil {
+		errs = append(errs, *err)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	oldPath := item.Path
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		path, err := s.path(tx, item)
+		if err != nil {
+			return err
+		}
+		item.Path = path
+		if !reflect.DeepEqual(before, content(item)) {
+			if err := s.revise(tx, item, userId, req.Note); err != nil {
+				return err
+			}
+		}
+		if err := tx.Save(item).Error; err != nil {
+			return err
+		}
+		if item.Path != oldPath {
+			if err := s.movePaths(tx, item); err != nil {
+				return err
+			}
+		}
+		return translation.Fields.WithDB(tx).Set(item.TableName(), item.Id, req.Translations)
+	})
+	if err != nil {
+		s.Logger.Error("failed to update page",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return nil, err
+	}
+
+	// Emit update event
+	s.Emitter.EmitContext(ctx, UpdatePageEvent, item)
+	if !wasPublished && item.Status == StatusPublished {
+		s.Emitter.EmitContext(ctx, PublishPageEvent, item)
+	}
+
+	return item, nil
+}
+
+// Delete deletes a page without subpages. Its revisions are kept.
+func (s *PageService) Delete(ctx context.Context, id uint) error {
+	item, err := s.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	var subpages int64
+	if err := s.DB.WithContext(ctx).Model(&Page{}).Where("parent_id = ?", item.Id).Count(&subpages).Error; err != nil {
+		return err
+	}
+	if subpages > 0 {
+		return ErrHasSubpages
+	}
+
+	if err := s.DB.WithContext(ctx).Delete(item).Error; err != nil {
+		s.Logger.Error("failed to delete page",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return err
+	}
+	if err := translation.Fields.WithDB(s.DB.WithContext(ctx)).Delete(item.TableName(), item.Id); err != nil {
+		s.Logger.Warn("failed to delete page translations",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+	}
+
+	// Emit delete event
+	s.Emitter.EmitContext(ctx, DeletePageEvent, item)
+
+	return nil
+}
+
+// Duplicate saves a draft copy of a page with its translations and SEO metadata, under its
+// slug with a -copy suffix next to it. The copy starts its own revisions and belongs to the
+// user copying it.
+func (s *PageService) Duplicate(ctx context.Context, id uint, userId uint) (*Page, error) {
+	var item *Page
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		item, err = crud.Copy(tx, id, crud.CopyOptions[Page]{
+			Change: func(tx *gorm.DB, copied *Page) error {
+				copied.Status = StatusDraft
+				copied.PublishedAt = nil
+				copied.ExpiresAt, copied.ExpiryNotifiedAt = nil, nil
+				copied.Version = 0
+				copied.AuthorId, copied.ReviewerId = nil, nil
+				copied.ArchivedAt = nil
+				if userId != 0 {
+					copied.AuthorId = &userId
+				}
+
+				slug, err := crud.UniqueCopy(copied.Slug, slugTaken(tx, copied))
+				if err != nil {
+					return err
+				}
+				copied.Slug = slug
+				copied.Path, err = s.path(tx, copied)
+				return err
+			},
+		})
+		if err != nil {
+			return err
+		}
+		return s.revise(tx, item, userId, fmt.Sprintf("Copy of page %d", id))
+	})
+	if err != nil {
+		s.Logger.Error("failed to duplicate page",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return nil, err
+	}
+
+	// Emit create event
+	s.Emitter.EmitContext(ctx, CreatePageEvent, item)
+
+	return item, nil
+}
+
+// Archive hides a page from lists and the public site without deleting it, or brings it
+// back. Archiving doesn't save revisions.
+func (s *PageService) Archive(ctx context.Context, id uint, archived bool) (*Page, error) {
+	if err := crud.Archive(s.DB.WithContext(ctx), &Page{}, id, archived); err != nil {
+		return nil, err
+	}
+
+	item, err := s.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Emit update event
+	s.Emitter.EmitContext(ctx, UpdatePageEvent, item)
+
+	return item, nil
+}
+
+// Reorder puts sibling pages in the order of ids, e.g. to pin pages at the top of their
+// section, and returns them in their new order. Reordering doesn't save revisions.
+func (s *PageService) Reorder(ctx context.Context, ids []uint) ([]*Page, error) {
+	if err := crud.Reorder(s.DB.WithContext(ctx), &Page{}, ids, "parent_id"); err != nil {
+		return nil, err
+	}
+
+	var items []*Page
+	if err := s.DB.WithContext(ctx).Omit("blocks").Where("id IN ?", ids).Order("position, title").Find(&items).Error; err != nil {
+		s.Logger.Error("failed to get reordered pages",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	s.Emitter.EmitContext(ctx, ReorderPageEvent, items)
+
+	return items, nil
+}
+
+// GetById returns a page by id
+func (s *PageService) GetById(ctx context.Context, id uint) (*Page, error) {
+	item := &Page{}
+	if err := s.DB.WithContext(ctx).First(item, id).Error; err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// GetByPath returns a public page by its path, for the public site
+func (s *PageService) GetByPath(ctx context.Context, path string) (*Page, error) {
+	item := &Page{}
+	if err := s.public(s.DB.WithContext(ctx)).Where("path = ?", path).First(item).Error; err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// GetPublished returns the public pages ordered by position and title, for navigation
+func (s *PageService) GetPublished(ctx context.Context) ([]*Page, error) {
+	var items []*Page
+	if err := s.public(s.DB.WithContext(ctx)).Omit("blocks").Order("position, title").Find(&items).Error; err != nil {
+		s.Logger.Error("failed to get published pages", logger.String("error", err.Error()))
+		return nil, err
+	}
+	return items, nil
+}
+
+// GetPublishedByIds returns the public pages of the given ids without their blocks
+func (s *PageService) GetPublishedByIds(ctx context.Context, ids []uint) ([]*Page, error) {
+	var items []*Page
+	if err := s.public(s.DB.WithContext(ctx)).Omit("blocks").Where("id IN ?", ids).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// BlocksOf returns the blocks of the pages, by page id
+func (s *PageService) BlocksOf(ctx context.Context, ids []uint) (map[uint][]Block, error) {
+	var items []*Page
+	if err := s.DB.WithContext(ctx).Select("id", "blocks").Where("id IN ?", ids).Find(&items).Error; err != nil {
+		s.Logger.Error("failed to load page blocks", logger.String("error", err.Error()))
+		return nil, err
+	}
+	result := make(map[uint][]Block, len(items))
+	for _, item := range items {
+		result[item.Id] = item.Blocks
+		if result[item.Id] == nil {
+			result[item.Id] = []Block{}
+		}
+	}
+	return result, nil
+}
+
+// GetAll returns a page of pages without their blocks, ordered by path so subpages follow
+// their parent
+func (s *PageService) GetAll(ctx context.Context, page *int, limit *int, filter PageFilter) (*types.PaginatedResponse, error) {
+	var items []*Page
+	var total int64
+
+	query := s.DB.WithContext(ctx).Model(&Page{})
+	if filter.Query != "" {
+		like := "%" + filter.Query + "%"
+		query = query.Where("title LIKE ? OR path LIKE ?", like, like)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.ReviewerId != nil {
+		query = query.Where("reviewer_id = ?", *filter.ReviewerId)
+	}
+	if filter.ParentId != nil {
+		if *filter.ParentId == 0 {
+			query = query.Where("parent_id IS NULL")
+		} else {
+			query = query.Where("parent_id = ?", *filter.ParentId)
+		}
+	}
+	query = query.Scopes(filter.Archived.Scope("archived_at"))
+
+	// Set default values if nil
+	defaultPage := 1
+	defaultLimit := 10
+	if page == nil {
+		page = &defaultPage
+	}
+	if limit == nil {
+		limit = &defaultLimit
+	}
+
+	// Get total count
+	if err := query.Count(&total).Error; err != nil {
+		s.Logger.Error("failed to count pages",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	offset := (*page - 1) * *limit
+	if err := query.Omit("blocks").Order("path").Offset(offset).Limit(*limit).Find(&items).Error; err != nil {
+		s.Logger.Error("failed to get pages",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	responses := make([]*PageListResponse, 0, len(items))
+	for _, item := range items {
+		responses = append(responses, item.ToListResponse())
+	}
+
+	// Calculate total pages
+	totalPages := int(math.Ceil(float64(total) / float64(*limit)))
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	return &types.PaginatedResponse{
+		Data: responses,
+		Pagination: types.Pagination{
+			Total:      int(total),
+			Page:       *page,
+			PageSize:   *limit,
+			TotalPages: totalPages,
+		},
+	}, nil
+}
+
+// GetRevisions returns the revisions of a page without their blocks, newest first
+func (s *PageService) GetRevisions(ctx context.Context, pageId uint) ([]*PageRevision, error) {
+	if _, err := s.GetById(ctx, pageId); err != nil {
+		return nil, err
+	}
+	var items []*PageRevision
+	if err := s.DB.WithContext(ctx).Omit("blocks").Where("page_id = ?", pageId).Order("version DESC").Find(&items).Error; err != nil {
+		s.Logger.Error("failed to get page revisions",
+			logger.String("error", err.Error()),
+			logger.Int("page_id", int(pageId)))
+		return nil, err
+	}
+	return items, nil
+}
+
+// GetRevision returns a revision of a page by its version
+func (s *PageService) GetRevision(ctx context.Context, pageId uint, version int) (*PageRevision, error) {
+	item := &PageRevision{}
+	if err := s.DB.WithContext(ctx).Where("page_id = ? AND version = ?", pageId, version).First(item).Error; err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// Restore puts the content of a revision back on its page and saves it as a new revision.
+// The slug is only restored when it is still free; the parent and publish state are kept.
+func (s *PageService) Restore(ctx context.Context, pageId uint, version int, req *RestoreRequest, userId uint) (*Page, error) {
+	if err := ValidateRestoreRequest(req); err != nil {
+		return nil, err
+	}
+	item, err := s.GetById(ctx, pageId)
+	if err != nil {
+		return nil, err
+	}
+	revision, err := s.GetRevision(ctx, pageId, version)
+	if err != nil {
+		return nil, err
+	}
+
+	item.Title = revision.Title
+	item.Template = revision.Template
+	item.Blocks = revision.Blocks
+	if revision.Slug != item.Slug {
+		if slug, errs := s.slug(ctx, revision.Slug, item.Title, item); len(errs) == 0 {
+			item.Slug = slug
+		}
+	}
+
+	note := req.Note
+	if note == "" {
+		note = fmt.Sprintf("Restored version %d", version)
+	}
+
+	oldPath := item.Path
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		path, err := s.path(tx, item)
+		if err != nil {
+			return err
+		}
+		item.Path = path
+		if err := s.revise(tx, item, userId, note); err != nil {
+			return err
+		}
+		if err := tx.Save(item).Error; err != nil {
+			return err
+		}
+		if item.Path != oldPath {
+			return s.movePaths(tx, item)
+		}
+		return nil
+	})
+	if err != nil {
+		s.Logger.Error("failed to restore page revision",
+			logger.String("error", err.Error()),
+			logger.Int("page_id", int(pageId)),
+			logger.Int("version", version))
+		return nil, err
+	}
+
+	s.Emitter.EmitContext(ctx, RestorePageEvent, item)
+	return item, nil
+}
+
+// public narrows a query to the pages that are published, whose publication date has come,
+// that haven't expired and that aren't archived. Expired pages are left out before Expire
+// unpublishes them.
+func (s *PageService) public(query *gorm.DB) *gorm.DB {
+	now := time.Now()
+	return query.Where("status = ? AND published_at <= ? AND (expires_at IS NULL OR expires_at > ?) AND archived_at IS NULL",
+		StatusPublished, now, now)
+}
+
+// revise saves the content of a page as its next revision and drops the revisions
+// beyond maxRevisions
+func (s *PageService) revise(tx *gorm.DB, item *Page, userId uint, note string) error {
+	item.Version++
+	revision := content(item)
+	revision.PageId = item.Id
+	revision.Version = item.Version
+	revision.Note = note
+	if userId != 0 {
+		revision.UserId = &userId
+	}
+	if err := tx.Create(revision).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&Page{}).Where("id = ?", item.Id).Update("version", item.Version).Error; err != nil {
+		return err
+	}
+	return tx.Where("page_id = ? AND version <= ?", item.Id, item.Version-maxRevisions).Delete(&PageRevision{}).Error
+}
+
+// path returns the path of a page from the path of its parent
+func (s *PageService) path(tx *gorm.DB, item *Page) (string, error) {
+	if item.ParentId == nil {
+		return item.Slug, nil
+	}
+	var parentPath []string
+	if err := tx.Model(&Page{}).Where("id = ?", *item.ParentId).Limit(1).Pluck("path", &parentPath).Error; err != nil {
+		return "", err
+	}
+	if len(parentPath) == 0 {
+		return "", gorm.ErrRecordNotFound
+	}
+	return parentPath[0] + "/" + item.Slug, nil
+}
+
+// movePaths updates the paths of the subpages of a page after its path changed
+func (s *PageService) movePaths(tx *gorm.DB, parent *Page) error {
+	var children []*Page
+	if err := tx.Select("id, slug").Where("parent_id = ?", parent.Id).Find(&children).Error; err != nil {
+		return err
+	}
+	for _, child := range children {
+		child.Path = parent.Path + "/" + child.Slug
+		if err := tx.Model(&Page{}).Where("id = ?", child.Id).Update("path", child.Path).Error; err != nil {
+			return err
+		}
+		if err := s.movePaths(tx, child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// slug validates a requested slug, or generates one from the title, that no other page
+// under the same parent has
+func (s *PageService) slug(ctx context.Context, requested, title string, item *Page) (string, validator.ValidationErrors) {
+	exists := slugTaken(s.DB.WithContext(ctx), item)
+
+	if requested == "" {
+		base := s.slugs.Normalize(title, "", "en")
+		if base == "" {
+			base = "page"
+		}
+		slug, err := s.slugs.GenerateUniqueSlug(base, exists)
+		if err != nil {
+			return "", validator.ValidationErrors{{Field: "slug", Tag: "slug", Value: base, Message: err.Error()}}
+		}
+		return slug, nil
+	}
+
+	if !slugPattern.MatchString(requested) {
+		return requested, validator.ValidationErrors{{
+			Field:   "slug",
+			Tag:     "slug",
+			Value:   requested,
+			Message: "slug must contain only lowercase letters, digits, '.', '_' or '-'",
+		}}
+	}
+	if taken, err := exists(requested); err != nil || taken {
+		return requested, validator.ValidationErrors{{
+			Field:   "slug",
+			Tag:     "unique",
+			Value:   requested,
+			Message: "slug is already taken by another page with the same parent",
+		}}
+	}
+	return requested, nil
+}
+
+// slugTaken returns whether another page under the same parent as item has a slug
+func slugTaken(db *gorm.DB, item *Page) func(slug string) (bool, error) {
+	return func(slug string) (bool, error) {
+		var count int64
+		query := db.Model(&Page{}).Where("slug = ? AND id <> ?", slug, item.Id)
+		if item.ParentId == nil {
+			query = query.Where("parent_id IS NULL")
+		} else {
+			query = query.Where("parent_id = ?", *item.ParentId)
+		}
+		err := query.Count(&count).Error
+		return count > 0, err
+	}
+}
+
+// checkParent checks that the parent of a page exists and isn't the page itself or one
+// of its subpages
+func (s *PageService) checkParent(ctx context.Context, item *Page) *validator.ValidationError {
+	if item.ParentId == nil {
+		return nil
+	}
+	invalid := func(tag, message string) *validator.ValidationError {
+		return &validator.ValidationError{
+			Field:   "parent_id",
+			Tag:     tag,
+			Value:   fmt.Sprint(*item.ParentId),
+			Message: message,
+		}
+	}
+
+	seen := map[uint]bool{}
+	for id := item.ParentId; id != nil; {
+		if item.Id != 0 && *id == item.Id {
+			return invalid("invalid", "parent_id can't be the page or one of its subpages")
+		}
+		if seen[*id] {
+			break // An existing cycle, don't loop forever
+		}
+		seen[*id] = true
+
+		parent := &Page{}
+		if err := s.DB.WithContext(ctx).Select("id, parent_id").First(parent, *id).Error; err != nil {
+			return invalid("exists", fmt.Sprintf("page %d does not exist", *id))
+		}
+		id = parent.ParentId
+	}
+	return nil
+}
+
+// publish dates a published page that has no publication date yet
+func publish(item *Page) {
+	if item.Status == StatusPublished && item.PublishedAt == nil {
+		now := time.Now()
+		item.PublishedAt = &now
+	}
+}
+
+// content returns the content of a page as a revision, without page and version
+func content(item *Page) *PageRevision {
+	return &PageRevision{
+		Title:    item.Title,
+		Slug:     item.Slug,
+		Template: item.Template,
+		Blocks:   item.Blocks,
+	}
+}
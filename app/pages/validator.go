@@ -0,0 +1,103 @@
+package pages
+
+import (
+	"fmt"
+	"regexp"
+
+	"base/core/sanitize"
+	"base/core/validator"
+)
+
+// Global validator instance using Base core validator wrapper
+var validate = validator.New()
+
+// slugPattern matches URL slugs such as "about-us"
+var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,254}$`)
+
+// ValidatePageCreateRequest validates the create request
+func ValidatePageCreateRequest(req *CreatePageRequest) error {
+	if req == nil {
+		return nilRequest()
+	}
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	return validateBlocks(req.Blocks)
+}
+
+// ValidatePageUpdateRequest validates the update request
+func ValidatePageUpdateRequest(req *UpdatePageRequest, id uint) error {
+	if req == nil {
+		return nilRequest()
+	}
+
+	if id == 0 {
+		return validator.ValidationErrors{
+			{
+				Field:   "id",
+				Tag:     "required",
+				Value:   "0",
+				Message: "id cannot be zero",
+			},
+		}
+	}
+
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	if req.Blocks != nil {
+		return validateBlocks(*req.Blocks)
+	}
+	return nil
+}
+
+// ValidateRestoreRequest validates the restore request
+func ValidateRestoreRequest(req *RestoreRequest) error {
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateBlocks checks that every block has the data its type requires and that its URLs
+// are safe to link to
+func validateBlocks(blocks []Block) error {
+	var errs validator.ValidationErrors
+	for i, block := range blocks {
+		for _, field := range blockFields[block.Type] {
+			if value, ok := block.Data[field]; !ok || value == nil || value == "" {
+				errs = append(errs, validator.ValidationError{
+					Field:   fmt.Sprintf("blocks[%d].data.%s", i, field),
+					Tag:     "required",
+					Message: fmt.Sprintf("%s blocks require data.%s", block.Type, field),
+				})
+			}
+		}
+		for _, field := range blockURLs[block.Type] {
+			if value, ok := block.Data[field].(string); ok && !sanitize.SafeURL(value) {
+				errs = append(errs, validator.ValidationError{
+					Field:   fmt.Sprintf("blocks[%d].data.%s", i, field),
+					Tag:     "url",
+					Value:   value,
+					Message: "URLs must be relative or use http, https, mailto or tel",
+				})
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// nilRequest is the error of a missing request
+func nilRequest() error {
+	return validator.ValidationErrors{
+		{
+			Field:   "request",
+			Tag:     "required",
+			Value:   "nil",
+			Message: "request cannot be nil",
+		},
+	}
+}
@@ -0,0 +1,193 @@
+package pages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"base/core/app/notifications"
+	"base/core/app/users"
+	"base/core/logger"
+	"base/core/validator"
+)
+
+// Workflow lists the statuses pages can change to from each status; the "" entry lists those
+// new pages can start in. By default pages are reviewed and approved before they are
+// published. Projects change it to add or skip steps, e.g. to publish drafts directly:
+//
+//	pages.Workflow[pages.StatusDraft] = append(pages.Workflow[pages.StatusDraft], pages.StatusPublished)
+var Workflow = map[string][]string{
+	"":              {StatusDraft},
+	StatusDraft:     {StatusInReview},
+	StatusInReview:  {StatusApproved, StatusDraft},
+	StatusApproved:  {StatusPublished, StatusDraft},
+	StatusPublished: {StatusDraft},
+}
+
+// ErrNotReviewer is returned when someone else than the reviewer of a page decides on it
+var ErrNotReviewer = errors.New("only the reviewer of the page can approve or reject it")
+
+// Review is the data of the submit, approve and reject events
+type Review struct {
+	Page   *Page
+	UserId uint // Who submitted, approved or rejected the page
+	Note   string
+}
+
+// Submit submits a page for review, to the reviewer of the request or its current one
+func (s *PageService) Submit(ctx context.Context, id uint, req *SubmitPageRequest, userId uint) (*Page, error) {
+	if req == nil {
+		return nil, nilRequest()
+	}
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return nil, errs
+	}
+
+	status := StatusInReview
+	item, err := s.Update(ctx, id, &UpdatePageRequest{Status: &status, ReviewerId: req.ReviewerId}, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Emitter.EmitContext(ctx, SubmitPageEvent, &Review{Page: item, UserId: userId, Note: req.Note})
+	return item, nil
+}
+
+// Approve approves a page in review, so it can be published
+func (s *PageService) Approve(ctx context.Context, id uint, req *ReviewPageRequest, userId uint) (*Page, error) {
+	return s.decide(ctx, id, StatusApproved, ApprovePageEvent, req, userId)
+}
+
+// Reject sends a page in review back to draft; the note tells the author what to change
+func (s *PageService) Reject(ctx context.Context, id uint, req *ReviewPageRequest, userId uint) (*Page, error) {
+	return s.decide(ctx, id, StatusDraft, RejectPageEvent, req, userId)
+}
+
+// decide moves a page in review to status. Pages with a reviewer can only be decided on
+// by them.
+func (s *PageService) decide(ctx context.Context, id uint, status, event string, req *ReviewPageRequest, userId uint) (*Page, error) {
+	if req == nil {
+		return nil, nilRequest()
+	}
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return nil, errs
+	}
+
+	item, err := s.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if item.Status != StatusInReview {
+		return nil, validator.ValidationErrors{{
+			Field:   "status",
+			Tag:     "workflow",
+			Param:   StatusInReview,
+			Value:   item.Status,
+			Message: fmt.Sprintf("only pages in review can be approved or rejected, this one is %s", item.Status),
+		}}
+	}
+	if !isReviewer(item, userId) {
+		return nil, ErrNotReviewer
+	}
+
+	item, err = s.Update(ctx, id, &UpdatePageRequest{Status: &status}, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Emitter.EmitContext(ctx, event, &Review{Page: item, UserId: userId, Note: req.Note})
+	return item, nil
+}
+
+// isReviewer reports whether a user can decide on a page: anyone if it has no reviewer
+func isReviewer(item *Page, userId uint) bool {
+	return item.ReviewerId == nil || *item.ReviewerId == userId
+}
+
+// checkTransition returns the error of a status change Workflow doesn't allow; from is empty
+// for new pages
+func checkTransition(from, to string) *validator.ValidationError {
+	allowed := Workflow[from]
+	if slices.Contains(allowed, to) {
+		return nil
+	}
+
+	message := fmt.Sprintf("a %s page can't become %s", from, to)
+	if from == "" {
+		message = fmt.Sprintf("new pages can't be %s", to)
+	}
+	if len(allowed) == 0 {
+		return &validator.ValidationError{Field: "status", Tag: "invalid", Value: to, Message: message}
+	}
+	return &validator.ValidationError{
+		Field:   "status",
+		Tag:     "workflow",
+		Param:   strings.Join(allowed, ", "),
+		Value:   to,
+		Message: message + ", only " + strings.Join(allowed, " or "),
+	}
+}
+
+// checkReviewer checks that the reviewer of a request is a user
+func (s *PageService) checkReviewer(ctx context.Context, id uint) *validator.ValidationError {
+	var count int64
+	if err := s.DB.WithContext(ctx).Model(&users.User{}).Where("id = ?", id).Count(&count).Error; err != nil || count == 0 {
+		return &validator.ValidationError{
+			Field:   "reviewer_id",
+			Tag:     "exists",
+			Value:   fmt.Sprint(id),
+			Message: fmt.Sprintf("user %d does not exist", id),
+		}
+	}
+	return nil
+}
+
+// Listen notifies reviewers of the pages submitted to them, and authors of the decisions on
+// their pages
+func (s *PageService) Listen() {
+	for _, event := range []string{SubmitPageEvent, ApprovePageEvent, RejectPageEvent} {
+		s.Emitter.OnContext(event, func(ctx context.Context, data any) {
+			if review, ok := data.(*Review); ok {
+				if err := s.notify(ctx, event, review); err != nil {
+					s.Logger.Error("failed to send page review notification",
+						logger.String("error", err.Error()),
+						logger.Int("page_id", int(review.Page.Id)))
+				}
+			}
+		})
+	}
+}
+
+// notify sends the notification of a review event
+func (s *PageService) notify(ctx context.Context, event string, review *Review) error {
+	if s.Notifications == nil {
+		return nil
+	}
+
+	item := review.Page
+	var recipient *uint
+	var title string
+	switch event {
+	case SubmitPageEvent:
+		recipient, title = item.ReviewerId, fmt.Sprintf("%q is waiting for your review", item.Title)
+	case ApprovePageEvent:
+		recipient, title = item.AuthorId, fmt.Sprintf("%q was approved", item.Title)
+	case RejectPageEvent:
+		recipient, title = item.AuthorId, fmt.Sprintf("%q needs changes", item.Title)
+	}
+	// Nobody to tell, or people deciding on their own pages
+	if recipient == nil || *recipient == review.UserId {
+		return nil
+	}
+
+	_, err := s.Notifications.Create(ctx, &notifications.CreateNotificationRequest{
+		UserId:    *recipient,
+		Title:     title,
+		Body:      review.Note,
+		Type:      event,
+		ActionUrl: fmt.Sprintf("/pages/%d", item.Id),
+	})
+	return err
+}
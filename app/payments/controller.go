@@ -0,0 +1,218 @@
+package payments
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"base/core/logger"
+	"base/core/router"
+	"base/core/translation"
+	"base/core/types"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+// maxWebhookSize caps the body of provider webhooks
+const maxWebhookSize = 1 << 20
+
+type PaymentController struct {
+	Service *PaymentService
+}
+
+func NewPaymentController(service *PaymentService) *PaymentController {
+	return &PaymentController{
+		Service: service,
+	}
+}
+
+// Routes registers the management endpoints; the group is restricted to admins by the module
+func (c *PaymentController) Routes(router *router.RouterGroup) {
+	router.GET("/payments", c.List)               // Paginated list
+	router.POST("/payments", c.Create)            // Create a payment intent for an order
+	router.GET("/payments/:id", c.Get)            // Get by ID
+	router.POST("/payments/:id/refund", c.Refund) // Refund
+}
+
+// WebhookRoutes registers the provider webhook. It is under /api/webhooks, which needs no
+// API key or token (MIDDLEWARE_WEBHOOK_PATHS); requests are verified by their signature.
+func (c *PaymentController) WebhookRoutes(router *router.RouterGroup) {
+	router.POST("/webhooks/"+c.Service.Provider.Name(), c.Webhook)
+}
+
+// CreatePayment godoc
+// @Summary Create a payment
+// @Description Create a payment intent for an order at the payment provider; the response carries the client_secret the client completes the payment with (Admin only)
+// @Tags App/Payment
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param payment body CreatePaymentRequest true "Create payment request"
+// @Success 201 {object} PaymentResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 409 {object} types.ErrorResponse
+// @Failure 502 {object} types.ErrorResponse
+// @Router /payments [post]
+func (c *PaymentController) Create(ctx *router.Context) error {
+	var req CreatePaymentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	payment, err := c.Service.CreatePayment(ctx, &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to create payment")
+	}
+	return ctx.JSON(http.StatusCreated, payment.ToResponse())
+}
+
+// GetPayment godoc
+// @Summary Get a payment
+// @Description Get a payment by id (Admin only)
+// @Tags App/Payment
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Payment id"
+// @Success 200 {object} PaymentResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /payments/{id} [get]
+func (c *PaymentController) Get(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	payment, err := c.Service.GetById(ctx.Request.Context(), uint(id))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to get payment")
+	}
+	return ctx.JSON(http.StatusOK, payment.ToResponse())
+}
+
+// ListPayments godoc
+// @Summary List payments
+// @Description Get a page of payments, newest first (Admin only)
+// @Tags App/Payment
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of items per page"
+// @Param order_id query int false "Only payments of the order"
+// @Param status query string false "Only payments with the status (pending, succeeded, failed, canceled, partially_refunded, refunded)"
+// @Success 200 {object} types.PaginatedResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /payments [get]
+func (c *PaymentController) List(ctx *router.Context) error {
+	params, err := ctx.ListParams()
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	filter := PaymentFilter{Status: ctx.Query("status")}
+	if orderId := ctx.Query("order_id"); orderId != "" {
+		id, err := strconv.ParseUint(orderId, 10, 32)
+		if err != nil {
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid order_id"})
+		}
+		filter.OrderId = uint(id)
+	}
+
+	paginatedResponse, err := c.Service.GetAll(ctx.Request.Context(), params.Page, params.Limit, filter)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch payments: " + err.Error()})
+	}
+	return ctx.JSON(http.StatusOK, paginatedResponse)
+}
+
+// RefundPayment godoc
+// @Summary Refund a payment
+// @Description Refund a paid payment at the payment provider, all of what is left or the given amount in cents (Admin only)
+// @Tags App/Payment
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Payment id"
+// @Param refund body RefundRequest false "Refund request"
+// @Success 200 {object} PaymentResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 502 {object} types.ErrorResponse
+// @Router /payments/{id}/refund [post]
+func (c *PaymentController) Refund(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	var req RefundRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+		}
+	}
+
+	payment, err := c.Service.Refund(ctx, uint(id), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to refund payment")
+	}
+	return ctx.JSON(http.StatusOK, payment.ToResponse())
+}
+
+// PaymentWebhook godoc
+// @Summary Payment provider webhook
+// @Description Receives the payment events of the provider (e.g. Stripe payment_intent.succeeded, payment_intent.payment_failed, payment_intent.canceled and charge.refunded); requests must carry the provider signature
+// @Tags App/Payment
+// @Accept json
+// @Produce json
+// @Success 200 {object} types.SuccessResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 503 {object} types.ErrorResponse
+// @Router /webhooks/stripe [post]
+func (c *PaymentController) Webhook(ctx *router.Context) error {
+	payload, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxWebhookSize))
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Failed to read the request body"})
+	}
+
+	if err := c.Service.HandleWebhook(ctx.Request.Context(), payload, ctx.Request.Header); err != nil {
+		switch {
+		case errors.Is(err, ErrNotConfigured):
+			return ctx.JSON(http.StatusServiceUnavailable, types.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, ErrInvalidSignature):
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+		}
+		c.Service.Logger.Error("failed to handle payment webhook", logger.String("error", err.Error()))
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to handle webhook: " + err.Error()})
+	}
+	return ctx.JSON(http.StatusOK, types.SuccessResponse{Success: true, Message: "received"})
+}
+
+func (c *PaymentController) fail(ctx *router.Context, err error, message string) error {
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   translation.Error(ctx, err),
+			Details: translation.LocalizeValidation(ctx, validationErrors),
+		})
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Item not found"})
+	}
+	if errors.Is(err, ErrOrderPaid) {
+		return ctx.JSON(http.StatusConflict, types.ErrorResponse{Error: translation.Error(ctx, err)})
+	}
+	if errors.Is(err, ErrNotConfigured) {
+		return ctx.JSON(http.StatusServiceUnavailable, types.ErrorResponse{Error: err.Error()})
+	}
+	if errors.Is(err, ErrProvider) {
+		return ctx.JSON(http.StatusBadGateway, types.ErrorResponse{Error: message + ": " + err.Error()})
+	}
+	return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: message + ": " + err.Error()})
+}
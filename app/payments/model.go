@@ -0,0 +1,138 @@
+package payments
+
+import (
+	"time"
+
+	"base/core/types"
+)
+
+// Payment statuses
+const (
+	StatusPending           = "pending"            // Created, waiting for the customer
+	StatusSucceeded         = "succeeded"          // Paid
+	StatusFailed            = "failed"             // The last attempt failed, the customer can retry
+	StatusCanceled          = "canceled"           // Won't be paid
+	StatusPartiallyRefunded = "partially_refunded" // Paid, part of the amount was refunded
+	StatusRefunded          = "refunded"           // Paid, the whole amount was refunded
+)
+
+// Payment is a payment of an order at the payment provider. Amounts are in the minor unit
+// of the currency (cents).
+type Payment struct {
+	Id                uint        `json:"id" gorm:"primarykey"`
+	CreatedAt         time.Time   `json:"created_at"`
+	UpdatedAt         time.Time   `json:"updated_at"`
+	OrderId           uint        `json:"order_id" gorm:"index;not null"`
+	Provider          string      `json:"provider" gorm:"size:32;uniqueIndex:idx_payments_provider_payment"`
+	ProviderPaymentId string      `json:"provider_payment_id" gorm:"size:255;uniqueIndex:idx_payments_provider_payment"`
+	Amount            types.Money `json:"amount"`
+	AmountRefunded    types.Money `json:"amount_refunded"`
+	Currency          string      `json:"currency" gorm:"size:3"`
+	Description       string      `json:"description" gorm:"size:255"`
+	Status            string      `json:"status" gorm:"size:32;index"`
+	FailureMessage    string      `json:"failure_message" gorm:"type:text"`
+	PaidAt            *time.Time  `json:"paid_at"`
+
+	// Secret the client completes the payment with; only known when the payment is created
+	ClientSecret string `json:"-" gorm:"-"`
+}
+
+// TableName returns the table name for the Payment model
+func (m *Payment) TableName() string {
+	return "payments"
+}
+
+// GetId returns the Id of the model
+func (m *Payment) GetId() uint {
+	return m.Id
+}
+
+// GetModelName returns the model name
+func (m *Payment) GetModelName() string {
+	return "payments"
+}
+
+// Paid reports whether the payment went through, refunded or not
+func (m *Payment) Paid() bool {
+	return m.Status == StatusSucceeded || m.Status == StatusPartiallyRefunded || m.Status == StatusRefunded
+}
+
+// PaymentEvent is a processed provider webhook; the unique event id makes redelivered
+// webhooks no-ops
+type PaymentEvent struct {
+	Id           uint      `json:"id" gorm:"primarykey"`
+	CreatedAt    time.Time `json:"created_at"`
+	PaymentId    uint      `json:"payment_id" gorm:"index"`
+	Provider     string    `json:"provider" gorm:"size:32;uniqueIndex:idx_payment_events_provider_event"`
+	EventId      string    `json:"event_id" gorm:"size:255;uniqueIndex:idx_payment_events_provider_event"`
+	Type         string    `json:"type" gorm:"size:64"`
+	ProviderType string    `json:"provider_type" gorm:"size:64"`
+}
+
+// TableName returns the table name for the PaymentEvent model
+func (m *PaymentEvent) TableName() string {
+	return "payment_events"
+}
+
+// CreatePaymentRequest creates a payment for an order
+type CreatePaymentRequest struct {
+	OrderId     uint              `json:"order_id" validate:"required"`
+	Amount      types.Money       `json:"amount" validate:"gt=0"`
+	Currency    string            `json:"currency,omitempty" validate:"omitempty,iso4217"` // Defaults to PAYMENTS_CURRENCY
+	Description string            `json:"description,omitempty" validate:"max=255"`
+	Metadata    map[string]string `json:"metadata,omitempty"` // Passed on to the provider
+}
+
+// RefundRequest refunds a payment
+type RefundRequest struct {
+	Amount types.Money `json:"amount" validate:"gte=0"` // 0 refunds what is left
+}
+
+// PaymentFilter narrows payment lists
+type PaymentFilter struct {
+	OrderId uint
+	Status  string
+}
+
+// PaymentResponse represents the API response for a payment
+type PaymentResponse struct {
+	Id                uint        `json:"id"`
+	OrderId           uint        `json:"order_id"`
+	Provider          string      `json:"provider"`
+	ProviderPaymentId string      `json:"provider_payment_id"`
+	Amount            types.Money `json:"amount"`
+	AmountRefunded    types.Money `json:"amount_refunded"`
+	Currency          string      `json:"currency"`
+	Description       string      `json:"description"`
+	Status            string      `json:"status"`
+	FailureMessage    string      `json:"failure_message,omitempty"`
+	PaidAt            *time.Time  `json:"paid_at"`
+	CreatedAt         time.Time   `json:"created_at"`
+	UpdatedAt         time.Time   `json:"updated_at"`
+
+	// Only set in the response to the creation of the payment
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// ToResponse converts the model to an API response
+func (m *Payment) ToResponse() *PaymentResponse {
+	if m == nil {
+		return nil
+	}
+	return &PaymentResponse{
+		Id:                m.Id,
+		OrderId:           m.OrderId,
+		Provider:          m.Provider,
+		ProviderPaymentId: m.ProviderPaymentId,
+		Amount:            m.Amount,
+		AmountRefunded:    m.AmountRefunded,
+		Currency:          m.Currency,
+		Description:       m.Description,
+		Status:            m.Status,
+		FailureMessage:    m.FailureMessage,
+		PaidAt:            m.PaidAt,
+		CreatedAt:         m.CreatedAt,
+		UpdatedAt:         m.UpdatedAt,
+		ClientSecret:      m.ClientSecret,
+	}
+}
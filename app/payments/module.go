@@ -0,0 +1,128 @@
+package payments
+
+import (
+	"errors"
+
+	"base/core/app/authorization"
+	"base/core/app/reports"
+	"base/core/logger"
+	"base/core/module"
+	"base/core/router"
+
+	"gorm.io/gorm"
+)
+
+// Module provides payments of orders through the payment provider (PAYMENTS_PROVIDER)
+// and receives its webhooks
+type Module struct {
+	module.DefaultModule
+	DB         *gorm.DB
+	Service    *PaymentService
+	Controller *PaymentController
+}
+
+// Init creates and initializes the payments module with all dependencies
+func Init(deps module.Dependencies) module.Module {
+	registerEvents()
+	provider, err := NewProvider(deps.Config)
+	if err != nil {
+		// Payments answer 503 until the provider is configured
+		deps.Logger.Error("failed to create payment provider", logger.String("error", err.Error()))
+		provider = NewStripeProvider(deps.Config.StripeAPIURL, "", "")
+	}
+	service := NewPaymentService(deps.DB, deps.Emitter, deps.Logger, provider, deps.Config.PaymentsCurrency)
+
+	reports.RegisterEntity(reports.Entity{
+		Name:    "payments",
+		Columns: []string{"id", "order_id", "provider", "amount", "amount_refunded", "currency", "status", "paid_at", "created_at"},
+	})
+
+	return &Module{
+		DB:         deps.DB,
+		Service:    service,
+		Controller: NewPaymentController(service),
+	}
+}
+
+// Routes registers the admin routes and the provider webhook
+func (m *Module) Routes(router *router.RouterGroup) {
+	group := router.Group("", authorization.RequireAdmin(authorization.NewAuthorizationService(m.DB)))
+	m.Controller.Routes(group)
+
+	m.Controller.WebhookRoutes(router)
+}
+
+func (m *Module) Init() error {
+	return m.SeedPermissions()
+}
+
+func (m *Module) SeedPermissions() error {
+	// Ensure permissions table exists before seeding
+	if err := m.DB.AutoMigrate(&authorization.Permission{}); err != nil {
+		return err
+	}
+
+	// Define permissions for payment operations
+	permissions := []authorization.Permission{
+		{
+			Name:         "payment list",
+			Description:  "View payment list",
+			ResourceType: "payment",
+			Action:       "list",
+		},
+		{
+			Name:         "payment read",
+			Description:  "View payment details",
+			ResourceType: "payment",
+			Action:       "read",
+		},
+		{
+			Name:         "payment create",
+			Description:  "Create payments for orders",
+			ResourceType: "payment",
+			Action:       "create",
+		},
+		{
+			Name:         "payment refund",
+			Description:  "Refund payments",
+			ResourceType: "payment",
+			Action:       "refund",
+		},
+	}
+
+	// Upsert permissions - create or update if they exist
+	for _, permission := range permissions {
+		var existingPermission authorization.Permission
+		result := m.DB.Where("resource_type = ? AND action = ?", permission.ResourceType, permission.Action).First(&existingPermission)
+
+		if result.Error != nil && errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// Create new permission
+			if err := m.DB.Create(&permission).Error; err != nil {
+				return err
+			}
+		} else if result.Error == nil {
+			// Update existing permission
+			existingPermission.Name = permission.Name
+			existingPermission.Description = permission.Description
+			if err := m.DB.Save(&existingPermission).Error; err != nil {
+				return err
+			}
+		} else {
+			// Return any other error
+			return result.Error
+		}
+	}
+
+	return nil
+}
+
+func (m *Module) Migrate() error {
+	return m.DB.AutoMigrate(&Payment{}, &PaymentEvent{})
+}
+
+func (m *Module) GetModels() []any {
+	return []any{
+		&Payment{},
+		&PaymentEvent{},
+	}
+}
@@ -0,0 +1,84 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"base/core/config"
+	"base/core/types"
+)
+
+var (
+	// ErrNotConfigured is returned when the payment provider has no credentials
+	ErrNotConfigured = errors.New("payment provider is not configured")
+
+	// ErrProvider wraps the errors of the payment provider API
+	ErrProvider = errors.New("payment provider error")
+
+	// ErrInvalidSignature is returned for webhooks that weren't signed by the provider
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+)
+
+// EventType is a payment change reported by a provider webhook
+type EventType string
+
+const (
+	EventSucceeded EventType = "succeeded"
+	EventFailed    EventType = "failed"
+	EventCanceled  EventType = "canceled"
+	EventRefunded  EventType = "refunded"
+)
+
+// Intent is a payment created at the provider. The client completes it with the
+// ClientSecret (e.g. with Stripe.js).
+type Intent struct {
+	Id           string
+	ClientSecret string
+	Status       string
+}
+
+// IntentParams describes a payment to create
+type IntentParams struct {
+	Amount      types.Money // In the smallest currency unit (cents)
+	Currency    string
+	Description string
+	Metadata    map[string]string
+}
+
+// Refund is a refund created at the provider
+type Refund struct {
+	Id     string
+	Amount types.Money
+	Status string
+}
+
+// WebhookEvent is a verified provider webhook. Type is empty for events that don't
+// change a payment.
+type WebhookEvent struct {
+	Id             string // Provider event id, used to skip redeliveries
+	Type           EventType
+	ProviderType   string      // Event type as named by the provider
+	PaymentId      string      // Provider payment id
+	AmountRefunded types.Money // Total refunded so far, for EventRefunded
+	FailureMessage string
+}
+
+// Provider creates payments and refunds at a payment service and verifies its webhooks
+type Provider interface {
+	Name() string
+	CreateIntent(ctx context.Context, params IntentParams) (*Intent, error)
+	Refund(ctx context.Context, paymentId string, amount types.Money) (*Refund, error)
+	ParseWebhook(payload []byte, header http.Header) (*WebhookEvent, error)
+}
+
+// NewProvider creates the payment provider selected by PAYMENTS_PROVIDER
+func NewProvider(cfg *config.Config) (Provider, error) {
+	switch cfg.PaymentsProvider {
+	case "stripe", "":
+		return NewStripeProvider(cfg.StripeAPIURL, cfg.StripeSecretKey, cfg.StripeWebhookSecret), nil
+	default:
+		return nil, fmt.Errorf("unsupported payment provider: %s", cfg.PaymentsProvider)
+	}
+}
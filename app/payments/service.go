@@ -0,0 +1,335 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"base/core/emitter"
+	"base/core/logger"
+	"base/core/types"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	CreatePaymentEvent    = "payments.create"
+	SucceededPaymentEvent = "payments.succeeded"
+	FailedPaymentEvent    = "payments.failed"
+	CanceledPaymentEvent  = "payments.canceled"
+	RefundedPaymentEvent  = "payments.refunded"
+)
+
+// registerEvents adds the events of the module to the event catalog
+func registerEvents() {
+	emitter.RegisterEvents(
+		emitter.EventType{Name: CreatePaymentEvent, Description: "A payment was started", Payload: &Payment{}},
+		emitter.EventType{Name: SucceededPaymentEvent, Description: "A payment succeeded", Payload: &Payment{}},
+		emitter.EventType{Name: FailedPaymentEvent, Description: "A payment failed", Payload: &Payment{}},
+		emitter.EventType{Name: CanceledPaymentEvent, Description: "A payment was canceled", Payload: &Payment{}},
+		emitter.EventType{Name: RefundedPaymentEvent, Description: "A payment was partly or fully refunded", Payload: &Payment{}},
+	)
+}
+
+// ErrOrderPaid is returned when creating a payment for an order that is already paid
+var ErrOrderPaid = errors.New("order is already paid")
+
+// PaymentService creates payments at the provider and keeps them in sync through its
+// webhooks. Orders react to the payment events (payments.succeeded, ...), which carry the
+// *Payment with its OrderId.
+type PaymentService struct {
+	DB       *gorm.DB
+	Emitter  *emitter.Emitter
+	Logger   logger.Logger
+	Provider Provider
+	currency string
+}
+
+func NewPaymentService(db *gorm.DB, emitter *emitter.Emitter, logger logger.Logger, provider Provider, currency string) *PaymentService {
+	return &PaymentService{
+		DB:       db,
+		Emitter:  emitter,
+		Logger:   logger,
+		Provider: provider,
+		currency: currency,
+	}
+}
+
+// CreatePayment creates a payment intent for an order at the provider. The returned
+// payment carries the ClientSecret the client completes the payment with.
+func (s *PaymentService) CreatePayment(ctx context.Context, req *CreatePaymentRequest) (*Payment, error) {
+	if err := ValidateCreatePaymentRequest(req); err != nil {
+		return nil, err
+	}
+	if req.Currency == "" {
+		req.Currency = s.currency
+	}
+
+	var paid int64
+	if err := s.DB.WithContext(ctx).Model(&Payment{}).
+		Where("order_id = ? AND status IN ?", req.OrderId, []string{StatusSucceeded, StatusPartiallyRefunded}).
+		Count(&paid).Error; err != nil {
+		return nil, err
+	}
+	if paid > 0 {
+		return nil, ErrOrderPaid
+	}
+
+	metadata := map[string]string{}
+	for key, value := range req.Metadata {
+		metadata[key] = value
+	}
+	metadata["order_id"] = fmt.Sprint(req.OrderId)
+
+	intent, err := s.Provider.CreateIntent(ctx, IntentParams{
+		Amount:      req.Amount,
+		Currency:    req.Currency,
+		Description: req.Description,
+		Metadata:    metadata,
+	})
+	if err != nil {
+		s.Logger.Error("failed to create payment intent",
+			logger.String("error", err.Error()),
+			logger.Int("order_id", int(req.OrderId)))
+		return nil, providerError(err)
+	}
+
+	payment := &Payment{
+		OrderId:           req.OrderId,
+		Provider:          s.Provider.Name(),
+		ProviderPaymentId: intent.Id,
+		Amount:            req.Amount,
+		Currency:          req.Currency,
+		Description:       req.Description,
+		Status:            StatusPending,
+	}
+	if err := s.DB.WithContext(ctx).Create(payment).Error; err != nil {
+		s.Logger.Error("failed to save payment",
+			logger.String("error", err.Error()),
+			logger.String("provider_payment_id", intent.Id))
+		return nil, err
+	}
+	payment.ClientSecret = intent.ClientSecret
+
+	s.Emitter.EmitContext(ctx, CreatePaymentEvent, payment)
+	return payment, nil
+}
+
+// GetById returns a payment
+func (s *PaymentService) GetById(ctx context.Context, id uint) (*Payment, error) {
+	payment := &Payment{}
+	if err := s.DB.WithContext(ctx).First(payment, id).Error; err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// GetByOrder returns the payments of an order, newest first
+func (s *PaymentService) GetByOrder(ctx context.Context, orderId uint) ([]*Payment, error) {
+	var payments []*Payment
+	if err := s.DB.WithContext(ctx).Where("order_id = ?", orderId).Order("id DESC").Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+// GetAll returns a page of payments, newest first
+func (s *PaymentService) GetAll(ctx context.Context, page *int, limit *int, filter PaymentFilter) (*types.PaginatedResponse, error) {
+	var items []*Payment
+	var total int64
+
+	query := s.DB.WithContext(ctx).Model(&Payment{})
+	if filter.OrderId != 0 {
+		query = query.Where("order_id = ?", filter.OrderId)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	// Set default values if nil
+	defaultPage := 1
+	defaultLimit := 10
+	if page == nil {
+		page = &defaultPage
+	}
+	if limit == nil {
+		limit = &defaultLimit
+	}
+
+	// Get total count
+	if err := query.Count(&total).Error; err != nil {
+		s.Logger.Error("failed to count payments",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	offset := (*page - 1) * *limit
+	if err := query.Order("id DESC").Offset(offset).Limit(*limit).Find(&items).Error; err != nil {
+		s.Logger.Error("failed to get payments",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	responses := make([]*PaymentResponse, len(items))
+	for i, item := range items {
+		responses[i] = item.ToResponse()
+	}
+
+	// Calculate total pages
+	totalPages := int(math.Ceil(float64(total) / float64(*limit)))
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	return &types.PaginatedResponse{
+		Data: responses,
+		Pagination: types.Pagination{
+			Total:      int(total),
+			Page:       *page,
+			PageSize:   *limit,
+			TotalPages: totalPages,
+		},
+	}, nil
+}
+
+// Refund refunds a paid payment at the provider, all of what is left or req.Amount
+func (s *PaymentService) Refund(ctx context.Context, id uint, req *RefundRequest) (*Payment, error) {
+	payment, err := s.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateRefundRequest(req, payment); err != nil {
+		return nil, err
+	}
+
+	refund, err := s.Provider.Refund(ctx, payment.ProviderPaymentId, req.Amount)
+	if err != nil {
+		s.Logger.Error("failed to refund payment",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return nil, providerError(err)
+	}
+
+	// The refund webhook reports the same total and is then a no-op
+	if err := s.setRefunded(ctx, payment, min(payment.AmountRefunded+refund.Amount, payment.Amount)); err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// HandleWebhook verifies a provider webhook and applies it to its payment. Redelivered
+// events and events of unknown payments are ignored.
+func (s *PaymentService) HandleWebhook(ctx context.Context, payload []byte, header http.Header) error {
+	event, err := s.Provider.ParseWebhook(payload, header)
+	if err != nil {
+		return err
+	}
+	if event.Type == "" {
+		return nil
+	}
+
+	var processed int64
+	if err := s.DB.WithContext(ctx).Model(&PaymentEvent{}).
+		Where("provider = ? AND event_id = ?", s.Provider.Name(), event.Id).
+		Count(&processed).Error; err != nil {
+		return err
+	}
+	if processed > 0 {
+		return nil
+	}
+
+	payment := &Payment{}
+	if err := s.DB.WithContext(ctx).Where("provider = ? AND provider_payment_id = ?", s.Provider.Name(), event.PaymentId).
+		First(payment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.Logger.Warn("webhook for an unknown payment",
+				logger.String("event_id", event.Id),
+				logger.String("provider_payment_id", event.PaymentId))
+			return nil
+		}
+		return err
+	}
+
+	switch event.Type {
+	case EventSucceeded:
+		err = s.setSucceeded(ctx, payment)
+	case EventFailed:
+		err = s.setFailed(ctx, payment, StatusFailed, event.FailureMessage, FailedPaymentEvent)
+	case EventCanceled:
+		err = s.setFailed(ctx, payment, StatusCanceled, "", CanceledPaymentEvent)
+	case EventRefunded:
+		err = s.setRefunded(ctx, payment, max(payment.AmountRefunded, event.AmountRefunded))
+	}
+	if err != nil {
+		return err
+	}
+
+	// Recorded once applied, so a failed update is retried with the redelivery
+	return s.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&PaymentEvent{
+		PaymentId:    payment.Id,
+		Provider:     s.Provider.Name(),
+		EventId:      event.Id,
+		Type:         string(event.Type),
+		ProviderType: event.ProviderType,
+	}).Error
+}
+
+// setSucceeded marks a payment paid
+func (s *PaymentService) setSucceeded(ctx context.Context, payment *Payment) error {
+	if payment.Paid() {
+		return nil
+	}
+	now := time.Now()
+	payment.Status = StatusSucceeded
+	payment.FailureMessage = ""
+	payment.PaidAt = &now
+	if err := s.DB.WithContext(ctx).Model(payment).Select("status", "failure_message", "paid_at").Updates(payment).Error; err != nil {
+		return err
+	}
+	s.Emitter.EmitContext(ctx, SucceededPaymentEvent, payment)
+	return nil
+}
+
+// setFailed marks an unpaid payment failed or canceled; paid payments don't go back
+func (s *PaymentService) setFailed(ctx context.Context, payment *Payment, status, message, event string) error {
+	if payment.Paid() {
+		return nil
+	}
+	payment.Status = status
+	payment.FailureMessage = message
+	if err := s.DB.WithContext(ctx).Model(payment).Select("status", "failure_message").Updates(payment).Error; err != nil {
+		return err
+	}
+	s.Emitter.EmitContext(ctx, event, payment)
+	return nil
+}
+
+// setRefunded records the total refunded amount of a payment
+func (s *PaymentService) setRefunded(ctx context.Context, payment *Payment, amountRefunded types.Money) error {
+	status := StatusPartiallyRefunded
+	if amountRefunded >= payment.Amount {
+		status = StatusRefunded
+	}
+	if payment.Status == status && payment.AmountRefunded == amountRefunded {
+		return nil
+	}
+	payment.Status = status
+	payment.AmountRefunded = amountRefunded
+	if err := s.DB.WithContext(ctx).Model(payment).Select("status", "amount_refunded").Updates(payment).Error; err != nil {
+		return err
+	}
+	s.Emitter.EmitContext(ctx, RefundedPaymentEvent, payment)
+	return nil
+}
+
+// providerError marks an error of the provider API, unless the provider isn't configured
+func providerError(err error) error {
+	if errors.Is(err, ErrNotConfigured) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrProvider, err)
+}
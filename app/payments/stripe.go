@@ -0,0 +1,205 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"base/core/types"
+)
+
+// stripeSignatureTolerance is how old a signed webhook may be, against replays
+const stripeSignatureTolerance = 5 * time.Minute
+
+// StripeProvider implements Provider with the Stripe API (PaymentIntents and Refunds)
+type StripeProvider struct {
+	baseURL       string
+	secretKey     string
+	webhookSecret string
+	client        *http.Client
+}
+
+func NewStripeProvider(baseURL, secretKey, webhookSecret string) *StripeProvider {
+	return &StripeProvider{
+		baseURL:       strings.TrimSuffix(baseURL, "/"),
+		secretKey:     secretKey,
+		webhookSecret: webhookSecret,
+		client:        &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (p *StripeProvider) Name() string {
+	return "stripe"
+}
+
+// CreateIntent creates a PaymentIntent that accepts the payment methods enabled in the
+// Stripe dashboard
+func (p *StripeProvider) CreateIntent(ctx context.Context, params IntentParams) (*Intent, error) {
+	form := url.Values{}
+	form.Set("amount", strconv.FormatInt(int64(params.Amount), 10))
+	form.Set("currency", strings.ToLower(params.Currency))
+	form.Set("automatic_payment_methods[enabled]", "true")
+	if params.Description != "" {
+		form.Set("description", params.Description)
+	}
+	for key, value := range params.Metadata {
+		form.Set("metadata["+key+"]", value)
+	}
+
+	var intent struct {
+		Id           string `json:"id"`
+		ClientSecret string `json:"client_secret"`
+		Status       string `json:"status"`
+	}
+	if err := p.post(ctx, "/v1/payment_intents", form, &intent); err != nil {
+		return nil, err
+	}
+	return &Intent{Id: intent.Id, ClientSecret: intent.ClientSecret, Status: intent.Status}, nil
+}
+
+// Refund refunds a PaymentIntent; an amount of 0 refunds what is left of it
+func (p *StripeProvider) Refund(ctx context.Context, paymentId string, amount types.Money) (*Refund, error) {
+	form := url.Values{}
+	form.Set("payment_intent", paymentId)
+	if amount > 0 {
+		form.Set("amount", strconv.FormatInt(int64(amount), 10))
+	}
+
+	var refund struct {
+		Id     string      `json:"id"`
+		Amount types.Money `json:"amount"`
+		Status string      `json:"status"`
+	}
+	if err := p.post(ctx, "/v1/refunds", form, &refund); err != nil {
+		return nil, err
+	}
+	return &Refund{Id: refund.Id, Amount: refund.Amount, Status: refund.Status}, nil
+}
+
+// ParseWebhook verifies the Stripe-Signature header of a webhook and maps the
+// payment_intent and charge.refunded events
+func (p *StripeProvider) ParseWebhook(payload []byte, header http.Header) (*WebhookEvent, error) {
+	if p.webhookSecret == "" {
+		return nil, ErrNotConfigured
+	}
+	if err := p.verifySignature(payload, header.Get("Stripe-Signature"), time.Now()); err != nil {
+		return nil, err
+	}
+
+	var event struct {
+		Id   string `json:"id"`
+		Type string `json:"type"`
+		Data struct {
+			Object struct {
+				Id               string      `json:"id"`
+				PaymentIntent    string      `json:"payment_intent"`
+				AmountRefunded   types.Money `json:"amount_refunded"`
+				LastPaymentError *struct {
+					Message string `json:"message"`
+				} `json:"last_payment_error"`
+			} `json:"object"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+
+	object := event.Data.Object
+	result := &WebhookEvent{Id: event.Id, ProviderType: event.Type, PaymentId: object.Id}
+	switch event.Type {
+	case "payment_intent.succeeded":
+		result.Type = EventSucceeded
+	case "payment_intent.payment_failed":
+		result.Type = EventFailed
+		if object.LastPaymentError != nil {
+			result.FailureMessage = object.LastPaymentError.Message
+		}
+	case "payment_intent.canceled":
+		result.Type = EventCanceled
+	case "charge.refunded":
+		result.Type = EventRefunded
+		result.PaymentId = object.PaymentIntent
+		result.AmountRefunded = object.AmountRefunded
+	}
+	return result, nil
+}
+
+// verifySignature checks a "t=<timestamp>,v1=<signature>" header: the signature is the
+// hex HMAC-SHA256 of "<timestamp>.<payload>" with the webhook secret
+func (p *StripeProvider) verifySignature(payload []byte, header string, now time.Time) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > stripeSignatureTolerance || age < -stripeSignatureTolerance {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(p.webhookSecret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	expected := mac.Sum(nil)
+	for _, signature := range signatures {
+		if decoded, err := hex.DecodeString(signature); err == nil && hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// post sends a form-encoded request to the Stripe API and decodes the response into out
+func (p *StripeProvider) post(ctx context.Context, path string, form url.Values, out any) error {
+	if p.secretKey == "" {
+		return ErrNotConfigured
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.secretKey)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("stripe request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Error.Message != "" {
+			return fmt.Errorf("stripe: %s", apiErr.Error.Message)
+		}
+		return fmt.Errorf("stripe: unexpected status %d", resp.StatusCode)
+	}
+	return json.Unmarshal(body, out)
+}
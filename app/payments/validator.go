@@ -0,0 +1,61 @@
+package payments
+
+import (
+	"fmt"
+	"strings"
+
+	"base/core/validator"
+)
+
+// Global validator instance using Base core validator wrapper
+var validate = validator.New()
+
+// ValidateCreatePaymentRequest validates the create request
+func ValidateCreatePaymentRequest(req *CreatePaymentRequest) error {
+	if req == nil {
+		return validator.ValidationErrors{
+			{
+				Field:   "request",
+				Tag:     "required",
+				Value:   "nil",
+				Message: "request cannot be nil",
+			},
+		}
+	}
+
+	req.Currency = strings.ToUpper(req.Currency) // Currency codes are accepted in any case
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateRefundRequest validates a refund of a payment
+func ValidateRefundRequest(req *RefundRequest, payment *Payment) error {
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	if !payment.Paid() || payment.Status == StatusRefunded {
+		return validator.ValidationErrors{
+			{
+				Field:   "status",
+				Tag:     "oneof",
+				Value:   payment.Status,
+				Param:   StatusSucceeded + " " + StatusPartiallyRefunded,
+				Message: "only paid payments that aren't fully refunded can be refunded",
+			},
+		}
+	}
+	if left := payment.Amount - payment.AmountRefunded; req.Amount > left {
+		return validator.ValidationErrors{
+			{
+				Field:   "amount",
+				Tag:     "lte",
+				Value:   fmt.Sprint(req.Amount),
+				Param:   fmt.Sprint(left),
+				Message: "amount can't be more than what is left to refund",
+			},
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package products
+
+import (
+	"net/http"
+	"strconv"
+
+	"base/app/seo"
+	"base/core/router"
+	"base/core/translation"
+	"base/core/types"
+)
+
+// catalogSortFields are the fields public product lists can be sorted by
+var catalogSortFields = []string{"created_at", "name", "price"}
+
+// CatalogController serves the public, read-only catalog: active products and categories
+// in the request locale. Its routes don't need a user token (see /api/catalog/* in
+// MIDDLEWARE_AUTH_SKIP_PATHS).
+type CatalogController struct {
+	Service *ProductService
+}
+
+func NewCatalogController(service *ProductService) *CatalogController {
+	return &CatalogController{
+		Service: service,
+	}
+}
+
+// Routes registers the public catalog endpoints
+func (c *CatalogController) Routes(router *router.RouterGroup) {
+	router.GET("/catalog/products", c.List)         // Active products
+	router.GET("/catalog/products/:slug", c.Get)    // Active product by slug
+	router.GET("/catalog/categories", c.Categories) // Active categories
+}
+
+// ListCatalogProducts godoc
+// @Summary List catalog products
+// @Description Get a page of active products in the request locale
+// @Tags App/Catalog
+// @Security ApiKeyAuth
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of items per page (at most 100)"
+// @Param sort query string false "Sort field (created_at, name, price)"
+// @Param order query string false "Sort order (asc, desc)"
+// @Param q query string false "Search the name, SKU and slug"
+// @Param category query string false "Slug of a category"
+// @Param min_price query int false "Minimum price in cents"
+// @Param max_price query int false "Maximum price in cents"
+// @Param in_stock query bool false "Only products in stock"
+// @Success 200 {object} types.PaginatedResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /catalog/products [get]
+func (c *CatalogController) List(ctx *router.Context) error {
+	params, err := ctx.ListParams(catalogSortFields...)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	active := true
+	filter := ProductFilter{Query: ctx.Query("q"), Active: &active, InStock: ctx.Query("in_stock") == "true"}
+	if slug := ctx.Query("category"); slug != "" {
+		category, err := c.Service.GetCategoryBySlug(ctx.Request.Context(), slug)
+		if err != nil {
+			return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Category not found"})
+		}
+		filter.CategoryId = category.Id
+	}
+	for name, target := range map[string]**types.Money{"min_price": &filter.MinPrice, "max_price": &filter.MaxPrice} {
+		if value := ctx.Query(name); value != "" {
+			cents, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid " + name})
+			}
+			price := types.Money(cents)
+			*target = &price
+		}
+	}
+
+	paginatedResponse, err := c.Service.GetAll(ctx.Request.Context(), params.Page, params.Limit, params.SortBy, params.SortOrder, filter)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch products: " + err.Error()})
+	}
+
+	model := &Product{}
+	if err := translation.Localize(ctx, model.TableName(), model.TranslatedFields(), paginatedResponse.Data); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to load translations: " + err.Error()})
+	}
+
+	return ctx.JSON(http.StatusOK, paginatedResponse)
+}
+
+// GetCatalogProduct godoc
+// @Summary Get a catalog product
+// @Description Get an active product by its slug with its active categories, variants, images and SEO metadata, in the request locale
+// @Tags App/Catalog
+// @Security ApiKeyAuth
+// @Produce json
+// @Param slug path string true "Product slug"
+// @Success 200 {object} ProductResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /catalog/products/{slug} [get]
+func (c *CatalogController) Get(ctx *router.Context) error {
+	item, err := c.Service.GetBySlug(ctx.Request.Context(), ctx.Param("slug"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Product not found"})
+	}
+
+	categories := item.Categories[:0]
+	for _, category := range item.Categories {
+		if category.Active {
+			categories = append(categories, category)
+		}
+	}
+	item.Categories = categories
+
+	response := item.ToResponse()
+	if err := localizeProduct(ctx, response); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to load translations: " + err.Error()})
+	}
+	response.Translations = nil
+	for _, category := range response.Categories {
+		category.Translations = nil
+	}
+	if response.Seo, err = seo.Records.Get(ctx, item.TableName(), item.Id); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to load SEO metadata: " + err.Error()})
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// ListCatalogCategories godoc
+// @Summary List catalog categories
+// @Description Get the active categories ordered by position and name, with their SEO metadata in the request locale; parent_id links subcategories
+// @Tags App/Catalog
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} CategoryResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /catalog/categories [get]
+func (c *CatalogController) Categories(ctx *router.Context) error {
+	categories, err := c.Service.GetCategories(ctx.Request.Context(), true)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch categories: " + err.Error()})
+	}
+	return respondCategories(ctx, http.StatusOK, categories, true)
+}
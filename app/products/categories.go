@@ -0,0 +1,215 @@
+package products
+
+import (
+	"context"
+	"fmt"
+
+	"base/core/logger"
+	"base/core/translation"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+// GetCategories returns the categories ordered by position and name; activeOnly leaves
+// out inactive ones, for the public catalog
+func (s *ProductService) GetCategories(ctx context.Context, activeOnly bool) ([]*Category, error) {
+	var categories []*Category
+	query := s.DB.WithContext(ctx).Order("position, name")
+	if activeOnly {
+		query = query.Where("active = ?", true)
+	}
+	if err := query.Find(&categories).Error; err != nil {
+		s.Logger.Error("failed to get product categories", logger.String("error", err.Error()))
+		return nil, err
+	}
+	return categories, nil
+}
+
+// GetCategory returns a category by id
+func (s *ProductService) GetCategory(ctx context.Context, id uint) (*Category, error) {
+	category := &Category{}
+	if err := s.DB.WithContext(ctx).First(category, id).Error; err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// GetCategoryBySlug returns an active category by its slug
+func (s *ProductService) GetCategoryBySlug(ctx context.Context, slug string) (*Category, error) {
+	category := &Category{}
+	if err := s.DB.WithContext(ctx).Where("slug = ? AND active = ?", slug, true).First(category).Error; err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// CreateCategory creates a category
+func (s *ProductService) CreateCategory(ctx context.Context, req *CreateCategoryRequest) (*Category, error) {
+	if err := ValidateCategoryCreateRequest(req); err != nil {
+		return nil, err
+	}
+	if err := translation.ValidateFieldValues(req.Translations, (&Category{}).TranslatedFields()); err != nil {
+		return nil, err
+	}
+
+	category := &Category{
+		Name:        req.Name,
+		Description: req.Description,
+		ParentId:    req.ParentId,
+		Position:    req.Position,
+		Active:      true,
+	}
+	if req.Active != nil {
+		category.Active = *req.Active
+	}
+
+	slug, errs := s.slug(ctx, &Category{}, req.Slug, req.Name, 0)
+	if err := s.checkParent(ctx, category); err != nil {
+		errs = append(errs, *err)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	category.Slug = slug
+
+	if err := s.DB.WithContext(ctx).Create(category).Error; err != nil {
+		s.Logger.Error("failed to create product category", logger.String("error", err.Error()))
+		return nil, err
+	}
+	if err := translation.Fields.WithDB(s.DB.WithContext(ctx)).Set(category.TableName(), category.Id, req.Translations); err != nil {
+		s.Logger.Error("failed to save product category translations", logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	s.Emitter.EmitContext(ctx, CreateCategoryEvent, category)
+	return category, nil
+}
+
+// UpdateCategory updates the fields of a category that are set in the request
+func (s *ProductService) UpdateCategory(ctx context.Context, id uint, req *UpdateCategoryRequest) (*Category, error) {
+	category, err := s.GetCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateCategoryUpdateRequest(req); err != nil {
+		return nil, err
+	}
+	if err := translation.ValidateFieldValues(req.Translations, category.TranslatedFields()); err != nil {
+		return nil, err
+	}
+
+	var errs validator.ValidationErrors
+	if req.Name != nil {
+		category.Name = *req.Name
+	}
+	if req.Slug != nil && *req.Slug != category.Slug {
+		slug, slugErrs := s.slug(ctx, &Category{}, *req.Slug, category.Name, category.Id)
+		errs = append(errs, slugErrs...)
+		category.Slug = slug
+	}
+	if req.Description != nil {
+		category.Description = *req.Description
+	}
+	if req.ParentId != nil {
+		category.ParentId = req.ParentId
+		if *req.ParentId == 0 {
+			category.ParentId = nil
+		}
+		if err := s.checkParent(ctx, category); err != nil {
+			errs = append(errs, *err)
+		}
+	}
+	if req.Position != nil {
+		category.Position = *req.Position
+	}
+	if req.Active != nil {
+		category.Active = *req.Active
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	if err := s.DB.WithContext(ctx).Save(category).Error; err != nil {
+		s.Logger.Error("failed to update product category",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return nil, err
+	}
+	if err := translation.Fields.WithDB(s.DB.WithContext(ctx)).Set(category.TableName(), category.Id, req.Translations); err != nil {
+		s.Logger.Error("failed to save product category translations",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return nil, err
+	}
+
+	s.Emitter.EmitContext(ctx, UpdateCategoryEvent, category)
+	return category, nil
+}
+
+// DeleteCategory deletes a category. Its products stay in the catalog without it and its
+// subcategories move up to its parent.
+func (s *ProductService) DeleteCategory(ctx context.Context, id uint) error {
+	category, err := s.GetCategory(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM product_category_links WHERE category_id = ?", category.Id).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&Category{}).Where("parent_id = ?", category.Id).Update("parent_id", category.ParentId).Error; err != nil {
+			return err
+		}
+		return tx.Delete(category).Error
+	})
+	if err != nil {
+		s.Logger.Error("failed to delete product category",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return err
+	}
+	if err := translation.Fields.WithDB(s.DB.WithContext(ctx)).Delete(category.TableName(), category.Id); err != nil {
+		s.Logger.Warn("failed to delete product category translations",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+	}
+
+	s.Emitter.EmitContext(ctx, DeleteCategoryEvent, category)
+	return nil
+}
+
+// checkParent checks that the parent of a category exists and isn't the category itself
+// or one of its subcategories
+func (s *ProductService) checkParent(ctx context.Context, category *Category) *validator.ValidationError {
+	if category.ParentId == nil {
+		return nil
+	}
+	invalid := func(tag, message string) *validator.ValidationError {
+		return &validator.ValidationError{
+			Field:   "parent_id",
+			Tag:     tag,
+			Value:   fmt.Sprint(*category.ParentId),
+			Message: message,
+		}
+	}
+
+	seen := map[uint]bool{}
+	for id := category.ParentId; id != nil; {
+		if category.Id != 0 && *id == category.Id {
+			return invalid("invalid", "parent_id can't be the category or one of its subcategories")
+		}
+		if seen[*id] {
+			break // An existing cycle, don't loop forever
+		}
+		seen[*id] = true
+
+		parent := &Category{}
+		if err := s.DB.WithContext(ctx).Select("id, parent_id").First(parent, *id).Error; err != nil {
+			return invalid("exists", fmt.Sprintf("category %d does not exist", *id))
+		}
+		id = parent.ParentId
+	}
+	return nil
+}
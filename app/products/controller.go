@@ -0,0 +1,811 @@
+package products
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"base/app/seo"
+	"base/core/app/customfields"
+	"base/core/crud"
+	"base/core/fieldset"
+	"base/core/router"
+	"base/core/storage"
+	"base/core/translation"
+	"base/core/types"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+type ProductController struct {
+	Service *ProductService
+
+	// listFields are the fields and includes clients can choose in the list
+	listFields *fieldset.Spec
+}
+
+func NewProductController(service *ProductService) *ProductController {
+	c := &ProductController{
+		Service: service,
+	}
+	c.listFields = &fieldset.Spec{
+		Fields: []string{"id", "sku", "name", "slug", "price", "compare_at_price", "currency", "stock",
+			"in_stock", "active", "archived_at", "image", "created_at", "updated_at", "custom_fields"},
+		Includes: map[string]fieldset.Loader{
+			"categories": fieldset.Load(c.listCategories),
+			"images":     fieldset.Load(c.listImages),
+			"seo": fieldset.Load(func(ctx *router.Context, ids []uint) (map[uint]*seo.Metadata, error) {
+				return seo.Records.Load(ctx, (&Product{}).TableName(), ids)
+			}),
+		},
+	}
+	return c
+}
+
+// Routes registers the management endpoints; the group is restricted to admins by the module
+func (c *ProductController) Routes(router *router.RouterGroup) {
+	// Specific routes MUST come before parameterized routes
+	router.GET("/products", c.List)                                      // Paginated list
+	router.POST("/products", c.Create)                                   // Create
+	router.GET("/products/all", c.ListAll)                               // Select options - MUST be before /:id
+	router.GET("/products/:id", c.Get)                                   // Get by ID
+	router.PUT("/products/:id", c.Update)                                // Update
+	router.DELETE("/products/:id", c.Delete)                             // Delete
+	router.POST("/products/:id/duplicate", c.Duplicate)                  // Inactive copy
+	router.POST("/products/:id/archive", c.Archive)                      // Hide from lists and the catalog
+	router.POST("/products/:id/unarchive", c.Unarchive)                  // Bring back
+	router.POST("/products/:id/stock", c.UpdateStock)                    // Adjust or set stock
+	router.POST("/products/:id/variants", c.AddVariant)                  // Add variant
+	router.PUT("/products/:id/variants/:variant_id", c.UpdateVariant)    // Replace variant
+	router.DELETE("/products/:id/variants/:variant_id", c.DeleteVariant) // Remove variant
+	router.POST("/products/:id/images", c.AddImage)                      // Upload image
+	router.DELETE("/products/:id/images/:image_id", c.DeleteImage)       // Remove image
+	router.GET("/product-categories", c.ListCategories)                  // All categories
+	router.POST("/product-categories", c.CreateCategory)                 // Create category
+	router.GET("/product-categories/:id", c.GetCategory)                 // Get category
+	router.PUT("/product-categories/:id", c.UpdateCategory)              // Update category
+	router.DELETE("/product-categories/:id", c.DeleteCategory)           // Delete category
+}
+
+// CreateProduct godoc
+// @Summary Create a product
+// @Description Create a product with its variants; the slug is generated from the name when empty and prices are in cents (Admin only)
+// @Tags App/Product
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param product body CreateProductRequest true "Create product request"
+// @Success 201 {object} ProductResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /products [post]
+func (c *ProductController) Create(ctx *router.Context) error {
+	var req CreateProductRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	item, err := c.Service.Create(ctx.Request.Context(), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to create product")
+	}
+	return c.respond(ctx, http.StatusCreated, item.ToResponse())
+}
+
+// GetProduct godoc
+// @Summary Get a product
+// @Description Get a product with its categories, variants and images (Admin only)
+// @Tags App/Product
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Product id"
+// @Success 200 {object} ProductResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /products/{id} [get]
+func (c *ProductController) Get(ctx *router.Context) error {
+	id, err := parseId(ctx, "id")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	item, err := c.Service.GetById(ctx.Request.Context(), id)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to get product")
+	}
+	return c.respond(ctx, http.StatusOK, item.ToResponse())
+}
+
+// ListProducts godoc
+// @Summary List products
+// @Description Get a page of products, including inactive ones (Admin only)
+// @Tags App/Product
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of items per page"
+// @Param sort query string false "Sort field (id, created_at, updated_at, name, sku, price, stock)"
+// @Param order query string false "Sort order (asc, desc)"
+// @Param q query string false "Search the name, SKU and slug"
+// @Param category_id query int false "Only products of the category"
+// @Param active query bool false "Only active or inactive products"
+// @Param in_stock query bool false "Only products in stock"
+// @Param archived query string false "true for archived products only, all for both; archived products are left out by default"
+// @Param cf query string false "Custom field filters as cf[key]=value, e.g. cf[material]=cotton"
+// @Param fields query string false "Fields of the items, e.g. id,name,price (id is always included)"
+// @Param include query string false "Relationships to add to the items: categories, images, seo"
+// @Success 200 {object} types.PaginatedResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /products [get]
+func (c *ProductController) List(ctx *router.Context) error {
+	params, err := ctx.ListParams(sortFields...)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+	selection, err := c.listFields.Parse(ctx.Request.URL.Query())
+	if err != nil {
+		return c.fail(ctx, err, "Invalid fields")
+	}
+
+	filter := ProductFilter{
+		Query:        ctx.Query("q"),
+		InStock:      ctx.Query("in_stock") == "true",
+		CustomFields: customfields.ParseFilter(ctx.Request.URL.Query()),
+	}
+	if categoryId := ctx.Query("category_id"); categoryId != "" {
+		id, err := strconv.ParseUint(categoryId, 10, 32)
+		if err != nil {
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid category_id"})
+		}
+		filter.CategoryId = uint(id)
+	}
+	if active := ctx.Query("active"); active != "" {
+		value := active == "true"
+		filter.Active = &value
+	}
+	if filter.Archived, err = crud.ParseArchived(ctx.Query("archived")); err != nil {
+		return c.fail(ctx, err, "Invalid archived")
+	}
+
+	paginatedResponse, err := c.Service.GetAll(ctx.Request.Context(), params.Page, params.Limit, params.SortBy, params.SortOrder, filter)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to fetch products")
+	}
+
+	model := &Product{}
+	if err := translation.Localize(ctx, model.TableName(), model.TranslatedFields(), paginatedResponse.Data); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to load translations: " + err.Error()})
+	}
+	if responses, ok := paginatedResponse.Data.([]*ProductListResponse); ok {
+		ids := make([]uint, len(responses))
+		for i, response := range responses {
+			ids[i] = response.Id
+		}
+		customFields, err := customfields.Records.Load(model.TableName(), ids)
+		if err != nil {
+			return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to load custom fields: " + err.Error()})
+		}
+		for _, response := range responses {
+			response.CustomFields = customFields[response.Id]
+		}
+	}
+
+	data, err := selection.Apply(ctx, paginatedResponse)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to fetch products")
+	}
+	return ctx.JSON(http.StatusOK, data)
+}
+
+// listCategories loads the categories of the products of a list, in the request locale
+func (c *ProductController) listCategories(ctx *router.Context, ids []uint) (map[uint][]*CategoryResponse, error) {
+	categories, err := c.Service.CategoriesOf(ctx.Request.Context(), ids)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[uint][]*CategoryResponse, len(ids))
+	for _, id := range ids {
+		result[id] = []*CategoryResponse{}
+	}
+	var responses []*CategoryResponse
+	for id, items := range categories {
+		for _, item := range items {
+			response := item.ToResponse()
+			result[id] = append(result[id], response)
+			responses = append(responses, response)
+		}
+	}
+	model := &Category{}
+	if err := translation.Localize(ctx, model.TableName(), model.TranslatedFields(), responses); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// listImages loads the images of the products of a list
+func (c *ProductController) listImages(ctx *router.Context, ids []uint) (map[uint][]*ImageResponse, error) {
+	images, err := c.Service.ImagesOf(ctx.Request.Context(), ids)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[uint][]*ImageResponse, len(images))
+	for id, items := range images {
+		result[id] = []*ImageResponse{}
+		for _, item := range items {
+			result[id] = append(result[id], toImageResponse(item))
+		}
+	}
+	return result, nil
+}
+
+// ListAllProducts godoc
+// @Summary List all products for select options
+// @Description Get the id, name and SKU of all products (for dropdowns/select boxes) (Admin only)
+// @Tags App/Product
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} ProductSelectOption
+// @Failure 500 {object} types.ErrorResponse
+// @Router /products/all [get]
+func (c *ProductController) ListAll(ctx *router.Context) error {
+	items, err := c.Service.GetAllForSelect(ctx.Request.Context())
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch select options: " + err.Error()})
+	}
+
+	selectOptions := make([]*ProductSelectOption, 0, len(items))
+	for _, item := range items {
+		selectOptions = append(selectOptions, item.ToSelectOption())
+	}
+
+	return ctx.JSON(http.StatusOK, selectOptions)
+}
+
+// UpdateProduct godoc
+// @Summary Update a product
+// @Description Update the fields of a product that are set; category_ids replaces its categories (Admin only)
+// @Tags App/Product
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Product id"
+// @Param product body UpdateProductRequest true "Update product request"
+// @Success 200 {object} ProductResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /products/{id} [put]
+func (c *ProductController) Update(ctx *router.Context) error {
+	id, err := parseId(ctx, "id")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	var req UpdateProductRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	item, err := c.Service.Update(ctx.Request.Context(), id, &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to update product")
+	}
+	return c.respond(ctx, http.StatusOK, item.ToResponse())
+}
+
+// DeleteProduct godoc
+// @Summary Delete a product
+// @Description Delete a product with its variants and images; its SKU and slug stay reserved (Admin only)
+// @Tags App/Product
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param id path int true "Product id"
+// @Success 204
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /products/{id} [delete]
+func (c *ProductController) Delete(ctx *router.Context) error {
+	id, err := parseId(ctx, "id")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	if err := c.Service.Delete(ctx.Request.Context(), id); err != nil {
+		return c.fail(ctx, err, "Failed to delete product")
+	}
+
+	ctx.Status(http.StatusNoContent)
+	return nil
+}
+
+// DuplicateProduct godoc
+// @Summary Duplicate a product
+// @Description Create an inactive copy of a product with its variants, categories, images, translations, custom fields and SEO metadata; SKUs and slug get a -copy suffix (-copy-2... when taken) (Admin only)
+// @Tags App/Product
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Product id"
+// @Success 201 {object} ProductResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /products/{id}/duplicate [post]
+func (c *ProductController) Duplicate(ctx *router.Context) error {
+	id, err := parseId(ctx, "id")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	item, err := c.Service.Duplicate(ctx.Request.Context(), id)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to duplicate product")
+	}
+	return c.respond(ctx, http.StatusCreated, item.ToResponse())
+}
+
+// ArchiveProduct godoc
+// @Summary Archive a product
+// @Description Hide a product from lists and the catalog without deleting it; it keeps its orders, variants and images. Lists show archived products with archived=true (Admin only)
+// @Tags App/Product
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Product id"
+// @Success 200 {object} ProductResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /products/{id}/archive [post]
+func (c *ProductController) Archive(ctx *router.Context) error {
+	return c.archive(ctx, true)
+}
+
+// UnarchiveProduct godoc
+// @Summary Unarchive a product
+// @Description Bring an archived product back to lists, and to the catalog when it is active (Admin only)
+// @Tags App/Product
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Product id"
+// @Success 200 {object} ProductResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /products/{id}/unarchive [post]
+func (c *ProductController) Unarchive(ctx *router.Context) error {
+	return c.archive(ctx, false)
+}
+
+// archive archives the product of the :id parameter or brings it back
+func (c *ProductController) archive(ctx *router.Context, archived bool) error {
+	id, err := parseId(ctx, "id")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	item, err := c.Service.Archive(ctx.Request.Context(), id, archived)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to archive product")
+	}
+	return c.respond(ctx, http.StatusOK, item.ToResponse())
+}
+
+// UpdateProductStock godoc
+// @Summary Change the stock of a product
+// @Description Add a quantity to the stock (negative to take stock) or set it, of the product or one of its variants. Taking more than is left answers 409 for products that track stock (Admin only)
+// @Tags App/Product
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Product id"
+// @Param stock body StockRequest true "Stock change"
+// @Success 200 {object} ProductResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 409 {object} types.ErrorResponse
+// @Router /products/{id}/stock [post]
+func (c *ProductController) UpdateStock(ctx *router.Context) error {
+	id, err := parseId(ctx, "id")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	var req StockRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	item, err := c.Service.UpdateStock(ctx.Request.Context(), id, &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to update stock")
+	}
+	return c.respond(ctx, http.StatusOK, item.ToResponse())
+}
+
+// AddProductVariant godoc
+// @Summary Add a variant
+// @Description Add a variant with its own SKU, options and stock; a price overrides the product price (Admin only)
+// @Tags App/Product
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Product id"
+// @Param variant body VariantRequest true "Variant"
+// @Success 201 {object} ProductResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /products/{id}/variants [post]
+func (c *ProductController) AddVariant(ctx *router.Context) error {
+	id, err := parseId(ctx, "id")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	var req VariantRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	item, err := c.Service.AddVariant(ctx.Request.Context(), id, &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to add variant")
+	}
+	return c.respond(ctx, http.StatusCreated, item.ToResponse())
+}
+
+// UpdateProductVariant godoc
+// @Summary Replace a variant
+// @Description Replace the fields of a variant (Admin only)
+// @Tags App/Product
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Product id"
+// @Param variant_id path int true "Variant id"
+// @Param variant body VariantRequest true "Variant"
+// @Success 200 {object} ProductResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /products/{id}/variants/{variant_id} [put]
+func (c *ProductController) UpdateVariant(ctx *router.Context) error {
+	id, err := parseId(ctx, "id")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+	variantId, err := parseId(ctx, "variant_id")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid variant_id format"})
+	}
+
+	var req VariantRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	item, err := c.Service.UpdateVariant(ctx.Request.Context(), id, variantId, &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to update variant")
+	}
+	return c.respond(ctx, http.StatusOK, item.ToResponse())
+}
+
+// DeleteProductVariant godoc
+// @Summary Remove a variant
+// @Description Remove a variant from a product (Admin only)
+// @Tags App/Product
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Product id"
+// @Param variant_id path int true "Variant id"
+// @Success 200 {object} ProductResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /products/{id}/variants/{variant_id} [delete]
+func (c *ProductController) DeleteVariant(ctx *router.Context) error {
+	id, err := parseId(ctx, "id")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+	variantId, err := parseId(ctx, "variant_id")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid variant_id format"})
+	}
+
+	item, err := c.Service.DeleteVariant(ctx.Request.Context(), id, variantId)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to delete variant")
+	}
+	return c.respond(ctx, http.StatusOK, item.ToResponse())
+}
+
+// AddProductImage godoc
+// @Summary Upload a product image
+// @Description Upload an image (jpg, png, gif or webp, at most 10MB); images are listed in upload order and the first is the list image (Admin only)
+// @Tags App/Product
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Product id"
+// @Param image formData file true "Image file"
+// @Success 201 {object} ProductResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /products/{id}/images [post]
+func (c *ProductController) AddImage(ctx *router.Context) error {
+	id, err := parseId(ctx, "id")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	file, err := ctx.FormFile("image")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Failed to get image file: " + err.Error()})
+	}
+
+	item, err := c.Service.AddImage(ctx.Request.Context(), id, file)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to upload image")
+	}
+	return c.respond(ctx, http.StatusCreated, item.ToResponse())
+}
+
+// DeleteProductImage godoc
+// @Summary Remove a product image
+// @Description Remove an image of a product and its file (Admin only)
+// @Tags App/Product
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Product id"
+// @Param image_id path int true "Image id"
+// @Success 200 {object} ProductResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /products/{id}/images/{image_id} [delete]
+func (c *ProductController) DeleteImage(ctx *router.Context) error {
+	id, err := parseId(ctx, "id")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+	imageId, err := parseId(ctx, "image_id")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid image_id format"})
+	}
+
+	item, err := c.Service.DeleteImage(ctx.Request.Context(), id, imageId)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to delete image")
+	}
+	return c.respond(ctx, http.StatusOK, item.ToResponse())
+}
+
+// ListProductCategories godoc
+// @Summary List product categories
+// @Description Get all categories, including inactive ones, ordered by position and name (Admin only)
+// @Tags App/Product
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} CategoryResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /product-categories [get]
+func (c *ProductController) ListCategories(ctx *router.Context) error {
+	categories, err := c.Service.GetCategories(ctx.Request.Context(), false)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch categories: " + err.Error()})
+	}
+	return respondCategories(ctx, http.StatusOK, categories, false)
+}
+
+// CreateProductCategory godoc
+// @Summary Create a product category
+// @Description Create a category; the slug is generated from the name when empty (Admin only)
+// @Tags App/Product
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param category body CreateCategoryRequest true "Create category request"
+// @Success 201 {object} CategoryResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /product-categories [post]
+func (c *ProductController) CreateCategory(ctx *router.Context) error {
+	var req CreateCategoryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	category, err := c.Service.CreateCategory(ctx.Request.Context(), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to create category")
+	}
+	return c.respondCategory(ctx, http.StatusCreated, category)
+}
+
+// GetProductCategory godoc
+// @Summary Get a product category
+// @Description Get a category with all its translations (Admin only)
+// @Tags App/Product
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Category id"
+// @Success 200 {object} CategoryResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /product-categories/{id} [get]
+func (c *ProductController) GetCategory(ctx *router.Context) error {
+	id, err := parseId(ctx, "id")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	category, err := c.Service.GetCategory(ctx.Request.Context(), id)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to get category")
+	}
+	return c.respondCategory(ctx, http.StatusOK, category)
+}
+
+// UpdateProductCategory godoc
+// @Summary Update a product category
+// @Description Update the fields of a category that are set; a parent_id of 0 makes it a top-level category (Admin only)
+// @Tags App/Product
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Category id"
+// @Param category body UpdateCategoryRequest true "Update category request"
+// @Success 200 {object} CategoryResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /product-categories/{id} [put]
+func (c *ProductController) UpdateCategory(ctx *router.Context) error {
+	id, err := parseId(ctx, "id")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	var req UpdateCategoryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	category, err := c.Service.UpdateCategory(ctx.Request.Context(), id, &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to update category")
+	}
+	return c.respondCategory(ctx, http.StatusOK, category)
+}
+
+// DeleteProductCategory godoc
+// @Summary Delete a product category
+// @Description Delete a category; its products lose the category and its subcategories move up to its parent (Admin only)
+// @Tags App/Product
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param id path int true "Category id"
+// @Success 204
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /product-categories/{id} [delete]
+func (c *ProductController) DeleteCategory(ctx *router.Context) error {
+	id, err := parseId(ctx, "id")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	if err := c.Service.DeleteCategory(ctx.Request.Context(), id); err != nil {
+		return c.fail(ctx, err, "Failed to delete category")
+	}
+
+	ctx.Status(http.StatusNoContent)
+	return nil
+}
+
+// respond writes a product response with its translated fields in the request locale,
+// all their translations and its custom fields
+func (c *ProductController) respond(ctx *router.Context, status int, response *ProductResponse) error {
+	if err := localizeProduct(ctx, response); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to load translations: " + err.Error()})
+	}
+	customFields, err := customfields.Records.Get((&Product{}).TableName(), response.Id)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to load custom fields: " + err.Error()})
+	}
+	response.CustomFields = customFields
+	return ctx.JSON(status, response)
+}
+
+// respondCategory writes a category response with its translations
+func (c *ProductController) respondCategory(ctx *router.Context, status int, category *Category) error {
+	response := category.ToResponse()
+	if err := translation.Localize(ctx, category.TableName(), category.TranslatedFields(), response); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to load translations: " + err.Error()})
+	}
+	return ctx.JSON(status, response)
+}
+
+// respondCategories writes a list of categories in the request locale; public lists
+// leave out the translations of other locales
+func respondCategories(ctx *router.Context, status int, categories []*Category, public bool) error {
+	responses := make([]*CategoryResponse, 0, len(categories))
+	for _, category := range categories {
+		responses = append(responses, category.ToResponse())
+	}
+
+	model := &Category{}
+	if err := translation.Localize(ctx, model.TableName(), model.TranslatedFields(), responses); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to load translations: " + err.Error()})
+	}
+	if public {
+		ids := make([]uint, len(responses))
+		for i, response := range responses {
+			response.Translations = nil
+			ids[i] = response.Id
+		}
+		metadata, err := seo.Records.Load(ctx, model.TableName(), ids)
+		if err != nil {
+			return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to load SEO metadata: " + err.Error()})
+		}
+		for _, response := range responses {
+			response.Seo = metadata[response.Id]
+		}
+	}
+	return ctx.JSON(status, responses)
+}
+
+// fail writes the error response of a service error
+func (c *ProductController) fail(ctx *router.Context, err error, message string) error {
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   translation.Error(ctx, err),
+			Details: translation.LocalizeValidation(ctx, validationErrors),
+		})
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Item not found"})
+	}
+	if errors.Is(err, storage.ErrInvalidFile) {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+	if errors.Is(err, ErrInsufficientStock) {
+		return ctx.JSON(http.StatusConflict, types.ErrorResponse{Error: translation.Error(ctx, err)})
+	}
+	return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: message + ": " + err.Error()})
+}
+
+// localizeProduct translates a product response and its categories into the request locale
+func localizeProduct(ctx *router.Context, response *ProductResponse) error {
+	model := &Product{}
+	if err := translation.Localize(ctx, model.TableName(), model.TranslatedFields(), response); err != nil {
+		return err
+	}
+	category := &Category{}
+	return translation.Localize(ctx, category.TableName(), category.TranslatedFields(), response.Categories)
+}
+
+func parseId(ctx *router.Context, name string) (uint, error) {
+	id, err := strconv.ParseUint(ctx.Param(name), 10, 32)
+	return uint(id), err
+}
@@ -0,0 +1,455 @@
+package products
+
+import (
+	"time"
+
+	"base/app/seo"
+	"base/core/app/customfields"
+	"base/core/crud"
+	"base/core/storage"
+	"base/core/translation"
+	"base/core/types"
+
+	"gorm.io/gorm"
+)
+
+// Category groups products in the catalog; categories nest through ParentId
+type Category struct {
+	Id          uint           `json:"id" gorm:"primarykey"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	Name        string         `json:"name" gorm:"size:255"`
+	Slug        string         `json:"slug" gorm:"size:255;uniqueIndex"`
+	Description string         `json:"description" gorm:"type:text"`
+	ParentId    *uint          `json:"parent_id" gorm:"index"`
+	Position    int            `json:"position"`
+	Active      bool           `json:"active"`
+}
+
+// TableName returns the table name for the Category model
+func (m *Category) TableName() string {
+	return "product_categories"
+}
+
+// GetId returns the Id of the model
+func (m *Category) GetId() uint {
+	return m.Id
+}
+
+// TranslatedFields returns the fields that can have per-locale values
+func (m *Category) TranslatedFields() []string {
+	return []string{"name", "description"}
+}
+
+// Product is an item of the catalog. Prices are in the minor unit of the currency (cents).
+// Products with variants are sold through their variants, which have their own SKU and
+// stock and can override the price.
+type Product struct {
+	Id             uint                  `json:"id" gorm:"primarykey"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt        `json:"deleted_at" gorm:"index"`
+	Sku            string                `json:"sku" gorm:"size:64;uniqueIndex"`
+	Name           string                `json:"name" gorm:"size:255"`
+	Slug           string                `json:"slug" gorm:"size:255;uniqueIndex"`
+	Description    string                `json:"description" gorm:"type:text"`
+	Price          types.Money           `json:"price"`
+	CompareAtPrice types.Money           `json:"compare_at_price"` // Former price shown crossed out, 0 for none
+	Currency       string                `json:"currency" gorm:"size:3"`
+	Stock          int                   `json:"stock"`
+	Weight         int                   `json:"weight"`                   // Grams, for shipping
+	TrackStock     bool                  `json:"track_stock"`              // Stock can't go below zero
+	Active         bool                  `json:"active" gorm:"index"`      // Listed in the public catalog
+	ArchivedAt     *time.Time            `json:"archived_at" gorm:"index"` // Hidden from lists and the catalog, see crud.Archive
+	Categories     []*Category           `json:"categories,omitempty" gorm:"many2many:product_category_links;joinForeignKey:ProductId;joinReferences:CategoryId"`
+	Variants       []*ProductVariant     `json:"variants,omitempty" gorm:"foreignKey:ProductId"`
+	Images         []*storage.Attachment `json:"images,omitempty" gorm:"-"`
+}
+
+// TableName returns the table name for the Product model
+func (m *Product) TableName() string {
+	return "products"
+}
+
+// GetId returns the Id of the model
+func (m *Product) GetId() uint {
+	return m.Id
+}
+
+// GetModelName returns the model name (for storage attachments)
+func (m *Product) GetModelName() string {
+	return "products"
+}
+
+// TranslatedFields returns the fields that can have per-locale values
+func (m *Product) TranslatedFields() []string {
+	return []string{"name", "description"}
+}
+
+// InStock reports whether the product can be ordered: it doesn't track stock, or it
+// or one of its variants has stock left
+func (m *Product) InStock() bool {
+	if !m.TrackStock {
+		return true
+	}
+	if len(m.Variants) > 0 {
+		for _, variant := range m.Variants {
+			if variant.Stock > 0 {
+				return true
+			}
+		}
+		return false
+	}
+	return m.Stock > 0
+}
+
+// ProductVariant is a purchasable option of a product, e.g. a size and colour
+type ProductVariant struct {
+	Id        uint              `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	ProductId uint              `json:"product_id" gorm:"index"`
+	Sku       string            `json:"sku" gorm:"size:64;uniqueIndex"`
+	Name      string            `json:"name" gorm:"size:255"`
+	Options   map[string]string `json:"options" gorm:"type:text;serializer:json"` // e.g. {"size": "XL", "color": "red"}
+	Price     *types.Money      `json:"price"`                                    // Overrides the product price when set
+	Weight    *int              `json:"weight"`                                   // Overrides the product weight when set
+	Stock     int               `json:"stock"`
+	Position  int               `json:"position"`
+}
+
+// TableName returns the table name for the ProductVariant model
+func (m *ProductVariant) TableName() string {
+	return "product_variants"
+}
+
+// CreateCategoryRequest represents the request payload for creating a category
+type CreateCategoryRequest struct {
+	Name        string `json:"name" validate:"required,max=255"`
+	Slug        string `json:"slug" validate:"omitempty,max=255"` // Generated from the name when empty
+	Description string `json:"description"`
+	ParentId    *uint  `json:"parent_id,omitempty"`
+	Position    int    `json:"position"`
+	Active      *bool  `json:"active,omitempty"` // Defaults to true
+
+	// Translations of the translated fields by locale, e.g. {"name": {"de": "..."}}
+	Translations translation.FieldValues `json:"translations,omitempty"`
+}
+
+// UpdateCategoryRequest represents the request payload for updating a category. Omitted
+// fields are left unchanged; a parent_id of 0 makes it a top-level category.
+type UpdateCategoryRequest struct {
+	Name        *string `json:"name,omitempty" validate:"omitempty,min=1,max=255"`
+	Slug        *string `json:"slug,omitempty" validate:"omitempty,max=255"`
+	Description *string `json:"description,omitempty"`
+	ParentId    *uint   `json:"parent_id,omitempty"`
+	Position    *int    `json:"position,omitempty"`
+	Active      *bool   `json:"active,omitempty"`
+
+	// Translations to add or change; an empty value removes a translation
+	Translations translation.FieldValues `json:"translations,omitempty"`
+}
+
+// VariantRequest represents the request payload for creating or replacing a variant
+type VariantRequest struct {
+	Sku      string            `json:"sku" validate:"required,max=64"`
+	Name     string            `json:"name" validate:"required,max=255"`
+	Options  map[string]string `json:"options,omitempty"`
+	Price    *types.Money      `json:"price,omitempty" validate:"omitempty,gte=0"`
+	Weight   *int              `json:"weight,omitempty" validate:"omitempty,gte=0"`
+	Stock    int               `json:"stock" validate:"gte=0"`
+	Position int               `json:"position"`
+}
+
+// CreateProductRequest represents the request payload for creating a product
+type CreateProductRequest struct {
+	Sku            string           `json:"sku" validate:"required,max=64"`
+	Name           string           `json:"name" validate:"required,max=255"`
+	Slug           string           `json:"slug" validate:"omitempty,max=255"` // Generated from the name when empty
+	Description    string           `json:"description"`
+	Price          types.Money      `json:"price" validate:"gte=0"`
+	CompareAtPrice types.Money      `json:"compare_at_price" validate:"gte=0"`
+	Currency       string           `json:"currency" validate:"omitempty,iso4217"` // Defaults to DefaultCurrency
+	Stock          int              `json:"stock" validate:"gte=0"`
+	Weight         int              `json:"weight" validate:"gte=0"` // Grams
+	TrackStock     *bool            `json:"track_stock,omitempty"`   // Defaults to true
+	Active         bool             `json:"active"`
+	CategoryIds    []uint           `json:"category_ids,omitempty"`
+	Variants       []VariantRequest `json:"variants,omitempty" validate:"dive"`
+
+	// Translations of the translated fields by locale, e.g. {"name": {"de": "..."}}
+	Translations translation.FieldValues `json:"translations,omitempty"`
+
+	// Values of the custom fields of products, by key (see customfields)
+	CustomFields customfields.Values `json:"custom_fields,omitempty"`
+}
+
+// UpdateProductRequest represents the request payload for updating a product. Omitted
+// fields are left unchanged; category_ids replaces the categories. Stock is changed
+// through the stock endpoint and variants through their own endpoints.
+type UpdateProductRequest struct {
+	Sku            *string      `json:"sku,omitempty" validate:"omitempty,min=1,max=64"`
+	Name           *string      `json:"name,omitempty" validate:"omitempty,min=1,max=255"`
+	Slug           *string      `json:"slug,omitempty" validate:"omitempty,max=255"`
+	Description    *string      `json:"description,omitempty"`
+	Price          *types.Money `json:"price,omitempty" validate:"omitempty,gte=0"`
+	CompareAtPrice *types.Money `json:"compare_at_price,omitempty" validate:"omitempty,gte=0"`
+	Currency       *string      `json:"currency,omitempty" validate:"omitempty,iso4217"`
+	Weight         *int         `json:"weight,omitempty" validate:"omitempty,gte=0"`
+	TrackStock     *bool        `json:"track_stock,omitempty"`
+	Active         *bool        `json:"active,omitempty"`
+	CategoryIds    *[]uint      `json:"category_ids,omitempty"`
+
+	// Translations to add or change; an empty value removes a translation
+	Translations translation.FieldValues `json:"translations,omitempty"`
+
+	// Custom field values to change; null removes a value
+	CustomFields customfields.Values `json:"custom_fields,omitempty"`
+}
+
+// StockChange is the payload of the stock event: the product with its current stock and the
+// variant whose stock changed, nil for the product itself
+type StockChange struct {
+	Product   *Product `json:"product"`
+	VariantId *uint    `json:"variant_id"`
+}
+
+// StockRequest changes the stock of a product or one of its variants, either by a
+// quantity (negative to take stock) or to an absolute value
+type StockRequest struct {
+	VariantId *uint `json:"variant_id,omitempty"`
+	Quantity  *int  `json:"quantity,omitempty"` // Added to the stock
+	Stock     *int  `json:"stock,omitempty" validate:"omitempty,gte=0"`
+}
+
+// ProductFilter narrows product lists
+type ProductFilter struct {
+	Query      string // Matches the name, SKU or slug
+	CategoryId uint
+	Active     *bool
+	InStock    bool
+	MinPrice   *types.Money
+	MaxPrice   *types.Money
+	Archived   crud.Archived // Archived products are left out by default
+
+	// Custom field values to match, e.g. from cf[material]=cotton
+	CustomFields customfields.Filter
+}
+
+// CategoryResponse represents the API response for a category
+type CategoryResponse struct {
+	Id          uint      `json:"id"`
+	Name        string    `json:"name"`
+	Slug        string    `json:"slug"`
+	Description string    `json:"description"`
+	ParentId    *uint     `json:"parent_id"`
+	Position    int       `json:"position"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// SEO metadata, in the catalog (see seo.Records)
+	Seo *seo.Metadata `json:"seo,omitempty"`
+
+	// All translations, by field and locale
+	Translations translation.FieldValues `json:"translations,omitempty"`
+}
+
+// VariantResponse represents the API response for a variant
+type VariantResponse struct {
+	Id       uint              `json:"id"`
+	Sku      string            `json:"sku"`
+	Name     string            `json:"name"`
+	Options  map[string]string `json:"options"`
+	Price    types.Money       `json:"price"` // Effective price
+	Stock    int               `json:"stock"`
+	Position int               `json:"position"`
+}
+
+// ImageResponse represents a product image
+type ImageResponse struct {
+	Id       uint   `json:"id"`
+	URL      string `json:"url"`
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+}
+
+// ProductResponse represents the API response for a product
+type ProductResponse struct {
+	Id             uint                `json:"id"`
+	Sku            string              `json:"sku"`
+	Name           string              `json:"name"`
+	Slug           string              `json:"slug"`
+	Description    string              `json:"description"`
+	Price          types.Money         `json:"price"`
+	CompareAtPrice types.Money         `json:"compare_at_price"`
+	Currency       string              `json:"currency"`
+	Stock          int                 `json:"stock"`
+	TrackStock     bool                `json:"track_stock"`
+	InStock        bool                `json:"in_stock"`
+	Active         bool                `json:"active"`
+	ArchivedAt     *time.Time          `json:"archived_at"`
+	Categories     []*CategoryResponse `json:"categories"`
+	Variants       []*VariantResponse  `json:"variants"`
+	Images         []*ImageResponse    `json:"images"`
+	CreatedAt      time.Time           `json:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at"`
+
+	// SEO metadata, in the catalog (see seo.Records)
+	Seo *seo.Metadata `json:"seo,omitempty"`
+
+	// All translations, by field and locale
+	Translations translation.FieldValues `json:"translations,omitempty"`
+
+	// Values of the custom fields, in admin responses
+	CustomFields customfields.Values `json:"custom_fields,omitempty"`
+}
+
+// ProductListResponse represents a product in lists, with its first image
+type ProductListResponse struct {
+	Id             uint           `json:"id"`
+	Sku            string         `json:"sku"`
+	Name           string         `json:"name"`
+	Slug           string         `json:"slug"`
+	Price          types.Money    `json:"price"`
+	CompareAtPrice types.Money    `json:"compare_at_price"`
+	Currency       string         `json:"currency"`
+	Stock          int            `json:"stock"`
+	InStock        bool           `json:"in_stock"`
+	Active         bool           `json:"active"`
+	ArchivedAt     *time.Time     `json:"archived_at"`
+	Image          *ImageResponse `json:"image"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+
+	// Values of the custom fields, in admin lists
+	CustomFields customfields.Values `json:"custom_fields,omitempty"`
+}
+
+// ProductSelectOption represents a simplified response for select boxes and dropdowns
+type ProductSelectOption struct {
+	Id   uint   `json:"id"`
+	Name string `json:"name"`
+	Sku  string `json:"sku"`
+}
+
+// ToResponse converts the category to an API response
+func (m *Category) ToResponse() *CategoryResponse {
+	if m == nil {
+		return nil
+	}
+	return &CategoryResponse{
+		Id:          m.Id,
+		Name:        m.Name,
+		Slug:        m.Slug,
+		Description: m.Description,
+		ParentId:    m.ParentId,
+		Position:    m.Position,
+		Active:      m.Active,
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+	}
+}
+
+// ToResponse converts the variant to an API response with the effective price
+func (m *ProductVariant) ToResponse(product *Product) *VariantResponse {
+	price := product.Price
+	if m.Price != nil {
+		price = *m.Price
+	}
+	return &VariantResponse{
+		Id:       m.Id,
+		Sku:      m.Sku,
+		Name:     m.Name,
+		Options:  m.Options,
+		Price:    price,
+		Stock:    m.Stock,
+		Position: m.Position,
+	}
+}
+
+// toImageResponse converts an attachment to an image response
+func toImageResponse(attachment *storage.Attachment) *ImageResponse {
+	return &ImageResponse{
+		Id:       attachment.Id,
+		URL:      attachment.URL,
+		Filename: attachment.Filename,
+		Size:     attachment.Size,
+	}
+}
+
+// ToResponse converts the product with its categories, variants and images to an API response
+func (m *Product) ToResponse() *ProductResponse {
+	if m == nil {
+		return nil
+	}
+	response := &ProductResponse{
+		Id:             m.Id,
+		Sku:            m.Sku,
+		Name:           m.Name,
+		Slug:           m.Slug,
+		Description:    m.Description,
+		Price:          m.Price,
+		CompareAtPrice: m.CompareAtPrice,
+		Currency:       m.Currency,
+		Stock:          m.Stock,
+		TrackStock:     m.TrackStock,
+		InStock:        m.InStock(),
+		Active:         m.Active,
+		ArchivedAt:     m.ArchivedAt,
+		Categories:     make([]*CategoryResponse, 0, len(m.Categories)),
+		Variants:       make([]*VariantResponse, 0, len(m.Variants)),
+		Images:         make([]*ImageResponse, 0, len(m.Images)),
+		CreatedAt:      m.CreatedAt,
+		UpdatedAt:      m.UpdatedAt,
+	}
+	for _, category := range m.Categories {
+		response.Categories = append(response.Categories, category.ToResponse())
+	}
+	for _, variant := range m.Variants {
+		response.Variants = append(response.Variants, variant.ToResponse(m))
+	}
+	for _, image := range m.Images {
+		response.Images = append(response.Images, toImageResponse(image))
+	}
+	return response
+}
+
+// ToListResponse converts the product to a list response
+func (m *Product) ToListResponse() *ProductListResponse {
+	if m == nil {
+		return nil
+	}
+	response := &ProductListResponse{
+		Id:             m.Id,
+		Sku:            m.Sku,
+		Name:           m.Name,
+		Slug:           m.Slug,
+		Price:          m.Price,
+		CompareAtPrice: m.CompareAtPrice,
+		Currency:       m.Currency,
+		Stock:          m.Stock,
+		InStock:        m.InStock(),
+		Active:         m.Active,
+		ArchivedAt:     m.ArchivedAt,
+		CreatedAt:      m.CreatedAt,
+		UpdatedAt:      m.UpdatedAt,
+	}
+	if len(m.Images) > 0 {
+		response.Image = toImageResponse(m.Images[0])
+	}
+	return response
+}
+
+// ToSelectOption converts the product to a select option for dropdowns
+func (m *Product) ToSelectOption() *ProductSelectOption {
+	if m == nil {
+		return nil
+	}
+	return &ProductSelectOption{
+		Id:   m.Id,
+		Name: m.Name,
+		Sku:  m.Sku,
+	}
+}
@@ -0,0 +1,157 @@
+package products
+
+import (
+	"errors"
+
+	"base/app/seo"
+	"base/core/app/authorization"
+	"base/core/app/customfields"
+	"base/core/app/reports"
+	"base/core/app/trash"
+	"base/core/module"
+	"base/core/router"
+
+	"gorm.io/gorm"
+)
+
+// Module provides the product catalog: products with variants, stock and images, and
+// their categories. Management endpoints are restricted to admins; the catalog endpoints
+// are public.
+type Module struct {
+	module.DefaultModule
+	DB         *gorm.DB
+	Service    *ProductService
+	Controller *ProductController
+	Catalog    *CatalogController
+}
+
+// Init creates and initializes the products module with all dependencies
+func Init(deps module.Dependencies) module.Module {
+	registerEvents()
+	service := NewProductService(deps.DB, deps.Emitter, deps.Storage, deps.Logger)
+
+	reports.RegisterEntity(reports.Entity{
+		Name:       "products",
+		Columns:    []string{"id", "sku", "name", "slug", "price", "compare_at_price", "currency", "stock", "track_stock", "active", "archived_at", "created_at", "updated_at"},
+		SoftDelete: true,
+	})
+	customfields.RegisterEntity("products")
+	trash.RegisterEntity(trash.Entity{
+		Name:        "products",
+		Model:       &Product{},
+		Title:       "name",
+		DeleteEvent: DeleteProductEvent,
+		UpdateEvent: UpdateProductEvent,
+	})
+	seo.RegisterEntity(seo.Entity{
+		Name:        "products",
+		Path:        "/products",
+		Model:       &Product{},
+		DeleteEvent: DeleteProductEvent,
+	})
+	seo.RegisterEntity(seo.Entity{
+		Name:        "product_categories",
+		Path:        "/product-categories",
+		Model:       &Category{},
+		DeleteEvent: DeleteCategoryEvent,
+	})
+
+	return &Module{
+		DB:         deps.DB,
+		Service:    service,
+		Controller: NewProductController(service),
+		Catalog:    NewCatalogController(service),
+	}
+}
+
+// Routes registers the admin and the public catalog routes
+func (m *Module) Routes(router *router.RouterGroup) {
+	group := router.Group("", authorization.RequireAdmin(authorization.NewAuthorizationService(m.DB)))
+	m.Controller.Routes(group)
+	seo.Routes(group, "products")
+	seo.Routes(group, "product_categories")
+
+	m.Catalog.Routes(router)
+}
+
+func (m *Module) Init() error {
+	return m.SeedPermissions()
+}
+
+func (m *Module) SeedPermissions() error {
+	// Ensure permissions table exists before seeding
+	if err := m.DB.AutoMigrate(&authorization.Permission{}); err != nil {
+		return err
+	}
+
+	// Define permissions for product CRUD operations
+	permissions := []authorization.Permission{
+		{
+			Name:         "product list",
+			Description:  "View product list",
+			ResourceType: "product",
+			Action:       "list",
+		},
+		{
+			Name:         "product read",
+			Description:  "View product details",
+			ResourceType: "product",
+			Action:       "read",
+		},
+		{
+			Name:         "product create",
+			Description:  "Create new products",
+			ResourceType: "product",
+			Action:       "create",
+		},
+		{
+			Name:         "product update",
+			Description:  "Update products, their variants, stock and images",
+			ResourceType: "product",
+			Action:       "update",
+		},
+		{
+			Name:         "product delete",
+			Description:  "Delete products",
+			ResourceType: "product",
+			Action:       "delete",
+		},
+	}
+
+	// Upsert permissions - create or update if they exist
+	for _, permission := range permissions {
+		var existingPermission authorization.Permission
+		result := m.DB.Where("resource_type = ? AND action = ?", permission.ResourceType, permission.Action).First(&existingPermission)
+
+		if result.Error != nil && errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// Create new permission
+			if err := m.DB.Create(&permission).Error; err != nil {
+				return err
+			}
+		} else if result.Error == nil {
+			// Update existing permission
+			existingPermission.Name = permission.Name
+			existingPermission.Description = permission.Description
+			if err := m.DB.Save(&existingPermission).Error; err != nil {
+				return err
+			}
+		} else {
+			// Return any other error
+			return result.Error
+		}
+	}
+
+	return nil
+}
+
+func (m *Module) Migrate() error {
+	return m.DB.AutoMigrate(&Category{}, &Product{}, &ProductVariant{})
+}
+
+func (m *Module) GetModels() []any {
+	return []any{
+		&Category{},
+		&Product{},
+		&ProductVariant{},
+	}
+}
@@ -0,0 +1,954 @@
+package products
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"mime/multipart"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"base/core/app/customfields"
+	"base/core/crud"
+	"base/core/emitter"
+	"base/core/helper"
+	"base/core/logger"
+	"base/core/storage"
+	"base/core/translation"
+	"base/core/types"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+const (
+	CreateProductEvent = "products.create"
+	UpdateProductEvent = "products.update"
+	DeleteProductEvent = "products.delete"
+	StockProductEvent  = "products.stock"
+
+	CreateCategoryEvent = "product_categories.create"
+	UpdateCategoryEvent = "product_categories.update"
+	DeleteCategoryEvent = "product_categories.delete"
+)
+
+// registerEvents adds the events of the module to the event catalog
+func registerEvents() {
+	emitter.RegisterEvents(
+		emitter.EventType{Name: CreateProductEvent, Description: "A product was created", Payload: &Product{}},
+		emitter.EventType{Name: UpdateProductEvent, Description: "A product was updated", Payload: &Product{}},
+		emitter.EventType{Name: DeleteProductEvent, Description: "A product was deleted", Payload: &Product{}},
+		emitter.EventType{Name: StockProductEvent, Description: "The stock of a product or one of its variants changed", Payload: &StockChange{}},
+		emitter.EventType{Name: CreateCategoryEvent, Description: "A product category was created", Payload: &Category{}},
+		emitter.EventType{Name: UpdateCategoryEvent, Description: "A product category was updated", Payload: &Category{}},
+		emitter.EventType{Name: DeleteCategoryEvent, Description: "A product category was deleted", Payload: &Category{}},
+	)
+}
+
+// DefaultCurrency is the currency of products created without one
+const DefaultCurrency = "USD"
+
+// MaxImageSize is the largest product image that can be uploaded
+const MaxImageSize = 10 << 20 // 10MB
+
+// ImageExtensions are the accepted product image types
+var ImageExtensions = []string{".jpg", ".jpeg", ".png", ".gif", ".webp"}
+
+// ErrInsufficientStock is returned when taking more stock than is left
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+type ProductService struct {
+	DB      *gorm.DB
+	Emitter *emitter.Emitter
+	Storage *storage.ActiveStorage
+	Logger  logger.Logger
+	slugs   *helper.SlugHelper
+}
+
+func NewProductService(db *gorm.DB, emitter *emitter.Emitter, activeStorage *storage.ActiveStorage, logger logger.Logger) *ProductService {
+	if activeStorage != nil {
+		activeStorage.RegisterAttachment("products", storage.AttachmentConfig{
+			Field:             "images",
+			Path:              "products",
+			AllowedExtensions: ImageExtensions,
+			MaxFileSize:       MaxImageSize,
+			Multiple:          true,
+		})
+	}
+
+	return &ProductService{
+		DB:      db,
+		Logger:  logger,
+		Emitter: emitter,
+		Storage: activeStorage,
+		slugs:   helper.NewSlugHelper(),
+	}
+}
+
+// sortFields are the fields the list can be sorted by
+var sortFields = []string{
+	"id",
+	"created_at",
+	"updated_at",
+	"name",
+	"sku",
+	"price",
+	"stock",
+}
+
+// applySorting applies sorting to the query based on the sort and order parameters
+func (s *ProductService) applySorting(query *gorm.DB, sortBy *string, sortOrder *string) *gorm.DB {
+	sortField := "id"
+	if sortBy != nil {
+		if slices.Contains(sortFields, *sortBy) {
+			sortField = *sortBy
+		}
+	}
+
+	sortDirection := "desc"
+	if sortOrder != nil && (*sortOrder == "asc" || *sortOrder == "desc") {
+		sortDirection = *sortOrder
+	}
+
+	return query.Order(sortField + " " + sortDirection)
+}
+
+// applyFilter narrows a products query
+func (s *ProductService) applyFilter(ctx context.Context, query *gorm.DB, filter ProductFilter) *gorm.DB {
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		like := "%" + q + "%"
+		query = query.Where("name LIKE ? OR sku LIKE ? OR slug LIKE ?", like, like, like)
+	}
+	if filter.CategoryId != 0 {
+		query = query.Where("id IN (?)", s.DB.WithContext(ctx).Table("product_category_links").
+			Select("product_id").Where("category_id = ?", filter.CategoryId))
+	}
+	if filter.Active != nil {
+		query = query.Where("active = ?", *filter.Active)
+	}
+	query = query.Scopes(filter.Archived.Scope("products.archived_at"))
+	if filter.MinPrice != nil {
+		query = query.Where("price >= ?", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		query = query.Where("price <= ?", *filter.MaxPrice)
+	}
+	if filter.InStock {
+		variants := s.DB.WithContext(ctx).Table("product_variants").Select("1").Where("product_variants.product_id = products.id")
+		query = query.Where("track_stock = ? OR (stock > 0 AND NOT EXISTS (?)) OR EXISTS (?)",
+			false, variants, s.DB.WithContext(ctx).Table("product_variants").Select("1").
+				Where("product_variants.product_id = products.id AND product_variants.stock > 0"))
+	}
+	return query
+}
+
+// Create creates a product with its variants
+func (s *ProductService) Create(ctx context.Context, req *CreateProductRequest) (*Product, error) {
+	if err := ValidateProductCreateRequest(req); err != nil {
+		return nil, err
+	}
+	if err := translation.ValidateFieldValues(req.Translations, (&Product{}).TranslatedFields()); err != nil {
+		return nil, err
+	}
+
+	item := &Product{
+		Sku:            strings.TrimSpace(req.Sku),
+		Name:           req.Name,
+		Description:    req.Description,
+		Price:          req.Price,
+		CompareAtPrice: req.CompareAtPrice,
+		Currency:       req.Currency,
+		Stock:          req.Stock,
+		Weight:         req.Weight,
+		TrackStock:     true,
+		Active:         req.Active,
+	}
+	if item.Currency == "" {
+		item.Currency = DefaultCurrency
+	}
+	if req.TrackStock != nil {
+		item.TrackStock = *req.TrackStock
+	}
+
+	var errs validator.ValidationErrors
+	customFields, err := customfields.Records.Validate(item.TableName(), req.CustomFields, true)
+	if fieldErrs, ok := err.(validator.ValidationErrors); ok {
+		errs = append(errs, fieldErrs...)
+	} else if err != nil {
+		return nil, err
+	}
+	if err := s.checkSku(ctx, "sku", item.Sku, 0, 0); err != nil {
+		errs = append(errs, *err)
+	}
+	seen := map[string]bool{item.Sku: true}
+	for i, variant := range req.Variants {
+		field := fmt.Sprintf("variants[%d].sku", i)
+		if seen[variant.Sku] {
+			errs = append(errs, skuTaken(field, variant.Sku))
+		} else if err := s.checkSku(ctx, field, variant.Sku, 0, 0); err != nil {
+			errs = append(errs, *err)
+		}
+		seen[variant.Sku] = true
+	}
+	slug, slugErrs := s.productSlug(ctx, req.Slug, req.Name, 0)
+	errs = append(errs, slugErrs...)
+	categories, categoryErrs := s.categories(ctx, req.CategoryIds)
+	errs = append(errs, categoryErrs...)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	item.Slug = slug
+	item.Categories = categories
+	for _, variant := range req.Variants {
+		item.Variants = append(item.Variants, newVariant(&variant))
+	}
+
+	if err := s.DB.WithContext(ctx).Create(item).Error; err != nil {
+		s.Logger.Error("failed to create product", logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	if err := translation.Fields.WithDB(s.DB.WithContext(ctx)).Set(item.TableName(), item.Id, req.Translations); err != nil {
+		s.Logger.Error("failed to save product translations", logger.String("error", err.Error()))
+		return nil, err
+	}
+	if err := customfields.Records.WithDB(s.DB.WithContext(ctx)).Set(item.TableName(), item.Id, customFields); err != nil {
+		s.Logger.Error("failed to save product custom fields", logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	result, err := s.GetById(ctx, item.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Emit create event
+	s.Emitter.EmitContext(ctx, CreateProductEvent, result)
+
+	return result, nil
+}
+
+// Duplicate saves an inactive copy of a product with its variants, categories, images,
+// translations, custom fields and SEO metadata. The SKUs and the slug get a -copy suffix.
+func (s *ProductService) Duplicate(ctx context.Context, id uint) (*Product, error) {
+	var item *Product
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		item, err = crud.Copy(tx, id, crud.CopyOptions[Product]{
+			Change: func(tx *gorm.DB, copied *Product) error {
+				copied.Active = false
+				copied.ArchivedAt = nil
+
+				// The copies of the SKUs are free among products and among variants, but
+				// SKUs are unique across both
+				var errs validator.ValidationErrors
+				if skuUsed(tx, copied.Sku, 0, 0) {
+					errs = append(errs, skuTaken("sku", copied.Sku))
+				}
+				for i, variant := range copied.Variants {
+					if skuUsed(tx, variant.Sku, 0, 0) {
+						errs = append(errs, skuTaken(fmt.Sprintf("variants[%d].sku", i), variant.Sku))
+					}
+				}
+				if len(errs) > 0 {
+					return errs
+				}
+				return nil
+			},
+		})
+		return err
+	})
+	if err != nil {
+		s.Logger.Error("failed to duplicate product",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return nil, err
+	}
+
+	result, err := s.GetById(ctx, item.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Emit create event
+	s.Emitter.EmitContext(ctx, CreateProductEvent, result)
+
+	return result, nil
+}
+
+// Update updates the fields of a product that are set in the request
+func (s *ProductService) Update(ctx context.Context, id uint, req *UpdateProductRequest) (*Product, error) {
+	item := &Product{}
+	if err := s.DB.WithContext(ctx).First(item, id).Error; err != nil {
+		s.Logger.Error("failed to find product for update",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return nil, err
+	}
+
+	if err := ValidateProductUpdateRequest(req, id); err != nil {
+		return nil, err
+	}
+	if err := translation.ValidateFieldValues(req.Translations, item.TranslatedFields()); err != nil {
+		return nil, err
+	}
+
+	var errs validator.ValidationErrors
+	customFields, err := customfields.Records.Validate(item.TableName(), req.CustomFields, false)
+	if fieldErrs, ok := err.(validator.ValidationErrors); ok {
+		errs = append(errs, fieldErrs...)
+	} else if err != nil {
+		return nil, err
+	}
+	if req.Sku != nil && strings.TrimSpace(*req.Sku) != item.Sku {
+		item.Sku = strings.TrimSpace(*req.Sku)
+		if err := s.checkSku(ctx, "sku", item.Sku, item.Id, 0); err != nil {
+			errs = append(errs, *err)
+		}
+	}
+	if req.Name != nil {
+		item.Name = *req.Name
+	}
+	if req.Slug != nil && *req.Slug != item.Slug {
+		slug, err := s.productSlug(ctx, *req.Slug, item.Name, item.Id)
+		if err != nil {
+			errs = append(errs, err...)
+		}
+		item.Slug = slug
+	}
+	if req.Description != nil {
+		item.Description = *req.Description
+	}
+	if req.Price != nil {
+		item.Price = *req.Price
+	}
+	if req.CompareAtPrice != nil {
+		item.CompareAtPrice = *req.CompareAtPrice
+	}
+	if req.Currency != nil {
+		item.Currency = *req.Currency
+	}
+	if req.Weight != nil {
+		item.Weight = *req.Weight
+	}
+	if req.TrackStock != nil {
+		item.TrackStock = *req.TrackStock
+	}
+	if req.Active != nil {
+		item.Active = *req.Active
+	}
+	var categories []*Category
+	if req.CategoryIds != nil {
+		var err validator.ValidationErrors
+		if categories, err = s.categories(ctx, *req.CategoryIds); err != nil {
+			errs = append(errs, err...)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(item).Error; err != nil {
+			return err
+		}
+		if req.CategoryIds != nil {
+			if err := tx.Model(item).Association("Categories").Replace(categories); err != nil {
+				return err
+			}
+		}
+		return customfields.Records.WithDB(tx).Set(item.TableName(), item.Id, customFields)
+	})
+	if err != nil {
+		s.Logger.Error("failed to update product",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return nil, err
+	}
+
+	if err := translation.Fields.WithDB(s.DB.WithContext(ctx)).Set(item.TableName(), item.Id, req.Translations); err != nil {
+		s.Logger.Error("failed to save product translations",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return nil, err
+	}
+
+	result, err := s.GetById(ctx, item.Id)
+	if err != nil {
+		s.Logger.Error("failed to get updated product",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return nil, err
+	}
+
+	// Emit update event
+	s.Emitter.EmitContext(ctx, UpdateProductEvent, result)
+
+	return result, nil
+}
+
+// Archive hides a product from lists and the catalog without deleting it, or brings it back
+func (s *ProductService) Archive(ctx context.Context, id uint, archived bool) (*Product, error) {
+	if err := crud.Archive(s.DB.WithContext(ctx), &Product{}, id, archived); err != nil {
+		return nil, err
+	}
+
+	result, err := s.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Emit update event
+	s.Emitter.EmitContext(ctx, UpdateProductEvent, result)
+
+	return result, nil
+}
+
+// Delete deletes a product with its variants, category links and images
+func (s *ProductService) Delete(ctx context.Context, id uint) error {
+	item := &Product{}
+	if err := s.DB.WithContext(ctx).First(item, id).Error; err != nil {
+		s.Logger.Error("failed to find product for deletion",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return err
+	}
+	if err := s.loadImages(ctx, []*Product{item}); err != nil {
+		return err
+	}
+
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", item.Id).Delete(&ProductVariant{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(item).Association("Categories").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(item).Error
+	})
+	if err != nil {
+		s.Logger.Error("failed to delete product",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return err
+	}
+
+	for _, image := range item.Images {
+		if err := s.Storage.Delete(ctx, image); err != nil {
+			s.Logger.Warn("failed to delete product image",
+				logger.String("error", err.Error()),
+				logger.Int("id", int(image.Id)))
+		}
+	}
+	if err := translation.Fields.WithDB(s.DB.WithContext(ctx)).Delete(item.TableName(), item.Id); err != nil {
+		s.Logger.Warn("failed to delete product translations",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+	}
+	if err := customfields.Records.WithDB(s.DB.WithContext(ctx)).Delete(item.TableName(), item.Id); err != nil {
+		s.Logger.Warn("failed to delete product custom fields",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+	}
+
+	// Emit delete event
+	s.Emitter.EmitContext(ctx, DeleteProductEvent, item)
+
+	return nil
+}
+
+// GetById returns a product with its categories, variants and images
+func (s *ProductService) GetById(ctx context.Context, id uint) (*Product, error) {
+	return s.get(ctx, s.DB.WithContext(ctx).Where("id = ?", id))
+}
+
+// GetBySlug returns an active product by its slug, for the public catalog
+func (s *ProductService) GetBySlug(ctx context.Context, slug string) (*Product, error) {
+	return s.get(ctx, s.DB.WithContext(ctx).Where("slug = ? AND active = ? AND archived_at IS NULL", slug, true))
+}
+
+func (s *ProductService) get(ctx context.Context, query *gorm.DB) (*Product, error) {
+	item := &Product{}
+	err := query.
+		Preload("Categories", func(db *gorm.DB) *gorm.DB { return db.Order("position, name") }).
+		Preload("Variants", func(db *gorm.DB) *gorm.DB { return db.Order("position, id") }).
+		First(item).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadImages(ctx, []*Product{item}); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// GetAll returns a page of products matching the filter
+func (s *ProductService) GetAll(ctx context.Context, page *int, limit *int, sortBy *string, sortOrder *string, filter ProductFilter) (*types.PaginatedResponse, error) {
+	var items []*Product
+	var total int64
+
+	query, err := customfields.Records.Where(s.applyFilter(ctx, s.DB.WithContext(ctx).Model(&Product{}), filter), "products", "products.id", filter.CustomFields)
+	if err != nil {
+		return nil, err
+	}
+	// Set default values if nil
+	defaultPage := 1
+	defaultLimit := 10
+	if page == nil {
+		page = &defaultPage
+	}
+	if limit == nil {
+		limit = &defaultLimit
+	}
+
+	// Get total count
+	if err := query.Count(&total).Error; err != nil {
+		s.Logger.Error("failed to count products",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	// Apply pagination and sorting
+	offset := (*page - 1) * *limit
+	query = s.applySorting(query.Offset(offset).Limit(*limit), sortBy, sortOrder)
+
+	if err := query.Preload("Variants").Find(&items).Error; err != nil {
+		s.Logger.Error("failed to get products",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+	if err := s.loadImages(ctx, items); err != nil {
+		return nil, err
+	}
+
+	// Convert to response type
+	responses := make([]*ProductListResponse, len(items))
+	for i, item := range items {
+		responses[i] = item.ToListResponse()
+	}
+
+	// Calculate total pages
+	totalPages := int(math.Ceil(float64(total) / float64(*limit)))
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	return &types.PaginatedResponse{
+		Data: responses,
+		Pagination: types.Pagination{
+			Total:      int(total),
+			Page:       *page,
+			PageSize:   *limit,
+			TotalPages: totalPages,
+		},
+	}, nil
+}
+
+// GetAllForSelect gets all items for select box/dropdown options (simplified response)
+func (s *ProductService) GetAllForSelect(ctx context.Context) ([]*Product, error) {
+	var items []*Product
+	if err := s.DB.WithContext(ctx).Select("id, name, sku").Where("archived_at IS NULL").Order("name ASC").Find(&items).Error; err != nil {
+		s.Logger.Error("Failed to fetch items for select", logger.String("error", err.Error()))
+		return nil, err
+	}
+	return items, nil
+}
+
+// AddVariant adds a variant to a product
+func (s *ProductService) AddVariant(ctx context.Context, productId uint, req *VariantRequest) (*Product, error) {
+	item := &Product{}
+	if err := s.DB.WithContext(ctx).First(item, productId).Error; err != nil {
+		return nil, err
+	}
+	if err := ValidateVariantRequest(req); err != nil {
+		return nil, err
+	}
+	if err := s.checkSku(ctx, "sku", req.Sku, 0, 0); err != nil {
+		return nil, validator.ValidationErrors{*err}
+	}
+
+	variant := newVariant(req)
+	variant.ProductId = item.Id
+	if err := s.DB.WithContext(ctx).Create(variant).Error; err != nil {
+		s.Logger.Error("failed to create product variant",
+			logger.String("error", err.Error()),
+			logger.Int("product_id", int(productId)))
+		return nil, err
+	}
+	return s.changed(ctx, item.Id)
+}
+
+// UpdateVariant replaces the fields of a variant
+func (s *ProductService) UpdateVariant(ctx context.Context, productId, variantId uint, req *VariantRequest) (*Product, error) {
+	variant, err := s.variant(ctx, productId, variantId)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateVariantRequest(req); err != nil {
+		return nil, err
+	}
+	if err := s.checkSku(ctx, "sku", req.Sku, 0, variant.Id); err != nil {
+		return nil, validator.ValidationErrors{*err}
+	}
+
+	updated := newVariant(req)
+	updated.Id = variant.Id
+	updated.CreatedAt = variant.CreatedAt
+	updated.ProductId = variant.ProductId
+	if err := s.DB.WithContext(ctx).Save(updated).Error; err != nil {
+		s.Logger.Error("failed to update product variant",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(variantId)))
+		return nil, err
+	}
+	return s.changed(ctx, productId)
+}
+
+// DeleteVariant removes a variant from a product
+func (s *ProductService) DeleteVariant(ctx context.Context, productId, variantId uint) (*Product, error) {
+	variant, err := s.variant(ctx, productId, variantId)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.DB.WithContext(ctx).Delete(variant).Error; err != nil {
+		s.Logger.Error("failed to delete product variant",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(variantId)))
+		return nil, err
+	}
+	return s.changed(ctx, productId)
+}
+
+// UpdateStock applies a stock request to a product or one of its variants
+func (s *ProductService) UpdateStock(ctx context.Context, productId uint, req *StockRequest) (*Product, error) {
+	if err := ValidateStockRequest(req); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if req.Stock != nil {
+		err = s.SetStock(ctx, productId, req.VariantId, *req.Stock)
+	} else {
+		err = s.AdjustStock(ctx, productId, req.VariantId, *req.Quantity)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.GetById(ctx, productId)
+}
+
+// AdjustStock adds quantity (negative to take stock) to the stock of a product, or of its
+// variant when variantId is set. Taking more than is left fails with ErrInsufficientStock
+// for products that track stock; the check and the change are a single statement, so
+// concurrent orders can't oversell.
+func (s *ProductService) AdjustStock(ctx context.Context, productId uint, variantId *uint, quantity int) error {
+	item := &Product{}
+	if err := s.DB.WithContext(ctx).First(item, productId).Error; err != nil {
+		return err
+	}
+	if quantity == 0 {
+		return nil
+	}
+
+	query := s.DB.WithContext(ctx).Model(&Product{}).Where("id = ?", item.Id)
+	if variantId != nil {
+		if _, err := s.variant(ctx, productId, *variantId); err != nil {
+			return err
+		}
+		query = s.DB.WithContext(ctx).Model(&ProductVariant{}).Where("id = ?", *variantId)
+	}
+	if item.TrackStock && quantity < 0 {
+		query = query.Where("stock >= ?", -quantity)
+	}
+
+	result := query.Update("stock", gorm.Expr("stock + ?", quantity))
+	if result.Error != nil {
+		s.Logger.Error("failed to adjust product stock",
+			logger.String("error", result.Error.Error()),
+			logger.Int("product_id", int(productId)))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInsufficientStock
+	}
+
+	s.stockChanged(ctx, item.Id, variantId)
+	return nil
+}
+
+// SetStock sets the stock of a product, or of its variant when variantId is set
+func (s *ProductService) SetStock(ctx context.Context, productId uint, variantId *uint, stock int) error {
+	query := s.DB.WithContext(ctx).Model(&Product{}).Where("id = ?", productId)
+	if variantId != nil {
+		if _, err := s.variant(ctx, productId, *variantId); err != nil {
+			return err
+		}
+		query = s.DB.WithContext(ctx).Model(&ProductVariant{}).Where("id = ?", *variantId)
+	}
+
+	result := query.Update("stock", stock)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 && variantId == nil {
+		return gorm.ErrRecordNotFound
+	}
+
+	s.stockChanged(ctx, productId, variantId)
+	return nil
+}
+
+// stockChanged emits the stock event with the current stock
+func (s *ProductService) stockChanged(ctx context.Context, productId uint, variantId *uint) {
+	s.Logger.Info("product stock changed",
+		logger.Int("product_id", int(productId)))
+	if item, err := s.GetById(ctx, productId); err == nil {
+		s.Emitter.EmitContext(ctx, StockProductEvent, &StockChange{Product: item, VariantId: variantId})
+	}
+}
+
+// AddImage uploads an image of a product; images are listed in upload order
+func (s *ProductService) AddImage(ctx context.Context, productId uint, file *multipart.FileHeader) (*Product, error) {
+	item := &Product{}
+	if err := s.DB.WithContext(ctx).First(item, productId).Error; err != nil {
+		return nil, err
+	}
+	if err := ValidateImage(file); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.Storage.Attach(ctx, item, "images", file); err != nil {
+		s.Logger.Error("failed to upload product image",
+			logger.String("error", err.Error()),
+			logger.Int("product_id", int(productId)))
+		return nil, fmt.Errorf("failed to upload image: %w", err)
+	}
+	return s.changed(ctx, item.Id)
+}
+
+// DeleteImage removes an image of a product
+func (s *ProductService) DeleteImage(ctx context.Context, productId, imageId uint) (*Product, error) {
+	var image storage.Attachment
+	err := s.DB.WithContext(ctx).Where("id = ? AND model_type = ? AND model_id = ? AND field = ?",
+		imageId, (&Product{}).GetModelName(), productId, "images").First(&image).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Storage.Delete(ctx, &image); err != nil {
+		s.Logger.Error("failed to delete product image",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(imageId)))
+		return nil, err
+	}
+	return s.changed(ctx, productId)
+}
+
+// changed reloads a product after a change of its variants or images and emits the
+// update event
+func (s *ProductService) changed(ctx context.Context, productId uint) (*Product, error) {
+	result, err := s.GetById(ctx, productId)
+	if err != nil {
+		return nil, err
+	}
+	s.Emitter.EmitContext(ctx, UpdateProductEvent, result)
+	return result, nil
+}
+
+// CategoriesOf returns the categories of the products, by product id
+func (s *ProductService) CategoriesOf(ctx context.Context, ids []uint) (map[uint][]*Category, error) {
+	var links []struct {
+		ProductId uint
+		Category
+	}
+	err := s.DB.WithContext(ctx).Table("product_categories").
+		Select("product_categories.*, product_category_links.product_id").
+		Joins("JOIN product_category_links ON product_category_links.category_id = product_categories.id").
+		Where("product_category_links.product_id IN ? AND product_categories.deleted_at IS NULL", ids).
+		Order("product_categories.position, product_categories.name").
+		Scan(&links).Error
+	if err != nil {
+		s.Logger.Error("failed to load product categories", logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	result := make(map[uint][]*Category, len(ids))
+	for i := range links {
+		result[links[i].ProductId] = append(result[links[i].ProductId], &links[i].Category)
+	}
+	return result, nil
+}
+
+// ImagesOf returns the images of the products, by product id
+func (s *ProductService) ImagesOf(ctx context.Context, ids []uint) (map[uint][]*storage.Attachment, error) {
+	items := make([]*Product, len(ids))
+	for i, id := range ids {
+		items[i] = &Product{Id: id}
+	}
+	if err := s.loadImages(ctx, items); err != nil {
+		return nil, err
+	}
+	result := make(map[uint][]*storage.Attachment, len(items))
+	for _, item := range items {
+		result[item.Id] = item.Images
+	}
+	return result, nil
+}
+
+// loadImages sets the images of the products, oldest first
+func (s *ProductService) loadImages(ctx context.Context, items []*Product) error {
+	if len(items) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(items))
+	byId := make(map[uint]*Product, len(items))
+	for i, item := range items {
+		ids[i] = item.Id
+		byId[item.Id] = item
+	}
+
+	var images []*storage.Attachment
+	err := s.DB.WithContext(ctx).Where("model_type = ? AND field = ? AND model_id IN ?", (&Product{}).GetModelName(), "images", ids).
+		Order("id").Find(&images).Error
+	if err != nil {
+		s.Logger.Error("failed to load product images", logger.String("error", err.Error()))
+		return err
+	}
+	for _, image := range images {
+		if s.Storage != nil {
+			image.URL = s.Storage.GetProvider().GetURL(image.Path)
+		}
+		if item := byId[image.ModelId]; item != nil {
+			item.Images = append(item.Images, image)
+		}
+	}
+	return nil
+}
+
+// variant returns a variant of a product
+func (s *ProductService) variant(ctx context.Context, productId, variantId uint) (*ProductVariant, error) {
+	variant := &ProductVariant{}
+	if err := s.DB.WithContext(ctx).Where("id = ? AND product_id = ?", variantId, productId).First(variant).Error; err != nil {
+		return nil, err
+	}
+	return variant, nil
+}
+
+// checkSku checks that a SKU isn't used by another product or variant, including deleted
+// products whose SKU is still reserved
+func (s *ProductService) checkSku(ctx context.Context, field, sku string, productId, variantId uint) *validator.ValidationError {
+	if skuUsed(s.DB.WithContext(ctx), sku, productId, variantId) {
+		err := skuTaken(field, sku)
+		return &err
+	}
+	return nil
+}
+
+// skuUsed reports whether another product or variant than the given ones has a SKU
+func skuUsed(db *gorm.DB, sku string, productId, variantId uint) bool {
+	var count int64
+	db.Unscoped().Model(&Product{}).Where("sku = ? AND id <> ?", sku, productId).Count(&count)
+	if count == 0 {
+		db.Model(&ProductVariant{}).Where("sku = ? AND id <> ?", sku, variantId).Count(&count)
+	}
+	return count > 0
+}
+
+func skuTaken(field, sku string) validator.ValidationError {
+	return validator.ValidationError{
+		Field:   field,
+		Tag:     "unique",
+		Value:   sku,
+		Message: field + " is already taken",
+	}
+}
+
+// productSlug validates a requested slug or generates one from the name
+func (s *ProductService) productSlug(ctx context.Context, requested, name string, productId uint) (string, validator.ValidationErrors) {
+	return s.slug(ctx, &Product{}, requested, name, productId)
+}
+
+// slug validates a requested slug, or generates a unique one from the name, for the
+// table of model. Deleted records keep their slug.
+func (s *ProductService) slug(ctx context.Context, model any, requested, name string, id uint) (string, validator.ValidationErrors) {
+	exists := func(slug string) (bool, error) {
+		var count int64
+		err := s.DB.WithContext(ctx).Unscoped().Model(model).Where("slug = ? AND id <> ?", slug, id).Count(&count).Error
+		return count > 0, err
+	}
+
+	if requested == "" {
+		base := s.slugs.Normalize(name, "", "en")
+		if base == "" {
+			base = "item"
+		}
+		slug, err := s.slugs.GenerateUniqueSlug(base, exists)
+		if err != nil {
+			return "", validator.ValidationErrors{{Field: "slug", Tag: "slug", Value: base, Message: err.Error()}}
+		}
+		return slug, nil
+	}
+
+	if !slugPattern.MatchString(requested) {
+		return requested, validator.ValidationErrors{{
+			Field:   "slug",
+			Tag:     "slug",
+			Value:   requested,
+			Message: "slug must contain only lowercase letters, digits, '.', '_' or '-'",
+		}}
+	}
+	if taken, err := exists(requested); err != nil || taken {
+		return requested, validator.ValidationErrors{{
+			Field:   "slug",
+			Tag:     "unique",
+			Value:   requested,
+			Message: "slug is already taken",
+		}}
+	}
+	return requested, nil
+}
+
+// categories loads the categories with the given ids
+func (s *ProductService) categories(ctx context.Context, ids []uint) ([]*Category, validator.ValidationErrors) {
+	var categories []*Category
+	if len(ids) == 0 {
+		return categories, nil
+	}
+	if err := s.DB.WithContext(ctx).Where("id IN ?", ids).Find(&categories).Error; err != nil {
+		return nil, validator.ValidationErrors{{Field: "category_ids", Tag: "exists", Message: err.Error()}}
+	}
+
+	var errs validator.ValidationErrors
+	for i, id := range ids {
+		if !slices.ContainsFunc(categories, func(c *Category) bool { return c.Id == id }) {
+			errs = append(errs, validator.ValidationError{
+				Field:   fmt.Sprintf("category_ids[%d]", i),
+				Tag:     "exists",
+				Value:   fmt.Sprint(id),
+				Message: fmt.Sprintf("category %d does not exist", id),
+			})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return categories, nil
+}
+
+func newVariant(req *VariantRequest) *ProductVariant {
+	return &ProductVariant{
+		Sku:      strings.TrimSpace(req.Sku),
+		Name:     req.Name,
+		Options:  req.Options,
+		Price:    req.Price,
+		Weight:   req.Weight,
+		Stock:    req.Stock,
+		Position: req.Position,
+	}
+}
+
+// imageExtension returns the lower case extension of an uploaded file
+func imageExtension(file *multipart.FileHeader) string {
+	return strings.ToLower(filepath.Ext(file.Filename))
+}
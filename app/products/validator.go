@@ -0,0 +1,150 @@
+package products
+
+import (
+	"fmt"
+	"mime/multipart"
+	"regexp"
+	"slices"
+	"strings"
+
+	"base/core/validator"
+)
+
+// Global validator instance using Base core validator wrapper
+var validate = validator.New()
+
+// slugPattern matches URL slugs such as "blue-t-shirt"
+var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,254}$`)
+
+// ValidateProductCreateRequest validates the create request
+func ValidateProductCreateRequest(req *CreateProductRequest) error {
+	if req == nil {
+		return validator.ValidationErrors{
+			{
+				Field:   "request",
+				Tag:     "required",
+				Value:   "nil",
+				Message: "request cannot be nil",
+			},
+		}
+	}
+
+	req.Currency = strings.ToUpper(req.Currency) // Currency codes are accepted in any case
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateProductUpdateRequest validates the update request
+func ValidateProductUpdateRequest(req *UpdateProductRequest, id uint) error {
+	if req == nil {
+		return validator.ValidationErrors{
+			{
+				Field:   "request",
+				Tag:     "required",
+				Value:   "nil",
+				Message: "request cannot be nil",
+			},
+		}
+	}
+
+	if id == 0 {
+		return validator.ValidationErrors{
+			{
+				Field:   "id",
+				Tag:     "required",
+				Value:   "0",
+				Message: "id cannot be zero",
+			},
+		}
+	}
+
+	if req.Currency != nil {
+		currency := strings.ToUpper(*req.Currency)
+		req.Currency = &currency
+	}
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateVariantRequest validates a variant
+func ValidateVariantRequest(req *VariantRequest) error {
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateStockRequest checks that a stock request sets either a quantity or the stock
+func ValidateStockRequest(req *StockRequest) error {
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	if req.Quantity == nil && req.Stock == nil {
+		return validator.ValidationErrors{
+			{
+				Field:   "quantity",
+				Tag:     "required",
+				Message: "quantity or stock is required",
+			},
+		}
+	}
+	if req.Quantity != nil && req.Stock != nil {
+		return validator.ValidationErrors{
+			{
+				Field:   "quantity",
+				Tag:     "excluded_with",
+				Param:   "stock",
+				Message: "quantity can't be set together with stock",
+			},
+		}
+	}
+	return nil
+}
+
+// ValidateCategoryCreateRequest validates the category create request
+func ValidateCategoryCreateRequest(req *CreateCategoryRequest) error {
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateCategoryUpdateRequest validates the category update request
+func ValidateCategoryUpdateRequest(req *UpdateCategoryRequest) error {
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateImage checks the type and size of an uploaded product image
+func ValidateImage(file *multipart.FileHeader) error {
+	if ext := imageExtension(file); !slices.Contains(ImageExtensions, ext) {
+		allowed := strings.Join(ImageExtensions, " ")
+		return validator.ValidationErrors{
+			{
+				Field:   "image",
+				Tag:     "oneof",
+				Value:   ext,
+				Param:   allowed,
+				Message: "image must be one of: " + allowed,
+			},
+		}
+	}
+	if file.Size > MaxImageSize {
+		return validator.ValidationErrors{
+			{
+				Field:   "image",
+				Tag:     "max_size",
+				Value:   fmt.Sprint(file.Size),
+				Param:   "10MB",
+				Message: "image must be at most 10MB",
+			},
+		}
+	}
+	return nil
+}
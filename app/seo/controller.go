@@ -0,0 +1,205 @@
+package seo
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"base/core/router"
+	"base/core/storage"
+	"base/core/translation"
+	"base/core/types"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+type SeoController struct {
+	Service *SeoService
+}
+
+func NewSeoController(service *SeoService) *SeoController {
+	return &SeoController{
+		Service: service,
+	}
+}
+
+// recordHandler handles a request for the metadata of a record of an entity
+type recordHandler func(ctx *router.Context, entity string, id uint) error
+
+// controller serves the SEO endpoints the modules of the entities add with Routes; it is
+// set up by the seo module
+var controller *SeoController
+
+// Routes registers the SEO endpoints of a registered entity below its admin route, e.g.
+// /pages/:id/seo. Modules call it at the end of their admin routes, since the router
+// doesn't take static segments such as /products/all after an :id next to them. Without
+// the seo module there are no endpoints.
+func Routes(router *router.RouterGroup, name string) {
+	entity, ok := getEntity(name)
+	if !ok || controller == nil {
+		return
+	}
+	controller.Routes(router, entity)
+}
+
+// Routes registers the SEO endpoints of an entity
+func (c *SeoController) Routes(router *router.RouterGroup, entity Entity) {
+	router.GET(entity.Path+"/:id/seo", c.record(entity.Name, c.Get))                     // Get metadata
+	router.PUT(entity.Path+"/:id/seo", c.record(entity.Name, c.Update))                  // Set metadata
+	router.DELETE(entity.Path+"/:id/seo", c.record(entity.Name, c.Delete))               // Delete metadata
+	router.POST(entity.Path+"/:id/seo/og-image", c.record(entity.Name, c.SetImage))      // Upload OG image
+	router.DELETE(entity.Path+"/:id/seo/og-image", c.record(entity.Name, c.DeleteImage)) // Remove OG image
+}
+
+// GetSeo godoc
+// @Summary Get the SEO metadata of a record
+// @Description Get the meta tags, robots flags and OG image of a page, product or product category with all their translations; records without metadata get empty metadata (Admin only)
+// @Tags App/SEO
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param entity path string true "Records, e.g. pages, products or product-categories"
+// @Param id path int true "Record id"
+// @Success 200 {object} Metadata
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /{entity}/{id}/seo [get]
+func (c *SeoController) Get(ctx *router.Context, entity string, id uint) error {
+	item, err := c.Service.Get(ctx, entity, id)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to get SEO metadata")
+	}
+	return ctx.JSON(http.StatusOK, item)
+}
+
+// UpdateSeo godoc
+// @Summary Set the SEO metadata of a record
+// @Description Create or update the meta tags and robots flags of a page, product or product category; omitted fields are left unchanged (Admin only)
+// @Tags App/SEO
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param entity path string true "Records, e.g. pages, products or product-categories"
+// @Param id path int true "Record id"
+// @Param seo body UpdateRequest true "SEO metadata"
+// @Success 200 {object} Metadata
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /{entity}/{id}/seo [put]
+func (c *SeoController) Update(ctx *router.Context, entity string, id uint) error {
+	var req UpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	item, err := c.Service.Set(ctx, entity, id, &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to update SEO metadata")
+	}
+	return ctx.JSON(http.StatusOK, item)
+}
+
+// DeleteSeo godoc
+// @Summary Delete the SEO metadata of a record
+// @Description Delete the metadata of a page, product or product category with its OG image; the record falls back to its defaults (Admin only)
+// @Tags App/SEO
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param entity path string true "Records, e.g. pages, products or product-categories"
+// @Param id path int true "Record id"
+// @Success 204 "No Content"
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /{entity}/{id}/seo [delete]
+func (c *SeoController) Delete(ctx *router.Context, entity string, id uint) error {
+	if err := c.Service.Delete(ctx.Request.Context(), entity, id); err != nil {
+		return c.fail(ctx, err, "Failed to delete SEO metadata")
+	}
+
+	ctx.Status(http.StatusNoContent)
+	return nil
+}
+
+// SetSeoImage godoc
+// @Summary Upload the OG image of a record
+// @Description Upload the image shown when a page, product or product category is shared, replacing the previous one (Admin only)
+// @Tags App/SEO
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param entity path string true "Records, e.g. pages, products or product-categories"
+// @Param id path int true "Record id"
+// @Param image formData file true "Image file"
+// @Success 200 {object} Metadata
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /{entity}/{id}/seo/og-image [post]
+func (c *SeoController) SetImage(ctx *router.Context, entity string, id uint) error {
+	file, err := ctx.FormFile("image")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Failed to get image file: " + err.Error()})
+	}
+
+	item, err := c.Service.SetImage(ctx, entity, id, file)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to upload image")
+	}
+	return ctx.JSON(http.StatusOK, item)
+}
+
+// DeleteSeoImage godoc
+// @Summary Remove the OG image of a record
+// @Description Remove the OG image of a page, product or product category and its file (Admin only)
+// @Tags App/SEO
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param entity path string true "Records, e.g. pages, products or product-categories"
+// @Param id path int true "Record id"
+// @Success 200 {object} Metadata
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /{entity}/{id}/seo/og-image [delete]
+func (c *SeoController) DeleteImage(ctx *router.Context, entity string, id uint) error {
+	item, err := c.Service.DeleteImage(ctx, entity, id)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to remove image")
+	}
+	return ctx.JSON(http.StatusOK, item)
+}
+
+// record returns a handler for the metadata of the records of an entity, which parses
+// the record id
+func (c *SeoController) record(entity string, handler recordHandler) router.HandlerFunc {
+	return func(ctx *router.Context) error {
+		id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+		if err != nil {
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+		}
+		return handler(ctx, entity, uint(id))
+	}
+}
+
+// fail writes the error response for a failed request
+func (c *SeoController) fail(ctx *router.Context, err error, message string) error {
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   translation.Error(ctx, err),
+			Details: translation.LocalizeValidation(ctx, validationErrors),
+		})
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Item not found"})
+	}
+	if errors.Is(err, storage.ErrInvalidFile) {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+	return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: message + ": " + err.Error()})
+}
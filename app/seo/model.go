@@ -0,0 +1,72 @@
+package seo
+
+import (
+	"time"
+
+	"base/core/storage"
+	"base/core/translation"
+)
+
+// Metadata is what search engines and social networks are told about a record of another
+// module (see RegisterEntity), e.g. a page or a product
+type Metadata struct {
+	Id              uint                `json:"id" gorm:"primarykey"`
+	CreatedAt       time.Time           `json:"created_at"`
+	UpdatedAt       time.Time           `json:"updated_at"`
+	ModelType       string              `json:"model_type" gorm:"size:64;uniqueIndex:idx_seo_metadata_model"`
+	ModelId         uint                `json:"model_id" gorm:"uniqueIndex:idx_seo_metadata_model"`
+	MetaTitle       string              `json:"meta_title" gorm:"size:255"`
+	MetaDescription string              `json:"meta_description" gorm:"size:512"`
+	CanonicalUrl    string              `json:"canonical_url" gorm:"size:512"`
+	NoIndex         bool                `json:"no_index"`  // robots: noindex
+	NoFollow        bool                `json:"no_follow"` // robots: nofollow
+	OgTitle         string              `json:"og_title" gorm:"size:255"`
+	OgDescription   string              `json:"og_description" gorm:"size:512"`
+	OgImage         *storage.Attachment `json:"og_image" gorm:"-"`
+
+	// All translations, by field and locale
+	Translations translation.FieldValues `json:"translations,omitempty" gorm:"-"`
+}
+
+// TableName returns the table name for the Metadata model
+func (m *Metadata) TableName() string {
+	return "seo_metadata"
+}
+
+// GetId returns the Id of the model
+func (m *Metadata) GetId() uint {
+	return m.Id
+}
+
+// GetModelName returns the model name (for storage attachments)
+func (m *Metadata) GetModelName() string {
+	return "seo_metadata"
+}
+
+// TranslatedFields returns the fields that can have per-locale values
+func (m *Metadata) TranslatedFields() []string {
+	return []string{"meta_title", "meta_description", "og_title", "og_description"}
+}
+
+// UpdateRequest represents the request payload for setting the SEO metadata of a record.
+// Omitted fields are left unchanged.
+type UpdateRequest struct {
+	MetaTitle       *string `json:"meta_title,omitempty" validate:"omitempty,max=255"`
+	MetaDescription *string `json:"meta_description,omitempty" validate:"omitempty,max=512"`
+	CanonicalUrl    *string `json:"canonical_url,omitempty" validate:"omitempty,url,max=512"`
+	NoIndex         *bool   `json:"no_index,omitempty"`
+	NoFollow        *bool   `json:"no_follow,omitempty"`
+	OgTitle         *string `json:"og_title,omitempty" validate:"omitempty,max=255"`
+	OgDescription   *string `json:"og_description,omitempty" validate:"omitempty,max=512"`
+
+	// Translations of the translated fields by locale; locales left out are unchanged
+	Translations translation.FieldValues `json:"translations,omitempty"`
+}
+
+// Entity is a kind of record SEO metadata can be attached to
+type Entity struct {
+	Name        string // Model type of the records, e.g. "pages"
+	Path        string // Admin route of the records, e.g. "/pages"; metadata is at Path/:id/seo
+	Model       any    // Model of the records, to check that they exist
+	DeleteEvent string // Event emitted with a deleted record (with a GetId method), to delete its metadata
+}
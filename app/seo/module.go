@@ -0,0 +1,102 @@
+package seo
+
+import (
+	"errors"
+
+	"base/core/app/authorization"
+	"base/core/crud"
+	"base/core/module"
+
+	"gorm.io/gorm"
+)
+
+// Module provides the SEO metadata of pages, products, product categories and the records
+// of any other module that registers with RegisterEntity. The modules add the management
+// endpoints to their admin routes with Routes and include the metadata in their public
+// responses through Records.
+type Module struct {
+	module.DefaultModule
+	DB         *gorm.DB
+	Service    *SeoService
+	Controller *SeoController
+}
+
+// Init creates and initializes the SEO module with all dependencies
+func Init(deps module.Dependencies) module.Module {
+	registerEvents()
+	service := NewSeoService(deps.DB, deps.Emitter, deps.Storage, deps.Logger)
+	Records.Setup(deps.DB, deps.Storage)
+	controller = NewSeoController(service)
+	crud.RegisterCopier("seo", service.Copy)
+
+	return &Module{
+		DB:         deps.DB,
+		Service:    service,
+		Controller: controller,
+	}
+}
+
+func (m *Module) Init() error {
+	// Entities register in the constructors of their modules, which have all run by now
+	m.Service.Listen()
+
+	return m.SeedPermissions()
+}
+
+func (m *Module) SeedPermissions() error {
+	// Ensure permissions table exists before seeding
+	if err := m.DB.AutoMigrate(&authorization.Permission{}); err != nil {
+		return err
+	}
+
+	// Define permissions for SEO metadata
+	permissions := []authorization.Permission{
+		{
+			Name:         "seo read",
+			Description:  "View the SEO metadata of records",
+			ResourceType: "seo",
+			Action:       "read",
+		},
+		{
+			Name:         "seo update",
+			Description:  "Set and delete the SEO metadata and OG images of records",
+			ResourceType: "seo",
+			Action:       "update",
+		},
+	}
+
+	// Upsert permissions - create or update if they exist
+	for _, permission := range permissions {
+		var existingPermission authorization.Permission
+		result := m.DB.Where("resource_type = ? AND action = ?", permission.ResourceType, permission.Action).First(&existingPermission)
+
+		if result.Error != nil && errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// Create new permission
+			if err := m.DB.Create(&permission).Error; err != nil {
+				return err
+			}
+		} else if result.Error == nil {
+			// Update existing permission
+			existingPermission.Name = permission.Name
+			existingPermission.Description = permission.Description
+			if err := m.DB.Save(&existingPermission).Error; err != nil {
+				return err
+			}
+		} else {
+			// Return any other error
+			return result.Error
+		}
+	}
+
+	return nil
+}
+
+func (m *Module) Migrate() error {
+	return m.DB.AutoMigrate(&Metadata{})
+}
+
+func (m *Module) GetModels() []any {
+	return []any{
+		&Metadata{},
+	}
+}
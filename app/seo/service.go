@@ -0,0 +1,324 @@
+package seo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime/multipart"
+
+	"base/core/crud"
+	"base/core/emitter"
+	"base/core/logger"
+	"base/core/storage"
+	"base/core/translation"
+
+	"gorm.io/gorm"
+)
+
+const (
+	UpdateMetadataEvent = "seo.update"
+	DeleteMetadataEvent = "seo.delete"
+)
+
+// registerEvents adds the events of the module to the event catalog
+func registerEvents() {
+	emitter.RegisterEvents(
+		emitter.EventType{Name: UpdateMetadataEvent, Description: "The SEO metadata of a record was saved", Payload: &Metadata{}},
+		emitter.EventType{Name: DeleteMetadataEvent, Description: "The SEO metadata of a record was deleted", Payload: &Metadata{}},
+	)
+}
+
+// MaxImageSize is the largest OG image that can be uploaded
+const MaxImageSize = 10 << 20 // 10MB
+
+// ImageExtensions are the accepted OG image types
+var ImageExtensions = []string{".jpg", ".jpeg", ".png", ".gif", ".webp"}
+
+// ErrUnknownEntity is returned for a model type that wasn't registered with RegisterEntity
+var ErrUnknownEntity = errors.New("unknown SEO entity")
+
+// SeoService manages the SEO metadata of the records of other modules
+type SeoService struct {
+	DB      *gorm.DB
+	Emitter *emitter.Emitter
+	Storage *storage.ActiveStorage
+	Logger  logger.Logger
+}
+
+func NewSeoService(db *gorm.DB, emitter *emitter.Emitter, activeStorage *storage.ActiveStorage, logger logger.Logger) *SeoService {
+	if activeStorage != nil {
+		activeStorage.RegisterAttachment((&Metadata{}).GetModelName(), storage.AttachmentConfig{
+			Field:             "og_image",
+			Path:              "seo",
+			AllowedExtensions: ImageExtensions,
+			MaxFileSize:       MaxImageSize,
+		})
+	}
+
+	return &SeoService{
+		DB:      db,
+		Emitter: emitter,
+		Storage: activeStorage,
+		Logger:  logger,
+	}
+}
+
+// Get returns the metadata of a record with all its translations. A record without
+// metadata gets empty metadata, which isn't stored until it is set.
+func (s *SeoService) Get(ctx context.Context, entity string, id uint) (*Metadata, error) {
+	if err := s.checkRecord(ctx, entity, id); err != nil {
+		return nil, err
+	}
+	items, err := find(ctx, s.DB.WithContext(ctx), s.Storage, entity, []uint{id})
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return &Metadata{ModelType: entity, ModelId: id, Translations: translation.FieldValues{}}, nil
+	}
+	return items[0], nil
+}
+
+// Set creates or updates the metadata of a record with the fields that are set in the request
+func (s *SeoService) Set(ctx context.Context, entity string, id uint, req *UpdateRequest) (*Metadata, error) {
+	if err := s.checkRecord(ctx, entity, id); err != nil {
+		return nil, err
+	}
+	if err := ValidateUpdateRequest(req); err != nil {
+		return nil, err
+	}
+	if err := translation.ValidateFieldValues(req.Translations, (&Metadata{}).TranslatedFields()); err != nil {
+		return nil, err
+	}
+
+	item, err := s.record(ctx, entity, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.MetaTitle != nil {
+		item.MetaTitle = *req.MetaTitle
+	}
+	if req.MetaDescription != nil {
+		item.MetaDescription = *req.MetaDescription
+	}
+	if req.CanonicalUrl != nil {
+		item.CanonicalUrl = *req.CanonicalUrl
+	}
+	if req.NoIndex != nil {
+		item.NoIndex = *req.NoIndex
+	}
+	if req.NoFollow != nil {
+		item.NoFollow = *req.NoFollow
+	}
+	if req.OgTitle != nil {
+		item.OgTitle = *req.OgTitle
+	}
+	if req.OgDescription != nil {
+		item.OgDescription = *req.OgDescription
+	}
+
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(item).Error; err != nil {
+			return err
+		}
+		return translation.Fields.WithDB(tx).Set(item.TableName(), item.Id, req.Translations)
+	})
+	if err != nil {
+		s.Logger.Error("failed to save SEO metadata",
+			logger.String("error", err.Error()),
+			logger.String("model_type", entity),
+			logger.Int("model_id", int(id)))
+		return nil, err
+	}
+
+	return s.changed(ctx, entity, id)
+}
+
+// Delete removes the metadata of a record with its OG image and translations
+func (s *SeoService) Delete(ctx context.Context, entity string, id uint) error {
+	if _, ok := getEntity(entity); !ok {
+		return ErrUnknownEntity
+	}
+	item := &Metadata{}
+	if err := s.DB.WithContext(ctx).Where("model_type = ? AND model_id = ?", entity, id).First(item).Error; err != nil {
+		return err
+	}
+	if err := s.removeImage(ctx, item); err != nil {
+		return err
+	}
+
+	if err := s.DB.WithContext(ctx).Delete(item).Error; err != nil {
+		s.Logger.Error("failed to delete SEO metadata",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(item.Id)))
+		return err
+	}
+	if err := translation.Fields.WithDB(s.DB.WithContext(ctx)).Delete(item.TableName(), item.Id); err != nil {
+		s.Logger.Warn("failed to delete SEO metadata translations",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(item.Id)))
+	}
+
+	// Emit delete event
+	s.Emitter.EmitContext(ctx, DeleteMetadataEvent, item)
+
+	return nil
+}
+
+// Copy gives a record of an entity the metadata of another one, e.g. the record it is a
+// copy of, with its translations and OG image, in tx; it is the SEO copier of crud.Copy
+func (s *SeoService) Copy(tx *gorm.DB, entity string, from, to uint) error {
+	if _, ok := getEntity(entity); !ok {
+		return nil
+	}
+	item := &Metadata{}
+	err := tx.Where("model_type = ? AND model_id = ?", entity, from).First(item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = crud.Copy(tx, item.Id, crud.CopyOptions[Metadata]{
+		Change: func(tx *gorm.DB, copied *Metadata) error {
+			copied.ModelId = to
+			return nil
+		},
+	})
+	return err
+}
+
+// SetImage uploads the OG image of a record, replacing the previous one
+func (s *SeoService) SetImage(ctx context.Context, entity string, id uint, file *multipart.FileHeader) (*Metadata, error) {
+	if err := s.checkRecord(ctx, entity, id); err != nil {
+		return nil, err
+	}
+	if err := ValidateImage(file); err != nil {
+		return nil, err
+	}
+
+	item, err := s.record(ctx, entity, id)
+	if err != nil {
+		return nil, err
+	}
+	if item.Id == 0 {
+		if err := s.DB.WithContext(ctx).Create(item).Error; err != nil {
+			return nil, err
+		}
+	}
+	if err := s.removeImage(ctx, item); err != nil {
+		return nil, err
+	}
+	if _, err := s.Storage.Attach(ctx, item, "og_image", file); err != nil {
+		s.Logger.Error("failed to upload OG image",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(item.Id)))
+		return nil, fmt.Errorf("failed to upload image: %w", err)
+	}
+	return s.changed(ctx, entity, id)
+}
+
+// DeleteImage removes the OG image of a record
+func (s *SeoService) DeleteImage(ctx context.Context, entity string, id uint) (*Metadata, error) {
+	if err := s.checkRecord(ctx, entity, id); err != nil {
+		return nil, err
+	}
+	item := &Metadata{}
+	if err := s.DB.WithContext(ctx).Where("model_type = ? AND model_id = ?", entity, id).First(item).Error; err != nil {
+		return nil, err
+	}
+	var image storage.Attachment
+	err := s.DB.WithContext(ctx).Where("model_type = ? AND model_id = ? AND field = ?", item.GetModelName(), item.Id, "og_image").
+		First(&image).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Storage.Delete(ctx, &image); err != nil {
+		s.Logger.Error("failed to delete OG image",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(image.Id)))
+		return nil, err
+	}
+	return s.changed(ctx, entity, id)
+}
+
+// removeImage deletes the OG image of metadata, if it has one
+func (s *SeoService) removeImage(ctx context.Context, item *Metadata) error {
+	var images []*storage.Attachment
+	err := s.DB.WithContext(ctx).Where("model_type = ? AND model_id = ? AND field = ?", item.GetModelName(), item.Id, "og_image").
+		Find(&images).Error
+	if err != nil {
+		return err
+	}
+	for _, image := range images {
+		if err := s.Storage.Delete(ctx, image); err != nil {
+			s.Logger.Error("failed to delete OG image",
+				logger.String("error", err.Error()),
+				logger.Int("id", int(image.Id)))
+			return err
+		}
+	}
+	return nil
+}
+
+// record returns the stored metadata of a record, or new metadata for it
+func (s *SeoService) record(ctx context.Context, entity string, id uint) (*Metadata, error) {
+	item := &Metadata{}
+	err := s.DB.WithContext(ctx).Where("model_type = ? AND model_id = ?", entity, id).First(item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &Metadata{ModelType: entity, ModelId: id}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// checkRecord checks that a record of a registered entity exists
+func (s *SeoService) checkRecord(ctx context.Context, entity string, id uint) error {
+	registered, ok := getEntity(entity)
+	if !ok {
+		return ErrUnknownEntity
+	}
+	var count int64
+	if err := s.DB.WithContext(ctx).Model(registered.Model).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// changed reloads the metadata of a record after a change and emits the update event
+func (s *SeoService) changed(ctx context.Context, entity string, id uint) (*Metadata, error) {
+	result, err := s.Get(ctx, entity, id)
+	if err != nil {
+		return nil, err
+	}
+	s.Emitter.EmitContext(ctx, UpdateMetadataEvent, result)
+	return result, nil
+}
+
+// Listen deletes the metadata of records when they are deleted
+func (s *SeoService) Listen() {
+	for _, entity := range Entities() {
+		if entity.DeleteEvent == "" {
+			continue
+		}
+		s.Emitter.On(entity.DeleteEvent, func(data any) {
+			record, ok := data.(interface{ GetId() uint })
+			if !ok {
+				return
+			}
+			err := s.Delete(context.Background(), entity.Name, record.GetId())
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				s.Logger.Error("failed to delete SEO metadata of deleted record",
+					logger.String("error", err.Error()),
+					logger.String("model_type", entity.Name),
+					logger.Int("model_id", int(record.GetId())))
+			}
+		})
+	}
+}
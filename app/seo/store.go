@@ -0,0 +1,150 @@
+package seo
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"base/core/storage"
+	"base/core/translation"
+
+	"gorm.io/gorm"
+)
+
+var (
+	entitiesMu sync.RWMutex
+	entities   = map[string]Entity{}
+)
+
+// RegisterEntity lets records of a module have SEO metadata, managed at Path/:id/seo.
+// Modules register in their Init, before routes are set up.
+func RegisterEntity(entity Entity) {
+	entitiesMu.Lock()
+	defer entitiesMu.Unlock()
+	entities[entity.Name] = entity
+}
+
+// getEntity returns a registered entity by name
+func getEntity(name string) (Entity, bool) {
+	entitiesMu.RLock()
+	defer entitiesMu.RUnlock()
+	entity, ok := entities[name]
+	return entity, ok
+}
+
+// Entities returns the registered entities sorted by name
+func Entities() []Entity {
+	entitiesMu.RLock()
+	defer entitiesMu.RUnlock()
+	result := make([]Entity, 0, len(entities))
+	for _, entity := range entities {
+		result = append(result, entity)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// Store reads the SEO metadata of records for public responses. Without the seo module
+// it has no database and records have no metadata.
+type Store struct {
+	mu      sync.RWMutex
+	db      *gorm.DB
+	storage *storage.ActiveStorage
+}
+
+// Records is the store modules include SEO metadata in their responses with
+var Records = &Store{}
+
+// Setup sets the database and storage the store reads from
+func (s *Store) Setup(db *gorm.DB, activeStorage *storage.ActiveStorage) {
+	s.mu.Lock()
+	s.db = db
+	s.storage = activeStorage
+	s.mu.Unlock()
+}
+
+// conn returns the database and storage of the store
+func (s *Store) conn() (*gorm.DB, *storage.ActiveStorage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.db == nil {
+		return nil, nil, errors.New("seo: store has no database")
+	}
+	return s.db, s.storage, nil
+}
+
+// Get returns the metadata of a record in the locale of ctx, nil when it has none
+func (s *Store) Get(ctx context.Context, entity string, id uint) (*Metadata, error) {
+	items, err := s.Load(ctx, entity, []uint{id})
+	if err != nil {
+		return nil, err
+	}
+	return items[id], nil
+}
+
+// Load returns the metadata of the records with the given ids in the locale of ctx, by
+// record id. Records without metadata are left out.
+func (s *Store) Load(ctx context.Context, entity string, ids []uint) (map[uint]*Metadata, error) {
+	db, activeStorage, err := s.conn()
+	if err != nil || len(ids) == 0 {
+		return map[uint]*Metadata{}, nil
+	}
+
+	items, err := find(ctx, db, activeStorage, entity, ids)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[uint]*Metadata, len(items))
+	for _, item := range items {
+		// Public responses only need the values in the request locale
+		item.Translations = nil
+		result[item.ModelId] = item
+	}
+	return result, nil
+}
+
+// find returns the metadata of records with their OG images and translations
+func find(ctx context.Context, db *gorm.DB, activeStorage *storage.ActiveStorage, entity string, ids []uint) ([]*Metadata, error) {
+	var items []*Metadata
+	if err := db.Where("model_type = ? AND model_id IN ?", entity, ids).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	if err := loadImages(db, activeStorage, items); err != nil {
+		return nil, err
+	}
+	model := &Metadata{}
+	if err := translation.Localize(ctx, model.TableName(), model.TranslatedFields(), items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// loadImages sets the OG images of metadata
+func loadImages(db *gorm.DB, activeStorage *storage.ActiveStorage, items []*Metadata) error {
+	if len(items) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(items))
+	byId := make(map[uint]*Metadata, len(items))
+	for i, item := range items {
+		ids[i] = item.Id
+		byId[item.Id] = item
+	}
+
+	var images []*storage.Attachment
+	err := db.Where("model_type = ? AND field = ? AND model_id IN ?", (&Metadata{}).GetModelName(), "og_image", ids).
+		Find(&images).Error
+	if err != nil {
+		return err
+	}
+	for _, image := range images {
+		if activeStorage != nil {
+			image.URL = activeStorage.GetProvider().GetURL(image.Path)
+		}
+		if item := byId[image.ModelId]; item != nil {
+			item.OgImage = image
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package seo
+
+import (
+	"fmt"
+	"mime/multipart"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"base/core/validator"
+)
+
+// Global validator instance using Base core validator wrapper
+var validate = validator.New()
+
+// ValidateUpdateRequest validates the update request
+func ValidateUpdateRequest(req *UpdateRequest) error {
+	if req == nil {
+		return nilRequest()
+	}
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateImage checks the type and size of an uploaded OG image
+func ValidateImage(file *multipart.FileHeader) error {
+	if ext := strings.ToLower(filepath.Ext(file.Filename)); !slices.Contains(ImageExtensions, ext) {
+		allowed := strings.Join(ImageExtensions, " ")
+		return validator.ValidationErrors{
+			{
+				Field:   "image",
+				Tag:     "oneof",
+				Value:   ext,
+				Param:   allowed,
+				Message: "image must be one of: " + allowed,
+			},
+		}
+	}
+	if file.Size > MaxImageSize {
+		return validator.ValidationErrors{
+			{
+				Field:   "image",
+				Tag:     "max_size",
+				Value:   fmt.Sprint(file.Size),
+				Param:   "10MB",
+				Message: "image must be at most 10MB",
+			},
+		}
+	}
+	return nil
+}
+
+// nilRequest is the error of a missing request
+func nilRequest() error {
+	return validator.ValidationErrors{
+		{
+			Field:   "request",
+			Tag:     "required",
+			Value:   "nil",
+			Message: "request cannot be nil",
+		},
+	}
+}
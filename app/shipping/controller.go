@@ -0,0 +1,396 @@
+package shipping
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"base/core/logger"
+	"base/core/router"
+	"base/core/translation"
+	"base/core/types"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+// maxWebhookSize caps the body of carrier webhooks
+const maxWebhookSize = 1 << 20
+
+type ShippingController struct {
+	Service *ShippingService
+}
+
+func NewShippingController(service *ShippingService) *ShippingController {
+	return &ShippingController{
+		Service: service,
+	}
+}
+
+// Routes registers the shipping method and shipment management endpoints; the group is
+// restricted to admins by the module
+func (c *ShippingController) Routes(router *router.RouterGroup) {
+	router.GET("/shipping-methods", c.ListMethods)         // Paginated list
+	router.POST("/shipping-methods", c.CreateMethod)       // Create
+	router.GET("/shipping-methods/:id", c.GetMethod)       // Get by ID
+	router.PUT("/shipping-methods/:id", c.UpdateMethod)    // Update
+	router.DELETE("/shipping-methods/:id", c.DeleteMethod) // Delete
+
+	router.GET("/shipments", c.ListShipments)      // Paginated list
+	router.POST("/shipments", c.CreateShipment)    // Ship an order
+	router.GET("/shipments/:id", c.GetShipment)    // Get by ID with its events
+	router.PUT("/shipments/:id", c.UpdateShipment) // Update tracking or status
+}
+
+// ProfileRoutes registers the shipments of the orders of the signed in user
+func (c *ShippingController) ProfileRoutes(router *router.RouterGroup) {
+	router.GET("/profile/orders/:id/shipments", c.MyShipments)
+}
+
+// WebhookRoutes registers the carrier status webhooks. They are under /api/webhooks, which
+// needs no API key or token (MIDDLEWARE_WEBHOOK_PATHS); requests are verified by their
+// signature.
+func (c *ShippingController) WebhookRoutes(router *router.RouterGroup) {
+	router.POST("/webhooks/carriers/:carrier", c.Webhook)
+}
+
+// CreateShippingMethod godoc
+// @Summary Create a shipping method
+// @Description Create a shipping method. Flat methods cost price per order, weight methods price plus price_per_kg for every started kilogram; orders from free_over ship free. Amounts are in cents and weights in grams (Admin only)
+// @Tags App/Shipping
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param method body CreateMethodRequest true "Create shipping method request"
+// @Success 201 {object} ShippingMethod
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /shipping-methods [post]
+func (c *ShippingController) CreateMethod(ctx *router.Context) error {
+	var req CreateMethodRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	item, err := c.Service.CreateMethod(ctx.Request.Context(), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to create shipping method")
+	}
+	return ctx.JSON(http.StatusCreated, item)
+}
+
+// GetShippingMethod godoc
+// @Summary Get a shipping method
+// @Description Get a shipping method by its id (Admin only)
+// @Tags App/Shipping
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Shipping method id"
+// @Success 200 {object} ShippingMethod
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /shipping-methods/{id} [get]
+func (c *ShippingController) GetMethod(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	item, err := c.Service.GetMethod(ctx.Request.Context(), uint(id))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to get shipping method")
+	}
+	return ctx.JSON(http.StatusOK, item)
+}
+
+// ListShippingMethods godoc
+// @Summary List shipping methods
+// @Description Get a page of shipping methods by position (Admin only)
+// @Tags App/Shipping
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of items per page"
+// @Success 200 {object} types.PaginatedResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /shipping-methods [get]
+func (c *ShippingController) ListMethods(ctx *router.Context) error {
+	params, err := ctx.ListParams()
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	paginatedResponse, err := c.Service.GetMethods(ctx.Request.Context(), params.Page, params.Limit)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch shipping methods: " + err.Error()})
+	}
+	return ctx.JSON(http.StatusOK, paginatedResponse)
+}
+
+// UpdateShippingMethod godoc
+// @Summary Update a shipping method
+// @Description Update a shipping method; omitted fields are left unchanged and countries replaces the countries (Admin only)
+// @Tags App/Shipping
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Shipping method id"
+// @Param method body UpdateMethodRequest true "Update shipping method request"
+// @Success 200 {object} ShippingMethod
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /shipping-methods/{id} [put]
+func (c *ShippingController) UpdateMethod(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	var req UpdateMethodRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	item, err := c.Service.UpdateMethod(ctx.Request.Context(), uint(id), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to update shipping method")
+	}
+	return ctx.JSON(http.StatusOK, item)
+}
+
+// DeleteShippingMethod godoc
+// @Summary Delete a shipping method
+// @Description Delete a shipping method; orders keep the name of the method they were placed with (Admin only)
+// @Tags App/Shipping
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Shipping method id"
+// @Success 204 "Successfully deleted"
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /shipping-methods/{id} [delete]
+func (c *ShippingController) DeleteMethod(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	if err := c.Service.DeleteMethod(ctx.Request.Context(), uint(id)); err != nil {
+		return c.fail(ctx, err, "Failed to delete shipping method")
+	}
+
+	ctx.Status(http.StatusNoContent)
+	return nil
+}
+
+// CreateShipment godoc
+// @Summary Ship an order
+// @Description Create a shipment of a paid order with its tracking number. The method defaults to the one the order was placed with and the carrier to the one of the method; a status other than pending is recorded as the first event (Admin only)
+// @Tags App/Shipping
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param shipment body CreateShipmentRequest true "Create shipment request"
+// @Success 201 {object} Shipment
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 409 {object} types.ErrorResponse
+// @Router /shipments [post]
+func (c *ShippingController) CreateShipment(ctx *router.Context) error {
+	var req CreateShipmentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	item, err := c.Service.CreateShipment(ctx.Request.Context(), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to create shipment")
+	}
+	return ctx.JSON(http.StatusCreated, item)
+}
+
+// GetShipment godoc
+// @Summary Get a shipment
+// @Description Get a shipment with its tracking events, oldest first (Admin only)
+// @Tags App/Shipping
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Shipment id"
+// @Success 200 {object} Shipment
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /shipments/{id} [get]
+func (c *ShippingController) GetShipment(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	item, err := c.Service.GetShipment(ctx.Request.Context(), uint(id))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to get shipment")
+	}
+	return ctx.JSON(http.StatusOK, item)
+}
+
+// ListShipments godoc
+// @Summary List shipments
+// @Description Get a page of shipments without their events, newest first (Admin only)
+// @Tags App/Shipping
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of items per page"
+// @Param order_id query int false "Only the shipments of the order"
+// @Param status query string false "Only shipments with the status"
+// @Success 200 {object} types.PaginatedResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /shipments [get]
+func (c *ShippingController) ListShipments(ctx *router.Context) error {
+	params, err := ctx.ListParams()
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	filter := ShipmentFilter{Status: ctx.Query("status")}
+	if value := ctx.Query("order_id"); value != "" {
+		orderId, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid order_id"})
+		}
+		filter.OrderId = uint(orderId)
+	}
+
+	paginatedResponse, err := c.Service.GetShipments(ctx.Request.Context(), params.Page, params.Limit, filter)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch shipments: " + err.Error()})
+	}
+	return ctx.JSON(http.StatusOK, paginatedResponse)
+}
+
+// UpdateShipment godoc
+// @Summary Update a shipment
+// @Description Update the tracking details of a shipment. A new status is recorded as an event with the description and location, updates the fulfillment status of the order and emails the customer when shipped, out for delivery, delivered or failed (Admin only)
+// @Tags App/Shipping
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Shipment id"
+// @Param shipment body UpdateShipmentRequest true "Update shipment request"
+// @Success 200 {object} Shipment
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /shipments/{id} [put]
+func (c *ShippingController) UpdateShipment(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	var req UpdateShipmentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	item, err := c.Service.UpdateShipment(ctx.Request.Context(), uint(id), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to update shipment")
+	}
+	return ctx.JSON(http.StatusOK, item)
+}
+
+// ListMyShipments godoc
+// @Summary Track an order
+// @Description Get the shipments of an order of the signed in user with their tracking events
+// @Tags App/Shipping
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Order id"
+// @Success 200 {array} Shipment
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /profile/orders/{id}/shipments [get]
+func (c *ShippingController) MyShipments(ctx *router.Context) error {
+	userId := ctx.GetUint("user_id")
+	if userId == 0 {
+		return ctx.JSON(http.StatusUnauthorized, types.ErrorResponse{Error: "Unauthorized"})
+	}
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	items, err := c.Service.GetOrderShipments(ctx.Request.Context(), uint(id), userId)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to get shipments")
+	}
+	return ctx.JSON(http.StatusOK, items)
+}
+
+// CarrierWebhook godoc
+// @Summary Carrier status webhook
+// @Description Receives the status of a shipment from its carrier, found by the carrier in the path and the tracking_number. Requests carry X-Webhook-Signature: sha256=<hex HMAC-SHA256 of the body with SHIPPING_WEBHOOK_SECRET>; events with an event_id that was received already are skipped
+// @Tags App/Shipping
+// @Accept json
+// @Produce json
+// @Param carrier path string true "Carrier, e.g. dhl"
+// @Param event body CarrierEvent true "Status event"
+// @Success 200 {object} types.SuccessResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 503 {object} types.ErrorResponse
+// @Router /webhooks/carriers/{carrier} [post]
+func (c *ShippingController) Webhook(ctx *router.Context) error {
+	payload, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxWebhookSize))
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Failed to read the request body"})
+	}
+
+	if err := c.Service.HandleWebhook(ctx.Request.Context(), ctx.Param("carrier"), payload, ctx.Request.Header); err != nil {
+		switch {
+		case errors.Is(err, ErrWebhookNotConfigured):
+			return ctx.JSON(http.StatusServiceUnavailable, types.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, ErrInvalidSignature):
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+		}
+		if _, ok := err.(validator.ValidationErrors); ok {
+			return c.fail(ctx, err, "Invalid event")
+		}
+		c.Service.Logger.Error("failed to handle carrier webhook", logger.String("error", err.Error()))
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to handle webhook: " + err.Error()})
+	}
+	return ctx.JSON(http.StatusOK, types.SuccessResponse{Success: true, Message: "received"})
+}
+
+// fail writes the error response of a service error
+func (c *ShippingController) fail(ctx *router.Context, err error, message string) error {
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   translation.Error(ctx, err),
+			Details: translation.LocalizeValidation(ctx, validationErrors),
+		})
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Item not found"})
+	}
+	if errors.Is(err, ErrNotShippable) || errors.Is(err, ErrMethodUnavailable) {
+		return ctx.JSON(http.StatusConflict, types.ErrorResponse{Error: translation.Error(ctx, err)})
+	}
+	return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: message + ": " + err.Error()})
+}
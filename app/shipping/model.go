@@ -0,0 +1,202 @@
+package shipping
+
+import (
+	"slices"
+	"time"
+
+	"base/core/types"
+
+	"gorm.io/gorm"
+)
+
+// Shipping method types
+const (
+	TypeFlat   = "flat"   // Price per order
+	TypeWeight = "weight" // Price plus PricePerKg for every started kilogram
+)
+
+// Shipment statuses
+const (
+	StatusPending        = "pending"          // Created, not handed to the carrier yet
+	StatusShipped        = "shipped"          // Handed to the carrier
+	StatusInTransit      = "in_transit"       // On its way
+	StatusOutForDelivery = "out_for_delivery" // With the courier for delivery today
+	StatusDelivered      = "delivered"        // Delivered to the customer
+	StatusFailed         = "failed"           // Delivery failed, e.g. nobody was home
+	StatusReturned       = "returned"         // Sent back to the shop
+)
+
+// notifiedStatuses are the statuses the customer is emailed about
+var notifiedStatuses = []string{StatusShipped, StatusOutForDelivery, StatusDelivered, StatusFailed}
+
+// ShippingMethod is a way orders can be shipped and what it costs. Prices are in cents
+// and weights in grams.
+type ShippingMethod struct {
+	Id          uint           `json:"id" gorm:"primarykey"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	Name        string         `json:"name" gorm:"size:100"`
+	Description string         `json:"description" gorm:"type:text"`
+	Carrier     string         `json:"carrier" gorm:"size:64"` // Carrier of the shipments, e.g. dhl
+	Type        string         `json:"type" gorm:"size:16"`
+	Price       types.Money    `json:"price"`
+	PricePerKg  types.Money    `json:"price_per_kg"`                               // Weight methods only
+	MaxWeight   int            `json:"max_weight"`                                 // Heavier orders can't use the method, 0 for no limit
+	FreeOver    types.Money    `json:"free_over"`                                  // Subtotal after discounts from which shipping is free, 0 for never
+	Countries   []string       `json:"countries" gorm:"type:text;serializer:json"` // Countries shipped to, empty for all
+	Active      bool           `json:"active" gorm:"index"`
+	Position    int            `json:"position"`
+}
+
+// TableName returns the table name for the ShippingMethod model
+func (m *ShippingMethod) TableName() string {
+	return "shipping_methods"
+}
+
+// ShipsTo reports whether the method ships to a country
+func (m *ShippingMethod) ShipsTo(country string) bool {
+	return len(m.Countries) == 0 || slices.Contains(m.Countries, country)
+}
+
+// Quote returns the price of shipping an order with the subtotal and weight in grams;
+// false when the order is too heavy for the method
+func (m *ShippingMethod) Quote(subtotal types.Money, weight int) (types.Money, bool) {
+	if m.MaxWeight > 0 && weight > m.MaxWeight {
+		return 0, false
+	}
+	if m.FreeOver > 0 && subtotal >= m.FreeOver {
+		return 0, true
+	}
+	price := m.Price
+	if m.Type == TypeWeight {
+		kilograms := (weight + 999) / 1000
+		price += m.PricePerKg.Times(kilograms)
+	}
+	return price, true
+}
+
+// Shipment is a parcel of an order handed to a carrier. Carrier webhooks find it by its
+// tracking number and add to its Events.
+type Shipment struct {
+	Id             uint             `json:"id" gorm:"primarykey"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+	OrderId        uint             `json:"order_id" gorm:"index"`
+	MethodId       *uint            `json:"method_id"`
+	Carrier        string           `json:"carrier" gorm:"size:64;index:idx_shipments_tracking"`
+	TrackingNumber string           `json:"tracking_number" gorm:"size:128;index:idx_shipments_tracking"`
+	TrackingUrl    string           `json:"tracking_url" gorm:"size:512"`
+	Status         string           `json:"status" gorm:"size:32;index"`
+	ShippedAt      *time.Time       `json:"shipped_at"`
+	DeliveredAt    *time.Time       `json:"delivered_at"`
+	Events         []*ShipmentEvent `json:"events,omitempty" gorm:"foreignKey:ShipmentId"`
+}
+
+// TableName returns the table name for the Shipment model
+func (m *Shipment) TableName() string {
+	return "shipments"
+}
+
+// ShipmentEvent is a status change of a shipment, by an admin or reported by the carrier
+type ShipmentEvent struct {
+	Id          uint      `json:"id" gorm:"primarykey"`
+	CreatedAt   time.Time `json:"created_at"`
+	ShipmentId  uint      `json:"shipment_id" gorm:"index"`
+	EventId     string    `json:"event_id,omitempty" gorm:"size:128;index"` // Id of the carrier event, to skip redeliveries
+	Status      string    `json:"status" gorm:"size:32"`
+	Description string    `json:"description" gorm:"size:512"`
+	Location    string    `json:"location" gorm:"size:255"`
+	OccurredAt  time.Time `json:"occurred_at"`
+}
+
+// TableName returns the table name for the ShipmentEvent model
+func (m *ShipmentEvent) TableName() string {
+	return "shipment_events"
+}
+
+// CreateMethodRequest represents the request payload for creating a ShippingMethod
+type CreateMethodRequest struct {
+	Name        string      `json:"name" validate:"required,max=100"`
+	Description string      `json:"description"`
+	Carrier     string      `json:"carrier" validate:"max=64"`
+	Type        string      `json:"type" validate:"required,oneof=flat weight"`
+	Price       types.Money `json:"price" validate:"gte=0"`
+	PricePerKg  types.Money `json:"price_per_kg" validate:"gte=0"`
+	MaxWeight   int         `json:"max_weight" validate:"gte=0"`
+	FreeOver    types.Money `json:"free_over" validate:"gte=0"`
+	Countries   []string    `json:"countries,omitempty" validate:"dive,iso3166_1_alpha2"`
+	Active      *bool       `json:"active,omitempty"` // Defaults to true
+	Position    int         `json:"position"`
+}
+
+// UpdateMethodRequest represents the request payload for updating a ShippingMethod.
+// Omitted fields are left unchanged; countries replaces the countries.
+type UpdateMethodRequest struct {
+	Name        *string      `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
+	Description *string      `json:"description,omitempty"`
+	Carrier     *string      `json:"carrier,omitempty" validate:"omitempty,max=64"`
+	Type        *string      `json:"type,omitempty" validate:"omitempty,oneof=flat weight"`
+	Price       *types.Money `json:"price,omitempty" validate:"omitempty,gte=0"`
+	PricePerKg  *types.Money `json:"price_per_kg,omitempty" validate:"omitempty,gte=0"`
+	MaxWeight   *int         `json:"max_weight,omitempty" validate:"omitempty,gte=0"`
+	FreeOver    *types.Money `json:"free_over,omitempty" validate:"omitempty,gte=0"`
+	Countries   *[]string    `json:"countries,omitempty" validate:"omitempty,dive,iso3166_1_alpha2"`
+	Active      *bool        `json:"active,omitempty"`
+	Position    *int         `json:"position,omitempty"`
+}
+
+// CreateShipmentRequest represents the request payload for creating a Shipment. The
+// carrier defaults to the one of the method.
+type CreateShipmentRequest struct {
+	OrderId        uint   `json:"order_id" validate:"required"`
+	MethodId       *uint  `json:"method_id,omitempty"` // Defaults to the method of the order
+	Carrier        string `json:"carrier" validate:"max=64"`
+	TrackingNumber string `json:"tracking_number" validate:"max=128"`
+	TrackingUrl    string `json:"tracking_url" validate:"omitempty,url,max=512"`
+	Status         string `json:"status" validate:"omitempty,oneof=pending shipped in_transit out_for_delivery delivered failed returned"` // Defaults to pending
+}
+
+// UpdateShipmentRequest represents the request payload for updating a Shipment. A new
+// status is recorded as an event with the description and location.
+type UpdateShipmentRequest struct {
+	Carrier        *string `json:"carrier,omitempty" validate:"omitempty,max=64"`
+	TrackingNumber *string `json:"tracking_number,omitempty" validate:"omitempty,max=128"`
+	TrackingUrl    *string `json:"tracking_url,omitempty" validate:"omitempty,url,max=512"`
+	Status         *string `json:"status,omitempty" validate:"omitempty,oneof=pending shipped in_transit out_for_delivery delivered failed returned"`
+	Description    string  `json:"description,omitempty" validate:"max=512"`
+	Location       string  `json:"location,omitempty" validate:"max=255"`
+}
+
+// CarrierEvent is the payload of a carrier status webhook
+type CarrierEvent struct {
+	EventId        string     `json:"event_id" validate:"max=128"` // Redelivered events with the same id are skipped
+	TrackingNumber string     `json:"tracking_number" validate:"required,max=128"`
+	Status         string     `json:"status" validate:"required,oneof=pending shipped in_transit out_for_delivery delivered failed returned"`
+	Description    string     `json:"description" validate:"max=512"`
+	Location       string     `json:"location" validate:"max=255"`
+	OccurredAt     *time.Time `json:"occurred_at,omitempty"` // Defaults to when it is received
+}
+
+// QuoteRequest asks what shipping an order costs with each method
+type QuoteRequest struct {
+	Country  string
+	Subtotal types.Money
+	Weight   int // Grams
+}
+
+// Quote is the price of shipping an order with a method
+type Quote struct {
+	MethodId    uint        `json:"method_id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Carrier     string      `json:"carrier"`
+	Price       types.Money `json:"price"`
+	Currency    string      `json:"currency"`
+}
+
+// ShipmentFilter narrows shipment lists
+type ShipmentFilter struct {
+	OrderId uint
+	Status  string
+}
@@ -0,0 +1,158 @@
+package shipping
+
+import (
+	"errors"
+
+	"base/core/app/authorization"
+	"base/core/app/reports"
+	"base/core/module"
+	"base/core/router"
+
+	"gorm.io/gorm"
+)
+
+// Module provides the shipping methods carts are quoted with and the shipments of orders,
+// tracked through carrier webhooks and emailed to customers
+type Module struct {
+	module.DefaultModule
+	DB         *gorm.DB
+	Service    *ShippingService
+	Controller *ShippingController
+}
+
+// Init creates and initializes the shipping module with all dependencies
+func Init(deps module.Dependencies) module.Module {
+	registerEvents()
+	service := NewShippingService(deps.DB, deps.Emitter, deps.Logger, deps.EmailSender, deps.Config.PaymentsCurrency)
+	service.From = deps.Config.EmailFromAddress
+	service.WebhookSecret = deps.Config.ShippingWebhookSecret
+	service.Listen()
+
+	reports.RegisterEntity(reports.Entity{
+		Name:    "shipments",
+		Columns: []string{"id", "order_id", "method_id", "carrier", "tracking_number", "status", "shipped_at", "delivered_at", "created_at"},
+	})
+
+	return &Module{
+		DB:         deps.DB,
+		Service:    service,
+		Controller: NewShippingController(service),
+	}
+}
+
+// Routes registers the shipments of the signed in user, the admin routes and the carrier
+// webhooks
+func (m *Module) Routes(router *router.RouterGroup) {
+	m.Controller.ProfileRoutes(router)
+
+	group := router.Group("", authorization.RequireAdmin(authorization.NewAuthorizationService(m.DB)))
+	m.Controller.Routes(group)
+
+	m.Controller.WebhookRoutes(router)
+}
+
+func (m *Module) Init() error {
+	return m.SeedPermissions()
+}
+
+func (m *Module) SeedPermissions() error {
+	// Ensure permissions table exists before seeding
+	if err := m.DB.AutoMigrate(&authorization.Permission{}); err != nil {
+		return err
+	}
+
+	// Define permissions for shipping operations
+	permissions := []authorization.Permission{
+		{
+			Name:         "shipping method list",
+			Description:  "View shipping method list",
+			ResourceType: "shipping_method",
+			Action:       "list",
+		},
+		{
+			Name:         "shipping method read",
+			Description:  "View shipping methods",
+			ResourceType: "shipping_method",
+			Action:       "read",
+		},
+		{
+			Name:         "shipping method create",
+			Description:  "Create shipping methods",
+			ResourceType: "shipping_method",
+			Action:       "create",
+		},
+		{
+			Name:         "shipping method update",
+			Description:  "Update shipping methods",
+			ResourceType: "shipping_method",
+			Action:       "update",
+		},
+		{
+			Name:         "shipping method delete",
+			Description:  "Delete shipping methods",
+			ResourceType: "shipping_method",
+			Action:       "delete",
+		},
+		{
+			Name:         "shipment list",
+			Description:  "View shipment list",
+			ResourceType: "shipment",
+			Action:       "list",
+		},
+		{
+			Name:         "shipment read",
+			Description:  "View shipments and their tracking events",
+			ResourceType: "shipment",
+			Action:       "read",
+		},
+		{
+			Name:         "shipment create",
+			Description:  "Ship orders",
+			ResourceType: "shipment",
+			Action:       "create",
+		},
+		{
+			Name:         "shipment update",
+			Description:  "Update the tracking and status of shipments",
+			ResourceType: "shipment",
+			Action:       "update",
+		},
+	}
+
+	// Upsert permissions - create or update if they exist
+	for _, permission := range permissions {
+		var existingPermission authorization.Permission
+		result := m.DB.Where("resource_type = ? AND action = ?", permission.ResourceType, permission.Action).First(&existingPermission)
+
+		if result.Error != nil && errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// Create new permission
+			if err := m.DB.Create(&permission).Error; err != nil {
+				return err
+			}
+		} else if result.Error == nil {
+			// Update existing permission
+			existingPermission.Name = permission.Name
+			existingPermission.Description = permission.Description
+			if err := m.DB.Save(&existingPermission).Error; err != nil {
+				return err
+			}
+		} else {
+			// Return any other error
+			return result.Error
+		}
+	}
+
+	return nil
+}
+
+func (m *Module) Migrate() error {
+	return m.DB.AutoMigrate(&ShippingMethod{}, &Shipment{}, &ShipmentEvent{})
+}
+
+func (m *Module) GetModels() []any {
+	return []any{
+		&ShippingMethod{},
+		&Shipment{},
+		&ShipmentEvent{},
+	}
+}
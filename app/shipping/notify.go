@@ -0,0 +1,71 @@
+package shipping
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"base/app/orders"
+	"base/core/email"
+	"base/core/emitter"
+)
+
+// Listen emails customers when their shipments are shipped, out for delivery, delivered
+// or failed to be delivered; emails that fail are kept as event failures to retry
+func (s *ShippingService) Listen() {
+	emitter.Handle(s.Emitter, StatusShipmentEvent, func(ctx context.Context, shipment *Shipment) error {
+		if !slices.Contains(notifiedStatuses, shipment.Status) {
+			return nil
+		}
+		return s.notify(ctx, shipment)
+	})
+}
+
+// notify emails the customer of the order about the status of a shipment
+func (s *ShippingService) notify(ctx context.Context, shipment *Shipment) error {
+	if s.EmailSender == nil {
+		return nil
+	}
+	order := &orders.Order{}
+	if err := s.DB.WithContext(ctx).First(order, shipment.OrderId).Error; err != nil {
+		return err
+	}
+	if order.Email == "" {
+		return nil
+	}
+
+	var subject, message string
+	switch shipment.Status {
+	case StatusShipped:
+		subject, message = "Your order #%d has shipped", "Your order #%d is on its way."
+	case StatusOutForDelivery:
+		subject, message = "Your order #%d is out for delivery", "Your order #%d will be delivered today."
+	case StatusDelivered:
+		subject, message = "Your order #%d was delivered", "Your order #%d was delivered. We hope you enjoy it!"
+	case StatusFailed:
+		subject, message = "Delivery of your order #%d failed", "The carrier couldn't deliver your order #%d. They will get in touch to arrange another delivery."
+	default:
+		return nil
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Dear %s,\n\n", order.ShippingAddress.Name)
+	fmt.Fprintf(&body, message, order.Id)
+	if shipment.TrackingNumber != "" {
+		fmt.Fprintf(&body, "\n\nTracking number: %s", shipment.TrackingNumber)
+		if shipment.Carrier != "" {
+			fmt.Fprintf(&body, " (%s)", shipment.Carrier)
+		}
+	}
+	if shipment.TrackingUrl != "" {
+		fmt.Fprintf(&body, "\nTrack your parcel: %s", shipment.TrackingUrl)
+	}
+
+	return s.EmailSender.Send(email.Message{
+		To:      []string{order.Email},
+		From:    s.From,
+		Subject: fmt.Sprintf(subject, order.Id),
+		Body:    body.String(),
+	})
+}
@@ -0,0 +1,288 @@
+package shipping
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	"base/core/email"
+	"base/core/emitter"
+	"base/core/logger"
+	"base/core/types"
+
+	"gorm.io/gorm"
+)
+
+const (
+	CreateMethodEvent   = "shipping_methods.create"
+	UpdateMethodEvent   = "shipping_methods.update"
+	DeleteMethodEvent   = "shipping_methods.delete"
+	CreateShipmentEvent = "shipments.create"
+	UpdateShipmentEvent = "shipments.update"
+	StatusShipmentEvent = "shipments.status" // The status of a shipment changed
+)
+
+// registerEvents adds the events of the module to the event catalog
+func registerEvents() {
+	emitter.RegisterEvents(
+		emitter.EventType{Name: CreateMethodEvent, Description: "A shipping method was created", Payload: &ShippingMethod{}},
+		emitter.EventType{Name: UpdateMethodEvent, Description: "A shipping method was updated", Payload: &ShippingMethod{}},
+		emitter.EventType{Name: DeleteMethodEvent, Description: "A shipping method was deleted", Payload: &ShippingMethod{}},
+		emitter.EventType{Name: CreateShipmentEvent, Description: "A shipment was created", Payload: &Shipment{}},
+		emitter.EventType{Name: UpdateShipmentEvent, Description: "A shipment was updated", Payload: &Shipment{}},
+		emitter.EventType{Name: StatusShipmentEvent, Description: "The status of a shipment changed", Payload: &Shipment{}},
+	)
+}
+
+var (
+	// ErrMethodUnavailable is returned when a shipping method doesn't ship an order, e.g.
+	// to its country or at its weight
+	ErrMethodUnavailable = errors.New("shipping method is not available for this order")
+
+	// ErrNotShippable is returned when creating a shipment for an order that isn't paid
+	ErrNotShippable = errors.New("only paid orders can be shipped")
+
+	// ErrWebhookNotConfigured is returned for carrier webhooks without SHIPPING_WEBHOOK_SECRET
+	ErrWebhookNotConfigured = errors.New("carrier webhooks are not configured")
+
+	// ErrInvalidSignature is returned for carrier webhooks with a missing or wrong signature
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+)
+
+// ShippingService manages the shipping methods orders are quoted with and the shipments
+// of orders, which it follows through carrier webhooks and emails the customer about
+type ShippingService struct {
+	DB            *gorm.DB
+	Emitter       *emitter.Emitter
+	Logger        logger.Logger
+	EmailSender   email.Sender // Nil to send no notifications
+	From          string
+	WebhookSecret string
+	currency      string
+}
+
+func NewShippingService(db *gorm.DB, emitter *emitter.Emitter, logger logger.Logger, sender email.Sender, currency string) *ShippingService {
+	return &ShippingService{
+		DB:          db,
+		Emitter:     emitter,
+		Logger:      logger,
+		EmailSender: sender,
+		currency:    currency,
+	}
+}
+
+// CreateMethod creates a shipping method
+func (s *ShippingService) CreateMethod(ctx context.Context, req *CreateMethodRequest) (*ShippingMethod, error) {
+	if err := ValidateMethodCreateRequest(req); err != nil {
+		return nil, err
+	}
+
+	item := &ShippingMethod{
+		Name:        req.Name,
+		Description: req.Description,
+		Carrier:     req.Carrier,
+		Type:        req.Type,
+		Price:       req.Price,
+		PricePerKg:  req.PricePerKg,
+		MaxWeight:   req.MaxWeight,
+		FreeOver:    req.FreeOver,
+		Countries:   req.Countries,
+		Active:      req.Active == nil || *req.Active,
+		Position:    req.Position,
+	}
+	if err := s.DB.WithContext(ctx).Create(item).Error; err != nil {
+		s.Logger.Error("failed to create shipping method", logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	// Emit create event
+	s.Emitter.EmitContext(ctx, CreateMethodEvent, item)
+
+	return item, nil
+}
+
+// UpdateMethod updates a shipping method
+func (s *ShippingService) UpdateMethod(ctx context.Context, id uint, req *UpdateMethodRequest) (*ShippingMethod, error) {
+	item, err := s.GetMethod(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateMethodUpdateRequest(req); err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		item.Name = *req.Name
+	}
+	if req.Description != nil {
+		item.Description = *req.Description
+	}
+	if req.Carrier != nil {
+		item.Carrier = *req.Carrier
+	}
+	if req.Type != nil {
+		item.Type = *req.Type
+	}
+	if req.Price != nil {
+		item.Price = *req.Price
+	}
+	if req.PricePerKg != nil {
+		item.PricePerKg = *req.PricePerKg
+	}
+	if req.MaxWeight != nil {
+		item.MaxWeight = *req.MaxWeight
+	}
+	if req.FreeOver != nil {
+		item.FreeOver = *req.FreeOver
+	}
+	if req.Countries != nil {
+		item.Countries = *req.Countries
+	}
+	if req.Active != nil {
+		item.Active = *req.Active
+	}
+	if req.Position != nil {
+		item.Position = *req.Position
+	}
+
+	if err := s.DB.WithContext(ctx).Save(item).Error; err != nil {
+		s.Logger.Error("failed to update shipping method",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return nil, err
+	}
+
+	// Emit update event
+	s.Emitter.EmitContext(ctx, UpdateMethodEvent, item)
+
+	return item, nil
+}
+
+// DeleteMethod deletes a shipping method; orders and shipments keep its name
+func (s *ShippingService) DeleteMethod(ctx context.Context, id uint) error {
+	item, err := s.GetMethod(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.DB.WithContext(ctx).Delete(item).Error; err != nil {
+		s.Logger.Error("failed to delete shipping method",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return err
+	}
+
+	// Emit delete event
+	s.Emitter.EmitContext(ctx, DeleteMethodEvent, item)
+
+	return nil
+}
+
+// GetMethod returns a shipping method
+func (s *ShippingService) GetMethod(ctx context.Context, id uint) (*ShippingMethod, error) {
+	item := &ShippingMethod{}
+	if err := s.DB.WithContext(ctx).First(item, id).Error; err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// GetMethods returns a page of shipping methods in their order
+func (s *ShippingService) GetMethods(ctx context.Context, page *int, limit *int) (*types.PaginatedResponse, error) {
+	var items []*ShippingMethod
+	var total int64
+
+	// Set default values if nil
+	defaultPage := 1
+	defaultLimit := 10
+	if page == nil {
+		page = &defaultPage
+	}
+	if limit == nil {
+		limit = &defaultLimit
+	}
+
+	// Get total count
+	if err := s.DB.WithContext(ctx).Model(&ShippingMethod{}).Count(&total).Error; err != nil {
+		s.Logger.Error("failed to count shipping methods",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	offset := (*page - 1) * *limit
+	if err := s.DB.WithContext(ctx).Order("position, id").Offset(offset).Limit(*limit).Find(&items).Error; err != nil {
+		s.Logger.Error("failed to get shipping methods",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	// Calculate total pages
+	totalPages := int(math.Ceil(float64(total) / float64(*limit)))
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	return &types.PaginatedResponse{
+		Data: items,
+		Pagination: types.Pagination{
+			Total:      int(total),
+			Page:       *page,
+			PageSize:   *limit,
+			TotalPages: totalPages,
+		},
+	}, nil
+}
+
+// Quotes returns the price of shipping an order with each active method that ships it.
+// Without a country every method is quoted, so carts can show prices before the
+// address is known.
+func (s *ShippingService) Quotes(ctx context.Context, req QuoteRequest) ([]*Quote, error) {
+	var methods []*ShippingMethod
+	if err := s.DB.WithContext(ctx).Where("active = ?", true).Order("position, id").Find(&methods).Error; err != nil {
+		return nil, err
+	}
+
+	quotes := []*Quote{}
+	for _, method := range methods {
+		if quote, ok := s.quote(method, req); ok {
+			quotes = append(quotes, quote)
+		}
+	}
+	return quotes, nil
+}
+
+// Quote returns the price of shipping an order with a method; ErrMethodUnavailable when
+// the method is inactive or doesn't ship the order
+func (s *ShippingService) Quote(ctx context.Context, methodId uint, req QuoteRequest) (*Quote, error) {
+	method := &ShippingMethod{}
+	err := s.DB.WithContext(ctx).Where("active = ?", true).First(method, methodId).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrMethodUnavailable
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	quote, ok := s.quote(method, req)
+	if !ok {
+		return nil, ErrMethodUnavailable
+	}
+	return quote, nil
+}
+
+func (s *ShippingService) quote(method *ShippingMethod, req QuoteRequest) (*Quote, bool) {
+	if req.Country != "" && !method.ShipsTo(req.Country) {
+		return nil, false
+	}
+	price, ok := method.Quote(req.Subtotal, req.Weight)
+	if !ok {
+		return nil, false
+	}
+	return &Quote{
+		MethodId:    method.Id,
+		Name:        method.Name,
+		Description: method.Description,
+		Carrier:     method.Carrier,
+		Price:       price,
+		Currency:    s.currency,
+	}, true
+}
@@ -0,0 +1,349 @@
+package shipping
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"base/app/orders"
+	"base/core/logger"
+	"base/core/types"
+
+	"gorm.io/gorm"
+)
+
+// SignatureHeader is the header carriers send the webhook signature in:
+// sha256=<hex HMAC-SHA256 of the body with SHIPPING_WEBHOOK_SECRET>
+const SignatureHeader = "X-Webhook-Signature"
+
+// CreateShipment creates a shipment of a paid order. The carrier defaults to the one of
+// the shipping method.
+func (s *ShippingService) CreateShipment(ctx context.Context, req *CreateShipmentRequest) (*Shipment, error) {
+	if err := ValidateShipmentCreateRequest(req); err != nil {
+		return nil, err
+	}
+
+	order := &orders.Order{}
+	if err := s.DB.WithContext(ctx).First(order, req.OrderId).Error; err != nil {
+		return nil, err
+	}
+	if order.Status != orders.StatusPaid {
+		return nil, ErrNotShippable
+	}
+
+	item := &Shipment{
+		OrderId:        order.Id,
+		MethodId:       req.MethodId,
+		Carrier:        req.Carrier,
+		TrackingNumber: req.TrackingNumber,
+		TrackingUrl:    req.TrackingUrl,
+		Status:         StatusPending,
+	}
+	if item.MethodId == nil {
+		item.MethodId = order.ShippingMethodId
+	}
+	if item.MethodId != nil && item.Carrier == "" {
+		var carrier []string
+		if err := s.DB.WithContext(ctx).Model(&ShippingMethod{}).Unscoped().Where("id = ?", *item.MethodId).Limit(1).Pluck("carrier", &carrier).Error; err != nil {
+			return nil, err
+		}
+		if len(carrier) > 0 {
+			item.Carrier = carrier[0]
+		}
+	}
+
+	if err := s.DB.WithContext(ctx).Create(item).Error; err != nil {
+		s.Logger.Error("failed to create shipment",
+			logger.String("error", err.Error()),
+			logger.Int("order_id", int(order.Id)))
+		return nil, err
+	}
+
+	// Emit create event
+	s.Emitter.EmitContext(ctx, CreateShipmentEvent, item)
+
+	if req.Status != "" && req.Status != StatusPending {
+		if err := s.record(ctx, item, &ShipmentEvent{Status: req.Status, OccurredAt: time.Now()}); err != nil {
+			return nil, err
+		}
+	}
+	return s.GetShipment(ctx, item.Id)
+}
+
+// UpdateShipment changes the tracking details of a shipment; a new status is recorded as
+// an event
+func (s *ShippingService) UpdateShipment(ctx context.Context, id uint, req *UpdateShipmentRequest) (*Shipment, error) {
+	item, err := s.GetShipment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateShipmentUpdateRequest(req); err != nil {
+		return nil, err
+	}
+
+	updates := map[string]any{}
+	if req.Carrier != nil {
+		updates["carrier"] = *req.Carrier
+	}
+	if req.TrackingNumber != nil {
+		updates["tracking_number"] = *req.TrackingNumber
+	}
+	if req.TrackingUrl != nil {
+		updates["tracking_url"] = *req.TrackingUrl
+	}
+	if len(updates) > 0 {
+		if err := s.DB.WithContext(ctx).Model(&Shipment{}).Where("id = ?", item.Id).Updates(updates).Error; err != nil {
+			s.Logger.Error("failed to update shipment",
+				logger.String("error", err.Error()),
+				logger.Int("id", int(id)))
+			return nil, err
+		}
+	}
+
+	if req.Status != nil {
+		event := &ShipmentEvent{Status: *req.Status, Description: req.Description, Location: req.Location, OccurredAt: time.Now()}
+		if err := s.record(ctx, item, event); err != nil {
+			return nil, err
+		}
+	}
+
+	item, err = s.GetShipment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Emit update event
+	s.Emitter.EmitContext(ctx, UpdateShipmentEvent, item)
+
+	return item, nil
+}
+
+// GetShipment returns a shipment with its events, oldest first
+func (s *ShippingService) GetShipment(ctx context.Context, id uint) (*Shipment, error) {
+	item := &Shipment{}
+	if err := s.DB.WithContext(ctx).Preload("Events", func(db *gorm.DB) *gorm.DB { return db.Order("occurred_at, id") }).
+		First(item, id).Error; err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// GetOrderShipments returns the shipments of an order with their events; with a userId
+// only when the order is the user's
+func (s *ShippingService) GetOrderShipments(ctx context.Context, orderId, userId uint) ([]*Shipment, error) {
+	if userId != 0 {
+		if err := s.DB.WithContext(ctx).Where("user_id = ?", userId).First(&orders.Order{}, orderId).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	items := []*Shipment{}
+	if err := s.DB.WithContext(ctx).Preload("Events", func(db *gorm.DB) *gorm.DB { return db.Order("occurred_at, id") }).
+		Where("order_id = ?", orderId).Order("id").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// GetShipments returns a page of shipments without their events, newest first
+func (s *ShippingService) GetShipments(ctx context.Context, page *int, limit *int, filter ShipmentFilter) (*types.PaginatedResponse, error) {
+	var items []*Shipment
+	var total int64
+
+	query := s.DB.WithContext(ctx).Model(&Shipment{})
+	if filter.OrderId != 0 {
+		query = query.Where("order_id = ?", filter.OrderId)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	// Set default values if nil
+	defaultPage := 1
+	defaultLimit := 10
+	if page == nil {
+		page = &defaultPage
+	}
+	if limit == nil {
+		limit = &defaultLimit
+	}
+
+	// Get total count
+	if err := query.Count(&total).Error; err != nil {
+		s.Logger.Error("failed to count shipments",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	offset := (*page - 1) * *limit
+	if err := query.Order("id DESC").Offset(offset).Limit(*limit).Find(&items).Error; err != nil {
+		s.Logger.Error("failed to get shipments",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	// Calculate total pages
+	totalPages := int(math.Ceil(float64(total) / float64(*limit)))
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	return &types.PaginatedResponse{
+		Data: items,
+		Pagination: types.Pagination{
+			Total:      int(total),
+			Page:       *page,
+			PageSize:   *limit,
+			TotalPages: totalPages,
+		},
+	}, nil
+}
+
+// HandleWebhook verifies a carrier status webhook and records it on the shipment with
+// the tracking number. Redelivered events and events of unknown shipments are ignored.
+func (s *ShippingService) HandleWebhook(ctx context.Context, carrier string, payload []byte, header http.Header) error {
+	if err := s.verifySignature(payload, header.Get(SignatureHeader)); err != nil {
+		return err
+	}
+
+	var event CarrierEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("invalid webhook payload: %w", err)
+	}
+	if err := ValidateCarrierEvent(&event); err != nil {
+		return err
+	}
+
+	shipment := &Shipment{}
+	err := s.DB.WithContext(ctx).Where("carrier = ? AND tracking_number = ?", normalizeCarrier(carrier), event.TrackingNumber).
+		Order("id DESC").First(shipment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		s.Logger.Warn("webhook for an unknown shipment",
+			logger.String("carrier", carrier),
+			logger.String("tracking_number", event.TrackingNumber))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if event.EventId != "" {
+		var processed int64
+		if err := s.DB.WithContext(ctx).Model(&ShipmentEvent{}).
+			Where("shipment_id = ? AND event_id = ?", shipment.Id, event.EventId).
+			Count(&processed).Error; err != nil {
+			return err
+		}
+		if processed > 0 {
+			return nil
+		}
+	}
+
+	occurredAt := time.Now()
+	if event.OccurredAt != nil {
+		occurredAt = *event.OccurredAt
+	}
+	return s.record(ctx, shipment, &ShipmentEvent{
+		EventId:     event.EventId,
+		Status:      event.Status,
+		Description: event.Description,
+		Location:    event.Location,
+		OccurredAt:  occurredAt,
+	})
+}
+
+// record adds an event to a shipment and moves the shipment to its status, unless a
+// later event was recorded already (carriers don't always deliver in order). The
+// fulfillment status of the order follows.
+func (s *ShippingService) record(ctx context.Context, shipment *Shipment, event *ShipmentEvent) error {
+	event.ShipmentId = shipment.Id
+	changed := false
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var later int64
+		if err := tx.Model(&ShipmentEvent{}).
+			Where("shipment_id = ? AND occurred_at > ?", shipment.Id, event.OccurredAt).
+			Count(&later).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+		if later > 0 || event.Status == shipment.Status {
+			return nil
+		}
+
+		updates := map[string]any{"status": event.Status}
+		if shipment.ShippedAt == nil && event.Status != StatusPending {
+			updates["shipped_at"] = event.OccurredAt
+			shipment.ShippedAt = &event.OccurredAt
+		}
+		if event.Status == StatusDelivered {
+			updates["delivered_at"] = event.OccurredAt
+			shipment.DeliveredAt = &event.OccurredAt
+		}
+		if err := tx.Model(&Shipment{}).Where("id = ?", shipment.Id).Updates(updates).Error; err != nil {
+			return err
+		}
+		shipment.Status = event.Status
+		changed = true
+		return s.syncOrder(tx, shipment.OrderId)
+	})
+	if err != nil {
+		s.Logger.Error("failed to record shipment event",
+			logger.String("error", err.Error()),
+			logger.Int("shipment_id", int(shipment.Id)))
+		return err
+	}
+
+	if changed {
+		// Notifications are sent by a listener, don't wait for them
+		s.Emitter.EmitAsync(StatusShipmentEvent, shipment)
+	}
+	return nil
+}
+
+// syncOrder sets the fulfillment status of an order from its shipments: delivered when
+// every shipment that wasn't returned is, shipped once one is with the carrier
+func (s *ShippingService) syncOrder(tx *gorm.DB, orderId uint) error {
+	var statuses []string
+	if err := tx.Model(&Shipment{}).Where("order_id = ? AND status <> ?", orderId, StatusReturned).
+		Pluck("status", &statuses).Error; err != nil {
+		return err
+	}
+
+	fulfillment := orders.FulfillmentUnfulfilled
+	switch {
+	case len(statuses) > 0 && !slices.ContainsFunc(statuses, func(status string) bool { return status != StatusDelivered }):
+		fulfillment = orders.FulfillmentDelivered
+	case slices.ContainsFunc(statuses, func(status string) bool { return status != StatusPending }):
+		fulfillment = orders.FulfillmentShipped
+	}
+	return tx.Model(&orders.Order{}).Where("id = ?", orderId).Update("fulfillment_status", fulfillment).Error
+}
+
+// verifySignature checks the signature of a carrier webhook
+func (s *ShippingService) verifySignature(payload []byte, signature string) error {
+	if s.WebhookSecret == "" {
+		return ErrWebhookNotConfigured
+	}
+	decoded, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil || len(decoded) == 0 {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(s.WebhookSecret))
+	mac.Write(payload)
+	if !hmac.Equal(decoded, mac.Sum(nil)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package shipping
+
+import (
+	"strings"
+
+	"base/core/validator"
+)
+
+// Global validator instance using Base core validator wrapper
+var validate = validator.New()
+
+// ValidateMethodCreateRequest validates the create request; country codes are accepted in
+// any case
+func ValidateMethodCreateRequest(req *CreateMethodRequest) error {
+	if req == nil {
+		return nilRequest()
+	}
+
+	req.Carrier = normalizeCarrier(req.Carrier)
+	req.Countries = normalizeCountries(req.Countries)
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateMethodUpdateRequest validates the update request
+func ValidateMethodUpdateRequest(req *UpdateMethodRequest) error {
+	if req == nil {
+		return nilRequest()
+	}
+
+	if req.Carrier != nil {
+		*req.Carrier = normalizeCarrier(*req.Carrier)
+	}
+	if req.Countries != nil {
+		*req.Countries = normalizeCountries(*req.Countries)
+	}
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateShipmentCreateRequest validates the create request
+func ValidateShipmentCreateRequest(req *CreateShipmentRequest) error {
+	if req == nil {
+		return nilRequest()
+	}
+
+	req.Carrier = normalizeCarrier(req.Carrier)
+	req.TrackingNumber = strings.TrimSpace(req.TrackingNumber)
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateShipmentUpdateRequest validates the update request
+func ValidateShipmentUpdateRequest(req *UpdateShipmentRequest) error {
+	if req == nil {
+		return nilRequest()
+	}
+
+	if req.Carrier != nil {
+		*req.Carrier = normalizeCarrier(*req.Carrier)
+	}
+	if req.TrackingNumber != nil {
+		*req.TrackingNumber = strings.TrimSpace(*req.TrackingNumber)
+	}
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateCarrierEvent validates the payload of a carrier webhook
+func ValidateCarrierEvent(event *CarrierEvent) error {
+	event.TrackingNumber = strings.TrimSpace(event.TrackingNumber)
+	if errs := validate.Validate(event); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// normalizeCarrier lower cases carrier names, which are part of the webhook URL
+func normalizeCarrier(carrier string) string {
+	return strings.ToLower(strings.TrimSpace(carrier))
+}
+
+func normalizeCountries(countries []string) []string {
+	for i, country := range countries {
+		countries[i] = strings.ToUpper(strings.TrimSpace(country))
+	}
+	return countries
+}
+
+func nilRequest() validator.ValidationErrors {
+	return validator.ValidationErrors{
+		{
+			Field:   "request",
+			Tag:     "required",
+			Value:   "nil",
+			Message: "request cannot be nil",
+		},
+	}
+}
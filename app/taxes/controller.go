@@ -0,0 +1,186 @@
+package taxes
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"base/core/router"
+	"base/core/translation"
+	"base/core/types"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+type TaxController struct {
+	Service *TaxService
+}
+
+func NewTaxController(service *TaxService) *TaxController {
+	return &TaxController{
+		Service: service,
+	}
+}
+
+// Routes registers the tax rate management endpoints; the group is restricted to admins
+// by the module
+func (c *TaxController) Routes(router *router.RouterGroup) {
+	router.GET("/tax-rates", c.List)          // Paginated list
+	router.POST("/tax-rates", c.Create)       // Create
+	router.GET("/tax-rates/:id", c.Get)       // Get by ID
+	router.PUT("/tax-rates/:id", c.Update)    // Update
+	router.DELETE("/tax-rates/:id", c.Delete) // Delete
+}
+
+// CreateTaxRate godoc
+// @Summary Create a tax rate
+// @Description Create a tax rate in percent. Leave country, region or category_id empty to match every one; the most specific active rate applies to an item, the tax_rate setting to items none matches (Admin only)
+// @Tags App/Tax
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param tax_rate body CreateTaxRateRequest true "Create tax rate request"
+// @Success 201 {object} TaxRate
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /tax-rates [post]
+func (c *TaxController) Create(ctx *router.Context) error {
+	var req CreateTaxRateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	item, err := c.Service.Create(ctx.Request.Context(), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to create tax rate")
+	}
+	return ctx.JSON(http.StatusCreated, item)
+}
+
+// GetTaxRate godoc
+// @Summary Get a tax rate
+// @Description Get a tax rate by its id (Admin only)
+// @Tags App/Tax
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Tax rate id"
+// @Success 200 {object} TaxRate
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /tax-rates/{id} [get]
+func (c *TaxController) Get(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	item, err := c.Service.GetById(ctx.Request.Context(), uint(id))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to get tax rate")
+	}
+	return ctx.JSON(http.StatusOK, item)
+}
+
+// ListTaxRates godoc
+// @Summary List tax rates
+// @Description Get a page of tax rates ordered by country, region and category (Admin only)
+// @Tags App/Tax
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of items per page"
+// @Param country query string false "Only the rates of a country"
+// @Success 200 {object} types.PaginatedResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /tax-rates [get]
+func (c *TaxController) List(ctx *router.Context) error {
+	params, err := ctx.ListParams()
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	paginatedResponse, err := c.Service.GetAll(ctx.Request.Context(), params.Page, params.Limit, TaxRateFilter{Country: ctx.Query("country")})
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch tax rates: " + err.Error()})
+	}
+	return ctx.JSON(http.StatusOK, paginatedResponse)
+}
+
+// UpdateTaxRate godoc
+// @Summary Update a tax rate
+// @Description Update a tax rate; omitted fields are left unchanged, an empty country or region and a category_id of 0 clear them (Admin only)
+// @Tags App/Tax
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Tax rate id"
+// @Param tax_rate body UpdateTaxRateRequest true "Update tax rate request"
+// @Success 200 {object} TaxRate
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /tax-rates/{id} [put]
+func (c *TaxController) Update(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	var req UpdateTaxRateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	item, err := c.Service.Update(ctx.Request.Context(), uint(id), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to update tax rate")
+	}
+	return ctx.JSON(http.StatusOK, item)
+}
+
+// DeleteTaxRate godoc
+// @Summary Delete a tax rate
+// @Description Delete a tax rate; placed orders keep the rate they were taxed at (Admin only)
+// @Tags App/Tax
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Tax rate id"
+// @Success 204 "Successfully deleted"
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /tax-rates/{id} [delete]
+func (c *TaxController) Delete(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	if err := c.Service.Delete(ctx.Request.Context(), uint(id)); err != nil {
+		return c.fail(ctx, err, "Failed to delete tax rate")
+	}
+
+	ctx.Status(http.StatusNoContent)
+	return nil
+}
+
+// fail writes the error response of a service error
+func (c *TaxController) fail(ctx *router.Context, err error, message string) error {
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   translation.Error(ctx, err),
+			Details: translation.LocalizeValidation(ctx, validationErrors),
+		})
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Item not found"})
+	}
+	return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: message + ": " + err.Error()})
+}
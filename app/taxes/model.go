@@ -0,0 +1,76 @@
+package taxes
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TaxRate is a tax rate in percent for a region and/or a product category. Empty Country,
+// Region and CategoryId match everything; the most specific active rate applies, see
+// TaxService.Rates.
+type TaxRate struct {
+	Id         uint           `json:"id" gorm:"primarykey"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	Name       string         `json:"name" gorm:"size:100"`
+	Country    string         `json:"country" gorm:"size:2;index"` // ISO 3166-1 alpha-2, empty for every country
+	Region     string         `json:"region" gorm:"size:64"`       // State or province of the country, empty for all of them
+	CategoryId *uint          `json:"category_id" gorm:"index"`    // Product category, nil for every product
+	Rate       float64        `json:"rate"`                        // Percent
+	Active     bool           `json:"active"`
+}
+
+// TableName returns the table name for the TaxRate model
+func (m *TaxRate) TableName() string {
+	return "tax_rates"
+}
+
+// specificity ranks how closely the rate targets a location and product: the country
+// weighs most, then the region, then the category
+func (m *TaxRate) specificity() int {
+	score := 0
+	if m.Country != "" {
+		score += 4
+	}
+	if m.Region != "" {
+		score += 2
+	}
+	if m.CategoryId != nil {
+		score++
+	}
+	return score
+}
+
+// CreateTaxRateRequest represents the request payload for creating a TaxRate
+type CreateTaxRateRequest struct {
+	Name       string  `json:"name" validate:"required,max=100"`
+	Country    string  `json:"country" validate:"omitempty,iso3166_1_alpha2"`
+	Region     string  `json:"region" validate:"max=64"`
+	CategoryId *uint   `json:"category_id,omitempty"`
+	Rate       float64 `json:"rate" validate:"gte=0,lte=100"`
+	Active     *bool   `json:"active,omitempty"` // Defaults to true
+}
+
+// UpdateTaxRateRequest represents the request payload for updating a TaxRate. A
+// category_id of 0 removes the category.
+type UpdateTaxRateRequest struct {
+	Name       string   `json:"name,omitempty" validate:"max=100"`
+	Country    *string  `json:"country,omitempty" validate:"omitempty,iso3166_1_alpha2"`
+	Region     *string  `json:"region,omitempty" validate:"omitempty,max=64"`
+	CategoryId *uint    `json:"category_id,omitempty"`
+	Rate       *float64 `json:"rate,omitempty" validate:"omitempty,gte=0,lte=100"`
+	Active     *bool    `json:"active,omitempty"`
+}
+
+// TaxRateFilter narrows the tax rate list
+type TaxRateFilter struct {
+	Country string
+}
+
+// Location is where goods are shipped to, which decides their tax rate
+type Location struct {
+	Country string `json:"country"`
+	Region  string `json:"region,omitempty"`
+}
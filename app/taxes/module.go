@@ -0,0 +1,125 @@
+package taxes
+
+import (
+	"errors"
+
+	"base/core/app/authorization"
+	"base/core/app/reports"
+	"base/core/module"
+	"base/core/router"
+
+	"gorm.io/gorm"
+)
+
+// Module provides the tax rates by country, region and product category that carts and
+// orders are taxed at
+type Module struct {
+	module.DefaultModule
+	DB         *gorm.DB
+	Service    *TaxService
+	Controller *TaxController
+}
+
+// Init creates and initializes the taxes module with all dependencies
+func Init(deps module.Dependencies) module.Module {
+	registerEvents()
+	service := NewTaxService(deps.DB, deps.Emitter, deps.Logger)
+
+	reports.RegisterEntity(reports.Entity{
+		Name:       "tax_rates",
+		Columns:    []string{"id", "name", "country", "region", "category_id", "rate", "active", "created_at"},
+		SoftDelete: true,
+	})
+
+	return &Module{
+		DB:         deps.DB,
+		Service:    service,
+		Controller: NewTaxController(service),
+	}
+}
+
+// Routes registers the admin routes
+func (m *Module) Routes(router *router.RouterGroup) {
+	group := router.Group("", authorization.RequireAdmin(authorization.NewAuthorizationService(m.DB)))
+	m.Controller.Routes(group)
+}
+
+func (m *Module) Init() error {
+	return m.SeedPermissions()
+}
+
+func (m *Module) SeedPermissions() error {
+	// Ensure permissions table exists before seeding
+	if err := m.DB.AutoMigrate(&authorization.Permission{}); err != nil {
+		return err
+	}
+
+	// Define permissions for tax rate operations
+	permissions := []authorization.Permission{
+		{
+			Name:         "tax rate list",
+			Description:  "View tax rate list",
+			ResourceType: "tax_rate",
+			Action:       "list",
+		},
+		{
+			Name:         "tax rate read",
+			Description:  "View tax rates",
+			ResourceType: "tax_rate",
+			Action:       "read",
+		},
+		{
+			Name:         "tax rate create",
+			Description:  "Create tax rates",
+			ResourceType: "tax_rate",
+			Action:       "create",
+		},
+		{
+			Name:         "tax rate update",
+			Description:  "Update tax rates",
+			ResourceType: "tax_rate",
+			Action:       "update",
+		},
+		{
+			Name:         "tax rate delete",
+			Description:  "Delete tax rates",
+			ResourceType: "tax_rate",
+			Action:       "delete",
+		},
+	}
+
+	// Upsert permissions - create or update if they exist
+	for _, permission := range permissions {
+		var existingPermission authorization.Permission
+		result := m.DB.Where("resource_type = ? AND action = ?", permission.ResourceType, permission.Action).First(&existingPermission)
+
+		if result.Error != nil && errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// Create new permission
+			if err := m.DB.Create(&permission).Error; err != nil {
+				return err
+			}
+		} else if result.Error == nil {
+			// Update existing permission
+			existingPermission.Name = permission.Name
+			existingPermission.Description = permission.Description
+			if err := m.DB.Save(&existingPermission).Error; err != nil {
+				return err
+			}
+		} else {
+			// Return any other error
+			return result.Error
+		}
+	}
+
+	return nil
+}
+
+func (m *Module) Migrate() error {
+	return m.DB.AutoMigrate(&TaxRate{})
+}
+
+func (m *Module) GetModels() []any {
+	return []any{
+		&TaxRate{},
+	}
+}
@@ -0,0 +1,291 @@
+package taxes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"base/app/products"
+	"base/core/app/settings"
+	"base/core/emitter"
+	"base/core/logger"
+	"base/core/types"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+const (
+	CreateTaxRateEvent = "tax_rates.create"
+	UpdateTaxRateEvent = "tax_rates.update"
+	DeleteTaxRateEvent = "tax_rates.delete"
+)
+
+// registerEvents adds the events of the module to the event catalog
+func registerEvents() {
+	emitter.RegisterEvents(
+		emitter.EventType{Name: CreateTaxRateEvent, Description: "A tax rate was created", Payload: &TaxRate{}},
+		emitter.EventType{Name: UpdateTaxRateEvent, Description: "A tax rate was updated", Payload: &TaxRate{}},
+		emitter.EventType{Name: DeleteTaxRateEvent, Description: "A tax rate was deleted", Payload: &TaxRate{}},
+	)
+}
+
+// DefaultRateSetting is the setting with the tax rate in percent of products no tax rate
+// matches
+const DefaultRateSetting = "tax_rate"
+
+// TaxService manages the tax rates and resolves the rate of products shipped to a location
+type TaxService struct {
+	DB      *gorm.DB
+	Emitter *emitter.Emitter
+	Logger  logger.Logger
+}
+
+func NewTaxService(db *gorm.DB, emitter *emitter.Emitter, logger logger.Logger) *TaxService {
+	return &TaxService{
+		DB:      db,
+		Emitter: emitter,
+		Logger:  logger,
+	}
+}
+
+// Create creates a tax rate
+func (s *TaxService) Create(ctx context.Context, req *CreateTaxRateRequest) (*TaxRate, error) {
+	if err := ValidateTaxRateCreateRequest(req); err != nil {
+		return nil, err
+	}
+	if err := s.checkCategory(ctx, req.CategoryId); err != nil {
+		return nil, err
+	}
+
+	item := &TaxRate{
+		Name:       req.Name,
+		Country:    req.Country,
+		Region:     req.Region,
+		CategoryId: req.CategoryId,
+		Rate:       req.Rate,
+		Active:     req.Active == nil || *req.Active,
+	}
+	if err := s.DB.WithContext(ctx).Create(item).Error; err != nil {
+		s.Logger.Error("failed to create tax rate", logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	// Emit create event
+	s.Emitter.EmitContext(ctx, CreateTaxRateEvent, item)
+
+	return item, nil
+}
+
+// Update updates a tax rate
+func (s *TaxService) Update(ctx context.Context, id uint, req *UpdateTaxRateRequest) (*TaxRate, error) {
+	item, err := s.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateTaxRateUpdateRequest(req, item); err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" {
+		item.Name = req.Name
+	}
+	if req.Country != nil {
+		item.Country = *req.Country
+	}
+	if req.Region != nil {
+		item.Region = *req.Region
+	}
+	if req.CategoryId != nil {
+		if *req.CategoryId == 0 {
+			item.CategoryId = nil
+		} else {
+			if err := s.checkCategory(ctx, req.CategoryId); err != nil {
+				return nil, err
+			}
+			item.CategoryId = req.CategoryId
+		}
+	}
+	if req.Rate != nil {
+		item.Rate = *req.Rate
+	}
+	if req.Active != nil {
+		item.Active = *req.Active
+	}
+
+	if err := s.DB.WithContext(ctx).Save(item).Error; err != nil {
+		s.Logger.Error("failed to update tax rate",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return nil, err
+	}
+
+	// Emit update event
+	s.Emitter.EmitContext(ctx, UpdateTaxRateEvent, item)
+
+	return item, nil
+}
+
+// Delete deletes a tax rate
+func (s *TaxService) Delete(ctx context.Context, id uint) error {
+	item, err := s.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.DB.WithContext(ctx).Delete(item).Error; err != nil {
+		s.Logger.Error("failed to delete tax rate",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return err
+	}
+
+	// Emit delete event
+	s.Emitter.EmitContext(ctx, DeleteTaxRateEvent, item)
+
+	return nil
+}
+
+// GetById returns a tax rate
+func (s *TaxService) GetById(ctx context.Context, id uint) (*TaxRate, error) {
+	item := &TaxRate{}
+	if err := s.DB.WithContext(ctx).First(item, id).Error; err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// GetAll returns a page of tax rates ordered by country, region and category
+func (s *TaxService) GetAll(ctx context.Context, page *int, limit *int, filter TaxRateFilter) (*types.PaginatedResponse, error) {
+	var items []*TaxRate
+	var total int64
+
+	query := s.DB.WithContext(ctx).Model(&TaxRate{})
+	if filter.Country != "" {
+		query = query.Where("country = ?", strings.ToUpper(filter.Country))
+	}
+
+	// Set default values if nil
+	defaultPage := 1
+	defaultLimit := 10
+	if page == nil {
+		page = &defaultPage
+	}
+	if limit == nil {
+		limit = &defaultLimit
+	}
+
+	// Get total count
+	if err := query.Count(&total).Error; err != nil {
+		s.Logger.Error("failed to count tax rates",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	offset := (*page - 1) * *limit
+	if err := query.Order("country, region, category_id, id").Offset(offset).Limit(*limit).Find(&items).Error; err != nil {
+		s.Logger.Error("failed to get tax rates",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	// Calculate total pages
+	totalPages := int(math.Ceil(float64(total) / float64(*limit)))
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	return &types.PaginatedResponse{
+		Data: items,
+		Pagination: types.Pagination{
+			Total:      int(total),
+			Page:       *page,
+			PageSize:   *limit,
+			TotalPages: totalPages,
+		},
+	}, nil
+}
+
+// Rates returns the tax rate in percent of each product shipped to a location. Of the
+// active rates whose country, region and category match, the most specific applies: one
+// for the country beats one for a category anywhere, and one for the country and the
+// category beats both. Ties go to the oldest rate. Products no rate matches get the
+// tax_rate setting.
+func (s *TaxService) Rates(ctx context.Context, location Location, productIds []uint) (map[uint]float64, error) {
+	rates := make(map[uint]float64, len(productIds))
+	if len(productIds) == 0 {
+		return rates, nil
+	}
+
+	country := strings.ToUpper(location.Country)
+	var candidates []*TaxRate
+	if err := s.DB.WithContext(ctx).Where("active = ? AND (country = '' OR country = ?)", true, country).
+		Order("id").Find(&candidates).Error; err != nil {
+		s.Logger.Error("failed to get tax rates", logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	var links []struct {
+		ProductId  uint
+		CategoryId uint
+	}
+	if err := s.DB.WithContext(ctx).Table("product_category_links").Select("product_id, category_id").
+		Where("product_id IN ?", productIds).Scan(&links).Error; err != nil {
+		return nil, err
+	}
+	categories := make(map[uint][]uint)
+	for _, link := range links {
+		categories[link.ProductId] = append(categories[link.ProductId], link.CategoryId)
+	}
+
+	fallback := s.DefaultRate(ctx)
+	for _, productId := range productIds {
+		var best *TaxRate
+		for _, rate := range candidates {
+			if rate.Region != "" && !strings.EqualFold(rate.Region, location.Region) {
+				continue
+			}
+			if rate.CategoryId != nil && !slices.Contains(categories[productId], *rate.CategoryId) {
+				continue
+			}
+			if best == nil || rate.specificity() > best.specificity() {
+				best = rate
+			}
+		}
+		if best != nil {
+			rates[productId] = best.Rate
+		} else {
+			rates[productId] = fallback
+		}
+	}
+	return rates, nil
+}
+
+// DefaultRate returns the tax_rate setting, the rate in percent of products no tax rate
+// matches
+func (s *TaxService) DefaultRate(ctx context.Context) float64 {
+	var setting settings.Settings
+	if err := s.DB.WithContext(ctx).Where("setting_key = ?", DefaultRateSetting).First(&setting).Error; err != nil {
+		return 0
+	}
+	return setting.ValueFloat
+}
+
+// checkCategory checks that the category of a rate exists
+func (s *TaxService) checkCategory(ctx context.Context, categoryId *uint) error {
+	if categoryId == nil {
+		return nil
+	}
+	err := s.DB.WithContext(ctx).First(&products.Category{}, *categoryId).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return validator.ValidationErrors{{
+			Field:   "category_id",
+			Tag:     "exists",
+			Value:   fmt.Sprint(*categoryId),
+			Message: fmt.Sprintf("category %d does not exist", *categoryId),
+		}}
+	}
+	return err
+}
@@ -0,0 +1,80 @@
+package taxes
+
+import (
+	"strings"
+
+	"base/core/validator"
+)
+
+// Global validator instance using Base core validator wrapper
+var validate = validator.New()
+
+// ValidateTaxRateCreateRequest validates the create request
+func ValidateTaxRateCreateRequest(req *CreateTaxRateRequest) error {
+	if req == nil {
+		return nilRequest()
+	}
+
+	req.Country = strings.ToUpper(strings.TrimSpace(req.Country))
+	req.Region = strings.TrimSpace(req.Region)
+	errs := validate.Validate(req)
+	errs = append(errs, validateRegion(req.Country, req.Region)...)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateTaxRateUpdateRequest validates the update request against the rate it changes
+func ValidateTaxRateUpdateRequest(req *UpdateTaxRateRequest, item *TaxRate) error {
+	if req == nil {
+		return nilRequest()
+	}
+
+	country, region := item.Country, item.Region
+	if req.Country != nil {
+		*req.Country = strings.ToUpper(strings.TrimSpace(*req.Country))
+		country = *req.Country
+	}
+	if req.Region != nil {
+		*req.Region = strings.TrimSpace(*req.Region)
+		region = *req.Region
+	}
+
+	// An empty country clears it, so it is checked apart from the struct tags
+	countryValue := req.Country
+	if countryValue != nil && *countryValue == "" {
+		req.Country = nil
+	}
+	errs := validate.Validate(req)
+	req.Country = countryValue
+	errs = append(errs, validateRegion(country, region)...)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateRegion checks that a region comes with its country
+func validateRegion(country, region string) validator.ValidationErrors {
+	if region != "" && country == "" {
+		return validator.ValidationErrors{{
+			Field:   "country",
+			Tag:     "required_with",
+			Param:   "region",
+			Message: "country is required with a region",
+		}}
+	}
+	return nil
+}
+
+func nilRequest() validator.ValidationErrors {
+	return validator.ValidationErrors{
+		{
+			Field:   "request",
+			Tag:     "required",
+			Value:   "nil",
+			Message: "request cannot be nil",
+		},
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+)
+
+// Command is a CLI subcommand that runs instead of the HTTP server
+type Command struct {
+	Name        string
+	Usage       string
+	Description string
+	Run         func(app *App, args []string) error
+}
+
+// commands stores all registered CLI commands. The key is the command name.
+var commands = make(map[string]Command)
+
+// registerCommand registers a CLI command; it should be called from an init() function
+func registerCommand(cmd Command) {
+	commands[cmd.Name] = cmd
+}
+
+func init() {
+	registerCommand(Command{
+		Name:        "help",
+		Usage:       "help",
+		Description: "List available commands",
+		Run: func(app *App, args []string) error {
+			printCommands()
+			return nil
+		},
+	})
+}
+
+// RunCommand runs the named CLI command
+func (app *App) RunCommand(name string, args []string) error {
+	cmd, exists := commands[name]
+	if !exists {
+		printCommands()
+		return fmt.Errorf("unknown command: %s", name)
+	}
+	return cmd.Run(app, args)
+}
+
+// bootstrap initializes the parts of the application commands need, without starting the server
+func (app *App) bootstrap() *App {
+	return app.
+		loadEnvironment().
+		initConfig().
+		initLogger().
+		initDatabase()
+}
+
+// printCommands prints the available commands
+func printCommands() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Println("Usage: base <command> [arguments]")
+	fmt.Println()
+	fmt.Println("Commands:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\t%s\n", commands[name].Usage, commands[name].Description)
+	}
+	w.Flush()
+	fmt.Println()
+	fmt.Println("Run without a command to start the server.")
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"base/core/openapi"
+	"base/core/openapi/clientgen"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:        "client",
+		Usage:       "client [--spec file] [--out dir] [--package name]",
+		Description: "Generate typed Go and TypeScript API clients from the OpenAPI document",
+		Run:         runClient,
+	})
+}
+
+// runClient writes the clients of the document: <out>/go/client.go and
+// <out>/typescript/client.ts. Without --spec the document of the registered routes is used.
+func runClient(app *App, args []string) error {
+	flags := flag.NewFlagSet("client", flag.ContinueOnError)
+	spec := flags.String("spec", "", "OpenAPI document written by the openapi command")
+	out := flags.String("out", "swagger/clients", "output directory")
+	pkg := flags.String("package", "client", "name of the Go package")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var doc *openapi.Document
+	if *spec != "" {
+		loaded, err := openapi.Load(*spec)
+		if err != nil {
+			return err
+		}
+		doc = loaded
+	} else {
+		if err := app.buildAPIDocs(); err != nil {
+			return err
+		}
+		doc = app.apiDocs
+	}
+
+	goClient, err := clientgen.Go(doc, *pkg)
+	if err != nil {
+		return err
+	}
+	tsClient, err := clientgen.TypeScript(doc)
+	if err != nil {
+		return err
+	}
+
+	files := map[string][]byte{
+		filepath.Join(*out, "go", "client.go"):         goClient,
+		filepath.Join(*out, "typescript", "client.ts"): tsClient,
+	}
+	for path, data := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return err
+		}
+		fmt.Printf("Created  %s\n", path)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"base/core/config"
+	"fmt"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:        "config",
+		Usage:       "config check",
+		Description: "Validate the environment configuration and list every problem",
+		Run:         runConfig,
+	})
+}
+
+func runConfig(app *App, args []string) error {
+	if len(args) == 0 || args[0] != "check" {
+		return fmt.Errorf("usage: config check")
+	}
+
+	app.loadEnvironment()
+	cfg := config.NewConfig()
+
+	errs := cfg.Validate()
+	if len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Printf("  - %v\n", err)
+		}
+		return fmt.Errorf("%d configuration problem(s) found", len(errs))
+	}
+
+	fmt.Printf("Configuration OK (env: %s, database: %s, storage: %s, email: %s)\n",
+		cfg.Env, cfg.DBDriver, cfg.StorageProvider, cfg.EmailProvider)
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"base/core/database"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:        "copy-data",
+		Usage:       "copy-data --to-driver=postgres --to-url=DSN [--batch=500] [--truncate]",
+		Description: "Copy all data from the configured database into another database",
+		Run:         runCopyData,
+	})
+}
+
+func runCopyData(app *App, args []string) error {
+	flags := flag.NewFlagSet("copy-data", flag.ContinueOnError)
+	toDriver := flags.String("to-driver", "postgres", "target database driver (sqlite, mysql, postgres)")
+	toURL := flags.String("to-url", os.Getenv("TARGET_DB_URL"), "target database DSN (default: $TARGET_DB_URL)")
+	batch := flags.Int("batch", 500, "rows per batch")
+	truncate := flags.Bool("truncate", false, "empty target tables before copying")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *toURL == "" {
+		return fmt.Errorf("a target database is required: pass --to-url or set TARGET_DB_URL")
+	}
+
+	app.bootstrap()
+
+	target, err := database.Open(*toDriver, *toURL)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Copying %s -> %s\n", app.config.DBDriver, *toDriver)
+	err = database.CopyData(app.db.DB, target, database.CopyOptions{
+		BatchSize: *batch,
+		Truncate:  *truncate,
+		Progress: func(table string, copied, total int64) {
+			fmt.Printf("\r  %-40s %d/%d", table, copied, total)
+			if copied == total {
+				fmt.Println()
+			}
+		},
+		Skipped: func(table string) {
+			fmt.Printf("  %-40s skipped (missing in target)\n", table)
+		},
+	})
+	if err != nil {
+		fmt.Println()
+		return err
+	}
+
+	fmt.Println("Done")
+	return nil
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"base/core/config"
+	"base/core/database"
+	"base/core/email"
+	"base/core/router"
+	"base/core/storage"
+)
+
+// authorizationPackage prefixes the names of the permission and role middleware
+const authorizationPackage = "base/core/app/authorization."
+
+func init() {
+	registerCommand(Command{
+		Name:        "doctor",
+		Usage:       "doctor [--email=ADDRESS]",
+		Description: "Check the configuration, database, storage, email and ffmpeg, and list routes without authorization",
+		Run:         runDoctor,
+	})
+}
+
+// doctor prints the result of each check and counts the failures
+type doctor struct {
+	failed int
+}
+
+func (d *doctor) ok(check, detail string) {
+	fmt.Printf("  \033[32mok\033[0m    %-14s %s\n", check, detail)
+}
+
+func (d *doctor) warn(check, detail string) {
+	fmt.Printf("  \033[33mwarn\033[0m  %-14s %s\n", check, detail)
+}
+
+func (d *doctor) fail(check string, err error) {
+	d.failed++
+	fmt.Printf("  \033[31mfail\033[0m  %-14s %v\n", check, err)
+}
+
+// runDoctor checks what the server needs at runtime without starting it. Checks that depend
+// on a failed one are skipped.
+func runDoctor(app *App, args []string) error {
+	flags := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	to := flags.String("email", "", "send a test email to this address")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	d := &doctor{}
+	fmt.Println()
+
+	app.loadEnvironment()
+	app.config = config.NewConfig()
+	if errs := app.config.Validate(); len(errs) > 0 {
+		for _, err := range errs {
+			d.fail("config", err)
+		}
+		return fmt.Errorf("%d configuration problem(s) found; fix them before the other checks", len(errs))
+	}
+	d.ok("config", fmt.Sprintf("env %s", app.config.Env))
+	app.initLogger()
+
+	if err := d.checkDatabase(app); err != nil {
+		d.fail("database", err)
+		return fmt.Errorf("%d check(s) failed", d.failed)
+	}
+
+	storageOK := d.checkStorage(app)
+	d.checkEmail(app, *to)
+	d.checkFFmpeg()
+
+	if storageOK {
+		d.checkRoutes(app)
+	} else {
+		d.warn("routes", "skipped, the modules need storage")
+	}
+
+	fmt.Println()
+	if d.failed > 0 {
+		return fmt.Errorf("%d check(s) failed", d.failed)
+	}
+	fmt.Println("All checks passed")
+	return nil
+}
+
+// checkDatabase connects and pings the database
+func (d *doctor) checkDatabase(app *App) error {
+	db, err := database.InitDB(app.config)
+	if err != nil {
+		return err
+	}
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return err
+	}
+	app.db = db
+	d.ok("database", fmt.Sprintf("%s, ping %s", app.config.DBDriver, time.Since(start).Round(time.Microsecond)))
+
+	if pending, err := database.NewMigrator(db.DB).Pending(); err != nil {
+		d.warn("migrations", err.Error())
+	} else if len(pending) > 0 {
+		d.warn("migrations", fmt.Sprintf("%d pending, run the migrate command", len(pending)))
+	}
+	return nil
+}
+
+// checkStorage uploads and deletes a small file with the configured provider
+func (d *doctor) checkStorage(app *App) bool {
+	activeStorage, err := storage.NewActiveStorage(app.db.DB, app.storageConfig())
+	if err != nil {
+		d.fail("storage", err)
+		return false
+	}
+	provider := activeStorage.GetProvider()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	result, err := provider.UploadBytes(ctx, []byte("base doctor\n"), "doctor.txt", storage.UploadConfig{
+		AllowedExtensions: []string{".txt"},
+		MaxFileSize:       1024,
+		UploadPath:        "doctor",
+	})
+	if err != nil {
+		d.fail("storage", fmt.Errorf("test upload to %s failed: %w", app.config.StorageProvider, err))
+		return false
+	}
+	if err := provider.Delete(ctx, result.Path); err != nil {
+		d.fail("storage", fmt.Errorf("deleting the test upload %s failed: %w", result.Path, err))
+		return false
+	}
+	d.ok("storage", fmt.Sprintf("%s, test upload written and deleted", app.config.StorageProvider))
+	return true
+}
+
+// checkEmail creates the configured sender and, given an address, sends a test email
+func (d *doctor) checkEmail(app *App, to string) {
+	sender, err := email.NewSender(app.config)
+	if err != nil {
+		d.fail("email", err)
+		return
+	}
+	if to == "" {
+		d.ok("email", fmt.Sprintf("%s configured, pass --email=ADDRESS to send a test email", app.config.EmailProvider))
+		return
+	}
+	err = sender.Send(email.Message{
+		To:      []string{to},
+		From:    app.config.EmailFromAddress,
+		Subject: "Test email",
+		Body:    "This email was sent by the doctor command to check the email configuration.",
+	})
+	if err != nil {
+		d.fail("email", fmt.Errorf("test email to %s failed: %w", to, err))
+		return
+	}
+	d.ok("email", fmt.Sprintf("%s, test email sent to %s", app.config.EmailProvider, to))
+}
+
+// checkFFmpeg looks for the ffmpeg binary the audio and video conversion use
+func (d *doctor) checkFFmpeg() {
+	path, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		d.warn("ffmpeg", "not found, audio and video uploads are stored unconverted")
+		return
+	}
+	d.ok("ffmpeg", path)
+}
+
+// checkRoutes registers the routes like the server does and lists the API routes that
+// aren't authenticated, or are authenticated without a permission or role check
+func (d *doctor) checkRoutes(app *App) {
+	app.initInfrastructure().initRouter().autoDiscoverModules().setupRoutes()
+
+	var public, unchecked []string
+	total := 0
+	for _, route := range app.router.Routes() {
+		if route.Method == http.MethodOptions || !strings.HasPrefix(route.Path, "/api/") {
+			continue
+		}
+		total++
+		name := fmt.Sprintf("%-6s %s", route.Method, route.Path)
+		switch {
+		case !app.config.Middleware.IsAuthRequired(route.Path):
+			public = append(public, name)
+		case !hasAuthorization(route):
+			unchecked = append(unchecked, name)
+		}
+	}
+
+	d.ok("routes", fmt.Sprintf("%d API routes, %d public, %d without a permission or role check",
+		total, len(public), len(unchecked)))
+	printRoutes("Public routes (no authentication)", public)
+	printRoutes("Authenticated routes without a permission or role check", unchecked)
+}
+
+// hasAuthorization reports whether a route uses one of the authorization middleware
+func hasAuthorization(route router.Route) bool {
+	for _, name := range route.Middleware {
+		if strings.HasPrefix(name, authorizationPackage) {
+			return true
+		}
+	}
+	return false
+}
+
+// printRoutes prints a titled list of routes, if there are any
+func printRoutes(title string, routes []string) {
+	if len(routes) == 0 {
+		return
+	}
+	fmt.Printf("\n  %s (%d):\n", title, len(routes))
+	for _, route := range routes {
+		fmt.Printf("    %s\n", route)
+	}
+}
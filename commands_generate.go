@@ -0,0 +1,72 @@
+package main
+
+import (
+	"base/core/generator"
+	"flag"
+	"fmt"
+	"path/filepath"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:        "generate",
+		Usage:       "generate module <name> [field:type ...] [--force] [--no-register]",
+		Description: "Generate a CRUD module in app/ (types: string, text, int, uint, float, bool, time)",
+		Run:         runGenerate,
+	})
+}
+
+func runGenerate(app *App, args []string) error {
+	if len(args) < 2 || args[0] != "module" {
+		return fmt.Errorf("usage: generate module <name> [field:type ...] [--force] [--no-register]")
+	}
+
+	flags := flag.NewFlagSet("generate", flag.ContinueOnError)
+	force := flags.Bool("force", false, "overwrite existing files")
+	noRegister := flags.Bool("no-register", false, "don't add the module to app/init.go")
+
+	// Flags may follow the field list
+	var positional []string
+	rest := args[1:]
+	for len(rest) > 0 {
+		if err := flags.Parse(rest); err != nil {
+			return err
+		}
+		rest = flags.Args()
+		if len(rest) > 0 {
+			positional = append(positional, rest[0])
+			rest = rest[1:]
+		}
+	}
+
+	if len(positional) == 0 {
+		return fmt.Errorf("a module name is required")
+	}
+
+	spec, err := generator.NewModuleSpec(positional[0], positional[1:])
+	if err != nil {
+		return err
+	}
+
+	written, err := spec.Generate("app", *force)
+	for _, path := range written {
+		fmt.Printf("Created  %s\n", path)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *noRegister {
+		fmt.Printf("\nRegister the module in app/init.go:\n\tmodules[%q] = %s.Init(deps.ForModule(%q))\n", spec.Table, spec.Package, spec.Table)
+		return nil
+	}
+
+	registered, err := spec.Register(filepath.Join("app", "init.go"), "base/app/"+spec.Package)
+	if err != nil {
+		return err
+	}
+	if registered {
+		fmt.Println("Updated  app/init.go")
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"base/core/database"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:        "migrate",
+		Usage:       "migrate",
+		Description: "Apply pending versioned migrations",
+		Run:         runMigrate,
+	})
+	registerCommand(Command{
+		Name:        "rollback",
+		Usage:       "rollback [--steps=N]",
+		Description: "Revert the last migration batch, or the last N migrations",
+		Run:         runRollback,
+	})
+	registerCommand(Command{
+		Name:        "status",
+		Usage:       "status",
+		Description: "Show which migrations have been applied",
+		Run:         runMigrationStatus,
+	})
+}
+
+func runMigrate(app *App, args []string) error {
+	app.bootstrap()
+
+	applied, err := database.NewMigrator(app.db.DB).Migrate()
+	for _, m := range applied {
+		fmt.Printf("Applied  %s_%s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(applied) == 0 {
+		fmt.Println("Nothing to migrate")
+	}
+	return nil
+}
+
+func runRollback(app *App, args []string) error {
+	flags := flag.NewFlagSet("rollback", flag.ContinueOnError)
+	steps := flags.Int("steps", 0, "number of migrations to revert (default: the last batch)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	app.bootstrap()
+
+	reverted, err := database.NewMigrator(app.db.DB).Rollback(*steps)
+	for _, m := range reverted {
+		fmt.Printf("Reverted %s_%s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(reverted) == 0 {
+		fmt.Println("Nothing to roll back")
+	}
+	return nil
+}
+
+func runMigrationStatus(app *App, args []string) error {
+	app.bootstrap()
+
+	statuses, err := database.NewMigrator(app.db.DB).Status()
+	if err != nil {
+		return err
+	}
+
+	if len(statuses) == 0 {
+		fmt.Println("No migrations registered")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tBATCH\tAPPLIED AT")
+	for _, s := range statuses {
+		if s.Applied {
+			fmt.Fprintf(w, "%s\t%s\tapplied\t%d\t%s\n", s.Version, s.Name, s.Batch, s.AppliedAt.Format("2006-01-02 15:04:05"))
+		} else {
+			fmt.Fprintf(w, "%s\t%s\tpending\t-\t-\n", s.Version, s.Name)
+		}
+	}
+	return w.Flush()
+}
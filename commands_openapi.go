@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"base/core/openapi"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:        "openapi",
+		Usage:       "openapi [output] [--check]",
+		Description: "Write the OpenAPI document of the registered routes (default " + openapi.DefaultDocument + ")",
+		Run:         runOpenAPI,
+	})
+}
+
+// runOpenAPI registers the routes like the server does and writes their document. With
+// --check it fails when routes and annotations don't match, e.g. in CI.
+func runOpenAPI(app *App, args []string) error {
+	flags := flag.NewFlagSet("openapi", flag.ContinueOnError)
+	check := flags.Bool("check", false, "fail when routes lack annotations or annotations lack routes")
+	output := openapi.DefaultDocument
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		output, args = args[0], args[1:]
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if err := app.buildAPIDocs(); err != nil {
+		return err
+	}
+
+	var undocumented []string
+	for path, item := range app.apiDocs.Paths {
+		for method, operation := range item {
+			if operation.Undocumented {
+				undocumented = append(undocumented, fmt.Sprintf("%s %s", method, path))
+			}
+		}
+	}
+	annotations, err := openapi.LoadAnnotations(".", output)
+	if err != nil {
+		return err
+	}
+	unregistered := openapi.Unregistered(app.router.Routes(), annotations)
+
+	data, err := json.MarshalIndent(app.apiDocs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(output, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s (%d paths, %d schemas)\n", output, len(app.apiDocs.Paths), len(app.apiDocs.Components.Schemas))
+
+	if len(undocumented) > 0 {
+		fmt.Printf("\nRoutes without annotations (%d):\n", len(undocumented))
+		sort.Strings(undocumented)
+		for _, route := range undocumented {
+			fmt.Printf("  %s\n", route)
+		}
+	}
+	if len(unregistered) > 0 {
+		fmt.Printf("\nAnnotations without a registered route (%d):\n", len(unregistered))
+		for _, route := range unregistered {
+			fmt.Printf("  %s\n", route)
+		}
+	}
+	if *check && len(undocumented)+len(unregistered) > 0 {
+		return fmt.Errorf("the routes and their annotations don't match")
+	}
+	return nil
+}
+
+// buildAPIDocs registers the routes like the server does and assembles their document
+func (app *App) buildAPIDocs() error {
+	app.bootstrap()
+	// The document covers the docs routes even where the server doesn't serve them
+	app.config.SwaggerEnabled = true
+	app.initInfrastructure().initRouter().autoDiscoverModules().setupRoutes().setupDocs()
+	if app.apiDocs == nil {
+		return fmt.Errorf("failed to build the OpenAPI document")
+	}
+	return nil
+}
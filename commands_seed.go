@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"base/core/app/activities"
+	"base/core/app/media"
+	"base/core/app/notifications"
+	"base/core/app/users"
+	"base/core/factory"
+
+	"gorm.io/gorm"
+)
+
+// demoRoles are given to the demo users in turn
+var demoRoles = []string{"Manager", "Employee", "Viewer"}
+
+func init() {
+	registerCommand(Command{
+		Name:        "seed",
+		Usage:       "seed --demo [--users=N] [--email=EMAIL]",
+		Description: "Fill the database with demo users, activities, notifications and media",
+		Run:         runSeed,
+	})
+}
+
+// runSeed creates the demo data with the model factories, in one transaction
+func runSeed(app *App, args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	demo := flags.Bool("demo", false, "create demo data")
+	count := flags.Int("users", 10, "number of demo users besides the admin")
+	email := flags.String("email", "demo@example.com", "email address of the demo admin")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if !*demo {
+		return fmt.Errorf("usage: seed --demo [--users=N] [--email=EMAIL]")
+	}
+
+	app.bootstrap()
+	if err := app.migrateUsers(); err != nil {
+		return err
+	}
+	db := app.db.DB
+	if err := db.AutoMigrate(&media.Media{}, &activities.Activity{}, &notifications.Notification{}); err != nil {
+		return err
+	}
+
+	var existing int64
+	if err := db.Model(&users.User{}).Where("email = ?", *email).Count(&existing).Error; err != nil {
+		return err
+	}
+	if existing > 0 {
+		return fmt.Errorf("the demo data already exists (a user with the email %s exists)", *email)
+	}
+
+	password, _, err := choosePassword("")
+	if err != nil {
+		return err
+	}
+
+	var created struct{ users, activities, notifications, media int }
+	err = db.Transaction(func(tx *gorm.DB) error {
+		admin, err := factory.User().
+			WithEmail(*email).
+			WithPassword(password).
+			WithRole("Super Admin").
+			With(func(u *users.User) { u.FirstName, u.LastName, u.Username = "Demo", "Admin", "demo" }).
+			Create(tx)
+		if err != nil {
+			return err
+		}
+		people := []*users.User{admin}
+		for i := range *count {
+			user, err := factory.User().WithRole(demoRoles[i%len(demoRoles)]).Create(tx)
+			if err != nil {
+				return err
+			}
+			people = append(people, user)
+		}
+		created.users = len(people)
+
+		for _, user := range people {
+			items, err := factory.Activity().ForUser(user).CreateMany(tx, 5)
+			if err != nil {
+				return err
+			}
+			created.activities += len(items)
+
+			unread, err := factory.Notification().ForUser(user).CreateMany(tx, 2)
+			if err != nil {
+				return err
+			}
+			read, err := factory.Notification().ForUser(user).Read().CreateMany(tx, 1)
+			if err != nil {
+				return err
+			}
+			created.notifications += len(unread) + len(read)
+		}
+
+		for _, name := range []string{"Products", "Marketing"} {
+			folder, err := factory.Folder().With(func(m *media.Media) { m.Name, m.Folder = name, name }).Create(tx)
+			if err != nil {
+				return err
+			}
+			images, err := factory.Media().In(folder).ByAuthor(admin).CreateMany(tx, 4)
+			if err != nil {
+				return err
+			}
+			created.media += 1 + len(images)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Created  %d users, %d activities, %d notifications and %d media items\n",
+		created.users, created.activities, created.notifications, created.media)
+	fmt.Printf("Log in as %s; the other users have the password %s\n", *email, factory.DefaultPassword)
+	printPassword(password, true)
+	return nil
+}
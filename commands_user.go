@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"base/core/app/activities"
+	"base/core/app/authorization"
+	"base/core/app/users"
+	"base/core/emitter"
+	"base/core/storage"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// minPasswordLength matches the validation of the users API
+const minPasswordLength = 8
+
+func init() {
+	registerCommand(Command{
+		Name:        "user",
+		Usage:       "user create-admin|reset-password|assign-role --email=EMAIL [...]",
+		Description: "Create the first admin or recover a locked-out one without the API",
+		Run:         runUser,
+	})
+}
+
+// userCommands are the subcommands of the user command
+var userCommands = map[string]func(app *App, args []string) error{
+	"create-admin":   runCreateAdmin,
+	"reset-password": runResetPassword,
+	"assign-role":    runAssignRole,
+}
+
+func runUser(app *App, args []string) error {
+	if len(args) == 0 || userCommands[args[0]] == nil {
+		return fmt.Errorf(`usage:
+  user create-admin --email=EMAIL [--password=PASSWORD] [--username=NAME] [--first-name=NAME] [--last-name=NAME] [--role="Super Admin"]
+  user reset-password --email=EMAIL [--password=PASSWORD]
+  user assign-role --email=EMAIL --role=ROLE`)
+	}
+	return userCommands[args[0]](app, args[1:])
+}
+
+func runCreateAdmin(app *App, args []string) error {
+	flags := flag.NewFlagSet("user create-admin", flag.ContinueOnError)
+	email := flags.String("email", "", "email address, used to log in")
+	password := flags.String("password", "", "password (default: a generated one, printed once)")
+	username := flags.String("username", "", "username (default: the part of the email before @)")
+	firstName := flags.String("first-name", "Super", "first name")
+	lastName := flags.String("last-name", "Admin", "last name")
+	roleName := flags.String("role", "Super Admin", "role name or id")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" || !strings.Contains(*email, "@") {
+		return fmt.Errorf("a valid --email is required")
+	}
+	if *username == "" {
+		*username, _, _ = strings.Cut(*email, "@")
+	}
+
+	app.bootstrap()
+	db := app.db.DB
+	if err := app.migrateUsers(); err != nil {
+		return err
+	}
+
+	role, err := findRole(db, *roleName)
+	if err != nil {
+		return err
+	}
+	var existing int64
+	if err := db.Model(&users.User{}).Where("email = ? OR username = ?", *email, *username).Count(&existing).Error; err != nil {
+		return err
+	}
+	if existing > 0 {
+		return fmt.Errorf("a user with the email %s or username %s already exists; use reset-password or assign-role", *email, *username)
+	}
+
+	plain, generated, err := choosePassword(*password)
+	if err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(plain), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	service, err := app.userService()
+	if err != nil {
+		return err
+	}
+
+	// The user starts with the default role like one created through the API, then gets the
+	// role the way the API assigns it, so the assignment is recorded
+	user := users.User{
+		FirstName: *firstName,
+		LastName:  *lastName,
+		Username:  *username,
+		Email:     *email,
+		Password:  string(hash),
+	}
+	if err := db.Create(&user).Error; err != nil {
+		return err
+	}
+	if _, err := service.ChangeRole(context.Background(), user.Id, role.Id, &users.RoleChange{}); err != nil {
+		db.Unscoped().Delete(&user)
+		return err
+	}
+
+	fmt.Printf("Created  user %d (%s) with the role %s\n", user.Id, user.Email, role.Name)
+	printPassword(plain, generated)
+	return nil
+}
+
+func runResetPassword(app *App, args []string) error {
+	flags := flag.NewFlagSet("user reset-password", flag.ContinueOnError)
+	email := flags.String("email", "", "email address of the user")
+	password := flags.String("password", "", "new password (default: a generated one, printed once)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	app.bootstrap()
+	user, err := findUser(app.db.DB, *email)
+	if err != nil {
+		return err
+	}
+
+	plain, generated, err := choosePassword(*password)
+	if err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(plain), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := app.db.DB.Model(user).Update("password", string(hash)).Error; err != nil {
+		return err
+	}
+
+	fmt.Printf("Updated  password of user %d (%s)\n", user.Id, user.Email)
+	printPassword(plain, generated)
+	return nil
+}
+
+func runAssignRole(app *App, args []string) error {
+	flags := flag.NewFlagSet("user assign-role", flag.ContinueOnError)
+	email := flags.String("email", "", "email address of the user")
+	roleName := flags.String("role", "", `role name or id, e.g. "Super Admin"`)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *roleName == "" {
+		return fmt.Errorf("--role is required")
+	}
+
+	app.bootstrap()
+	user, err := findUser(app.db.DB, *email)
+	if err != nil {
+		return err
+	}
+	role, err := findRole(app.db.DB, *roleName)
+	if err != nil {
+		return err
+	}
+	service, err := app.userService()
+	if err != nil {
+		return err
+	}
+	if _, err := service.ChangeRole(context.Background(), user.Id, role.Id, &users.RoleChange{}); err != nil {
+		return err
+	}
+
+	fmt.Printf("Assigned role %s to user %d (%s)\n", role.Name, user.Id, user.Email)
+	return nil
+}
+
+// migrateUsers creates the role, user and activity tables and the default roles when the
+// server hasn't run against the database yet. The default user isn't seeded.
+func (app *App) migrateUsers() error {
+	if err := authorization.NewAuthorizationModule(app.db.DB, nil, app.logger, nil).Migrate(); err != nil {
+		return err
+	}
+	return app.db.DB.AutoMigrate(&users.User{}, &activities.Activity{})
+}
+
+// userService returns a users service whose role changes, made by the command line, are
+// recorded as activities like those made through the API
+func (app *App) userService() (*users.UserService, error) {
+	activeStorage, err := storage.NewActiveStorage(app.db.DB, app.storageConfig())
+	if err != nil {
+		return nil, err
+	}
+	events := emitter.New()
+	activities.NewActivityService(app.db.DB, events, activeStorage, app.logger).Listen()
+	return users.NewUserService(app.db.DB, nil, events, activeStorage, app.logger), nil
+}
+
+// findUser returns the user with an email address
+func findUser(db *gorm.DB, email string) (*users.User, error) {
+	if email == "" {
+		return nil, fmt.Errorf("--email is required")
+	}
+	var user users.User
+	if err := db.Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("no user with the email %s", email)
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// findRole returns a role by id or, ignoring case, name
+func findRole(db *gorm.DB, nameOrId string) (*authorization.Role, error) {
+	var role authorization.Role
+	query := db.Where("LOWER(name) = ?", strings.ToLower(nameOrId))
+	if id, err := strconv.ParseUint(nameOrId, 10, 64); err == nil {
+		query = db.Where("id = ?", id)
+	}
+	if err := query.First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			var names []string
+			db.Model(&authorization.Role{}).Order("id").Pluck("name", &names)
+			return nil, fmt.Errorf("no role %q; roles: %s", nameOrId, strings.Join(names, ", "))
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+// choosePassword returns the given password, or a generated one when it is empty
+func choosePassword(password string) (string, bool, error) {
+	if password != "" {
+		if len(password) < minPasswordLength {
+			return "", false, fmt.Errorf("the password must have at least %d characters", minPasswordLength)
+		}
+		return password, false, nil
+	}
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", false, err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), true, nil
+}
+
+// printPassword prints a generated password; it isn't stored anywhere else
+func printPassword(password string, generated bool) {
+	if generated {
+		fmt.Printf("Password: %s (shown once, change it after logging in)\n", password)
+	}
+}
@@ -0,0 +1,223 @@
+package activities
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"base/core/emitter"
+	"base/core/logger"
+	"base/core/storage"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// ArchiveActivitiesEvent is emitted with the ArchiveResult after every archive run that
+	// moved rows
+	ArchiveActivitiesEvent = "activities.archive"
+
+	archiveBatchSize = 1000
+	archiveInterval  = 24 * time.Hour
+	archiveExportDir = "archives/activities"
+)
+
+// registerEvents adds the events of the module to the event catalog
+func registerEvents() {
+	emitter.RegisterEvents(
+		emitter.EventType{Name: ArchiveActivitiesEvent, Description: "Old activities were archived", Payload: &ArchiveResult{}},
+	)
+}
+
+// ArchivedActivity is an activity moved out of the activities table by the archive job. The
+// activities table only keeps recent rows, so that lists and filters stay fast; older ones
+// live in activities_archive with the same columns and ids.
+type ArchivedActivity Activity
+
+// TableName returns the table name for the ArchivedActivity model
+func (m *ArchivedActivity) TableName() string {
+	return "activities_archive"
+}
+
+// ArchiveOptions configures the archive job
+type ArchiveOptions struct {
+	// After is the age at which activities are archived; 0 disables the job
+	After time.Duration
+
+	// Export also writes every archived batch to the storage provider as gzipped NDJSON
+	Export bool
+}
+
+// ArchiveResult summarizes an archive run
+type ArchiveResult struct {
+	Before   time.Time `json:"before"`   // Activities created before this time were archived
+	Archived int64     `json:"archived"` // Number of activities moved to activities_archive
+	Exports  []string  `json:"exports"`  // Storage paths of the exported batches
+}
+
+// archiver runs the archive job in the background
+type archiver struct {
+	options ArchiveOptions
+	once    sync.Once
+	mu      sync.Mutex // Serializes runs
+}
+
+// StartArchiving archives activities older than options.After once a day, in the background.
+// It does nothing when After is 0 and can be called more than once.
+func (s *ActivityService) StartArchiving(options ArchiveOptions) {
+	s.archiver.options = options
+	if options.After <= 0 {
+		return
+	}
+	s.archiver.once.Do(func() {
+		go func() {
+			ticker := time.NewTicker(archiveInterval)
+			defer ticker.Stop()
+			for {
+				if _, err := s.Archive(context.Background(), time.Now().Add(-options.After)); err != nil {
+					s.Logger.Error("failed to archive activities", logger.String("error", err.Error()))
+				}
+				<-ticker.C
+			}
+		}()
+	})
+}
+
+// Archive moves the activities created before the given time to activities_archive, in
+// batches. With exports enabled every batch is written to storage before it is moved, so a
+// failed upload leaves the batch in place for the next run.
+func (s *ActivityService) Archive(ctx context.Context, before time.Time) (*ArchiveResult, error) {
+	s.archiver.mu.Lock()
+	defer s.archiver.mu.Unlock()
+
+	result := &ArchiveResult{Before: before, Exports: []string{}}
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		var batch []*Activity
+		err := s.DB.WithContext(ctx).Unscoped().
+			Where("created_at < ?", before).
+			Order("id").Limit(archiveBatchSize).
+			Find(&batch).Error
+		if err != nil {
+			return result, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		if s.archiver.options.Export {
+			exported, err := s.exportBatch(ctx, batch)
+			if err != nil {
+				return result, fmt.Errorf("failed to export activities: %w", err)
+			}
+			result.Exports = append(result.Exports, exported)
+		}
+
+		if err := s.moveBatch(ctx, batch); err != nil {
+			return result, err
+		}
+		result.Archived += int64(len(batch))
+	}
+
+	if result.Archived > 0 {
+		s.Logger.Info("Archived activities",
+			logger.Int64("count", result.Archived),
+			logger.String("before", before.Format(time.RFC3339)))
+		s.Emitter.EmitContext(ctx, ArchiveActivitiesEvent, result)
+	}
+	return result, nil
+}
+
+// moveBatch copies the activities to activities_archive and removes them from activities
+func (s *ActivityService) moveBatch(ctx context.Context, batch []*Activity) error {
+	archived := make([]*ArchivedActivity, len(batch))
+	ids := make([]uint, len(batch))
+	for i, item := range batch {
+		copied := ArchivedActivity(*item)
+		copied.User = nil
+		archived[i] = &copied
+		ids[i] = item.Id
+	}
+
+	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// A batch that was copied before a failed delete is already there
+		if err := tx.Unscoped().Where("id IN ?", ids).Delete(&ArchivedActivity{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Omit("User").Create(&archived).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id IN ?", ids).Delete(&Activity{}).Error
+	})
+}
+
+// exportBatch writes the activities to the storage provider as gzipped NDJSON and returns
+// the storage path
+func (s *ActivityService) exportBatch(ctx context.Context, batch []*Activity) (string, error) {
+	if s.Storage == nil {
+		return "", fmt.Errorf("no storage configured")
+	}
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	encoder := json.NewEncoder(writer)
+	for _, item := range batch {
+		if err := encoder.Encode(item.ToListResponse()); err != nil {
+			return "", err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	first, last := batch[0], batch[len(batch)-1]
+	filename := fmt.Sprintf("activities-%d-%d.ndjson.gz", first.Id, last.Id)
+	uploaded, err := s.Storage.GetProvider().UploadBytes(ctx, buf.Bytes(), filename, storage.UploadConfig{
+		UploadPath: path.Join(archiveExportDir, first.CreatedAt.Format("2006-01")),
+	})
+	if err != nil {
+		return "", err
+	}
+	return uploaded.Path, nil
+}
+
+// getArchived returns an archived activity by id
+func (s *ActivityService) getArchived(ctx context.Context, id uint) (*Activity, error) {
+	item := &ArchivedActivity{}
+	if err := s.DB.WithContext(ctx).Preload("User").First(item, id).Error; err != nil {
+		return nil, err
+	}
+	activity := Activity(*item)
+	return &activity, nil
+}
+
+// findArchived appends up to limit archived activities matching the condition to items,
+// newest first; it is used to top up recent-activity lists once the hot table runs out
+func (s *ActivityService) findArchived(ctx context.Context, items []*Activity, limit int, query any, args ...any) ([]*Activity, error) {
+	if len(items) >= limit {
+		return items, nil
+	}
+
+	var archived []*ArchivedActivity
+	err := s.DB.WithContext(ctx).Preload("User").
+		Where(query, args...).
+		Order("created_at DESC").
+		Limit(limit - len(items)).
+		Find(&archived).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range archived {
+		activity := Activity(*item)
+		items = append(items, &activity)
+	}
+	return items, nil
+}
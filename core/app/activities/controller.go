@@ -4,10 +4,14 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"base/core/database"
 	"base/core/router"
 	"base/core/storage"
+	"base/core/translation"
 	"base/core/types"
+	"base/core/validator"
 )
 
 type ActivityController struct {
@@ -32,9 +36,26 @@ func (c *ActivityController) Routes(router *router.RouterGroup) {
 	router.PUT("/activities/:id", c.Update)       // Update
 	router.DELETE("/activities/:id", c.Delete)    // Delete
 
+	// Feed of the signed in user and the records they follow
+	router.GET("/profile/feed", c.Feed)
+	router.GET("/profile/follows", c.ListFollows)
+	router.POST("/follows/:type/:id", c.Follow)
+	router.DELETE("/follows/:type/:id", c.Unfollow)
+
+	// Notifications of the changes of watched records
+	router.GET("/watch", c.ListWatches)
+	router.POST("/watch", c.Watch)
+	router.DELETE("/watch", c.UnwatchAll)
+	router.DELETE("/watch/:type/:id", c.Unwatch)
+
 	//Upload endpoints for each file field
 }
 
+// AdminRoutes registers the routes restricted to admins
+func (c *ActivityController) AdminRoutes(router *router.RouterGroup) {
+	router.POST("/activities/archive", c.Archive) // Archive old activities now
+}
+
 // CreateActivity godoc
 // @Summary Create a new Activity
 // @Description Create a new Activity with the input payload
@@ -54,7 +75,7 @@ func (c *ActivityController) Create(ctx *router.Context) error {
 		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
 	}
 
-	item, err := c.Service.Create(&req)
+	item, err := c.Service.Create(ctx.Request.Context(), &req)
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to create item: " + err.Error()})
 	}
@@ -81,7 +102,7 @@ func (c *ActivityController) Get(ctx *router.Context) error {
 		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
 	}
 
-	item, err := c.Service.GetById(uint(id))
+	item, err := c.Service.GetById(ctx.Request.Context(), uint(id))
 	if err != nil {
 		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Item not found"})
 	}
@@ -101,51 +122,67 @@ func (c *ActivityController) Get(ctx *router.Context) error {
 // @Param limit query int false "Number of items per page"
 // @Param sort query string false "Sort field (id, created_at, updated_at,user_id,entity_type,entity_id,action,description,metadata,ip_address,user_agent,)"
 // @Param order query string false "Sort order (asc, desc)"
+// @Param count query string false "How to count the total (exact, estimated, none); defaults to DB_COUNT_MODE"
+// @Param archived query bool false "List the archived activities instead of the recent ones"
 // @Success 200 {object} types.PaginatedResponse
 // @Failure 400 {object} types.ErrorResponse
 // @Failure 500 {object} types.ErrorResponse
 // @Router /activities [get]
 func (c *ActivityController) List(ctx *router.Context) error {
-	var page, limit *int
-	var sortBy, sortOrder *string
-
-	// Parse page parameter
-	if pageStr := ctx.Query("page"); pageStr != "" {
-		if pageNum, err := strconv.Atoi(pageStr); err == nil && pageNum > 0 {
-			page = &pageNum
-		} else {
-			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid page number"})
-		}
+	params, err := ctx.ListParams(sortFields...)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
 	}
 
-	// Parse limit parameter
-	if limitStr := ctx.Query("limit"); limitStr != "" {
-		if limitNum, err := strconv.Atoi(limitStr); err == nil && limitNum > 0 {
-			limit = &limitNum
-		} else {
-			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid limit number"})
-		}
+	count, err := database.Counts.Mode(ctx.Query("count"))
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
 	}
 
-	// Parse sort parameters
-	if sortStr := ctx.Query("sort"); sortStr != "" {
-		sortBy = &sortStr
+	archived, _ := strconv.ParseBool(ctx.Query("archived"))
+
+	paginatedResponse, err := c.Service.GetAll(ctx.Request.Context(), params.Page, params.Limit, params.SortBy, params.SortOrder, count, archived)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch items: " + err.Error()})
 	}
 
-	if orderStr := ctx.Query("order"); orderStr != "" {
-		if orderStr == "asc" || orderStr == "desc" {
-			sortOrder = &orderStr
-		} else {
-			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid sort order. Use 'asc' or 'desc'"})
+	return ctx.JSON(http.StatusOK, paginatedResponse)
+}
+
+// ArchiveActivities godoc
+// @Summary Archive old activities
+// @Description Move the activities created before a time to the archive (admin only). Without a time, the ones older than ACTIVITY_ARCHIVE_AFTER are archived.
+// @Tags Core/Activity
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body ArchiveActivitiesRequest false "Archive request"
+// @Success 200 {object} ArchiveResult
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /activities/archive [post]
+func (c *ActivityController) Archive(ctx *router.Context) error {
+	var req ArchiveActivitiesRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
 		}
 	}
 
-	paginatedResponse, err := c.Service.GetAll(page, limit, sortBy, sortOrder)
+	before := time.Now().Add(-c.Service.archiver.options.After)
+	if req.Before != nil {
+		before = *req.Before
+	} else if c.Service.archiver.options.After <= 0 {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "before is required when ACTIVITY_ARCHIVE_AFTER is not set"})
+	}
+
+	result, err := c.Service.Archive(ctx.Request.Context(), before)
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch items: " + err.Error()})
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to archive activities: " + err.Error()})
 	}
 
-	return ctx.JSON(http.StatusOK, paginatedResponse)
+	return ctx.JSON(http.StatusOK, result)
 }
 
 // ListAllActivities godoc
@@ -160,7 +197,7 @@ func (c *ActivityController) List(ctx *router.Context) error {
 // @Failure 500 {object} types.ErrorResponse
 // @Router /activities/all [get]
 func (c *ActivityController) ListAll(ctx *router.Context) error {
-	items, err := c.Service.GetAllForSelect()
+	items, err := c.Service.GetAllForSelect(ctx.Request.Context())
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch select options: " + err.Error()})
 	}
@@ -200,11 +237,17 @@ func (c *ActivityController) Update(ctx *router.Context) error {
 		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
 	}
 
-	item, err := c.Service.Update(uint(id), &req)
+	item, err := c.Service.Update(ctx.Request.Context(), uint(id), &req)
 	if err != nil {
 		if strings.Contains(err.Error(), "record not found") {
 			return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Item not found"})
 		}
+		if validationErrors, ok := err.(validator.ValidationErrors); ok {
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+				Error:   translation.Error(ctx, err),
+				Details: translation.LocalizeValidation(ctx, validationErrors),
+			})
+		}
 		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to update item: " + err.Error()})
 	}
 
@@ -230,7 +273,7 @@ func (c *ActivityController) Delete(ctx *router.Context) error {
 		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
 	}
 
-	if err := c.Service.Delete(uint(id)); err != nil {
+	if err := c.Service.Delete(ctx.Request.Context(), uint(id)); err != nil {
 		if strings.Contains(err.Error(), "record not found") {
 			return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Item not found"})
 		}
@@ -260,11 +303,11 @@ func (c *ActivityController) GetRecent(ctx *router.Context) error {
 	if limitStr != "" {
 		parsedLimit, err := strconv.Atoi(limitStr)
 		if err == nil && parsedLimit > 0 {
-			limit = parsedLimit
+			limit = min(parsedLimit, router.MaxListLimit)
 		}
 	}
 
-	activities, err := c.Service.GetRecentActivities(limit)
+	activities, err := c.Service.GetRecentActivities(ctx.Request.Context(), limit)
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to get recent activities: " + err.Error()})
 	}
@@ -277,3 +320,222 @@ func (c *ActivityController) GetRecent(ctx *router.Context) error {
 
 	return ctx.JSON(http.StatusOK, responses)
 }
+
+// GetFeed godoc
+// @Summary Get my feed
+// @Description Get a page of the feed of the signed in user, newest first: their own activities (kind "activity"), the activities of others on the records they follow ("following") and the records assigned to them, e.g. pages to review ("assigned")
+// @Tags Core/Profile
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of items per page"
+// @Success 200 {object} types.PaginatedResponse{data=[]FeedItem}
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /profile/feed [get]
+func (c *ActivityController) Feed(ctx *router.Context) error {
+	params, err := ctx.ListParams()
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	paginatedResponse, err := c.Service.Feed(ctx.Request.Context(), ctx.GetUint("user_id"), params.Page, params.Limit)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch feed: " + err.Error()})
+	}
+	return ctx.JSON(http.StatusOK, paginatedResponse)
+}
+
+// ListFollows godoc
+// @Summary List the records I follow
+// @Description Get the records the signed in user follows, the most recently followed first
+// @Tags Core/Profile
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} Follow
+// @Failure 500 {object} types.ErrorResponse
+// @Router /profile/follows [get]
+func (c *ActivityController) ListFollows(ctx *router.Context) error {
+	follows, err := c.Service.Following(ctx.Request.Context(), ctx.GetUint("user_id"))
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch follows: " + err.Error()})
+	}
+	return ctx.JSON(http.StatusOK, follows)
+}
+
+// FollowRecord godoc
+// @Summary Follow a record
+// @Description Follow a record, e.g. a page, so the activities of others on it show in the feed of the signed in user. Following a record again keeps the existing follow
+// @Tags Core/Profile
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param type path string true "Entity type of the activities of the record, e.g. pages"
+// @Param id path int true "Record id"
+// @Success 200 {object} Follow
+// @Success 201 {object} Follow
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /follows/{type}/{id} [post]
+func (c *ActivityController) Follow(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	follow, created, err := c.Service.Follow(ctx.Request.Context(), ctx.GetUint("user_id"), ctx.Param("type"), uint(id))
+	if err != nil {
+		if validationErrors, ok := err.(validator.ValidationErrors); ok {
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+				Error:   translation.Error(ctx, err),
+				Details: translation.LocalizeValidation(ctx, validationErrors),
+			})
+		}
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to follow: " + err.Error()})
+	}
+
+	if created {
+		return ctx.JSON(http.StatusCreated, follow)
+	}
+	return ctx.JSON(http.StatusOK, follow)
+}
+
+// UnfollowRecord godoc
+// @Summary Unfollow a record
+// @Description Stop following a record
+// @Tags Core/Profile
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param type path string true "Entity type of the record"
+// @Param id path int true "Record id"
+// @Success 204 "No Content"
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /follows/{type}/{id} [delete]
+func (c *ActivityController) Unfollow(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	removed, err := c.Service.Unfollow(ctx.Request.Context(), ctx.GetUint("user_id"), ctx.Param("type"), uint(id))
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to unfollow: " + err.Error()})
+	}
+	if !removed {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Not following this record"})
+	}
+
+	ctx.Status(http.StatusNoContent)
+	return nil
+}
+
+// ListWatches godoc
+// @Summary List the records I watch
+// @Description Get the records the signed in user watches with their channels, the most recently watched first
+// @Tags Core/Profile
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} Watch
+// @Failure 500 {object} types.ErrorResponse
+// @Router /watch [get]
+func (c *ActivityController) ListWatches(ctx *router.Context) error {
+	watches, err := c.Service.Watches(ctx.Request.Context(), ctx.GetUint("user_id"))
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch watches: " + err.Error()})
+	}
+	return ctx.JSON(http.StatusOK, watches)
+}
+
+// WatchRecord godoc
+// @Summary Watch a record
+// @Description Get notified when others change a record, e.g. a page or an order: its activities and the events of its module. Channels are in_app (default) and email. Watching a record again changes the channels of the watch
+// @Tags Core/Profile
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param watch body WatchRequest true "Watch request"
+// @Success 200 {object} Watch
+// @Success 201 {object} Watch
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /watch [post]
+func (c *ActivityController) Watch(ctx *router.Context) error {
+	var req WatchRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	watch, created, err := c.Service.Watch(ctx.Request.Context(), ctx.GetUint("user_id"), &req)
+	if err != nil {
+		if validationErrors, ok := err.(validator.ValidationErrors); ok {
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+				Error:   translation.Error(ctx, err),
+				Details: translation.LocalizeValidation(ctx, validationErrors),
+			})
+		}
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to watch: " + err.Error()})
+	}
+
+	if created {
+		return ctx.JSON(http.StatusCreated, watch)
+	}
+	return ctx.JSON(http.StatusOK, watch)
+}
+
+// UnwatchRecord godoc
+// @Summary Unwatch a record
+// @Description Stop getting notified of the changes of a record
+// @Tags Core/Profile
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param type path string true "Entity type of the record"
+// @Param id path int true "Record id"
+// @Success 204 "No Content"
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /watch/{type}/{id} [delete]
+func (c *ActivityController) Unwatch(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	removed, err := c.Service.Unwatch(ctx.Request.Context(), ctx.GetUint("user_id"), ctx.Param("type"), uint(id))
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to unwatch: " + err.Error()})
+	}
+	if !removed {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Not watching this record"})
+	}
+
+	ctx.Status(http.StatusNoContent)
+	return nil
+}
+
+// UnwatchAll godoc
+// @Summary Unwatch all records
+// @Description Stop watching every record, or every record of an entity type
+// @Tags Core/Profile
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param entity_type query string false "Only unwatch the records of this entity type"
+// @Success 200 {object} map[string]int64
+// @Failure 500 {object} types.ErrorResponse
+// @Router /watch [delete]
+func (c *ActivityController) UnwatchAll(ctx *router.Context) error {
+	removed, err := c.Service.UnwatchAll(ctx.Request.Context(), ctx.GetUint("user_id"), ctx.Query("entity_type"))
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to unwatch: " + err.Error()})
+	}
+	return ctx.JSON(http.StatusOK, map[string]int64{"removed": removed})
+}
@@ -0,0 +1,183 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"base/core/logger"
+	"base/core/types"
+
+	"gorm.io/gorm"
+)
+
+// Kinds of feed items
+const (
+	FeedActivity  = "activity"  // An activity of the user
+	FeedFollowing = "following" // An activity of another user on a record the user follows
+	FeedAssigned  = "assigned"  // A record waiting for the user, e.g. a page to review
+)
+
+// Assignable is a model whose records are assigned to a user through a column, e.g. pages to
+// their reviewer. The records assigned to a user show in their feed.
+type Assignable struct {
+	Name   string // Type of the records, e.g. "pages"
+	Model  any    // Model of the records
+	Column string // Column of the assignee, e.g. "reviewer_id"
+	Title  string // Column of the title of a record, e.g. "title"
+
+	// Scope narrows the records to the ones waiting for their assignee, e.g. to the pages in
+	// review; optional
+	Scope func(db *gorm.DB) *gorm.DB
+}
+
+var (
+	assignablesMu sync.RWMutex
+	assignables   = map[string]Assignable{}
+)
+
+// RegisterAssignable adds a model to the feeds, e.g. from a module's Init:
+//
+//	activities.RegisterAssignable(activities.Assignable{Name: "pages", Model: &Page{},
+//		Column: "reviewer_id", Title: "title", Scope: func(db *gorm.DB) *gorm.DB { ... }})
+func RegisterAssignable(model Assignable) {
+	assignablesMu.Lock()
+	defer assignablesMu.Unlock()
+	assignables[model.Name] = model
+}
+
+// Assignables returns the registered assignable models sorted by name
+func Assignables() []Assignable {
+	assignablesMu.RLock()
+	defer assignablesMu.RUnlock()
+	result := make([]Assignable, 0, len(assignables))
+	for _, model := range assignables {
+		result = append(result, model)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// FeedItem is an entry of the feed of a user
+type FeedItem struct {
+	Kind       string                `json:"kind"` // FeedActivity, FeedFollowing or FeedAssigned
+	EntityType string                `json:"entity_type"`
+	EntityId   uint                  `json:"entity_id"`
+	Title      string                `json:"title"` // Description of the activity or title of the record
+	At         time.Time             `json:"at"`    // When the activity happened or the record changed
+	Activity   *ActivityListResponse `json:"activity,omitempty"`
+}
+
+// feedRow is a row of the feed query
+type feedRow struct {
+	Kind       string
+	EntityType string
+	EntityId   uint
+	ActivityId uint
+	Title      string
+	At         time.Time
+}
+
+// Feed returns a page of the feed of a user, newest first: their own activities, the
+// activities of others on the records they follow, and the records assigned to them. The
+// sources are merged in one query, so pages stay consistent however deep they go.
+func (s *ActivityService) Feed(ctx context.Context, userId uint, page *int, limit *int) (*types.PaginatedResponse, error) {
+	// Set default values if nil
+	defaultPage := 1
+	defaultLimit := 10
+	if page == nil {
+		page = &defaultPage
+	}
+	if limit == nil {
+		limit = &defaultLimit
+	}
+
+	db := s.DB.WithContext(ctx)
+	parts := []string{"?"}
+	args := []any{db.Model(&Activity{}).
+		Select(fmt.Sprintf("CASE WHEN user_id = ? THEN '%s' ELSE '%s' END AS kind, entity_type, entity_id, id AS activity_id, description AS title, created_at AS at",
+			FeedActivity, FeedFollowing), userId).
+		Where("user_id = ? OR EXISTS (?)", userId, db.Model(&Follow{}).Select("1").
+			Where("follows.user_id = ? AND follows.entity_type = activities.entity_type AND follows.entity_id = activities.entity_id", userId))}
+	for _, model := range Assignables() {
+		// The name is written into the query as a literal
+		if !entityTypePattern.MatchString(model.Name) {
+			continue
+		}
+		query := db.Model(model.Model).
+			Select(fmt.Sprintf("'%s' AS kind, '%s' AS entity_type, id AS entity_id, 0 AS activity_id, %s AS title, updated_at AS at",
+				FeedAssigned, model.Name, model.Title)).
+			Where(model.Column+" = ?", userId)
+		if model.Scope != nil {
+			query = model.Scope(query)
+		}
+		parts = append(parts, "?")
+		args = append(args, query)
+	}
+	feed := "(" + strings.Join(parts, " UNION ALL ") + ") AS feed"
+
+	var total int64
+	if err := db.Table(feed, args...).Count(&total).Error; err != nil {
+		s.Logger.Error("failed to count feed", logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	var rows []feedRow
+	offset := (*page - 1) * *limit
+	if err := db.Table(feed, args...).Order("at DESC, activity_id DESC, entity_id DESC").
+		Offset(offset).Limit(*limit).Scan(&rows).Error; err != nil {
+		s.Logger.Error("failed to get feed", logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	// Attach the activities of the page, with one query
+	ids := []uint{}
+	for _, row := range rows {
+		if row.ActivityId != 0 {
+			ids = append(ids, row.ActivityId)
+		}
+	}
+	found := map[uint]*Activity{}
+	if len(ids) > 0 {
+		var items []*Activity
+		if err := db.Where("id IN ?", ids).Find(&items).Error; err != nil {
+			s.Logger.Error("failed to get feed activities", logger.String("error", err.Error()))
+			return nil, err
+		}
+		for _, item := range items {
+			found[item.Id] = item
+		}
+	}
+
+	items := make([]*FeedItem, len(rows))
+	for i, row := range rows {
+		items[i] = &FeedItem{
+			Kind:       row.Kind,
+			EntityType: row.EntityType,
+			EntityId:   row.EntityId,
+			Title:      row.Title,
+			At:         row.At,
+			Activity:   found[row.ActivityId].ToListResponse(),
+		}
+	}
+
+	// Calculate total pages
+	totalPages := int(math.Ceil(float64(total) / float64(*limit)))
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	return &types.PaginatedResponse{
+		Data: items,
+		Pagination: types.Pagination{
+			Total:      int(total),
+			Page:       *page,
+			PageSize:   *limit,
+			TotalPages: totalPages,
+		},
+	}, nil
+}
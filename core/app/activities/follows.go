@@ -0,0 +1,89 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"base/core/validator"
+
+	"gorm.io/gorm/clause"
+)
+
+// Follow subscribes a user to the activities of a record, which then show in their feed
+type Follow struct {
+	Id         uint      `json:"id" gorm:"primaryKey"`
+	UserId     uint      `json:"user_id" gorm:"uniqueIndex:idx_follows_record,priority:1;not null"`
+	EntityType string    `json:"entity_type" gorm:"size:64;uniqueIndex:idx_follows_record,priority:2;index:idx_follows_entity,priority:1"`
+	EntityId   uint      `json:"entity_id" gorm:"uniqueIndex:idx_follows_record,priority:3;index:idx_follows_entity,priority:2"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for the Follow model
+func (Follow) TableName() string {
+	return "follows"
+}
+
+// entityTypePattern matches the entity types of activities, e.g. "product" or "pages"
+var entityTypePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// Follow makes a user follow a record. Following a record again keeps the existing follow;
+// created reports whether it is new.
+func (s *ActivityService) Follow(ctx context.Context, userId uint, entityType string, entityId uint) (follow *Follow, created bool, err error) {
+	if err := validateFollow(entityType, entityId); err != nil {
+		return nil, false, err
+	}
+
+	follow = &Follow{UserId: userId, EntityType: entityType, EntityId: entityId}
+	result := s.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(follow)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return follow, true, nil
+	}
+
+	follow = &Follow{}
+	err = s.DB.WithContext(ctx).
+		Where("user_id = ? AND entity_type = ? AND entity_id = ?", userId, entityType, entityId).
+		First(follow).Error
+	return follow, false, err
+}
+
+// Unfollow stops a user following a record; it returns false when they didn't follow it
+func (s *ActivityService) Unfollow(ctx context.Context, userId uint, entityType string, entityId uint) (bool, error) {
+	result := s.DB.WithContext(ctx).
+		Where("user_id = ? AND entity_type = ? AND entity_id = ?", userId, entityType, entityId).
+		Delete(&Follow{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Following returns the records a user follows, the most recently followed first
+func (s *ActivityService) Following(ctx context.Context, userId uint) ([]*Follow, error) {
+	follows := []*Follow{}
+	err := s.DB.WithContext(ctx).Where("user_id = ?", userId).
+		Order("created_at DESC, id DESC").Find(&follows).Error
+	return follows, err
+}
+
+// validateFollow checks the record of a follow
+func validateFollow(entityType string, entityId uint) error {
+	var errs validator.ValidationErrors
+	if !entityTypePattern.MatchString(entityType) {
+		errs = append(errs, validator.ValidationError{
+			Field: "entity_type", Tag: "invalid", Value: entityType,
+			Message: "entity_type must be lowercase letters, digits and underscores",
+		})
+	}
+	if entityId == 0 {
+		errs = append(errs, validator.ValidationError{
+			Field: "entity_id", Tag: "required", Value: fmt.Sprint(entityId),
+			Message: "entity_id is required",
+		})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
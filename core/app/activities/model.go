@@ -13,17 +13,17 @@ import (
 // Activity represents an audit log entry tracking system actions
 type Activity struct {
 	Id        uint           `json:"id" gorm:"primarykey"`
-	CreatedAt time.Time      `json:"created_at" gorm:"index"` // Indexed for fast sorting
+	CreatedAt time.Time      `json:"created_at" gorm:"index;index:,composite:user_created,priority:2;index:,composite:entity_created,priority:3"` // Indexed for fast sorting
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 
 	// User who performed the action
-	UserId uint          `json:"user_id" gorm:"index"` // Indexed for filtering by user
+	UserId uint          `json:"user_id" gorm:"index;index:,composite:user_created,priority:1"` // Indexed for filtering by user
 	User   *users.User `json:"user,omitempty" gorm:"foreignKey:UserId"`
 
 	// Entity being acted upon (e.g., "post", "employee", "order")
-	EntityType string `json:"entity_type" gorm:"index"` // Indexed for filtering by entity
-	EntityId   uint   `json:"entity_id" gorm:"index"`   // Indexed for filtering by entity ID
+	EntityType string `json:"entity_type" gorm:"index;index:,composite:entity_created,priority:1"` // Indexed for filtering by entity
+	EntityId   uint   `json:"entity_id" gorm:"index;index:,composite:entity_created,priority:2"` // Indexed for filtering by entity ID
 
 	// Action performed (e.g., "create", "update", "delete", "login", "logout")
 	Action string `json:"action" gorm:"index"` // Indexed for filtering by action
@@ -66,6 +66,11 @@ type CreateActivityRequest struct {
 	UserAgent   string          `json:"user_agent"`
 }
 
+// ArchiveActivitiesRequest represents the request payload for archiving activities
+type ArchiveActivitiesRequest struct {
+	Before *time.Time `json:"before"` // Archive the activities created before this time
+}
+
 // UpdateActivityRequest represents the request payload for updating a Activity
 type UpdateActivityRequest struct {
 	UserId      uint            `json:"user_id,omitempty"`
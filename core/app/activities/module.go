@@ -1,6 +1,8 @@
 package activities
 
 import (
+	"base/core/app/authorization"
+	"base/core/app/notifications"
 	"base/core/module"
 	"base/core/router"
 
@@ -12,12 +14,16 @@ type Module struct {
 	DB         *gorm.DB
 	Service    *ActivityService
 	Controller *ActivityController
+	archive    ArchiveOptions
 }
 
 // Init creates and initializes the Activity module with all dependencies
 func Init(deps module.Dependencies) module.Module {
+	registerEvents()
 	// Initialize service and controller
 	service := NewActivityService(deps.DB, deps.Emitter, deps.Storage, deps.Logger)
+	service.Notifications = notifications.NewNotificationService(deps.DB, deps.Emitter, deps.Storage, deps.Logger)
+	service.EmailSender = deps.EmailSender
 	controller := NewActivityController(service, deps.Storage)
 
 	// Create module
@@ -26,6 +32,13 @@ func Init(deps module.Dependencies) module.Module {
 		Service:    service,
 		Controller: controller,
 	}
+	if deps.Config != nil {
+		mod.archive = ArchiveOptions{
+			After:  deps.Config.ActivityArchiveAfter,
+			Export: deps.Config.ActivityArchiveExport,
+		}
+		service.From = deps.Config.EmailFromAddress
+	}
 
 	return mod
 }
@@ -33,18 +46,32 @@ func Init(deps module.Dependencies) module.Module {
 // Routes registers the module routes
 func (m *Module) Routes(router *router.RouterGroup) {
 	m.Controller.Routes(router)
+
+	group := router.Group("", authorization.RequireAdmin(authorization.NewAuthorizationService(m.DB)))
+	m.Controller.AdminRoutes(group)
 }
 
 func (m *Module) Init() error {
+	// Audit role changes
+	m.Service.Listen()
+
+	// Notify the watchers of records of their changes
+	m.Service.ListenWatches()
+
+	// Archive old activities in the background
+	m.Service.StartArchiving(m.archive)
 	return nil
 }
 
 func (m *Module) Migrate() error {
-	return m.DB.AutoMigrate(&Activity{})
+	return m.DB.AutoMigrate(&Activity{}, &ArchivedActivity{}, &Follow{}, &Watch{})
 }
 
 func (m *Module) GetModels() []any {
 	return []any{
 		&Activity{},
+		&ArchivedActivity{},
+		&Follow{},
+		&Watch{},
 	}
 }
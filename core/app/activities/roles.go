@@ -0,0 +1,42 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+
+	"base/core/app/users"
+	"base/core/emitter"
+	"base/core/logger"
+)
+
+// Listen records the role changes of users as activities of the user who made them
+func (s *ActivityService) Listen() {
+	if s.Emitter == nil {
+		return
+	}
+	emitter.Listen(s.Emitter, users.RoleChangeUserEvent, func(ctx context.Context, change *users.RoleChange) {
+		if err := s.logRoleChange(ctx, change); err != nil {
+			s.Logger.Error("failed to record role change",
+				logger.String("error", err.Error()),
+				logger.Int("user_id", int(change.User.Id)))
+		}
+	})
+}
+
+// logRoleChange records a role change with the old and the new role. A change from the command
+// line has no user making it, so it is recorded as an activity of the user it changed.
+func (s *ActivityService) logRoleChange(ctx context.Context, change *users.RoleChange) error {
+	description := fmt.Sprintf("Changed the role of %s from %s to %s", change.User.Username, change.OldRole, change.NewRole)
+	metadata := map[string]interface{}{
+		"old_role_id": change.OldRoleId,
+		"old_role":    change.OldRole,
+		"new_role_id": change.NewRoleId,
+		"new_role":    change.NewRole,
+	}
+	userId := change.ChangedBy
+	if userId == 0 {
+		userId = change.User.Id
+		metadata["command_line"] = true
+	}
+	return s.Log(ctx, userId, "user", change.User.Id, "role_change", description, metadata, change.IpAddress, change.UserAgent)
+}
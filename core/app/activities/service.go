@@ -1,9 +1,15 @@
 package activities
 
 import (
+	"context"
 	"encoding/json"
-	"math"
+	"errors"
+	"sync"
 
+	"base/core/app/notifications"
+	"base/core/crud"
+	"base/core/database"
+	"base/core/email"
 	"base/core/emitter"
 	"base/core/logger"
 	"base/core/storage"
@@ -18,63 +24,49 @@ const (
 	DeleteActivityEvent = "activities.delete"
 )
 
+// ActivityService records and lists the activities; listing, creating, updating and
+// deleting them are the shared crud operations
 type ActivityService struct {
-	DB      *gorm.DB
-	Emitter *emitter.Emitter
+	*crud.CrudService[Activity]
 	Storage *storage.ActiveStorage
-	Logger  logger.Logger
+
+	// Notify the watchers of records, see ListenWatches
+	Notifications *notifications.NotificationService
+	EmailSender   email.Sender // Nil to send no emails
+	From          string
+
+	archiver archiver
+	watchMu  sync.Mutex
+	watched  map[string]bool
 }
 
 func NewActivityService(db *gorm.DB, emitter *emitter.Emitter, storage *storage.ActiveStorage, logger logger.Logger) *ActivityService {
-	return &ActivityService{
-		DB:      db,
-		Logger:  logger,
-		Emitter: emitter,
-		Storage: storage,
+	service := &ActivityService{
+		CrudService: crud.New[Activity](db, emitter, logger, "activity", "activities"),
+		Storage:     storage,
 	}
+	service.SortFields = sortFields
+	service.ListResponse = func(item *Activity) any { return item.ToListResponse() }
+	return service
 }
 
-// applySorting applies sorting to the query based on the sort and order parameters
-func (s *ActivityService) applySorting(query *gorm.DB, sortBy *string, sortOrder *string) {
-	// Valid sortable fields for Activity
-	validSortFields := map[string]string{
-		"id":          "id",
-		"created_at":  "created_at",
-		"updated_at":  "updated_at",
-		"user_id":     "user_id",
-		"entity_type": "entity_type",
-		"entity_id":   "entity_id",
-		"action":      "action",
-		"description": "description",
-		"metadata":    "metadata",
-		"ip_address":  "ip_address",
-		"user_agent":  "user_agent",
-	}
-
-	// Default sorting - if sort_order exists, always use it for custom ordering
-	defaultSortBy := "id"
-	defaultSortOrder := "desc"
-
-	// Determine sort field
-	sortField := defaultSortBy
-	if sortBy != nil && *sortBy != "" {
-		if field, exists := validSortFields[*sortBy]; exists {
-			sortField = field
-		}
-	}
-
-	// Determine sort direction (order parameter)
-	sortDirection := defaultSortOrder
-	if sortOrder != nil && (*sortOrder == "asc" || *sortOrder == "desc") {
-		sortDirection = *sortOrder
-	}
-
-	// Apply sorting
-	query.Order(sortField + " " + sortDirection)
+// sortFields are the fields the list can be sorted by
+var sortFields = []string{
+	"id",
+	"created_at",
+	"updated_at",
+	"user_id",
+	"entity_type",
+	"entity_id",
+	"action",
+	"description",
+	"metadata",
+	"ip_address",
+	"user_agent",
 }
 
-func (s *ActivityService) Create(req *CreateActivityRequest) (*Activity, error) {
-	item := &Activity{
+func (s *ActivityService) Create(ctx context.Context, req *CreateActivityRequest) (*Activity, error) {
+	return s.CrudService.Create(ctx, &Activity{
 		UserId:      req.UserId,
 		EntityType:  req.EntityType,
 		EntityId:    req.EntityId,
@@ -83,211 +75,74 @@ func (s *ActivityService) Create(req *CreateActivityRequest) (*Activity, error)
 		Metadata:    req.Metadata,
 		IpAddress:   req.IpAddress,
 		UserAgent:   req.UserAgent,
-	}
-
-	if err := s.DB.Create(item).Error; err != nil {
-		s.Logger.Error("failed to create activity", logger.String("error", err.Error()))
-		return nil, err
-	}
-
-	// Emit create event
-	s.Emitter.Emit(CreateActivityEvent, item)
-
-	return s.GetById(item.Id)
+	})
 }
 
-func (s *ActivityService) Update(id uint, req *UpdateActivityRequest) (*Activity, error) {
-	item := &Activity{}
-	if err := s.DB.First(item, id).Error; err != nil {
-		s.Logger.Error("failed to find activity for update",
-			logger.String("error", err.Error()),
-			logger.Int("id", int(id)))
-		return nil, err
-	}
-
-	// Validate request
-	if err := ValidateActivityUpdateRequest(req, id); err != nil {
-		return nil, err
-	}
-
-	// Update fields directly on the model
-	// For non-pointer unsigned integer fields
-	if req.UserId != 0 {
-		item.UserId = req.UserId
-	}
-	// For non-pointer string fields
-	if req.EntityType != "" {
-		item.EntityType = req.EntityType
-	}
-	// For non-pointer unsigned integer fields
-	if req.EntityId != 0 {
-		item.EntityId = req.EntityId
-	}
-	// For non-pointer string fields
-	if req.Action != "" {
-		item.Action = req.Action
-	}
-	// For non-pointer string fields
-	if req.Description != "" {
-		item.Description = req.Description
-	}
-	// For non-pointer string fields
-	if req.IpAddress != "" {
-		item.IpAddress = req.IpAddress
-	}
-	// For non-pointer string fields
-	if req.UserAgent != "" {
-		item.UserAgent = req.UserAgent
-	}
-
-	if err := s.DB.Save(item).Error; err != nil {
-		s.Logger.Error("failed to update activity",
-			logger.String("error", err.Error()),
-			logger.Int("id", int(id)))
-		return nil, err
-	}
-
-	// Handle many-to-many relationships
-
-	result, err := s.GetById(item.Id)
-	if err != nil {
-		s.Logger.Error("failed to get updated activity",
-			logger.String("error", err.Error()),
-			logger.Int("id", int(id)))
-		return nil, err
-	}
-
-	// Emit update event
-	s.Emitter.Emit(UpdateActivityEvent, result)
-
-	return result, nil
-}
-
-func (s *ActivityService) Delete(id uint) error {
-	item := &Activity{}
-	if err := s.DB.First(item, id).Error; err != nil {
-		s.Logger.Error("failed to find activity for deletion",
-			logger.String("error", err.Error()),
-			logger.Int("id", int(id)))
-		return err
-	}
-
-	// Delete file attachments if any
-
-	if err := s.DB.Delete(item).Error; err != nil {
-		s.Logger.Error("failed to delete activity",
-			logger.String("error", err.Error()),
-			logger.Int("id", int(id)))
-		return err
-	}
-
-	// Emit delete event
-	s.Emitter.Emit(DeleteActivityEvent, item)
-
-	return nil
-}
-
-func (s *ActivityService) GetById(id uint) (*Activity, error) {
-	item := &Activity{}
-
-	query := item.Preload(s.DB)
-	if err := query.First(item, id).Error; err != nil {
-		s.Logger.Error("failed to get activity",
-			logger.String("error", err.Error()),
-			logger.Int("id", int(id)))
-		return nil, err
-	}
+func (s *ActivityService) Update(ctx context.Context, id uint, req *UpdateActivityRequest) (*Activity, error) {
+	return s.CrudService.Update(ctx, id, func(tx *gorm.DB, item *Activity) error {
+		// Validate request
+		if err := ValidateActivityUpdateRequest(req, id); err != nil {
+			return err
+		}
 
-	return item, nil
+		// Update the fields set in the request; empty ones are kept
+		if req.UserId != 0 {
+			item.UserId = req.UserId
+		}
+		if req.EntityType != "" {
+			item.EntityType = req.EntityType
+		}
+		if req.EntityId != 0 {
+			item.EntityId = req.EntityId
+		}
+		if req.Action != "" {
+			item.Action = req.Action
+		}
+		if req.Description != "" {
+			item.Description = req.Description
+		}
+		if req.IpAddress != "" {
+			item.IpAddress = req.IpAddress
+		}
+		if req.UserAgent != "" {
+			item.UserAgent = req.UserAgent
+		}
+		return nil
+	})
 }
 
-func (s *ActivityService) GetAll(page *int, limit *int, sortBy *string, sortOrder *string) (*types.PaginatedResponse, error) {
-	var items []*Activity
-	var total int64
-
-	query := s.DB.Model(&Activity{})
-	// Set default values if nil
-	defaultPage := 1
-	defaultLimit := 10
-	if page == nil {
-		page = &defaultPage
-	}
-	if limit == nil {
-		limit = &defaultLimit
-	}
-
-	// Get total count
-	if err := query.Count(&total).Error; err != nil {
-		s.Logger.Error("failed to count activities",
-			logger.String("error", err.Error()))
-		return nil, err
-	}
-
-	// Apply pagination if provided
-	if page != nil && limit != nil {
-		offset := (*page - 1) * *limit
-		query = query.Offset(offset).Limit(*limit)
-	}
-
-	// Apply sorting
-	s.applySorting(query, sortBy, sortOrder)
-
-	// Don't preload relationships for list response (faster)
-	// query = (&Activity{}).Preload(query)
-
-	// Execute query
-	if err := query.Find(&items).Error; err != nil {
-		s.Logger.Error("failed to get activities",
-			logger.String("error", err.Error()))
-		return nil, err
-	}
-
-	// Convert to response type
-	responses := make([]*ActivityListResponse, len(items))
-	for i, item := range items {
-		responses[i] = item.ToListResponse()
-	}
-
-	// Calculate total pages
-	totalPages := int(math.Ceil(float64(total) / float64(*limit)))
-	if totalPages == 0 {
-		totalPages = 1
+// GetById returns an activity, recent or archived
+func (s *ActivityService) GetById(ctx context.Context, id uint) (*Activity, error) {
+	item, err := s.CrudService.GetById(ctx, id)
+	// Older activities may have been archived
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if archived, archiveErr := s.getArchived(ctx, id); archiveErr == nil {
+			return archived, nil
+		}
 	}
-
-	return &types.PaginatedResponse{
-		Data: responses,
-		Pagination: types.Pagination{
-			Total:      int(total),
-			Page:       *page,
-			PageSize:   *limit,
-			TotalPages: totalPages,
-		},
-	}, nil
+	return item, err
 }
 
-// GetAllForSelect gets all items for select box/dropdown options (simplified response)
-func (s *ActivityService) GetAllForSelect() ([]*Activity, error) {
-	var items []*Activity
-
-	query := s.DB.Model(&Activity{})
-
-	// Only select the necessary fields for select options
-	query = query.Select("id") // Only ID if no name/title field found
-
-	// Order by name/title for better UX
-	query = query.Order("id ASC")
-
-	if err := query.Find(&items).Error; err != nil {
-		s.Logger.Error("Failed to fetch items for select", logger.String("error", err.Error()))
-		return nil, err
-	}
-
-	return items, nil
+// GetAll returns a page of recent activities, or of archived ones. The count mode decides
+// how the total is computed; with database.CountNone the pagination reports has_more
+// instead of the totals.
+func (s *ActivityService) GetAll(ctx context.Context, page *int, limit *int, sortBy *string, sortOrder *string, count database.CountMode, archived bool) (*types.PaginatedResponse, error) {
+	query := s.DB.WithContext(ctx).Model(&Activity{})
+	if archived {
+		query = s.DB.WithContext(ctx).Model(&ArchivedActivity{})
+	}
+	return s.List(ctx, query, crud.ListParams{
+		Page:      page,
+		Limit:     limit,
+		SortBy:    sortBy,
+		SortOrder: sortOrder,
+		Count:     count,
+	})
 }
 
 // Log is a convenient helper to log an activity
 // Example: Log(userId, "post", postId, "create", "Created new blog post", metadata, ipAddress, userAgent)
-func (s *ActivityService) Log(userId uint, entityType string, entityId uint, action string, description string, metadata map[string]interface{}, ipAddress string, userAgent string) error {
+func (s *ActivityService) Log(ctx context.Context, userId uint, entityType string, entityId uint, action string, description string, metadata map[string]interface{}, ipAddress string, userAgent string) error {
 	var metadataJSON []byte
 	var err error
 
@@ -310,7 +165,7 @@ func (s *ActivityService) Log(userId uint, entityType string, entityId uint, act
 		UserAgent:   userAgent,
 	}
 
-	_, err = s.Create(req)
+	_, err = s.Create(ctx, req)
 	if err != nil {
 		s.Logger.Error("failed to log activity", logger.String("error", err.Error()))
 		return err
@@ -320,10 +175,10 @@ func (s *ActivityService) Log(userId uint, entityType string, entityId uint, act
 }
 
 // GetRecentActivities gets the most recent N activities
-func (s *ActivityService) GetRecentActivities(limit int) ([]*Activity, error) {
+func (s *ActivityService) GetRecentActivities(ctx context.Context, limit int) ([]*Activity, error) {
 	var activities []*Activity
 
-	query := s.DB.Model(&Activity{}).
+	query := s.DB.WithContext(ctx).Model(&Activity{}).
 		Order("created_at DESC").
 		Limit(limit)
 
@@ -339,10 +194,10 @@ func (s *ActivityService) GetRecentActivities(limit int) ([]*Activity, error) {
 }
 
 // GetActivitiesByUser gets activities for a specific user
-func (s *ActivityService) GetActivitiesByUser(userId uint, limit int) ([]*Activity, error) {
+func (s *ActivityService) GetActivitiesByUser(ctx context.Context, userId uint, limit int) ([]*Activity, error) {
 	var activities []*Activity
 
-	query := s.DB.Model(&Activity{}).
+	query := s.DB.WithContext(ctx).Model(&Activity{}).
 		Where("user_id = ?", userId).
 		Order("created_at DESC").
 		Limit(limit)
@@ -354,14 +209,21 @@ func (s *ActivityService) GetActivitiesByUser(userId uint, limit int) ([]*Activi
 		return nil, err
 	}
 
+	// Top up from the archive when the user has fewer recent activities
+	activities, err := s.findArchived(ctx, activities, limit, "user_id = ?", userId)
+	if err != nil {
+		s.Logger.Error("failed to get archived user activities", logger.String("error", err.Error()))
+		return nil, err
+	}
+
 	return activities, nil
 }
 
 // GetActivitiesByEntity gets activities for a specific entity
-func (s *ActivityService) GetActivitiesByEntity(entityType string, entityId uint, limit int) ([]*Activity, error) {
+func (s *ActivityService) GetActivitiesByEntity(ctx context.Context, entityType string, entityId uint, limit int) ([]*Activity, error) {
 	var activities []*Activity
 
-	query := s.DB.Model(&Activity{}).
+	query := s.DB.WithContext(ctx).Model(&Activity{}).
 		Where("entity_type = ? AND entity_id = ?", entityType, entityId).
 		Order("created_at DESC").
 		Limit(limit)
@@ -373,5 +235,12 @@ func (s *ActivityService) GetActivitiesByEntity(entityType string, entityId uint
 		return nil, err
 	}
 
+	// Top up from the archive when the entity has fewer recent activities
+	activities, err := s.findArchived(ctx, activities, limit, "entity_type = ? AND entity_id = ?", entityType, entityId)
+	if err != nil {
+		s.Logger.Error("failed to get archived entity activities", logger.String("error", err.Error()))
+		return nil, err
+	}
+
 	return activities, nil
 }
@@ -0,0 +1,321 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"base/core/app/notifications"
+	"base/core/app/users"
+	"base/core/email"
+	"base/core/logger"
+	"base/core/router/middleware"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Channels watchers are notified on
+const (
+	WatchInApp = "in_app" // A notification, see the notifications module
+	WatchEmail = "email"  // An email to the address of the watcher
+)
+
+// WatchChannels are the channels a watch can notify on
+var WatchChannels = []string{WatchInApp, WatchEmail}
+
+// Watch subscribes a user to the changes of a record: its activities and the events of its
+// Watchable. They are notified on their channels of changes made by others.
+type Watch struct {
+	Id         uint      `json:"id" gorm:"primaryKey"`
+	UserId     uint      `json:"user_id" gorm:"uniqueIndex:idx_watches_record,priority:1;not null"`
+	EntityType string    `json:"entity_type" gorm:"size:64;uniqueIndex:idx_watches_record,priority:2;index:idx_watches_entity,priority:1"`
+	EntityId   uint      `json:"entity_id" gorm:"uniqueIndex:idx_watches_record,priority:3;index:idx_watches_entity,priority:2"`
+	Channels   []string  `json:"channels" gorm:"type:text;serializer:json"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for the Watch model
+func (Watch) TableName() string {
+	return "watches"
+}
+
+// WatchRequest watches a record, or changes the channels of a watch
+type WatchRequest struct {
+	EntityType string   `json:"entity_type" binding:"required"`
+	EntityId   uint     `json:"entity_id" binding:"required"`
+	Channels   []string `json:"channels,omitempty"` // Defaults to in_app
+}
+
+// Watchable is a model whose records can be watched through its events, e.g. pages through
+// their update and delete events. Activities notify the watchers of every entity type.
+type Watchable struct {
+	Name string // Type of the records, e.g. "pages"
+	Path string // Path of the records in the admin, e.g. "/pages"; the record id is appended
+
+	// Events emitted with a changed record (with a GetId method), and what they tell the
+	// watchers, e.g. {UpdatePageEvent: "was updated"}
+	Events map[string]string
+
+	// Title returns the title of a record for the notifications; optional
+	Title func(record any) string
+}
+
+var (
+	watchablesMu sync.RWMutex
+	watchables   = map[string]Watchable{}
+
+	// watchEvents subscribes the activities module to the events of a watchable. Modules
+	// register their models before or after the activities module starts, depending on
+	// their order.
+	watchEvents func(Watchable)
+)
+
+// RegisterWatchable adds a model to the watch notifications, e.g. from a module's Init:
+//
+//	activities.RegisterWatchable(activities.Watchable{Name: "pages", Path: "/pages",
+//		Events: map[string]string{UpdatePageEvent: "was updated"},
+//		Title: func(record any) string { ... }})
+func RegisterWatchable(model Watchable) {
+	watchablesMu.Lock()
+	watchables[model.Name] = model
+	subscribe := watchEvents
+	watchablesMu.Unlock()
+
+	if subscribe != nil {
+		subscribe(model)
+	}
+}
+
+// Watchables returns the registered watchable models sorted by name
+func Watchables() []Watchable {
+	watchablesMu.RLock()
+	defer watchablesMu.RUnlock()
+	result := make([]Watchable, 0, len(watchables))
+	for _, model := range watchables {
+		result = append(result, model)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// watchURL returns the path of a record in the admin, when its watchable has one
+func watchURL(entityType string, entityId uint) string {
+	watchablesMu.RLock()
+	model, ok := watchables[entityType]
+	watchablesMu.RUnlock()
+	if !ok || model.Path == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%d", model.Path, entityId)
+}
+
+// ListenWatches notifies watchers of the activities on their records and of the events of
+// the watchables, registered so far and later
+func (s *ActivityService) ListenWatches() {
+	if s.Emitter == nil {
+		return
+	}
+	s.Emitter.OnContext(CreateActivityEvent, func(ctx context.Context, data any) {
+		activity, ok := data.(*Activity)
+		if !ok {
+			return
+		}
+		title := activity.Description
+		if title == "" {
+			title = fmt.Sprintf("%s #%d: %s", activity.EntityType, activity.EntityId, activity.Action)
+		}
+		s.notifyWatchers(ctx, activity.EntityType, activity.EntityId, activity.UserId, title, CreateActivityEvent,
+			watchURL(activity.EntityType, activity.EntityId))
+	})
+
+	watchablesMu.Lock()
+	watchEvents = s.watchEvents
+	watchablesMu.Unlock()
+
+	for _, model := range Watchables() {
+		s.watchEvents(model)
+	}
+}
+
+// watchEvents notifies the watchers of the records of a watchable of its events
+func (s *ActivityService) watchEvents(model Watchable) {
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+	if s.watched == nil {
+		s.watched = map[string]bool{}
+	}
+	if s.watched[model.Name] {
+		return
+	}
+	s.watched[model.Name] = true
+
+	events := make([]string, 0, len(model.Events))
+	for event := range model.Events {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+	for _, event := range events {
+		change := model.Events[event]
+		s.Emitter.OnContext(event, func(ctx context.Context, data any) {
+			record, ok := data.(interface{ GetId() uint })
+			if !ok {
+				return
+			}
+			name := fmt.Sprintf("%s #%d", model.Name, record.GetId())
+			if model.Title != nil {
+				if title := model.Title(data); title != "" {
+					name = fmt.Sprintf("%q", title)
+				}
+			}
+			actor, _ := middleware.UserFromContext[uint](ctx)
+			s.notifyWatchers(ctx, model.Name, record.GetId(), actor, name+" "+change, event, watchURL(model.Name, record.GetId()))
+		})
+	}
+}
+
+// notifyWatchers notifies the watchers of a record, but the user who changed it, on their
+// channels. Failures are logged: the change doesn't depend on them.
+func (s *ActivityService) notifyWatchers(ctx context.Context, entityType string, entityId, actor uint, title, event, url string) {
+	var watches []*Watch
+	if err := s.DB.WithContext(ctx).
+		Where("entity_type = ? AND entity_id = ? AND user_id <> ?", entityType, entityId, actor).
+		Find(&watches).Error; err != nil {
+		s.Logger.Error("failed to get watchers", logger.String("error", err.Error()))
+		return
+	}
+
+	for _, watch := range watches {
+		if slices.Contains(watch.Channels, WatchInApp) && s.Notifications != nil {
+			_, err := s.Notifications.Create(ctx, &notifications.CreateNotificationRequest{
+				UserId:    watch.UserId,
+				Title:     title,
+				Type:      event,
+				ActionUrl: url,
+			})
+			if err != nil {
+				s.Logger.Error("failed to send watch notification",
+					logger.String("error", err.Error()),
+					logger.Int("user_id", int(watch.UserId)))
+			}
+		}
+		if slices.Contains(watch.Channels, WatchEmail) && s.EmailSender != nil {
+			if err := s.emailWatcher(ctx, watch.UserId, title, url); err != nil {
+				s.Logger.Error("failed to email watcher",
+					logger.String("error", err.Error()),
+					logger.Int("user_id", int(watch.UserId)))
+			}
+		}
+	}
+}
+
+// emailWatcher emails a watcher about a change
+func (s *ActivityService) emailWatcher(ctx context.Context, userId uint, title, url string) error {
+	var user users.User
+	if err := s.DB.WithContext(ctx).Select("id", "email").First(&user, userId).Error; err != nil {
+		return err
+	}
+	body := title
+	if url != "" {
+		body += "\n\n" + url
+	}
+	return s.EmailSender.Send(email.Message{
+		To:      []string{user.Email},
+		From:    s.From,
+		Subject: title,
+		Body:    body,
+	})
+}
+
+// Watch makes a user watch a record on the channels of the request; watching a record again
+// changes the channels of the watch. created reports whether the watch is new.
+func (s *ActivityService) Watch(ctx context.Context, userId uint, req *WatchRequest) (watch *Watch, created bool, err error) {
+	channels, err := validateWatch(req)
+	if err != nil {
+		return nil, false, err
+	}
+
+	watch = &Watch{UserId: userId, EntityType: req.EntityType, EntityId: req.EntityId, Channels: channels}
+	result := s.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(watch)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return watch, true, nil
+	}
+
+	watch = &Watch{}
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND entity_type = ? AND entity_id = ?", userId, req.EntityType, req.EntityId).
+			First(watch).Error; err != nil {
+			return err
+		}
+		watch.Channels = channels
+		return tx.Model(watch).Select("channels").Updates(&Watch{Channels: channels}).Error
+	})
+	return watch, false, err
+}
+
+// Unwatch stops a user watching a record; it returns false when they didn't watch it
+func (s *ActivityService) Unwatch(ctx context.Context, userId uint, entityType string, entityId uint) (bool, error) {
+	result := s.DB.WithContext(ctx).
+		Where("user_id = ? AND entity_type = ? AND entity_id = ?", userId, entityType, entityId).
+		Delete(&Watch{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// UnwatchAll stops a user watching every record, or every record of an entity type, and
+// returns the number of watches removed
+func (s *ActivityService) UnwatchAll(ctx context.Context, userId uint, entityType string) (int64, error) {
+	query := s.DB.WithContext(ctx).Where("user_id = ?", userId)
+	if entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+	result := query.Delete(&Watch{})
+	return result.RowsAffected, result.Error
+}
+
+// Watches returns the records a user watches, the most recently watched first
+func (s *ActivityService) Watches(ctx context.Context, userId uint) ([]*Watch, error) {
+	watches := []*Watch{}
+	err := s.DB.WithContext(ctx).Where("user_id = ?", userId).
+		Order("created_at DESC, id DESC").Find(&watches).Error
+	return watches, err
+}
+
+// validateWatch checks the record and the channels of a watch request and returns its
+// channels without duplicates
+func validateWatch(req *WatchRequest) ([]string, error) {
+	var errs validator.ValidationErrors
+	if err := validateFollow(req.EntityType, req.EntityId); err != nil {
+		errs = append(errs, err.(validator.ValidationErrors)...)
+	}
+
+	channels := []string{}
+	for i, channel := range req.Channels {
+		if !slices.Contains(WatchChannels, channel) {
+			errs = append(errs, validator.ValidationError{
+				Field: fmt.Sprintf("channels[%d]", i), Tag: "oneof", Param: strings.Join(WatchChannels, " "), Value: channel,
+				Message: fmt.Sprintf("channels must be one of %s", strings.Join(WatchChannels, ", ")),
+			})
+			continue
+		}
+		if !slices.Contains(channels, channel) {
+			channels = append(channels, channel)
+		}
+	}
+	if len(req.Channels) == 0 {
+		channels = []string{WatchInApp}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return channels, nil
+}
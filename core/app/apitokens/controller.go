@@ -0,0 +1,127 @@
+package apitokens
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"base/core/emitter"
+	"base/core/router"
+	"base/core/security"
+	"base/core/translation"
+	"base/core/types"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+type TokenController struct {
+	Service *TokenService
+	Emitter *emitter.Emitter
+}
+
+func NewTokenController(service *TokenService, emitter *emitter.Emitter) *TokenController {
+	return &TokenController{
+		Service: service,
+		Emitter: emitter,
+	}
+}
+
+// Routes registers the token endpoints of the signed in user
+func (c *TokenController) Routes(router *router.RouterGroup) {
+	router.POST("/auth/tokens", c.Create)       // Issue
+	router.GET("/auth/tokens", c.List)          // Own tokens
+	router.DELETE("/auth/tokens/:id", c.Revoke) // Revoke
+}
+
+// CreateToken godoc
+// @Summary Issue a scoped token
+// @Description Issue a bearer token of the signed in user restricted to scopes such as media:create, for integrations that shouldn't run with the full identity of the user. The token is returned once. Scoped tokens can't issue tokens
+// @Tags Core/Auth
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param token body CreateTokenRequest true "Token name, scopes and expiry"
+// @Success 201 {object} Issued
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 403 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /auth/tokens [post]
+func (c *TokenController) Create(ctx *router.Context) error {
+	if _, scoped := ctx.Get("token_scopes"); scoped {
+		return ctx.JSON(http.StatusForbidden, types.ErrorResponse{Error: "Scoped tokens can't issue tokens"})
+	}
+
+	var req CreateTokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid input: " + err.Error()})
+	}
+
+	userId := ctx.GetUint("user_id")
+	issued, err := c.Service.Issue(ctx.Request.Context(), userId, &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to issue token")
+	}
+	security.Emit(c.Emitter, ctx, security.APIKeyCreatedEvent, userId, "", map[string]any{
+		"token_id": issued.Id,
+		"name":     issued.Name,
+		"scopes":   issued.Scopes,
+	})
+	return ctx.JSON(http.StatusCreated, issued)
+}
+
+// ListTokens godoc
+// @Summary List scoped tokens
+// @Description List the scoped tokens of the signed in user, without their values
+// @Tags Core/Auth
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} Token
+// @Failure 500 {object} types.ErrorResponse
+// @Router /auth/tokens [get]
+func (c *TokenController) List(ctx *router.Context) error {
+	tokens, err := c.Service.List(ctx.Request.Context(), ctx.GetUint("user_id"))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to fetch tokens")
+	}
+	return ctx.JSON(http.StatusOK, tokens)
+}
+
+// RevokeToken godoc
+// @Summary Revoke a scoped token
+// @Description Revoke a scoped token of the signed in user; requests with it fail from then on
+// @Tags Core/Auth
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param id path int true "Token id"
+// @Success 204
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /auth/tokens/{id} [delete]
+func (c *TokenController) Revoke(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+	if err := c.Service.Revoke(ctx.Request.Context(), ctx.GetUint("user_id"), uint(id)); err != nil {
+		return c.fail(ctx, err, "Failed to revoke token")
+	}
+	return ctx.NoContent()
+}
+
+// fail writes the error response of a service error
+func (c *TokenController) fail(ctx *router.Context, err error, message string) error {
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   translation.Error(ctx, err),
+			Details: translation.LocalizeValidation(ctx, validationErrors),
+		})
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Token not found"})
+	}
+	return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: message + ": " + err.Error()})
+}
@@ -0,0 +1,36 @@
+package apitokens
+
+import "time"
+
+// Token is a bearer token of a user restricted to scopes, e.g. for an integration that only
+// uploads media. Only the hash of the token is stored; it is shown once, when issued.
+type Token struct {
+	Id         uint       `json:"id" gorm:"primarykey"`
+	UserId     uint       `json:"user_id" gorm:"index"`
+	Name       string     `json:"name" gorm:"size:100"`
+	TokenHash  string     `json:"-" gorm:"size:64;uniqueIndex"`
+	Hint       string     `json:"hint" gorm:"size:16"` // Start of the token, to tell tokens apart
+	Scopes     []string   `json:"scopes" gorm:"type:text;serializer:json"`
+	ExpiresAt  *time.Time `json:"expires_at"` // Never when null
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// TableName returns the table name for the Token model
+func (t *Token) TableName() string {
+	return "api_tokens"
+}
+
+// CreateTokenRequest issues a token; scopes are resource:action pairs, where the resource
+// is the first segment of the API path and the action read, create, update, delete or *
+type CreateTokenRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`               // e.g. ["media:create", "pages:read"]
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // Never expires when left out
+}
+
+// Issued is a new token with its value, which isn't shown again
+type Issued struct {
+	*Token
+	Value string `json:"token"`
+}
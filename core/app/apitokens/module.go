@@ -0,0 +1,49 @@
+package apitokens
+
+import (
+	"base/core/module"
+	"base/core/router"
+	"base/core/router/middleware"
+
+	"gorm.io/gorm"
+)
+
+// Module issues bearer tokens restricted to scopes (/auth/tokens) and resolves them for the
+// auth middleware, which only lets them reach the routes their scopes grant
+type Module struct {
+	module.DefaultModule
+	DB         *gorm.DB
+	Service    *TokenService
+	Controller *TokenController
+}
+
+// Init creates and initializes the scoped token module with all dependencies
+func Init(deps module.Dependencies) module.Module {
+	service := NewTokenService(deps.DB, deps.Logger)
+
+	return &Module{
+		DB:         deps.DB,
+		Service:    service,
+		Controller: NewTokenController(service, deps.Emitter),
+	}
+}
+
+// Routes registers the module routes
+func (m *Module) Routes(router *router.RouterGroup) {
+	m.Controller.Routes(router)
+}
+
+func (m *Module) Init() error {
+	middleware.SetScopedTokenResolver(m.Service.Resolve)
+	return nil
+}
+
+func (m *Module) Migrate() error {
+	return m.DB.AutoMigrate(&Token{})
+}
+
+func (m *Module) GetModels() []any {
+	return []any{
+		&Token{},
+	}
+}
@@ -0,0 +1,141 @@
+package apitokens
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"base/core/logger"
+	"base/core/router/middleware"
+	"base/core/security"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+// lastUsedInterval is how often the last use of a token is saved, rather than on every request
+const lastUsedInterval = time.Minute
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
+
+	scopePattern = regexp.MustCompile(`^(\*|[a-z0-9_-]+):(\*|read|create|update|delete)$`)
+)
+
+type TokenService struct {
+	DB     *gorm.DB
+	Logger logger.Logger
+	now    func() time.Time
+}
+
+func NewTokenService(db *gorm.DB, logger logger.Logger) *TokenService {
+	return &TokenService{
+		DB:     db,
+		Logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Issue creates a token of a user restricted to the scopes of the request
+func (s *TokenService) Issue(ctx context.Context, userId uint, req *CreateTokenRequest) (*Issued, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if err := s.validate(req); err != nil {
+		return nil, err
+	}
+
+	value := middleware.ScopedTokenPrefix + security.NewToken(32)
+	token := &Token{
+		UserId:    userId,
+		Name:      req.Name,
+		TokenHash: security.HashToken(value),
+		Hint:      value[:len(middleware.ScopedTokenPrefix)+6],
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := s.DB.WithContext(ctx).Create(token).Error; err != nil {
+		s.Logger.Error("failed to create api token", logger.String("error", err.Error()))
+		return nil, fmt.Errorf("failed to create token: %w", err)
+	}
+	return &Issued{Token: token, Value: value}, nil
+}
+
+// List returns the tokens of a user, newest first
+func (s *TokenService) List(ctx context.Context, userId uint) ([]*Token, error) {
+	tokens := []*Token{}
+	if err := s.DB.WithContext(ctx).Where("user_id = ?", userId).Order("id desc").Find(&tokens).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// Revoke deletes a token of a user; other users' tokens are not found
+func (s *TokenService) Revoke(ctx context.Context, userId, id uint) error {
+	result := s.DB.WithContext(ctx).Where("user_id = ?", userId).Delete(&Token{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke token: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Resolve returns the user and scopes of a token; it is the scoped token resolver of the
+// auth middleware
+func (s *TokenService) Resolve(ctx context.Context, value string) (*middleware.ScopedUser, error) {
+	token := &Token{}
+	err := s.DB.WithContext(ctx).Joins("JOIN users ON users.id = api_tokens.user_id AND users.deleted_at IS NULL").
+		Where("api_tokens.token_hash = ?", security.HashToken(value)).First(token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	if token.ExpiresAt != nil && !now.Before(*token.ExpiresAt) {
+		return nil, ErrTokenExpired
+	}
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= lastUsedInterval {
+		if err := s.DB.WithContext(ctx).Model(token).UpdateColumn("last_used_at", now).Error; err != nil {
+			s.Logger.Error("failed to save api token use", logger.String("error", err.Error()))
+		}
+	}
+	return &middleware.ScopedUser{Id: token.UserId, Scopes: token.Scopes}, nil
+}
+
+// validate checks the name, scopes and expiry of a token request
+func (s *TokenService) validate(req *CreateTokenRequest) error {
+	var errs validator.ValidationErrors
+	if req.Name == "" {
+		errs = append(errs, validator.ValidationError{Field: "name", Tag: "required", Message: "name is required"})
+	} else if len(req.Name) > 100 {
+		errs = append(errs, validator.ValidationError{Field: "name", Tag: "max", Param: "100", Value: req.Name, Message: "name must be at most 100 characters"})
+	}
+	if len(req.Scopes) == 0 {
+		errs = append(errs, validator.ValidationError{Field: "scopes", Tag: "required", Message: "scopes are required"})
+	}
+	for i, scope := range req.Scopes {
+		if !scopePattern.MatchString(scope) {
+			errs = append(errs, validator.ValidationError{
+				Field: fmt.Sprintf("scopes[%d]", i), Tag: "invalid", Value: scope,
+				Message: "scope must be resource:action with an action of read, create, update, delete or *",
+			})
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(s.now()) {
+		errs = append(errs, validator.ValidationError{
+			Field: "expires_at", Tag: "invalid", Value: req.ExpiresAt.Format(time.RFC3339),
+			Message: "expires_at must be in the future",
+		})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -4,8 +4,11 @@ import (
 	"base/core/email"
 	"base/core/logger"
 	"base/core/router"
+	"base/core/security"
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"go.uber.org/zap"
@@ -14,13 +17,15 @@ import (
 type AuthController struct {
 	service     *AuthService
 	emailSender email.Sender
+	throttle    *Throttle
 	logger      logger.Logger
 }
 
-func NewAuthController(service *AuthService, emailSender email.Sender, logger logger.Logger) *AuthController {
+func NewAuthController(service *AuthService, emailSender email.Sender, throttle *Throttle, logger logger.Logger) *AuthController {
 	return &AuthController{
 		service:     service,
 		emailSender: emailSender,
+		throttle:    throttle,
 		logger:      logger,
 	}
 }
@@ -59,7 +64,7 @@ func (c *AuthController) Register(ctx *router.Context) error {
 		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 	}
 
-	user, err := c.service.Register(&req)
+	user, err := c.service.Register(ctx.Request.Context(), &req)
 	if err != nil {
 		// Log the underlying service error to help debug 500s
 		c.logger.Error("Failed to register user",
@@ -105,6 +110,7 @@ func (c *AuthController) Register(ctx *router.Context) error {
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ThrottleResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /auth/login [post]
 func (c *AuthController) Login(ctx *router.Context) error {
@@ -113,9 +119,19 @@ func (c *AuthController) Login(ctx *router.Context) error {
 		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 	}
 
-	response, err := c.service.Login(&req)
+	if refused, err := c.checkThrottle(ctx, ThrottleLogin, req.Email); refused || err != nil {
+		security.Emit(c.service.emitter, ctx, security.LoginFailedEvent, 0, req.Email, map[string]any{"reason": "throttled"})
+		return err
+	}
+
+	response, err := c.service.Login(ctx.Request.Context(), &req)
 	if err != nil {
+		if strings.Contains(err.Error(), "invalid credentials") {
+			// The attempt stays counted as a failure
+			security.Emit(c.service.emitter, ctx, security.LoginFailedEvent, 0, req.Email, map[string]any{"reason": "invalid_credentials"})
+		}
 		if strings.Contains(err.Error(), "access_denied") {
+			c.throttle.Release(ctx, ThrottleLogin, req.Email)
 			// Return both the response and error when user is not an author
 			return ctx.JSON(http.StatusForbidden, map[string]any{
 				"error": err.Error(),
@@ -125,8 +141,11 @@ func (c *AuthController) Login(ctx *router.Context) error {
 		if strings.Contains(err.Error(), "invalid credentials") {
 			return ctx.JSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error()})
 		}
+		c.throttle.Release(ctx, ThrottleLogin, req.Email)
 		return ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Internal server error"})
 	}
+	c.throttle.Succeed(ctx, ThrottleLogin, req.Email)
+	security.Emit(c.service.emitter, ctx, security.LoginSucceededEvent, response.Id, req.Email, nil)
 
 	return ctx.JSON(http.StatusOK, response)
 }
@@ -156,6 +175,7 @@ func (c *AuthController) Logout(ctx *router.Context) error {
 // @Success 200 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ThrottleResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /auth/forgot-password [post]
 func (c *AuthController) ForgotPassword(ctx *router.Context) error {
@@ -165,9 +185,14 @@ func (c *AuthController) ForgotPassword(ctx *router.Context) error {
 		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 	}
 
+	if refused, err := c.checkThrottle(ctx, ThrottleForgotPassword, req.Email); refused || err != nil {
+		return err
+	}
+	// Every request stays counted, as each one sends an email or probes an address
+
 	c.logger.Info("Processing forgot password request", zap.String("email", req.Email))
 
-	err := c.service.ForgotPassword(req.Email)
+	err := c.service.ForgotPassword(ctx.Request.Context(), req.Email)
 	if err != nil {
 		if strings.Contains(err.Error(), "user not found") {
 			return ctx.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
@@ -199,7 +224,7 @@ func (c *AuthController) ResetPassword(ctx *router.Context) error {
 		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request format"})
 	}
 
-	err := c.service.ResetPassword(req.Email, req.Token, req.NewPassword)
+	err := c.service.ResetPassword(ctx.Request.Context(), req.Email, req.Token, req.NewPassword)
 	if err != nil {
 		switch {
 		case errors.Is(err, ErrInvalidToken):
@@ -210,10 +235,37 @@ func (c *AuthController) ResetPassword(ctx *router.Context) error {
 			return ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to reset password"})
 		}
 	}
+	security.Emit(c.service.emitter, ctx, security.PasswordChangedEvent, 0, req.Email, map[string]any{"via": "reset"})
 
 	return ctx.JSON(http.StatusOK, SuccessResponse{Message: "Password reset successful"})
 }
 
+// checkThrottle responds with 429 when the throttle refuses an attempt of the action for the
+// identifier, and reports whether it did
+func (c *AuthController) checkThrottle(ctx *router.Context, action, identifier string) (bool, error) {
+	refusal, err := c.throttle.Check(ctx, action, identifier)
+	if err != nil {
+		c.logger.Error("Failed to verify captcha", logger.String("error", err.Error()))
+		return true, ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to verify captcha"})
+	}
+	if refusal == nil {
+		return false, nil
+	}
+
+	if refusal.Captcha {
+		return true, ctx.JSON(http.StatusTooManyRequests, ThrottleResponse{
+			Error:           "Too many failed attempts, solve the captcha to continue",
+			CaptchaRequired: true,
+		})
+	}
+	retryAfter := int(math.Ceil(refusal.RetryAfter.Seconds()))
+	ctx.SetHeader("Retry-After", strconv.Itoa(retryAfter))
+	return true, ctx.JSON(http.StatusTooManyRequests, ThrottleResponse{
+		Error:      "Too many failed attempts, try again later",
+		RetryAfter: retryAfter,
+	})
+}
+
 func (c *AuthController) getWelcomeEmailBody(name string) string {
 	return "<h1>Welcome to Base!</h1>" +
 		"<p>Hi " + name + ",</p>" +
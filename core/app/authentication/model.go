@@ -74,6 +74,14 @@ type SuccessResponse struct {
 	Message string `json:"message"`
 }
 
+// ThrottleResponse is returned for attempts refused after repeated failures: retry after
+// some seconds, or send a solved captcha in the X-Captcha-Token header
+type ThrottleResponse struct {
+	Error           string `json:"error"`
+	RetryAfter      int    `json:"retry_after,omitempty"`
+	CaptchaRequired bool   `json:"captcha_required,omitempty"`
+}
+
 // VerifyOTPRequest represents the payload to verify an OTP for login
 type VerifyOTPRequest struct {
 	Email string `json:"email" binding:"required,email"`
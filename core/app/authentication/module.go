@@ -1,6 +1,7 @@
 package authentication
 
 import (
+	"base/core/config"
 	"base/core/email"
 	"base/core/emitter"
 	"base/core/logger"
@@ -20,9 +21,16 @@ type AuthenticationModule struct {
 	Emitter     *emitter.Emitter
 }
 
-func NewAuthenticationModule(db *gorm.DB, router *router.RouterGroup, emailSender email.Sender, logger logger.Logger, emitter *emitter.Emitter) module.Module {
+func NewAuthenticationModule(db *gorm.DB, router *router.RouterGroup, emailSender email.Sender, logger logger.Logger, emitter *emitter.Emitter, cfg *config.Config) module.Module {
+	registerEvents()
 	service := NewAuthService(db, emailSender, emitter)
-	controller := NewAuthController(service, emailSender, logger)
+
+	// Repeated failed logins and password reset requests are delayed, then need a captcha
+	var throttle *Throttle
+	if cfg != nil {
+		throttle = NewThrottle(cfg.AuthThrottleAttempts, cfg.AuthThrottleMaxDelay, cfg.AuthCaptchaAfter)
+	}
+	controller := NewAuthController(service, emailSender, throttle, logger)
 
 	authModule := &AuthenticationModule{
 		DB:          db,
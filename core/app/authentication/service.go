@@ -2,6 +2,7 @@ package authentication
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"database/sql"
 	"errors"
@@ -20,6 +21,22 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	RegisteredEvent = "user.registered" // With the types.UserData of the new user
+
+	// LoginAttemptEvent is emitted with the *LoginEvent of a successful password check before
+	// the response is sent; listeners may deny the login or extend the response
+	LoginAttemptEvent = "user.login_attempt"
+)
+
+// registerEvents adds the events of the module to the event catalog
+func registerEvents() {
+	emitter.RegisterEvents(
+		emitter.EventType{Name: RegisteredEvent, Description: "A user registered", Payload: types.UserData{}},
+		emitter.EventType{Name: LoginAttemptEvent, Description: "A user passed the password check; listeners may deny the login", Payload: &LoginEvent{}, Internal: true},
+	)
+}
+
 var (
 	emailTemplateMutex sync.RWMutex
 	emailTemplateCache *template.Template
@@ -46,9 +63,9 @@ func (s *AuthService) ValidateKey(key string) (any, error) {
 }
 
 // validateUser checks if username or email already exists
-func (s *AuthService) validateUser(email, username string) error {
+func (s *AuthService) validateUser(ctx context.Context, email, username string) error {
 	var count int64
-	if err := s.db.Model(&AuthUser{}).
+	if err := s.db.WithContext(ctx).Model(&AuthUser{}).
 		Where("email = ? OR username = ?", email, username).
 		Count(&count).Error; err != nil {
 		return fmt.Errorf("database error: %w", err)
@@ -60,9 +77,9 @@ func (s *AuthService) validateUser(email, username string) error {
 	return nil
 }
 
-func (s *AuthService) Register(req *RegisterRequest) (*AuthResponse, error) {
+func (s *AuthService) Register(ctx context.Context, req *RegisterRequest) (*AuthResponse, error) {
 	// Validate unique constraints first
-	if err := s.validateUser(req.Email, req.Username); err != nil {
+	if err := s.validateUser(ctx, req.Email, req.Username); err != nil {
 		return nil, err
 	}
 
@@ -73,7 +90,7 @@ func (s *AuthService) Register(req *RegisterRequest) (*AuthResponse, error) {
 	}
 
 	// Determine role: first user gets Owner (1), subsequent users get Member (3)
-	roleId := s.determineUserRole()
+	roleId := s.determineUserRole(ctx)
 
 	now := time.Now()
 
@@ -91,7 +108,7 @@ func (s *AuthService) Register(req *RegisterRequest) (*AuthResponse, error) {
 	}
 
 	// Start transaction
-	tx := s.db.Begin()
+	tx := s.db.WithContext(ctx).Begin()
 	if tx.Error != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", tx.Error)
 	}
@@ -127,7 +144,7 @@ func (s *AuthService) Register(req *RegisterRequest) (*AuthResponse, error) {
 
 	// Emit registration event
 	if s.emitter != nil {
-		s.emitter.Emit("user.registered", userData)
+		s.emitter.EmitContext(ctx, RegisteredEvent, userData)
 	} else {
 		fmt.Printf("Emitter is nil in AuthService.Register; cannot emit 'user.registered' event")
 	}
@@ -150,9 +167,9 @@ func (s *AuthService) Register(req *RegisterRequest) (*AuthResponse, error) {
 	}, nil
 }
 
-func (s *AuthService) Login(req *LoginRequest) (*AuthResponse, error) {
+func (s *AuthService) Login(ctx context.Context, req *LoginRequest) (*AuthResponse, error) {
 	var user AuthUser
-	if err := s.db.Where("email = ?", req.Email).First(&user).Error; err != nil {
+	if err := s.db.WithContext(ctx).Where("email = ?", req.Email).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("invalid credentials")
 		}
@@ -195,7 +212,7 @@ func (s *AuthService) Login(req *LoginRequest) (*AuthResponse, error) {
 	}
 
 	// Emit the login attempt event
-	s.emitter.Emit("user.login_attempt", &event)
+	s.emitter.EmitContext(ctx, LoginAttemptEvent, &event)
 
 	// Check if login was allowed after event listeners have processed it
 	if !loginAllowed {
@@ -206,7 +223,7 @@ func (s *AuthService) Login(req *LoginRequest) (*AuthResponse, error) {
 	}
 
 	// Update last login with proper time handling
-	if err := s.db.Model(&user).Update("last_login", sql.NullTime{
+	if err := s.db.WithContext(ctx).Model(&user).Update("last_login", sql.NullTime{
 		Time:  now,
 		Valid: true,
 	}).Error; err != nil {
@@ -216,9 +233,9 @@ func (s *AuthService) Login(req *LoginRequest) (*AuthResponse, error) {
 	return response, nil
 }
 
-func (s *AuthService) ForgotPassword(email string) error {
+func (s *AuthService) ForgotPassword(ctx context.Context, email string) error {
 	var user AuthUser
-	if err := s.db.Where("email = ?", email).First(&user).Error; err != nil {
+	if err := s.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return fmt.Errorf("user not found: %w", err)
 		}
@@ -232,7 +249,7 @@ func (s *AuthService) ForgotPassword(email string) error {
 	expiry := time.Now().Add(15 * time.Minute)
 
 	// Update reset token fields in transaction
-	tx := s.db.Begin()
+	tx := s.db.WithContext(ctx).Begin()
 	if tx.Error != nil {
 		return fmt.Errorf("failed to begin transaction: %w", tx.Error)
 	}
@@ -258,9 +275,9 @@ func (s *AuthService) ForgotPassword(email string) error {
 	return nil
 }
 
-func (s *AuthService) ResetPassword(email, token, newPassword string) error {
+func (s *AuthService) ResetPassword(ctx context.Context, email, token, newPassword string) error {
 	var user AuthUser
-	if err := s.db.Where("email = ?", email).First(&user).Error; err != nil {
+	if err := s.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return fmt.Errorf("user not found: %w", err)
 		}
@@ -281,7 +298,7 @@ func (s *AuthService) ResetPassword(email, token, newPassword string) error {
 	}
 
 	// Update password and clear reset token in transaction
-	tx := s.db.Begin()
+	tx := s.db.WithContext(ctx).Begin()
 	if tx.Error != nil {
 		return fmt.Errorf("failed to begin transaction: %w", tx.Error)
 	}
@@ -379,9 +396,9 @@ func (s *AuthService) sendPasswordChangedEmail(user *AuthUser) error {
 
 // determineUserRole returns the appropriate role ID for a new user
 // First user gets Owner role (1), subsequent users get Member role (3)
-func (s *AuthService) determineUserRole() uint {
+func (s *AuthService) determineUserRole(ctx context.Context) uint {
 	var userCount int64
-	if err := s.db.Model(&AuthUser{}).Count(&userCount).Error; err != nil {
+	if err := s.db.WithContext(ctx).Model(&AuthUser{}).Count(&userCount).Error; err != nil {
 		// If we can't count users, default to Member role for safety
 		return 3 // Member role
 	}
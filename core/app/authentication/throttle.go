@@ -0,0 +1,218 @@
+package authentication
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"base/core/router"
+)
+
+// Throttled actions, each counted separately
+const (
+	ThrottleLogin          = "login"
+	ThrottleForgotPassword = "forgot_password"
+	ThrottleOTP            = "otp" // For OTP verification
+)
+
+const (
+	throttleDelay  = time.Second // Delay after the first failure past the allowed attempts
+	throttleForget = time.Hour   // Failures are forgotten after this long without another
+)
+
+// CaptchaHeader is the header that carries the captcha solution once one is required
+const CaptchaHeader = "X-Captcha-Token"
+
+// CaptchaVerifier checks the captcha solution sent with a request, e.g. with the API of
+// reCAPTCHA, hCaptcha or Turnstile
+type CaptchaVerifier func(ctx *router.Context, token string) (bool, error)
+
+var captchaVerifier CaptchaVerifier
+
+// RegisterCaptcha sets the captcha verifier of the auth endpoints. Without one, repeated
+// failures are only delayed.
+func RegisterCaptcha(verifier CaptchaVerifier) {
+	captchaVerifier = verifier
+}
+
+// Throttle slows down guessing on the auth endpoints. Failures are counted per action, by
+// client IP and by identifier (the email). Past Attempts failures of either, each attempt
+// waits a delay that doubles with every failure, up to MaxDelay; past CaptchaAfter failures
+// a registered captcha must also be solved. Attempts count as failures from Check on, until
+// they succeed.
+type Throttle struct {
+	Attempts     int           // Failures allowed without delay; 0 disables throttling
+	MaxDelay     time.Duration // Longest delay between attempts
+	CaptchaAfter int           // Failures after which a captcha is required; 0 never asks
+
+	mu       sync.Mutex
+	failures map[string]*failures
+	pruned   time.Time
+}
+
+type failures struct {
+	count int
+	last  time.Time
+}
+
+// Refusal is why an attempt isn't allowed: it comes too early or needs a captcha
+type Refusal struct {
+	RetryAfter time.Duration
+	Captcha    bool
+}
+
+// NewThrottle creates a throttle
+func NewThrottle(attempts int, maxDelay time.Duration, captchaAfter int) *Throttle {
+	return &Throttle{
+		Attempts:     attempts,
+		MaxDelay:     maxDelay,
+		CaptchaAfter: captchaAfter,
+		failures:     make(map[string]*failures),
+	}
+}
+
+// Check returns why an attempt of the action for the identifier isn't allowed, or nil. An
+// allowed attempt is counted as a failure right away, under the same lock, so parallel
+// attempts can't all pass before the first one fails; Succeed and Release give it back.
+func (t *Throttle) Check(ctx *router.Context, action, identifier string) (*Refusal, error) {
+	if t == nil || t.Attempts <= 0 {
+		return nil, nil
+	}
+
+	keys := t.keys(ctx, action, identifier)
+	t.mu.Lock()
+	wait, captcha := t.refusal(keys, time.Now())
+	if wait <= 0 && !captcha {
+		t.reserve(keys, time.Now())
+	}
+	t.mu.Unlock()
+	if wait > 0 {
+		return &Refusal{RetryAfter: wait}, nil
+	}
+	if !captcha {
+		return nil, nil
+	}
+
+	// The captcha is verified without holding the lock, then the attempt is reserved unless
+	// another one came first
+	token := ctx.GetHeader(CaptchaHeader)
+	if token == "" {
+		return &Refusal{Captcha: true}, nil
+	}
+	solved, err := captchaVerifier(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if !solved {
+		return &Refusal{Captcha: true}, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if wait, _ := t.refusal(keys, time.Now()); wait > 0 {
+		return &Refusal{RetryAfter: wait}, nil
+	}
+	t.reserve(keys, time.Now())
+	return nil, nil
+}
+
+// Succeed forgets the failures of the action for the identifier and gives back the attempt
+// reserved by Check. The failures of the client IP are kept, so logging into one account
+// doesn't allow guessing others.
+func (t *Throttle) Succeed(ctx *router.Context, action, identifier string) {
+	if t == nil || t.Attempts <= 0 {
+		return
+	}
+
+	keys := t.keys(ctx, action, identifier)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.release(keys[:1])
+	delete(t.failures, keys[1])
+}
+
+// Release gives back the attempt reserved by Check, for attempts that neither succeeded nor
+// failed, e.g. when the server failed
+func (t *Throttle) Release(ctx *router.Context, action, identifier string) {
+	if t == nil || t.Attempts <= 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.release(t.keys(ctx, action, identifier))
+}
+
+// refusal returns how long an attempt with the keys has to wait, and whether it needs a
+// captcha. The lock must be held.
+func (t *Throttle) refusal(keys []string, now time.Time) (time.Duration, bool) {
+	var count int
+	var last time.Time
+	for _, key := range keys {
+		if f := t.failures[key]; f != nil && now.Sub(f.last) <= throttleForget && f.count > count {
+			count, last = f.count, f.last
+		}
+	}
+	if count < t.Attempts {
+		return 0, false
+	}
+	if wait := last.Add(t.delay(count)).Sub(now); wait > 0 {
+		return wait, false
+	}
+	return 0, t.CaptchaAfter > 0 && count >= t.CaptchaAfter && captchaVerifier != nil
+}
+
+// reserve counts an attempt with the keys as a failure. The lock must be held.
+func (t *Throttle) reserve(keys []string, now time.Time) {
+	t.prune(now)
+	for _, key := range keys {
+		f := t.failures[key]
+		if f == nil || now.Sub(f.last) > throttleForget {
+			f = &failures{}
+			t.failures[key] = f
+		}
+		f.count++
+		f.last = now
+	}
+}
+
+// release uncounts an attempt reserved with the keys. The lock must be held.
+func (t *Throttle) release(keys []string) {
+	for _, key := range keys {
+		if f := t.failures[key]; f != nil {
+			if f.count--; f.count <= 0 {
+				delete(t.failures, key)
+			}
+		}
+	}
+}
+
+// keys returns the failure keys of an attempt: by client IP and by identifier
+func (t *Throttle) keys(ctx *router.Context, action, identifier string) []string {
+	return []string{
+		action + ":ip:" + ctx.ClientIP(),
+		action + ":id:" + strings.ToLower(identifier),
+	}
+}
+
+// delay returns the wait after the last of count failures
+func (t *Throttle) delay(count int) time.Duration {
+	exponent := count - t.Attempts
+	if exponent >= 30 {
+		return t.MaxDelay
+	}
+	return min(throttleDelay<<exponent, t.MaxDelay)
+}
+
+// prune drops forgotten failures, at most once a minute
+func (t *Throttle) prune(now time.Time) {
+	if now.Sub(t.pruned) < time.Minute {
+		return
+	}
+	t.pruned = now
+	for key, f := range t.failures {
+		if now.Sub(f.last) > throttleForget {
+			delete(t.failures, key)
+		}
+	}
+}
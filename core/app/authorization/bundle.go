@@ -0,0 +1,250 @@
+package authorization
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"base/core/bundle"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+// bundledPermission is a permission in configuration bundles, matched by its resource type
+// and action
+type bundledPermission struct {
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	ResourceType string `json:"resource_type"`
+	Action       string `json:"action"`
+}
+
+func toBundledPermission(p *Permission) *bundledPermission {
+	return &bundledPermission{Name: p.Name, Description: p.Description, ResourceType: p.ResourceType, Action: p.Action}
+}
+
+// key returns the key of a permission in bundles, e.g. products:update
+func (p *bundledPermission) key() string {
+	return p.ResourceType + ":" + p.Action
+}
+
+// bundledRole is a role in configuration bundles, matched by its name, with the keys of its
+// permissions
+type bundledRole struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	System      bool     `json:"system,omitempty"`
+	Permissions []string `json:"permissions"`
+}
+
+// registerBundle adds the permissions and the roles to configuration bundles. Roles are
+// imported after the permissions they list. System roles keep their name and description,
+// like UpdateRole leaves them, and only their permissions are imported; system roles missing
+// here aren't created, since only the seeds create system roles.
+func registerBundle() {
+	bundle.RegisterSection(bundle.Section{
+		Name:   "permissions",
+		Order:  10,
+		Export: exportPermissions,
+		Import: importPermissions,
+	})
+	bundle.RegisterSection(bundle.Section{
+		Name:   "roles",
+		Order:  20,
+		Export: exportRoles,
+		Import: importRoles,
+	})
+}
+
+func exportPermissions(ctx context.Context, db *gorm.DB) (any, error) {
+	var permissions []Permission
+	if err := db.Order("resource_type, action").Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	records := make([]*bundledPermission, len(permissions))
+	for i, p := range permissions {
+		records[i] = toBundledPermission(&p)
+	}
+	return records, nil
+}
+
+func importPermissions(ctx context.Context, tx *gorm.DB, data json.RawMessage, changes *bundle.Changes) error {
+	var records []*bundledPermission
+	if err := json.Unmarshal(data, &records); err != nil {
+		return validator.ValidationErrors{{Tag: "invalid", Message: "permissions must be a list of permissions"}}
+	}
+
+	var errs validator.ValidationErrors
+	for i, record := range records {
+		required := []struct{ field, value string }{{"resource_type", record.ResourceType}, {"action", record.Action}}
+		for _, r := range required {
+			if r.value == "" {
+				errs = append(errs, validator.ValidationError{
+					Field: fmt.Sprintf("[%d].%s", i, r.field), Tag: "required",
+					Message: r.field + " is required",
+				})
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	for _, record := range records {
+		var permission Permission
+		err := tx.Where("resource_type = ? AND action = ?", record.ResourceType, record.Action).First(&permission).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var before *bundledPermission
+		if err == nil {
+			before = toBundledPermission(&permission)
+		}
+		if !changes.Track(record.key(), before, record) {
+			continue
+		}
+
+		permission.Name = record.Name
+		permission.Description = record.Description
+		permission.ResourceType = record.ResourceType
+		permission.Action = record.Action
+		if err := tx.Save(&permission).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func exportRoles(ctx context.Context, db *gorm.DB) (any, error) {
+	var roles []Role
+	if err := db.Order("name").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	records := make([]*bundledRole, len(roles))
+	for i, role := range roles {
+		keys, err := rolePermissionKeys(db, role.Id)
+		if err != nil {
+			return nil, err
+		}
+		records[i] = &bundledRole{Name: role.Name, Description: role.Description, System: role.IsSystem, Permissions: keys}
+	}
+	return records, nil
+}
+
+func importRoles(ctx context.Context, tx *gorm.DB, data json.RawMessage, changes *bundle.Changes) error {
+	var records []*bundledRole
+	if err := json.Unmarshal(data, &records); err != nil {
+		return validator.ValidationErrors{{Tag: "invalid", Message: "roles must be a list of roles"}}
+	}
+
+	// Permissions of the bundle were imported already
+	var permissions []Permission
+	if err := tx.Find(&permissions).Error; err != nil {
+		return err
+	}
+	permissionIds := make(map[string]uint, len(permissions))
+	for _, p := range permissions {
+		permissionIds[p.ResourceType+":"+p.Action] = p.Id
+	}
+
+	var errs validator.ValidationErrors
+	for i, record := range records {
+		if record.Name == "" {
+			errs = append(errs, validator.ValidationError{
+				Field: fmt.Sprintf("[%d].name", i), Tag: "required", Message: "name is required",
+			})
+		}
+		for j, key := range record.Permissions {
+			if _, ok := permissionIds[key]; !ok {
+				errs = append(errs, validator.ValidationError{
+					Field: fmt.Sprintf("[%d].permissions[%d]", i, j), Tag: "exists", Value: key,
+					Message: fmt.Sprintf("permission %s does not exist", key),
+				})
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	for _, record := range records {
+		keys := append([]string{}, record.Permissions...)
+		slices.Sort(keys)
+		record.Permissions = slices.Compact(keys)
+
+		var role Role
+		err := tx.Where("name = ?", record.Name).First(&role).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		found := err == nil
+		if !found && record.System {
+			changes.Skip(record.Name, "system role doesn't exist and isn't created by imports")
+			continue
+		}
+
+		var before *bundledRole
+		if found {
+			current, err := rolePermissionKeys(tx, role.Id)
+			if err != nil {
+				return err
+			}
+			before = &bundledRole{Name: role.Name, Description: role.Description, System: role.IsSystem, Permissions: current}
+		}
+
+		if found && record.System && !role.IsSystem {
+			changes.Skip(record.Name, "role isn't a system role here and isn't made one by imports")
+		}
+
+		// System roles only take the permissions of the bundle
+		if role.IsSystem {
+			if record.Description != role.Description {
+				changes.Skip(record.Name, ErrSystemRoleUnmodifiable.Error()+": description isn't imported")
+			}
+			record.Description = role.Description
+		}
+		record.System = role.IsSystem
+		if !changes.Track(record.Name, before, record) {
+			continue
+		}
+
+		if !role.IsSystem {
+			role.Name = record.Name
+			role.Description = record.Description
+			if err := tx.Save(&role).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("role_id = ?", role.Id).Delete(&RolePermission{}).Error; err != nil {
+			return err
+		}
+		for _, key := range record.Permissions {
+			if err := tx.Create(&RolePermission{RoleId: role.Id, PermissionId: permissionIds[key]}).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// rolePermissionKeys returns the sorted keys of the permissions of a role
+func rolePermissionKeys(db *gorm.DB, roleId uint) ([]string, error) {
+	var permissions []Permission
+	err := db.Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Where("role_permissions.role_id = ?", roleId).
+		Find(&permissions).Error
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, len(permissions))
+	for i, p := range permissions {
+		keys[i] = p.ResourceType + ":" + p.Action
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
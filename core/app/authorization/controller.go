@@ -1,8 +1,10 @@
 package authorization
 
 import (
+	"base/core/emitter"
 	"base/core/logger"
 	"base/core/router"
+	"base/core/security"
 	"base/core/types"
 	"fmt"
 	"net/http"
@@ -12,13 +14,15 @@ import (
 // AuthorizationController handles HTTP requests for authorization
 type AuthorizationController struct {
 	Service *AuthorizationService
+	Emitter *emitter.Emitter // Receives the security events of permission grants
 	Logger  logger.Logger
 }
 
 // NewAuthorizationController creates a new authorization controller
-func NewAuthorizationController(service *AuthorizationService, logger logger.Logger) *AuthorizationController {
+func NewAuthorizationController(service *AuthorizationService, emitter *emitter.Emitter, logger logger.Logger) *AuthorizationController {
 	return &AuthorizationController{
 		Service: service,
+		Emitter: emitter,
 		Logger:  logger,
 	}
 }
@@ -35,6 +39,7 @@ func (c *AuthorizationController) Routes(router *router.RouterGroup) {
 		authzRoutes.POST("/roles", c.CreateRole)
 		authzRoutes.PUT("/roles/:id", c.UpdateRole)
 		authzRoutes.DELETE("/roles/:id", c.DeleteRole)
+		authzRoutes.POST("/roles/:id/duplicate", c.DuplicateRole)
 
 		// Permission management
 		authzRoutes.GET("/permissions", c.GetPermissions)
@@ -72,7 +77,7 @@ func (c *AuthorizationController) Routes(router *router.RouterGroup) {
 func (c *AuthorizationController) GetRoles(ctx *router.Context) error {
 	c.Logger.Info("Fetching all roles")
 
-	roles, err := c.Service.GetRoles()
+	roles, err := c.Service.GetRoles(ctx.Request.Context())
 	if err != nil {
 		c.Logger.Error("Error getting roles",
 			logger.String("error", err.Error()))
@@ -109,7 +114,7 @@ func (c *AuthorizationController) GetRole(ctx *router.Context) error {
 		})
 	}
 
-	role, err := c.Service.GetRole(roleIdUint)
+	role, err := c.Service.GetRole(ctx.Request.Context(), roleIdUint)
 	if err != nil {
 		if err == ErrRoleNotFound {
 			return ctx.JSON(http.StatusNotFound, types.ErrorResponse{
@@ -152,7 +157,7 @@ func (c *AuthorizationController) CreateRole(ctx *router.Context) error {
 		})
 	}
 
-	if err := c.Service.CreateRole(&role); err != nil {
+	if err := c.Service.CreateRole(ctx.Request.Context(), &role); err != nil {
 		c.Logger.Error("Error creating role",
 			logger.String("error", err.Error()),
 			logger.String("role_name", role.Name))
@@ -201,7 +206,7 @@ func (c *AuthorizationController) UpdateRole(ctx *router.Context) error {
 
 	role.Id = uint(roleIdInt)
 
-	if err := c.Service.UpdateRole(&role); err != nil {
+	if err := c.Service.UpdateRole(ctx.Request.Context(), &role); err != nil {
 		switch err {
 		case ErrRoleNotFound:
 			return ctx.JSON(http.StatusNotFound, types.ErrorResponse{
@@ -250,7 +255,7 @@ func (c *AuthorizationController) DeleteRole(ctx *router.Context) error {
 		})
 	}
 
-	if err := c.Service.DeleteRole(roleIdUint); err != nil {
+	if err := c.Service.DeleteRole(ctx.Request.Context(), roleIdUint); err != nil {
 		switch err {
 		case ErrRoleNotFound:
 			return ctx.JSON(http.StatusNotFound, types.ErrorResponse{
@@ -276,6 +281,50 @@ func (c *AuthorizationController) DeleteRole(ctx *router.Context) error {
 	})
 }
 
+// DuplicateRole creates a copy of a role
+// @Summary Duplicate a role
+// @Description Creates a copy of a role with its permissions; the name gets a -copy suffix (-copy-2... when taken) and copies of system roles are regular roles
+// @Tags Core/Authorization
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path string true "Role Id"
+// @Success 201 {object} object{data=Role} "Role duplicated successfully"
+// @Failure 400 {object} types.ErrorResponse "Invalid role Id"
+// @Failure 404 {object} types.ErrorResponse "Role not found"
+// @Failure 500 {object} types.ErrorResponse "Internal server error"
+// @Router /authorization/roles/{id}/duplicate [post]
+func (c *AuthorizationController) DuplicateRole(ctx *router.Context) error {
+	roleId := ctx.Param("id")
+	roleIdUint, err := strconv.ParseUint(roleId, 10, 64)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error: "Invalid role Id: " + err.Error(),
+		})
+	}
+
+	role, err := c.Service.DuplicateRole(ctx.Request.Context(), roleIdUint)
+	if err != nil {
+		if err == ErrRoleNotFound {
+			return ctx.JSON(http.StatusNotFound, types.ErrorResponse{
+				Error: "Role not found",
+			})
+		}
+
+		c.Logger.Error("Error duplicating role",
+			logger.String("error", err.Error()),
+			logger.String("role_id", roleId))
+
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{
+			Error: "Failed to duplicate role",
+		})
+	}
+
+	return ctx.JSON(http.StatusCreated, map[string]any{
+		"data": role,
+	})
+}
+
 // GetPermissions returns all permissions in the system
 // @Summary Get all permissions
 // @Description Get all permissions in the system
@@ -289,7 +338,7 @@ func (c *AuthorizationController) DeleteRole(ctx *router.Context) error {
 func (c *AuthorizationController) GetPermissions(ctx *router.Context) error {
 	c.Logger.Info("Fetching all permissions")
 
-	permissions, err := c.Service.GetPermissions()
+	permissions, err := c.Service.GetPermissions(ctx.Request.Context())
 	if err != nil {
 		c.Logger.Error("Error getting permissions",
 			logger.String("error", err.Error()))
@@ -326,7 +375,7 @@ func (c *AuthorizationController) GetRolePermissions(ctx *router.Context) error
 		})
 	}
 
-	permissions, err := c.Service.GetRolePermissions(roleIdUint)
+	permissions, err := c.Service.GetRolePermissions(ctx.Request.Context(), roleIdUint)
 	if err != nil {
 		if err == ErrRoleNotFound {
 			return ctx.JSON(http.StatusNotFound, types.ErrorResponse{
@@ -388,7 +437,7 @@ func (c *AuthorizationController) UpdateRolePermissions(ctx *router.Context) err
 		permissionIds[i] = uint64(id)
 	}
 
-	if err := c.Service.UpdateRolePermissions(roleIdUint, permissionIds); err != nil {
+	if err := c.Service.UpdateRolePermissions(ctx.Request.Context(), roleIdUint, permissionIds); err != nil {
 		switch err {
 		case ErrRoleNotFound:
 			return ctx.JSON(http.StatusNotFound, types.ErrorResponse{
@@ -404,6 +453,10 @@ func (c *AuthorizationController) UpdateRolePermissions(ctx *router.Context) err
 			Error: "Failed to update role permissions",
 		})
 	}
+	security.Emit(c.Emitter, ctx, security.PermissionGrantedEvent, 0, "", map[string]any{
+		"role_id":        roleIdUint,
+		"permission_ids": permissionIds,
+	})
 
 	return ctx.JSON(http.StatusOK, map[string]any{
 		"success": true,
@@ -452,7 +505,7 @@ func (c *AuthorizationController) AssignPermission(ctx *router.Context) error {
 		})
 	}
 
-	if err := c.Service.AssignPermissionToRole(roleIdUint, permissionIdUint); err != nil {
+	if err := c.Service.AssignPermissionToRole(ctx.Request.Context(), roleIdUint, permissionIdUint); err != nil {
 		switch err {
 		case ErrRoleNotFound:
 			return ctx.JSON(http.StatusNotFound, types.ErrorResponse{
@@ -477,6 +530,10 @@ func (c *AuthorizationController) AssignPermission(ctx *router.Context) error {
 			Error: "Failed to assign permission",
 		})
 	}
+	security.Emit(c.Emitter, ctx, security.PermissionGrantedEvent, 0, "", map[string]any{
+		"role_id":        roleIdUint,
+		"permission_ids": []uint64{permissionIdUint},
+	})
 
 	return ctx.JSON(http.StatusOK, map[string]any{
 		"success": true,
@@ -515,7 +572,7 @@ func (c *AuthorizationController) RevokePermission(ctx *router.Context) error {
 		})
 	}
 
-	if err := c.Service.RevokePermissionFromRole(roleIdUint, permissionIdUint); err != nil {
+	if err := c.Service.RevokePermissionFromRole(ctx.Request.Context(), roleIdUint, permissionIdUint); err != nil {
 		switch err {
 		case ErrRoleNotFound:
 			return ctx.JSON(http.StatusNotFound, types.ErrorResponse{
@@ -563,7 +620,7 @@ func (c *AuthorizationController) CreateResourcePermission(ctx *router.Context)
 		})
 	}
 
-	if err := c.Service.CreateResourcePermission(&resourcePermission); err != nil {
+	if err := c.Service.CreateResourcePermission(ctx.Request.Context(), &resourcePermission); err != nil {
 		c.Logger.Error("Error creating resource permission",
 			logger.String("error", err.Error()),
 			logger.String("resource_type", resourcePermission.ResourceType),
@@ -573,6 +630,13 @@ func (c *AuthorizationController) CreateResourcePermission(ctx *router.Context)
 			Error: "Failed to create resource permission",
 		})
 	}
+	security.Emit(c.Emitter, ctx, security.PermissionGrantedEvent, resourcePermission.UserId, "", map[string]any{
+		"resource_permission_id": resourcePermission.Id,
+		"resource_type":          resourcePermission.ResourceType,
+		"resource_id":            resourcePermission.ResourceId,
+		"role_id":                resourcePermission.RoleId,
+		"action":                 resourcePermission.Action,
+	})
 
 	return ctx.JSON(http.StatusCreated, map[string]any{
 		"data": resourcePermission,
@@ -600,7 +664,7 @@ func (c *AuthorizationController) DeleteResourcePermission(ctx *router.Context)
 		})
 	}
 
-	if err := c.Service.DeleteResourcePermission(idUint); err != nil {
+	if err := c.Service.DeleteResourcePermission(ctx.Request.Context(), idUint); err != nil {
 		c.Logger.Error("Error deleting resource permission",
 			logger.String("error", err.Error()),
 			logger.String("id", id))
@@ -704,7 +768,7 @@ func (c *AuthorizationController) GetUserPermissions(ctx *router.Context) error
 	c.Logger.Info("Fetching permissions for user",
 		logger.String("user_id", fmt.Sprintf("%d", userID)))
 
-	permissions, err := c.Service.GetUserPermissions(fmt.Sprintf("%d", userID))
+	permissions, err := c.Service.GetUserPermissions(ctx.Request.Context(), fmt.Sprintf("%d", userID))
 	if err != nil {
 		c.Logger.Error("Error getting user permissions",
 			logger.String("error", err.Error()),
@@ -0,0 +1,59 @@
+package authorization
+
+import (
+	"context"
+
+	coregrpc "base/core/grpc"
+	"base/core/grpc/pb"
+
+	"google.golang.org/grpc"
+)
+
+// authorizationServer implements the Authorization gRPC service with the
+// AuthorizationService, so checks give the same result as for the REST API
+type authorizationServer struct {
+	pb.UnimplementedAuthorizationServer
+	service *AuthorizationService
+}
+
+func registerGRPC(service *AuthorizationService) {
+	coregrpc.Register(func(s grpc.ServiceRegistrar) {
+		pb.RegisterAuthorizationServer(s, &authorizationServer{service: service})
+	})
+}
+
+func (s *authorizationServer) HasRole(ctx context.Context, req *pb.HasRoleRequest) (*pb.CheckResponse, error) {
+	if len(req.GetRoles()) == 0 {
+		return &pb.CheckResponse{}, nil
+	}
+	allowed, err := s.service.HasRole(ctx, req.GetUserId(), req.GetRoles()...)
+	if err != nil {
+		return nil, coregrpc.Error(err)
+	}
+	return &pb.CheckResponse{Allowed: allowed}, nil
+}
+
+// HasPermission checks the permissions of the user's role, like RequirePermission
+func (s *authorizationServer) HasPermission(ctx context.Context, req *pb.HasPermissionRequest) (*pb.CheckResponse, error) {
+	allowed, err := s.service.HasRolePermission(ctx, req.GetUserId(), req.GetResourceType(), req.GetAction())
+	if err != nil {
+		return nil, coregrpc.Error(err)
+	}
+	return &pb.CheckResponse{Allowed: allowed}, nil
+}
+
+// HasResourcePermission allows the action on a resource when the user's role has the
+// permission on every resource of the type, or a resource permission grants it on the resource
+func (s *authorizationServer) HasResourcePermission(ctx context.Context, req *pb.HasResourcePermissionRequest) (*pb.CheckResponse, error) {
+	allowed, err := s.service.HasRolePermission(ctx, req.GetUserId(), req.GetResourceType(), req.GetAction())
+	if err != nil {
+		return nil, coregrpc.Error(err)
+	}
+	if !allowed {
+		allowed, err = s.service.HasGrant(ctx, req.GetUserId(), req.GetResourceType(), req.GetResourceId(), req.GetAction())
+		if err != nil {
+			return nil, coregrpc.Error(err)
+		}
+	}
+	return &pb.CheckResponse{Allowed: allowed}, nil
+}
@@ -0,0 +1,89 @@
+package authorization_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"base/core/app/authorization"
+	coregrpc "base/core/grpc"
+	"base/core/grpc/pb"
+	"base/core/testutil"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// dialAuthorization starts the gRPC server of the app's modules and returns a client of its
+// Authorization service
+func dialAuthorization(t *testing.T, app *testutil.App) (pb.AuthorizationClient, context.Context) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	server, err := coregrpc.NewServer(addr, "test-key", app.Logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Stop(time.Second) })
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), coregrpc.APIKeyHeader, "test-key")
+	return pb.NewAuthorizationClient(conn), ctx
+}
+
+func TestGRPCPermissionChecks(t *testing.T) {
+	app := testutil.New(t, testutil.Options{})
+	admin := app.CreateUser("Super Admin")
+	viewer := app.CreateUser("Viewer")
+	client, ctx := dialAuthorization(t, app)
+
+	check := func(name string, call func() (*pb.CheckResponse, error), want bool) {
+		t.Helper()
+		res, err := call()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if res.GetAllowed() != want {
+			t.Errorf("%s = %t, want %t", name, res.GetAllowed(), want)
+		}
+	}
+	hasPermission := func(userId uint, resourceType, action string) func() (*pb.CheckResponse, error) {
+		return func() (*pb.CheckResponse, error) {
+			return client.HasPermission(ctx, &pb.HasPermissionRequest{UserId: uint64(userId), ResourceType: resourceType, Action: action})
+		}
+	}
+	hasResourcePermission := func(userId uint, resourceType, resourceId, action string) func() (*pb.CheckResponse, error) {
+		return func() (*pb.CheckResponse, error) {
+			return client.HasResourcePermission(ctx, &pb.HasResourcePermissionRequest{
+				UserId: uint64(userId), ResourceType: resourceType, ResourceId: resourceId, Action: action,
+			})
+		}
+	}
+
+	check("viewer deletes users", hasPermission(viewer.Id, "user", "delete"), false)
+	check("viewer reads users", hasPermission(viewer.Id, "user", "read"), true)
+	check("admin deletes users", hasPermission(admin.Id, "user", "delete"), true)
+
+	check("viewer deletes media 7", hasResourcePermission(viewer.Id, "media", "7", "delete"), false)
+	grant := &authorization.ResourcePermission{UserId: viewer.Id, ResourceType: "media", ResourceId: "7", Action: "delete"}
+	if err := app.DB.Create(grant).Error; err != nil {
+		t.Fatal(err)
+	}
+	check("viewer deletes granted media 7", hasResourcePermission(viewer.Id, "media", "7", "delete"), true)
+	check("viewer deletes media 8", hasResourcePermission(viewer.Id, "media", "8", "delete"), false)
+}
@@ -196,3 +196,69 @@ func RequireRole(roleName string) router.MiddlewareFunc {
 		}
 	}
 }
+
+// RequireAdmin creates a middleware function that only lets users with one of the
+// AdminRoles through
+func RequireAdmin(service *AuthorizationService) router.MiddlewareFunc {
+	return func(next router.HandlerFunc) router.HandlerFunc {
+		return func(c *router.Context) error {
+			userId, err := GetUserIdFromContext(c)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, map[string]any{
+					"error": err.Error(),
+				})
+				return nil
+			}
+
+			isAdmin, err := service.HasRole(c.Request.Context(), userId, AdminRoles...)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, map[string]any{
+					"error": fmt.Sprintf("error checking role: %v", err),
+				})
+				return nil
+			}
+
+			if !isAdmin {
+				c.AbortWithStatusJSON(http.StatusForbidden, map[string]any{
+					"error": "admin role required",
+				})
+				return nil
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// RequirePermission creates a middleware function that only lets users whose role has been
+// given the permission to perform the action on the resource type through
+func RequirePermission(service *AuthorizationService, resourceType, action string) router.MiddlewareFunc {
+	return func(next router.HandlerFunc) router.HandlerFunc {
+		return func(c *router.Context) error {
+			userId, err := GetUserIdFromContext(c)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, map[string]any{
+					"error": err.Error(),
+				})
+				return nil
+			}
+
+			allowed, err := service.HasRolePermission(c.Request.Context(), userId, resourceType, action)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, map[string]any{
+					"error": fmt.Sprintf("error checking permission: %v", err),
+				})
+				return nil
+			}
+
+			if !allowed {
+				c.AbortWithStatusJSON(http.StatusForbidden, map[string]any{
+					"error": fmt.Sprintf("permission denied: cannot %s %s", action, resourceType),
+				})
+				return nil
+			}
+
+			return next(c)
+		}
+	}
+}
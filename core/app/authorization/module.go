@@ -3,6 +3,7 @@ package authorization
 import (
 	"errors"
 
+	"base/core/emitter"
 	"base/core/logger"
 	"base/core/module"
 	"base/core/router"
@@ -20,9 +21,15 @@ type AuthorizationModule struct {
 	Logger     logger.Logger
 }
 
-func NewAuthorizationModule(db *gorm.DB, router *router.RouterGroup, logger logger.Logger) module.Module {
+func NewAuthorizationModule(db *gorm.DB, router *router.RouterGroup, logger logger.Logger, emitter *emitter.Emitter) module.Module {
 	service := NewAuthorizationService(db)
-	controller := NewAuthorizationController(service, logger)
+	controller := NewAuthorizationController(service, emitter, logger)
+
+	// Internal gRPC service (see core/grpc)
+	registerGRPC(service)
+
+	// Roles and permissions are promoted between environments with configuration bundles
+	registerBundle()
 
 	authzModule := &AuthorizationModule{
 		DB:         db,
@@ -154,6 +161,12 @@ func (m *AuthorizationModule) seedDefaultData() error {
 			ResourceType: "permission",
 			Action:       "assign",
 		},
+		{
+			Name:         "Assign Roles",
+			Description:  "Change the role of users",
+			ResourceType: "role",
+			Action:       "assign",
+		},
 	}
 	defaultPermissions = append(defaultPermissions, specialPermissions...)
 
@@ -221,7 +234,7 @@ func (m *AuthorizationModule) seedDefaultData() error {
 			"authorization:create", "authorization:read", "authorization:update", "authorization:delete", "authorization:list",
 			"media:create", "media:read", "media:update", "media:delete", "media:list",
 			"profile:create", "profile:read", "profile:update", "profile:delete", "profile:list",
-			"role:create", "role:read", "role:update", "role:delete", "role:list",
+			"role:create", "role:read", "role:update", "role:delete", "role:list", "role:assign",
 			"permission:create", "permission:read", "permission:update", "permission:delete", "permission:list",
 			"resource_permission:create", "resource_permission:read", "resource_permission:update", "resource_permission:delete", "resource_permission:list",
 		}
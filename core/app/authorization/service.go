@@ -1,11 +1,16 @@
 package authorization
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
+	"base/core/crud"
+	"base/core/explain"
+
 	"gorm.io/gorm"
 )
 
@@ -22,9 +27,9 @@ func NewAuthorizationService(db *gorm.DB) *AuthorizationService {
 }
 
 // GetRoles returns all roles
-func (s *AuthorizationService) GetRoles() ([]Role, error) {
+func (s *AuthorizationService) GetRoles(ctx context.Context) ([]Role, error) {
 	var roles []Role
-	result := s.DB.Find(&roles)
+	result := s.DB.WithContext(ctx).Find(&roles)
 
 	if result.Error != nil {
 		return nil, result.Error
@@ -34,7 +39,7 @@ func (s *AuthorizationService) GetRoles() ([]Role, error) {
 	for i := range roles {
 		// Count permissions for this role
 		var count int64
-		if err := s.DB.Model(&RolePermission{}).
+		if err := s.DB.WithContext(ctx).Model(&RolePermission{}).
 			Where("role_id = ?", roles[i].Id).
 			Count(&count).Error; err != nil {
 			// Log the error but continue
@@ -48,9 +53,9 @@ func (s *AuthorizationService) GetRoles() ([]Role, error) {
 }
 
 // GetPermissions returns all permissions
-func (s *AuthorizationService) GetPermissions() ([]Permission, error) {
+func (s *AuthorizationService) GetPermissions(ctx context.Context) ([]Permission, error) {
 	var permissions []Permission
-	result := s.DB.Find(&permissions)
+	result := s.DB.WithContext(ctx).Find(&permissions)
 
 	if result.Error != nil {
 		return nil, result.Error
@@ -60,9 +65,9 @@ func (s *AuthorizationService) GetPermissions() ([]Permission, error) {
 }
 
 // GetRole returns a role by Id
-func (s *AuthorizationService) GetRole(id uint64) (*Role, error) {
+func (s *AuthorizationService) GetRole(ctx context.Context, id uint64) (*Role, error) {
 	var role Role
-	result := s.DB.First(&role, "id = ?", id)
+	result := s.DB.WithContext(ctx).First(&role, "id = ?", id)
 
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -75,19 +80,19 @@ func (s *AuthorizationService) GetRole(id uint64) (*Role, error) {
 }
 
 // CreateRole creates a new role
-func (s *AuthorizationService) CreateRole(role *Role) error {
+func (s *AuthorizationService) CreateRole(ctx context.Context, role *Role) error {
 	// Set creation time
 	role.CreatedAt = time.Now()
 	role.UpdatedAt = time.Now()
 
-	result := s.DB.Create(role)
+	result := s.DB.WithContext(ctx).Create(role)
 	return result.Error
 }
 
 // UpdateRole updates an existing role
-func (s *AuthorizationService) UpdateRole(role *Role) error {
+func (s *AuthorizationService) UpdateRole(ctx context.Context, role *Role) error {
 	var existingRole Role
-	result := s.DB.First(&existingRole, "id = ?", role.Id)
+	result := s.DB.WithContext(ctx).First(&existingRole, "id = ?", role.Id)
 
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -106,7 +111,7 @@ func (s *AuthorizationService) UpdateRole(role *Role) error {
 	existingRole.Description = role.Description
 	existingRole.UpdatedAt = time.Now()
 
-	result = s.DB.Save(&existingRole)
+	result = s.DB.WithContext(ctx).Save(&existingRole)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -117,10 +122,47 @@ func (s *AuthorizationService) UpdateRole(role *Role) error {
 	return nil
 }
 
+// DuplicateRole creates a copy of a role with its permissions, named after it with a -copy
+// suffix. Copies of system roles are regular roles.
+func (s *AuthorizationService) DuplicateRole(ctx context.Context, id uint64) (*Role, error) {
+	var role *Role
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		role, err = crud.Copy(tx, uint(id), crud.CopyOptions[Role]{
+			Unique: []string{"name"},
+			Change: func(tx *gorm.DB, copied *Role) error {
+				copied.IsSystem = false
+				return nil
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		var permissions []RolePermission
+		if err := tx.Where("role_id = ?", id).Find(&permissions).Error; err != nil || len(permissions) == 0 {
+			return err
+		}
+		for i := range permissions {
+			permissions[i].Id = 0
+			permissions[i].RoleId = role.Id
+			permissions[i].CreatedAt = time.Time{}
+		}
+		return tx.Omit("Role", "Permission").Create(&permissions).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRoleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
 // DeleteRole deletes a role
-func (s *AuthorizationService) DeleteRole(id uint64) error {
+func (s *AuthorizationService) DeleteRole(ctx context.Context, id uint64) error {
 	var existingRole Role
-	result := s.DB.First(&existingRole, "id = ?", id)
+	result := s.DB.WithContext(ctx).First(&existingRole, "id = ?", id)
 
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -135,22 +177,22 @@ func (s *AuthorizationService) DeleteRole(id uint64) error {
 	}
 
 	// First delete associated role permissions
-	if err := s.DB.Where("role_id = ?", id).Delete(&RolePermission{}).Error; err != nil {
+	if err := s.DB.WithContext(ctx).Where("role_id = ?", id).Delete(&RolePermission{}).Error; err != nil {
 		return err
 	}
 
 	// Then delete the role
-	result = s.DB.Delete(&existingRole)
+	result = s.DB.WithContext(ctx).Delete(&existingRole)
 	return result.Error
 }
 
 // GetRolePermissions returns all permissions for a role
-func (s *AuthorizationService) GetRolePermissions(roleId uint64) ([]Permission, error) {
+func (s *AuthorizationService) GetRolePermissions(ctx context.Context, roleId uint64) ([]Permission, error) {
 	// Convert string Id to uint
 
 	// Check if role exists
 	var role Role
-	result := s.DB.First(&role, "id = ?", roleId)
+	result := s.DB.WithContext(ctx).First(&role, "id = ?", roleId)
 
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -161,7 +203,7 @@ func (s *AuthorizationService) GetRolePermissions(roleId uint64) ([]Permission,
 
 	// Get permissions
 	var permissions []Permission
-	err := s.DB.Raw(`
+	err := s.DB.WithContext(ctx).Raw(`
 		SELECT p.* FROM permissions p
 		JOIN role_permissions rp ON p.id = rp.permission_id
 		WHERE rp.role_id = ?
@@ -175,10 +217,10 @@ func (s *AuthorizationService) GetRolePermissions(roleId uint64) ([]Permission,
 }
 
 // UpdateRolePermissions replaces all permissions for a role
-func (s *AuthorizationService) UpdateRolePermissions(roleId uint64, permissionIds []uint64) error {
+func (s *AuthorizationService) UpdateRolePermissions(ctx context.Context, roleId uint64, permissionIds []uint64) error {
 	// Check if role exists
 	var role Role
-	result := s.DB.First(&role, "id = ?", roleId)
+	result := s.DB.WithContext(ctx).First(&role, "id = ?", roleId)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return ErrRoleNotFound
@@ -187,7 +229,7 @@ func (s *AuthorizationService) UpdateRolePermissions(roleId uint64, permissionId
 	}
 
 	// Begin transaction
-	tx := s.DB.Begin()
+	tx := s.DB.WithContext(ctx).Begin()
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
@@ -235,11 +277,11 @@ func (s *AuthorizationService) UpdateRolePermissions(roleId uint64, permissionId
 }
 
 // AssignPermissionToRole assigns a permission to a role
-func (s *AuthorizationService) AssignPermissionToRole(roleId uint64, permissionId uint64) error {
+func (s *AuthorizationService) AssignPermissionToRole(ctx context.Context, roleId uint64, permissionId uint64) error {
 
 	// Check if role exists
 	var role Role
-	result := s.DB.First(&role, "id = ?", roleId)
+	result := s.DB.WithContext(ctx).First(&role, "id = ?", roleId)
 
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -250,7 +292,7 @@ func (s *AuthorizationService) AssignPermissionToRole(roleId uint64, permissionI
 
 	// Check if permission exists
 	var permission Permission
-	result = s.DB.First(&permission, "id = ?", permissionId)
+	result = s.DB.WithContext(ctx).First(&permission, "id = ?", permissionId)
 
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -261,7 +303,7 @@ func (s *AuthorizationService) AssignPermissionToRole(roleId uint64, permissionI
 
 	// Check if permission is already assigned
 	var count int64
-	s.DB.Model(&RolePermission{}).
+	s.DB.WithContext(ctx).Model(&RolePermission{}).
 		Where("role_id = ? AND permission_id = ?", roleId, permissionId).
 		Count(&count)
 
@@ -276,15 +318,15 @@ func (s *AuthorizationService) AssignPermissionToRole(roleId uint64, permissionI
 		CreatedAt:    time.Now(),
 	}
 
-	result = s.DB.Create(&rolePermission)
+	result = s.DB.WithContext(ctx).Create(&rolePermission)
 	return result.Error
 }
 
 // RevokePermissionFromRole removes a permission from a role
-func (s *AuthorizationService) RevokePermissionFromRole(roleId uint64, permissionId uint64) error {
+func (s *AuthorizationService) RevokePermissionFromRole(ctx context.Context, roleId uint64, permissionId uint64) error {
 	// Check if role exists
 	var role Role
-	result := s.DB.First(&role, "id = ?", roleId)
+	result := s.DB.WithContext(ctx).First(&role, "id = ?", roleId)
 
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -295,7 +337,7 @@ func (s *AuthorizationService) RevokePermissionFromRole(roleId uint64, permissio
 
 	// Check if permission exists
 	var permission Permission
-	result = s.DB.First(&permission, "id = ?", permissionId)
+	result = s.DB.WithContext(ctx).First(&permission, "id = ?", permissionId)
 
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -305,25 +347,25 @@ func (s *AuthorizationService) RevokePermissionFromRole(roleId uint64, permissio
 	}
 
 	// Delete role permission
-	result = s.DB.Where("role_id = ? AND permission_id = ?", roleId, permissionId).
+	result = s.DB.WithContext(ctx).Where("role_id = ? AND permission_id = ?", roleId, permissionId).
 		Delete(&RolePermission{})
 
 	return result.Error
 }
 
 // CreateResourcePermission creates a resource-specific permission
-func (s *AuthorizationService) CreateResourcePermission(rp *ResourcePermission) error {
+func (s *AuthorizationService) CreateResourcePermission(ctx context.Context, rp *ResourcePermission) error {
 	// Set creation time
 	rp.CreatedAt = time.Now()
 	rp.UpdatedAt = time.Now()
 
-	result := s.DB.Create(rp)
+	result := s.DB.WithContext(ctx).Create(rp)
 	return result.Error
 }
 
 // DeleteResourcePermission deletes a resource-specific permission
-func (s *AuthorizationService) DeleteResourcePermission(id uint64) error {
-	result := s.DB.Delete(&ResourcePermission{}, "id = ?", id)
+func (s *AuthorizationService) DeleteResourcePermission(ctx context.Context, id uint64) error {
+	result := s.DB.WithContext(ctx).Delete(&ResourcePermission{}, "id = ?", id)
 	return result.Error
 }
 
@@ -349,6 +391,55 @@ func (s *AuthorizationService) HasPermission(userId uint64, resourceType, action
 	return true, nil
 }
 
+// AdminRoles are the system roles with full access to the admin API
+var AdminRoles = []string{"Super Admin", "Owner", "Administrator"}
+
+// HasRole checks if the user's role is one of the given role names
+func (s *AuthorizationService) HasRole(ctx context.Context, userId uint64, roleNames ...string) (bool, error) {
+	var count int64
+	err := s.DB.WithContext(ctx).Table("users").
+		Joins("JOIN roles ON roles.id = users.role_id").
+		Where("users.id = ? AND users.deleted_at IS NULL AND roles.name IN ?", userId, roleNames).
+		Count(&count).Error
+	if err == nil {
+		explain.FromContext(ctx).Decision("role", fmt.Sprintf("user %d in %s", userId, strings.Join(roleNames, ", ")), count > 0)
+	}
+	return count > 0, err
+}
+
+// HasRolePermission checks if the user's role has been given the permission to perform the
+// action on the resource type
+func (s *AuthorizationService) HasRolePermission(ctx context.Context, userId uint64, resourceType, action string) (bool, error) {
+	var count int64
+	err := s.DB.WithContext(ctx).Table("users").
+		Joins("JOIN role_permissions ON role_permissions.role_id = users.role_id").
+		Joins("JOIN permissions ON permissions.id = role_permissions.permission_id").
+		Where("users.id = ? AND users.deleted_at IS NULL AND permissions.resource_type = ? AND permissions.action = ?",
+			userId, resourceType, action).
+		Count(&count).Error
+	if err == nil {
+		explain.FromContext(ctx).Decision("role permission", fmt.Sprintf("user %d: %s %s", userId, action, resourceType), count > 0)
+	}
+	return count > 0, err
+}
+
+// HasRoleWithin checks that a role gives no permission the role of the user lacks, so that
+// the user may grant it or take it away
+func (s *AuthorizationService) HasRoleWithin(ctx context.Context, userId uint64, roleId uint) (bool, error) {
+	held := s.DB.WithContext(ctx).Table("role_permissions").
+		Joins("JOIN users ON users.role_id = role_permissions.role_id").
+		Select("role_permissions.permission_id").
+		Where("users.id = ? AND users.deleted_at IS NULL", userId)
+	var count int64
+	err := s.DB.WithContext(ctx).Table("role_permissions").
+		Where("role_id = ? AND permission_id NOT IN (?)", roleId, held).
+		Count(&count).Error
+	if err == nil {
+		explain.FromContext(ctx).Decision("role within", fmt.Sprintf("user %d: role %d", userId, roleId), count == 0)
+	}
+	return count == 0, err
+}
+
 // HasResourcePermission checks if a user has permission for a specific resource
 func (s *AuthorizationService) HasResourcePermission(userId uint64, resourceType, resourceId, action string) (bool, error) {
 	// Simplified resource permission check without organization context
@@ -357,8 +448,66 @@ func (s *AuthorizationService) HasResourcePermission(userId uint64, resourceType
 	return true, nil
 }
 
+// HasGrant checks if a resource permission grants the user the action on a resource, or on
+// every resource of its type (resource permissions without a resource id)
+func (s *AuthorizationService) HasGrant(ctx context.Context, userId uint64, resourceType, resourceId, action string) (bool, error) {
+	query, err := s.grants(ctx, userId, resourceType, action)
+	if err != nil {
+		return false, err
+	}
+
+	var count int64
+	err = query.Where("resource_id = ? OR resource_id = '' OR resource_id IS NULL", resourceId).Count(&count).Error
+	if err == nil {
+		explain.FromContext(ctx).Decision("grant", fmt.Sprintf("user %d: %s %s %s", userId, action, resourceType, resourceId), count > 0)
+	}
+	return count > 0, err
+}
+
+// GrantedResources returns the ids of the resources of the type on which resource
+// permissions grant the user the action; all is true when one grants it on every resource
+func (s *AuthorizationService) GrantedResources(ctx context.Context, userId uint64, resourceType, action string) (ids []string, all bool, err error) {
+	query, err := s.grants(ctx, userId, resourceType, action)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var resourceIds []*string
+	if err := query.Pluck("resource_id", &resourceIds).Error; err != nil {
+		return nil, false, err
+	}
+	report := explain.FromContext(ctx)
+	for _, id := range resourceIds {
+		if id == nil || *id == "" {
+			report.Decision("grants", fmt.Sprintf("user %d: %s every %s", userId, action, resourceType), true)
+			return nil, true, nil
+		}
+		ids = append(ids, *id)
+	}
+	report.Decision("grants", fmt.Sprintf("user %d: %s %s %s", userId, action, resourceType, strings.Join(ids, ", ")), len(ids) > 0)
+	return ids, false, nil
+}
+
+// grants returns the query of the resource permissions granting the action on resources of
+// the type to the user, directly or through their role
+func (s *AuthorizationService) grants(ctx context.Context, userId uint64, resourceType, action string) (*gorm.DB, error) {
+	var roleIds []uint
+	err := s.DB.WithContext(ctx).Table("users").
+		Where("id = ? AND deleted_at IS NULL", userId).
+		Pluck("role_id", &roleIds).Error
+	if err != nil {
+		return nil, err
+	}
+
+	query := s.DB.WithContext(ctx).Model(&ResourcePermission{}).Where("resource_type = ? AND action = ?", resourceType, action)
+	if len(roleIds) > 0 && roleIds[0] != 0 {
+		return query.Where("user_id = ? OR role_id = ?", userId, strconv.FormatUint(uint64(roleIds[0]), 10)), nil
+	}
+	return query.Where("user_id = ?", userId), nil
+}
+
 // GetUserPermissions returns all permissions for a user across all organizations
-func (s *AuthorizationService) GetUserPermissions(userId string) ([]Permission, error) {
+func (s *AuthorizationService) GetUserPermissions(ctx context.Context, userId string) ([]Permission, error) {
 	// Convert string Id to uint
 	userIdUint, err := strconv.ParseUint(userId, 10, 32)
 	if err != nil {
@@ -370,7 +519,7 @@ func (s *AuthorizationService) GetUserPermissions(userId string) ([]Permission,
 
 	// Get permissions from role-based permissions
 	var permissions []Permission
-	err = s.DB.Raw(`
+	err = s.DB.WithContext(ctx).Raw(`
 		SELECT DISTINCT p.* FROM permissions p
 		JOIN role_permissions rp ON p.id = rp.permission_id
 		JOIN users u ON u.role_id = rp.role_id
@@ -386,7 +535,7 @@ func (s *AuthorizationService) GetUserPermissions(userId string) ([]Permission,
 
 	// Get permissions from resource-specific permissions
 	var resourcePermissions []Permission
-	err = s.DB.Raw(`
+	err = s.DB.WithContext(ctx).Raw(`
 		SELECT DISTINCT p.* FROM permissions p
 		JOIN resource_permissions rp ON p.id = rp.permission_id
 		WHERE rp.user_id = ?
@@ -421,7 +570,7 @@ func (s *AuthorizationService) GetUserPermissions(userId string) ([]Permission,
 }
 
 // SeedPermissions creates default permissions if they don't exist
-func (s *AuthorizationService) SeedPermissions() error {
+func (s *AuthorizationService) SeedPermissions(ctx context.Context) error {
 	// Define resource types and actions (aligned with module seeding) Only for system roles and core modules
 	resourceTypes := []string{
 		"user", "authorization", "media", "profile",
@@ -434,7 +583,7 @@ func (s *AuthorizationService) SeedPermissions() error {
 			var permission Permission
 
 			// Check if permission already exists
-			result := s.DB.Where("resource_type = ? AND action = ?", resourceType, action).First(&permission)
+			result := s.DB.WithContext(ctx).Where("resource_type = ? AND action = ?", resourceType, action).First(&permission)
 			if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 				// Create permission
 				permission = Permission{
@@ -446,7 +595,7 @@ func (s *AuthorizationService) SeedPermissions() error {
 					UpdatedAt:    time.Now(),
 				}
 
-				if err := s.DB.Create(&permission).Error; err != nil {
+				if err := s.DB.WithContext(ctx).Create(&permission).Error; err != nil {
 					return err
 				}
 			} else if result.Error != nil {
@@ -459,7 +608,7 @@ func (s *AuthorizationService) SeedPermissions() error {
 }
 
 // SeedRoles creates default roles if they don't exist
-func (s *AuthorizationService) SeedRoles() error {
+func (s *AuthorizationService) SeedRoles(ctx context.Context) error {
 	// Define default roles
 	defaultRoles := []Role{
 		{
@@ -495,10 +644,10 @@ func (s *AuthorizationService) SeedRoles() error {
 	// Create roles if they don't exist
 	for _, role := range defaultRoles {
 		var existingRole Role
-		result := s.DB.First(&existingRole, "name = ? AND is_system = ?", role.Name, role.IsSystem)
+		result := s.DB.WithContext(ctx).First(&existingRole, "name = ? AND is_system = ?", role.Name, role.IsSystem)
 
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			if err := s.DB.Create(&role).Error; err != nil {
+			if err := s.DB.WithContext(ctx).Create(&role).Error; err != nil {
 				return err
 			}
 		} else if result.Error != nil {
@@ -510,26 +659,26 @@ func (s *AuthorizationService) SeedRoles() error {
 }
 
 // SetupRolePermissions assigns default permissions to system roles
-func (s *AuthorizationService) SetupRolePermissions() error {
+func (s *AuthorizationService) SetupRolePermissions(ctx context.Context) error {
 	// First seed permissions
-	if err := s.SeedPermissions(); err != nil {
+	if err := s.SeedPermissions(ctx); err != nil {
 		return err
 	}
 
 	// Then seed roles
-	if err := s.SeedRoles(); err != nil {
+	if err := s.SeedRoles(ctx); err != nil {
 		return err
 	}
 
 	// Get all permissions
 	var permissions []Permission
-	if err := s.DB.Find(&permissions).Error; err != nil {
+	if err := s.DB.WithContext(ctx).Find(&permissions).Error; err != nil {
 		return err
 	}
 
 	// Get the owner role
 	var ownerRole Role
-	if err := s.DB.Where("name = ? AND is_system = ?", "Owner", true).First(&ownerRole).Error; err != nil {
+	if err := s.DB.WithContext(ctx).Where("name = ? AND is_system = ?", "Owner", true).First(&ownerRole).Error; err != nil {
 		return err
 	}
 
@@ -542,7 +691,7 @@ func (s *AuthorizationService) SetupRolePermissions() error {
 
 		// Check if permission is already assigned
 		var count int64
-		s.DB.Model(&RolePermission{}).
+		s.DB.WithContext(ctx).Model(&RolePermission{}).
 			Where("role_id = ? AND permission_id = ?", ownerRole.Id, permission.Id).
 			Count(&count)
 
@@ -553,7 +702,7 @@ func (s *AuthorizationService) SetupRolePermissions() error {
 				CreatedAt:    time.Now(),
 			}
 
-			if err := s.DB.Create(&rolePermission).Error; err != nil {
+			if err := s.DB.WithContext(ctx).Create(&rolePermission).Error; err != nil {
 				return err
 			}
 		}
@@ -561,7 +710,7 @@ func (s *AuthorizationService) SetupRolePermissions() error {
 
 	// Get the admin role
 	var adminRole Role
-	if err := s.DB.Where("name = ? AND is_system = ?", "Administrator", true).First(&adminRole).Error; err != nil {
+	if err := s.DB.WithContext(ctx).Where("name = ? AND is_system = ?", "Administrator", true).First(&adminRole).Error; err != nil {
 		return err
 	}
 
@@ -576,13 +725,13 @@ func (s *AuthorizationService) SetupRolePermissions() error {
 	for resourceType, actions := range adminPermissionTypes {
 		for _, action := range actions {
 			var permission Permission
-			if err := s.DB.Where("resource_type = ? AND action = ?", resourceType, action).First(&permission).Error; err != nil {
+			if err := s.DB.WithContext(ctx).Where("resource_type = ? AND action = ?", resourceType, action).First(&permission).Error; err != nil {
 				continue // Skip if permission not found
 			}
 
 			// Check if permission is already assigned
 			var count int64
-			s.DB.Model(&RolePermission{}).
+			s.DB.WithContext(ctx).Model(&RolePermission{}).
 				Where("role_id = ? AND permission_id = ?", adminRole.Id, permission.Id).
 				Count(&count)
 
@@ -593,7 +742,7 @@ func (s *AuthorizationService) SetupRolePermissions() error {
 					CreatedAt:    time.Now(),
 				}
 
-				if err := s.DB.Create(&rolePermission).Error; err != nil {
+				if err := s.DB.WithContext(ctx).Create(&rolePermission).Error; err != nil {
 					return err
 				}
 			}
@@ -602,7 +751,7 @@ func (s *AuthorizationService) SetupRolePermissions() error {
 
 	// Get the member role
 	var memberRole Role
-	if err := s.DB.Where("name = ? AND is_system = ?", "Member", true).First(&memberRole).Error; err != nil {
+	if err := s.DB.WithContext(ctx).Where("name = ? AND is_system = ?", "Member", true).First(&memberRole).Error; err != nil {
 		return err
 	}
 
@@ -617,13 +766,13 @@ func (s *AuthorizationService) SetupRolePermissions() error {
 	for resourceType, actions := range memberPermissionTypes {
 		for _, action := range actions {
 			var permission Permission
-			if err := s.DB.Where("resource_type = ? AND action = ?", resourceType, action).First(&permission).Error; err != nil {
+			if err := s.DB.WithContext(ctx).Where("resource_type = ? AND action = ?", resourceType, action).First(&permission).Error; err != nil {
 				continue // Skip if permission not found
 			}
 
 			// Check if permission is already assigned
 			var count int64
-			s.DB.Model(&RolePermission{}).
+			s.DB.WithContext(ctx).Model(&RolePermission{}).
 				Where("role_id = ? AND permission_id = ?", memberRole.Id, permission.Id).
 				Count(&count)
 
@@ -634,7 +783,7 @@ func (s *AuthorizationService) SetupRolePermissions() error {
 					CreatedAt:    time.Now(),
 				}
 
-				if err := s.DB.Create(&rolePermission).Error; err != nil {
+				if err := s.DB.WithContext(ctx).Create(&rolePermission).Error; err != nil {
 					return err
 				}
 			}
@@ -643,7 +792,7 @@ func (s *AuthorizationService) SetupRolePermissions() error {
 
 	// Get the external role
 	var externalRole Role
-	if err := s.DB.Where("name = ? AND is_system = ?", "External", true).First(&externalRole).Error; err != nil {
+	if err := s.DB.WithContext(ctx).Where("name = ? AND is_system = ?", "External", true).First(&externalRole).Error; err != nil {
 		return err
 	}
 
@@ -658,13 +807,13 @@ func (s *AuthorizationService) SetupRolePermissions() error {
 	for resourceType, actions := range externalPermissionTypes {
 		for _, action := range actions {
 			var permission Permission
-			if err := s.DB.Where("resource_type = ? AND action = ?", resourceType, action).First(&permission).Error; err != nil {
+			if err := s.DB.WithContext(ctx).Where("resource_type = ? AND action = ?", resourceType, action).First(&permission).Error; err != nil {
 				continue // Skip if permission not found
 			}
 
 			// Check if permission is already assigned
 			var count int64
-			s.DB.Model(&RolePermission{}).
+			s.DB.WithContext(ctx).Model(&RolePermission{}).
 				Where("role_id = ? AND permission_id = ?", externalRole.Id, permission.Id).
 				Count(&count)
 
@@ -675,7 +824,7 @@ func (s *AuthorizationService) SetupRolePermissions() error {
 					CreatedAt:    time.Now(),
 				}
 
-				if err := s.DB.Create(&rolePermission).Error; err != nil {
+				if err := s.DB.WithContext(ctx).Create(&rolePermission).Error; err != nil {
 					return err
 				}
 			}
@@ -0,0 +1,106 @@
+package bundles
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"base/core/bundle"
+	"base/core/router"
+	"base/core/translation"
+	"base/core/types"
+	"base/core/validator"
+)
+
+type BundleController struct {
+	Service *BundleService
+}
+
+func NewBundleController(service *BundleService) *BundleController {
+	return &BundleController{
+		Service: service,
+	}
+}
+
+// Routes registers the configuration endpoints; the group is restricted to admins by the module
+func (c *BundleController) Routes(router *router.RouterGroup) {
+	router.GET("/config/export", c.Export)  // Export
+	router.POST("/config/import", c.Import) // Import or diff
+}
+
+// ExportConfig godoc
+// @Summary Export configuration
+// @Description Export settings, roles and permissions, menus and feature flags as one JSON bundle, to import into another environment (Admin only)
+// @Tags Core/Config
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param sections query string false "Comma-separated sections, e.g. settings,menus; all by default"
+// @Success 200 {object} bundle.Bundle
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /config/export [get]
+func (c *BundleController) Export(ctx *router.Context) error {
+	var names []string
+	if sections := ctx.Query("sections"); sections != "" {
+		for _, name := range strings.Split(sections, ",") {
+			names = append(names, strings.TrimSpace(name))
+		}
+	}
+
+	b, err := c.Service.Export(ctx.Request.Context(), names)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to export configuration")
+	}
+	return ctx.JSON(http.StatusOK, b)
+}
+
+// ImportConfig godoc
+// @Summary Import configuration
+// @Description Import a bundle of the export endpoint in one transaction. Records are matched by key, handle or name, created or updated but never deleted. With dry_run the changes are only reported, field by field (Admin only)
+// @Tags Core/Config
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param dry_run query bool false "Report the changes without applying them"
+// @Param bundle body bundle.Bundle true "Configuration bundle"
+// @Success 200 {object} bundle.Report
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /config/import [post]
+func (c *BundleController) Import(ctx *router.Context) error {
+	var b bundle.Bundle
+	if err := ctx.ShouldBindJSON(&b); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid input: " + err.Error()})
+	}
+
+	dryRun := false
+	if value := ctx.Query("dry_run"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return c.fail(ctx, validator.ValidationErrors{{
+				Field: "dry_run", Tag: "invalid", Value: value,
+				Message: "dry_run must be true or false",
+			}}, "Invalid import")
+		}
+		dryRun = parsed
+	}
+
+	report, err := c.Service.Import(ctx.Request.Context(), &b, dryRun)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to import configuration")
+	}
+	return ctx.JSON(http.StatusOK, report)
+}
+
+// fail writes the error response of a service error
+func (c *BundleController) fail(ctx *router.Context, err error, message string) error {
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   translation.Error(ctx, err),
+			Details: translation.LocalizeValidation(ctx, validationErrors),
+		})
+	}
+	return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: message + ": " + err.Error()})
+}
@@ -0,0 +1,35 @@
+package bundles
+
+import (
+	"base/core/app/authorization"
+	"base/core/module"
+	"base/core/router"
+
+	"gorm.io/gorm"
+)
+
+// Module provides /config/export and /config/import, promoting the configuration of the
+// modules registering bundle sections between environments
+type Module struct {
+	module.DefaultModule
+	DB         *gorm.DB
+	Service    *BundleService
+	Controller *BundleController
+}
+
+// Init creates and initializes the configuration bundle module with all dependencies
+func Init(deps module.Dependencies) module.Module {
+	service := NewBundleService(deps.DB)
+
+	return &Module{
+		DB:         deps.DB,
+		Service:    service,
+		Controller: NewBundleController(service),
+	}
+}
+
+// Routes registers the module routes, restricted to admins
+func (m *Module) Routes(router *router.RouterGroup) {
+	group := router.Group("", authorization.RequireAdmin(authorization.NewAuthorizationService(m.DB)))
+	m.Controller.Routes(group)
+}
@@ -0,0 +1,30 @@
+package bundles
+
+import (
+	"context"
+
+	"base/core/bundle"
+
+	"gorm.io/gorm"
+)
+
+// BundleService exports and imports the configuration bundles of the registered sections
+type BundleService struct {
+	DB *gorm.DB
+}
+
+func NewBundleService(db *gorm.DB) *BundleService {
+	return &BundleService{
+		DB: db,
+	}
+}
+
+// Export returns a bundle of the named sections, all of them when names is empty
+func (s *BundleService) Export(ctx context.Context, names []string) (*bundle.Bundle, error) {
+	return bundle.Export(ctx, s.DB, names)
+}
+
+// Import applies a bundle, or only reports the changes it would make on a dry run
+func (s *BundleService) Import(ctx context.Context, b *bundle.Bundle, dryRun bool) (*bundle.Report, error) {
+	return bundle.Import(ctx, s.DB, b, dryRun)
+}
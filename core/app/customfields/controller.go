@@ -0,0 +1,190 @@
+package customfields
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"base/core/router"
+	"base/core/translation"
+	"base/core/types"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+type DefinitionController struct {
+	Service *DefinitionService
+}
+
+func NewDefinitionController(service *DefinitionService) *DefinitionController {
+	return &DefinitionController{
+		Service: service,
+	}
+}
+
+// Routes registers the admin endpoints; the group is restricted to admins by the module
+func (c *DefinitionController) Routes(router *router.RouterGroup) {
+	router.GET("/custom-fields", c.List)                  // List
+	router.POST("/custom-fields", c.Create)               // Create
+	router.GET("/custom-fields/entities", c.ListEntities) // Entities - MUST be before /:id
+	router.GET("/custom-fields/:id", c.Get)               // Get by ID
+	router.PUT("/custom-fields/:id", c.Update)            // Update
+	router.DELETE("/custom-fields/:id", c.Delete)         // Delete
+}
+
+// ListCustomFields godoc
+// @Summary List custom fields
+// @Description Get the custom fields ordered by entity and position (Admin only)
+// @Tags Core/CustomFields
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param entity query string false "Only the custom fields of the entity, e.g. users"
+// @Success 200 {array} Definition
+// @Failure 500 {object} types.ErrorResponse
+// @Router /custom-fields [get]
+func (c *DefinitionController) List(ctx *router.Context) error {
+	definitions, err := c.Service.GetAll(ctx.Request.Context(), ctx.Query("entity"))
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: err.Error()})
+	}
+	return ctx.JSON(http.StatusOK, definitions)
+}
+
+// ListCustomFieldEntities godoc
+// @Summary List entities with custom fields
+// @Description Get the entities custom fields can be defined for (Admin only)
+// @Tags Core/CustomFields
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} string
+// @Router /custom-fields/entities [get]
+func (c *DefinitionController) ListEntities(ctx *router.Context) error {
+	return ctx.JSON(http.StatusOK, Entities())
+}
+
+// CreateCustomField godoc
+// @Summary Create a custom field
+// @Description Create a custom field for an entity; select fields need options (Admin only)
+// @Tags Core/CustomFields
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param field body CreateDefinitionRequest true "Create custom field request"
+// @Success 201 {object} Definition
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /custom-fields [post]
+func (c *DefinitionController) Create(ctx *router.Context) error {
+	var req CreateDefinitionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	definition, err := c.Service.Create(ctx.Request.Context(), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to create custom field")
+	}
+	return ctx.JSON(http.StatusCreated, definition)
+}
+
+// GetCustomField godoc
+// @Summary Get a custom field
+// @Description Get a custom field by its id (Admin only)
+// @Tags Core/CustomFields
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Custom field id"
+// @Success 200 {object} Definition
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /custom-fields/{id} [get]
+func (c *DefinitionController) Get(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	definition, err := c.Service.GetById(ctx.Request.Context(), uint(id))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to fetch custom field")
+	}
+	return ctx.JSON(http.StatusOK, definition)
+}
+
+// UpdateCustomField godoc
+// @Summary Update a custom field
+// @Description Update a custom field by its id; the entity, key and type can't be changed (Admin only)
+// @Tags Core/CustomFields
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Custom field id"
+// @Param field body UpdateDefinitionRequest true "Update custom field request"
+// @Success 200 {object} Definition
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /custom-fields/{id} [put]
+func (c *DefinitionController) Update(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	var req UpdateDefinitionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	definition, err := c.Service.Update(ctx.Request.Context(), uint(id), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to update custom field")
+	}
+	return ctx.JSON(http.StatusOK, definition)
+}
+
+// DeleteCustomField godoc
+// @Summary Delete a custom field
+// @Description Delete a custom field by its id with the values of all records (Admin only)
+// @Tags Core/CustomFields
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param id path int true "Custom field id"
+// @Success 204 {object} nil
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /custom-fields/{id} [delete]
+func (c *DefinitionController) Delete(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	if err := c.Service.Delete(ctx.Request.Context(), uint(id)); err != nil {
+		return c.fail(ctx, err, "Failed to delete custom field")
+	}
+
+	ctx.Status(http.StatusNoContent)
+	return nil
+}
+
+// fail writes the error response of a service error
+func (c *DefinitionController) fail(ctx *router.Context, err error, message string) error {
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   translation.Error(ctx, err),
+			Details: translation.LocalizeValidation(ctx, validationErrors),
+		})
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Custom field not found"})
+	}
+	return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: message + ": " + err.Error()})
+}
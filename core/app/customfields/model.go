@@ -0,0 +1,89 @@
+package customfields
+
+import (
+	"time"
+)
+
+// Field types
+const (
+	TypeText    = "text"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+	TypeDate    = "date" // YYYY-MM-DD
+	TypeSelect  = "select"
+)
+
+// Definition is an extra field admins add to an entity, e.g. a "shoe_size" number for
+// users. Its values are stored in the custom_field_values table, so no migration is needed.
+type Definition struct {
+	Id        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Entity    string    `json:"entity" gorm:"size:64;uniqueIndex:idx_custom_field_definitions_entity_key"` // e.g. users
+	Key       string    `json:"key" gorm:"size:64;uniqueIndex:idx_custom_field_definitions_entity_key"`
+	Label     string    `json:"label" gorm:"size:255"`
+	Type      string    `json:"type" gorm:"size:16"`
+	Required  bool      `json:"required"`
+	Options   []string  `json:"options" gorm:"type:text;serializer:json"` // Choices of select fields
+	Position  int       `json:"position"`
+}
+
+// TableName returns the table name for the Definition model
+func (m *Definition) TableName() string {
+	return "custom_field_definitions"
+}
+
+// Value is the value of a custom field of one record. Only the column of the type of the
+// field is set, so values can be filtered with plain comparisons.
+type Value struct {
+	Id           uint     `gorm:"primarykey"`
+	Entity       string   `gorm:"size:64;index:idx_custom_field_values_record"`
+	RecordId     uint     `gorm:"index:idx_custom_field_values_record"`
+	DefinitionId uint     `gorm:"index"`
+	Key          string   `gorm:"size:64"`
+	TextValue    *string  `gorm:"type:text"` // Text, date and select fields
+	NumberValue  *float64 // Number fields
+	BoolValue    *bool    // Boolean fields
+}
+
+// TableName returns the table name for the Value model
+func (m *Value) TableName() string {
+	return "custom_field_values"
+}
+
+// get returns the value in the type of its field
+func (m *Value) get() any {
+	switch {
+	case m.NumberValue != nil:
+		return *m.NumberValue
+	case m.BoolValue != nil:
+		return *m.BoolValue
+	case m.TextValue != nil:
+		return *m.TextValue
+	}
+	return nil
+}
+
+// Values holds the custom field values of a record by key. It is also the format of the
+// "custom_fields" object in requests and responses.
+type Values map[string]any
+
+// CreateDefinitionRequest represents the request payload for creating a custom field
+type CreateDefinitionRequest struct {
+	Entity   string   `json:"entity" validate:"required,max=64"`
+	Key      string   `json:"key" validate:"required,max=64"`
+	Label    string   `json:"label" validate:"required,max=255"`
+	Type     string   `json:"type" validate:"required,oneof=text number boolean date select"`
+	Required bool     `json:"required"`
+	Options  []string `json:"options,omitempty" validate:"dive,required,max=255"`
+	Position int      `json:"position"`
+}
+
+// UpdateDefinitionRequest represents the request payload for updating a custom field.
+// Omitted fields are left unchanged; the entity, key and type can't be changed.
+type UpdateDefinitionRequest struct {
+	Label    *string   `json:"label,omitempty" validate:"omitempty,min=1,max=255"`
+	Required *bool     `json:"required,omitempty"`
+	Options  *[]string `json:"options,omitempty" validate:"omitempty,dive,required,max=255"`
+	Position *int      `json:"position,omitempty"`
+}
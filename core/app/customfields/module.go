@@ -0,0 +1,57 @@
+package customfields
+
+import (
+	"base/core/app/authorization"
+	"base/core/crud"
+	"base/core/module"
+	"base/core/router"
+
+	"gorm.io/gorm"
+)
+
+// Module provides custom fields: extra typed fields admins define for registered entities
+// (see RegisterEntity) without migrations. Modules validate and store the values of their
+// records with Records and include them in responses under "custom_fields".
+type Module struct {
+	module.DefaultModule
+	DB         *gorm.DB
+	Service    *DefinitionService
+	Controller *DefinitionController
+}
+
+// Init creates and initializes the custom fields module with all dependencies
+func Init(deps module.Dependencies) module.Module {
+	registerEvents()
+	Records.SetDB(deps.DB)
+	crud.RegisterCopier("customfields", CopyValues)
+
+	service := NewDefinitionService(deps.DB, deps.Emitter, deps.Logger)
+	controller := NewDefinitionController(service)
+
+	return &Module{
+		DB:         deps.DB,
+		Service:    service,
+		Controller: controller,
+	}
+}
+
+// Routes registers the module routes; managing custom fields is restricted to admins
+func (m *Module) Routes(router *router.RouterGroup) {
+	group := router.Group("", authorization.RequireAdmin(authorization.NewAuthorizationService(m.DB)))
+	m.Controller.Routes(group)
+}
+
+func (m *Module) Init() error {
+	return nil
+}
+
+func (m *Module) Migrate() error {
+	return m.DB.AutoMigrate(&Definition{}, &Value{})
+}
+
+func (m *Module) GetModels() []any {
+	return []any{
+		&Definition{},
+		&Value{},
+	}
+}
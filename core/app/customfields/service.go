@@ -0,0 +1,214 @@
+package customfields
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"base/core/emitter"
+	"base/core/logger"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+const (
+	CreateDefinitionEvent = "custom_fields.create"
+	UpdateDefinitionEvent = "custom_fields.update"
+	DeleteDefinitionEvent = "custom_fields.delete"
+)
+
+// registerEvents adds the events of the module to the event catalog
+func registerEvents() {
+	emitter.RegisterEvents(
+		emitter.EventType{Name: CreateDefinitionEvent, Description: "A custom field was defined", Payload: &Definition{}},
+		emitter.EventType{Name: UpdateDefinitionEvent, Description: "A custom field was updated", Payload: &Definition{}},
+		emitter.EventType{Name: DeleteDefinitionEvent, Description: "A custom field was deleted", Payload: &Definition{}},
+	)
+}
+
+// Global validator instance using Base core validator wrapper
+var validate = validator.New()
+
+// keyPattern matches field keys such as "shoe_size"
+var keyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// DefinitionService manages the custom fields of entities
+type DefinitionService struct {
+	DB      *gorm.DB
+	Emitter *emitter.Emitter
+	Logger  logger.Logger
+}
+
+func NewDefinitionService(db *gorm.DB, emitter *emitter.Emitter, logger logger.Logger) *DefinitionService {
+	return &DefinitionService{
+		DB:      db,
+		Emitter: emitter,
+		Logger:  logger,
+	}
+}
+
+// GetAll returns the custom fields, of one entity when it is set
+func (s *DefinitionService) GetAll(ctx context.Context, entity string) ([]*Definition, error) {
+	var definitions []*Definition
+	query := s.DB.WithContext(ctx).Order("entity, position, id")
+	if entity != "" {
+		query = query.Where("entity = ?", entity)
+	}
+	if err := query.Find(&definitions).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch custom fields: %w", err)
+	}
+	return definitions, nil
+}
+
+// GetById returns a custom field by id
+func (s *DefinitionService) GetById(ctx context.Context, id uint) (*Definition, error) {
+	var definition Definition
+	if err := s.DB.WithContext(ctx).First(&definition, id).Error; err != nil {
+		return nil, err
+	}
+	return &definition, nil
+}
+
+// Create creates a custom field for an entity
+func (s *DefinitionService) Create(ctx context.Context, req *CreateDefinitionRequest) (*Definition, error) {
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return nil, errs
+	}
+
+	definition := &Definition{
+		Entity:   req.Entity,
+		Key:      req.Key,
+		Label:    req.Label,
+		Type:     req.Type,
+		Required: req.Required,
+		Options:  req.Options,
+		Position: req.Position,
+	}
+	errs := validateDefinition(definition)
+	if !isEntity(definition.Entity) {
+		errs = append(errs, validator.ValidationError{
+			Field:   "entity",
+			Tag:     "oneof",
+			Value:   definition.Entity,
+			Param:   strings.Join(Entities(), " "),
+			Message: "entity must be one of: " + strings.Join(Entities(), " "),
+		})
+	}
+	if keyPattern.MatchString(definition.Key) {
+		var count int64
+		if err := s.DB.WithContext(ctx).Model(&Definition{}).Where("entity = ? AND `key` = ?", definition.Entity, definition.Key).
+			Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			errs = append(errs, validator.ValidationError{
+				Field:   "key",
+				Tag:     "unique",
+				Value:   definition.Key,
+				Message: "key is already taken",
+			})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	if err := s.DB.WithContext(ctx).Create(definition).Error; err != nil {
+		s.Logger.Error("failed to create custom field", logger.String("error", err.Error()))
+		return nil, fmt.Errorf("failed to create custom field: %w", err)
+	}
+
+	s.Emitter.EmitContext(ctx, CreateDefinitionEvent, definition)
+	return definition, nil
+}
+
+// Update updates a custom field; values stored before are kept even when they no longer
+// match its options
+func (s *DefinitionService) Update(ctx context.Context, id uint, req *UpdateDefinitionRequest) (*Definition, error) {
+	definition, err := s.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return nil, errs
+	}
+
+	if req.Label != nil {
+		definition.Label = *req.Label
+	}
+	if req.Required != nil {
+		definition.Required = *req.Required
+	}
+	if req.Options != nil {
+		definition.Options = *req.Options
+	}
+	if req.Position != nil {
+		definition.Position = *req.Position
+	}
+	if errs := validateDefinition(definition); len(errs) > 0 {
+		return nil, errs
+	}
+
+	if err := s.DB.WithContext(ctx).Save(definition).Error; err != nil {
+		s.Logger.Error("failed to update custom field",
+			logger.String("error", err.Error()),
+			logger.Uint("id", id))
+		return nil, fmt.Errorf("failed to update custom field: %w", err)
+	}
+
+	s.Emitter.EmitContext(ctx, UpdateDefinitionEvent, definition)
+	return definition, nil
+}
+
+// Delete deletes a custom field with its values
+func (s *DefinitionService) Delete(ctx context.Context, id uint) error {
+	definition, err := s.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("definition_id = ?", definition.Id).Delete(&Value{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(definition).Error
+	})
+	if err != nil {
+		s.Logger.Error("failed to delete custom field",
+			logger.String("error", err.Error()),
+			logger.Uint("id", id))
+		return fmt.Errorf("failed to delete custom field: %w", err)
+	}
+
+	s.Emitter.EmitContext(ctx, DeleteDefinitionEvent, definition)
+	return nil
+}
+
+// validateDefinition checks the key and the options of a custom field
+func validateDefinition(definition *Definition) validator.ValidationErrors {
+	var errs validator.ValidationErrors
+	if !keyPattern.MatchString(definition.Key) {
+		errs = append(errs, validator.ValidationError{
+			Field:   "key",
+			Tag:     "field_name",
+			Value:   definition.Key,
+			Message: "key must start with a lowercase letter and contain only lowercase letters, digits or '_'",
+		})
+	}
+	if definition.Type == TypeSelect && len(definition.Options) == 0 {
+		errs = append(errs, validator.ValidationError{
+			Field:   "options",
+			Tag:     "required",
+			Message: "options are required for select fields",
+		})
+	}
+	if definition.Type != TypeSelect {
+		definition.Options = nil
+	}
+	if definition.Options == nil {
+		definition.Options = []string{}
+	}
+	return errs
+}
@@ -0,0 +1,404 @@
+package customfields
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+// maxTextLength is the longest value of text fields
+const maxTextLength = 10000
+
+var (
+	entitiesMu sync.RWMutex
+	entities   = map[string]bool{}
+)
+
+// RegisterEntity lets admins define custom fields for records of a module, e.g. "users".
+// Modules register in their Init and store the values with Records.
+func RegisterEntity(name string) {
+	entitiesMu.Lock()
+	defer entitiesMu.Unlock()
+	entities[name] = true
+}
+
+// Entities returns the registered entities sorted by name
+func Entities() []string {
+	entitiesMu.RLock()
+	defer entitiesMu.RUnlock()
+	result := make([]string, 0, len(entities))
+	for name := range entities {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// isEntity reports whether an entity is registered
+func isEntity(name string) bool {
+	entitiesMu.RLock()
+	defer entitiesMu.RUnlock()
+	return entities[name]
+}
+
+// Store validates, stores and filters the custom field values of records. Without the
+// custom fields module it has no database and records have no custom fields.
+type Store struct {
+	mu sync.RWMutex
+	db *gorm.DB
+}
+
+// Records is the store modules keep the custom field values of their records in
+var Records = &Store{}
+
+// SetDB sets the database the store reads and writes
+func (s *Store) SetDB(db *gorm.DB) {
+	s.mu.Lock()
+	s.db = db
+	s.mu.Unlock()
+}
+
+// WithDB returns a store using db, e.g. a transaction
+func (s *Store) WithDB(db *gorm.DB) *Store {
+	return &Store{db: db}
+}
+
+// conn returns the database of the store
+func (s *Store) conn() (*gorm.DB, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.db == nil {
+		return nil, errors.New("customfields: store has no database")
+	}
+	return s.db, nil
+}
+
+// Definitions returns the custom fields of an entity ordered by position
+func (s *Store) Definitions(entity string) ([]*Definition, error) {
+	db, err := s.conn()
+	if err != nil {
+		return nil, nil
+	}
+	var definitions []*Definition
+	if err := db.Where("entity = ?", entity).Order("position, id").Find(&definitions).Error; err != nil {
+		return nil, err
+	}
+	return definitions, nil
+}
+
+// Validate checks values against the custom fields of an entity and returns them cleaned
+// up: text trimmed and empty values as nil, which removes a value. When creating a record
+// its required fields must be given; on updates only the given fields are checked.
+func (s *Store) Validate(entity string, values Values, create bool) (Values, error) {
+	if len(values) == 0 && !create {
+		return nil, nil
+	}
+	definitions, err := s.Definitions(entity)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs validator.ValidationErrors
+	byKey := make(map[string]*Definition, len(definitions))
+	for _, definition := range definitions {
+		byKey[definition.Key] = definition
+	}
+	for key := range values {
+		if byKey[key] == nil {
+			errs = append(errs, validator.ValidationError{
+				Field:   "custom_fields." + key,
+				Tag:     "unknown",
+				Message: key + " is not a custom field",
+			})
+		}
+	}
+
+	result := make(Values, len(values))
+	for _, definition := range definitions {
+		raw, given := values[definition.Key]
+		if !given && !create {
+			continue
+		}
+		value, err := definition.value(raw)
+		if err != nil {
+			errs = append(errs, *err)
+			continue
+		}
+		if value == nil && definition.Required {
+			errs = append(errs, validator.ValidationError{
+				Field:   "custom_fields." + definition.Key,
+				Tag:     "required",
+				Message: definition.Key + " is required",
+			})
+			continue
+		}
+		if given {
+			result[definition.Key] = value
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return result, nil
+}
+
+// Set stores validated values of a record. Only the given fields are changed; a nil
+// value removes that value.
+func (s *Store) Set(entity string, id uint, values Values) error {
+	if len(values) == 0 {
+		return nil
+	}
+	db, err := s.conn()
+	if err != nil {
+		return err
+	}
+	definitions, err := s.WithDB(db).Definitions(entity)
+	if err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, definition := range definitions {
+			value, ok := values[definition.Key]
+			if !ok {
+				continue
+			}
+			if err := tx.Where("entity = ? AND record_id = ? AND definition_id = ?", entity, id, definition.Id).
+				Delete(&Value{}).Error; err != nil {
+				return err
+			}
+			if value == nil {
+				continue
+			}
+
+			row := &Value{Entity: entity, RecordId: id, DefinitionId: definition.Id, Key: definition.Key}
+			switch value := value.(type) {
+			case float64:
+				row.NumberValue = &value
+			case bool:
+				row.BoolValue = &value
+			case string:
+				row.TextValue = &value
+			default:
+				return fmt.Errorf("customfields: unexpected value of %s: %T", definition.Key, value)
+			}
+			if err := tx.Create(row).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Load returns the custom field values of the records with the given ids, by id. Every
+// record gets a map, empty when it has no values.
+func (s *Store) Load(entity string, ids []uint) (map[uint]Values, error) {
+	result := make(map[uint]Values, len(ids))
+	for _, id := range ids {
+		result[id] = Values{}
+	}
+	db, err := s.conn()
+	if err != nil || len(ids) == 0 {
+		return result, nil
+	}
+
+	var rows []Value
+	if err := db.Where("entity = ? AND record_id IN ?", entity, ids).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if values := result[row.RecordId]; values != nil {
+			values[row.Key] = row.get()
+		}
+	}
+	return result, nil
+}
+
+// Get returns the custom field values of a record
+func (s *Store) Get(entity string, id uint) (Values, error) {
+	values, err := s.Load(entity, []uint{id})
+	if err != nil {
+		return nil, err
+	}
+	return values[id], nil
+}
+
+// Delete removes all custom field values of a record
+func (s *Store) Delete(entity string, id uint) error {
+	db, err := s.conn()
+	if err != nil {
+		return nil
+	}
+	return db.Where("entity = ? AND record_id = ?", entity, id).Delete(&Value{}).Error
+}
+
+// CopyValues gives a record of an entity the custom field values of another one, e.g. the
+// record it is a copy of, in tx; it is the custom fields copier of crud.Copy
+func CopyValues(tx *gorm.DB, entity string, from, to uint) error {
+	var rows []*Value
+	if err := tx.Where("entity = ? AND record_id = ?", entity, from).Find(&rows).Error; err != nil || len(rows) == 0 {
+		return err
+	}
+	for _, row := range rows {
+		row.Id = 0
+		row.RecordId = to
+	}
+	return tx.Create(rows).Error
+}
+
+// Filter holds the custom field values list endpoints are filtered by, by key
+type Filter map[string]string
+
+// ParseFilter reads a filter from query parameters such as cf[color]=red
+func ParseFilter(query url.Values) Filter {
+	filter := Filter{}
+	for name, values := range query {
+		if key, ok := strings.CutPrefix(name, "cf["); ok && strings.HasSuffix(key, "]") && len(values) > 0 {
+			filter[strings.TrimSuffix(key, "]")] = values[0]
+		}
+	}
+	return filter
+}
+
+// Where narrows a query of records of the entity to the ones whose custom fields have the
+// values of the filter; column is the id column of the records, e.g. "products.id"
+func (s *Store) Where(query *gorm.DB, entity, column string, filter Filter) (*gorm.DB, error) {
+	if len(filter) == 0 {
+		return query, nil
+	}
+	db, err := s.conn()
+	if err != nil {
+		return query, nil
+	}
+	definitions, err := s.Definitions(entity)
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[string]*Definition, len(definitions))
+	for _, definition := range definitions {
+		byKey[definition.Key] = definition
+	}
+
+	var errs validator.ValidationErrors
+	keys := make([]string, 0, len(filter))
+	for key := range filter {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		definition := byKey[key]
+		if definition == nil {
+			errs = append(errs, validator.ValidationError{
+				Field:   "cf[" + key + "]",
+				Tag:     "unknown",
+				Message: key + " is not a custom field",
+			})
+			continue
+		}
+
+		var raw any = filter[key]
+		switch definition.Type {
+		case TypeNumber, TypeBoolean:
+			// Query parameters are text; parse them like JSON values
+			if definition.Type == TypeNumber {
+				if number, err := strconv.ParseFloat(filter[key], 64); err == nil {
+					raw = number
+				}
+			} else if boolean, err := strconv.ParseBool(filter[key]); err == nil {
+				raw = boolean
+			}
+		}
+		value, invalid := definition.value(raw)
+		if invalid != nil {
+			invalid.Field = "cf[" + key + "]"
+			errs = append(errs, *invalid)
+			continue
+		}
+		if value == nil {
+			continue
+		}
+
+		matches := db.Model(&Value{}).Select("record_id").
+			Where("entity = ? AND definition_id = ?", entity, definition.Id)
+		switch value := value.(type) {
+		case float64:
+			matches = matches.Where("number_value = ?", value)
+		case bool:
+			matches = matches.Where("bool_value = ?", value)
+		default:
+			matches = matches.Where("text_value = ?", value)
+		}
+		query = query.Where(column+" IN (?)", matches)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return query, nil
+}
+
+// value checks a value of the field; it is nil when the value is empty
+func (m *Definition) value(raw any) (any, *validator.ValidationError) {
+	invalid := func(tag, param, message string) *validator.ValidationError {
+		return &validator.ValidationError{
+			Field:   "custom_fields." + m.Key,
+			Tag:     tag,
+			Value:   fmt.Sprint(raw),
+			Param:   param,
+			Message: m.Key + " " + message,
+		}
+	}
+	if raw == nil {
+		return nil, nil
+	}
+
+	switch m.Type {
+	case TypeNumber:
+		number, ok := raw.(float64)
+		if !ok {
+			return nil, invalid("numeric", "", "must be a number")
+		}
+		return number, nil
+	case TypeBoolean:
+		boolean, ok := raw.(bool)
+		if !ok {
+			return nil, invalid("boolean", "", "must be true or false")
+		}
+		return boolean, nil
+	}
+
+	text, ok := raw.(string)
+	if !ok {
+		return nil, invalid("string", "", "must be text")
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, nil
+	}
+
+	switch m.Type {
+	case TypeText:
+		if utf8.RuneCountInString(text) > maxTextLength {
+			return nil, invalid("max", strconv.Itoa(maxTextLength), fmt.Sprintf("must be at most %d characters long", maxTextLength))
+		}
+	case TypeDate:
+		if _, err := time.Parse(time.DateOnly, text); err != nil {
+			return nil, invalid("date", "", "must be a date such as 2024-12-31")
+		}
+	case TypeSelect:
+		if !slices.Contains(m.Options, text) {
+			return nil, invalid("oneof", strings.Join(m.Options, " "), "must be one of: "+strings.Join(m.Options, " "))
+		}
+	}
+	return text, nil
+}
@@ -0,0 +1,55 @@
+package dashboard
+
+import (
+	"net/http"
+	"strconv"
+
+	"base/core/router"
+	"base/core/types"
+)
+
+type DashboardController struct {
+	Service *DashboardService
+}
+
+func NewDashboardController(service *DashboardService) *DashboardController {
+	return &DashboardController{
+		Service: service,
+	}
+}
+
+func (c *DashboardController) Routes(router *router.RouterGroup) {
+	router.GET("/dashboard/stats", c.Stats)
+}
+
+// DashboardStats godoc
+// @Summary Dashboard statistics
+// @Description Counts and trends for the admin home page: new users per day, published posts, storage used, recent activities and unread notifications. Cached for a minute. (Admin only)
+// @Tags Core/Dashboard
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param days query int false "Length of the trend period in days (default 30, max 365)"
+// @Param refresh query bool false "Recompute instead of using the cached stats"
+// @Success 200 {object} StatsResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /dashboard/stats [get]
+func (c *DashboardController) Stats(ctx *router.Context) error {
+	days := DefaultDays
+	if daysStr := ctx.Query("days"); daysStr != "" {
+		value, err := strconv.Atoi(daysStr)
+		if err != nil || value < 1 || value > MaxDays {
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid days, use 1 to " + strconv.Itoa(MaxDays)})
+		}
+		days = value
+	}
+	refresh := ctx.Query("refresh") == "true"
+
+	stats, err := c.Service.Stats(ctx.Request.Context(), ctx.GetUint("user_id"), days, refresh)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to compute dashboard stats: " + err.Error()})
+	}
+
+	return ctx.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,65 @@
+package dashboard
+
+import (
+	"time"
+
+	"base/core/app/activities"
+)
+
+// StatsResponse is everything the admin home page shows, in one response
+type StatsResponse struct {
+	GeneratedAt   time.Time         `json:"generated_at"` // When the cached part was computed
+	Days          int               `json:"days"`         // Length of the trend period
+	Users         UserStats         `json:"users"`
+	Posts         *PostStats        `json:"posts,omitempty"` // Only when a posts module is installed
+	Storage       StorageStats      `json:"storage"`
+	Activities    ActivityStats     `json:"activities"`
+	Notifications NotificationStats `json:"notifications"`
+}
+
+// DailyCount is the number of records of one day (UTC)
+type DailyCount struct {
+	Date  string `json:"date"` // YYYY-MM-DD
+	Count int64  `json:"count"`
+}
+
+// Trend compares the period with the one before it
+type Trend struct {
+	Current  int64   `json:"current"`
+	Previous int64   `json:"previous"`
+	Change   float64 `json:"change"` // Percent; 0 when the previous period is empty
+}
+
+// UserStats counts users
+type UserStats struct {
+	Total  int64        `json:"total"`
+	New    Trend        `json:"new"`
+	PerDay []DailyCount `json:"per_day"`
+}
+
+// PostStats counts posts of the generated posts module
+type PostStats struct {
+	Total     int64        `json:"total"`
+	Published int64        `json:"published"`
+	New       Trend        `json:"new"`
+	PerDay    []DailyCount `json:"per_day"` // Published per day
+}
+
+// StorageStats sums stored files
+type StorageStats struct {
+	Files int64 `json:"files"`
+	Bytes int64 `json:"bytes"`
+	Media int64 `json:"media"` // Items in the media library
+}
+
+// ActivityStats summarizes the activity log
+type ActivityStats struct {
+	Total  int64                          `json:"total"`
+	New    Trend                          `json:"new"`
+	Recent []*activities.ActivityResponse `json:"recent"`
+}
+
+// NotificationStats counts the notifications of the current user
+type NotificationStats struct {
+	Unread int64 `json:"unread"`
+}
@@ -0,0 +1,48 @@
+package dashboard
+
+import (
+	"base/core/app/authorization"
+	"base/core/module"
+	"base/core/router"
+
+	"gorm.io/gorm"
+)
+
+// Module provides GET /dashboard/stats, the aggregated stats of the admin home page. It
+// has no tables of its own.
+type Module struct {
+	module.DefaultModule
+	DB         *gorm.DB
+	Service    *DashboardService
+	Controller *DashboardController
+}
+
+// Init creates and initializes the dashboard module with all dependencies
+func Init(deps module.Dependencies) module.Module {
+	service := NewDashboardService(deps.DB, deps.Logger)
+	controller := NewDashboardController(service)
+
+	return &Module{
+		DB:         deps.DB,
+		Service:    service,
+		Controller: controller,
+	}
+}
+
+// Routes registers the module routes; they are restricted to admins
+func (m *Module) Routes(router *router.RouterGroup) {
+	group := router.Group("", authorization.RequireAdmin(authorization.NewAuthorizationService(m.DB)))
+	m.Controller.Routes(group)
+}
+
+func (m *Module) Init() error {
+	return nil
+}
+
+func (m *Module) Migrate() error {
+	return nil
+}
+
+func (m *Module) GetModels() []any {
+	return []any{}
+}
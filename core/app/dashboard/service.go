@@ -0,0 +1,247 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"base/core/app/activities"
+	"base/core/app/notifications"
+	"base/core/app/users"
+	"base/core/logger"
+	"base/core/storage"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultDays is the default length of the trend period
+	DefaultDays = 30
+	// MaxDays is the longest trend period
+	MaxDays = 365
+	// recentActivities is the number of activities in the stats
+	recentActivities = 10
+	// cacheTTL is how long the stats are reused; unread notifications are always live
+	cacheTTL = time.Minute
+)
+
+// cachedStats are the stats of one period length
+type cachedStats struct {
+	stats *StatsResponse
+	at    time.Time
+}
+
+// DashboardService computes the dashboard stats with one grouped query per series
+type DashboardService struct {
+	DB     *gorm.DB
+	Logger logger.Logger
+
+	mu    sync.Mutex
+	cache map[int]cachedStats
+	now   func() time.Time
+}
+
+func NewDashboardService(db *gorm.DB, logger logger.Logger) *DashboardService {
+	return &DashboardService{
+		DB:     db,
+		Logger: logger,
+		cache:  make(map[int]cachedStats),
+		now:    time.Now,
+	}
+}
+
+// Stats returns the stats of the last days days for the user. The system-wide part is
+// cached for a minute unless refresh is set.
+func (s *DashboardService) Stats(ctx context.Context, userId uint, days int, refresh bool) (*StatsResponse, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[days]
+	s.mu.Unlock()
+
+	if !ok || refresh || s.now().Sub(cached.at) > cacheTTL {
+		stats, err := s.compute(ctx, days)
+		if err != nil {
+			return nil, err
+		}
+		cached = cachedStats{stats: stats, at: s.now()}
+
+		s.mu.Lock()
+		s.cache[days] = cached
+		s.mu.Unlock()
+	}
+
+	// Copy so the per-user part doesn't leak into the cache
+	response := *cached.stats
+	if err := s.DB.WithContext(ctx).Model(&notifications.Notification{}).
+		Where("user_id = ? AND `read` = ?", userId, false).
+		Count(&response.Notifications.Unread).Error; err != nil {
+		return nil, fmt.Errorf("failed to count notifications: %w", err)
+	}
+	return &response, nil
+}
+
+// compute runs the queries of the system-wide stats
+func (s *DashboardService) compute(ctx context.Context, days int) (*StatsResponse, error) {
+	started := s.now()
+	today := started.UTC().Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, -(days - 1))
+	previous := since.AddDate(0, 0, -days)
+
+	stats := &StatsResponse{GeneratedAt: started.UTC(), Days: days}
+
+	// Users
+	userModel := s.DB.WithContext(ctx).Model(&users.User{})
+	if err := userModel.Session(&gorm.Session{}).Count(&stats.Users.Total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count users: %w", err)
+	}
+	perDay, err := s.perDay(userModel, "created_at", since, days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count new users: %w", err)
+	}
+	stats.Users.PerDay = perDay
+	if stats.Users.New, err = s.trend(userModel, "created_at", previous, since, perDay); err != nil {
+		return nil, fmt.Errorf("failed to count new users: %w", err)
+	}
+
+	// Posts, when the generated posts module exists
+	if stats.Posts, err = s.posts(ctx, since, previous, days); err != nil {
+		return nil, err
+	}
+
+	// Storage
+	var storageTotals struct {
+		Files int64
+		Bytes int64
+	}
+	if err := s.DB.WithContext(ctx).Model(&storage.Attachment{}).
+		Select("COUNT(*) AS files, COALESCE(SUM(size), 0) AS bytes").
+		Scan(&storageTotals).Error; err != nil {
+		return nil, fmt.Errorf("failed to sum storage: %w", err)
+	}
+	stats.Storage.Files = storageTotals.Files
+	stats.Storage.Bytes = storageTotals.Bytes
+	if s.DB.WithContext(ctx).Migrator().HasTable("media") {
+		if err := s.DB.WithContext(ctx).Table("media").Where("deleted_at IS NULL").Count(&stats.Storage.Media).Error; err != nil {
+			return nil, fmt.Errorf("failed to count media: %w", err)
+		}
+	}
+
+	// Activities
+	activityModel := s.DB.WithContext(ctx).Model(&activities.Activity{})
+	if err := activityModel.Session(&gorm.Session{}).Count(&stats.Activities.Total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count activities: %w", err)
+	}
+	activityPerDay, err := s.perDay(activityModel, "created_at", since, days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count activities: %w", err)
+	}
+	if stats.Activities.New, err = s.trend(activityModel, "created_at", previous, since, activityPerDay); err != nil {
+		return nil, fmt.Errorf("failed to count activities: %w", err)
+	}
+
+	var recent []*activities.Activity
+	if err := s.DB.WithContext(ctx).Preload("User").Order("created_at DESC").Limit(recentActivities).Find(&recent).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch recent activities: %w", err)
+	}
+	stats.Activities.Recent = make([]*activities.ActivityResponse, 0, len(recent))
+	for _, activity := range recent {
+		stats.Activities.Recent = append(stats.Activities.Recent, activity.ToResponse())
+	}
+
+	s.Logger.Debug("dashboard stats computed",
+		logger.Int("days", days),
+		logger.Duration("duration", s.now().Sub(started)))
+	return stats, nil
+}
+
+// posts returns the stats of the posts table, or nil when there is none
+func (s *DashboardService) posts(ctx context.Context, since, previous time.Time, days int) (*PostStats, error) {
+	migrator := s.DB.WithContext(ctx).Migrator()
+	if !migrator.HasTable("posts") {
+		return nil, nil
+	}
+
+	model := s.DB.WithContext(ctx).Table("posts")
+	if migrator.HasColumn("posts", "deleted_at") {
+		model = model.Where("deleted_at IS NULL")
+	}
+
+	stats := &PostStats{}
+	if err := model.Session(&gorm.Session{}).Count(&stats.Total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count posts: %w", err)
+	}
+
+	// Published posts: by the published flag or a published_at date, whichever exists
+	published := model.Session(&gorm.Session{})
+	column := "created_at"
+	switch {
+	case migrator.HasColumn("posts", "published_at"):
+		published = published.Where("published_at IS NOT NULL")
+		column = "published_at"
+	case migrator.HasColumn("posts", "published"):
+		published = published.Where("published = ?", true)
+	}
+	if err := published.Session(&gorm.Session{}).Count(&stats.Published).Error; err != nil {
+		return nil, fmt.Errorf("failed to count published posts: %w", err)
+	}
+
+	perDay, err := s.perDay(published, column, since, days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count published posts: %w", err)
+	}
+	stats.PerDay = perDay
+	if stats.New, err = s.trend(published, column, previous, since, perDay); err != nil {
+		return nil, fmt.Errorf("failed to count published posts: %w", err)
+	}
+	return stats, nil
+}
+
+// perDay counts the rows of query per day of column since the day, with a single
+// grouped query. Days without rows are included with a zero count.
+func (s *DashboardService) perDay(query *gorm.DB, column string, since time.Time, days int) ([]DailyCount, error) {
+	var rows []struct {
+		Day   string
+		Count int64
+	}
+	if err := query.Session(&gorm.Session{}).
+		Select(fmt.Sprintf("DATE(%s) AS day, COUNT(*) AS count", column)).
+		Where(column+" >= ?", since).
+		Group("day").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		day := row.Day
+		if len(day) > 10 {
+			day = day[:10] // Drivers that return dates as timestamps
+		}
+		counts[day] += row.Count
+	}
+
+	result := make([]DailyCount, days)
+	for i := range result {
+		date := since.AddDate(0, 0, i).Format("2006-01-02")
+		result[i] = DailyCount{Date: date, Count: counts[date]}
+	}
+	return result, nil
+}
+
+// trend compares the rows of the period (from the per-day counts) with the period
+// before it
+func (s *DashboardService) trend(query *gorm.DB, column string, previous, since time.Time, perDay []DailyCount) (Trend, error) {
+	var trend Trend
+	for _, day := range perDay {
+		trend.Current += day.Count
+	}
+	if err := query.Session(&gorm.Session{}).
+		Where(column+" >= ? AND "+column+" < ?", previous, since).
+		Count(&trend.Previous).Error; err != nil {
+		return trend, err
+	}
+	if trend.Previous > 0 {
+		trend.Change = float64(trend.Current-trend.Previous) / float64(trend.Previous) * 100
+	}
+	return trend, nil
+}
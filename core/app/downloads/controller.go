@@ -0,0 +1,145 @@
+package downloads
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"base/core/router"
+	"base/core/translation"
+	"base/core/types"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+type DownloadController struct {
+	Service *DownloadService
+}
+
+func NewDownloadController(service *DownloadService) *DownloadController {
+	return &DownloadController{
+		Service: service,
+	}
+}
+
+// Routes registers the token list; the group is restricted to admins by the module
+func (c *DownloadController) Routes(router *router.RouterGroup) {
+	router.GET("/download-tokens", c.List) // Issued tokens and their downloads
+}
+
+// PublicRoutes registers the downloads. The token is the authorization, so they don't need
+// a user token or an API key (see /api/downloads/* in MIDDLEWARE_AUTH_SKIP_PATHS and
+// MIDDLEWARE_API_KEY_SKIP_PATHS) and links work from emails.
+func (c *DownloadController) PublicRoutes(group *router.RouterGroup) {
+	group.GET("/downloads/:token", c.Download) // Download once
+}
+
+// Download godoc
+// @Summary Download a file with a token
+// @Description Download the file of a single-use download token, e.g. from a report run. The token is used up by the first download; used and expired tokens get 410
+// @Tags Core/Downloads
+// @Produce octet-stream
+// @Param token path string true "Download token"
+// @Success 200 {file} file
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 410 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /downloads/{token} [get]
+func (c *DownloadController) Download(ctx *router.Context) error {
+	token, file, err := c.Service.Redeem(ctx.Request.Context(), ctx.Param("token"), Download{
+		IpAddress: ctx.ClientIP(),
+		UserAgent: ctx.GetHeader("User-Agent"),
+	})
+	if err != nil {
+		return c.fail(ctx, err, "Failed to download file")
+	}
+	defer file.Close()
+
+	contentType := token.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	ctx.SetHeader("Content-Type", contentType)
+	if info, err := file.Stat(); err == nil {
+		ctx.SetHeader("Content-Length", strconv.FormatInt(info.Size(), 10))
+	}
+	ctx.SetHeader("Content-Disposition", fmt.Sprintf("attachment; filename=%q", token.Filename))
+	ctx.SetHeader("Cache-Control", "no-store")
+	ctx.Writer.WriteHeader(http.StatusOK)
+	_, err = io.Copy(ctx.Writer, file)
+	return err
+}
+
+// ListDownloadTokens godoc
+// @Summary List download tokens
+// @Description Get the download tokens issued, the newest first, with who they were issued to and when and from where their file was downloaded (Admin only)
+// @Tags Core/Downloads
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param source query string false "Module of the files, e.g. reports"
+// @Param source_id query int false "Record of the files, e.g. a report run"
+// @Param limit query int false "Most tokens listed (1-200)" default(50)
+// @Success 200 {array} DownloadToken
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /download-tokens [get]
+func (c *DownloadController) List(ctx *router.Context) error {
+	req := ListRequest{Source: ctx.Query("source")}
+	var errs validator.ValidationErrors
+	if value := ctx.Query("source_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			errs = append(errs, validator.ValidationError{
+				Field: "source_id", Tag: "numeric", Value: value,
+				Message: "source_id must be a number",
+			})
+		}
+		req.SourceId = uint(id)
+	}
+	if value := ctx.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			errs = append(errs, validator.ValidationError{
+				Field: "limit", Tag: "numeric", Value: value,
+				Message: "limit must be a number",
+			})
+		}
+		req.Limit = limit
+	}
+	if len(errs) > 0 {
+		return c.fail(ctx, errs, "Invalid download token filter")
+	}
+
+	tokens, err := c.Service.List(ctx.Request.Context(), req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to fetch download tokens")
+	}
+	return ctx.JSON(http.StatusOK, tokens)
+}
+
+// fail writes the error response of a service error
+func (c *DownloadController) fail(ctx *router.Context, err error, message string) error {
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   translation.Error(ctx, err),
+			Details: translation.LocalizeValidation(ctx, validationErrors),
+		})
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Download link not found"})
+	}
+	for gone, message := range map[error]string{
+		ErrTokenUsed:    "Download link was already used",
+		ErrTokenExpired: "Download link expired",
+		ErrFileGone:     "File is no longer available",
+	} {
+		if errors.Is(err, gone) {
+			return ctx.JSON(http.StatusGone, types.ErrorResponse{Error: message})
+		}
+	}
+	return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: message + ": " + err.Error()})
+}
@@ -0,0 +1,64 @@
+package downloads
+
+import (
+	"time"
+)
+
+// Limits of the token list
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+// DownloadToken is a single-use, expiring link to a generated file, e.g. the result of a
+// report run. Only the SHA-256 of the token is stored. Once used, it records when and from
+// where the file was downloaded.
+type DownloadToken struct {
+	Id           uint       `json:"id" gorm:"primarykey"`
+	CreatedAt    time.Time  `json:"created_at"`
+	TokenHash    string     `json:"-" gorm:"size:64;uniqueIndex"`
+	Source       string     `json:"source" gorm:"size:50;index:idx_download_tokens_source"` // Module of the file, e.g. reports
+	SourceId     uint       `json:"source_id" gorm:"index:idx_download_tokens_source"`      // e.g. the report run
+	Filename     string     `json:"filename" gorm:"size:255"`
+	ContentType  string     `json:"content_type" gorm:"size:100"`
+	Path         string     `json:"-" gorm:"size:500"`
+	CreatedBy    uint       `json:"created_by"` // User the link was issued to, or for
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"index"`
+	DownloadedAt *time.Time `json:"downloaded_at"`
+	IpAddress    string     `json:"ip_address,omitempty" gorm:"size:45"`
+	UserAgent    string     `json:"user_agent,omitempty" gorm:"size:500"`
+}
+
+// TableName returns the table name for the DownloadToken model
+func (m *DownloadToken) TableName() string {
+	return "download_tokens"
+}
+
+// File is a file to issue a download token for
+type File struct {
+	Source      string
+	SourceId    uint
+	Path        string // Absolute, or relative to the working directory
+	Filename    string // Sent as the name of the download
+	ContentType string
+}
+
+// Issued is a new download token; the token itself is only returned here
+type Issued struct {
+	Token     string    `json:"token"`
+	Url       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Download is who downloaded a token's file
+type Download struct {
+	IpAddress string
+	UserAgent string
+}
+
+// ListRequest filters the token list
+type ListRequest struct {
+	Source   string
+	SourceId uint
+	Limit    int
+}
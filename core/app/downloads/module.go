@@ -0,0 +1,63 @@
+package downloads
+
+import (
+	"time"
+
+	"base/core/app/authorization"
+	"base/core/config"
+	"base/core/module"
+	"base/core/router"
+
+	"gorm.io/gorm"
+)
+
+// Module provides single-use, expiring download links for generated files at
+// GET /downloads/:token and the audit of their downloads. Modules issue links with a
+// DownloadService of their own, see NewService.
+type Module struct {
+	module.DefaultModule
+	DB         *gorm.DB
+	Service    *DownloadService
+	Controller *DownloadController
+}
+
+// Init creates and initializes the downloads module with all dependencies
+func Init(deps module.Dependencies) module.Module {
+	registerEvents()
+	service := NewService(deps)
+
+	return &Module{
+		DB:         deps.DB,
+		Service:    service,
+		Controller: NewDownloadController(service),
+	}
+}
+
+// NewService returns a download service configured with DOWNLOAD_TOKEN_TTL and the base
+// URL of the API
+func NewService(deps module.Dependencies) *DownloadService {
+	ttl, _ := time.ParseDuration(config.DefaultDownloadTokenTTL)
+	baseUrl := ""
+	if deps.Config != nil {
+		ttl, baseUrl = deps.Config.DownloadTokenTTL, deps.Config.BaseURL
+	}
+	return NewDownloadService(deps.DB, deps.Emitter, deps.Logger, ttl, baseUrl)
+}
+
+// Routes registers the module routes: the downloads are public, the token list is
+// restricted to admins
+func (m *Module) Routes(router *router.RouterGroup) {
+	group := router.Group("", authorization.RequireAdmin(authorization.NewAuthorizationService(m.DB)))
+	m.Controller.Routes(group)
+	m.Controller.PublicRoutes(router)
+}
+
+func (m *Module) Migrate() error {
+	return m.DB.AutoMigrate(&DownloadToken{})
+}
+
+func (m *Module) GetModels() []any {
+	return []any{
+		&DownloadToken{},
+	}
+}
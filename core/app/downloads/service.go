@@ -0,0 +1,175 @@
+package downloads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"base/core/emitter"
+	"base/core/logger"
+	"base/core/security"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+// DownloadEvent is emitted with the DownloadToken after its file was handed out
+const DownloadEvent = "downloads.download"
+
+// registerEvents adds the events of the module to the event catalog
+func registerEvents() {
+	emitter.RegisterEvents(
+		emitter.EventType{Name: DownloadEvent, Description: "A download link was used", Payload: &DownloadToken{}},
+	)
+}
+
+// Errors of tokens that can't be downloaded
+var (
+	ErrTokenUsed    = errors.New("download link was already used")
+	ErrTokenExpired = errors.New("download link expired")
+	ErrFileGone     = errors.New("file is no longer available")
+)
+
+// DownloadService issues download tokens and hands out their files once
+type DownloadService struct {
+	DB      *gorm.DB
+	Emitter *emitter.Emitter
+	Logger  logger.Logger
+	TTL     time.Duration // How long tokens are valid
+	BaseUrl string        // Of the links, e.g. https://api.example.com
+}
+
+func NewDownloadService(db *gorm.DB, emitter *emitter.Emitter, logger logger.Logger, ttl time.Duration, baseUrl string) *DownloadService {
+	return &DownloadService{
+		DB:      db,
+		Emitter: emitter,
+		Logger:  logger,
+		TTL:     ttl,
+		BaseUrl: strings.TrimSuffix(baseUrl, "/"),
+	}
+}
+
+// Issue creates a token downloading file once before it expires
+func (s *DownloadService) Issue(ctx context.Context, file File, userId uint) (*Issued, error) {
+	token := security.NewToken(32)
+	record := &DownloadToken{
+		TokenHash:   security.HashToken(token),
+		Source:      file.Source,
+		SourceId:    file.SourceId,
+		Filename:    file.Filename,
+		ContentType: file.ContentType,
+		Path:        file.Path,
+		CreatedBy:   userId,
+		ExpiresAt:   time.Now().Add(s.TTL).Truncate(time.Second),
+	}
+	if err := s.DB.WithContext(ctx).Create(record).Error; err != nil {
+		return nil, fmt.Errorf("failed to create download token: %w", err)
+	}
+
+	return &Issued{
+		Token:     token,
+		Url:       s.BaseUrl + "/api/downloads/" + token,
+		ExpiresAt: record.ExpiresAt,
+	}, nil
+}
+
+// Redeem uses a token up and returns it with its file, recording who downloaded it. The
+// caller closes the file.
+func (s *DownloadService) Redeem(ctx context.Context, token string, download Download) (*DownloadToken, *os.File, error) {
+	var record DownloadToken
+	if err := s.DB.WithContext(ctx).Where("token_hash = ?", security.HashToken(token)).First(&record).Error; err != nil {
+		return nil, nil, err
+	}
+	if record.DownloadedAt != nil {
+		return nil, nil, ErrTokenUsed
+	}
+	now := time.Now()
+	if !now.Before(record.ExpiresAt) {
+		return nil, nil, ErrTokenExpired
+	}
+
+	file, err := os.Open(record.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, ErrFileGone
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open download: %w", err)
+	}
+
+	// Concurrent requests with the same token race for the update; only one wins
+	result := s.DB.WithContext(ctx).Model(&DownloadToken{}).
+		Where("id = ? AND downloaded_at IS NULL", record.Id).
+		Updates(map[string]any{
+			"downloaded_at": now,
+			"ip_address":    download.IpAddress,
+			"user_agent":    truncate(download.UserAgent, 500),
+		})
+	if result.Error != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to use download token: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		file.Close()
+		return nil, nil, ErrTokenUsed
+	}
+
+	record.DownloadedAt = &now
+	record.IpAddress = download.IpAddress
+	record.UserAgent = truncate(download.UserAgent, 500)
+	if s.Emitter != nil {
+		s.Emitter.EmitContext(ctx, DownloadEvent, &record)
+	}
+	s.Logger.Info("file downloaded",
+		logger.String("source", record.Source),
+		logger.Uint("source_id", record.SourceId),
+		logger.Uint("token_id", record.Id),
+		logger.String("ip_address", download.IpAddress))
+	return &record, file, nil
+}
+
+// List returns the tokens issued, the newest first, with their downloads
+func (s *DownloadService) List(ctx context.Context, req ListRequest) ([]*DownloadToken, error) {
+	if err := validateListRequest(&req); err != nil {
+		return nil, err
+	}
+
+	query := s.DB.WithContext(ctx).Order("id desc").Limit(req.Limit)
+	if req.Source != "" {
+		query = query.Where("source = ?", req.Source)
+	}
+	if req.SourceId != 0 {
+		query = query.Where("source_id = ?", req.SourceId)
+	}
+	tokens := []*DownloadToken{}
+	if err := query.Find(&tokens).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch download tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// validateListRequest applies the default limit and checks its range
+func validateListRequest(req *ListRequest) error {
+	if req.Limit == 0 {
+		req.Limit = DefaultLimit
+	}
+	if req.Limit < 1 || req.Limit > MaxLimit {
+		return validator.ValidationErrors{{
+			Field:   "limit",
+			Tag:     "range",
+			Param:   fmt.Sprintf("1-%d", MaxLimit),
+			Value:   fmt.Sprint(req.Limit),
+			Message: fmt.Sprintf("limit must be between 1 and %d", MaxLimit),
+		}}
+	}
+	return nil
+}
+
+func truncate(value string, length int) string {
+	if len(value) > length {
+		return value[:length]
+	}
+	return value
+}
@@ -0,0 +1,136 @@
+package events
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"base/core/emitter"
+	"base/core/router"
+	"base/core/translation"
+	"base/core/types"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+type EventController struct {
+	Service *EventService
+}
+
+func NewEventController(service *EventService) *EventController {
+	return &EventController{
+		Service: service,
+	}
+}
+
+// Routes registers the catalog and failure endpoints; the group is restricted to admins by
+// the module
+func (c *EventController) Routes(router *router.RouterGroup) {
+	router.GET("/_events", c.List)                            // Event catalog
+	router.GET("/events/failures", c.ListFailures)            // Failed listener calls
+	router.POST("/events/failures/:id/retry", c.RetryFailure) // Run the listener again
+}
+
+// ListEvents godoc
+// @Summary List events
+// @Description List the events the modules emit, with their descriptions and the JSON schemas of their payloads, e.g. to configure webhooks. Internal events are hooks of the modules rather than notifications and aren't meant for webhooks (Admin only)
+// @Tags Core/Events
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param module query string false "Events of a module only, e.g. pages"
+// @Success 200 {array} Event
+// @Router /_events [get]
+func (c *EventController) List(ctx *router.Context) error {
+	return ctx.JSON(http.StatusOK, c.Service.List(ctx.Query("module")))
+}
+
+// ListEventFailures godoc
+// @Summary List event listener failures
+// @Description List the listener calls that returned an error or panicked, with the payload of their event, newest first (Admin only)
+// @Tags Core/Events
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of items per page (at most 100)"
+// @Param event query string false "Event name, e.g. forms.submit"
+// @Param status query string false "open (default), resolved or all"
+// @Success 200 {object} types.PaginatedResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /events/failures [get]
+func (c *EventController) ListFailures(ctx *router.Context) error {
+	params, err := ctx.ListParams()
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+	page, limit := 1, 50
+	if params.Page != nil {
+		page = *params.Page
+	}
+	if params.Limit != nil {
+		limit = *params.Limit
+	}
+
+	filter := &FailureFilter{Event: ctx.Query("event"), Status: ctx.Query("status")}
+	result, err := c.Service.ListFailures(ctx.Request.Context(), filter, page, limit)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to fetch event failures")
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
+
+// RetryEventFailure godoc
+// @Summary Retry an event listener failure
+// @Description Run the failed listener again with the stored payload. A successful retry resolves the failure; otherwise it keeps the new error (Admin only)
+// @Tags Core/Events
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Failure id"
+// @Success 200 {object} Failure
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 409 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /events/failures/{id}/retry [post]
+func (c *EventController) RetryFailure(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	item, err := c.Service.Retry(ctx.Request.Context(), uint(id))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to retry event failure")
+	}
+	return ctx.JSON(http.StatusOK, item)
+}
+
+// retryConflicts are the messages of the failures that can't be retried
+var retryConflicts = map[error]string{
+	ErrResolved:                "The failure was already retried successfully",
+	ErrNoPayload:               "The payload of internal events isn't kept, so they can't be retried",
+	emitter.ErrUnknownListener: "The listener isn't registered anymore",
+}
+
+// fail writes the error response of a service error
+func (c *EventController) fail(ctx *router.Context, err error, message string) error {
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   translation.Error(ctx, err),
+			Details: translation.LocalizeValidation(ctx, validationErrors),
+		})
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Failure not found"})
+	}
+	for conflict, text := range retryConflicts {
+		if errors.Is(err, conflict) {
+			return ctx.JSON(http.StatusConflict, types.ErrorResponse{Error: text})
+		}
+	}
+	return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: message + ": " + err.Error()})
+}
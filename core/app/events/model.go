@@ -0,0 +1,40 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Failure statuses of the list filter
+const (
+	StatusOpen     = "open" // Not retried successfully yet
+	StatusResolved = "resolved"
+	StatusAll      = "all"
+)
+
+// Failure is a failed call of an event listener with the payload of the event, kept to be
+// retried. The payload is left out for internal events, whose payloads may hold secrets, and
+// has the fields of the payload JSON only.
+type Failure struct {
+	Id         uint            `json:"id" gorm:"primarykey"`
+	Event      string          `json:"event" gorm:"size:128;index"`
+	Listener   string          `json:"listener" gorm:"size:255"`
+	Payload    json.RawMessage `json:"payload" gorm:"type:json"`
+	Error      string          `json:"error" gorm:"type:text"` // Of the last failed attempt
+	Panic      bool            `json:"panic"`
+	Attempts   int             `json:"attempts"`
+	ResolvedAt *time.Time      `json:"resolved_at" gorm:"index"` // When a retry succeeded
+	CreatedAt  time.Time       `json:"created_at" gorm:"index"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+// TableName returns the table name for the Failure model
+func (f *Failure) TableName() string {
+	return "event_failures"
+}
+
+// FailureFilter holds the list filters. Zero values are ignored.
+type FailureFilter struct {
+	Event  string
+	Status string // StatusOpen by default
+}
@@ -0,0 +1,58 @@
+package events
+
+import (
+	"base/core/app/authorization"
+	"base/core/emitter"
+	"base/core/module"
+	"base/core/router"
+
+	"gorm.io/gorm"
+)
+
+// Module provides /_events, the catalog of the events the modules register with their
+// payload types, and keeps the failures of the event listeners to retry them from
+// /events/failures
+type Module struct {
+	module.DefaultModule
+	DB         *gorm.DB
+	Emitter    *emitter.Emitter
+	Service    *EventService
+	Controller *EventController
+}
+
+// Init creates and initializes the event catalog module with all dependencies
+func Init(deps module.Dependencies) module.Module {
+	registerCoreEvents()
+	service := NewEventService(deps.DB, deps.Emitter, deps.Logger)
+
+	return &Module{
+		DB:         deps.DB,
+		Emitter:    deps.Emitter,
+		Service:    service,
+		Controller: NewEventController(service),
+	}
+}
+
+// Routes registers the module routes, restricted to admins
+func (m *Module) Routes(router *router.RouterGroup) {
+	group := router.Group("", authorization.RequireAdmin(authorization.NewAuthorizationService(m.DB)))
+	m.Controller.Routes(group)
+}
+
+// Init stores the failures of the listeners from now on
+func (m *Module) Init() error {
+	if m.Emitter != nil {
+		m.Emitter.OnFailure(m.Service.Record)
+	}
+	return nil
+}
+
+func (m *Module) Migrate() error {
+	return m.DB.AutoMigrate(&Failure{})
+}
+
+func (m *Module) GetModels() []any {
+	return []any{
+		&Failure{},
+	}
+}
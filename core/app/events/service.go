@@ -0,0 +1,215 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"base/core/config"
+	"base/core/emitter"
+	"base/core/locks"
+	"base/core/logger"
+	"base/core/security"
+	"base/core/storage"
+	"base/core/types"
+	"base/core/validator"
+	"base/core/websocket"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrResolved  = errors.New("failure already resolved")
+	ErrNoPayload = errors.New("payload of internal events isn't kept")
+)
+
+// Event is an event of the catalog with the JSON schema of its payload
+type Event struct {
+	Name        string          `json:"name"`
+	Module      string          `json:"module"`
+	Description string          `json:"description"`
+	Internal    bool            `json:"internal"`
+	Payload     *emitter.Schema `json:"payload"`
+}
+
+// EventService lists the event catalog, e.g. for the webhook configuration of the admin UI,
+// and keeps the failures of the listeners to retry them
+type EventService struct {
+	DB      *gorm.DB
+	Emitter *emitter.Emitter
+	Logger  logger.Logger
+}
+
+func NewEventService(db *gorm.DB, emitter *emitter.Emitter, logger logger.Logger) *EventService {
+	return &EventService{
+		DB:      db,
+		Emitter: emitter,
+		Logger:  logger,
+	}
+}
+
+// List returns the events of the catalog sorted by name, those of a module when module
+// isn't empty
+func (s *EventService) List(module string) []*Event {
+	result := []*Event{}
+	for _, eventType := range emitter.EventTypes() {
+		if module != "" && eventType.Module() != module {
+			continue
+		}
+		result = append(result, &Event{
+			Name:        eventType.Name,
+			Module:      eventType.Module(),
+			Description: eventType.Description,
+			Internal:    eventType.Internal,
+			Payload:     eventType.Schema(),
+		})
+	}
+	return result
+}
+
+// Record logs and stores the failure of a listener; it is the failure handler of the emitter
+func (s *EventService) Record(ctx context.Context, failure *emitter.Failure) {
+	s.Logger.Error("event listener failed",
+		logger.String("event", failure.Event),
+		logger.String("listener", failure.Listener),
+		logger.String("error", failure.Err.Error()))
+
+	item := &Failure{
+		Event:    failure.Event,
+		Listener: failure.Listener,
+		Error:    failure.Err.Error(),
+		Panic:    failure.Panic,
+		Attempts: 1,
+	}
+	if eventType, ok := emitter.LookupEvent(failure.Event); !ok || !eventType.Internal {
+		payload, err := json.Marshal(failure.Data)
+		if err != nil {
+			s.Logger.Error("failed to encode event payload",
+				logger.String("error", err.Error()),
+				logger.String("event", failure.Event))
+		} else {
+			item.Payload = payload
+		}
+	}
+	if err := s.DB.WithContext(ctx).Create(item).Error; err != nil {
+		s.Logger.Error("failed to store event failure",
+			logger.String("error", err.Error()),
+			logger.String("event", failure.Event))
+	}
+}
+
+// ListFailures returns the failures matching the filter, newest first
+func (s *EventService) ListFailures(ctx context.Context, filter *FailureFilter, page, limit int) (*types.PaginatedResponse, error) {
+	query := s.DB.WithContext(ctx).Model(&Failure{})
+	if filter.Event != "" {
+		query = query.Where("event = ?", filter.Event)
+	}
+	switch filter.Status {
+	case "", StatusOpen:
+		query = query.Where("resolved_at IS NULL")
+	case StatusResolved:
+		query = query.Where("resolved_at IS NOT NULL")
+	case StatusAll:
+	default:
+		return nil, validator.ValidationErrors{{
+			Field: "status", Tag: "oneof", Param: "open resolved all", Value: filter.Status,
+			Message: "status must be one of open, resolved, all",
+		}}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count event failures: %w", err)
+	}
+	var items []*Failure
+	if err := query.Order("id desc").Offset((page - 1) * limit).Limit(limit).Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch event failures: %w", err)
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+	if totalPages == 0 {
+		totalPages = 1
+	}
+	return &types.PaginatedResponse{
+		Data: items,
+		Pagination: types.Pagination{
+			Total:      int(total),
+			Page:       page,
+			PageSize:   limit,
+			TotalPages: totalPages,
+		},
+	}, nil
+}
+
+// Retry runs the listener of a failure again with its stored payload. A successful retry
+// resolves the failure; a failed one keeps its error. Either way the attempt is counted.
+func (s *EventService) Retry(ctx context.Context, id uint) (*Failure, error) {
+	item := &Failure{}
+	if err := s.DB.WithContext(ctx).First(item, id).Error; err != nil {
+		return nil, err
+	}
+	if item.ResolvedAt != nil {
+		return nil, ErrResolved
+	}
+	if len(item.Payload) == 0 {
+		return nil, ErrNoPayload
+	}
+	data, err := decodePayload(item.Event, item.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode payload: %w", err)
+	}
+
+	err = s.Emitter.Retry(ctx, item.Event, item.Listener, data)
+	if errors.Is(err, emitter.ErrUnknownListener) {
+		return nil, err
+	}
+	item.Attempts++
+	if err != nil {
+		item.Error = err.Error()
+	} else {
+		now := time.Now()
+		item.ResolvedAt = &now
+	}
+	if err := s.DB.WithContext(ctx).Save(item).Error; err != nil {
+		return nil, fmt.Errorf("failed to save event failure: %w", err)
+	}
+	return item, nil
+}
+
+// decodePayload decodes a stored payload into the payload type of the event in the
+// catalog, e.g. a *pages.Page, or into plain JSON values for events without one
+func decodePayload(event string, payload json.RawMessage) (any, error) {
+	eventType, ok := emitter.LookupEvent(event)
+	if !ok || eventType.Payload == nil {
+		var data any
+		return data, json.Unmarshal(payload, &data)
+	}
+	value := reflect.New(reflect.TypeOf(eventType.Payload))
+	if err := json.Unmarshal(payload, value.Interface()); err != nil {
+		return nil, err
+	}
+	return value.Elem().Interface(), nil
+}
+
+// registerCoreEvents adds the events of the core packages, which aren't modules, to the
+// catalog
+func registerCoreEvents() {
+	emitter.RegisterEvents(
+		emitter.EventType{Name: security.LoginSucceededEvent, Description: "A user signed in", Payload: &security.Event{}},
+		emitter.EventType{Name: security.LoginFailedEvent, Description: "A sign in failed or was throttled", Payload: &security.Event{}},
+		emitter.EventType{Name: security.PasswordChangedEvent, Description: "The password of a user changed", Payload: &security.Event{}},
+		emitter.EventType{Name: security.RoleChangedEvent, Description: "The role of a user changed", Payload: &security.Event{}},
+		emitter.EventType{Name: security.PermissionGrantedEvent, Description: "A permission was granted", Payload: &security.Event{}},
+		emitter.EventType{Name: security.APIKeyCreatedEvent, Description: "An API key was created", Payload: &security.Event{}},
+		emitter.EventType{Name: locks.LockEvent, Description: "A record was locked for editing", Payload: &locks.Lock{}},
+		emitter.EventType{Name: locks.UnlockEvent, Description: "The lock of a record was released or expired", Payload: &locks.Lock{}},
+		emitter.EventType{Name: websocket.PresenceJoinEvent, Description: "A user opened their first connection to a room", Payload: &websocket.PresenceChange{}},
+		emitter.EventType{Name: websocket.PresenceLeaveEvent, Description: "A user closed their last connection to a room", Payload: &websocket.PresenceChange{}},
+		emitter.EventType{Name: storage.UploadProgressEvent, Description: "An upload was received, converted or stored, or failed", Payload: &storage.UploadProgress{}},
+		emitter.EventType{Name: config.RuntimeChangedEvent, Description: "Runtime-tunable configuration changed", Payload: config.RuntimeChange{}, Internal: true},
+	)
+}
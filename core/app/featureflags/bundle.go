@@ -0,0 +1,172 @@
+package featureflags
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"base/core/app/authorization"
+	"base/core/bundle"
+	"base/core/features"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+// bundledFlag is a feature flag in configuration bundles, matched by its key. The roles it
+// is limited to are named, as role ids differ between environments; the users it is always
+// on for are left out for the same reason and kept on import.
+type bundledFlag struct {
+	Key         string   `json:"key"`
+	Description string   `json:"description"`
+	Enabled     bool     `json:"enabled"`
+	Percentage  int      `json:"percentage"`
+	Roles       []string `json:"roles"`
+}
+
+// registerBundle adds the feature flags to configuration bundles, imported after the roles
+// they are limited to
+func (s *FlagService) registerBundle() {
+	bundle.RegisterSection(bundle.Section{
+		Name:   "feature_flags",
+		Order:  30,
+		Export: exportFlags,
+		Import: importFlags,
+		Applied: func(ctx context.Context, changes *bundle.Changes) {
+			var flags []*features.Flag
+			if err := s.DB.WithContext(ctx).Where("`key` IN ?", changes.Changed()).Find(&flags).Error; err != nil {
+				s.Evaluator.Invalidate()
+				return
+			}
+			for _, flag := range flags {
+				event := UpdateFlagEvent
+				if slices.Contains(changes.Created, flag.Key) {
+					event = CreateFlagEvent
+				}
+				s.changed(event, flag)
+			}
+		},
+	})
+}
+
+func exportFlags(ctx context.Context, db *gorm.DB) (any, error) {
+	var flags []*features.Flag
+	if err := db.Order("`key`").Find(&flags).Error; err != nil {
+		return nil, err
+	}
+	names, err := roleNames(db)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]*bundledFlag, len(flags))
+	for i, flag := range flags {
+		records[i] = toBundled(flag, names)
+	}
+	return records, nil
+}
+
+func importFlags(ctx context.Context, tx *gorm.DB, data json.RawMessage, changes *bundle.Changes) error {
+	var records []*bundledFlag
+	if err := json.Unmarshal(data, &records); err != nil {
+		return validator.ValidationErrors{{Tag: "invalid", Message: "feature_flags must be a list of feature flags"}}
+	}
+
+	// Roles of the bundle were imported already
+	names, err := roleNames(tx)
+	if err != nil {
+		return err
+	}
+	roleIds := make(map[string]uint, len(names))
+	for id, name := range names {
+		roleIds[name] = id
+	}
+
+	var errs validator.ValidationErrors
+	for i, record := range records {
+		var fieldErrs validator.ValidationErrors
+		if errors.As(validateFlag(&features.Flag{Key: record.Key, Percentage: record.Percentage}), &fieldErrs) {
+			for _, e := range fieldErrs {
+				e.Field = fmt.Sprintf("[%d].%s", i, e.Field)
+				errs = append(errs, e)
+			}
+		}
+		for j, name := range record.Roles {
+			if _, ok := roleIds[name]; !ok {
+				errs = append(errs, validator.ValidationError{
+					Field: fmt.Sprintf("[%d].roles[%d]", i, j), Tag: "exists", Value: name,
+					Message: fmt.Sprintf("role %s does not exist", name),
+				})
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	for _, record := range records {
+		roles := append([]string{}, record.Roles...)
+		slices.Sort(roles)
+		record.Roles = slices.Compact(roles)
+
+		var flag features.Flag
+		err := tx.Where("`key` = ?", record.Key).First(&flag).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var before *bundledFlag
+		if err == nil {
+			before = toBundled(&flag, names)
+		}
+		if !changes.Track(record.Key, before, record) {
+			continue
+		}
+
+		flag.Key = record.Key
+		flag.Description = record.Description
+		flag.Enabled = record.Enabled
+		flag.Percentage = record.Percentage
+		flag.RoleIds = make([]uint, len(record.Roles))
+		for i, name := range record.Roles {
+			flag.RoleIds[i] = roleIds[name]
+		}
+		if err := tx.Save(&flag).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// toBundled returns a flag as it is in bundles, with the sorted names of its roles
+func toBundled(flag *features.Flag, names map[uint]string) *bundledFlag {
+	roles := []string{}
+	for _, id := range flag.RoleIds {
+		if name, ok := names[id]; ok {
+			roles = append(roles, name)
+		}
+	}
+	slices.Sort(roles)
+	return &bundledFlag{
+		Key:         flag.Key,
+		Description: flag.Description,
+		Enabled:     flag.Enabled,
+		Percentage:  flag.Percentage,
+		Roles:       slices.Compact(roles),
+	}
+}
+
+// roleNames returns the names of the roles by id
+func roleNames(db *gorm.DB) (map[uint]string, error) {
+	var roles []authorization.Role
+	if err := db.Select("id", "name").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string, len(roles))
+	for _, role := range roles {
+		names[role.Id] = role.Name
+	}
+	return names, nil
+}
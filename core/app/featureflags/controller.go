@@ -0,0 +1,201 @@
+package featureflags
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"base/core/router"
+	"base/core/translation"
+	"base/core/types"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+type FlagController struct {
+	Service *FlagService
+}
+
+func NewFlagController(service *FlagService) *FlagController {
+	return &FlagController{
+		Service: service,
+	}
+}
+
+// Routes registers the admin endpoints; the group is restricted to admins by the module
+func (c *FlagController) Routes(router *router.RouterGroup) {
+	router.GET("/feature-flags", c.List)          // List
+	router.POST("/feature-flags", c.Create)       // Create
+	router.GET("/feature-flags/:id", c.Get)       // Get by ID
+	router.PUT("/feature-flags/:id", c.Update)    // Update
+	router.DELETE("/feature-flags/:id", c.Delete) // Delete
+}
+
+// UserRoutes registers the endpoints available to every authenticated user
+func (c *FlagController) UserRoutes(router *router.RouterGroup) {
+	router.GET("/features", c.Current)
+}
+
+// CurrentFeatures godoc
+// @Summary Feature flags of the current user
+// @Description Get every feature flag and whether it is on for the authenticated user
+// @Tags Core/Features
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} UserFeaturesResponse
+// @Router /features [get]
+func (c *FlagController) Current(ctx *router.Context) error {
+	subject := c.Service.Evaluator.SubjectOf(ctx)
+	return ctx.JSON(http.StatusOK, c.Service.ForSubject(subject))
+}
+
+// ListFeatureFlags godoc
+// @Summary List feature flags
+// @Description Get all feature flags ordered by key (Admin only)
+// @Tags Core/Features
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} FlagResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /feature-flags [get]
+func (c *FlagController) List(ctx *router.Context) error {
+	flags, err := c.Service.GetAll(ctx.Request.Context())
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: err.Error()})
+	}
+
+	responses := make([]*FlagResponse, 0, len(flags))
+	for _, flag := range flags {
+		responses = append(responses, ToFlagResponse(flag))
+	}
+	return ctx.JSON(http.StatusOK, responses)
+}
+
+// CreateFeatureFlag godoc
+// @Summary Create a feature flag
+// @Description Create a feature flag; percentage defaults to 100 (Admin only)
+// @Tags Core/Features
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param flag body CreateFlagRequest true "Create feature flag request"
+// @Success 201 {object} FlagResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /feature-flags [post]
+func (c *FlagController) Create(ctx *router.Context) error {
+	var req CreateFlagRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	flag, err := c.Service.Create(ctx.Request.Context(), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to create feature flag")
+	}
+
+	return ctx.JSON(http.StatusCreated, ToFlagResponse(flag))
+}
+
+// GetFeatureFlag godoc
+// @Summary Get a feature flag
+// @Description Get a feature flag by its id (Admin only)
+// @Tags Core/Features
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Feature flag id"
+// @Success 200 {object} FlagResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /feature-flags/{id} [get]
+func (c *FlagController) Get(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	flag, err := c.Service.GetById(ctx.Request.Context(), uint(id))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to fetch feature flag")
+	}
+
+	return ctx.JSON(http.StatusOK, ToFlagResponse(flag))
+}
+
+// UpdateFeatureFlag godoc
+// @Summary Update a feature flag
+// @Description Update a feature flag by its id; the key can't be changed (Admin only)
+// @Tags Core/Features
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Feature flag id"
+// @Param flag body UpdateFlagRequest true "Update feature flag request"
+// @Success 200 {object} FlagResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /feature-flags/{id} [put]
+func (c *FlagController) Update(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	var req UpdateFlagRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	flag, err := c.Service.Update(ctx.Request.Context(), uint(id), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to update feature flag")
+	}
+
+	return ctx.JSON(http.StatusOK, ToFlagResponse(flag))
+}
+
+// DeleteFeatureFlag godoc
+// @Summary Delete a feature flag
+// @Description Delete a feature flag by its id; the feature is off afterwards (Admin only)
+// @Tags Core/Features
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param id path int true "Feature flag id"
+// @Success 200 {object} types.SuccessResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /feature-flags/{id} [delete]
+func (c *FlagController) Delete(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	if err := c.Service.Delete(ctx.Request.Context(), uint(id)); err != nil {
+		return c.fail(ctx, err, "Failed to delete feature flag")
+	}
+
+	return ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "Feature flag deleted successfully", Success: true})
+}
+
+// fail writes the error response of a service error
+func (c *FlagController) fail(ctx *router.Context, err error, message string) error {
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   translation.Error(ctx, err),
+			Details: translation.LocalizeValidation(ctx, validationErrors),
+		})
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Feature flag not found"})
+	}
+	return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: message + ": " + err.Error()})
+}
@@ -0,0 +1,72 @@
+package featureflags
+
+import (
+	"time"
+
+	"base/core/features"
+)
+
+// CreateFlagRequest represents the request payload for creating a feature flag
+type CreateFlagRequest struct {
+	Key         string `json:"key"`
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled"`
+	Percentage  *int   `json:"percentage,omitempty"` // Defaults to 100
+	RoleIds     []uint `json:"role_ids,omitempty"`
+	UserIds     []uint `json:"user_ids,omitempty"`
+}
+
+// UpdateFlagRequest represents the request payload for updating a feature flag. Omitted
+// fields are left unchanged; an empty role_ids or user_ids list clears the targeting.
+type UpdateFlagRequest struct {
+	Description *string `json:"description,omitempty"`
+	Enabled     *bool   `json:"enabled,omitempty"`
+	Percentage  *int    `json:"percentage,omitempty"`
+	RoleIds     *[]uint `json:"role_ids,omitempty"`
+	UserIds     *[]uint `json:"user_ids,omitempty"`
+}
+
+// FlagResponse represents the API response for a feature flag
+type FlagResponse struct {
+	Id          uint      `json:"id"`
+	Key         string    `json:"key"`
+	Description string    `json:"description"`
+	Enabled     bool      `json:"enabled"`
+	Percentage  int       `json:"percentage"`
+	RoleIds     []uint    `json:"role_ids"`
+	UserIds     []uint    `json:"user_ids"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// UserFeaturesResponse lists the flags of the current user
+type UserFeaturesResponse struct {
+	Features map[string]bool `json:"features"` // Every flag and whether it is on
+	Enabled  []string        `json:"enabled"`  // Keys of the flags that are on
+}
+
+// ToFlagResponse converts a flag to its API response
+func ToFlagResponse(flag *features.Flag) *FlagResponse {
+	if flag == nil {
+		return nil
+	}
+
+	response := &FlagResponse{
+		Id:          flag.Id,
+		Key:         flag.Key,
+		Description: flag.Description,
+		Enabled:     flag.Enabled,
+		Percentage:  flag.Percentage,
+		RoleIds:     flag.RoleIds,
+		UserIds:     flag.UserIds,
+		CreatedAt:   flag.CreatedAt,
+		UpdatedAt:   flag.UpdatedAt,
+	}
+	if response.RoleIds == nil {
+		response.RoleIds = []uint{}
+	}
+	if response.UserIds == nil {
+		response.UserIds = []uint{}
+	}
+	return response
+}
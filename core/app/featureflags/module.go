@@ -0,0 +1,63 @@
+package featureflags
+
+import (
+	"base/core/app/authorization"
+	"base/core/features"
+	"base/core/module"
+	"base/core/router"
+
+	"gorm.io/gorm"
+)
+
+// Module provides the feature_flags table, its admin endpoints and GET /features for the
+// current user. Flags are evaluated by deps.Features (features.Flags); guard routes with
+// features.RequireFeature("key").
+type Module struct {
+	module.DefaultModule
+	DB         *gorm.DB
+	Service    *FlagService
+	Controller *FlagController
+}
+
+// Init creates and initializes the feature flags module with all dependencies
+func Init(deps module.Dependencies) module.Module {
+	registerEvents()
+	service := NewFlagService(deps.DB, deps.Emitter, deps.Features, deps.Logger)
+	controller := NewFlagController(service)
+
+	// Flags are promoted between environments with configuration bundles
+	service.registerBundle()
+
+	return &Module{
+		DB:         deps.DB,
+		Service:    service,
+		Controller: controller,
+	}
+}
+
+// Routes registers the module routes; managing flags is restricted to admins
+func (m *Module) Routes(router *router.RouterGroup) {
+	m.Controller.UserRoutes(router)
+
+	group := router.Group("", authorization.RequireAdmin(authorization.NewAuthorizationService(m.DB)))
+	m.Controller.Routes(group)
+}
+
+func (m *Module) Init() error {
+	return nil
+}
+
+func (m *Module) Migrate() error {
+	if err := m.DB.AutoMigrate(&features.Flag{}); err != nil {
+		return err
+	}
+	// Flags created before the table existed couldn't be loaded
+	m.Service.Evaluator.Invalidate()
+	return nil
+}
+
+func (m *Module) GetModels() []any {
+	return []any{
+		&features.Flag{},
+	}
+}
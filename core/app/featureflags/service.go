@@ -0,0 +1,213 @@
+package featureflags
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"base/core/emitter"
+	"base/core/features"
+	"base/core/logger"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+const (
+	CreateFlagEvent = "feature_flags.create"
+	UpdateFlagEvent = "feature_flags.update"
+	DeleteFlagEvent = "feature_flags.delete"
+)
+
+// registerEvents adds the events of the module to the event catalog
+func registerEvents() {
+	emitter.RegisterEvents(
+		emitter.EventType{Name: CreateFlagEvent, Description: "A feature flag was created", Payload: &features.Flag{}},
+		emitter.EventType{Name: UpdateFlagEvent, Description: "A feature flag was updated", Payload: &features.Flag{}},
+		emitter.EventType{Name: DeleteFlagEvent, Description: "A feature flag was deleted", Payload: &features.Flag{}},
+	)
+}
+
+// keyPattern matches flag keys such as "new-media-ui" or "billing.v2"
+var keyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,99}$`)
+
+// FlagService manages feature flags and keeps the evaluator up to date
+type FlagService struct {
+	DB        *gorm.DB
+	Emitter   *emitter.Emitter
+	Logger    logger.Logger
+	Evaluator *features.Evaluator
+}
+
+func NewFlagService(db *gorm.DB, emitter *emitter.Emitter, evaluator *features.Evaluator, logger logger.Logger) *FlagService {
+	if evaluator == nil {
+		evaluator = features.Flags
+	}
+	return &FlagService{
+		DB:        db,
+		Emitter:   emitter,
+		Logger:    logger,
+		Evaluator: evaluator,
+	}
+}
+
+// GetAll returns all flags ordered by key
+func (s *FlagService) GetAll(ctx context.Context) ([]*features.Flag, error) {
+	var flags []*features.Flag
+	if err := s.DB.WithContext(ctx).Order("`key` asc").Find(&flags).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch feature flags: %w", err)
+	}
+	return flags, nil
+}
+
+// GetById returns a flag by id
+func (s *FlagService) GetById(ctx context.Context, id uint) (*features.Flag, error) {
+	var flag features.Flag
+	if err := s.DB.WithContext(ctx).First(&flag, id).Error; err != nil {
+		return nil, err
+	}
+	return &flag, nil
+}
+
+// Create creates a flag
+func (s *FlagService) Create(ctx context.Context, req *CreateFlagRequest) (*features.Flag, error) {
+	flag := &features.Flag{
+		Key:         req.Key,
+		Description: req.Description,
+		Enabled:     req.Enabled,
+		Percentage:  100,
+		RoleIds:     req.RoleIds,
+		UserIds:     req.UserIds,
+	}
+	if req.Percentage != nil {
+		flag.Percentage = *req.Percentage
+	}
+
+	if err := validateFlag(flag); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.DB.WithContext(ctx).Model(&features.Flag{}).Where("`key` = ?", flag.Key).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, validator.ValidationErrors{{
+			Field:   "key",
+			Tag:     "unique",
+			Value:   flag.Key,
+			Message: "key is already taken",
+		}}
+	}
+
+	if err := s.DB.WithContext(ctx).Create(flag).Error; err != nil {
+		s.Logger.Error("failed to create feature flag", logger.String("error", err.Error()))
+		return nil, fmt.Errorf("failed to create feature flag: %w", err)
+	}
+
+	s.changed(CreateFlagEvent, flag)
+	return flag, nil
+}
+
+// Update updates a flag; its key can't be changed
+func (s *FlagService) Update(ctx context.Context, id uint, req *UpdateFlagRequest) (*features.Flag, error) {
+	flag, err := s.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Description != nil {
+		flag.Description = *req.Description
+	}
+	if req.Enabled != nil {
+		flag.Enabled = *req.Enabled
+	}
+	if req.Percentage != nil {
+		flag.Percentage = *req.Percentage
+	}
+	if req.RoleIds != nil {
+		flag.RoleIds = *req.RoleIds
+	}
+	if req.UserIds != nil {
+		flag.UserIds = *req.UserIds
+	}
+
+	if err := validateFlag(flag); err != nil {
+		return nil, err
+	}
+
+	if err := s.DB.WithContext(ctx).Save(flag).Error; err != nil {
+		s.Logger.Error("failed to update feature flag",
+			logger.String("error", err.Error()),
+			logger.Uint("id", id))
+		return nil, fmt.Errorf("failed to update feature flag: %w", err)
+	}
+
+	s.changed(UpdateFlagEvent, flag)
+	return flag, nil
+}
+
+// Delete deletes a flag; it is off everywhere afterwards
+func (s *FlagService) Delete(ctx context.Context, id uint) error {
+	flag, err := s.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.DB.WithContext(ctx).Delete(flag).Error; err != nil {
+		s.Logger.Error("failed to delete feature flag",
+			logger.String("error", err.Error()),
+			logger.Uint("id", id))
+		return fmt.Errorf("failed to delete feature flag: %w", err)
+	}
+
+	s.changed(DeleteFlagEvent, flag)
+	return nil
+}
+
+// ForSubject returns the flags of a user
+func (s *FlagService) ForSubject(subject features.Subject) *UserFeaturesResponse {
+	return &UserFeaturesResponse{
+		Features: s.Evaluator.Evaluate(subject),
+		Enabled:  s.Evaluator.Enabled(subject),
+	}
+}
+
+// changed reloads the evaluator and emits the event of a change
+func (s *FlagService) changed(event string, flag *features.Flag) {
+	s.Evaluator.Invalidate()
+	if s.Emitter != nil {
+		s.Emitter.Emit(event, flag)
+	}
+	s.Logger.Info("feature flag changed",
+		logger.String("event", event),
+		logger.String("key", flag.Key),
+		logger.Bool("enabled", flag.Enabled),
+		logger.Int("percentage", flag.Percentage))
+}
+
+// validateFlag checks the key and the rollout percentage of a flag
+func validateFlag(flag *features.Flag) error {
+	var errs validator.ValidationErrors
+	if !keyPattern.MatchString(flag.Key) {
+		errs = append(errs, validator.ValidationError{
+			Field:   "key",
+			Tag:     "slug",
+			Value:   flag.Key,
+			Message: "key must contain only lowercase letters, digits, '.', '_' or '-'",
+		})
+	}
+	if flag.Percentage < 0 || flag.Percentage > 100 {
+		errs = append(errs, validator.ValidationError{
+			Field:   "percentage",
+			Tag:     "range",
+			Value:   fmt.Sprint(flag.Percentage),
+			Param:   "0-100",
+			Message: "percentage must be in the range 0-100",
+		})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -2,14 +2,28 @@ package app
 
 import (
 	"base/core/app/activities"
+	"base/core/app/apitokens"
 	"base/core/app/authentication"
 	"base/core/app/authorization"
+	"base/core/app/bundles"
+	"base/core/app/customfields"
+	"base/core/app/dashboard"
+	"base/core/app/downloads"
+	"base/core/app/events"
+	"base/core/app/featureflags"
+	"base/core/app/limits"
 	"base/core/app/media"
 	"base/core/app/notifications"
 	"base/core/app/oauth"
+	"base/core/app/reports"
+	"base/core/app/requestlogs"
 	"base/core/app/search"
+	"base/core/app/securitylog"
 	"base/core/app/settings"
+	"base/core/app/trash"
 	"base/core/app/users"
+	"base/core/app/views"
+	"base/core/logger"
 	"base/core/module"
 	"base/core/scheduler"
 	"base/core/translation"
@@ -28,37 +42,41 @@ func (cm *CoreModules) GetCoreModules(deps module.Dependencies) map[string]modul
 	// Core modules - essential system functionality
 	modules["media"] = media.NewMediaModule(
 		deps.DB,
+		deps.Tx,
 		deps.Router,
 		deps.Storage,
 		deps.Emitter,
-		deps.Logger,
+		logger.ForModule(deps.Logger, "media"),
+		deps.Config,
 	)
 
 	modules["authentication"] = authentication.NewAuthenticationModule(
 		deps.DB,
 		deps.Router, // Will be handled by orchestrator to use AuthRouter
 		deps.EmailSender,
-		deps.Logger,
+		logger.ForModule(deps.Logger, "authentication"),
 		deps.Emitter,
+		deps.Config,
 	)
 
 	modules["oauth"] = oauth.NewOAuthModule(
 		deps.DB,
 		deps.Router,
-		deps.Logger,
+		logger.ForModule(deps.Logger, "oauth"),
 		deps.Storage,
 	)
 
 	modules["authorization"] = authorization.NewAuthorizationModule(
 		deps.DB,
 		deps.Router, // Will be handled by orchestrator to use AuthRouter
-		deps.Logger,
+		logger.ForModule(deps.Logger, "authorization"),
+		deps.Emitter,
 	)
 
 	modules["translation"] = translation.NewTranslationModule(
 		deps.DB,
 		deps.Router,
-		deps.Logger,
+		logger.ForModule(deps.Logger, "translation"),
 		deps.Emitter,
 		deps.Storage,
 	)
@@ -66,19 +84,32 @@ func (cm *CoreModules) GetCoreModules(deps module.Dependencies) map[string]modul
 	modules["scheduler"] = scheduler.NewSchedulerModule(
 		deps.DB,
 		deps.Router,
-		deps.Logger,
+		logger.ForModule(deps.Logger, "scheduler"),
 		deps.Emitter,
 	)
 
 	// Admin template essential modules
-	modules["settings"] = settings.Init(deps)
-	modules["users"] = users.Init(deps) // Merged profile + employees management
+	modules["customfields"] = customfields.Init(deps.ForModule("customfields"))
+	modules["settings"] = settings.Init(deps.ForModule("settings"))
+	modules["users"] = users.Init(deps.ForModule("users")) // Merged profile + employees management
 
 	// Initialize search with registry (can be nil, will create empty registry)
-	modules["search"] = search.Init(deps, cm.SearchRegistry)
+	modules["search"] = search.Init(deps.ForModule("search"), cm.SearchRegistry)
 
-	modules["notifications"] = notifications.Init(deps)
-	modules["activities"] = activities.Init(deps)
+	modules["notifications"] = notifications.Init(deps.ForModule("notifications"))
+	modules["activities"] = activities.Init(deps.ForModule("activities"))
+	modules["requestlogs"] = requestlogs.Init(deps.ForModule("requestlogs"))
+	modules["securitylog"] = securitylog.Init(deps.ForModule("securitylog"))
+	modules["featureflags"] = featureflags.Init(deps.ForModule("featureflags"))
+	modules["dashboard"] = dashboard.Init(deps.ForModule("dashboard"))
+	modules["downloads"] = downloads.Init(deps.ForModule("downloads"))
+	modules["reports"] = reports.Init(deps.ForModule("reports"))
+	modules["trash"] = trash.Init(deps.ForModule("trash"))
+	modules["bundles"] = bundles.Init(deps.ForModule("bundles"))
+	modules["events"] = events.Init(deps.ForModule("events"))
+	modules["apitokens"] = apitokens.Init(deps.ForModule("apitokens"))
+	modules["views"] = views.Init(deps.ForModule("views"))
+	modules["limits"] = limits.Init(deps.ForModule("limits"))
 
 	return modules
 }
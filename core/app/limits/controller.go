@@ -0,0 +1,50 @@
+package limits
+
+import (
+	"net/http"
+
+	"base/core/router"
+	"base/core/router/middleware"
+	"base/core/types"
+)
+
+type LimitController struct {
+	Service *LimitService
+}
+
+func NewLimitController(service *LimitService) *LimitController {
+	return &LimitController{
+		Service: service,
+	}
+}
+
+func (c *LimitController) Routes(router *router.RouterGroup) {
+	router.GET("/limits", c.Get)
+}
+
+// GetLimits godoc
+// @Summary Get quotas
+// @Description The quotas of the caller, so clients can pace themselves: the rate limit left after this request (also sent in the X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset headers of every rate limited response), the storage used and the user seats taken. Unlimited quotas have a null limit.
+// @Tags Core/Limits
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} LimitsResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /limits [get]
+func (c *LimitController) Get(ctx *router.Context) error {
+	response := &LimitsResponse{}
+	if quota, ok := middleware.RateLimitQuota(ctx); ok {
+		response.RateLimit = &quota
+	}
+
+	var err error
+	if response.Storage, err = c.Service.Storage(ctx.Request.Context()); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: err.Error()})
+	}
+	if response.Seats, err = c.Service.Seats(ctx.Request.Context()); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: err.Error()})
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
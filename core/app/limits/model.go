@@ -0,0 +1,28 @@
+package limits
+
+import "base/core/router/middleware"
+
+// Usage is the use of a quota; Limit and Remaining are null when it is unlimited
+type Usage struct {
+	Limit     *int64 `json:"limit"`
+	Used      int64  `json:"used"`
+	Remaining *int64 `json:"remaining"`
+}
+
+// newUsage returns the usage of a quota, where a limit of 0 is unlimited
+func newUsage(limit, used int64) Usage {
+	usage := Usage{Used: used}
+	if limit > 0 {
+		remaining := max(limit-used, 0)
+		usage.Limit = &limit
+		usage.Remaining = &remaining
+	}
+	return usage
+}
+
+// LimitsResponse represents the API response for the quotas of the caller
+type LimitsResponse struct {
+	RateLimit *middleware.Quota `json:"rate_limit"` // Null when the caller isn't rate limited
+	Storage   Usage             `json:"storage"`    // Bytes of stored files
+	Seats     Usage             `json:"seats"`      // User accounts
+}
@@ -0,0 +1,45 @@
+package limits
+
+import (
+	"base/core/module"
+	"base/core/router"
+)
+
+// Module provides GET /limits, the quotas of the caller: rate limit, storage and seats. It
+// has no tables of its own.
+type Module struct {
+	module.DefaultModule
+	Service    *LimitService
+	Controller *LimitController
+}
+
+// Init creates and initializes the limits module with all dependencies
+func Init(deps module.Dependencies) module.Module {
+	var storageQuota, userSeats int64
+	if deps.Config != nil {
+		storageQuota, userSeats = deps.Config.StorageQuota, int64(deps.Config.UserSeats)
+	}
+	service := NewLimitService(deps.DB, storageQuota, userSeats)
+
+	return &Module{
+		Service:    service,
+		Controller: NewLimitController(service),
+	}
+}
+
+// Routes registers the module routes
+func (m *Module) Routes(router *router.RouterGroup) {
+	m.Controller.Routes(router)
+}
+
+func (m *Module) Init() error {
+	return nil
+}
+
+func (m *Module) Migrate() error {
+	return nil
+}
+
+func (m *Module) GetModels() []any {
+	return []any{}
+}
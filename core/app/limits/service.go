@@ -0,0 +1,45 @@
+package limits
+
+import (
+	"context"
+	"fmt"
+
+	"base/core/app/users"
+	"base/core/storage"
+
+	"gorm.io/gorm"
+)
+
+// LimitService reports the use of the storage and seat quotas
+type LimitService struct {
+	DB           *gorm.DB
+	StorageQuota int64 // Bytes, 0 is unlimited
+	UserSeats    int64 // Users, 0 is unlimited
+}
+
+func NewLimitService(db *gorm.DB, storageQuota, userSeats int64) *LimitService {
+	return &LimitService{
+		DB:           db,
+		StorageQuota: storageQuota,
+		UserSeats:    userSeats,
+	}
+}
+
+// Storage returns the bytes of the stored files against the storage quota
+func (s *LimitService) Storage(ctx context.Context) (Usage, error) {
+	var used int64
+	if err := s.DB.WithContext(ctx).Model(&storage.Attachment{}).
+		Select("COALESCE(SUM(size), 0)").Scan(&used).Error; err != nil {
+		return Usage{}, fmt.Errorf("failed to sum storage: %w", err)
+	}
+	return newUsage(s.StorageQuota, used), nil
+}
+
+// Seats returns the user accounts against the seats; deleted users free their seat
+func (s *LimitService) Seats(ctx context.Context) (Usage, error) {
+	var used int64
+	if err := s.DB.WithContext(ctx).Model(&users.User{}).Count(&used).Error; err != nil {
+		return Usage{}, fmt.Errorf("failed to count users: %w", err)
+	}
+	return newUsage(s.UserSeats, used), nil
+}
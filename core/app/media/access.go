@@ -0,0 +1,100 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+
+	"base/core/app/authorization"
+
+	"gorm.io/gorm"
+)
+
+// resourceType is the resource type of media in authorization resource permissions
+const resourceType = "media"
+
+var (
+	// ErrNotFound is returned for media items that don't exist
+	ErrNotFound = errors.New("media not found")
+
+	// ErrForbidden is returned when a user may not act on a media item
+	ErrForbidden = errors.New("access to media denied")
+
+	// ErrNoFile is returned for the files of media items without a file
+	ErrNoFile = errors.New("media has no file")
+)
+
+// Access is what a user may do with media. Admins may do anything. Other users may act on the
+// media they authored, and on other media - shared media included - only when a resource
+// permission of the authorization module grants them the action, on the item or on all media.
+type Access struct {
+	UserId uint
+	Admin  bool
+
+	readAll bool   // A resource permission grants reading all media
+	readIds []uint // Media that resource permissions grant reading
+}
+
+// Access returns the media access of a user
+func (s *MediaService) Access(ctx context.Context, userId uint) (*Access, error) {
+	admin, err := s.Authorization.HasRole(ctx, uint64(userId), authorization.AdminRoles...)
+	if err != nil {
+		return nil, err
+	}
+	access := &Access{UserId: userId, Admin: admin}
+	if admin {
+		return access, nil
+	}
+
+	ids, all, err := s.Authorization.GrantedResources(ctx, uint64(userId), resourceType, authorization.ActionRead)
+	if err != nil {
+		return nil, err
+	}
+	access.readAll = all
+	for _, id := range ids {
+		if mediaId, err := strconv.ParseUint(id, 10, 32); err == nil {
+			access.readIds = append(access.readIds, uint(mediaId))
+		}
+	}
+	return access, nil
+}
+
+// Authorize returns the media item when the access allows the action on it, or ErrForbidden
+func (s *MediaService) Authorize(ctx context.Context, access *Access, id uint, action string) (*Media, error) {
+	item, err := s.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if access == nil || access.Admin || access.owns(item) {
+		return item, nil
+	}
+	if action == authorization.ActionRead && (access.readAll || slices.Contains(access.readIds, item.Id)) {
+		return item, nil
+	}
+
+	granted, err := s.Authorization.HasGrant(ctx, uint64(access.UserId), resourceType, strconv.FormatUint(uint64(item.Id), 10), action)
+	if err != nil {
+		return nil, err
+	}
+	if !granted {
+		return nil, ErrForbidden
+	}
+	return item, nil
+}
+
+// owns reports whether the user of the access authored the item
+func (a *Access) owns(item *Media) bool {
+	return item.AuthorId != nil && *item.AuthorId == a.UserId
+}
+
+// scope limits a media query to the items the access may read
+func (a *Access) scope(query *gorm.DB) *gorm.DB {
+	if a == nil || a.Admin || a.readAll {
+		return query
+	}
+	if len(a.readIds) == 0 {
+		return query.Where("author_id = ?", a.UserId)
+	}
+	return query.Where("author_id = ? OR id IN ?", a.UserId, a.readIds)
+}
@@ -0,0 +1,268 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"base/core/config"
+	"base/core/logger"
+	"base/core/storage"
+)
+
+// maxCaptionSize is the size of the largest image sent to a captioner; larger images keep
+// the alt text of the uploader
+const maxCaptionSize = 20 << 20 // 20MB
+
+// DefaultOpenAIURL is the API of the "openai" captioner when MEDIA_CAPTION_URL is empty
+const DefaultOpenAIURL = "https://api.openai.com/v1"
+
+// Caption is the description of an image written by a Captioner
+type Caption struct {
+	AltText string `json:"alt_text"` // Short, for screen readers
+	Caption string `json:"caption"`  // Longer, shown with the image; may be empty
+}
+
+// Captioner describes uploaded images, e.g. with a vision model
+type Captioner interface {
+	Name() string
+	// Caption describes an image in the language of locale, e.g. "en"
+	Caption(ctx context.Context, image []byte, contentType, locale string) (*Caption, error)
+}
+
+// NewCaptioner creates the captioner selected by MEDIA_CAPTION_PROVIDER; nil when none is
+// selected and alt text is left to the uploader
+func NewCaptioner(cfg *config.Config) (Captioner, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	switch cfg.MediaCaptionProvider {
+	case "":
+		return nil, nil
+	case "http":
+		return NewHTTPCaptioner(cfg.MediaCaptionURL, cfg.MediaCaptionAPIKey), nil
+	case "openai":
+		baseURL := cfg.MediaCaptionURL
+		if baseURL == "" {
+			baseURL = DefaultOpenAIURL
+		}
+		return NewOpenAICaptioner(baseURL, cfg.MediaCaptionAPIKey, cfg.MediaCaptionModel), nil
+	default:
+		return nil, fmt.Errorf("unsupported caption provider: %s", cfg.MediaCaptionProvider)
+	}
+}
+
+// UseCaptioner makes the service caption uploaded images with the captioner selected by
+// MEDIA_CAPTION_PROVIDER, in the default locale
+func (s *MediaService) UseCaptioner(cfg *config.Config) {
+	captioner, err := NewCaptioner(cfg)
+	if err != nil {
+		// Alt text can still be written by the uploader
+		s.Logger.Error("failed to create caption provider", logger.String("error", err.Error()))
+	}
+	s.Captioner = captioner
+	if cfg != nil {
+		s.Locale = cfg.DefaultLocale
+	}
+}
+
+// HTTPCaptioner implements Captioner with an API of your own: the image is posted as JSON
+// ({"image": "<base64>", "content_type": "image/webp", "locale": "en"}) and the API answers
+// with a Caption. The API key, if any, is sent as a Bearer token.
+type HTTPCaptioner struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+func NewHTTPCaptioner(url, apiKey string) *HTTPCaptioner {
+	return &HTTPCaptioner{
+		url:    url,
+		apiKey: apiKey,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (p *HTTPCaptioner) Name() string {
+	return "http"
+}
+
+// Caption posts the image to the API
+func (p *HTTPCaptioner) Caption(ctx context.Context, image []byte, contentType, locale string) (*Caption, error) {
+	body, err := json.Marshal(map[string]string{
+		"image":        base64.StdEncoding.EncodeToString(image),
+		"content_type": contentType,
+		"locale":       locale,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var caption Caption
+	if err := postJSON(ctx, p.client, p.url, p.apiKey, body, &caption); err != nil {
+		return nil, fmt.Errorf("caption api: %w", err)
+	}
+	return &caption, nil
+}
+
+// OpenAICaptioner implements Captioner with the chat completions of the OpenAI API, or of an
+// API compatible with it, e.g. a self-hosted vision model
+type OpenAICaptioner struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+func NewOpenAICaptioner(baseURL, apiKey, model string) *OpenAICaptioner {
+	return &OpenAICaptioner{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		client:  &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+func (p *OpenAICaptioner) Name() string {
+	return "openai"
+}
+
+// Caption asks the model for the alt text and caption of the image as a JSON object
+func (p *OpenAICaptioner) Caption(ctx context.Context, image []byte, contentType, locale string) (*Caption, error) {
+	prompt := fmt.Sprintf("Describe this image for a website in the language with the code %q. "+
+		"Answer with a JSON object with \"alt_text\", one sentence of at most 125 characters for "+
+		"screen readers that doesn't start with \"Image of\", and \"caption\", a short caption.", locale)
+	body, err := json.Marshal(map[string]any{
+		"model":           p.model,
+		"response_format": map[string]string{"type": "json_object"},
+		"messages": []map[string]any{{
+			"role": "user",
+			"content": []map[string]any{
+				{"type": "text", "text": prompt},
+				{"type": "image_url", "image_url": map[string]string{
+					"url": "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(image),
+				}},
+			},
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var completion struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := postJSON(ctx, p.client, p.baseURL+"/chat/completions", p.apiKey, body, &completion); err != nil {
+		return nil, fmt.Errorf("openai: %w", err)
+	}
+	if len(completion.Choices) == 0 {
+		return nil, fmt.Errorf("openai: no completion")
+	}
+
+	var caption Caption
+	if err := json.Unmarshal([]byte(completion.Choices[0].Message.Content), &caption); err != nil {
+		return nil, fmt.Errorf("openai: unexpected completion: %w", err)
+	}
+	return &caption, nil
+}
+
+// postJSON posts body to url and decodes the JSON response into result
+func postJSON(ctx context.Context, client *http.Client, url, apiKey string, body []byte, result any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		// {"error": {"message": "..."}} from OpenAI, {"error": "..."} from most other APIs
+		var apiErr struct {
+			Error json.RawMessage `json:"error"`
+		}
+		var detail struct {
+			Message string `json:"message"`
+		}
+		var message string
+		if json.Unmarshal(data, &apiErr) == nil && len(apiErr.Error) > 0 {
+			if json.Unmarshal(apiErr.Error, &detail) == nil {
+				message = detail.Message
+			} else {
+				_ = json.Unmarshal(apiErr.Error, &message)
+			}
+		}
+		if message != "" {
+			return errors.New(message)
+		}
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return json.Unmarshal(data, result)
+}
+
+// caption fills in the alt text and caption of a media item left empty by its uploader from
+// the Captioner, if any. Failures are logged: the upload doesn't depend on them.
+func (s *MediaService) caption(ctx context.Context, item *Media) {
+	if s.Captioner == nil || item.File == nil || (item.AltText != "" && item.Caption != "") {
+		return
+	}
+	attachment := item.File
+	contentType := mime.TypeByExtension(strings.ToLower(filepath.Ext(attachment.Filename)))
+	if !strings.HasPrefix(contentType, "image/") || attachment.Size > maxCaptionSize {
+		return
+	}
+
+	image, err := s.read(ctx, attachment)
+	if err != nil {
+		s.Logger.Error("failed to read image to caption", logger.String("error", err.Error()))
+		return
+	}
+	caption, err := s.Captioner.Caption(ctx, image, contentType, s.Locale)
+	if err != nil {
+		s.Logger.Error("failed to caption image",
+			logger.String("error", err.Error()),
+			logger.String("provider", s.Captioner.Name()))
+		return
+	}
+
+	if item.AltText == "" {
+		item.AltText = truncate(strings.TrimSpace(caption.AltText), 255)
+	}
+	if item.Caption == "" {
+		item.Caption = strings.TrimSpace(caption.Caption)
+	}
+}
+
+// read returns the content of the stored file of an attachment
+func (s *MediaService) read(ctx context.Context, attachment *storage.Attachment) ([]byte, error) {
+	file, err := s.ActiveStorage.Open(ctx, attachment)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(io.LimitReader(file, maxCaptionSize+1))
+}
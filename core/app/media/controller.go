@@ -1,16 +1,29 @@
 package media
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
+	"base/core/app/authorization"
+	"base/core/confirm"
+	"base/core/crud"
 	"base/core/logger"
 	"base/core/router"
 	"base/core/storage"
+	"base/core/types"
+	"base/core/validator"
 )
 
+// streamBatchSize is the number of media items loaded at a time by streamed lists
+const streamBatchSize = 500
+
 type MediaController struct {
 	Service *MediaService
 	Storage *storage.ActiveStorage
@@ -33,6 +46,8 @@ func (c *MediaController) Routes(router *router.RouterGroup) {
 	// Specific endpoints (must come before :id routes)
 	router.GET("/media/all", c.ListAll) // Unpaginated list
 	router.POST("/media/sync", c.SyncFromR2) // Sync from R2 bucket
+	router.PUT("/media/reorder", c.Reorder)  // Order media within a folder
+	router.POST("/media/usages/rebuild", c.RebuildUsages)
 
 	// Parameterized routes (must come last)
 	router.GET("/media/:id", c.Get)
@@ -42,6 +57,10 @@ func (c *MediaController) Routes(router *router.RouterGroup) {
 	// File management endpoints
 	router.PUT("/media/:id/file", c.UpdateFile)
 	router.DELETE("/media/:id/file", c.RemoveFile)
+	router.GET("/media/:id/download", c.Download)
+	router.GET("/media/:id/original", c.DownloadOriginal)
+	router.GET("/media/:id/access-log", c.AccessLog)
+	router.GET("/media/:id/usages", c.Usages)
 }
 
 // Create godoc
@@ -53,8 +72,11 @@ func (c *MediaController) Routes(router *router.RouterGroup) {
 // @Param name formData string true "Media name"
 // @Param type formData string true "Media type"
 // @Param description formData string false "Media description"
+// @Param alt_text formData string false "Text alternative for screen readers; written by the caption provider when empty"
+// @Param caption formData string false "Caption shown with the image"
 // @Param file formData file false "Media file"
 // @Success 201 {object} MediaResponse
+// @Param X-Upload-Id header string false "Id the upload progress is sent under over WebSocket"
 // @Router /media [post]
 // @Security ApiKeyAuth
 // @Security BearerAuth
@@ -64,6 +86,12 @@ func (c *MediaController) Create(ctx *router.Context) error {
 	contentType := ctx.Request.Header.Get("Content-Type")
 
 	// Try to parse as JSON first, fall back to form data
+	access, err := c.access(ctx)
+	if err != nil {
+		return c.fail(ctx, err)
+	}
+
+	finish := func(error) {}
 	if strings.Contains(contentType, "application/json") {
 		// Parse JSON request
 		if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -71,7 +99,9 @@ func (c *MediaController) Create(ctx *router.Context) error {
 		}
 	} else {
 		// Parse multipart form first
-		if parseErr := ctx.Request.ParseMultipartForm(32 << 20); parseErr != nil {
+		finish = c.trackUpload(ctx)
+		if parseErr := ctx.Request.ParseMultipartForm(router.MultipartMemory()); parseErr != nil {
+			finish(parseErr)
 			return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: parseErr.Error()})
 		}
 
@@ -79,6 +109,8 @@ func (c *MediaController) Create(ctx *router.Context) error {
 		req.Name = ctx.Request.FormValue("name")
 		req.Type = ctx.Request.FormValue("type")
 		req.Description = ctx.Request.FormValue("description")
+		req.AltText = ctx.Request.FormValue("alt_text")
+		req.Caption = ctx.Request.FormValue("caption")
 		req.Folder = ctx.Request.FormValue("folder")
 		req.Tags = ctx.Request.FormValue("tags")
 		req.Metadata = ctx.Request.FormValue("metadata")
@@ -105,10 +137,15 @@ func (c *MediaController) Create(ctx *router.Context) error {
 		}
 	}
 
+	// Only admins choose the author; other users upload as themselves
+	if !access.Admin {
+		req.AuthorId = &access.UserId
+	}
 
-	item, err := c.Service.Create(&req)
+	item, err := c.Service.Create(ctx.Request.Context(), &req)
+	finish(err)
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return c.fail(ctx, err)
 	}
 
 	return ctx.JSON(http.StatusCreated, item.ToResponse())
@@ -123,23 +160,27 @@ func (c *MediaController) Create(ctx *router.Context) error {
 // @Param id path int true "Media Id"
 // @Param file formData file true "Media file"
 // @Success 200 {object} MediaResponse
+// @Param X-Upload-Id header string false "Id the upload progress is sent under over WebSocket"
 // @Router /media/{id}/file [put]
 // @Security ApiKeyAuth
 // @Security BearerAuth
 func (c *MediaController) UpdateFile(ctx *router.Context) error {
-	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	_, media, err := c.authorize(ctx, authorization.ActionUpdate)
 	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid id parameter"})
+		return c.fail(ctx, err)
 	}
 
+	finish := c.trackUpload(ctx)
 	file, err := ctx.FormFile("file")
 	if err != nil {
+		finish(err)
 		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "file is required"})
 	}
 
-	item, err := c.Service.UpdateFile(ctx, uint(id), file)
+	item, err := c.Service.UpdateFile(ctx, media.Id, file)
+	finish(err)
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return c.fail(ctx, err)
 	}
 
 	return ctx.JSON(http.StatusOK, item.ToResponse())
@@ -156,12 +197,12 @@ func (c *MediaController) UpdateFile(ctx *router.Context) error {
 // @Security ApiKeyAuth
 // @Security BearerAuth
 func (c *MediaController) RemoveFile(ctx *router.Context) error {
-	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	_, media, err := c.authorize(ctx, authorization.ActionUpdate)
 	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid id parameter"})
+		return c.fail(ctx, err)
 	}
 
-	item, err := c.Service.RemoveFile(ctx, uint(id))
+	item, err := c.Service.RemoveFile(ctx, media.Id)
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
 	}
@@ -179,19 +220,24 @@ func (c *MediaController) RemoveFile(ctx *router.Context) error {
 // @Param name formData string false "Media name"
 // @Param type formData string false "Media type"
 // @Param description formData string false "Media description"
+// @Param alt_text formData string false "Text alternative for screen readers; written by the caption provider when empty"
+// @Param caption formData string false "Caption shown with the image"
 // @Param file formData file false "Media file"
 // @Success 200 {object} MediaResponse
+// @Param X-Upload-Id header string false "Id the upload progress is sent under over WebSocket"
 // @Router /media/{id} [put]
 // @Security ApiKeyAuth
 // @Security BearerAuth
 func (c *MediaController) Update(ctx *router.Context) error {
-	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	access, media, err := c.authorize(ctx, authorization.ActionUpdate)
 	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid id parameter"})
+		return c.fail(ctx, err)
 	}
 
 	var req UpdateMediaRequest
+	finish := c.trackUpload(ctx)
 	if err := ctx.ShouldBind(&req); err != nil {
+		finish(err)
 		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 	}
 
@@ -200,31 +246,86 @@ func (c *MediaController) Update(ctx *router.Context) error {
 		req.File = file
 	}
 
-	item, err := c.Service.Update(uint(id), &req)
+	// Only admins change the author
+	if !access.Admin {
+		req.AuthorId = nil
+	}
+
+	item, err := c.Service.Update(ctx.Request.Context(), media.Id, &req)
+	finish(err)
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return c.fail(ctx, err)
 	}
 
 	return ctx.JSON(http.StatusOK, item.ToResponse())
 }
 
+// Reorder godoc
+// @Summary Reorder media
+// @Description Put media of a folder in a manual order, e.g. after dragging and dropping them: the first id gets position 0, the second 1 and so on, in one transaction. The media must share their folder; media left out keep their position. Lists show media by position, then oldest first
+// @Tags Core/Media
+// @Accept json
+// @Produce json
+// @Param reorder body crud.ReorderRequest true "Media ids in their new order"
+// @Success 200 {array} MediaListResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /media/reorder [put]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (c *MediaController) Reorder(ctx *router.Context) error {
+	var req crud.ReorderRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	access, err := c.access(ctx)
+	if err != nil {
+		return c.fail(ctx, err)
+	}
+
+	items, err := c.Service.Reorder(ctx.Request.Context(), access, req.Ids)
+	if err != nil {
+		return c.fail(ctx, err)
+	}
+
+	responses := make([]*MediaListResponse, len(items))
+	for i, item := range items {
+		responses[i] = item.ToListResponse()
+	}
+	return ctx.JSON(http.StatusOK, responses)
+}
+
 // Delete godoc
 // @Summary Delete a media item
 // @Description Delete a media item and its associated file
 // @Tags Core/Media
 // @Produce json
 // @Param id path int true "Media Id"
+// @Param force query bool false "Delete the media even when records still use it"
 // @Success 204 "No Content"
+// @Failure 409 {object} types.ErrorResponse "Records still use the media; details lists them"
 // @Router /media/{id} [delete]
 // @Security ApiKeyAuth
 // @Security BearerAuth
 func (c *MediaController) Delete(ctx *router.Context) error {
-	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	_, media, err := c.authorize(ctx, authorization.ActionDelete)
 	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid id parameter"})
+		return c.fail(ctx, err)
 	}
 
-	if err := c.Service.Delete(uint(id)); err != nil {
+	force := ctx.Query("force") == "true"
+	if err := c.Service.Delete(ctx.Request.Context(), media.Id, force); err != nil {
+		if errors.Is(err, ErrInUse) {
+			usages, usagesErr := c.Service.Usages(ctx.Request.Context(), media.Id)
+			if usagesErr != nil {
+				return c.fail(ctx, usagesErr)
+			}
+			return ctx.JSON(http.StatusConflict, types.ErrorResponse{
+				Error:   fmt.Sprintf("media is still used by %d records; delete with ?force=true anyway", len(usages)),
+				Details: usages,
+			})
+		}
 		return ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
 	}
 
@@ -232,6 +333,151 @@ func (c *MediaController) Delete(ctx *router.Context) error {
 	return nil
 }
 
+// Download godoc
+// @Summary Download the file of a media item
+// @Description Download the stored file of a media item; the download is recorded in its access log
+// @Tags Core/Media
+// @Produce octet-stream
+// @Param id path int true "Media Id"
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /media/{id}/download [get]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (c *MediaController) Download(ctx *router.Context) error {
+	return c.download(ctx, storage.AccessDownload)
+}
+
+// DownloadOriginal godoc
+// @Summary Download the original file of a media item
+// @Description Download the file of a media item as it was uploaded, before conversion; the download is recorded in its access log
+// @Tags Core/Media
+// @Produce octet-stream
+// @Param id path int true "Media Id"
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /media/{id}/original [get]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (c *MediaController) DownloadOriginal(ctx *router.Context) error {
+	return c.download(ctx, storage.AccessOriginal)
+}
+
+// AccessLog godoc
+// @Summary Get the access log of a media item
+// @Description Get the downloads of the files of a media item, newest first: who downloaded which file, when and how, with the download counts of its current files
+// @Tags Core/Media
+// @Produce json
+// @Param id path int true "Media Id"
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of items per page"
+// @Success 200 {object} AccessLogResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /media/{id}/access-log [get]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (c *MediaController) AccessLog(ctx *router.Context) error {
+	params, err := ctx.ListParams()
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	_, item, err := c.authorize(ctx, authorization.ActionUpdate)
+	if err != nil {
+		return c.fail(ctx, err)
+	}
+
+	response, err := c.Service.AccessLog(ctx.Request.Context(), item, params.Page, params.Limit)
+	if err != nil {
+		return c.fail(ctx, err)
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// Usages godoc
+// @Summary List the usages of a media item
+// @Description List the records that embed the file of a media item, e.g. pages with an image block of it, the most recently saved first
+// @Tags Core/Media
+// @Produce json
+// @Param id path int true "Media Id"
+// @Success 200 {array} Usage
+// @Router /media/{id}/usages [get]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (c *MediaController) Usages(ctx *router.Context) error {
+	_, item, err := c.authorize(ctx, authorization.ActionRead)
+	if err != nil {
+		return c.fail(ctx, err)
+	}
+
+	usages, err := c.Service.Usages(ctx.Request.Context(), item.Id)
+	if err != nil {
+		return c.fail(ctx, err)
+	}
+	return ctx.JSON(http.StatusOK, usages)
+}
+
+// RebuildUsages godoc
+// @Summary Rebuild the media usages
+// @Description Track the media embedded by all records of the models with usages again, e.g. after upgrading from a version without usages (admins only)
+// @Tags Core/Media
+// @Produce json
+// @Success 200 {object} map[string]int
+// @Router /media/usages/rebuild [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (c *MediaController) RebuildUsages(ctx *router.Context) error {
+	access, err := c.access(ctx)
+	if err != nil {
+		return c.fail(ctx, err)
+	}
+	if !access.Admin {
+		return c.fail(ctx, ErrForbidden)
+	}
+
+	count, err := c.Service.RebuildUsages(ctx.Request.Context())
+	if err != nil {
+		return c.fail(ctx, err)
+	}
+	return ctx.JSON(http.StatusOK, map[string]int{"records": count})
+}
+
+// download sends a file of the media item, recording the download the way via says
+func (c *MediaController) download(ctx *router.Context, via string) error {
+	access, item, err := c.authorize(ctx, authorization.ActionRead)
+	if err != nil {
+		return c.fail(ctx, err)
+	}
+
+	attachment, file, err := c.Service.Open(ctx.Request.Context(), item, &storage.AttachmentAccess{
+		UserId:    access.UserId,
+		Via:       via,
+		IpAddress: ctx.ClientIP(),
+		UserAgent: ctx.GetHeader("User-Agent"),
+	})
+	if err != nil {
+		return c.fail(ctx, err)
+	}
+	defer file.Close()
+
+	contentType := mime.TypeByExtension(filepath.Ext(attachment.Filename))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	ctx.SetHeader("Content-Type", contentType)
+	ctx.SetHeader("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	ctx.SetHeader("Content-Disposition", fmt.Sprintf("attachment; filename=%q", attachment.Filename))
+	ctx.Writer.WriteHeader(http.StatusOK)
+	_, err = io.Copy(ctx.Writer, file)
+	return err
+}
+
 // Get godoc
 // @Summary Get a media item
 // @Description Get a media item by Id
@@ -243,14 +489,9 @@ func (c *MediaController) Delete(ctx *router.Context) error {
 // @Security ApiKeyAuth
 // @Security BearerAuth
 func (c *MediaController) Get(ctx *router.Context) error {
-	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	_, item, err := c.authorize(ctx, authorization.ActionRead)
 	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid id parameter"})
-	}
-
-	item, err := c.Service.GetById(uint(id))
-	if err != nil {
-		return ctx.JSON(http.StatusNotFound, ErrorResponse{Error: "media not found"})
+		return c.fail(ctx, err)
 	}
 
 	return ctx.JSON(http.StatusOK, item.ToResponse())
@@ -271,19 +512,16 @@ func (c *MediaController) Get(ctx *router.Context) error {
 // @Security ApiKeyAuth
 // @Security BearerAuth
 func (c *MediaController) List(ctx *router.Context) error {
-	page := 1
-	limit := 10
-
-	if pageStr := ctx.Query("page"); pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
-		}
+	params, err := ctx.ListParams()
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 	}
-
-	if limitStr := ctx.Query("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
-		}
+	page, limit := 1, 10
+	if params.Page != nil {
+		page = *params.Page
+	}
+	if params.Limit != nil {
+		limit = *params.Limit
 	}
 
 	// Parse filtering parameters
@@ -307,13 +545,15 @@ func (c *MediaController) List(ctx *router.Context) error {
 		filters.Type = typeStr
 	}
 
-	// Get author ID from context or header
+	access, err := c.access(ctx)
+	if err != nil {
+		return c.fail(ctx, err)
+	}
+	filters.Access = access
+
+	// Admins can look at the media of an author; other users only see what they may read
 	var authorId uint
-	if aid, exists := ctx.Get("author_id"); exists {
-		if authorIdUint, ok := aid.(uint); ok {
-			authorId = authorIdUint
-		}
-	} else if authorIdStr := ctx.GetHeader("Base-Author-Id"); authorIdStr != "" {
+	if authorIdStr := ctx.GetHeader("Base-Author-Id"); authorIdStr != "" && access.Admin {
 		if aid, err := strconv.ParseUint(authorIdStr, 10, 32); err == nil {
 			authorId = uint(aid)
 		}
@@ -327,7 +567,7 @@ func (c *MediaController) List(ctx *router.Context) error {
 	}
 
 	// Use filtering method instead of basic GetAll
-	result, err := c.Service.GetAllWithFilters(&page, &limit, filters)
+	result, err := c.Service.GetAllWithFilters(ctx.Request.Context(), &page, &limit, filters)
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
 	}
@@ -343,6 +583,8 @@ func (c *MediaController) List(ctx *router.Context) error {
 // @Param parent_id query int false "Parent folder ID for hierarchical navigation"
 // @Param folder query string false "Folder path for filtering"
 // @Param type query string false "Media type for filtering (e.g., image, audio, video)"
+// @Param stream query bool false "Stream the items as a JSON array instead of a paginated response"
+// @Param format query string false "ndjson streams the items as newline-delimited JSON"
 // @Success 200 {array} MediaListResponse
 // @Router /media/all [get]
 // @Security ApiKeyAuth
@@ -369,8 +611,19 @@ func (c *MediaController) ListAll(ctx *router.Context) error {
 		filters.Type = typeStr
 	}
 
+	access, err := c.access(ctx)
+	if err != nil {
+		return c.fail(ctx, err)
+	}
+	filters.Access = access
+
+	// Large libraries can be streamed instead of loaded at once
+	if stream, _ := strconv.ParseBool(ctx.Query("stream")); stream || ctx.WantsNDJSON() {
+		return c.streamAll(ctx, filters)
+	}
+
 	// Use filtering method without pagination
-	result, err := c.Service.GetAllWithFilters(nil, nil, filters)
+	result, err := c.Service.GetAllWithFilters(ctx.Request.Context(), nil, nil, filters)
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
 	}
@@ -378,21 +631,46 @@ func (c *MediaController) ListAll(ctx *router.Context) error {
 	return ctx.JSON(http.StatusOK, result)
 }
 
+// streamAll writes the media items matching the filters as they are loaded
+func (c *MediaController) streamAll(ctx *router.Context, filters *MediaFilters) error {
+	stream := ctx.Stream(http.StatusOK)
+	err := c.Service.StreamWithFilters(ctx.Request.Context(), filters, streamBatchSize, func(item *Media) error {
+		return stream.Write(item.ToListResponse())
+	})
+	if err != nil {
+		// The status is already sent, so the response just ends early
+		return nil
+	}
+	return stream.Close()
+}
+
 // SyncFromR2 godoc
 // @Summary Sync media from R2 bucket
-// @Description Sync all files from R2 bucket to media database
+// @Description Sync all files from R2 bucket to media database. Files synced already are skipped, or refreshed from the bucket with overwrite, in two phases: with dry_run=true the files that would be overwritten are counted and a confirmation token valid for 5 minutes is returned; the sync then runs with that confirm_token
 // @Tags Core/Media
 // @Accept json
 // @Produce json
-// @Param body body object false "Sync options" example({"prefix": "media/"})
+// @Param body body object false "Sync options" example({"prefix": "media/", "overwrite": false})
+// @Param dry_run query bool false "With overwrite, report what would be overwritten and get a confirmation token"
+// @Param confirm_token query string false "Token of the dry run, required with overwrite"
 // @Success 200 {object} SyncResult
+// @Failure 428 {object} ErrorResponse
 // @Router /media/sync [post]
 // @Security ApiKeyAuth
 // @Security BearerAuth
 func (c *MediaController) SyncFromR2(ctx *router.Context) error {
+	access, err := c.access(ctx)
+	if err != nil {
+		return c.fail(ctx, err)
+	}
+	if !access.Admin {
+		return c.fail(ctx, ErrForbidden)
+	}
+
 	// Parse request body for options
 	var req struct {
-		Prefix string `json:"prefix"`
+		Prefix    string `json:"prefix"`
+		Overwrite bool   `json:"overwrite"` // Refresh the files synced already
 	}
 	if err := ctx.BindJSON(&req); err != nil {
 		req.Prefix = "media/" // Default prefix
@@ -405,8 +683,19 @@ func (c *MediaController) SyncFromR2(ctx *router.Context) error {
 		cdnURL = os.Getenv("STORAGE_PUBLIC_URL")
 	}
 
+	// Overwriting needs the confirmation of a dry run
+	if req.Overwrite {
+		impact, err := c.Service.SyncOverwriteImpact(ctx.Request.Context(), c.Storage, bucket, cdnURL, req.Prefix)
+		if err != nil {
+			return ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		if answered, err := confirm.Require(ctx, impact); answered {
+			return err
+		}
+	}
+
 	// Run sync
-	result, err := c.Service.SyncFromR2(c.Storage, bucket, cdnURL, req.Prefix)
+	result, err := c.Service.SyncFromR2(ctx.Request.Context(), c.Storage, bucket, cdnURL, req.Prefix, req.Overwrite)
 	if err != nil {
 		c.Logger.Error("Failed to sync from R2", logger.String("error", err.Error()))
 		return ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
@@ -416,6 +705,7 @@ func (c *MediaController) SyncFromR2(ctx *router.Context) error {
 		logger.Int("total", result.TotalFiles),
 		logger.Int("processed", result.ProcessedFiles),
 		logger.Int("skipped", result.SkippedFiles),
+		logger.Int("overwritten", result.OverwrittenFiles),
 		logger.Int("failed", result.FailedFiles),
 	)
 
@@ -425,3 +715,61 @@ func (c *MediaController) SyncFromR2(ctx *router.Context) error {
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
+
+// errInvalidId is returned by authorize for an :id parameter that isn't a media id
+var errInvalidId = errors.New("invalid id parameter")
+
+// access returns the media access of the authenticated user
+func (c *MediaController) access(ctx *router.Context) (*Access, error) {
+	return c.Service.Access(ctx.Request.Context(), ctx.GetUint("user_id"))
+}
+
+// trackUpload reports the progress of the upload of a request with an X-Upload-Id header to
+// its user over WebSocket, as storage.UploadProgressEvent; the returned function reports the
+// outcome and must be called once the upload is handled
+func (c *MediaController) trackUpload(ctx *router.Context) func(error) {
+	uploadId := ctx.Header("X-Upload-Id")
+	userId := ctx.GetUint("user_id")
+	if uploadId == "" || userId == 0 || c.Service.Emitter == nil {
+		return func(error) {}
+	}
+
+	progress := storage.NewProgressReporter(uploadId, userId, func(p *storage.UploadProgress) {
+		c.Service.Emitter.Emit(storage.UploadProgressEvent, p)
+	})
+	ctx.Request.Body = progress.Reader(ctx.Request.Body, ctx.Request.ContentLength)
+	ctx.Request = ctx.Request.WithContext(storage.WithProgress(ctx.Request.Context(), progress))
+	return progress.Finish
+}
+
+// authorize returns the access of the authenticated user and the media item of the :id
+// parameter, when the user may perform the action on it
+func (c *MediaController) authorize(ctx *router.Context, action string) (*Access, *Media, error) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return nil, nil, errInvalidId
+	}
+	access, err := c.access(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	item, err := c.Service.Authorize(ctx.Request.Context(), access, uint(id), action)
+	if err != nil {
+		return nil, nil, err
+	}
+	return access, item, nil
+}
+
+// fail answers with the status of an error returned by access or authorize
+func (c *MediaController) fail(ctx *router.Context, err error) error {
+	switch {
+	case errors.Is(err, errInvalidId), errors.Is(err, storage.ErrInvalidFile), errors.As(err, new(validator.ValidationErrors)):
+		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrNoFile):
+		return ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, ErrForbidden):
+		return ctx.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+	default:
+		return ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	}
+}
@@ -0,0 +1,142 @@
+package media
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"base/core/app/authorization"
+	"base/core/graphql"
+	"base/core/types"
+
+	"gorm.io/gorm"
+)
+
+// registerGraphQL adds the Media type and the media and media_list query fields to the
+// GraphQL schema. Like the /media routes they show non-admin users only the media they may read.
+func registerGraphQL(db *gorm.DB, service *MediaService) {
+	mediaId := func(source any) (uint, bool) {
+		if item, ok := source.(*Media); ok {
+			return item.Id, true
+		}
+		return 0, false
+	}
+	modelName := (&Media{}).GetModelName()
+
+	media := graphql.NewObject("Media", "Media file or folder")
+	media.
+		AddField("id", &graphql.Field{Type: graphql.NonNull{Of: graphql.ID}}).
+		AddField("name", &graphql.Field{Type: graphql.NonNull{Of: graphql.String}}).
+		AddField("type", &graphql.Field{Type: graphql.NonNull{Of: graphql.String}}).
+		AddField("description", &graphql.Field{Type: graphql.String}).
+		AddField("alt_text", &graphql.Field{Type: graphql.String}).
+		AddField("caption", &graphql.Field{Type: graphql.String}).
+		AddField("parent_id", &graphql.Field{Type: graphql.ID}).
+		AddField("folder", &graphql.Field{Type: graphql.String}).
+		AddField("tags", &graphql.Field{Type: graphql.String}).
+		AddField("author_id", &graphql.Field{Type: graphql.ID}).
+		AddField("original_format", &graphql.Field{Type: graphql.String}).
+		AddField("converted_format", &graphql.Field{Type: graphql.String}).
+		AddField("created_at", &graphql.Field{Type: graphql.NonNull{Of: graphql.Time}}).
+		AddField("updated_at", &graphql.Field{Type: graphql.NonNull{Of: graphql.Time}}).
+		AddField("file", &graphql.Field{
+			Type:  graphql.Attachment,
+			Batch: graphql.BatchAttachments(db, modelName, "file", mediaId),
+		}).
+		AddField("original_file", &graphql.Field{
+			Type:  graphql.Attachment,
+			Batch: graphql.BatchAttachments(db, modelName, "original_file", mediaId),
+		}).
+		AddField("parent", &graphql.Field{
+			Type:        media,
+			Description: "Folder of the media",
+			Batch: func(p graphql.Params, sources []any) ([]any, error) {
+				return graphql.BatchBy(sources, func(source any) (uint, bool) {
+					if item, ok := source.(*Media); ok && item.ParentId != nil {
+						return *item.ParentId, true
+					}
+					return 0, false
+				}, func(ids []uint) (map[uint]*Media, error) {
+					var parents []*Media
+					if err := db.Where("id IN ?", ids).Find(&parents).Error; err != nil {
+						return nil, err
+					}
+					byId := make(map[uint]*Media, len(parents))
+					for _, parent := range parents {
+						byId[parent.Id] = parent
+					}
+					return byId, nil
+				})
+			},
+		})
+
+	mediaPage := graphql.NewObject("MediaPage", "Page of media").
+		AddField("data", &graphql.Field{Type: graphql.NonNull{Of: graphql.List{Of: graphql.NonNull{Of: media}}}}).
+		AddField("pagination", &graphql.Field{Type: graphql.NonNull{Of: graphql.Pagination}})
+
+	graphql.Query("media", &graphql.Field{
+		Type:        media,
+		Description: "Media by id",
+		Args:        []*graphql.Argument{{Name: "id", Type: graphql.NonNull{Of: graphql.ID}}},
+		Guard:       graphql.Authenticated,
+		Resolve: func(p graphql.Params) (any, error) {
+			id, err := strconv.ParseUint(p.Args["id"].(string), 10, 32)
+			if err != nil {
+				return nil, nil
+			}
+			access, err := service.Access(p.Context.Request.Context(), p.Context.GetUint("user_id"))
+			if err != nil {
+				return nil, err
+			}
+			item, err := service.Authorize(p.Context.Request.Context(), access, uint(id), authorization.ActionRead)
+			if errors.Is(err, ErrNotFound) || errors.Is(err, ErrForbidden) {
+				return nil, nil
+			}
+			return item, err
+		},
+	})
+
+	graphql.Query("media_list", &graphql.Field{
+		Type:        graphql.NonNull{Of: mediaPage},
+		Description: "Paginated list of media, newest first",
+		Args: append(graphql.PageArgs(),
+			&graphql.Argument{Name: "parent_id", Type: graphql.ID, Description: "Only the media of a folder"},
+		),
+		Guard: graphql.Authenticated,
+		Resolve: func(p graphql.Params) (any, error) {
+			page, limit := graphql.PageOf(p)
+			access, err := service.Access(p.Context.Request.Context(), p.Context.GetUint("user_id"))
+			if err != nil {
+				return nil, err
+			}
+			query := access.scope(db.Model(&Media{}))
+			if parentId, ok := p.Args["parent_id"].(string); ok {
+				query = query.Where("parent_id = ?", parentId)
+			}
+
+			var total int64
+			if err := query.Count(&total).Error; err != nil {
+				return nil, err
+			}
+			var items []*Media
+			if err := query.Order("id DESC").Offset((page - 1) * limit).Limit(limit).Find(&items).Error; err != nil {
+				return nil, err
+			}
+
+			totalPages := int(math.Ceil(float64(total) / float64(limit)))
+			if totalPages == 0 {
+				totalPages = 1
+			}
+
+			return &types.PaginatedResponse{
+				Data: items,
+				Pagination: types.Pagination{
+					Total:      int(total),
+					Page:       page,
+					PageSize:   limit,
+					TotalPages: totalPages,
+				},
+			}, nil
+		},
+	})
+}
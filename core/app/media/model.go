@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"base/core/storage"
+	"base/core/types"
 
 	"gorm.io/gorm"
 )
@@ -13,13 +14,16 @@ import (
 type Media struct {
 	Id           uint                `json:"id" gorm:"primaryKey"`
 	Name         string              `json:"name" gorm:"column:name"`
-	Type         string              `json:"type" gorm:"column:type"`
+	Type         string              `json:"type" gorm:"column:type;index:,composite:parent_type,priority:2"`
 	Description  string              `json:"description" gorm:"column:description"`
-	ParentId     *uint               `json:"parent_id" gorm:"column:parent_id;index"`   // Reference to parent folder
-	Folder       string              `json:"folder" gorm:"column:folder;index"`         // Computed full path for compatibility
-	Tags         string              `json:"tags" gorm:"column:tags"`                   // Comma-separated tags for searching
-	Metadata     *string             `json:"metadata" gorm:"column:metadata;type:json"` // JSON metadata for extra properties (nullable)
-	AuthorId     *uint               `json:"author_id" gorm:"column:author_id;index"`   // Optional author ownership
+	AltText      string              `json:"alt_text" gorm:"column:alt_text;size:255"`                                                                                  // Text alternative of images for screen readers
+	Caption      string              `json:"caption" gorm:"column:caption;type:text"`                                                                                   // Shown with images on the site
+	ParentId     *uint               `json:"parent_id" gorm:"column:parent_id;index;index:,composite:parent_type,priority:1;index:,composite:author_parent,priority:2"` // Reference to parent folder
+	Folder       string              `json:"folder" gorm:"column:folder;index"`                                                                                         // Computed full path for compatibility
+	Tags         string              `json:"tags" gorm:"column:tags"`                                                                                                   // Comma-separated tags for searching
+	Position     int                 `json:"position" gorm:"column:position;default:0"`                                                                                 // Manual order within the folder
+	Metadata     *string             `json:"metadata" gorm:"column:metadata;type:json"`                                                                                 // JSON metadata for extra properties (nullable)
+	AuthorId     *uint               `json:"author_id" gorm:"column:author_id;index;index:,composite:author_parent,priority:1"`                                         // Optional author ownership
 	File         *storage.Attachment `json:"file,omitempty" gorm:"polymorphic:Model;polymorphicValue:file"`
 	OriginalFile *storage.Attachment `json:"original_file,omitempty" gorm:"polymorphic:Model;polymorphicValue:original_file"`
 
@@ -53,7 +57,19 @@ func (item *Media) GetModelName() string {
 
 // Preload preloads all the model's relationships
 func (item *Media) Preload(db *gorm.DB) *gorm.DB {
-	return db.Preload("File").Preload("OriginalFile").Preload("Parent").Preload("Children")
+	return db.Preload("Parent").Preload("Children", func(db *gorm.DB) *gorm.DB {
+		return db.Order(ListOrder)
+	})
+}
+
+// ListOrder orders media within their folder: by their manual position, then oldest first
+const ListOrder = "position, id"
+
+// PreloadList preloads only the relationships rendered by ToListResponse: none, since the
+// files are stored in columns of the row. The parent and children of every row would cost
+// two more queries and are not part of list responses.
+func (item *Media) PreloadList(db *gorm.DB) *gorm.DB {
+	return db
 }
 
 // MediaListResponse represents the list view response
@@ -64,9 +80,12 @@ type MediaListResponse struct {
 	Name         string              `json:"name"`
 	Type         string              `json:"type"`
 	Description  string              `json:"description"`
+	AltText      string              `json:"alt_text"`
+	Caption      string              `json:"caption"`
 	ParentId     *uint               `json:"parent_id"`
 	Folder       string              `json:"folder"`
 	Tags         string              `json:"tags"`
+	Position     int                 `json:"position"`
 	AuthorId     *uint               `json:"author_id"`
 	File         *storage.Attachment `json:"file,omitempty"`
 	OriginalFile *storage.Attachment `json:"original_file,omitempty"`
@@ -81,9 +100,12 @@ type MediaResponse struct {
 	Name         string              `json:"name"`
 	Type         string              `json:"type"`
 	Description  string              `json:"description"`
+	AltText      string              `json:"alt_text"`
+	Caption      string              `json:"caption"`
 	ParentId     *uint               `json:"parent_id"`
 	Folder       string              `json:"folder"`
 	Tags         string              `json:"tags"`
+	Position     int                 `json:"position"`
 	Metadata     *string             `json:"metadata"`
 	AuthorId     *uint               `json:"author_id"`
 	File         *storage.Attachment `json:"file,omitempty"`
@@ -101,6 +123,8 @@ type MediaModelResponse struct {
 	Name        string              `json:"name"`
 	Type        string              `json:"type"`
 	Description string              `json:"description"`
+	AltText     string              `json:"alt_text"`
+	Caption     string              `json:"caption"`
 	File        *storage.Attachment `json:"file,omitempty"`
 }
 
@@ -109,6 +133,8 @@ type CreateMediaRequest struct {
 	Name        string                `form:"name" json:"name" binding:"required"`
 	Type        string                `form:"type" json:"type" binding:"required"`
 	Description string                `form:"description" json:"description"`
+	AltText     string                `form:"alt_text" json:"alt_text"` // Written by the captioner when empty
+	Caption     string                `form:"caption" json:"caption"`
 	ParentId    *uint                 `json:"parent_id"`                // For JSON requests
 	Folder      string                `form:"folder" json:"folder"`     // Optional folder path (for compatibility)
 	Tags        string                `form:"tags" json:"tags"`         // Optional comma-separated tags
@@ -122,6 +148,8 @@ type UpdateMediaRequest struct {
 	Name        *string               `form:"name"`
 	Type        *string               `form:"type"`
 	Description *string               `form:"description"`
+	AltText     *string               `form:"alt_text"`
+	Caption     *string               `form:"caption"`
 	ParentId    *uint                 `form:"parent_id"`
 	Folder      *string               `form:"folder"`
 	Tags        *string               `form:"tags"`
@@ -139,9 +167,12 @@ func (item *Media) ToListResponse() *MediaListResponse {
 		Name:         item.Name,
 		Type:         item.Type,
 		Description:  item.Description,
+		AltText:      item.AltText,
+		Caption:      item.Caption,
 		ParentId:     item.ParentId,
 		Folder:       item.Folder,
 		Tags:         item.Tags,
+		Position:     item.Position,
 		AuthorId:     item.AuthorId,
 		File:         item.File,
 		OriginalFile: item.OriginalFile,
@@ -158,9 +189,12 @@ func (item *Media) ToResponse() *MediaResponse {
 		Name:         item.Name,
 		Type:         item.Type,
 		Description:  item.Description,
+		AltText:      item.AltText,
+		Caption:      item.Caption,
 		ParentId:     item.ParentId,
 		Folder:       item.Folder,
 		Tags:         item.Tags,
+		Position:     item.Position,
 		Metadata:     item.Metadata,
 		AuthorId:     item.AuthorId,
 		File:         item.File,
@@ -180,6 +214,8 @@ func (item *Media) ToModelResponse() *MediaModelResponse {
 		Name:        item.Name,
 		Type:        item.Type,
 		Description: item.Description,
+		AltText:     item.AltText,
+		Caption:     item.Caption,
 		File:        item.File,
 	}
 }
@@ -192,8 +228,8 @@ func (item *Media) GetAttachmentConfig() map[string]interface{} {
 		"file": map[string]interface{}{
 			"path":       "media/:id/:filename",
 			"validators": []string{"image", "audio"},
-			"min_size":   1,                 // 1 byte
-			"max_size":   100 * 1024 * 1024, // 100MB
+			"min_size":   1,                            // 1 byte
+			"max_size":   storage.DefaultMaxFileSize(), // upload_max_size setting
 		},
 	}
 }
@@ -263,4 +299,15 @@ type MediaFilters struct {
 	Type          string `json:"type"`
 	AuthorId      *uint  `json:"author_id"`
 	IncludeShared bool   `json:"include_shared"`
+
+	// Access limits the items to those its user may read; nil doesn't limit them
+	Access *Access `json:"-"`
+}
+
+// AccessLogResponse is a page of the downloads of the files of a media item, with the
+// download counts of its current files
+type AccessLogResponse struct {
+	FileDownloads     int64 `json:"file_downloads"`
+	OriginalDownloads int64 `json:"original_downloads"`
+	types.PaginatedResponse
 }
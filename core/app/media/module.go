@@ -1,6 +1,11 @@
 package media
 
 import (
+	"context"
+
+	"base/core/app/users"
+	"base/core/config"
+	"base/core/database"
 	"base/core/emitter"
 	"base/core/logger"
 	"base/core/module"
@@ -22,14 +27,26 @@ type MediaModule struct {
 
 func NewMediaModule(
 	db *gorm.DB,
+	tx *database.TxManager,
 	router *router.RouterGroup,
 	activeStorage *storage.ActiveStorage,
 	emitter *emitter.Emitter,
 	logger logger.Logger,
+	cfg *config.Config,
 ) module.Module {
-	service := NewMediaService(db, emitter, activeStorage, logger)
+	service := NewMediaService(db, tx, emitter, activeStorage, logger)
+	service.UseCaptioner(cfg)
+	service.ListenUsages()
 	controller := NewMediaController(service, activeStorage, logger)
 
+	// Read-only GraphQL fields (see core/graphql)
+	registerGraphQL(db, service)
+
+	// Media of offboarded users can be handed over to another author. Deleting a user with
+	// their content deletes their media with their files, unless they are still used.
+	users.RegisterOwned(users.Owned{Name: "media", Model: &Media{}, Column: "author_id",
+		Delete: func(ctx context.Context, id uint) error { return service.Delete(ctx, id, false) }})
+
 	mediaModule := &MediaModule{
 		DB:            db,
 		Controller:    controller,
@@ -49,9 +66,9 @@ func (m *MediaModule) Routes(router *router.RouterGroup) {
 }
 
 func (m *MediaModule) Migrate() error {
-	return m.DB.AutoMigrate(&Media{})
+	return m.DB.AutoMigrate(&Media{}, &Usage{})
 }
 
 func (m *MediaModule) GetModels() []any {
-	return []any{&Media{}}
+	return []any{&Media{}, &Usage{}}
 }
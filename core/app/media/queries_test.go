@@ -0,0 +1,44 @@
+package media_test
+
+import (
+	"fmt"
+	"testing"
+
+	"base/core/app/media"
+	"base/core/testutil"
+)
+
+// createMedia saves a folder of an author with images in it
+func createMedia(t *testing.T, app *testutil.App, authorId uint, count int) {
+	t.Helper()
+	folder := &media.Media{Name: fmt.Sprintf("Folder of %d", authorId), Type: "folder", AuthorId: &authorId}
+	if err := app.DB.Create(folder).Error; err != nil {
+		t.Fatalf("folder: %v", err)
+	}
+	for i := range count {
+		item := &media.Media{Name: fmt.Sprintf("Image %d", i), Type: "image", AuthorId: &authorId, ParentId: &folder.Id}
+		if err := app.DB.Create(item).Error; err != nil {
+			t.Fatalf("media: %v", err)
+		}
+	}
+}
+
+func TestListMediaQueriesDontGrowWithRows(t *testing.T) {
+	app := testutil.New(t, testutil.Options{})
+	admin := app.CreateUser("Super Admin")
+	createMedia(t, app, app.CreateUser("Employee").Id, 2)
+
+	few := app.CountQueries(func() {
+		app.As(admin).GET("/api/media?limit=2").AssertStatus(200)
+	})
+	for range 5 {
+		createMedia(t, app, app.CreateUser("Employee").Id, 2)
+	}
+	many := app.CountQueries(func() {
+		app.As(admin).GET("/api/media?limit=12").AssertStatus(200)
+	})
+
+	if many != few {
+		t.Errorf("listing media runs %d queries for 2 rows and %d for 12", few, many)
+	}
+}
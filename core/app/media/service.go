@@ -2,36 +2,57 @@ package media
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"math"
 	"mime/multipart"
-
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"base/core/app/authorization"
+	"base/core/confirm"
+	"base/core/crud"
+	"base/core/database"
 	"base/core/emitter"
 	"base/core/logger"
 	"base/core/storage"
 	"base/core/types"
 
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 )
 
+// keepOriginals makes media keep the uploaded files of converted files, whatever the
+// media_keep_original setting says
+var keepOriginals = true
+
 type MediaService struct {
 	DB            *gorm.DB
+	Tx            *database.TxManager
 	Emitter       *emitter.Emitter
 	ActiveStorage *storage.ActiveStorage
 	Logger        logger.Logger
+	Authorization *authorization.AuthorizationService
+	Captioner     Captioner // Writes the alt text of uploaded images; nil leaves it to the uploader
+	Locale        string    // Language of the alt text written by the Captioner
+
+	usagesMu      sync.Mutex
+	watchedUsages map[string]bool // Usage entities whose events are tracked
 }
 
-func NewMediaService(db *gorm.DB, emitter *emitter.Emitter, activeStorage *storage.ActiveStorage, logger logger.Logger) *MediaService {
+func NewMediaService(db *gorm.DB, tx *database.TxManager, emitter *emitter.Emitter, activeStorage *storage.ActiveStorage, logger logger.Logger) *MediaService {
 	// Register file attachment configuration
 	// Note: Images (jpg, jpeg, png, heic, heif) will be auto-converted to webp
 	// Videos (mp4, mov, avi, etc.) will be auto-converted to webm
+	// The uploaded files of converted files are kept as original_file
+	// Both fields are capped by the upload_max_size setting (UPLOAD_MAX_SIZE, 100MB by default)
 	activeStorage.RegisterAttachment("media", storage.AttachmentConfig{
 		Field:             "file",
 		Path:              "media/files",
 		AllowedExtensions: []string{".jpg", ".jpeg", ".png", ".heic", ".heif", ".webp", ".mp4", ".mov", ".avi", ".mkv", ".webm", ".mp3", ".wav", ".ogg", ".opus"},
-		MaxFileSize:       100 << 20, // 100MB
 		Multiple:          false,
+		Conversion:        storage.ConversionPolicy{KeepOriginal: &keepOriginals, OriginalField: "original_file"},
 	})
 
 	// Register original_file attachment configuration (for keeping originals)
@@ -39,47 +60,48 @@ func NewMediaService(db *gorm.DB, emitter *emitter.Emitter, activeStorage *stora
 		Field:             "original_file",
 		Path:              "media/files/originals",
 		AllowedExtensions: []string{".jpg", ".jpeg", ".png", ".heic", ".heif", ".webp", ".mp4", ".mov", ".avi", ".mkv", ".webm", ".mp3", ".wav", ".ogg", ".opus"},
-		MaxFileSize:       100 << 20, // 100MB
 		Multiple:          false,
+		Conversion:        storage.ConversionPolicy{Format: storage.FormatOriginal},
 	})
 
+	if tx == nil {
+		tx = database.NewTxManager(db)
+	}
+
 	return &MediaService{
 		DB:            db,
+		Tx:            tx,
 		Emitter:       emitter,
 		ActiveStorage: activeStorage,
 		Logger:        logger,
+		Authorization: authorization.NewAuthorizationService(db),
 	}
 }
 
 // GetById returns a single media item by id
-func (s *MediaService) GetById(id uint) (*Media, error) {
+func (s *MediaService) GetById(ctx context.Context, id uint) (*Media, error) {
 	var item Media
 
-	if err := s.DB.First(&item, id).Error; err != nil {
+	// Load the item with its relationships in one pass
+	if err := item.Preload(s.DB.WithContext(ctx)).First(&item, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("media not found")
+			return nil, ErrNotFound
 		}
 		s.Logger.Error("failed to get media", logger.String("error", err.Error()))
 		return nil, fmt.Errorf("failed to get media: %w", err)
 	}
 
-	// Load relationships
-	if err := s.DB.Preload(clause.Associations).First(&item, id).Error; err != nil {
-		s.Logger.Error("failed to load media relationships", logger.String("error", err.Error()))
-		return nil, fmt.Errorf("failed to load media relationships: %w", err)
-	}
-
 	return &item, nil
 }
 
 // GetByIds returns multiple media items by their IDs
-func (s *MediaService) GetByIds(ids []uint) ([]*Media, error) {
+func (s *MediaService) GetByIds(ctx context.Context, ids []uint) ([]*Media, error) {
 	if len(ids) == 0 {
 		return []*Media{}, nil
 	}
 
 	var items []*Media
-	if err := s.DB.Where("id IN ?", ids).Preload(clause.Associations).Find(&items).Error; err != nil {
+	if err := (&Media{}).PreloadList(s.DB.WithContext(ctx).Where("id IN ?", ids)).Find(&items).Error; err != nil {
 		s.Logger.Error("failed to get media by ids", logger.String("error", err.Error()))
 		return nil, fmt.Errorf("failed to get media by ids: %w", err)
 	}
@@ -88,18 +110,18 @@ func (s *MediaService) GetByIds(ids []uint) ([]*Media, error) {
 }
 
 // GetAll returns a paginated list of media items
-func (s *MediaService) GetAll(page, limit *int) (*types.PaginatedResponse, error) {
+func (s *MediaService) GetAll(ctx context.Context, page, limit *int) (*types.PaginatedResponse, error) {
 	var items []*Media
 	var total int64
 
 	// Get total count
-	if err := s.DB.Model(&Media{}).Count(&total).Error; err != nil {
+	if err := s.DB.WithContext(ctx).Model(&Media{}).Count(&total).Error; err != nil {
 		s.Logger.Error("failed to count media", logger.String("error", err.Error()))
 		return nil, fmt.Errorf("failed to count media: %w", err)
 	}
 
 	// Build query
-	query := s.DB.Model(&Media{})
+	query := s.DB.WithContext(ctx).Model(&Media{})
 
 	// Add pagination if provided
 	if page != nil && limit != nil {
@@ -107,8 +129,8 @@ func (s *MediaService) GetAll(page, limit *int) (*types.PaginatedResponse, error
 		query = query.Offset(offset).Limit(*limit)
 	}
 
-	// Execute query with preloads
-	if err := query.Preload(clause.Associations).Find(&items).Error; err != nil {
+	// Execute query with the preloads of the list response
+	if err := (&Media{}).PreloadList(query.Order(ListOrder)).Find(&items).Error; err != nil {
 		s.Logger.Error("failed to get media", logger.String("error", err.Error()))
 		return nil, fmt.Errorf("failed to get media: %w", err)
 	}
@@ -146,24 +168,14 @@ func (s *MediaService) GetAll(page, limit *int) (*types.PaginatedResponse, error
 }
 
 // Create creates a new media item
-func (s *MediaService) Create(req *CreateMediaRequest) (*Media, error) {
-	// Begin transaction
-	tx := s.DB.Begin()
-	if tx.Error != nil {
-		s.Logger.Error("failed to begin transaction", logger.String("error", tx.Error.Error()))
-		return nil, fmt.Errorf("failed to begin transaction: %w", tx.Error)
-	}
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
+func (s *MediaService) Create(ctx context.Context, req *CreateMediaRequest) (*Media, error) {
 	// Create media item
 	item := &Media{
 		Name:        req.Name,
 		Type:        req.Type,
 		Description: req.Description,
+		AltText:     req.AltText,
+		Caption:     req.Caption,
 		ParentId:    req.ParentId,
 		Folder:      req.Folder,
 		Tags:        req.Tags,
@@ -175,59 +187,41 @@ func (s *MediaService) Create(req *CreateMediaRequest) (*Media, error) {
 		item.Metadata = &req.Metadata
 	}
 
-	if err := tx.Create(item).Error; err != nil {
-		tx.Rollback()
-		s.Logger.Error("failed to create media", logger.String("error", err.Error()))
-		return nil, fmt.Errorf("failed to create media: %w", err)
-	}
-
-	// Handle file upload if provided
-	if req.File != nil {
-		// Upload the file using storage system
-		attachment, err := s.ActiveStorage.Attach(item, "file", req.File)
-		if err != nil {
-			tx.Rollback()
-			s.Logger.Error("failed to upload file", logger.String("error", err.Error()))
-			return nil, fmt.Errorf("failed to upload file: %w", err)
+	err := s.Tx.WithTx(ctx, func(tx *gorm.DB) error {
+		if err := tx.Create(item).Error; err != nil {
+			s.Logger.Error("failed to create media", logger.String("error", err.Error()))
+			return fmt.Errorf("failed to create media: %w", err)
 		}
 
-		// Update media with file information
-		item.File = attachment
-		if err := tx.Save(item).Error; err != nil {
-			tx.Rollback()
-			s.Logger.Error("failed to update media with file", logger.String("error", err.Error()))
-			return nil, fmt.Errorf("failed to update media with file: %w", err)
+		// Handle file upload if provided
+		if req.File != nil {
+			// Upload the file using storage system
+			if err := s.attachFile(ctx, item, req.File); err != nil {
+				return err
+			}
+
+			// Update media with file information
+			if err := tx.Save(item).Error; err != nil {
+				s.Logger.Error("failed to update media with file", logger.String("error", err.Error()))
+				return fmt.Errorf("failed to update media with file: %w", err)
+			}
 		}
-	}
 
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		s.Logger.Error("failed to commit transaction", logger.String("error", err.Error()))
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Reload item with relationships
-	return s.GetById(item.Id)
+	return s.GetById(ctx, item.Id)
 }
 
 // Update updates a media item
-func (s *MediaService) Update(id uint, req *UpdateMediaRequest) (*Media, error) {
-	// Begin transaction
-	tx := s.DB.Begin()
-	if tx.Error != nil {
-		s.Logger.Error("failed to begin transaction", logger.String("error", tx.Error.Error()))
-		return nil, fmt.Errorf("failed to begin transaction: %w", tx.Error)
-	}
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
+func (s *MediaService) Update(ctx context.Context, id uint, req *UpdateMediaRequest) (*Media, error) {
 	// Get existing item
-	item, err := s.GetById(id)
+	item, err := s.GetById(ctx, id)
 	if err != nil {
-		tx.Rollback()
 		return nil, err
 	}
 
@@ -241,211 +235,286 @@ func (s *MediaService) Update(id uint, req *UpdateMediaRequest) (*Media, error)
 	if req.Description != nil {
 		item.Description = *req.Description
 	}
+	if req.AltText != nil {
+		item.AltText = *req.AltText
+	}
+	if req.Caption != nil {
+		item.Caption = *req.Caption
+	}
 	if req.AuthorId != nil {
 		item.AuthorId = req.AuthorId
 	}
 
-	// Handle file update if provided
-	if req.File != nil {
-		// Remove existing file if any
-		if item.File != nil {
-			if err := s.ActiveStorage.Delete(item.File); err != nil {
-				tx.Rollback()
-				s.Logger.Error("failed to delete existing file", logger.String("error", err.Error()))
-				return nil, fmt.Errorf("failed to delete existing file: %w", err)
+	err = s.Tx.WithTx(ctx, func(tx *gorm.DB) error {
+		// Handle file update if provided
+		if req.File != nil {
+			// Replace the existing file, if any, with the new one
+			if err := s.attachFile(ctx, item, req.File); err != nil {
+				return err
 			}
 		}
 
-		// Upload new file
-		attachment, err := s.ActiveStorage.Attach(item, "file", req.File)
-		if err != nil {
-			tx.Rollback()
-			s.Logger.Error("failed to upload file", logger.String("error", err.Error()))
-			return nil, fmt.Errorf("failed to upload file: %w", err)
+		// Save changes
+		if err := tx.Save(item).Error; err != nil {
+			s.Logger.Error("failed to update media", logger.String("error", err.Error()))
+			return fmt.Errorf("failed to update media: %w", err)
 		}
 
-		// Update media with new file information
-		item.File = attachment
-	}
-
-	// Save changes
-	if err := tx.Save(item).Error; err != nil {
-		tx.Rollback()
-		s.Logger.Error("failed to update media", logger.String("error", err.Error()))
-		return nil, fmt.Errorf("failed to update media: %w", err)
-	}
-
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		s.Logger.Error("failed to commit transaction", logger.String("error", err.Error()))
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Reload item with relationships
-	return s.GetById(id)
+	return s.GetById(ctx, id)
 }
 
-// Delete deletes a media item
-func (s *MediaService) Delete(id uint) error {
+// Delete deletes a media item. Media that records still embed (see Usages) is only deleted
+// with force; ErrInUse is returned otherwise.
+func (s *MediaService) Delete(ctx context.Context, id uint, force bool) error {
 	// Get existing item
-	item, err := s.GetById(id)
+	item, err := s.GetById(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	// Begin transaction
-	tx := s.DB.Begin()
-	if tx.Error != nil {
-		s.Logger.Error("failed to begin transaction", logger.String("error", tx.Error.Error()))
-		return fmt.Errorf("failed to begin transaction: %w", tx.Error)
+	if !force {
+		var used int64
+		if err := s.DB.WithContext(ctx).Model(&Usage{}).Where("media_id = ?", id).Count(&used).Error; err != nil {
+			return err
+		}
+		if used > 0 {
+			return ErrInUse
+		}
 	}
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+
+	return s.Tx.WithTx(ctx, func(tx *gorm.DB) error {
+		if err := tx.Where("media_id = ?", id).Delete(&Usage{}).Error; err != nil {
+			return err
 		}
-	}()
 
-	// Delete the file if it exists
-	if item.File != nil {
-		if err := s.ActiveStorage.Delete(item.File); err != nil {
-			s.Logger.Error("failed to delete file", logger.String("error", err.Error()))
-			return fmt.Errorf("failed to delete file: %w", err)
+		// Delete the file and its original if they exist
+		if err := s.removeFiles(ctx, item); err != nil {
+			return err
 		}
-	}
 
-	// Delete the media item
-	if err := tx.Delete(item).Error; err != nil {
-		tx.Rollback()
-		s.Logger.Error("failed to delete media", logger.String("error", err.Error()))
-		return fmt.Errorf("failed to delete media: %w", err)
+		// Delete the media item
+		if err := tx.Delete(item).Error; err != nil {
+			s.Logger.Error("failed to delete media", logger.String("error", err.Error()))
+			return fmt.Errorf("failed to delete media: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// Reorder puts media of a folder in the order of ids, e.g. after dragging and dropping them,
+// and returns them in their new order. Users other than admins can only reorder the media
+// they may update.
+func (s *MediaService) Reorder(ctx context.Context, access *Access, ids []uint) ([]*Media, error) {
+	if access != nil && !access.Admin {
+		for _, id := range ids {
+			// Unknown ids are reported by crud.Reorder with the other validation errors
+			if _, err := s.Authorize(ctx, access, id, authorization.ActionUpdate); err != nil && !errors.Is(err, ErrNotFound) {
+				return nil, err
+			}
+		}
 	}
 
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		s.Logger.Error("failed to commit transaction", logger.String("error", err.Error()))
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	if err := crud.Reorder(s.DB.WithContext(ctx), &Media{}, ids, "parent_id"); err != nil {
+		return nil, err
 	}
 
-	return nil
+	var items []*Media
+	if err := (&Media{}).PreloadList(s.DB.WithContext(ctx).Where("id IN ?", ids).Order(ListOrder)).Find(&items).Error; err != nil {
+		s.Logger.Error("failed to get reordered media", logger.String("error", err.Error()))
+		return nil, fmt.Errorf("failed to get media: %w", err)
+	}
+	return items, nil
 }
 
 // UpdateFile updates the file of a media item
 func (s *MediaService) UpdateFile(ctx context.Context, id uint, file *multipart.FileHeader) (*Media, error) {
-	// Begin transaction
-	tx := s.DB.Begin()
-	if tx.Error != nil {
-		s.Logger.Error("failed to begin transaction", logger.String("error", tx.Error.Error()))
-		return nil, fmt.Errorf("failed to begin transaction: %w", tx.Error)
-	}
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
 	// Get existing item
-	item, err := s.GetById(id)
+	item, err := s.GetById(ctx, id)
 	if err != nil {
-		tx.Rollback()
 		return nil, err
 	}
 
-	// Remove existing file if any
-	if item.File != nil {
-		if err := s.ActiveStorage.Delete(item.File); err != nil {
-			tx.Rollback()
-			s.Logger.Error("failed to delete existing file", logger.String("error", err.Error()))
-			return nil, fmt.Errorf("failed to delete existing file: %w", err)
+	err = s.Tx.WithTx(ctx, func(tx *gorm.DB) error {
+		// Replace the existing file, if any, with the new one
+		if err := s.attachFile(ctx, item, file); err != nil {
+			return err
 		}
-	}
-
-	// Upload new file
-	attachment, err := s.ActiveStorage.Attach(item, "file", file)
-	if err != nil {
-		tx.Rollback()
-		s.Logger.Error("failed to upload file", logger.String("error", err.Error()))
-		return nil, fmt.Errorf("failed to upload file: %w", err)
-	}
 
-	// Update media with new file information
-	item.File = attachment
-	if err := tx.Save(item).Error; err != nil {
-		tx.Rollback()
-		s.Logger.Error("failed to update media with file", logger.String("error", err.Error()))
-		return nil, fmt.Errorf("failed to update media with file: %w", err)
-	}
+		// Update media with new file information
+		if err := tx.Save(item).Error; err != nil {
+			s.Logger.Error("failed to update media with file", logger.String("error", err.Error()))
+			return fmt.Errorf("failed to update media with file: %w", err)
+		}
 
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		s.Logger.Error("failed to commit transaction", logger.String("error", err.Error()))
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Reload item with relationships
-	return s.GetById(id)
+	return s.GetById(ctx, id)
 }
 
 // RemoveFile removes the file from a media item
 func (s *MediaService) RemoveFile(ctx context.Context, id uint) (*Media, error) {
-	// Begin transaction
-	tx := s.DB.Begin()
-	if tx.Error != nil {
-		s.Logger.Error("failed to begin transaction", logger.String("error", tx.Error.Error()))
-		return nil, fmt.Errorf("failed to begin transaction: %w", tx.Error)
-	}
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
 	// Get existing item
-	item, err := s.GetById(id)
+	item, err := s.GetById(ctx, id)
 	if err != nil {
-		tx.Rollback()
 		return nil, err
 	}
 
 	// Remove file if exists
 	if item.File != nil {
-		if err := s.ActiveStorage.Delete(item.File); err != nil {
-			tx.Rollback()
-			s.Logger.Error("failed to delete file", logger.String("error", err.Error()))
-			return nil, fmt.Errorf("failed to delete file: %w", err)
+		err = s.Tx.WithTx(ctx, func(tx *gorm.DB) error {
+			if err := s.removeFiles(ctx, item); err != nil {
+				return err
+			}
+
+			// Update media item
+			if err := tx.Save(item).Error; err != nil {
+				s.Logger.Error("failed to update media", logger.String("error", err.Error()))
+				return fmt.Errorf("failed to update media: %w", err)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
 		}
+	}
 
-		// Update media item
-		item.File = nil
-		if err := tx.Save(item).Error; err != nil {
-			tx.Rollback()
-			s.Logger.Error("failed to update media", logger.String("error", err.Error()))
-			return nil, fmt.Errorf("failed to update media: %w", err)
+	// Reload item with relationships
+	return s.GetById(ctx, id)
+}
+
+// attachFile replaces the file of a media item, and the original kept by the conversion of
+// the new file, if any. The alt text and caption are written by the Captioner when empty.
+func (s *MediaService) attachFile(ctx context.Context, item *Media, file *multipart.FileHeader) error {
+	if err := s.removeFiles(ctx, item); err != nil {
+		return err
+	}
+
+	attachment, err := s.ActiveStorage.Attach(ctx, item, "file", file)
+	if err != nil {
+		s.Logger.Error("failed to upload file", logger.String("error", err.Error()))
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+	item.File = attachment
+
+	// Record the conversion, and the original when it was kept
+	item.OriginalFormat, item.ConvertedFormat = "", ""
+	originalFormat := strings.TrimPrefix(strings.ToLower(filepath.Ext(file.Filename)), ".")
+	if format := strings.TrimPrefix(strings.ToLower(filepath.Ext(attachment.Filename)), "."); format != originalFormat {
+		item.OriginalFormat, item.ConvertedFormat = originalFormat, format
+		if original, err := s.ActiveStorage.LoadAttachment(ctx, item, "original_file"); err == nil {
+			item.OriginalFile = original
 		}
 	}
 
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		s.Logger.Error("failed to commit transaction", logger.String("error", err.Error()))
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	s.caption(ctx, item)
+	return nil
+}
+
+// Open opens the file of a media item for a download, which is recorded in the access trail
+// of the file. With AccessOriginal it opens the original file: the uploaded file when the
+// file was converted, the file otherwise.
+func (s *MediaService) Open(ctx context.Context, item *Media, access *storage.AttachmentAccess) (*storage.Attachment, io.ReadCloser, error) {
+	attachment := item.File
+	if access.Via == storage.AccessOriginal && item.OriginalFile != nil {
+		attachment = item.OriginalFile
+	}
+	if attachment == nil {
+		return nil, nil, ErrNoFile
+	}
+
+	if err := s.ActiveStorage.RecordAccess(ctx, attachment, access); err != nil {
+		s.Logger.Error("failed to record file access", logger.String("error", err.Error()))
+		return nil, nil, fmt.Errorf("failed to record file access: %w", err)
 	}
 
-	// Reload item with relationships
-	return s.GetById(id)
+	file, err := s.ActiveStorage.Open(ctx, attachment)
+	if err != nil {
+		s.Logger.Error("failed to open file", logger.String("error", err.Error()))
+		return nil, nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	return attachment, file, nil
+}
+
+// AccessLog returns a page of the downloads of the files of a media item, newest first, with
+// the download counts of its current files
+func (s *MediaService) AccessLog(ctx context.Context, item *Media, page, limit *int) (*AccessLogResponse, error) {
+	pageSize := 20
+	currentPage := 1
+	if limit != nil {
+		pageSize = *limit
+	}
+	if page != nil {
+		currentPage = *page
+	}
+
+	accesses, total, err := s.ActiveStorage.Accesses(ctx, item, (currentPage-1)*pageSize, pageSize)
+	if err != nil {
+		s.Logger.Error("failed to get media access log", logger.String("error", err.Error()))
+		return nil, fmt.Errorf("failed to get media access log: %w", err)
+	}
+
+	response := &AccessLogResponse{}
+	if file, err := s.ActiveStorage.LoadAttachment(ctx, item, "file"); err == nil {
+		response.FileDownloads = file.DownloadCount
+	}
+	if original, err := s.ActiveStorage.LoadAttachment(ctx, item, "original_file"); err == nil {
+		response.OriginalDownloads = original.DownloadCount
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(pageSize)))
+	if totalPages == 0 {
+		totalPages = 1
+	}
+	response.Data = accesses
+	response.Pagination = types.Pagination{
+		Total:      int(total),
+		Page:       currentPage,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+	}
+	return response, nil
 }
 
-// SyncFromR2 syncs media files from R2 bucket to database
-func (s *MediaService) SyncFromR2(activeStorage *storage.ActiveStorage, bucket, cdnURL, prefix string) (*SyncResult, error) {
-	// Get storage provider
-	provider := activeStorage.GetProvider()
+// removeFiles deletes the file of a media item and its original
+func (s *MediaService) removeFiles(ctx context.Context, item *Media) error {
+	for _, attachment := range []*storage.Attachment{item.File, item.OriginalFile} {
+		if attachment == nil {
+			continue
+		}
+		if err := s.ActiveStorage.Delete(ctx, attachment); err != nil {
+			s.Logger.Error("failed to delete file", logger.String("error", err.Error()))
+			return fmt.Errorf("failed to delete file: %w", err)
+		}
+	}
+	item.File, item.OriginalFile = nil, nil
+	return nil
+}
 
+// SyncFromR2 syncs media files from R2 bucket to database; with overwrite the files synced
+// already are refreshed from the bucket
+func (s *MediaService) SyncFromR2(ctx context.Context, activeStorage *storage.ActiveStorage, bucket, cdnURL, prefix string, overwrite bool) (*SyncResult, error) {
 	// Create syncer
-	syncer, err := NewR2Syncer(s.DB, provider, bucket, cdnURL)
+	syncer, err := NewR2Syncer(s.DB, activeStorage.GetProvider(), bucket, cdnURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create R2 syncer: %w", err)
 	}
 
 	// Run sync
-	result, err := syncer.SyncFromR2(prefix)
+	result, err := syncer.SyncFromR2(ctx, prefix, overwrite)
 	if err != nil {
 		return nil, err
 	}
@@ -453,54 +522,31 @@ func (s *MediaService) SyncFromR2(activeStorage *storage.ActiveStorage, bucket,
 	return result, nil
 }
 
+// SyncOverwriteImpact returns the media a sync with overwrite would refresh from the bucket,
+// for its dry run
+func (s *MediaService) SyncOverwriteImpact(ctx context.Context, activeStorage *storage.ActiveStorage, bucket, cdnURL, prefix string) (*confirm.Impact, error) {
+	syncer, err := NewR2Syncer(s.DB, activeStorage.GetProvider(), bucket, cdnURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create R2 syncer: %w", err)
+	}
+
+	existing, err := syncer.CountExisting(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return confirm.NewImpact("media.sync_overwrite:"+prefix, map[string]int64{"files": existing}, "will be overwritten"), nil
+}
+
 // GetAllWithFilters returns a paginated list of media items with filtering support
-func (s *MediaService) GetAllWithFilters(page, limit *int, filters *MediaFilters) (*types.PaginatedResponse, error) {
+func (s *MediaService) GetAllWithFilters(ctx context.Context, page, limit *int, filters *MediaFilters) (*types.PaginatedResponse, error) {
 	var items []*Media
 	var total int64
 
 	// Build query
-	query := s.DB.Model(&Media{})
+	query := s.DB.WithContext(ctx).Model(&Media{})
 
 	// Apply filters
-	hasFilters := filters != nil && (filters.ParentId != nil || filters.Folder != "" || filters.Type != "" || filters.AuthorId != nil)
-
-	if hasFilters {
-		// Filter by parent ID for hierarchical navigation
-		if filters.ParentId != nil {
-			query = query.Where("parent_id = ?", *filters.ParentId)
-		} else if filters.Folder == "" {
-			// If no parent_id and no folder filter, show only root level items
-			query = query.Where("parent_id IS NULL")
-		}
-
-		// Filter by folder path for backward compatibility
-		if filters.Folder != "" && filters.ParentId == nil {
-			query = query.Where("folder = ? OR folder LIKE ?", filters.Folder, filters.Folder+"/%")
-		}
-
-		// Filter by type
-		if filters.Type != "" {
-			query = query.Where("type LIKE ?", "%"+filters.Type+"%")
-		}
-
-		// Filter by author ID
-		if filters.AuthorId != nil {
-			if filters.IncludeShared {
-				// Include both author-specific and shared (null author_id) media
-				query = query.Where("author_id = ? OR author_id IS NULL", *filters.AuthorId)
-			} else {
-				// Only author-specific media
-				query = query.Where("author_id = ?", *filters.AuthorId)
-			}
-		} else if !filters.IncludeShared {
-			// If no author filter but include_shared is false, show only shared media
-			query = query.Where("author_id IS NULL")
-		}
-	} else if page != nil && limit != nil {
-		// Default: show root level items when no actual filters in paginated request
-		query = query.Where("parent_id IS NULL")
-	}
-	// If no actual filters and no pagination (ListAll case), return all items without parent_id filter
+	query = applyFilters(query, filters, page != nil && limit != nil)
 
 	// Get total count with filters applied
 	if err := query.Count(&total).Error; err != nil {
@@ -514,8 +560,8 @@ func (s *MediaService) GetAllWithFilters(page, limit *int, filters *MediaFilters
 		query = query.Offset(offset).Limit(*limit)
 	}
 
-	// Execute query with preloads
-	if err := query.Preload(clause.Associations).Find(&items).Error; err != nil {
+	// Execute query with the preloads of the list response
+	if err := (&Media{}).PreloadList(query.Order(ListOrder)).Find(&items).Error; err != nil {
 		s.Logger.Error("failed to get media with filters", logger.String("error", err.Error()))
 		return nil, fmt.Errorf("failed to get media: %w", err)
 	}
@@ -551,3 +597,72 @@ func (s *MediaService) GetAllWithFilters(page, limit *int, filters *MediaFilters
 		},
 	}, nil
 }
+
+// applyFilters adds the conditions of the filters to query. Without filters, paginated lists
+// show the root level and unpaginated ones (ListAll) every item.
+func applyFilters(query *gorm.DB, filters *MediaFilters, paginated bool) *gorm.DB {
+	if filters != nil {
+		query = filters.Access.scope(query)
+	}
+
+	hasFilters := filters != nil && (filters.ParentId != nil || filters.Folder != "" || filters.Type != "" || filters.AuthorId != nil)
+
+	if hasFilters {
+		// Filter by parent ID for hierarchical navigation
+		if filters.ParentId != nil {
+			query = query.Where("parent_id = ?", *filters.ParentId)
+		} else if filters.Folder == "" {
+			// If no parent_id and no folder filter, show only root level items
+			query = query.Where("parent_id IS NULL")
+		}
+
+		// Filter by folder path for backward compatibility
+		if filters.Folder != "" && filters.ParentId == nil {
+			query = query.Where("folder = ? OR folder LIKE ?", filters.Folder, filters.Folder+"/%")
+		}
+
+		// Filter by type
+		if filters.Type != "" {
+			query = query.Where("type LIKE ?", "%"+filters.Type+"%")
+		}
+
+		// Filter by author ID
+		if filters.AuthorId != nil {
+			if filters.IncludeShared {
+				// Include both author-specific and shared (null author_id) media
+				query = query.Where("author_id = ? OR author_id IS NULL", *filters.AuthorId)
+			} else {
+				// Only author-specific media
+				query = query.Where("author_id = ?", *filters.AuthorId)
+			}
+		} else if !filters.IncludeShared {
+			// If no author filter but include_shared is false, show only shared media
+			query = query.Where("author_id IS NULL")
+		}
+	} else if paginated {
+		// Default: show root level items when no actual filters in paginated request
+		query = query.Where("parent_id IS NULL")
+	}
+	return query
+}
+
+// StreamWithFilters calls fn for every media item matching the filters, loading them in
+// batches of batchSize with the preloads of the list response, so that the whole list is
+// never held in memory. It stops at the first error of fn.
+func (s *MediaService) StreamWithFilters(ctx context.Context, filters *MediaFilters, batchSize int, fn func(*Media) error) error {
+	var batch []*Media
+	query := applyFilters(s.DB.WithContext(ctx).Model(&Media{}), filters, false)
+	err := (&Media{}).PreloadList(query).FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+		for _, item := range batch {
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+	if err != nil {
+		s.Logger.Error("failed to stream media", logger.String("error", err.Error()))
+		return fmt.Errorf("failed to stream media: %w", err)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package media
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"path/filepath"
 	"strings"
@@ -17,13 +18,14 @@ import (
 
 // SyncResult represents the result of a sync operation
 type SyncResult struct {
-	TotalFiles      int      `json:"total_files"`
-	ProcessedFiles  int      `json:"processed_files"`
-	SkippedFiles    int      `json:"skipped_files"`
-	FailedFiles     int      `json:"failed_files"`
-	CreatedFolders  int      `json:"created_folders"`
-	Errors          []string `json:"errors,omitempty"`
-	DurationSeconds float64  `json:"duration_seconds"`
+	TotalFiles       int      `json:"total_files"`
+	ProcessedFiles   int      `json:"processed_files"`
+	SkippedFiles     int      `json:"skipped_files"`
+	OverwrittenFiles int      `json:"overwritten_files"` // Files already synced, refreshed with overwrite
+	FailedFiles      int      `json:"failed_files"`
+	CreatedFolders   int      `json:"created_folders"`
+	Errors           []string `json:"errors,omitempty"`
+	DurationSeconds  float64  `json:"duration_seconds"`
 }
 
 // R2Syncer handles syncing R2 bucket contents to media database
@@ -53,13 +55,32 @@ func NewR2Syncer(db *gorm.DB, storageProvider storage.Provider, bucket, cdnURL s
 	}, nil
 }
 
-// SyncFromR2 syncs files from R2 bucket to media database
-func (s *R2Syncer) SyncFromR2(prefix string) (*SyncResult, error) {
+// CountExisting returns the files of the bucket under a prefix that are synced already, which
+// a sync with overwrite refreshes
+func (s *R2Syncer) CountExisting(ctx context.Context, prefix string) (int64, error) {
+	objects, err := s.listR2Objects(ctx, prefix)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list R2 objects: %w", err)
+	}
+
+	var existing int64
+	for _, obj := range objects {
+		key := aws.ToString(obj.Key)
+		if !strings.HasSuffix(key, "/") && s.attachmentExists(ctx, key) {
+			existing++
+		}
+	}
+	return existing, nil
+}
+
+// SyncFromR2 syncs files from R2 bucket to media database. Files synced already are skipped,
+// or with overwrite refreshed from the bucket.
+func (s *R2Syncer) SyncFromR2(ctx context.Context, prefix string, overwrite bool) (*SyncResult, error) {
 	startTime := time.Now()
 	result := &SyncResult{}
 
 	// List all objects in bucket
-	objects, err := s.listR2Objects(prefix)
+	objects, err := s.listR2Objects(ctx, prefix)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list R2 objects: %w", err)
 	}
@@ -84,13 +105,22 @@ func (s *R2Syncer) SyncFromR2(prefix string) (*SyncResult, error) {
 		}
 
 		// Check if already exists
-		if s.attachmentExists(key) {
-			result.SkippedFiles++
+		if s.attachmentExists(ctx, key) {
+			if !overwrite {
+				result.SkippedFiles++
+				continue
+			}
+			if err := s.overwriteFile(ctx, key, relativeKey, size); err != nil {
+				result.FailedFiles++
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", relativeKey, err))
+				continue
+			}
+			result.OverwrittenFiles++
 			continue
 		}
 
 		// Process file
-		if err := s.processFile(key, relativeKey, size); err != nil {
+		if err := s.processFile(ctx, key, relativeKey, size); err != nil {
 			result.FailedFiles++
 			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", relativeKey, err))
 			continue
@@ -106,7 +136,7 @@ func (s *R2Syncer) SyncFromR2(prefix string) (*SyncResult, error) {
 }
 
 // listR2Objects lists all objects in R2 bucket with given prefix
-func (s *R2Syncer) listR2Objects(prefix string) ([]types.Object, error) {
+func (s *R2Syncer) listR2Objects(ctx context.Context, prefix string) ([]types.Object, error) {
 	var allObjects []types.Object
 
 	input := &s3.ListObjectsV2Input{
@@ -120,7 +150,7 @@ func (s *R2Syncer) listR2Objects(prefix string) ([]types.Object, error) {
 	paginator := s3.NewListObjectsV2Paginator(s.s3Client, input)
 
 	for paginator.HasMorePages() {
-		page, err := paginator.NextPage(context.Background())
+		page, err := paginator.NextPage(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -131,14 +161,14 @@ func (s *R2Syncer) listR2Objects(prefix string) ([]types.Object, error) {
 }
 
 // attachmentExists checks if attachment already exists for this path
-func (s *R2Syncer) attachmentExists(path string) bool {
+func (s *R2Syncer) attachmentExists(ctx context.Context, path string) bool {
 	var count int64
-	s.db.Model(&storage.Attachment{}).Where("path = ?", path).Count(&count)
+	s.db.WithContext(ctx).Model(&storage.Attachment{}).Where("path = ?", path).Count(&count)
 	return count > 0
 }
 
 // processFile processes a single file and creates media + attachment records
-func (s *R2Syncer) processFile(key, relativeKey string, size int64) error {
+func (s *R2Syncer) processFile(ctx context.Context, key, relativeKey string, size int64) error {
 	filename := filepath.Base(relativeKey)
 	dirPath := filepath.Dir(relativeKey)
 
@@ -148,7 +178,7 @@ func (s *R2Syncer) processFile(key, relativeKey string, size int64) error {
 	// Get or create folder hierarchy
 	var parentID *uint
 	if dirPath != "." && dirPath != "/" && dirPath != "" {
-		folderID, err := s.ensureFolderHierarchy(dirPath)
+		folderID, err := s.ensureFolderHierarchy(ctx, dirPath)
 		if err != nil {
 			return fmt.Errorf("failed to create folder hierarchy: %w", err)
 		}
@@ -166,26 +196,16 @@ func (s *R2Syncer) processFile(key, relativeKey string, size int64) error {
 		immediateFolder = filepath.Base(dirPath)
 	}
 
-	// Build CDN URL
-	cdnURL := fmt.Sprintf("%s/%s", s.cdnURL, key)
-
-	// Create metadata JSON
-	metadataStr := fmt.Sprintf(`{"original_filename": "%s", "path": "%s", "size": %d}`,
-		strings.ReplaceAll(filename, `"`, `\"`),
-		strings.ReplaceAll(key, `"`, `\"`),
-		size)
-
-	// Create media record
+	// Create media record, with its metadata and file once its attachment exists
 	media := &Media{
 		Name:        mediaName,
 		Type:        mediaType,
 		Folder:      immediateFolder,
 		ParentId:    parentID,
 		Description: "",
-		Metadata:    &metadataStr,
 	}
 
-	if err := s.db.Create(media).Error; err != nil {
+	if err := s.db.WithContext(ctx).Create(media).Error; err != nil {
 		return fmt.Errorf("failed to create media record: %w", err)
 	}
 
@@ -197,26 +217,86 @@ func (s *R2Syncer) processFile(key, relativeKey string, size int64) error {
 		Filename:  filename,
 		Path:      key,
 		Size:      size,
-		URL:       cdnURL,
+		URL:       fmt.Sprintf("%s/%s", s.cdnURL, key),
 	}
 
-	if err := s.db.Create(attachment).Error; err != nil {
+	if err := s.db.WithContext(ctx).Create(attachment).Error; err != nil {
 		return fmt.Errorf("failed to create attachment: %w", err)
 	}
 
-	// Update media with file reference (JSON field)
-	fileJSON := fmt.Sprintf(`{"id": %d, "filename": "%s", "path": "%s", "size": %d, "url": "%s"}`,
-		attachment.Id, filename, key, size, cdnURL)
-
-	if err := s.db.Model(media).Update("file", gorm.Expr("?", fileJSON)).Error; err != nil {
+	columns, err := syncedFileColumns(attachment)
+	if err != nil {
+		return err
+	}
+	if err := s.db.WithContext(ctx).Model(media).Updates(columns).Error; err != nil {
 		return fmt.Errorf("failed to update media with file reference: %w", err)
 	}
 
 	return nil
 }
 
+// overwriteFile refreshes the attachments of a synced file, and the media they belong to,
+// with the size and URL of the object in the bucket
+func (s *R2Syncer) overwriteFile(ctx context.Context, key, relativeKey string, size int64) error {
+	filename := filepath.Base(relativeKey)
+	cdnURL := fmt.Sprintf("%s/%s", s.cdnURL, key)
+
+	var attachments []storage.Attachment
+	if err := s.db.WithContext(ctx).Where("path = ?", key).Find(&attachments).Error; err != nil {
+		return fmt.Errorf("failed to find attachments: %w", err)
+	}
+	for _, attachment := range attachments {
+		if err := s.db.WithContext(ctx).Model(&attachment).Updates(map[string]any{
+			"filename": filename,
+			"size":     size,
+			"url":      cdnURL,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to update attachment: %w", err)
+		}
+		if attachment.ModelType != "media" || attachment.Field != "file" {
+			continue
+		}
+
+		columns, err := syncedFileColumns(&attachment)
+		if err != nil {
+			return err
+		}
+		if err := s.db.WithContext(ctx).Model(&Media{}).Where("id = ?", attachment.ModelId).Updates(columns).Error; err != nil {
+			return fmt.Errorf("failed to update media: %w", err)
+		}
+	}
+	return nil
+}
+
+// syncedFileColumns returns the metadata and file columns of the media of a synced file
+// with their attachment
+func syncedFileColumns(attachment *storage.Attachment) (map[string]any, error) {
+	metadata, err := json.Marshal(map[string]any{
+		"original_filename": attachment.Filename,
+		"path":              attachment.Path,
+		"size":              attachment.Size,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode media metadata: %w", err)
+	}
+	file, err := json.Marshal(map[string]any{
+		"id":       attachment.Id,
+		"filename": attachment.Filename,
+		"path":     attachment.Path,
+		"size":     attachment.Size,
+		"url":      attachment.URL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode media file: %w", err)
+	}
+	return map[string]any{
+		"metadata": string(metadata),
+		"file":     gorm.Expr("?", string(file)),
+	}, nil
+}
+
 // ensureFolderHierarchy ensures all parent folders exist and returns the leaf folder ID
-func (s *R2Syncer) ensureFolderHierarchy(path string) (uint, error) {
+func (s *R2Syncer) ensureFolderHierarchy(ctx context.Context, path string) (uint, error) {
 	// Check cache first
 	if id, exists := s.folderCache[path]; exists {
 		return id, nil
@@ -247,7 +327,7 @@ func (s *R2Syncer) ensureFolderHierarchy(path string) (uint, error) {
 
 		// Check if folder exists in database
 		var folder Media
-		query := s.db.Where("type = ? AND folder = ?", "folder", currentPath)
+		query := s.db.WithContext(ctx).Where("type = ? AND folder = ?", "folder", currentPath)
 		if err := query.First(&folder).Error; err == nil {
 			// Folder exists
 			s.folderCache[currentPath] = folder.Id
@@ -256,7 +336,11 @@ func (s *R2Syncer) ensureFolderHierarchy(path string) (uint, error) {
 		}
 
 		// Create folder with metadata
-		folderMetadataStr := fmt.Sprintf(`{"path": "%s/"}`, strings.ReplaceAll(currentPath, `"`, `\"`))
+		folderMetadata, err := json.Marshal(map[string]string{"path": currentPath + "/"})
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode folder metadata: %w", err)
+		}
+		folderMetadataStr := string(folderMetadata)
 
 		folder = Media{
 			Name:        part,
@@ -267,7 +351,7 @@ func (s *R2Syncer) ensureFolderHierarchy(path string) (uint, error) {
 			Metadata:    &folderMetadataStr,
 		}
 
-		if err := s.db.Create(&folder).Error; err != nil {
+		if err := s.db.WithContext(ctx).Create(&folder).Error; err != nil {
 			return 0, fmt.Errorf("failed to create folder %s: %w", currentPath, err)
 		}
 
@@ -0,0 +1,269 @@
+package media
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"base/core/logger"
+
+	"gorm.io/gorm"
+)
+
+// ErrInUse is returned for deleting media that records still embed, see Usages
+var ErrInUse = errors.New("media is still used")
+
+// Usage links a media item to a record that embeds its file, e.g. the image block of a page
+type Usage struct {
+	Id        uint      `json:"id" gorm:"primaryKey"`
+	MediaId   uint      `json:"media_id" gorm:"index;not null"`
+	ModelType string    `json:"model_type" gorm:"size:64;index:idx_media_usages_model"`
+	ModelId   uint      `json:"model_id" gorm:"index:idx_media_usages_model"`
+	Field     string    `json:"field" gorm:"size:64"`
+	Title     string    `json:"title" gorm:"size:255"` // Of the record when it was saved
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for the Usage model
+func (Usage) TableName() string {
+	return "media_usages"
+}
+
+// UsageEntity is a model whose records embed media by the URL of their files, e.g. pages
+// with image blocks. The usages of a record are tracked from its events.
+type UsageEntity struct {
+	Name        string   // Type of the records, e.g. "pages"
+	Model       any      // Model of the records, to rebuild the usages of existing records
+	SaveEvents  []string // Events emitted with a created or changed record (with a GetId method)
+	DeleteEvent string   // Event emitted with a deleted record (with a GetId method)
+
+	// Content returns the title of a record and its fields that may embed media, e.g.
+	// "blocks"; their values are searched for the URLs of media files
+	Content func(record any) (title string, fields map[string]any)
+}
+
+var (
+	usageEntitiesMu sync.RWMutex
+	usageEntities   = map[string]UsageEntity{}
+
+	// watchUsage subscribes the media module to the events of an entity. Modules register
+	// their entities before or after the media module starts, depending on their order.
+	watchUsage func(UsageEntity)
+)
+
+// RegisterUsageEntity adds a model to the tracked media usages, e.g. from a module's Init:
+//
+//	media.RegisterUsageEntity(media.UsageEntity{Name: "pages", Model: &Page{},
+//		SaveEvents: []string{CreatePageEvent, UpdatePageEvent}, DeleteEvent: DeletePageEvent,
+//		Content: func(record any) (string, map[string]any) { ... }})
+func RegisterUsageEntity(entity UsageEntity) {
+	usageEntitiesMu.Lock()
+	usageEntities[entity.Name] = entity
+	subscribe := watchUsage
+	usageEntitiesMu.Unlock()
+
+	if subscribe != nil {
+		subscribe(entity)
+	}
+}
+
+// UsageEntities returns the registered usage entities sorted by name
+func UsageEntities() []UsageEntity {
+	usageEntitiesMu.RLock()
+	defer usageEntitiesMu.RUnlock()
+	result := make([]UsageEntity, 0, len(usageEntities))
+	for _, entity := range usageEntities {
+		result = append(result, entity)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// ListenUsages tracks the usages of the entities, registered so far and later
+func (s *MediaService) ListenUsages() {
+	usageEntitiesMu.Lock()
+	watchUsage = s.watchUsages
+	usageEntitiesMu.Unlock()
+
+	for _, entity := range UsageEntities() {
+		s.watchUsages(entity)
+	}
+}
+
+// watchUsages updates the usages of the records of an entity when they are saved or deleted
+func (s *MediaService) watchUsages(entity UsageEntity) {
+	if s.Emitter == nil {
+		return
+	}
+	s.usagesMu.Lock()
+	defer s.usagesMu.Unlock()
+	if s.watchedUsages == nil {
+		s.watchedUsages = map[string]bool{}
+	}
+	if s.watchedUsages[entity.Name] {
+		return
+	}
+	s.watchedUsages[entity.Name] = true
+
+	for _, event := range entity.SaveEvents {
+		s.Emitter.OnContext(event, func(ctx context.Context, data any) {
+			if err := s.TrackUsages(ctx, entity, data); err != nil {
+				s.Logger.Error("failed to track media usages",
+					logger.String("error", err.Error()),
+					logger.String("model_type", entity.Name))
+			}
+		})
+	}
+	if entity.DeleteEvent != "" {
+		s.Emitter.OnContext(entity.DeleteEvent, func(ctx context.Context, data any) {
+			record, ok := data.(interface{ GetId() uint })
+			if !ok {
+				return
+			}
+			if err := s.DB.WithContext(ctx).Where("model_type = ? AND model_id = ?", entity.Name, record.GetId()).
+				Delete(&Usage{}).Error; err != nil {
+				s.Logger.Error("failed to delete media usages",
+					logger.String("error", err.Error()),
+					logger.String("model_type", entity.Name))
+			}
+		})
+	}
+}
+
+// TrackUsages replaces the usages of a record of an entity with the media its content embeds
+func (s *MediaService) TrackUsages(ctx context.Context, entity UsageEntity, record any) error {
+	identified, ok := record.(interface{ GetId() uint })
+	if !ok || entity.Content == nil {
+		return nil
+	}
+	title, fields := entity.Content(record)
+
+	usages := []*Usage{}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ids, err := s.referencedMedia(ctx, fields[name])
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			usages = append(usages, &Usage{
+				MediaId:   id,
+				ModelType: entity.Name,
+				ModelId:   identified.GetId(),
+				Field:     name,
+				Title:     truncate(title, 255),
+			})
+		}
+	}
+
+	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("model_type = ? AND model_id = ?", entity.Name, identified.GetId()).Delete(&Usage{}).Error; err != nil {
+			return err
+		}
+		if len(usages) == 0 {
+			return nil
+		}
+		return tx.Create(&usages).Error
+	})
+}
+
+// RebuildUsages tracks the usages of all records of the registered entities again, e.g. for
+// records saved before their entity was registered, and returns the number of records
+func (s *MediaService) RebuildUsages(ctx context.Context) (int, error) {
+	count := 0
+	for _, entity := range UsageEntities() {
+		if entity.Model == nil {
+			continue
+		}
+		records := reflect.New(reflect.SliceOf(reflect.TypeOf(entity.Model)))
+		if err := s.DB.WithContext(ctx).Model(entity.Model).Find(records.Interface()).Error; err != nil {
+			return count, err
+		}
+		for i := 0; i < records.Elem().Len(); i++ {
+			if err := s.TrackUsages(ctx, entity, records.Elem().Index(i).Interface()); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Usages returns the records that embed a media item, the most recently saved first
+func (s *MediaService) Usages(ctx context.Context, mediaId uint) ([]*Usage, error) {
+	usages := []*Usage{}
+	err := s.DB.WithContext(ctx).Where("media_id = ?", mediaId).
+		Order("updated_at DESC, id DESC").Find(&usages).Error
+	return usages, err
+}
+
+// mediaURLPattern matches absolute and root-relative URLs in text, e.g. in the JSON of blocks
+// or in the src attributes of HTML
+var mediaURLPattern = regexp.MustCompile(`(?:https?://|/)[^\s"'<>()\\]+`)
+
+// referencedMedia returns the ids of the media whose files a value links to. The value is
+// searched as JSON, so URLs in nested data and in HTML are found too. URLs match a file by
+// its URL or by a path ending with its storage path, e.g. through a CDN.
+func (s *MediaService) referencedMedia(ctx context.Context, value any) ([]uint, error) {
+	text, ok := value.(string)
+	if !ok {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	}
+
+	var urls, paths []string
+	for _, match := range mediaURLPattern.FindAllString(text, -1) {
+		match, _, _ = strings.Cut(match, "?")
+		match, _, _ = strings.Cut(match, "#")
+		if !slices.Contains(urls, match) {
+			urls = append(urls, match)
+		}
+		path := match
+		if parsed, err := url.Parse(match); err == nil {
+			path = parsed.Path
+		}
+		// Every suffix after a slash may be the storage path of a file
+		for i := range len(path) {
+			if path[i] == '/' && i+1 < len(path) && !slices.Contains(paths, path[i+1:]) {
+				paths = append(paths, path[i+1:])
+			}
+		}
+	}
+	if len(urls) == 0 {
+		return nil, nil
+	}
+
+	ids := []uint{}
+	err := s.DB.WithContext(ctx).Table("attachments").
+		Where("model_type = ? AND field IN ?", "media", []string{"file", "original_file"}).
+		Where("url IN ? OR path IN ?", urls, paths).
+		Distinct().Order("model_id").Pluck("model_id", &ids).Error
+	return ids, err
+}
+
+// truncate shortens a string to at most n bytes, at a rune boundary
+func truncate(value string, n int) string {
+	if len(value) <= n {
+		return value
+	}
+	for n > 0 && !utf8.RuneStart(value[n]) {
+		n--
+	}
+	return value[:n]
+}
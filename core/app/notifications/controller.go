@@ -7,7 +7,9 @@ import (
 
 	"base/core/router"
 	"base/core/storage"
+	"base/core/translation"
 	"base/core/types"
+	"base/core/validator"
 )
 
 type NotificationController struct {
@@ -53,7 +55,7 @@ func (c *NotificationController) Create(ctx *router.Context) error {
 		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
 	}
 
-	item, err := c.Service.Create(&req)
+	item, err := c.Service.Create(ctx.Request.Context(), &req)
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to create item: " + err.Error()})
 	}
@@ -80,7 +82,7 @@ func (c *NotificationController) Get(ctx *router.Context) error {
 		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
 	}
 
-	item, err := c.Service.GetById(uint(id))
+	item, err := c.Service.GetById(ctx.Request.Context(), uint(id))
 	if err != nil {
 		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Item not found"})
 	}
@@ -105,41 +107,12 @@ func (c *NotificationController) Get(ctx *router.Context) error {
 // @Failure 500 {object} types.ErrorResponse
 // @Router /notifications [get]
 func (c *NotificationController) List(ctx *router.Context) error {
-	var page, limit *int
-	var sortBy, sortOrder *string
-
-	// Parse page parameter
-	if pageStr := ctx.Query("page"); pageStr != "" {
-		if pageNum, err := strconv.Atoi(pageStr); err == nil && pageNum > 0 {
-			page = &pageNum
-		} else {
-			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid page number"})
-		}
-	}
-
-	// Parse limit parameter
-	if limitStr := ctx.Query("limit"); limitStr != "" {
-		if limitNum, err := strconv.Atoi(limitStr); err == nil && limitNum > 0 {
-			limit = &limitNum
-		} else {
-			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid limit number"})
-		}
-	}
-
-	// Parse sort parameters
-	if sortStr := ctx.Query("sort"); sortStr != "" {
-		sortBy = &sortStr
-	}
-
-	if orderStr := ctx.Query("order"); orderStr != "" {
-		if orderStr == "asc" || orderStr == "desc" {
-			sortOrder = &orderStr
-		} else {
-			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid sort order. Use 'asc' or 'desc'"})
-		}
+	params, err := ctx.ListParams(sortFields...)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
 	}
 
-	paginatedResponse, err := c.Service.GetAll(page, limit, sortBy, sortOrder)
+	paginatedResponse, err := c.Service.GetAll(ctx.Request.Context(), params.Page, params.Limit, params.SortBy, params.SortOrder)
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch items: " + err.Error()})
 	}
@@ -159,7 +132,7 @@ func (c *NotificationController) List(ctx *router.Context) error {
 // @Failure 500 {object} types.ErrorResponse
 // @Router /notifications/all [get]
 func (c *NotificationController) ListAll(ctx *router.Context) error {
-	items, err := c.Service.GetAllForSelect()
+	items, err := c.Service.GetAllForSelect(ctx.Request.Context())
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch select options: " + err.Error()})
 	}
@@ -199,11 +172,17 @@ func (c *NotificationController) Update(ctx *router.Context) error {
 		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
 	}
 
-	item, err := c.Service.Update(uint(id), &req)
+	item, err := c.Service.Update(ctx.Request.Context(), uint(id), &req)
 	if err != nil {
 		if strings.Contains(err.Error(), "record not found") {
 			return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Item not found"})
 		}
+		if validationErrors, ok := err.(validator.ValidationErrors); ok {
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+				Error:   translation.Error(ctx, err),
+				Details: translation.LocalizeValidation(ctx, validationErrors),
+			})
+		}
 		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to update item: " + err.Error()})
 	}
 
@@ -229,7 +208,7 @@ func (c *NotificationController) Delete(ctx *router.Context) error {
 		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
 	}
 
-	if err := c.Service.Delete(uint(id)); err != nil {
+	if err := c.Service.Delete(ctx.Request.Context(), uint(id)); err != nil {
 		if strings.Contains(err.Error(), "record not found") {
 			return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Item not found"})
 		}
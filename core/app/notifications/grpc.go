@@ -0,0 +1,50 @@
+package notifications
+
+import (
+	"context"
+
+	coregrpc "base/core/grpc"
+	"base/core/grpc/pb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// notificationsServer implements the Notifications gRPC service with the
+// NotificationService, so notifications sent over gRPC emit the same events
+type notificationsServer struct {
+	pb.UnimplementedNotificationsServer
+	service *NotificationService
+}
+
+func registerGRPC(service *NotificationService) {
+	coregrpc.Register(func(s grpc.ServiceRegistrar) {
+		pb.RegisterNotificationsServer(s, &notificationsServer{service: service})
+	})
+}
+
+func (s *notificationsServer) Send(ctx context.Context, req *pb.SendNotificationRequest) (*pb.SendNotificationResponse, error) {
+	if len(req.GetUserIds()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "user_ids is required")
+	}
+	if req.GetTitle() == "" {
+		return nil, status.Error(codes.InvalidArgument, "title is required")
+	}
+
+	resp := &pb.SendNotificationResponse{Ids: make([]uint64, 0, len(req.GetUserIds()))}
+	for _, userId := range req.GetUserIds() {
+		notification, err := s.service.Create(ctx, &CreateNotificationRequest{
+			UserId:    uint(userId),
+			Title:     req.GetTitle(),
+			Body:      req.GetBody(),
+			Type:      req.GetType(),
+			ActionUrl: req.GetActionUrl(),
+		})
+		if err != nil {
+			return nil, coregrpc.Error(err)
+		}
+		resp.Ids = append(resp.Ids, uint64(notification.Id))
+	}
+	return resp, nil
+}
@@ -10,14 +10,14 @@ import (
 // Notification represents a notification entity
 type Notification struct {
 	Id        uint           `json:"id" gorm:"primarykey"`
-	CreatedAt time.Time      `json:"created_at"`
+	CreatedAt time.Time      `json:"created_at" gorm:"index:,composite:user_read_created,priority:3"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
-	UserId    uint           `json:"user_id"`
+	UserId    uint           `json:"user_id" gorm:"index:,composite:user_read_created,priority:1"`
 	Title     string         `json:"title"`
 	Body      string         `json:"body"`
 	Type      string         `json:"type"`
-	Read      bool           `json:"read"`
+	Read      bool           `json:"read" gorm:"index:,composite:user_read_created,priority:2"`
 	ReadAt    types.DateTime `json:"read_at"`
 	ActionUrl string         `json:"action_url"`
 }
@@ -50,13 +50,13 @@ type CreateNotificationRequest struct {
 
 // UpdateNotificationRequest represents the request payload for updating a Notification
 type UpdateNotificationRequest struct {
-	UserId    uint           `json:"user_id,omitempty"`
-	Title     string         `json:"title,omitempty"`
-	Body      string         `json:"body,omitempty"`
-	Type      string         `json:"type,omitempty"`
-	Read      *bool          `json:"read,omitempty"`
-	ReadAt    types.DateTime `json:"read_at,omitempty" swaggertype:"string"`
-	ActionUrl string         `json:"action_url,omitempty"`
+	UserId    *uint           `json:"user_id,omitempty"`
+	Title     *string         `json:"title,omitempty"`
+	Body      *string         `json:"body,omitempty"`
+	Type      *string         `json:"type,omitempty"`
+	Read      *bool           `json:"read,omitempty"`
+	ReadAt    *types.DateTime `json:"read_at,omitempty" swaggertype:"string"` // "" clears it
+	ActionUrl *string         `json:"action_url,omitempty"`
 }
 
 // NotificationResponse represents the API response for Notification
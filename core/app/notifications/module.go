@@ -22,6 +22,9 @@ func Init(deps module.Dependencies) module.Module {
 	service := NewNotificationService(deps.DB, deps.Emitter, deps.Storage, deps.Logger)
 	controller := NewNotificationController(service, deps.Storage)
 
+	// Internal gRPC service (see core/grpc)
+	registerGRPC(service)
+
 	// Create module
 	mod := &Module{
 		DB:         deps.DB,
@@ -0,0 +1,39 @@
+package notifications_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"base/core/app/notifications"
+	"base/core/testutil"
+	"base/core/types"
+)
+
+func TestUpdateNotificationClearsValues(t *testing.T) {
+	app := testutil.New(t, testutil.Options{})
+	admin := app.CreateUser("Super Admin")
+	notification := &notifications.Notification{
+		UserId: admin.Id, Title: "Welcome", Body: "Hello", Type: "info",
+		Read: true, ReadAt: types.DateTime{Time: time.Now()}, ActionUrl: "/welcome",
+	}
+	if err := app.DB.Create(notification).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	app.As(admin).PUT(fmt.Sprintf("/api/notifications/%d", notification.Id), map[string]any{
+		"read": false, "read_at": "", "action_url": "",
+	}).AssertStatus(http.StatusOK)
+
+	var stored notifications.Notification
+	if err := app.DB.First(&stored, notification.Id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Read || !stored.ReadAt.IsZero() || stored.ActionUrl != "" {
+		t.Errorf("read = %t, read_at = %v, action_url = %q, want them cleared", stored.Read, stored.ReadAt, stored.ActionUrl)
+	}
+	if stored.Title != notification.Title {
+		t.Errorf("title = %q, want it unchanged as %q", stored.Title, notification.Title)
+	}
+}
@@ -49,7 +49,7 @@ func (c *OAuthController) GoogleCallback(ctx *router.Context) error {
 		return nil
 	}
 
-	user, err := c.Service.ProcessGoogleOAuth(req.IdToken)
+	user, err := c.Service.ProcessGoogleOAuth(ctx.Request.Context(), req.IdToken)
 	if err != nil {
 		c.Logger.Error("Google OAuth authentication failed", logger.String("error", err.Error()))
 		ctx.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error()})
@@ -83,7 +83,7 @@ func (c *OAuthController) FacebookCallback(ctx *router.Context) error {
 		return nil
 	}
 
-	user, err := c.Service.ProcessFacebookOAuth(req.AccessToken)
+	user, err := c.Service.ProcessFacebookOAuth(ctx.Request.Context(), req.AccessToken)
 	if err != nil {
 		c.Logger.Error("Facebook OAuth authentication failed", logger.String("error", err.Error()))
 		ctx.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error()})
@@ -117,7 +117,7 @@ func (c *OAuthController) AppleCallback(ctx *router.Context) error {
 		return nil
 	}
 
-	user, err := c.Service.ProcessAppleOAuth(req.IdToken)
+	user, err := c.Service.ProcessAppleOAuth(ctx.Request.Context(), req.IdToken)
 	if err != nil {
 		c.Logger.Error("Apple OAuth authentication failed", logger.String("error", err.Error()))
 		ctx.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error()})
@@ -32,31 +32,31 @@ func NewOAuthService(db *gorm.DB, config *OAuthConfig, activeStorage *storage.Ac
 	}
 }
 
-func (s *OAuthService) ProcessAppleOAuth(idToken string) (*OAuthUser, error) {
+func (s *OAuthService) ProcessAppleOAuth(ctx context.Context, idToken string) (*OAuthUser, error) {
 	email, name, username, picture, providerId, err := s.handleAppleOAuth(idToken)
 	if err != nil {
 		return nil, err
 	}
 
-	return s.processUser(email, name, username, picture, "apple", providerId, idToken)
+	return s.processUser(ctx, email, name, username, picture, "apple", providerId, idToken)
 }
 
-func (s *OAuthService) ProcessGoogleOAuth(idToken string) (*OAuthUser, error) {
+func (s *OAuthService) ProcessGoogleOAuth(ctx context.Context, idToken string) (*OAuthUser, error) {
 	email, name, username, picture, providerId, err := s.handleGoogleOAuth(idToken)
 	if err != nil {
 		return nil, err
 	}
 
-	return s.processUser(email, name, username, picture, "google", providerId, idToken)
+	return s.processUser(ctx, email, name, username, picture, "google", providerId, idToken)
 }
 
-func (s *OAuthService) ProcessFacebookOAuth(accessToken string) (*OAuthUser, error) {
+func (s *OAuthService) ProcessFacebookOAuth(ctx context.Context, accessToken string) (*OAuthUser, error) {
 	email, name, username, picture, providerId, err := s.handleFacebookOAuth(accessToken)
 	if err != nil {
 		return nil, err
 	}
 
-	return s.processUser(email, name, username, picture, "facebook", providerId, accessToken)
+	return s.processUser(ctx, email, name, username, picture, "facebook", providerId, accessToken)
 }
 
 func (s *OAuthService) handleAppleOAuth(idToken string) (email, name, username, picture, providerId string, err error) {
@@ -122,9 +122,9 @@ func (s *OAuthService) handleFacebookOAuth(accessToken string) (email, name, use
 	return email, name, username, picture, providerId, nil
 }
 
-func (s *OAuthService) processUser(email, name, username, pictureURL, provider, providerId, token string) (*OAuthUser, error) {
+func (s *OAuthService) processUser(ctx context.Context, email, name, username, pictureURL, provider, providerId, token string) (*OAuthUser, error) {
 	var user OAuthUser
-	err := s.DB.Where("email = ?", email).First(&user).Error
+	err := s.DB.WithContext(ctx).Where("email = ?", email).First(&user).Error
 
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -134,7 +134,7 @@ func (s *OAuthService) processUser(email, name, username, pictureURL, provider,
 					Email:     email,
 					FirstName: name[:strings.Index(name, " ")],
 					LastName:  name[strings.Index(name, " ")+1:],
-					Username:  s.generateUniqueUsername(username),
+					Username:  s.generateUniqueUsername(ctx, username),
 				},
 				Provider:       provider,
 				ProviderId:     providerId,
@@ -144,7 +144,7 @@ func (s *OAuthService) processUser(email, name, username, pictureURL, provider,
 
 			// Fetch and attach avatar if URL is provided
 			if pictureURL != "" {
-				attachment, err := s.fetchAndAttachAvatar(&user, pictureURL)
+				attachment, err := s.fetchAndAttachAvatar(ctx, &user, pictureURL)
 				if err == nil {
 					user.User.Avatar = attachment
 				} else {
@@ -154,7 +154,7 @@ func (s *OAuthService) processUser(email, name, username, pictureURL, provider,
 			}
 
 			// Create the user in the database
-			if err := s.DB.Create(&user).Error; err != nil {
+			if err := s.DB.WithContext(ctx).Create(&user).Error; err != nil {
 				return nil, fmt.Errorf("failed to create user: %w", err)
 			}
 		} else {
@@ -171,7 +171,7 @@ func (s *OAuthService) processUser(email, name, username, pictureURL, provider,
 
 		// Update avatar if a new URL is provided
 		if pictureURL != "" {
-			attachment, err := s.fetchAndAttachAvatar(&user, pictureURL)
+			attachment, err := s.fetchAndAttachAvatar(ctx, &user, pictureURL)
 			if err == nil {
 				user.User.Avatar = attachment
 			} else {
@@ -179,7 +179,7 @@ func (s *OAuthService) processUser(email, name, username, pictureURL, provider,
 			}
 		}
 
-		if err := s.DB.Save(&user).Error; err != nil {
+		if err := s.DB.WithContext(ctx).Save(&user).Error; err != nil {
 			return nil, fmt.Errorf("failed to update user: %w", err)
 		}
 	}
@@ -192,7 +192,7 @@ func (s *OAuthService) processUser(email, name, username, pictureURL, provider,
 		AccessToken: token,
 		LastLogin:   time.Now(),
 	}
-	if err := s.DB.Where("user_id = ? AND provider = ?", user.Id, provider).
+	if err := s.DB.WithContext(ctx).Where("user_id = ? AND provider = ?", user.Id, provider).
 		Assign(authProvider).
 		FirstOrCreate(&authProvider).Error; err != nil {
 		return nil, fmt.Errorf("failed to update or create auth provider: %w", err)
@@ -203,7 +203,7 @@ func (s *OAuthService) processUser(email, name, username, pictureURL, provider,
 
 // fetchAndAttachAvatar downloads the avatar from the URL and attaches it to the user using ActiveStorage.
 
-func (s *OAuthService) fetchAndAttachAvatar(user *OAuthUser, avatarURL string) (*storage.Attachment, error) {
+func (s *OAuthService) fetchAndAttachAvatar(ctx context.Context, user *OAuthUser, avatarURL string) (*storage.Attachment, error) {
 	// Download the avatar from the URL
 	resp, err := http.Get(avatarURL)
 	if err != nil {
@@ -250,7 +250,7 @@ func (s *OAuthService) fetchAndAttachAvatar(user *OAuthUser, avatarURL string) (
 	}
 
 	// Attach the avatar to ActiveStorage
-	attachment, err := s.ActiveStorage.Attach(&user.User, "avatar", fileHeader)
+	attachment, err := s.ActiveStorage.Attach(ctx, &user.User, "avatar", fileHeader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to attach avatar: %w", err)
 	}
@@ -258,12 +258,12 @@ func (s *OAuthService) fetchAndAttachAvatar(user *OAuthUser, avatarURL string) (
 	return attachment, nil
 }
 
-func (s *OAuthService) generateUniqueUsername(baseUsername string) string {
+func (s *OAuthService) generateUniqueUsername(ctx context.Context, baseUsername string) string {
 	username := baseUsername
 	counter := 1
 	for {
 		var existingUser users.User
-		if s.DB.Where("username = ?", username).First(&existingUser).Error == gorm.ErrRecordNotFound {
+		if s.DB.WithContext(ctx).Where("username = ?", username).First(&existingUser).Error == gorm.ErrRecordNotFound {
 			break
 		}
 		username = fmt.Sprintf("%s%d", baseUsername, counter)
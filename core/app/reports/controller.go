@@ -0,0 +1,379 @@
+package reports
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"base/core/router"
+	"base/core/translation"
+	"base/core/types"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+type ReportController struct {
+	Service *ReportService
+}
+
+func NewReportController(service *ReportService) *ReportController {
+	return &ReportController{
+		Service: service,
+	}
+}
+
+// Routes registers the endpoints; the group is restricted to admins by the module
+func (c *ReportController) Routes(router *router.RouterGroup) {
+	router.GET("/reports", c.List)                                                 // List
+	router.POST("/reports", c.Create)                                              // Create
+	router.GET("/reports/entities", c.Entities)                                    // Entities, formats and operators
+	router.GET("/reports/:id", c.Get)                                              // Get by ID
+	router.PUT("/reports/:id", c.Update)                                           // Update
+	router.DELETE("/reports/:id", c.Delete)                                        // Delete
+	router.POST("/reports/:id/run", c.Run)                                         // Queue a run
+	router.GET("/reports/:id/runs", c.Runs)                                        // Run history
+	router.GET("/reports/:id/runs/:run_id", c.GetRun)                              // Run status
+	router.GET("/reports/:id/runs/:run_id/download", c.Download)                   // Result file
+	router.POST("/reports/:id/runs/:run_id/download-token", c.CreateDownloadToken) // Single-use download link
+}
+
+// ListReports godoc
+// @Summary List reports
+// @Description Get all report definitions with their latest run (Admin only)
+// @Tags Core/Reports
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} ReportResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /reports [get]
+func (c *ReportController) List(ctx *router.Context) error {
+	reports, err := c.Service.GetAll(ctx.Request.Context())
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: err.Error()})
+	}
+	return ctx.JSON(http.StatusOK, reports)
+}
+
+// ReportEntities godoc
+// @Summary Report entities
+// @Description Get the entities reports can be built on with their columns, the output formats and the filter operators (Admin only)
+// @Tags Core/Reports
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} EntitiesResponse
+// @Router /reports/entities [get]
+func (c *ReportController) Entities(ctx *router.Context) error {
+	return ctx.JSON(http.StatusOK, EntitiesResponse{
+		Entities:  Entities(),
+		Formats:   FormatNames(),
+		Operators: Operators(),
+	})
+}
+
+// CreateReport godoc
+// @Summary Create a report
+// @Description Create a report definition; a schedule (cron expression) runs it periodically and emails the result to the recipients (Admin only)
+// @Tags Core/Reports
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param report body CreateReportRequest true "Create report request"
+// @Success 201 {object} ReportResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /reports [post]
+func (c *ReportController) Create(ctx *router.Context) error {
+	var req CreateReportRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	report, err := c.Service.Create(ctx.Request.Context(), &req, ctx.GetUint("user_id"))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to create report")
+	}
+
+	return ctx.JSON(http.StatusCreated, report)
+}
+
+// GetReport godoc
+// @Summary Get a report
+// @Description Get a report definition by its id with its latest run (Admin only)
+// @Tags Core/Reports
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Report id"
+// @Success 200 {object} ReportResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /reports/{id} [get]
+func (c *ReportController) Get(ctx *router.Context) error {
+	id, err := parseId(ctx, "id")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	report, err := c.Service.Get(ctx.Request.Context(), id)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to fetch report")
+	}
+
+	return ctx.JSON(http.StatusOK, report)
+}
+
+// UpdateReport godoc
+// @Summary Update a report
+// @Description Update a report definition by its id; omitted fields are left unchanged (Admin only)
+// @Tags Core/Reports
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Report id"
+// @Param report body UpdateReportRequest true "Update report request"
+// @Success 200 {object} ReportResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /reports/{id} [put]
+func (c *ReportController) Update(ctx *router.Context) error {
+	id, err := parseId(ctx, "id")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	var req UpdateReportRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	report, err := c.Service.Update(ctx.Request.Context(), id, &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to update report")
+	}
+
+	return ctx.JSON(http.StatusOK, report)
+}
+
+// DeleteReport godoc
+// @Summary Delete a report
+// @Description Delete a report definition with its run history and files (Admin only)
+// @Tags Core/Reports
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param id path int true "Report id"
+// @Success 200 {object} types.SuccessResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /reports/{id} [delete]
+func (c *ReportController) Delete(ctx *router.Context) error {
+	id, err := parseId(ctx, "id")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	if err := c.Service.Delete(ctx.Request.Context(), id); err != nil {
+		return c.fail(ctx, err, "Failed to delete report")
+	}
+
+	return ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "Report deleted successfully", Success: true})
+}
+
+// RunReport godoc
+// @Summary Run a report
+// @Description Queue a run of the report; poll the run until it is completed, then download it (Admin only)
+// @Tags Core/Reports
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Report id"
+// @Param run body RunReportRequest false "Output format, defaults to the report's format"
+// @Success 202 {object} ReportRun
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /reports/{id}/run [post]
+func (c *ReportController) Run(ctx *router.Context) error {
+	id, err := parseId(ctx, "id")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	var req RunReportRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+		}
+	}
+
+	run, err := c.Service.Run(ctx.Request.Context(), id, req.Format, ctx.GetUint("user_id"))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to run report")
+	}
+
+	return ctx.JSON(http.StatusAccepted, run)
+}
+
+// ListReportRuns godoc
+// @Summary Report run history
+// @Description Get the latest runs of a report, newest first (Admin only)
+// @Tags Core/Reports
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Report id"
+// @Success 200 {array} ReportRun
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /reports/{id}/runs [get]
+func (c *ReportController) Runs(ctx *router.Context) error {
+	id, err := parseId(ctx, "id")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	runs, err := c.Service.Runs(ctx.Request.Context(), id)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to fetch report runs")
+	}
+
+	return ctx.JSON(http.StatusOK, runs)
+}
+
+// GetReportRun godoc
+// @Summary Get a report run
+// @Description Get the status of a report run (Admin only)
+// @Tags Core/Reports
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Report id"
+// @Param run_id path int true "Run id"
+// @Success 200 {object} ReportRun
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /reports/{id}/runs/{run_id} [get]
+func (c *ReportController) GetRun(ctx *router.Context) error {
+	id, err := parseId(ctx, "id")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+	runId, err := parseId(ctx, "run_id")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid run id format"})
+	}
+
+	run, err := c.Service.GetRun(ctx.Request.Context(), id, runId)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to fetch report run")
+	}
+
+	return ctx.JSON(http.StatusOK, run)
+}
+
+// DownloadReportRun godoc
+// @Summary Download a report run
+// @Description Download the result file of a completed report run (Admin only)
+// @Tags Core/Reports
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce octet-stream
+// @Param id path int true "Report id"
+// @Param run_id path int true "Run id"
+// @Success 200 {file} file
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 409 {object} types.ErrorResponse
+// @Router /reports/{id}/runs/{run_id}/download [get]
+func (c *ReportController) Download(ctx *router.Context) error {
+	id, err := parseId(ctx, "id")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+	runId, err := parseId(ctx, "run_id")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid run id format"})
+	}
+
+	run, path, contentType, err := c.Service.File(ctx.Request.Context(), id, runId)
+	if errors.Is(err, ErrRunNotReady) {
+		return ctx.JSON(http.StatusConflict, types.ErrorResponse{
+			Error:   "Report run is not completed",
+			Details: map[string]string{"status": run.Status},
+		})
+	}
+	if err != nil {
+		return c.fail(ctx, err, "Failed to fetch report run")
+	}
+
+	ctx.SetHeader("Content-Type", contentType)
+	ctx.SetHeader("Content-Disposition", fmt.Sprintf("attachment; filename=%q", run.Filename))
+	ctx.File(path)
+	return nil
+}
+
+// CreateReportDownloadToken godoc
+// @Summary Create a download link of a report run
+// @Description Create a single-use link downloading the result file of a completed report run without authentication until it expires (DOWNLOAD_TOKEN_TTL), e.g. to hand it to a browser or another person (Admin only)
+// @Tags Core/Reports
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Report id"
+// @Param run_id path int true "Run id"
+// @Success 201 {object} downloads.Issued
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 409 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /reports/{id}/runs/{run_id}/download-token [post]
+func (c *ReportController) CreateDownloadToken(ctx *router.Context) error {
+	id, err := parseId(ctx, "id")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+	runId, err := parseId(ctx, "run_id")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid run id format"})
+	}
+
+	run, issued, err := c.Service.DownloadToken(ctx.Request.Context(), id, runId, ctx.GetUint("user_id"))
+	if errors.Is(err, ErrRunNotReady) {
+		return ctx.JSON(http.StatusConflict, types.ErrorResponse{
+			Error:   "Report run is not completed",
+			Details: map[string]string{"status": run.Status},
+		})
+	}
+	if err != nil {
+		return c.fail(ctx, err, "Failed to create download link")
+	}
+	return ctx.JSON(http.StatusCreated, issued)
+}
+
+// parseId parses an id path parameter
+func parseId(ctx *router.Context, name string) (uint, error) {
+	id, err := strconv.ParseUint(ctx.Param(name), 10, 32)
+	return uint(id), err
+}
+
+// fail writes the error response of a service error
+func (c *ReportController) fail(ctx *router.Context, err error, message string) error {
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   translation.Error(ctx, err),
+			Details: translation.LocalizeValidation(ctx, validationErrors),
+		})
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Report not found"})
+	}
+	return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: message + ": " + err.Error()})
+}
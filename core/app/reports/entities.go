@@ -0,0 +1,91 @@
+package reports
+
+import (
+	"slices"
+	"sort"
+	"sync"
+)
+
+// Entity is a table reports can be built on. Only the listed columns can be selected,
+// filtered, grouped and sorted, so secrets such as password hashes never end up in a file.
+type Entity struct {
+	Name       string   `json:"name"`
+	Table      string   `json:"table"`
+	Columns    []string `json:"columns"`
+	SoftDelete bool     `json:"soft_delete"` // Skip rows with a deleted_at
+}
+
+// HasColumn reports whether the column can be used in reports
+func (e Entity) HasColumn(column string) bool {
+	return slices.Contains(e.Columns, column)
+}
+
+var (
+	entitiesMu sync.RWMutex
+	entities   = map[string]Entity{
+		"users": {
+			Name:       "users",
+			Table:      "users",
+			Columns:    []string{"id", "first_name", "last_name", "username", "email", "phone", "role_id", "locale", "timezone", "last_login", "created_at", "updated_at"},
+			SoftDelete: true,
+		},
+		"activities": {
+			Name:       "activities",
+			Table:      "activities",
+			Columns:    []string{"id", "user_id", "entity_type", "entity_id", "action", "description", "ip_address", "created_at"},
+			SoftDelete: true,
+		},
+		"notifications": {
+			Name:       "notifications",
+			Table:      "notifications",
+			Columns:    []string{"id", "user_id", "title", "type", "read", "read_at", "created_at"},
+			SoftDelete: true,
+		},
+		"media": {
+			Name:       "media",
+			Table:      "media",
+			Columns:    []string{"id", "name", "type", "folder", "tags", "author_id", "original_format", "converted_format", "created_at", "updated_at"},
+			SoftDelete: true,
+		},
+		"settings": {
+			Name:       "settings",
+			Table:      "settings",
+			Columns:    []string{"id", "setting_key", "label", "group", "type", "value_string", "value_int", "value_float", "value_bool", "is_public", "created_at", "updated_at"},
+			SoftDelete: true,
+		},
+	}
+)
+
+// RegisterEntity makes a table available to reports, e.g. from a module's Init:
+//
+//	reports.RegisterEntity(reports.Entity{Name: "products", Table: "products",
+//		Columns: []string{"id", "name", "price", "created_at"}, SoftDelete: true})
+func RegisterEntity(entity Entity) {
+	if entity.Table == "" {
+		entity.Table = entity.Name
+	}
+	entitiesMu.Lock()
+	entities[entity.Name] = entity
+	entitiesMu.Unlock()
+}
+
+// GetEntity returns a registered entity by name
+func GetEntity(name string) (Entity, bool) {
+	entitiesMu.RLock()
+	defer entitiesMu.RUnlock()
+	entity, ok := entities[name]
+	return entity, ok
+}
+
+// Entities returns the registered entities sorted by name
+func Entities() []Entity {
+	entitiesMu.RLock()
+	defer entitiesMu.RUnlock()
+
+	result := make([]Entity, 0, len(entities))
+	for _, entity := range entities {
+		result = append(result, entity)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
@@ -0,0 +1,130 @@
+package reports
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Run statuses
+const (
+	RunPending   = "pending"
+	RunRunning   = "running"
+	RunCompleted = "completed"
+	RunFailed    = "failed"
+)
+
+// Run triggers
+const (
+	TriggerManual   = "manual"
+	TriggerSchedule = "schedule"
+)
+
+// Filter narrows the rows of a report. Operators: eq, ne, gt, gte, lt, lte, like, in,
+// null and not_null; in takes a list, null and not_null no value.
+type Filter struct {
+	Column   string `json:"column"`
+	Operator string `json:"operator"`
+	Value    any    `json:"value,omitempty"`
+}
+
+// Report is a saved report definition
+type Report struct {
+	Id          uint           `json:"id" gorm:"primarykey"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	Name        string         `json:"name" gorm:"size:200;not null"`
+	Description string         `json:"description" gorm:"type:text"`
+	Entity      string         `json:"entity" gorm:"size:100;not null"`
+	Columns     []string       `json:"columns" gorm:"type:text;serializer:json"`  // Empty selects every column of the entity
+	Filters     []Filter       `json:"filters" gorm:"type:text;serializer:json"`  // All must match
+	GroupBy     []string       `json:"group_by" gorm:"type:text;serializer:json"` // Grouped reports select the group columns and a count
+	SortBy      string         `json:"sort_by" gorm:"size:100"`
+	SortOrder   string         `json:"sort_order" gorm:"size:4"`
+	Format      string         `json:"format" gorm:"size:10"`                       // Default format of runs
+	Schedule    string         `json:"schedule" gorm:"size:100"`                    // Cron expression of recurring runs, e.g. "0 8 * * 1"
+	Recipients  []string       `json:"recipients" gorm:"type:text;serializer:json"` // Emailed the result of scheduled runs
+	NextRunAt   *time.Time     `json:"next_run_at" gorm:"index"`
+	CreatedBy   uint           `json:"created_by"`
+}
+
+// TableName returns the table name for the Report model
+func (m *Report) TableName() string {
+	return "reports"
+}
+
+// ReportRun is one generation of a report and its result file
+type ReportRun struct {
+	Id          uint       `json:"id" gorm:"primarykey"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	ReportId    uint       `json:"report_id" gorm:"index;not null"`
+	Status      string     `json:"status" gorm:"size:20;index"`
+	Trigger     string     `json:"trigger" gorm:"size:20"`
+	Format      string     `json:"format" gorm:"size:10"`
+	RequestedBy uint       `json:"requested_by"`
+	StartedAt   *time.Time `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+	Rows        int        `json:"rows"`
+	Size        int64      `json:"size"`
+	Truncated   bool       `json:"truncated"` // More rows matched than REPORTS_MAX_ROWS
+	Filename    string     `json:"filename" gorm:"size:255"`
+	Path        string     `json:"-" gorm:"size:500"` // Relative to REPORTS_PATH
+	Emailed     bool       `json:"emailed"`
+	Error       string     `json:"error,omitempty" gorm:"type:text"`
+}
+
+// TableName returns the table name for the ReportRun model
+func (m *ReportRun) TableName() string {
+	return "report_runs"
+}
+
+// CreateReportRequest represents the request payload for creating a report
+type CreateReportRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Entity      string   `json:"entity"`
+	Columns     []string `json:"columns"`
+	Filters     []Filter `json:"filters"`
+	GroupBy     []string `json:"group_by"`
+	SortBy      string   `json:"sort_by"`
+	SortOrder   string   `json:"sort_order"`
+	Format      string   `json:"format"`
+	Schedule    string   `json:"schedule"`
+	Recipients  []string `json:"recipients"`
+}
+
+// UpdateReportRequest represents the request payload for updating a report; omitted fields
+// are left unchanged
+type UpdateReportRequest struct {
+	Name        *string   `json:"name,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	Entity      *string   `json:"entity,omitempty"`
+	Columns     *[]string `json:"columns,omitempty"`
+	Filters     *[]Filter `json:"filters,omitempty"`
+	GroupBy     *[]string `json:"group_by,omitempty"`
+	SortBy      *string   `json:"sort_by,omitempty"`
+	SortOrder   *string   `json:"sort_order,omitempty"`
+	Format      *string   `json:"format,omitempty"`
+	Schedule    *string   `json:"schedule,omitempty"`
+	Recipients  *[]string `json:"recipients,omitempty"`
+}
+
+// RunReportRequest represents the request payload for running a report
+type RunReportRequest struct {
+	Format string `json:"format"` // Defaults to the format of the report
+}
+
+// ReportResponse represents the API response for a report
+type ReportResponse struct {
+	*Report
+	LastRun *ReportRun `json:"last_run,omitempty"`
+}
+
+// EntitiesResponse lists what reports can be built on
+type EntitiesResponse struct {
+	Entities  []Entity `json:"entities"`
+	Formats   []string `json:"formats"`
+	Operators []string `json:"operators"`
+}
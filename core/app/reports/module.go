@@ -0,0 +1,75 @@
+package reports
+
+import (
+	"base/core/app/authorization"
+	"base/core/app/downloads"
+	"base/core/config"
+	"base/core/module"
+	"base/core/router"
+
+	"gorm.io/gorm"
+)
+
+// Module provides the reports and report_runs tables and the admin endpoints to define,
+// run and download reports. Runs are generated in the background by Runner; files are
+// kept in REPORTS_PATH, which isn't served publicly.
+type Module struct {
+	module.DefaultModule
+	DB         *gorm.DB
+	Service    *ReportService
+	Controller *ReportController
+	Runner     *Runner
+}
+
+// Init creates and initializes the reports module with all dependencies
+func Init(deps module.Dependencies) module.Module {
+	registerEvents()
+	path, workers, maxRows := config.DefaultReportsPath, config.DefaultReportsWorkers, config.DefaultReportsMaxRows
+	from := ""
+	if deps.Config != nil {
+		path, workers, maxRows = deps.Config.ReportsPath, deps.Config.ReportsWorkers, deps.Config.ReportsMaxRows
+		from = deps.Config.EmailFromAddress
+	}
+
+	if deps.PDF != nil {
+		RegisterFormat(pdfFormat(deps.PDF))
+	}
+
+	runner := NewRunner(deps.DB, deps.Logger, deps.EmailSender, path, workers, maxRows)
+	runner.From = from
+	runner.Downloads = downloads.NewService(deps)
+	service := NewReportService(deps.DB, deps.Emitter, runner, deps.Logger)
+	service.Downloads = runner.Downloads
+	controller := NewReportController(service)
+
+	return &Module{
+		DB:         deps.DB,
+		Service:    service,
+		Controller: controller,
+		Runner:     runner,
+	}
+}
+
+// Routes registers the module routes, which are restricted to admins, and starts the
+// runner now that the tables are migrated
+func (m *Module) Routes(router *router.RouterGroup) {
+	group := router.Group("", authorization.RequireAdmin(authorization.NewAuthorizationService(m.DB)))
+	m.Controller.Routes(group)
+
+	m.Runner.Start()
+}
+
+func (m *Module) Init() error {
+	return nil
+}
+
+func (m *Module) Migrate() error {
+	return m.DB.AutoMigrate(&Report{}, &ReportRun{})
+}
+
+func (m *Module) GetModels() []any {
+	return []any{
+		&Report{},
+		&ReportRun{},
+	}
+}
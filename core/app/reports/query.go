@@ -0,0 +1,194 @@
+package reports
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// operators maps filter operators to SQL
+var operators = map[string]string{
+	"eq":       "=",
+	"ne":       "<>",
+	"gt":       ">",
+	"gte":      ">=",
+	"lt":       "<",
+	"lte":      "<=",
+	"like":     "LIKE",
+	"in":       "IN",
+	"null":     "IS NULL",
+	"not_null": "IS NOT NULL",
+}
+
+// Operators returns the filter operators, sorted
+func Operators() []string {
+	names := make([]string, 0, len(operators))
+	for name := range operators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// countColumn is the column grouped reports add with the number of rows of each group
+const countColumn = "count"
+
+// Result is the data of a report run. Values are nil, string, int64, float64, bool or
+// time.Time.
+type Result struct {
+	Title       string
+	GeneratedAt time.Time
+	Columns     []string
+	Rows        [][]any
+	Truncated   bool // More rows matched than the row limit
+}
+
+// Execute runs the report query and returns at most maxRows rows
+func Execute(db *gorm.DB, report *Report, maxRows int) (*Result, error) {
+	entity, ok := GetEntity(report.Entity)
+	if !ok {
+		return nil, fmt.Errorf("unknown entity %q", report.Entity)
+	}
+
+	columns := report.Columns
+	if len(columns) == 0 {
+		columns = entity.Columns
+	}
+
+	query := db.Table(quote(db, entity.Table))
+	if entity.SoftDelete {
+		query = query.Where("deleted_at IS NULL")
+	}
+
+	for _, filter := range report.Filters {
+		condition, args, err := filterCondition(db, filter)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(condition, args...)
+	}
+
+	selected := make([]string, 0, len(columns)+1)
+	if len(report.GroupBy) > 0 {
+		// Grouped reports show the group columns and the number of rows of each group
+		columns = append(slices.Clone(report.GroupBy), countColumn)
+		groups := make([]string, 0, len(report.GroupBy))
+		for _, column := range report.GroupBy {
+			groups = append(groups, quote(db, column))
+		}
+		selected = append(selected, groups...)
+		selected = append(selected, "COUNT(*) AS "+quote(db, countColumn))
+		query = query.Group(strings.Join(groups, ", "))
+	} else {
+		for _, column := range columns {
+			selected = append(selected, quote(db, column))
+		}
+	}
+	query = query.Select(strings.Join(selected, ", "))
+
+	if report.SortBy != "" {
+		order := "ASC"
+		if strings.EqualFold(report.SortOrder, "desc") {
+			order = "DESC"
+		}
+		query = query.Order(quote(db, report.SortBy) + " " + order)
+	}
+
+	rows, err := query.Limit(maxRows + 1).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := &Result{Title: report.Name, GeneratedAt: time.Now().UTC(), Columns: columns}
+	values := make([]any, len(columns))
+	pointers := make([]any, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if len(result.Rows) == maxRows {
+			result.Truncated = true
+			break
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		row := make([]any, len(values))
+		for i, value := range values {
+			row[i] = normalize(value)
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	return result, rows.Err()
+}
+
+// filterCondition returns the SQL condition of a filter
+func filterCondition(db *gorm.DB, filter Filter) (string, []any, error) {
+	operator, ok := operators[filter.Operator]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown operator %q", filter.Operator)
+	}
+
+	column := quote(db, filter.Column)
+	switch filter.Operator {
+	case "null", "not_null":
+		return column + " " + operator, nil, nil
+	case "in":
+		values, ok := filter.Value.([]any)
+		if !ok || len(values) == 0 {
+			return "", nil, fmt.Errorf("filter %s in needs a list of values", filter.Column)
+		}
+		return column + " IN ?", []any{values}, nil
+	}
+	return column + " " + operator + " ?", []any{filter.Value}, nil
+}
+
+// quote quotes a table or column name for the database
+func quote(db *gorm.DB, name string) string {
+	var b strings.Builder
+	db.Dialector.QuoteTo(&b, name)
+	return b.String()
+}
+
+// normalize converts a scanned value to one of the Result value types
+func normalize(value any) any {
+	switch v := value.(type) {
+	case nil, string, int64, float64, bool, time.Time:
+		return v
+	case []byte:
+		return string(v)
+	}
+
+	reflected := reflect.ValueOf(value)
+	switch reflected.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflected.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(reflected.Uint())
+	case reflect.Float32, reflect.Float64:
+		return reflected.Float()
+	}
+	return fmt.Sprint(value)
+}
+
+// formatValue formats a Result value as text
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case float64:
+		return fmt.Sprintf("%g", v)
+	}
+	return fmt.Sprint(value)
+}
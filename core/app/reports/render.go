@@ -0,0 +1,121 @@
+package reports
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"base/core/pdf"
+)
+
+// DefaultFormat is the format of reports that don't set one
+const DefaultFormat = "csv"
+
+// Format renders report results into a downloadable file
+type Format struct {
+	Name        string
+	ContentType string
+	Extension   string
+	Render      func(w io.Writer, result *Result) error
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   = map[string]Format{
+		"csv": {
+			Name:        "csv",
+			ContentType: "text/csv; charset=utf-8",
+			Extension:   "csv",
+			Render:      renderCSV,
+		},
+		"xlsx": {
+			Name:        "xlsx",
+			ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+			Extension:   "xlsx",
+			Render:      renderXLSX,
+		},
+	}
+)
+
+// RegisterFormat adds an output format, e.g. PDF from a package that can render it
+func RegisterFormat(format Format) {
+	if format.Extension == "" {
+		format.Extension = format.Name
+	}
+	formatsMu.Lock()
+	formats[format.Name] = format
+	formatsMu.Unlock()
+}
+
+// GetFormat returns a registered format by name
+func GetFormat(name string) (Format, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	format, ok := formats[name]
+	return format, ok
+}
+
+// FormatNames returns the names of the registered formats, sorted
+func FormatNames() []string {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// renderCSV writes the result as CSV with a header row
+func renderCSV(w io.Writer, result *Result) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(result.Columns); err != nil {
+		return err
+	}
+
+	record := make([]string, len(result.Columns))
+	for _, row := range result.Rows {
+		for i, value := range row {
+			record[i] = formatValue(value)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// pdfFormat renders results as a PDF table with the service's page size; wide reports
+// are printed in landscape
+func pdfFormat(service *pdf.Service) Format {
+	return Format{
+		Name:        "pdf",
+		ContentType: "application/pdf",
+		Extension:   "pdf",
+		Render: func(w io.Writer, result *Result) error {
+			rows := make([][]string, len(result.Rows))
+			for i, row := range result.Rows {
+				rows[i] = make([]string, len(row))
+				for j, value := range row {
+					rows[i][j] = formatValue(value)
+				}
+			}
+
+			subtitle := fmt.Sprintf("Generated %s, %d rows", result.GeneratedAt.Format("2006-01-02 15:04 MST"), len(result.Rows))
+			if result.Truncated {
+				subtitle += " (truncated)"
+			}
+			data, err := service.Table(result.Title, subtitle, result.Columns, rows)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(data)
+			return err
+		},
+	}
+}
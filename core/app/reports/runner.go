@@ -0,0 +1,407 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"base/core/app/downloads"
+	"base/core/email"
+	"base/core/logger"
+
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+const (
+	// keepRuns is the number of runs kept per report; older runs and their files are removed
+	keepRuns = 50
+	// maxAttachmentSize is the largest result file attached to report emails
+	maxAttachmentSize = 10 << 20
+	// pollInterval is how often due scheduled reports and pending runs are picked up
+	pollInterval = time.Minute
+)
+
+// Runner generates report runs in the background with a fixed pool of workers. Runs are
+// queued in the database, so pending runs survive restarts and a run is claimed by one
+// worker only.
+type Runner struct {
+	DB          *gorm.DB
+	Logger      logger.Logger
+	EmailSender email.Sender
+	From        string // Sender address of report emails
+	Path        string // Directory of the result files
+	MaxRows     int
+	Downloads   *downloads.DownloadService // Links to files too large to attach (optional)
+
+	workers int
+	queue   chan uint
+	once    sync.Once
+	now     func() time.Time
+}
+
+func NewRunner(db *gorm.DB, logger logger.Logger, sender email.Sender, path string, workers, maxRows int) *Runner {
+	if workers < 1 {
+		workers = 1
+	}
+	return &Runner{
+		DB:          db,
+		Logger:      logger,
+		EmailSender: sender,
+		Path:        path,
+		MaxRows:     maxRows,
+		workers:     workers,
+		queue:       make(chan uint, 1000),
+		now:         time.Now,
+	}
+}
+
+// Start starts the workers and the scheduler loop; it can be called more than once
+func (r *Runner) Start() {
+	r.once.Do(func() {
+		// Runs that were generating when the server stopped won't finish
+		now := r.now()
+		if err := r.DB.Model(&ReportRun{}).
+			Where("status = ?", RunRunning).
+			Updates(map[string]any{"status": RunFailed, "error": "interrupted", "finished_at": now}).Error; err != nil {
+			r.Logger.Error("failed to recover report runs", logger.String("error", err.Error()))
+		}
+
+		for i := 0; i < r.workers; i++ {
+			go r.work()
+		}
+		go r.loop()
+	})
+}
+
+// Enqueue queues a pending run for a worker. When the queue is full the run stays
+// pending and is picked up by the next poll.
+func (r *Runner) Enqueue(runId uint) {
+	select {
+	case r.queue <- runId:
+	default:
+		r.Logger.Warn("report queue is full, run deferred", logger.Uint("run_id", runId))
+	}
+}
+
+// loop creates the runs of due scheduled reports and queues pending runs
+func (r *Runner) loop() {
+	r.poll()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		r.poll()
+	}
+}
+
+func (r *Runner) poll() {
+	r.scheduleDue()
+
+	var pending []uint
+	if err := r.DB.Model(&ReportRun{}).
+		Where("status = ?", RunPending).
+		Order("id asc").
+		Pluck("id", &pending).Error; err != nil {
+		r.Logger.Error("failed to fetch pending report runs", logger.String("error", err.Error()))
+		return
+	}
+	for _, id := range pending {
+		r.Enqueue(id)
+	}
+}
+
+// scheduleDue creates a run for every scheduled report whose next run time has passed
+// and moves it to the following time of its schedule
+func (r *Runner) scheduleDue() {
+	now := r.now()
+
+	var due []*Report
+	if err := r.DB.Where("schedule <> '' AND next_run_at <= ?", now).Find(&due).Error; err != nil {
+		r.Logger.Error("failed to fetch scheduled reports", logger.String("error", err.Error()))
+		return
+	}
+
+	for _, report := range due {
+		next := NextRun(report.Schedule, now)
+
+		// Only the instance that moves next_run_at creates the run
+		result := r.DB.Model(&Report{}).
+			Where("id = ? AND next_run_at <= ?", report.Id, now).
+			Update("next_run_at", next)
+		if result.Error != nil {
+			r.Logger.Error("failed to schedule report",
+				logger.String("error", result.Error.Error()),
+				logger.Uint("report_id", report.Id))
+			continue
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		run := &ReportRun{
+			ReportId: report.Id,
+			Status:   RunPending,
+			Trigger:  TriggerSchedule,
+			Format:   report.Format,
+		}
+		if err := r.DB.Create(run).Error; err != nil {
+			r.Logger.Error("failed to create scheduled report run",
+				logger.String("error", err.Error()),
+				logger.Uint("report_id", report.Id))
+		}
+	}
+}
+
+// NextRun returns the next time of a cron schedule after t, or nil when there is no
+// valid schedule
+func NextRun(schedule string, t time.Time) *time.Time {
+	if schedule == "" {
+		return nil
+	}
+	parsed, err := cron.ParseStandard(schedule)
+	if err != nil {
+		return nil
+	}
+	next := parsed.Next(t)
+	return &next
+}
+
+func (r *Runner) work() {
+	for id := range r.queue {
+		r.process(id)
+	}
+}
+
+// process claims a pending run and generates it
+func (r *Runner) process(runId uint) {
+	started := r.now()
+	claim := r.DB.Model(&ReportRun{}).
+		Where("id = ? AND status = ?", runId, RunPending).
+		Updates(map[string]any{"status": RunRunning, "started_at": started})
+	if claim.Error != nil {
+		r.Logger.Error("failed to claim report run",
+			logger.String("error", claim.Error.Error()),
+			logger.Uint("run_id", runId))
+		return
+	}
+	if claim.RowsAffected == 0 {
+		return // Claimed by another worker
+	}
+
+	var run ReportRun
+	if err := r.DB.First(&run, runId).Error; err != nil {
+		r.Logger.Error("failed to load report run",
+			logger.String("error", err.Error()),
+			logger.Uint("run_id", runId))
+		return
+	}
+
+	var report Report
+	err := r.DB.First(&report, run.ReportId).Error
+	if err == nil {
+		err = r.generate(&report, &run)
+	}
+
+	finished := r.now()
+	run.FinishedAt = &finished
+	if err != nil {
+		run.Status = RunFailed
+		run.Error = err.Error()
+		r.Logger.Error("report run failed",
+			logger.String("error", err.Error()),
+			logger.Uint("report_id", run.ReportId),
+			logger.Uint("run_id", run.Id))
+	} else {
+		run.Status = RunCompleted
+		r.Logger.Info("report run completed",
+			logger.Uint("report_id", run.ReportId),
+			logger.Uint("run_id", run.Id),
+			logger.Int("rows", run.Rows),
+			logger.Duration("duration", finished.Sub(started)))
+	}
+	if err := r.DB.Save(&run).Error; err != nil {
+		r.Logger.Error("failed to save report run",
+			logger.String("error", err.Error()),
+			logger.Uint("run_id", run.Id))
+	}
+
+	if err == nil && run.Trigger == TriggerSchedule && len(report.Recipients) > 0 {
+		r.deliver(&report, &run)
+	}
+	r.prune(run.ReportId)
+}
+
+// generate runs the report query and writes the result file of the run
+func (r *Runner) generate(report *Report, run *ReportRun) error {
+	format, ok := GetFormat(run.Format)
+	if !ok {
+		return fmt.Errorf("unknown format %q", run.Format)
+	}
+
+	result, err := Execute(r.DB, report, r.MaxRows)
+	if err != nil {
+		return fmt.Errorf("failed to query report: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := format.Render(&buf, result); err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+
+	relative := filepath.Join(fmt.Sprint(report.Id), fmt.Sprintf("run-%d.%s", run.Id, format.Extension))
+	target := filepath.Join(r.Path, relative)
+	if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+		return fmt.Errorf("failed to create report directory: %w", err)
+	}
+	if err := os.WriteFile(target, buf.Bytes(), 0o640); err != nil {
+		return fmt.Errorf("failed to write report file: %w", err)
+	}
+
+	run.Path = relative
+	run.Filename = fmt.Sprintf("%s-%s.%s", slugify(report.Name), result.GeneratedAt.Format("20060102-150405"), format.Extension)
+	run.Rows = len(result.Rows)
+	run.Size = int64(buf.Len())
+	run.Truncated = result.Truncated
+	return nil
+}
+
+// deliver emails the result of a scheduled run to the recipients of the report
+func (r *Runner) deliver(report *Report, run *ReportRun) {
+	if r.EmailSender == nil {
+		r.Logger.Warn("no email sender, scheduled report not delivered", logger.Uint("report_id", report.Id))
+		return
+	}
+
+	body := fmt.Sprintf("The report %q was generated with %d rows.", report.Name, run.Rows)
+	if run.Truncated {
+		body += fmt.Sprintf(" Only the first %d rows are included.", r.MaxRows)
+	}
+
+	msg := email.Message{
+		To:      report.Recipients,
+		From:    r.From,
+		Subject: "Report: " + report.Name,
+	}
+	if run.Size > maxAttachmentSize && r.Downloads != nil {
+		// Download links are single-use, so every recipient gets a message with a link of their own
+		if r.deliverLinks(report, run, msg, body) {
+			r.markEmailed(run)
+		}
+		return
+	}
+	if run.Size <= maxAttachmentSize {
+		data, err := os.ReadFile(filepath.Join(r.Path, run.Path))
+		if err != nil {
+			r.Logger.Error("failed to read report file",
+				logger.String("error", err.Error()),
+				logger.Uint("run_id", run.Id))
+			return
+		}
+		format, _ := GetFormat(run.Format)
+		msg.Attachments = []email.Attachment{{
+			Filename:    run.Filename,
+			ContentType: format.ContentType,
+			Data:        data,
+		}}
+	} else {
+		body += " The file is too large to attach; download it from the report history."
+	}
+	msg.Body = body
+
+	if err := r.EmailSender.Send(msg); err != nil {
+		r.Logger.Error("failed to email report",
+			logger.String("error", err.Error()),
+			logger.Uint("report_id", report.Id),
+			logger.Uint("run_id", run.Id))
+		return
+	}
+	r.markEmailed(run)
+}
+
+// deliverLinks emails each recipient a download link of the result file of a run. It
+// reports whether every message was sent.
+func (r *Runner) deliverLinks(report *Report, run *ReportRun, msg email.Message, body string) bool {
+	format, _ := GetFormat(run.Format)
+	file := runFile(run, filepath.Join(r.Path, run.Path), format.ContentType)
+	sent := true
+	for _, recipient := range report.Recipients {
+		issued, err := r.Downloads.Issue(context.Background(), file, report.CreatedBy)
+		if err != nil {
+			r.Logger.Error("failed to issue report download link",
+				logger.String("error", err.Error()),
+				logger.Uint("run_id", run.Id))
+			return false
+		}
+
+		msg.To = []string{recipient}
+		msg.Body = fmt.Sprintf("%s Download it once before %s: %s", body,
+			issued.ExpiresAt.UTC().Format("2006-01-02 15:04 MST"), issued.Url)
+		if err := r.EmailSender.Send(msg); err != nil {
+			r.Logger.Error("failed to email report",
+				logger.String("error", err.Error()),
+				logger.Uint("report_id", report.Id),
+				logger.Uint("run_id", run.Id))
+			sent = false
+		}
+	}
+	return sent
+}
+
+// markEmailed records that the result of a run was emailed
+func (r *Runner) markEmailed(run *ReportRun) {
+	run.Emailed = true
+	if err := r.DB.Model(run).Update("emailed", true).Error; err != nil {
+		r.Logger.Error("failed to update report run", logger.String("error", err.Error()))
+	}
+}
+
+// prune removes the runs of a report beyond the newest keepRuns, with their files
+func (r *Runner) prune(reportId uint) {
+	var old []*ReportRun
+	if err := r.DB.Where("report_id = ? AND status IN ?", reportId, []string{RunCompleted, RunFailed}).
+		Order("id desc").
+		Offset(keepRuns).
+		Limit(1000).
+		Find(&old).Error; err != nil || len(old) == 0 {
+		return
+	}
+	r.remove(old)
+}
+
+// remove deletes runs and their files
+func (r *Runner) remove(runs []*ReportRun) {
+	ids := make([]uint, 0, len(runs))
+	for _, run := range runs {
+		ids = append(ids, run.Id)
+		if run.Path != "" {
+			if err := os.Remove(filepath.Join(r.Path, run.Path)); err != nil && !os.IsNotExist(err) {
+				r.Logger.Warn("failed to remove report file",
+					logger.String("error", err.Error()),
+					logger.Uint("run_id", run.Id))
+			}
+		}
+	}
+	if len(ids) > 0 {
+		if err := r.DB.Where("id IN ?", ids).Delete(&ReportRun{}).Error; err != nil {
+			r.Logger.Error("failed to delete report runs", logger.String("error", err.Error()))
+		}
+	}
+}
+
+var nonSlugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slugify turns a report name into a file name
+func slugify(name string) string {
+	slug := strings.Trim(nonSlugPattern.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if slug == "" {
+		return "report"
+	}
+	return slug
+}
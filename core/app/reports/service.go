@@ -0,0 +1,326 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"base/core/app/downloads"
+	"base/core/emitter"
+	"base/core/logger"
+
+	"gorm.io/gorm"
+)
+
+const (
+	CreateReportEvent = "reports.create"
+	UpdateReportEvent = "reports.update"
+	DeleteReportEvent = "reports.delete"
+	RunReportEvent    = "reports.run"
+)
+
+// registerEvents adds the events of the module to the event catalog
+func registerEvents() {
+	emitter.RegisterEvents(
+		emitter.EventType{Name: CreateReportEvent, Description: "A report was created", Payload: &Report{}},
+		emitter.EventType{Name: UpdateReportEvent, Description: "A report was updated", Payload: &Report{}},
+		emitter.EventType{Name: DeleteReportEvent, Description: "A report was deleted", Payload: &Report{}},
+		emitter.EventType{Name: RunReportEvent, Description: "A report run finished or failed", Payload: &ReportRun{}},
+	)
+}
+
+// ErrRunNotReady is returned when downloading a run that hasn't completed
+var ErrRunNotReady = errors.New("report run is not completed")
+
+// ReportService manages report definitions and queues their runs
+type ReportService struct {
+	DB        *gorm.DB
+	Emitter   *emitter.Emitter
+	Logger    logger.Logger
+	Runner    *Runner
+	Downloads *downloads.DownloadService // Issues download links of result files
+}
+
+func NewReportService(db *gorm.DB, emitter *emitter.Emitter, runner *Runner, logger logger.Logger) *ReportService {
+	return &ReportService{
+		DB:      db,
+		Emitter: emitter,
+		Logger:  logger,
+		Runner:  runner,
+	}
+}
+
+// GetAll returns all reports with their latest run, newest first
+func (s *ReportService) GetAll(ctx context.Context) ([]*ReportResponse, error) {
+	var reports []*Report
+	if err := s.DB.WithContext(ctx).Order("id desc").Find(&reports).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch reports: %w", err)
+	}
+
+	responses := make([]*ReportResponse, 0, len(reports))
+	for _, report := range reports {
+		response, err := s.response(ctx, report)
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
+
+// GetById returns a report by id
+func (s *ReportService) GetById(ctx context.Context, id uint) (*Report, error) {
+	var report Report
+	if err := s.DB.WithContext(ctx).First(&report, id).Error; err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Get returns a report with its latest run
+func (s *ReportService) Get(ctx context.Context, id uint) (*ReportResponse, error) {
+	report, err := s.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.response(ctx, report)
+}
+
+// Create creates a report definition
+func (s *ReportService) Create(ctx context.Context, req *CreateReportRequest, userId uint) (*ReportResponse, error) {
+	report := &Report{
+		Name:        req.Name,
+		Description: req.Description,
+		Entity:      req.Entity,
+		Columns:     req.Columns,
+		Filters:     req.Filters,
+		GroupBy:     req.GroupBy,
+		SortBy:      req.SortBy,
+		SortOrder:   req.SortOrder,
+		Format:      req.Format,
+		Schedule:    req.Schedule,
+		Recipients:  req.Recipients,
+		CreatedBy:   userId,
+	}
+	if report.Format == "" {
+		report.Format = DefaultFormat
+	}
+
+	if err := validateReport(report); err != nil {
+		return nil, err
+	}
+	report.NextRunAt = NextRun(report.Schedule, s.Runner.now())
+
+	if err := s.DB.WithContext(ctx).Create(report).Error; err != nil {
+		s.Logger.Error("failed to create report", logger.String("error", err.Error()))
+		return nil, fmt.Errorf("failed to create report: %w", err)
+	}
+
+	s.emit(CreateReportEvent, report)
+	return s.response(ctx, report)
+}
+
+// Update updates a report definition; changing the schedule restarts it from now
+func (s *ReportService) Update(ctx context.Context, id uint, req *UpdateReportRequest) (*ReportResponse, error) {
+	report, err := s.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		report.Name = *req.Name
+	}
+	if req.Description != nil {
+		report.Description = *req.Description
+	}
+	if req.Entity != nil {
+		report.Entity = *req.Entity
+	}
+	if req.Columns != nil {
+		report.Columns = *req.Columns
+	}
+	if req.Filters != nil {
+		report.Filters = *req.Filters
+	}
+	if req.GroupBy != nil {
+		report.GroupBy = *req.GroupBy
+	}
+	if req.SortBy != nil {
+		report.SortBy = *req.SortBy
+	}
+	if req.SortOrder != nil {
+		report.SortOrder = *req.SortOrder
+	}
+	if req.Format != nil {
+		report.Format = *req.Format
+	}
+	if req.Schedule != nil && *req.Schedule != report.Schedule {
+		report.Schedule = *req.Schedule
+		report.NextRunAt = NextRun(report.Schedule, s.Runner.now())
+	}
+	if req.Recipients != nil {
+		report.Recipients = *req.Recipients
+	}
+
+	if err := validateReport(report); err != nil {
+		return nil, err
+	}
+	// Validation rejects an invalid schedule, so the next run can be computed now
+	if report.Schedule != "" && report.NextRunAt == nil {
+		report.NextRunAt = NextRun(report.Schedule, s.Runner.now())
+	}
+
+	if err := s.DB.WithContext(ctx).Save(report).Error; err != nil {
+		s.Logger.Error("failed to update report",
+			logger.String("error", err.Error()),
+			logger.Uint("id", id))
+		return nil, fmt.Errorf("failed to update report: %w", err)
+	}
+
+	s.emit(UpdateReportEvent, report)
+	return s.response(ctx, report)
+}
+
+// Delete deletes a report with its runs and their files
+func (s *ReportService) Delete(ctx context.Context, id uint) error {
+	report, err := s.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.DB.WithContext(ctx).Delete(report).Error; err != nil {
+		s.Logger.Error("failed to delete report",
+			logger.String("error", err.Error()),
+			logger.Uint("id", id))
+		return fmt.Errorf("failed to delete report: %w", err)
+	}
+
+	var runs []*ReportRun
+	if err := s.DB.WithContext(ctx).Where("report_id = ?", id).Find(&runs).Error; err != nil {
+		return fmt.Errorf("failed to fetch report runs: %w", err)
+	}
+	s.Runner.remove(runs)
+	os.Remove(filepath.Join(s.Runner.Path, fmt.Sprint(id))) // The directory, once empty
+
+	s.emit(DeleteReportEvent, report)
+	return nil
+}
+
+// Run queues a run of the report in the given format, or the report's format when empty
+func (s *ReportService) Run(ctx context.Context, id uint, format string, userId uint) (*ReportRun, error) {
+	report, err := s.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == "" {
+		format = report.Format
+	}
+	if err := validateFormat(format); err != nil {
+		return nil, err
+	}
+	// The entity or its columns may have changed since the report was saved
+	if err := validateReport(report); err != nil {
+		return nil, err
+	}
+
+	run := &ReportRun{
+		ReportId:    report.Id,
+		Status:      RunPending,
+		Trigger:     TriggerManual,
+		Format:      format,
+		RequestedBy: userId,
+	}
+	if err := s.DB.WithContext(ctx).Create(run).Error; err != nil {
+		s.Logger.Error("failed to create report run",
+			logger.String("error", err.Error()),
+			logger.Uint("report_id", id))
+		return nil, fmt.Errorf("failed to create report run: %w", err)
+	}
+
+	s.Runner.Enqueue(run.Id)
+	s.emit(RunReportEvent, run)
+	return run, nil
+}
+
+// Runs returns the run history of a report, newest first
+func (s *ReportService) Runs(ctx context.Context, reportId uint) ([]*ReportRun, error) {
+	if _, err := s.GetById(ctx, reportId); err != nil {
+		return nil, err
+	}
+
+	var runs []*ReportRun
+	if err := s.DB.WithContext(ctx).Where("report_id = ?", reportId).Order("id desc").Limit(keepRuns).Find(&runs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch report runs: %w", err)
+	}
+	return runs, nil
+}
+
+// GetRun returns a run of a report
+func (s *ReportService) GetRun(ctx context.Context, reportId, runId uint) (*ReportRun, error) {
+	var run ReportRun
+	if err := s.DB.WithContext(ctx).Where("report_id = ?", reportId).First(&run, runId).Error; err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// File returns the run, the path of its result file and its content type
+func (s *ReportService) File(ctx context.Context, reportId, runId uint) (*ReportRun, string, string, error) {
+	run, err := s.GetRun(ctx, reportId, runId)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if run.Status != RunCompleted || run.Path == "" {
+		return run, "", "", ErrRunNotReady
+	}
+
+	contentType := "application/octet-stream"
+	if format, ok := GetFormat(run.Format); ok {
+		contentType = format.ContentType
+	}
+	return run, filepath.Join(s.Runner.Path, run.Path), contentType, nil
+}
+
+// DownloadToken returns a single-use, expiring link to the result file of a completed run
+func (s *ReportService) DownloadToken(ctx context.Context, reportId, runId, userId uint) (*ReportRun, *downloads.Issued, error) {
+	run, path, contentType, err := s.File(ctx, reportId, runId)
+	if err != nil {
+		return run, nil, err
+	}
+	issued, err := s.Downloads.Issue(ctx, runFile(run, path, contentType), userId)
+	return run, issued, err
+}
+
+// runFile is the download of the result file of a run
+func runFile(run *ReportRun, path, contentType string) downloads.File {
+	return downloads.File{
+		Source:      "reports",
+		SourceId:    run.Id,
+		Path:        path,
+		Filename:    run.Filename,
+		ContentType: contentType,
+	}
+}
+
+// response adds the latest run to a report
+func (s *ReportService) response(ctx context.Context, report *Report) (*ReportResponse, error) {
+	response := &ReportResponse{Report: report}
+
+	var runs []*ReportRun
+	if err := s.DB.WithContext(ctx).Where("report_id = ?", report.Id).Order("id desc").Limit(1).Find(&runs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch report runs: %w", err)
+	}
+	if len(runs) > 0 {
+		response.LastRun = runs[0]
+	}
+	return response, nil
+}
+
+func (s *ReportService) emit(event string, data any) {
+	if s.Emitter != nil {
+		s.Emitter.Emit(event, data)
+	}
+}
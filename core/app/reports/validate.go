@@ -0,0 +1,122 @@
+package reports
+
+import (
+	"fmt"
+	"net/mail"
+	"slices"
+	"strings"
+
+	"base/core/validator"
+
+	"github.com/robfig/cron/v3"
+)
+
+// validateReport checks a report definition against its entity, the formats and the
+// filter operators
+func validateReport(report *Report) error {
+	var errs validator.ValidationErrors
+	add := func(field, tag, value, param, message string) {
+		errs = append(errs, validator.ValidationError{
+			Field:   field,
+			Tag:     tag,
+			Value:   value,
+			Param:   param,
+			Message: message,
+		})
+	}
+
+	if strings.TrimSpace(report.Name) == "" {
+		add("name", "required", "", "", "name is required")
+	}
+
+	entity, ok := GetEntity(report.Entity)
+	if !ok {
+		var names []string
+		for _, e := range Entities() {
+			names = append(names, e.Name)
+		}
+		add("entity", "oneof", report.Entity, strings.Join(names, " "),
+			"entity must be one of: "+strings.Join(names, " "))
+		return errs // The columns can't be checked without the entity
+	}
+
+	columns := strings.Join(entity.Columns, " ")
+	column := func(field, value string) {
+		if !entity.HasColumn(value) {
+			add(field, "oneof", value, columns, field+" must be one of: "+columns)
+		}
+	}
+
+	for i, c := range report.Columns {
+		column(fmt.Sprintf("columns[%d]", i), c)
+	}
+	for i, c := range report.GroupBy {
+		column(fmt.Sprintf("group_by[%d]", i), c)
+	}
+	for i, filter := range report.Filters {
+		field := fmt.Sprintf("filters[%d]", i)
+		column(field+".column", filter.Column)
+		if _, ok := operators[filter.Operator]; !ok {
+			ops := strings.Join(Operators(), " ")
+			add(field+".operator", "oneof", filter.Operator, ops, field+".operator must be one of: "+ops)
+			continue
+		}
+		if filter.Operator == "in" {
+			if values, ok := filter.Value.([]any); !ok || len(values) == 0 {
+				add(field+".value", "required", fmt.Sprint(filter.Value), "", field+".value is required")
+			}
+		}
+	}
+
+	if report.SortBy != "" {
+		if len(report.GroupBy) > 0 {
+			// Grouped reports can be sorted by a group column or the count
+			if report.SortBy != countColumn && !slices.Contains(report.GroupBy, report.SortBy) {
+				allowed := strings.Join(append(slices.Clone(report.GroupBy), countColumn), " ")
+				add("sort_by", "oneof", report.SortBy, allowed, "sort_by must be one of: "+allowed)
+			}
+		} else {
+			column("sort_by", report.SortBy)
+		}
+	}
+	if report.SortOrder != "" && report.SortOrder != "asc" && report.SortOrder != "desc" {
+		add("sort_order", "oneof", report.SortOrder, "asc desc", "sort_order must be one of: asc desc")
+	}
+
+	if _, ok := GetFormat(report.Format); !ok {
+		formats := strings.Join(FormatNames(), " ")
+		add("format", "oneof", report.Format, formats, "format must be one of: "+formats)
+	}
+
+	if report.Schedule != "" {
+		if _, err := cron.ParseStandard(report.Schedule); err != nil {
+			add("schedule", "cron", report.Schedule, "", "schedule must be a cron expression such as 0 8 * * 1")
+		}
+	}
+	for i, recipient := range report.Recipients {
+		if _, err := mail.ParseAddress(recipient); err != nil {
+			field := fmt.Sprintf("recipients[%d]", i)
+			add(field, "email", recipient, "", field+" must be a valid email address")
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateFormat checks the format of a run
+func validateFormat(format string) error {
+	if _, ok := GetFormat(format); ok {
+		return nil
+	}
+	formats := strings.Join(FormatNames(), " ")
+	return validator.ValidationErrors{{
+		Field:   "format",
+		Tag:     "oneof",
+		Value:   format,
+		Param:   formats,
+		Message: "format must be one of: " + formats,
+	}}
+}
@@ -0,0 +1,169 @@
+package requestlogs
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"base/core/database"
+	"base/core/router"
+	"base/core/types"
+)
+
+type RequestLogController struct {
+	Service *RequestLogService
+}
+
+func NewRequestLogController(service *RequestLogService) *RequestLogController {
+	return &RequestLogController{
+		Service: service,
+	}
+}
+
+func (c *RequestLogController) Routes(router *router.RouterGroup) {
+	router.GET("/logs/requests", c.List)    // Paginated, filtered list
+	router.GET("/logs/requests/:id", c.Get) // Get by ID, including the body
+}
+
+// ListRequestLogs godoc
+// @Summary List stored requests
+// @Description Get the stored HTTP requests, newest first (admin only)
+// @Tags Core/Logs
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of items per page (at most 100)"
+// @Param method query string false "HTTP method"
+// @Param path query string false "Path prefix, * is a wildcard"
+// @Param status query string false "Status code (404) or class (4xx)"
+// @Param user_id query int false "User id"
+// @Param ip query string false "Client IP address"
+// @Param request_id query string false "Request id"
+// @Param min_duration query number false "Minimum duration in milliseconds"
+// @Param from query string false "Start time (RFC3339)"
+// @Param to query string false "End time (RFC3339)"
+// @Param count query string false "How to count the total (exact, estimated, none); defaults to DB_COUNT_MODE"
+// @Success 200 {object} types.PaginatedResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 403 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /logs/requests [get]
+func (c *RequestLogController) List(ctx *router.Context) error {
+	params, err := ctx.ListParams()
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+	page, limit := 1, 50
+	if params.Page != nil {
+		page = *params.Page
+	}
+	if params.Limit != nil {
+		limit = *params.Limit
+	}
+
+	filter, err := parseFilter(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	paginatedResponse, err := c.Service.GetAll(ctx.Request.Context(), filter, page, limit)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch request logs: " + err.Error()})
+	}
+
+	return ctx.JSON(http.StatusOK, paginatedResponse)
+}
+
+// GetRequestLog godoc
+// @Summary Get a stored request
+// @Description Get a stored HTTP request by its id, including the request body (admin only)
+// @Tags Core/Logs
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Request log id"
+// @Success 200 {object} RequestLog
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /logs/requests/{id} [get]
+func (c *RequestLogController) Get(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	item, err := c.Service.GetById(ctx.Request.Context(), uint(id))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Item not found"})
+	}
+
+	return ctx.JSON(http.StatusOK, item)
+}
+
+// parseFilter reads the list filters from the query string
+func parseFilter(ctx *router.Context) (*RequestLogFilter, error) {
+	filter := &RequestLogFilter{
+		Method:    ctx.Query("method"),
+		Path:      ctx.Query("path"),
+		IpAddress: ctx.Query("ip"),
+		RequestId: ctx.Query("request_id"),
+	}
+
+	if status := strings.ToLower(ctx.Query("status")); status != "" {
+		if len(status) == 3 && strings.HasSuffix(status, "xx") && status[0] >= '1' && status[0] <= '5' {
+			filter.StatusClass = int(status[0] - '0')
+		} else if code, err := strconv.Atoi(status); err == nil && code >= 100 && code <= 599 {
+			filter.Status = code
+		} else {
+			return nil, &filterError{"status", "use a status code (404) or class (4xx)"}
+		}
+	}
+
+	if userId := ctx.Query("user_id"); userId != "" {
+		id, err := strconv.ParseUint(userId, 10, 32)
+		if err != nil {
+			return nil, &filterError{"user_id", "must be a number"}
+		}
+		filter.UserId = uint(id)
+	}
+
+	if minDuration := ctx.Query("min_duration"); minDuration != "" {
+		ms, err := strconv.ParseFloat(minDuration, 64)
+		if err != nil || ms < 0 {
+			return nil, &filterError{"min_duration", "must be a number of milliseconds"}
+		}
+		filter.MinDuration = ms
+	}
+
+	for name, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := ctx.Query(name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return nil, &filterError{name, "must be an RFC3339 time such as 2024-01-02T15:04:05Z"}
+			}
+			*target = &t
+		}
+	}
+
+	count, err := database.Counts.Mode(ctx.Query("count"))
+	if err != nil {
+		return nil, &filterError{"count", "use exact, estimated or none"}
+	}
+	filter.Count = count
+
+	return filter, nil
+}
+
+// filterError reports an invalid query parameter
+type filterError struct {
+	param   string
+	message string
+}
+
+func (e *filterError) Error() string {
+	return "Invalid " + e.param + ": " + e.message
+}
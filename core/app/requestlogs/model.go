@@ -0,0 +1,99 @@
+package requestlogs
+
+import (
+	"time"
+
+	"base/core/database"
+)
+
+// RequestLog is a stored HTTP request, kept for debugging client issues
+type RequestLog struct {
+	Id        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"` // Indexed for sorting and retention cleanup
+
+	RequestId string `json:"request_id" gorm:"index"`
+	Method    string `json:"method" gorm:"size:10"`
+	Path      string `json:"path" gorm:"index"`
+	Query     string `json:"query"`
+	Status    int    `json:"status" gorm:"index"`
+
+	// Duration in milliseconds
+	Duration float64 `json:"duration"`
+
+	// Authenticated user, 0 for anonymous requests
+	UserId uint `json:"user_id" gorm:"index"`
+
+	IpAddress string `json:"ip_address" gorm:"index"`
+	UserAgent string `json:"user_agent"`
+
+	// Request body, truncated to REQUEST_LOG_BODY_LIMIT with sensitive JSON fields redacted
+	Body      string `json:"body,omitempty" gorm:"type:text"`
+	Truncated bool   `json:"truncated"`
+	Error     string `json:"error,omitempty"`
+}
+
+// TableName returns the table name for the RequestLog model
+func (m *RequestLog) TableName() string {
+	return "request_logs"
+}
+
+// GetId returns the Id of the model
+func (m *RequestLog) GetId() uint {
+	return m.Id
+}
+
+// GetModelName returns the model name
+func (m *RequestLog) GetModelName() string {
+	return "request_log"
+}
+
+// RequestLogListResponse represents the list view response for RequestLog (without the body)
+type RequestLogListResponse struct {
+	Id        uint      `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	RequestId string    `json:"request_id"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Query     string    `json:"query"`
+	Status    int       `json:"status"`
+	Duration  float64   `json:"duration"`
+	UserId    uint      `json:"user_id"`
+	IpAddress string    `json:"ip_address"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// ToListResponse converts the model to a list response
+func (m *RequestLog) ToListResponse() *RequestLogListResponse {
+	if m == nil {
+		return nil
+	}
+	return &RequestLogListResponse{
+		Id:        m.Id,
+		CreatedAt: m.CreatedAt,
+		RequestId: m.RequestId,
+		Method:    m.Method,
+		Path:      m.Path,
+		Query:     m.Query,
+		Status:    m.Status,
+		Duration:  m.Duration,
+		UserId:    m.UserId,
+		IpAddress: m.IpAddress,
+		Error:     m.Error,
+	}
+}
+
+// RequestLogFilter holds the list filters. Zero values are ignored.
+type RequestLogFilter struct {
+	Method      string
+	Path        string // prefix match; "*" anywhere is a wildcard
+	Status      int    // exact status code
+	StatusClass int    // 2, 3, 4 or 5 for 2xx..5xx
+	UserId      uint
+	IpAddress   string
+	RequestId   string
+	MinDuration float64 // milliseconds
+	From        *time.Time
+	To          *time.Time
+
+	Count database.CountMode // how the total is computed
+}
@@ -0,0 +1,50 @@
+package requestlogs
+
+import (
+	"base/core/app/authorization"
+	"base/core/module"
+	"base/core/router"
+
+	"gorm.io/gorm"
+)
+
+// Module provides the request_logs table and the admin viewer. Requests are stored by
+// the Recorder middleware, which is installed globally when REQUEST_LOG_ENABLED is set.
+type Module struct {
+	module.DefaultModule
+	DB         *gorm.DB
+	Service    *RequestLogService
+	Controller *RequestLogController
+}
+
+// Init creates and initializes the request log module with all dependencies
+func Init(deps module.Dependencies) module.Module {
+	service := NewRequestLogService(deps.DB, deps.Logger)
+	controller := NewRequestLogController(service)
+
+	return &Module{
+		DB:         deps.DB,
+		Service:    service,
+		Controller: controller,
+	}
+}
+
+// Routes registers the module routes; they are restricted to admins
+func (m *Module) Routes(router *router.RouterGroup) {
+	group := router.Group("", authorization.RequireAdmin(authorization.NewAuthorizationService(m.DB)))
+	m.Controller.Routes(group)
+}
+
+func (m *Module) Init() error {
+	return nil
+}
+
+func (m *Module) Migrate() error {
+	return m.DB.AutoMigrate(&RequestLog{})
+}
+
+func (m *Module) GetModels() []any {
+	return []any{
+		&RequestLog{},
+	}
+}
@@ -0,0 +1,284 @@
+package requestlogs
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"base/core/app/authorization"
+	"base/core/logger"
+	"base/core/router"
+
+	"gorm.io/gorm"
+)
+
+// RecorderOptions configures which requests are stored and for how long
+type RecorderOptions struct {
+	// SampleRate is the percentage (0-100) of successful requests to store. Requests
+	// answered with a 4xx or 5xx status are always stored.
+	SampleRate int
+
+	// Retention is how long entries are kept; 0 keeps them forever
+	Retention time.Duration
+
+	// BodyLimit is the number of request body bytes to store; 0 disables body capture
+	BodyLimit int
+
+	// SkipPaths lists paths that are never stored ("/health/*" matches everything below /health)
+	SkipPaths []string
+
+	// BufferSize is the number of entries waiting to be written; more are dropped
+	BufferSize int
+}
+
+const (
+	recorderBatchSize     = 100
+	recorderFlushInterval = 2 * time.Second
+	cleanupInterval       = time.Hour
+)
+
+// sensitiveFields are redacted from stored JSON bodies
+var sensitiveFields = []string{"password", "token", "secret", "api_key", "apikey", "authorization", "credit_card", "card_number", "cvv"}
+
+// Recorder stores requests in the request_logs table. Entries are written in batches
+// by a background goroutine, so storing them doesn't slow down requests.
+type Recorder struct {
+	db      *gorm.DB
+	options RecorderOptions
+	logger  logger.Logger
+	entries chan *RequestLog
+	dropped atomic.Uint64
+}
+
+// NewRecorder creates a recorder and starts its writer and retention cleanup
+func NewRecorder(db *gorm.DB, options RecorderOptions, log logger.Logger) *Recorder {
+	if options.BufferSize <= 0 {
+		options.BufferSize = 1000
+	}
+
+	r := &Recorder{
+		db:      db,
+		options: options,
+		logger:  log,
+		entries: make(chan *RequestLog, options.BufferSize),
+	}
+	go r.run()
+	return r
+}
+
+// Middleware records every request that isn't skipped or sampled out
+func (r *Recorder) Middleware() router.MiddlewareFunc {
+	return func(next router.HandlerFunc) router.HandlerFunc {
+		return func(c *router.Context) error {
+			path := c.Request.URL.Path
+			if r.skip(path) {
+				return next(c)
+			}
+
+			body, truncated := r.captureBody(c)
+			start := time.Now()
+			err := next(c)
+
+			status := c.Writer.Status()
+			if status < 400 && !r.sampled() {
+				return err
+			}
+
+			entry := &RequestLog{
+				CreatedAt: start,
+				Method:    c.Request.Method,
+				Path:      path,
+				Query:     c.Request.URL.RawQuery,
+				Status:    status,
+				Duration:  float64(time.Since(start).Microseconds()) / 1000,
+				IpAddress: c.ClientIP(),
+				UserAgent: c.Request.UserAgent(),
+				Body:      body,
+				Truncated: truncated,
+			}
+			if value, exists := c.Get("request_id"); exists {
+				entry.RequestId, _ = value.(string)
+			}
+			if userId, uerr := authorization.GetUserIdFromContext(c); uerr == nil {
+				entry.UserId = uint(userId)
+			}
+			if err != nil {
+				entry.Error = err.Error()
+			}
+
+			select {
+			case r.entries <- entry:
+			default:
+				r.dropped.Add(1)
+			}
+			return err
+		}
+	}
+}
+
+// Dropped returns the number of entries dropped because the buffer was full
+func (r *Recorder) Dropped() uint64 {
+	return r.dropped.Load()
+}
+
+// skip reports whether the path is excluded from the request log
+func (r *Recorder) skip(path string) bool {
+	for _, pattern := range r.options.SkipPaths {
+		if pattern == path || (strings.HasSuffix(pattern, "/*") && strings.HasPrefix(path, strings.TrimSuffix(pattern, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// sampled reports whether a successful request should be stored
+func (r *Recorder) sampled() bool {
+	if r.options.SampleRate >= 100 {
+		return true
+	}
+	return rand.IntN(100) < r.options.SampleRate
+}
+
+// captureBody reads up to BodyLimit bytes of the request body and puts them back, so
+// handlers still see the full body. Multipart uploads are not captured.
+func (r *Recorder) captureBody(c *router.Context) (string, bool) {
+	if r.options.BodyLimit <= 0 || c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return "", false
+	}
+	if strings.HasPrefix(c.Request.Header.Get("Content-Type"), "multipart/") {
+		return "[multipart body not stored]", false
+	}
+
+	prefix, err := io.ReadAll(io.LimitReader(c.Request.Body, int64(r.options.BodyLimit)+1))
+	c.Request.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(prefix), c.Request.Body), Closer: c.Request.Body}
+	if err != nil {
+		return "", false
+	}
+
+	truncated := len(prefix) > r.options.BodyLimit
+	if truncated {
+		prefix = prefix[:r.options.BodyLimit]
+	}
+	return redact(prefix, c.Request.Header.Get("Content-Type"), truncated), truncated
+}
+
+// readCloser reads the captured prefix and the rest of the body, and closes the original body
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// sensitiveJSONPattern matches sensitive "key": "value" pairs in bodies that can't be parsed
+var sensitiveJSONPattern = regexp.MustCompile(`(?i)("[^"]*(?:` + strings.Join(sensitiveFields, "|") + `)[^"]*"\s*:\s*)"(?:[^"\\]|\\.)*"?`)
+
+// redact replaces the values of sensitive fields in JSON and form bodies. Truncated JSON
+// can't be parsed, so sensitive string values are replaced by pattern instead.
+func redact(body []byte, contentType string, truncated bool) string {
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") && !truncated {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return string(body)
+		}
+		for key := range values {
+			if isSensitive(key) {
+				values.Set(key, "[redacted]")
+			}
+		}
+		return values.Encode()
+	}
+
+	var value any
+	if truncated || json.Unmarshal(body, &value) != nil {
+		return sensitiveJSONPattern.ReplaceAllString(string(body), `$1"[redacted]"`)
+	}
+	redacted, err := json.Marshal(redactValue(value))
+	if err != nil {
+		return string(body)
+	}
+	return string(redacted)
+}
+
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if isSensitive(key) {
+				v[key] = "[redacted]"
+			} else {
+				v[key] = redactValue(item)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, field := range sensitiveFields {
+		if strings.Contains(key, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// run writes entries in batches and removes entries older than the retention period
+func (r *Recorder) run() {
+	flush := time.NewTicker(recorderFlushInterval)
+	defer flush.Stop()
+	cleanup := time.NewTicker(cleanupInterval)
+	defer cleanup.Stop()
+
+	batch := make([]*RequestLog, 0, recorderBatchSize)
+	write := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := r.db.CreateInBatches(batch, recorderBatchSize).Error; err != nil {
+			r.logger.Error("Failed to store request logs",
+				logger.Int("count", len(batch)),
+				logger.String("error", err.Error()))
+		}
+		batch = make([]*RequestLog, 0, recorderBatchSize)
+	}
+
+	for {
+		select {
+		case entry := <-r.entries:
+			batch = append(batch, entry)
+			if len(batch) >= recorderBatchSize {
+				write()
+			}
+		case <-flush.C:
+			write()
+		case <-cleanup.C:
+			r.cleanup()
+		}
+	}
+}
+
+// cleanup deletes entries older than the retention period
+func (r *Recorder) cleanup() {
+	if r.options.Retention <= 0 {
+		return
+	}
+	result := r.db.Where("created_at < ?", time.Now().Add(-r.options.Retention)).Delete(&RequestLog{})
+	if result.Error != nil {
+		r.logger.Warn("Failed to remove old request logs", logger.String("error", result.Error.Error()))
+		return
+	}
+	if result.RowsAffected > 0 {
+		r.logger.Info("Removed old request logs", logger.Int64("count", result.RowsAffected))
+	}
+}
@@ -0,0 +1,128 @@
+package requestlogs
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	"base/core/database"
+	"base/core/logger"
+	"base/core/types"
+
+	"gorm.io/gorm"
+)
+
+type RequestLogService struct {
+	DB     *gorm.DB
+	Logger logger.Logger
+}
+
+func NewRequestLogService(db *gorm.DB, logger logger.Logger) *RequestLogService {
+	return &RequestLogService{
+		DB:     db,
+		Logger: logger,
+	}
+}
+
+// applyFilter adds the filter conditions to the query
+func (s *RequestLogService) applyFilter(query *gorm.DB, filter *RequestLogFilter) *gorm.DB {
+	if filter.Method != "" {
+		query = query.Where("method = ?", strings.ToUpper(filter.Method))
+	}
+	if filter.Path != "" {
+		pattern := strings.ReplaceAll(filter.Path, "*", "%")
+		if !strings.HasSuffix(pattern, "%") {
+			pattern += "%"
+		}
+		query = query.Where("path LIKE ?", pattern)
+	}
+	if filter.Status != 0 {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.StatusClass != 0 {
+		query = query.Where("status >= ? AND status < ?", filter.StatusClass*100, (filter.StatusClass+1)*100)
+	}
+	if filter.UserId != 0 {
+		query = query.Where("user_id = ?", filter.UserId)
+	}
+	if filter.IpAddress != "" {
+		query = query.Where("ip_address = ?", filter.IpAddress)
+	}
+	if filter.RequestId != "" {
+		query = query.Where("request_id = ?", filter.RequestId)
+	}
+	if filter.MinDuration > 0 {
+		query = query.Where("duration >= ?", filter.MinDuration)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at <= ?", *filter.To)
+	}
+	return query
+}
+
+// GetAll returns the stored requests matching the filter, newest first
+func (s *RequestLogService) GetAll(ctx context.Context, filter *RequestLogFilter, page int, limit int) (*types.PaginatedResponse, error) {
+	var items []*RequestLog
+
+	query := s.applyFilter(s.DB.WithContext(ctx).Model(&RequestLog{}), filter)
+
+	// Get total count
+	total, exact, err := database.Counts.Count(query, filter.Count)
+	if err != nil {
+		s.Logger.Error("failed to count request logs",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	// Without a count one more row tells whether there is a next page
+	offset := (page - 1) * limit
+	rows := limit
+	if filter.Count == database.CountNone {
+		rows++
+	}
+	if err := query.Order("id desc").Offset(offset).Limit(rows).Find(&items).Error; err != nil {
+		s.Logger.Error("failed to get request logs",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	pagination := types.Pagination{Page: page, PageSize: limit}
+	if filter.Count == database.CountNone {
+		hasMore := len(items) > limit
+		if hasMore {
+			items = items[:limit]
+		}
+		pagination.HasMore = &hasMore
+	} else {
+		// Calculate total pages
+		pagination.Total = int(total)
+		pagination.TotalPages = int(math.Ceil(float64(total) / float64(limit)))
+		if pagination.TotalPages == 0 {
+			pagination.TotalPages = 1
+		}
+		pagination.Estimated = !exact
+	}
+
+	// Convert to response type
+	responses := make([]*RequestLogListResponse, len(items))
+	for i, item := range items {
+		responses[i] = item.ToListResponse()
+	}
+
+	return &types.PaginatedResponse{
+		Data:       responses,
+		Pagination: pagination,
+	}, nil
+}
+
+// GetById returns a stored request including its body
+func (s *RequestLogService) GetById(ctx context.Context, id uint) (*RequestLog, error) {
+	item := &RequestLog{}
+	if err := s.DB.WithContext(ctx).First(item, id).Error; err != nil {
+		return nil, err
+	}
+	return item, nil
+}
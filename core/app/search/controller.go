@@ -59,12 +59,12 @@ func (c *SearchController) Search(ctx *router.Context) error {
 	limit := 10
 	if limitStr != "" {
 		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
-			limit = parsedLimit
+			limit = min(parsedLimit, router.MaxListLimit)
 		}
 	}
 
 	// Perform search
-	response, err := c.Service.GlobalSearch(query, modules, limit)
+	response, err := c.Service.GlobalSearch(ctx.Request.Context(), query, modules, limit)
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Search failed: " + err.Error()})
 	}
@@ -1,6 +1,7 @@
 package search
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -30,7 +31,7 @@ func NewSearchService(db *gorm.DB, emitter *emitter.Emitter, storage *storage.Ac
 }
 
 // GlobalSearch performs search across multiple modules using the registry
-func (s *SearchService) GlobalSearch(query, modules string, limit int) (*SearchResponse, error) {
+func (s *SearchService) GlobalSearch(ctx context.Context, query, modules string, limit int) (*SearchResponse, error) {
 	response := &SearchResponse{
 		Query:   query,
 		Results: make(map[string][]SearchResult),
@@ -65,7 +66,7 @@ func (s *SearchService) GlobalSearch(query, modules string, limit int) (*SearchR
 			continue
 		}
 
-		results, err := s.searchWithConfig(config, query, limit)
+		results, err := s.searchWithConfig(ctx, config, query, limit)
 		if err != nil {
 			s.Logger.Error("Failed to search module",
 				logger.String("module", moduleName),
@@ -84,18 +85,18 @@ func (s *SearchService) GlobalSearch(query, modules string, limit int) (*SearchR
 }
 
 // searchWithConfig searches using a registered search config
-func (s *SearchService) searchWithConfig(config *SearchConfig, query string, limit int) ([]SearchResult, error) {
+func (s *SearchService) searchWithConfig(ctx context.Context, config *SearchConfig, query string, limit int) ([]SearchResult, error) {
 	// If custom search function is provided, use it
 	if config.CustomSearchFunc != nil {
-		return config.CustomSearchFunc(s.DB, query, limit)
+		return config.CustomSearchFunc(s.DB.WithContext(ctx), query, limit)
 	}
 
 	// Default search: build dynamic LIKE query for all fields
-	return s.defaultSearch(config, query, limit)
+	return s.defaultSearch(ctx, config, query, limit)
 }
 
 // defaultSearch performs a default LIKE search across configured fields
-func (s *SearchService) defaultSearch(config *SearchConfig, query string, limit int) ([]SearchResult, error) {
+func (s *SearchService) defaultSearch(ctx context.Context, config *SearchConfig, query string, limit int) ([]SearchResult, error) {
 	if len(config.Fields) == 0 {
 		s.Logger.Warn("No search fields configured for module",
 			logger.String("module", config.Name))
@@ -114,7 +115,7 @@ func (s *SearchService) defaultSearch(config *SearchConfig, query string, limit
 	whereClause := strings.Join(whereClauses, " OR ")
 
 	// Execute query - get all columns as we'll use the model's ToSearchResult method
-	rows, err := s.DB.Table(config.Table).
+	rows, err := s.DB.WithContext(ctx).Table(config.Table).
 		Where("deleted_at IS NULL").
 		Where(whereClause, whereArgs...).
 		Limit(limit).
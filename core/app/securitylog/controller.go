@@ -0,0 +1,114 @@
+package securitylog
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"base/core/router"
+	"base/core/types"
+)
+
+type SecurityLogController struct {
+	Service *SecurityLogService
+}
+
+func NewSecurityLogController(service *SecurityLogService) *SecurityLogController {
+	return &SecurityLogController{
+		Service: service,
+	}
+}
+
+func (c *SecurityLogController) Routes(router *router.RouterGroup) {
+	router.GET("/logs/security", c.List)    // Paginated, filtered list
+	router.GET("/logs/security/:id", c.Get) // Get by ID
+}
+
+// ListSecurityEvents godoc
+// @Summary List security events
+// @Description Get the security events (logins, password and role changes, permission grants), newest first (admin only)
+// @Tags Core/Logs
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of items per page (at most 100)"
+// @Param type query string false "Event type, e.g. login_failed"
+// @Param user_id query int false "User the events are about or by"
+// @Param ip query string false "Client IP address"
+// @Param from query string false "Start time (RFC3339)"
+// @Param to query string false "End time (RFC3339)"
+// @Success 200 {object} types.PaginatedResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 403 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /logs/security [get]
+func (c *SecurityLogController) List(ctx *router.Context) error {
+	params, err := ctx.ListParams()
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+	page, limit := 1, 50
+	if params.Page != nil {
+		page = *params.Page
+	}
+	if params.Limit != nil {
+		limit = *params.Limit
+	}
+
+	filter := &SecurityEventFilter{
+		Type:      ctx.Query("type"),
+		IpAddress: ctx.Query("ip"),
+	}
+	if userId := ctx.Query("user_id"); userId != "" {
+		id, err := strconv.ParseUint(userId, 10, 32)
+		if err != nil {
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid user_id: must be a number"})
+		}
+		filter.UserId = uint(id)
+	}
+	for name, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := ctx.Query(name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid " + name + ": must be an RFC3339 time such as 2024-01-02T15:04:05Z"})
+			}
+			*target = &t
+		}
+	}
+
+	paginatedResponse, err := c.Service.GetAll(ctx.Request.Context(), filter, page, limit)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch security events: " + err.Error()})
+	}
+
+	return ctx.JSON(http.StatusOK, paginatedResponse)
+}
+
+// GetSecurityEvent godoc
+// @Summary Get a security event
+// @Description Get a security event by its id (admin only)
+// @Tags Core/Logs
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Security event id"
+// @Success 200 {object} security.Event
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /logs/security/{id} [get]
+func (c *SecurityLogController) Get(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	item, err := c.Service.GetById(ctx.Request.Context(), uint(id))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Item not found"})
+	}
+
+	return ctx.JSON(http.StatusOK, item)
+}
@@ -0,0 +1,14 @@
+package securitylog
+
+import (
+	"time"
+)
+
+// SecurityEventFilter holds the list filters. Zero values are ignored.
+type SecurityEventFilter struct {
+	Type      string // Event type, with or without the security. prefix
+	UserId    uint   // Events about the user or by the user
+	IpAddress string
+	From      *time.Time
+	To        *time.Time
+}
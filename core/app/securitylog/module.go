@@ -0,0 +1,76 @@
+package securitylog
+
+import (
+	"context"
+
+	"base/core/app/authorization"
+	"base/core/emitter"
+	"base/core/logger"
+	"base/core/module"
+	"base/core/router"
+	"base/core/security"
+
+	"gorm.io/gorm"
+)
+
+// Module stores the security events (see core/security) for the admin viewer and forwards
+// them to a SIEM over syslog and/or HTTP when configured
+type Module struct {
+	module.DefaultModule
+	DB         *gorm.DB
+	Emitter    *emitter.Emitter
+	Service    *SecurityLogService
+	Controller *SecurityLogController
+}
+
+// Init creates and initializes the security log module with all dependencies
+func Init(deps module.Dependencies) module.Module {
+	service := NewSecurityLogService(deps.DB, deps.Logger)
+	controller := NewSecurityLogController(service)
+
+	if cfg := deps.Config; cfg != nil {
+		if cfg.SecurityLogSyslogAddress != "" {
+			service.Forwarders = append(service.Forwarders, logger.NewSyslogSink(cfg.SecurityLogSyslogNetwork, cfg.SecurityLogSyslogAddress,
+				cfg.LogServiceName, logger.SinkOptions{Name: "security_syslog"}))
+		}
+		if cfg.SecurityLogHTTPURL != "" {
+			service.Forwarders = append(service.Forwarders, logger.NewHTTPSink(cfg.SecurityLogHTTPURL, cfg.SecurityLogHTTPHeaders,
+				logger.SinkOptions{Name: "security_http"}))
+		}
+	}
+
+	return &Module{
+		DB:         deps.DB,
+		Emitter:    deps.Emitter,
+		Service:    service,
+		Controller: controller,
+	}
+}
+
+// Routes registers the module routes; they are restricted to admins
+func (m *Module) Routes(router *router.RouterGroup) {
+	group := router.Group("", authorization.RequireAdmin(authorization.NewAuthorizationService(m.DB)))
+	m.Controller.Routes(group)
+}
+
+func (m *Module) Init() error {
+	if m.Emitter == nil {
+		return nil
+	}
+	for _, event := range security.Events {
+		emitter.Listen(m.Emitter, event, func(ctx context.Context, e *security.Event) {
+			m.Service.Record(ctx, e)
+		})
+	}
+	return nil
+}
+
+func (m *Module) Migrate() error {
+	return m.DB.AutoMigrate(&security.Event{})
+}
+
+func (m *Module) GetModels() []any {
+	return []any{
+		&security.Event{},
+	}
+}
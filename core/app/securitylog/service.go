@@ -0,0 +1,125 @@
+package securitylog
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+
+	"base/core/logger"
+	"base/core/security"
+	"base/core/types"
+
+	"gorm.io/gorm"
+)
+
+type SecurityLogService struct {
+	DB     *gorm.DB
+	Logger logger.Logger
+
+	// Forwarders send every event to a SIEM
+	Forwarders []logger.Sink
+}
+
+func NewSecurityLogService(db *gorm.DB, logger logger.Logger) *SecurityLogService {
+	return &SecurityLogService{
+		DB:     db,
+		Logger: logger,
+	}
+}
+
+// Record stores a security event and forwards it
+func (s *SecurityLogService) Record(ctx context.Context, event *security.Event) {
+	if err := s.DB.WithContext(ctx).Create(event).Error; err != nil {
+		s.Logger.Error("failed to store security event",
+			logger.String("error", err.Error()),
+			logger.String("type", event.Type))
+	}
+
+	if len(s.Forwarders) == 0 {
+		return
+	}
+	level := "info"
+	if event.Failed() {
+		level = "warn"
+	}
+	line, err := json.Marshal(struct {
+		*security.Event
+		Level string `json:"level"`
+	}{event, level})
+	if err != nil {
+		return
+	}
+	for _, forwarder := range s.Forwarders {
+		forwarder.Write(append(line, '\n'))
+	}
+}
+
+// applyFilter adds the filter conditions to the query
+func (s *SecurityLogService) applyFilter(query *gorm.DB, filter *SecurityEventFilter) *gorm.DB {
+	if filter.Type != "" {
+		eventType := filter.Type
+		if !strings.HasPrefix(eventType, "security.") {
+			eventType = "security." + eventType
+		}
+		query = query.Where("type = ?", eventType)
+	}
+	if filter.UserId != 0 {
+		query = query.Where("user_id = ? OR actor_id = ?", filter.UserId, filter.UserId)
+	}
+	if filter.IpAddress != "" {
+		query = query.Where("ip_address = ?", filter.IpAddress)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at <= ?", *filter.To)
+	}
+	return query
+}
+
+// GetAll returns the security events matching the filter, newest first
+func (s *SecurityLogService) GetAll(ctx context.Context, filter *SecurityEventFilter, page int, limit int) (*types.PaginatedResponse, error) {
+	var items []*security.Event
+
+	query := s.applyFilter(s.DB.WithContext(ctx).Model(&security.Event{}), filter)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		s.Logger.Error("failed to count security events",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Order("id desc").Offset(offset).Limit(limit).Find(&items).Error; err != nil {
+		s.Logger.Error("failed to get security events",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	return &types.PaginatedResponse{
+		Data: items,
+		Pagination: types.Pagination{
+			Total:      int(total),
+			Page:       page,
+			PageSize:   limit,
+			TotalPages: totalPages,
+		},
+	}, nil
+}
+
+// GetById returns a security event
+func (s *SecurityLogService) GetById(ctx context.Context, id uint) (*security.Event, error) {
+	item := &security.Event{}
+	if err := s.DB.WithContext(ctx).First(item, id).Error; err != nil {
+		return nil, err
+	}
+	return item, nil
+}
@@ -0,0 +1,146 @@
+package settings
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"base/core/bundle"
+	"base/core/logger"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+// bundledSetting is a setting in configuration bundles, matched by its key
+type bundledSetting struct {
+	SettingKey  string  `json:"setting_key"`
+	Label       string  `json:"label"`
+	Group       string  `json:"group"`
+	Type        string  `json:"type"`
+	ValueString string  `json:"value_string"`
+	ValueInt    int     `json:"value_int"`
+	ValueFloat  float64 `json:"value_float"`
+	ValueBool   bool    `json:"value_bool"`
+	Description string  `json:"description"`
+	IsPublic    bool    `json:"is_public"`
+}
+
+func toBundled(item *Settings) *bundledSetting {
+	return &bundledSetting{
+		SettingKey:  item.SettingKey,
+		Label:       item.Label,
+		Group:       item.Group,
+		Type:        item.Type,
+		ValueString: item.ValueString,
+		ValueInt:    item.ValueInt,
+		ValueFloat:  item.ValueFloat,
+		ValueBool:   item.ValueBool,
+		Description: item.Description,
+		IsPublic:    item.IsPublic,
+	}
+}
+
+// registerBundle adds the settings to configuration bundles. Imported settings emit their
+// create and update events, so runtime configuration and cached responses follow.
+func (s *SettingsService) registerBundle() {
+	bundle.RegisterSection(bundle.Section{
+		Name:   "settings",
+		Export: s.exportBundle,
+		Import: s.importBundle,
+		Applied: func(ctx context.Context, changes *bundle.Changes) {
+			var items []*Settings
+			if err := s.DB.WithContext(ctx).Where("setting_key IN ?", changes.Changed()).Find(&items).Error; err != nil {
+				s.Logger.Error("failed to load imported settings", logger.String("error", err.Error()))
+				return
+			}
+			for _, item := range items {
+				event := UpdateSettingsEvent
+				if slices.Contains(changes.Created, item.SettingKey) {
+					event = CreateSettingsEvent
+				}
+				s.Emitter.EmitContext(ctx, event, item)
+			}
+		},
+	})
+}
+
+// exportBundle returns the settings sorted by key
+func (s *SettingsService) exportBundle(ctx context.Context, db *gorm.DB) (any, error) {
+	var items []*Settings
+	if err := db.Order("setting_key").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	records := make([]*bundledSetting, len(items))
+	for i, item := range items {
+		records[i] = toBundled(item)
+	}
+	return records, nil
+}
+
+// importBundle creates and updates the settings of a bundle by key; deleted settings are
+// created again
+func (s *SettingsService) importBundle(ctx context.Context, tx *gorm.DB, data json.RawMessage, changes *bundle.Changes) error {
+	var records []*bundledSetting
+	if err := json.Unmarshal(data, &records); err != nil {
+		return validator.ValidationErrors{{Field: "", Tag: "invalid", Message: "settings must be a list of settings"}}
+	}
+
+	var errs validator.ValidationErrors
+	for i, record := range records {
+		if record.SettingKey == "" {
+			errs = append(errs, validator.ValidationError{
+				Field: fmt.Sprintf("[%d].setting_key", i), Tag: "required",
+				Message: "setting_key is required",
+			})
+			continue
+		}
+		for _, err := range []error{validateSettingType(record.Type),
+			validateSettingValue(record.Type, record.ValueString, record.ValueInt, record.ValueFloat, record.ValueBool)} {
+			var fieldErrs validator.ValidationErrors
+			if errors.As(err, &fieldErrs) {
+				for _, e := range fieldErrs {
+					e.Field = fmt.Sprintf("[%d].%s", i, e.Field)
+					errs = append(errs, e)
+				}
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	for _, record := range records {
+		var item Settings
+		err := tx.Unscoped().Where("setting_key = ?", record.SettingKey).First(&item).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var before *bundledSetting
+		if err == nil && !item.DeletedAt.Valid {
+			before = toBundled(&item)
+		}
+		if !changes.Track(record.SettingKey, before, record) {
+			continue
+		}
+
+		item.SettingKey = record.SettingKey
+		item.Label = record.Label
+		item.Group = record.Group
+		item.Type = record.Type
+		item.ValueString = record.ValueString
+		item.ValueInt = record.ValueInt
+		item.ValueFloat = record.ValueFloat
+		item.ValueBool = record.ValueBool
+		item.Description = record.Description
+		item.IsPublic = record.IsPublic
+		item.DeletedAt = gorm.DeletedAt{}
+		if err := tx.Unscoped().Save(&item).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -5,9 +5,12 @@ import (
 	"strconv"
 	"strings"
 
+	"base/core/locks"
 	"base/core/router"
 	"base/core/storage"
+	"base/core/translation"
 	"base/core/types"
+	"base/core/validator"
 )
 
 type SettingsController struct {
@@ -24,16 +27,42 @@ func NewSettingsController(service *SettingsService, storage *storage.ActiveStor
 
 func (c *SettingsController) Routes(router *router.RouterGroup) {
 	// Main CRUD endpoints - specific routes MUST come before parameterized routes
-	router.GET("/settings", c.List)          // Paginated list
-	router.POST("/settings", c.Create)       // Create
-	router.GET("/settings/all", c.ListAll)   // Unpaginated list - MUST be before /:id
-	router.GET("/settings/:id", c.Get)       // Get by ID - MUST be after /all
-	router.PUT("/settings/:id", c.Update)    // Update
-	router.DELETE("/settings/:id", c.Delete) // Delete
+	router.GET("/settings", c.List)                                   // Paginated list
+	router.POST("/settings", c.Create)                                // Create
+	router.GET("/settings/all", c.ListAll)                            // Unpaginated list - MUST be before /:id
+	router.GET("/settings/:id", c.Get)                                // Get by ID - MUST be after /all
+	router.PUT("/settings/:id", c.Update, locks.Guard("settings"))    // Update
+	router.DELETE("/settings/:id", c.Delete, locks.Guard("settings")) // Delete
+	router.POST("/settings/:id/duplicate", c.Duplicate)               // Copy
+	locks.Routes(router, "settings")                                  // Edit locks
 
 	//Upload endpoints for each file field
 }
 
+// PublicRoutes registers the public settings. They don't need a user token (see
+// /api/public/* in MIDDLEWARE_AUTH_SKIP_PATHS) and are cached until a setting changes.
+func (c *SettingsController) PublicRoutes(group *router.RouterGroup) {
+	group.GET("/public/settings", c.Public, router.Responses.Cache(cacheTag))
+}
+
+// PublicSettings godoc
+// @Summary Get the public settings
+// @Description Get the values of the settings marked public, by key, for the frontend. Responses carry an ETag and can be revalidated with If-None-Match.
+// @Tags Core/Settings
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Success 304 "Not modified"
+// @Failure 500 {object} types.ErrorResponse
+// @Router /public/settings [get]
+func (c *SettingsController) Public(ctx *router.Context) error {
+	values, err := c.Service.GetPublic(ctx.Request.Context())
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch public settings: " + err.Error()})
+	}
+
+	return ctx.JSON(http.StatusOK, values)
+}
+
 // CreateSettings godoc
 // @Summary Create a new Settings
 // @Description Create a new Settings with the input payload
@@ -53,12 +82,18 @@ func (c *SettingsController) Create(ctx *router.Context) error {
 		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
 	}
 
-	item, err := c.Service.Create(&req)
+	item, err := c.Service.Create(ctx.Request.Context(), &req)
 	if err != nil {
+		if validationErrors, ok := err.(validator.ValidationErrors); ok {
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+				Error:   translation.Error(ctx, err),
+				Details: translation.LocalizeValidation(ctx, validationErrors),
+			})
+		}
 		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to create item: " + err.Error()})
 	}
 
-	return ctx.JSON(http.StatusCreated, item.ToResponse())
+	return c.respond(ctx, http.StatusCreated, item.ToResponse())
 }
 
 // GetSettings godoc
@@ -80,12 +115,12 @@ func (c *SettingsController) Get(ctx *router.Context) error {
 		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
 	}
 
-	item, err := c.Service.GetById(uint(id))
+	item, err := c.Service.GetById(ctx.Request.Context(), uint(id))
 	if err != nil {
 		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Item not found"})
 	}
 
-	return ctx.JSON(http.StatusOK, item.ToResponse())
+	return c.respond(ctx, http.StatusOK, item.ToResponse())
 }
 
 // ListSettings godoc
@@ -105,45 +140,20 @@ func (c *SettingsController) Get(ctx *router.Context) error {
 // @Failure 500 {object} types.ErrorResponse
 // @Router /settings [get]
 func (c *SettingsController) List(ctx *router.Context) error {
-	var page, limit *int
-	var sortBy, sortOrder *string
-
-	// Parse page parameter
-	if pageStr := ctx.Query("page"); pageStr != "" {
-		if pageNum, err := strconv.Atoi(pageStr); err == nil && pageNum > 0 {
-			page = &pageNum
-		} else {
-			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid page number"})
-		}
-	}
-
-	// Parse limit parameter
-	if limitStr := ctx.Query("limit"); limitStr != "" {
-		if limitNum, err := strconv.Atoi(limitStr); err == nil && limitNum > 0 {
-			limit = &limitNum
-		} else {
-			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid limit number"})
-		}
-	}
-
-	// Parse sort parameters
-	if sortStr := ctx.Query("sort"); sortStr != "" {
-		sortBy = &sortStr
-	}
-
-	if orderStr := ctx.Query("order"); orderStr != "" {
-		if orderStr == "asc" || orderStr == "desc" {
-			sortOrder = &orderStr
-		} else {
-			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid sort order. Use 'asc' or 'desc'"})
-		}
+	params, err := ctx.ListParams(sortFields...)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
 	}
 
-	paginatedResponse, err := c.Service.GetAll(page, limit, sortBy, sortOrder)
+	paginatedResponse, err := c.Service.GetAll(ctx.Request.Context(), params.Page, params.Limit, params.SortBy, params.SortOrder)
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch items: " + err.Error()})
 	}
 
+	if err := translation.Localize(ctx, settingsEntity, (&Settings{}).TranslatedFields(), paginatedResponse.Data); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to load translations: " + err.Error()})
+	}
+
 	return ctx.JSON(http.StatusOK, paginatedResponse)
 }
 
@@ -159,7 +169,7 @@ func (c *SettingsController) List(ctx *router.Context) error {
 // @Failure 500 {object} types.ErrorResponse
 // @Router /settings/all [get]
 func (c *SettingsController) ListAll(ctx *router.Context) error {
-	items, err := c.Service.GetAllForSelect()
+	items, err := c.Service.GetAllForSelect(ctx.Request.Context())
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch select options: " + err.Error()})
 	}
@@ -199,15 +209,21 @@ func (c *SettingsController) Update(ctx *router.Context) error {
 		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
 	}
 
-	item, err := c.Service.Update(uint(id), &req)
+	item, err := c.Service.Update(ctx.Request.Context(), uint(id), &req)
 	if err != nil {
 		if strings.Contains(err.Error(), "record not found") {
 			return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Item not found"})
 		}
+		if validationErrors, ok := err.(validator.ValidationErrors); ok {
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+				Error:   translation.Error(ctx, err),
+				Details: translation.LocalizeValidation(ctx, validationErrors),
+			})
+		}
 		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to update item: " + err.Error()})
 	}
 
-	return ctx.JSON(http.StatusOK, item.ToResponse())
+	return c.respond(ctx, http.StatusOK, item.ToResponse())
 }
 
 // DeleteSettings godoc
@@ -229,7 +245,7 @@ func (c *SettingsController) Delete(ctx *router.Context) error {
 		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
 	}
 
-	if err := c.Service.Delete(uint(id)); err != nil {
+	if err := c.Service.Delete(ctx.Request.Context(), uint(id)); err != nil {
 		if strings.Contains(err.Error(), "record not found") {
 			return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Item not found"})
 		}
@@ -239,3 +255,42 @@ func (c *SettingsController) Delete(ctx *router.Context) error {
 	ctx.Status(http.StatusNoContent)
 	return nil
 }
+
+// DuplicateSettings godoc
+// @Summary Duplicate a Settings
+// @Description Create a copy of a Settings with its translations; the key gets a -copy suffix (-copy-2... when taken)
+// @Tags Core/Settings
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Settings id"
+// @Success 201 {object} SettingsResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /settings/{id}/duplicate [post]
+func (c *SettingsController) Duplicate(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	item, err := c.Service.Duplicate(ctx.Request.Context(), uint(id))
+	if err != nil {
+		if strings.Contains(err.Error(), "record not found") {
+			return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Item not found"})
+		}
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to duplicate item: " + err.Error()})
+	}
+
+	return c.respond(ctx, http.StatusCreated, item.ToResponse())
+}
+
+// respond writes a settings response with label and description in the request locale
+// and all their translations
+func (c *SettingsController) respond(ctx *router.Context, status int, response *SettingsResponse) error {
+	if err := translation.Localize(ctx, settingsEntity, (&Settings{}).TranslatedFields(), response); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to load translations: " + err.Error()})
+	}
+	return ctx.JSON(status, response)
+}
@@ -0,0 +1,58 @@
+package settings
+
+import (
+	"base/core/graphql"
+
+	"gorm.io/gorm"
+)
+
+// registerGraphQL adds the Setting type and the setting and settings query fields to the
+// GraphQL schema. Like the /settings routes they are open to every signed-in user.
+func registerGraphQL(db *gorm.DB) {
+	setting := graphql.NewObject("Setting", "Application setting").
+		AddField("id", &graphql.Field{Type: graphql.NonNull{Of: graphql.ID}}).
+		AddField("setting_key", &graphql.Field{Type: graphql.NonNull{Of: graphql.String}}).
+		AddField("label", &graphql.Field{Type: graphql.String}).
+		AddField("group", &graphql.Field{Type: graphql.String}).
+		AddField("type", &graphql.Field{Type: graphql.String}).
+		AddField("value_string", &graphql.Field{Type: graphql.String}).
+		AddField("value_int", &graphql.Field{Type: graphql.Int}).
+		AddField("value_float", &graphql.Field{Type: graphql.Float}).
+		AddField("value_bool", &graphql.Field{Type: graphql.Boolean}).
+		AddField("description", &graphql.Field{Type: graphql.String}).
+		AddField("is_public", &graphql.Field{Type: graphql.NonNull{Of: graphql.Boolean}}).
+		AddField("created_at", &graphql.Field{Type: graphql.NonNull{Of: graphql.Time}}).
+		AddField("updated_at", &graphql.Field{Type: graphql.NonNull{Of: graphql.Time}})
+
+	graphql.Query("setting", &graphql.Field{
+		Type:        setting,
+		Description: "Setting by key",
+		Args:        []*graphql.Argument{{Name: "key", Type: graphql.NonNull{Of: graphql.String}}},
+		Guard:       graphql.Authenticated,
+		Resolve: func(p graphql.Params) (any, error) {
+			var items []*Settings
+			if err := db.Where(&Settings{SettingKey: p.Args["key"].(string)}).Limit(1).Find(&items).Error; err != nil || len(items) == 0 {
+				return nil, err
+			}
+			return items[0], nil
+		},
+	})
+
+	graphql.Query("settings", &graphql.Field{
+		Type:        graphql.NonNull{Of: graphql.List{Of: graphql.NonNull{Of: setting}}},
+		Description: "All settings, or the settings of a group",
+		Args:        []*graphql.Argument{{Name: "group", Type: graphql.String}},
+		Guard:       graphql.Authenticated,
+		Resolve: func(p graphql.Params) (any, error) {
+			query := db.Order("setting_key")
+			if group, ok := p.Args["group"].(string); ok {
+				query = query.Where(&Settings{Group: group})
+			}
+			var items []*Settings
+			if err := query.Find(&items).Error; err != nil {
+				return nil, err
+			}
+			return items, nil
+		},
+	})
+}
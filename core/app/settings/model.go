@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"time"
 
+	"base/core/translation"
+
 	"gorm.io/gorm"
 )
 
@@ -13,10 +15,10 @@ type Settings struct {
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index"`
-	SettingKey  string         `json:"setting_key" gorm:"type:varchar(100);index"`
+	SettingKey  string         `json:"setting_key" gorm:"type:varchar(100);not null;uniqueIndex:uni_settings_setting_key"`
 	Label       string         `json:"label" gorm:"type:varchar(200)"`
 	Group       string         `json:"group" gorm:"type:varchar(50)"`
-	Type        string         `json:"type" gorm:"type:varchar(20)"`
+	Type        string         `json:"type" gorm:"type:varchar(20);not null"`
 	ValueString string         `json:"value_string" gorm:"type:text"`
 	ValueInt    int            `json:"value_int"`
 	ValueFloat  float64        `json:"value_float"`
@@ -40,32 +42,79 @@ func (m *Settings) GetModelName() string {
 	return "settings"
 }
 
+// Value returns the value of the setting's type, or nil for an unknown type
+func (m *Settings) Value() any {
+	switch m.Type {
+	case "string":
+		return m.ValueString
+	case "int":
+		return m.ValueInt
+	case "float":
+		return m.ValueFloat
+	case "bool":
+		return m.ValueBool
+	default:
+		return nil
+	}
+}
+
+// clearOtherValues zeroes the value columns of the types other than the setting's type
+func (m *Settings) clearOtherValues() {
+	if m.Type != "string" {
+		m.ValueString = ""
+	}
+	if m.Type != "int" {
+		m.ValueInt = 0
+	}
+	if m.Type != "float" {
+		m.ValueFloat = 0
+	}
+	if m.Type != "bool" {
+		m.ValueBool = false
+	}
+}
+
+// settingsEntity is the entity name of settings translations (see translation.Fields)
+const settingsEntity = "settings"
+
+// TranslatedFields returns the fields that can have per-locale values
+func (m *Settings) TranslatedFields() []string {
+	return []string{"label", "description"}
+}
+
 // CreateSettingsRequest represents the request payload for creating a Settings
 type CreateSettingsRequest struct {
-	SettingKey  string  `json:"setting_key"`
-	Label       string  `json:"label"`
-	Group       string  `json:"group"`
-	Type        string  `json:"type"`
+	SettingKey  string  `json:"setting_key" validate:"required,max=100"`
+	Label       string  `json:"label" validate:"max=200"`
+	Group       string  `json:"group" validate:"max=50"`
+	Type        string  `json:"type" validate:"required,oneof=string int float bool"`
 	ValueString string  `json:"value_string"`
 	ValueInt    int     `json:"value_int"`
 	ValueFloat  float64 `json:"value_float"`
 	ValueBool   bool    `json:"value_bool"`
 	Description string  `json:"description"`
 	IsPublic    bool    `json:"is_public"`
+
+	// Translations of label and description by locale, e.g. {"label": {"de": "Seitenname"}}
+	Translations translation.FieldValues `json:"translations,omitempty"`
 }
 
-// UpdateSettingsRequest represents the request payload for updating a Settings
+// UpdateSettingsRequest represents the request payload for updating a Settings. Fields left
+// out keep their value; fields sent are set, so "" and 0 clear them.
 type UpdateSettingsRequest struct {
-	SettingKey  string  `json:"setting_key,omitempty"`
-	Label       string  `json:"label,omitempty"`
-	Group       string  `json:"group,omitempty"`
-	Type        string  `json:"type,omitempty"`
-	ValueString string  `json:"value_string,omitempty"`
-	ValueInt    int     `json:"value_int,omitempty"`
-	ValueFloat  float64 `json:"value_float,omitempty"`
-	ValueBool   *bool   `json:"value_bool,omitempty"`
-	Description string  `json:"description,omitempty"`
-	IsPublic    *bool   `json:"is_public,omitempty"`
+	SettingKey  *string  `json:"setting_key,omitempty"`
+	Label       *string  `json:"label,omitempty"`
+	Group       *string  `json:"group,omitempty"`
+	Type        *string  `json:"type,omitempty"`
+	ValueString *string  `json:"value_string,omitempty"`
+	ValueInt    *int     `json:"value_int,omitempty"`
+	ValueFloat  *float64 `json:"value_float,omitempty"`
+	ValueBool   *bool    `json:"value_bool,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	IsPublic    *bool    `json:"is_public,omitempty"`
+
+	// Translations to add or change; an empty value removes a translation
+	Translations translation.FieldValues `json:"translations,omitempty"`
 }
 
 // SettingsResponse represents the API response for Settings
@@ -84,6 +133,9 @@ type SettingsResponse struct {
 	ValueBool   bool           `json:"value_bool"`
 	Description string         `json:"description"`
 	IsPublic    bool           `json:"is_public"`
+
+	// All translations of label and description, by field and locale
+	Translations translation.FieldValues `json:"translations,omitempty"`
 }
 
 // SettingsModelResponse represents a simplified response when this model is part of other entities
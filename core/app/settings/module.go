@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"base/core/app/authorization"
+	"base/core/app/media"
 	"base/core/module"
 	"base/core/router"
 
@@ -23,6 +24,30 @@ func Init(deps module.Dependencies) module.Module {
 	service := NewSettingsService(deps.DB, deps.Emitter, deps.Storage, deps.Logger)
 	controller := NewSettingsController(service, deps.Storage)
 
+	// Read-only GraphQL fields (see core/graphql)
+	registerGraphQL(deps.DB)
+
+	// Settings are promoted between environments with configuration bundles
+	service.registerBundle()
+
+	// Settings such as a logo may link to media files
+	media.RegisterUsageEntity(media.UsageEntity{
+		Name:        "settings",
+		Model:       &Settings{},
+		SaveEvents:  []string{CreateSettingsEvent, UpdateSettingsEvent},
+		DeleteEvent: DeleteSettingsEvent,
+		Content: func(record any) (string, map[string]any) {
+			setting, ok := record.(*Settings)
+			if !ok || setting.Type != "string" {
+				return "", nil
+			}
+			return setting.SettingKey, map[string]any{"value_string": setting.ValueString}
+		},
+	})
+
+	// Cached public settings are dropped when a setting changes
+	router.Responses.InvalidateOn(deps.Emitter, cacheTag, CreateSettingsEvent, UpdateSettingsEvent, DeleteSettingsEvent)
+
 	// Create module
 	mod := &Module{
 		DB:         deps.DB,
@@ -36,6 +61,7 @@ func Init(deps module.Dependencies) module.Module {
 // Routes registers the module routes
 func (m *Module) Routes(router *router.RouterGroup) {
 	m.Controller.Routes(router)
+	m.Controller.PublicRoutes(router)
 }
 
 func (m *Module) Init() error {
@@ -43,6 +69,11 @@ func (m *Module) Init() error {
 }
 
 func (m *Module) Migrate() error {
+	// Duplicate keys have to go before auto migration adds the unique index
+	if err := dedupeKeys(m.DB); err != nil {
+		return err
+	}
+
 	// Run auto migration first
 	if err := m.DB.AutoMigrate(&Settings{}); err != nil {
 		return err
@@ -57,6 +88,48 @@ func (m *Module) Migrate() error {
 	return m.SeedPermissions()
 }
 
+// legacyKeyIndex is the non-unique index on setting_key that uni_settings_setting_key replaces
+const legacyKeyIndex = "idx_settings_setting_key"
+
+// dedupeKeys prepares an existing settings table for the unique key index: of the settings
+// sharing a key it keeps the newest one that isn't deleted, and it drops the old index
+func dedupeKeys(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&Settings{}) {
+		return nil
+	}
+	if migrator.HasIndex(&Settings{}, legacyKeyIndex) {
+		if err := migrator.DropIndex(&Settings{}, legacyKeyIndex); err != nil {
+			return err
+		}
+	}
+
+	var keys []string
+	err := db.Unscoped().Model(&Settings{}).
+		Group("setting_key").
+		Having("COUNT(*) > 1").
+		Pluck("setting_key", &keys).Error
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		var keep Settings
+		err := db.Unscoped().
+			Where("setting_key = ?", key).
+			Order("CASE WHEN deleted_at IS NULL THEN 0 ELSE 1 END, id DESC").
+			First(&keep).Error
+		if err != nil {
+			return err
+		}
+		err = db.Unscoped().Where("setting_key = ? AND id <> ?", key, keep.Id).Delete(&Settings{}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // seedDefaultSettings creates default system settings if they don't exist
 func (m *Module) seedDefaultSettings() error {
 	defaultSettings := []Settings{
@@ -206,11 +279,11 @@ func (m *Module) seedDefaultSettings() error {
 		// Media Settings
 		{
 			SettingKey:  "media_convert_images",
-			Label:       "Convert Images to WebP",
+			Label:       "Convert Images",
 			Group:       "media",
 			Type:        "bool",
 			ValueBool:   true,
-			Description: "Automatically convert uploaded images to WebP format",
+			Description: "Automatically convert uploaded images to the image format (WebP by default)",
 			IsPublic:    false,
 		},
 		{
@@ -240,6 +313,15 @@ func (m *Module) seedDefaultSettings() error {
 			Description: "Keep original files after conversion (stores both versions)",
 			IsPublic:    false,
 		},
+		{
+			SettingKey:  "media_image_format",
+			Label:       "Image Format",
+			Group:       "media",
+			Type:        "string",
+			ValueString: "webp",
+			Description: "Format uploaded images are converted to: webp, avif (falls back to webp without ffmpeg's AV1 encoder) or jpeg",
+			IsPublic:    false,
+		},
 		{
 			SettingKey:  "media_image_quality",
 			Label:       "Image Quality",
@@ -267,6 +349,26 @@ func (m *Module) seedDefaultSettings() error {
 			Description: "Audio bitrate in kbps (recommended 96 for speech, 128 for music)",
 			IsPublic:    false,
 		},
+
+		// Commerce Settings
+		{
+			SettingKey:  "tax_rate",
+			Label:       "Tax Rate (%)",
+			Group:       "commerce",
+			Type:        "float",
+			ValueFloat:  0,
+			Description: "Tax rate in percent of items no tax rate matches",
+			IsPublic:    true,
+		},
+		{
+			SettingKey:  "currencies",
+			Label:       "Display Currencies",
+			Group:       "commerce",
+			Type:        "string",
+			ValueString: "",
+			Description: "Currencies prices can be shown in besides the payments currency, comma separated (e.g. EUR,GBP)",
+			IsPublic:    true,
+		},
 	}
 
 	// Insert settings that don't already exist
@@ -276,6 +378,9 @@ func (m *Module) seedDefaultSettings() error {
 		
 		// If setting doesn't exist, create it
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			if err := purgeDeleted(m.DB, setting.SettingKey); err != nil {
+				return err
+			}
 			if err := m.DB.Create(&setting).Error; err != nil {
 				return err
 			}
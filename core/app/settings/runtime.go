@@ -0,0 +1,96 @@
+package settings
+
+import (
+	"slices"
+	"strings"
+
+	"base/core/config"
+
+	"gorm.io/gorm"
+)
+
+// IsRuntimeSetting reports whether a setting changes configuration at runtime
+func IsRuntimeSetting(key string) bool {
+	return slices.Contains(config.RuntimeSettingKeys, key)
+}
+
+// ApplyRuntimeOverrides overrides runtime-tunable values with the matching settings.
+// Settings that don't exist leave the environment value in place; maintenance mode is
+// enabled when either MAINTENANCE_MODE or the maintenance_mode setting is on.
+func ApplyRuntimeOverrides(db *gorm.DB, values *config.RuntimeValues) error {
+	var items []Settings
+	if err := db.Where("setting_key IN ?", config.RuntimeSettingKeys).Find(&items).Error; err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		switch item.SettingKey {
+		case config.SettingLogLevel:
+			if level := strings.TrimSpace(item.ValueString); level != "" {
+				values.LogLevel = strings.ToLower(level)
+			}
+		case config.SettingCORSAllowedOrigins:
+			if origins := splitOrigins(item.ValueString); origins != nil {
+				values.CORSAllowedOrigins = origins
+			}
+		case config.SettingCORSPublicOrigins:
+			if origins := splitOrigins(item.ValueString); origins != nil {
+				values.CORSPublicOrigins = origins
+			}
+		case config.SettingRateLimitRequests:
+			if item.ValueInt > 0 {
+				values.RateLimitRequests = item.ValueInt
+			}
+		case config.SettingRateLimitWindow:
+			if window := strings.TrimSpace(item.ValueString); window != "" {
+				values.RateLimitWindow = window
+			}
+		case config.SettingMaintenanceMode:
+			values.MaintenanceMode = values.MaintenanceMode || item.ValueBool
+		case config.SettingDefaultLocale:
+			if locale := strings.TrimSpace(item.ValueString); locale != "" {
+				values.DefaultLocale = locale
+			}
+		case config.SettingTimezone:
+			if timezone := strings.TrimSpace(item.ValueString); timezone != "" {
+				values.Timezone = timezone
+			}
+		case config.SettingDateFormat:
+			if format := strings.TrimSpace(item.ValueString); format != "" {
+				values.DateFormat = format
+			}
+		case config.SettingTimeFormat:
+			if format := strings.TrimSpace(item.ValueString); format != "" {
+				values.TimeFormat = strings.ToLower(format)
+			}
+		case config.SettingHTMLPolicy:
+			if policy := strings.TrimSpace(item.ValueString); policy != "" {
+				values.HTMLPolicy = strings.ToLower(policy)
+			}
+		case config.SettingUploadMaxSize:
+			if item.ValueInt > 0 {
+				values.UploadMaxSize = int64(item.ValueInt)
+			}
+		case config.SettingMultipartMemory:
+			if item.ValueInt > 0 {
+				values.MultipartMemory = int64(item.ValueInt)
+			}
+		}
+	}
+
+	return nil
+}
+
+// splitOrigins parses a comma-separated origins setting, or returns nil when it's empty
+func splitOrigins(value string) []string {
+	if value == "" {
+		return nil
+	}
+	origins := []string{}
+	for _, origin := range strings.Split(value, ",") {
+		if trimmed := strings.TrimSpace(origin); trimmed != "" {
+			origins = append(origins, trimmed)
+		}
+	}
+	return origins
+}
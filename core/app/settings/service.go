@@ -1,13 +1,15 @@
 package settings
 
 import (
+	"context"
 	"fmt"
-	"math"
 
+	"base/core/crud"
 	"base/core/emitter"
 	"base/core/logger"
 	"base/core/storage"
-	"base/core/types"
+	"base/core/translation"
+	"base/core/validator"
 
 	"gorm.io/gorm"
 )
@@ -16,30 +18,34 @@ const (
 	CreateSettingsEvent = "settings.create"
 	UpdateSettingsEvent = "settings.update"
 	DeleteSettingsEvent = "settings.delete"
+
+	// cacheTag tags the cached public settings responses
+	cacheTag = "settings"
 )
 
+// SettingsService manages the settings and reads their values; listing, getting and
+// deleting them are the shared crud operations
 type SettingsService struct {
-	DB      *gorm.DB
-	Emitter *emitter.Emitter
+	*crud.CrudService[Settings]
 	Storage *storage.ActiveStorage
-	Logger  logger.Logger
 }
 
 func NewSettingsService(db *gorm.DB, emitter *emitter.Emitter, storage *storage.ActiveStorage, logger logger.Logger) *SettingsService {
-	return &SettingsService{
-		DB:      db,
-		Logger:  logger,
-		Emitter: emitter,
-		Storage: storage,
+	service := &SettingsService{
+		CrudService: crud.New[Settings](db, emitter, logger, "settings", "settings"),
+		Storage:     storage,
 	}
+	service.SortFields = sortFields
+	service.ListResponse = func(item *Settings) any { return item.ToListResponse() }
+	return service
 }
 
 // Configuration helper methods for modules to retrieve settings
 
 // GetSettingString retrieves a string setting value by key
-func (s *SettingsService) GetSettingString(key string, defaultValue string) string {
+func (s *SettingsService) GetSettingString(ctx context.Context, key string, defaultValue string) string {
 	var setting Settings
-	if err := s.DB.Where("setting_key = ?", key).First(&setting).Error; err != nil {
+	if err := s.DB.WithContext(ctx).Where("setting_key = ?", key).First(&setting).Error; err != nil {
 		s.Logger.Warn("setting not found, using default", 
 			logger.String("key", key), 
 			logger.String("default", defaultValue))
@@ -49,9 +55,9 @@ func (s *SettingsService) GetSettingString(key string, defaultValue string) stri
 }
 
 // GetSettingInt retrieves an integer setting value by key
-func (s *SettingsService) GetSettingInt(key string, defaultValue int) int {
+func (s *SettingsService) GetSettingInt(ctx context.Context, key string, defaultValue int) int {
 	var setting Settings
-	if err := s.DB.Where("setting_key = ?", key).First(&setting).Error; err != nil {
+	if err := s.DB.WithContext(ctx).Where("setting_key = ?", key).First(&setting).Error; err != nil {
 		s.Logger.Warn("setting not found, using default", 
 			logger.String("key", key), 
 			logger.Int("default", defaultValue))
@@ -61,9 +67,9 @@ func (s *SettingsService) GetSettingInt(key string, defaultValue int) int {
 }
 
 // GetSettingBool retrieves a boolean setting value by key
-func (s *SettingsService) GetSettingBool(key string, defaultValue bool) bool {
+func (s *SettingsService) GetSettingBool(ctx context.Context, key string, defaultValue bool) bool {
 	var setting Settings
-	if err := s.DB.Where("setting_key = ?", key).First(&setting).Error; err != nil {
+	if err := s.DB.WithContext(ctx).Where("setting_key = ?", key).First(&setting).Error; err != nil {
 		s.Logger.Warn("setting not found, using default", 
 			logger.String("key", key), 
 			logger.Bool("default", defaultValue))
@@ -73,9 +79,9 @@ func (s *SettingsService) GetSettingBool(key string, defaultValue bool) bool {
 }
 
 // GetSettingFloat retrieves a float setting value by key
-func (s *SettingsService) GetSettingFloat(key string, defaultValue float64) float64 {
+func (s *SettingsService) GetSettingFloat(ctx context.Context, key string, defaultValue float64) float64 {
 	var setting Settings
-	if err := s.DB.Where("setting_key = ?", key).First(&setting).Error; err != nil {
+	if err := s.DB.WithContext(ctx).Where("setting_key = ?", key).First(&setting).Error; err != nil {
 		s.Logger.Warn("setting not found, using default", 
 			logger.String("key", key), 
 			logger.Float64("default", defaultValue))
@@ -85,9 +91,9 @@ func (s *SettingsService) GetSettingFloat(key string, defaultValue float64) floa
 }
 
 // GetSettingsByGroup retrieves all settings for a specific group
-func (s *SettingsService) GetSettingsByGroup(group string) ([]*Settings, error) {
+func (s *SettingsService) GetSettingsByGroup(ctx context.Context, group string) ([]*Settings, error) {
 	var settings []*Settings
-	if err := s.DB.Where("group = ?", group).Find(&settings).Error; err != nil {
+	if err := s.DB.WithContext(ctx).Where("group = ?", group).Find(&settings).Error; err != nil {
 		s.Logger.Error("failed to get settings by group", 
 			logger.String("group", group), 
 			logger.String("error", err.Error()))
@@ -97,9 +103,9 @@ func (s *SettingsService) GetSettingsByGroup(group string) ([]*Settings, error)
 }
 
 // UpsertSetting creates or updates a setting
-func (s *SettingsService) UpsertSetting(key, label, group, settingType, description string, isPublic bool, stringVal string, intVal int, floatVal float64, boolVal bool) error {
+func (s *SettingsService) UpsertSetting(ctx context.Context, key, label, group, settingType, description string, isPublic bool, stringVal string, intVal int, floatVal float64, boolVal bool) error {
 	var setting Settings
-	result := s.DB.Where("setting_key = ?", key).First(&setting)
+	result := s.DB.WithContext(ctx).Where("setting_key = ?", key).First(&setting)
 	
 	if result.Error != nil && result.Error.Error() == "record not found" {
 		// Create new setting
@@ -115,7 +121,7 @@ func (s *SettingsService) UpsertSetting(key, label, group, settingType, descript
 			ValueFloat:  floatVal,
 			ValueBool:   boolVal,
 		}
-		if err := s.DB.Create(&setting).Error; err != nil {
+		if err := s.DB.WithContext(ctx).Create(&setting).Error; err != nil {
 			s.Logger.Error("failed to create setting", 
 				logger.String("key", key), 
 				logger.String("error", err.Error()))
@@ -133,7 +139,7 @@ func (s *SettingsService) UpsertSetting(key, label, group, settingType, descript
 		setting.ValueFloat = floatVal
 		setting.ValueBool = boolVal
 		
-		if err := s.DB.Save(&setting).Error; err != nil {
+		if err := s.DB.WithContext(ctx).Save(&setting).Error; err != nil {
 			s.Logger.Error("failed to update setting", 
 				logger.String("key", key), 
 				logger.String("error", err.Error()))
@@ -146,48 +152,37 @@ func (s *SettingsService) UpsertSetting(key, label, group, settingType, descript
 	return nil
 }
 
-// applySorting applies sorting to the query based on the sort and order parameters
-func (s *SettingsService) applySorting(query *gorm.DB, sortBy *string, sortOrder *string) {
-	// Valid sortable fields for Settings
-	validSortFields := map[string]string{
-		"id":           "id",
-		"created_at":   "created_at",
-		"updated_at":   "updated_at",
-		"setting_key":  "setting_key",
-		"label":        "label",
-		"group":        "group",
-		"type":         "type",
-		"value_string": "value_string",
-		"value_int":    "value_int",
-		"value_float":  "value_float",
-		"value_bool":   "value_bool",
-		"description":  "description",
-		"is_public":    "is_public",
-	}
-
-	// Default sorting - if sort_order exists, always use it for custom ordering
-	defaultSortBy := "id"
-	defaultSortOrder := "desc"
-
-	// Determine sort field
-	sortField := defaultSortBy
-	if sortBy != nil && *sortBy != "" {
-		if field, exists := validSortFields[*sortBy]; exists {
-			sortField = field
-		}
-	}
+// sortFields are the fields the list can be sorted by
+var sortFields = []string{
+	"id",
+	"created_at",
+	"updated_at",
+	"setting_key",
+	"label",
+	"group",
+	"type",
+	"value_string",
+	"value_int",
+	"value_float",
+	"value_bool",
+	"description",
+	"is_public",
+}
 
-	// Determine sort direction (order parameter)
-	sortDirection := defaultSortOrder
-	if sortOrder != nil && (*sortOrder == "asc" || *sortOrder == "desc") {
-		sortDirection = *sortOrder
+func (s *SettingsService) Create(ctx context.Context, req *CreateSettingsRequest) (*Settings, error) {
+	if err := ValidateSettingsCreateRequest(req); err != nil {
+		return nil, err
+	}
+	if err := validateSettingValue(req.Type, req.ValueString, req.ValueInt, req.ValueFloat, req.ValueBool); err != nil {
+		return nil, err
+	}
+	if err := translation.ValidateFieldValues(req.Translations, (&Settings{}).TranslatedFields()); err != nil {
+		return nil, err
+	}
+	if err := s.claimKey(ctx, req.SettingKey, 0); err != nil {
+		return nil, err
 	}
 
-	// Apply sorting
-	query.Order(sortField + " " + sortDirection)
-}
-
-func (s *SettingsService) Create(req *CreateSettingsRequest) (*Settings, error) {
 	item := &Settings{
 		SettingKey:  req.SettingKey,
 		Label:       req.Label,
@@ -200,219 +195,120 @@ func (s *SettingsService) Create(req *CreateSettingsRequest) (*Settings, error)
 		Description: req.Description,
 		IsPublic:    req.IsPublic,
 	}
-
-	if err := s.DB.Create(item).Error; err != nil {
-		s.Logger.Error("failed to create settings", logger.String("error", err.Error()))
-		return nil, err
-	}
-
-	// Emit create event
-	s.Emitter.Emit(CreateSettingsEvent, item)
-
-	return s.GetById(item.Id)
+	return s.CrudService.Create(ctx, item, func(tx *gorm.DB, item *Settings) error {
+		return translation.Fields.WithDB(tx).Set(settingsEntity, item.Id, req.Translations)
+	})
 }
 
-func (s *SettingsService) Update(id uint, req *UpdateSettingsRequest) (*Settings, error) {
-	item := &Settings{}
-	if err := s.DB.First(item, id).Error; err != nil {
-		s.Logger.Error("failed to find settings for update",
-			logger.String("error", err.Error()),
-			logger.Int("id", int(id)))
-		return nil, err
-	}
-
-	// Validate request
-	if err := ValidateSettingsUpdateRequest(req, id); err != nil {
-		return nil, err
-	}
-
-	// Update fields directly on the model
-	// For non-pointer string fields
-	if req.SettingKey != "" {
-		item.SettingKey = req.SettingKey
-	}
-	// For non-pointer string fields
-	if req.Label != "" {
-		item.Label = req.Label
-	}
-	// For non-pointer string fields
-	if req.Group != "" {
-		item.Group = req.Group
-	}
-	// For non-pointer string fields
-	if req.Type != "" {
-		item.Type = req.Type
-	}
-	// For non-pointer string fields
-	if req.ValueString != "" {
-		item.ValueString = req.ValueString
-	}
-	// For non-pointer integer fields
-	if req.ValueInt != 0 {
-		item.ValueInt = req.ValueInt
-	}
-	// For boolean fields, check if it's included in the request (pointer would be non-nil)
-	if req.ValueBool != nil {
-		item.ValueBool = *req.ValueBool
-	}
-	// For non-pointer string fields
-	if req.Description != "" {
-		item.Description = req.Description
-	}
-	// For boolean fields, check if it's included in the request (pointer would be non-nil)
-	if req.IsPublic != nil {
-		item.IsPublic = *req.IsPublic
-	}
-
-	if err := s.DB.Save(item).Error; err != nil {
-		s.Logger.Error("failed to update settings",
-			logger.String("error", err.Error()),
-			logger.Int("id", int(id)))
-		return nil, err
-	}
+func (s *SettingsService) Update(ctx context.Context, id uint, req *UpdateSettingsRequest) (*Settings, error) {
+	change := func(tx *gorm.DB, item *Settings) error {
+		// Validate request
+		if err := ValidateSettingsUpdateRequest(req, id); err != nil {
+			return err
+		}
+		if err := translation.ValidateFieldValues(req.Translations, item.TranslatedFields()); err != nil {
+			return err
+		}
 
-	// Handle many-to-many relationships
+		if req.SettingKey != nil && *req.SettingKey != item.SettingKey {
+			if err := claimKey(tx, *req.SettingKey, item.Id); err != nil {
+				return err
+			}
+		}
 
-	result, err := s.GetById(item.Id)
-	if err != nil {
-		s.Logger.Error("failed to get updated settings",
-			logger.String("error", err.Error()),
-			logger.Int("id", int(id)))
-		return nil, err
+		// Update the fields included in the request; zero values clear them
+		if req.SettingKey != nil {
+			item.SettingKey = *req.SettingKey
+		}
+		if req.Label != nil {
+			item.Label = *req.Label
+		}
+		if req.Group != nil {
+			item.Group = *req.Group
+		}
+		if req.Type != nil {
+			item.Type = *req.Type
+		}
+		// Only the value of the type is kept, so changing the type drops the old value
+		item.clearOtherValues()
+		if req.ValueString != nil {
+			item.ValueString = *req.ValueString
+		}
+		if req.ValueInt != nil {
+			item.ValueInt = *req.ValueInt
+		}
+		if req.ValueFloat != nil {
+			item.ValueFloat = *req.ValueFloat
+		}
+		if req.ValueBool != nil {
+			item.ValueBool = *req.ValueBool
+		}
+		if req.Description != nil {
+			item.Description = *req.Description
+		}
+		if req.IsPublic != nil {
+			item.IsPublic = *req.IsPublic
+		}
+		return validateSettingValue(item.Type, item.ValueString, item.ValueInt, item.ValueFloat, item.ValueBool)
 	}
 
-	// Emit update event
-	s.Emitter.Emit(UpdateSettingsEvent, result)
+	return s.CrudService.Update(ctx, id, change, func(tx *gorm.DB, item *Settings) error {
+		return translation.Fields.WithDB(tx).Set(settingsEntity, item.Id, req.Translations)
+	})
+}
 
-	return result, nil
+// Duplicate saves a copy of a setting with its translations, under the key with a -copy
+// suffix
+func (s *SettingsService) Duplicate(ctx context.Context, id uint) (*Settings, error) {
+	return s.CrudService.Duplicate(ctx, id, crud.CopyOptions[Settings]{})
 }
 
-func (s *SettingsService) Delete(id uint) error {
-	item := &Settings{}
-	if err := s.DB.First(item, id).Error; err != nil {
-		s.Logger.Error("failed to find settings for deletion",
-			logger.String("error", err.Error()),
-			logger.Int("id", int(id)))
+// Delete deletes a setting and its translations
+func (s *SettingsService) Delete(ctx context.Context, id uint) error {
+	if err := s.CrudService.Delete(ctx, id); err != nil {
 		return err
 	}
 
-	// Delete file attachments if any
-
-	if err := s.DB.Delete(item).Error; err != nil {
-		s.Logger.Error("failed to delete settings",
+	if err := translation.Fields.WithDB(s.DB.WithContext(ctx)).Delete(settingsEntity, id); err != nil {
+		s.Logger.Warn("failed to delete settings translations",
 			logger.String("error", err.Error()),
 			logger.Int("id", int(id)))
-		return err
 	}
-
-	// Emit delete event
-	s.Emitter.Emit(DeleteSettingsEvent, item)
-
 	return nil
 }
 
-func (s *SettingsService) GetById(id uint) (*Settings, error) {
-	item := &Settings{}
-
-	query := item.Preload(s.DB)
-	if err := query.First(item, id).Error; err != nil {
-		s.Logger.Error("failed to get settings",
-			logger.String("error", err.Error()),
-			logger.Int("id", int(id)))
-		return nil, err
-	}
-
-	return item, nil
+// claimKey checks that no other setting has the key. Keys are unique across deleted
+// settings too, so deleted settings holding the key are removed for good.
+func (s *SettingsService) claimKey(ctx context.Context, settingKey string, id uint) error {
+	return claimKey(s.DB.WithContext(ctx), settingKey, id)
 }
 
-func (s *SettingsService) GetAll(page *int, limit *int, sortBy *string, sortOrder *string) (*types.PaginatedResponse, error) {
-	var items []*Settings
-	var total int64
-
-	query := s.DB.Model(&Settings{})
-	// Set default values if nil
-	defaultPage := 1
-	defaultLimit := 10
-	if page == nil {
-		page = &defaultPage
-	}
-	if limit == nil {
-		limit = &defaultLimit
-	}
-
-	// Get total count
-	if err := query.Count(&total).Error; err != nil {
-		s.Logger.Error("failed to count settings",
-			logger.String("error", err.Error()))
-		return nil, err
-	}
-
-	// Apply pagination if provided
-	if page != nil && limit != nil {
-		offset := (*page - 1) * *limit
-		query = query.Offset(offset).Limit(*limit)
-	}
-
-	// Apply sorting
-	s.applySorting(query, sortBy, sortOrder)
-
-	// Don't preload relationships for list response (faster)
-	// query = (&Settings{}).Preload(query)
-
-	// Execute query
-	if err := query.Find(&items).Error; err != nil {
-		s.Logger.Error("failed to get settings",
-			logger.String("error", err.Error()))
-		return nil, err
-	}
-
-	// Convert to response type
-	responses := make([]*SettingsListResponse, len(items))
-	for i, item := range items {
-		responses[i] = item.ToListResponse()
+func claimKey(db *gorm.DB, settingKey string, id uint) error {
+	var count int64
+	err := db.Model(&Settings{}).Where("setting_key = ? AND id <> ?", settingKey, id).Count(&count).Error
+	if err != nil {
+		return err
 	}
-
-	// Calculate total pages
-	totalPages := int(math.Ceil(float64(total) / float64(*limit)))
-	if totalPages == 0 {
-		totalPages = 1
+	if count > 0 {
+		return validator.ValidationErrors{{
+			Field:   "setting_key",
+			Tag:     "unique",
+			Value:   settingKey,
+			Message: "setting_key is already taken",
+		}}
 	}
-
-	return &types.PaginatedResponse{
-		Data: responses,
-		Pagination: types.Pagination{
-			Total:      int(total),
-			Page:       *page,
-			PageSize:   *limit,
-			TotalPages: totalPages,
-		},
-	}, nil
+	return purgeDeleted(db, settingKey)
 }
 
-// GetAllForSelect gets all items for select box/dropdown options (simplified response)
-func (s *SettingsService) GetAllForSelect() ([]*Settings, error) {
-	var items []*Settings
-
-	query := s.DB.Model(&Settings{})
-
-	// Only select the necessary fields for select options
-	query = query.Select("id") // Only ID if no name/title field found
-
-	// Order by name/title for better UX
-	query = query.Order("id ASC")
-
-	if err := query.Find(&items).Error; err != nil {
-		s.Logger.Error("Failed to fetch items for select", logger.String("error", err.Error()))
-		return nil, err
-	}
-
-	return items, nil
+// purgeDeleted removes the deleted settings with the key
+func purgeDeleted(db *gorm.DB, settingKey string) error {
+	return db.Unscoped().Where("setting_key = ? AND deleted_at IS NOT NULL", settingKey).Delete(&Settings{}).Error
 }
 
 // GetByKey retrieves a setting value by its setting_key
-func (s *SettingsService) GetByKey(settingKey string) (*Settings, error) {
+func (s *SettingsService) GetByKey(ctx context.Context, settingKey string) (*Settings, error) {
 	item := &Settings{}
-	if err := s.DB.Where("setting_key = ?", settingKey).First(item).Error; err != nil {
+	if err := s.DB.WithContext(ctx).Where("setting_key = ?", settingKey).First(item).Error; err != nil {
 		s.Logger.Error("failed to get setting by key",
 			logger.String("error", err.Error()),
 			logger.String("setting_key", settingKey))
@@ -421,10 +317,25 @@ func (s *SettingsService) GetByKey(settingKey string) (*Settings, error) {
 	return item, nil
 }
 
+// GetPublic returns the values of the public settings by key
+func (s *SettingsService) GetPublic(ctx context.Context) (map[string]any, error) {
+	var items []*Settings
+	if err := s.DB.WithContext(ctx).Where("is_public = ?", true).Order("setting_key").Find(&items).Error; err != nil {
+		s.Logger.Error("failed to get public settings", logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	values := make(map[string]any, len(items))
+	for _, item := range items {
+		values[item.SettingKey] = item.Value()
+	}
+	return values, nil
+}
+
 // GetByGroup retrieves all settings in a group
-func (s *SettingsService) GetByGroup(group string) ([]*Settings, error) {
+func (s *SettingsService) GetByGroup(ctx context.Context, group string) ([]*Settings, error) {
 	var items []*Settings
-	if err := s.DB.Where("group = ?", group).Find(&items).Error; err != nil {
+	if err := s.DB.WithContext(ctx).Where("group = ?", group).Find(&items).Error; err != nil {
 		s.Logger.Error("failed to get settings by group",
 			logger.String("error", err.Error()),
 			logger.String("group", group))
@@ -434,8 +345,8 @@ func (s *SettingsService) GetByGroup(group string) ([]*Settings, error) {
 }
 
 // GetStringValue gets a string setting value by key
-func (s *SettingsService) GetStringValue(settingKey string, defaultValue string) string {
-	setting, err := s.GetByKey(settingKey)
+func (s *SettingsService) GetStringValue(ctx context.Context, settingKey string, defaultValue string) string {
+	setting, err := s.GetByKey(ctx, settingKey)
 	if err != nil {
 		return defaultValue
 	}
@@ -446,8 +357,8 @@ func (s *SettingsService) GetStringValue(settingKey string, defaultValue string)
 }
 
 // GetIntValue gets an integer setting value by key
-func (s *SettingsService) GetIntValue(settingKey string, defaultValue int) int {
-	setting, err := s.GetByKey(settingKey)
+func (s *SettingsService) GetIntValue(ctx context.Context, settingKey string, defaultValue int) int {
+	setting, err := s.GetByKey(ctx, settingKey)
 	if err != nil {
 		return defaultValue
 	}
@@ -458,8 +369,8 @@ func (s *SettingsService) GetIntValue(settingKey string, defaultValue int) int {
 }
 
 // GetFloatValue gets a float setting value by key
-func (s *SettingsService) GetFloatValue(settingKey string, defaultValue float64) float64 {
-	setting, err := s.GetByKey(settingKey)
+func (s *SettingsService) GetFloatValue(ctx context.Context, settingKey string, defaultValue float64) float64 {
+	setting, err := s.GetByKey(ctx, settingKey)
 	if err != nil {
 		return defaultValue
 	}
@@ -470,8 +381,8 @@ func (s *SettingsService) GetFloatValue(settingKey string, defaultValue float64)
 }
 
 // GetBoolValue gets a boolean setting value by key
-func (s *SettingsService) GetBoolValue(settingKey string, defaultValue bool) bool {
-	setting, err := s.GetByKey(settingKey)
+func (s *SettingsService) GetBoolValue(ctx context.Context, settingKey string, defaultValue bool) bool {
+	setting, err := s.GetByKey(ctx, settingKey)
 	if err != nil {
 		return defaultValue
 	}
@@ -482,32 +393,32 @@ func (s *SettingsService) GetBoolValue(settingKey string, defaultValue bool) boo
 }
 
 // SetStringValue creates or updates a string setting
-func (s *SettingsService) SetStringValue(settingKey, label, group, description string, value string, isPublic bool) error {
-	return s.SetValue(settingKey, label, group, "string", value, 0, 0.0, false, description, isPublic)
+func (s *SettingsService) SetStringValue(ctx context.Context, settingKey, label, group, description string, value string, isPublic bool) error {
+	return s.SetValue(ctx, settingKey, label, group, "string", value, 0, 0.0, false, description, isPublic)
 }
 
 // SetIntValue creates or updates an integer setting
-func (s *SettingsService) SetIntValue(settingKey, label, group, description string, value int, isPublic bool) error {
-	return s.SetValue(settingKey, label, group, "int", "", value, 0.0, false, description, isPublic)
+func (s *SettingsService) SetIntValue(ctx context.Context, settingKey, label, group, description string, value int, isPublic bool) error {
+	return s.SetValue(ctx, settingKey, label, group, "int", "", value, 0.0, false, description, isPublic)
 }
 
 // SetFloatValue creates or updates a float setting
-func (s *SettingsService) SetFloatValue(settingKey, label, group, description string, value float64, isPublic bool) error {
-	return s.SetValue(settingKey, label, group, "float", "", 0, value, false, description, isPublic)
+func (s *SettingsService) SetFloatValue(ctx context.Context, settingKey, label, group, description string, value float64, isPublic bool) error {
+	return s.SetValue(ctx, settingKey, label, group, "float", "", 0, value, false, description, isPublic)
 }
 
 // SetBoolValue creates or updates a boolean setting
-func (s *SettingsService) SetBoolValue(settingKey, label, group, description string, value bool, isPublic bool) error {
-	return s.SetValue(settingKey, label, group, "bool", "", 0, 0.0, value, description, isPublic)
+func (s *SettingsService) SetBoolValue(ctx context.Context, settingKey, label, group, description string, value bool, isPublic bool) error {
+	return s.SetValue(ctx, settingKey, label, group, "bool", "", 0, 0.0, value, description, isPublic)
 }
 
 // SetValue creates or updates a setting value by key
-func (s *SettingsService) SetValue(settingKey, label, group, settingType string, valueString string, valueInt int, valueFloat float64, valueBool bool, description string, isPublic bool) error {
+func (s *SettingsService) SetValue(ctx context.Context, settingKey, label, group, settingType string, valueString string, valueInt int, valueFloat float64, valueBool bool, description string, isPublic bool) error {
 	// Try to find existing setting
-	existing, err := s.GetByKey(settingKey)
+	existing, err := s.GetByKey(ctx, settingKey)
 	if err != nil {
 		// Create new setting
-		_, createErr := s.Create(&CreateSettingsRequest{
+		_, createErr := s.Create(ctx, &CreateSettingsRequest{
 			SettingKey:  settingKey,
 			Label:       label,
 			Group:       group,
@@ -519,28 +430,40 @@ func (s *SettingsService) SetValue(settingKey, label, group, settingType string,
 			Description: description,
 			IsPublic:    isPublic,
 		})
-		return createErr
-	}
-
-	// Update existing setting
-	_, updateErr := s.Update(existing.Id, &UpdateSettingsRequest{
-		SettingKey:  settingKey,
-		Label:       label,
-		Group:       group,
-		Type:        settingType,
-		ValueString: valueString,
-		ValueInt:    valueInt,
-		ValueFloat:  valueFloat,
+		if createErr == nil {
+			return nil
+		}
+		// A concurrent call may have created the setting in the meantime
+		if existing, err = s.GetByKey(ctx, settingKey); err != nil {
+			return createErr
+		}
+	}
+
+	// Update the value of the existing setting, and its label, group and description when given
+	req := &UpdateSettingsRequest{
+		Type:        &settingType,
+		ValueString: &valueString,
+		ValueInt:    &valueInt,
+		ValueFloat:  &valueFloat,
 		ValueBool:   &valueBool,
-		Description: description,
 		IsPublic:    &isPublic,
-	})
+	}
+	if label != "" {
+		req.Label = &label
+	}
+	if group != "" {
+		req.Group = &group
+	}
+	if description != "" {
+		req.Description = &description
+	}
+	_, updateErr := s.Update(ctx, existing.Id, req)
 	return updateErr
 }
 
 // Get retrieves a setting value by key and returns any
-func (s *SettingsService) Get(settingKey string, defaultValue any) any {
-	setting, err := s.GetByKey(settingKey)
+func (s *SettingsService) Get(ctx context.Context, settingKey string, defaultValue any) any {
+	setting, err := s.GetByKey(ctx, settingKey)
 	if err != nil {
 		return defaultValue
 	}
@@ -560,16 +483,16 @@ func (s *SettingsService) Get(settingKey string, defaultValue any) any {
 }
 
 // Set creates or updates a setting with automatic type detection
-func (s *SettingsService) Set(settingKey, label, group, description string, value any, isPublic bool) error {
+func (s *SettingsService) Set(ctx context.Context, settingKey, label, group, description string, value any, isPublic bool) error {
 	switch v := value.(type) {
 	case string:
-		return s.SetStringValue(settingKey, label, group, description, v, isPublic)
+		return s.SetStringValue(ctx, settingKey, label, group, description, v, isPublic)
 	case int:
-		return s.SetIntValue(settingKey, label, group, description, v, isPublic)
+		return s.SetIntValue(ctx, settingKey, label, group, description, v, isPublic)
 	case float64:
-		return s.SetFloatValue(settingKey, label, group, description, v, isPublic)
+		return s.SetFloatValue(ctx, settingKey, label, group, description, v, isPublic)
 	case bool:
-		return s.SetBoolValue(settingKey, label, group, description, v, isPublic)
+		return s.SetBoolValue(ctx, settingKey, label, group, description, v, isPublic)
 	default:
 		return fmt.Errorf("unsupported setting type: %T", value)
 	}
@@ -0,0 +1,38 @@
+package settings_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"base/core/app/settings"
+	"base/core/testutil"
+)
+
+func TestUpdateSettingClearsValues(t *testing.T) {
+	app := testutil.New(t, testutil.Options{})
+	admin := app.CreateUser("Super Admin")
+	setting := &settings.Settings{
+		SettingKey: "test_limit", Label: "Limit", Group: "test", Type: "int",
+		ValueInt: 5, Description: "A limit", IsPublic: true,
+	}
+	if err := app.DB.Create(setting).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	app.As(admin).PUT(fmt.Sprintf("/api/settings/%d", setting.Id), map[string]any{
+		"value_int": 0, "description": "", "is_public": false,
+	}).AssertStatus(http.StatusOK)
+
+	var stored settings.Settings
+	if err := app.DB.First(&stored, setting.Id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.ValueInt != 0 || stored.Description != "" || stored.IsPublic {
+		t.Errorf("value_int = %d, description = %q, is_public = %t, want them cleared",
+			stored.ValueInt, stored.Description, stored.IsPublic)
+	}
+	if stored.Label != setting.Label {
+		t.Errorf("label = %q, want it unchanged as %q", stored.Label, setting.Label)
+	}
+}
@@ -1,6 +1,9 @@
 package settings
 
 import (
+	"fmt"
+	"slices"
+
 	"base/core/validator"
 )
 
@@ -21,7 +24,10 @@ func ValidateSettingsCreateRequest(req *CreateSettingsRequest) error {
 	}
 
 	// Use Base core validator
-	return validate.Validate(req)
+	if errs := validate.Validate(req); len(errs) > 0 {
+		return errs
+	}
+	return nil
 }
 
 // ValidateSettingsUpdateRequest validates the update request
@@ -48,7 +54,82 @@ func ValidateSettingsUpdateRequest(req *UpdateSettingsRequest, id uint) error {
 		}
 	}
 
-	// Skip validation for update requests - all fields are optional
+	// All fields are optional, but the key and the type can't be cleared
+	if req.SettingKey != nil && *req.SettingKey == "" {
+		return validator.ValidationErrors{
+			{
+				Field:   "setting_key",
+				Tag:     "required",
+				Value:   "",
+				Message: "setting_key cannot be empty",
+			},
+		}
+	}
+	if req.Type != nil && *req.Type == "" {
+		return validator.ValidationErrors{
+			{
+				Field:   "type",
+				Tag:     "required",
+				Value:   "",
+				Message: "type cannot be empty",
+			},
+		}
+	}
+	if req.Type != nil {
+		return validateSettingType(*req.Type)
+	}
+	return nil
+}
+
+// settingTypes are the types of setting values; each type has its own value column
+var settingTypes = []string{"string", "int", "float", "bool"}
+
+// validateSettingType checks that a setting type is one of settingTypes
+func validateSettingType(settingType string) error {
+	if slices.Contains(settingTypes, settingType) {
+		return nil
+	}
+	return validator.ValidationErrors{
+		{
+			Field:   "type",
+			Tag:     "oneof",
+			Value:   settingType,
+			Param:   "string int float bool",
+			Message: "type must be one of: string int float bool",
+		},
+	}
+}
+
+// validateSettingValue checks that a setting has no value besides the one of its type, e.g.
+// that an int setting has no value_string
+func validateSettingValue(settingType string, valueString string, valueInt int, valueFloat float64, valueBool bool) error {
+	values := []struct {
+		settingType string
+		field       string
+		set         bool
+		value       any
+	}{
+		{"string", "value_string", valueString != "", valueString},
+		{"int", "value_int", valueInt != 0, valueInt},
+		{"float", "value_float", valueFloat != 0, valueFloat},
+		{"bool", "value_bool", valueBool, valueBool},
+	}
+
+	var errs validator.ValidationErrors
+	for _, v := range values {
+		if v.set && v.settingType != settingType {
+			errs = append(errs, validator.ValidationError{
+				Field:   v.field,
+				Tag:     "excluded_with",
+				Value:   fmt.Sprint(v.value),
+				Param:   "type " + settingType,
+				Message: fmt.Sprintf("%s can't be set together with type %s", v.field, settingType),
+			})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
 	return nil
 }
 
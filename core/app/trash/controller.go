@@ -0,0 +1,163 @@
+package trash
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"base/core/confirm"
+	"base/core/router"
+	"base/core/translation"
+	"base/core/types"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+type TrashController struct {
+	Service *TrashService
+}
+
+func NewTrashController(service *TrashService) *TrashController {
+	return &TrashController{
+		Service: service,
+	}
+}
+
+// Routes registers the trash endpoints; the group is restricted to admins by the module
+func (c *TrashController) Routes(router *router.RouterGroup) {
+	router.GET("/trash", c.List)                       // List deleted records
+	router.DELETE("/trash", c.Purge)                   // Delete records for good, with a confirmation
+	router.POST("/trash/:type/:id/restore", c.Restore) // Restore
+}
+
+// ListTrash godoc
+// @Summary List deleted records
+// @Description Get the records of every module deleted in the last days, the most recently deleted first, with who deleted them and where to restore them (Admin only)
+// @Tags Core/Trash
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param type query string false "Type of the records, e.g. pages"
+// @Param days query int false "Records deleted in the last days (1-365)" default(30)
+// @Param limit query int false "Most records listed (1-200)" default(50)
+// @Success 200 {array} Item
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /trash [get]
+func (c *TrashController) List(ctx *router.Context) error {
+	req := ListRequest{Type: ctx.Query("type")}
+	var errs validator.ValidationErrors
+	for _, param := range []struct {
+		name  string
+		value *int
+	}{{"days", &req.Days}, {"limit", &req.Limit}} {
+		value := ctx.Query(param.name)
+		if value == "" {
+			continue
+		}
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			errs = append(errs, validator.ValidationError{
+				Field: param.name, Tag: "numeric", Value: value,
+				Message: param.name + " must be a number",
+			})
+			continue
+		}
+		*param.value = number
+	}
+	if len(errs) > 0 {
+		return c.fail(ctx, errs, "Invalid trash filter")
+	}
+
+	items, err := c.Service.List(ctx.Request.Context(), req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to fetch trash")
+	}
+	return ctx.JSON(http.StatusOK, items)
+}
+
+// RestoreFromTrash godoc
+// @Summary Restore a deleted record
+// @Description Restore a record from the trash. What its module deleted along with it, e.g. its translations, isn't restored (Admin only)
+// @Tags Core/Trash
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param type path string true "Type of the record, e.g. pages"
+// @Param id path int true "Record id"
+// @Success 200 {object} Item
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /trash/{type}/{id}/restore [post]
+func (c *TrashController) Restore(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	item, err := c.Service.Restore(ctx.Request.Context(), ctx.Param("type"), uint(id))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to restore record")
+	}
+	return ctx.JSON(http.StatusOK, item)
+}
+
+// PurgeTrash godoc
+// @Summary Purge the trash
+// @Description Delete the records in the trash for good. Two phases: with dry_run=true the records that would be deleted are counted and a confirmation token valid for 5 minutes is returned; the purge then runs with that confirm_token, unless the records to delete changed (Admin only)
+// @Tags Core/Trash
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param type query string false "Type of the records, e.g. pages"
+// @Param older_than query int false "Only records deleted more than this many days ago"
+// @Param dry_run query bool false "Report what would be deleted and get a confirmation token"
+// @Param confirm_token query string false "Token of the dry run, required to purge"
+// @Success 200 {object} Purge
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 428 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /trash [delete]
+func (c *TrashController) Purge(ctx *router.Context) error {
+	req := PurgeRequest{Type: ctx.Query("type")}
+	if value := ctx.Query("older_than"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil {
+			return c.fail(ctx, validator.ValidationErrors{{
+				Field: "older_than", Tag: "numeric", Value: value,
+				Message: "older_than must be a number",
+			}}, "Invalid purge")
+		}
+		req.OlderThan = days
+	}
+
+	impact, err := c.Service.PurgeImpact(ctx.Request.Context(), req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to count the trash")
+	}
+	if answered, err := confirm.Require(ctx, impact); answered {
+		return err
+	}
+
+	purge, err := c.Service.Purge(ctx.Request.Context(), req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to purge the trash")
+	}
+	return ctx.JSON(http.StatusOK, purge)
+}
+
+// fail writes the error response of a service error
+func (c *TrashController) fail(ctx *router.Context, err error, message string) error {
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   translation.Error(ctx, err),
+			Details: translation.LocalizeValidation(ctx, validationErrors),
+		})
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Deleted record not found"})
+	}
+	return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: message + ": " + err.Error()})
+}
@@ -0,0 +1,71 @@
+package trash
+
+import (
+	"sort"
+	"sync"
+)
+
+// Entity is a model whose soft-deleted records are listed in the trash and can be restored
+// from it
+type Entity struct {
+	Name        string // Type of the records, e.g. "pages"
+	Model       any    // Model of the records, with a gorm.DeletedAt
+	Title       string // Column shown as the title of the records, e.g. "title"
+	DeleteEvent string // Event emitted with a deleted record (with a GetId method), to record who deleted it
+	UpdateEvent string // Event emitted with a restored record, e.g. so caches drop the lists it was missing from
+}
+
+var (
+	entitiesMu sync.RWMutex
+	entities   = map[string]Entity{}
+
+	// watch subscribes the trash module to the delete event of an entity. Modules register
+	// their entities before or after the trash module starts, depending on their order.
+	watch func(Entity)
+)
+
+// RegisterEntity adds a model to the trash, e.g. from a module's Init:
+//
+//	trash.RegisterEntity(trash.Entity{Name: "pages", Model: &Page{}, Title: "title",
+//		DeleteEvent: DeletePageEvent, UpdateEvent: UpdatePageEvent})
+func RegisterEntity(entity Entity) {
+	entitiesMu.Lock()
+	entities[entity.Name] = entity
+	subscribe := watch
+	entitiesMu.Unlock()
+
+	if subscribe != nil {
+		subscribe(entity)
+	}
+}
+
+// getEntity returns a registered entity by name
+func getEntity(name string) (Entity, bool) {
+	entitiesMu.RLock()
+	defer entitiesMu.RUnlock()
+	entity, ok := entities[name]
+	return entity, ok
+}
+
+// Entities returns the registered entities sorted by name
+func Entities() []Entity {
+	entitiesMu.RLock()
+	defer entitiesMu.RUnlock()
+	result := make([]Entity, 0, len(entities))
+	for _, entity := range entities {
+		result = append(result, entity)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// watchEntities subscribes to the registered entities and to those registered later
+func watchEntities(subscribe func(Entity)) {
+	entitiesMu.Lock()
+	watch = subscribe
+	entitiesMu.Unlock()
+
+	for _, entity := range Entities() {
+		subscribe(entity)
+	}
+}
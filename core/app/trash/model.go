@@ -0,0 +1,55 @@
+package trash
+
+import "time"
+
+const (
+	// DefaultDays is how far back the trash goes by default
+	DefaultDays = 30
+
+	// DefaultLimit and MaxLimit bound the records listed at once
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+// Deletion records who deleted a record; records deleted outside requests have none
+type Deletion struct {
+	Id         uint      `json:"id" gorm:"primarykey"`
+	EntityType string    `json:"entity_type" gorm:"size:100;index:idx_trash_deletions_entity,priority:1"`
+	EntityId   uint      `json:"entity_id" gorm:"index:idx_trash_deletions_entity,priority:2"`
+	DeletedBy  uint      `json:"deleted_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for the Deletion model
+func (m *Deletion) TableName() string {
+	return "trash_deletions"
+}
+
+// Item is a deleted record in the trash
+type Item struct {
+	Type       string    `json:"type"`
+	Id         uint      `json:"id"`
+	Title      string    `json:"title"`
+	DeletedBy  *uint     `json:"deleted_by"` // Null when it isn't known who deleted the record
+	DeletedAt  time.Time `json:"deleted_at"`
+	RestoreUrl string    `json:"restore_url,omitempty"` // POST restores the record; empty once restored
+}
+
+// ListRequest filters the trash
+type ListRequest struct {
+	Type  string // Type of the records, all types when empty
+	Days  int    // Records deleted in the last days, DefaultDays when 0
+	Limit int    // Most records listed, DefaultLimit when 0
+}
+
+// PurgeRequest narrows the records purged from the trash
+type PurgeRequest struct {
+	Type      string // Type of the records, all types when empty
+	OlderThan int    // Records deleted more than this many days ago, all deleted records when 0
+}
+
+// Purge reports the records deleted for good from the trash
+type Purge struct {
+	Purged map[string]int64 `json:"purged"` // Records purged by type
+	Total  int64            `json:"total"`
+}
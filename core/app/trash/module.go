@@ -0,0 +1,52 @@
+package trash
+
+import (
+	"base/core/app/authorization"
+	"base/core/module"
+	"base/core/router"
+
+	"gorm.io/gorm"
+)
+
+// Module provides GET /trash, one recycle bin for the soft-deleted records of the modules
+// registering their models with RegisterEntity, and restoring them
+type Module struct {
+	module.DefaultModule
+	DB         *gorm.DB
+	Service    *TrashService
+	Controller *TrashController
+}
+
+// Init creates and initializes the trash module with all dependencies
+func Init(deps module.Dependencies) module.Module {
+	registerEvents()
+	service := NewTrashService(deps.DB, deps.Emitter, deps.Storage, deps.Logger)
+
+	return &Module{
+		DB:         deps.DB,
+		Service:    service,
+		Controller: NewTrashController(service),
+	}
+}
+
+// Routes registers the module routes, restricted to admins
+func (m *Module) Routes(router *router.RouterGroup) {
+	group := router.Group("", authorization.RequireAdmin(authorization.NewAuthorizationService(m.DB)))
+	m.Controller.Routes(group)
+}
+
+func (m *Module) Init() error {
+	// App modules register their entities after the core modules started
+	m.Service.Listen()
+	return nil
+}
+
+func (m *Module) Migrate() error {
+	return m.DB.AutoMigrate(&Deletion{})
+}
+
+func (m *Module) GetModels() []any {
+	return []any{
+		&Deletion{},
+	}
+}
@@ -0,0 +1,383 @@
+package trash
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"base/core/confirm"
+	"base/core/emitter"
+	"base/core/logger"
+	"base/core/router/middleware"
+	"base/core/storage"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+const (
+	RestoreEvent = "trash.restore" // A record was restored from the trash, with its *Item
+	PurgeEvent   = "trash.purge"   // Records were deleted for good from the trash, with the *Purge
+)
+
+// registerEvents adds the events of the module to the event catalog
+func registerEvents() {
+	emitter.RegisterEvents(
+		emitter.EventType{Name: RestoreEvent, Description: "A record was restored from the trash", Payload: &Item{}},
+		emitter.EventType{Name: PurgeEvent, Description: "Records were deleted for good from the trash", Payload: &Purge{}},
+	)
+}
+
+// TrashService lists the soft-deleted records of the registered entities and restores them
+type TrashService struct {
+	DB      *gorm.DB
+	Emitter *emitter.Emitter
+	Storage *storage.ActiveStorage
+	Logger  logger.Logger
+
+	mu      sync.Mutex
+	watched map[string]bool
+}
+
+func NewTrashService(db *gorm.DB, emitter *emitter.Emitter, storage *storage.ActiveStorage, logger logger.Logger) *TrashService {
+	return &TrashService{
+		DB:      db,
+		Emitter: emitter,
+		Storage: storage,
+		Logger:  logger,
+		watched: make(map[string]bool),
+	}
+}
+
+// Listen records who deletes the records of the entities, registered so far and later
+func (s *TrashService) Listen() {
+	watchEntities(s.watch)
+}
+
+// watch records the user deleting the records of an entity, from the request context of
+// its delete event
+func (s *TrashService) watch(entity Entity) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entity.DeleteEvent == "" || s.watched[entity.Name] {
+		return
+	}
+	s.watched[entity.Name] = true
+
+	s.Emitter.OnContext(entity.DeleteEvent, func(ctx context.Context, data any) {
+		record, ok := data.(interface{ GetId() uint })
+		if !ok {
+			return
+		}
+		userId, ok := middleware.UserFromContext[uint](ctx)
+		if !ok {
+			return
+		}
+		deletion := &Deletion{EntityType: entity.Name, EntityId: record.GetId(), DeletedBy: userId}
+		if err := s.DB.WithContext(ctx).Create(deletion).Error; err != nil {
+			s.Logger.Error("failed to record deletion",
+				logger.String("error", err.Error()),
+				logger.String("entity_type", entity.Name),
+				logger.Int("entity_id", int(record.GetId())))
+		}
+	})
+}
+
+// List returns the records deleted in the last days, the most recently deleted first
+func (s *TrashService) List(ctx context.Context, req ListRequest) ([]*Item, error) {
+	if req.Days == 0 {
+		req.Days = DefaultDays
+	}
+	if req.Limit == 0 {
+		req.Limit = DefaultLimit
+	}
+	if err := validateListRequest(req); err != nil {
+		return nil, err
+	}
+
+	since := time.Now().AddDate(0, 0, -req.Days)
+	items := []*Item{}
+	for _, entity := range Entities() {
+		if req.Type != "" && entity.Name != req.Type {
+			continue
+		}
+		found, err := s.deleted(ctx, entity, since, req.Limit)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, found...)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool { return items[i].DeletedAt.After(items[j].DeletedAt) })
+	if len(items) > req.Limit {
+		items = items[:req.Limit]
+	}
+	return items, nil
+}
+
+// deleted returns the records of an entity deleted since a time, with who deleted them
+func (s *TrashService) deleted(ctx context.Context, entity Entity, since time.Time, limit int) ([]*Item, error) {
+	var rows []struct {
+		Id        uint
+		Title     string
+		DeletedAt time.Time
+	}
+	err := s.DB.WithContext(ctx).Unscoped().Model(newRecord(entity)).
+		Select("id, "+entity.Title+" AS title, deleted_at").
+		Where("deleted_at IS NOT NULL AND deleted_at >= ?", since).
+		Order("deleted_at desc").
+		Limit(limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch deleted %s: %w", entity.Name, err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uint, len(rows))
+	for i, row := range rows {
+		ids[i] = row.Id
+	}
+	var deletions []Deletion
+	err = s.DB.WithContext(ctx).Where("entity_type = ? AND entity_id IN ?", entity.Name, ids).
+		Order("id").Find(&deletions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch deletions of %s: %w", entity.Name, err)
+	}
+	deletedBy := make(map[uint]uint, len(deletions))
+	for _, deletion := range deletions {
+		deletedBy[deletion.EntityId] = deletion.DeletedBy
+	}
+
+	items := make([]*Item, len(rows))
+	for i, row := range rows {
+		items[i] = &Item{
+			Type:       entity.Name,
+			Id:         row.Id,
+			Title:      row.Title,
+			DeletedAt:  row.DeletedAt,
+			RestoreUrl: fmt.Sprintf("/api/trash/%s/%d/restore", entity.Name, row.Id),
+		}
+		if userId, ok := deletedBy[row.Id]; ok {
+			items[i].DeletedBy = &userId
+		}
+	}
+	return items, nil
+}
+
+// Restore clears the deleted_at of a record in the trash and emits the update event of its
+// entity with it. What its module deleted along with it, e.g. its translations, isn't
+// restored. Unknown types and records that aren't deleted return gorm.ErrRecordNotFound.
+func (s *TrashService) Restore(ctx context.Context, entityType string, id uint) (*Item, error) {
+	entity, ok := getEntity(entityType)
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	item := &Item{Type: entity.Name, Id: id}
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var row struct {
+			Title     string
+			DeletedAt time.Time
+		}
+		result := tx.Unscoped().Model(newRecord(entity)).
+			Select(entity.Title+" AS title, deleted_at").
+			Where("id = ? AND deleted_at IS NOT NULL", id).
+			Scan(&row)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		item.Title, item.DeletedAt = row.Title, row.DeletedAt
+
+		var deletion Deletion
+		err := tx.Where("entity_type = ? AND entity_id = ?", entity.Name, id).Order("id desc").Limit(1).Find(&deletion).Error
+		if err != nil {
+			return err
+		}
+		if deletion.Id != 0 {
+			item.DeletedBy = &deletion.DeletedBy
+		}
+
+		if err := tx.Unscoped().Model(newRecord(entity)).Where("id = ?", id).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return tx.Where("entity_type = ? AND entity_id = ?", entity.Name, id).Delete(&Deletion{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if entity.UpdateEvent != "" {
+		record := newRecord(entity)
+		if err := s.DB.WithContext(ctx).First(record, id).Error; err != nil {
+			return nil, err
+		}
+		s.Emitter.EmitContext(ctx, entity.UpdateEvent, record)
+	}
+	s.Emitter.EmitContext(ctx, RestoreEvent, item)
+
+	return item, nil
+}
+
+// PurgeImpact returns what purging the trash would delete, for its dry run
+func (s *TrashService) PurgeImpact(ctx context.Context, req PurgeRequest) (*confirm.Impact, error) {
+	entities, err := purgedEntities(req)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(entities))
+	for _, entity := range entities {
+		var count int64
+		if err := purged(s.DB.WithContext(ctx), entity, req).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("failed to count deleted %s: %w", entity.Name, err)
+		}
+		counts[entity.Name] = count
+	}
+	operation := fmt.Sprintf("trash.purge:%s:%d", req.Type, req.OlderThan)
+	return confirm.NewImpact(operation, counts, "will be deleted for good"), nil
+}
+
+// Purge deletes the records in the trash for good, in one transaction. They can't be
+// restored anymore. The attachments of the records are deleted with their files once the
+// transaction committed.
+func (s *TrashService) Purge(ctx context.Context, req PurgeRequest) (*Purge, error) {
+	entities, err := purgedEntities(req)
+	if err != nil {
+		return nil, err
+	}
+
+	purge := &Purge{Purged: make(map[string]int64, len(entities))}
+	var attachments []*storage.Attachment
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, entity := range entities {
+			var ids []uint
+			if err := purged(tx, entity, req).Pluck("id", &ids).Error; err != nil {
+				return fmt.Errorf("failed to fetch deleted %s: %w", entity.Name, err)
+			}
+			if len(ids) == 0 {
+				continue
+			}
+			if model, ok := newRecord(entity).(storage.Attachable); ok {
+				var found []*storage.Attachment
+				if err := tx.Where("model_type = ? AND model_id IN ?", model.GetModelName(), ids).Find(&found).Error; err != nil {
+					return fmt.Errorf("failed to fetch attachments of %s: %w", entity.Name, err)
+				}
+				attachments = append(attachments, found...)
+			}
+			result := tx.Unscoped().Where("id IN ?", ids).Delete(newRecord(entity))
+			if result.Error != nil {
+				return fmt.Errorf("failed to purge %s: %w", entity.Name, result.Error)
+			}
+			if err := tx.Where("entity_type = ? AND entity_id IN ?", entity.Name, ids).Delete(&Deletion{}).Error; err != nil {
+				return err
+			}
+			purge.Purged[entity.Name] = result.RowsAffected
+			purge.Total += result.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		s.Logger.Error("failed to purge trash", logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	for _, attachment := range attachments {
+		if err := s.Storage.Delete(ctx, attachment); err != nil {
+			s.Logger.Warn("failed to delete attachment of purged record",
+				logger.String("error", err.Error()),
+				logger.String("model_type", attachment.ModelType),
+				logger.Int("model_id", int(attachment.ModelId)))
+		}
+	}
+
+	s.Emitter.EmitContext(ctx, PurgeEvent, purge)
+	return purge, nil
+}
+
+// purged returns the query of the records of an entity a purge deletes
+func purged(db *gorm.DB, entity Entity, req PurgeRequest) *gorm.DB {
+	query := db.Unscoped().Model(newRecord(entity)).Where("deleted_at IS NOT NULL")
+	if req.OlderThan > 0 {
+		query = query.Where("deleted_at < ?", time.Now().AddDate(0, 0, -req.OlderThan))
+	}
+	return query
+}
+
+// purgedEntities checks a purge request and returns the entities it purges
+func purgedEntities(req PurgeRequest) ([]Entity, error) {
+	var errs validator.ValidationErrors
+	if err := validateType(req.Type); err != nil {
+		errs = append(errs, *err)
+	}
+	if req.OlderThan < 0 {
+		errs = append(errs, validator.ValidationError{
+			Field: "older_than", Tag: "min", Param: "0", Value: fmt.Sprint(req.OlderThan),
+			Message: "older_than must be at least 0",
+		})
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	if req.Type != "" {
+		entity, _ := getEntity(req.Type)
+		return []Entity{entity}, nil
+	}
+	return Entities(), nil
+}
+
+// newRecord returns a new record of the model of an entity
+func newRecord(entity Entity) any {
+	return reflect.New(reflect.TypeOf(entity.Model).Elem()).Interface()
+}
+
+// validateListRequest checks the filters of the trash
+func validateListRequest(req ListRequest) error {
+	var errs validator.ValidationErrors
+	if err := validateType(req.Type); err != nil {
+		errs = append(errs, *err)
+	}
+	if req.Days < 1 || req.Days > 365 {
+		errs = append(errs, validator.ValidationError{
+			Field: "days", Tag: "range", Param: "1-365", Value: fmt.Sprint(req.Days),
+			Message: "days must be between 1 and 365",
+		})
+	}
+	if req.Limit < 1 || req.Limit > MaxLimit {
+		errs = append(errs, validator.ValidationError{
+			Field: "limit", Tag: "range", Param: fmt.Sprintf("1-%d", MaxLimit), Value: fmt.Sprint(req.Limit),
+			Message: fmt.Sprintf("limit must be between 1 and %d", MaxLimit),
+		})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateType checks the type filter of a request, which may be empty
+func validateType(name string) *validator.ValidationError {
+	if name == "" {
+		return nil
+	}
+	if _, ok := getEntity(name); ok {
+		return nil
+	}
+	names := make([]string, 0)
+	for _, entity := range Entities() {
+		names = append(names, entity.Name)
+	}
+	return &validator.ValidationError{
+		Field: "type", Tag: "oneof", Param: strings.Join(names, " "), Value: name,
+		Message: fmt.Sprintf("type must be one of %s", strings.Join(names, ", ")),
+	}
+}
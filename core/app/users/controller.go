@@ -2,10 +2,16 @@ package users
 
 import (
 	"base/core/app/authorization"
+	"base/core/app/customfields"
+	"base/core/confirm"
+	"base/core/crud"
 	"base/core/logger"
 	"base/core/router"
+	"base/core/security"
 	"base/core/storage"
+	"base/core/translation"
 	"base/core/types"
+	"base/core/validator"
 	"errors"
 	"net/http"
 	"strconv"
@@ -16,16 +22,18 @@ import (
 )
 
 type UserController struct {
-	service *UserService
-	storage *storage.ActiveStorage
-	logger  logger.Logger
+	service       *UserService
+	storage       *storage.ActiveStorage
+	logger        logger.Logger
+	authorization *authorization.AuthorizationService
 }
 
 func NewUserController(service *UserService, storage *storage.ActiveStorage, logger logger.Logger) *UserController {
 	return &UserController{
-		service: service,
-		storage: storage,
-		logger:  logger,
+		service:       service,
+		storage:       storage,
+		logger:        logger,
+		authorization: authorization.NewAuthorizationService(service.db),
 	}
 }
 
@@ -37,7 +45,7 @@ func (c *UserController) Routes(router *router.RouterGroup) {
 	router.PUT("/profile/password", c.UpdatePassword)
 
 	// User management endpoints - admin only
-	adminOnlyMiddleware := authorization.RequireRole("Admin")
+	adminOnlyMiddleware := authorization.RequireAdmin(c.authorization)
 	usersGroup := router.Group("/users")
 	usersGroup.Use(adminOnlyMiddleware)
 
@@ -46,7 +54,11 @@ func (c *UserController) Routes(router *router.RouterGroup) {
 	usersGroup.GET("/all", c.ListAll)           // Unpaginated list
 	usersGroup.GET("/:id", c.Get)               // Get by ID
 	usersGroup.PUT("/:id", c.Update)            // Update
+	usersGroup.PUT("/:id/role", c.UpdateRole, authorization.RequirePermission(c.authorization, "role", "assign")) // Change role
 	usersGroup.PUT("/:id/password", c.ChangePassword) // Change password
+	usersGroup.POST("/:id/archive", c.Archive)        // Hide from lists
+	usersGroup.POST("/:id/unarchive", c.Unarchive)    // Bring back
+	usersGroup.POST("/:id/transfer-ownership", c.TransferOwnership) // Hand over owned records
 	usersGroup.GET("/:id/tasks", c.GetUserTasks)      // Get tasks
 	usersGroup.DELETE("/:id", c.Delete)               // Delete
 }
@@ -73,7 +85,7 @@ func (c *UserController) GetProfile(ctx *router.Context) error {
 		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid user Id"})
 	}
 
-	item, err := c.service.GetById(id)
+	item, err := c.service.GetById(ctx.Request.Context(), id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User not found"})
@@ -82,7 +94,7 @@ func (c *UserController) GetProfile(ctx *router.Context) error {
 		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch user"})
 	}
 
-	return ctx.JSON(http.StatusOK, item.ToResponse())
+	return ctx.JSON(http.StatusOK, item.ToLocalResponse(ctx))
 }
 
 // UpdateProfile godoc
@@ -109,14 +121,21 @@ func (c *UserController) UpdateProfile(ctx *router.Context) error {
 	if err := ctx.ShouldBind(&req); err != nil {
 		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid input: " + err.Error()})
 	}
+	req.CustomFields = nil // Custom fields are managed by admins
 
-	item, err := c.service.Update(id, &req)
+	item, err := c.service.Update(ctx.Request.Context(), id, &req)
 	if err != nil {
+		if validationErrors, ok := err.(validator.ValidationErrors); ok {
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+				Error:   translation.Error(ctx, err),
+				Details: translation.LocalizeValidation(ctx, validationErrors),
+			})
+		}
 		c.logger.Error("Failed to update user", logger.Uint("user_id", id))
 		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to update user: " + err.Error()})
 	}
 
-	return ctx.JSON(http.StatusOK, item.ToResponse())
+	return ctx.JSON(http.StatusOK, item.ToLocalResponse(ctx))
 }
 
 // UpdateAvatar godoc
@@ -150,10 +169,13 @@ func (c *UserController) UpdateAvatar(ctx *router.Context) error {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User not found"})
 		}
+		if errors.Is(err, storage.ErrInvalidFile) {
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+		}
 		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to update avatar: " + err.Error()})
 	}
 
-	return ctx.JSON(http.StatusOK, updatedUser.ToResponse())
+	return ctx.JSON(http.StatusOK, updatedUser.ToLocalResponse(ctx))
 }
 
 // UpdatePassword godoc
@@ -186,7 +208,7 @@ func (c *UserController) UpdatePassword(ctx *router.Context) error {
 		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "New password must be at least 6 characters long"})
 	}
 
-	err := c.service.UpdatePassword(id, &req)
+	err := c.service.UpdatePassword(ctx.Request.Context(), id, &req)
 	if err != nil {
 		c.logger.Error("Failed to update password", logger.Uint("user_id", id))
 		switch {
@@ -199,6 +221,8 @@ func (c *UserController) UpdatePassword(ctx *router.Context) error {
 		}
 	}
 
+	security.Emit(c.service.emitter, ctx, security.PasswordChangedEvent, id, "", map[string]any{"via": "profile"})
+
 	return ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "Password updated successfully"})
 }
 
@@ -206,7 +230,7 @@ func (c *UserController) UpdatePassword(ctx *router.Context) error {
 
 // Create godoc
 // @Summary Create a new User
-// @Description Create a new User with the input payload (Admin only)
+// @Description Create a new User with the input payload. New users get the default role; role_id is rejected, roles change with PUT /users/{id}/role (Admin only)
 // @Tags Core/Users
 // @Security ApiKeyAuth
 // @Security BearerAuth
@@ -223,12 +247,18 @@ func (c *UserController) Create(ctx *router.Context) error {
 		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
 	}
 
-	item, err := c.service.Create(&req)
+	item, err := c.service.Create(ctx.Request.Context(), &req)
 	if err != nil {
+		if validationErrors, ok := err.(validator.ValidationErrors); ok {
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+				Error:   translation.Error(ctx, err),
+				Details: translation.LocalizeValidation(ctx, validationErrors),
+			})
+		}
 		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to create user: " + err.Error()})
 	}
 
-	return ctx.JSON(http.StatusCreated, item.ToResponse())
+	return c.respond(ctx, http.StatusCreated, item)
 }
 
 // Get godoc
@@ -250,12 +280,12 @@ func (c *UserController) Get(ctx *router.Context) error {
 		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
 	}
 
-	item, err := c.service.GetById(uint(id))
+	item, err := c.service.GetById(ctx.Request.Context(), uint(id))
 	if err != nil {
 		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User not found"})
 	}
 
-	return ctx.JSON(http.StatusOK, item.ToResponse())
+	return c.respond(ctx, http.StatusOK, item)
 }
 
 // List godoc
@@ -270,48 +300,41 @@ func (c *UserController) Get(ctx *router.Context) error {
 // @Param limit query int false "Number of items per page"
 // @Param sort query string false "Sort field (id, created_at, updated_at, first_name, last_name, username, phone, email, role_id)"
 // @Param order query string false "Sort order (asc, desc)"
+// @Param cf query string false "Custom field filters as cf[key]=value, e.g. cf[department]=sales"
+// @Param archived query string false "true for archived users only, all for both; archived users are left out by default"
 // @Success 200 {object} types.PaginatedResponse
 // @Failure 400 {object} types.ErrorResponse
 // @Failure 500 {object} types.ErrorResponse
 // @Router /users [get]
 func (c *UserController) List(ctx *router.Context) error {
-	var page, limit *int
-	var sortBy, sortOrder *string
-
-	// Parse page parameter
-	if pageStr := ctx.Query("page"); pageStr != "" {
-		if pageNum, err := strconv.Atoi(pageStr); err == nil && pageNum > 0 {
-			page = &pageNum
-		} else {
-			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid page number"})
-		}
-	}
-
-	// Parse limit parameter
-	if limitStr := ctx.Query("limit"); limitStr != "" {
-		if limitNum, err := strconv.Atoi(limitStr); err == nil && limitNum > 0 {
-			limit = &limitNum
-		} else {
-			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid limit number"})
-		}
+	params, err := ctx.ListParams(sortFields...)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
 	}
 
-	// Parse sort parameters
-	if sortStr := ctx.Query("sort"); sortStr != "" {
-		sortBy = &sortStr
+	archived, err := crud.ParseArchived(ctx.Query("archived"))
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   translation.Error(ctx, err),
+			Details: translation.LocalizeValidation(ctx, err.(validator.ValidationErrors)),
+		})
 	}
 
-	if orderStr := ctx.Query("order"); orderStr != "" {
-		if orderStr == "asc" || orderStr == "desc" {
-			sortOrder = &orderStr
-		} else {
-			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid sort order. Use 'asc' or 'desc'"})
+	paginatedResponse, err := c.service.GetAll(ctx.Request.Context(), params.Page, params.Limit, params.SortBy, params.SortOrder, customfields.ParseFilter(ctx.Request.URL.Query()), archived)
+	if err != nil {
+		if validationErrors, ok := err.(validator.ValidationErrors); ok {
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+				Error:   translation.Error(ctx, err),
+				Details: translation.LocalizeValidation(ctx, validationErrors),
+			})
 		}
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch users: " + err.Error()})
 	}
 
-	paginatedResponse, err := c.service.GetAll(page, limit, sortBy, sortOrder)
-	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch users: " + err.Error()})
+	if responses, ok := paginatedResponse.Data.([]*UserResponse); ok {
+		for _, response := range responses {
+			response.Localize(ctx)
+		}
 	}
 
 	return ctx.JSON(http.StatusOK, paginatedResponse)
@@ -329,7 +352,7 @@ func (c *UserController) List(ctx *router.Context) error {
 // @Failure 500 {object} types.ErrorResponse
 // @Router /users/all [get]
 func (c *UserController) ListAll(ctx *router.Context) error {
-	items, err := c.service.GetAllForSelect()
+	items, err := c.service.GetAllForSelect(ctx.Request.Context())
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch select options: " + err.Error()})
 	}
@@ -369,28 +392,106 @@ func (c *UserController) Update(ctx *router.Context) error {
 		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
 	}
 
-	item, err := c.service.Update(uint(id), &req)
+	item, err := c.service.Update(ctx.Request.Context(), uint(id), &req)
 	if err != nil {
+		if validationErrors, ok := err.(validator.ValidationErrors); ok {
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+				Error:   translation.Error(ctx, err),
+				Details: translation.LocalizeValidation(ctx, validationErrors),
+			})
+		}
 		if strings.Contains(err.Error(), "record not found") {
 			return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User not found"})
 		}
 		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to update user: " + err.Error()})
 	}
 
-	return ctx.JSON(http.StatusOK, item.ToResponse())
+	return c.respond(ctx, http.StatusOK, item)
+}
+
+// UpdateRole godoc
+// @Summary Change the role of a User
+// @Description Change the role of a User by its id; needs the role:assign permission. Users can't change their own role, nor grant or take away a role with permissions their own role lacks. The change is recorded as an activity.
+// @Tags Core/Users
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "User id"
+// @Param role body UpdateRoleRequest true "Update role request"
+// @Success 200 {object} UserResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 403 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /users/{id}/role [put]
+func (c *UserController) UpdateRole(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	var req UpdateRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	// Without a user, e.g. with an API key, the change would be as unlimited as one from the command line
+	changedBy := ctx.GetUint("user_id")
+	if changedBy == 0 {
+		return ctx.JSON(http.StatusForbidden, types.ErrorResponse{Error: "Roles can only be changed by users"})
+	}
+
+	change := &RoleChange{
+		ChangedBy: changedBy,
+		IpAddress: ctx.ClientIP(),
+		UserAgent: ctx.GetHeader("User-Agent"),
+	}
+	item, err := c.service.ChangeRole(ctx.Request.Context(), uint(id), req.RoleId, change)
+	if err != nil {
+		if validationErrors, ok := err.(validator.ValidationErrors); ok {
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+				Error:   translation.Error(ctx, err),
+				Details: translation.LocalizeValidation(ctx, validationErrors),
+			})
+		}
+		if errors.Is(err, ErrOwnRole) || errors.Is(err, ErrRoleAbove) {
+			return ctx.JSON(http.StatusForbidden, types.ErrorResponse{Error: err.Error()})
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User not found"})
+		}
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to change role: " + err.Error()})
+	}
+	if change.User != nil {
+		security.Emit(c.service.emitter, ctx, security.RoleChangedEvent, item.Id, item.Email, map[string]any{
+			"old_role_id": change.OldRoleId,
+			"old_role":    change.OldRole,
+			"new_role_id": change.NewRoleId,
+			"new_role":    change.NewRole,
+		})
+	}
+
+	return c.respond(ctx, http.StatusOK, item)
 }
 
 // Delete godoc
 // @Summary Delete a User
-// @Description Delete a User by its id (Admin only)
+// @Description Delete a User by its id. With with_content=true their owned records, e.g. their media and pages, are deleted too, media with their files and only if no record uses them (409 otherwise), in two phases: with dry_run=true the records that would be deleted are counted and a confirmation token valid for 5 minutes is returned; the deletion then runs with that confirm_token (Admin only)
 // @Tags Core/Users
 // @Security ApiKeyAuth
 // @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param id path int true "User id"
+// @Param with_content query bool false "Delete the owned records of the user too"
+// @Param dry_run query bool false "With with_content, report what would be deleted and get a confirmation token"
+// @Param confirm_token query string false "Token of the dry run, required with with_content"
 // @Success 204 {object} nil
 // @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 409 {object} types.ErrorResponse
+// @Failure 428 {object} types.ErrorResponse
 // @Failure 500 {object} types.ErrorResponse
 // @Router /users/{id} [delete]
 func (c *UserController) Delete(ctx *router.Context) error {
@@ -399,7 +500,39 @@ func (c *UserController) Delete(ctx *router.Context) error {
 		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
 	}
 
-	if err := c.service.Delete(uint(id)); err != nil {
+	withContent := false
+	if value := ctx.Query("with_content"); value != "" {
+		if withContent, err = strconv.ParseBool(value); err != nil {
+			validationErrors := validator.ValidationErrors{{
+				Field: "with_content", Tag: "invalid", Value: value,
+				Message: "with_content must be true or false",
+			}}
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+				Error:   translation.Error(ctx, validationErrors),
+				Details: translation.LocalizeValidation(ctx, validationErrors),
+			})
+		}
+	}
+
+	deleteUser := c.service.Delete
+	if withContent {
+		impact, err := c.service.DeleteContentImpact(ctx.Request.Context(), uint(id))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User not found"})
+		}
+		if err != nil {
+			return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to count the content of the user: " + err.Error()})
+		}
+		if answered, err := confirm.Require(ctx, impact); answered {
+			return err
+		}
+		deleteUser = c.service.DeleteWithContent
+	}
+
+	if err := deleteUser(ctx.Request.Context(), uint(id)); err != nil {
+		if errors.Is(err, ErrContentNotDeleted) {
+			return ctx.JSON(http.StatusConflict, types.ErrorResponse{Error: err.Error()})
+		}
 		if strings.Contains(err.Error(), "record not found") {
 			return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User not found"})
 		}
@@ -410,6 +543,101 @@ func (c *UserController) Delete(ctx *router.Context) error {
 	return nil
 }
 
+// Archive godoc
+// @Summary Archive a User
+// @Description Hide a User from lists and select options without deleting them; they keep their data and role. Lists show archived users with archived=true (Admin only)
+// @Tags Core/Users
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "User id"
+// @Success 200 {object} UserResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /users/{id}/archive [post]
+func (c *UserController) Archive(ctx *router.Context) error {
+	return c.archive(ctx, true)
+}
+
+// Unarchive godoc
+// @Summary Unarchive a User
+// @Description Bring an archived User back to lists and select options (Admin only)
+// @Tags Core/Users
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "User id"
+// @Success 200 {object} UserResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /users/{id}/unarchive [post]
+func (c *UserController) Unarchive(ctx *router.Context) error {
+	return c.archive(ctx, false)
+}
+
+// archive archives the user of the :id parameter or brings them back
+func (c *UserController) archive(ctx *router.Context, archived bool) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	item, err := c.service.Archive(ctx.Request.Context(), uint(id), archived)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User not found"})
+		}
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to archive user: " + err.Error()})
+	}
+
+	return c.respond(ctx, http.StatusOK, item)
+}
+
+// TransferOwnership godoc
+// @Summary Transfer the records of a User
+// @Description Hand over the records a User owns in every module (media, pages...) to another User in one transaction, e.g. when they are offboarded; types narrows it to some modules. Deleted users can be handed over from (Admin only)
+// @Tags Core/Users
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "User id"
+// @Param transfer body TransferOwnershipRequest true "Transfer request"
+// @Success 200 {object} OwnershipTransfer
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /users/{id}/transfer-ownership [post]
+func (c *UserController) TransferOwnership(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	var req TransferOwnershipRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	transfer, err := c.service.TransferOwnership(ctx.Request.Context(), uint(id), &req)
+	if err != nil {
+		if validationErrors, ok := err.(validator.ValidationErrors); ok {
+			return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+				Error:   translation.Error(ctx, err),
+				Details: translation.LocalizeValidation(ctx, validationErrors),
+			})
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User not found"})
+		}
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to transfer ownership: " + err.Error()})
+	}
+
+	return ctx.JSON(http.StatusOK, transfer)
+}
+
 // ChangePassword godoc
 // @Summary Change user password
 // @Description Change the password for a specific user (Admin only)
@@ -436,7 +664,7 @@ func (c *UserController) ChangePassword(ctx *router.Context) error {
 		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
 	}
 
-	if err := c.service.ChangePassword(uint(id), req.NewPassword, req.CurrentPassword); err != nil {
+	if err := c.service.ChangePassword(ctx.Request.Context(), uint(id), req.NewPassword, req.CurrentPassword); err != nil {
 		if strings.Contains(err.Error(), "record not found") {
 			return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User not found"})
 		}
@@ -445,6 +673,7 @@ func (c *UserController) ChangePassword(ctx *router.Context) error {
 		}
 		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to change password: " + err.Error()})
 	}
+	security.Emit(c.service.emitter, ctx, security.PasswordChangedEvent, uint(id), "", map[string]any{"via": "admin"})
 
 	return ctx.JSON(http.StatusOK, types.SuccessResponse{
 		Success: true,
@@ -478,3 +707,14 @@ func (c *UserController) GetUserTasks(ctx *router.Context) error {
 
 	return ctx.JSON(http.StatusOK, map[string]interface{}{"data": tasks})
 }
+
+// respond writes a user response with the values of its custom fields
+func (c *UserController) respond(ctx *router.Context, status int, item *User) error {
+	response := item.ToLocalResponse(ctx)
+	customFields, err := customfields.Records.Get("users", item.Id)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to load custom fields: " + err.Error()})
+	}
+	response.CustomFields = customFields
+	return ctx.JSON(status, response)
+}
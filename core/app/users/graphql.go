@@ -0,0 +1,149 @@
+package users
+
+import (
+	"math"
+	"strconv"
+
+	"base/core/app/authorization"
+	"base/core/app/customfields"
+	"base/core/crud"
+	"base/core/graphql"
+	"base/core/types"
+)
+
+// registerGraphQL adds the User type and the user and users query fields to the GraphQL
+// schema. Like the /users routes they are for admins only.
+func registerGraphQL(service *UserService) {
+	db := service.db
+	admin := graphql.RequireAdmin(db)
+	userId := func(source any) (uint, bool) {
+		if user, ok := source.(*User); ok {
+			return user.Id, true
+		}
+		return 0, false
+	}
+
+	role := graphql.NewObject("Role", "Role of a user").
+		AddField("id", &graphql.Field{Type: graphql.NonNull{Of: graphql.ID}}).
+		AddField("name", &graphql.Field{Type: graphql.NonNull{Of: graphql.String}}).
+		AddField("description", &graphql.Field{Type: graphql.String})
+
+	user := graphql.NewObject("User", "User account").
+		AddField("id", &graphql.Field{Type: graphql.NonNull{Of: graphql.ID}}).
+		AddField("first_name", &graphql.Field{Type: graphql.NonNull{Of: graphql.String}}).
+		AddField("last_name", &graphql.Field{Type: graphql.NonNull{Of: graphql.String}}).
+		AddField("username", &graphql.Field{Type: graphql.NonNull{Of: graphql.String}}).
+		AddField("email", &graphql.Field{Type: graphql.NonNull{Of: graphql.String}}).
+		AddField("phone", &graphql.Field{Type: graphql.String}).
+		AddField("role_id", &graphql.Field{Type: graphql.NonNull{Of: graphql.ID}}).
+		AddField("locale", &graphql.Field{Type: graphql.String}).
+		AddField("timezone", &graphql.Field{Type: graphql.String}).
+		AddField("last_login", &graphql.Field{Type: graphql.Time}).
+		AddField("archived_at", &graphql.Field{Type: graphql.Time}).
+		AddField("created_at", &graphql.Field{Type: graphql.NonNull{Of: graphql.Time}}).
+		AddField("updated_at", &graphql.Field{Type: graphql.NonNull{Of: graphql.Time}}).
+		AddField("role", &graphql.Field{
+			Type: role,
+			Batch: func(p graphql.Params, sources []any) ([]any, error) {
+				return graphql.BatchBy(sources, func(source any) (uint, bool) {
+					if user, ok := source.(*User); ok {
+						return user.RoleId, true
+					}
+					return 0, false
+				}, func(ids []uint) (map[uint]*authorization.Role, error) {
+					var roles []*authorization.Role
+					if err := db.Where("id IN ?", ids).Find(&roles).Error; err != nil {
+						return nil, err
+					}
+					byId := make(map[uint]*authorization.Role, len(roles))
+					for _, role := range roles {
+						byId[role.Id] = role
+					}
+					return byId, nil
+				})
+			},
+		}).
+		AddField("avatar", &graphql.Field{
+			Type:  graphql.Attachment,
+			Batch: graphql.BatchAttachments(db, (&User{}).GetModelName(), "avatar", userId),
+		}).
+		AddField("custom_fields", &graphql.Field{
+			Type:        graphql.JSON,
+			Description: "Values of the custom fields, by key",
+			Batch: func(p graphql.Params, sources []any) ([]any, error) {
+				return graphql.BatchBy(sources, userId, func(ids []uint) (map[uint]customfields.Values, error) {
+					return customfields.Records.Load("users", ids)
+				})
+			},
+		})
+
+	userPage := graphql.NewObject("UserPage", "Page of users").
+		AddField("data", &graphql.Field{Type: graphql.NonNull{Of: graphql.List{Of: graphql.NonNull{Of: user}}}}).
+		AddField("pagination", &graphql.Field{Type: graphql.NonNull{Of: graphql.Pagination}})
+
+	graphql.Query("user", &graphql.Field{
+		Type:        user,
+		Description: "User by id",
+		Args:        []*graphql.Argument{{Name: "id", Type: graphql.NonNull{Of: graphql.ID}}},
+		Guard:       admin,
+		Resolve: func(p graphql.Params) (any, error) {
+			id, err := strconv.ParseUint(p.Args["id"].(string), 10, 64)
+			if err != nil {
+				return nil, nil
+			}
+			var items []*User
+			if err := db.Where("id = ?", id).Limit(1).Find(&items).Error; err != nil || len(items) == 0 {
+				return nil, err
+			}
+			return items[0], nil
+		},
+	})
+
+	graphql.Query("users", &graphql.Field{
+		Type:        graphql.NonNull{Of: userPage},
+		Description: "Paginated list of users",
+		Args: append(graphql.PageArgs(),
+			&graphql.Argument{Name: "sort", Type: graphql.String, Default: "id"},
+			&graphql.Argument{Name: "order", Type: graphql.String, Default: "desc"},
+			&graphql.Argument{Name: "archived", Type: graphql.String, Description: "true for archived users only, all for both"},
+		),
+		Guard: admin,
+		Resolve: func(p graphql.Params) (any, error) {
+			page, limit := graphql.PageOf(p)
+			sortBy, _ := p.Args["sort"].(string)
+			sortOrder, _ := p.Args["order"].(string)
+			value, _ := p.Args["archived"].(string)
+			archived, err := crud.ParseArchived(value)
+			if err != nil {
+				return nil, err
+			}
+			scope := archived.Scope("archived_at")
+
+			var total int64
+			if err := db.Model(&User{}).Scopes(scope).Count(&total).Error; err != nil {
+				return nil, err
+			}
+			var items []*User
+			query := db.Model(&User{}).Scopes(scope).Offset((page - 1) * limit).Limit(limit)
+			service.applySorting(query, &sortBy, &sortOrder)
+			if err := query.Find(&items).Error; err != nil {
+				return nil, err
+			}
+
+			totalPages := int(math.Ceil(float64(total) / float64(limit)))
+			if totalPages == 0 {
+				totalPages = 1
+			}
+
+			return &types.PaginatedResponse{
+				Data: items,
+				Pagination: types.Pagination{
+					Total:      int(total),
+					Page:       page,
+					PageSize:   limit,
+					TotalPages: totalPages,
+				},
+			}, nil
+		},
+	})
+}
@@ -0,0 +1,71 @@
+package users
+
+import (
+	"context"
+
+	coregrpc "base/core/grpc"
+	"base/core/grpc/pb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// usersServer implements the Users gRPC service with the UserService
+type usersServer struct {
+	pb.UnimplementedUsersServer
+	service *UserService
+}
+
+func registerGRPC(service *UserService) {
+	coregrpc.Register(func(s grpc.ServiceRegistrar) {
+		pb.RegisterUsersServer(s, &usersServer{service: service})
+	})
+}
+
+func (s *usersServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error) {
+	user, err := s.service.Lookup(ctx, uint(req.GetId()), req.GetEmail(), req.GetUsername())
+	if err != nil {
+		return nil, coregrpc.Error(err)
+	}
+	return user.toProto(), nil
+}
+
+func (s *usersServer) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	ids := make([]uint, len(req.GetIds()))
+	for i, id := range req.GetIds() {
+		ids[i] = uint(id)
+	}
+	users, err := s.service.GetByIds(ctx, ids)
+	if err != nil {
+		return nil, coregrpc.Error(err)
+	}
+
+	resp := &pb.ListUsersResponse{Users: make([]*pb.User, len(users))}
+	for i, user := range users {
+		resp.Users[i] = user.toProto()
+	}
+	return resp, nil
+}
+
+// toProto converts the user to its gRPC message
+func (m *User) toProto() *pb.User {
+	user := &pb.User{
+		Id:        uint64(m.Id),
+		FirstName: m.FirstName,
+		LastName:  m.LastName,
+		Username:  m.Username,
+		Email:     m.Email,
+		Phone:     m.Phone,
+		RoleId:    uint64(m.RoleId),
+		Locale:    m.Locale,
+		Timezone:  m.Timezone,
+		CreatedAt: timestamppb.New(m.CreatedAt),
+	}
+	if m.Role != nil {
+		user.Role = m.Role.Name
+	}
+	if m.LastLogin != nil {
+		user.LastLogin = timestamppb.New(*m.LastLogin)
+	}
+	return user
+}
@@ -2,7 +2,10 @@ package users
 
 import (
 	"base/core/app/authorization"
+	"base/core/app/customfields"
 	"base/core/storage"
+	"base/core/translation"
+	"context"
 	"fmt"
 	"time"
 
@@ -11,20 +14,23 @@ import (
 
 // User represents a user entity (used for both profile and employee management)
 type User struct {
-	Id        uint                `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
-	FirstName string              `json:"first_name" gorm:"column:first_name;not null;size:255"`
-	LastName  string              `json:"last_name" gorm:"column:last_name;not null;size:255"`
-	Username  string              `json:"username" gorm:"column:username;unique;not null;size:255"`
-	Phone     string              `json:"phone" gorm:"column:phone;size:255"`
-	Email     string              `json:"email" gorm:"column:email;unique;not null;size:255"`
-	Password  string              `json:"-" gorm:"column:password;size:255;not null"` // Hidden from JSON
-	RoleId    uint                `json:"role_id" gorm:"column:role_id;default:3"`
-	Role      *authorization.Role `json:"role,omitempty" gorm:"foreignKey:RoleId;references:Id"`
-	Avatar    *storage.Attachment `json:"avatar,omitempty" gorm:"foreignKey:ModelId;references:Id"`
-	LastLogin *time.Time          `json:"last_login,omitempty" gorm:"column:last_login"`
-	CreatedAt time.Time           `json:"created_at" gorm:"column:created_at"`
-	UpdatedAt time.Time           `json:"updated_at" gorm:"column:updated_at"`
-	DeletedAt gorm.DeletedAt      `json:"deleted_at,omitempty" gorm:"column:deleted_at;index"`
+	Id         uint                `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	FirstName  string              `json:"first_name" gorm:"column:first_name;not null;size:255"`
+	LastName   string              `json:"last_name" gorm:"column:last_name;not null;size:255"`
+	Username   string              `json:"username" gorm:"column:username;unique;not null;size:255"`
+	Phone      string              `json:"phone" gorm:"column:phone;size:255"`
+	Email      string              `json:"email" gorm:"column:email;unique;not null;size:255"`
+	Password   string              `json:"-" gorm:"column:password;size:255;not null"` // Hidden from JSON
+	RoleId     uint                `json:"role_id" gorm:"column:role_id;default:3"`
+	Locale     string              `json:"locale" gorm:"column:locale;size:10"`     // Preferred locale, e.g. "de" or "pt-BR"
+	Timezone   string              `json:"timezone" gorm:"column:timezone;size:64"` // Preferred timezone, e.g. "Europe/Berlin"
+	Role       *authorization.Role `json:"role,omitempty" gorm:"foreignKey:RoleId;references:Id"`
+	Avatar     *storage.Attachment `json:"avatar,omitempty" gorm:"foreignKey:ModelId;references:Id"`
+	LastLogin  *time.Time          `json:"last_login,omitempty" gorm:"column:last_login"`
+	ArchivedAt *time.Time          `json:"archived_at,omitempty" gorm:"column:archived_at;index"` // Hidden from lists, see crud.Archive
+	CreatedAt  time.Time           `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt  time.Time           `json:"updated_at" gorm:"column:updated_at"`
+	DeletedAt  gorm.DeletedAt      `json:"deleted_at,omitempty" gorm:"column:deleted_at;index"`
 }
 
 // TableName returns the table name for the User model
@@ -50,17 +56,43 @@ type CreateUserRequest struct {
 	Phone     string `json:"phone" binding:"max=255"`
 	Email     string `json:"email" binding:"required,email,max=255"`
 	Password  string `json:"password" binding:"required,min=8,max=255"`
-	RoleId    uint   `json:"role_id"`
+	RoleId    uint   `json:"role_id"` // Rejected: new users get the default role, see PUT /users/:id/role
+
+	// Values of the custom fields of users, by key (see customfields)
+	CustomFields customfields.Values `json:"custom_fields,omitempty"`
 }
 
-// UpdateUserRequest represents the request payload for updating a User
+// UpdateUserRequest represents the request payload for updating a User. Fields left out keep
+// their value; fields sent are set, so "" clears the phone, locale and timezone.
 type UpdateUserRequest struct {
-	FirstName string `json:"first_name,omitempty" binding:"max=255"`
-	LastName  string `json:"last_name,omitempty" binding:"max=255"`
-	Username  string `json:"username,omitempty" binding:"max=255"`
-	Phone     string `json:"phone,omitempty" binding:"max=255"`
-	Email     string `json:"email,omitempty" binding:"email,max=255"`
-	RoleId    uint   `json:"role_id,omitempty"`
+	FirstName *string `json:"first_name,omitempty" binding:"max=255"`
+	LastName  *string `json:"last_name,omitempty" binding:"max=255"`
+	Username  *string `json:"username,omitempty" binding:"max=255"`
+	Phone     *string `json:"phone,omitempty" binding:"max=255"`
+	Email     *string `json:"email,omitempty" binding:"email,max=255"`
+	RoleId    *uint   `json:"role_id,omitempty"`
+	Locale    *string `json:"locale,omitempty" binding:"max=10"`
+	Timezone  *string `json:"timezone,omitempty" binding:"max=64"`
+
+	// Custom field values to change; null removes a value
+	CustomFields customfields.Values `json:"custom_fields,omitempty"`
+}
+
+// UpdateRoleRequest represents the request payload for changing the role of a User
+type UpdateRoleRequest struct {
+	RoleId uint `json:"role_id" binding:"required"`
+}
+
+// RoleChange is a change of the role of a user, emitted with RoleChangeUserEvent
+type RoleChange struct {
+	User      *User  `json:"user"`
+	OldRoleId uint   `json:"old_role_id"`
+	OldRole   string `json:"old_role"`
+	NewRoleId uint   `json:"new_role_id"`
+	NewRole   string `json:"new_role"`
+	ChangedBy uint   `json:"changed_by"` // The user who changed the role, 0 for the command line
+	IpAddress string `json:"ip_address"`
+	UserAgent string `json:"user_agent"`
 }
 
 // UpdatePasswordRequest represents the request for updating own password
@@ -77,18 +109,24 @@ type ChangePasswordRequest struct {
 
 // UserResponse represents the API response for User
 type UserResponse struct {
-	Id        uint   `json:"id"`
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	Username  string `json:"username"`
-	Phone     string `json:"phone"`
-	Email     string `json:"email"`
-	RoleId    uint   `json:"role_id"`
-	RoleName  string `json:"role_name,omitempty"`
-	AvatarURL string `json:"avatar_url,omitempty"`
-	LastLogin string `json:"last_login,omitempty"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	Id         uint   `json:"id"`
+	FirstName  string `json:"first_name"`
+	LastName   string `json:"last_name"`
+	Username   string `json:"username"`
+	Phone      string `json:"phone"`
+	Email      string `json:"email"`
+	RoleId     uint   `json:"role_id"`
+	RoleName   string `json:"role_name,omitempty"`
+	Locale     string `json:"locale,omitempty"`
+	Timezone   string `json:"timezone,omitempty"`
+	AvatarURL  string `json:"avatar_url,omitempty"`
+	LastLogin  string `json:"last_login,omitempty"`
+	ArchivedAt string `json:"archived_at,omitempty"`
+	CreatedAt  string `json:"created_at"`
+	UpdatedAt  string `json:"updated_at"`
+
+	// Values of the custom fields, in admin responses
+	CustomFields customfields.Values `json:"custom_fields,omitempty"`
 }
 
 // UserSelectOption represents a simplified response for select boxes and dropdowns
@@ -119,6 +157,8 @@ func (m *User) ToResponse() *UserResponse {
 		Phone:     m.Phone,
 		Email:     m.Email,
 		RoleId:    m.RoleId,
+		Locale:    m.Locale,
+		Timezone:  m.Timezone,
 		CreatedAt: m.CreatedAt.Format(time.RFC3339),
 		UpdatedAt: m.UpdatedAt.Format(time.RFC3339),
 	}
@@ -137,10 +177,32 @@ func (m *User) ToResponse() *UserResponse {
 	if m.LastLogin != nil {
 		response.LastLogin = m.LastLogin.Format(time.RFC3339)
 	}
+	if m.ArchivedAt != nil {
+		response.ArchivedAt = m.ArchivedAt.Format(time.RFC3339)
+	}
 
 	return response
 }
 
+// ToLocalResponse converts the User to a UserResponse with its timestamps in the
+// timezone of the request
+func (m *User) ToLocalResponse(ctx context.Context) *UserResponse {
+	response := m.ToResponse()
+	if response != nil {
+		response.Localize(ctx)
+	}
+	return response
+}
+
+// Localize converts the timestamps of the response to the timezone of the request
+func (r *UserResponse) Localize(ctx context.Context) {
+	for _, value := range []*string{&r.LastLogin, &r.ArchivedAt, &r.CreatedAt, &r.UpdatedAt} {
+		if t, err := time.Parse(time.RFC3339, *value); err == nil {
+			*value = translation.FormatTimestamp(ctx, t)
+		}
+	}
+}
+
 // ToSelectOption converts the model to a select option for dropdowns
 func (m *User) ToSelectOption() *UserSelectOption {
 	if m == nil {
@@ -183,8 +245,9 @@ func (m *User) ToModelResponse() *UserModelResponse {
 	}
 }
 
-// Preload preloads all the model's relationships
+// Preload preloads all the model's relationships. The avatar is stored in a column of the
+// row, so it is loaded with it.
 func (m *User) Preload(db *gorm.DB) *gorm.DB {
-	query := db.Preload("Role").Preload("Avatar")
+	query := db.Preload("Role")
 	return query
 }
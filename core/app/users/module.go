@@ -4,6 +4,8 @@ import (
 	"errors"
 
 	"base/core/app/authorization"
+	"base/core/app/customfields"
+	"base/core/app/trash"
 	"base/core/module"
 	"base/core/router"
 
@@ -20,10 +22,29 @@ type Module struct {
 
 // Init creates and initializes the User module with all dependencies
 func Init(deps module.Dependencies) module.Module {
+	registerEvents()
 	// Initialize service and controller
-	service := NewUserService(deps.DB, deps.Emitter, deps.Storage, deps.Logger)
+	service := NewUserService(deps.DB, deps.Tx, deps.Emitter, deps.Storage, deps.Logger)
 	controller := NewUserController(service, deps.Storage, deps.Logger)
 
+	// Admins can add custom fields to users
+	customfields.RegisterEntity("users")
+
+	// Deleted users can be restored from the trash
+	trash.RegisterEntity(trash.Entity{
+		Name:        "users",
+		Model:       &User{},
+		Title:       "username",
+		DeleteEvent: DeleteUserEvent,
+		UpdateEvent: UpdateUserEvent,
+	})
+
+	// Read-only GraphQL fields (see core/graphql)
+	registerGraphQL(service)
+
+	// Internal gRPC service (see core/grpc)
+	registerGRPC(service)
+
 	// Create module
 	mod := &Module{
 		DB:         deps.DB,
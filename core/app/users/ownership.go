@@ -0,0 +1,242 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"base/core/confirm"
+	"base/core/database"
+	"base/core/logger"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+// TransferOwnershipEvent is emitted with a *OwnershipTransfer once records changed owner
+const TransferOwnershipEvent = "users.transfer_ownership"
+
+// ErrContentNotDeleted wraps the error of the Delete of an owned model refusing to delete a
+// record of a user deleted with their content, e.g. media still in use
+var ErrContentNotDeleted = errors.New("content of the user can't be deleted")
+
+// Owned is a model whose records belong to a user through a column, e.g. media through
+// author_id. Their records are handed over to another user by TransferOwnership.
+type Owned struct {
+	Name   string // Type of the records, e.g. "media"
+	Model  any    // Model of the records
+	Column string // Column of the owner, e.g. "author_id"
+
+	// Delete deletes a record through its module, e.g. so its files go too, when the user is
+	// deleted with their content. Records are deleted by their column when nil.
+	Delete func(ctx context.Context, id uint) error
+}
+
+var (
+	ownedMu sync.RWMutex
+	owned   = map[string]Owned{}
+)
+
+// RegisterOwned adds a model to the ownership transfers, e.g. from a module's Init:
+//
+//	users.RegisterOwned(users.Owned{Name: "pages", Model: &Page{}, Column: "author_id"})
+//
+// Models whose records own more than rows, e.g. files, delete them with Delete:
+//
+//	users.RegisterOwned(users.Owned{Name: "media", Model: &Media{}, Column: "author_id",
+//		Delete: func(ctx context.Context, id uint) error { return service.Delete(ctx, id, false) }})
+func RegisterOwned(model Owned) {
+	ownedMu.Lock()
+	defer ownedMu.Unlock()
+	owned[model.Name] = model
+}
+
+// OwnedModels returns the registered owned models sorted by name
+func OwnedModels() []Owned {
+	ownedMu.RLock()
+	defer ownedMu.RUnlock()
+	result := make([]Owned, 0, len(owned))
+	for _, model := range owned {
+		result = append(result, model)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// TransferOwnershipRequest hands over the records of a user to another one
+type TransferOwnershipRequest struct {
+	ToUserId uint     `json:"to_user_id" binding:"required"`
+	Types    []string `json:"types,omitempty"` // Types of the records to hand over, all registered types when empty
+}
+
+// OwnershipTransfer reports the records handed over from a user to another one
+type OwnershipTransfer struct {
+	FromUserId  uint             `json:"from_user_id"`
+	ToUserId    uint             `json:"to_user_id"`
+	Transferred map[string]int64 `json:"transferred"` // Records handed over by type
+	Total       int64            `json:"total"`
+}
+
+// TransferOwnership hands over the records of the registered owned models from a user to
+// another one in one transaction, e.g. when the user is offboarded. The user may be deleted
+// already; their deleted records are handed over too, so they have an owner once restored.
+func (s *UserService) TransferOwnership(ctx context.Context, fromId uint, req *TransferOwnershipRequest) (*OwnershipTransfer, error) {
+	var from User
+	if err := s.db.WithContext(ctx).Unscoped().Select("id").First(&from, fromId).Error; err != nil {
+		return nil, err
+	}
+
+	models, err := s.validateTransfer(ctx, fromId, req)
+	if err != nil {
+		return nil, err
+	}
+
+	transfer := &OwnershipTransfer{FromUserId: fromId, ToUserId: req.ToUserId, Transferred: make(map[string]int64, len(models))}
+	err = s.tx.WithTx(ctx, func(tx *gorm.DB) error {
+		for _, model := range models {
+			record := reflect.New(reflect.TypeOf(model.Model).Elem()).Interface()
+			result := tx.Unscoped().Model(record).Where(model.Column+" = ?", fromId).UpdateColumn(model.Column, req.ToUserId)
+			if result.Error != nil {
+				return fmt.Errorf("failed to transfer %s: %w", model.Name, result.Error)
+			}
+			transfer.Transferred[model.Name] = result.RowsAffected
+			transfer.Total += result.RowsAffected
+		}
+
+		database.AfterCommit(tx, func() {
+			s.emitter.EmitContext(ctx, TransferOwnershipEvent, transfer)
+		})
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("failed to transfer ownership",
+			logger.String("error", err.Error()),
+			logger.Uint("from_user_id", fromId),
+			logger.Uint("to_user_id", req.ToUserId))
+		return nil, err
+	}
+
+	s.logger.Info("Ownership transferred",
+		logger.Uint("from_user_id", fromId),
+		logger.Uint("to_user_id", req.ToUserId),
+		logger.Int64("total", transfer.Total))
+	return transfer, nil
+}
+
+// DeleteContentImpact returns what deleting a user with their content would delete: them
+// and their records of the owned models, for the dry run of the deletion
+func (s *UserService) DeleteContentImpact(ctx context.Context, id uint) (*confirm.Impact, error) {
+	var user User
+	if err := s.db.WithContext(ctx).Select("id").First(&user, id).Error; err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int64{"users": 1}
+	for _, model := range OwnedModels() {
+		var count int64
+		record := reflect.New(reflect.TypeOf(model.Model).Elem()).Interface()
+		if err := s.db.WithContext(ctx).Model(record).Where(model.Column+" = ?", id).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("failed to count %s: %w", model.Name, err)
+		}
+		counts[model.Name] = count
+	}
+	return confirm.NewImpact(fmt.Sprintf("users.delete_content:%d", id), counts, "will be deleted"), nil
+}
+
+// DeleteWithContent deletes a user and their records of the owned models, e.g. a spam
+// account. The records of models with a Delete are deleted through it first, one by one, and
+// an error of it, e.g. for media still in use, stops the deletion before the user is
+// deleted; the records it deleted so far stay deleted. The other records are deleted with
+// the user in one transaction, and those of models with a deleted_at go to the trash.
+func (s *UserService) DeleteWithContent(ctx context.Context, id uint) error {
+	var user User
+	if err := s.db.WithContext(ctx).Select("id").First(&user, id).Error; err != nil {
+		return err
+	}
+
+	var rest []Owned
+	for _, model := range OwnedModels() {
+		if model.Delete == nil {
+			rest = append(rest, model)
+			continue
+		}
+		var ids []uint
+		record := reflect.New(reflect.TypeOf(model.Model).Elem()).Interface()
+		if err := s.db.WithContext(ctx).Model(record).Where(model.Column+" = ?", id).Order("id").Pluck("id", &ids).Error; err != nil {
+			return fmt.Errorf("failed to fetch %s: %w", model.Name, err)
+		}
+		for _, recordId := range ids {
+			if err := model.Delete(ctx, recordId); err != nil {
+				return fmt.Errorf("%w: %s %d: %w", ErrContentNotDeleted, model.Name, recordId, err)
+			}
+		}
+	}
+	return s.delete(ctx, id, rest)
+}
+
+// validateTransfer checks the new owner and the types of a transfer and returns the owned
+// models to hand over
+func (s *UserService) validateTransfer(ctx context.Context, fromId uint, req *TransferOwnershipRequest) ([]Owned, error) {
+	var errs validator.ValidationErrors
+	switch {
+	case req.ToUserId == 0:
+		errs = append(errs, validator.ValidationError{
+			Field: "to_user_id", Tag: "required", Message: "to_user_id is required",
+		})
+	case req.ToUserId == fromId:
+		errs = append(errs, validator.ValidationError{
+			Field: "to_user_id", Tag: "invalid", Value: fmt.Sprint(req.ToUserId),
+			Message: "to_user_id must be another user",
+		})
+	default:
+		var to User
+		err := s.db.WithContext(ctx).Select("id", "archived_at").First(&to, req.ToUserId).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			errs = append(errs, validator.ValidationError{
+				Field: "to_user_id", Tag: "exists", Value: fmt.Sprint(req.ToUserId),
+				Message: "to_user_id does not exist",
+			})
+		case err != nil:
+			return nil, err
+		case to.ArchivedAt != nil:
+			errs = append(errs, validator.ValidationError{
+				Field: "to_user_id", Tag: "invalid", Value: fmt.Sprint(req.ToUserId),
+				Message: "to_user_id is archived",
+			})
+		}
+	}
+
+	models := OwnedModels()
+	if len(req.Types) > 0 {
+		names := make([]string, len(models))
+		for i, model := range models {
+			names[i] = model.Name
+		}
+		selected := make([]Owned, 0, len(req.Types))
+		for i, name := range req.Types {
+			index := slices.Index(names, name)
+			if index < 0 {
+				errs = append(errs, validator.ValidationError{
+					Field: fmt.Sprintf("types[%d]", i), Tag: "oneof", Param: strings.Join(names, " "), Value: name,
+					Message: fmt.Sprintf("types must be one of %s", strings.Join(names, ", ")),
+				})
+				continue
+			}
+			if !slices.Contains(req.Types[:i], name) {
+				selected = append(selected, models[index])
+			}
+		}
+		models = selected
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return models, nil
+}
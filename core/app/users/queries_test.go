@@ -0,0 +1,29 @@
+package users_test
+
+import (
+	"testing"
+
+	"base/core/testutil"
+)
+
+func TestListUsersQueriesDontGrowWithRows(t *testing.T) {
+	app := testutil.New(t, testutil.Options{})
+	admin := app.CreateUser("Super Admin")
+	for range 2 {
+		app.CreateUser("Employee")
+	}
+
+	few := app.CountQueries(func() {
+		app.As(admin).GET("/api/users?limit=2").AssertStatus(200)
+	})
+	for range 10 {
+		app.CreateUser("Employee")
+	}
+	many := app.CountQueries(func() {
+		app.As(admin).GET("/api/users?limit=12").AssertStatus(200)
+	})
+
+	if many != few {
+		t.Errorf("listing users runs %d queries for 2 rows and %d for 12", few, many)
+	}
+}
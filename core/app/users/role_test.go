@@ -0,0 +1,114 @@
+package users_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"base/core/app/activities"
+	"base/core/app/authorization"
+	"base/core/app/users"
+	"base/core/testutil"
+)
+
+// roleId returns the id of a role by name
+func roleId(t *testing.T, app *testutil.App, name string) uint {
+	t.Helper()
+	var role authorization.Role
+	if err := app.DB.Where("name = ?", name).First(&role).Error; err != nil {
+		t.Fatalf("role %q: %v", name, err)
+	}
+	return role.Id
+}
+
+// storedRole returns the role id of a user in the database
+func storedRole(t *testing.T, app *testutil.App, id uint) uint {
+	t.Helper()
+	var user users.User
+	if err := app.DB.First(&user, id).Error; err != nil {
+		t.Fatalf("user %d: %v", id, err)
+	}
+	return user.RoleId
+}
+
+func TestChangeRoleRefusesRolesAboveTheChanger(t *testing.T) {
+	app := testutil.New(t, testutil.Options{})
+	admin := app.CreateUser("Administrator")
+	employee := app.CreateUser("Employee")
+	superAdmin := app.CreateUser("Super Admin")
+	superAdminRole := roleId(t, app, "Super Admin")
+
+	// Granting Super Admin
+	app.As(admin).PUT(fmt.Sprintf("/api/users/%d/role", employee.Id), map[string]any{"role_id": superAdminRole}).
+		AssertStatus(http.StatusForbidden)
+	if got := storedRole(t, app, employee.Id); got != employee.RoleId {
+		t.Errorf("role of the employee = %d, want %d", got, employee.RoleId)
+	}
+
+	// Demoting a Super Admin
+	app.As(admin).PUT(fmt.Sprintf("/api/users/%d/role", superAdmin.Id), map[string]any{"role_id": employee.RoleId}).
+		AssertStatus(http.StatusForbidden)
+	if got := storedRole(t, app, superAdmin.Id); got != superAdminRole {
+		t.Errorf("role of the super admin = %d, want %d", got, superAdminRole)
+	}
+
+	// Roles within their own are fine
+	app.As(admin).PUT(fmt.Sprintf("/api/users/%d/role", employee.Id), map[string]any{"role_id": admin.RoleId}).
+		AssertStatus(http.StatusOK)
+	if got := storedRole(t, app, employee.Id); got != admin.RoleId {
+		t.Errorf("role of the employee = %d, want %d", got, admin.RoleId)
+	}
+}
+
+func TestSuperAdminGrantsSuperAdmin(t *testing.T) {
+	app := testutil.New(t, testutil.Options{})
+	superAdmin := app.CreateUser("Super Admin")
+	employee := app.CreateUser("Employee")
+	superAdminRole := roleId(t, app, "Super Admin")
+
+	app.As(superAdmin).PUT(fmt.Sprintf("/api/users/%d/role", employee.Id), map[string]any{"role_id": superAdminRole}).
+		AssertStatus(http.StatusOK)
+	if got := storedRole(t, app, employee.Id); got != superAdminRole {
+		t.Errorf("role = %d, want %d", got, superAdminRole)
+	}
+}
+
+func TestChangeRoleFromTheCommandLine(t *testing.T) {
+	app := testutil.New(t, testutil.Options{})
+	employee := app.CreateUser("Employee")
+	superAdminRole := roleId(t, app, "Super Admin")
+
+	service := users.NewUserService(app.DB, nil, app.Emitter, app.Storage, app.Logger)
+	if _, err := service.ChangeRole(context.Background(), employee.Id, superAdminRole, &users.RoleChange{}); err != nil {
+		t.Fatalf("ChangeRole: %v", err)
+	}
+	if got := storedRole(t, app, employee.Id); got != superAdminRole {
+		t.Errorf("role = %d, want %d", got, superAdminRole)
+	}
+
+	var recorded int64
+	app.DB.Model(&activities.Activity{}).Where("action = ? AND entity_id = ?", "role_change", employee.Id).Count(&recorded)
+	if recorded != 1 {
+		t.Errorf("recorded role changes = %d, want 1", recorded)
+	}
+}
+
+func TestCreateUserRejectsRole(t *testing.T) {
+	app := testutil.New(t, testutil.Options{})
+	superAdmin := app.CreateUser("Super Admin")
+
+	app.As(superAdmin).POST("/api/users", map[string]any{
+		"first_name": "Jane", "last_name": "Doe", "username": "jane", "email": "jane@example.com",
+		"password": "password123", "role_id": roleId(t, app, "Super Admin"),
+	}).AssertStatus(http.StatusBadRequest)
+
+	var created users.UserResponse
+	app.As(superAdmin).POST("/api/users", map[string]any{
+		"first_name": "Jane", "last_name": "Doe", "username": "jane", "email": "jane@example.com",
+		"password": "password123",
+	}).AssertStatus(http.StatusCreated).Decode(&created)
+	if got := storedRole(t, app, created.Id); got == roleId(t, app, "Super Admin") {
+		t.Errorf("new user got the Super Admin role")
+	}
+}
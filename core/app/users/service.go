@@ -1,15 +1,22 @@
 package users
 
 import (
+	"base/core/app/authorization"
+	"base/core/app/customfields"
+	"base/core/crud"
+	"base/core/database"
 	"base/core/emitter"
 	"base/core/logger"
 	"base/core/storage"
 	"base/core/types"
+	"base/core/validator"
 	"context"
 	"errors"
 	"fmt"
 	"math"
 	"mime/multipart"
+	"reflect"
+	"slices"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
@@ -19,16 +26,38 @@ const (
 	CreateUserEvent = "users.create"
 	UpdateUserEvent = "users.update"
 	DeleteUserEvent = "users.delete"
+
+	// RoleChangeUserEvent is emitted with a *RoleChange when the role of a user changes
+	RoleChangeUserEvent = "users.role_change"
 )
 
+// registerEvents adds the events of the module to the event catalog
+func registerEvents() {
+	emitter.RegisterEvents(
+		emitter.EventType{Name: CreateUserEvent, Description: "A user was created", Payload: &User{}},
+		emitter.EventType{Name: UpdateUserEvent, Description: "A user was updated", Payload: &User{}},
+		emitter.EventType{Name: DeleteUserEvent, Description: "A user was deleted", Payload: &User{}},
+		emitter.EventType{Name: RoleChangeUserEvent, Description: "The role of a user changed", Payload: &RoleChange{}},
+		emitter.EventType{Name: TransferOwnershipEvent, Description: "The records of a user were transferred to another user", Payload: &OwnershipTransfer{}},
+	)
+}
+
+// ErrOwnRole is returned when users try to change their own role
+var ErrOwnRole = errors.New("users can't change their own role")
+
+// ErrRoleAbove is returned when users try to grant a role with permissions their own role
+// lacks, or to change the role of a user who has such a role
+var ErrRoleAbove = errors.New("users can't grant or take away roles above their own")
+
 type UserService struct {
 	db            *gorm.DB
+	tx            *database.TxManager
 	emitter       *emitter.Emitter
 	activeStorage *storage.ActiveStorage
 	logger        logger.Logger
 }
 
-func NewUserService(db *gorm.DB, emitter *emitter.Emitter, activeStorage *storage.ActiveStorage, logger logger.Logger) *UserService {
+func NewUserService(db *gorm.DB, tx *database.TxManager, emitter *emitter.Emitter, activeStorage *storage.ActiveStorage, logger logger.Logger) *UserService {
 	if db == nil {
 		panic("db is required")
 	}
@@ -38,6 +67,9 @@ func NewUserService(db *gorm.DB, emitter *emitter.Emitter, activeStorage *storag
 	if activeStorage == nil {
 		panic("activeStorage is required")
 	}
+	if tx == nil {
+		tx = database.NewTxManager(db)
+	}
 
 	// Register avatar attachment configuration
 	activeStorage.RegisterAttachment("users", storage.AttachmentConfig{
@@ -50,33 +82,35 @@ func NewUserService(db *gorm.DB, emitter *emitter.Emitter, activeStorage *storag
 
 	return &UserService{
 		db:            db,
+		tx:            tx,
 		emitter:       emitter,
 		activeStorage: activeStorage,
 		logger:        logger,
 	}
 }
 
+// sortFields are the fields the list can be sorted by
+var sortFields = []string{
+	"id",
+	"created_at",
+	"updated_at",
+	"first_name",
+	"last_name",
+	"username",
+	"phone",
+	"email",
+	"role_id",
+}
+
 // applySorting applies sorting to the query based on the sort and order parameters
 func (s *UserService) applySorting(query *gorm.DB, sortBy *string, sortOrder *string) {
-	validSortFields := map[string]string{
-		"id":         "id",
-		"created_at": "created_at",
-		"updated_at": "updated_at",
-		"first_name": "first_name",
-		"last_name":  "last_name",
-		"username":   "username",
-		"phone":      "phone",
-		"email":      "email",
-		"role_id":    "role_id",
-	}
-
 	defaultSortBy := "id"
 	defaultSortOrder := "desc"
 
 	sortField := defaultSortBy
 	if sortBy != nil && *sortBy != "" {
-		if field, exists := validSortFields[*sortBy]; exists {
-			sortField = field
+		if slices.Contains(sortFields, *sortBy) {
+			sortField = *sortBy
 		}
 	}
 
@@ -89,7 +123,23 @@ func (s *UserService) applySorting(query *gorm.DB, sortBy *string, sortOrder *st
 }
 
 // Create creates a new user
-func (s *UserService) Create(req *CreateUserRequest) (*User, error) {
+func (s *UserService) Create(ctx context.Context, req *CreateUserRequest) (*User, error) {
+	// New users get the default role; roles only change through ChangeRole, so the change is
+	// authorized and audited
+	if req.RoleId != 0 {
+		return nil, validator.ValidationErrors{{
+			Field:   "role_id",
+			Tag:     "readonly",
+			Value:   fmt.Sprint(req.RoleId),
+			Param:   "PUT /users/:id/role",
+			Message: "role_id can't be set here, use PUT /users/:id/role",
+		}}
+	}
+	customFields, err := customfields.Records.Validate("users", req.CustomFields, true)
+	if err != nil {
+		return nil, err
+	}
+
 	// Hash the password before saving
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -104,24 +154,34 @@ func (s *UserService) Create(req *CreateUserRequest) (*User, error) {
 		Phone:     req.Phone,
 		Email:     req.Email,
 		Password:  string(hashedPassword),
-		RoleId:    req.RoleId,
 	}
 
-	if err := s.db.Create(item).Error; err != nil {
+	err = s.tx.WithTx(ctx, func(tx *gorm.DB) error {
+		if err := tx.Create(item).Error; err != nil {
+			return err
+		}
+		if err := customfields.Records.WithDB(tx).Set("users", item.Id, customFields); err != nil {
+			return err
+		}
+
+		// Emit create event once the user is committed
+		database.AfterCommit(tx, func() {
+			s.emitter.EmitContext(ctx, CreateUserEvent, item)
+		})
+		return nil
+	})
+	if err != nil {
 		s.logger.Error("failed to create user", logger.String("error", err.Error()))
 		return nil, err
 	}
 
-	// Emit create event
-	s.emitter.Emit(CreateUserEvent, item)
-
-	return s.GetById(item.Id)
+	return s.GetById(ctx, item.Id)
 }
 
 // GetById gets a user by ID with relationships preloaded
-func (s *UserService) GetById(id uint) (*User, error) {
+func (s *UserService) GetById(ctx context.Context, id uint) (*User, error) {
 	var user User
-	query := user.Preload(s.db)
+	query := user.Preload(s.db.WithContext(ctx))
 	if err := query.First(&user, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			s.logger.Error("User not found", logger.Uint("user_id", id))
@@ -135,43 +195,71 @@ func (s *UserService) GetById(id uint) (*User, error) {
 }
 
 // Update updates a user
-func (s *UserService) Update(id uint, req *UpdateUserRequest) (*User, error) {
+func (s *UserService) Update(ctx context.Context, id uint, req *UpdateUserRequest) (*User, error) {
+	if err := ValidateUserUpdateRequest(req, id); err != nil {
+		return nil, err
+	}
+	customFields, err := customfields.Records.Validate("users", req.CustomFields, false)
+	if err != nil {
+		return nil, err
+	}
+
 	item := &User{}
-	if err := s.db.First(item, id).Error; err != nil {
+	if err := s.db.WithContext(ctx).First(item, id).Error; err != nil {
 		s.logger.Error("failed to find user for update",
 			logger.String("error", err.Error()),
 			logger.Int("id", int(id)))
 		return nil, err
 	}
 
-	// Update fields if provided
-	if req.FirstName != "" {
-		item.FirstName = req.FirstName
+	// Roles only change through ChangeRole, so the change is authorized and audited
+	if req.RoleId != nil && *req.RoleId != item.RoleId {
+		return nil, validator.ValidationErrors{{
+			Field:   "role_id",
+			Tag:     "readonly",
+			Value:   fmt.Sprint(*req.RoleId),
+			Param:   "PUT /users/:id/role",
+			Message: "role_id can't be changed here, use PUT /users/:id/role",
+		}}
 	}
-	if req.LastName != "" {
-		item.LastName = req.LastName
+
+	// Update the fields included in the request
+	if req.FirstName != nil {
+		item.FirstName = *req.FirstName
 	}
-	if req.Username != "" {
-		item.Username = req.Username
+	if req.LastName != nil {
+		item.LastName = *req.LastName
 	}
-	if req.Phone != "" {
-		item.Phone = req.Phone
+	if req.Username != nil {
+		item.Username = *req.Username
 	}
-	if req.Email != "" {
-		item.Email = req.Email
+	if req.Phone != nil {
+		item.Phone = *req.Phone
 	}
-	if req.RoleId != 0 {
-		item.RoleId = req.RoleId
+	if req.Email != nil {
+		item.Email = *req.Email
+	}
+	if req.Locale != nil {
+		item.Locale = *req.Locale
+	}
+	if req.Timezone != nil {
+		item.Timezone = *req.Timezone
 	}
 
-	if err := s.db.Save(item).Error; err != nil {
+	err = s.tx.WithTx(ctx, func(tx *gorm.DB) error {
+		if err := tx.Save(item).Error; err != nil {
+			return err
+		}
+		return customfields.Records.WithDB(tx).Set("users", item.Id, customFields)
+	})
+	if err != nil {
 		s.logger.Error("failed to update user",
 			logger.String("error", err.Error()),
 			logger.Int("id", int(id)))
 		return nil, err
 	}
 
-	result, err := s.GetById(item.Id)
+	result, err := s.GetById(ctx, item.Id)
 	if err != nil {
 		s.logger.Error("failed to get updated user",
 			logger.String("error", err.Error()),
@@ -180,15 +268,110 @@ func (s *UserService) Update(id uint, req *UpdateUserRequest) (*User, error) {
 	}
 
 	// Emit update event
-	s.emitter.Emit(UpdateUserEvent, result)
+	s.emitter.EmitContext(ctx, UpdateUserEvent, result)
 
 	return result, nil
 }
 
+// ChangeRole changes the role of a user and emits RoleChangeUserEvent. The change gives the
+// user making it and their client; the user and the roles are filled in. Users can't change
+// their own role, nor grant or take away a role with permissions theirs lacks; changes from the
+// command line, with ChangedBy 0, aren't limited.
+func (s *UserService) ChangeRole(ctx context.Context, id uint, roleId uint, change *RoleChange) (*User, error) {
+	if change.ChangedBy == id {
+		return nil, ErrOwnRole
+	}
+
+	var role authorization.Role
+	if err := s.db.WithContext(ctx).First(&role, roleId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, validator.ValidationErrors{{
+				Field:   "role_id",
+				Tag:     "exists",
+				Value:   fmt.Sprint(roleId),
+				Message: "role_id does not exist",
+			}}
+		}
+		return nil, err
+	}
+
+	item, err := s.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if item.RoleId == role.Id {
+		return item, nil
+	}
+
+	// Both the granted role and the one taken away must be within the role of the changer
+	if change.ChangedBy != 0 {
+		authorizer := authorization.NewAuthorizationService(s.db)
+		for _, roleId := range []uint{role.Id, item.RoleId} {
+			within, err := authorizer.HasRoleWithin(ctx, uint64(change.ChangedBy), roleId)
+			if err != nil {
+				return nil, err
+			}
+			if !within {
+				return nil, ErrRoleAbove
+			}
+		}
+	}
+
+	change.OldRoleId = item.RoleId
+	if item.Role != nil {
+		change.OldRole = item.Role.Name
+	}
+	change.NewRoleId = role.Id
+	change.NewRole = role.Name
+
+	if err := s.db.WithContext(ctx).Model(&User{}).Where("id = ?", id).Update("role_id", role.Id).Error; err != nil {
+		s.logger.Error("failed to change user role",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return nil, err
+	}
+
+	result, err := s.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	change.User = result
+
+	s.emitter.EmitContext(ctx, RoleChangeUserEvent, change)
+	s.emitter.EmitContext(ctx, UpdateUserEvent, result)
+
+	return result, nil
+}
+
+// Archive hides a user from lists without deleting them, or brings them back
+func (s *UserService) Archive(ctx context.Context, id uint, archived bool) (*User, error) {
+	if err := crud.Archive(s.db.WithContext(ctx), &User{}, id, archived); err != nil {
+		s.logger.Error("failed to archive user",
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return nil, err
+	}
+
+	item, err := s.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Emit update event
+	s.emitter.EmitContext(ctx, UpdateUserEvent, item)
+
+	return item, nil
+}
+
 // Delete deletes a user
-func (s *UserService) Delete(id uint) error {
+func (s *UserService) Delete(ctx context.Context, id uint) error {
+	return s.delete(ctx, id, nil)
+}
+
+// delete deletes a user along with their records of the owned models, in one transaction
+func (s *UserService) delete(ctx context.Context, id uint, content []Owned) error {
 	item := &User{}
-	if err := s.db.First(item, id).Error; err != nil {
+	if err := s.db.WithContext(ctx).First(item, id).Error; err != nil {
 		s.logger.Error("failed to find user for deletion",
 			logger.String("error", err.Error()),
 			logger.Int("id", int(id)))
@@ -197,32 +380,53 @@ func (s *UserService) Delete(id uint) error {
 
 	// Delete avatar if exists
 	if item.Avatar != nil {
-		if err := s.activeStorage.Delete(item.Avatar); err != nil {
+		if err := s.activeStorage.Delete(ctx, item.Avatar); err != nil {
 			s.logger.Error("Failed to delete avatar",
 				logger.String("error", err.Error()),
 				logger.Uint("user_id", id))
 		}
 	}
 
-	if err := s.db.Delete(item).Error; err != nil {
+	err := s.tx.WithTx(ctx, func(tx *gorm.DB) error {
+		for _, model := range content {
+			record := reflect.New(reflect.TypeOf(model.Model).Elem()).Interface()
+			if err := tx.Where(model.Column+" = ?", id).Delete(record).Error; err != nil {
+				return fmt.Errorf("failed to delete %s: %w", model.Name, err)
+			}
+		}
+		if err := tx.Delete(item).Error; err != nil {
+			return err
+		}
+		if err := customfields.Records.WithDB(tx).Delete("users", item.Id); err != nil {
+			return err
+		}
+
+		// Emit delete event once the deletion is committed
+		database.AfterCommit(tx, func() {
+			s.emitter.EmitContext(ctx, DeleteUserEvent, item)
+		})
+		return nil
+	})
+	if err != nil {
 		s.logger.Error("failed to delete user",
 			logger.String("error", err.Error()),
 			logger.Int("id", int(id)))
 		return err
 	}
 
-	// Emit delete event
-	s.emitter.Emit(DeleteUserEvent, item)
-
 	return nil
 }
 
-// GetAll gets all users with pagination
-func (s *UserService) GetAll(page *int, limit *int, sortBy *string, sortOrder *string) (*types.PaginatedResponse, error) {
+// GetAll gets all users with pagination, narrowed to the ones matching the custom field filter
+// and the archived filter
+func (s *UserService) GetAll(ctx context.Context, page *int, limit *int, sortBy *string, sortOrder *string, filter customfields.Filter, archived crud.Archived) (*types.PaginatedResponse, error) {
 	var items []*User
 	var total int64
 
-	query := s.db.Model(&User{})
+	query, err := customfields.Records.Where(s.db.WithContext(ctx).Model(&User{}).Scopes(archived.Scope("users.archived_at")), "users", "users.id", filter)
+	if err != nil {
+		return nil, err
+	}
 
 	// Set default values if nil
 	defaultPage := 1
@@ -258,10 +462,21 @@ func (s *UserService) GetAll(page *int, limit *int, sortBy *string, sortOrder *s
 		return nil, err
 	}
 
+	ids := make([]uint, len(items))
+	for i, item := range items {
+		ids[i] = item.Id
+	}
+	customFields, err := customfields.Records.Load("users", ids)
+	if err != nil {
+		s.logger.Error("failed to get user custom fields", logger.String("error", err.Error()))
+		return nil, err
+	}
+
 	// Convert to response type
 	responses := make([]*UserResponse, len(items))
 	for i, item := range items {
 		responses[i] = item.ToResponse()
+		responses[i].CustomFields = customFields[item.Id]
 	}
 
 	// Calculate total pages
@@ -281,12 +496,46 @@ func (s *UserService) GetAll(page *int, limit *int, sortBy *string, sortOrder *s
 	}, nil
 }
 
+// Lookup returns a user with its role by id, email or username, whichever is set first
+func (s *UserService) Lookup(ctx context.Context, id uint, email, username string) (*User, error) {
+	query := s.db.WithContext(ctx).Preload("Role")
+	switch {
+	case id != 0:
+		query = query.Where("id = ?", id)
+	case email != "":
+		query = query.Where("email = ?", email)
+	case username != "":
+		query = query.Where("username = ?", username)
+	default:
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var user User
+	if err := query.First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetByIds returns the users with the ids and their roles, ordered by id
+func (s *UserService) GetByIds(ctx context.Context, ids []uint) ([]*User, error) {
+	var users []*User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	if err := s.db.WithContext(ctx).Preload("Role").Where("id IN ?", ids).Order("id").Find(&users).Error; err != nil {
+		s.logger.Error("failed to get users by ids", logger.String("error", err.Error()))
+		return nil, err
+	}
+	return users, nil
+}
+
 // GetAllForSelect gets all users for select box/dropdown options
-func (s *UserService) GetAllForSelect() ([]*User, error) {
+func (s *UserService) GetAllForSelect(ctx context.Context) ([]*User, error) {
 	var items []*User
 
-	query := s.db.Model(&User{})
-	query = query.Select("id, first_name, last_name, username, email")
+	query := s.db.WithContext(ctx).Model(&User{})
+	query = query.Select("id, first_name, last_name, username, email").Where("archived_at IS NULL")
 	query = query.Order("id ASC")
 
 	if err := query.Find(&items).Error; err != nil {
@@ -300,19 +549,19 @@ func (s *UserService) GetAllForSelect() ([]*User, error) {
 // UpdateAvatar updates user's avatar
 func (s *UserService) UpdateAvatar(ctx context.Context, id uint, avatarFile *multipart.FileHeader) (*User, error) {
 	var user User
-	if err := s.db.First(&user, id).Error; err != nil {
+	if err := s.db.WithContext(ctx).First(&user, id).Error; err != nil {
 		return nil, err
 	}
 
 	// Attach the new file - cleanup is handled inside Attach
-	attachment, err := s.activeStorage.Attach(&user, "avatar", avatarFile)
+	attachment, err := s.activeStorage.Attach(ctx, &user, "avatar", avatarFile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to upload avatar: %w", err)
 	}
 
 	// Update user's avatar
 	user.Avatar = attachment
-	if err := s.db.Save(&user).Error; err != nil {
+	if err := s.db.WithContext(ctx).Save(&user).Error; err != nil {
 		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
 
@@ -321,35 +570,27 @@ func (s *UserService) UpdateAvatar(ctx context.Context, id uint, avatarFile *mul
 
 // RemoveAvatar removes user's avatar
 func (s *UserService) RemoveAvatar(ctx context.Context, id uint) (*User, error) {
-	tx := s.db.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
 	var user User
-	if err := tx.First(&user, id).Error; err != nil {
-		tx.Rollback()
-		return nil, err
-	}
-
-	if user.Avatar != nil {
-		if err := s.activeStorage.Delete(user.Avatar); err != nil {
-			tx.Rollback()
-			s.logger.Error("Failed to delete avatar",
-				logger.String("error", err.Error()),
-				logger.Uint("user_id", id))
-			return nil, fmt.Errorf("failed to delete avatar: %w", err)
+	err := s.tx.WithTx(ctx, func(tx *gorm.DB) error {
+		if err := tx.First(&user, id).Error; err != nil {
+			return err
 		}
-		user.Avatar = nil
-		if err := tx.Save(&user).Error; err != nil {
-			tx.Rollback()
-			return nil, err
-		}
-	}
 
-	if err := tx.Commit().Error; err != nil {
+		if user.Avatar != nil {
+			if err := s.activeStorage.Delete(ctx, user.Avatar); err != nil {
+				s.logger.Error("Failed to delete avatar",
+					logger.String("error", err.Error()),
+					logger.Uint("user_id", id))
+				return fmt.Errorf("failed to delete avatar: %w", err)
+			}
+			user.Avatar = nil
+			if err := tx.Save(&user).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -357,9 +598,9 @@ func (s *UserService) RemoveAvatar(ctx context.Context, id uint) (*User, error)
 }
 
 // UpdatePassword updates own password (requires old password verification)
-func (s *UserService) UpdatePassword(id uint, req *UpdatePasswordRequest) error {
+func (s *UserService) UpdatePassword(ctx context.Context, id uint, req *UpdatePasswordRequest) error {
 	var user User
-	if err := s.db.First(&user, id).Error; err != nil {
+	if err := s.db.WithContext(ctx).First(&user, id).Error; err != nil {
 		s.logger.Error("Failed to find user for password update",
 			logger.String("error", err.Error()),
 			logger.Uint("user_id", id))
@@ -382,7 +623,7 @@ func (s *UserService) UpdatePassword(id uint, req *UpdatePasswordRequest) error
 	}
 
 	user.Password = string(hashedPassword)
-	if err := s.db.Save(&user).Error; err != nil {
+	if err := s.db.WithContext(ctx).Save(&user).Error; err != nil {
 		s.logger.Error("Failed to save new password",
 			logger.String("error", err.Error()),
 			logger.Uint("user_id", id))
@@ -393,9 +634,9 @@ func (s *UserService) UpdatePassword(id uint, req *UpdatePasswordRequest) error
 }
 
 // ChangePassword changes password for another user (admin only - no old password verification)
-func (s *UserService) ChangePassword(id uint, newPassword, currentPassword string) error {
+func (s *UserService) ChangePassword(ctx context.Context, id uint, newPassword, currentPassword string) error {
 	var user User
-	if err := s.db.First(&user, id).Error; err != nil {
+	if err := s.db.WithContext(ctx).First(&user, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			s.logger.Error("User not found for password change", logger.Int("id", int(id)))
 		} else {
@@ -425,7 +666,7 @@ func (s *UserService) ChangePassword(id uint, newPassword, currentPassword strin
 
 	user.Password = string(hashedPassword)
 
-	if err := s.db.Save(&user).Error; err != nil {
+	if err := s.db.WithContext(ctx).Save(&user).Error; err != nil {
 		s.logger.Error("Failed to save new password for user",
 			logger.String("error", err.Error()),
 			logger.Int("id", int(id)))
@@ -0,0 +1,33 @@
+package users_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"base/core/app/users"
+	"base/core/testutil"
+)
+
+func TestUpdateUserClearsValues(t *testing.T) {
+	app := testutil.New(t, testutil.Options{})
+	admin := app.CreateUser("Super Admin")
+	user := app.CreateUser("Employee")
+	if err := app.DB.Model(&users.User{}).Where("id = ?", user.Id).Update("phone", "+1 555 0100").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	app.As(admin).PUT(fmt.Sprintf("/api/users/%d", user.Id), map[string]any{"phone": ""}).
+		AssertStatus(http.StatusOK)
+
+	var stored users.User
+	if err := app.DB.First(&stored, user.Id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Phone != "" {
+		t.Errorf("phone = %q, want it cleared", stored.Phone)
+	}
+	if stored.FirstName != user.FirstName {
+		t.Errorf("first name = %q, want it unchanged as %q", stored.FirstName, user.FirstName)
+	}
+}
@@ -1,6 +1,7 @@
 package users
 
 import (
+	"base/core/translation"
 	"base/core/validator"
 )
 
@@ -48,7 +49,57 @@ func ValidateUserUpdateRequest(req *UpdateUserRequest, id uint) error {
 		}
 	}
 
-	// Skip validation for update requests - all fields are optional
+	// All fields are optional, but the names, the email and the role can't be cleared
+	var errs validator.ValidationErrors
+	required := []struct {
+		field string
+		value *string
+	}{
+		{"first_name", req.FirstName},
+		{"last_name", req.LastName},
+		{"username", req.Username},
+		{"email", req.Email},
+	}
+	for _, r := range required {
+		if r.value != nil && *r.value == "" {
+			errs = append(errs, validator.ValidationError{
+				Field:   r.field,
+				Tag:     "required",
+				Value:   "",
+				Message: r.field + " cannot be empty",
+			})
+		}
+	}
+	if req.RoleId != nil && *req.RoleId == 0 {
+		errs = append(errs, validator.ValidationError{
+			Field:   "role_id",
+			Tag:     "required",
+			Value:   "0",
+			Message: "role_id cannot be zero",
+		})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return ValidateUserPreferences(req)
+}
+
+// ValidateUserPreferences validates the locale and timezone preferences of an update
+func ValidateUserPreferences(req *UpdateUserRequest) error {
+	if req.Timezone == nil || *req.Timezone == "" {
+		return nil
+	}
+	if _, ok := translation.LoadTimezone(*req.Timezone); !ok {
+		return validator.ValidationErrors{
+			{
+				Field:   "timezone",
+				Tag:     "timezone",
+				Value:   *req.Timezone,
+				Message: "timezone must be an IANA timezone such as Europe/Berlin",
+			},
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,194 @@
+package views
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"base/core/router"
+	"base/core/translation"
+	"base/core/types"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+type ViewController struct {
+	Service *ViewService
+}
+
+func NewViewController(service *ViewService) *ViewController {
+	return &ViewController{
+		Service: service,
+	}
+}
+
+// Routes registers the endpoints; every authenticated user saves their own views
+func (c *ViewController) Routes(router *router.RouterGroup) {
+	router.GET("/views", c.List)          // Own and shared views
+	router.POST("/views", c.Create)       // Save
+	router.GET("/views/:id", c.Get)       // Get by ID
+	router.PUT("/views/:id", c.Update)    // Update (owner)
+	router.DELETE("/views/:id", c.Delete) // Delete (owner)
+}
+
+// ListViews godoc
+// @Summary List saved views
+// @Description Get the saved list views of the current user and the ones shared with their role, by resource and name
+// @Tags Core/Views
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param resource query string false "Only the views of this list, e.g. orders"
+// @Success 200 {array} ViewResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /views [get]
+func (c *ViewController) List(ctx *router.Context) error {
+	userId := ctx.GetUint("user_id")
+	items, err := c.Service.GetAll(ctx.Request.Context(), userId, ctx.Query("resource"))
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: err.Error()})
+	}
+
+	now := time.Now()
+	responses := make([]*ViewResponse, 0, len(items))
+	for _, item := range items {
+		responses = append(responses, item.ToResponse(userId, now))
+	}
+	return ctx.JSON(http.StatusOK, responses)
+}
+
+// CreateView godoc
+// @Summary Save a view
+// @Description Save the filters, sort and columns of a list under a name, optionally shared with roles. Filter values {today}, {week_start}, {month_start} and {year_start} are replaced with their date when the view is read
+// @Tags Core/Views
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param view body CreateViewRequest true "Create view request"
+// @Success 201 {object} ViewResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /views [post]
+func (c *ViewController) Create(ctx *router.Context) error {
+	var req CreateViewRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	userId := ctx.GetUint("user_id")
+	item, err := c.Service.Create(ctx.Request.Context(), userId, &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to save view")
+	}
+
+	return ctx.JSON(http.StatusCreated, item.ToResponse(userId, time.Now()))
+}
+
+// GetView godoc
+// @Summary Get a saved view
+// @Description Get a view of the current user or shared with their role
+// @Tags Core/Views
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "View id"
+// @Success 200 {object} ViewResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /views/{id} [get]
+func (c *ViewController) Get(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	userId := ctx.GetUint("user_id")
+	item, err := c.Service.GetById(ctx.Request.Context(), userId, uint(id))
+	if err != nil {
+		return c.fail(ctx, err, "Failed to fetch view")
+	}
+
+	return ctx.JSON(http.StatusOK, item.ToResponse(userId, time.Now()))
+}
+
+// UpdateView godoc
+// @Summary Update a saved view
+// @Description Update a view of the current user; the resource can't be changed and an empty role_ids list stops sharing it
+// @Tags Core/Views
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "View id"
+// @Param view body UpdateViewRequest true "Update view request"
+// @Success 200 {object} ViewResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 403 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /views/{id} [put]
+func (c *ViewController) Update(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	var req UpdateViewRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+	}
+
+	userId := ctx.GetUint("user_id")
+	item, err := c.Service.Update(ctx.Request.Context(), userId, uint(id), &req)
+	if err != nil {
+		return c.fail(ctx, err, "Failed to update view")
+	}
+
+	return ctx.JSON(http.StatusOK, item.ToResponse(userId, time.Now()))
+}
+
+// DeleteView godoc
+// @Summary Delete a saved view
+// @Description Delete a view of the current user; it is gone for the roles it was shared with too
+// @Tags Core/Views
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param id path int true "View id"
+// @Success 200 {object} types.SuccessResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 403 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /views/{id} [delete]
+func (c *ViewController) Delete(ctx *router.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid id format"})
+	}
+
+	if err := c.Service.Delete(ctx.Request.Context(), ctx.GetUint("user_id"), uint(id)); err != nil {
+		return c.fail(ctx, err, "Failed to delete view")
+	}
+
+	return ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "View deleted successfully", Success: true})
+}
+
+// fail writes the error response of a service error
+func (c *ViewController) fail(ctx *router.Context, err error, message string) error {
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   translation.Error(ctx, err),
+			Details: translation.LocalizeValidation(ctx, validationErrors),
+		})
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "View not found"})
+	}
+	if errors.Is(err, ErrNotOwner) {
+		return ctx.JSON(http.StatusForbidden, types.ErrorResponse{Error: translation.Error(ctx, err)})
+	}
+	return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: message + ": " + err.Error()})
+}
@@ -0,0 +1,114 @@
+package views
+
+import (
+	"net/url"
+	"time"
+)
+
+// View is a saved configuration of a list endpoint: its filters, sort and columns. Views
+// belong to the user who saved them and can be shared with roles.
+type View struct {
+	Id        uint              `json:"id" gorm:"primaryKey"`
+	UserId    uint              `json:"user_id" gorm:"index;not null"`             // Owner
+	Resource  string            `json:"resource" gorm:"size:64;index;not null"`    // List the view is for, e.g. "orders"
+	Name      string            `json:"name" gorm:"size:100;not null"`             // e.g. "Unpaid orders this month"
+	Filters   map[string]string `json:"filters" gorm:"type:text;serializer:json"`  // Query parameters of the list, e.g. {"status": "pending"}
+	Sort      string            `json:"sort" gorm:"size:64"`                       // Sort field of the list
+	Order     string            `json:"order" gorm:"size:4"`                       // asc or desc
+	Columns   []string          `json:"columns" gorm:"type:text;serializer:json"`  // Columns shown, in order
+	RoleIds   []uint            `json:"role_ids" gorm:"type:text;serializer:json"` // Roles the view is shared with
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// TableName returns the table name for the View model
+func (m *View) TableName() string {
+	return "saved_views"
+}
+
+// GetId returns the Id of the model
+func (m *View) GetId() uint {
+	return m.Id
+}
+
+// CreateViewRequest represents the request payload for saving a view
+type CreateViewRequest struct {
+	Resource string            `json:"resource"`
+	Name     string            `json:"name"`
+	Filters  map[string]string `json:"filters,omitempty"`
+	Sort     string            `json:"sort,omitempty"`
+	Order    string            `json:"order,omitempty"`
+	Columns  []string          `json:"columns,omitempty"`
+	RoleIds  []uint            `json:"role_ids,omitempty"`
+}
+
+// UpdateViewRequest represents the request payload for updating a view. Omitted fields are
+// left unchanged; an empty role_ids list stops sharing the view.
+type UpdateViewRequest struct {
+	Name    *string            `json:"name,omitempty"`
+	Filters *map[string]string `json:"filters,omitempty"`
+	Sort    *string            `json:"sort,omitempty"`
+	Order   *string            `json:"order,omitempty"`
+	Columns *[]string          `json:"columns,omitempty"`
+	RoleIds *[]uint            `json:"role_ids,omitempty"`
+}
+
+// ViewResponse represents the API response for a view
+type ViewResponse struct {
+	Id        uint              `json:"id"`
+	UserId    uint              `json:"user_id"`
+	Resource  string            `json:"resource"`
+	Name      string            `json:"name"`
+	Filters   map[string]string `json:"filters"`
+	Sort      string            `json:"sort"`
+	Order     string            `json:"order"`
+	Columns   []string          `json:"columns"`
+	RoleIds   []uint            `json:"role_ids"`
+	Owned     bool              `json:"owned"` // Whether the current user saved the view, and can change it
+	Query     string            `json:"query"` // Query string of the list, with the date tokens of the filters resolved
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// ToResponse converts a view to its API response for a user, at a time
+func (m *View) ToResponse(userId uint, now time.Time) *ViewResponse {
+	if m == nil {
+		return nil
+	}
+	response := &ViewResponse{
+		Id:        m.Id,
+		UserId:    m.UserId,
+		Resource:  m.Resource,
+		Name:      m.Name,
+		Filters:   m.Filters,
+		Sort:      m.Sort,
+		Order:     m.Order,
+		Columns:   m.Columns,
+		RoleIds:   m.RoleIds,
+		Owned:     m.UserId == userId,
+		CreatedAt: m.CreatedAt,
+		UpdatedAt: m.UpdatedAt,
+	}
+	if response.Filters == nil {
+		response.Filters = map[string]string{}
+	}
+	if response.Columns == nil {
+		response.Columns = []string{}
+	}
+	if response.RoleIds == nil {
+		response.RoleIds = []uint{}
+	}
+
+	query := url.Values{}
+	for key, value := range m.Filters {
+		query.Set(key, resolveToken(value, now))
+	}
+	if m.Sort != "" {
+		query.Set("sort", m.Sort)
+	}
+	if m.Order != "" {
+		query.Set("order", m.Order)
+	}
+	response.Query = query.Encode()
+	return response
+}
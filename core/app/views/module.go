@@ -0,0 +1,48 @@
+package views
+
+import (
+	"base/core/module"
+	"base/core/router"
+
+	"gorm.io/gorm"
+)
+
+// Module saves the filters, sort and columns of lists as named views per user, which they
+// can share with roles
+type Module struct {
+	module.DefaultModule
+	DB         *gorm.DB
+	Service    *ViewService
+	Controller *ViewController
+}
+
+// Init creates and initializes the views module with all dependencies
+func Init(deps module.Dependencies) module.Module {
+	registerEvents()
+	service := NewViewService(deps.DB, deps.Emitter, deps.Logger)
+
+	return &Module{
+		DB:         deps.DB,
+		Service:    service,
+		Controller: NewViewController(service),
+	}
+}
+
+// Routes registers the module routes
+func (m *Module) Routes(router *router.RouterGroup) {
+	m.Controller.Routes(router)
+}
+
+func (m *Module) Init() error {
+	return nil
+}
+
+func (m *Module) Migrate() error {
+	return m.DB.AutoMigrate(&View{})
+}
+
+func (m *Module) GetModels() []any {
+	return []any{
+		&View{},
+	}
+}
@@ -0,0 +1,327 @@
+package views
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"base/core/emitter"
+	"base/core/logger"
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+const (
+	CreateViewEvent = "views.create"
+	UpdateViewEvent = "views.update"
+	DeleteViewEvent = "views.delete"
+)
+
+// registerEvents adds the events of the module to the event catalog
+func registerEvents() {
+	emitter.RegisterEvents(
+		emitter.EventType{Name: CreateViewEvent, Description: "A list view was saved", Payload: &View{}},
+		emitter.EventType{Name: UpdateViewEvent, Description: "A saved list view was updated", Payload: &View{}},
+		emitter.EventType{Name: DeleteViewEvent, Description: "A saved list view was deleted", Payload: &View{}},
+	)
+}
+
+// ErrNotOwner is returned for changing a view shared with the user by someone else
+var ErrNotOwner = errors.New("only the owner of a view can change it")
+
+var (
+	// resourcePattern matches list resources such as "orders" or "product-categories"
+	resourcePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,63}$`)
+
+	// fieldPattern matches filter keys, sort fields and columns such as "status",
+	// "customer.email" or "created_at[gte]"
+	fieldPattern = regexp.MustCompile(`^[A-Za-z0-9_.\[\]-]{1,64}$`)
+)
+
+// tokens are the dates filter values can be relative to, resolved when a view is read, so
+// that e.g. {"created_from": "{month_start}"} always means this month
+var tokens = map[string]func(now time.Time) time.Time{
+	"{today}": func(now time.Time) time.Time { return now },
+	"{week_start}": func(now time.Time) time.Time {
+		return now.AddDate(0, 0, -(int(now.Weekday())+6)%7) // Monday
+	},
+	"{month_start}": func(now time.Time) time.Time { return now.AddDate(0, 0, 1-now.Day()) },
+	"{year_start}":  func(now time.Time) time.Time { return now.AddDate(0, 0, 1-now.YearDay()) },
+}
+
+// resolveToken returns the date of a token filter value in UTC, e.g. 2024-05-01, or the
+// value itself
+func resolveToken(value string, now time.Time) string {
+	if resolve, ok := tokens[value]; ok {
+		return resolve(now.UTC()).Format("2006-01-02")
+	}
+	return value
+}
+
+// ViewService saves the list views of users and shares them with roles
+type ViewService struct {
+	DB      *gorm.DB
+	Emitter *emitter.Emitter
+	Logger  logger.Logger
+}
+
+func NewViewService(db *gorm.DB, emitter *emitter.Emitter, logger logger.Logger) *ViewService {
+	return &ViewService{
+		DB:      db,
+		Emitter: emitter,
+		Logger:  logger,
+	}
+}
+
+// GetAll returns the views a user can use, of one resource or all: theirs and the ones
+// shared with their role, by resource and name
+func (s *ViewService) GetAll(ctx context.Context, userId uint, resource string) ([]*View, error) {
+	roleId, err := s.roleOf(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	query := s.DB.WithContext(ctx)
+	if resource != "" {
+		query = query.Where("resource = ?", resource)
+	}
+	var items []*View
+	if err := query.Order("resource, name, id").Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch views: %w", err)
+	}
+
+	// Views are shared with few roles, so they are matched here rather than in JSON queries
+	result := make([]*View, 0, len(items))
+	for _, item := range items {
+		if item.UserId == userId || slices.Contains(item.RoleIds, roleId) {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+// GetById returns a view the user can use
+func (s *ViewService) GetById(ctx context.Context, userId, id uint) (*View, error) {
+	var item View
+	if err := s.DB.WithContext(ctx).First(&item, id).Error; err != nil {
+		return nil, err
+	}
+	if item.UserId == userId {
+		return &item, nil
+	}
+
+	roleId, err := s.roleOf(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(item.RoleIds, roleId) {
+		// Views of others aren't disclosed
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &item, nil
+}
+
+// Create saves a view of a user
+func (s *ViewService) Create(ctx context.Context, userId uint, req *CreateViewRequest) (*View, error) {
+	item := &View{
+		UserId:   userId,
+		Resource: req.Resource,
+		Name:     strings.TrimSpace(req.Name),
+		Filters:  req.Filters,
+		Sort:     req.Sort,
+		Order:    req.Order,
+		Columns:  req.Columns,
+		RoleIds:  req.RoleIds,
+	}
+	if err := s.validate(ctx, item); err != nil {
+		return nil, err
+	}
+
+	if err := s.DB.WithContext(ctx).Create(item).Error; err != nil {
+		s.Logger.Error("failed to create view", logger.String("error", err.Error()))
+		return nil, fmt.Errorf("failed to create view: %w", err)
+	}
+
+	s.Emitter.EmitContext(ctx, CreateViewEvent, item)
+	return item, nil
+}
+
+// Update changes a view of a user; views shared with them can't be changed
+func (s *ViewService) Update(ctx context.Context, userId, id uint, req *UpdateViewRequest) (*View, error) {
+	item, err := s.owned(ctx, userId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		item.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Filters != nil {
+		item.Filters = *req.Filters
+	}
+	if req.Sort != nil {
+		item.Sort = *req.Sort
+	}
+	if req.Order != nil {
+		item.Order = *req.Order
+	}
+	if req.Columns != nil {
+		item.Columns = *req.Columns
+	}
+	if req.RoleIds != nil {
+		item.RoleIds = *req.RoleIds
+	}
+	if err := s.validate(ctx, item); err != nil {
+		return nil, err
+	}
+
+	if err := s.DB.WithContext(ctx).Save(item).Error; err != nil {
+		s.Logger.Error("failed to update view",
+			logger.String("error", err.Error()),
+			logger.Uint("id", id))
+		return nil, fmt.Errorf("failed to update view: %w", err)
+	}
+
+	s.Emitter.EmitContext(ctx, UpdateViewEvent, item)
+	return item, nil
+}
+
+// Delete deletes a view of a user
+func (s *ViewService) Delete(ctx context.Context, userId, id uint) error {
+	item, err := s.owned(ctx, userId, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.DB.WithContext(ctx).Delete(item).Error; err != nil {
+		s.Logger.Error("failed to delete view",
+			logger.String("error", err.Error()),
+			logger.Uint("id", id))
+		return fmt.Errorf("failed to delete view: %w", err)
+	}
+
+	s.Emitter.EmitContext(ctx, DeleteViewEvent, item)
+	return nil
+}
+
+// owned returns a view the user can change
+func (s *ViewService) owned(ctx context.Context, userId, id uint) (*View, error) {
+	item, err := s.GetById(ctx, userId, id)
+	if err != nil {
+		return nil, err
+	}
+	if item.UserId != userId {
+		return nil, ErrNotOwner
+	}
+	return item, nil
+}
+
+// roleOf returns the role of a user
+func (s *ViewService) roleOf(ctx context.Context, userId uint) (uint, error) {
+	var roleId uint
+	err := s.DB.WithContext(ctx).Table("users").Select("role_id").Where("id = ?", userId).Scan(&roleId).Error
+	return roleId, err
+}
+
+// validate checks a view, and that its name is unique among the views of its owner for its
+// resource
+func (s *ViewService) validate(ctx context.Context, item *View) error {
+	var errs validator.ValidationErrors
+	if !resourcePattern.MatchString(item.Resource) {
+		errs = append(errs, validator.ValidationError{
+			Field: "resource", Tag: "invalid", Value: item.Resource,
+			Message: "resource must contain only lowercase letters, digits, '_' or '-'",
+		})
+	}
+	switch {
+	case item.Name == "":
+		errs = append(errs, validator.ValidationError{
+			Field: "name", Tag: "required",
+			Message: "name is required",
+		})
+	case len(item.Name) > 100:
+		errs = append(errs, validator.ValidationError{
+			Field: "name", Tag: "max", Param: "100", Value: item.Name,
+			Message: "name must be at most 100 characters",
+		})
+	}
+
+	keys := make([]string, 0, len(item.Filters))
+	for key := range item.Filters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		switch {
+		case !fieldPattern.MatchString(key):
+			errs = append(errs, validator.ValidationError{
+				Field: "filters." + key, Tag: "invalid", Value: key,
+				Message: "filter names must contain only letters, digits, '.', '_', '-', '[' or ']'",
+			})
+		case slices.Contains([]string{"sort", "order", "page", "limit"}, key):
+			errs = append(errs, validator.ValidationError{
+				Field: "filters." + key, Tag: "excluded", Value: key,
+				Message: fmt.Sprintf("%s isn't a filter", key),
+			})
+		}
+	}
+	if item.Sort != "" && !fieldPattern.MatchString(item.Sort) {
+		errs = append(errs, validator.ValidationError{
+			Field: "sort", Tag: "invalid", Value: item.Sort,
+			Message: "sort must contain only letters, digits, '.', '_', '-', '[' or ']'",
+		})
+	}
+	if item.Order != "" && item.Order != "asc" && item.Order != "desc" {
+		errs = append(errs, validator.ValidationError{
+			Field: "order", Tag: "oneof", Param: "asc desc", Value: item.Order,
+			Message: "order must be one of asc, desc",
+		})
+	}
+	for i, column := range item.Columns {
+		if !fieldPattern.MatchString(column) {
+			errs = append(errs, validator.ValidationError{
+				Field: fmt.Sprintf("columns[%d]", i), Tag: "invalid", Value: column,
+				Message: "columns must contain only letters, digits, '.', '_', '-', '[' or ']'",
+			})
+		}
+	}
+
+	if len(item.RoleIds) > 0 {
+		var count int64
+		if err := s.DB.WithContext(ctx).Table("roles").Where("id IN ?", item.RoleIds).Count(&count).Error; err != nil {
+			return err
+		}
+		if int(count) != len(slices.Compact(slices.Sorted(slices.Values(item.RoleIds)))) {
+			errs = append(errs, validator.ValidationError{
+				Field: "role_ids", Tag: "exists", Value: fmt.Sprint(item.RoleIds),
+				Message: "role_ids must be existing roles",
+			})
+		}
+	}
+
+	if len(errs) == 0 {
+		var count int64
+		if err := s.DB.WithContext(ctx).Model(&View{}).
+			Where("user_id = ? AND resource = ? AND name = ? AND id <> ?", item.UserId, item.Resource, item.Name, item.Id).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			errs = append(errs, validator.ValidationError{
+				Field: "name", Tag: "unique", Value: item.Name,
+				Message: "name is already taken by another view of this list",
+			})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+// Package bundle exports configuration-like data of the modules (settings, roles and
+// permissions, feature flags, menus...) as one JSON bundle and imports it into another
+// environment, so staging configuration can be promoted to production reproducibly.
+//
+// Modules add a section for their data with RegisterSection. Sections match records by a
+// natural key (a setting key, a role name, a menu handle) rather than by id, since ids differ
+// between environments. Imports create and update records but never delete any, and a dry
+// run reports the same changes without keeping them.
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+// Version is the format of the bundles written by Export
+const Version = 1
+
+// Section is the data a module adds to bundles
+type Section struct {
+	Name string // Key of the section in bundles, e.g. "settings"
+
+	// Order sorts the imports of sections referring to each other: roles come after the
+	// permissions they list and feature flags after the roles they are limited to
+	Order int
+
+	// Export returns the records of the section, without ids
+	Export func(ctx context.Context, db *gorm.DB) (any, error)
+
+	// Import creates and updates the records of data in tx and tracks them in changes.
+	// Invalid records fail with validation errors.
+	Import func(ctx context.Context, tx *gorm.DB, data json.RawMessage, changes *Changes) error
+
+	// Applied runs after a committed import changed records of the section, e.g. to
+	// reload caches (optional)
+	Applied func(ctx context.Context, changes *Changes)
+}
+
+// Bundle is exported configuration, by section
+type Bundle struct {
+	Version    int                        `json:"version"`
+	ExportedAt time.Time                  `json:"exported_at"`
+	Sections   map[string]json.RawMessage `json:"sections"`
+}
+
+// Changes are the records an import created and updated in a section
+type Changes struct {
+	Created   []string  `json:"created"`
+	Updated   []*Update `json:"updated"`
+	Unchanged int       `json:"unchanged"`
+	Skipped   []*Skip   `json:"skipped,omitempty"` // Changes of the bundle the import left out
+}
+
+// Skip is a change of a record of the bundle an import left out, with why
+type Skip struct {
+	Key    string `json:"key"`
+	Reason string `json:"reason"`
+}
+
+// Update is a record an import changed, with its changed fields
+type Update struct {
+	Key    string                  `json:"key"`
+	Fields map[string]*FieldChange `json:"fields"`
+}
+
+// FieldChange is the value of a field before and after an import
+type FieldChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// Report is the outcome of an import, by section
+type Report struct {
+	DryRun   bool                `json:"dry_run"`
+	Sections map[string]*Changes `json:"sections"`
+}
+
+var (
+	sectionsMu sync.RWMutex
+	sections   = map[string]Section{}
+
+	// errDryRun rolls dry runs back
+	errDryRun = errors.New("dry run")
+)
+
+// RegisterSection adds the data of a module to bundles, e.g. from a module's Init
+func RegisterSection(section Section) {
+	sectionsMu.Lock()
+	defer sectionsMu.Unlock()
+	sections[section.Name] = section
+}
+
+// Sections returns the registered sections in import order
+func Sections() []Section {
+	sectionsMu.RLock()
+	defer sectionsMu.RUnlock()
+	result := make([]Section, 0, len(sections))
+	for _, section := range sections {
+		result = append(result, section)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Order != result[j].Order {
+			return result[i].Order < result[j].Order
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// Export returns a bundle of the named sections, all of them when names is empty
+func Export(ctx context.Context, db *gorm.DB, names []string) (*Bundle, error) {
+	selected, err := selectSections(names, "sections")
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &Bundle{Version: Version, ExportedAt: time.Now().UTC(), Sections: make(map[string]json.RawMessage, len(selected))}
+	for _, section := range selected {
+		records, err := section.Export(ctx, db.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("failed to export %s: %w", section.Name, err)
+		}
+		data, err := json.Marshal(records)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export %s: %w", section.Name, err)
+		}
+		bundle.Sections[section.Name] = data
+	}
+	return bundle, nil
+}
+
+// Import applies the sections of a bundle in one transaction and reports what changed. A
+// dry run rolls the transaction back, so the report is a diff of the bundle against the
+// database.
+func Import(ctx context.Context, db *gorm.DB, bundle *Bundle, dryRun bool) (*Report, error) {
+	if bundle.Version != Version {
+		return nil, validator.ValidationErrors{{
+			Field:   "version",
+			Tag:     "eq",
+			Param:   fmt.Sprint(Version),
+			Value:   fmt.Sprint(bundle.Version),
+			Message: fmt.Sprintf("version must be %d", Version),
+		}}
+	}
+	names := make([]string, 0, len(bundle.Sections))
+	for name := range bundle.Sections {
+		names = append(names, name)
+	}
+	selected, err := selectSections(names, "sections")
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{DryRun: dryRun, Sections: make(map[string]*Changes, len(selected))}
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var errs validator.ValidationErrors
+		for _, section := range selected {
+			changes := &Changes{Created: []string{}, Updated: []*Update{}}
+			err := section.Import(ctx, tx, bundle.Sections[section.Name], changes)
+			var sectionErrs validator.ValidationErrors
+			if errors.As(err, &sectionErrs) {
+				for _, e := range sectionErrs {
+					e.Field = "sections." + section.Name + prefix(e.Field)
+					errs = append(errs, e)
+				}
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to import %s: %w", section.Name, err)
+			}
+			report.Sections[section.Name] = changes
+		}
+		if len(errs) > 0 {
+			return errs
+		}
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+
+	if !dryRun {
+		for _, section := range selected {
+			changes := report.Sections[section.Name]
+			if section.Applied != nil && (len(changes.Created) > 0 || len(changes.Updated) > 0) {
+				section.Applied(ctx, changes)
+			}
+		}
+	}
+	return report, nil
+}
+
+// Track records a record of an import: created when before (a pointer) is nil, updated
+// when the JSON fields of before and after differ, unchanged otherwise. It reports whether
+// the record needs saving.
+func (c *Changes) Track(key string, before, after any) bool {
+	if before == nil || reflect.ValueOf(before).IsNil() {
+		c.Created = append(c.Created, key)
+		return true
+	}
+
+	from, to := fields(before), fields(after)
+	update := &Update{Key: key, Fields: map[string]*FieldChange{}}
+	for name, value := range to {
+		if !reflect.DeepEqual(from[name], value) {
+			update.Fields[name] = &FieldChange{From: from[name], To: value}
+		}
+	}
+	if len(update.Fields) == 0 {
+		c.Unchanged++
+		return false
+	}
+	c.Updated = append(c.Updated, update)
+	return true
+}
+
+// Skip records a change of a record of the bundle the import leaves out, e.g. because the
+// record is protected
+func (c *Changes) Skip(key, reason string) {
+	c.Skipped = append(c.Skipped, &Skip{Key: key, Reason: reason})
+}
+
+// Changed returns the keys of the created and updated records
+func (c *Changes) Changed() []string {
+	keys := slices.Clone(c.Created)
+	for _, update := range c.Updated {
+		keys = append(keys, update.Key)
+	}
+	return keys
+}
+
+// fields returns the JSON fields of a record
+func fields(record any) map[string]any {
+	data, _ := json.Marshal(record)
+	var result map[string]any
+	_ = json.Unmarshal(data, &result)
+	return result
+}
+
+// selectSections returns the registered sections of names in import order, all of them when
+// names is empty
+func selectSections(names []string, field string) ([]Section, error) {
+	all := Sections()
+	if len(names) == 0 {
+		return all, nil
+	}
+
+	known := make([]string, len(all))
+	for i, section := range all {
+		known[i] = section.Name
+	}
+	var errs validator.ValidationErrors
+	for _, name := range names {
+		if !slices.Contains(known, name) {
+			errs = append(errs, validator.ValidationError{
+				Field: field, Tag: "oneof", Param: strings.Join(known, " "), Value: name,
+				Message: fmt.Sprintf("%s must be one of %s", field, strings.Join(known, ", ")),
+			})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	selected := make([]Section, 0, len(names))
+	for _, section := range all {
+		if slices.Contains(names, section.Name) {
+			selected = append(selected, section)
+		}
+	}
+	return selected, nil
+}
+
+// prefix returns the field of an error of a section as a path suffix, e.g. [2].name or .name
+func prefix(field string) string {
+	if field == "" || strings.HasPrefix(field, "[") {
+		return field
+	}
+	return "." + field
+}
@@ -18,14 +18,34 @@ const (
 	DefaultEnvironment   = "debug"
 	DefaultVersion       = "0.0.1"
 
+	// HTTPS defaults
+	DefaultTLSAutocertCache = "storage/certs"
+	DefaultServerHTTP2      = true
+
 	// Database defaults
-	DefaultDBDriver   = "mysql"
-	DefaultDBHost     = "localhost"
-	DefaultDBPort     = "3306"
-	DefaultDBUser     = "root"
-	DefaultDBPassword = "RockeT"
-	DefaultDBName     = "mydatabase"
-	DefaultDBPath     = "test.db"
+	DefaultDBDriver    = "mysql"
+	DefaultDBHost      = "localhost"
+	DefaultDBPort      = "3306"
+	DefaultDBUser      = "root"
+	DefaultDBPassword  = "RockeT"
+	DefaultDBName      = "mydatabase"
+	DefaultDBPath      = "test.db"
+	DefaultAutoMigrate = true
+
+	// Connection pool defaults
+	DefaultDBMaxOpenConns     = 25
+	DefaultDBMaxIdleConns     = 10
+	DefaultDBConnMaxLifetime  = "30m"
+	DefaultDBConnMaxIdleTime  = "5m"
+	DefaultDBStatementTimeout = "30s"
+
+	// Query instrumentation defaults
+	DefaultDBSlowQueryThreshold = "200ms"
+	DefaultDBQueryCountHeader   = false
+
+	// List count defaults
+	DefaultDBCountMode     = "exact"
+	DefaultDBCountCacheTTL = "1m"
 
 	// Security defaults
 	DefaultJWTSecret = "secret"
@@ -34,6 +54,7 @@ const (
 	// Email defaults
 	DefaultEmailProvider    = "default"
 	DefaultEmailFromAddress = "no-reply@localhost"
+	DefaultEmailDevPath     = "logs/emails"
 	DefaultSMTPPort         = 587
 
 	// Storage defaults
@@ -44,10 +65,112 @@ const (
 	DefaultStorageBucket     = "default"
 	DefaultStorageExtensions = ".jpg,.jpeg,.png,.gif,.pdf,.doc,.docx"
 
+	// Upload defaults: the size cap of attachments without their own and the part of
+	// multipart requests kept in memory (the rest is written to temporary files)
+	DefaultUploadMaxSize   = 104857600 // 100MB
+	DefaultMultipartMemory = 33554432  // 32MB
+
 	// Feature toggles defaults
 	DefaultWebSocketEnabled = true
 	DefaultSwaggerEnabled   = true
+	DefaultMetricsEnabled   = true
+	DefaultGraphQLEnabled   = false
+	DefaultGRPCEnabled      = false
+	DefaultMaintenanceMode  = false
 	DefaultOLTProvider      = "smartolt"
+
+	// gRPC defaults
+	DefaultGRPCPort = ":9090"
+
+	// Maintenance mode defaults
+	DefaultMaintenanceRetryAfter = "5m"
+	DefaultMaintenanceAllowPaths = "/health,/health/*,/api/auth/*"
+
+	// Response envelope defaults
+	DefaultResponseEnvelope          = "off"
+	DefaultResponseEnvelopeSkipPaths = "/health,/health/*,/metrics,/api/openapi.json,/api/graphql,/api/graphql/*"
+
+	// CORS defaults: the admin API for CORS_ALLOWED_ORIGINS with credentials, the public API
+	// for any site
+	DefaultCORSAllowCredentials = true
+	DefaultCORSExposeHeaders    = "Content-Length,Content-Type,Content-Disposition,ETag,Retry-After,X-RateLimit-Limit,X-RateLimit-Remaining,X-RateLimit-Reset,X-Request-Id,X-Response-Envelope"
+	DefaultCORSMaxAge           = "12h"
+	DefaultCORSPublicPaths      = "/api/public/*"
+	DefaultCORSPublicOrigins    = "*"
+
+	// Client IP defaults: a reverse proxy on the same host
+	DefaultTrustedProxies  = "127.0.0.1,::1"
+	DefaultClientIPHeaders = "X-Forwarded-For,X-Real-IP"
+
+	// Response cache defaults
+	DefaultResponseCacheTTL = "1m"
+
+	// How long preview links of unpublished pages work
+	DefaultPreviewTokenTTL = "72h"
+
+	// How long single-use download links of generated files work
+	DefaultDownloadTokenTTL = "24h"
+
+	// How long edit locks last without a heartbeat
+	DefaultLockTTL = "2m"
+
+	// Cache lifetime of the files under /static and /storage
+	DefaultStaticCacheMaxAge = "1h"
+
+	// Logging defaults
+	DefaultLogLevel             = "debug"
+	DefaultLogServiceName       = "base-api"
+	DefaultLogSyslogNetwork     = "udp"
+	DefaultLogSinkBufferSize    = 1000
+	DefaultLogSinkBatchSize     = 100
+	DefaultLogSinkFlushInterval = "2s"
+
+	// Locale defaults
+	DefaultLocale           = "en"
+	DefaultSupportedLocales = "en"
+	DefaultTimezone         = "UTC"
+	DefaultDateFormat       = "YYYY-MM-DD"
+	DefaultTimeFormat       = "24h"
+
+	// HTML sanitization default: formatting, links, images and tables are kept
+	DefaultHTMLPolicy = "relaxed"
+
+	// Request log defaults
+	DefaultRequestLogEnabled    = false
+	DefaultRequestLogSampleRate = 100
+	DefaultRequestLogRetention  = "168h"
+	DefaultRequestLogBodyLimit  = 2048
+	DefaultRequestLogSkipPaths  = "/health,/health/*,/metrics,/api/logs/*"
+
+	// Activity archive defaults
+	DefaultActivityArchiveAfter  = "0"
+	DefaultActivityArchiveExport = false
+
+	// Auth throttling defaults
+	DefaultAuthThrottleAttempts = 5
+	DefaultAuthThrottleMaxDelay = "15m"
+	DefaultAuthCaptchaAfter     = 10
+
+	// Report defaults
+	DefaultReportsPath    = "data/reports"
+	DefaultReportsWorkers = 2
+	DefaultReportsMaxRows = 100000
+
+	// PDF defaults
+	DefaultPDFTemplatesPath = "templates"
+	DefaultPDFPageSize      = "A4"
+
+	// Payment defaults
+	DefaultPaymentsProvider = "stripe"
+	DefaultPaymentsCurrency = "USD"
+	DefaultStripeAPIURL     = "https://api.stripe.com"
+
+	// Exchange rate defaults
+	DefaultExchangeRatesURL     = "https://api.frankfurter.app"
+	DefaultExchangeRatesRefresh = "12h"
+
+	// Media caption defaults
+	DefaultMediaCaptionModel = "gpt-4o-mini"
 )
 
 // Config holds the application configuration.
@@ -64,6 +187,17 @@ type Config struct {
 	DBName               string
 	DBPath               string
 	DBURL                string
+	DBReplicaURLs        []string
+	AutoMigrate          bool
+	DBMaxOpenConns       int
+	DBMaxIdleConns       int
+	DBConnMaxLifetime    time.Duration
+	DBConnMaxIdleTime    time.Duration
+	DBStatementTimeout   time.Duration
+	DBSlowQueryThreshold time.Duration
+	DBQueryCountHeader   bool
+	DBCountMode          string
+	DBCountCacheTTL      time.Duration
 	ApiKey               string
 	JWTSecret            string
 	ServerAddress        string
@@ -72,6 +206,7 @@ type Config struct {
 	Version              string
 	EmailProvider        string
 	EmailFromAddress     string
+	EmailDevPath         string
 	SMTPHost             string
 	SMTPPort             int
 	SMTPUsername         string
@@ -91,8 +226,168 @@ type Config struct {
 	StoragePublicURL     string   `json:"storage_public_url"`
 	StorageMaxSize       int64    `json:"storage_max_size"`
 	StorageAllowedExt    []string `json:"storage_allowed_ext"`
+	UploadMaxSize        int64    `json:"upload_max_size"`
+	MultipartMemory      int64    `json:"multipart_memory"`
 	WebSocketEnabled     bool     `json:"websocket_enabled"`
 	SwaggerEnabled       bool     `json:"swagger_enabled"`
+	MetricsEnabled       bool     `json:"metrics_enabled"`
+	GraphQLEnabled       bool     `json:"graphql_enabled"`
+	MaintenanceMode      bool     `json:"maintenance_mode"`
+	LogLevel             string   `json:"log_level"`
+	DefaultLocale        string   `json:"default_locale"`
+	SupportedLocales     []string `json:"supported_locales"`
+	Timezone             string   `json:"timezone"`
+	DateFormat           string   `json:"date_format"`
+	TimeFormat           string   `json:"time_format"`
+
+	// Policy rich-text fields are sanitized with (strict or relaxed), and the fields with
+	// their own policy as field=policy (see sanitize.Sanitizer)
+	HTMLPolicy        string   `json:"html_policy"`
+	HTMLFieldPolicies []string `json:"html_field_policies"`
+
+	// Remote log sinks (each one is enabled by setting its address)
+	LogServiceName       string            `json:"log_service_name"`
+	LogLokiURL           string            `json:"log_loki_url"`
+	LogLokiLabels        map[string]string `json:"log_loki_labels"`
+	LogSyslogNetwork     string            `json:"log_syslog_network"`
+	LogSyslogAddress     string            `json:"log_syslog_address"`
+	LogOTLPEndpoint      string            `json:"log_otlp_endpoint"`
+	LogOTLPHeaders       map[string]string `json:"-"`
+	LogSinkBufferSize    int               `json:"log_sink_buffer_size"`
+	LogSinkBatchSize     int               `json:"log_sink_batch_size"`
+	LogSinkFlushInterval time.Duration     `json:"log_sink_flush_interval"`
+
+	// Per-module log levels ("storage=debug,router=warn") and the token that enables
+	// debug logging for a single request
+	LogModuleLevels map[string]string `json:"log_module_levels"`
+	LogDebugToken   string            `json:"-"`
+
+	// Request log stored in the database: percentage of successful requests to keep (failed
+	// requests are always kept), how long to keep them and how much of the body to store
+	RequestLogEnabled    bool          `json:"request_log_enabled"`
+	RequestLogSampleRate int           `json:"request_log_sample_rate"`
+	RequestLogRetention  time.Duration `json:"request_log_retention"`
+	RequestLogBodyLimit  int           `json:"request_log_body_limit"`
+	RequestLogSkipPaths  []string      `json:"request_log_skip_paths"`
+
+	// Security events (see core/security) forwarded to a SIEM: a syslog server and/or an HTTP
+	// endpoint that receives batches of events as JSON arrays
+	SecurityLogSyslogNetwork string            `json:"security_log_syslog_network"`
+	SecurityLogSyslogAddress string            `json:"security_log_syslog_address"`
+	SecurityLogHTTPURL       string            `json:"security_log_http_url"`
+	SecurityLogHTTPHeaders   map[string]string `json:"-"`
+
+	// Activity archive: age at which activities move to activities_archive (0 disables it)
+	// and whether archived batches are also exported to storage
+	ActivityArchiveAfter  time.Duration `json:"activity_archive_after"`
+	ActivityArchiveExport bool          `json:"activity_archive_export"`
+
+	// Auth throttling: failed logins and password reset requests allowed per client IP and
+	// per email before attempts are delayed (0 disables it), the longest delay, and the
+	// failures after which a registered captcha is required (0 never asks)
+	AuthThrottleAttempts int           `json:"auth_throttle_attempts"`
+	AuthThrottleMaxDelay time.Duration `json:"auth_throttle_max_delay"`
+	AuthCaptchaAfter     int           `json:"auth_captcha_after"`
+
+	// Reports: where generated files are kept (outside the public storage directory), how
+	// many reports are generated at once and the row limit of a report
+	ReportsPath    string `json:"reports_path"`
+	ReportsWorkers int    `json:"reports_workers"`
+	ReportsMaxRows int    `json:"reports_max_rows"`
+
+	// Quotas reported by GET /limits so clients can pace themselves: bytes of stored files
+	// and user accounts; 0 is unlimited
+	StorageQuota int64 `json:"storage_quota"`
+	UserSeats    int   `json:"user_seats"`
+
+	// PDF: directory of the HTML templates (and their images) and the page size
+	PDFTemplatesPath string `json:"pdf_templates_path"`
+	PDFPageSize      string `json:"pdf_page_size"`
+
+	// Payments: the provider, the currency of payments created without one and the Stripe
+	// API (stripe-mock in development), key and webhook signing secret
+	PaymentsProvider    string `json:"payments_provider"`
+	PaymentsCurrency    string `json:"payments_currency"`
+	StripeAPIURL        string `json:"stripe_api_url"`
+	StripeSecretKey     string `json:"-"`
+	StripeWebhookSecret string `json:"-"`
+
+	// Shipping: the secret carrier status webhooks are signed with (HMAC-SHA256 of the body)
+	ShippingWebhookSecret string `json:"-"`
+
+	// Exchange rates: the provider ("frankfurter", empty to keep rates set by hand), its API
+	// and key, and how often the rates of the currencies setting are refreshed
+	ExchangeRatesProvider string        `json:"exchange_rates_provider"`
+	ExchangeRatesURL      string        `json:"exchange_rates_url"`
+	ExchangeRatesAPIKey   string        `json:"-"`
+	ExchangeRatesRefresh  time.Duration `json:"exchange_rates_refresh"`
+
+	// Media captions: the provider writing the alt text of uploaded images ("http", "openai",
+	// empty to leave it to the uploader), its API and key, and the model of "openai"
+	MediaCaptionProvider string `json:"media_caption_provider"`
+	MediaCaptionURL      string `json:"media_caption_url"`
+	MediaCaptionAPIKey   string `json:"-"`
+	MediaCaptionModel    string `json:"media_caption_model"`
+
+	// gRPC: the port of the internal services (see core/grpc) and the key their clients send
+	// in the x-api-key metadata
+	GRPCEnabled bool   `json:"grpc_enabled"`
+	GRPCPort    string `json:"grpc_port"`
+	GRPCAPIKey  string `json:"-"`
+
+	// Maintenance mode: paths that stay available, the Retry-After value and the bypass token
+	MaintenanceAllowPaths  []string      `json:"maintenance_allow_paths"`
+	MaintenanceRetryAfter  time.Duration `json:"maintenance_retry_after"`
+	MaintenanceBypassToken string        `json:"-"`
+
+	// Response envelope: "off", "compat" (for requests sending X-Response-Envelope: true) or
+	// "on", and the paths whose responses keep their shape (see router.Envelope)
+	ResponseEnvelope          string   `json:"response_envelope"`
+	ResponseEnvelopeSkipPaths []string `json:"response_envelope_skip_paths"`
+
+	// HTTPS: certificate files, or Let's Encrypt certificates for the autocert domains; the
+	// redirect address listens for plain HTTP and redirects it (see router.ServerConfig)
+	TLSCertFile        string   `json:"tls_cert_file"`
+	TLSKeyFile         string   `json:"tls_key_file"`
+	TLSAutocertDomains []string `json:"tls_autocert_domains"`
+	TLSAutocertEmail   string   `json:"tls_autocert_email"`
+	TLSAutocertCache   string   `json:"tls_autocert_cache"`
+	TLSRedirectAddress string   `json:"tls_redirect_address"`
+	ServerHTTP2        bool     `json:"server_http2"`
+
+	// CORS: CORSAllowedOrigins may call every path with credentials, except the public paths,
+	// open to CORSPublicOrigins without credentials (see middleware.CORSPolicy)
+	CORSAllowCredentials bool          `json:"cors_allow_credentials"`
+	CORSExposeHeaders    []string      `json:"cors_expose_headers"`
+	CORSMaxAge           time.Duration `json:"cors_max_age"`
+	CORSPublicPaths      []string      `json:"cors_public_paths"`
+	CORSPublicOrigins    []string      `json:"cors_public_origins"`
+
+	// Client IP: the proxies (IPs and CIDR ranges) trusted to report it, and the headers they
+	// report it in, checked in order (see router.ProxyConfig)
+	TrustedProxies  []string `json:"trusted_proxies"`
+	ClientIPHeaders []string `json:"client_ip_headers"`
+
+	// How long responses of the public settings, pages and menus are cached (0 disables it)
+	ResponseCacheTTL time.Duration `json:"response_cache_ttl"`
+
+	// How long preview tokens show unpublished pages through the public API
+	PreviewTokenTTL time.Duration `json:"preview_token_ttl"`
+
+	// How long single-use download links, e.g. of report runs, work
+	DownloadTokenTTL time.Duration `json:"download_token_ttl"`
+
+	// How long the locks of records being edited last without a heartbeat
+	LockTTL time.Duration `json:"lock_ttl"`
+
+	// Static files: how long browsers may cache those under /static and /storage (0 sends
+	// no Cache-Control), and the paths of ./storage served under /storage ("/uploads/*"
+	// matches everything below storage/uploads; empty serves the local upload directory)
+	StaticCacheMaxAge  time.Duration `json:"static_cache_max_age"`
+	StoragePublicPaths []string      `json:"storage_public_paths"`
+
+	// Runtime holds the values that can be changed without a restart (see RuntimeValues)
+	Runtime *Runtime `json:"-"`
 
 	// Middleware configuration
 	Middleware MiddlewareConfig `json:"middleware"`
@@ -302,6 +597,20 @@ func NewConfig() *Config {
 		ServerAddress: serverAddr,
 		ServerPort:    serverPort,
 		Version:       getEnvWithLog("APP_VERSION", DefaultVersion),
+		LogLevel:      getEnvWithLog("LOG_LEVEL", DefaultLogLevel),
+
+		// Locales the API responds in (see translation.LocaleMiddleware)
+		DefaultLocale:    getEnvWithLog("DEFAULT_LOCALE", DefaultLocale),
+		SupportedLocales: parsePathList("SUPPORTED_LOCALES", DefaultSupportedLocales),
+
+		// Timezone and human-readable date formats (see translation.FormatDate)
+		Timezone:   getEnvWithLog("TIMEZONE", DefaultTimezone),
+		DateFormat: getEnvWithLog("DATE_FORMAT", DefaultDateFormat),
+		TimeFormat: getEnvWithLog("TIME_FORMAT", DefaultTimeFormat),
+
+		// HTML sanitization of rich-text fields
+		HTMLPolicy:        getEnvWithLog("HTML_POLICY", DefaultHTMLPolicy),
+		HTMLFieldPolicies: parsePathList("HTML_FIELD_POLICIES", ""),
 
 		// Database settings
 		DBDriver:   getEnvWithLog("DB_DRIVER", DefaultDBDriver),
@@ -313,6 +622,94 @@ func NewConfig() *Config {
 		DBPath:     getEnvWithLog("DB_PATH", DefaultDBPath),
 		DBURL:      getEnvWithLog("DB_URL", ""),
 
+		// Read replicas (comma-separated DSNs using the same driver as the primary)
+		DBReplicaURLs: parsePathList("DB_REPLICA_URLS", ""),
+
+		// How large lists count their rows (see database.CountMode)
+		DBCountMode: getEnvWithLog("DB_COUNT_MODE", DefaultDBCountMode),
+
+		// Remote log sinks
+		LogServiceName:   getEnvWithLog("LOG_SERVICE_NAME", DefaultLogServiceName),
+		LogLokiURL:       getEnvWithLog("LOG_LOKI_URL", ""),
+		LogLokiLabels:    parseKeyValueList("LOG_LOKI_LABELS"),
+		LogSyslogNetwork: getEnvWithLog("LOG_SYSLOG_NETWORK", DefaultLogSyslogNetwork),
+		LogSyslogAddress: getEnvWithLog("LOG_SYSLOG_ADDRESS", ""),
+		LogOTLPEndpoint:  getEnvWithLog("LOG_OTLP_ENDPOINT", ""),
+		LogOTLPHeaders:   parseKeyValueList("LOG_OTLP_HEADERS"),
+
+		// Per-module log levels and per-request debug logging
+		LogModuleLevels: parseKeyValueList("LOG_MODULE_LEVELS"),
+		LogDebugToken:   getEnvWithLog("LOG_DEBUG_TOKEN", ""),
+
+		// Request log
+		RequestLogSkipPaths: parsePathList("REQUEST_LOG_SKIP_PATHS", DefaultRequestLogSkipPaths),
+
+		// Security event forwarding
+		SecurityLogSyslogNetwork: getEnvWithLog("SECURITY_LOG_SYSLOG_NETWORK", DefaultLogSyslogNetwork),
+		SecurityLogSyslogAddress: getEnvWithLog("SECURITY_LOG_SYSLOG_ADDRESS", ""),
+		SecurityLogHTTPURL:       getEnvWithLog("SECURITY_LOG_HTTP_URL", ""),
+		SecurityLogHTTPHeaders:   parseKeyValueList("SECURITY_LOG_HTTP_HEADERS"),
+
+		// Reports
+		ReportsPath: getEnvWithLog("REPORTS_PATH", DefaultReportsPath),
+
+		// PDF
+		PDFTemplatesPath: getEnvWithLog("PDF_TEMPLATES_PATH", DefaultPDFTemplatesPath),
+		PDFPageSize:      getEnvWithLog("PDF_PAGE_SIZE", DefaultPDFPageSize),
+
+		// Payments
+		PaymentsProvider:    getEnvWithLog("PAYMENTS_PROVIDER", DefaultPaymentsProvider),
+		PaymentsCurrency:    strings.ToUpper(getEnvWithLog("PAYMENTS_CURRENCY", DefaultPaymentsCurrency)),
+		StripeAPIURL:        getEnvWithLog("STRIPE_API_URL", DefaultStripeAPIURL),
+		StripeSecretKey:     getEnvWithLog("STRIPE_SECRET_KEY", ""),
+		StripeWebhookSecret: getEnvWithLog("STRIPE_WEBHOOK_SECRET", ""),
+
+		// Shipping
+		ShippingWebhookSecret: getEnvWithLog("SHIPPING_WEBHOOK_SECRET", ""),
+
+		// Exchange rates
+		ExchangeRatesProvider: getEnvWithLog("EXCHANGE_RATES_PROVIDER", ""),
+		ExchangeRatesURL:      getEnvWithLog("EXCHANGE_RATES_URL", DefaultExchangeRatesURL),
+		ExchangeRatesAPIKey:   getEnvWithLog("EXCHANGE_RATES_API_KEY", ""),
+
+		// Media captions
+		MediaCaptionProvider: getEnvWithLog("MEDIA_CAPTION_PROVIDER", ""),
+		MediaCaptionURL:      getEnvWithLog("MEDIA_CAPTION_URL", ""),
+		MediaCaptionAPIKey:   getEnvWithLog("MEDIA_CAPTION_API_KEY", ""),
+		MediaCaptionModel:    getEnvWithLog("MEDIA_CAPTION_MODEL", DefaultMediaCaptionModel),
+
+		// gRPC
+		GRPCPort:   normalizePort(getEnvWithLog("GRPC_PORT", DefaultGRPCPort)),
+		GRPCAPIKey: getEnvWithLog("GRPC_API_KEY", ""),
+
+		// Maintenance mode
+		MaintenanceAllowPaths:  parsePathList("MAINTENANCE_ALLOW_PATHS", DefaultMaintenanceAllowPaths),
+		MaintenanceBypassToken: getEnvWithLog("MAINTENANCE_BYPASS_TOKEN", ""),
+
+		// Response envelope
+		ResponseEnvelope:          getEnvWithLog("RESPONSE_ENVELOPE", DefaultResponseEnvelope),
+		ResponseEnvelopeSkipPaths: parsePathList("RESPONSE_ENVELOPE_SKIP_PATHS", DefaultResponseEnvelopeSkipPaths),
+
+		// HTTPS
+		TLSCertFile:        getEnvWithLog("TLS_CERT_FILE", ""),
+		TLSKeyFile:         getEnvWithLog("TLS_KEY_FILE", ""),
+		TLSAutocertDomains: parsePathList("TLS_AUTOCERT_DOMAINS", ""),
+		TLSAutocertEmail:   getEnvWithLog("TLS_AUTOCERT_EMAIL", ""),
+		TLSAutocertCache:   getEnvWithLog("TLS_AUTOCERT_CACHE", DefaultTLSAutocertCache),
+		TLSRedirectAddress: normalizePort(getEnvWithLog("TLS_REDIRECT_ADDRESS", "")),
+
+		// CORS
+		CORSExposeHeaders: parsePathList("CORS_EXPOSE_HEADERS", DefaultCORSExposeHeaders),
+		CORSPublicPaths:   parsePathList("CORS_PUBLIC_PATHS", DefaultCORSPublicPaths),
+		CORSPublicOrigins: parsePathList("CORS_PUBLIC_ORIGINS", DefaultCORSPublicOrigins),
+
+		// Client IP
+		TrustedProxies:  parsePathList("TRUSTED_PROXIES", DefaultTrustedProxies),
+		ClientIPHeaders: parsePathList("CLIENT_IP_HEADERS", DefaultClientIPHeaders),
+
+		// Static files
+		StoragePublicPaths: parsePathList("STORAGE_PUBLIC_PATHS", ""),
+
 		// Security settings
 		ApiKey:    getEnvWithLog("API_KEY", DefaultAPIKey),
 		JWTSecret: getEnvWithLog("JWT_SECRET", DefaultJWTSecret),
@@ -320,6 +717,7 @@ func NewConfig() *Config {
 		// Email settings
 		EmailProvider:        getEnvWithLog("EMAIL_PROVIDER", DefaultEmailProvider),
 		EmailFromAddress:     getEnvWithLog("EMAIL_FROM_ADDRESS", DefaultEmailFromAddress),
+		EmailDevPath:         getEnvWithLog("EMAIL_DEV_PATH", DefaultEmailDevPath),
 		SMTPHost:             getEnvWithLog("SMTP_HOST", ""),
 		SMTPUsername:         getEnvWithLog("SMTP_USERNAME", ""),
 		SMTPPassword:         getEnvWithLog("SMTP_PASSWORD", ""),
@@ -345,8 +743,11 @@ func NewConfig() *Config {
 	parseStorageExtensions(config)
 	parseIntegerValues(config)
 	parseBooleanValues(config)
+	parseDurationValues(config)
 	parseMiddlewareConfig(config)
 
+	config.Runtime = NewRuntime(config.RuntimeValues())
+
 	return config
 }
 
@@ -383,6 +784,86 @@ func parseIntegerValues(config *Config) {
 
 	// Storage Max Size
 	config.StorageMaxSize = parseInt64WithDefault("STORAGE_MAX_SIZE", DefaultStorageMaxSize)
+
+	// Upload limits
+	config.UploadMaxSize = parseInt64WithDefault("UPLOAD_MAX_SIZE", DefaultUploadMaxSize)
+	config.MultipartMemory = parseInt64WithDefault("MULTIPART_MEMORY", DefaultMultipartMemory)
+
+	// Database connection pool
+	config.DBMaxOpenConns = parseIntWithDefault("DB_MAX_OPEN_CONNS", DefaultDBMaxOpenConns)
+	config.DBMaxIdleConns = parseIntWithDefault("DB_MAX_IDLE_CONNS", DefaultDBMaxIdleConns)
+
+	// Remote log sink buffering
+	config.LogSinkBufferSize = parseIntWithDefault("LOG_SINK_BUFFER_SIZE", DefaultLogSinkBufferSize)
+	config.LogSinkBatchSize = parseIntWithDefault("LOG_SINK_BATCH_SIZE", DefaultLogSinkBatchSize)
+
+	// Request log sampling (percent) and stored body size (bytes, 0 disables body capture)
+	config.RequestLogSampleRate = parseIntWithDefault("REQUEST_LOG_SAMPLE_RATE", DefaultRequestLogSampleRate)
+	config.RequestLogBodyLimit = parseIntWithDefault("REQUEST_LOG_BODY_LIMIT", DefaultRequestLogBodyLimit)
+
+	// Auth throttling: failures allowed before delays and before a captcha is required
+	config.AuthThrottleAttempts = parseIntWithDefault("AUTH_THROTTLE_ATTEMPTS", DefaultAuthThrottleAttempts)
+	config.AuthCaptchaAfter = parseIntWithDefault("AUTH_CAPTCHA_AFTER", DefaultAuthCaptchaAfter)
+
+	// Report generation workers and row limit
+	config.ReportsWorkers = parseIntWithDefault("REPORTS_WORKERS", DefaultReportsWorkers)
+	config.ReportsMaxRows = parseIntWithDefault("REPORTS_MAX_ROWS", DefaultReportsMaxRows)
+
+	// Quotas reported to clients
+	config.StorageQuota = parseInt64WithDefault("STORAGE_QUOTA", 0)
+	config.UserSeats = parseIntWithDefault("USER_SEATS", 0)
+}
+
+// parseDurationValues parses all duration configuration values
+func parseDurationValues(config *Config) {
+	// Database connection pool
+	config.DBConnMaxLifetime = parseDurationWithDefault("DB_CONN_MAX_LIFETIME", DefaultDBConnMaxLifetime)
+	config.DBConnMaxIdleTime = parseDurationWithDefault("DB_CONN_MAX_IDLE_TIME", DefaultDBConnMaxIdleTime)
+
+	// Default per-statement timeout (0 disables it)
+	config.DBStatementTimeout = parseDurationWithDefault("DB_STATEMENT_TIMEOUT", DefaultDBStatementTimeout)
+
+	// Queries slower than this are logged (0 disables slow query logging)
+	config.DBSlowQueryThreshold = parseDurationWithDefault("DB_SLOW_QUERY_THRESHOLD", DefaultDBSlowQueryThreshold)
+
+	// How long counts of estimated lists are cached
+	config.DBCountCacheTTL = parseDurationWithDefault("DB_COUNT_CACHE_TTL", DefaultDBCountCacheTTL)
+
+	// Maximum time a log entry waits in a remote sink buffer
+	config.LogSinkFlushInterval = parseDurationWithDefault("LOG_SINK_FLUSH_INTERVAL", DefaultLogSinkFlushInterval)
+
+	// How long stored request logs are kept (0 keeps them forever)
+	config.RequestLogRetention = parseDurationWithDefault("REQUEST_LOG_RETENTION", DefaultRequestLogRetention)
+
+	// Age at which activities are archived (0 disables the archive job)
+	config.ActivityArchiveAfter = parseDurationWithDefault("ACTIVITY_ARCHIVE_AFTER", DefaultActivityArchiveAfter)
+
+	// Longest delay between throttled auth attempts
+	config.AuthThrottleMaxDelay = parseDurationWithDefault("AUTH_THROTTLE_MAX_DELAY", DefaultAuthThrottleMaxDelay)
+
+	// How long public responses are cached (0 disables the cache)
+	config.ResponseCacheTTL = parseDurationWithDefault("RESPONSE_CACHE_TTL", DefaultResponseCacheTTL)
+
+	// How long preview tokens are valid
+	config.PreviewTokenTTL = parseDurationWithDefault("PREVIEW_TOKEN_TTL", DefaultPreviewTokenTTL)
+
+	// How long download tokens are valid
+	config.DownloadTokenTTL = parseDurationWithDefault("DOWNLOAD_TOKEN_TTL", DefaultDownloadTokenTTL)
+
+	// How long edit locks are kept without a heartbeat
+	config.LockTTL = parseDurationWithDefault("LOCK_TTL", DefaultLockTTL)
+
+	// How long browsers cache CORS preflight responses
+	config.CORSMaxAge = parseDurationWithDefault("CORS_MAX_AGE", DefaultCORSMaxAge)
+
+	// How long browsers cache static files
+	config.StaticCacheMaxAge = parseDurationWithDefault("STATIC_CACHE_MAX_AGE", DefaultStaticCacheMaxAge)
+
+	// Retry-After sent while maintenance mode is on
+	config.MaintenanceRetryAfter = parseDurationWithDefault("MAINTENANCE_RETRY_AFTER", DefaultMaintenanceRetryAfter)
+
+	// How often exchange rates are fetched from the provider
+	config.ExchangeRatesRefresh = parseDurationWithDefault("EXCHANGE_RATES_REFRESH", DefaultExchangeRatesRefresh)
 }
 
 // parseBooleanValues parses all boolean configuration values
@@ -392,6 +873,36 @@ func parseBooleanValues(config *Config) {
 
 	// Swagger enabled
 	config.SwaggerEnabled = parseBoolWithDefault("SWAGGER_ENABLED", DefaultSwaggerEnabled)
+
+	// HTTP/2, over TLS or unencrypted (h2c)
+	config.ServerHTTP2 = parseBoolWithDefault("SERVER_HTTP2", DefaultServerHTTP2)
+
+	// Credentials (cookies, Authorization) for CORS_ALLOWED_ORIGINS
+	config.CORSAllowCredentials = parseBoolWithDefault("CORS_ALLOW_CREDENTIALS", DefaultCORSAllowCredentials)
+
+	// GraphQL endpoint (/api/graphql)
+	config.GraphQLEnabled = parseBoolWithDefault("GRAPHQL_ENABLED", DefaultGraphQLEnabled)
+
+	// gRPC server for internal services
+	config.GRPCEnabled = parseBoolWithDefault("GRPC_ENABLED", DefaultGRPCEnabled)
+
+	// Module AutoMigrate on startup (versioned migrations are applied with the migrate command)
+	config.AutoMigrate = parseBoolWithDefault("AUTO_MIGRATE", DefaultAutoMigrate)
+
+	// Per-request query count response header (X-DB-Query-Count)
+	config.DBQueryCountHeader = parseBoolWithDefault("DB_QUERY_COUNT_HEADER", DefaultDBQueryCountHeader)
+
+	// Prometheus metrics endpoint
+	config.MetricsEnabled = parseBoolWithDefault("METRICS_ENABLED", DefaultMetricsEnabled)
+
+	// Request log stored in the database
+	config.RequestLogEnabled = parseBoolWithDefault("REQUEST_LOG_ENABLED", DefaultRequestLogEnabled)
+
+	// Export archived activities to storage
+	config.ActivityArchiveExport = parseBoolWithDefault("ACTIVITY_ARCHIVE_EXPORT", DefaultActivityArchiveExport)
+
+	// Maintenance mode (the maintenance_mode setting can also enable it at runtime)
+	config.MaintenanceMode = parseBoolWithDefault("MAINTENANCE_MODE", DefaultMaintenanceMode)
 }
 
 // parseMiddlewareConfig parses middleware configuration from environment variables
@@ -417,13 +928,13 @@ func parseMiddlewareConfig(config *Config) {
 	config.Middleware = MiddlewareConfig{
 		// Global middleware settings
 		APIKeyEnabled:      parseBoolWithDefault("MIDDLEWARE_API_KEY_ENABLED", true),
-		APIKeySkipPaths:    parsePathList("MIDDLEWARE_API_KEY_SKIP_PATHS", "/health,/,/docs,/swagger"),
+		APIKeySkipPaths:    parsePathList("MIDDLEWARE_API_KEY_SKIP_PATHS", "/health/*,/,/docs,/swagger,/api/openapi.json,/api/downloads/*"),
 		AuthEnabled:        parseBoolWithDefault("MIDDLEWARE_AUTH_ENABLED", false),
-		AuthSkipPaths:      parsePathList("MIDDLEWARE_AUTH_SKIP_PATHS", "/api/auth/login,/api/auth/register,/api/auth/forgot-password,/docs,/docs/,/swagger,/swagger/,/api/search"),
+		AuthSkipPaths:      parsePathList("MIDDLEWARE_AUTH_SKIP_PATHS", "/api/auth/login,/api/auth/register,/api/auth/forgot-password,/docs,/docs/,/swagger,/swagger/,/api/openapi.json,/api/search,/api/catalog/*,/api/cart/*,/api/public/*,/api/downloads/*"),
 		RateLimitEnabled:   parseBoolWithDefault("MIDDLEWARE_RATE_LIMIT_ENABLED", true),
 		RateLimitRequests:  parseIntWithDefault("MIDDLEWARE_RATE_LIMIT_REQUESTS", 60),
 		RateLimitWindow:    getEnvWithLog("MIDDLEWARE_RATE_LIMIT_WINDOW", "1m"),
-		RateLimitSkipPaths: parsePathList("MIDDLEWARE_RATE_LIMIT_SKIP_PATHS", "/health,/"),
+		RateLimitSkipPaths: parsePathList("MIDDLEWARE_RATE_LIMIT_SKIP_PATHS", "/health/*,/"),
 		LoggingEnabled:     parseBoolWithDefault("MIDDLEWARE_LOGGING_ENABLED", true),
 		LoggingSkipPaths:   parsePathList("MIDDLEWARE_LOGGING_SKIP_PATHS", ""),
 		RecoveryEnabled:    parseBoolWithDefault("MIDDLEWARE_RECOVERY_ENABLED", true),
@@ -460,6 +971,20 @@ func parsePathList(key, defaultValue string) []string {
 	return result
 }
 
+// parseKeyValueList parses a comma-separated list of key=value pairs
+func parseKeyValueList(key string) map[string]string {
+	result := make(map[string]string)
+	for _, pair := range parsePathList(key, "") {
+		name, value, found := strings.Cut(pair, "=")
+		if !found || strings.TrimSpace(name) == "" {
+			logConfigError("Invalid %s entry: %s. Expected key=value", key, pair)
+			continue
+		}
+		result[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return result
+}
+
 // Helper functions for type parsing with error handling
 
 // parseIntWithDefault parses an integer environment variable with default fallback
@@ -495,6 +1020,17 @@ func parseBoolWithDefault(key string, defaultValue bool) bool {
 	return value
 }
 
+// parseDurationWithDefault parses a duration environment variable (e.g. "30s", "5m") with default fallback
+func parseDurationWithDefault(key string, defaultValue string) time.Duration {
+	valueStr := getEnvWithLog(key, defaultValue)
+	value, err := time.ParseDuration(valueStr)
+	if err != nil {
+		logConfigError("Invalid %s value: %s. Using default: %s", key, valueStr, defaultValue)
+		value, _ = time.ParseDuration(defaultValue)
+	}
+	return value
+}
+
 // normalizePort ensures port starts with ":"
 func normalizePort(port string) string {
 	if port != "" && port[0] != ':' {
@@ -565,9 +1101,10 @@ func getEnvWithLog(key, fallback string) string {
 
 // Validation methods for production use
 
-// Validate validates the configuration and returns any errors
+// Validate validates the configuration and returns every problem found
 func (c *Config) Validate() []error {
-	var errors []error
+	// Formats, enums and conditionally required variables
+	errors := c.validateEnv()
 
 	// Validate database configuration
 	if c.DBDriver != "sqlite" && c.DBURL == "" {
@@ -600,6 +1137,9 @@ func (c *Config) Validate() []error {
 	if c.EmailProvider == "smtp" && c.SMTPHost == "" {
 		errors = append(errors, fmt.Errorf("SMTP_HOST is required for SMTP email provider"))
 	}
+	if (c.EmailProvider == "dev" || c.EmailProvider == "log") && c.IsProduction() {
+		errors = append(errors, fmt.Errorf("EMAIL_PROVIDER %s captures emails instead of sending them and must not be used in production", c.EmailProvider))
+	}
 
 	// Security validations for production
 	if c.Env == "production" {
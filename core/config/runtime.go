@@ -0,0 +1,226 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+)
+
+// RuntimeChangedEvent is emitted with a RuntimeChange whenever runtime-tunable values change
+const RuntimeChangedEvent = "config.runtime.changed"
+
+// Setting keys that override runtime-tunable values when they exist in the settings table
+const (
+	SettingLogLevel           = "log_level"
+	SettingCORSAllowedOrigins = "cors_allowed_origins"
+	SettingCORSPublicOrigins  = "cors_public_origins"
+	SettingRateLimitRequests  = "rate_limit_requests"
+	SettingRateLimitWindow    = "rate_limit_window"
+	SettingMaintenanceMode    = "maintenance_mode"
+	SettingDefaultLocale      = "default_locale"
+	SettingTimezone           = "timezone"
+	SettingDateFormat         = "date_format"
+	SettingTimeFormat         = "time_format"
+	SettingHTMLPolicy         = "html_policy"
+	SettingUploadMaxSize      = "upload_max_size"
+	SettingMultipartMemory    = "multipart_memory"
+)
+
+// RuntimeSettingKeys lists the settings that are applied without a restart
+var RuntimeSettingKeys = []string{
+	SettingLogLevel,
+	SettingCORSAllowedOrigins,
+	SettingCORSPublicOrigins,
+	SettingRateLimitRequests,
+	SettingRateLimitWindow,
+	SettingMaintenanceMode,
+	SettingDefaultLocale,
+	SettingTimezone,
+	SettingDateFormat,
+	SettingTimeFormat,
+	SettingHTMLPolicy,
+	SettingUploadMaxSize,
+	SettingMultipartMemory,
+}
+
+// LogLevels are the accepted values for LOG_LEVEL and the log_level setting
+var LogLevels = []string{"debug", "info", "warn", "error"}
+
+// HTMLPolicies are the accepted values for HTML_POLICY and the html_policy setting
+var HTMLPolicies = []string{"strict", "relaxed"}
+
+// RuntimeValues is the subset of the configuration that can change while the server is running
+type RuntimeValues struct {
+	LogLevel           string   `json:"log_level"`
+	CORSAllowedOrigins []string `json:"cors_allowed_origins"`
+	CORSPublicOrigins  []string `json:"cors_public_origins"`
+	RateLimitRequests  int      `json:"rate_limit_requests"`
+	RateLimitWindow    string   `json:"rate_limit_window"`
+	MaintenanceMode    bool     `json:"maintenance_mode"`
+	DefaultLocale      string   `json:"default_locale"`
+	Timezone           string   `json:"timezone"`
+	DateFormat         string   `json:"date_format"`
+	TimeFormat         string   `json:"time_format"`
+	HTMLPolicy         string   `json:"html_policy"`
+	UploadMaxSize      int64    `json:"upload_max_size"`  // Bytes
+	MultipartMemory    int64    `json:"multipart_memory"` // Bytes
+}
+
+// RuntimeChange is the payload of RuntimeChangedEvent
+type RuntimeChange struct {
+	Source  string        `json:"source"` // startup, signal or settings
+	Changed []string      `json:"changed"`
+	Values  RuntimeValues `json:"values"`
+}
+
+// GetRateLimitDuration returns the rate limit window as time.Duration
+func (v RuntimeValues) GetRateLimitDuration() time.Duration {
+	duration, err := time.ParseDuration(v.RateLimitWindow)
+	if err != nil {
+		return time.Minute // default to 1 minute
+	}
+	return duration
+}
+
+// Validate checks values that may come from settings rather than the environment
+func (v RuntimeValues) Validate() error {
+	if !slices.Contains(LogLevels, v.LogLevel) {
+		return fmt.Errorf("%s: %q is not one of: debug, info, warn, error", SettingLogLevel, v.LogLevel)
+	}
+	if v.RateLimitRequests < 0 {
+		return fmt.Errorf("%s must not be negative", SettingRateLimitRequests)
+	}
+	if d, err := time.ParseDuration(v.RateLimitWindow); err != nil || d <= 0 {
+		return fmt.Errorf("%s: %q is not a positive duration", SettingRateLimitWindow, v.RateLimitWindow)
+	}
+	if !localePattern.MatchString(v.DefaultLocale) {
+		return fmt.Errorf("%s: %q is not a locale such as en or pt-BR", SettingDefaultLocale, v.DefaultLocale)
+	}
+	if _, err := time.LoadLocation(v.Timezone); err != nil || v.Timezone == "Local" {
+		return fmt.Errorf("%s: %q is not a timezone such as UTC or Europe/Berlin", SettingTimezone, v.Timezone)
+	}
+	if !validDateFormat(v.DateFormat) {
+		return fmt.Errorf("%s: %q is not a date format such as YYYY-MM-DD or DD.MM.YYYY", SettingDateFormat, v.DateFormat)
+	}
+	if v.TimeFormat != "12h" && v.TimeFormat != "24h" {
+		return fmt.Errorf("%s: %q is not one of: 12h, 24h", SettingTimeFormat, v.TimeFormat)
+	}
+	if !slices.Contains(HTMLPolicies, v.HTMLPolicy) {
+		return fmt.Errorf("%s: %q is not one of: strict, relaxed", SettingHTMLPolicy, v.HTMLPolicy)
+	}
+	if v.UploadMaxSize <= 0 {
+		return fmt.Errorf("%s must be a positive number of bytes", SettingUploadMaxSize)
+	}
+	if v.MultipartMemory <= 0 {
+		return fmt.Errorf("%s must be a positive number of bytes", SettingMultipartMemory)
+	}
+	return nil
+}
+
+// dateFormatPattern matches YYYY, MM and DD in any order with the same separator
+var dateFormatPattern = regexp.MustCompile(`^(YYYY|MM|DD)([-/. ])(YYYY|MM|DD)([-/. ])(YYYY|MM|DD)$`)
+
+// validDateFormat reports whether a date_format uses each of YYYY, MM and DD once
+func validDateFormat(format string) bool {
+	match := dateFormatPattern.FindStringSubmatch(format)
+	return match != nil && match[2] == match[4] &&
+		match[1] != match[3] && match[1] != match[5] && match[3] != match[5]
+}
+
+// localePattern matches locales such as "en", "pt-BR" and "pt_BR"
+var localePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}([-_][a-zA-Z0-9]{2,4})?$`)
+
+// Runtime holds the current runtime-tunable values. It is safe for concurrent use.
+type Runtime struct {
+	mu     sync.RWMutex
+	values RuntimeValues
+}
+
+// NewRuntime creates a Runtime with the given initial values
+func NewRuntime(values RuntimeValues) *Runtime {
+	return &Runtime{values: values}
+}
+
+// Get returns a copy of the current values
+func (r *Runtime) Get() RuntimeValues {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	values := r.values
+	values.CORSAllowedOrigins = slices.Clone(r.values.CORSAllowedOrigins)
+	values.CORSPublicOrigins = slices.Clone(r.values.CORSPublicOrigins)
+	return values
+}
+
+// Set replaces the current values and returns the names of the fields that changed
+func (r *Runtime) Set(values RuntimeValues) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var changed []string
+	if r.values.LogLevel != values.LogLevel {
+		changed = append(changed, SettingLogLevel)
+	}
+	if !slices.Equal(r.values.CORSAllowedOrigins, values.CORSAllowedOrigins) {
+		changed = append(changed, SettingCORSAllowedOrigins)
+	}
+	if !slices.Equal(r.values.CORSPublicOrigins, values.CORSPublicOrigins) {
+		changed = append(changed, SettingCORSPublicOrigins)
+	}
+	if r.values.RateLimitRequests != values.RateLimitRequests {
+		changed = append(changed, SettingRateLimitRequests)
+	}
+	if r.values.RateLimitWindow != values.RateLimitWindow {
+		changed = append(changed, SettingRateLimitWindow)
+	}
+	if r.values.MaintenanceMode != values.MaintenanceMode {
+		changed = append(changed, SettingMaintenanceMode)
+	}
+	if r.values.DefaultLocale != values.DefaultLocale {
+		changed = append(changed, SettingDefaultLocale)
+	}
+	if r.values.Timezone != values.Timezone {
+		changed = append(changed, SettingTimezone)
+	}
+	if r.values.DateFormat != values.DateFormat {
+		changed = append(changed, SettingDateFormat)
+	}
+	if r.values.TimeFormat != values.TimeFormat {
+		changed = append(changed, SettingTimeFormat)
+	}
+	if r.values.HTMLPolicy != values.HTMLPolicy {
+		changed = append(changed, SettingHTMLPolicy)
+	}
+	if r.values.UploadMaxSize != values.UploadMaxSize {
+		changed = append(changed, SettingUploadMaxSize)
+	}
+	if r.values.MultipartMemory != values.MultipartMemory {
+		changed = append(changed, SettingMultipartMemory)
+	}
+
+	values.CORSAllowedOrigins = slices.Clone(values.CORSAllowedOrigins)
+	values.CORSPublicOrigins = slices.Clone(values.CORSPublicOrigins)
+	r.values = values
+	return changed
+}
+
+// RuntimeValues returns the runtime-tunable values as loaded from the environment
+func (c *Config) RuntimeValues() RuntimeValues {
+	return RuntimeValues{
+		LogLevel:           c.LogLevel,
+		CORSAllowedOrigins: slices.Clone(c.CORSAllowedOrigins),
+		CORSPublicOrigins:  slices.Clone(c.CORSPublicOrigins),
+		RateLimitRequests:  c.Middleware.RateLimitRequests,
+		RateLimitWindow:    c.Middleware.RateLimitWindow,
+		MaintenanceMode:    c.MaintenanceMode,
+		DefaultLocale:      c.DefaultLocale,
+		Timezone:           c.Timezone,
+		DateFormat:         c.DateFormat,
+		TimeFormat:         c.TimeFormat,
+		HTMLPolicy:         c.HTMLPolicy,
+		UploadMaxSize:      c.UploadMaxSize,
+		MultipartMemory:    c.MultipartMemory,
+	}
+}
@@ -0,0 +1,295 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// valueKind is the expected type of an environment variable
+type valueKind int
+
+const (
+	kindString valueKind = iota
+	kindInt
+	kindBool
+	kindDuration
+	kindPort
+	kindURL
+	kindEnum
+	kindOverrides
+)
+
+// envRule describes an environment variable in the configuration schema
+type envRule struct {
+	Key    string
+	Kind   valueKind
+	Values []string // allowed values for kindEnum
+
+	// RequiredIf reports whether the variable must be set for the given configuration
+	RequiredIf func(c *Config) bool
+}
+
+// Allowed values for the provider settings
+var (
+	Environments     = []string{"debug", "development", "test", "staging", "production", "release"}
+	DBDrivers        = []string{"sqlite", "mysql", "postgres"}
+	EmailProviders   = []string{"default", "smtp", "sendgrid", "postmark", "dev", "log"}
+	StorageProviders = []string{"local", "s3", "r2"}
+	PaymentProviders = []string{"stripe"}
+	CaptionProviders = []string{"http", "openai"}
+	EnvelopeModes    = []string{"off", "compat", "on"}
+	CountModes       = []string{"exact", "estimated", "none"}
+)
+
+// envSchema lists every environment variable read by NewConfig that has a format to check.
+// Plain strings are only listed when they are conditionally required.
+var envSchema = []envRule{
+	// Server
+	{Key: "ENV", Kind: kindEnum, Values: Environments},
+	{Key: "SERVER_PORT", Kind: kindPort},
+	{Key: "APPHOST", Kind: kindURL},
+	{Key: "CDN", Kind: kindURL},
+	{Key: "LOG_LEVEL", Kind: kindEnum, Values: LogLevels},
+	{Key: "MAINTENANCE_MODE", Kind: kindBool},
+	{Key: "MAINTENANCE_RETRY_AFTER", Kind: kindDuration},
+	{Key: "TIME_FORMAT", Kind: kindEnum, Values: []string{"12h", "24h"}},
+	{Key: "HTML_POLICY", Kind: kindEnum, Values: HTMLPolicies},
+	{Key: "RESPONSE_ENVELOPE", Kind: kindEnum, Values: EnvelopeModes},
+	{Key: "RESPONSE_CACHE_TTL", Kind: kindDuration},
+	{Key: "PREVIEW_TOKEN_TTL", Kind: kindDuration},
+	{Key: "LOCK_TTL", Kind: kindDuration},
+	{Key: "STATIC_CACHE_MAX_AGE", Kind: kindDuration},
+	{Key: "CORS_ALLOW_CREDENTIALS", Kind: kindBool},
+	{Key: "CORS_MAX_AGE", Kind: kindDuration},
+	{Key: "TLS_REDIRECT_ADDRESS", Kind: kindPort},
+	{Key: "SERVER_HTTP2", Kind: kindBool},
+
+	// Remote log sinks
+	{Key: "LOG_LOKI_URL", Kind: kindURL},
+	{Key: "LOG_SYSLOG_NETWORK", Kind: kindEnum, Values: []string{"udp", "tcp"}},
+	{Key: "LOG_OTLP_ENDPOINT", Kind: kindURL},
+	{Key: "LOG_SINK_BUFFER_SIZE", Kind: kindInt},
+	{Key: "LOG_SINK_BATCH_SIZE", Kind: kindInt},
+	{Key: "LOG_SINK_FLUSH_INTERVAL", Kind: kindDuration},
+
+	// Request log
+	{Key: "REQUEST_LOG_ENABLED", Kind: kindBool},
+	{Key: "REQUEST_LOG_SAMPLE_RATE", Kind: kindInt},
+	{Key: "REQUEST_LOG_RETENTION", Kind: kindDuration},
+	{Key: "REQUEST_LOG_BODY_LIMIT", Kind: kindInt},
+
+	// Activity archive
+	{Key: "ACTIVITY_ARCHIVE_AFTER", Kind: kindDuration},
+	{Key: "ACTIVITY_ARCHIVE_EXPORT", Kind: kindBool},
+
+	// Auth throttling
+	{Key: "AUTH_THROTTLE_ATTEMPTS", Kind: kindInt},
+	{Key: "AUTH_THROTTLE_MAX_DELAY", Kind: kindDuration},
+	{Key: "AUTH_CAPTCHA_AFTER", Kind: kindInt},
+
+	// Security event forwarding
+	{Key: "SECURITY_LOG_SYSLOG_NETWORK", Kind: kindEnum, Values: []string{"udp", "tcp"}},
+	{Key: "SECURITY_LOG_HTTP_URL", Kind: kindURL},
+
+	// Reports
+	{Key: "REPORTS_WORKERS", Kind: kindInt},
+	{Key: "REPORTS_MAX_ROWS", Kind: kindInt},
+	{Key: "PDF_PAGE_SIZE", Kind: kindEnum, Values: []string{"A4", "Letter"}},
+
+	// Payments
+	{Key: "PAYMENTS_PROVIDER", Kind: kindEnum, Values: PaymentProviders},
+	{Key: "STRIPE_API_URL", Kind: kindURL},
+	{Key: "STRIPE_WEBHOOK_SECRET", RequiredIf: func(c *Config) bool { return c.PaymentsProvider == "stripe" && c.StripeSecretKey != "" }},
+
+	// Database
+	{Key: "DB_DRIVER", Kind: kindEnum, Values: DBDrivers},
+	{Key: "DB_PORT", Kind: kindPort},
+	{Key: "DB_MAX_OPEN_CONNS", Kind: kindInt},
+	{Key: "DB_MAX_IDLE_CONNS", Kind: kindInt},
+	{Key: "DB_CONN_MAX_LIFETIME", Kind: kindDuration},
+	{Key: "DB_CONN_MAX_IDLE_TIME", Kind: kindDuration},
+	{Key: "DB_STATEMENT_TIMEOUT", Kind: kindDuration},
+	{Key: "DB_SLOW_QUERY_THRESHOLD", Kind: kindDuration},
+	{Key: "DB_QUERY_COUNT_HEADER", Kind: kindBool},
+	{Key: "DB_COUNT_MODE", Kind: kindEnum, Values: CountModes},
+	{Key: "DB_COUNT_CACHE_TTL", Kind: kindDuration},
+	{Key: "AUTO_MIGRATE", Kind: kindBool},
+
+	// Email
+	{Key: "EMAIL_PROVIDER", Kind: kindEnum, Values: EmailProviders},
+	{Key: "SMTP_PORT", Kind: kindPort},
+	{Key: "SENDGRID_API_KEY", RequiredIf: func(c *Config) bool { return c.EmailProvider == "sendgrid" }},
+	{Key: "POSTMARK_SERVER_TOKEN", RequiredIf: func(c *Config) bool { return c.EmailProvider == "postmark" }},
+
+	// Storage
+	{Key: "STORAGE_PROVIDER", Kind: kindEnum, Values: StorageProviders},
+	{Key: "STORAGE_MAX_SIZE", Kind: kindInt},
+	{Key: "UPLOAD_MAX_SIZE", Kind: kindInt},
+	{Key: "MULTIPART_MEMORY", Kind: kindInt},
+	{Key: "STORAGE_BASE_URL", Kind: kindURL},
+	{Key: "STORAGE_PUBLIC_URL", Kind: kindURL},
+	{Key: "STORAGE_ENDPOINT", Kind: kindURL},
+
+	// Media captions
+	{Key: "MEDIA_CAPTION_PROVIDER", Kind: kindEnum, Values: CaptionProviders},
+	{Key: "MEDIA_CAPTION_URL", Kind: kindURL, RequiredIf: func(c *Config) bool { return c.MediaCaptionProvider == "http" }},
+	{Key: "MEDIA_CAPTION_API_KEY", RequiredIf: func(c *Config) bool { return c.MediaCaptionProvider == "openai" }},
+
+	// Features
+	{Key: "WS_ENABLED", Kind: kindBool},
+	{Key: "SWAGGER_ENABLED", Kind: kindBool},
+	{Key: "METRICS_ENABLED", Kind: kindBool},
+	{Key: "GRAPHQL_ENABLED", Kind: kindBool},
+	{Key: "GRPC_ENABLED", Kind: kindBool},
+	{Key: "GRPC_PORT", Kind: kindPort},
+	{Key: "GRPC_API_KEY", RequiredIf: func(c *Config) bool { return c.GRPCEnabled }},
+
+	// Middleware
+	{Key: "MIDDLEWARE_API_KEY_ENABLED", Kind: kindBool},
+	{Key: "MIDDLEWARE_AUTH_ENABLED", Kind: kindBool},
+	{Key: "MIDDLEWARE_RATE_LIMIT_ENABLED", Kind: kindBool},
+	{Key: "MIDDLEWARE_RATE_LIMIT_REQUESTS", Kind: kindInt},
+	{Key: "MIDDLEWARE_RATE_LIMIT_WINDOW", Kind: kindDuration},
+	{Key: "MIDDLEWARE_LOGGING_ENABLED", Kind: kindBool},
+	{Key: "MIDDLEWARE_RECOVERY_ENABLED", Kind: kindBool},
+	{Key: "MIDDLEWARE_CORS_ENABLED", Kind: kindBool},
+	{Key: "MIDDLEWARE_WEBHOOK_API_KEY_ENABLED", Kind: kindBool},
+	{Key: "MIDDLEWARE_WEBHOOK_AUTH_ENABLED", Kind: kindBool},
+	{Key: "MIDDLEWARE_WEBHOOK_SIGNATURE_ENABLED", Kind: kindBool},
+	{Key: "MIDDLEWARE_WEBHOOK_RATE_LIMIT_REQUESTS", Kind: kindInt},
+	{Key: "MIDDLEWARE_WEBHOOK_RATE_LIMIT_WINDOW", Kind: kindDuration},
+	{Key: "MIDDLEWARE_OVERRIDES", Kind: kindOverrides},
+}
+
+// validateEnv checks the raw environment against envSchema. Values are checked as they
+// were set, since NewConfig falls back to defaults for anything it can't parse.
+func (c *Config) validateEnv() []error {
+	var errors []error
+
+	for _, rule := range envSchema {
+		value, exists := os.LookupEnv(rule.Key)
+		value = strings.TrimSpace(value)
+
+		if !exists || value == "" {
+			if rule.RequiredIf != nil && rule.RequiredIf(c) {
+				errors = append(errors, fmt.Errorf("%s is required", rule.Key))
+			}
+			continue
+		}
+
+		if err := rule.check(value); err != nil {
+			errors = append(errors, fmt.Errorf("%s: %w", rule.Key, err))
+		}
+	}
+
+	for module, level := range c.LogModuleLevels {
+		if !slices.Contains(LogLevels, level) {
+			errors = append(errors, fmt.Errorf("LOG_MODULE_LEVELS: %q is not a valid level for %s (use one of: %s)",
+				level, module, strings.Join(LogLevels, ", ")))
+		}
+	}
+
+	if _, err := time.LoadLocation(c.Timezone); err != nil || c.Timezone == "Local" {
+		errors = append(errors, fmt.Errorf("TIMEZONE: %q is not a timezone such as UTC or Europe/Berlin", c.Timezone))
+	}
+	if !validDateFormat(c.DateFormat) {
+		errors = append(errors, fmt.Errorf("DATE_FORMAT: %q is not a date format such as YYYY-MM-DD or DD.MM.YYYY", c.DateFormat))
+	}
+
+	for _, entry := range c.HTMLFieldPolicies {
+		field, policy, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(field) == "" || !slices.Contains(HTMLPolicies, strings.TrimSpace(policy)) {
+			errors = append(errors, fmt.Errorf("HTML_FIELD_POLICIES: %q is not field=policy with a policy of: %s",
+				entry, strings.Join(HTMLPolicies, ", ")))
+		}
+	}
+
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		errors = append(errors, fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together"))
+	}
+	if c.TLSCertFile != "" && len(c.TLSAutocertDomains) > 0 {
+		errors = append(errors, fmt.Errorf("TLS_AUTOCERT_DOMAINS can't be used with TLS_CERT_FILE"))
+	}
+
+	for _, proxy := range c.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			errors = append(errors, fmt.Errorf("TRUSTED_PROXIES: %q is not an IP address or CIDR range", proxy))
+		}
+	}
+
+	if c.RequestLogSampleRate > 100 {
+		errors = append(errors, fmt.Errorf("REQUEST_LOG_SAMPLE_RATE: %d is not a percentage (0-100)", c.RequestLogSampleRate))
+	}
+
+	if c.ReportsWorkers < 1 {
+		errors = append(errors, fmt.Errorf("REPORTS_WORKERS: must be at least 1"))
+	}
+	if c.ReportsMaxRows < 1 {
+		errors = append(errors, fmt.Errorf("REPORTS_MAX_ROWS: must be at least 1"))
+	}
+
+	for _, replica := range c.DBReplicaURLs {
+		if c.DBDriver == "sqlite" {
+			errors = append(errors, fmt.Errorf("DB_REPLICA_URLS is not supported for the sqlite driver"))
+			break
+		}
+		if replica == "" {
+			errors = append(errors, fmt.Errorf("DB_REPLICA_URLS contains an empty entry"))
+		}
+	}
+
+	return errors
+}
+
+// check validates a single value against the rule's kind
+func (r envRule) check(value string) error {
+	switch r.Kind {
+	case kindInt:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		if n < 0 {
+			return fmt.Errorf("%d must not be negative", n)
+		}
+	case kindBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%q is not a boolean (use true or false)", value)
+		}
+	case kindDuration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration (e.g. 30s, 5m, 1h)", value)
+		}
+		if d < 0 {
+			return fmt.Errorf("%s must not be negative", value)
+		}
+	case kindPort:
+		port, err := strconv.Atoi(strings.TrimPrefix(value, ":"))
+		if err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("%q is not a valid port (1-65535)", value)
+		}
+	case kindURL:
+		u, err := url.Parse(value)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("%q is not a valid http(s) URL", value)
+		}
+	case kindEnum:
+		if !slices.Contains(r.Values, value) {
+			return fmt.Errorf("%q is not one of: %s", value, strings.Join(r.Values, ", "))
+		}
+	case kindOverrides:
+		var overrides map[string]map[string]string
+		if err := json.Unmarshal([]byte(value), &overrides); err != nil {
+			return fmt.Errorf(`must be a JSON object such as {"api/public/*": {"auth": "disabled"}}`)
+		}
+	}
+	return nil
+}
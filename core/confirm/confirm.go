@@ -0,0 +1,161 @@
+// Package confirm guards destructive operations, e.g. purging the trash, with two phases. A
+// dry run reports what the operation would do, e.g. "37 media, 12 pages will be deleted",
+// and issues a short-lived token; the operation then only runs with that token, for the
+// user it was issued to and while its impact is the same. Tokens are single use and kept in
+// memory, like the locks: after a restart, clients run the dry run again.
+//
+// Controllers compute the impact of a request and let Require answer the dry runs and the
+// requests without a valid token:
+//
+//	impact, err := c.Service.PurgeImpact(ctx.Request.Context(), req)
+//	...
+//	if answered, err := confirm.Require(ctx, impact); answered {
+//		return err
+//	}
+//	// Run the operation
+package confirm
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"base/core/security"
+)
+
+// DefaultTTL is how long confirmation tokens last
+const DefaultTTL = 5 * time.Minute
+
+var (
+	// ErrTokenRequired is returned for running an operation without a token
+	ErrTokenRequired = errors.New("confirmation required: run the operation with dry_run=true and send its confirm_token")
+
+	// ErrTokenInvalid is returned for tokens that expired, were used, or were issued to
+	// another user or for another operation
+	ErrTokenInvalid = errors.New("confirmation token is invalid or expired")
+
+	// ErrImpactChanged is returned when the operation would now do something else than its
+	// dry run reported, e.g. delete more records
+	ErrImpactChanged = errors.New("the impact of the operation changed since its dry run")
+)
+
+// Impact is what a destructive operation would do
+type Impact struct {
+	Operation string           `json:"operation"` // Operation and its target, e.g. "users.delete:12"
+	Counts    map[string]int64 `json:"counts"`    // Records affected by type, e.g. {"media": 37}
+	Summary   string           `json:"summary"`   // e.g. "37 media, 12 pages will be deleted"
+}
+
+// NewImpact returns the impact of an operation on records by type, summarized with what
+// happens to them, e.g. "will be deleted"
+func NewImpact(operation string, counts map[string]int64, outcome string) *Impact {
+	names := make([]string, 0, len(counts))
+	for name, count := range counts {
+		if count > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%d %s", counts[name], name)
+	}
+	summary := "nothing " + outcome
+	if len(parts) > 0 {
+		summary = strings.Join(parts, ", ") + " " + outcome
+	}
+	return &Impact{Operation: operation, Counts: counts, Summary: summary}
+}
+
+// digest identifies an impact: the operation and its non-zero counts
+func (i *Impact) digest() string {
+	names := make([]string, 0, len(i.Counts))
+	for name, count := range i.Counts {
+		if count > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var digest strings.Builder
+	digest.WriteString(i.Operation)
+	for _, name := range names {
+		fmt.Fprintf(&digest, "|%s=%d", name, i.Counts[name])
+	}
+	return digest.String()
+}
+
+// DryRun is the answer to a dry run: the impact of the operation and the token running it
+type DryRun struct {
+	Impact
+	DryRun       bool      `json:"dry_run"`
+	ConfirmToken string    `json:"confirm_token"` // Send as confirm_token to run the operation
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// token is an issued confirmation token
+type token struct {
+	userId    uint
+	operation string
+	digest    string
+	expiresAt time.Time
+}
+
+// Store holds the issued tokens. It is safe for concurrent use.
+type Store struct {
+	mu     sync.Mutex
+	ttl    time.Duration
+	tokens map[string]*token
+}
+
+// NewStore creates an empty store whose tokens last ttl
+func NewStore(ttl time.Duration) *Store {
+	return &Store{ttl: ttl, tokens: make(map[string]*token)}
+}
+
+// Default is the store of Require
+var Default = NewStore(DefaultTTL)
+
+// Issue issues a token to a user for running an operation with an impact
+func (s *Store) Issue(userId uint, impact *Impact) *DryRun {
+	now := time.Now()
+	value := security.NewToken(16)
+	issued := &token{userId: userId, operation: impact.Operation, digest: impact.digest(), expiresAt: now.Add(s.ttl)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, t := range s.tokens {
+		if !now.Before(t.expiresAt) {
+			delete(s.tokens, key)
+		}
+	}
+	s.tokens[value] = issued
+
+	return &DryRun{Impact: *impact, DryRun: true, ConfirmToken: value, ExpiresAt: issued.expiresAt}
+}
+
+// Redeem uses up a token of a user for running an operation with an impact. It returns
+// ErrTokenRequired without a token, ErrTokenInvalid for a token that can't run the
+// operation and ErrImpactChanged when the impact isn't the one of the dry run.
+func (s *Store) Redeem(value string, userId uint, impact *Impact) error {
+	if value == "" {
+		return ErrTokenRequired
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	issued, ok := s.tokens[value]
+	if !ok || issued.userId != userId || issued.operation != impact.Operation || !time.Now().Before(issued.expiresAt) {
+		return ErrTokenInvalid
+	}
+
+	delete(s.tokens, value)
+	if issued.digest != impact.digest() {
+		return ErrImpactChanged
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package confirm
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"base/core/router"
+	"base/core/translation"
+	"base/core/types"
+	"base/core/validator"
+)
+
+// Require answers the dry runs of an operation (dry_run=true) with its impact and a token,
+// and its requests without a valid token (confirm_token) with 428 Precondition Required.
+// It returns whether it answered the request; when it didn't, the operation runs.
+func Require(ctx *router.Context, impact *Impact) (bool, error) {
+	return Default.Require(ctx, impact)
+}
+
+// Require is Require with the tokens of the store
+func (s *Store) Require(ctx *router.Context, impact *Impact) (bool, error) {
+	dryRun, err := DryRunRequested(ctx)
+	if err != nil {
+		return true, fail(ctx, err)
+	}
+	userId := ctx.GetUint("user_id")
+	if dryRun {
+		return true, ctx.JSON(http.StatusOK, s.Issue(userId, impact))
+	}
+	if err := s.Redeem(ctx.Query("confirm_token"), userId, impact); err != nil {
+		return true, fail(ctx, err)
+	}
+	return false, nil
+}
+
+// DryRunRequested returns whether a request asks for a dry run, with dry_run=true
+func DryRunRequested(ctx *router.Context) (bool, error) {
+	value := ctx.Query("dry_run")
+	if value == "" {
+		return false, nil
+	}
+	dryRun, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, validator.ValidationErrors{{
+			Field: "dry_run", Tag: "invalid", Value: value,
+			Message: "dry_run must be true or false",
+		}}
+	}
+	return dryRun, nil
+}
+
+// fail writes the error response of a dry run parameter or a token
+func fail(ctx *router.Context, err error) error {
+	var validationErrors validator.ValidationErrors
+	switch {
+	case errors.As(err, &validationErrors):
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   translation.Error(ctx, err),
+			Details: translation.LocalizeValidation(ctx, validationErrors),
+		})
+	case errors.Is(err, ErrTokenRequired), errors.Is(err, ErrTokenInvalid), errors.Is(err, ErrImpactChanged):
+		return ctx.JSON(http.StatusPreconditionRequired, types.ErrorResponse{Error: err.Error()})
+	default:
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: err.Error()})
+	}
+}
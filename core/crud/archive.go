@@ -0,0 +1,78 @@
+package crud
+
+import (
+	"fmt"
+	"time"
+
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+// Archived says which records lists show, from their archived query parameter. Archived
+// records are hidden without being deleted: they keep their relationships and can be
+// unarchived at any time.
+type Archived string
+
+const (
+	ArchivedExclude Archived = ""     // Records that aren't archived, the default
+	ArchivedOnly    Archived = "true" // Archived records only
+	ArchivedAll     Archived = "all"  // Both
+)
+
+// ParseArchived parses the archived query parameter of a list: empty or false for the
+// records that aren't archived, true for archived ones and all for both
+func ParseArchived(value string) (Archived, error) {
+	switch value {
+	case "", "false":
+		return ArchivedExclude, nil
+	case string(ArchivedOnly), string(ArchivedAll):
+		return Archived(value), nil
+	}
+	return "", validator.ValidationErrors{{
+		Field:   "archived",
+		Tag:     "oneof",
+		Param:   "false true all",
+		Value:   value,
+		Message: fmt.Sprintf("archived must be false, true or all, not %q", value),
+	}}
+}
+
+// Scope returns the scope narrowing a query to the records of the filter, by their archived_at
+// column, e.g. products.archived_at in queries with joins
+func (a Archived) Scope(column string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		switch a {
+		case ArchivedOnly:
+			return db.Where(column + " IS NOT NULL")
+		case ArchivedAll:
+			return db
+		}
+		return db.Where(column + " IS NULL")
+	}
+}
+
+// Archive sets the archived_at column of a record of model to now, or clears it, and
+// returns gorm.ErrRecordNotFound for unknown ids. Records archived again keep the time they
+// were first archived at.
+func Archive(db *gorm.DB, model any, id uint, archived bool) error {
+	var value *time.Time
+	query := db.Model(model).Where("id = ?", id)
+	if archived {
+		now := time.Now()
+		value = &now
+		query = query.Where("archived_at IS NULL")
+	}
+	if err := query.Update("archived_at", value).Error; err != nil {
+		return err
+	}
+
+	var count int64
+	if err := db.Model(model).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+package crud
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"sync"
+
+	"base/core/hooks"
+	"base/core/logger"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// CopySuffix is appended to the unique columns of copies: about becomes about-copy, then
+// about-copy-2 when that is taken
+const CopySuffix = "-copy"
+
+// maxCopies bounds the search for a free copy value
+const maxCopies = 100
+
+// Copier copies the rows a module keeps about the records of any table, e.g. their
+// translations or attachments, to the copy of a record. It runs inside the transaction of
+// the copy.
+type Copier func(tx *gorm.DB, table string, from, to uint) error
+
+var (
+	copiersMu sync.RWMutex
+	copiers   = map[string]Copier{}
+)
+
+// RegisterCopier adds the copier of a module; a copier registered again under the same
+// name replaces the previous one
+func RegisterCopier(name string, copier Copier) {
+	copiersMu.Lock()
+	defer copiersMu.Unlock()
+	copiers[name] = copier
+}
+
+// CopyOptions change how Copy copies a record
+type CopyOptions[T any] struct {
+	// Unique are the columns the copy gets a free value of with CopySuffix, besides those
+	// with a unique index
+	Unique []string
+
+	// Change adjusts the copy before it is saved, e.g. to make it a draft
+	Change Hook[T]
+}
+
+// Copy saves a deep copy of a record in tx and returns it: its has-one and has-many
+// relationships are copied with it, its many-to-many relationships link to the same
+// records, and the registered copiers copy what other modules keep about it. Unique
+// columns get a free value ending with CopySuffix.
+func Copy[T any](tx *gorm.DB, id uint, options CopyOptions[T]) (*T, error) {
+	ctx := tx.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	s := stmt.Schema
+
+	item := new(T)
+	query := tx
+	for _, rel := range owned(s) {
+		query = query.Preload(rel.Name)
+	}
+	for _, rel := range s.Relationships.Many2Many {
+		query = query.Preload(rel.Name)
+	}
+	if err := query.First(item, id).Error; err != nil {
+		return nil, err
+	}
+
+	if err := reset(ctx, tx, s, reflect.ValueOf(item).Elem(), options.Unique); err != nil {
+		return nil, err
+	}
+	if options.Change != nil {
+		if err := options.Change(tx, item); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Create(item).Error; err != nil {
+		return nil, err
+	}
+
+	if s.PrioritizedPrimaryField == nil {
+		return item, nil
+	}
+	copyId, _ := s.PrioritizedPrimaryField.ValueOf(ctx, reflect.ValueOf(item).Elem())
+	to, ok := copyId.(uint)
+	if !ok {
+		return item, nil
+	}
+
+	copiersMu.RLock()
+	names := slices.Sorted(maps.Keys(copiers))
+	fns := make([]Copier, len(names))
+	for i, name := range names {
+		fns[i] = copiers[name]
+	}
+	copiersMu.RUnlock()
+
+	for i, fn := range fns {
+		if err := fn(tx, s.Table, id, to); err != nil {
+			return nil, fmt.Errorf("failed to copy %s: %w", names[i], err)
+		}
+	}
+	return item, nil
+}
+
+// UniqueCopy returns the first value of CopySuffix copies of value that isn't taken
+func UniqueCopy(value string, taken func(string) (bool, error)) (string, error) {
+	for n := 1; n <= maxCopies; n++ {
+		candidate := value + CopySuffix
+		if n > 1 {
+			candidate = fmt.Sprintf("%s%s-%d", value, CopySuffix, n)
+		}
+		exists, err := taken(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no free copy of %q", value)
+}
+
+// reset turns a loaded record and its owned relationships into new records: primary keys,
+// timestamps and foreign keys are cleared and unique columns get free copy values
+func reset(ctx context.Context, tx *gorm.DB, s *schema.Schema, value reflect.Value, unique []string) error {
+	for _, field := range s.Fields {
+		if field.PrimaryKey || field.AutoCreateTime > 0 || field.AutoUpdateTime > 0 ||
+			field.FieldType == reflect.TypeOf(gorm.DeletedAt{}) {
+			field.ReflectValueOf(ctx, value).SetZero()
+		}
+	}
+
+	for _, column := range uniqueColumns(s, unique) {
+		field := s.LookUpField(column)
+		if field == nil || field.FieldType.Kind() != reflect.String {
+			continue
+		}
+		current := field.ReflectValueOf(ctx, value)
+		if current.String() == "" {
+			continue
+		}
+		copied, err := UniqueCopy(current.String(), func(candidate string) (bool, error) {
+			var count int64
+			err := tx.Session(&gorm.Session{NewDB: true}).Unscoped().Table(s.Table).
+				Where(field.DBName+" = ?", candidate).Count(&count).Error
+			return count > 0, err
+		})
+		if err != nil {
+			return err
+		}
+		current.SetString(copied)
+	}
+
+	for _, rel := range owned(s) {
+		children := rel.Field.ReflectValueOf(ctx, value)
+		for _, child := range elements(children) {
+			for _, ref := range rel.References {
+				if ref.OwnPrimaryKey {
+					ref.ForeignKey.ReflectValueOf(ctx, child).SetZero()
+				}
+			}
+			if err := reset(ctx, tx, rel.FieldSchema, child, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// owned returns the relationships copied with a record
+func owned(s *schema.Schema) []*schema.Relationship {
+	return append(slices.Clone(s.Relationships.HasOne), s.Relationships.HasMany...)
+}
+
+// uniqueColumns returns the columns of the table with a unique index of their own, and the
+// extra ones
+func uniqueColumns(s *schema.Schema, extra []string) []string {
+	columns := slices.Clone(extra)
+	for _, field := range s.Fields {
+		if field.Unique && !slices.Contains(columns, field.DBName) {
+			columns = append(columns, field.DBName)
+		}
+	}
+	for _, index := range s.ParseIndexes() {
+		if index.Class == "UNIQUE" && len(index.Fields) == 1 && !slices.Contains(columns, index.Fields[0].DBName) {
+			columns = append(columns, index.Fields[0].DBName)
+		}
+	}
+	return columns
+}
+
+// elements returns the structs of a relationship field: the struct or the pointer it
+// holds, or the items of its slice
+func elements(value reflect.Value) []reflect.Value {
+	var items []reflect.Value
+	add := func(v reflect.Value) {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return
+			}
+			v = v.Elem()
+		}
+		items = append(items, v)
+	}
+	if value.Kind() == reflect.Slice {
+		for i := range value.Len() {
+			add(value.Index(i))
+		}
+	} else {
+		add(value)
+	}
+	return items
+}
+
+// Duplicate saves a copy of an item (see Copy) and returns it with its relationships. The
+// create hooks run for the copy and the create event is emitted.
+func (s *CrudService[T]) Duplicate(ctx context.Context, id uint, options CopyOptions[T]) (*T, error) {
+	change := options.Change
+	options.Change = func(tx *gorm.DB, item *T) error {
+		if change != nil {
+			if err := change(tx, item); err != nil {
+				return err
+			}
+		}
+		return s.run(tx, item, hooks.BeforeCreate, s.Hooks.BeforeCreate)
+	}
+
+	var item *T
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if item, err = Copy(tx, id, options); err != nil {
+			return err
+		}
+		return s.run(tx, item, hooks.AfterCreate, s.Hooks.AfterCreate)
+	})
+	if err != nil {
+		s.Logger.Error("failed to duplicate "+s.Name,
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return nil, err
+	}
+
+	result, err := s.reload(ctx, item)
+	if err != nil {
+		return nil, err
+	}
+
+	s.emit(ctx, "create", result)
+
+	return result, nil
+}
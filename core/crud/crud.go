@@ -0,0 +1,395 @@
+// Package crud has the list, get, create, update and delete logic shared by the services of
+// simple models, so a fix to it applies to all of them. A service embeds a CrudService and
+// keeps its own Create and Update, which turn requests into models:
+//
+//	type NotificationService struct {
+//		*crud.CrudService[Notification]
+//	}
+//
+//	func (s *NotificationService) Create(ctx context.Context, req *CreateNotificationRequest) (*Notification, error) {
+//		return s.CrudService.Create(ctx, &Notification{Title: req.Title})
+//	}
+package crud
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"slices"
+
+	"base/core/database"
+	"base/core/emitter"
+	"base/core/hooks"
+	"base/core/logger"
+	"base/core/types"
+
+	"gorm.io/gorm"
+)
+
+// Hook runs inside the transaction of a write; an error, e.g. validator.ValidationErrors,
+// rolls it back and is returned by the write. Other modules register theirs in the hooks
+// registry instead.
+type Hook[T any] func(tx *gorm.DB, item *T) error
+
+// Hooks run around the writes of a CrudService, in order
+type Hooks[T any] struct {
+	BeforeCreate []Hook[T]
+	AfterCreate  []Hook[T]
+	BeforeUpdate []Hook[T]
+	AfterUpdate  []Hook[T]
+	BeforeDelete []Hook[T]
+	AfterDelete  []Hook[T]
+}
+
+// ListParams are the paging, sorting and counting of a list
+type ListParams struct {
+	Page      *int
+	Limit     *int
+	SortBy    *string
+	SortOrder *string
+	Count     database.CountMode // The default mode of database.Counts when empty
+}
+
+// CrudService lists, gets, creates, updates and deletes the items of a model
+type CrudService[T any] struct {
+	DB      *gorm.DB
+	Emitter *emitter.Emitter
+	Logger  logger.Logger
+
+	// Name of an item in log messages, e.g. "notification"
+	Name string
+
+	// Events prefixes the events emitted after writes and the hooks of the registry:
+	// "notifications" emits notifications.create, notifications.update and
+	// notifications.delete, and runs the notifications.before_create hooks
+	Events string
+
+	// SortFields are the columns lists can be sorted by; lists are sorted by id, newest
+	// first, by default
+	SortFields []string
+
+	// Display is the column of the select options, "id" by default
+	Display string
+
+	// ListResponse converts the items of lists, e.g. to leave relationships out
+	ListResponse func(item *T) any
+
+	// Hooks run around the writes, before the hooks of the registry for Events
+	Hooks    Hooks[T]
+	Registry *hooks.Registry
+}
+
+// preloader is implemented by models with relationships to load with single items
+type preloader interface {
+	Preload(db *gorm.DB) *gorm.DB
+}
+
+// New creates the service of a model and adds its events to the event catalog
+func New[T any](db *gorm.DB, emitter *emitter.Emitter, logger logger.Logger, name, events string) *CrudService[T] {
+	registerEvents[T](name, events)
+	return &CrudService[T]{
+		DB:       db,
+		Emitter:  emitter,
+		Logger:   logger,
+		Name:     name,
+		Events:   events,
+		Registry: hooks.Default,
+	}
+}
+
+// Sort orders a query by the requested field and direction; unknown fields and directions
+// fall back to id desc
+func (s *CrudService[T]) Sort(query *gorm.DB, sortBy *string, sortOrder *string) *gorm.DB {
+	sortField := "id"
+	if sortBy != nil && slices.Contains(s.SortFields, *sortBy) {
+		sortField = *sortBy
+	}
+	sortDirection := "desc"
+	if sortOrder != nil && (*sortOrder == "asc" || *sortOrder == "desc") {
+		sortDirection = *sortOrder
+	}
+	return query.Order(sortField + " " + sortDirection)
+}
+
+// GetById returns an item with its relationships
+func (s *CrudService[T]) GetById(ctx context.Context, id uint) (*T, error) {
+	item := new(T)
+
+	query := s.DB.WithContext(ctx)
+	if p, ok := any(item).(preloader); ok {
+		query = p.Preload(query)
+	}
+	if err := query.First(item, id).Error; err != nil {
+		s.Logger.Error("failed to get "+s.Name,
+			logger.String("error", err.Error()),
+			logger.Int("id", int(id)))
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// GetAll returns a page of the items
+func (s *CrudService[T]) GetAll(ctx context.Context, page *int, limit *int, sortBy *string, sortOrder *string) (*types.PaginatedResponse, error) {
+	return s.List(ctx, s.DB.WithContext(ctx).Model(new(T)), ListParams{
+		Page:      page,
+		Limit:     limit,
+		SortBy:    sortBy,
+		SortOrder: sortOrder,
+	})
+}
+
+// List returns a page of the items of a query, e.g. a filtered one. With database.CountNone
+// the pagination reports has_more instead of the totals.
+func (s *CrudService[T]) List(ctx context.Context, query *gorm.DB, params ListParams) (*types.PaginatedResponse, error) {
+	var items []*T
+
+	page, limit := 1, 10
+	if params.Page != nil {
+		page = *params.Page
+	}
+	if params.Limit != nil {
+		limit = *params.Limit
+	}
+
+	total, exact, err := database.Counts.Count(query, params.Count)
+	if err != nil {
+		s.Logger.Error("failed to count "+s.Name+" items",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	// Without a count one more row tells whether there is a next page
+	offset := (page - 1) * limit
+	if params.Count == database.CountNone {
+		query = query.Offset(offset).Limit(limit + 1)
+	} else {
+		query = query.Offset(offset).Limit(limit)
+	}
+
+	// Relationships aren't preloaded for lists (faster)
+	if err := s.Sort(query, params.SortBy, params.SortOrder).Find(&items).Error; err != nil {
+		s.Logger.Error("failed to get "+s.Name+" items",
+			logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	pagination := types.Pagination{Page: page, PageSize: limit}
+	if params.Count == database.CountNone {
+		hasMore := len(items) > limit
+		if hasMore {
+			items = items[:limit]
+		}
+		pagination.HasMore = &hasMore
+	} else {
+		pagination.Total = int(total)
+		pagination.TotalPages = max(int(math.Ceil(float64(total)/float64(limit))), 1)
+		pagination.Estimated = !exact
+	}
+
+	var data any = items
+	if s.ListResponse != nil {
+		responses := make([]any, len(items))
+		for i, item := range items {
+			responses[i] = s.ListResponse(item)
+		}
+		data = responses
+	}
+
+	return &types.PaginatedResponse{
+		Data:       data,
+		Pagination: pagination,
+	}, nil
+}
+
+// GetAllForSelect returns the id and the display column of every item, for select boxes
+func (s *CrudService[T]) GetAllForSelect(ctx context.Context) ([]*T, error) {
+	var items []*T
+
+	query := s.DB.WithContext(ctx).Model(new(T))
+	if s.Display != "" && s.Display != "id" {
+		query = query.Select("id, " + s.Display).Order(s.Display + " ASC")
+	} else {
+		query = query.Select("id").Order("id ASC")
+	}
+
+	if err := query.Find(&items).Error; err != nil {
+		s.Logger.Error("failed to get "+s.Name+" select options", logger.String("error", err.Error()))
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// Create saves a new item and returns it with its relationships. The also hooks run after
+// the AfterCreate hooks, e.g. to save the translations of a request.
+func (s *CrudService[T]) Create(ctx context.Context, item *T, also ...Hook[T]) (*T, error) {
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.run(tx, item, hooks.BeforeCreate, s.Hooks.BeforeCreate); err != nil {
+			return err
+		}
+		if err := tx.Create(item).Error; err != nil {
+			s.Logger.Error("failed to create "+s.Name, logger.String("error", err.Error()))
+			return err
+		}
+		if err := s.run(tx, item, hooks.AfterCreate, s.Hooks.AfterCreate); err != nil {
+			return err
+		}
+		return run(tx, item, also)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.reload(ctx, item)
+	if err != nil {
+		return nil, err
+	}
+
+	// Emit create event
+	s.emit(ctx, "create", result)
+
+	return result, nil
+}
+
+// Update applies change to an item and saves it, then returns it with its relationships.
+// The also hooks run after the AfterUpdate hooks.
+func (s *CrudService[T]) Update(ctx context.Context, id uint, change Hook[T], also ...Hook[T]) (*T, error) {
+	item := new(T)
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(item, id).Error; err != nil {
+			s.Logger.Error("failed to find "+s.Name+" for update",
+				logger.String("error", err.Error()),
+				logger.Int("id", int(id)))
+			return err
+		}
+		if change != nil {
+			if err := change(tx, item); err != nil {
+				return err
+			}
+		}
+		if err := s.run(tx, item, hooks.BeforeUpdate, s.Hooks.BeforeUpdate); err != nil {
+			return err
+		}
+		if err := tx.Save(item).Error; err != nil {
+			s.Logger.Error("failed to update "+s.Name,
+				logger.String("error", err.Error()),
+				logger.Int("id", int(id)))
+			return err
+		}
+		if err := s.run(tx, item, hooks.AfterUpdate, s.Hooks.AfterUpdate); err != nil {
+			return err
+		}
+		return run(tx, item, also)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.reload(ctx, item)
+	if err != nil {
+		return nil, err
+	}
+
+	// Emit update event
+	s.emit(ctx, "update", result)
+
+	return result, nil
+}
+
+// Delete deletes an item. The also hooks run after the AfterDelete hooks.
+func (s *CrudService[T]) Delete(ctx context.Context, id uint, also ...Hook[T]) error {
+	item := new(T)
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(item, id).Error; err != nil {
+			s.Logger.Error("failed to find "+s.Name+" for deletion",
+				logger.String("error", err.Error()),
+				logger.Int("id", int(id)))
+			return err
+		}
+		if err := s.run(tx, item, hooks.BeforeDelete, s.Hooks.BeforeDelete); err != nil {
+			return err
+		}
+		if err := tx.Delete(item).Error; err != nil {
+			s.Logger.Error("failed to delete "+s.Name,
+				logger.String("error", err.Error()),
+				logger.Int("id", int(id)))
+			return err
+		}
+		if err := s.run(tx, item, hooks.AfterDelete, s.Hooks.AfterDelete); err != nil {
+			return err
+		}
+		return run(tx, item, also)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Emit delete event
+	s.emit(ctx, "delete", item)
+
+	return nil
+}
+
+// reload gets a saved item again, by its primary key, with its relationships
+func (s *CrudService[T]) reload(ctx context.Context, item *T) (*T, error) {
+	stmt := &gorm.Statement{DB: s.DB}
+	if err := stmt.Parse(item); err != nil {
+		return nil, err
+	}
+	if stmt.Schema.PrioritizedPrimaryField == nil {
+		return item, nil
+	}
+	id, _ := stmt.Schema.PrioritizedPrimaryField.ValueOf(ctx, reflect.ValueOf(item).Elem())
+
+	result := new(T)
+	query := s.DB.WithContext(ctx)
+	if p, ok := any(result).(preloader); ok {
+		query = p.Preload(query)
+	}
+	if err := query.First(result, id).Error; err != nil {
+		s.Logger.Error("failed to get saved "+s.Name, logger.String("error", err.Error()))
+		return nil, err
+	}
+	return result, nil
+}
+
+// registerEvents adds the events emitted after writes to the event catalog
+func registerEvents[T any](name, events string) {
+	if events == "" {
+		return
+	}
+	emitter.RegisterEvents(
+		emitter.EventType{Name: events + ".create", Description: "Created " + name, Payload: new(T)},
+		emitter.EventType{Name: events + ".update", Description: "Updated " + name, Payload: new(T)},
+		emitter.EventType{Name: events + ".delete", Description: "Deleted " + name, Payload: new(T)},
+	)
+}
+
+// emit emits the event of a write, if the service has events
+func (s *CrudService[T]) emit(ctx context.Context, action string, item *T) {
+	if s.Events != "" && s.Emitter != nil {
+		s.Emitter.EmitContext(ctx, s.Events+"."+action, item)
+	}
+}
+
+// run runs the hooks of the service for a stage, then those of the registry
+func (s *CrudService[T]) run(tx *gorm.DB, item *T, stage string, own []Hook[T]) error {
+	if err := run(tx, item, own); err != nil {
+		return err
+	}
+	if s.Events == "" {
+		return nil
+	}
+	return s.Registry.Run(s.Events, stage, tx, item)
+}
+
+// run runs hooks in order, stopping at the first error
+func run[T any](tx *gorm.DB, item *T, hooks []Hook[T]) error {
+	for _, hook := range hooks {
+		if err := hook(tx, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
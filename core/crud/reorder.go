@@ -0,0 +1,93 @@
+package crud
+
+import (
+	"fmt"
+	"slices"
+
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+// MaxReorder is the most records a reorder request can list
+const MaxReorder = 1000
+
+// ReorderRequest puts records in a manual order, e.g. after dragging and dropping them: the
+// first id gets position 0, the second 1 and so on. Records left out keep their position.
+type ReorderRequest struct {
+	Ids []uint `json:"ids" validate:"required,min=1,max=1000,dive,required"`
+}
+
+// Reorder sets the position column of the records of model to their index in ids, in one
+// transaction. With a group column, e.g. parent_id, the records must share its value: media
+// are ordered within their folder, pages among their siblings. Unknown, repeated and mixed
+// ids fail with validation errors.
+func Reorder(db *gorm.DB, model any, ids []uint, group string) error {
+	if len(ids) == 0 || len(ids) > MaxReorder {
+		return validator.ValidationErrors{{
+			Field:   "ids",
+			Tag:     "range",
+			Param:   fmt.Sprintf("1-%d", MaxReorder),
+			Message: fmt.Sprintf("ids must list 1 to %d records", MaxReorder),
+		}}
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		columns := "id"
+		if group != "" {
+			columns += ", " + group + " AS reorder_group"
+		}
+		var rows []struct {
+			Id           uint
+			ReorderGroup *string
+		}
+		if err := tx.Model(model).Select(columns).Where("id IN ?", ids).Find(&rows).Error; err != nil {
+			return err
+		}
+		groups := make(map[uint]*string, len(rows))
+		for _, row := range rows {
+			groups[row.Id] = row.ReorderGroup
+		}
+		first, found := groups[ids[0]]
+
+		var errs validator.ValidationErrors
+		for i, id := range ids {
+			field := fmt.Sprintf("ids[%d]", i)
+			value, ok := groups[id]
+			switch {
+			case !ok:
+				errs = append(errs, validator.ValidationError{
+					Field: field, Tag: "exists", Value: fmt.Sprint(id),
+					Message: fmt.Sprintf("record %d does not exist", id),
+				})
+			case slices.Index(ids, id) < i:
+				errs = append(errs, validator.ValidationError{
+					Field: field, Tag: "invalid", Value: fmt.Sprint(id),
+					Message: fmt.Sprintf("record %d is listed more than once", id),
+				})
+			case found && !equal(value, first):
+				errs = append(errs, validator.ValidationError{
+					Field: field, Tag: "invalid", Value: fmt.Sprint(id),
+					Message: fmt.Sprintf("record %d doesn't have the %s of record %d", id, group, ids[0]),
+				})
+			}
+		}
+		if len(errs) > 0 {
+			return errs
+		}
+
+		for position, id := range ids {
+			if err := tx.Model(model).Where("id = ?", id).UpdateColumn("position", position).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// equal reports whether two group values are the same, both null included
+func equal(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,324 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// CopyOptions configures CopyData
+type CopyOptions struct {
+	// BatchSize is the number of rows read and written per round trip
+	BatchSize int
+
+	// Truncate empties target tables before copying instead of refusing to copy into them
+	Truncate bool
+
+	// Progress is called after each batch with the running and total row counts
+	Progress func(table string, copied, total int64)
+
+	// Skipped is called for source tables that don't exist in the target
+	Skipped func(table string)
+}
+
+// Open opens a database connection for the driver and DSN without registering it as the global DB
+func Open(driver, dsn string) (*gorm.DB, error) {
+	switch driver {
+	case "sqlite", "mysql", "postgres":
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %s", driver)
+	}
+
+	db, err := gorm.Open(openDialector(driver, dsn), &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Warn),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the database: %v", err)
+	}
+	return db, nil
+}
+
+// CopyData copies every table that exists in both databases from src to dst. Tables are
+// copied so that referenced tables come before the tables that reference them, using the
+// foreign keys of the target schema. The target schema must already exist.
+func CopyData(src, dst *gorm.DB, opts CopyOptions) error {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 500
+	}
+
+	srcTables, err := src.Migrator().GetTables()
+	if err != nil {
+		return fmt.Errorf("failed to list source tables: %w", err)
+	}
+	dstTables, err := dst.Migrator().GetTables()
+	if err != nil {
+		return fmt.Errorf("failed to list target tables: %w", err)
+	}
+
+	inTarget := make(map[string]bool, len(dstTables))
+	for _, table := range dstTables {
+		inTarget[table] = true
+	}
+
+	var tables []string
+	for _, table := range srcTables {
+		if strings.HasPrefix(table, "sqlite_") {
+			continue
+		}
+		if !inTarget[table] {
+			if opts.Skipped != nil {
+				opts.Skipped(table)
+			}
+			continue
+		}
+		tables = append(tables, table)
+	}
+
+	deps, err := foreignKeyDependencies(dst, tables)
+	if err != nil {
+		return fmt.Errorf("failed to read target foreign keys: %w", err)
+	}
+	ordered := orderTables(tables, deps)
+
+	if opts.Truncate {
+		for i := len(ordered) - 1; i >= 0; i-- {
+			if err := dst.Exec("DELETE FROM " + dst.Statement.Quote(ordered[i])).Error; err != nil {
+				return fmt.Errorf("failed to empty %s: %w", ordered[i], err)
+			}
+		}
+	}
+
+	for _, table := range ordered {
+		if err := copyTable(src, dst, table, opts); err != nil {
+			return err
+		}
+	}
+
+	if dst.Dialector.Name() == "postgres" {
+		for _, table := range ordered {
+			if err := resetSequence(dst, table); err != nil {
+				return fmt.Errorf("failed to reset id sequence of %s: %w", table, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// copyTable copies all rows of a single table in batches, ordered by primary key
+func copyTable(src, dst *gorm.DB, table string, opts CopyOptions) error {
+	var existing int64
+	if err := dst.Table(table).Count(&existing).Error; err != nil {
+		return fmt.Errorf("failed to count rows in target %s: %w", table, err)
+	}
+	if existing > 0 {
+		return fmt.Errorf("target table %s is not empty (use truncate to overwrite it)", table)
+	}
+
+	var total int64
+	if err := src.Table(table).Count(&total).Error; err != nil {
+		return fmt.Errorf("failed to count rows in %s: %w", table, err)
+	}
+
+	srcColumns, err := src.Migrator().ColumnTypes(table)
+	if err != nil {
+		return fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	dstColumns, err := dst.Migrator().ColumnTypes(table)
+	if err != nil {
+		return fmt.Errorf("failed to read target columns of %s: %w", table, err)
+	}
+
+	// Only copy columns that exist on both sides, and remember which ones are boolean
+	// in the target since SQLite stores booleans as integers
+	targetTypes := make(map[string]string, len(dstColumns))
+	for _, column := range dstColumns {
+		targetTypes[column.Name()] = strings.ToLower(column.DatabaseTypeName())
+	}
+	var columns, selects, orderBy []string
+	for _, column := range srcColumns {
+		if _, ok := targetTypes[column.Name()]; !ok {
+			continue
+		}
+		columns = append(columns, column.Name())
+		selects = append(selects, src.Statement.Quote(column.Name()))
+		if primary, ok := column.PrimaryKey(); ok && primary {
+			orderBy = append(orderBy, src.Statement.Quote(column.Name()))
+		}
+	}
+	if len(orderBy) == 0 && len(columns) > 0 {
+		orderBy = append(orderBy, src.Statement.Quote(columns[0]))
+	}
+
+	if opts.Progress != nil {
+		opts.Progress(table, 0, total)
+	}
+
+	var copied int64
+	for offset := 0; int64(offset) < total; offset += opts.BatchSize {
+		var rows []map[string]any
+		err := src.Table(table).
+			Select(strings.Join(selects, ", ")).
+			Order(strings.Join(orderBy, ", ")).
+			Limit(opts.BatchSize).
+			Offset(offset).
+			Find(&rows).Error
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", table, err)
+		}
+		if len(rows) == 0 {
+			break
+		}
+
+		for _, row := range rows {
+			for column, value := range row {
+				if isBooleanType(targetTypes[column]) {
+					row[column] = toBool(value)
+				}
+			}
+		}
+
+		if err := dst.Table(table).Create(&rows).Error; err != nil {
+			return fmt.Errorf("failed to write %s: %w", table, err)
+		}
+
+		copied += int64(len(rows))
+		if opts.Progress != nil {
+			opts.Progress(table, copied, total)
+		}
+	}
+
+	return nil
+}
+
+// foreignKeyDependencies returns, for each table, the tables it references
+func foreignKeyDependencies(db *gorm.DB, tables []string) (map[string][]string, error) {
+	deps := make(map[string][]string, len(tables))
+
+	type reference struct {
+		TableName      string
+		ReferencedName string
+	}
+	var refs []reference
+
+	switch db.Dialector.Name() {
+	case "postgres":
+		err := db.Raw(`SELECT tc.table_name AS table_name, ccu.table_name AS referenced_name
+			FROM information_schema.table_constraints tc
+			JOIN information_schema.constraint_column_usage ccu
+				ON tc.constraint_name = ccu.constraint_name AND tc.table_schema = ccu.table_schema
+			WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_schema = CURRENT_SCHEMA()`).Scan(&refs).Error
+		if err != nil {
+			return nil, err
+		}
+	case "mysql":
+		err := db.Raw(`SELECT table_name AS table_name, referenced_table_name AS referenced_name
+			FROM information_schema.key_column_usage
+			WHERE table_schema = DATABASE() AND referenced_table_name IS NOT NULL`).Scan(&refs).Error
+		if err != nil {
+			return nil, err
+		}
+	case "sqlite":
+		for _, table := range tables {
+			var targets []string
+			if err := db.Raw("SELECT DISTINCT \"table\" FROM pragma_foreign_key_list(?)", table).Scan(&targets).Error; err != nil {
+				return nil, err
+			}
+			for _, target := range targets {
+				refs = append(refs, reference{TableName: table, ReferencedName: target})
+			}
+		}
+	}
+
+	for _, ref := range refs {
+		if ref.TableName != ref.ReferencedName {
+			deps[ref.TableName] = append(deps[ref.TableName], ref.ReferencedName)
+		}
+	}
+	return deps, nil
+}
+
+// orderTables sorts tables so that referenced tables come first. Tables that are part of
+// a reference cycle keep alphabetical order at the end.
+func orderTables(tables []string, deps map[string][]string) []string {
+	sorted := append([]string(nil), tables...)
+	sort.Strings(sorted)
+
+	known := make(map[string]bool, len(sorted))
+	for _, table := range sorted {
+		known[table] = true
+	}
+
+	done := make(map[string]bool, len(sorted))
+	var ordered []string
+	for len(ordered) < len(sorted) {
+		progressed := false
+		for _, table := range sorted {
+			if done[table] {
+				continue
+			}
+			ready := true
+			for _, dep := range deps[table] {
+				if known[dep] && !done[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				done[table] = true
+				ordered = append(ordered, table)
+				progressed = true
+			}
+		}
+		if !progressed {
+			for _, table := range sorted {
+				if !done[table] {
+					done[table] = true
+					ordered = append(ordered, table)
+				}
+			}
+		}
+	}
+	return ordered
+}
+
+// resetSequence moves a Postgres id sequence past the copied ids
+func resetSequence(db *gorm.DB, table string) error {
+	if !db.Migrator().HasColumn(table, "id") {
+		return nil
+	}
+
+	var sequence *string
+	if err := db.Raw("SELECT pg_get_serial_sequence(?, 'id')", table).Scan(&sequence).Error; err != nil {
+		return err
+	}
+	if sequence == nil || *sequence == "" {
+		return nil
+	}
+
+	return db.Exec(fmt.Sprintf("SELECT setval(?, COALESCE((SELECT MAX(id) FROM %s), 1), (SELECT MAX(id) FROM %s) IS NOT NULL)",
+		db.Statement.Quote(table), db.Statement.Quote(table)), *sequence).Error
+}
+
+func isBooleanType(typeName string) bool {
+	return typeName == "bool" || typeName == "boolean"
+}
+
+// toBool converts SQLite's integer booleans
+func toBool(value any) any {
+	switch v := value.(type) {
+	case int64:
+		return v != 0
+	case int:
+		return v != 0
+	case []byte:
+		return string(v) == "1" || strings.EqualFold(string(v), "true")
+	case string:
+		return v == "1" || strings.EqualFold(v, "true")
+	default:
+		return value
+	}
+}
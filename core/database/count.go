@@ -0,0 +1,165 @@
+package database
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"base/core/explain"
+
+	"gorm.io/gorm"
+)
+
+// CountMode selects how a paginated list counts the rows matching its filter
+type CountMode string
+
+const (
+	// CountExact runs COUNT(*) for every page
+	CountExact CountMode = "exact"
+
+	// CountEstimated uses the planner's row estimate for unfiltered lists on Postgres and
+	// an exact count cached for a while otherwise
+	CountEstimated CountMode = "estimated"
+
+	// CountNone skips counting; the page reports whether there is a next one instead
+	CountNone CountMode = "none"
+)
+
+// minEstimatedRows is the table size below which estimates aren't worth it: small tables
+// are counted exactly, as their statistics are often stale
+const minEstimatedRows = 10000
+
+// ParseCountMode returns the count mode named by value, or fallback when value is empty
+func ParseCountMode(value string, fallback CountMode) (CountMode, error) {
+	switch mode := CountMode(value); mode {
+	case "":
+		return fallback, nil
+	case CountExact, CountEstimated, CountNone:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid count mode %q (exact, estimated or none)", value)
+	}
+}
+
+// cachedCount is a count remembered by a Counter
+type cachedCount struct {
+	total   int64
+	expires time.Time
+}
+
+// Counter counts the rows of paginated lists according to a CountMode
+type Counter struct {
+	mu      sync.Mutex
+	mode    CountMode
+	ttl     time.Duration
+	entries map[string]cachedCount
+}
+
+// Counts is the counter used by the list endpoints of large tables; InitDB sets its default
+// mode and cache lifetime from DB_COUNT_MODE and DB_COUNT_CACHE_TTL
+var Counts = NewCounter(CountExact, time.Minute)
+
+// NewCounter creates a counter with the default mode and the lifetime of cached counts
+func NewCounter(mode CountMode, ttl time.Duration) *Counter {
+	return &Counter{mode: mode, ttl: ttl, entries: make(map[string]cachedCount)}
+}
+
+// Configure changes the default mode and the lifetime of cached counts
+func (c *Counter) Configure(mode CountMode, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mode = mode
+	c.ttl = ttl
+	c.entries = make(map[string]cachedCount)
+}
+
+// Mode returns the mode to use for a request's count parameter; an empty value selects the
+// default mode
+func (c *Counter) Mode(value string) (CountMode, error) {
+	c.mu.Lock()
+	fallback := c.mode
+	c.mu.Unlock()
+	return ParseCountMode(value, fallback)
+}
+
+// Count returns the number of rows matching query and whether the number is exact. With
+// CountNone it returns 0 without querying. Call it before adding the order, offset and
+// limit of the page to query.
+func (c *Counter) Count(query *gorm.DB, mode CountMode) (int64, bool, error) {
+	switch mode {
+	case CountNone:
+		return 0, false, nil
+	case CountEstimated:
+		if total, ok := c.estimate(query); ok {
+			return total, false, nil
+		}
+		return c.cached(query)
+	default:
+		var total int64
+		err := query.Count(&total).Error
+		return total, err == nil, err
+	}
+}
+
+// estimate returns the planner's row estimate for an unfiltered query on Postgres
+func (c *Counter) estimate(query *gorm.DB) (int64, bool) {
+	if query.Dialector.Name() != "postgres" || len(query.Statement.Clauses) > 0 || len(query.Statement.Joins) > 0 {
+		return 0, false
+	}
+	table := query.Statement.Table
+	if table == "" && query.Statement.Model != nil {
+		if err := query.Statement.Parse(query.Statement.Model); err != nil {
+			return 0, false
+		}
+		table = query.Statement.Table
+	}
+	if table == "" {
+		return 0, false
+	}
+
+	// reltuples is -1 for tables that were never vacuumed or analyzed
+	var estimate float64
+	err := query.Session(&gorm.Session{NewDB: true}).
+		Raw("SELECT reltuples FROM pg_class WHERE oid = to_regclass(?)", table).
+		Scan(&estimate).Error
+	if err != nil || estimate < minEstimatedRows {
+		return 0, false
+	}
+	return int64(estimate), true
+}
+
+// cached returns an exact count of query, computed at most once per TTL
+func (c *Counter) cached(query *gorm.DB) (int64, bool, error) {
+	key := query.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		var total int64
+		return tx.Count(&total)
+	})
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	ttl := c.ttl
+	c.mu.Unlock()
+	report := explain.FromContext(query.Statement.Context)
+	if ok && time.Now().Before(entry.expires) {
+		report.Cache("counts", key, "hit")
+		return entry.total, false, nil
+	}
+	report.Cache("counts", key, "miss")
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return 0, false, err
+	}
+
+	c.mu.Lock()
+	now := time.Now()
+	for k, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = cachedCount{total: total, expires: now.Add(ttl)}
+	c.mu.Unlock()
+
+	return total, true, nil
+}
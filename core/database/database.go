@@ -8,34 +8,36 @@ import (
 	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
 )
 
 var DB *gorm.DB
 
 type Database struct {
 	*gorm.DB
+	QueryLog *QueryLogger
 }
 
 // InitDB initializes the database connection based on the provided configuration.
 func InitDB(cfg *config.Config) (*Database, error) {
 	var err error
+	queryLog := NewQueryLogger(cfg.DBSlowQueryThreshold)
+	gormConfig := &gorm.Config{Logger: queryLog}
 	switch cfg.DBDriver {
 	case "sqlite":
-		// Configure SQLite with WAL mode and busy timeout for better concurrency
-		dsn := cfg.DBPath + "?_journal_mode=WAL&_busy_timeout=5000&_synchronous=NORMAL&cache=shared"
-		DB, err = gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+		DB, err = gorm.Open(openDialector(cfg.DBDriver, cfg.DBPath), gormConfig)
 	case "mysql":
 		if cfg.DBURL == "" {
 			cfg.DBURL = fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
 				cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName)
 		}
-		DB, err = gorm.Open(mysql.Open(cfg.DBURL), &gorm.Config{})
+		DB, err = gorm.Open(openDialector(cfg.DBDriver, cfg.DBURL), gormConfig)
 	case "postgres":
 		if cfg.DBURL == "" {
 			cfg.DBURL = fmt.Sprintf("host=%s port=%s user=%s dbname=%s password=%s sslmode=disable",
 				cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBName, cfg.DBPassword)
 		}
-		DB, err = gorm.Open(postgres.Open(cfg.DBURL), &gorm.Config{})
+		DB, err = gorm.Open(openDialector(cfg.DBDriver, cfg.DBURL), gormConfig)
 	default:
 		return nil, fmt.Errorf("unsupported database driver: %s", cfg.DBDriver)
 	}
@@ -44,5 +46,61 @@ func InitDB(cfg *config.Config) (*Database, error) {
 		return nil, fmt.Errorf("failed to connect to the database: %v", err)
 	}
 
-	return &Database{DB: DB}, nil
+	if err := configurePool(DB, cfg); err != nil {
+		return nil, fmt.Errorf("failed to configure the connection pool: %v", err)
+	}
+
+	if err := registerReplicas(DB, cfg); err != nil {
+		return nil, fmt.Errorf("failed to configure read replicas: %v", err)
+	}
+
+	if err := registerStatementTimeout(DB, cfg.DBStatementTimeout); err != nil {
+		return nil, fmt.Errorf("failed to register the statement timeout: %v", err)
+	}
+
+	countMode, err := ParseCountMode(cfg.DBCountMode, CountExact)
+	if err != nil {
+		return nil, err
+	}
+	Counts.Configure(countMode, cfg.DBCountCacheTTL)
+
+	return &Database{DB: DB, QueryLog: queryLog}, nil
+}
+
+// openDialector returns the GORM dialector for the driver and DSN
+func openDialector(driver, dsn string) gorm.Dialector {
+	switch driver {
+	case "sqlite":
+		// Configure SQLite with WAL mode and busy timeout for better concurrency
+		return sqlite.Open(dsn + "?_journal_mode=WAL&_busy_timeout=5000&_synchronous=NORMAL&cache=shared")
+	case "mysql":
+		return mysql.Open(dsn)
+	default:
+		return postgres.Open(dsn)
+	}
+}
+
+// registerReplicas routes reads to the configured replicas and writes to the primary.
+// Queries inside a transaction always use the primary. Use db.Clauses(dbresolver.Write)
+// to force a read onto the primary, e.g. when it must see a write that just happened.
+func registerReplicas(db *gorm.DB, cfg *config.Config) error {
+	if len(cfg.DBReplicaURLs) == 0 {
+		return nil
+	}
+
+	replicas := make([]gorm.Dialector, 0, len(cfg.DBReplicaURLs))
+	for _, dsn := range cfg.DBReplicaURLs {
+		replicas = append(replicas, openDialector(cfg.DBDriver, dsn))
+	}
+
+	resolver := dbresolver.Register(dbresolver.Config{
+		Replicas: replicas,
+		Policy:   dbresolver.RandomPolicy{},
+	}).
+		SetMaxOpenConns(cfg.DBMaxOpenConns).
+		SetMaxIdleConns(cfg.DBMaxIdleConns).
+		SetConnMaxLifetime(cfg.DBConnMaxLifetime).
+		SetConnMaxIdleTime(cfg.DBConnMaxIdleTime)
+
+	return db.Use(resolver)
 }
@@ -0,0 +1,133 @@
+package database
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// queryDurationBuckets are the histogram upper bounds in seconds
+var queryDurationBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// queryHistogram is a cumulative histogram of query durations
+type queryHistogram struct {
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+// QueryMetrics collects query duration histograms per SQL operation
+type QueryMetrics struct {
+	mu          sync.Mutex
+	histograms  map[string]*queryHistogram
+	slowQueries uint64
+}
+
+// NewQueryMetrics creates an empty metrics collector
+func NewQueryMetrics() *QueryMetrics {
+	return &QueryMetrics{
+		histograms: make(map[string]*queryHistogram),
+	}
+}
+
+// Observe records a query duration for the operation
+func (m *QueryMetrics) Observe(operation string, duration time.Duration, slow bool) {
+	seconds := duration.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, exists := m.histograms[operation]
+	if !exists {
+		h = &queryHistogram{buckets: make([]uint64, len(queryDurationBuckets))}
+		m.histograms[operation] = h
+	}
+
+	for i, bound := range queryDurationBuckets {
+		if seconds <= bound {
+			h.buckets[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+
+	if slow {
+		m.slowQueries++
+	}
+}
+
+// WriteMetrics writes query histograms and connection pool statistics in the
+// Prometheus text exposition format
+func WriteMetrics(w io.Writer, db *gorm.DB, metrics *QueryMetrics) error {
+	if metrics != nil {
+		if err := metrics.write(w); err != nil {
+			return err
+		}
+	}
+
+	if db == nil {
+		return nil
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	stats := sqlDB.Stats()
+
+	gauges := []struct {
+		name, help, kind string
+		value            float64
+	}{
+		{"db_pool_max_open_connections", "Maximum number of open connections.", "gauge", float64(stats.MaxOpenConnections)},
+		{"db_pool_open_connections", "Number of established connections.", "gauge", float64(stats.OpenConnections)},
+		{"db_pool_in_use_connections", "Number of connections currently in use.", "gauge", float64(stats.InUse)},
+		{"db_pool_idle_connections", "Number of idle connections.", "gauge", float64(stats.Idle)},
+		{"db_pool_wait_count_total", "Total number of connections waited for.", "counter", float64(stats.WaitCount)},
+		{"db_pool_wait_duration_seconds_total", "Total time blocked waiting for a new connection.", "counter", stats.WaitDuration.Seconds()},
+	}
+	for _, g := range gauges {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %s\n", g.name, g.help, g.name, g.kind, g.name, formatFloat(g.value)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// write writes the histograms in the Prometheus text exposition format
+func (m *QueryMetrics) write(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	operations := make([]string, 0, len(m.histograms))
+	for operation := range m.histograms {
+		operations = append(operations, operation)
+	}
+	sort.Strings(operations)
+
+	fmt.Fprintln(w, "# HELP db_query_duration_seconds Duration of database queries.")
+	fmt.Fprintln(w, "# TYPE db_query_duration_seconds histogram")
+	for _, operation := range operations {
+		h := m.histograms[operation]
+		for i, bound := range queryDurationBuckets {
+			fmt.Fprintf(w, "db_query_duration_seconds_bucket{operation=%q,le=%q} %d\n", operation, formatFloat(bound), h.buckets[i])
+		}
+		fmt.Fprintf(w, "db_query_duration_seconds_bucket{operation=%q,le=\"+Inf\"} %d\n", operation, h.count)
+		fmt.Fprintf(w, "db_query_duration_seconds_sum{operation=%q} %s\n", operation, formatFloat(h.sum))
+		fmt.Fprintf(w, "db_query_duration_seconds_count{operation=%q} %d\n", operation, h.count)
+	}
+
+	fmt.Fprintln(w, "# HELP db_slow_queries_total Number of queries slower than the slow query threshold.")
+	fmt.Fprintln(w, "# TYPE db_slow_queries_total counter")
+	_, err := fmt.Fprintf(w, "db_slow_queries_total %d\n", m.slowQueries)
+	return err
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,345 @@
+package database
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is a versioned schema change. Up and Down each run inside their own transaction.
+// Versions are compared as strings, so use a fixed-width format such as 20250102150405.
+type Migration struct {
+	Version string
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration records an applied migration in the schema_migrations table
+type SchemaMigration struct {
+	Version   string    `gorm:"primaryKey;size:64" json:"version"`
+	Name      string    `gorm:"size:255" json:"name"`
+	Batch     int       `gorm:"index" json:"batch"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+// TableName returns the table name for the SchemaMigration model
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationStatus describes whether a registered migration has been applied
+type MigrationStatus struct {
+	Version   string     `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	Batch     int        `json:"batch,omitempty"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+var (
+	// migrationsRegistry stores all registered migrations keyed by version
+	migrationsRegistry = make(map[string]Migration)
+	migrationsMu       sync.RWMutex
+)
+
+// RegisterMigration registers a versioned migration. It should be called from
+// the init() function of the package that owns the migration.
+func RegisterMigration(m Migration) error {
+	if m.Version == "" {
+		return fmt.Errorf("migration version is required")
+	}
+	if m.Up == nil {
+		return fmt.Errorf("migration %s has no up step", m.Version)
+	}
+
+	migrationsMu.Lock()
+	defer migrationsMu.Unlock()
+	if _, exists := migrationsRegistry[m.Version]; exists {
+		return fmt.Errorf("migration already registered: %s", m.Version)
+	}
+	migrationsRegistry[m.Version] = m
+	return nil
+}
+
+// RegisterSQLMigrations registers every <version>_<name>.up.sql file found in dir,
+// paired with its optional <version>_<name>.down.sql counterpart
+func RegisterSQLMigrations(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return fmt.Errorf("failed to read migrations directory %s: %w", dir, err)
+	}
+
+	files := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			files[entry.Name()] = true
+		}
+	}
+
+	for filename := range files {
+		if !strings.HasSuffix(filename, ".up.sql") {
+			continue
+		}
+
+		base := strings.TrimSuffix(filename, ".up.sql")
+		version, name, found := strings.Cut(base, "_")
+		if !found || version == "" {
+			return fmt.Errorf("invalid migration filename %s: expected <version>_<name>.up.sql", filename)
+		}
+
+		upSQL, err := fs.ReadFile(fsys, path.Join(dir, filename))
+		if err != nil {
+			return err
+		}
+
+		migration := Migration{
+			Version: version,
+			Name:    name,
+			Up:      execSQL(string(upSQL)),
+		}
+
+		downFile := base + ".down.sql"
+		if files[downFile] {
+			downSQL, err := fs.ReadFile(fsys, path.Join(dir, downFile))
+			if err != nil {
+				return err
+			}
+			migration.Down = execSQL(string(downSQL))
+		}
+
+		if err := RegisterMigration(migration); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetMigrations returns all registered migrations ordered by version
+func GetMigrations() []Migration {
+	migrationsMu.RLock()
+	defer migrationsMu.RUnlock()
+
+	migrations := make([]Migration, 0, len(migrationsRegistry))
+	for _, m := range migrationsRegistry {
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations
+}
+
+// execSQL returns a migration step that executes each statement of a SQL script
+func execSQL(script string) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		for _, statement := range splitSQLStatements(script) {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// splitSQLStatements splits a script on semicolons that terminate a line
+func splitSQLStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		current.WriteString(line)
+		current.WriteString("\n")
+
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+
+	return statements
+}
+
+// Migrator applies and rolls back registered migrations
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator creates a migrator for all registered migrations
+func NewMigrator(db *gorm.DB) *Migrator {
+	return &Migrator{
+		db:         db,
+		migrations: GetMigrations(),
+	}
+}
+
+// ensureTable creates the schema_migrations table if it doesn't exist
+func (m *Migrator) ensureTable() error {
+	return m.db.AutoMigrate(&SchemaMigration{})
+}
+
+// applied returns the applied migrations keyed by version
+func (m *Migrator) applied() (map[string]SchemaMigration, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	var records []SchemaMigration
+	if err := m.db.Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	applied := make(map[string]SchemaMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// Pending returns the migrations that haven't been applied yet
+func (m *Migrator) Pending() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Migrate applies all pending migrations as a single batch
+func (m *Migrator) Migrate() ([]Migration, error) {
+	pending, err := m.Pending()
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	var lastBatch int
+	if err := m.db.Model(&SchemaMigration{}).Select("COALESCE(MAX(batch), 0)").Scan(&lastBatch).Error; err != nil {
+		return nil, err
+	}
+	batch := lastBatch + 1
+
+	var done []Migration
+	for _, migration := range pending {
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				Batch:     batch,
+				AppliedAt: time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %s_%s failed: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Rollback reverts the last `steps` applied migrations, or the whole last batch when steps is 0
+func (m *Migrator) Rollback(steps int) ([]Migration, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	query := m.db.Order("batch DESC").Order("version DESC")
+	if steps > 0 {
+		query = query.Limit(steps)
+	} else {
+		var lastBatch int
+		if err := m.db.Model(&SchemaMigration{}).Select("COALESCE(MAX(batch), 0)").Scan(&lastBatch).Error; err != nil {
+			return nil, err
+		}
+		query = query.Where("batch = ?", lastBatch)
+	}
+
+	var records []SchemaMigration
+	if err := query.Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	registered := make(map[string]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		registered[migration.Version] = migration
+	}
+
+	var done []Migration
+	for _, record := range records {
+		migration, ok := registered[record.Version]
+		if !ok {
+			return done, fmt.Errorf("migration %s is applied but not registered", record.Version)
+		}
+		if migration.Down == nil {
+			return done, fmt.Errorf("migration %s_%s has no down step", migration.Version, migration.Name)
+		}
+
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Down(tx); err != nil {
+				return err
+			}
+			return tx.Where("version = ?", record.Version).Delete(&SchemaMigration{}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("rollback of %s_%s failed: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Status reports every registered migration and whether it has been applied
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{
+			Version: migration.Version,
+			Name:    migration.Name,
+		}
+		if record, ok := applied[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.Applied = true
+			status.Batch = record.Batch
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
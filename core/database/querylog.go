@@ -0,0 +1,176 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"base/core/explain"
+	"base/core/logger"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+type queryStatsKey struct{}
+
+// QueryStats counts the queries executed with a request's context
+type QueryStats struct {
+	RequestId string
+	count     atomic.Int64
+	duration  atomic.Int64
+}
+
+// Count returns the number of queries executed so far
+func (s *QueryStats) Count() int64 {
+	return s.count.Load()
+}
+
+// Duration returns the total time spent in queries so far
+func (s *QueryStats) Duration() time.Duration {
+	return time.Duration(s.duration.Load())
+}
+
+// WithQueryStats returns a context that collects query statistics for the given request.
+// Only queries run with this context (db.WithContext(ctx)) are counted.
+func WithQueryStats(ctx context.Context, requestId string) (context.Context, *QueryStats) {
+	stats := &QueryStats{RequestId: requestId}
+	return context.WithValue(ctx, queryStatsKey{}, stats), stats
+}
+
+// QueryStatsFromContext returns the query statistics attached to ctx, if any
+func QueryStatsFromContext(ctx context.Context) *QueryStats {
+	if ctx == nil {
+		return nil
+	}
+	stats, _ := ctx.Value(queryStatsKey{}).(*QueryStats)
+	return stats
+}
+
+// QueryLogger is a GORM logger that records query durations and reports slow queries
+type QueryLogger struct {
+	gormlogger.Interface
+	log           logger.Logger
+	level         gormlogger.LogLevel
+	slowThreshold time.Duration
+	metrics       *QueryMetrics
+}
+
+// NewQueryLogger creates a query logger. Queries slower than slowThreshold are logged
+// as warnings; a threshold of 0 disables slow query logging.
+func NewQueryLogger(slowThreshold time.Duration) *QueryLogger {
+	return &QueryLogger{
+		Interface: gormlogger.New(log.New(os.Stdout, "\r\n", log.LstdFlags), gormlogger.Config{
+			SlowThreshold: slowThreshold,
+			LogLevel:      gormlogger.Warn,
+			Colorful:      true,
+		}),
+		level:         gormlogger.Warn,
+		slowThreshold: slowThreshold,
+		metrics:       NewQueryMetrics(),
+	}
+}
+
+// SetLogger routes slow query reports to the application logger
+func (l *QueryLogger) SetLogger(log logger.Logger) {
+	l.log = log
+}
+
+// Metrics returns the collected query duration metrics
+func (l *QueryLogger) Metrics() *QueryMetrics {
+	return l.metrics
+}
+
+// LogMode returns a copy of the logger with the given GORM log level
+func (l *QueryLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *l
+	clone.Interface = l.Interface.LogMode(level)
+	clone.level = level
+	return &clone
+}
+
+// Trace records the query duration, in the debug report of the request too, and logs failed
+// and slow queries. Once SetLogger has been called the application logger is used,
+// otherwise GORM's default output.
+func (l *QueryLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	sql, rows := fc()
+	slow := l.slowThreshold > 0 && elapsed >= l.slowThreshold
+
+	l.metrics.Observe(queryOperation(sql), elapsed, slow)
+
+	stats := QueryStatsFromContext(ctx)
+	if stats != nil {
+		stats.count.Add(1)
+		stats.duration.Add(int64(elapsed))
+	}
+	explain.FromContext(ctx).Query(sql, rows, elapsed, err)
+
+	if l.log == nil {
+		l.Interface.Trace(ctx, begin, func() (string, int64) { return sql, rows }, err)
+		return
+	}
+	if l.level <= gormlogger.Silent {
+		return
+	}
+
+	// Debug requests (see logger.WithDebug) log every query
+	debug := logger.IsDebug(ctx)
+	failed := err != nil && !errors.Is(err, gorm.ErrRecordNotFound)
+	if !failed && !slow && !debug && l.level < gormlogger.Info {
+		return
+	}
+
+	fields := []logger.Field{
+		logger.String("sql", sql),
+		logger.Duration("duration", elapsed),
+		logger.Int64("rows", rows),
+		logger.String("source", queryCaller()),
+	}
+	if stats != nil && stats.RequestId != "" {
+		fields = append(fields, logger.String("request_id", stats.RequestId))
+	}
+
+	log := logger.FromContext(ctx, l.log)
+	switch {
+	case failed:
+		log.Error("Query failed", append(fields, logger.String("error", err.Error()))...)
+	case slow:
+		log.Warn("Slow query", fields...)
+	default:
+		log.Debug("Query", fields...)
+	}
+}
+
+// queryCaller returns the file and line of the first caller outside GORM and this logger
+func queryCaller() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.Contains(frame.File, "gorm.io/") && !strings.HasSuffix(frame.File, "core/database/querylog.go") {
+			return frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// queryOperation returns the lower-cased SQL verb used to label metrics
+func queryOperation(sql string) string {
+	verb, _, _ := strings.Cut(strings.TrimSpace(sql), " ")
+	switch verb = strings.ToLower(verb); verb {
+	case "select", "insert", "update", "delete":
+		return verb
+	default:
+		return "other"
+	}
+}
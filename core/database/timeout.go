@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const statementCancelKey = "database:statement_cancel"
+
+// statementTimeout is stored on the statement between the before and after callbacks
+type statementTimeout struct {
+	parent context.Context
+	cancel context.CancelFunc
+}
+
+// registerStatementTimeout bounds every create/query/update/delete/exec with a default timeout.
+// Statements whose context already carries a deadline keep it. Row/Rows are left alone because
+// their results are read after the callback chain has finished.
+func registerStatementTimeout(db *gorm.DB, timeout time.Duration) error {
+	if timeout <= 0 {
+		return nil
+	}
+
+	before := func(tx *gorm.DB) {
+		ctx := tx.Statement.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		if _, hasDeadline := ctx.Deadline(); hasDeadline {
+			return
+		}
+		parent := tx.Statement.Context
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		tx.Statement.Context = ctx
+		tx.InstanceSet(statementCancelKey, statementTimeout{parent: parent, cancel: cancel})
+	}
+
+	// after cancels the timeout and restores the original context, so a query builder that is
+	// executed again (e.g. Count followed by Find) doesn't inherit the cancelled context
+	after := func(tx *gorm.DB) {
+		if value, ok := tx.InstanceGet(statementCancelKey); ok {
+			if st, ok := value.(statementTimeout); ok {
+				st.cancel()
+				tx.Statement.Context = st.parent
+				tx.InstanceSet(statementCancelKey, nil)
+			}
+		}
+	}
+
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("gorm:begin_transaction").Register("database:timeout_before_create", before); err != nil {
+		return err
+	}
+	if err := callbacks.Create().After("gorm:commit_or_rollback_transaction").Register("database:timeout_after_create", after); err != nil {
+		return err
+	}
+	if err := callbacks.Query().Before("gorm:query").Register("database:timeout_before_query", before); err != nil {
+		return err
+	}
+	if err := callbacks.Query().After("gorm:after_query").Register("database:timeout_after_query", after); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:begin_transaction").Register("database:timeout_before_update", before); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:commit_or_rollback_transaction").Register("database:timeout_after_update", after); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:begin_transaction").Register("database:timeout_before_delete", before); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().After("gorm:commit_or_rollback_transaction").Register("database:timeout_after_delete", after); err != nil {
+		return err
+	}
+	if err := callbacks.Raw().Before("gorm:raw").Register("database:timeout_before_raw", before); err != nil {
+		return err
+	}
+	return callbacks.Raw().After("gorm:raw").Register("database:timeout_after_raw", after)
+}
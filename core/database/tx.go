@@ -0,0 +1,109 @@
+package database
+
+import (
+	"context"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+type txStateKey struct{}
+
+// txState tracks the open transaction or savepoint and the callbacks that should only run once
+// the outermost transaction commits
+type txState struct {
+	tx    *gorm.DB
+	mu    sync.Mutex
+	funcs []func()
+}
+
+func (s *txState) add(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.funcs = append(s.funcs, fn)
+}
+
+// take removes and returns the callbacks
+func (s *txState) take() []func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	funcs := s.funcs
+	s.funcs = nil
+	return funcs
+}
+
+func (s *txState) run() {
+	for _, fn := range s.take() {
+		fn()
+	}
+}
+
+// TxManager runs units of work inside a database transaction
+type TxManager struct {
+	db *gorm.DB
+}
+
+// NewTxManager creates a transaction manager for the given connection
+func NewTxManager(db *gorm.DB) *TxManager {
+	return &TxManager{db: db}
+}
+
+// WithTx runs fn inside a transaction. The transaction is rolled back when fn returns an
+// error or panics, and committed otherwise. Passing tx.Statement.Context from an open
+// transaction nests the call in a savepoint, and AfterCommit callbacks wait for the
+// outermost commit; those of a savepoint rolled back are dropped.
+func (m *TxManager) WithTx(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if parent, nested := ctx.Value(txStateKey{}).(*txState); nested && parent.tx != nil {
+		state := &txState{}
+		ctx = context.WithValue(ctx, txStateKey{}, state)
+		err := parent.tx.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			state.tx = tx
+			return fn(tx)
+		})
+		state.tx = nil
+		if err != nil {
+			return err
+		}
+
+		// The savepoint is released, so its callbacks wait for the transaction around it
+		for _, fn := range state.take() {
+			parent.add(fn)
+		}
+		return nil
+	}
+
+	state := &txState{}
+	ctx = context.WithValue(ctx, txStateKey{}, state)
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		state.tx = tx
+		return fn(tx)
+	})
+	state.tx = nil
+	if err != nil {
+		return err
+	}
+
+	state.run()
+	return nil
+}
+
+// WithTx runs fn inside a transaction on this database
+func (d *Database) WithTx(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	return NewTxManager(d.DB).WithTx(ctx, fn)
+}
+
+// AfterCommit defers fn until the transaction tx belongs to has committed; it is dropped
+// if the transaction rolls back. Outside of WithTx, fn runs immediately.
+func AfterCommit(tx *gorm.DB, fn func()) {
+	if tx != nil && tx.Statement != nil && tx.Statement.Context != nil {
+		if state, ok := tx.Statement.Context.Value(txStateKey{}).(*txState); ok {
+			state.add(fn)
+			return
+		}
+	}
+	fn()
+}
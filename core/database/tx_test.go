@@ -0,0 +1,123 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"base/core/database"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type item struct {
+	Id   uint `gorm:"primaryKey"`
+	Name string
+}
+
+func openDatabase(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "tx.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err := db.AutoMigrate(&item{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func TestAfterCommitDropsRolledBackSavepoints(t *testing.T) {
+	db := openDatabase(t)
+	manager := database.NewTxManager(db)
+	var fired []string
+
+	err := manager.WithTx(context.Background(), func(tx *gorm.DB) error {
+		if err := tx.Create(&item{Name: "outer"}).Error; err != nil {
+			return err
+		}
+		database.AfterCommit(tx, func() { fired = append(fired, "outer") })
+
+		rollback := errors.New("rollback")
+		err := manager.WithTx(tx.Statement.Context, func(tx *gorm.DB) error {
+			if err := tx.Create(&item{Name: "rolled back"}).Error; err != nil {
+				return err
+			}
+			database.AfterCommit(tx, func() { fired = append(fired, "rolled back") })
+			return rollback
+		})
+		if !errors.Is(err, rollback) {
+			t.Errorf("rolled back savepoint: err = %v, want %v", err, rollback)
+		}
+
+		if err := manager.WithTx(tx.Statement.Context, func(tx *gorm.DB) error {
+			database.AfterCommit(tx, func() { fired = append(fired, "released") })
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		if len(fired) != 0 {
+			t.Errorf("callbacks ran before the commit: %v", fired)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithTx: %v", err)
+	}
+
+	if want := []string{"outer", "released"}; !slices.Equal(fired, want) {
+		t.Errorf("fired = %v, want %v", fired, want)
+	}
+	var names []string
+	db.Model(&item{}).Order("id").Pluck("name", &names)
+	if want := []string{"outer"}; !slices.Equal(names, want) {
+		t.Errorf("items = %v, want %v", names, want)
+	}
+}
+
+func TestAfterCommitWaitsForTheOutermostCommit(t *testing.T) {
+	db := openDatabase(t)
+	manager := database.NewTxManager(db)
+	fired := false
+
+	rollback := errors.New("rollback")
+	err := manager.WithTx(context.Background(), func(tx *gorm.DB) error {
+		return manager.WithTx(tx.Statement.Context, func(tx *gorm.DB) error {
+			database.AfterCommit(tx, func() { fired = true })
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("WithTx: %v", err)
+	}
+	if !fired {
+		t.Errorf("callback of a released savepoint didn't run after the commit")
+	}
+
+	fired = false
+	err = manager.WithTx(context.Background(), func(tx *gorm.DB) error {
+		if err := manager.WithTx(tx.Statement.Context, func(tx *gorm.DB) error {
+			database.AfterCommit(tx, func() { fired = true })
+			return nil
+		}); err != nil {
+			return err
+		}
+		return rollback
+	})
+	if !errors.Is(err, rollback) {
+		t.Fatalf("WithTx: err = %v, want %v", err, rollback)
+	}
+	if fired {
+		t.Errorf("callback of a savepoint ran although the transaction rolled back")
+	}
+}
@@ -19,6 +19,9 @@ func (s *DefaultSender) Send(msg Message) error {
 	fmt.Println("-------------------")
 	fmt.Println(msg.Body)
 	fmt.Println("-------------------")
+	for _, attachment := range msg.Attachments {
+		fmt.Printf("Attachment: %s (%s, %d bytes)\n", attachment.Filename, attachment.ContentType, len(attachment.Data))
+	}
 
 	return nil
 }
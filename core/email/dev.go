@@ -0,0 +1,169 @@
+package email
+
+import (
+	"base/core/config"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CapturedEmail represents an email captured by the DevSender
+type CapturedEmail struct {
+	Id          string               `json:"id"`
+	To          []string             `json:"to"`
+	From        string               `json:"from"`
+	Subject     string               `json:"subject"`
+	Body        string               `json:"body"`
+	IsHTML      bool                 `json:"is_html"`
+	Attachments []CapturedAttachment `json:"attachments,omitempty"`
+	SentAt      time.Time            `json:"sent_at"`
+}
+
+// CapturedAttachment describes an attachment of a captured email; the file itself is not kept
+type CapturedAttachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size"`
+}
+
+// DevSender captures outgoing emails on disk instead of delivering them,
+// so password-reset and invite emails can be inspected during development
+type DevSender struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewDevSender(cfg *config.Config) (*DevSender, error) {
+	path := cfg.EmailDevPath
+	if path == "" {
+		path = config.DefaultEmailDevPath
+	}
+
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create email capture directory: %w", err)
+	}
+
+	return &DevSender{path: path}, nil
+}
+
+func (s *DevSender) Send(msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	captured := CapturedEmail{
+		Id:      strconv.FormatInt(now.UnixNano(), 10),
+		To:      msg.To,
+		From:    msg.From,
+		Subject: msg.Subject,
+		Body:    msg.Body,
+		IsHTML:  msg.IsHTML,
+		SentAt:  now,
+	}
+	for _, attachment := range msg.Attachments {
+		captured.Attachments = append(captured.Attachments, CapturedAttachment{
+			Filename:    attachment.Filename,
+			ContentType: attachment.ContentType,
+			Size:        len(attachment.Data),
+		})
+	}
+
+	data, err := json.MarshalIndent(captured, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode captured email: %w", err)
+	}
+
+	filename := filepath.Join(s.path, captured.Id+".json")
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return fmt.Errorf("failed to write captured email: %w", err)
+	}
+
+	fmt.Printf("Captured email - To: %v, Subject: %s, File: %s\n", msg.To, msg.Subject, filename)
+
+	return nil
+}
+
+// List returns all captured emails, newest first
+func (s *DevSender) List() ([]*CapturedEmail, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	emails := make([]*CapturedEmail, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		captured, err := s.read(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			continue // Skip unreadable files
+		}
+		emails = append(emails, captured)
+	}
+
+	sort.Slice(emails, func(i, j int) bool {
+		return emails[i].SentAt.After(emails[j].SentAt)
+	})
+
+	return emails, nil
+}
+
+// Get returns a single captured email by its id
+func (s *DevSender) Get(id string) (*CapturedEmail, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.read(id)
+}
+
+// Clear removes all captured emails
+func (s *DevSender) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.path)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.path, entry.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// read loads a captured email from disk; ids are numeric so they can't escape the capture directory
+func (s *DevSender) read(id string) (*CapturedEmail, error) {
+	if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid email id: %s", id)
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.path, id+".json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var captured CapturedEmail
+	if err := json.Unmarshal(data, &captured); err != nil {
+		return nil, err
+	}
+
+	return &captured, nil
+}
@@ -0,0 +1,71 @@
+package email
+
+import (
+	"html"
+	"net/http"
+
+	"base/core/router"
+	"base/core/types"
+)
+
+// DevController exposes captured emails for inspection during development.
+// It must never be registered in production.
+type DevController struct {
+	Sender *DevSender
+}
+
+func NewDevController(sender *DevSender) *DevController {
+	return &DevController{
+		Sender: sender,
+	}
+}
+
+func (c *DevController) Routes(router *router.RouterGroup) {
+	router.GET("/emails", c.List)
+	router.DELETE("/emails", c.Clear)
+	router.GET("/emails/:id", c.Get)
+	router.GET("/emails/:id/preview", c.Preview)
+}
+
+// List returns all captured emails, newest first
+func (c *DevController) List(ctx *router.Context) error {
+	emails, err := c.Sender.List()
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to read captured emails: " + err.Error()})
+	}
+
+	return ctx.JSON(http.StatusOK, emails)
+}
+
+// Get returns a single captured email
+func (c *DevController) Get(ctx *router.Context) error {
+	captured, err := c.Sender.Get(ctx.Param("id"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Email not found"})
+	}
+
+	return ctx.JSON(http.StatusOK, captured)
+}
+
+// Preview renders the email body as the recipient would see it
+func (c *DevController) Preview(ctx *router.Context) error {
+	captured, err := c.Sender.Get(ctx.Param("id"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Email not found"})
+	}
+
+	if captured.IsHTML {
+		return ctx.HTML(http.StatusOK, captured.Body)
+	}
+
+	return ctx.HTML(http.StatusOK, "<pre>"+html.EscapeString(captured.Body)+"</pre>")
+}
+
+// Clear removes all captured emails
+func (c *DevController) Clear(ctx *router.Context) error {
+	if err := c.Sender.Clear(); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to clear captured emails: " + err.Error()})
+	}
+
+	return ctx.JSON(http.StatusOK, types.SuccessResponse{Success: true, Message: "Captured emails cleared"})
+}
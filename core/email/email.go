@@ -12,11 +12,19 @@ var (
 )
 
 type Message struct {
-	To      []string
-	From    string
-	Subject string
-	Body    string
-	IsHTML  bool
+	To          []string
+	From        string
+	Subject     string
+	Body        string
+	IsHTML      bool
+	Attachments []Attachment
+}
+
+// Attachment is a file sent with a Message
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
 }
 
 type Sender interface {
@@ -51,6 +59,8 @@ func NewSender(cfg *config.Config) (Sender, error) {
 		return NewSendGridSender(cfg)
 	case "postmark":
 		return NewPostmarkSender(cfg)
+	case "dev", "log":
+		return NewDevSender(cfg)
 	case "default":
 		return NewDefaultSender(cfg)
 	case "":
@@ -2,6 +2,7 @@ package email
 
 import (
 	"base/core/config"
+	"encoding/base64"
 
 	"github.com/keighl/postmark"
 )
@@ -28,6 +29,14 @@ func (s *PostmarkSender) Send(msg Message) error {
 		HtmlBody: msg.Body,
 	}
 
+	for _, attachment := range msg.Attachments {
+		email.Attachments = append(email.Attachments, postmark.Attachment{
+			Name:        attachment.Filename,
+			Content:     base64.StdEncoding.EncodeToString(attachment.Data),
+			ContentType: attachment.ContentType,
+		})
+	}
+
 	if !msg.IsHTML {
 		email.HtmlBody = ""
 	} else {
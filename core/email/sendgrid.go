@@ -2,6 +2,7 @@ package email
 
 import (
 	"base/core/config"
+	"encoding/base64"
 
 	"github.com/sendgrid/sendgrid-go"
 	"github.com/sendgrid/sendgrid-go/helpers/mail"
@@ -29,6 +30,13 @@ func (s *SendGridSender) Send(msg Message) error {
 	}
 
 	email := mail.NewV3MailInit(from, msg.Subject, to, content)
+	for _, attachment := range msg.Attachments {
+		email.AddAttachment(mail.NewAttachment().
+			SetContent(base64.StdEncoding.EncodeToString(attachment.Data)).
+			SetType(attachment.ContentType).
+			SetFilename(attachment.Filename).
+			SetDisposition("attachment"))
+	}
 
 	_, err := s.client.Send(email)
 	return err
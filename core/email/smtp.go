@@ -2,8 +2,11 @@ package email
 
 import (
 	"base/core/config"
+	"encoding/base64"
 	"fmt"
 	"net/smtp"
+	"strings"
+	"time"
 )
 
 type SMTPSender struct {
@@ -37,6 +40,40 @@ func (s *SMTPSender) Send(msg Message) error {
 
 	message := fmt.Sprintf("To: %s\r\nFrom: %s\r\nSubject: %s\r\n%s\r\n\r\n%s",
 		msg.To[0], msg.From, msg.Subject, contentType, msg.Body)
+	if len(msg.Attachments) > 0 {
+		message = multipartMessage(msg, contentType)
+	}
 
 	return smtp.SendMail(addr, auth, s.from, msg.To, []byte(message))
 }
+
+// multipartMessage builds a multipart/mixed message with the body and the attachments
+func multipartMessage(msg Message, contentType string) string {
+	boundary := fmt.Sprintf("base-%d", time.Now().UnixNano())
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "To: %s\r\nFrom: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\n", msg.To[0], msg.From, msg.Subject)
+	fmt.Fprintf(&b, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", boundary)
+	fmt.Fprintf(&b, "--%s\r\n%s\r\n\r\n%s\r\n", boundary, contentType, msg.Body)
+
+	for _, attachment := range msg.Attachments {
+		attachmentType := attachment.ContentType
+		if attachmentType == "" {
+			attachmentType = "application/octet-stream"
+		}
+		fmt.Fprintf(&b, "--%s\r\n", boundary)
+		fmt.Fprintf(&b, "Content-Type: %s; name=%q\r\n", attachmentType, attachment.Filename)
+		fmt.Fprintf(&b, "Content-Disposition: attachment; filename=%q\r\n", attachment.Filename)
+		b.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+
+		// Base64 lines must not exceed 76 characters
+		encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+		for len(encoded) > 76 {
+			b.WriteString(encoded[:76] + "\r\n")
+			encoded = encoded[76:]
+		}
+		b.WriteString(encoded + "\r\n")
+	}
+	fmt.Fprintf(&b, "--%s--\r\n", boundary)
+	return b.String()
+}
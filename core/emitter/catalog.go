@@ -0,0 +1,207 @@
+package emitter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventType describes an event for the catalog: what it means and the payload its listeners
+// get. Modules register their events with RegisterEvents, next to the constants of their
+// names.
+type EventType struct {
+	Name        string // e.g. pages.create
+	Description string
+	Payload     any  // A value of the payload type, e.g. &Page{}; nil for events without one
+	Internal    bool // A hook of the module rather than a notification, e.g. with secrets in its payload; not for webhooks
+}
+
+// Module returns the module of an event, the part of its name before the first dot
+func (t EventType) Module() string {
+	module, _, _ := strings.Cut(t.Name, ".")
+	return module
+}
+
+// Schema returns the JSON schema of the payload, nil for events without one
+func (t EventType) Schema() *Schema {
+	if t.Payload == nil {
+		return nil
+	}
+	return SchemaOf(reflect.TypeOf(t.Payload))
+}
+
+var (
+	eventTypesMu sync.RWMutex
+	eventTypes   = map[string]EventType{}
+)
+
+// RegisterEvents adds events to the catalog, e.g. from a module's Init
+func RegisterEvents(types ...EventType) {
+	eventTypesMu.Lock()
+	defer eventTypesMu.Unlock()
+	for _, eventType := range types {
+		eventTypes[eventType.Name] = eventType
+	}
+}
+
+// EventTypes returns the events of the catalog sorted by name
+func EventTypes() []EventType {
+	eventTypesMu.RLock()
+	defer eventTypesMu.RUnlock()
+	result := make([]EventType, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		result = append(result, eventType)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// LookupEvent returns an event of the catalog
+func LookupEvent(name string) (EventType, bool) {
+	eventTypesMu.RLock()
+	defer eventTypesMu.RUnlock()
+	eventType, ok := eventTypes[name]
+	return eventType, ok
+}
+
+// Listen registers a listener of an event with a typed payload. Payloads of another type are
+// logged and skipped, so listeners don't assert the type themselves:
+//
+//	emitter.Listen(e, payments.SucceededPaymentEvent, func(ctx context.Context, payment *payments.Payment) { ... })
+func Listen[T any](e *Emitter, event string, listener func(ctx context.Context, payload T)) {
+	e.add(event, funcName(listener), func(ctx context.Context, data any) error {
+		payload, ok := data.(T)
+		if !ok {
+			fmt.Printf("Listener of event %s expects %T, got %T\n", event, *new(T), data)
+			return nil
+		}
+		listener(ctx, payload)
+		return nil
+	})
+}
+
+// Handle registers a listener of an event with a typed payload that can fail, see
+// Emitter.Handle. Payloads of another type fail.
+func Handle[T any](e *Emitter, event string, handler func(ctx context.Context, payload T) error) {
+	e.add(event, funcName(handler), func(ctx context.Context, data any) error {
+		payload, ok := data.(T)
+		if !ok {
+			return fmt.Errorf("listener expects %T, got %T", *new(T), data)
+		}
+		return handler(ctx, payload)
+	})
+}
+
+// checkPayload logs events emitted with another payload than their catalog entry, which
+// would break the listeners and the schema webhooks were configured with
+func checkPayload(event string, data any) {
+	eventType, ok := LookupEvent(event)
+	if !ok || eventType.Payload == nil || data == nil {
+		return
+	}
+	if expected := reflect.TypeOf(eventType.Payload); reflect.TypeOf(data) != expected {
+		fmt.Printf("Event %s emitted with %T instead of %s\n", event, data, expected)
+	}
+}
+
+// Schema is the JSON schema of a payload
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// SchemaOf returns the JSON schema of the values of a type as encoding/json writes them
+func SchemaOf(t reflect.Type) *Schema {
+	return schemaOf(t, map[reflect.Type]bool{})
+}
+
+// schemaOf returns the schema of a type; seen holds the structs being described, whose
+// recursive fields are left as plain objects
+func schemaOf(t reflect.Type, seen map[reflect.Type]bool) *Schema {
+	if t.Kind() == reflect.Pointer {
+		schema := schemaOf(t.Elem(), seen)
+		schema.Nullable = true
+		return schema
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType):
+		// Custom encodings, e.g. gorm.DeletedAt, aren't known from the type
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: schemaOf(t.Elem(), seen)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaOf(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return &Schema{Type: "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		addFields(schema, t, seen)
+		return schema
+	}
+	return &Schema{}
+}
+
+// addFields adds the JSON fields of a struct to a schema, with those of embedded structs
+func addFields(schema *Schema, t reflect.Type, seen map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				addFields(schema, embedded, seen)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = schemaOf(field.Type, seen)
+	}
+}
@@ -2,45 +2,130 @@ package emitter
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"base/core/explain"
 )
 
+// Listener handles an event. The context is the one the event was emitted with, without its
+// cancellation (see EmitContext).
+type Listener func(ctx context.Context, data any)
+
+// Handler handles an event and reports whether it failed; failures are passed to the failure
+// handler of the emitter, e.g. to keep them for a retry
+type Handler func(ctx context.Context, data any) error
+
+// FailureHandler gets the failures of listeners: the errors they returned and their panics
+type FailureHandler func(ctx context.Context, failure *Failure)
+
+// Failure is a listener that returned an error or panicked
+type Failure struct {
+	Event    string
+	Listener string // Name of the listener, see Retry
+	Data     any
+	Err      error
+	Panic    bool
+}
+
+// ErrUnknownListener is returned for retries of listeners that aren't registered, e.g. since
+// the code changed
+var ErrUnknownListener = errors.New("listener not registered")
+
+// listener is a registered handler with its name, the function it was registered with
+type listener struct {
+	name    string
+	handler Handler
+}
+
 type Emitter struct {
-	listeners map[string][]func(any)
+	listeners map[string][]listener
 	mutex     sync.RWMutex
+	onFailure atomic.Pointer[FailureHandler]
+	metrics   *Metrics
 }
 
 func New() *Emitter {
 	return &Emitter{
-		listeners: make(map[string][]func(any)),
+		listeners: make(map[string][]listener),
+		metrics:   newMetrics(),
 	}
 }
 
 func (e *Emitter) On(event string, listener func(any)) {
+	e.add(event, funcName(listener), func(_ context.Context, data any) error {
+		listener(data)
+		return nil
+	})
+}
+
+// OnContext registers a listener that gets the context of the event, e.g. to run its queries
+// with the request's logger and query stats
+func (e *Emitter) OnContext(event string, listener Listener) {
+	e.add(event, funcName(listener), func(ctx context.Context, data any) error {
+		listener(ctx, data)
+		return nil
+	})
+}
+
+// Handle registers a listener that can fail, e.g. sending an email. Its errors go to the
+// failure handler instead of being logged by the listener.
+func (e *Emitter) Handle(event string, handler Handler) {
+	e.add(event, funcName(handler), handler)
+}
+
+// OnFailure sets the handler of the listener failures; they are printed without one
+func (e *Emitter) OnFailure(handler FailureHandler) {
+	e.onFailure.Store(&handler)
+}
+
+// add registers a handler under the name of the function it was registered with. Functions
+// registered more than once for an event get a #2, #3... suffix, so every listener of an
+// event can be told apart.
+func (e *Emitter) add(event, name string, handler Handler) {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
-	e.listeners[event] = append(e.listeners[event], listener)
+	unique, n := name, 1
+	for slices.ContainsFunc(e.listeners[event], func(l listener) bool { return l.name == unique }) {
+		n++
+		unique = fmt.Sprintf("%s#%d", name, n)
+	}
+	e.listeners[event] = append(e.listeners[event], listener{name: unique, handler: handler})
 }
 
 func (e *Emitter) Emit(event string, data any) {
+	e.EmitContext(context.Background(), event, data)
+}
+
+// EmitContext emits an event of a request and waits for its listeners. They get ctx without
+// its cancellation: the change they react to is done, so they finish even when the client
+// disconnects. The event is recorded in the debug report of the request, if any.
+func (e *Emitter) EmitContext(ctx context.Context, event string, data any) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx = context.WithoutCancel(ctx)
+	explain.FromContext(ctx).Event(event)
+	checkPayload(event, data)
+
 	e.mutex.RLock()
 	defer e.mutex.RUnlock()
 
 	// Use a WaitGroup to wait for all listeners to finish
 	var wg sync.WaitGroup
-	for _, listener := range e.listeners[event] {
+	for _, l := range e.listeners[event] {
 		wg.Add(1)
-		go func(listener func(any)) {
+		go func(l listener) {
 			defer wg.Done()
-			defer func() {
-				if r := recover(); r != nil {
-					fmt.Printf("Recovered from panic in listener for event %s: %v\n", event, r)
-				}
-			}()
-			listener(data)
-		}(listener)
+			e.run(ctx, event, l, data, "listener")
+		}(l)
 	}
 	wg.Wait() // Block until all listeners complete
 }
@@ -48,51 +133,35 @@ func (e *Emitter) Emit(event string, data any) {
 func (e *Emitter) Clear() {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
-	e.listeners = make(map[string][]func(any))
+	e.listeners = make(map[string][]listener)
 }
 
 // EmitAsync emits an event asynchronously without blocking
 func (e *Emitter) EmitAsync(event string, data any) {
-	e.mutex.RLock()
-	listeners := make([]func(any), len(e.listeners[event]))
-	copy(listeners, e.listeners[event])
-	e.mutex.RUnlock()
+	checkPayload(event, data)
+	listeners := e.listenersOf(event)
 
 	// Fire and forget - don't wait for listeners
-	for _, listener := range listeners {
-		go func(listener func(any)) {
-			defer func() {
-				if r := recover(); r != nil {
-					fmt.Printf("Recovered from panic in async listener for event %s: %v\n", event, r)
-				}
-			}()
-			listener(data)
-		}(listener)
+	for _, l := range listeners {
+		go e.run(context.Background(), event, l, data, "async listener")
 	}
 }
 
 // EmitWithContext emits an event with context support
 func (e *Emitter) EmitWithContext(ctx context.Context, event string, data any) error {
-	e.mutex.RLock()
-	listeners := make([]func(any), len(e.listeners[event]))
-	copy(listeners, e.listeners[event])
-	e.mutex.RUnlock()
+	checkPayload(event, data)
+	listeners := e.listenersOf(event)
 
 	// Create a channel to signal completion
 	done := make(chan struct{})
 	var wg sync.WaitGroup
 
-	for _, listener := range listeners {
+	for _, l := range listeners {
 		wg.Add(1)
-		go func(listener func(any)) {
+		go func(l listener) {
 			defer wg.Done()
-			defer func() {
-				if r := recover(); r != nil {
-					fmt.Printf("Recovered from panic in context listener for event %s: %v\n", event, r)
-				}
-			}()
-			listener(data)
-		}(listener)
+			e.run(ctx, event, l, data, "context listener")
+		}(l)
 	}
 
 	go func() {
@@ -115,6 +184,18 @@ func (e *Emitter) EmitWithTimeout(event string, data any, timeout time.Duration)
 	return e.EmitWithContext(ctx, event, data)
 }
 
+// Retry runs a listener of an event again, e.g. with the payload of a failure, and returns
+// its error. The failure handler isn't called: the caller keeps track of the outcome.
+func (e *Emitter) Retry(ctx context.Context, event, name string, data any) error {
+	for _, l := range e.listenersOf(event) {
+		if l.name == name {
+			_, err := e.call(context.WithoutCancel(ctx), event, l, data)
+			return err
+		}
+	}
+	return ErrUnknownListener
+}
+
 // ListenerCount returns the number of listeners for an event
 func (e *Emitter) ListenerCount(event string) int {
 	e.mutex.RLock()
@@ -122,6 +203,16 @@ func (e *Emitter) ListenerCount(event string) int {
 	return len(e.listeners[event])
 }
 
+// ListenerNames returns the names of the listeners of an event, in the order they run
+func (e *Emitter) ListenerNames(event string) []string {
+	listeners := e.listenersOf(event)
+	names := make([]string, len(listeners))
+	for i, l := range listeners {
+		names[i] = l.name
+	}
+	return names
+}
+
 // EventNames returns all registered event names
 func (e *Emitter) EventNames() []string {
 	e.mutex.RLock()
@@ -133,3 +224,59 @@ func (e *Emitter) EventNames() []string {
 	}
 	return names
 }
+
+// Metrics returns the latency and failure metrics of the listeners
+func (e *Emitter) Metrics() *Metrics {
+	return e.metrics
+}
+
+// listenersOf returns a copy of the listeners of an event
+func (e *Emitter) listenersOf(event string) []listener {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	listeners := make([]listener, len(e.listeners[event]))
+	copy(listeners, e.listeners[event])
+	return listeners
+}
+
+// run calls a listener and reports its failure; kind names the listener in the messages
+// printed without a failure handler
+func (e *Emitter) run(ctx context.Context, event string, l listener, data any, kind string) {
+	panicked, err := e.call(ctx, event, l, data)
+	if err == nil {
+		return
+	}
+
+	onFailure := e.onFailure.Load()
+	if onFailure == nil || *onFailure == nil {
+		if panicked {
+			fmt.Printf("Recovered from panic in %s for event %s: %v\n", kind, event, err)
+		} else {
+			fmt.Printf("Error in %s for event %s: %v\n", kind, event, err)
+		}
+		return
+	}
+	(*onFailure)(ctx, &Failure{Event: event, Listener: l.name, Data: data, Err: err, Panic: panicked})
+}
+
+// call runs a listener, turning its panic into an error, and records its duration and
+// outcome in the metrics
+func (e *Emitter) call(ctx context.Context, event string, l listener, data any) (panicked bool, err error) {
+	started := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			panicked, err = true, fmt.Errorf("%v", r)
+		}
+		e.metrics.observe(event, time.Since(started), err != nil)
+	}()
+	return false, l.handler(ctx, data)
+}
+
+// funcName returns the name of a function, e.g. base/app/orders.(*OrderService).Listen.func1
+func funcName(fn any) string {
+	f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer())
+	if f == nil {
+		return "unknown"
+	}
+	return strings.TrimSuffix(f.Name(), "-fm")
+}
@@ -0,0 +1,96 @@
+package emitter
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// durationBuckets are the histogram upper bounds of listener durations in seconds
+var durationBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// histogram is a cumulative histogram of listener durations with the failures of an event
+type histogram struct {
+	buckets  []uint64
+	count    uint64
+	sum      float64
+	failures uint64
+}
+
+// Metrics collects the durations and failures of listeners per event
+type Metrics struct {
+	mu         sync.Mutex
+	histograms map[string]*histogram
+}
+
+func newMetrics() *Metrics {
+	return &Metrics{histograms: make(map[string]*histogram)}
+}
+
+// observe records a listener call
+func (m *Metrics) observe(event string, duration time.Duration, failed bool) {
+	seconds := duration.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, exists := m.histograms[event]
+	if !exists {
+		h = &histogram{buckets: make([]uint64, len(durationBuckets))}
+		m.histograms[event] = h
+	}
+	for i, bound := range durationBuckets {
+		if seconds <= bound {
+			h.buckets[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+	if failed {
+		h.failures++
+	}
+}
+
+// Write writes the listener duration histograms and failure counters in the Prometheus text
+// exposition format; the failure rate of an event is its failures over its calls
+func (m *Metrics) Write(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.histograms) == 0 {
+		return nil
+	}
+
+	events := make([]string, 0, len(m.histograms))
+	for event := range m.histograms {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+
+	fmt.Fprintln(w, "# HELP event_listener_duration_seconds Duration of event listeners.")
+	fmt.Fprintln(w, "# TYPE event_listener_duration_seconds histogram")
+	for _, event := range events {
+		h := m.histograms[event]
+		for i, bound := range durationBuckets {
+			fmt.Fprintf(w, "event_listener_duration_seconds_bucket{event=%q,le=%q} %d\n", event, formatFloat(bound), h.buckets[i])
+		}
+		fmt.Fprintf(w, "event_listener_duration_seconds_bucket{event=%q,le=\"+Inf\"} %d\n", event, h.count)
+		fmt.Fprintf(w, "event_listener_duration_seconds_sum{event=%q} %s\n", event, formatFloat(h.sum))
+		fmt.Fprintf(w, "event_listener_duration_seconds_count{event=%q} %d\n", event, h.count)
+	}
+
+	fmt.Fprintln(w, "# HELP event_listener_failures_total Event listeners that returned an error or panicked.")
+	fmt.Fprintln(w, "# TYPE event_listener_failures_total counter")
+	for _, event := range events {
+		if _, err := fmt.Fprintf(w, "event_listener_failures_total{event=%q} %d\n", event, m.histograms[event].failures); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
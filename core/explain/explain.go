@@ -0,0 +1,167 @@
+// Package explain collects what happened while serving a request in debug mode: the queries
+// run, the cache lookups, the authorization decisions and the events emitted. Admins turn it
+// on with ?_debug=1 and get the report in the meta of the response, e.g. to find out why a
+// record is missing from a filtered list.
+//
+// Packages record into the report of the request context; the methods do nothing without
+// one, so call sites don't check whether debug mode is on:
+//
+//	explain.FromContext(ctx).Event(event)
+package explain
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// maxEntries bounds each list of a report, e.g. for imports running thousands of queries
+const maxEntries = 200
+
+type reportKey struct{}
+
+// Query is a query run while serving the request
+type Query struct {
+	SQL      string  `json:"sql"`
+	Rows     int64   `json:"rows"`
+	Duration float64 `json:"duration_ms"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// CacheLookup is a lookup in a cache: hit, miss or bypass
+type CacheLookup struct {
+	Cache  string `json:"cache"` // e.g. responses or counts
+	Key    string `json:"key"`
+	Result string `json:"result"`
+}
+
+// Decision is an authorization check and its outcome
+type Decision struct {
+	Check   string `json:"check"` // e.g. role or grant
+	Subject string `json:"subject"`
+	Allowed bool   `json:"allowed"`
+}
+
+// Report is what happened while serving a request. It is safe for concurrent use, e.g. by
+// event listeners.
+type Report struct {
+	mu        sync.Mutex
+	started   time.Time
+	queries   []Query
+	count     int
+	duration  time.Duration
+	cache     []CacheLookup
+	decisions []Decision
+	events    []string
+	truncated bool
+}
+
+// Summary is the report as it is sent in the meta of a response
+type Summary struct {
+	Duration  float64       `json:"duration_ms"` // Since debug mode was turned on
+	Queries   QuerySummary  `json:"queries"`
+	Cache     []CacheLookup `json:"cache"`
+	Decisions []Decision    `json:"authorization"`
+	Events    []string      `json:"events"`
+	Truncated bool          `json:"truncated,omitempty"` // Lists were cut at their first entries
+}
+
+// QuerySummary counts the queries and lists them in the order they ran
+type QuerySummary struct {
+	Count    int     `json:"count"`
+	Duration float64 `json:"duration_ms"`
+	List     []Query `json:"list"`
+}
+
+// With returns a context collecting a report
+func With(ctx context.Context) (context.Context, *Report) {
+	report := &Report{started: time.Now()}
+	return context.WithValue(ctx, reportKey{}, report), report
+}
+
+// FromContext returns the report of the context, nil outside debug mode
+func FromContext(ctx context.Context) *Report {
+	if ctx == nil {
+		return nil
+	}
+	report, _ := ctx.Value(reportKey{}).(*Report)
+	return report
+}
+
+// Query records a query
+func (r *Report) Query(sql string, rows int64, duration time.Duration, err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.count++
+	r.duration += duration
+	query := Query{SQL: sql, Rows: rows, Duration: milliseconds(duration)}
+	if err != nil {
+		query.Error = err.Error()
+	}
+	r.queries = add(r, r.queries, query)
+}
+
+// Cache records a cache lookup
+func (r *Report) Cache(cache, key, result string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cache = add(r, r.cache, CacheLookup{Cache: cache, Key: key, Result: result})
+}
+
+// Decision records an authorization check, e.g. Decision("role", "user 3 in Super Admin", true)
+func (r *Report) Decision(check, subject string, allowed bool) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.decisions = add(r, r.decisions, Decision{Check: check, Subject: subject, Allowed: allowed})
+}
+
+// Event records an emitted event
+func (r *Report) Event(event string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = add(r, r.events, event)
+}
+
+// Summary returns the report so far
+func (r *Report) Summary() *Summary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &Summary{
+		Duration: milliseconds(time.Since(r.started)),
+		Queries: QuerySummary{
+			Count:    r.count,
+			Duration: milliseconds(r.duration),
+			List:     append([]Query{}, r.queries...),
+		},
+		Cache:     append([]CacheLookup{}, r.cache...),
+		Decisions: append([]Decision{}, r.decisions...),
+		Events:    append([]string{}, r.events...),
+		Truncated: r.truncated,
+	}
+}
+
+// add appends an entry to a list of the report unless it is full. The caller holds the
+// mutex.
+func add[T any](r *Report, list []T, entry T) []T {
+	if len(list) >= maxEntries {
+		r.truncated = true
+		return list
+	}
+	return append(list, entry)
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,272 @@
+package factory
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"base/core/app/activities"
+	"base/core/app/media"
+	"base/core/app/notifications"
+	"base/core/app/settings"
+	"base/core/app/users"
+	"base/core/features"
+
+	"gorm.io/gorm"
+)
+
+// MediaFactory builds media.Media
+type MediaFactory struct {
+	builder[media.Media]
+}
+
+// Media returns a factory of image media items without a file, at the root
+func Media() *MediaFactory {
+	return &MediaFactory{newBuilder(func(n int64) media.Media {
+		return media.Media{
+			Name:        fmt.Sprintf("Image %d", n),
+			Type:        "image",
+			Description: "Created by a factory",
+			Tags:        pick(n, "product", "banner", "team", "blog"),
+		}
+	})}
+}
+
+// Folder returns a factory of media folders with unique names, at the root
+func Folder() *MediaFactory {
+	return &MediaFactory{newBuilder(func(n int64) media.Media {
+		name := fmt.Sprintf("Folder %d", n)
+		return media.Media{Name: name, Type: "folder", Folder: name}
+	})}
+}
+
+// With changes the media item before it is saved
+func (f *MediaFactory) With(fn func(*media.Media)) *MediaFactory {
+	f.with(fn)
+	return f
+}
+
+// WithType sets the type, e.g. "image", "video", "audio" or "other"
+func (f *MediaFactory) WithType(mediaType string) *MediaFactory {
+	return f.With(func(m *media.Media) { m.Type = mediaType })
+}
+
+// In puts the media item in a folder
+func (f *MediaFactory) In(folder *media.Media) *MediaFactory {
+	return f.With(func(m *media.Media) {
+		m.ParentId = &folder.Id
+		if m.Type == "folder" {
+			m.Folder = folder.Folder + "/" + m.Name
+		} else {
+			m.Folder = folder.Folder
+		}
+	})
+}
+
+// ByAuthor sets the user who owns the media item
+func (f *MediaFactory) ByAuthor(user *users.User) *MediaFactory {
+	return f.With(func(m *media.Media) { m.AuthorId = &user.Id })
+}
+
+// Build returns a media item without saving it
+func (f *MediaFactory) Build() *media.Media {
+	return f.build()
+}
+
+// Create saves a media item
+func (f *MediaFactory) Create(db *gorm.DB) (*media.Media, error) {
+	return f.create(db)
+}
+
+// CreateMany saves n media items
+func (f *MediaFactory) CreateMany(db *gorm.DB, n int) ([]*media.Media, error) {
+	return f.createMany(db, n)
+}
+
+// ActivityFactory builds activities.Activity
+type ActivityFactory struct {
+	builder[activities.Activity]
+}
+
+// Activity returns a factory of activities on products, cycling through the common actions.
+// Use ForUser to set who performed them.
+func Activity() *ActivityFactory {
+	return &ActivityFactory{newBuilder(func(n int64) activities.Activity {
+		action := pick(n, "create", "update", "update", "delete", "login")
+		return activities.Activity{
+			EntityType:  "product",
+			EntityId:    uint(n),
+			Action:      action,
+			Description: fmt.Sprintf("%s product %d", action, n),
+			Metadata:    json.RawMessage(`{}`),
+			IpAddress:   fmt.Sprintf("192.0.2.%d", n%254+1),
+			UserAgent:   "factory",
+		}
+	})}
+}
+
+// With changes the activity before it is saved
+func (f *ActivityFactory) With(fn func(*activities.Activity)) *ActivityFactory {
+	f.with(fn)
+	return f
+}
+
+// ForUser sets the user who performed the activity
+func (f *ActivityFactory) ForUser(user *users.User) *ActivityFactory {
+	return f.With(func(a *activities.Activity) { a.UserId = user.Id })
+}
+
+// On sets the entity the activity was performed on
+func (f *ActivityFactory) On(entityType string, entityId uint) *ActivityFactory {
+	return f.With(func(a *activities.Activity) { a.EntityType, a.EntityId = entityType, entityId })
+}
+
+// Build returns an activity without saving it
+func (f *ActivityFactory) Build() *activities.Activity {
+	return f.build()
+}
+
+// Create saves an activity
+func (f *ActivityFactory) Create(db *gorm.DB) (*activities.Activity, error) {
+	return f.create(db)
+}
+
+// CreateMany saves n activities
+func (f *ActivityFactory) CreateMany(db *gorm.DB, n int) ([]*activities.Activity, error) {
+	return f.createMany(db, n)
+}
+
+// NotificationFactory builds notifications.Notification
+type NotificationFactory struct {
+	builder[notifications.Notification]
+}
+
+// Notification returns a factory of unread notifications. Use ForUser to set the recipient.
+func Notification() *NotificationFactory {
+	return &NotificationFactory{newBuilder(func(n int64) notifications.Notification {
+		return notifications.Notification{
+			Title:     fmt.Sprintf("Notification %d", n),
+			Body:      "Created by a factory",
+			Type:      pick(n, "info", "success", "warning"),
+			ActionUrl: "/dashboard",
+		}
+	})}
+}
+
+// With changes the notification before it is saved
+func (f *NotificationFactory) With(fn func(*notifications.Notification)) *NotificationFactory {
+	f.with(fn)
+	return f
+}
+
+// ForUser sets the recipient
+func (f *NotificationFactory) ForUser(user *users.User) *NotificationFactory {
+	return f.With(func(n *notifications.Notification) { n.UserId = user.Id })
+}
+
+// Read marks the notification as read
+func (f *NotificationFactory) Read() *NotificationFactory {
+	return f.With(func(n *notifications.Notification) { n.Read = true })
+}
+
+// Build returns a notification without saving it
+func (f *NotificationFactory) Build() *notifications.Notification {
+	return f.build()
+}
+
+// Create saves a notification
+func (f *NotificationFactory) Create(db *gorm.DB) (*notifications.Notification, error) {
+	return f.create(db)
+}
+
+// CreateMany saves n notifications
+func (f *NotificationFactory) CreateMany(db *gorm.DB, n int) ([]*notifications.Notification, error) {
+	return f.createMany(db, n)
+}
+
+// SettingFactory builds settings.Settings
+type SettingFactory struct {
+	builder[settings.Settings]
+}
+
+// Setting returns a factory of string settings with unique keys
+func Setting() *SettingFactory {
+	return &SettingFactory{newBuilder(func(n int64) settings.Settings {
+		key := fmt.Sprintf("setting_%d", n)
+		return settings.Settings{
+			SettingKey:  key,
+			Label:       fmt.Sprintf("Setting %d", n),
+			Group:       "general",
+			Type:        "string",
+			ValueString: "value",
+		}
+	})}
+}
+
+// With changes the setting before it is saved
+func (f *SettingFactory) With(fn func(*settings.Settings)) *SettingFactory {
+	f.with(fn)
+	return f
+}
+
+// WithValue sets the key and a string value
+func (f *SettingFactory) WithValue(key, value string) *SettingFactory {
+	return f.With(func(s *settings.Settings) { s.SettingKey, s.Type, s.ValueString = key, "string", value })
+}
+
+// Build returns a setting without saving it
+func (f *SettingFactory) Build() *settings.Settings {
+	return f.build()
+}
+
+// Create saves a setting
+func (f *SettingFactory) Create(db *gorm.DB) (*settings.Settings, error) {
+	return f.create(db)
+}
+
+// CreateMany saves n settings
+func (f *SettingFactory) CreateMany(db *gorm.DB, n int) ([]*settings.Settings, error) {
+	return f.createMany(db, n)
+}
+
+// FlagFactory builds features.Flag
+type FlagFactory struct {
+	builder[features.Flag]
+}
+
+// Flag returns a factory of enabled feature flags for every user, with unique keys
+func Flag() *FlagFactory {
+	return &FlagFactory{newBuilder(func(n int64) features.Flag {
+		return features.Flag{
+			Key:         fmt.Sprintf("feature_%d", n),
+			Description: "Created by a factory",
+			Enabled:     true,
+			Percentage:  100,
+		}
+	})}
+}
+
+// With changes the flag before it is saved
+func (f *FlagFactory) With(fn func(*features.Flag)) *FlagFactory {
+	f.with(fn)
+	return f
+}
+
+// Disabled turns the flag off
+func (f *FlagFactory) Disabled() *FlagFactory {
+	return f.With(func(flag *features.Flag) { flag.Enabled = false })
+}
+
+// Build returns a flag without saving it
+func (f *FlagFactory) Build() *features.Flag {
+	return f.build()
+}
+
+// Create saves a flag
+func (f *FlagFactory) Create(db *gorm.DB) (*features.Flag, error) {
+	return f.create(db)
+}
+
+// CreateMany saves n flags
+func (f *FlagFactory) CreateMany(db *gorm.DB, n int) ([]*features.Flag, error) {
+	return f.createMany(db, n)
+}
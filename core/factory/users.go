@@ -0,0 +1,222 @@
+package factory
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"base/core/app/authorization"
+	"base/core/app/users"
+
+	"gorm.io/gorm"
+)
+
+// DefaultPassword is the password of the users built by User, unless WithPassword is used
+const DefaultPassword = "password123"
+
+var (
+	firstNames = []string{"Ada", "Grace", "Alan", "Linus", "Margaret", "Dennis", "Barbara", "Ken"}
+	lastNames  = []string{"Lovelace", "Hopper", "Turing", "Torvalds", "Hamilton", "Ritchie", "Liskov", "Thompson"}
+)
+
+// UserFactory builds users.User
+type UserFactory struct {
+	builder[users.User]
+	password string
+}
+
+// User returns a factory of users with unique usernames and email addresses, the password
+// DefaultPassword and the database's default role
+func User() *UserFactory {
+	f := &UserFactory{password: DefaultPassword}
+	f.builder = newBuilder(func(n int64) users.User {
+		first, last := pick(n, firstNames...), pick(n/int64(len(firstNames)), lastNames...)
+		username := fmt.Sprintf("%s.%s.%d", strings.ToLower(first), strings.ToLower(last), n)
+		return users.User{
+			FirstName: first,
+			LastName:  last,
+			Username:  username,
+			Email:     username + "@example.com",
+		}
+	})
+	f.beforeCreate(func(db *gorm.DB, user *users.User) error {
+		if user.Password != "" {
+			return nil
+		}
+		hash, err := hashPassword(f.password)
+		user.Password = hash
+		return err
+	})
+	return f
+}
+
+// With changes the user before it is saved
+func (f *UserFactory) With(fn func(*users.User)) *UserFactory {
+	f.with(fn)
+	return f
+}
+
+// WithEmail sets the email address
+func (f *UserFactory) WithEmail(email string) *UserFactory {
+	return f.With(func(user *users.User) { user.Email = email })
+}
+
+// WithPassword sets the plain password; it is hashed when the user is saved
+func (f *UserFactory) WithPassword(password string) *UserFactory {
+	f.password = password
+	return f
+}
+
+// WithRole gives the user a role by name, ignoring case, e.g. "Super Admin". The role must
+// exist when the user is saved.
+func (f *UserFactory) WithRole(name string) *UserFactory {
+	var role *authorization.Role
+	f.beforeCreate(func(db *gorm.DB, user *users.User) error {
+		var err error
+		role, err = findRole(db, name)
+		if err != nil {
+			return err
+		}
+		user.RoleId = role.Id
+		return nil
+	})
+	// Set after saving, so GORM doesn't save the role again
+	f.afterCreate(func(db *gorm.DB, user *users.User) error {
+		user.Role = role
+		return nil
+	})
+	return f
+}
+
+// Build returns a user without saving it; its password isn't set
+func (f *UserFactory) Build() *users.User {
+	return f.build()
+}
+
+// Create saves a user
+func (f *UserFactory) Create(db *gorm.DB) (*users.User, error) {
+	return f.create(db)
+}
+
+// CreateMany saves n users
+func (f *UserFactory) CreateMany(db *gorm.DB, n int) ([]*users.User, error) {
+	return f.createMany(db, n)
+}
+
+// findRole returns a role by name, ignoring case
+func findRole(db *gorm.DB, name string) (*authorization.Role, error) {
+	var role authorization.Role
+	if err := db.Where("LOWER(name) = ?", strings.ToLower(name)).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("factory: no role %q", name)
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+// RoleFactory builds authorization.Role
+type RoleFactory struct {
+	builder[authorization.Role]
+}
+
+// Role returns a factory of custom (non-system) roles with unique names
+func Role() *RoleFactory {
+	return &RoleFactory{newBuilder(func(n int64) authorization.Role {
+		return authorization.Role{
+			Name:        fmt.Sprintf("Role %d", n),
+			Description: "Created by a factory",
+		}
+	})}
+}
+
+// With changes the role before it is saved
+func (f *RoleFactory) With(fn func(*authorization.Role)) *RoleFactory {
+	f.with(fn)
+	return f
+}
+
+// WithName sets the name
+func (f *RoleFactory) WithName(name string) *RoleFactory {
+	return f.With(func(role *authorization.Role) { role.Name = name })
+}
+
+// WithPermissions grants the role existing permissions, given as "resource:action", e.g.
+// "product:list", once it is saved
+func (f *RoleFactory) WithPermissions(permissions ...string) *RoleFactory {
+	f.afterCreate(func(db *gorm.DB, role *authorization.Role) error {
+		for _, permission := range permissions {
+			resource, action, _ := strings.Cut(permission, ":")
+			var p authorization.Permission
+			if err := db.Where("resource_type = ? AND action = ?", resource, action).First(&p).Error; err != nil {
+				return fmt.Errorf("factory: no permission %q: %w", permission, err)
+			}
+			if err := db.Create(&authorization.RolePermission{RoleId: role.Id, PermissionId: p.Id}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return f
+}
+
+// Build returns a role without saving it
+func (f *RoleFactory) Build() *authorization.Role {
+	return f.build()
+}
+
+// Create saves a role
+func (f *RoleFactory) Create(db *gorm.DB) (*authorization.Role, error) {
+	return f.create(db)
+}
+
+// CreateMany saves n roles
+func (f *RoleFactory) CreateMany(db *gorm.DB, n int) ([]*authorization.Role, error) {
+	return f.createMany(db, n)
+}
+
+// PermissionFactory builds authorization.Permission
+type PermissionFactory struct {
+	builder[authorization.Permission]
+}
+
+// Permission returns a factory of permissions to read a resource, with unique resource types
+func Permission() *PermissionFactory {
+	return &PermissionFactory{newBuilder(func(n int64) authorization.Permission {
+		resource := fmt.Sprintf("resource_%d", n)
+		return authorization.Permission{
+			Name:         resource + " read",
+			Description:  "Created by a factory",
+			ResourceType: resource,
+			Action:       "read",
+		}
+	})}
+}
+
+// With changes the permission before it is saved
+func (f *PermissionFactory) With(fn func(*authorization.Permission)) *PermissionFactory {
+	f.with(fn)
+	return f
+}
+
+// For sets the resource type and action, e.g. For("product", "update")
+func (f *PermissionFactory) For(resource, action string) *PermissionFactory {
+	return f.With(func(p *authorization.Permission) {
+		p.ResourceType, p.Action, p.Name = resource, action, resource+" "+action
+	})
+}
+
+// Build returns a permission without saving it
+func (f *PermissionFactory) Build() *authorization.Permission {
+	return f.build()
+}
+
+// Create saves a permission
+func (f *PermissionFactory) Create(db *gorm.DB) (*authorization.Permission, error) {
+	return f.create(db)
+}
+
+// CreateMany saves n permissions
+func (f *PermissionFactory) CreateMany(db *gorm.DB, n int) ([]*authorization.Permission, error) {
+	return f.createMany(db, n)
+}
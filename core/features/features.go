@@ -0,0 +1,157 @@
+package features
+
+import (
+	"hash/fnv"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Flag is a feature flag. An enabled flag is on for the users listed in UserIds; for
+// everyone else it is on when their role is in RoleIds (or RoleIds is empty) and they
+// fall within the rollout Percentage.
+type Flag struct {
+	Id          uint      `json:"id" gorm:"primarykey"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Key         string    `json:"key" gorm:"column:key;size:100;uniqueIndex;not null"`
+	Description string    `json:"description" gorm:"type:text"`
+	Enabled     bool      `json:"enabled"`
+	Percentage  int       `json:"percentage"`                                // 0-100 of users, by a stable hash of key and user
+	RoleIds     []uint    `json:"role_ids" gorm:"type:text;serializer:json"` // Roles the flag is limited to
+	UserIds     []uint    `json:"user_ids" gorm:"type:text;serializer:json"` // Users the flag is always on for
+}
+
+// TableName returns the table name for the Flag model
+func (m *Flag) TableName() string {
+	return "feature_flags"
+}
+
+// Subject is who a flag is evaluated for. The zero Subject is an anonymous request.
+type Subject struct {
+	UserId uint
+	RoleId uint
+}
+
+// Evaluate reports whether the flag is on for the subject
+func (m *Flag) Evaluate(subject Subject) bool {
+	if !m.Enabled {
+		return false
+	}
+	if subject.UserId != 0 && slices.Contains(m.UserIds, subject.UserId) {
+		return true
+	}
+	if len(m.RoleIds) > 0 && !slices.Contains(m.RoleIds, subject.RoleId) {
+		return false
+	}
+	if m.Percentage >= 100 {
+		return true
+	}
+	if m.Percentage <= 0 || subject.UserId == 0 {
+		return false
+	}
+	return bucket(m.Key, subject.UserId) < m.Percentage
+}
+
+// bucket places a user in one of 100 buckets. Hashing the key with the user gives every
+// flag its own rollout order, and raising the percentage only ever adds users.
+func bucket(key string, userId uint) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	hash.Write([]byte{0, byte(userId >> 24), byte(userId >> 16), byte(userId >> 8), byte(userId)})
+	return int(hash.Sum32() % 100)
+}
+
+// Evaluator evaluates feature flags. Flags are cached in memory and reloaded after
+// Invalidate, which the feature_flags module calls on every change.
+type Evaluator struct {
+	mu     sync.RWMutex
+	db     *gorm.DB
+	flags  map[string]*Flag
+	loaded bool
+}
+
+// Flags is the evaluator used by RequireFeature and injected into modules as
+// module.Dependencies.Features
+var Flags = &Evaluator{}
+
+// SetDB sets the database the evaluator loads flags from
+func (e *Evaluator) SetDB(db *gorm.DB) {
+	e.mu.Lock()
+	e.db = db
+	e.loaded = false
+	e.mu.Unlock()
+}
+
+// Invalidate makes the evaluator reload flags on next use
+func (e *Evaluator) Invalidate() {
+	e.mu.Lock()
+	e.loaded = false
+	e.mu.Unlock()
+}
+
+// load reads the flags if they haven't been loaded yet
+func (e *Evaluator) load() {
+	e.mu.RLock()
+	loaded, db := e.loaded, e.db
+	e.mu.RUnlock()
+	if loaded || db == nil {
+		return
+	}
+
+	var rows []*Flag
+	if err := db.Find(&rows).Error; err != nil {
+		// The table may not exist yet; every flag is off until it does
+		return
+	}
+
+	flags := make(map[string]*Flag, len(rows))
+	for _, flag := range rows {
+		flags[flag.Key] = flag
+	}
+
+	e.mu.Lock()
+	e.flags = flags
+	e.loaded = true
+	e.mu.Unlock()
+}
+
+// IsEnabled reports whether the flag with the key is on for the subject. Unknown flags
+// are off.
+func (e *Evaluator) IsEnabled(key string, subject Subject) bool {
+	e.load()
+
+	e.mu.RLock()
+	flag, ok := e.flags[key]
+	e.mu.RUnlock()
+	return ok && flag.Evaluate(subject)
+}
+
+// Evaluate returns every flag and whether it is on for the subject, by key
+func (e *Evaluator) Evaluate(subject Subject) map[string]bool {
+	e.load()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	result := make(map[string]bool, len(e.flags))
+	for key, flag := range e.flags {
+		result[key] = flag.Evaluate(subject)
+	}
+	return result
+}
+
+// Enabled returns the keys of the flags that are on for the subject, sorted
+func (e *Evaluator) Enabled(subject Subject) []string {
+	keys := make([]string, 0)
+	for key, on := range e.Evaluate(subject) {
+		if on {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,57 @@
+package features
+
+import (
+	"net/http"
+
+	"base/core/router"
+	"base/core/types"
+)
+
+// subjectKey is the context key the request Subject is cached under
+const subjectKey = "feature_subject"
+
+// SubjectOf returns the Subject of a request: the authenticated user and their role
+func (e *Evaluator) SubjectOf(c *router.Context) Subject {
+	if cached, ok := c.Get(subjectKey); ok {
+		if subject, ok := cached.(Subject); ok {
+			return subject
+		}
+	}
+
+	subject := Subject{UserId: c.GetUint("user_id")}
+	if subject.UserId != 0 {
+		e.mu.RLock()
+		db := e.db
+		e.mu.RUnlock()
+		if db != nil {
+			db.Table("users").Select("role_id").Where("id = ?", subject.UserId).Scan(&subject.RoleId)
+		}
+	}
+
+	c.Set(subjectKey, subject)
+	return subject
+}
+
+// IsEnabledFor reports whether the flag with the key is on for the user of the request
+func (e *Evaluator) IsEnabledFor(c *router.Context, key string) bool {
+	return e.IsEnabled(key, e.SubjectOf(c))
+}
+
+// RequireFeature creates a middleware that answers 404 unless the flag with the key is
+// on for the user of the request, e.g. RequireFeature("new-media-ui")
+func RequireFeature(key string) router.MiddlewareFunc {
+	return Flags.RequireFeature(key)
+}
+
+// RequireFeature creates a middleware that answers 404 unless the flag with the key is
+// on for the user of the request
+func (e *Evaluator) RequireFeature(key string) router.MiddlewareFunc {
+	return func(next router.HandlerFunc) router.HandlerFunc {
+		return func(c *router.Context) error {
+			if !e.IsEnabledFor(c, key) {
+				return c.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Feature not available"})
+			}
+			return next(c)
+		}
+	}
+}
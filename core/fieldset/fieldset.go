@@ -0,0 +1,243 @@
+// Package fieldset lets clients of list endpoints choose the fields of the items with
+// ?fields=id,name,price and add relationships with ?include=categories,seo. Each endpoint
+// declares what can be chosen in a Spec; anything else is a validation error.
+package fieldset
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"base/core/router"
+	"base/core/types"
+	"base/core/validator"
+)
+
+// Loader loads a relationship for the items with the ids, by item id. Items without a value
+// get null.
+type Loader func(ctx *router.Context, ids []uint) (map[uint]any, error)
+
+// Load adapts a typed loader to a Loader
+func Load[V any](load func(ctx *router.Context, ids []uint) (map[uint]V, error)) Loader {
+	return func(ctx *router.Context, ids []uint) (map[uint]any, error) {
+		values, err := load(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		result := make(map[uint]any, len(values))
+		for id, value := range values {
+			result[id] = value
+		}
+		return result, nil
+	}
+}
+
+// Spec lists the fields of the items of a list (their JSON names) and the relationships
+// that can be included
+type Spec struct {
+	Fields   []string
+	Includes map[string]Loader
+}
+
+// Selection holds the fields and includes a request chose
+type Selection struct {
+	spec     *Spec
+	fields   []string // nil for all fields
+	includes []string
+}
+
+// Parse reads the fields and include query parameters. Unknown names are returned as
+// validator.ValidationErrors.
+func (s *Spec) Parse(query url.Values) (*Selection, error) {
+	selection := &Selection{spec: s}
+	var errs validator.ValidationErrors
+
+	if value := query.Get("fields"); value != "" {
+		selection.fields = []string{"id"} // needed to include relationships
+		for _, name := range split(value) {
+			if !slices.Contains(s.Fields, name) {
+				errs = append(errs, invalid("fields", name, s.Fields))
+				continue
+			}
+			if !slices.Contains(selection.fields, name) {
+				selection.fields = append(selection.fields, name)
+			}
+		}
+	}
+
+	if value := query.Get("include"); value != "" {
+		names := make([]string, 0, len(s.Includes))
+		for name := range s.Includes {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range split(value) {
+			if _, ok := s.Includes[name]; !ok {
+				errs = append(errs, invalid("include", name, names))
+				continue
+			}
+			if !slices.Contains(selection.includes, name) {
+				selection.includes = append(selection.includes, name)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return selection, nil
+}
+
+// Includes reports whether the relationship was included
+func (sel *Selection) Includes(name string) bool {
+	return slices.Contains(sel.includes, name)
+}
+
+// Apply trims the items of a list to the chosen fields and adds the included relationships.
+// data is a slice of items or a *types.PaginatedResponse of them; the items need an "id".
+func (sel *Selection) Apply(ctx *router.Context, data any) (any, error) {
+	if sel == nil || (sel.fields == nil && len(sel.includes) == 0) {
+		return data, nil
+	}
+	if paginated, ok := data.(*types.PaginatedResponse); ok {
+		items, err := sel.Apply(ctx, paginated.Data)
+		if err != nil {
+			return nil, err
+		}
+		return &types.PaginatedResponse{Data: items, Pagination: paginated.Pagination}, nil
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var raw []json.RawMessage
+	if err := json.Unmarshal(encoded, &raw); err != nil {
+		return nil, fmt.Errorf("fieldset: %T is not a list", data)
+	}
+
+	items := make([]*Item, len(raw))
+	ids := make([]uint, 0, len(raw))
+	for i, value := range raw {
+		item, err := decodeItem(value)
+		if err != nil {
+			return nil, err
+		}
+		var id uint
+		if value, ok := item.values["id"]; ok && json.Unmarshal(value, &id) == nil {
+			ids = append(ids, id)
+		}
+		item.id = id
+		if sel.fields != nil {
+			item.keep(sel.fields)
+		}
+		items[i] = item
+	}
+
+	for _, name := range sel.includes {
+		loaded, err := sel.spec.Includes[name](ctx, ids)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", name, err)
+		}
+		for _, item := range items {
+			value, err := json.Marshal(loaded[item.id])
+			if err != nil {
+				return nil, err
+			}
+			item.set(name, value)
+		}
+	}
+	return items, nil
+}
+
+// Item is a list item with some of its fields; it encodes them in their original order
+type Item struct {
+	id     uint
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+// decodeItem decodes a JSON object, keeping the order of its keys
+func decodeItem(data json.RawMessage) (*Item, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, fmt.Errorf("fieldset: list items must be objects")
+	}
+	item := &Item{values: map[string]json.RawMessage{}}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		item.set(token.(string), value)
+	}
+	return item, nil
+}
+
+// keep removes the fields that aren't listed
+func (i *Item) keep(fields []string) {
+	keys := i.keys[:0]
+	for _, key := range i.keys {
+		if slices.Contains(fields, key) {
+			keys = append(keys, key)
+		} else {
+			delete(i.values, key)
+		}
+	}
+	i.keys = keys
+}
+
+func (i *Item) set(key string, value json.RawMessage) {
+	if _, ok := i.values[key]; !ok {
+		i.keys = append(i.keys, key)
+	}
+	i.values[key] = value
+}
+
+// MarshalJSON encodes the fields in their original order, includes last
+func (i *Item) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for n, key := range i.keys {
+		if n > 0 {
+			b.WriteByte(',')
+		}
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(name)
+		b.WriteByte(':')
+		b.Write(i.values[key])
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// split splits a comma separated list, ignoring blanks
+func split(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func invalid(field, value string, allowed []string) validator.ValidationError {
+	return validator.ValidationError{
+		Field:   field,
+		Tag:     "oneof",
+		Value:   value,
+		Param:   strings.Join(allowed, " "),
+		Message: fmt.Sprintf("%s must be one of: %s", field, strings.Join(allowed, " ")),
+	}
+}
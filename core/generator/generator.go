@@ -0,0 +1,350 @@
+package generator
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+//go:embed templates/*.tmpl
+var templates embed.FS
+
+// Field is a model field parsed from a name:type argument
+type Field struct {
+	Name   string // Go field name, e.g. UnitPrice
+	Column string // column and JSON name, e.g. unit_price
+	Type   string // generator type, e.g. float
+	GoType string // Go type, e.g. float64
+
+	Translated bool // per-locale values are stored in the translations table
+}
+
+// IsString reports whether the field holds text
+func (f Field) IsString() bool { return f.GoType == "string" }
+
+// IsBool reports whether the field is a boolean
+func (f Field) IsBool() bool { return f.GoType == "bool" }
+
+// IsTime reports whether the field is a timestamp
+func (f Field) IsTime() bool { return f.GoType == "types.DateTime" }
+
+// GormTag returns the gorm struct tag for the field, if any
+func (f Field) GormTag() string {
+	switch f.Type {
+	case "text":
+		return "type:text"
+	case "string", "translation":
+		return "size:255"
+	}
+	return ""
+}
+
+// fieldTypes maps the supported generator types to Go types
+var fieldTypes = map[string]string{
+	"string": "string",
+	"text":   "string",
+	"int":    "int",
+	"uint":   "uint",
+	"float":  "float64",
+	"money":  "types.Money", // minor units, e.g. cents
+	"bool":   "bool",
+	"time":   "types.DateTime",
+
+	// translation is a string with per-locale values, same as string:translated
+	"translation": "string",
+}
+
+// ModuleSpec describes the module to generate
+type ModuleSpec struct {
+	Package     string // Go package and directory, e.g. blogposts
+	Struct      string // model type, e.g. BlogPost
+	Plural      string // plural type name, e.g. BlogPosts
+	Table       string // table name and event prefix, e.g. blog_posts
+	Route       string // URL segment, e.g. blog-posts
+	Resource    string // permission resource type, e.g. blog_post
+	Label       string // human readable name, e.g. blog post
+	LabelPlural string // human readable plural, e.g. blog posts
+	Fields      []Field
+}
+
+// Display returns the field used as the display name in select options
+func (s *ModuleSpec) Display() *Field {
+	for i := range s.Fields {
+		if s.Fields[i].IsString() {
+			return &s.Fields[i]
+		}
+	}
+	return nil
+}
+
+// TranslatedColumns returns the columns of the translated fields as a Go slice literal body
+func (s *ModuleSpec) TranslatedColumns() string {
+	var columns []string
+	for _, field := range s.Fields {
+		if field.Translated {
+			columns = append(columns, fmt.Sprintf("%q", field.Column))
+		}
+	}
+	return strings.Join(columns, ", ")
+}
+
+// HasTranslated reports whether any field is translated
+func (s *ModuleSpec) HasTranslated() bool {
+	return s.TranslatedColumns() != ""
+}
+
+// UsesTypes reports whether any field has a type of the core types package
+func (s *ModuleSpec) UsesTypes() bool {
+	for _, field := range s.Fields {
+		if strings.HasPrefix(field.GoType, "types.") {
+			return true
+		}
+	}
+	return false
+}
+
+// NewModuleSpec builds a module spec from a name (singular or plural, snake_case or
+// CamelCase) and field arguments in name:type form. Without fields a name:string field is used.
+// String and text fields take a ":translated" suffix to store per-locale values
+// ("translation" is short for "string:translated").
+func NewModuleSpec(name string, fieldArgs []string) (*ModuleSpec, error) {
+	snake := toSnake(name)
+	if snake == "" {
+		return nil, fmt.Errorf("invalid module name: %q", name)
+	}
+
+	singular := singularize(snake)
+	plural := pluralize(singular)
+
+	spec := &ModuleSpec{
+		Package:     strings.ReplaceAll(plural, "_", ""),
+		Struct:      toCamel(singular),
+		Plural:      toCamel(plural),
+		Table:       plural,
+		Route:       strings.ReplaceAll(plural, "_", "-"),
+		Resource:    singular,
+		Label:       strings.ReplaceAll(singular, "_", " "),
+		LabelPlural: strings.ReplaceAll(plural, "_", " "),
+	}
+
+	if len(fieldArgs) == 0 {
+		fieldArgs = []string{"name:string"}
+	}
+
+	seen := map[string]bool{"id": true, "created_at": true, "updated_at": true, "deleted_at": true}
+	for _, arg := range fieldArgs {
+		fieldName, fieldType, found := strings.Cut(arg, ":")
+		if !found {
+			return nil, fmt.Errorf("invalid field %q: expected name:type", arg)
+		}
+		fieldType, modifier, _ := strings.Cut(fieldType, ":")
+		switch modifier {
+		case "":
+		case "translated":
+			if fieldType != "string" && fieldType != "text" {
+				return nil, fmt.Errorf("invalid field %q: only string and text fields can be translated", arg)
+			}
+		default:
+			return nil, fmt.Errorf("invalid field %q: unknown modifier %q (supported: translated)", arg, modifier)
+		}
+		goType, ok := fieldTypes[fieldType]
+		if !ok {
+			return nil, fmt.Errorf("unsupported type %q for field %s (supported: string, text, translation, int, uint, float, money, bool, time)", fieldType, fieldName)
+		}
+
+		column := toSnake(fieldName)
+		if column == "" || seen[column] {
+			return nil, fmt.Errorf("invalid or duplicate field name: %q", fieldName)
+		}
+		seen[column] = true
+
+		spec.Fields = append(spec.Fields, Field{
+			Name:   toCamel(column),
+			Column: column,
+			Type:   fieldType,
+			GoType: goType,
+
+			Translated: modifier == "translated" || fieldType == "translation",
+		})
+	}
+
+	return spec, nil
+}
+
+// files maps generated file names to their templates
+var files = []struct {
+	name     string
+	template string
+}{
+	{"model.go", "model.go.tmpl"},
+	{"service.go", "service.go.tmpl"},
+	{"controller.go", "controller.go.tmpl"},
+	{"validator.go", "validator.go.tmpl"},
+	{"module.go", "module.go.tmpl"},
+	{"service_test.go", "service_test.go.tmpl"},
+}
+
+// Generate writes the module files into baseDir/<package> and returns their paths.
+// Existing files are never overwritten unless force is set.
+func (s *ModuleSpec) Generate(baseDir string, force bool) ([]string, error) {
+	dir := filepath.Join(baseDir, s.Package)
+
+	if !force {
+		for _, file := range files {
+			path := filepath.Join(dir, file.name)
+			if _, err := os.Stat(path); err == nil {
+				return nil, fmt.Errorf("%s already exists (use --force to overwrite)", path)
+			}
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	var written []string
+	for _, file := range files {
+		source, err := s.render(file.template)
+		if err != nil {
+			return written, fmt.Errorf("failed to render %s: %w", file.name, err)
+		}
+
+		path := filepath.Join(dir, file.name)
+		if err := os.WriteFile(path, source, 0644); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+
+	return written, nil
+}
+
+// render executes a template with the spec and formats the result
+func (s *ModuleSpec) render(name string) ([]byte, error) {
+	tmpl, err := template.ParseFS(templates, "templates/"+name)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, s); err != nil {
+		return nil, err
+	}
+
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated code does not compile: %w", err)
+	}
+	return source, nil
+}
+
+// Register adds the module to GetAppModules in the given init.go file. It returns false
+// when the module is already registered.
+func (s *ModuleSpec) Register(initPath, importPath string) (bool, error) {
+	content, err := os.ReadFile(initPath)
+	if err != nil {
+		return false, err
+	}
+	source := string(content)
+
+	registration := fmt.Sprintf("modules[%q] = %s.Init(deps.ForModule(%q))", s.Table, s.Package, s.Table)
+	if strings.Contains(source, fmt.Sprintf("modules[%q] =", s.Table)) {
+		return false, nil
+	}
+
+	funcStart := strings.Index(source, "GetAppModules(deps module.Dependencies)")
+	if funcStart < 0 {
+		return false, fmt.Errorf("GetAppModules not found in %s", initPath)
+	}
+	returnAt := strings.Index(source[funcStart:], "\treturn modules")
+	if returnAt < 0 {
+		return false, fmt.Errorf("return statement of GetAppModules not found in %s", initPath)
+	}
+	returnAt += funcStart
+	source = source[:returnAt] + "\t" + registration + "\n\n" + source[returnAt:]
+
+	importLine := fmt.Sprintf("%q", importPath)
+	if !strings.Contains(source, importLine) {
+		source = strings.Replace(source, "import (\n", "import (\n\t"+importLine+"\n", 1)
+	}
+
+	formatted, err := format.Source([]byte(source))
+	if err != nil {
+		return false, fmt.Errorf("failed to update %s: %w", initPath, err)
+	}
+	return true, os.WriteFile(initPath, formatted, 0644)
+}
+
+// toSnake converts CamelCase, kebab-case and spaced names to snake_case
+func toSnake(name string) string {
+	var b strings.Builder
+	runes := []rune(strings.TrimSpace(name))
+	for i, r := range runes {
+		switch {
+		case r == '-' || r == ' ' || r == '_':
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), "_") {
+				b.WriteRune('_')
+			}
+		case unicode.IsUpper(r):
+			if i > 0 && b.Len() > 0 && !strings.HasSuffix(b.String(), "_") &&
+				(unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteRune('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if b.Len() == 0 && unicode.IsDigit(r) {
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	return strings.Trim(b.String(), "_")
+}
+
+// toCamel converts snake_case to CamelCase (user_id becomes UserId)
+func toCamel(snake string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(snake, "_") {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// pluralize returns the plural of the last word of a snake_case name
+func pluralize(word string) string {
+	switch {
+	case strings.HasSuffix(word, "y") && len(word) > 1 && !strings.ContainsRune("aeiou", rune(word[len(word)-2])):
+		return word[:len(word)-1] + "ies"
+	case strings.HasSuffix(word, "s"), strings.HasSuffix(word, "x"), strings.HasSuffix(word, "z"),
+		strings.HasSuffix(word, "ch"), strings.HasSuffix(word, "sh"):
+		return word + "es"
+	default:
+		return word + "s"
+	}
+}
+
+// singularize returns the singular of the last word of a snake_case name
+func singularize(word string) string {
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 3:
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "xes"), strings.HasSuffix(word, "zes"),
+		strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "ss"), strings.HasSuffix(word, "us"), strings.HasSuffix(word, "is"):
+		return word
+	case strings.HasSuffix(word, "s"):
+		return word[:len(word)-1]
+	default:
+		return word
+	}
+}
@@ -0,0 +1,651 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"base/core/router"
+)
+
+// MaxDepth is the deepest nesting of selections a query can have
+const MaxDepth = 10
+
+// Request is a GraphQL request, as posted to the endpoint
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// Response is the result of a request. Data holds the fields that could be resolved; the
+// others are null and have an error.
+type Response struct {
+	Data   any      `json:"data"`
+	Errors []*Error `json:"errors,omitempty"`
+}
+
+// Error is an error of a request, with the path of the field it occurred at
+type Error struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path,omitempty"`
+}
+
+// Execute runs a query request against the schema
+func (s *Schema) Execute(ctx *router.Context, req Request) *Response {
+	doc, err := Parse(req.Query)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+	operation, err := doc.operation(req.OperationName)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+	if operation.Type != "query" {
+		return &Response{Errors: []*Error{{Message: operation.Type + "s are not supported; use the REST API to change data"}}}
+	}
+
+	e := &executor{
+		ctx:       ctx,
+		doc:       doc,
+		variables: map[string]any{},
+		guards:    map[*Field]error{},
+	}
+	for _, definition := range operation.Variables {
+		value, given := req.Variables[definition.Name]
+		if !given || value == nil {
+			value = definition.Default
+		}
+		if value == nil && strings.HasSuffix(definition.Type, "!") {
+			return &Response{Errors: []*Error{{Message: fmt.Sprintf("variable $%s of type %s is required", definition.Name, definition.Type)}}}
+		}
+		e.variables[definition.Name] = value
+	}
+
+	data := newResult()
+	e.selections(s.query, []*item{{out: data}}, operation.Selections, 1)
+	return &Response{Data: data, Errors: e.errors}
+}
+
+// operation returns the operation to run: the named one, or the only one of the document
+func (d *Document) operation(name string) (*Operation, error) {
+	if name == "" {
+		if len(d.Operations) > 1 {
+			return nil, fmt.Errorf("operationName is required for documents with several operations")
+		}
+		return d.Operations[0], nil
+	}
+	for _, operation := range d.Operations {
+		if operation.Name == name {
+			return operation, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation %q", name)
+}
+
+// executor resolves the selections of one request. The fields of a selection are resolved
+// for all sources at once, level by level, so Batch resolvers see a whole list.
+type executor struct {
+	ctx       *router.Context
+	doc       *Document
+	variables map[string]any
+	errors    []*Error
+	guards    map[*Field]error
+}
+
+// item is an object being resolved: its source value, the result its fields are set on and
+// its path in the response
+type item struct {
+	source any
+	out    *result
+	path   []any
+}
+
+// fieldGroup holds the field nodes of a selection that have the same response key
+type fieldGroup struct {
+	key   string
+	nodes []*FieldNode
+}
+
+func (e *executor) fail(path []any, format string, args ...any) {
+	e.errors = append(e.errors, &Error{Message: fmt.Sprintf(format, args...), Path: path})
+}
+
+// selections resolves the selected fields of the items of an object type
+func (e *executor) selections(object *Object, items []*item, selections []Selection, depth int) {
+	if depth > MaxDepth {
+		e.fail(items[0].path, "the query is nested deeper than %d levels", MaxDepth)
+		return
+	}
+
+	for _, group := range e.collect(object, selections, map[string]bool{}) {
+		node := group.nodes[0]
+		if node.Name == "__typename" {
+			for _, item := range items {
+				item.out.set(group.key, object.Name)
+			}
+			continue
+		}
+		nullAll := func() {
+			for _, item := range items {
+				item.out.set(group.key, nil)
+			}
+		}
+		path := appendPath(items[0].path, group.key)
+
+		field, ok := object.Field(node.Name)
+		if !ok {
+			e.fail(path, "cannot query field %q on type %q", node.Name, object.Name)
+			nullAll()
+			continue
+		}
+		var subselections []Selection
+		for _, node := range group.nodes {
+			subselections = append(subselections, node.Selections...)
+		}
+		fieldObject := objectOf(field.Type)
+		if fieldObject != nil && len(subselections) == 0 {
+			e.fail(path, "field %q of type %s must have a selection of subfields", node.Name, field.Type)
+			nullAll()
+			continue
+		}
+		if fieldObject == nil && len(subselections) > 0 {
+			e.fail(path, "field %q of type %s can't have a selection of subfields", node.Name, field.Type)
+			nullAll()
+			continue
+		}
+
+		args, err := e.arguments(field, node)
+		if err != nil {
+			e.fail(path, "%s", err.Error())
+			nullAll()
+			continue
+		}
+		if err := e.guard(field); err != nil {
+			e.fail(path, "%s", err.Error())
+			nullAll()
+			continue
+		}
+
+		values := e.resolve(field, node, args, items, group.key)
+		var pending []*item
+		for i, item := range items {
+			item.out.set(group.key, e.complete(field.Type, values[i], appendPath(item.path, group.key), &pending))
+		}
+		if len(pending) > 0 {
+			e.selections(fieldObject, pending, subselections, depth+1)
+		}
+	}
+}
+
+// resolve returns the values of a field for the items, nil for the ones that failed
+func (e *executor) resolve(field *Field, node *FieldNode, args map[string]any, items []*item, key string) []any {
+	params := Params{Context: e.ctx, Args: args}
+	values := make([]any, len(items))
+
+	if field.Batch != nil {
+		sources := make([]any, len(items))
+		for i, item := range items {
+			sources[i] = item.source
+		}
+		results, err := field.Batch(params, sources)
+		if err == nil && len(results) != len(items) {
+			err = fmt.Errorf("field %q resolved %d values for %d objects", node.Name, len(results), len(items))
+		}
+		if err != nil {
+			e.fail(appendPath(items[0].path, key), "%s", err.Error())
+			return values
+		}
+		return results
+	}
+
+	for i, item := range items {
+		if field.Resolve == nil {
+			values[i] = fieldOf(item.source, node.Name)
+			continue
+		}
+		params.Source = item.source
+		value, err := field.Resolve(params)
+		if err != nil {
+			e.fail(appendPath(item.path, key), "%s", err.Error())
+			continue
+		}
+		values[i] = value
+	}
+	return values
+}
+
+// guard runs the guard of a field once per request
+func (e *executor) guard(field *Field) error {
+	if field.Guard == nil {
+		return nil
+	}
+	if err, ok := e.guards[field]; ok {
+		return err
+	}
+	err := field.Guard(e.ctx)
+	e.guards[field] = err
+	return err
+}
+
+// complete converts a resolved value to its type; objects are added to pending so their
+// fields are resolved together with the objects of the other items
+func (e *executor) complete(typ Type, value any, path []any, pending *[]*item) any {
+	switch typ := typ.(type) {
+	case NonNull:
+		result := e.complete(typ.Of, value, path, pending)
+		if result == nil {
+			e.fail(path, "cannot return null for the non-null field %s", path[len(path)-1])
+		}
+		return result
+	case List:
+		if isNil(value) {
+			return nil
+		}
+		list := reflect.ValueOf(value)
+		for list.Kind() == reflect.Pointer {
+			list = list.Elem()
+		}
+		if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+			e.fail(path, "expected a list, got %T", value)
+			return nil
+		}
+		results := make([]any, list.Len())
+		for i := range results {
+			results[i] = e.complete(typ.Of, list.Index(i).Interface(), appendPath(path, i), pending)
+		}
+		return results
+	case *Object:
+		if isNil(value) {
+			return nil
+		}
+		out := newResult()
+		*pending = append(*pending, &item{source: value, out: out, path: path})
+		return out
+	case Scalar:
+		if isNil(value) {
+			return nil
+		}
+		return serialize(typ, value)
+	}
+	return nil
+}
+
+// collect returns the fields of a selection set, grouped by response key in the order they
+// are selected, with fragments expanded and @skip and @include applied
+func (e *executor) collect(object *Object, selections []Selection, visited map[string]bool) []*fieldGroup {
+	var groups []*fieldGroup
+	byKey := map[string]*fieldGroup{}
+	add := func(node *FieldNode) {
+		if group, ok := byKey[node.Key()]; ok {
+			group.nodes = append(group.nodes, node)
+			return
+		}
+		group := &fieldGroup{key: node.Key(), nodes: []*FieldNode{node}}
+		byKey[group.key] = group
+		groups = append(groups, group)
+	}
+	merge := func(collected []*fieldGroup) {
+		for _, group := range collected {
+			for _, node := range group.nodes {
+				add(node)
+			}
+		}
+	}
+
+	for _, selection := range selections {
+		switch selection := selection.(type) {
+		case *FieldNode:
+			if e.included(selection.Directives) {
+				add(selection)
+			}
+		case *InlineFragment:
+			if e.included(selection.Directives) && (selection.On == "" || selection.On == object.Name) {
+				merge(e.collect(object, selection.Selections, visited))
+			}
+		case *FragmentSpread:
+			if visited[selection.Name] || !e.included(selection.Directives) {
+				continue
+			}
+			visited[selection.Name] = true
+			fragment, ok := e.doc.Fragments[selection.Name]
+			if !ok {
+				e.fail(nil, "unknown fragment %q", selection.Name)
+				continue
+			}
+			if fragment.On == object.Name {
+				merge(e.collect(object, fragment.Selections, visited))
+			}
+		}
+	}
+	return groups
+}
+
+// included applies the @skip and @include directives
+func (e *executor) included(directives []*Directive) bool {
+	for _, directive := range directives {
+		condition, _ := e.value(directive.Arguments["if"]).(bool)
+		if (directive.Name == "skip" && condition) || (directive.Name == "include" && !condition) {
+			return false
+		}
+	}
+	return true
+}
+
+// arguments returns the arguments of a field node coerced to the types of the field
+func (e *executor) arguments(field *Field, node *FieldNode) (map[string]any, error) {
+	args := make(map[string]any, len(field.Args))
+	declared := make(map[string]bool, len(field.Args))
+	for _, arg := range field.Args {
+		declared[arg.Name] = true
+		value := e.value(node.Arguments[arg.Name])
+		if value == nil {
+			value = arg.Default
+		}
+		if value == nil {
+			if _, required := arg.Type.(NonNull); required {
+				return nil, fmt.Errorf("argument %q of type %s is required", arg.Name, arg.Type)
+			}
+			continue
+		}
+		coerced, err := coerce(arg.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("argument %q: %s", arg.Name, err.Error())
+		}
+		args[arg.Name] = coerced
+	}
+	for name := range node.Arguments {
+		if !declared[name] {
+			return nil, fmt.Errorf("unknown argument %q of field %q", name, node.Name)
+		}
+	}
+	return args, nil
+}
+
+// value replaces the variables in an argument value with their values
+func (e *executor) value(value any) any {
+	switch value := value.(type) {
+	case Variable:
+		return e.variables[string(value)]
+	case []any:
+		list := make([]any, len(value))
+		for i, item := range value {
+			list[i] = e.value(item)
+		}
+		return list
+	case map[string]any:
+		object := make(map[string]any, len(value))
+		for key, item := range value {
+			object[key] = e.value(item)
+		}
+		return object
+	}
+	return value
+}
+
+// coerce converts an argument value to its type
+func coerce(typ Type, value any) (any, error) {
+	switch typ := typ.(type) {
+	case NonNull:
+		if value == nil {
+			return nil, fmt.Errorf("expected a value of type %s", typ)
+		}
+		return coerce(typ.Of, value)
+	case List:
+		items, ok := value.([]any)
+		if !ok {
+			items = []any{value}
+		}
+		list := make([]any, len(items))
+		for i, item := range items {
+			coerced, err := coerce(typ.Of, item)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = coerced
+		}
+		return list, nil
+	}
+
+	invalid := fmt.Errorf("expected a value of type %s, got %v", typ, value)
+	switch typ {
+	case Int:
+		switch value := value.(type) {
+		case int:
+			return value, nil
+		case float64:
+			if value == math.Trunc(value) && math.Abs(value) <= math.MaxInt32 {
+				return int(value), nil
+			}
+		}
+		return nil, invalid
+	case Float:
+		switch value := value.(type) {
+		case int:
+			return float64(value), nil
+		case float64:
+			return value, nil
+		}
+		return nil, invalid
+	case Boolean:
+		if value, ok := value.(bool); ok {
+			return value, nil
+		}
+		return nil, invalid
+	case ID:
+		switch value := value.(type) {
+		case string:
+			return value, nil
+		case int:
+			return fmt.Sprint(value), nil
+		case float64:
+			if value == math.Trunc(value) {
+				return fmt.Sprint(int64(value)), nil
+			}
+		}
+		return nil, invalid
+	case String, Time:
+		switch value := value.(type) {
+		case string:
+			return value, nil
+		case Enum:
+			return string(value), nil
+		}
+		return nil, invalid
+	}
+	return value, nil
+}
+
+// serialize converts a scalar value for the response
+func serialize(typ Scalar, value any) any {
+	rv := reflect.ValueOf(value)
+	for rv.Kind() == reflect.Pointer {
+		rv = rv.Elem()
+	}
+	value = rv.Interface()
+
+	switch typ {
+	case ID:
+		return fmt.Sprint(value)
+	case Time:
+		if t, ok := value.(time.Time); ok {
+			if t.IsZero() {
+				return nil
+			}
+			return t.Format(time.RFC3339)
+		}
+	}
+	return value
+}
+
+// objectOf returns the object type of a field type, nil for scalars
+func objectOf(typ Type) *Object {
+	switch typ := typ.(type) {
+	case NonNull:
+		return objectOf(typ.Of)
+	case List:
+		return objectOf(typ.Of)
+	case *Object:
+		return typ
+	}
+	return nil
+}
+
+// isNil reports whether a value is nil or a nil pointer, map or slice
+func isNil(value any) bool {
+	if value == nil {
+		return true
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// appendPath returns a copy of the path with the key added
+func appendPath(path []any, key any) []any {
+	result := make([]any, len(path), len(path)+1)
+	copy(result, path)
+	return append(result, key)
+}
+
+// jsonFields caches the struct field index of every json name, by type
+var jsonFields sync.Map
+
+// fieldOf reads a field from a source: a map key, or a struct field by its json name
+func fieldOf(source any, name string) any {
+	rv := reflect.ValueOf(source)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		value := rv.MapIndex(reflect.ValueOf(name).Convert(rv.Type().Key()))
+		if !value.IsValid() {
+			return nil
+		}
+		return value.Interface()
+	case reflect.Struct:
+		cached, ok := jsonFields.Load(rv.Type())
+		if !ok {
+			fields := map[string][]int{}
+			for _, field := range reflect.VisibleFields(rv.Type()) {
+				if !field.IsExported() || field.Anonymous {
+					continue
+				}
+				jsonName := field.Name
+				if tag, ok := field.Tag.Lookup("json"); ok {
+					tagName, _, _ := strings.Cut(tag, ",")
+					if tagName == "-" {
+						continue
+					}
+					if tagName != "" {
+						jsonName = tagName
+					}
+				}
+				if _, taken := fields[jsonName]; !taken {
+					fields[jsonName] = field.Index
+				}
+			}
+			cached, _ = jsonFields.LoadOrStore(rv.Type(), fields)
+		}
+		index, ok := cached.(map[string][]int)[name]
+		if !ok {
+			return nil
+		}
+		value, err := rv.FieldByIndexErr(index)
+		if err != nil {
+			return nil
+		}
+		return value.Interface()
+	}
+	return nil
+}
+
+// result is an object in the response; it keeps its fields in the order they were selected
+type result struct {
+	keys   []string
+	values map[string]any
+}
+
+func newResult() *result {
+	return &result{values: map[string]any{}}
+}
+
+func (r *result) set(key string, value any) {
+	if _, ok := r.values[key]; !ok {
+		r.keys = append(r.keys, key)
+	}
+	r.values[key] = value
+}
+
+// MarshalJSON encodes the fields in the order they were selected
+func (r *result) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, key := range r.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(r.values[key])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(name)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// BatchBy resolves a field for many sources with one load, like a dataloader: key returns
+// the key of a source (false when it has none) and load the values of the distinct keys
+func BatchBy[K comparable, V any](sources []any, key func(source any) (K, bool), load func(keys []K) (map[K]V, error)) ([]any, error) {
+	keys := make([]K, len(sources))
+	has := make([]bool, len(sources))
+	var distinct []K
+	seen := map[K]bool{}
+	for i, source := range sources {
+		keys[i], has[i] = key(source)
+		if has[i] && !seen[keys[i]] {
+			seen[keys[i]] = true
+			distinct = append(distinct, keys[i])
+		}
+	}
+
+	values := make([]any, len(sources))
+	if len(distinct) == 0 {
+		return values, nil
+	}
+	loaded, err := load(distinct)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sources {
+		if has[i] {
+			if value, ok := loaded[keys[i]]; ok {
+				values[i] = value
+			}
+		}
+	}
+	return values, nil
+}
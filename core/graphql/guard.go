@@ -0,0 +1,40 @@
+package graphql
+
+import (
+	"errors"
+
+	"base/core/app/authorization"
+	"base/core/router"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnauthenticated = errors.New("authentication required")
+	ErrForbidden       = errors.New("admin role required")
+)
+
+// Authenticated is a guard for fields that any signed-in user can read
+func Authenticated(c *router.Context) error {
+	if _, err := authorization.GetUserIdFromContext(c); err != nil {
+		return ErrUnauthenticated
+	}
+	return nil
+}
+
+// RequireAdmin returns a guard for fields that only users with one of the
+// authorization.AdminRoles can read, like the admin-only REST routes
+func RequireAdmin(db *gorm.DB) func(c *router.Context) error {
+	service := authorization.NewAuthorizationService(db)
+	return func(c *router.Context) error {
+		userId, err := authorization.GetUserIdFromContext(c)
+		if err != nil {
+			return ErrUnauthenticated
+		}
+		isAdmin, err := service.HasRole(c.Request.Context(), userId, authorization.AdminRoles...)
+		if err != nil || !isAdmin {
+			return ErrForbidden
+		}
+		return nil
+	}
+}
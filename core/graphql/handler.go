@@ -0,0 +1,67 @@
+package graphql
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"base/core/router"
+	"base/core/types"
+)
+
+// Routes registers the GraphQL endpoint of the default schema
+func Routes(group *router.RouterGroup) {
+	handler := &Handler{Schema: DefaultSchema}
+	group.GET("/graphql", handler.Query)
+	group.POST("/graphql", handler.Query)
+	group.GET("/graphql/schema", handler.SDL)
+}
+
+// Handler serves the queries of a schema
+type Handler struct {
+	Schema *Schema
+}
+
+// Query runs a GraphQL query
+// @Summary Run a GraphQL query
+// @Description Runs a query against the users, media and settings; fields keep the authorization checks of their REST endpoints. Changes are made through the REST API.
+// @Tags Core/GraphQL
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body Request true "Query"
+// @Success 200 {object} Response
+// @Failure 400 {object} types.ErrorResponse
+// @Router /graphql [post]
+func (h *Handler) Query(ctx *router.Context) error {
+	var req Request
+	if ctx.Request.Method == http.MethodGet {
+		req.Query = ctx.Query("query")
+		req.OperationName = ctx.Query("operationName")
+		if variables := ctx.Query("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid variables: " + err.Error()})
+			}
+		}
+	} else if err := ctx.ShouldBindJSON(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid request: " + err.Error()})
+	}
+	if req.Query == "" {
+		return ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "query is required"})
+	}
+
+	return ctx.JSON(http.StatusOK, h.Schema.Execute(ctx, req))
+}
+
+// SDL returns the schema
+// @Summary Get the GraphQL schema
+// @Description Returns the schema in the GraphQL schema definition language
+// @Tags Core/GraphQL
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce plain
+// @Success 200 {string} string
+// @Router /graphql/schema [get]
+func (h *Handler) SDL(ctx *router.Context) error {
+	return ctx.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(h.Schema.SDL()))
+}
@@ -0,0 +1,654 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Document is a parsed GraphQL request document
+type Document struct {
+	Operations []*Operation
+	Fragments  map[string]*Fragment
+}
+
+// Operation is a query or mutation of a document
+type Operation struct {
+	Type       string // query or mutation
+	Name       string
+	Variables  []*VariableDefinition
+	Selections []Selection
+}
+
+// VariableDefinition declares a variable of an operation, e.g. $id: ID!
+type VariableDefinition struct {
+	Name    string
+	Type    string
+	Default any
+}
+
+// Fragment is a named fragment, e.g. fragment userFields on User { id }
+type Fragment struct {
+	Name       string
+	On         string
+	Selections []Selection
+}
+
+// Selection is a *FieldNode, *FragmentSpread or *InlineFragment
+type Selection interface{}
+
+// FieldNode is a field in a selection set
+type FieldNode struct {
+	Alias      string
+	Name       string
+	Arguments  map[string]any
+	Directives []*Directive
+	Selections []Selection
+}
+
+// Key returns the name of the field in the response
+func (f *FieldNode) Key() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+// FragmentSpread includes a named fragment, e.g. ...userFields
+type FragmentSpread struct {
+	Name       string
+	Directives []*Directive
+}
+
+// InlineFragment is a fragment without a name, e.g. ... on User { id }
+type InlineFragment struct {
+	On         string
+	Directives []*Directive
+	Selections []Selection
+}
+
+// Directive is a directive of a selection, e.g. @include(if: $withRole)
+type Directive struct {
+	Name      string
+	Arguments map[string]any
+}
+
+// Variable is a reference to a variable in an argument value
+type Variable string
+
+// Enum is an enum value in an argument, e.g. DESC
+type Enum string
+
+// Parse parses a GraphQL request document
+func Parse(source string) (*Document, error) {
+	p := &parser{lexer: &lexer{source: source}}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	doc := &Document{Fragments: map[string]*Fragment{}}
+	for p.token.kind != tokenEOF {
+		switch {
+		case p.token.kind == tokenPunct && p.token.value == "{":
+			selections, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.Operations = append(doc.Operations, &Operation{Type: "query", Selections: selections})
+		case p.token.kind == tokenName && p.token.value == "fragment":
+			fragment, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			doc.Fragments[fragment.Name] = fragment
+		case p.token.kind == tokenName && (p.token.value == "query" || p.token.value == "mutation" || p.token.value == "subscription"):
+			operation, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.Operations = append(doc.Operations, operation)
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	if len(doc.Operations) == 0 {
+		return nil, fmt.Errorf("syntax error: the document has no operation")
+	}
+	return doc, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+// lexer splits a document into tokens; commas, whitespace and comments are ignored
+type lexer struct {
+	source string
+	pos    int
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.source) {
+		c := l.source[l.pos]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' {
+			l.pos++
+			continue
+		}
+		if c == '#' {
+			for l.pos < len(l.source) && l.source[l.pos] != '\n' {
+				l.pos++
+			}
+			continue
+		}
+		break
+	}
+	if l.pos >= len(l.source) {
+		return token{kind: tokenEOF, pos: l.pos}, nil
+	}
+
+	start := l.pos
+	c := l.source[l.pos]
+	switch {
+	case strings.HasPrefix(l.source[l.pos:], "..."):
+		l.pos += 3
+		return token{kind: tokenPunct, value: "...", pos: start}, nil
+	case strings.IndexByte("!$()/:=@[]{}|&", c) >= 0:
+		l.pos++
+		return token{kind: tokenPunct, value: string(c), pos: start}, nil
+	case c == '_' || isLetter(c):
+		for l.pos < len(l.source) && (l.source[l.pos] == '_' || isLetter(l.source[l.pos]) || isDigit(l.source[l.pos])) {
+			l.pos++
+		}
+		return token{kind: tokenName, value: l.source[start:l.pos], pos: start}, nil
+	case c == '-' || isDigit(c):
+		return l.number()
+	case c == '"':
+		return l.string()
+	}
+	return token{}, fmt.Errorf("syntax error at %d: unexpected character %q", start, c)
+}
+
+func (l *lexer) number() (token, error) {
+	start := l.pos
+	kind := tokenInt
+	if l.source[l.pos] == '-' {
+		l.pos++
+	}
+	digits := func() {
+		for l.pos < len(l.source) && isDigit(l.source[l.pos]) {
+			l.pos++
+		}
+	}
+	digits()
+	if l.pos < len(l.source) && l.source[l.pos] == '.' {
+		kind = tokenFloat
+		l.pos++
+		digits()
+	}
+	if l.pos < len(l.source) && (l.source[l.pos] == 'e' || l.source[l.pos] == 'E') {
+		kind = tokenFloat
+		l.pos++
+		if l.pos < len(l.source) && (l.source[l.pos] == '+' || l.source[l.pos] == '-') {
+			l.pos++
+		}
+		digits()
+	}
+	value := l.source[start:l.pos]
+	if value == "-" {
+		return token{}, fmt.Errorf("syntax error at %d: invalid number", start)
+	}
+	return token{kind: kind, value: value, pos: start}, nil
+}
+
+func (l *lexer) string() (token, error) {
+	start := l.pos
+	if strings.HasPrefix(l.source[l.pos:], `"""`) {
+		end := strings.Index(l.source[l.pos+3:], `"""`)
+		if end < 0 {
+			return token{}, fmt.Errorf("syntax error at %d: unterminated string", start)
+		}
+		value := l.source[l.pos+3 : l.pos+3+end]
+		l.pos += end + 6
+		return token{kind: tokenString, value: strings.TrimSpace(value), pos: start}, nil
+	}
+
+	var b strings.Builder
+	l.pos++
+	for l.pos < len(l.source) {
+		c := l.source[l.pos]
+		switch {
+		case c == '"':
+			l.pos++
+			return token{kind: tokenString, value: b.String(), pos: start}, nil
+		case c == '\n':
+			return token{}, fmt.Errorf("syntax error at %d: unterminated string", start)
+		case c == '\\' && l.pos+1 < len(l.source):
+			escaped := l.source[l.pos+1]
+			l.pos += 2
+			switch escaped {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'u':
+				if l.pos+4 > len(l.source) {
+					return token{}, fmt.Errorf("syntax error at %d: invalid unicode escape", l.pos)
+				}
+				code, err := strconv.ParseUint(l.source[l.pos:l.pos+4], 16, 32)
+				if err != nil {
+					return token{}, fmt.Errorf("syntax error at %d: invalid unicode escape", l.pos)
+				}
+				b.WriteRune(rune(code))
+				l.pos += 4
+			default:
+				b.WriteByte(escaped)
+			}
+		default:
+			r, size := utf8.DecodeRuneInString(l.source[l.pos:])
+			b.WriteRune(r)
+			l.pos += size
+		}
+	}
+	return token{}, fmt.Errorf("syntax error at %d: unterminated string", start)
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// parser builds a document from the tokens of the lexer
+type parser struct {
+	lexer *lexer
+	token token
+}
+
+func (p *parser) next() error {
+	token, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.token = token
+	return nil
+}
+
+func (p *parser) unexpected() error {
+	if p.token.kind == tokenEOF {
+		return fmt.Errorf("syntax error: unexpected end of document")
+	}
+	return fmt.Errorf("syntax error at %d: unexpected %q", p.token.pos, p.token.value)
+}
+
+// peek reports whether the current token is the punctuator
+func (p *parser) peek(punct string) bool {
+	return p.token.kind == tokenPunct && p.token.value == punct
+}
+
+// expect consumes the punctuator or fails
+func (p *parser) expect(punct string) error {
+	if !p.peek(punct) {
+		return p.unexpected()
+	}
+	return p.next()
+}
+
+// name consumes a name
+func (p *parser) name() (string, error) {
+	if p.token.kind != tokenName {
+		return "", p.unexpected()
+	}
+	name := p.token.value
+	return name, p.next()
+}
+
+func (p *parser) operation() (*Operation, error) {
+	operation := &Operation{Type: p.token.value}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if p.token.kind == tokenName {
+		operation.Name = p.token.value
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek("(") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		for !p.peek(")") {
+			definition, err := p.variableDefinition()
+			if err != nil {
+				return nil, err
+			}
+			operation.Variables = append(operation.Variables, definition)
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	selections, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	operation.Selections = selections
+	return operation, nil
+}
+
+func (p *parser) variableDefinition() (*VariableDefinition, error) {
+	if err := p.expect("$"); err != nil {
+		return nil, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	typ, err := p.typeRef()
+	if err != nil {
+		return nil, err
+	}
+	definition := &VariableDefinition{Name: name, Type: typ}
+	if p.peek("=") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if definition.Default, err = p.value(true); err != nil {
+			return nil, err
+		}
+	}
+	return definition, nil
+}
+
+// typeRef reads a type such as ID!, [String] or [Int!]!
+func (p *parser) typeRef() (string, error) {
+	var typ string
+	if p.peek("[") {
+		if err := p.next(); err != nil {
+			return "", err
+		}
+		inner, err := p.typeRef()
+		if err != nil {
+			return "", err
+		}
+		if err := p.expect("]"); err != nil {
+			return "", err
+		}
+		typ = "[" + inner + "]"
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return "", err
+		}
+		typ = name
+	}
+	if p.peek("!") {
+		if err := p.next(); err != nil {
+			return "", err
+		}
+		typ += "!"
+	}
+	return typ, nil
+}
+
+func (p *parser) fragment() (*Fragment, error) {
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if p.token.kind != tokenName || p.token.value != "on" {
+		return nil, p.unexpected()
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	on, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	selections, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	return &Fragment{Name: name, On: on, Selections: selections}, nil
+}
+
+func (p *parser) selectionSet() ([]Selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var selections []Selection
+	for !p.peek("}") {
+		selection, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, selection)
+	}
+	if len(selections) == 0 {
+		return nil, fmt.Errorf("syntax error at %d: empty selection set", p.token.pos)
+	}
+	return selections, p.next()
+}
+
+func (p *parser) selection() (Selection, error) {
+	if p.peek("...") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if p.token.kind == tokenName && p.token.value != "on" {
+			name := p.token.value
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			directives, err := p.directives()
+			if err != nil {
+				return nil, err
+			}
+			return &FragmentSpread{Name: name, Directives: directives}, nil
+		}
+
+		fragment := &InlineFragment{}
+		if p.token.kind == tokenName {
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			on, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			fragment.On = on
+		}
+		directives, err := p.directives()
+		if err != nil {
+			return nil, err
+		}
+		fragment.Directives = directives
+		if fragment.Selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+		return fragment, nil
+	}
+
+	field := &FieldNode{}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	field.Name = name
+	if p.peek(":") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		field.Alias = name
+		if field.Name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if field.Arguments, err = p.arguments(); err != nil {
+		return nil, err
+	}
+	if field.Directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if p.peek("{") {
+		if field.Selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return field, nil
+}
+
+func (p *parser) arguments() (map[string]any, error) {
+	arguments := map[string]any{}
+	if !p.peek("(") {
+		return arguments, nil
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	for !p.peek(")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if arguments[name], err = p.value(false); err != nil {
+			return nil, err
+		}
+	}
+	return arguments, p.next()
+}
+
+func (p *parser) directives() ([]*Directive, error) {
+	var directives []*Directive
+	for p.peek("@") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		arguments, err := p.arguments()
+		if err != nil {
+			return nil, err
+		}
+		directives = append(directives, &Directive{Name: name, Arguments: arguments})
+	}
+	return directives, nil
+}
+
+// value reads an argument value; constant values (variable defaults) can't use variables
+func (p *parser) value(constant bool) (any, error) {
+	token := p.token
+	switch token.kind {
+	case tokenInt:
+		number, err := strconv.ParseInt(token.value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("syntax error at %d: invalid integer %s", token.pos, token.value)
+		}
+		return int(number), p.next()
+	case tokenFloat:
+		number, err := strconv.ParseFloat(token.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("syntax error at %d: invalid number %s", token.pos, token.value)
+		}
+		return number, p.next()
+	case tokenString:
+		return token.value, p.next()
+	case tokenName:
+		var value any
+		switch token.value {
+		case "true":
+			value = true
+		case "false":
+			value = false
+		case "null":
+			value = nil
+		default:
+			value = Enum(token.value)
+		}
+		return value, p.next()
+	}
+
+	switch {
+	case p.peek("$") && !constant:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		return Variable(name), nil
+	case p.peek("["):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		list := []any{}
+		for !p.peek("]") {
+			item, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, p.next()
+	case p.peek("{"):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		object := map[string]any{}
+		for !p.peek("}") {
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if object[name], err = p.value(constant); err != nil {
+				return nil, err
+			}
+		}
+		return object, p.next()
+	}
+	return nil, p.unexpected()
+}
@@ -0,0 +1,234 @@
+package graphql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"base/core/router"
+)
+
+// Type is the type of a field: a Scalar, an *Object, a List or a NonNull
+type Type interface {
+	String() string
+}
+
+// Scalar is a leaf type; values are sent as they encode to JSON
+type Scalar string
+
+// Built-in scalars. JSON holds any JSON value, e.g. the custom fields of a record.
+const (
+	String  Scalar = "String"
+	Int     Scalar = "Int"
+	Float   Scalar = "Float"
+	Boolean Scalar = "Boolean"
+	ID      Scalar = "ID"
+	Time    Scalar = "Time" // RFC 3339 timestamp
+	JSON    Scalar = "JSON"
+)
+
+func (s Scalar) String() string {
+	return string(s)
+}
+
+// List is a list of values of a type
+type List struct {
+	Of Type
+}
+
+func (l List) String() string {
+	return "[" + l.Of.String() + "]"
+}
+
+// NonNull is a type whose values are never null
+type NonNull struct {
+	Of Type
+}
+
+func (n NonNull) String() string {
+	return n.Of.String() + "!"
+}
+
+// Object is an object type, e.g. User. Modules add fields to an object with AddField, so
+// other modules can extend a type they don't own.
+type Object struct {
+	Name        string
+	Description string
+
+	mu     sync.RWMutex
+	fields map[string]*Field
+}
+
+func (o *Object) String() string {
+	return o.Name
+}
+
+// AddField adds or replaces a field of the object
+func (o *Object) AddField(name string, field *Field) *Object {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.fields == nil {
+		o.fields = map[string]*Field{}
+	}
+	o.fields[name] = field
+	return o
+}
+
+// Field returns a field of the object by name
+func (o *Object) Field(name string) (*Field, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	field, ok := o.fields[name]
+	return field, ok
+}
+
+// FieldNames returns the names of the fields sorted by name
+func (o *Object) FieldNames() []string {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	names := make([]string, 0, len(o.fields))
+	for name := range o.fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Argument is an argument of a field; its type is a scalar, e.g. Int or ID!
+type Argument struct {
+	Name        string
+	Type        Type
+	Default     any
+	Description string
+}
+
+// Params are passed to the resolvers of a field
+type Params struct {
+	Context *router.Context
+	Source  any            // Value of the parent object; nil for root fields
+	Args    map[string]any // Arguments coerced to their types: int, float64, string or bool
+}
+
+// Field is a field of an object. Without Resolve or Batch its value is read from the source:
+// the map key or struct field (by json tag) of the field name.
+type Field struct {
+	Type        Type
+	Description string
+	Args        []*Argument
+
+	// Resolve returns the value of the field for one source
+	Resolve func(p Params) (any, error)
+
+	// Batch returns the values of the field for all sources of a selection at once, in the
+	// order of the sources. Use it instead of Resolve for fields that query the database,
+	// e.g. the role of every user in a list, so a list costs one query instead of one per item.
+	Batch func(p Params, sources []any) ([]any, error)
+
+	// Guard runs before the field is resolved, e.g. to check the permissions of the user;
+	// an error leaves the field null
+	Guard func(ctx *router.Context) error
+}
+
+// Schema holds the object types and the query fields of the API. Modules register their
+// types and root fields with it in their Init; see Query and NewObject.
+type Schema struct {
+	mu      sync.RWMutex
+	query   *Object
+	objects map[string]*Object
+}
+
+// NewSchema creates an empty schema
+func NewSchema() *Schema {
+	schema := &Schema{objects: map[string]*Object{}}
+	schema.query = schema.Object("Query", "Root query fields")
+	return schema
+}
+
+// DefaultSchema is the schema served at /api/graphql
+var DefaultSchema = NewSchema()
+
+// Object returns the object type with the name, creating it on first use
+func (s *Schema) Object(name, description string) *Object {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if object, ok := s.objects[name]; ok {
+		if object.Description == "" {
+			object.Description = description
+		}
+		return object
+	}
+	object := &Object{Name: name, Description: description}
+	s.objects[name] = object
+	return object
+}
+
+// Query adds a root query field
+func (s *Schema) Query(name string, field *Field) {
+	s.query.AddField(name, field)
+}
+
+// NewObject returns an object type of the default schema, creating it on first use
+func NewObject(name, description string) *Object {
+	return DefaultSchema.Object(name, description)
+}
+
+// Query adds a root query field to the default schema
+func Query(name string, field *Field) {
+	DefaultSchema.Query(name, field)
+}
+
+// SDL returns the schema in the GraphQL schema definition language
+func (s *Schema) SDL() string {
+	s.mu.RLock()
+	names := make([]string, 0, len(s.objects))
+	for name := range s.objects {
+		names = append(names, name)
+	}
+	s.mu.RUnlock()
+	sort.Slice(names, func(i, j int) bool {
+		// Query first, the other types by name
+		if names[i] == "Query" || names[j] == "Query" {
+			return names[i] == "Query"
+		}
+		return names[i] < names[j]
+	})
+
+	var b strings.Builder
+	b.WriteString("scalar Time\nscalar JSON\n")
+	for _, name := range names {
+		s.mu.RLock()
+		object := s.objects[name]
+		s.mu.RUnlock()
+
+		b.WriteString("\n")
+		if object.Description != "" {
+			fmt.Fprintf(&b, "%q\n", object.Description)
+		}
+		fmt.Fprintf(&b, "type %s {\n", object.Name)
+		for _, fieldName := range object.FieldNames() {
+			field, _ := object.Field(fieldName)
+			if field.Description != "" {
+				fmt.Fprintf(&b, "  %q\n", field.Description)
+			}
+			fmt.Fprintf(&b, "  %s", fieldName)
+			if len(field.Args) > 0 {
+				args := make([]string, len(field.Args))
+				for i, arg := range field.Args {
+					args[i] = arg.Name + ": " + arg.Type.String()
+					switch value := arg.Default.(type) {
+					case nil:
+					case string:
+						args[i] += fmt.Sprintf(" = %q", value)
+					default:
+						args[i] += fmt.Sprintf(" = %v", value)
+					}
+				}
+				fmt.Fprintf(&b, "(%s)", strings.Join(args, ", "))
+			}
+			fmt.Fprintf(&b, ": %s\n", field.Type)
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
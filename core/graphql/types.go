@@ -0,0 +1,63 @@
+package graphql
+
+import (
+	"base/core/storage"
+
+	"gorm.io/gorm"
+)
+
+// Pagination is the pagination of list fields; it reads a types.Pagination
+var Pagination = NewObject("Pagination", "Pagination of a list").
+	AddField("total", &Field{Type: NonNull{Int}}).
+	AddField("page", &Field{Type: NonNull{Int}}).
+	AddField("page_size", &Field{Type: NonNull{Int}}).
+	AddField("total_pages", &Field{Type: NonNull{Int}})
+
+// Attachment is a file attached to a record; it reads a storage.Attachment
+var Attachment = NewObject("Attachment", "File attached to a record").
+	AddField("id", &Field{Type: NonNull{ID}}).
+	AddField("filename", &Field{Type: NonNull{String}}).
+	AddField("path", &Field{Type: NonNull{String}}).
+	AddField("size", &Field{Type: NonNull{Int}}).
+	AddField("url", &Field{Type: NonNull{String}}).
+	AddField("created_at", &Field{Type: Time})
+
+// PageArgs are the arguments of paginated list fields
+func PageArgs() []*Argument {
+	return []*Argument{
+		{Name: "page", Type: Int, Default: 1},
+		{Name: "limit", Type: Int, Default: 10, Description: "Items per page, at most 100"},
+	}
+}
+
+// PageOf reads the page and limit arguments of a paginated list field
+func PageOf(p Params) (page, limit int) {
+	page, _ = p.Args["page"].(int)
+	limit, _ = p.Args["limit"].(int)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+	return page, limit
+}
+
+// BatchAttachments returns a Batch resolver that loads the attachments of a field, e.g. the
+// avatars of users, for all sources with one query; id returns the record id of a source
+func BatchAttachments(db *gorm.DB, modelType, field string, id func(source any) (uint, bool)) func(Params, []any) ([]any, error) {
+	return func(p Params, sources []any) ([]any, error) {
+		return BatchBy(sources, id, func(ids []uint) (map[uint]*storage.Attachment, error) {
+			var attachments []*storage.Attachment
+			if err := db.Where("model_type = ? AND field = ? AND model_id IN ?", modelType, field, ids).
+				Order("id").Find(&attachments).Error; err != nil {
+				return nil, err
+			}
+			byId := make(map[uint]*storage.Attachment, len(attachments))
+			for _, attachment := range attachments {
+				byId[attachment.ModelId] = attachment // the latest one wins
+			}
+			return byId, nil
+		})
+	}
+}
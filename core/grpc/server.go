@@ -0,0 +1,178 @@
+// Package grpc serves internal services, such as user lookup, authorization checks and
+// notifications, over gRPC on their own port, for other services that shouldn't go through
+// the REST API. The services are defined in pb/base.proto; modules implement them with their
+// own services, so gRPC and HTTP share the service layer, and register them in their Init.
+package grpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"base/core/logger"
+	"base/core/validator"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+// APIKeyHeader is the metadata key clients send the API key in
+const APIKeyHeader = "x-api-key"
+
+var (
+	mu            sync.Mutex
+	registrations []func(grpc.ServiceRegistrar)
+)
+
+// Register adds services to the server, e.g.
+//
+//	coregrpc.Register(func(s grpc.ServiceRegistrar) { pb.RegisterUsersServer(s, &usersServer{service}) })
+//
+// Modules call it in their Init; the services are registered when the server starts. A
+// service registered again, e.g. by a module created twice, replaces the earlier one.
+func Register(register func(s grpc.ServiceRegistrar)) {
+	mu.Lock()
+	defer mu.Unlock()
+	registrations = append(registrations, register)
+}
+
+// Server is the gRPC server of the internal services
+type Server struct {
+	server *grpc.Server
+	health *health.Server
+	port   string
+	logger logger.Logger
+}
+
+// NewServer creates a server that serves the registered services on a port, e.g. ":9090".
+// Every call must send the API key.
+func NewServer(port, apiKey string, log logger.Logger) (*Server, error) {
+	if apiKey == "" {
+		return nil, errors.New("GRPC_API_KEY is required to run the gRPC server")
+	}
+
+	s := &Server{port: port, logger: log, health: health.NewServer()}
+	s.server = grpc.NewServer(grpc.ChainUnaryInterceptor(
+		s.logCalls,
+		authenticate(apiKey),
+	))
+
+	mu.Lock()
+	registrar := &latestRegistrar{server: s.server}
+	for i := len(registrations) - 1; i >= 0; i-- {
+		registrations[i](registrar)
+	}
+	mu.Unlock()
+	healthpb.RegisterHealthServer(s.server, s.health)
+
+	return s, nil
+}
+
+// latestRegistrar registers the services on a server once, so registering the latest
+// registrations first keeps them
+type latestRegistrar struct {
+	server *grpc.Server
+}
+
+func (r *latestRegistrar) RegisterService(desc *grpc.ServiceDesc, impl any) {
+	if _, ok := r.server.GetServiceInfo()[desc.ServiceName]; ok {
+		return
+	}
+	r.server.RegisterService(desc, impl)
+}
+
+// Start listens on the port and serves in the background
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.port)
+	if err != nil {
+		return fmt.Errorf("gRPC server failed to listen on %s: %w", s.port, err)
+	}
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil {
+			s.logger.Error("gRPC server stopped", logger.String("error", err.Error()))
+		}
+	}()
+
+	services := make([]string, 0, len(s.server.GetServiceInfo()))
+	for name := range s.server.GetServiceInfo() {
+		services = append(services, name)
+	}
+	s.logger.Info("gRPC server started",
+		logger.String("port", s.port),
+		logger.Any("services", services))
+	return nil
+}
+
+// Stop waits for the running calls to finish, at most for the timeout
+func (s *Server) Stop(timeout time.Duration) {
+	s.health.Shutdown()
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		s.server.Stop()
+	}
+}
+
+// authenticate rejects calls without the API key. The health service stays open so load
+// balancers can check the server.
+func authenticate(apiKey string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if info.FullMethod == healthpb.Health_Check_FullMethodName {
+			return handler(ctx, req)
+		}
+		md, _ := metadata.FromIncomingContext(ctx)
+		keys := md.Get(APIKeyHeader)
+		if len(keys) == 0 || subtle.ConstantTimeCompare([]byte(keys[0]), []byte(apiKey)) != 1 {
+			return nil, status.Error(codes.Unauthenticated, "invalid or missing API key")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// logCalls logs every call with its status code and duration
+func (s *Server) logCalls(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	fields := []logger.Field{
+		logger.String("method", info.FullMethod),
+		logger.String("code", status.Code(err).String()),
+		logger.Duration("duration", time.Since(start)),
+	}
+	if status.Code(err) == codes.Internal || status.Code(err) == codes.Unknown {
+		s.logger.Error("gRPC call", append(fields, logger.String("error", err.Error()))...)
+	} else {
+		s.logger.Info("gRPC call", fields...)
+	}
+	return resp, err
+}
+
+// Error converts an error of a service to a gRPC status, like the HTTP controllers do for
+// their responses: not found, invalid argument or internal
+func Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	var validationErrors validator.ValidationErrors
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return status.Error(codes.NotFound, "not found")
+	case errors.As(err, &validationErrors):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
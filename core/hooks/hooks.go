@@ -0,0 +1,88 @@
+// Package hooks lets modules run code around the writes of other modules' models without
+// editing their services. The shared crud service runs the hooks registered for the events
+// prefix of its model and the stage of the write:
+//
+//	hooks.Register("notifications.before_create", hooks.Typed(func(tx *gorm.DB, item *notifications.Notification) error {
+//		item.Title = strings.TrimSpace(item.Title)
+//		return nil
+//	}))
+//
+// Hooks registered for "*.<stage>" run for every model, e.g. to invalidate caches.
+package hooks
+
+import (
+	"fmt"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// Stages of a write
+const (
+	BeforeCreate = "before_create"
+	AfterCreate  = "after_create"
+	BeforeUpdate = "before_update"
+	AfterUpdate  = "after_update"
+	BeforeDelete = "before_delete"
+	AfterDelete  = "after_delete"
+)
+
+// Func is a hook. It runs inside the transaction of the write, whose context is
+// tx.Statement.Context; an error rolls the write back and is returned by it.
+type Func func(tx *gorm.DB, item any) error
+
+// Registry holds the hooks by name ("<events>.<stage>")
+type Registry struct {
+	mu    sync.RWMutex
+	hooks map[string][]Func
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{hooks: make(map[string][]Func)}
+}
+
+// Default is the registry of the crud services
+var Default = NewRegistry()
+
+// Register adds a hook to the default registry
+func Register(name string, fn Func) {
+	Default.Register(name, fn)
+}
+
+// Register adds a hook; hooks of the same name run in the order they were registered
+func (r *Registry) Register(name string, fn Func) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks[name] = append(r.hooks[name], fn)
+}
+
+// Run runs the hooks of a stage for an events prefix, those for every model first, and
+// stops at the first error
+func (r *Registry) Run(events, stage string, tx *gorm.DB, item any) error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.RLock()
+	fns := append(append([]Func(nil), r.hooks["*."+stage]...), r.hooks[events+"."+stage]...)
+	r.mu.RUnlock()
+
+	for _, fn := range fns {
+		if err := fn(tx, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Typed adapts a hook for items of one model; it fails on items of other types
+func Typed[T any](fn func(tx *gorm.DB, item *T) error) Func {
+	return func(tx *gorm.DB, item any) error {
+		typed, ok := item.(*T)
+		if !ok {
+			return fmt.Errorf("hook for %T got %T", typed, item)
+		}
+		return fn(tx, typed)
+	}
+}
@@ -0,0 +1,154 @@
+package locks
+
+import (
+	"errors"
+	"net/http"
+
+	"base/core/router"
+	"base/core/types"
+)
+
+// Routes registers the lock endpoints of a resource below its admin route, e.g.
+// /settings/:id/lock, and the list of its locks at /locks/<resource>. Modules call it at
+// the end of their admin routes, since the router doesn't take static segments next to an
+// :id.
+func Routes(group *router.RouterGroup, resource string) {
+	group.GET("/locks/"+resource, list(resource))
+	group.GET("/"+resource+"/:id/lock", get(resource))
+	group.POST("/"+resource+"/:id/lock", acquire(resource))
+	group.PUT("/"+resource+"/:id/lock", heartbeat(resource))
+	group.DELETE("/"+resource+"/:id/lock", release(resource))
+}
+
+// Guard rejects the requests of users changing a record of the resource while someone
+// else holds its lock, with 423 Locked and the lock
+func Guard(resource string) router.MiddlewareFunc {
+	return func(next router.HandlerFunc) router.HandlerFunc {
+		return func(ctx *router.Context) error {
+			if err := Default.Check(resource, ctx.Param("id"), ctx.GetUint("user_id")); err != nil {
+				return fail(ctx, err, http.StatusLocked)
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// ListLocks godoc
+// @Summary List the locks of a resource
+// @Description List the records of a resource being edited and who is editing them, oldest first, e.g. to mark them in lists (Admin only)
+// @Tags Core/Locks
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param resource path string true "Records, e.g. settings or pages"
+// @Success 200 {array} Lock
+// @Router /locks/{resource} [get]
+func list(resource string) router.HandlerFunc {
+	return func(ctx *router.Context) error {
+		return ctx.JSON(http.StatusOK, Default.List(resource))
+	}
+}
+
+// GetLock godoc
+// @Summary Get the lock of a record
+// @Description Get who is editing a setting or a page and until when their lock lasts without a heartbeat (Admin only)
+// @Tags Core/Locks
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param resource path string true "Records, e.g. settings or pages"
+// @Param id path int true "Record id"
+// @Success 200 {object} Lock
+// @Failure 404 {object} types.ErrorResponse
+// @Router /{resource}/{id}/lock [get]
+func get(resource string) router.HandlerFunc {
+	return func(ctx *router.Context) error {
+		lock := Default.Get(resource, ctx.Param("id"))
+		if lock == nil {
+			return fail(ctx, ErrNotLocked, http.StatusConflict)
+		}
+		return ctx.JSON(http.StatusOK, lock)
+	}
+}
+
+// AcquireLock godoc
+// @Summary Lock a record
+// @Description Lock a setting or a page while editing it, or renew your lock. Locks expire after LOCK_TTL without a heartbeat; records locked by someone else get 409 with their lock (Admin only)
+// @Tags Core/Locks
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param resource path string true "Records, e.g. settings or pages"
+// @Param id path int true "Record id"
+// @Success 200 {object} Lock
+// @Failure 409 {object} types.ErrorResponse
+// @Router /{resource}/{id}/lock [post]
+func acquire(resource string) router.HandlerFunc {
+	return func(ctx *router.Context) error {
+		lock, err := Default.Lock(ctx.Request.Context(), resource, ctx.Param("id"), ctx.GetUint("user_id"))
+		if err != nil {
+			return fail(ctx, err, http.StatusConflict)
+		}
+		return ctx.JSON(http.StatusOK, lock)
+	}
+}
+
+// HeartbeatLock godoc
+// @Summary Renew the lock of a record
+// @Description Extend your lock of a setting or a page by LOCK_TTL; editors send it periodically while the form is open. Expired locks get 404, locks taken over by someone else 409 (Admin only)
+// @Tags Core/Locks
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Produce json
+// @Param resource path string true "Records, e.g. settings or pages"
+// @Param id path int true "Record id"
+// @Success 200 {object} Lock
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 409 {object} types.ErrorResponse
+// @Router /{resource}/{id}/lock [put]
+func heartbeat(resource string) router.HandlerFunc {
+	return func(ctx *router.Context) error {
+		lock, err := Default.Heartbeat(ctx.Request.Context(), resource, ctx.Param("id"), ctx.GetUint("user_id"))
+		if err != nil {
+			return fail(ctx, err, http.StatusConflict)
+		}
+		return ctx.JSON(http.StatusOK, lock)
+	}
+}
+
+// ReleaseLock godoc
+// @Summary Release the lock of a record
+// @Description Release your lock of a setting or a page when you stop editing it; with force=true, release someone else's lock to take over the record (Admin only)
+// @Tags Core/Locks
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param resource path string true "Records, e.g. settings or pages"
+// @Param id path int true "Record id"
+// @Param force query bool false "Release someone else's lock"
+// @Success 204 "No Content"
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 409 {object} types.ErrorResponse
+// @Router /{resource}/{id}/lock [delete]
+func release(resource string) router.HandlerFunc {
+	return func(ctx *router.Context) error {
+		force := ctx.Query("force") == "true"
+		if err := Default.Unlock(ctx.Request.Context(), resource, ctx.Param("id"), ctx.GetUint("user_id"), force); err != nil {
+			return fail(ctx, err, http.StatusConflict)
+		}
+		ctx.Status(http.StatusNoContent)
+		return nil
+	}
+}
+
+// fail writes the error response of a lock error; records locked by someone else get the
+// status given with their lock
+func fail(ctx *router.Context, err error, lockedStatus int) error {
+	var locked *LockedError
+	if errors.As(err, &locked) {
+		return ctx.JSON(lockedStatus, types.ErrorResponse{Error: locked.Error(), Details: locked.Lock})
+	}
+	if errors.Is(err, ErrNotLocked) {
+		return ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: err.Error()})
+	}
+	return ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: err.Error()})
+}
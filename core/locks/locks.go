@@ -0,0 +1,289 @@
+// Package locks keeps soft locks on the records admins are editing, so a second admin
+// opening a setting or a page sees who is editing it instead of overwriting their changes.
+// Editors lock a record when they open it, renew the lock with heartbeats while the form
+// stays open and release it when they leave; locks nobody renews expire. Locks are kept in
+// memory: they are hints between editors, not transactions, and don't survive restarts.
+//
+// Modules add the lock endpoints below their admin routes and guard their writes:
+//
+//	router.PUT("/settings/:id", c.Update, locks.Guard("settings"))
+//	locks.Routes(router, "settings") // /settings/:id/lock
+package locks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"base/core/emitter"
+
+	"gorm.io/gorm"
+)
+
+// Events emitted with the *Lock when a record is locked and when its lock is released or
+// expires; the server forwards them to the WebSocket rooms of the record
+const (
+	LockEvent   = "locks.lock"
+	UnlockEvent = "locks.unlock"
+)
+
+// DefaultTTL is how long locks last without a heartbeat
+const DefaultTTL = 2 * time.Minute
+
+// sweepInterval is how often expired locks are released
+const sweepInterval = 15 * time.Second
+
+// ErrNotLocked is returned for heartbeats and releases of records that aren't locked
+var ErrNotLocked = errors.New("record is not locked")
+
+// Lock is the lock of a record
+type Lock struct {
+	Resource  string    `json:"resource"`  // e.g. settings
+	RecordId  string    `json:"record_id"` // Id of the record, as in the URL
+	UserId    uint      `json:"user_id"`
+	UserName  string    `json:"user_name"` // Who is editing, e.g. Jane Doe
+	LockedAt  time.Time `json:"locked_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Room returns the WebSocket room of the record, e.g. settings:12
+func (l *Lock) Room() string {
+	return l.Resource + ":" + l.RecordId
+}
+
+// LockedError is returned when someone else holds the lock of a record
+type LockedError struct {
+	Lock *Lock
+}
+
+func (e *LockedError) Error() string {
+	return "locked by " + e.Lock.UserName
+}
+
+// Store holds the locks. It is safe for concurrent use.
+type Store struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	db      *gorm.DB
+	emitter *emitter.Emitter
+	locks   map[string]*Lock
+	once    sync.Once
+}
+
+// NewStore creates an empty store whose locks last ttl without a heartbeat
+func NewStore(ttl time.Duration) *Store {
+	return &Store{ttl: ttl, locks: make(map[string]*Lock)}
+}
+
+// Default is the store of the lock endpoints and guards; the server configures it from
+// LOCK_TTL
+var Default = NewStore(DefaultTTL)
+
+// Configure sets how long locks last without a heartbeat (0 keeps the current TTL), the
+// database the names of the users are read from and the emitter of the lock events. It
+// drops the locks held and starts releasing expired locks in the background.
+func (s *Store) Configure(ttl time.Duration, db *gorm.DB, e *emitter.Emitter) {
+	s.mu.Lock()
+	if ttl > 0 {
+		s.ttl = ttl
+	}
+	s.db, s.emitter = db, e
+	s.locks = make(map[string]*Lock)
+	s.mu.Unlock()
+
+	s.once.Do(func() {
+		go func() {
+			ticker := time.NewTicker(sweepInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				s.Expire(context.Background())
+			}
+		}()
+	})
+}
+
+// Lock locks a record for a user, or renews their lock. It fails with a *LockedError when
+// someone else holds it.
+func (s *Store) Lock(ctx context.Context, resource, id string, userId uint) (*Lock, error) {
+	name := s.userName(ctx, userId)
+
+	s.mu.Lock()
+	now := time.Now()
+	current, err := s.current(resource, id, userId, now)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	lock := &Lock{Resource: resource, RecordId: id, UserId: userId, UserName: name, LockedAt: now, ExpiresAt: now.Add(s.ttl)}
+	if current != nil {
+		lock.LockedAt = current.LockedAt
+	}
+	s.locks[key(resource, id)] = lock
+	s.mu.Unlock()
+
+	if current == nil {
+		s.emit(ctx, LockEvent, lock)
+	}
+	return copyOf(lock), nil
+}
+
+// Heartbeat extends the lock of a user by the TTL. It fails with ErrNotLocked when the lock
+// expired, and with a *LockedError when someone else took it since.
+func (s *Store) Heartbeat(ctx context.Context, resource, id string, userId uint) (*Lock, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, err := s.current(resource, id, userId, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, ErrNotLocked
+	}
+	current.ExpiresAt = time.Now().Add(s.ttl)
+	return copyOf(current), nil
+}
+
+// Unlock releases the lock of a user. With force, it releases anyone's lock, e.g. for
+// admins taking over a record left open.
+func (s *Store) Unlock(ctx context.Context, resource, id string, userId uint, force bool) error {
+	s.mu.Lock()
+	lock, ok := s.locks[key(resource, id)]
+	if !ok || lock.expired(time.Now()) {
+		s.mu.Unlock()
+		return ErrNotLocked
+	}
+	if lock.UserId != userId && !force {
+		s.mu.Unlock()
+		return &LockedError{Lock: copyOf(lock)}
+	}
+	delete(s.locks, key(resource, id))
+	s.mu.Unlock()
+
+	s.emit(ctx, UnlockEvent, lock)
+	return nil
+}
+
+// Get returns the lock of a record, or nil when it isn't locked
+func (s *Store) Get(resource, id string) *Lock {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lock, ok := s.locks[key(resource, id)]
+	if !ok || lock.expired(time.Now()) {
+		return nil
+	}
+	return copyOf(lock)
+}
+
+// Check returns a *LockedError when someone else than the user holds the lock of a record
+func (s *Store) Check(resource, id string, userId uint) error {
+	if lock := s.Get(resource, id); lock != nil && lock.UserId != userId {
+		return &LockedError{Lock: lock}
+	}
+	return nil
+}
+
+// List returns the locks of the records of a resource, oldest first
+func (s *Store) List(resource string) []*Lock {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	items := []*Lock{}
+	for _, lock := range s.locks {
+		if lock.Resource == resource && !lock.expired(now) {
+			items = append(items, copyOf(lock))
+		}
+	}
+	slices.SortFunc(items, func(a, b *Lock) int {
+		return a.LockedAt.Compare(b.LockedAt)
+	})
+	return items
+}
+
+// Expire releases the locks nobody renewed in time
+func (s *Store) Expire(ctx context.Context) {
+	s.mu.Lock()
+	now := time.Now()
+	var expired []*Lock
+	for k, lock := range s.locks {
+		if lock.expired(now) {
+			expired = append(expired, lock)
+			delete(s.locks, k)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, lock := range expired {
+		s.emit(ctx, UnlockEvent, lock)
+	}
+}
+
+// current returns the live lock of a record held by the user, nil when there is none, and a
+// *LockedError when someone else holds it. The caller holds the mutex.
+func (s *Store) current(resource, id string, userId uint, now time.Time) (*Lock, error) {
+	lock, ok := s.locks[key(resource, id)]
+	if !ok || lock.expired(now) {
+		return nil, nil
+	}
+	if lock.UserId != userId {
+		return nil, &LockedError{Lock: copyOf(lock)}
+	}
+	return lock, nil
+}
+
+// userName returns the name other editors see for a user
+func (s *Store) userName(ctx context.Context, userId uint) string {
+	s.mu.Lock()
+	db := s.db
+	s.mu.Unlock()
+
+	fallback := fmt.Sprintf("user %d", userId)
+	if db == nil {
+		return fallback
+	}
+	var user struct {
+		FirstName string
+		LastName  string
+		Username  string
+	}
+	if err := db.WithContext(ctx).Table("users").Select("first_name, last_name, username").
+		Where("id = ?", userId).Take(&user).Error; err != nil {
+		return fallback
+	}
+	if name := strings.TrimSpace(user.FirstName + " " + user.LastName); name != "" {
+		return name
+	}
+	if user.Username != "" {
+		return user.Username
+	}
+	return fallback
+}
+
+func (s *Store) emit(ctx context.Context, event string, lock *Lock) {
+	s.mu.Lock()
+	e := s.emitter
+	s.mu.Unlock()
+
+	if e != nil {
+		e.EmitContext(ctx, event, copyOf(lock))
+	}
+}
+
+func (l *Lock) expired(now time.Time) bool {
+	return !now.Before(l.ExpiresAt)
+}
+
+func key(resource, id string) string {
+	return resource + ":" + id
+}
+
+func copyOf(lock *Lock) *Lock {
+	c := *lock
+	return &c
+}
@@ -0,0 +1,236 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// moduleLevels holds the per-module level overrides set with SetModuleLevel
+var moduleLevels = struct {
+	sync.RWMutex
+	levels map[string]zapcore.Level
+}{levels: make(map[string]zapcore.Level)}
+
+// modules records the names passed to ForModule, so they can be listed
+var modules sync.Map
+
+// Modules returns the names of the modules that have a logger, sorted
+func Modules() []string {
+	var names []string
+	modules.Range(func(key, _ any) bool {
+		names = append(names, key.(string))
+		return true
+	})
+	slices.Sort(names)
+	return names
+}
+
+// ValidateLevel checks that level is one of debug, info, warn, error, dpanic, panic or fatal
+func ValidateLevel(level string) error {
+	var l zapcore.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid level %q", level)
+	}
+	return nil
+}
+
+// SetModuleLevel overrides the minimum level for loggers created with ForModule(name)
+func SetModuleLevel(module, level string) error {
+	module = strings.TrimSpace(module)
+	if module == "" {
+		return fmt.Errorf("module name is required")
+	}
+
+	var l zapcore.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid level %q for module %s", level, module)
+	}
+
+	moduleLevels.Lock()
+	moduleLevels.levels[module] = l
+	moduleLevels.Unlock()
+	return nil
+}
+
+// ClearModuleLevel removes the override for a module so it follows the global level again
+func ClearModuleLevel(module string) {
+	moduleLevels.Lock()
+	delete(moduleLevels.levels, module)
+	moduleLevels.Unlock()
+}
+
+// SetModuleLevels replaces all module overrides
+func SetModuleLevels(levels map[string]string) error {
+	parsed := make(map[string]zapcore.Level, len(levels))
+	for module, level := range levels {
+		var l zapcore.Level
+		if err := l.UnmarshalText([]byte(level)); err != nil {
+			return fmt.Errorf("invalid level %q for module %s", level, module)
+		}
+		parsed[module] = l
+	}
+
+	moduleLevels.Lock()
+	moduleLevels.levels = parsed
+	moduleLevels.Unlock()
+	return nil
+}
+
+// ModuleLevels returns the current module overrides
+func ModuleLevels() map[string]string {
+	moduleLevels.RLock()
+	defer moduleLevels.RUnlock()
+
+	levels := make(map[string]string, len(moduleLevels.levels))
+	for module, level := range moduleLevels.levels {
+		levels[module] = level.String()
+	}
+	return levels
+}
+
+// moduleLevel returns the override for a module. "media.images" falls back to "media".
+func moduleLevel(module string) (zapcore.Level, bool) {
+	moduleLevels.RLock()
+	defer moduleLevels.RUnlock()
+
+	for module != "" {
+		if level, ok := moduleLevels.levels[module]; ok {
+			return level, true
+		}
+		i := strings.LastIndexByte(module, '.')
+		if i < 0 {
+			break
+		}
+		module = module[:i]
+	}
+	return 0, false
+}
+
+// levelCore decides which entries are written. The cores it wraps accept every level,
+// so the global level, module overrides and per-request elevation are all applied here.
+type levelCore struct {
+	zapcore.Core
+	global   zap.AtomicLevel
+	module   string
+	elevated bool
+}
+
+// Enabled reports whether the entry level should be written
+func (c *levelCore) Enabled(level zapcore.Level) bool {
+	if c.elevated {
+		return true
+	}
+	if min, ok := moduleLevel(c.module); ok {
+		return level >= min
+	}
+	return c.global.Enabled(level)
+}
+
+// With adds fields while keeping the level settings
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.Core = c.Core.With(fields)
+	return &clone
+}
+
+// Check adds the wrapped cores to the checked entry when the level is enabled
+func (c *levelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(entry.Level) {
+		return checked
+	}
+	return c.Core.Check(entry, checked)
+}
+
+// withLevelCore returns a copy of the logger with its levelCore changed by fn. Loggers
+// not created by NewLogger are returned unchanged.
+func withLevelCore(l Logger, fn func(*levelCore)) (Logger, bool) {
+	zl, ok := l.(*ZapLogger)
+	if !ok {
+		return l, false
+	}
+	if _, ok := zl.logger.Core().(*levelCore); !ok {
+		return l, false
+	}
+
+	logger := zl.logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		clone := *core.(*levelCore)
+		fn(&clone)
+		return &clone
+	}))
+	return &ZapLogger{logger: logger, level: zl.level}, true
+}
+
+// ForModule returns a logger for a module or package. Its entries carry the module name
+// and its level can be changed independently with SetModuleLevel.
+func ForModule(l Logger, name string) Logger {
+	if l == nil {
+		return nil
+	}
+	modules.Store(name, true)
+	if ml, ok := withLevelCore(l, func(c *levelCore) { c.module = name }); ok {
+		return ml.With(String("module", name))
+	}
+	return l.With(String("module", name))
+}
+
+// Elevate returns a logger that writes every level, regardless of the global and module levels
+func Elevate(l Logger) Logger {
+	if l == nil {
+		return nil
+	}
+	el, _ := withLevelCore(l, func(c *levelCore) { c.elevated = true })
+	return el
+}
+
+// ParseModuleLevels parses a list such as "storage=debug,router=warn"
+func ParseModuleLevels(value string) (map[string]string, error) {
+	levels := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		module, level, found := strings.Cut(pair, "=")
+		module, level = strings.TrimSpace(module), strings.TrimSpace(level)
+		if !found || module == "" {
+			return nil, fmt.Errorf("%q is not in module=level form", pair)
+		}
+		var l zapcore.Level
+		if err := l.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("invalid level %q for module %s", level, module)
+		}
+		levels[module] = l.String()
+	}
+	return levels, nil
+}
+
+// debugKey marks a request context whose logging is elevated
+type debugKey struct{}
+
+// WithDebug marks the context so loggers obtained through FromContext log every level
+func WithDebug(ctx context.Context) context.Context {
+	return context.WithValue(ctx, debugKey{}, true)
+}
+
+// IsDebug reports whether the context was marked with WithDebug
+func IsDebug(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	debug, _ := ctx.Value(debugKey{}).(bool)
+	return debug
+}
+
+// FromContext returns l, elevated when the context belongs to a debug request
+func FromContext(ctx context.Context, l Logger) Logger {
+	if IsDebug(ctx) {
+		return Elevate(l)
+	}
+	return l
+}
@@ -29,11 +29,19 @@ type Config struct {
 	Environment string // "development" or "production"
 	LogPath     string // Path to log directory
 	Level       string // "debug", "info", "warn", "error", "fatal"
+	Sinks       []Sink // remote outputs in addition to the log file and stdout
+}
+
+// LevelController is implemented by loggers whose minimum level can change at runtime
+type LevelController interface {
+	SetLevel(level string) error
+	Level() string
 }
 
 // ZapLogger implements the Logger interface using zap
 type ZapLogger struct {
 	logger *zap.Logger
+	level  *zap.AtomicLevel // nil when wrapping an existing zap logger
 }
 
 // timeEncoder encodes the time as RFC3339Nano
@@ -104,22 +112,29 @@ func NewLogger(config Config) (Logger, error) {
 	consoleConfig.ConsoleSeparator = "  "
 	consoleEncoder := zapcore.NewConsoleEncoder(consoleConfig)
 
-	// Create multi-writer core
-	core := zapcore.NewTee(
+	// Create multi-writer core. The outputs accept every level; levelCore applies the
+	// global level, module overrides and per-request elevation.
+	cores := []zapcore.Core{
 		zapcore.NewCore(
 			encoder,
 			zapcore.AddSync(f),
-			level,
+			zapcore.DebugLevel,
 		),
 		zapcore.NewCore(
 			consoleEncoder,
 			zapcore.AddSync(os.Stdout),
-			level,
+			zapcore.DebugLevel,
 		),
-	)
+	}
+
+	// Remote sinks receive the same JSON entries as the log file
+	for _, sink := range config.Sinks {
+		cores = append(cores, zapcore.NewCore(encoder, sink, zapcore.DebugLevel))
+	}
+	core := &levelCore{Core: zapcore.NewTee(cores...), global: level}
 	logger := zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1))
 
-	return &ZapLogger{logger: logger}, nil
+	return &ZapLogger{logger: logger, level: &level}, nil
 }
 
 // NewLoggerFromZap creates a new Logger from an existing zap.Logger
@@ -197,5 +212,22 @@ func (l *ZapLogger) Fatal(msg string, fields ...Field) {
 }
 
 func (l *ZapLogger) With(fields ...Field) Logger {
-	return &ZapLogger{logger: l.logger.With(fields...)}
+	return &ZapLogger{logger: l.logger.With(fields...), level: l.level}
+}
+
+// SetLevel changes the minimum level ("debug", "info", "warn", "error") of the logger
+// and every logger derived from it with With
+func (l *ZapLogger) SetLevel(level string) error {
+	if l.level == nil {
+		return fmt.Errorf("logger level can't be changed")
+	}
+	return l.level.UnmarshalText([]byte(level))
+}
+
+// Level returns the current minimum level
+func (l *ZapLogger) Level() string {
+	if l.level == nil {
+		return ""
+	}
+	return l.level.String()
 }
@@ -0,0 +1,239 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Sink is a remote log output. Entries are JSON encoded lines; Write never blocks and drops
+// entries when the buffer is full.
+type Sink interface {
+	io.Writer
+	Sync() error
+	Close() error
+	Name() string
+	Stats() SinkStats
+}
+
+// SinkOptions controls buffering for remote sinks
+type SinkOptions struct {
+	BufferSize    int           // entries kept in memory before new ones are dropped
+	BatchSize     int           // entries sent per request
+	FlushInterval time.Duration // maximum time an entry waits before being sent
+	Name          string        // name in metrics, defaults to the kind of sink
+}
+
+// SinkStats are the delivery counters of a sink
+type SinkStats struct {
+	Name    string `json:"name"`
+	Sent    int64  `json:"sent"`
+	Dropped int64  `json:"dropped"`
+	Failed  int64  `json:"failed"`
+}
+
+// sinkEntry is a buffered log line
+type sinkEntry struct {
+	Time  time.Time
+	Level string
+	Line  []byte
+}
+
+var (
+	// sinks stores every sink created so their stats can be reported
+	sinks   []Sink
+	sinksMu sync.RWMutex
+)
+
+// GetSinks returns all sinks created so far
+func GetSinks() []Sink {
+	sinksMu.RLock()
+	defer sinksMu.RUnlock()
+	return append([]Sink(nil), sinks...)
+}
+
+// WriteSinkMetrics writes the sink counters in the Prometheus text format
+func WriteSinkMetrics(w io.Writer) error {
+	all := GetSinks()
+	if len(all) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w, "# HELP log_sink_entries_total Log entries handled by remote sinks, by result.")
+	fmt.Fprintln(w, "# TYPE log_sink_entries_total counter")
+	for _, sink := range all {
+		stats := sink.Stats()
+		fmt.Fprintf(w, "log_sink_entries_total{sink=%q,result=\"sent\"} %d\n", stats.Name, stats.Sent)
+		fmt.Fprintf(w, "log_sink_entries_total{sink=%q,result=\"dropped\"} %d\n", stats.Name, stats.Dropped)
+		if _, err := fmt.Fprintf(w, "log_sink_entries_total{sink=%q,result=\"failed\"} %d\n", stats.Name, stats.Failed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bufferedSink queues entries and hands them to send in batches from a background goroutine
+type bufferedSink struct {
+	name    string
+	send    func(entries []sinkEntry) error
+	options SinkOptions
+
+	entries chan sinkEntry
+	flush   chan chan struct{}
+	done    chan struct{}
+	once    sync.Once
+
+	sent    atomic.Int64
+	dropped atomic.Int64
+	failed  atomic.Int64
+}
+
+// newBufferedSink starts a sink and registers it for stats reporting
+func newBufferedSink(name string, options SinkOptions, send func(entries []sinkEntry) error) *bufferedSink {
+	if options.BufferSize <= 0 {
+		options.BufferSize = 1000
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = 100
+	}
+	if options.FlushInterval <= 0 {
+		options.FlushInterval = 2 * time.Second
+	}
+	if options.Name != "" {
+		name = options.Name
+	}
+
+	s := &bufferedSink{
+		name:    name,
+		send:    send,
+		options: options,
+		entries: make(chan sinkEntry, options.BufferSize),
+		flush:   make(chan chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go s.run()
+
+	sinksMu.Lock()
+	sinks = append(sinks, s)
+	sinksMu.Unlock()
+
+	return s
+}
+
+// Write queues one encoded entry. It never blocks; entries are dropped when the buffer is full.
+func (s *bufferedSink) Write(p []byte) (int, error) {
+	line := bytes.TrimRight(p, "\n")
+	entry := sinkEntry{
+		Time:  time.Now(),
+		Level: entryLevel(line),
+		Line:  append([]byte(nil), line...),
+	}
+
+	select {
+	case s.entries <- entry:
+	default:
+		s.dropped.Add(1)
+	}
+	return len(p), nil
+}
+
+// Sync sends everything buffered so far
+func (s *bufferedSink) Sync() error {
+	done := make(chan struct{})
+	select {
+	case s.flush <- done:
+	case <-s.done:
+		return nil
+	}
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		return fmt.Errorf("%s sink: flush timed out", s.name)
+	}
+	return nil
+}
+
+// Close flushes the buffer and stops the sink
+func (s *bufferedSink) Close() error {
+	err := s.Sync()
+	s.once.Do(func() { close(s.done) })
+	return err
+}
+
+// Name returns the sink name used in metrics
+func (s *bufferedSink) Name() string {
+	return s.name
+}
+
+// Stats returns the delivery counters
+func (s *bufferedSink) Stats() SinkStats {
+	return SinkStats{
+		Name:    s.name,
+		Sent:    s.sent.Load(),
+		Dropped: s.dropped.Load(),
+		Failed:  s.failed.Load(),
+	}
+}
+
+func (s *bufferedSink) run() {
+	ticker := time.NewTicker(s.options.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]sinkEntry, 0, s.options.BatchSize)
+	deliver := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := s.send(batch); err != nil {
+			s.failed.Add(int64(len(batch)))
+		} else {
+			s.sent.Add(int64(len(batch)))
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case entry := <-s.entries:
+			batch = append(batch, entry)
+			if len(batch) >= s.options.BatchSize {
+				deliver()
+			}
+		case <-ticker.C:
+			deliver()
+		case done := <-s.flush:
+			// Drain whatever is queued, then send
+			for drained := false; !drained; {
+				select {
+				case entry := <-s.entries:
+					batch = append(batch, entry)
+					if len(batch) >= s.options.BatchSize {
+						deliver()
+					}
+				default:
+					drained = true
+				}
+			}
+			deliver()
+			close(done)
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// entryLevel reads the level from a JSON encoded entry
+func entryLevel(line []byte) string {
+	var entry struct {
+		Level string `json:"level"`
+	}
+	if err := json.Unmarshal(line, &entry); err != nil || entry.Level == "" {
+		return "info"
+	}
+	return entry.Level
+}
@@ -0,0 +1,26 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// NewHTTPSink creates a sink that posts entries to an HTTP endpoint, each batch as a JSON
+// array of the entries, with the given headers (e.g. Authorization)
+func NewHTTPSink(url string, headers map[string]string, options SinkOptions) Sink {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	return newBufferedSink("http", options, func(entries []sinkEntry) error {
+		batch := make([]json.RawMessage, len(entries))
+		for i, entry := range entries {
+			batch[i] = entry.Line
+		}
+
+		body, err := json.Marshal(batch)
+		if err != nil {
+			return err
+		}
+		return postJSON(client, url, headers, body)
+	})
+}
@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NewLokiSink creates a sink that pushes entries to Grafana Loki. url is the Loki base URL
+// (e.g. http://loki:3100); every stream gets the given labels plus a level label.
+func NewLokiSink(url string, labels map[string]string, options SinkOptions) Sink {
+	endpoint := strings.TrimSuffix(url, "/")
+	if !strings.HasSuffix(endpoint, "/loki/api/v1/push") {
+		endpoint += "/loki/api/v1/push"
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	return newBufferedSink("loki", options, func(entries []sinkEntry) error {
+		type stream struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"`
+		}
+
+		// One stream per level
+		streams := make(map[string]*stream)
+		for _, entry := range entries {
+			s, ok := streams[entry.Level]
+			if !ok {
+				streamLabels := map[string]string{"level": entry.Level}
+				for key, value := range labels {
+					streamLabels[key] = value
+				}
+				s = &stream{Stream: streamLabels}
+				streams[entry.Level] = s
+			}
+			s.Values = append(s.Values, [2]string{strconv.FormatInt(entry.Time.UnixNano(), 10), string(entry.Line)})
+		}
+
+		payload := struct {
+			Streams []*stream `json:"streams"`
+		}{}
+		for _, s := range streams {
+			payload.Streams = append(payload.Streams, s)
+		}
+
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		return postJSON(client, endpoint, nil, body)
+	})
+}
+
+// postJSON posts a JSON body and treats any non-2xx response as an error
+func postJSON(client *http.Client, url string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// otlpSeverities maps zap levels to OTLP severity numbers and texts
+var otlpSeverities = map[string]struct {
+	Number int
+	Text   string
+}{
+	"debug":  {5, "DEBUG"},
+	"info":   {9, "INFO"},
+	"warn":   {13, "WARN"},
+	"error":  {17, "ERROR"},
+	"dpanic": {21, "FATAL"},
+	"panic":  {21, "FATAL"},
+	"fatal":  {21, "FATAL"},
+}
+
+// NewOTLPSink creates a sink that exports entries to an OpenTelemetry collector using
+// OTLP/HTTP with JSON encoding. endpoint is the collector base URL (e.g. http://otel:4318).
+func NewOTLPSink(endpoint, serviceName string, headers map[string]string, options SinkOptions) Sink {
+	url := strings.TrimSuffix(endpoint, "/")
+	if !strings.HasSuffix(url, "/v1/logs") {
+		url += "/v1/logs"
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	type anyValue struct {
+		StringValue string `json:"stringValue"`
+	}
+	type keyValue struct {
+		Key   string   `json:"key"`
+		Value anyValue `json:"value"`
+	}
+	type logRecord struct {
+		TimeUnixNano   string   `json:"timeUnixNano"`
+		SeverityNumber int      `json:"severityNumber"`
+		SeverityText   string   `json:"severityText"`
+		Body           anyValue `json:"body"`
+	}
+
+	return newBufferedSink("otlp", options, func(entries []sinkEntry) error {
+		records := make([]logRecord, 0, len(entries))
+		for _, entry := range entries {
+			severity, ok := otlpSeverities[entry.Level]
+			if !ok {
+				severity = otlpSeverities["info"]
+			}
+			records = append(records, logRecord{
+				TimeUnixNano:   strconv.FormatInt(entry.Time.UnixNano(), 10),
+				SeverityNumber: severity.Number,
+				SeverityText:   severity.Text,
+				Body:           anyValue{StringValue: string(entry.Line)},
+			})
+		}
+
+		payload := map[string]any{
+			"resourceLogs": []any{
+				map[string]any{
+					"resource": map[string]any{
+						"attributes": []keyValue{{Key: "service.name", Value: anyValue{StringValue: serviceName}}},
+					},
+					"scopeLogs": []any{
+						map[string]any{
+							"scope":      map[string]string{"name": "base/core/logger"},
+							"logRecords": records,
+						},
+					},
+				},
+			},
+		}
+
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		return postJSON(client, url, headers, body)
+	})
+}
@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+// syslog facility local0
+const syslogFacility = 16
+
+// syslogSeverities maps zap levels to syslog severities
+var syslogSeverities = map[string]int{
+	"debug":  7,
+	"info":   6,
+	"warn":   4,
+	"error":  3,
+	"dpanic": 2,
+	"panic":  2,
+	"fatal":  2,
+}
+
+// NewSyslogSink creates a sink that sends RFC 5424 messages to a syslog server.
+// network is "udp" or "tcp"; the connection is re-established after errors.
+func NewSyslogSink(network, address, appName string, options SinkOptions) Sink {
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+	var conn net.Conn
+
+	return newBufferedSink("syslog", options, func(entries []sinkEntry) error {
+		if conn == nil {
+			c, err := net.DialTimeout(network, address, 5*time.Second)
+			if err != nil {
+				return err
+			}
+			conn = c
+		}
+
+		conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+		for _, entry := range entries {
+			severity, ok := syslogSeverities[entry.Level]
+			if !ok {
+				severity = 6
+			}
+			message := fmt.Sprintf("<%d>1 %s %s %s %d - - %s\n",
+				syslogFacility*8+severity,
+				entry.Time.UTC().Format(time.RFC3339Nano),
+				hostname, appName, os.Getpid(), entry.Line)
+
+			if _, err := conn.Write([]byte(message)); err != nil {
+				conn.Close()
+				conn = nil
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,180 @@
+package markdown
+
+import (
+	"html"
+	"strings"
+)
+
+// Classes of the highlighted tokens; frontends style them
+const (
+	ClassKeyword = "hl-keyword"
+	ClassString  = "hl-string"
+	ClassNumber  = "hl-number"
+	ClassComment = "hl-comment"
+)
+
+// language describes the tokens of a language
+type language struct {
+	keywords      []string
+	foldCase      bool     // Keywords match in any case, as in SQL
+	lineComments  []string // e.g. "//"
+	blockComments [2]string
+	quotes        string // Characters that start strings
+}
+
+var (
+	golang = &language{
+		keywords: []string{"break", "case", "chan", "const", "continue", "default", "defer", "else",
+			"fallthrough", "for", "func", "go", "goto", "if", "import", "interface", "map", "package",
+			"range", "return", "select", "struct", "switch", "type", "var", "nil", "true", "false", "iota"},
+		lineComments:  []string{"//"},
+		blockComments: [2]string{"/*", "*/"},
+		quotes:        "\"'`",
+	}
+	javascript = &language{
+		keywords: []string{"async", "await", "break", "case", "catch", "class", "const", "continue",
+			"default", "delete", "do", "else", "export", "extends", "finally", "for", "from", "function",
+			"if", "import", "in", "instanceof", "interface", "let", "new", "of", "return", "static",
+			"super", "switch", "this", "throw", "try", "type", "typeof", "var", "void", "while", "yield",
+			"null", "undefined", "true", "false"},
+		lineComments:  []string{"//"},
+		blockComments: [2]string{"/*", "*/"},
+		quotes:        "\"'`",
+	}
+	python = &language{
+		keywords: []string{"and", "as", "assert", "async", "await", "break", "class", "continue", "def",
+			"del", "elif", "else", "except", "finally", "for", "from", "global", "if", "import", "in",
+			"is", "lambda", "nonlocal", "not", "or", "pass", "raise", "return", "try", "while", "with",
+			"yield", "None", "True", "False"},
+		lineComments: []string{"#"},
+		quotes:       "\"'",
+	}
+	sql = &language{
+		keywords: []string{"select", "from", "where", "and", "or", "not", "insert", "into", "values",
+			"update", "set", "delete", "create", "table", "alter", "drop", "index", "join", "left",
+			"right", "inner", "outer", "on", "as", "group", "by", "order", "having", "limit", "offset",
+			"distinct", "union", "null", "is", "in", "like", "between", "case", "when", "then", "else",
+			"end", "primary", "key", "foreign", "references", "default", "asc", "desc", "true", "false"},
+		foldCase:      true,
+		lineComments:  []string{"--"},
+		blockComments: [2]string{"/*", "*/"},
+		quotes:        "'\"",
+	}
+	shell = &language{
+		keywords: []string{"if", "then", "else", "elif", "fi", "for", "while", "until", "do", "done",
+			"case", "esac", "in", "function", "return", "export", "local", "echo", "exit"},
+		lineComments: []string{"#"},
+		quotes:       "\"'",
+	}
+	json = &language{
+		keywords: []string{"true", "false", "null"},
+		quotes:   "\"",
+	}
+)
+
+// languages are the highlighted languages by the names of fenced code blocks
+var languages = map[string]*language{
+	"go":         golang,
+	"golang":     golang,
+	"js":         javascript,
+	"javascript": javascript,
+	"jsx":        javascript,
+	"ts":         javascript,
+	"typescript": javascript,
+	"tsx":        javascript,
+	"py":         python,
+	"python":     python,
+	"sql":        sql,
+	"sh":         shell,
+	"bash":       shell,
+	"shell":      shell,
+	"zsh":        shell,
+	"json":       json,
+}
+
+// Highlight returns code as HTML with its keywords, strings, numbers and comments in spans of
+// their class (ClassKeyword...). Code of languages it doesn't know is only escaped.
+func Highlight(lang, code string) string {
+	l, ok := languages[strings.ToLower(lang)]
+	if !ok {
+		return html.EscapeString(code)
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(code); {
+		end, class := l.token(code, i)
+		if class == "" {
+			b.WriteString(html.EscapeString(code[i:end]))
+		} else {
+			b.WriteString(`<span class="` + class + `">` + html.EscapeString(code[i:end]) + "</span>")
+		}
+		i = end
+	}
+	return b.String()
+}
+
+// token returns the end and the class of the token starting at i; text that isn't
+// highlighted has no class
+func (l *language) token(code string, i int) (int, string) {
+	rest := code[i:]
+
+	if open := l.blockComments[0]; open != "" && strings.HasPrefix(rest, open) {
+		if k := strings.Index(rest[len(open):], l.blockComments[1]); k >= 0 {
+			return i + len(open) + k + len(l.blockComments[1]), ClassComment
+		}
+		return len(code), ClassComment
+	}
+	for _, prefix := range l.lineComments {
+		if strings.HasPrefix(rest, prefix) {
+			if k := strings.IndexByte(rest, '\n'); k >= 0 {
+				return i + k, ClassComment
+			}
+			return len(code), ClassComment
+		}
+	}
+
+	c := code[i]
+	switch {
+	case strings.IndexByte(l.quotes, c) >= 0:
+		for j := i + 1; j < len(code); j++ {
+			switch code[j] {
+			case '\\':
+				j++
+			case c:
+				return j + 1, ClassString
+			case '\n':
+				// Only backquoted strings span lines
+				if c != '`' {
+					return j, ClassString
+				}
+			}
+		}
+		return len(code), ClassString
+	case c >= '0' && c <= '9':
+		j := i + 1
+		for j < len(code) && (isWord(code[j]) || code[j] == '.') {
+			j++
+		}
+		return j, ClassNumber
+	case isWord(c):
+		j := i + 1
+		for j < len(code) && isWord(code[j]) {
+			j++
+		}
+		if l.keyword(code[i:j]) {
+			return j, ClassKeyword
+		}
+		return j, ""
+	}
+	return i + 1, ""
+}
+
+// keyword reports whether a word is a keyword of the language
+func (l *language) keyword(word string) bool {
+	for _, keyword := range l.keywords {
+		if keyword == word || (l.foldCase && strings.EqualFold(keyword, word)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,501 @@
+// Package markdown renders Markdown to HTML on the server, so frontends get content they can
+// show without their own renderer. It supports the common syntax - headings, paragraphs,
+// emphasis, code, links, images, lists, quotes and rules - and highlights fenced code blocks
+// (see Highlight). Raw HTML in the source is escaped and links to unsafe URLs (javascript:
+// and the like) are left as text, so the output can be served as it is:
+//
+//	html := markdown.Render("# Hello\n\nSee [the docs](https://example.com).")
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"base/core/sanitize"
+)
+
+// Block syntax
+var (
+	fencePattern   = regexp.MustCompile("^ {0,3}(```+|~~~+)[ \t]*([^\\s`]*)")
+	headingPattern = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	rulePattern    = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	quotePattern   = regexp.MustCompile(`^ {0,3}> ?`)
+	listPattern    = regexp.MustCompile(`^ {0,3}([-*+]|\d{1,9}[.)])(?:[ \t]+|$)`)
+	langPattern    = regexp.MustCompile(`^[a-z0-9_+#.-]+$`)
+	emailPattern   = regexp.MustCompile(`^[a-zA-Z0-9.!#$%&'*+/=?^_{|}~-]+@[a-zA-Z0-9-]+(?:\.[a-zA-Z0-9-]+)+$`)
+)
+
+// linkRel is the rel of links to other sites, which can't control the page that opened them
+const linkRel = "noopener noreferrer nofollow"
+
+// Render returns the HTML of a Markdown document
+func Render(source string) string {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+	source = strings.ReplaceAll(source, "\t", "    ")
+
+	var b strings.Builder
+	renderBlocks(&b, strings.Split(source, "\n"))
+	return b.String()
+}
+
+// renderBlocks renders lines as a sequence of blocks
+func renderBlocks(b *strings.Builder, lines []string) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "":
+			i++
+		case fencePattern.MatchString(line):
+			i = renderCode(b, lines, i)
+		case headingPattern.MatchString(line):
+			m := headingPattern.FindStringSubmatch(line)
+			level := strconv.Itoa(len(m[1]))
+			b.WriteString("<h" + level + ">" + inline(m[2]) + "</h" + level + ">\n")
+			i++
+		case rulePattern.MatchString(line):
+			b.WriteString("<hr>\n")
+			i++
+		case quotePattern.MatchString(line):
+			i = renderQuote(b, lines, i)
+		case listPattern.MatchString(line):
+			i = renderList(b, lines, i)
+		default:
+			end := paragraphEnd(lines, i)
+			b.WriteString("<p>" + paragraph(lines[i:end]) + "</p>\n")
+			i = end
+		}
+	}
+}
+
+// startsBlock reports whether a line starts a block other than a paragraph
+func startsBlock(line string) bool {
+	return fencePattern.MatchString(line) || headingPattern.MatchString(line) ||
+		rulePattern.MatchString(line) || quotePattern.MatchString(line) || listPattern.MatchString(line)
+}
+
+// paragraphEnd returns the index of the line after the paragraph starting at start
+func paragraphEnd(lines []string, start int) int {
+	end := start + 1
+	for end < len(lines) && strings.TrimSpace(lines[end]) != "" && !startsBlock(lines[end]) {
+		end++
+	}
+	return end
+}
+
+// paragraph renders the lines of a paragraph; lines ending with two spaces or a backslash
+// break the line
+func paragraph(lines []string) string {
+	text := make([]string, len(lines))
+	for i, line := range lines {
+		line = strings.TrimLeft(line, " ")
+		if i < len(lines)-1 && strings.HasSuffix(line, "  ") {
+			line = strings.TrimRight(line, " ") + `\`
+		}
+		text[i] = line
+	}
+	return inline(strings.TrimRight(strings.Join(text, "\n"), " "))
+}
+
+// renderCode renders the fenced code block starting at start and returns the index of the
+// line after it. A block that isn't closed lasts until the end of the document.
+func renderCode(b *strings.Builder, lines []string, start int) int {
+	m := fencePattern.FindStringSubmatch(lines[start])
+	fence, lang := m[1], strings.ToLower(m[2])
+
+	end := len(lines)
+	for i := start + 1; i < len(lines); i++ {
+		closing := strings.TrimSpace(lines[i])
+		if len(closing) >= len(fence) && strings.Trim(closing, fence[:1]) == "" {
+			end = i
+			break
+		}
+	}
+
+	code := strings.Join(lines[start+1:end], "\n")
+	if code != "" {
+		code += "\n"
+	}
+	if langPattern.MatchString(lang) {
+		b.WriteString(`<pre><code class="language-` + lang + `">`)
+	} else {
+		b.WriteString("<pre><code>")
+	}
+	b.WriteString(Highlight(lang, code))
+	b.WriteString("</code></pre>\n")
+	return end + 1
+}
+
+// renderQuote renders the quote starting at start and returns the index of the line after it
+func renderQuote(b *strings.Builder, lines []string, start int) int {
+	var inner []string
+	i := start
+	for ; i < len(lines) && quotePattern.MatchString(lines[i]); i++ {
+		inner = append(inner, quotePattern.ReplaceAllString(lines[i], ""))
+	}
+	b.WriteString("<blockquote>\n")
+	renderBlocks(b, inner)
+	b.WriteString("</blockquote>\n")
+	return i
+}
+
+// renderList renders the list starting at start and returns the index of the line after it.
+// Items continue on lines indented by at least two spaces, and on the following lines of
+// their paragraph.
+func renderList(b *strings.Builder, lines []string, start int) int {
+	first := listPattern.FindStringSubmatch(lines[start])
+	ordered := isOrdered(first[1])
+
+	var items [][]string
+	loose := false
+	i := start
+	for i < len(lines) {
+		line := lines[i]
+		// Items indented further than the first belong to the previous item, as a sublist
+		if sameList(line, ordered) && indentation(line) < indentation(lines[start])+2 && !rulePattern.MatchString(line) {
+			m := listPattern.FindStringSubmatch(line)
+			items = append(items, []string{line[len(m[0]):]})
+			i++
+			continue
+		}
+
+		item := &items[len(items)-1]
+		switch {
+		case strings.TrimSpace(line) == "":
+			// A blank line only continues the list if more of it follows
+			if i+1 >= len(lines) || !(indentation(lines[i+1]) >= 2 || sameList(lines[i+1], ordered)) {
+				return closeList(b, items, ordered, first[1], loose, i)
+			}
+			loose = true
+			*item = append(*item, "")
+		case indentation(line) >= 2:
+			*item = append(*item, dedent(line, 4))
+		case strings.TrimSpace((*item)[len(*item)-1]) != "" && !startsBlock(line):
+			*item = append(*item, line)
+		default:
+			return closeList(b, items, ordered, first[1], loose, i)
+		}
+		i++
+	}
+	return closeList(b, items, ordered, first[1], loose, i)
+}
+
+// closeList writes the items of a list and returns next
+func closeList(b *strings.Builder, items [][]string, ordered bool, marker string, loose bool, next int) int {
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+	b.WriteString("<" + tag)
+	if n, _ := strconv.Atoi(strings.TrimRight(marker, ".)")); ordered && n != 1 {
+		b.WriteString(` start="` + strconv.Itoa(n) + `"`)
+	}
+	b.WriteString(">\n")
+
+	for _, item := range items {
+		b.WriteString("<li>")
+		// Items of tight lists (without blank lines) have no paragraphs
+		if !loose && len(item) > 0 && strings.TrimSpace(item[0]) != "" && !startsBlock(item[0]) {
+			end := paragraphEnd(item, 0)
+			b.WriteString(paragraph(item[:end]))
+			item = item[end:]
+			if len(item) > 0 {
+				b.WriteString("\n")
+			}
+		}
+		renderBlocks(b, item)
+		b.WriteString("</li>\n")
+	}
+
+	b.WriteString("</" + tag + ">\n")
+	return next
+}
+
+// isOrdered reports whether a list marker is a number
+func isOrdered(marker string) bool {
+	return !strings.ContainsAny(marker, "-*+")
+}
+
+// sameList reports whether a line is an item of a list of the same kind
+func sameList(line string, ordered bool) bool {
+	m := listPattern.FindStringSubmatch(line)
+	return m != nil && isOrdered(m[1]) == ordered
+}
+
+// indentation returns the number of leading spaces of a line
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// dedent removes up to n leading spaces from a line
+func dedent(line string, n int) string {
+	return line[min(indentation(line), n):]
+}
+
+// inline renders the inline syntax of text
+func inline(text string) string {
+	var b strings.Builder
+	renderInline(&b, text)
+	return b.String()
+}
+
+// renderInline renders emphasis, code spans, links, images, autolinks, escapes and line
+// breaks; everything else is written as escaped text
+func renderInline(b *strings.Builder, s string) {
+	for i := 0; i < len(s); {
+		c := s[i]
+		next := -1
+		switch {
+		case c == '\\' && i+1 < len(s) && s[i+1] == '\n':
+			b.WriteString("<br>\n")
+			next = i + 2
+		case c == '\\' && i+1 < len(s) && isPunct(s[i+1]):
+			b.WriteString(html.EscapeString(s[i+1 : i+2]))
+			next = i + 2
+		case c == '`':
+			next = codeSpan(b, s, i)
+		case c == '!' && i+1 < len(s) && s[i+1] == '[':
+			next = link(b, s, i+1, true)
+		case c == '[':
+			next = link(b, s, i, false)
+		case c == '<':
+			next = autolink(b, s, i)
+		case c == '*' || c == '_':
+			next = emphasis(b, s, i)
+		}
+		if next < 0 {
+			b.WriteString(html.EscapeString(s[i : i+1]))
+			next = i + 1
+		}
+		i = next
+	}
+}
+
+// codeSpan renders the code span starting at i; a backtick run without a closing run of the
+// same length is text
+func codeSpan(b *strings.Builder, s string, i int) int {
+	n := run(s, i)
+	for j := i + n; j < len(s); {
+		if s[j] != '`' {
+			j++
+			continue
+		}
+		m := run(s, j)
+		if m == n {
+			code := strings.ReplaceAll(s[i+n:j], "\n", " ")
+			if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+				code = code[1 : len(code)-1]
+			}
+			b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+			return j + m
+		}
+		j += m
+	}
+	b.WriteString(s[i : i+n])
+	return i + n
+}
+
+// link renders the link or image whose label starts at i, or returns -1 if there is none.
+// Links and images with unsafe URLs are rendered as their text.
+func link(b *strings.Builder, s string, i int, image bool) int {
+	// The label, which may contain brackets
+	depth, end := 0, -1
+	for j := i; j < len(s) && end < 0; j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '[':
+			depth++
+		case ']':
+			if depth--; depth == 0 {
+				end = j
+			}
+		}
+	}
+	if end < 0 || end+1 >= len(s) || s[end+1] != '(' {
+		return -1
+	}
+	label := s[i+1 : end]
+
+	// The destination and the optional title
+	j := skipSpaces(s, end+2)
+	var dest string
+	if j < len(s) && s[j] == '<' {
+		k := strings.IndexAny(s[j:], ">\n")
+		if k < 0 || s[j+k] != '>' {
+			return -1
+		}
+		dest, j = s[j+1:j+k], j+k+1
+	} else {
+		start, parens := j, 0
+		for ; j < len(s) && s[j] > ' '; j++ {
+			if s[j] == '(' {
+				parens++
+			} else if s[j] == ')' {
+				if parens == 0 {
+					break
+				}
+				parens--
+			}
+		}
+		dest = s[start:j]
+	}
+	j = skipSpaces(s, j)
+	var title string
+	if j < len(s) && (s[j] == '"' || s[j] == '\'') {
+		k := strings.IndexByte(s[j+1:], s[j])
+		if k < 0 {
+			return -1
+		}
+		title, j = s[j+1:j+1+k], skipSpaces(s, j+k+2)
+	}
+	if j >= len(s) || s[j] != ')' {
+		return -1
+	}
+
+	safe := sanitize.SafeURL(dest)
+	switch {
+	case image && safe:
+		b.WriteString(`<img src="` + html.EscapeString(dest) + `" alt="` + html.EscapeString(plain(label)) + `"`)
+		if title != "" {
+			b.WriteString(` title="` + html.EscapeString(title) + `"`)
+		}
+		b.WriteString(">")
+	case image:
+		b.WriteString(html.EscapeString(plain(label)))
+	case safe:
+		writeLink(b, dest, title, inline(label))
+	default:
+		renderInline(b, label)
+	}
+	return j + 1
+}
+
+// autolink renders the URL or email address in angle brackets starting at i, or returns -1
+// if there is none
+func autolink(b *strings.Builder, s string, i int) int {
+	k := strings.IndexAny(s[i+1:], "<> \n")
+	if k < 0 || s[i+1+k] != '>' {
+		return -1
+	}
+	target := s[i+1 : i+1+k]
+	switch {
+	case emailPattern.MatchString(target):
+		writeLink(b, "mailto:"+target, "", html.EscapeString(target))
+	case strings.Contains(target, ":") && sanitize.SafeURL(target):
+		writeLink(b, target, "", html.EscapeString(target))
+	default:
+		return -1
+	}
+	return i + k + 2
+}
+
+// writeLink writes a link; links to other sites get linkRel
+func writeLink(b *strings.Builder, href, title, content string) {
+	b.WriteString(`<a href="` + html.EscapeString(href) + `"`)
+	if title != "" {
+		b.WriteString(` title="` + html.EscapeString(title) + `"`)
+	}
+	if strings.HasPrefix(href, "//") || strings.Contains(strings.SplitN(href, "/", 2)[0], ":") {
+		b.WriteString(` rel="` + linkRel + `"`)
+	}
+	b.WriteString(">" + content + "</a>")
+}
+
+// emphasis renders the emphasis (*em*, **strong** or ***both***) starting at i, or writes
+// the delimiters as text if they aren't closed
+func emphasis(b *strings.Builder, s string, i int) int {
+	c, n := s[i], run(s, i)
+	// Delimiters open before text, and underscores not inside words
+	if i+n >= len(s) || isSpace(s[i+n]) || (c == '_' && i > 0 && isWord(s[i-1])) {
+		b.WriteString(s[i : i+n])
+		return i + n
+	}
+
+	for _, size := range []int{3, 2, 1} {
+		if n < size {
+			continue
+		}
+		end := closing(s, i+size, c, size)
+		if end < 0 {
+			continue
+		}
+		inner := inline(s[i+size : end])
+		switch size {
+		case 3:
+			b.WriteString("<em><strong>" + inner + "</strong></em>")
+		case 2:
+			b.WriteString("<strong>" + inner + "</strong>")
+		default:
+			b.WriteString("<em>" + inner + "</em>")
+		}
+		return end + size
+	}
+
+	b.WriteString(s[i : i+n])
+	return i + n
+}
+
+// closing returns the index of the delimiter run closing an emphasis of size delimiters c
+// that starts at start, or -1. Runs of other sizes are skipped, so *a **b** c* nests.
+func closing(s string, start int, c byte, size int) int {
+	for j := start; j < len(s); {
+		switch s[j] {
+		case '\\':
+			j += 2
+			continue
+		case '`':
+			if k := strings.IndexByte(s[j+1:], '`'); k >= 0 {
+				j += k + 2
+				continue
+			}
+		case c:
+			n := run(s, j)
+			closes := j > start && !isSpace(s[j-1]) &&
+				(c != '_' || j+n >= len(s) || !isWord(s[j+n]))
+			// ***, e.g. closing *a **b***, closes every size
+			if closes && (n == size || n == 3) {
+				return j + n - size
+			}
+			j += n
+			continue
+		}
+		j++
+	}
+	return -1
+}
+
+// plain returns the text of inline Markdown without its syntax, e.g. for alt texts
+func plain(s string) string {
+	return strings.NewReplacer("*", "", "_", "", "`", "", "[", "", "]", "").Replace(s)
+}
+
+// run returns the length of the run of the character at i
+func run(s string, i int) int {
+	n := 1
+	for i+n < len(s) && s[i+n] == s[i] {
+		n++
+	}
+	return n
+}
+
+// skipSpaces returns the index of the first character from i that isn't a space or newline
+func skipSpaces(s string, i int) int {
+	for i < len(s) && (s[i] == ' ' || s[i] == '\n') {
+		i++
+	}
+	return i
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n'
+}
+
+func isWord(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+func isPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
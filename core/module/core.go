@@ -2,7 +2,6 @@ package module
 
 import (
 	"base/core/logger"
-	"base/core/router"
 )
 
 // CoreModuleProvider defines the interface for providing core modules
@@ -44,39 +43,13 @@ func (co *CoreOrchestrator) initializeCoreModules(modules map[string]Module, dep
 	var initializedModules []Module
 
 	for name, mod := range modules {
-		// Register module
-		if err := RegisterModule(name, mod); err != nil {
-			deps.Logger.Error("Failed to register core module",
+		if _, err := startModule("core", name, mod, deps); err != nil {
+			deps.Logger.Error("Failed to initialize core module",
 				logger.String("module", name),
 				logger.String("error", err.Error()))
 			continue
 		}
 
-		// Initialize
-		if initModule, ok := mod.(interface{ Init() error }); ok {
-			if err := initModule.Init(); err != nil {
-				deps.Logger.Error("Failed to initialize core module",
-					logger.String("module", name),
-					logger.String("error", err.Error()))
-				continue
-			}
-		}
-
-		// Migrate
-		if migrator, ok := mod.(interface{ Migrate() error }); ok {
-			if err := migrator.Migrate(); err != nil {
-				deps.Logger.Error("Failed to migrate core module",
-					logger.String("module", name),
-					logger.String("error", err.Error()))
-				continue
-			}
-		}
-
-		// Setup routes
-		if routeModule, ok := mod.(interface{ Routes(*router.RouterGroup) }); ok {
-			routeModule.Routes(deps.Router)
-		}
-
 		initializedModules = append(initializedModules, mod)
 	}
 
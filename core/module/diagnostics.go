@@ -0,0 +1,154 @@
+package module
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"base/core/router"
+)
+
+// HealthChecker can be implemented by modules that depend on external services.
+// Health is called by the readiness check and should return quickly.
+type HealthChecker interface {
+	Health(ctx context.Context) error
+}
+
+// ModuleStatus records how a module's startup went
+type ModuleStatus struct {
+	Name       string  `json:"name"`
+	Kind       string  `json:"kind"` // core or app
+	Status     string  `json:"status"`
+	FailedStep string  `json:"failed_step,omitempty"`
+	Error      string  `json:"error,omitempty"`
+	InitMs     float64 `json:"init_ms"`
+	MigrateMs  float64 `json:"migrate_ms"`
+	RoutesMs   float64 `json:"routes_ms"`
+	TotalMs    float64 `json:"total_ms"`
+	HasHealth  bool    `json:"has_health"`
+	Health     string  `json:"health,omitempty"`
+}
+
+const (
+	ModuleStatusOK     = "ok"
+	ModuleStatusFailed = "failed"
+)
+
+var (
+	// moduleStatuses stores the startup status of every module keyed by name
+	moduleStatuses = make(map[string]*ModuleStatus)
+	statusMu       sync.RWMutex
+)
+
+// startModule registers a module and runs its Init, Migrate and Routes steps, recording
+// how long each step took. It returns the status and the error of the failed step, if any.
+func startModule(kind, name string, mod Module, deps Dependencies) (*ModuleStatus, error) {
+	status := &ModuleStatus{Name: name, Kind: kind, Status: ModuleStatusOK}
+	_, status.HasHealth = mod.(HealthChecker)
+	started := time.Now()
+
+	defer func() {
+		status.TotalMs = millis(time.Since(started))
+		statusMu.Lock()
+		moduleStatuses[name] = status
+		statusMu.Unlock()
+	}()
+
+	fail := func(step string, err error) (*ModuleStatus, error) {
+		status.Status = ModuleStatusFailed
+		status.FailedStep = step
+		status.Error = err.Error()
+		return status, err
+	}
+
+	// Register module
+	if err := RegisterModule(name, mod); err != nil {
+		return fail("register", err)
+	}
+
+	// Initialize
+	stepStarted := time.Now()
+	if err := mod.Init(); err != nil {
+		status.InitMs = millis(time.Since(stepStarted))
+		return fail("init", err)
+	}
+	status.InitMs = millis(time.Since(stepStarted))
+
+	// Migrate (AutoMigrate is a dev convenience and can be disabled with AUTO_MIGRATE=false)
+	if autoMigrateEnabled(deps) {
+		stepStarted = time.Now()
+		if err := mod.Migrate(); err != nil {
+			status.MigrateMs = millis(time.Since(stepStarted))
+			return fail("migrate", err)
+		}
+		status.MigrateMs = millis(time.Since(stepStarted))
+	}
+
+	// Setup routes
+	stepStarted = time.Now()
+	mod.Routes(deps.Router)
+	status.RoutesMs = millis(time.Since(stepStarted))
+
+	return status, nil
+}
+
+// GetModuleStatuses returns the startup status of all modules, slowest first
+func GetModuleStatuses() []ModuleStatus {
+	statusMu.RLock()
+	defer statusMu.RUnlock()
+
+	statuses := make([]ModuleStatus, 0, len(moduleStatuses))
+	for _, status := range moduleStatuses {
+		statuses = append(statuses, *status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].TotalMs > statuses[j].TotalMs
+	})
+	return statuses
+}
+
+// CheckHealth runs Health on every registered module that implements HealthChecker.
+// The result maps module names to "ok" or the error message.
+func CheckHealth(ctx context.Context) (map[string]string, bool) {
+	results := make(map[string]string)
+	healthy := true
+
+	for name, mod := range GetAllModules() {
+		checker, ok := mod.(HealthChecker)
+		if !ok {
+			continue
+		}
+		if err := checker.Health(ctx); err != nil {
+			results[name] = err.Error()
+			healthy = false
+		} else {
+			results[name] = ModuleStatusOK
+		}
+	}
+
+	return results, healthy
+}
+
+// DiagnosticsRoutes registers GET /_modules, which lists module startup timings,
+// failures and current health
+func DiagnosticsRoutes(group *router.RouterGroup) {
+	group.GET("/_modules", func(c *router.Context) error {
+		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		defer cancel()
+
+		health, _ := CheckHealth(ctx)
+		statuses := GetModuleStatuses()
+		for i := range statuses {
+			statuses[i].Health = health[statuses[i].Name]
+		}
+
+		return c.JSON(200, map[string]any{
+			"modules": statuses,
+		})
+	})
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
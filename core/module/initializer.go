@@ -2,9 +2,12 @@ package module
 
 import (
 	"base/core/config"
+	"base/core/database"
 	"base/core/email"
 	"base/core/emitter"
+	"base/core/features"
 	"base/core/logger"
+	"base/core/pdf"
 	"base/core/router"
 	"base/core/storage"
 
@@ -14,12 +17,22 @@ import (
 // Dependencies contains all dependencies that can be injected into modules
 type Dependencies struct {
 	DB          *gorm.DB
+	Tx          *database.TxManager
 	Router      *router.RouterGroup
 	Logger      logger.Logger
 	Emitter     *emitter.Emitter
 	Storage     *storage.ActiveStorage
 	EmailSender email.Sender
 	Config      *config.Config
+	Features    *features.Evaluator // Feature flag evaluation, see features.Flags
+	PDF         *pdf.Service        // PDF rendering of templates, tables and record sheets
+}
+
+// ForModule returns a copy of the dependencies whose logger is scoped to the named
+// module, so its level can be set independently (see logger.SetModuleLevel)
+func (d Dependencies) ForModule(name string) Dependencies {
+	d.Logger = logger.ForModule(d.Logger, name)
+	return d
 }
 
 // Initializer handles module initialization logic
@@ -41,42 +54,21 @@ func (mi *Initializer) Initialize(modules map[string]Module, deps Dependencies)
 	for name, mod := range modules {
 		mi.logger.Info("Initializing module", logger.String("module", name))
 
-		// Register module
-		if err := RegisterModule(name, mod); err != nil {
-			mi.logger.Error("Failed to register module",
+		if _, err := startModule("app", name, mod, deps); err != nil {
+			mi.logger.Error("Failed to initialize module",
 				logger.String("module", name),
 				logger.String("error", err.Error()))
 			continue
 		}
 
-		// Initialize
-		if initModule, ok := mod.(interface{ Init() error }); ok {
-			if err := initModule.Init(); err != nil {
-				mi.logger.Error("Failed to initialize module",
-					logger.String("module", name),
-					logger.String("error", err.Error()))
-				continue
-			}
-		}
-
-		// Migrate
-		if migrator, ok := mod.(interface{ Migrate() error }); ok {
-			if err := migrator.Migrate(); err != nil {
-				mi.logger.Error("Failed to migrate module",
-					logger.String("module", name),
-					logger.String("error", err.Error()))
-				continue
-			}
-		}
-
-		// Setup routes
-		if routeModule, ok := mod.(interface{ Routes(*router.RouterGroup) }); ok {
-			routeModule.Routes(deps.Router)
-		}
-
 		initializedModules = append(initializedModules, mod)
 		mi.logger.Info("Module initialized successfully", logger.String("module", name))
 	}
 
 	return initializedModules
 }
+
+// autoMigrateEnabled reports whether module Migrate hooks should run on startup
+func autoMigrateEnabled(deps Dependencies) bool {
+	return deps.Config == nil || deps.Config.AutoMigrate
+}
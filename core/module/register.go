@@ -72,6 +72,18 @@ func RegisterModule(name string, module Module) error {
 	return nil
 }
 
+// ResetModules forgets the registered modules and their startup statuses, so the modules can
+// be started again in the same process, e.g. by each test using core/testutil
+func ResetModules() {
+	lock.Lock()
+	modulesRegistry = make(map[string]Module)
+	lock.Unlock()
+
+	statusMu.Lock()
+	moduleStatuses = make(map[string]*ModuleStatus)
+	statusMu.Unlock()
+}
+
 // GetModule retrieves a module by its name.
 func GetModule(name string) (Module, error) {
 	lock.RLock()
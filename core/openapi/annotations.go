@@ -0,0 +1,501 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Annotations are the swag annotations (@Summary, @Param, @Router, ...) of the handlers
+// and the general API information, read from the Go source or a generated document.
+type Annotations struct {
+	Info            Info
+	BasePath        string // Prefix of the @Router paths, e.g. /api
+	SecuritySchemes map[string]*SecurityScheme
+	Operations      []*Annotated
+
+	// schemas holds the schemas of the types the operations reference by component name,
+	// e.g. products.ProductResponse
+	schemas map[string]*Schema
+	types   map[string]*typeDecl
+}
+
+// Annotated is an annotated handler
+type Annotated struct {
+	Handler   string // e.g. base/app/products.ProductController.Create; empty for generated documents
+	Method    string // e.g. GET
+	Path      string // Full path, e.g. /api/products/{id}
+	Operation *Operation
+}
+
+// typeDecl is a type declared in the source
+type typeDecl struct {
+	pkg  string
+	spec *ast.TypeSpec
+}
+
+// skipDirs are not searched for source files
+var skipDirs = map[string]bool{
+	"vendor": true, "node_modules": true, "testdata": true, "storage": true, "logs": true,
+	"static": true, "swagger": true, "templates": true, "public": true,
+}
+
+// ParseSource reads the annotations of the Go files under root, the directory of the
+// go.mod of module
+func ParseSource(root, module string) (*Annotations, error) {
+	a := &Annotations{
+		SecuritySchemes: map[string]*SecurityScheme{},
+		schemas:         map[string]*Schema{},
+		types:           map[string]*typeDecl{},
+	}
+
+	type source struct {
+		importPath string
+		file       *ast.File
+	}
+	var sources []source
+	fset := token.NewFileSet()
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(d.Name(), ".") || skipDirs[d.Name()]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return err
+		}
+
+		importPath := module
+		if dir, _ := filepath.Rel(root, filepath.Dir(path)); dir != "." {
+			importPath = module + "/" + filepath.ToSlash(dir)
+		}
+		if file.Name.Name == "main" {
+			importPath = "main"
+		}
+		sources = append(sources, source{importPath: importPath, file: file})
+
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				if spec, ok := spec.(*ast.TypeSpec); ok && spec.TypeParams == nil {
+					key := file.Name.Name + "." + spec.Name.Name
+					if _, exists := a.types[key]; !exists {
+						a.types[key] = &typeDecl{pkg: file.Name.Name, spec: spec}
+					}
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The general information comes first, as the @Router paths are relative to @BasePath
+	for _, src := range sources {
+		for _, group := range src.file.Comments {
+			if hasAnnotation(group, "@title") {
+				a.parseInfo(group)
+			}
+		}
+	}
+	// The types are known now, so the operations can reference types of any package
+	for _, src := range sources {
+		for _, decl := range src.file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Doc == nil || !hasAnnotation(fn.Doc, "@Router") {
+				continue
+			}
+			handler := src.importPath + "."
+			if fn.Recv != nil && len(fn.Recv.List) > 0 {
+				handler += receiverName(fn.Recv.List[0].Type) + "."
+			}
+			handler += fn.Name.Name
+			a.parseOperation(src.file.Name.Name, handler, fn)
+		}
+	}
+	return a, nil
+}
+
+// FromDocument returns the annotations of a generated document, e.g. one written by the
+// openapi command where the source isn't deployed
+func FromDocument(doc *Document) *Annotations {
+	a := &Annotations{
+		Info:            doc.Info,
+		SecuritySchemes: doc.Components.SecuritySchemes,
+		schemas:         doc.Components.Schemas,
+		types:           map[string]*typeDecl{},
+	}
+	if a.SecuritySchemes == nil {
+		a.SecuritySchemes = map[string]*SecurityScheme{}
+	}
+	if a.schemas == nil {
+		a.schemas = map[string]*Schema{}
+	}
+	for path, item := range doc.Paths {
+		for method, operation := range item {
+			if operation.Undocumented {
+				continue
+			}
+			a.Operations = append(a.Operations, &Annotated{
+				Method:    strings.ToUpper(method),
+				Path:      path,
+				Operation: operation,
+			})
+		}
+	}
+	return a
+}
+
+// hasAnnotation reports whether a comment has a line starting with the annotation
+func hasAnnotation(group *ast.CommentGroup, annotation string) bool {
+	for _, line := range strings.Split(group.Text(), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), annotation+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// receiverName returns the type name of a method receiver
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	}
+	return ""
+}
+
+// annotations returns the annotation lines of a comment as name and value
+func annotations(group *ast.CommentGroup) [][2]string {
+	var lines [][2]string
+	for _, line := range strings.Split(group.Text(), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "@") {
+			continue
+		}
+		name, value, _ := strings.Cut(line, " ")
+		lines = append(lines, [2]string{name, strings.TrimSpace(value)})
+	}
+	return lines
+}
+
+// parseInfo reads the general API information, e.g. @title and @securityDefinitions.apikey
+func (a *Annotations) parseInfo(group *ast.CommentGroup) {
+	var scheme *SecurityScheme
+	for _, line := range annotations(group) {
+		name, value := line[0], line[1]
+		switch name {
+		case "@title":
+			a.Info.Title = value
+		case "@version":
+			a.Info.Version = value
+		case "@termsOfService":
+			a.Info.TermsOfService = value
+		case "@contact.name", "@contact.email", "@contact.url":
+			if a.Info.Contact == nil {
+				a.Info.Contact = &Contact{}
+			}
+			switch name {
+			case "@contact.name":
+				a.Info.Contact.Name = value
+			case "@contact.email":
+				a.Info.Contact.Email = value
+			default:
+				a.Info.Contact.URL = value
+			}
+		case "@license.name":
+			if a.Info.License == nil {
+				a.Info.License = &License{}
+			}
+			a.Info.License.Name = value
+		case "@license.url":
+			if a.Info.License == nil {
+				a.Info.License = &License{}
+			}
+			a.Info.License.URL = value
+		case "@BasePath":
+			a.BasePath = strings.TrimSuffix(value, "/")
+		case "@securityDefinitions.apikey":
+			scheme = &SecurityScheme{Type: "apiKey"}
+			a.SecuritySchemes[value] = scheme
+		case "@in":
+			if scheme != nil {
+				scheme.In = value
+			}
+		case "@name":
+			if scheme != nil {
+				scheme.Name = value
+			}
+		case "@description":
+			// Descriptions after a security definition describe the scheme
+			if scheme != nil {
+				scheme.Description = value
+			} else {
+				a.Info.Description = joinLines(a.Info.Description, value)
+			}
+		}
+	}
+}
+
+// parseOperation reads the annotations of a handler
+func (a *Annotations) parseOperation(pkg, handler string, fn *ast.FuncDecl) {
+	operation := &Operation{Responses: map[string]*Response{}, OperationId: operationName(fn)}
+	produces := []string{"application/json"}
+	consumes := []string{"application/json"}
+	var routes [][2]string
+	var bodyParam *Parameter
+	var bodySchema *Schema
+	form := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+	lines := annotations(fn.Doc)
+	// Content types apply to all bodies, wherever they are in the comment
+	for _, line := range lines {
+		switch line[0] {
+		case "@Produce":
+			produces = mimeTypes(line[1])
+		case "@Accept":
+			consumes = mimeTypes(line[1])
+		}
+	}
+
+	for _, line := range lines {
+		name, value := line[0], line[1]
+		switch name {
+		case "@Summary":
+			operation.Summary = value
+		case "@Description":
+			operation.Description = joinLines(operation.Description, value)
+		case "@Tags":
+			for _, tag := range strings.Split(value, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					operation.Tags = append(operation.Tags, tag)
+				}
+			}
+		case "@ID":
+			operation.OperationId = value
+		case "@Deprecated":
+			operation.Deprecated = true
+		case "@Security":
+			operation.Security = append(operation.Security, map[string][]string{value: {}})
+		case "@Param":
+			fields := tokenize(value)
+			if len(fields) < 4 {
+				continue
+			}
+			param := &Parameter{Name: fields[0], In: fields[1], Required: fields[3] == "true"}
+			if len(fields) > 4 {
+				param.Description = unquote(fields[4])
+			}
+			for _, attr := range fields[min(len(fields), 5):] {
+				if value, ok := strings.CutPrefix(attr, "example("); ok {
+					param.Example = unquote(strings.TrimSuffix(value, ")"))
+				}
+			}
+			switch param.In {
+			case "body":
+				bodyParam = param
+				bodySchema = a.typeSchema(pkg, fields[2])
+			case "formData":
+				schema := a.typeSchema(pkg, fields[2])
+				schema.Description = param.Description
+				form.Properties[param.Name] = schema
+				if param.Required {
+					form.Required = append(form.Required, param.Name)
+				}
+			default:
+				param.Schema = a.typeSchema(pkg, fields[2])
+				operation.Parameters = append(operation.Parameters, param)
+			}
+		case "@Success", "@Failure":
+			code, rest, _ := strings.Cut(value, " ")
+			response := &Response{}
+			rest = strings.TrimSpace(rest)
+			if strings.HasPrefix(rest, "{") {
+				kind, rest2, _ := strings.Cut(rest[1:], "}")
+				fields := tokenize(strings.TrimSpace(rest2))
+				var schema *Schema
+				if len(fields) > 0 {
+					schema = a.typeSchema(pkg, fields[0])
+					if kind == "array" && schema != nil {
+						schema = &Schema{Type: "array", Items: schema}
+					}
+					if len(fields) > 1 {
+						response.Description = unquote(strings.Join(fields[1:], " "))
+					}
+				}
+				if kind == "file" {
+					schema = &Schema{Type: "string", Format: "binary"}
+				}
+				if schema != nil {
+					response.Content = map[string]MediaType{}
+					for _, mime := range produces {
+						response.Content[mime] = MediaType{Schema: schema}
+					}
+				}
+			} else if rest != "" {
+				response.Description = unquote(rest)
+			}
+			if response.Description == "" {
+				status, _ := strconv.Atoi(code)
+				response.Description = http.StatusText(status)
+			}
+			operation.Responses[code] = response
+		case "@Router":
+			path, method, _ := strings.Cut(value, " ")
+			method = strings.ToUpper(strings.Trim(strings.TrimSpace(method), "[]"))
+			routes = append(routes, [2]string{method, a.BasePath + path})
+		}
+	}
+
+	switch {
+	case bodySchema != nil:
+		operation.RequestBody = &RequestBody{
+			Description: bodyParam.Description,
+			Required:    bodyParam.Required,
+			Content:     map[string]MediaType{},
+		}
+		for _, mime := range consumes {
+			operation.RequestBody.Content[mime] = MediaType{Schema: bodySchema}
+		}
+	case len(form.Properties) > 0:
+		operation.RequestBody = &RequestBody{
+			Required: len(form.Required) > 0,
+			Content:  map[string]MediaType{"multipart/form-data": {Schema: form}},
+		}
+	}
+	if len(operation.Responses) == 0 {
+		operation.Responses["200"] = &Response{Description: http.StatusText(http.StatusOK)}
+	}
+
+	for _, route := range routes {
+		a.Operations = append(a.Operations, &Annotated{
+			Handler:   handler,
+			Method:    route[0],
+			Path:      route[1],
+			Operation: operation,
+		})
+	}
+}
+
+// operationName returns the name in the "// CreateProduct godoc" line of a handler, or the
+// function name
+func operationName(fn *ast.FuncDecl) string {
+	first, _, _ := strings.Cut(fn.Doc.Text(), "\n")
+	if name, ok := strings.CutSuffix(strings.TrimSpace(first), " godoc"); ok && !strings.Contains(name, " ") {
+		return lowerFirst(name)
+	}
+	return lowerFirst(fn.Name.Name)
+}
+
+// mimeTypes converts the content types of @Accept and @Produce to MIME types
+func mimeTypes(value string) []string {
+	aliases := map[string]string{
+		"json":                  "application/json",
+		"xml":                   "application/xml",
+		"plain":                 "text/plain",
+		"html":                  "text/html",
+		"mpfd":                  "multipart/form-data",
+		"x-www-form-urlencoded": "application/x-www-form-urlencoded",
+		"octet-stream":          "application/octet-stream",
+		"png":                   "image/png",
+		"jpeg":                  "image/jpeg",
+	}
+	var types []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if mime, ok := aliases[name]; ok {
+			name = mime
+		}
+		if name != "" {
+			types = append(types, name)
+		}
+	}
+	return types
+}
+
+// tokenize splits an annotation on spaces, keeping quoted strings together
+func tokenize(value string) []string {
+	var fields []string
+	var current strings.Builder
+	quoted := false
+	for _, r := range value {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			current.WriteRune(r)
+		case (r == ' ' || r == '\t') && !quoted:
+			if current.Len() > 0 {
+				fields = append(fields, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		fields = append(fields, current.String())
+	}
+	return fields
+}
+
+// unquote removes the quotes around an annotation string
+func unquote(value string) string {
+	return strings.Trim(value, `"`)
+}
+
+// joinLines appends a line to a multi-line annotation, e.g. @Description
+func joinLines(text, line string) string {
+	if text == "" {
+		return line
+	}
+	return text + "\n" + line
+}
+
+// lowerFirst lower cases the first word of a name, e.g. SEOGet to seoGet
+func lowerFirst(name string) string {
+	upper := 0
+	for upper < len(name) && name[upper] >= 'A' && name[upper] <= 'Z' {
+		upper++
+	}
+	if upper > 1 && upper < len(name) {
+		upper-- // The last capital starts the next word
+	}
+	return strings.ToLower(name[:upper]) + name[upper:]
+}
+
+// Load reads a document written by the openapi command
+func Load(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document %s: %w", path, err)
+	}
+	return &doc, nil
+}
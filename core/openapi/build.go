@@ -0,0 +1,327 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"base/core/router"
+)
+
+// closureSuffix matches the suffix the runtime adds to the names of closures, e.g. .func1
+var closureSuffix = regexp.MustCompile(`(\.func\d+)(\.\d+)*$`)
+
+// Build assembles the document of the registered routes. The operations come from the
+// annotations of the route handlers, or of the same method and path; routes without
+// annotations are listed with their path parameters and marked x-undocumented, so the
+// docs always list the routes the server actually has.
+func Build(routes []router.Route, annotations *Annotations) *Document {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    annotations.Info,
+		Paths:   map[string]PathItem{},
+		Components: Components{
+			Schemas:         map[string]*Schema{},
+			SecuritySchemes: annotations.SecuritySchemes,
+		},
+	}
+	if doc.Info.Title == "" {
+		doc.Info.Title = "API"
+	}
+
+	lookup := annotations.index()
+	var documented []router.Route
+	uses := map[*Operation]int{}
+	for _, route := range routes {
+		if route.Method != http.MethodOptions && route.Method != http.MethodHead {
+			documented = append(documented, route)
+			if annotated := lookup(route); annotated != nil {
+				uses[annotated.Operation]++
+			}
+		}
+	}
+
+	tags := map[string]bool{}
+	var operations []*Operation
+	for _, route := range documented {
+		path, params := openAPIPath(route.Path)
+
+		var operation *Operation
+		if annotated := lookup(route); annotated != nil {
+			operation = withPathParams(annotated.Operation, params)
+			// A handler serving several routes, e.g. the SEO endpoints of each entity, gets an
+			// operation id per route
+			if uses[annotated.Operation] > 1 {
+				operation.OperationId = generatedOperationId(route.Method, path)
+			}
+		} else {
+			operation = undocumented(route.Method, path, params)
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = PathItem{}
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = operation
+		operations = append(operations, operation)
+		for _, tag := range operation.Tags {
+			tags[tag] = true
+		}
+	}
+
+	uniqueOperationIds(operations)
+	for _, operation := range operations {
+		collectSchemas(operation, annotations.schemas, doc.Components.Schemas)
+	}
+
+	for tag := range tags {
+		doc.Tags = append(doc.Tags, Tag{Name: tag})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+	return doc
+}
+
+// Unregistered returns the annotated operations without a registered route, e.g. a handler
+// removed from Routes or an @Router path that doesn't match its route
+func Unregistered(routes []router.Route, annotations *Annotations) []string {
+	lookup := annotations.index()
+	registered := map[*Annotated]bool{}
+	for _, route := range routes {
+		if annotated := lookup(route); annotated != nil {
+			registered[annotated] = true
+		}
+	}
+
+	var missing []string
+	for _, annotated := range annotations.Operations {
+		if !registered[annotated] {
+			missing = append(missing, fmt.Sprintf("%s %s (%s)", annotated.Method, annotated.Path, annotated.Handler))
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// index returns a function finding the annotations of a route: those of the same method and
+// path, of its handler (so a handler whose @Router path is outdated is still documented), or of a path with a parameter for a segment of the route, e.g.
+// /api/{entity}/{id}/seo for /api/products/:id/seo of a handler shared by several modules
+func (a *Annotations) index() func(route router.Route) *Annotated {
+	byHandler := map[string]*Annotated{}
+	byRoute := map[string]*Annotated{}
+	for _, annotated := range a.Operations {
+		if annotated.Handler != "" {
+			byHandler[annotated.Handler+" "+annotated.Method] = annotated
+		}
+		byRoute[routeKey(annotated.Method, annotated.Path)] = annotated
+	}
+
+	return func(route router.Route) *Annotated {
+		path, _ := openAPIPath(route.Path)
+		if annotated, ok := byRoute[routeKey(route.Method, path)]; ok {
+			return annotated
+		}
+		if annotated, ok := byHandler[HandlerName(route.Handler)+" "+route.Method]; ok {
+			return annotated
+		}
+		for _, annotated := range a.Operations {
+			if annotated.Method == route.Method && matchesTemplate(annotated.Path, path) {
+				return annotated
+			}
+		}
+		return nil
+	}
+}
+
+// matchesTemplate reports whether a path matches an annotated path whose parameters stand
+// for any segment
+func matchesTemplate(template, path string) bool {
+	templateSegments := strings.Split(template, "/")
+	segments := strings.Split(path, "/")
+	if len(templateSegments) != len(segments) {
+		return false
+	}
+	for i, segment := range templateSegments {
+		if !strings.HasPrefix(segment, "{") && segment != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// HandlerName normalizes the name of a handler function as reported by the runtime, e.g.
+// base/app/products.(*ProductController).Create-fm to base/app/products.ProductController.Create.
+// Closures are named after the function returning them.
+func HandlerName(name string) string {
+	name = strings.TrimSuffix(name, "-fm")
+	name = closureSuffix.ReplaceAllString(name, "")
+	name = strings.ReplaceAll(name, "(*", "")
+	return strings.ReplaceAll(name, ")", "")
+}
+
+// openAPIPath converts a route path to an OpenAPI path, e.g. /users/:id to /users/{id},
+// and returns the names of its parameters
+func openAPIPath(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	var params []string
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			params = append(params, segment[1:])
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// routeKey identifies a route regardless of the names of its path parameters
+func routeKey(method, path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") {
+			segments[i] = "{}"
+		}
+	}
+	return method + " " + strings.Join(segments, "/")
+}
+
+// withPathParams returns a copy of an operation whose path parameters are those of the
+// route, in order, keeping the descriptions and types of the annotations
+func withPathParams(operation *Operation, params []string) *Operation {
+	copied := *operation
+	copied.Parameters = nil
+	var annotated []*Parameter
+	for _, param := range operation.Parameters {
+		if param.In == "path" {
+			annotated = append(annotated, param)
+		} else {
+			copied.Parameters = append(copied.Parameters, param)
+		}
+	}
+
+	pathParams := make([]*Parameter, len(params))
+	for i, name := range params {
+		param := &Parameter{Schema: &Schema{Type: "string"}}
+		for _, candidate := range annotated {
+			if candidate.Name == name {
+				param = candidate
+				break
+			}
+		}
+		if param.Name == "" && i < len(annotated) {
+			param = annotated[i]
+		}
+		param = &Parameter{
+			Name:        name,
+			In:          "path",
+			Description: param.Description,
+			Required:    true,
+			Schema:      param.Schema,
+			Example:     param.Example,
+		}
+		pathParams[i] = param
+	}
+	copied.Parameters = append(pathParams, copied.Parameters...)
+	return &copied
+}
+
+// undocumented returns the operation of a route without annotations
+func undocumented(method, path string, params []string) *Operation {
+	operation := &Operation{
+		OperationId: generatedOperationId(method, path),
+		Responses: map[string]*Response{
+			"default": {Description: "Response"},
+		},
+		Undocumented: true,
+	}
+	if segments := strings.Split(strings.TrimPrefix(path, "/api"), "/"); len(segments) > 1 && segments[1] != "" {
+		operation.Tags = []string{segments[1]}
+	}
+	return withPathParams(operation, params)
+}
+
+// generatedOperationId returns an operation id from the method and path of a route, e.g.
+// getUsersById for GET /api/users/{id}
+func generatedOperationId(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, segment := range strings.Split(strings.TrimPrefix(path, "/api"), "/") {
+		if strings.HasPrefix(segment, "{") {
+			b.WriteString("By")
+			segment = strings.Trim(segment, "{}")
+		}
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool {
+			return r == '-' || r == '_' || r == '.'
+		}) {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
+
+// uniqueOperationIds qualifies the operation ids used more than once, e.g. the Create of
+// several modules, with the first tag, then a number
+func uniqueOperationIds(operations []*Operation) {
+	count := map[string]int{}
+	for _, operation := range operations {
+		count[operation.OperationId]++
+	}
+	used := map[string]bool{}
+	for _, operation := range operations {
+		id := operation.OperationId
+		if count[id] > 1 && len(operation.Tags) > 0 {
+			tag := operation.Tags[0]
+			if i := strings.LastIndex(tag, "/"); i >= 0 {
+				tag = tag[i+1:]
+			}
+			id = lowerFirst(strings.ReplaceAll(tag, " ", "")) + strings.ToUpper(id[:1]) + id[1:]
+		}
+		for n, base := 2, id; used[id]; n++ {
+			id = fmt.Sprintf("%s%d", base, n)
+		}
+		used[id] = true
+		operation.OperationId = id
+	}
+}
+
+// collectSchemas copies the component schemas an operation references, and those they
+// reference, to the document
+func collectSchemas(operation *Operation, from, to map[string]*Schema) {
+	var visit func(schema *Schema)
+	visit = func(schema *Schema) {
+		if schema == nil {
+			return
+		}
+		if name, ok := strings.CutPrefix(schema.Ref, refPrefix); ok {
+			if _, done := to[name]; done {
+				return
+			}
+			if component, ok := from[name]; ok {
+				to[name] = component
+				visit(component)
+			}
+			return
+		}
+		for _, s := range schema.AllOf {
+			visit(s)
+		}
+		visit(schema.Items)
+		visit(schema.AdditionalProperties)
+		for _, property := range schema.Properties {
+			visit(property)
+		}
+	}
+
+	for _, param := range operation.Parameters {
+		visit(param.Schema)
+	}
+	if operation.RequestBody != nil {
+		for _, content := range operation.RequestBody.Content {
+			visit(content.Schema)
+		}
+	}
+	for _, response := range operation.Responses {
+		for _, content := range response.Content {
+			visit(content.Schema)
+		}
+	}
+}
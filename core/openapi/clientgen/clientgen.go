@@ -0,0 +1,234 @@
+// Package clientgen generates typed API clients, in Go and TypeScript, from an OpenAPI
+// document assembled by the openapi package.
+package clientgen
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"base/core/openapi"
+)
+
+// Component names with a meaning in the clients: paginated lists become Page types
+const (
+	paginatedResponse = "types.PaginatedResponse"
+	pagination        = "types.Pagination"
+	refPrefix         = "#/components/schemas/"
+)
+
+// reserved are the names of the types of the client runtimes; components with these names
+// are prefixed with their package
+var reserved = map[string]bool{
+	"Client": true, "Option": true, "Error": true, "ApiError": true, "Page": true,
+	"Pagination": true, "File": true, "Multipart": true, "ClientOptions": true, "RequestOptions": true,
+	"ApiClient": true, "PendingRequest": true,
+	"Ptr": true, "Items": true, "New": true, "WithAPIKey": true, "WithToken": true, "WithHTTPClient": true,
+}
+
+// operation is an operation of the document, in the order of the paths
+type operation struct {
+	Id          string // e.g. getProduct
+	Method      string // e.g. GET
+	Path        string // e.g. /api/products/{id}
+	Summary     string
+	Description string
+	Deprecated  bool
+
+	PathParams []*openapi.Parameter
+	Query      []*openapi.Parameter
+	Headers    []*openapi.Parameter
+
+	Body      *openapi.Schema // JSON request body
+	Multipart bool            // multipart/form-data request body
+	Required  bool            // whether the body is required
+
+	Result     *openapi.Schema // JSON body of the first 2xx response; nil without one
+	ResultType string          // content type of the first 2xx response; empty without content
+}
+
+// Binary reports whether the result is returned as bytes, e.g. a PDF or CSV download
+func (o *operation) Binary() bool {
+	return o.ResultType != "" && o.ResultType != "application/json"
+}
+
+// operations returns the operations of a document, sorted by path and method
+func operations(doc *openapi.Document) []*operation {
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var ops []*operation
+	for _, path := range paths {
+		item := doc.Paths[path]
+		methods := make([]string, 0, len(item))
+		for method := range item {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+
+		for _, method := range methods {
+			source := item[method]
+			op := &operation{
+				Id:          source.OperationId,
+				Method:      strings.ToUpper(method),
+				Path:        path,
+				Summary:     source.Summary,
+				Description: source.Description,
+				Deprecated:  source.Deprecated,
+			}
+			for _, param := range source.Parameters {
+				switch param.In {
+				case "path":
+					op.PathParams = append(op.PathParams, param)
+				case "query":
+					op.Query = append(op.Query, param)
+				case "header":
+					op.Headers = append(op.Headers, param)
+				}
+			}
+			if body := source.RequestBody; body != nil {
+				op.Required = body.Required
+				if content, ok := body.Content["application/json"]; ok {
+					op.Body = content.Schema
+				} else if _, ok := body.Content["multipart/form-data"]; ok {
+					op.Multipart = true
+				}
+			}
+			op.Result, op.ResultType = result(source)
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+// result returns the schema and content type of the first successful response with content
+func result(op *openapi.Operation) (*openapi.Schema, string) {
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		if strings.HasPrefix(code, "2") {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		content := op.Responses[code].Content
+		if len(content) == 0 {
+			continue
+		}
+		if media, ok := content["application/json"]; ok {
+			return media.Schema, "application/json"
+		}
+		types := make([]string, 0, len(content))
+		for contentType := range content {
+			types = append(types, contentType)
+		}
+		sort.Strings(types)
+		return nil, types[0]
+	}
+	return nil, ""
+}
+
+// typeNames names the component schemas of a document, e.g. ProductResponse for
+// products.ProductResponse; names used by several packages keep the package, e.g.
+// TypesErrorResponse and AuthenticationErrorResponse
+func typeNames(doc *openapi.Document) map[string]string {
+	count := map[string]int{}
+	for component := range doc.Components.Schemas {
+		count[shortName(component)]++
+	}
+	names := map[string]string{}
+	for component := range doc.Components.Schemas {
+		name := shortName(component)
+		if count[name] > 1 || reserved[name] {
+			name = pascal(component)
+		}
+		names[component] = name
+	}
+	return names
+}
+
+// shortName returns the type name of a component without its package
+func shortName(component string) string {
+	if i := strings.LastIndex(component, "."); i >= 0 {
+		component = component[i+1:]
+	}
+	return pascal(component)
+}
+
+// componentOf returns the component a schema references, if any
+func componentOf(schema *openapi.Schema) (string, bool) {
+	if schema == nil {
+		return "", false
+	}
+	if schema.Ref == "" && len(schema.AllOf) == 1 && len(schema.Properties) == 0 {
+		return componentOf(schema.AllOf[0])
+	}
+	return strings.CutPrefix(schema.Ref, refPrefix)
+}
+
+// pageItems returns the item schema of a paginated response composed with the type of its
+// items, e.g. types.PaginatedResponse{data=[]ProductResponse}
+func pageItems(schema *openapi.Schema) (*openapi.Schema, bool) {
+	if schema == nil || len(schema.AllOf) != 2 {
+		return nil, false
+	}
+	if component, _ := componentOf(schema.AllOf[0]); component != paginatedResponse {
+		return nil, false
+	}
+	data := schema.AllOf[1].Properties["data"]
+	if data == nil || data.Type != "array" {
+		return nil, false
+	}
+	return data.Items, true
+}
+
+// pascal converts a name to PascalCase, e.g. role_id to RoleId and products.Product to
+// ProductsProduct
+func pascal(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	result := b.String()
+	if result == "" || unicode.IsDigit(rune(result[0])) {
+		result = "X" + result
+	}
+	return result
+}
+
+// camel converts a name to camelCase, e.g. page_size to pageSize
+func camel(name string) string {
+	name = pascal(name)
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+// comment returns the lines of a doc comment, with the prefix, for a summary and description
+func comment(prefix string, texts ...string) string {
+	var lines []string
+	for _, text := range texts {
+		for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				lines = append(lines, prefix+line)
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// quote returns a string literal, valid in Go and TypeScript
+func quote(value string) string {
+	return strconv.Quote(value)
+}
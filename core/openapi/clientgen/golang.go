@@ -0,0 +1,385 @@
+package clientgen
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strings"
+	"text/template"
+
+	"base/core/openapi"
+)
+
+//go:embed templates/*.tmpl
+var templates embed.FS
+
+// goGenerator writes the types and methods of a Go client
+type goGenerator struct {
+	doc   *openapi.Document
+	names map[string]string // Go type names by component
+
+	types   bytes.Buffer    // Declarations of the named types
+	defined map[string]bool // Names declared so far, including inline types
+}
+
+// Go generates a Go client package named pkg; it only depends on the standard library
+func Go(doc *openapi.Document, pkg string) ([]byte, error) {
+	g := &goGenerator{doc: doc, names: typeNames(doc), defined: map[string]bool{}}
+	for name := range reserved {
+		g.defined[name] = true
+	}
+
+	components := make([]string, 0, len(doc.Components.Schemas))
+	for component := range doc.Components.Schemas {
+		if component != paginatedResponse && component != pagination {
+			components = append(components, component)
+		}
+	}
+	sort.Strings(components)
+	for _, component := range components {
+		g.declare(g.names[component], doc.Components.Schemas[component])
+	}
+
+	var methods bytes.Buffer
+	var login *operation
+	for _, op := range operations(doc) {
+		g.method(&methods, op)
+		if op.Id == "login" {
+			login = op
+		}
+	}
+
+	data := map[string]any{
+		"Package":      pkg,
+		"Title":        doc.Info.Title,
+		"Version":      doc.Info.Version,
+		"APIKeyHeader": apiKeyHeader(doc),
+		"Types":        g.types.String(),
+		"Methods":      methods.String(),
+		"Login":        g.login(login),
+	}
+	var out bytes.Buffer
+	tmpl := template.Must(template.ParseFS(templates, "templates/client.go.tmpl"))
+	if err := tmpl.Execute(&out, data); err != nil {
+		return nil, err
+	}
+	source, err := format.Source(out.Bytes())
+	if err != nil {
+		return out.Bytes(), fmt.Errorf("generated Go client is invalid: %w", err)
+	}
+	return source, nil
+}
+
+// apiKeyHeader returns the header of the API key scheme, e.g. X-Api-Key
+func apiKeyHeader(doc *openapi.Document) string {
+	names := make([]string, 0, len(doc.Components.SecuritySchemes))
+	for name := range doc.Components.SecuritySchemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		scheme := doc.Components.SecuritySchemes[name]
+		if scheme.Type == "apiKey" && scheme.In == "header" && !strings.EqualFold(scheme.Name, "Authorization") {
+			return scheme.Name
+		}
+	}
+	return "X-Api-Key"
+}
+
+// declare writes the declaration of a named type
+func (g *goGenerator) declare(name string, schema *openapi.Schema) {
+	g.defined[name] = true
+	if schema.Description != "" {
+		g.types.WriteString(comment("// ", schema.Description) + "\n")
+	}
+	switch {
+	case len(schema.AllOf) > 1:
+		fmt.Fprintf(&g.types, "type %s struct {\n", name)
+		var fields []string
+		for _, part := range schema.AllOf {
+			if component, ok := componentOf(part); ok {
+				g.types.WriteString("\t" + strings.TrimPrefix(g.typeOf(&openapi.Schema{Ref: refPrefix + component}, ""), "*") + "\n")
+			} else {
+				fields = append(fields, g.fields(name, part)...)
+			}
+		}
+		g.types.WriteString(strings.Join(fields, ""))
+		g.types.WriteString("}\n\n")
+	case schema.Type == "object" && schema.AdditionalProperties == nil || len(schema.Properties) > 0:
+		fmt.Fprintf(&g.types, "type %s struct {\n%s}\n\n", name, strings.Join(g.fields(name, schema), ""))
+	default:
+		fmt.Fprintf(&g.types, "type %s %s\n\n", name, g.typeOf(schema, name+"Value"))
+	}
+}
+
+// fields returns the struct fields of the properties of an object
+func (g *goGenerator) fields(parent string, schema *openapi.Schema) []string {
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	used := map[string]bool{}
+	var fields []string
+	for _, name := range names {
+		property := schema.Properties[name]
+		field := pascal(name)
+		for n := 2; used[field]; n++ {
+			field = fmt.Sprintf("%s%d", pascal(name), n)
+		}
+		used[field] = true
+
+		fieldType := g.typeOf(property, parent+field)
+		tag := name
+		if property.Nullable {
+			fieldType = pointer(fieldType)
+			tag += ",omitempty"
+		}
+		var line string
+		if property.Description != "" {
+			line = comment("\t// ", property.Description) + "\n"
+		}
+		fields = append(fields, line+fmt.Sprintf("\t%s %s `json:%q`\n", field, fieldType, tag))
+	}
+	return fields
+}
+
+// pointer returns a pointer to a type, except for types that can already be nil
+func pointer(goType string) string {
+	if strings.HasPrefix(goType, "*") || strings.HasPrefix(goType, "[]") ||
+		strings.HasPrefix(goType, "map[") || goType == "any" || goType == "json.RawMessage" {
+		return goType
+	}
+	return "*" + goType
+}
+
+// typeOf returns the Go type of a schema; inline objects are declared as types named hint
+func (g *goGenerator) typeOf(schema *openapi.Schema, hint string) string {
+	if schema == nil {
+		return "any"
+	}
+	if items, ok := pageItems(schema); ok {
+		return "Page[" + g.typeOf(items, hint+"Item") + "]"
+	}
+	if component, ok := componentOf(schema); ok {
+		switch component {
+		case paginatedResponse:
+			return "Page[json.RawMessage]"
+		case pagination:
+			return "Pagination"
+		}
+		if name, ok := g.names[component]; ok {
+			return name
+		}
+		return "any"
+	}
+
+	switch schema.Type {
+	case "string":
+		switch schema.Format {
+		case "date-time":
+			return "time.Time"
+		case "binary", "byte":
+			return "[]byte"
+		}
+		return "string"
+	case "integer":
+		switch schema.Format {
+		case "int64":
+			return "int64"
+		case "int32":
+			return "int32"
+		}
+		return "int"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		return "[]" + g.typeOf(schema.Items, hint+"Item")
+	}
+
+	if len(schema.Properties) > 0 || len(schema.AllOf) > 1 {
+		name := hint
+		for n := 2; g.defined[name]; n++ {
+			name = fmt.Sprintf("%s%d", hint, n)
+		}
+		g.declare(name, schema)
+		return name
+	}
+	if schema.AdditionalProperties != nil {
+		return "map[string]" + g.typeOf(schema.AdditionalProperties, hint+"Value")
+	}
+	if schema.Type == "object" {
+		return "map[string]any"
+	}
+	return "any"
+}
+
+// isStruct reports whether a Go type is a struct, returned by pointer
+func (g *goGenerator) isStruct(goType string) bool {
+	if strings.HasPrefix(goType, "Page[") {
+		return true
+	}
+	for component, name := range g.names {
+		if name == goType {
+			schema := g.doc.Components.Schemas[component]
+			return len(schema.AllOf) > 1 || len(schema.Properties) > 0 || schema.Type == "object" && schema.AdditionalProperties == nil
+		}
+	}
+	// Inline objects are declared as structs
+	return g.defined[goType] && !reserved[goType]
+}
+
+// goParam is a parameter of a generated method
+type goParam struct {
+	name   string // Go name, e.g. variantId
+	goType string
+}
+
+// method writes the method of an operation
+func (g *goGenerator) method(out *bytes.Buffer, op *operation) {
+	name := pascal(op.Id)
+	used := map[string]bool{"ctx": true, "c": true, "req": true, "result": true, "body": true, "form": true, "params": true}
+	var args []string
+	var pathParams []goParam
+	for _, param := range op.PathParams {
+		p := goParam{name: camel(param.Name), goType: g.typeOf(param.Schema, name+pascal(param.Name))}
+		for used[p.name] || token.IsKeyword(p.name) {
+			p.name += "Param"
+		}
+		used[p.name] = true
+		pathParams = append(pathParams, p)
+		args = append(args, p.name+" "+p.goType)
+	}
+
+	if op.Body != nil {
+		args = append(args, "body "+pointer(g.typeOf(op.Body, name+"Request")))
+	} else if op.Multipart {
+		args = append(args, "form *Multipart")
+	}
+
+	paramsType := ""
+	if len(op.Query)+len(op.Headers) > 0 {
+		paramsType = name + "Params"
+		for n := 2; g.defined[paramsType]; n++ {
+			paramsType = fmt.Sprintf("%sParams%d", name, n)
+		}
+		g.defined[paramsType] = true
+		fmt.Fprintf(&g.types, "// %s are the query and header parameters of %s\ntype %s struct {\n", paramsType, name, paramsType)
+		for _, param := range append(append([]*openapi.Parameter{}, op.Query...), op.Headers...) {
+			if param.Description != "" {
+				g.types.WriteString(comment("\t// ", param.Description) + "\n")
+			}
+			fieldType := g.typeOf(param.Schema, paramsType+pascal(param.Name))
+			if !param.Required {
+				fieldType = pointer(fieldType)
+			}
+			fmt.Fprintf(&g.types, "\t%s %s\n", pascal(param.Name), fieldType)
+		}
+		g.types.WriteString("}\n\n")
+		args = append(args, "params *"+paramsType)
+	}
+
+	resultType, returns := "", "error"
+	switch {
+	case op.Binary():
+		returns = "([]byte, error)"
+	case op.Result != nil:
+		resultType = g.typeOf(op.Result, name+"Response")
+		if g.isStruct(resultType) {
+			returns = "(*" + resultType + ", error)"
+		} else {
+			returns = "(" + resultType + ", error)"
+		}
+	}
+
+	out.WriteString(comment("// ", name+" calls "+op.Method+" "+op.Path) + "\n")
+	if text := comment("// ", op.Summary, op.Description); text != "" {
+		out.WriteString("//\n" + text + "\n")
+	}
+	if op.Deprecated {
+		out.WriteString("//\n// Deprecated: the endpoint is deprecated.\n")
+	}
+	fmt.Fprintf(out, "func (c *Client) %s(%s) %s {\n", name, strings.Join(append([]string{"ctx context.Context"}, args...), ", "), returns)
+
+	// The path with its parameters
+	path := quote(op.Path)
+	for i, param := range op.PathParams {
+		path = strings.Replace(path, "{"+param.Name+"}", `" + pathParam(`+pathParams[i].name+`) + "`, 1)
+	}
+	path = strings.TrimSuffix(strings.TrimPrefix(path, `"" + `), ` + ""`)
+	fmt.Fprintf(out, "\treq := &request{method: %q, path: %s}\n", op.Method, path)
+
+	if paramsType != "" {
+		out.WriteString("\tif params != nil {\n")
+		for _, param := range op.Query {
+			fmt.Fprintf(out, "\t\treq.addQuery(%q, params.%s)\n", param.Name, pascal(param.Name))
+		}
+		for _, param := range op.Headers {
+			fmt.Fprintf(out, "\t\treq.addHeader(%q, params.%s)\n", param.Name, pascal(param.Name))
+		}
+		out.WriteString("\t}\n")
+	}
+	if op.Body != nil {
+		out.WriteString("\treq.body = body\n")
+	} else if op.Multipart {
+		out.WriteString("\treq.form = form\n")
+	}
+
+	switch {
+	case op.Binary():
+		out.WriteString("\treturn c.raw(ctx, req)\n")
+	case resultType == "":
+		out.WriteString("\treturn c.do(ctx, req, nil)\n")
+	case g.isStruct(resultType):
+		fmt.Fprintf(out, "\tvar result %s\n\tif err := c.do(ctx, req, &result); err != nil {\n\t\treturn nil, err\n\t}\n\treturn &result, nil\n", resultType)
+	default:
+		fmt.Fprintf(out, "\tvar result %s\n\terr := c.do(ctx, req, &result)\n\treturn result, err\n", resultType)
+	}
+	out.WriteString("}\n\n")
+}
+
+// login returns the Authenticate helper when the API has a login operation taking an email
+// and password and returning an access token
+func (g *goGenerator) login(op *operation) string {
+	if op == nil || op.Body == nil || op.Result == nil {
+		return ""
+	}
+	request, ok := componentOf(op.Body)
+	if !ok || !hasProperties(g.doc.Components.Schemas[request], "email", "password") {
+		return ""
+	}
+	response, ok := componentOf(op.Result)
+	if !ok || !hasProperties(g.doc.Components.Schemas[response], "accessToken") {
+		return ""
+	}
+	return fmt.Sprintf(`// Authenticate logs in and sends the access token with the following requests
+func (c *Client) Authenticate(ctx context.Context, email, password string) (*%[1]s, error) {
+	response, err := c.%[3]s(ctx, &%[2]s{Email: email, Password: password})
+	if err != nil {
+		return nil, err
+	}
+	c.SetToken(response.AccessToken)
+	return response, nil
+}
+`, g.names[response], g.names[request], pascal(op.Id))
+}
+
+// hasProperties reports whether an object schema has the properties, all plain strings
+func hasProperties(schema *openapi.Schema, names ...string) bool {
+	if schema == nil {
+		return false
+	}
+	for _, name := range names {
+		property := schema.Properties[name]
+		if property == nil || property.Type != "string" || property.Nullable {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,297 @@
+package clientgen
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"base/core/openapi"
+)
+
+// identifier matches names usable as TypeScript properties without quotes
+var identifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// tsGenerator writes the types and methods of a TypeScript client
+type tsGenerator struct {
+	doc   *openapi.Document
+	names map[string]string
+}
+
+// TypeScript generates a TypeScript client module using fetch, e.g. for the Nuxt frontend
+func TypeScript(doc *openapi.Document) ([]byte, error) {
+	g := &tsGenerator{doc: doc, names: typeNames(doc)}
+
+	components := make([]string, 0, len(doc.Components.Schemas))
+	for component := range doc.Components.Schemas {
+		if component != paginatedResponse && component != pagination {
+			components = append(components, component)
+		}
+	}
+	sort.Strings(components)
+
+	var types bytes.Buffer
+	for _, component := range components {
+		schema := doc.Components.Schemas[component]
+		name := g.names[component]
+		if schema.Description != "" {
+			types.WriteString("/** " + strings.ReplaceAll(schema.Description, "*/", "*\\/") + " */\n")
+		}
+		if schema.Type == "object" && len(schema.AllOf) == 0 && schema.AdditionalProperties == nil {
+			fmt.Fprintf(&types, "export interface %s %s\n\n", name, g.object(schema, ""))
+		} else {
+			fmt.Fprintf(&types, "export type %s = %s\n\n", name, g.typeOf(schema, ""))
+		}
+	}
+
+	var methods bytes.Buffer
+	var login *operation
+	for _, op := range operations(doc) {
+		g.method(&methods, op)
+		if op.Id == "login" {
+			login = op
+		}
+	}
+
+	data := map[string]any{
+		"Title":        doc.Info.Title,
+		"Version":      doc.Info.Version,
+		"APIKeyHeader": apiKeyHeader(doc),
+		"Types":        types.String(),
+		"Methods":      methods.String(),
+		"Login":        g.login(login),
+	}
+	var out bytes.Buffer
+	tmpl := template.Must(template.ParseFS(templates, "templates/client.ts.tmpl"))
+	if err := tmpl.Execute(&out, data); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// typeOf returns the TypeScript type of a schema, indented for nested objects
+func (g *tsGenerator) typeOf(schema *openapi.Schema, indent string) string {
+	if schema == nil {
+		return "unknown"
+	}
+	t := g.baseType(schema, indent)
+	if schema.Nullable && t != "unknown" {
+		t += " | null"
+	}
+	return t
+}
+
+// baseType returns the type of a schema, without null
+func (g *tsGenerator) baseType(schema *openapi.Schema, indent string) string {
+	if items, ok := pageItems(schema); ok {
+		return "Page<" + g.typeOf(items, indent) + ">"
+	}
+	if component, ok := componentOf(schema); ok {
+		switch component {
+		case paginatedResponse:
+			return "Page"
+		case pagination:
+			return "Pagination"
+		}
+		if name, ok := g.names[component]; ok {
+			return name
+		}
+		return "unknown"
+	}
+	if len(schema.AllOf) > 0 {
+		parts := make([]string, len(schema.AllOf))
+		for i, part := range schema.AllOf {
+			parts[i] = g.typeOf(part, indent)
+		}
+		return strings.Join(parts, " & ")
+	}
+
+	switch schema.Type {
+	case "string":
+		if schema.Format == "binary" {
+			return "Blob"
+		}
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "array":
+		item := g.typeOf(schema.Items, indent)
+		if strings.ContainsAny(item, " |&") {
+			return "Array<" + item + ">"
+		}
+		return item + "[]"
+	}
+	if len(schema.Properties) > 0 {
+		return g.object(schema, indent)
+	}
+	if schema.AdditionalProperties != nil {
+		return "Record<string, " + g.typeOf(schema.AdditionalProperties, indent) + ">"
+	}
+	if schema.Type == "object" {
+		return "Record<string, unknown>"
+	}
+	return "unknown"
+}
+
+// object returns the type of an object literal with the properties of a schema
+func (g *tsGenerator) object(schema *openapi.Schema, indent string) string {
+	if len(schema.Properties) == 0 {
+		return "{}"
+	}
+	required := map[string]bool{}
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("{\n")
+	for _, name := range names {
+		property := schema.Properties[name]
+		if property.Description != "" {
+			fmt.Fprintf(&b, "%s  /** %s */\n", indent, strings.ReplaceAll(property.Description, "\n", " "))
+		}
+		optional := "?"
+		if required[name] {
+			optional = ""
+		}
+		fmt.Fprintf(&b, "%s  %s%s: %s\n", indent, propertyName(name), optional, g.typeOf(property, indent+"  "))
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+// propertyName quotes property names that aren't identifiers
+func propertyName(name string) string {
+	if identifier.MatchString(name) {
+		return name
+	}
+	return quote(name)
+}
+
+// method writes the method of an operation
+func (g *tsGenerator) method(out *bytes.Buffer, op *operation) {
+	var args []string
+	used := map[string]bool{"body": true, "form": true, "params": true, "options": true}
+	path := op.Path
+	for _, param := range op.PathParams {
+		name := camel(param.Name)
+		for used[name] {
+			name += "Param"
+		}
+		used[name] = true
+		args = append(args, name+": "+g.typeOf(param.Schema, "  "))
+		path = strings.Replace(path, "{"+param.Name+"}", "${encodeURIComponent(String("+name+"))}", 1)
+	}
+	if op.Body != nil {
+		args = append(args, "body: "+g.typeOf(op.Body, "  "))
+	} else if op.Multipart {
+		args = append(args, "form: FormData")
+	}
+
+	params := append(append([]*openapi.Parameter{}, op.Query...), op.Headers...)
+	if len(params) > 0 {
+		var b strings.Builder
+		b.WriteString("{\n")
+		allOptional := true
+		for _, param := range params {
+			if param.Description != "" {
+				fmt.Fprintf(&b, "      /** %s */\n", strings.ReplaceAll(param.Description, "\n", " "))
+			}
+			optional := "?"
+			if param.Required {
+				optional, allOptional = "", false
+			}
+			fmt.Fprintf(&b, "      %s%s: %s\n", propertyName(param.Name), optional, g.typeOf(param.Schema, "      "))
+		}
+		b.WriteString("    }")
+		if allOptional {
+			args = append(args, "params: "+b.String()+" = {}")
+		} else {
+			args = append(args, "params: "+b.String())
+		}
+	}
+	args = append(args, "options: RequestOptions = {}")
+
+	// The result type is written twice, at the indentation of the signature and of the call
+	resultType, callType, responseType := "void", "void", "none"
+	switch {
+	case op.Binary():
+		resultType, callType, responseType = "Blob", "Blob", "blob"
+		if strings.HasPrefix(op.ResultType, "text/") {
+			resultType, callType, responseType = "string", "string", "text"
+		}
+	case op.Result != nil:
+		resultType, callType, responseType = g.typeOf(op.Result, "  "), g.typeOf(op.Result, "    "), "json"
+	}
+
+	doc := []string{op.Method + " " + op.Path}
+	for _, text := range []string{op.Summary, op.Description} {
+		if text = strings.TrimSpace(text); text != "" {
+			doc = append(doc, "", text)
+		}
+	}
+	if op.Deprecated {
+		doc = append(doc, "", "@deprecated")
+	}
+	out.WriteString("  /**\n")
+	for _, line := range doc {
+		out.WriteString(strings.TrimRight("   * "+strings.ReplaceAll(line, "*/", "*\\/"), " ") + "\n")
+	}
+	out.WriteString("   */\n")
+
+	fmt.Fprintf(out, "  %s(\n    %s\n  ): Promise<%s> {\n", op.Id, strings.Join(args, ",\n    "), resultType)
+	fmt.Fprintf(out, "    return this.request<%s>(%q, `%s`, {\n      ...options,\n", callType, op.Method, path)
+	if len(op.Query) > 0 {
+		fields := make([]string, len(op.Query))
+		for i, param := range op.Query {
+			fields[i] = quote(param.Name) + ": params[" + quote(param.Name) + "]"
+		}
+		fmt.Fprintf(out, "      query: { %s },\n", strings.Join(fields, ", "))
+	}
+	if len(op.Headers) > 0 {
+		fields := make([]string, len(op.Headers))
+		for i, param := range op.Headers {
+			fields[i] = quote(param.Name) + ": params[" + quote(param.Name) + "]"
+		}
+		fmt.Fprintf(out, "      headers: { ...options.headers, %s },\n", strings.Join(fields, ", "))
+	}
+	if op.Body != nil {
+		out.WriteString("      body,\n")
+	} else if op.Multipart {
+		out.WriteString("      form,\n")
+	}
+	fmt.Fprintf(out, "      responseType: %q,\n    })\n  }\n\n", responseType)
+}
+
+// login returns the authenticate helper when the API has a login operation taking an email
+// and password and returning an access token
+func (g *tsGenerator) login(op *operation) string {
+	if op == nil || op.Body == nil || op.Result == nil {
+		return ""
+	}
+	request, ok := componentOf(op.Body)
+	if !ok || !hasProperties(g.doc.Components.Schemas[request], "email", "password") {
+		return ""
+	}
+	response, ok := componentOf(op.Result)
+	if !ok || !hasProperties(g.doc.Components.Schemas[response], "accessToken") {
+		return ""
+	}
+	return fmt.Sprintf(`  /** Logs in and sends the access token with the following requests */
+  async authenticate(email: string, password: string): Promise<%s> {
+    const response = await this.%s({ email, password })
+    this.setToken(response.accessToken)
+    return response
+  }
+
+`, g.names[response], op.Id)
+}
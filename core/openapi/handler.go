@@ -0,0 +1,82 @@
+package openapi
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"base/core/router"
+	"base/core/types"
+)
+
+// DefaultDocument is where the openapi command writes the document; servers without the
+// Go source read the annotations from it
+const DefaultDocument = "swagger/openapi.json"
+
+// LoadAnnotations reads the annotations from the source of the module in root, or from
+// the document written by the openapi command when the source isn't deployed
+func LoadAnnotations(root, document string) (*Annotations, error) {
+	if module, err := moduleName(filepath.Join(root, "go.mod")); err == nil {
+		return ParseSource(root, module)
+	}
+
+	doc, err := Load(document)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Annotations{SecuritySchemes: map[string]*SecurityScheme{}, schemas: map[string]*Schema{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return FromDocument(doc), nil
+}
+
+// moduleName reads the module path of a go.mod file
+func moduleName(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if module, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "module "); ok {
+			return strings.Trim(strings.TrimSpace(module), `"`), nil
+		}
+	}
+	return "", errors.New("no module directive in " + path)
+}
+
+// Handler serves the document of the server. It is registered with the other routes and
+// given the document once all routes are registered, so the document lists its own route.
+type Handler struct {
+	data []byte
+}
+
+// SetDocument sets the document the handler serves
+func (h *Handler) SetDocument(doc *Document) error {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	h.data = data
+	return nil
+}
+
+// Spec returns the OpenAPI document
+// @Summary Get the OpenAPI document
+// @Description Returns the OpenAPI 3 document of the API, assembled at startup from the registered routes and their annotations. Routes without annotations are marked x-undocumented.
+// @Tags Core/Docs
+// @Produce json
+// @Success 200 {object} Document
+// @Router /openapi.json [get]
+func (h *Handler) Spec(ctx *router.Context) error {
+	if h.data == nil {
+		return ctx.JSON(http.StatusServiceUnavailable, types.ErrorResponse{Error: "API docs are not ready"})
+	}
+	return ctx.Data(http.StatusOK, "application/json", h.data)
+}
@@ -0,0 +1,255 @@
+package openapi
+
+import (
+	"go/ast"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// refPrefix is the prefix of references to component schemas
+const refPrefix = "#/components/schemas/"
+
+// basicSchemas are the schemas of Go basic types and swag type names
+var basicSchemas = map[string]Schema{
+	"string":  {Type: "string"},
+	"bool":    {Type: "boolean"},
+	"boolean": {Type: "boolean"},
+	"int":     {Type: "integer"},
+	"int8":    {Type: "integer"},
+	"int16":   {Type: "integer"},
+	"int32":   {Type: "integer", Format: "int32"},
+	"int64":   {Type: "integer", Format: "int64"},
+	"integer": {Type: "integer"},
+	"uint":    {Type: "integer"},
+	"uint8":   {Type: "integer"},
+	"uint16":  {Type: "integer"},
+	"uint32":  {Type: "integer", Format: "int32"},
+	"uint64":  {Type: "integer", Format: "int64"},
+	"byte":    {Type: "integer"},
+	"rune":    {Type: "integer"},
+	"float32": {Type: "number", Format: "float"},
+	"float64": {Type: "number", Format: "double"},
+	"number":  {Type: "number"},
+	"file":    {Type: "string", Format: "binary"},
+	"error":   {Type: "string"},
+}
+
+// externalSchemas are the schemas of types from other modules
+var externalSchemas = map[string]Schema{
+	"time.Time":            {Type: "string", Format: "date-time"},
+	"time.Duration":        {Type: "integer", Format: "int64"},
+	"gorm.DeletedAt":       {Type: "string", Format: "date-time", Nullable: true},
+	"sql.NullTime":         {Type: "string", Format: "date-time", Nullable: true},
+	"json.RawMessage":      {},
+	"datatypes.JSON":       {},
+	"multipart.FileHeader": {Type: "string", Format: "binary"},
+}
+
+// typeSchema returns the schema of a type in an annotation, e.g. ProductResponse,
+// []types.Media, map[string]string or object{data=[]Role}, in the package pkg
+func (a *Annotations) typeSchema(pkg, expr string) *Schema {
+	expr = strings.TrimSpace(expr)
+	switch {
+	case expr == "" || expr == "nil":
+		return nil
+	case strings.HasPrefix(expr, "[]"):
+		return &Schema{Type: "array", Items: a.typeSchema(pkg, expr[2:])}
+	case strings.HasPrefix(expr, "map["):
+		end := strings.Index(expr, "]")
+		if end < 0 {
+			return &Schema{Type: "object"}
+		}
+		return &Schema{Type: "object", AdditionalProperties: a.typeSchema(pkg, expr[end+1:])}
+	case expr == "interface{}" || expr == "any":
+		return &Schema{}
+	}
+
+	// Composition: the properties in braces replace those of the base type
+	base, fields, composed := strings.Cut(expr, "{")
+	if composed {
+		fields = strings.TrimSuffix(fields, "}")
+	}
+	var schema *Schema
+	if base == "object" {
+		schema = &Schema{Type: "object"}
+	} else if basic, ok := basicSchemas[base]; ok {
+		schema = &basic
+	} else {
+		schema = a.component(pkg, base)
+	}
+	if !composed || fields == "" {
+		return schema
+	}
+
+	properties := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for _, field := range splitTopLevel(fields) {
+		name, value, ok := strings.Cut(field, "=")
+		if ok {
+			properties.Properties[strings.TrimSpace(name)] = a.typeSchema(pkg, value)
+		}
+	}
+	if base == "object" {
+		return properties
+	}
+	return &Schema{AllOf: []*Schema{schema, properties}}
+}
+
+// splitTopLevel splits the properties of a composition on the commas outside of braces
+func splitTopLevel(value string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range value {
+		switch r {
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, value[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, value[start:])
+}
+
+// component returns a reference to the schema of a named type, e.g. types.ErrorResponse,
+// building the schema on first use. Types not found in the source are plain objects.
+func (a *Annotations) component(pkg, name string) *Schema {
+	key := name
+	if !strings.Contains(name, ".") {
+		key = pkg + "." + name
+	}
+	if external, ok := externalSchemas[key]; ok {
+		return &external
+	}
+	if _, ok := a.schemas[key]; ok {
+		return &Schema{Ref: refPrefix + key}
+	}
+	decl, ok := a.types[key]
+	if !ok {
+		return &Schema{Type: "object"}
+	}
+
+	// Registered before it is built, so recursive types end in a reference
+	a.schemas[key] = &Schema{}
+	schema := a.goSchema(decl.pkg, decl.spec.Type)
+	if decl.spec.Doc != nil {
+		schema.Description = strings.TrimSpace(decl.spec.Doc.Text())
+	}
+	*a.schemas[key] = *schema
+	return &Schema{Ref: refPrefix + key}
+}
+
+// goSchema returns the schema of a type expression in the source of package pkg
+func (a *Annotations) goSchema(pkg string, expr ast.Expr) *Schema {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if basic, ok := basicSchemas[t.Name]; ok {
+			return &basic
+		}
+		if t.Name == "any" {
+			return &Schema{}
+		}
+		return a.component(pkg, t.Name)
+	case *ast.SelectorExpr:
+		if x, ok := t.X.(*ast.Ident); ok {
+			return a.component(pkg, x.Name+"."+t.Sel.Name)
+		}
+	case *ast.StarExpr:
+		return a.goSchema(pkg, t.X)
+	case *ast.ArrayType:
+		if ident, ok := t.Elt.(*ast.Ident); ok && ident.Name == "byte" {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: a.goSchema(pkg, t.Elt)}
+	case *ast.MapType:
+		return &Schema{Type: "object", AdditionalProperties: a.goSchema(pkg, t.Value)}
+	case *ast.InterfaceType:
+		return &Schema{}
+	case *ast.StructType:
+		return a.structSchema(pkg, t)
+	}
+	return &Schema{Type: "object"}
+}
+
+// structSchema returns the schema of a struct, named by the json tags of its fields
+func (a *Annotations) structSchema(pkg string, st *ast.StructType) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for _, field := range st.Fields.List {
+		var tag reflect.StructTag
+		if field.Tag != nil {
+			value, _ := strconv.Unquote(field.Tag.Value)
+			tag = reflect.StructTag(value)
+		}
+		jsonName, _, _ := strings.Cut(tag.Get("json"), ",")
+		if jsonName == "-" {
+			continue
+		}
+
+		// Fields of embedded structs are promoted, unless the embedded field is named
+		if len(field.Names) == 0 && jsonName == "" {
+			embedded := a.goSchema(pkg, field.Type)
+			if embedded.Ref != "" {
+				embedded = a.schemas[strings.TrimPrefix(embedded.Ref, refPrefix)]
+			}
+			for name, property := range embedded.Properties {
+				schema.Properties[name] = property
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+
+		names := []string{jsonName}
+		if jsonName == "" {
+			names = names[:0]
+			for _, name := range field.Names {
+				if name.IsExported() {
+					names = append(names, name.Name)
+				}
+			}
+		}
+		for _, name := range names {
+			property := a.goSchema(pkg, field.Type)
+			_, pointer := field.Type.(*ast.StarExpr)
+			if comment := fieldComment(field); comment != "" || pointer {
+				// References can't have siblings, so they are wrapped to be described
+				if property.Ref != "" {
+					property = &Schema{AllOf: []*Schema{property}}
+				}
+				property.Description = comment
+				property.Nullable = pointer
+			}
+			schema.Properties[name] = property
+			if isRequired(tag) {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+	}
+	return schema
+}
+
+// fieldComment returns the comment of a struct field
+func fieldComment(field *ast.Field) string {
+	if field.Comment != nil {
+		return strings.TrimSpace(field.Comment.Text())
+	}
+	if field.Doc != nil {
+		return strings.TrimSpace(field.Doc.Text())
+	}
+	return ""
+}
+
+// isRequired reports whether the validation tags of a field require it
+func isRequired(tag reflect.StructTag) bool {
+	for _, key := range []string{"validate", "binding"} {
+		for _, rule := range strings.Split(tag.Get(key), ",") {
+			if rule == "required" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,362 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PageSize is the size of a page in points (1/72 inch)
+type PageSize struct {
+	Width  float64
+	Height float64
+}
+
+var (
+	A4     = PageSize{Width: 595.28, Height: 841.89}
+	Letter = PageSize{Width: 612, Height: 792}
+)
+
+// PageSizes are the page sizes by name, see PDF_PAGE_SIZE
+var PageSizes = map[string]PageSize{
+	"A4":     A4,
+	"Letter": Letter,
+}
+
+// Color is an RGB color
+type Color struct {
+	R, G, B uint8
+}
+
+var (
+	Black     = Color{0, 0, 0}
+	DarkGray  = Color{64, 64, 64}
+	Gray      = Color{128, 128, 128}
+	LightGray = Color{220, 220, 220}
+	Shade     = Color{240, 240, 240}
+)
+
+// Align is the horizontal alignment of text
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignCenter
+	AlignRight
+)
+
+// Style is the look of a block of text; the zero value is 10pt black regular text
+type Style struct {
+	Size   float64
+	Bold   bool
+	Italic bool
+	Color  Color
+	Align  Align
+}
+
+func (s Style) size() float64 {
+	if s.Size <= 0 {
+		return 10
+	}
+	return s.Size
+}
+
+// Span is a run of text within a paragraph. A "\n" in the text starts a new line.
+type Span struct {
+	Text   string
+	Bold   bool
+	Italic bool
+}
+
+func spanFont(bold, italic bool) string {
+	switch {
+	case bold:
+		return fontBold
+	case italic:
+		return fontItalic
+	}
+	return fontRegular
+}
+
+// lineHeight is the height of a line relative to the font size
+const lineHeight = 1.35
+
+// page is the content stream of a page and the images it uses
+type page struct {
+	content bytes.Buffer
+	images  map[string]bool
+}
+
+// Document builds a PDF from flowing blocks (headings, paragraphs, tables, images) that
+// break across pages, and can also be drawn on directly with the Draw* methods.
+// Coordinates are in points from the top left corner of the page.
+type Document struct {
+	Title       string
+	Author      string
+	Size        PageSize
+	Margin      float64
+	PageNumbers bool // Print "Page n of N" at the bottom of every page
+
+	pages  []*page
+	images []*image
+	y      float64 // Top of the next block on the current page
+}
+
+// New creates an empty document with 40pt margins
+func New(size PageSize) *Document {
+	if size.Width <= 0 || size.Height <= 0 {
+		size = A4
+	}
+	return &Document{Size: size, Margin: 40}
+}
+
+// ContentWidth is the width between the margins
+func (d *Document) ContentWidth() float64 {
+	return d.Size.Width - 2*d.Margin
+}
+
+// bottom is the lowest y blocks can reach, leaving room for the page numbers
+func (d *Document) bottom() float64 {
+	bottom := d.Size.Height - d.Margin
+	if d.PageNumbers {
+		bottom -= 14
+	}
+	return bottom
+}
+
+// AddPage starts a new page
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &page{images: make(map[string]bool)})
+	d.y = d.Margin
+}
+
+// PageCount returns the number of pages so far
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+func (d *Document) current() *page {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	return d.pages[len(d.pages)-1]
+}
+
+// ensure starts a new page when a block of height h doesn't fit on the current one
+func (d *Document) ensure(h float64) {
+	p := d.current()
+	if d.y+h > d.bottom() && (d.y > d.Margin || p.content.Len() > 0) {
+		d.AddPage()
+	}
+}
+
+// Y returns the top of the next block on the current page
+func (d *Document) Y() float64 {
+	d.current()
+	return d.y
+}
+
+// DrawText draws a single line of text with its top at (x, y)
+func (d *Document) DrawText(x, y float64, text string, style Style) {
+	d.drawText(d.current(), x, y, text, spanFont(style.Bold, style.Italic), style.size(), style.Color)
+}
+
+func (d *Document) drawText(p *page, x, y float64, text, font string, size float64, color Color) {
+	if text == "" {
+		return
+	}
+	baseline := d.Size.Height - y - size*0.8
+	fmt.Fprintf(&p.content, "BT %s rg /%s %s Tf %s %s Td (%s) Tj ET\n",
+		rgb(color), font, num(size), num(x), num(baseline), escape(text))
+}
+
+// DrawLine draws a line from (x1, y1) to (x2, y2)
+func (d *Document) DrawLine(x1, y1, x2, y2, width float64, color Color) {
+	p := d.current()
+	fmt.Fprintf(&p.content, "%s RG %s w %s %s m %s %s l S\n",
+		rgb(color), num(width), num(x1), num(d.Size.Height-y1), num(x2), num(d.Size.Height-y2))
+}
+
+// DrawRect fills a rectangle with its top left corner at (x, y)
+func (d *Document) DrawRect(x, y, w, h float64, color Color) {
+	p := d.current()
+	fmt.Fprintf(&p.content, "%s rg %s %s %s %s re f\n",
+		rgb(color), num(x), num(d.Size.Height-y-h), num(w), num(h))
+}
+
+// Space moves the next block down
+func (d *Document) Space(h float64) {
+	d.current()
+	d.y += h
+}
+
+// PageBreak moves the next block to a new page
+func (d *Document) PageBreak() {
+	d.AddPage()
+}
+
+// Heading adds a bold heading; level 1 is the largest
+func (d *Document) Heading(text string, level int) {
+	size := headingSize(level)
+	if d.y > d.Margin {
+		d.Space(size * 0.5)
+	}
+	d.Text(text, Style{Size: size, Bold: true})
+	d.Space(size * 0.3)
+}
+
+func headingSize(level int) float64 {
+	switch level {
+	case 1:
+		return 20
+	case 2:
+		return 16
+	case 3:
+		return 13
+	}
+	return 11
+}
+
+// Paragraph adds regular text followed by a small gap
+func (d *Document) Paragraph(text string) {
+	d.Text(text, Style{})
+	d.Space(6)
+}
+
+// Text adds text in a style, wrapped to the content width
+func (d *Document) Text(text string, style Style) {
+	d.Spans([]Span{{Text: text, Bold: style.Bold, Italic: style.Italic}}, style)
+}
+
+// Spans adds text with mixed bold and italic runs, wrapped to the content width
+func (d *Document) Spans(spans []Span, style Style) {
+	size := style.size()
+	lines := wrap(spans, size, d.ContentWidth())
+	for _, line := range lines {
+		d.ensure(size * lineHeight)
+		d.drawLine(d.current(), line, d.Margin, d.y, d.ContentWidth(), size, style)
+		d.y += size * lineHeight
+	}
+}
+
+// Rule adds a horizontal line across the content width
+func (d *Document) Rule() {
+	d.ensure(10)
+	d.y += 5
+	d.DrawLine(d.Margin, d.y, d.Size.Width-d.Margin, d.y, 0.5, LightGray)
+	d.y += 5
+}
+
+// word is a piece of text without spaces, measured in its font
+type word struct {
+	text  string
+	font  string
+	width float64
+	space float64 // Width of the space before it, 0 at the start of a line
+}
+
+// wrap splits spans into lines of words that fit the width. Runs of whitespace become a
+// single space; "\n" starts a new line.
+func wrap(spans []Span, size, width float64) [][]word {
+	var lines [][]word
+	var line []word
+	lineWidth := 0.0
+	pendingSpace := false
+
+	flush := func() {
+		lines = append(lines, line)
+		line, lineWidth = nil, 0
+	}
+	place := func(text, font string) {
+		for _, piece := range splitLong(text, font, size, width) {
+			w := word{text: piece, font: font, width: textWidth(piece, font, size)}
+			if len(line) > 0 && pendingSpace {
+				w.space = textWidth(" ", font, size)
+			}
+			if len(line) > 0 && lineWidth+w.space+w.width > width {
+				flush()
+				w.space = 0
+			}
+			line = append(line, w)
+			lineWidth += w.space + w.width
+			pendingSpace = false
+		}
+	}
+
+	for _, span := range spans {
+		font := spanFont(span.Bold, span.Italic)
+		var current strings.Builder
+		for _, r := range span.Text {
+			switch r {
+			case '\n', ' ', '\t', '\r':
+				if current.Len() > 0 {
+					place(current.String(), font)
+					current.Reset()
+				}
+				if r == '\n' {
+					flush()
+					pendingSpace = false
+				} else {
+					pendingSpace = true
+				}
+			default:
+				current.WriteRune(r)
+			}
+		}
+		if current.Len() > 0 {
+			place(current.String(), font)
+		}
+	}
+	if len(line) > 0 || len(lines) == 0 {
+		flush()
+	}
+	return lines
+}
+
+// splitLong breaks a word that is wider than width into pieces that fit
+func splitLong(text, font string, size, width float64) []string {
+	if textWidth(text, font, size) <= width {
+		return []string{text}
+	}
+	var pieces []string
+	var current []rune
+	for _, r := range text {
+		if len(current) > 0 && textWidth(string(append(current, r)), font, size) > width {
+			pieces = append(pieces, string(current))
+			current = current[:0]
+		}
+		current = append(current, r)
+	}
+	if len(current) > 0 {
+		pieces = append(pieces, string(current))
+	}
+	return pieces
+}
+
+// drawLine draws a wrapped line aligned within the box starting at x
+func (d *Document) drawLine(p *page, line []word, x, y, width, size float64, style Style) {
+	total := 0.0
+	for _, w := range line {
+		total += w.space + w.width
+	}
+	switch style.Align {
+	case AlignCenter:
+		x += (width - total) / 2
+	case AlignRight:
+		x += width - total
+	}
+	for _, w := range line {
+		x += w.space
+		d.drawText(p, x, y, w.text, w.font, size, style.Color)
+		x += w.width
+	}
+}
+
+func num(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+func rgb(c Color) string {
+	return fmt.Sprintf("%s %s %s", num(float64(c.R)/255), num(float64(c.G)/255), num(float64(c.B)/255))
+}
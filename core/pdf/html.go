@@ -0,0 +1,356 @@
+package pdf
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ImageError is returned by Document.HTML when an image couldn't be rendered
+type ImageError struct {
+	Src string
+	Err error
+}
+
+func (e *ImageError) Error() string {
+	return fmt.Sprintf("image %s: %v", truncate(e.Src, 60), e.Err)
+}
+
+func (e *ImageError) Unwrap() error {
+	return e.Err
+}
+
+// ImageLoader returns the data of an <img> src; data: URIs are decoded without it
+type ImageLoader func(src string) ([]byte, error)
+
+// HTML lays out a subset of HTML: headings, paragraphs and divs, line breaks, lists,
+// tables, horizontal rules, images and bold/italic text. Alignment is taken from align
+// attributes and text-align styles; other CSS is ignored, so templates should keep to a
+// simple document structure. Images that fail to load are left out; the first such error
+// is returned after the whole document is laid out.
+func (d *Document) HTML(source string, images ImageLoader) error {
+	root, err := html.Parse(strings.NewReader(source))
+	if err != nil {
+		return fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	w := &htmlWriter{doc: d, images: images}
+	w.walk(root)
+	w.flush()
+	return w.err
+}
+
+// htmlWriter converts HTML nodes to document blocks. Inline text is collected as spans and
+// written as a paragraph when a block element starts or ends.
+type htmlWriter struct {
+	doc    *Document
+	images ImageLoader
+	spans  []Span
+	bold   int
+	italic int
+	align  Align
+	err    error
+}
+
+var headingLevels = map[atom.Atom]int{
+	atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 4, atom.H6: 4,
+}
+
+func (w *htmlWriter) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.spans = append(w.spans, Span{Text: n.Data, Bold: w.bold > 0, Italic: w.italic > 0})
+		return
+	case html.DocumentNode:
+		w.children(n)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Head, atom.Style, atom.Script, atom.Title, atom.Template:
+		// Not content
+	case atom.Br:
+		w.spans = append(w.spans, Span{Text: "\n"})
+	case atom.Hr:
+		w.flush()
+		w.doc.Rule()
+	case atom.B, atom.Strong:
+		w.bold++
+		w.children(n)
+		w.bold--
+	case atom.I, atom.Em:
+		w.italic++
+		w.children(n)
+		w.italic--
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		w.block(n, func() {
+			size := headingSize(headingLevels[n.DataAtom])
+			w.bold++
+			w.children(n)
+			w.bold--
+			if w.doc.y > w.doc.Margin {
+				w.doc.Space(size * 0.5)
+			}
+			w.flushStyle(Style{Size: size, Bold: true, Align: w.align})
+			w.doc.Space(size * 0.3)
+		})
+	case atom.P:
+		w.block(n, func() {
+			w.children(n)
+			w.flush()
+			w.doc.Space(6)
+		})
+	case atom.Li:
+		w.block(n, func() {
+			marker := "• "
+			if n.Parent != nil && n.Parent.DataAtom == atom.Ol {
+				marker = strconv.Itoa(listIndex(n)) + ". "
+			}
+			w.spans = append(w.spans, Span{Text: marker})
+			w.children(n)
+			w.flush()
+		})
+	case atom.Table:
+		w.flush()
+		w.table(n)
+	case atom.Img:
+		w.flush()
+		w.image(n)
+	case atom.Div, atom.Section, atom.Article, atom.Header, atom.Footer, atom.Main,
+		atom.Body, atom.Html, atom.Address, atom.Blockquote, atom.Ul, atom.Ol, atom.Nav, atom.Aside:
+		w.block(n, func() {
+			w.children(n)
+			w.flush()
+		})
+	default:
+		w.children(n)
+	}
+}
+
+func (w *htmlWriter) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.walk(c)
+	}
+}
+
+// block flushes the pending text and runs fn with the alignment of the element
+func (w *htmlWriter) block(n *html.Node, fn func()) {
+	w.flush()
+	saved := w.align
+	if align, ok := alignOf(n); ok {
+		w.align = align
+	}
+	fn()
+	w.align = saved
+}
+
+// flush writes the pending text as a paragraph
+func (w *htmlWriter) flush() {
+	w.flushStyle(Style{Align: w.align})
+}
+
+func (w *htmlWriter) flushStyle(style Style) {
+	spans := w.spans
+	w.spans = nil
+
+	text := ""
+	for _, span := range spans {
+		text += span.Text
+	}
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	w.doc.Spans(spans, style)
+}
+
+// table writes a <table> with its header row (from <thead> or a first row of <th>)
+func (w *htmlWriter) table(n *html.Node) {
+	var t Table
+	header := false
+	first := true
+
+	for _, row := range tableRows(n) {
+		var cells []string
+		allHeader := true
+		var aligns []Align
+		var bold []bool
+		for c := row.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || (c.DataAtom != atom.Td && c.DataAtom != atom.Th) {
+				continue
+			}
+			cells = append(cells, cellText(c))
+			align, _ := alignOf(c)
+			aligns = append(aligns, align)
+			bold = append(bold, c.DataAtom == atom.Th)
+			if c.DataAtom != atom.Th {
+				allHeader = false
+			}
+			for span := 1; span < attrInt(c, "colspan"); span++ {
+				cells = append(cells, "")
+				aligns = append(aligns, align)
+				bold = append(bold, false)
+			}
+		}
+		if len(cells) == 0 {
+			continue
+		}
+
+		inHead := row.Parent != nil && row.Parent.DataAtom == atom.Thead
+		if first && (inHead || allHeader) {
+			t.Columns = cells
+			header = true
+			first = false
+			continue
+		}
+		if t.Rows == nil {
+			// The first body row sets the alignment and bold columns (<th> row labels)
+			t.Align = aligns
+			t.Bold = bold
+		}
+		t.Rows = append(t.Rows, cells)
+		first = false
+	}
+
+	if header || len(t.Rows) > 0 {
+		w.doc.Table(t)
+	}
+}
+
+// tableRows returns the rows of a table, without those of nested tables
+func tableRows(table *html.Node) []*html.Node {
+	var rows []*html.Node
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || c.DataAtom == atom.Table {
+				continue
+			}
+			if c.DataAtom == atom.Tr {
+				rows = append(rows, c)
+				continue
+			}
+			visit(c)
+		}
+	}
+	visit(table)
+	return rows
+}
+
+// cellText returns the text of a cell with collapsed whitespace; <br> starts a new line
+func cellText(n *html.Node) string {
+	var b strings.Builder
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(n.Data)
+		case n.Type == html.ElementNode && n.DataAtom == atom.Br:
+			b.WriteString("\n")
+		case n.Type == html.ElementNode && (n.DataAtom == atom.Style || n.DataAtom == atom.Script):
+		default:
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				visit(c)
+			}
+		}
+	}
+	visit(n)
+
+	lines := strings.Split(b.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// image writes an <img>; its width attribute is in CSS pixels
+func (w *htmlWriter) image(n *html.Node) {
+	src := attr(n, "src")
+	if src == "" {
+		return
+	}
+
+	var data []byte
+	var err error
+	if strings.HasPrefix(src, "data:") {
+		data, err = decodeDataURI(src)
+	} else if w.images != nil {
+		data, err = w.images(src)
+	} else {
+		return
+	}
+	if err == nil {
+		align := w.align
+		if a, ok := alignOf(n); ok {
+			align = a
+		}
+		err = w.doc.Image(data, float64(attrInt(n, "width"))*0.75, align)
+	}
+	if err != nil && w.err == nil {
+		w.err = &ImageError{Src: src, Err: err}
+	}
+}
+
+func decodeDataURI(uri string) ([]byte, error) {
+	meta, payload, ok := strings.Cut(strings.TrimPrefix(uri, "data:"), ",")
+	if !ok || !strings.HasSuffix(meta, ";base64") {
+		return nil, fmt.Errorf("only base64 data URIs are supported")
+	}
+	return base64.StdEncoding.DecodeString(payload)
+}
+
+// listIndex returns the position of a list item in its list, from 1
+func listIndex(li *html.Node) int {
+	index := 1
+	for c := li.PrevSibling; c != nil; c = c.PrevSibling {
+		if c.Type == html.ElementNode && c.DataAtom == atom.Li {
+			index++
+		}
+	}
+	return index
+}
+
+// alignOf returns the alignment of an element from its align attribute or text-align style
+func alignOf(n *html.Node) (Align, bool) {
+	value := strings.ToLower(attr(n, "align"))
+	style := strings.ToLower(strings.ReplaceAll(attr(n, "style"), " ", ""))
+	if i := strings.Index(style, "text-align:"); i >= 0 {
+		value = strings.TrimSuffix(strings.SplitN(style[i+len("text-align:"):], ";", 2)[0], "!important")
+	}
+	switch value {
+	case "left", "start":
+		return AlignLeft, true
+	case "center":
+		return AlignCenter, true
+	case "right", "end":
+		return AlignRight, true
+	}
+	return AlignLeft, false
+}
+
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func attrInt(n *html.Node, name string) int {
+	v, _ := strconv.Atoi(strings.TrimSuffix(attr(n, name), "px"))
+	return v
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
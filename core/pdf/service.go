@@ -0,0 +1,226 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"base/core/config"
+	"base/core/logger"
+)
+
+// Service renders PDFs for modules: HTML templates (invoices, letters), tables (reports)
+// and record sheets. It is available to modules as deps.PDF.
+//
+//	data, err := m.PDF.RenderTemplate("invoice.html", invoiceData)
+type Service struct {
+	TemplatesPath string // Directory of template files and the images they reference
+	PageSize      PageSize
+	Author        string
+	Logger        logger.Logger
+
+	mu        sync.RWMutex
+	templates map[string]*template.Template // Registered with RegisterTemplate
+	files     map[string]cachedTemplate     // Parsed from TemplatesPath
+}
+
+// cachedTemplate is a parsed template file, reparsed when the file changes
+type cachedTemplate struct {
+	template *template.Template
+	modTime  time.Time
+}
+
+// Funcs are the functions available in templates, next to the html/template builtins.
+// Amounts are often stored in cents: {{printf "%.2f" (div .Amount 100)}}.
+var Funcs = template.FuncMap{
+	"add": func(a, b any) float64 { return toFloat(a) + toFloat(b) },
+	"sub": func(a, b any) float64 { return toFloat(a) - toFloat(b) },
+	"mul": func(a, b any) float64 { return toFloat(a) * toFloat(b) },
+	"div": func(a, b any) float64 {
+		if toFloat(b) == 0 {
+			return 0
+		}
+		return toFloat(a) / toFloat(b)
+	},
+	"date":  func(layout string, t time.Time) string { return t.Format(layout) },
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// NewService creates the PDF service from the PDF_* settings
+func NewService(cfg *config.Config, log logger.Logger) *Service {
+	s := &Service{
+		TemplatesPath: config.DefaultPDFTemplatesPath,
+		PageSize:      A4,
+		Logger:        log,
+		templates:     make(map[string]*template.Template),
+		files:         make(map[string]cachedTemplate),
+	}
+	if cfg != nil {
+		s.TemplatesPath = cfg.PDFTemplatesPath
+		if size, ok := PageSizes[cfg.PDFPageSize]; ok {
+			s.PageSize = size
+		}
+	}
+	return s
+}
+
+// New returns an empty document with the page size of the service and page numbers
+func (s *Service) New(title string) *Document {
+	doc := New(s.PageSize)
+	doc.Title = title
+	doc.Author = s.Author
+	doc.PageNumbers = true
+	return doc
+}
+
+// RegisterTemplate adds an HTML template by name; it takes precedence over a file of the
+// same name in TemplatesPath
+func (s *Service) RegisterTemplate(name, text string) error {
+	tmpl, err := template.New(name).Funcs(Funcs).Parse(text)
+	if err != nil {
+		return fmt.Errorf("failed to parse PDF template %s: %w", name, err)
+	}
+	s.mu.Lock()
+	s.templates[name] = tmpl
+	s.mu.Unlock()
+	return nil
+}
+
+// RenderTemplate executes an HTML template with data and renders the result, see
+// Document.HTML for the supported HTML. Images are loaded from TemplatesPath.
+func (s *Service) RenderTemplate(name string, data any) ([]byte, error) {
+	tmpl, err := s.template(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to execute PDF template %s: %w", name, err)
+	}
+	return s.RenderHTML(name, buf.String())
+}
+
+// RenderHTML renders an HTML document. Images that can't be loaded are left out and
+// logged.
+func (s *Service) RenderHTML(title, source string) ([]byte, error) {
+	doc := s.New(title)
+	if err := doc.HTML(source, s.loadImage); err != nil {
+		var imageErr *ImageError
+		if !errors.As(err, &imageErr) {
+			return nil, err
+		}
+		s.Logger.Warn("PDF image not rendered",
+			logger.String("document", title),
+			logger.String("error", err.Error()))
+	}
+	return doc.Bytes()
+}
+
+// Table renders a titled table, e.g. the rows of a report
+func (s *Service) Table(title, subtitle string, columns []string, rows [][]string) ([]byte, error) {
+	size := s.PageSize
+	if len(columns) > 6 {
+		size = PageSize{Width: size.Height, Height: size.Width} // Landscape
+	}
+
+	doc := s.New(title)
+	doc.Size = size
+	doc.Heading(title, 1)
+	if subtitle != "" {
+		doc.Text(subtitle, Style{Size: 9, Color: Gray})
+		doc.Space(8)
+	}
+	doc.Table(Table{Columns: columns, Rows: rows, Size: 8})
+	return doc.Bytes()
+}
+
+// RecordSheet renders the fields of a single record as labelled values
+func (s *Service) RecordSheet(title string, fields []KeyValue) ([]byte, error) {
+	doc := s.New(title)
+	doc.Heading(title, 1)
+	doc.Space(4)
+	doc.KeyValues(fields)
+	doc.Text("Generated "+time.Now().UTC().Format("2006-01-02 15:04 MST"), Style{Size: 8, Color: Gray})
+	return doc.Bytes()
+}
+
+// template returns a registered template or parses the file from TemplatesPath
+func (s *Service) template(name string) (*template.Template, error) {
+	s.mu.RLock()
+	tmpl, ok := s.templates[name]
+	cached, cachedOk := s.files[name]
+	s.mu.RUnlock()
+	if ok {
+		return tmpl, nil
+	}
+
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("PDF template %s not found", name)
+	}
+	if cachedOk && cached.modTime.Equal(info.ModTime()) {
+		return cached.template, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF template %s: %w", name, err)
+	}
+	tmpl, err = template.New(name).Funcs(Funcs).Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse PDF template %s: %w", name, err)
+	}
+
+	s.mu.Lock()
+	s.files[name] = cachedTemplate{template: tmpl, modTime: info.ModTime()}
+	s.mu.Unlock()
+	return tmpl, nil
+}
+
+// loadImage reads an image referenced by a template from TemplatesPath
+func (s *Service) loadImage(src string) ([]byte, error) {
+	if strings.Contains(src, "://") {
+		return nil, fmt.Errorf("remote images are not supported")
+	}
+	path, err := s.path(strings.TrimPrefix(src, "/"))
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+// path resolves a name within TemplatesPath
+func (s *Service) path(name string) (string, error) {
+	clean := filepath.Clean("/" + name)
+	if clean == "/" {
+		return "", fmt.Errorf("invalid template path %q", name)
+	}
+	return filepath.Join(s.TemplatesPath, clean), nil
+}
+
+// toFloat converts a number of any type for the template math functions
+func toFloat(v any) float64 {
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		return value.Float()
+	}
+	return 0
+}
@@ -0,0 +1,149 @@
+package pdf
+
+// Table is a grid of text cells. The header row is repeated on every page the table
+// spans; cells wrap within their column.
+type Table struct {
+	Columns []string // Header row; empty for a table without header
+	Rows    [][]string
+	Widths  []float64 // Relative column widths; by default they follow the content
+	Align   []Align   // Per column alignment
+	Size    float64   // Font size, default 9
+	Bold    []bool    // Per column bold text, e.g. the keys of a key/value table
+}
+
+// KeyValue is a labelled value of a record sheet
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+const cellPadding = 4
+
+// KeyValues adds a two-column table of labels and values, e.g. the fields of a record
+func (d *Document) KeyValues(pairs []KeyValue) {
+	rows := make([][]string, 0, len(pairs))
+	for _, pair := range pairs {
+		rows = append(rows, []string{pair.Key, pair.Value})
+	}
+	d.Table(Table{Rows: rows, Widths: []float64{1, 2}, Bold: []bool{true, false}, Size: 10})
+}
+
+// Table adds a table across the content width
+func (d *Document) Table(t Table) {
+	columns := len(t.Columns)
+	for _, row := range t.Rows {
+		columns = max(columns, len(row))
+	}
+	if columns == 0 {
+		return
+	}
+	size := t.Size
+	if size <= 0 {
+		size = 9
+	}
+
+	widths := d.columnWidths(t, columns, size)
+	x := d.Margin
+	total := d.ContentWidth()
+
+	// measure wraps the cells of a row and returns their lines and the row height
+	measure := func(cells []string, header bool) ([][][]word, float64) {
+		lines := make([][][]word, columns)
+		height := 0.0
+		for i := 0; i < columns; i++ {
+			text := ""
+			if i < len(cells) {
+				text = cells[i]
+			}
+			bold := header || (i < len(t.Bold) && t.Bold[i])
+			lines[i] = wrap([]Span{{Text: text, Bold: bold}}, size, widths[i]-2*cellPadding)
+			height = max(height, float64(len(lines[i]))*size*lineHeight+2*cellPadding)
+		}
+		return lines, height
+	}
+
+	draw := func(lines [][][]word, height float64, header bool) {
+		if header {
+			d.DrawRect(x, d.y, total, height, Shade)
+		}
+		p := d.current()
+		cellX := x
+		for i := 0; i < columns; i++ {
+			style := Style{Size: size}
+			if i < len(t.Align) {
+				style.Align = t.Align[i]
+			}
+			lineY := d.y + cellPadding
+			for _, line := range lines[i] {
+				d.drawLine(p, line, cellX+cellPadding, lineY, widths[i]-2*cellPadding, size, style)
+				lineY += size * lineHeight
+			}
+			cellX += widths[i]
+		}
+		d.DrawLine(x, d.y+height, x+total, d.y+height, 0.5, LightGray)
+		d.y += height
+	}
+
+	var headerLines [][][]word
+	headerHeight := 0.0
+	if len(t.Columns) > 0 {
+		headerLines, headerHeight = measure(t.Columns, true)
+		// Keep the header with the first row
+		d.ensure(headerHeight + size*lineHeight + 2*cellPadding)
+		draw(headerLines, headerHeight, true)
+	}
+
+	for _, row := range t.Rows {
+		lines, height := measure(row, false)
+		if d.y+height > d.bottom() && d.y > d.Margin {
+			d.AddPage()
+			if headerLines != nil {
+				draw(headerLines, headerHeight, true)
+			}
+		}
+		draw(lines, height, false)
+	}
+	d.Space(6)
+}
+
+// columnWidths returns the column widths in points: the given relative widths, or widths
+// proportional to the content of the first rows
+func (d *Document) columnWidths(t Table, columns int, size float64) []float64 {
+	total := d.ContentWidth()
+	weights := make([]float64, columns)
+
+	if len(t.Widths) == columns {
+		copy(weights, t.Widths)
+	} else {
+		sample := t.Rows
+		if len(sample) > 50 {
+			sample = sample[:50]
+		}
+		for i := 0; i < columns; i++ {
+			if i < len(t.Columns) {
+				weights[i] = textWidth(t.Columns[i], fontBold, size)
+			}
+			for _, row := range sample {
+				if i < len(row) {
+					weights[i] = max(weights[i], textWidth(row[i], fontRegular, size))
+				}
+			}
+			// Keep narrow columns readable and stop one long text from taking over
+			weights[i] = min(max(weights[i]+2*cellPadding, total/float64(columns)/2), total/2)
+		}
+	}
+
+	sum := 0.0
+	for _, w := range weights {
+		sum += w
+	}
+	widths := make([]float64, columns)
+	for i, w := range weights {
+		if sum <= 0 {
+			widths[i] = total / float64(columns)
+		} else {
+			widths[i] = w / sum * total
+		}
+	}
+	return widths
+}
@@ -0,0 +1,245 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	goimage "image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"time"
+)
+
+// image is an image XObject
+type image struct {
+	name       string
+	width      int
+	height     int
+	colorSpace string
+	filter     string
+	data       []byte
+	mask       *image // Alpha channel
+}
+
+// Image adds an image scaled to width points (its natural size when 0, at most the
+// content width). JPEG and PNG images are supported.
+func (d *Document) Image(data []byte, width float64, align Align) error {
+	img, err := newImage(data, fmt.Sprintf("Im%d", len(d.images)+1))
+	if err != nil {
+		return err
+	}
+	d.images = append(d.images, img)
+
+	if width <= 0 {
+		width = float64(img.width) * 0.75 // 96 dpi pixels to points
+	}
+	width = min(width, d.ContentWidth())
+	height := width * float64(img.height) / float64(img.width)
+
+	x := d.Margin
+	switch align {
+	case AlignCenter:
+		x += (d.ContentWidth() - width) / 2
+	case AlignRight:
+		x += d.ContentWidth() - width
+	}
+
+	d.ensure(height)
+	d.drawImage(img, x, d.y, width, height)
+	d.y += height + 4
+	return nil
+}
+
+// drawImage draws an image into the box with its top left corner at (x, y)
+func (d *Document) drawImage(img *image, x, y, w, h float64) {
+	p := d.current()
+	p.images[img.name] = true
+	fmt.Fprintf(&p.content, "q %s 0 0 %s %s %s cm /%s Do Q\n",
+		num(w), num(h), num(x), num(d.Size.Height-y-h), img.name)
+}
+
+// newImage decodes an image. Gray and YCbCr JPEGs are embedded as they are, everything
+// else is stored as compressed RGB with its alpha channel as a soft mask.
+func newImage(data []byte, name string) (*image, error) {
+	if cfg, err := jpeg.DecodeConfig(bytes.NewReader(data)); err == nil {
+		switch cfg.ColorModel {
+		case color.GrayModel:
+			return &image{name: name, width: cfg.Width, height: cfg.Height, colorSpace: "DeviceGray", filter: "DCTDecode", data: data}, nil
+		case color.YCbCrModel:
+			return &image{name: name, width: cfg.Width, height: cfg.Height, colorSpace: "DeviceRGB", filter: "DCTDecode", data: data}, nil
+		}
+	}
+
+	decoded, _, err := goimage.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("unsupported image: %w", err)
+	}
+
+	bounds := decoded.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w == 0 || h == 0 {
+		return nil, fmt.Errorf("empty image")
+	}
+	rgb := make([]byte, 0, w*h*3)
+	alpha := make([]byte, 0, w*h)
+	opaque := true
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := decoded.At(x, y).RGBA()
+			if a > 0 && a < 0xffff {
+				// Undo the premultiplication
+				r, g, b = r*0xffff/a, g*0xffff/a, b*0xffff/a
+			}
+			rgb = append(rgb, byte(r>>8), byte(g>>8), byte(b>>8))
+			alpha = append(alpha, byte(a>>8))
+			if a != 0xffff {
+				opaque = false
+			}
+		}
+	}
+
+	img := &image{name: name, width: w, height: h, colorSpace: "DeviceRGB", filter: "FlateDecode", data: deflate(rgb)}
+	if !opaque {
+		img.mask = &image{width: w, height: h, colorSpace: "DeviceGray", filter: "FlateDecode", data: deflate(alpha)}
+	}
+	return img, nil
+}
+
+func deflate(data []byte) []byte {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write(data)
+	w.Close()
+	return buf.Bytes()
+}
+
+// Bytes returns the PDF file
+func (d *Document) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := d.Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Write writes the PDF file
+func (d *Document) Write(w io.Writer) error {
+	d.current() // A PDF needs at least one page
+
+	// Object numbers: catalog, page tree, fonts, info, images, then a page and its content
+	const (
+		catalogId = 1
+		pagesId   = 2
+		fontsId   = 3 // Three fonts
+		infoId    = 6
+	)
+	next := infoId + 1
+	imageIds := make(map[string]int)
+	maskIds := make(map[string]int)
+	for _, img := range d.images {
+		imageIds[img.name] = next
+		next++
+		if img.mask != nil {
+			maskIds[img.name] = next
+			next++
+		}
+	}
+	pageIds := make([]int, len(d.pages))
+	for i := range d.pages {
+		pageIds[i] = next
+		next += 2
+	}
+
+	out := &countingWriter{w: w}
+	offsets := make([]int64, next)
+	object := func(id int, body string) {
+		offsets[id] = out.n
+		fmt.Fprintf(out, "%d 0 obj\n%s\nendobj\n", id, body)
+	}
+	stream := func(id int, dict string, data []byte) {
+		offsets[id] = out.n
+		fmt.Fprintf(out, "%d 0 obj\n<< %s /Length %d >>\nstream\n", id, dict, len(data))
+		out.Write(data)
+		fmt.Fprint(out, "\nendstream\nendobj\n")
+	}
+
+	fmt.Fprint(out, "%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	object(catalogId, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesId))
+
+	var kids bytes.Buffer
+	for _, id := range pageIds {
+		fmt.Fprintf(&kids, "%d 0 R ", id)
+	}
+	object(pagesId, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", kids.String(), len(pageIds)))
+
+	for i, font := range []string{fontRegular, fontBold, fontItalic} {
+		object(fontsId+i, fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", fontNames[font]))
+	}
+
+	object(infoId, fmt.Sprintf("<< /Title (%s) /Author (%s) /Producer (Base) /CreationDate (D:%s) >>",
+		escape(d.Title), escape(d.Author), time.Now().UTC().Format("20060102150405Z")))
+
+	for _, img := range d.images {
+		dict := fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /%s /BitsPerComponent 8 /Filter /%s",
+			img.width, img.height, img.colorSpace, img.filter)
+		if img.mask != nil {
+			dict += fmt.Sprintf(" /SMask %d 0 R", maskIds[img.name])
+		}
+		stream(imageIds[img.name], dict, img.data)
+		if img.mask != nil {
+			stream(maskIds[img.name], fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /FlateDecode",
+				img.mask.width, img.mask.height), img.mask.data)
+		}
+	}
+
+	fonts := fmt.Sprintf("/F1 %d 0 R /F2 %d 0 R /F3 %d 0 R", fontsId, fontsId+1, fontsId+2)
+	for i, p := range d.pages {
+		var xobjects bytes.Buffer
+		for name := range p.images {
+			fmt.Fprintf(&xobjects, "/%s %d 0 R ", name, imageIds[name])
+		}
+		resources := fmt.Sprintf("/Font << %s >>", fonts)
+		if xobjects.Len() > 0 {
+			resources += fmt.Sprintf(" /XObject << %s>>", xobjects.String())
+		}
+		object(pageIds[i], fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources << %s >> /Contents %d 0 R >>",
+			pagesId, num(d.Size.Width), num(d.Size.Height), resources, pageIds[i]+1))
+		content := p.content.Bytes()
+		if d.PageNumbers {
+			// Drawn on a copy, so the document can be written again
+			footer := &page{}
+			text := fmt.Sprintf("Page %d of %d", i+1, len(d.pages))
+			d.drawText(footer, (d.Size.Width-textWidth(text, fontRegular, 8))/2, d.Size.Height-d.Margin, text, fontRegular, 8, Gray)
+			content = append(append([]byte{}, content...), footer.content.Bytes()...)
+		}
+		stream(pageIds[i]+1, "/Filter /FlateDecode", deflate(content))
+	}
+
+	xref := out.n
+	fmt.Fprintf(out, "xref\n0 %d\n0000000000 65535 f \n", next)
+	for id := 1; id < next; id++ {
+		fmt.Fprintf(out, "%010d 00000 n \n", offsets[id])
+	}
+	fmt.Fprintf(out, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", next, catalogId, infoId, xref)
+	return out.err
+}
+
+// countingWriter tracks the offset of the objects for the cross-reference table
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
@@ -0,0 +1,245 @@
+package router
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"base/core/emitter"
+	"base/core/explain"
+)
+
+// cacheVaryHeaders are the request headers public responses depend on: the format, the
+// locale, the timezone and the envelope
+var cacheVaryHeaders = []string{"Accept", "Accept-Language", "X-Timezone", EnvelopeHeader}
+
+// cachedHeaders are the response headers kept with a cached response
+var cachedHeaders = []string{"Content-Type", "Content-Language", "Vary", EnvelopeHeader}
+
+// cachedResponse is a response kept by a ResponseCache
+type cachedResponse struct {
+	status  int
+	header  http.Header
+	body    []byte
+	etag    string
+	tags    []string
+	expires time.Time
+}
+
+// ResponseCache keeps successful GET responses of public routes in memory and answers
+// conditional requests with 304 Not Modified. Responses are tagged by the routes caching
+// them and dropped when one of their tags is invalidated, usually by the update events of
+// the records they show; the TTL bounds how stale they get otherwise (e.g. pages whose
+// publication date passes).
+type ResponseCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]*cachedResponse
+}
+
+// Responses is the response cache of the public routes; the server sets its TTL from
+// RESPONSE_CACHE_TTL
+var Responses = NewResponseCache(time.Minute, 1000)
+
+// NewResponseCache creates a response cache keeping responses for ttl, at most maxEntries of
+// them. A zero ttl disables the cache, but ETags are still sent.
+func NewResponseCache(ttl time.Duration, maxEntries int) *ResponseCache {
+	return &ResponseCache{ttl: ttl, maxEntries: maxEntries, entries: make(map[string]*cachedResponse)}
+}
+
+// Configure changes the TTL and drops the cached responses
+func (rc *ResponseCache) Configure(ttl time.Duration) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.ttl = ttl
+	rc.entries = make(map[string]*cachedResponse)
+}
+
+// Cache returns route middleware caching the responses under the given tags. Requests with
+// an Authorization header bypass the cache, as their responses may depend on the user, and
+// so do debug requests, whose responses report how they were built.
+func (rc *ResponseCache) Cache(tags ...string) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			if c.Request.Method != http.MethodGet {
+				return next(c)
+			}
+			report := explain.FromContext(c.Request.Context())
+			if c.Request.Header.Get("Authorization") != "" || report != nil {
+				report.Cache("responses", c.Request.URL.RequestURI(), "bypass")
+				return next(c)
+			}
+
+			key := cacheKey(c.Request)
+			if entry := rc.get(key); entry != nil {
+				c.SetHeader("X-Cache", "HIT")
+				return entry.write(c)
+			}
+
+			recorder := &cacheRecorder{ResponseWriter: c.Writer, status: http.StatusOK}
+			c.Writer = recorder
+			err := next(c)
+			c.Writer = recorder.ResponseWriter
+			if err != nil {
+				return err
+			}
+
+			entry := &cachedResponse{
+				status: recorder.status,
+				header: make(http.Header),
+				body:   recorder.body.Bytes(),
+				tags:   tags,
+			}
+			for _, name := range cachedHeaders {
+				if values := recorder.Header().Values(name); len(values) > 0 {
+					entry.header[name] = values
+				}
+			}
+			if entry.status == http.StatusOK {
+				sum := sha256.Sum256(entry.body)
+				entry.etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+				rc.put(key, entry)
+			}
+			c.SetHeader("X-Cache", "MISS")
+			return entry.write(c)
+		}
+	}
+}
+
+// Invalidate drops the cached responses having any of the tags
+func (rc *ResponseCache) Invalidate(tags ...string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	for key, entry := range rc.entries {
+		if hasAnyTag(entry.tags, tags) {
+			delete(rc.entries, key)
+		}
+	}
+}
+
+// InvalidateOn invalidates the tag whenever one of the events is emitted
+func (rc *ResponseCache) InvalidateOn(e *emitter.Emitter, tag string, events ...string) {
+	if e == nil {
+		return
+	}
+	for _, event := range events {
+		e.On(event, func(any) {
+			rc.Invalidate(tag)
+		})
+	}
+}
+
+// get returns the fresh cached response of key, if any
+func (rc *ResponseCache) get(key string) *cachedResponse {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	entry, ok := rc.entries[key]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(entry.expires) {
+		delete(rc.entries, key)
+		return nil
+	}
+	return entry
+}
+
+// put caches a response, making room by dropping expired responses and then any other one
+func (rc *ResponseCache) put(key string, entry *cachedResponse) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.ttl <= 0 {
+		return
+	}
+	now := time.Now()
+	entry.expires = now.Add(rc.ttl)
+
+	if len(rc.entries) >= rc.maxEntries {
+		for k, e := range rc.entries {
+			if now.After(e.expires) {
+				delete(rc.entries, k)
+			}
+		}
+	}
+	for k := range rc.entries {
+		if len(rc.entries) < rc.maxEntries {
+			break
+		}
+		delete(rc.entries, k)
+	}
+	rc.entries[key] = entry
+}
+
+// write sends the response, or 304 Not Modified when the client has it already
+func (entry *cachedResponse) write(c *Context) error {
+	for name, values := range entry.header {
+		c.Writer.Header()[name] = values
+	}
+	if entry.etag != "" {
+		c.SetHeader("ETag", entry.etag)
+		c.SetHeader("Cache-Control", "no-cache")
+		if etagMatches(c.Request.Header.Get("If-None-Match"), entry.etag) {
+			c.Writer.WriteHeader(http.StatusNotModified)
+			return nil
+		}
+	}
+	c.Writer.WriteHeader(entry.status)
+	_, err := c.Writer.Write(entry.body)
+	return err
+}
+
+// cacheKey identifies a response by its URL and the request headers it depends on
+func cacheKey(r *http.Request) string {
+	var b strings.Builder
+	b.WriteString(r.URL.RequestURI())
+	for _, name := range cacheVaryHeaders {
+		b.WriteByte('\n')
+		b.WriteString(r.Header.Get(name))
+	}
+	return b.String()
+}
+
+// etagMatches reports whether an If-None-Match header lists the ETag
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAnyTag reports whether the tags include one of wanted
+func hasAnyTag(tags, wanted []string) bool {
+	for _, tag := range tags {
+		for _, w := range wanted {
+			if tag == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cacheRecorder holds back a response so it can be cached before it is sent
+type cacheRecorder struct {
+	ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+// WriteHeader records the status code
+func (w *cacheRecorder) WriteHeader(code int) {
+	w.status = code
+}
+
+// Write records the body
+func (w *cacheRecorder) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
@@ -11,7 +11,10 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"base/core/explain"
 )
 
 // Context represents the context of an HTTP request
@@ -23,6 +26,8 @@ type Context struct {
 	mu       sync.RWMutex
 	index    int8
 	handlers []HandlerFunc
+	envelope EnvelopeConfig
+	proxies  *proxies
 }
 
 // Param represents a URL parameter
@@ -47,7 +52,7 @@ func (ps Params) Get(name string) string {
 // reset resets the context for reuse
 func (c *Context) reset(w http.ResponseWriter, r *http.Request) {
 	c.Request = r
-	c.Writer = &responseWriter{ResponseWriter: w, status: http.StatusOK}
+	c.Writer = &responseWriter{ResponseWriter: w, ctx: r.Context(), status: http.StatusOK}
 	c.params = c.params[:0]
 	c.keys = make(map[string]any)
 	c.index = -1
@@ -124,10 +129,30 @@ func (c *Context) FormValue(key string) string {
 	return c.Request.FormValue(key)
 }
 
+// defaultMultipartMemory is the part of multipart requests kept in memory until
+// SetMultipartMemory is called
+const defaultMultipartMemory = 32 << 20 // 32MB
+
+var multipartMemory atomic.Int64
+
+// SetMultipartMemory sets how many bytes of multipart requests are kept in memory while
+// they are parsed; the rest of the files is written to temporary files
+func SetMultipartMemory(bytes int64) {
+	multipartMemory.Store(bytes)
+}
+
+// MultipartMemory returns how many bytes of multipart requests are kept in memory
+func MultipartMemory() int64 {
+	if bytes := multipartMemory.Load(); bytes > 0 {
+		return bytes
+	}
+	return defaultMultipartMemory
+}
+
 // FormFile returns the multipart form file for the given key
 func (c *Context) FormFile(key string) (*multipart.FileHeader, error) {
 	if c.Request.MultipartForm == nil {
-		if err := c.Request.ParseMultipartForm(32 << 20); err != nil {
+		if err := c.Request.ParseMultipartForm(MultipartMemory()); err != nil {
 			return nil, err
 		}
 	}
@@ -141,7 +166,7 @@ func (c *Context) FormFile(key string) (*multipart.FileHeader, error) {
 
 // MultipartForm returns the parsed multipart form, including file uploads
 func (c *Context) MultipartForm() (*multipart.Form, error) {
-	err := c.Request.ParseMultipartForm(32 << 20)
+	err := c.Request.ParseMultipartForm(MultipartMemory())
 	return c.Request.MultipartForm, err
 }
 
@@ -234,8 +259,30 @@ func (c *Context) BindForm(obj any) error {
 	return bindData(obj, c.Request.Form)
 }
 
-// JSON sends a JSON response
+// JSON sends a JSON response. Debug requests (see explain) get their report in meta.debug.
 func (c *Context) JSON(code int, obj any) error {
+	report := explain.FromContext(c.Request.Context())
+	if report != nil || c.enveloped() {
+		envelope, err := wrap(code, obj)
+		if err != nil {
+			return err
+		}
+		if report != nil {
+			envelope.addMeta("debug", report.Summary())
+		}
+		c.SetHeader(EnvelopeHeader, "true")
+		obj = envelope
+	}
+
+	// Clients can ask for XML or MessagePack instead with the Accept header
+	c.Writer.Header().Add("Vary", "Accept")
+	switch c.NegotiateFormat() {
+	case MIMEXML:
+		return c.XML(code, obj)
+	case MIMEMsgPack:
+		return c.MsgPack(code, obj)
+	}
+
 	c.SetHeader("Content-Type", "application/json")
 	c.Writer.WriteHeader(code)
 	encoder := json.NewEncoder(c.Writer)
@@ -301,27 +348,21 @@ func (c *Context) NoContent() error {
 	return nil
 }
 
-// ClientIP returns the client's IP address
+// ClientIP returns the client's IP address. The proxy headers (X-Forwarded-For by default)
+// are only believed when the request comes from a trusted proxy (see Router.SetProxies), so
+// clients can't spoof their address.
 func (c *Context) ClientIP() string {
-	// Check X-Forwarded-For header
-	if xff := c.Header("X-Forwarded-For"); xff != "" {
-		if i := strings.Index(xff, ","); i != -1 {
-			return strings.TrimSpace(xff[:i])
-		}
-		return xff
-	}
-
-	// Check X-Real-IP header
-	if xri := c.Header("X-Real-IP"); xri != "" {
-		return xri
+	remote := c.Request.RemoteAddr
+	if ip, _, err := net.SplitHostPort(remote); err == nil {
+		remote = ip
 	}
 
-	// Fall back to RemoteAddr
-	if ip, _, err := net.SplitHostPort(c.Request.RemoteAddr); err == nil {
-		return ip
+	if c.proxies.trusted(net.ParseIP(remote)) {
+		if ip := c.proxies.clientIP(c.Header); ip != "" {
+			return ip
+		}
 	}
-
-	return c.Request.RemoteAddr
+	return remote
 }
 
 // ContentType returns the Content-Type header of the request
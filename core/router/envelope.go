@@ -0,0 +1,261 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// EnvelopeMode selects which JSON responses are wrapped in an Envelope
+type EnvelopeMode string
+
+const (
+	// EnvelopeOff sends responses in the shape their handlers write
+	EnvelopeOff EnvelopeMode = "off"
+	// EnvelopeCompat wraps the responses of requests asking for it with EnvelopeHeader
+	EnvelopeCompat EnvelopeMode = "compat"
+	// EnvelopeOn wraps every response, unless a request opts out with EnvelopeHeader
+	EnvelopeOn EnvelopeMode = "on"
+)
+
+// EnvelopeHeader chooses the shape of the responses of a request: "true" asks for the
+// envelope in compat mode and "false" for the handler's own shape in on mode. Wrapped
+// responses carry it with "true".
+const EnvelopeHeader = "X-Response-Envelope"
+
+// EnvelopeConfig configures the response envelope
+type EnvelopeConfig struct {
+	Mode EnvelopeMode
+
+	// SkipPaths lists paths whose responses keep their shape, e.g. the OpenAPI document
+	// ("/health/*" matches everything below /health)
+	SkipPaths []string
+}
+
+// Envelope is the shape of every JSON response when the envelope is enabled: the payload
+// in data, pagination and messages in meta, and errors in errors
+type Envelope struct {
+	Data   any             `json:"data"`
+	Meta   map[string]any  `json:"meta,omitempty"`
+	Errors []EnvelopeError `json:"errors,omitempty"`
+}
+
+// EnvelopeError is an error of an Envelope
+type EnvelopeError struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+	Field   string `json:"field,omitempty"`
+	Details any    `json:"details,omitempty"`
+}
+
+// SetEnvelope configures the response envelope applied by Context.JSON
+func (r *Router) SetEnvelope(config EnvelopeConfig) {
+	r.mu.Lock()
+	r.envelope = config
+	r.mu.Unlock()
+}
+
+// enveloped reports whether the JSON responses of the request are wrapped in an Envelope
+func (c *Context) enveloped() bool {
+	if c.envelope.Mode != EnvelopeCompat && c.envelope.Mode != EnvelopeOn {
+		return false
+	}
+	path := c.Request.URL.Path
+	for _, pattern := range c.envelope.SkipPaths {
+		if pattern == path || (strings.HasSuffix(pattern, "/*") && strings.HasPrefix(path, strings.TrimSuffix(pattern, "*"))) {
+			return false
+		}
+	}
+
+	c.Writer.Header().Add("Vary", EnvelopeHeader)
+	switch strings.ToLower(c.Request.Header.Get(EnvelopeHeader)) {
+	case "true", "1":
+		return true
+	case "false", "0":
+		return false
+	}
+	return c.envelope.Mode == EnvelopeOn
+}
+
+// wrap puts a response in an Envelope. The shapes handlers write are recognized: the data
+// of {"data": ...} and types.PaginatedResponse becomes the payload and their other fields
+// the meta; for error statuses {"error": ...}, {"message": ...} and {"errors": [...]} become
+// the errors.
+func wrap(code int, obj any) (*Envelope, error) {
+	switch obj := obj.(type) {
+	case Envelope:
+		return &obj, nil
+	case *Envelope:
+		return obj, nil
+	}
+
+	value, err := toOrdered(obj)
+	if err != nil {
+		return nil, err
+	}
+	object, _ := value.(*orderedObject)
+	if code >= 400 {
+		return wrapError(code, value, object), nil
+	}
+	if object == nil {
+		return &Envelope{Data: value}, nil
+	}
+
+	envelope := &Envelope{}
+	if data, ok := object.get("data"); ok {
+		envelope.Data = data
+	} else if _, ok := object.get("success"); !ok || !object.only("success", "message") {
+		// A plain object, e.g. a product
+		return &Envelope{Data: value}, nil
+	}
+	for i, key := range object.keys {
+		if key != "data" && key != "success" {
+			envelope.addMeta(key, object.values[i])
+		}
+	}
+	return envelope, nil
+}
+
+// wrapError puts an error response in an Envelope
+func wrapError(code int, value any, object *orderedObject) *Envelope {
+	envelope := &Envelope{}
+	if object == nil {
+		message := http.StatusText(code)
+		if s, ok := value.(string); ok && s != "" {
+			message = s
+		}
+		envelope.Errors = []EnvelopeError{{Status: code, Message: message}}
+		return envelope
+	}
+
+	// The message is the error, else the message; a message next to an error goes in the meta
+	main := EnvelopeError{Status: code}
+	errorValue, _ := object.get("error")
+	main.Message, _ = errorValue.(string)
+	for i, key := range object.keys {
+		value := object.values[i]
+		switch key {
+		case "error":
+			if main.Message == "" {
+				main.Details = value
+			}
+		case "message":
+			if s, ok := value.(string); ok && main.Message == "" {
+				main.Message = s
+			} else {
+				envelope.addMeta(key, value)
+			}
+		case "details":
+			// Validation details become errors of their fields
+			if errs := fieldErrors(code, value); len(errs) > 0 && !slices.ContainsFunc(errs, func(e EnvelopeError) bool { return e.Field == "" }) {
+				envelope.Errors = append(envelope.Errors, errs...)
+			} else {
+				main.Details = value
+			}
+		case "errors":
+			envelope.Errors = append(envelope.Errors, fieldErrors(code, value)...)
+		case "success":
+		default:
+			envelope.addMeta(key, value)
+		}
+	}
+	if main.Message != "" || main.Details != nil || len(envelope.Errors) == 0 {
+		if main.Message == "" {
+			main.Message = http.StatusText(code)
+		}
+		envelope.Errors = append([]EnvelopeError{main}, envelope.Errors...)
+	}
+	return envelope
+}
+
+// fieldErrors converts the errors of a validation response: a list of types.ValidationError
+// or messages, or an object of messages by field
+func fieldErrors(code int, value any) []EnvelopeError {
+	var errs []EnvelopeError
+	switch value := value.(type) {
+	case []any:
+		for _, item := range value {
+			e := EnvelopeError{Status: code}
+			switch item := item.(type) {
+			case string:
+				e.Message = item
+			case *orderedObject:
+				field, _ := item.get("field")
+				message, _ := item.get("message")
+				e.Field, _ = field.(string)
+				e.Message, _ = message.(string)
+				if e.Message == "" {
+					e.Details = item
+				}
+			default:
+				e.Details = item
+			}
+			errs = append(errs, e)
+		}
+	case *orderedObject:
+		for i, field := range value.keys {
+			e := EnvelopeError{Status: code, Field: field}
+			if message, ok := value.values[i].(string); ok {
+				e.Message = message
+			} else {
+				e.Details = value.values[i]
+			}
+			errs = append(errs, e)
+		}
+	}
+	return errs
+}
+
+// addMeta sets a field of the meta
+func (e *Envelope) addMeta(key string, value any) {
+	if e.Meta == nil {
+		e.Meta = make(map[string]any)
+	}
+	e.Meta[key] = value
+}
+
+// get returns the value of a key
+func (o *orderedObject) get(key string) (any, bool) {
+	for i, k := range o.keys {
+		if k == key {
+			return o.values[i], true
+		}
+	}
+	return nil, false
+}
+
+// only reports whether the object has no keys besides the given ones
+func (o *orderedObject) only(keys ...string) bool {
+	for _, k := range o.keys {
+		if !slices.Contains(keys, k) {
+			return false
+		}
+	}
+	return true
+}
+
+// MarshalJSON encodes the object with its keys in order
+func (o *orderedObject) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(o.values[i])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(name)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
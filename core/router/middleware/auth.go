@@ -113,6 +113,18 @@ func Auth(config *AuthConfig) router.MiddlewareFunc {
 				return config.ErrorHandler(c, err)
 			}
 
+			// Tokens restricted to scopes only reach the routes their scopes grant, and
+			// otherwise act as their user
+			if scoped, ok := user.(*ScopedUser); ok {
+				if !ScopesAllow(scoped.Scopes, c.Request.Method, c.Request.URL.Path) {
+					return c.JSON(http.StatusForbidden, map[string]string{
+						"error": "Forbidden: token scopes don't include " + ScopeOf(c.Request.Method, c.Request.URL.Path),
+					})
+				}
+				c.Set("token_scopes", scoped.Scopes)
+				user = scoped.Id
+			}
+
 			// Store user ID with "user_id" key for authorization middleware
 			// This is the essential information needed for permission checks
 			if userID, ok := user.(uint); ok {
@@ -5,18 +5,42 @@ import (
 	"base/core/helper"
 	"base/core/router"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
 // ConfigurableMiddleware creates middleware that can be conditionally applied based on configuration
 type ConfigurableMiddleware struct {
 	config *config.MiddlewareConfig
+
+	// Rate limiters shared by all requests; limiter is replaced when the limits change at runtime
+	limiter        atomic.Pointer[TokenBucket]
+	webhookLimiter *TokenBucket
 }
 
 // NewConfigurableMiddleware creates a new configurable middleware instance
 func NewConfigurableMiddleware(cfg *config.MiddlewareConfig) *ConfigurableMiddleware {
-	return &ConfigurableMiddleware{
+	cm := &ConfigurableMiddleware{
 		config: cfg,
 	}
+
+	if cfg.RateLimitEnabled {
+		requests := cfg.WebhookRateLimitRequests
+		cm.webhookLimiter = NewTokenBucket(requests, cfg.GetWebhookRateLimitDuration(), requests)
+		cm.SetRateLimit(cfg.RateLimitRequests, cfg.GetRateLimitDuration())
+	}
+
+	return cm
+}
+
+// SetRateLimit replaces the global rate limit. Clients start with a full bucket under the new limit.
+func (cm *ConfigurableMiddleware) SetRateLimit(requests int, window time.Duration) {
+	if !cm.config.RateLimitEnabled {
+		return
+	}
+	if previous := cm.limiter.Swap(NewTokenBucket(requests, window, requests)); previous != nil {
+		previous.Stop()
+	}
 }
 
 // ConditionalAPIKey returns API key middleware only if required for the path
@@ -52,6 +76,9 @@ func (cm *ConfigurableMiddleware) ConditionalAuth() router.MiddlewareFunc {
 				// Apply auth middleware
 				authConfig := DefaultAuthConfig()
 				authConfig.TokenValidator = func(token string) (any, error) {
+					if strings.HasPrefix(token, ScopedTokenPrefix) {
+						return resolveScopedToken(c.Request.Context(), token)
+					}
 					_, userID, err := helper.ValidateJWT(token)
 					return userID, err
 				}
@@ -72,19 +99,15 @@ func (cm *ConfigurableMiddleware) ConditionalRateLimit() router.MiddlewareFunc {
 			path := c.Request.URL.Path
 			
 			if cm.config.IsRateLimitRequired(path) {
-				// Determine rate limit settings based on path
-				requests := cm.config.RateLimitRequests
-				window := cm.config.GetRateLimitDuration()
-				
-				// Use webhook settings if it's a webhook path
+				// Use the webhook limits if it's a webhook path
+				var limiter RateLimiter = cm.limiter.Load()
 				if cm.isWebhookPath(path) {
-					requests = cm.config.WebhookRateLimitRequests
-					window = cm.config.GetWebhookRateLimitDuration()
+					limiter = cm.webhookLimiter
 				}
 				
 				// Apply rate limit middleware
 				rateLimitConfig := &RateLimitConfig{
-					Limiter: NewTokenBucket(requests, window, requests),
+					Limiter: limiter,
 					KeyFunc: func(c *router.Context) string {
 						return c.ClientIP()
 					},
@@ -166,7 +189,7 @@ func (cm *ConfigurableMiddleware) pathMatches(path, pattern string) bool {
 }
 
 // ApplyConfigurableMiddleware is a helper function to apply all configurable middleware
-func ApplyConfigurableMiddleware(router *router.Router, cfg *config.MiddlewareConfig) {
+func ApplyConfigurableMiddleware(router *router.Router, cfg *config.MiddlewareConfig) *ConfigurableMiddleware {
 	cm := NewConfigurableMiddleware(cfg)
 	
 	// Apply middleware in the correct order
@@ -183,4 +206,6 @@ func ApplyConfigurableMiddleware(router *router.Router, cfg *config.MiddlewareCo
 	router.Use(cm.ConditionalAuth())
 	router.Use(cm.ConditionalRateLimit())
 	router.Use(cm.ConditionalLogging())
+
+	return cm
 }
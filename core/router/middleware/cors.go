@@ -1,35 +1,122 @@
 package middleware
 
 import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
 	"base/core/router"
 )
 
-func CORSMiddleware(allowedOrigins []string) router.MiddlewareFunc {
+// Defaults of a CORSPolicy
+var (
+	DefaultCORSAllowHeaders = []string{
+		"Origin", "Content-Type", "Accept", "Accept-Language", "Authorization", "X-Api-Key",
+		"Base-Orgid", "X-Response-Envelope", "X-Captcha-Token", "X-Debug-Log", "If-None-Match",
+	}
+	DefaultCORSExposeHeaders = []string{"Content-Length", "Content-Type", "X-Response-Envelope"}
+	DefaultCORSMaxAge        = 12 * time.Hour
+)
+
+const corsAllowMethods = "GET, POST, PUT, DELETE, OPTIONS, PATCH, HEAD"
+
+// CORSPolicy is the CORS policy of a group of routes, e.g. the public API called from any
+// site and the admin API called by the frontend with cookies
+type CORSPolicy struct {
+	// Paths lists the paths of the policy ("/api/public/*" matches everything below
+	// /api/public); a policy without paths applies to every path
+	Paths []string
+
+	// Origins returns the allowed origins; it is called on every request so they can change
+	// at runtime. "*" allows any origin, without credentials.
+	Origins func() []string
+
+	// AllowCredentials lets browsers send cookies and Authorization headers
+	AllowCredentials bool
+
+	// AllowHeaders, ExposeHeaders and MaxAge default to DefaultCORSAllowHeaders,
+	// DefaultCORSExposeHeaders and DefaultCORSMaxAge
+	AllowHeaders  []string
+	ExposeHeaders []string
+	MaxAge        time.Duration
+}
+
+// matches reports whether the policy applies to a path
+func (p *CORSPolicy) matches(path string) bool {
+	if len(p.Paths) == 0 {
+		return true
+	}
+	for _, pattern := range p.Paths {
+		if pattern == path || (strings.HasSuffix(pattern, "/*") && strings.HasPrefix(path, strings.TrimSuffix(pattern, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowOrigin returns the Access-Control-Allow-Origin of a request origin, or "" when the
+// origin isn't allowed
+func (p *CORSPolicy) allowOrigin(origin string) string {
+	var origins []string
+	if p.Origins != nil {
+		origins = p.Origins()
+	}
+	if slices.Contains(origins, "*") {
+		return "*"
+	}
+	if origin != "" && slices.Contains(origins, origin) {
+		return origin
+	}
+	return ""
+}
+
+// CORS sets the CORS headers of the first policy matching the path of a request and answers
+// preflight requests from allowed origins. Requests matching no policy get no CORS headers.
+func CORS(policies ...CORSPolicy) router.MiddlewareFunc {
+	for i := range policies {
+		p := &policies[i]
+		if p.AllowHeaders == nil {
+			p.AllowHeaders = DefaultCORSAllowHeaders
+		}
+		if p.ExposeHeaders == nil {
+			p.ExposeHeaders = DefaultCORSExposeHeaders
+		}
+		if p.MaxAge == 0 {
+			p.MaxAge = DefaultCORSMaxAge
+		}
+	}
+
 	return func(next router.HandlerFunc) router.HandlerFunc {
 		return func(c *router.Context) error {
-			origin := c.GetHeader("Origin")
-
-			// Allow all origins if "*" is present, otherwise match against allowedOrigins
-			allowOrigin := ""
-			if len(allowedOrigins) == 1 && allowedOrigins[0] == "*" {
-				allowOrigin = "*"
-			} else {
-				for _, o := range allowedOrigins {
-					if o == origin {
-						allowOrigin = origin
-						break
-					}
+			var policy *CORSPolicy
+			for i := range policies {
+				if policies[i].matches(c.Request.URL.Path) {
+					policy = &policies[i]
+					break
 				}
 			}
+			if policy == nil {
+				return next(c)
+			}
+
+			allowOrigin := policy.allowOrigin(c.GetHeader("Origin"))
+			if allowOrigin != "*" {
+				// The response depends on the origin, so caches must keep one per origin
+				c.Writer.Header().Add("Vary", "Origin")
+			}
 
 			// Always set CORS headers if origin is allowed
 			if allowOrigin != "" {
 				c.SetHeader("Access-Control-Allow-Origin", allowOrigin)
-				c.SetHeader("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, PATCH, HEAD")
-				c.SetHeader("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization, X-Api-Key, Base-Orgid")
-				c.SetHeader("Access-Control-Expose-Headers", "Content-Length, Content-Type")
-				c.SetHeader("Access-Control-Allow-Credentials", "true")
-				c.SetHeader("Access-Control-Max-Age", "43200") // 12 hours
+				c.SetHeader("Access-Control-Allow-Methods", corsAllowMethods)
+				c.SetHeader("Access-Control-Allow-Headers", strings.Join(policy.AllowHeaders, ", "))
+				c.SetHeader("Access-Control-Expose-Headers", strings.Join(policy.ExposeHeaders, ", "))
+				c.SetHeader("Access-Control-Max-Age", strconv.Itoa(int(policy.MaxAge.Seconds())))
+				// Browsers refuse credentials with a wildcard origin
+				if policy.AllowCredentials && allowOrigin != "*" {
+					c.SetHeader("Access-Control-Allow-Credentials", "true")
+				}
 
 				// Handle preflight OPTIONS requests - respond immediately with 204
 				if c.Request.Method == "OPTIONS" {
@@ -41,3 +128,15 @@ func CORSMiddleware(allowedOrigins []string) router.MiddlewareFunc {
 		}
 	}
 }
+
+// CORSMiddleware allows a fixed list of origins, with credentials, on every path
+func CORSMiddleware(allowedOrigins []string) router.MiddlewareFunc {
+	return DynamicCORSMiddleware(func() []string {
+		return allowedOrigins
+	})
+}
+
+// DynamicCORSMiddleware looks up the allowed origins on every request, so they can change at runtime
+func DynamicCORSMiddleware(origins func() []string) router.MiddlewareFunc {
+	return CORS(CORSPolicy{Origins: origins, AllowCredentials: true})
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strconv"
+
+	"base/core/logger"
+	"base/core/router"
+)
+
+// DebugLogConfig contains per-request debug logging configuration
+type DebugLogConfig struct {
+	// Header enables debug logging when it carries Token, or "true" for admins
+	Header string
+	Token  string
+
+	// IsAdmin reports whether the authenticated user may enable debug logging
+	IsAdmin func(*router.Context) bool
+}
+
+// DebugLog elevates logging to debug for a single request. Loggers obtained with
+// logger.FromContext(c.Context(), ...) log every level, and so does the query log.
+func DebugLog(config DebugLogConfig) router.MiddlewareFunc {
+	if config.Header == "" {
+		config.Header = "X-Debug-Log"
+	}
+
+	return func(next router.HandlerFunc) router.HandlerFunc {
+		return func(c *router.Context) error {
+			value := c.GetHeader(config.Header)
+			if value == "" {
+				return next(c)
+			}
+
+			enabled := config.Token != "" && subtle.ConstantTimeCompare([]byte(value), []byte(config.Token)) == 1
+			if !enabled && config.IsAdmin != nil {
+				if on, err := strconv.ParseBool(value); err == nil && on {
+					enabled = config.IsAdmin(c)
+				}
+			}
+
+			if enabled {
+				c.WithContext(logger.WithDebug(c.Context()))
+				c.SetHeader(config.Header, "enabled")
+			}
+			return next(c)
+		}
+	}
+}
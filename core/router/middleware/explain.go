@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"strconv"
+
+	"base/core/explain"
+	"base/core/router"
+)
+
+// ExplainConfig contains the configuration of debug reports
+type ExplainConfig struct {
+	// Param turns debug reports on when true, e.g. ?_debug=1
+	Param string
+
+	// IsAdmin reports whether the authenticated user may see debug reports
+	IsAdmin func(*router.Context) bool
+}
+
+// Explain collects a debug report of the request for admins asking for one: the queries run,
+// the cache lookups, the authorization decisions and the events emitted. JSON responses get
+// it in meta.debug, enveloped even when the envelope is off. Requests of other users are
+// served as if the parameter weren't there.
+func Explain(config ExplainConfig) router.MiddlewareFunc {
+	if config.Param == "" {
+		config.Param = "_debug"
+	}
+
+	return func(next router.HandlerFunc) router.HandlerFunc {
+		return func(c *router.Context) error {
+			value := c.Query(config.Param)
+			if value == "" || config.IsAdmin == nil {
+				return next(c)
+			}
+			if on, err := strconv.ParseBool(value); err != nil || !on || !config.IsAdmin(c) {
+				return next(c)
+			}
+
+			ctx, _ := explain.With(c.Context())
+			c.WithContext(ctx)
+			return next(c)
+		}
+	}
+}
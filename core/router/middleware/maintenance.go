@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"base/core/router"
+)
+
+// MaintenanceConfig contains maintenance mode configuration
+type MaintenanceConfig struct {
+	// Enabled reports whether maintenance mode is on; it is checked on every request
+	Enabled func() bool
+
+	// AllowPaths lists paths that stay available ("/health/*" matches everything below /health)
+	AllowPaths []string
+
+	// BypassHeader carries BypassToken to let a request through. An empty token disables the bypass.
+	BypassHeader string
+	BypassToken  string
+
+	// RetryAfter is sent in the Retry-After header
+	RetryAfter time.Duration
+
+	// IsAdmin reports whether the authenticated user may keep using the API
+	IsAdmin func(*router.Context) bool
+}
+
+// Maintenance returns 503 Service Unavailable for all requests while maintenance mode is
+// enabled, except allowed paths, admins and requests carrying the bypass token
+func Maintenance(config MaintenanceConfig) router.MiddlewareFunc {
+	if config.BypassHeader == "" {
+		config.BypassHeader = "X-Maintenance-Bypass"
+	}
+	retryAfter := strconv.Itoa(int(config.RetryAfter.Seconds()))
+
+	return func(next router.HandlerFunc) router.HandlerFunc {
+		return func(c *router.Context) error {
+			if config.Enabled == nil || !config.Enabled() {
+				return next(c)
+			}
+
+			// Allowed paths
+			path := c.Request.URL.Path
+			for _, pattern := range config.AllowPaths {
+				if pattern == path || (strings.HasSuffix(pattern, "/*") && strings.HasPrefix(path, strings.TrimSuffix(pattern, "*"))) {
+					return next(c)
+				}
+			}
+
+			// Bypass header
+			if config.BypassToken != "" {
+				token := c.GetHeader(config.BypassHeader)
+				if subtle.ConstantTimeCompare([]byte(token), []byte(config.BypassToken)) == 1 {
+					return next(c)
+				}
+			}
+
+			// Admins
+			if config.IsAdmin != nil && config.IsAdmin(c) {
+				return next(c)
+			}
+
+			if config.RetryAfter > 0 {
+				c.SetHeader("Retry-After", retryAfter)
+			}
+			return c.JSON(http.StatusServiceUnavailable, map[string]string{
+				"error": "Service is under maintenance, please try again later",
+			})
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"strconv"
+
+	"base/core/database"
+	"base/core/router"
+)
+
+// QueryStats attaches a query counter to the request context, so queries run with
+// c.Context() are attributed to the request and slow query logs carry its request Id.
+// When exposeHeader is true the count is returned in the X-DB-Query-Count header.
+func QueryStats(exposeHeader bool) router.MiddlewareFunc {
+	return func(next router.HandlerFunc) router.HandlerFunc {
+		return func(c *router.Context) error {
+			requestId := ""
+			if value, exists := c.Get("request_id"); exists {
+				requestId, _ = value.(string)
+			}
+
+			ctx, stats := database.WithQueryStats(c.Context(), requestId)
+			c.WithContext(ctx)
+
+			if exposeHeader {
+				c.Writer = &queryCountWriter{ResponseWriter: c.Writer, stats: stats}
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// queryCountWriter adds the query count header right before the response headers are sent
+type queryCountWriter struct {
+	router.ResponseWriter
+	stats *database.QueryStats
+}
+
+// WriteHeader sets the query count header and writes the status code
+func (w *queryCountWriter) WriteHeader(code int) {
+	if !w.Written() {
+		w.Header().Set("X-DB-Query-Count", strconv.FormatInt(w.stats.Count(), 10))
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes the data, sending the headers first if needed
+func (w *queryCountWriter) Write(data []byte) (int, error) {
+	if !w.Written() {
+		w.WriteHeader(200)
+	}
+	return w.ResponseWriter.Write(data)
+}
@@ -3,6 +3,7 @@ package middleware
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
@@ -18,6 +19,36 @@ type RateLimiter interface {
 	Reset(key string)
 }
 
+// Quota is the rate limit of a key at a request
+type Quota struct {
+	Limit     int       `json:"limit"`     // Requests allowed per window
+	Remaining int       `json:"remaining"` // Requests left
+	Reset     time.Time `json:"reset"`     // When more requests are allowed
+}
+
+// QuotaLimiter is a rate limiter that reports the quota of keys, which RateLimit sends in the
+// X-RateLimit headers
+type QuotaLimiter interface {
+	RateLimiter
+
+	// Take counts a request of a key, like Allow, and returns the quota left after it
+	Take(key string) (Quota, bool)
+}
+
+// rateLimitQuotaKey is the context key of the quota of the current request
+const rateLimitQuotaKey = "rate_limit_quota"
+
+// RateLimitQuota returns the rate limit quota of the current request, when it was rate limited
+// by a QuotaLimiter
+func RateLimitQuota(c *router.Context) (Quota, bool) {
+	value, ok := c.Get(rateLimitQuotaKey)
+	if !ok {
+		return Quota{}, false
+	}
+	quota, ok := value.(Quota)
+	return quota, ok
+}
+
 // TokenBucket implements token bucket rate limiting
 type TokenBucket struct {
 	rate      int           // tokens per interval
@@ -52,6 +83,13 @@ func NewTokenBucket(rate int, interval time.Duration, maxTokens int) *TokenBucke
 
 // Allow checks if a request should be allowed
 func (tb *TokenBucket) Allow(key string) bool {
+	_, allowed := tb.Take(key)
+	return allowed
+}
+
+// Take takes a token of a key when there is one and returns the tokens left; they refill at
+// Reset
+func (tb *TokenBucket) Take(key string) (Quota, bool) {
 	tb.mu.RLock()
 	b, exists := tb.buckets[key]
 	tb.mu.RUnlock()
@@ -80,12 +118,12 @@ func (tb *TokenBucket) Allow(key string) bool {
 	}
 
 	// Check if we have tokens available
-	if b.tokens > 0 {
+	allowed := b.tokens > 0
+	if allowed {
 		b.tokens--
-		return true
 	}
 
-	return false
+	return Quota{Limit: tb.maxTokens, Remaining: b.tokens, Reset: b.lastFill.Add(tb.interval)}, allowed
 }
 
 // Reset resets the rate limiter for a specific key
@@ -164,8 +202,23 @@ func RateLimit(config *RateLimitConfig) router.MiddlewareFunc {
 			// Get rate limit key
 			key := config.KeyFunc(c)
 
-			// Check rate limit
-			if !config.Limiter.Allow(key) {
+			// Check rate limit, telling the client its quota when the limiter knows it
+			limiter, ok := config.Limiter.(QuotaLimiter)
+			if !ok {
+				if !config.Limiter.Allow(key) {
+					return config.ErrorHandler(c)
+				}
+				return next(c)
+			}
+
+			quota, allowed := limiter.Take(key)
+			c.Set(rateLimitQuotaKey, quota)
+			c.SetHeader("X-RateLimit-Limit", strconv.Itoa(quota.Limit))
+			c.SetHeader("X-RateLimit-Remaining", strconv.Itoa(quota.Remaining))
+			c.SetHeader("X-RateLimit-Reset", strconv.FormatInt(quota.Reset.Unix(), 10))
+			if !allowed {
+				retryAfter := int(time.Until(quota.Reset).Seconds()) + 1
+				c.SetHeader("Retry-After", strconv.Itoa(max(retryAfter, 1)))
 				return config.ErrorHandler(c)
 			}
 
@@ -218,6 +271,13 @@ func NewSlidingWindow(windowSize time.Duration, maxRequests int) *SlidingWindow
 
 // Allow checks if a request should be allowed
 func (sw *SlidingWindow) Allow(key string) bool {
+	_, allowed := sw.Take(key)
+	return allowed
+}
+
+// Take counts a request of a key when it is under the limit and returns the requests left;
+// the oldest request of the window leaves it at Reset
+func (sw *SlidingWindow) Take(key string) (Quota, bool) {
 	sw.mu.Lock()
 	defer sw.mu.Unlock()
 
@@ -228,7 +288,7 @@ func (sw *SlidingWindow) Allow(key string) bool {
 	history, exists := sw.requests[key]
 	if !exists {
 		sw.requests[key] = []time.Time{now}
-		return true
+		return Quota{Limit: sw.maxRequests, Remaining: sw.maxRequests - 1, Reset: now.Add(sw.windowSize)}, true
 	}
 
 	// Remove old requests outside window
@@ -240,14 +300,17 @@ func (sw *SlidingWindow) Allow(key string) bool {
 	}
 
 	// Check if under limit
-	if len(validRequests) < sw.maxRequests {
+	allowed := len(validRequests) < sw.maxRequests
+	if allowed {
 		validRequests = append(validRequests, now)
-		sw.requests[key] = validRequests
-		return true
 	}
-
 	sw.requests[key] = validRequests
-	return false
+
+	reset := now.Add(sw.windowSize)
+	if len(validRequests) > 0 {
+		reset = validRequests[0].Add(sw.windowSize)
+	}
+	return Quota{Limit: sw.maxRequests, Remaining: sw.maxRequests - len(validRequests), Reset: reset}, allowed
 }
 
 // Reset resets the rate limiter for a specific key
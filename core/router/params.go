@@ -0,0 +1,64 @@
+package router
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// MaxListLimit caps the page size of list endpoints; larger limits are lowered to it
+const MaxListLimit = 100
+
+// ListParams are the pagination and sorting query parameters of a list endpoint. Unset
+// parameters are nil, so services apply their own defaults.
+type ListParams struct {
+	Page      *int    // ?page, from 1
+	Limit     *int    // ?limit, at most MaxListLimit
+	SortBy    *string // ?sort, one of the sort fields of the list
+	SortOrder *string // ?order, asc or desc
+}
+
+// ListParams parses the page, limit, sort and order query parameters. The sort field must be
+// one of sortFields; lists without sort fields ignore sort and order. The errors are meant
+// for 400 responses.
+func (c *Context) ListParams(sortFields ...string) (ListParams, error) {
+	var params ListParams
+
+	if pageStr := c.Query("page"); pageStr != "" {
+		page, err := strconv.Atoi(pageStr)
+		if err != nil || page < 1 {
+			return params, errors.New("Invalid page number")
+		}
+		params.Page = &page
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			return params, errors.New("Invalid limit number")
+		}
+		limit = min(limit, MaxListLimit)
+		params.Limit = &limit
+	}
+
+	if len(sortFields) == 0 {
+		return params, nil
+	}
+
+	if sortBy := c.Query("sort"); sortBy != "" {
+		if !slices.Contains(sortFields, sortBy) {
+			return params, errors.New("Invalid sort field. Use one of: " + strings.Join(sortFields, ", "))
+		}
+		params.SortBy = &sortBy
+	}
+
+	if sortOrder := c.Query("order"); sortOrder != "" {
+		if sortOrder != "asc" && sortOrder != "desc" {
+			return params, errors.New("Invalid sort order. Use 'asc' or 'desc'")
+		}
+		params.SortOrder = &sortOrder
+	}
+
+	return params, nil
+}
@@ -0,0 +1,108 @@
+package router
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// DefaultTrustedProxies are the proxies trusted by a new router: a reverse proxy on the same host
+var DefaultTrustedProxies = []string{"127.0.0.1", "::1"}
+
+// DefaultClientIPHeaders are the headers that carry the client IP by default, checked in order
+var DefaultClientIPHeaders = []string{"X-Forwarded-For", "X-Real-IP"}
+
+// ProxyConfig tells ClientIP which proxies may report the client IP, and in which headers
+type ProxyConfig struct {
+	// IPs and CIDR ranges of the proxies in front of the server, e.g. 10.0.0.0/8 for a load
+	// balancer in a private network; the Cloudflare ranges for Cloudflare
+	TrustedProxies []string
+
+	// Headers with the client IP, checked in order: X-Forwarded-For lists, or single IPs such
+	// as X-Real-IP or CF-Connecting-IP
+	Headers []string
+}
+
+// proxies is the parsed ProxyConfig
+type proxies struct {
+	networks []*net.IPNet
+	headers  []string
+}
+
+// parseProxies parses the trusted proxies of a config
+func parseProxies(config ProxyConfig) (*proxies, error) {
+	p := &proxies{headers: config.Headers}
+	for _, proxy := range config.TrustedProxies {
+		proxy = strings.TrimSpace(proxy)
+		cidr := proxy
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				bits = 32
+			}
+			cidr = fmt.Sprintf("%s/%d", proxy, bits)
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		p.networks = append(p.networks, network)
+	}
+	return p, nil
+}
+
+// SetProxies configures the client IP of requests (see Context.ClientIP). Without trusted
+// proxies, the client IP is always the address of the connection.
+func (r *Router) SetProxies(config ProxyConfig) error {
+	p, err := parseProxies(config)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.proxies = p
+	r.mu.Unlock()
+	return nil
+}
+
+// trusted reports whether an address is a trusted proxy
+func (p *proxies) trusted(ip net.IP) bool {
+	if p == nil || ip == nil {
+		return false
+	}
+	for _, network := range p.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the client IP of a request from a trusted proxy, read from the headers, or
+// "" when none of them has one
+func (p *proxies) clientIP(header func(string) string) string {
+	for _, name := range p.headers {
+		value := header(name)
+		if value == "" {
+			continue
+		}
+
+		// Proxies append the address they got the request from, so the client is the last
+		// address that isn't a trusted proxy; the ones before it could be spoofed
+		addresses := strings.Split(value, ",")
+		for i := len(addresses) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(addresses[i]))
+			if ip == nil {
+				break
+			}
+			if !p.trusted(ip) || i == 0 {
+				return ip.String()
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,303 @@
+package router
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Response formats of JSON; see Context.NegotiateFormat
+const (
+	MIMEJSON    = "application/json"
+	MIMEXML     = "application/xml"
+	MIMEMsgPack = "application/msgpack"
+)
+
+// acceptedFormats maps the media types of the Accept header to the response formats
+var acceptedFormats = map[string]string{
+	"application/json":        MIMEJSON,
+	"application/xml":         MIMEXML,
+	"text/xml":                MIMEXML,
+	"application/msgpack":     MIMEMsgPack,
+	"application/x-msgpack":   MIMEMsgPack,
+	"application/vnd.msgpack": MIMEMsgPack,
+}
+
+// NegotiateFormat returns the response format the Accept header of the request prefers:
+// MIMEJSON, MIMEXML or MIMEMsgPack. It is MIMEJSON when the header is missing, accepts
+// anything or only lists unsupported types.
+func (c *Context) NegotiateFormat() string {
+	accept := c.Request.Header.Get("Accept")
+	if accept == "" {
+		return MIMEJSON
+	}
+
+	type candidate struct {
+		format string
+		q      float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		format, ok := acceptedFormats[mediaType]
+		if !ok && (mediaType == "*/*" || mediaType == "application/*") {
+			format, ok = MIMEJSON, true
+		}
+		if ok && q > 0 {
+			candidates = append(candidates, candidate{format, q})
+		}
+	}
+	if len(candidates) == 0 {
+		return MIMEJSON
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	return candidates[0].format
+}
+
+// XML sends obj as XML. The document has the structure of the JSON encoding of obj, so field
+// names and omitted fields are the same: objects become elements named after their keys and
+// lists repeat an <item> element, all inside a <response> element.
+func (c *Context) XML(code int, obj any) error {
+	value, err := toOrdered(obj)
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	writeXML(&b, "response", value)
+	b.WriteByte('\n')
+	return c.Data(code, MIMEXML+"; charset=utf-8", b.Bytes())
+}
+
+// MsgPack sends obj as MessagePack, with the structure of its JSON encoding
+func (c *Context) MsgPack(code int, obj any) error {
+	value, err := toOrdered(obj)
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	writeMsgPack(&b, value)
+	return c.Data(code, MIMEMsgPack, b.Bytes())
+}
+
+// orderedObject is a JSON object that keeps the order of its keys
+type orderedObject struct {
+	keys   []string
+	values []any
+}
+
+// toOrdered encodes obj as JSON and decodes it into nil, bool, json.Number, string, []any
+// and *orderedObject values, so other formats follow the JSON tags and marshalers of obj
+func toOrdered(obj any) (any, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decodeOrdered(decoder)
+}
+
+func decodeOrdered(decoder *json.Decoder) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return token, nil
+	}
+
+	switch delim {
+	case '[':
+		list := []any{}
+		for decoder.More() {
+			value, err := decodeOrdered(decoder)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err := decoder.Token()
+		return list, err
+	case '{':
+		object := &orderedObject{}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrdered(decoder)
+			if err != nil {
+				return nil, err
+			}
+			object.keys = append(object.keys, key.(string))
+			object.values = append(object.values, value)
+		}
+		_, err := decoder.Token()
+		return object, err
+	}
+	return nil, fmt.Errorf("unexpected JSON delimiter %v", delim)
+}
+
+// writeXML writes a value as an element; null values are empty elements
+func writeXML(b *bytes.Buffer, name string, value any) {
+	name = xmlName(name)
+	switch value := value.(type) {
+	case nil:
+		fmt.Fprintf(b, "<%s/>", name)
+	case *orderedObject:
+		fmt.Fprintf(b, "<%s>", name)
+		for i, key := range value.keys {
+			writeXML(b, key, value.values[i])
+		}
+		fmt.Fprintf(b, "</%s>", name)
+	case []any:
+		fmt.Fprintf(b, "<%s>", name)
+		for _, item := range value {
+			writeXML(b, "item", item)
+		}
+		fmt.Fprintf(b, "</%s>", name)
+	default:
+		fmt.Fprintf(b, "<%s>", name)
+		xml.EscapeText(b, []byte(fmt.Sprint(value)))
+		fmt.Fprintf(b, "</%s>", name)
+	}
+}
+
+// xmlName turns a JSON key into a valid element name, e.g. "cf[size]" into "cf_size_"
+func xmlName(key string) string {
+	if key == "" {
+		return "_"
+	}
+	var b strings.Builder
+	for i, r := range key {
+		valid := r == '_' || unicode.IsLetter(r) || (i > 0 && (r == '-' || r == '.' || unicode.IsDigit(r)))
+		if i == 0 && unicode.IsDigit(r) {
+			b.WriteByte('_')
+			valid = true
+		}
+		if valid {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	name := b.String()
+	if strings.HasPrefix(strings.ToLower(name), "xml") {
+		name = "_" + name
+	}
+	return name
+}
+
+// writeMsgPack writes a value in the MessagePack format
+func writeMsgPack(b *bytes.Buffer, value any) {
+	switch value := value.(type) {
+	case nil:
+		b.WriteByte(0xc0)
+	case bool:
+		if value {
+			b.WriteByte(0xc3)
+		} else {
+			b.WriteByte(0xc2)
+		}
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			writeMsgPackInt(b, i)
+			return
+		}
+		if u, err := strconv.ParseUint(string(value), 10, 64); err == nil {
+			b.WriteByte(0xcf)
+			binary.Write(b, binary.BigEndian, u)
+			return
+		}
+		f, _ := value.Float64()
+		b.WriteByte(0xcb)
+		binary.Write(b, binary.BigEndian, math.Float64bits(f))
+	case string:
+		writeMsgPackHeader(b, len(value), 0xa0, 31, 0xd9, 0xda, 0xdb)
+		b.WriteString(value)
+	case []any:
+		writeMsgPackHeader(b, len(value), 0x90, 15, 0, 0xdc, 0xdd)
+		for _, item := range value {
+			writeMsgPack(b, item)
+		}
+	case *orderedObject:
+		writeMsgPackHeader(b, len(value.keys), 0x80, 15, 0, 0xde, 0xdf)
+		for i, key := range value.keys {
+			writeMsgPack(b, key)
+			writeMsgPack(b, value.values[i])
+		}
+	}
+}
+
+// writeMsgPackHeader writes the length of a string, array or map: in the fixed format up to
+// fixMax, else with the 8 (strings only), 16 or 32 bit format
+func writeMsgPackHeader(b *bytes.Buffer, n int, fixed byte, fixMax int, code8, code16, code32 byte) {
+	switch {
+	case n <= fixMax:
+		b.WriteByte(fixed | byte(n))
+	case code8 != 0 && n <= math.MaxUint8:
+		b.WriteByte(code8)
+		b.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		b.WriteByte(code16)
+		binary.Write(b, binary.BigEndian, uint16(n))
+	default:
+		b.WriteByte(code32)
+		binary.Write(b, binary.BigEndian, uint32(n))
+	}
+}
+
+// writeMsgPackInt writes an integer in its smallest format
+func writeMsgPackInt(b *bytes.Buffer, i int64) {
+	switch {
+	case i >= 0 && i <= 127:
+		b.WriteByte(byte(i))
+	case i < 0 && i >= -32:
+		b.WriteByte(byte(int8(i)))
+	case i >= 0 && i <= math.MaxUint8:
+		b.WriteByte(0xcc)
+		b.WriteByte(byte(i))
+	case i >= 0 && i <= math.MaxUint16:
+		b.WriteByte(0xcd)
+		binary.Write(b, binary.BigEndian, uint16(i))
+	case i >= 0 && i <= math.MaxUint32:
+		b.WriteByte(0xce)
+		binary.Write(b, binary.BigEndian, uint32(i))
+	case i >= 0:
+		b.WriteByte(0xcf)
+		binary.Write(b, binary.BigEndian, uint64(i))
+	case i >= math.MinInt8:
+		b.WriteByte(0xd0)
+		b.WriteByte(byte(int8(i)))
+	case i >= math.MinInt16:
+		b.WriteByte(0xd1)
+		binary.Write(b, binary.BigEndian, int16(i))
+	case i >= math.MinInt32:
+		b.WriteByte(0xd2)
+		binary.Write(b, binary.BigEndian, int32(i))
+	default:
+		b.WriteByte(0xd3)
+		binary.Write(b, binary.BigEndian, i)
+	}
+}
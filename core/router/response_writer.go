@@ -2,13 +2,19 @@ package router
 
 import (
 	"bufio"
+	"context"
 	"net"
 	"net/http"
 )
 
+// StatusClientClosedRequest is the status of requests whose client disconnected before the
+// response, as logged by nginx
+const StatusClientClosedRequest = 499
+
 // ResponseWriter wraps http.ResponseWriter with additional functionality
 type responseWriter struct {
 	http.ResponseWriter
+	ctx     context.Context // of the request
 	status  int
 	size    int
 	written bool
@@ -19,6 +25,10 @@ func (w *responseWriter) WriteHeader(code int) {
 	if w.written {
 		return
 	}
+	// A failure after the client went away (canceled queries, mostly) isn't a server error
+	if code >= http.StatusInternalServerError && w.ctx != nil && w.ctx.Err() != nil {
+		code = StatusClientClosedRequest
+	}
 	w.status = code
 	w.written = true
 	w.ResponseWriter.WriteHeader(code)
@@ -2,6 +2,8 @@ package router
 
 import (
 	"net/http"
+	"reflect"
+	"runtime"
 	"strings"
 	"sync"
 )
@@ -12,10 +14,24 @@ type Router struct {
 	middleware   []MiddlewareFunc
 	staticRoutes map[string]http.Handler // Static file routes (bypass middleware)
 	notFound     HandlerFunc
+	routes       []Route
+	envelope     EnvelopeConfig
+	proxies      *proxies // Proxies trusted to report the client IP
 	pool         sync.Pool
 	mu           sync.RWMutex
 }
 
+// Route describes a registered route, e.g. for generating the API docs
+type Route struct {
+	Method  string
+	Path    string // e.g. /api/users/:id
+	Handler string // Name of the handler function, e.g. base/core/app/users.(*UserController).Get-fm
+
+	// Names of the route and group middleware, e.g. base/core/app/authorization.Can.func1;
+	// global middleware isn't included
+	Middleware []string
+}
+
 // New creates a new router
 func New() *Router {
 	r := &Router{
@@ -23,6 +39,7 @@ func New() *Router {
 		staticRoutes: make(map[string]http.Handler),
 		notFound:     defaultNotFound,
 	}
+	r.proxies, _ = parseProxies(ProxyConfig{TrustedProxies: DefaultTrustedProxies, Headers: DefaultClientIPHeaders})
 	r.pool.New = func() any {
 		return &Context{
 			params: make(Params, 0, 10),
@@ -105,6 +122,36 @@ func (r *Router) Handle(method, path string, handler HandlerFunc, middleware ...
 	}
 
 	root.addRoute(path, finalHandler)
+	names := make([]string, len(middleware))
+	for i, m := range middleware {
+		names[i] = middlewareName(m)
+	}
+	r.routes = append(r.routes, Route{Method: method, Path: path, Handler: handlerName(handler), Middleware: names})
+}
+
+// Routes returns the registered routes in the order they were registered
+func (r *Router) Routes() []Route {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	routes := make([]Route, len(r.routes))
+	copy(routes, r.routes)
+	return routes
+}
+
+// handlerName returns the name of the function of a handler
+func handlerName(handler HandlerFunc) string {
+	if fn := runtime.FuncForPC(reflect.ValueOf(handler).Pointer()); fn != nil {
+		return fn.Name()
+	}
+	return ""
+}
+
+// middlewareName returns the name of the function of a middleware
+func middlewareName(middleware MiddlewareFunc) string {
+	if fn := runtime.FuncForPC(reflect.ValueOf(middleware).Pointer()); fn != nil {
+		return fn.Name()
+	}
+	return ""
 }
 
 // Group creates a new route group with prefix
@@ -121,7 +168,7 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	// Check static routes first (bypass middleware)
 	r.mu.RLock()
 	for prefix, handler := range r.staticRoutes {
-		if strings.HasPrefix(req.URL.Path, prefix) {
+		if matchStatic(req.URL.Path, prefix) {
 			r.mu.RUnlock()
 			handler.ServeHTTP(w, req)
 			return
@@ -133,6 +180,10 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	c := r.pool.Get().(*Context)
 	c.reset(w, req)
 	defer r.pool.Put(c)
+	r.mu.RLock()
+	c.envelope = r.envelope
+	c.proxies = r.proxies
+	r.mu.RUnlock()
 
 	r.handleRequest(c)
 }
@@ -176,21 +227,6 @@ func (r *Router) NotFound(handler HandlerFunc) {
 	r.notFound = handler
 }
 
-// Static serves static files
-func (r *Router) Static(prefix, root string) {
-	// Ensure prefix starts with /
-	if !strings.HasPrefix(prefix, "/") {
-		prefix = "/" + prefix
-	}
-
-	// Use http.FileServer to serve static files (bypasses middleware)
-	fileServer := http.StripPrefix(prefix, http.FileServer(http.Dir(root)))
-
-	r.mu.Lock()
-	r.staticRoutes[prefix] = fileServer
-	r.mu.Unlock()
-}
-
 // defaultNotFound is the default 404 handler
 func defaultNotFound(c *Context) error {
 	return c.String(http.StatusNotFound, "404 page not found")
@@ -0,0 +1,113 @@
+package router
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// ServerConfig configures the HTTP server started by RunServer
+type ServerConfig struct {
+	Addr string // e.g. ":443"
+
+	// Certificate and key files for HTTPS
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// Domains to get certificates for from Let's Encrypt, instead of the files. The
+	// certificates are kept in AutocertCacheDir; AutocertEmail is told about problems.
+	AutocertDomains  []string
+	AutocertCacheDir string
+	AutocertEmail    string
+
+	// RedirectAddr listens for plain HTTP (e.g. ":80") and redirects it to HTTPS; with
+	// autocert it also answers the ACME HTTP challenges
+	RedirectAddr string
+
+	// HTTP2 enables HTTP/2: over TLS, or unencrypted (h2c) for proxies in front of a plain
+	// HTTP server
+	HTTP2 bool
+}
+
+// TLS reports whether the server serves HTTPS
+func (c ServerConfig) TLS() bool {
+	return c.TLSCertFile != "" || len(c.AutocertDomains) > 0
+}
+
+// RunServer starts the HTTP server, with HTTPS and HTTP/2 as configured
+func (r *Router) RunServer(config ServerConfig) error {
+	addr := config.Addr
+	if !strings.HasPrefix(addr, ":") {
+		addr = ":" + addr
+	}
+
+	server := &http.Server{
+		Addr:      addr,
+		Handler:   r,
+		Protocols: new(http.Protocols),
+	}
+	server.Protocols.SetHTTP1(true)
+	if config.HTTP2 {
+		if config.TLS() {
+			server.Protocols.SetHTTP2(true)
+		} else {
+			server.Protocols.SetUnencryptedHTTP2(true)
+		}
+	}
+
+	if !config.TLS() {
+		return server.ListenAndServe()
+	}
+
+	var redirect http.Handler = http.HandlerFunc(redirectToHTTPS(addr))
+	if len(config.AutocertDomains) > 0 {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(config.AutocertDomains...),
+			Cache:      autocert.DirCache(config.AutocertCacheDir),
+			Email:      config.AutocertEmail,
+		}
+		server.TLSConfig = manager.TLSConfig()
+		if !config.HTTP2 {
+			server.TLSConfig.NextProtos = []string{"http/1.1", "acme-tls/1"}
+		}
+		redirect = manager.HTTPHandler(redirect)
+	} else {
+		server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	errs := make(chan error, 2)
+	if config.RedirectAddr != "" {
+		go func() {
+			errs <- (&http.Server{Addr: config.RedirectAddr, Handler: redirect}).ListenAndServe()
+		}()
+	}
+	go func() {
+		errs <- server.ListenAndServeTLS(config.TLSCertFile, config.TLSKeyFile)
+	}()
+
+	err := <-errs
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// redirectToHTTPS returns a handler redirecting requests to the HTTPS server at addr
+func redirectToHTTPS(addr string) http.HandlerFunc {
+	_, port, _ := net.SplitHostPort(addr)
+	return func(w http.ResponseWriter, req *http.Request) {
+		host := req.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, req, "https://"+host+req.URL.RequestURI(), http.StatusMovedPermanently)
+	}
+}
@@ -0,0 +1,114 @@
+package router
+
+import (
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+// StaticConfig configures a static file route
+type StaticConfig struct {
+	// Allow lists the servable paths below the prefix ("/uploads/*" matches everything below
+	// /uploads); empty allows every file
+	Allow []string
+
+	// CacheControl is sent with the files, e.g. "public, max-age=31536000, immutable" for
+	// fingerprinted assets
+	CacheControl string
+}
+
+// staticHandler serves the files of a directory. Paths can't leave the directory, not even
+// through symlinks, directories aren't listed and dotfiles (.env, .git) aren't served.
+type staticHandler struct {
+	prefix string
+	root   string
+	config StaticConfig
+}
+
+// Static serves the files of a directory under a prefix, bypassing middleware
+func (r *Router) Static(prefix, root string) {
+	r.StaticWithConfig(prefix, root, StaticConfig{})
+}
+
+// StaticWithConfig serves the files of a directory under a prefix, bypassing middleware,
+// limited to the allowed paths and with the cache headers of the config
+func (r *Router) StaticWithConfig(prefix, root string, config StaticConfig) {
+	// Ensure prefix starts with /
+	if !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	r.mu.Lock()
+	r.staticRoutes[prefix] = &staticHandler{prefix: prefix, root: root, config: config}
+	r.mu.Unlock()
+}
+
+// matchStatic reports whether a request path is below a static prefix
+func matchStatic(path, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+func (h *staticHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := path.Clean("/" + strings.TrimPrefix(req.URL.Path, h.prefix))
+	if !h.servable(name) {
+		http.NotFound(w, req)
+		return
+	}
+
+	root, err := os.OpenRoot(h.root)
+	if err != nil {
+		http.NotFound(w, req)
+		return
+	}
+	defer root.Close()
+
+	// Missing files, and paths escaping the root through a symlink
+	file, err := root.Open(strings.TrimPrefix(name, "/"))
+	if err != nil {
+		http.NotFound(w, req)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, req)
+		return
+	}
+
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if h.config.CacheControl != "" {
+		w.Header().Set("Cache-Control", h.config.CacheControl)
+	}
+	http.ServeContent(w, req, info.Name(), info.ModTime(), file)
+}
+
+// servable reports whether a cleaned path may be served: no dotfiles and allowed
+func (h *staticHandler) servable(name string) bool {
+	if name == "/" {
+		return false
+	}
+	for _, segment := range strings.Split(name, "/") {
+		if strings.HasPrefix(segment, ".") {
+			return false
+		}
+	}
+
+	if len(h.config.Allow) == 0 {
+		return true
+	}
+	for _, pattern := range h.config.Allow {
+		if pattern == name || (strings.HasSuffix(pattern, "/*") && strings.HasPrefix(name, strings.TrimSuffix(pattern, "*"))) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package router
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// MIMENDJSON is the format of streamed lists with one JSON document per line
+const MIMENDJSON = "application/x-ndjson"
+
+// streamFlushEvery is the number of items written between flushes of a stream
+const streamFlushEvery = 100
+
+// Stream writes a list response item by item, so large lists never have to be held in
+// memory. Items are written as a JSON array, or as NDJSON when the request asks for it
+// (see Context.WantsNDJSON). Streams are never enveloped or converted to XML or MessagePack,
+// and once the first item is written the status can't change: errors after that only cut
+// the response short.
+type Stream struct {
+	ctx     *Context
+	ndjson  bool
+	encoder *json.Encoder
+	count   int
+	closed  bool
+}
+
+// WantsNDJSON reports whether the request asks for NDJSON, with ?format=ndjson or an Accept
+// header listing application/x-ndjson
+func (c *Context) WantsNDJSON() bool {
+	if strings.EqualFold(c.Query("format"), "ndjson") {
+		return true
+	}
+	return strings.Contains(c.Request.Header.Get("Accept"), MIMENDJSON)
+}
+
+// Stream starts a streamed list response with the given status
+func (c *Context) Stream(code int) *Stream {
+	s := &Stream{ctx: c, ndjson: c.WantsNDJSON(), encoder: json.NewEncoder(c.Writer)}
+	if s.ndjson {
+		c.SetHeader("Content-Type", MIMENDJSON)
+	} else {
+		c.SetHeader("Content-Type", MIMEJSON)
+	}
+	c.Writer.WriteHeader(code)
+	if !s.ndjson {
+		c.Writer.Write([]byte("["))
+	}
+	return s
+}
+
+// Write writes an item of the list
+func (s *Stream) Write(item any) error {
+	if !s.ndjson && s.count > 0 {
+		if _, err := s.ctx.Writer.Write([]byte(",")); err != nil {
+			return err
+		}
+	}
+	if err := s.encoder.Encode(item); err != nil {
+		return err
+	}
+	s.count++
+	if s.count%streamFlushEvery == 0 {
+		s.ctx.Writer.Flush()
+	}
+	return s.ctx.Err()
+}
+
+// Count returns the number of items written
+func (s *Stream) Count() int {
+	return s.count
+}
+
+// Close ends the list and flushes the response
+func (s *Stream) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if !s.ndjson {
+		if _, err := s.ctx.Writer.Write([]byte("]\n")); err != nil {
+			return err
+		}
+	}
+	s.ctx.Writer.Flush()
+	return nil
+}
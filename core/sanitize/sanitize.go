@@ -0,0 +1,244 @@
+// Package sanitize cleans HTML written by admins before it is stored and served to the
+// public frontend. A policy allows some elements and attributes and drops everything else:
+// scripts, styles, event handlers and javascript: links never survive. Fields are sanitized
+// with the policy configured for them, or the default policy (the html_policy setting):
+//
+//	block.Data["html"] = sanitize.HTML("pages.html", html)
+package sanitize
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Policy names
+const (
+	PolicyStrict  = "strict"  // Text only; all markup is removed
+	PolicyRelaxed = "relaxed" // Formatting, headings, lists, links, images and tables
+)
+
+// Policies are the accepted values of HTML_POLICY, the html_policy setting and
+// HTML_FIELD_POLICIES
+var Policies = []string{PolicyStrict, PolicyRelaxed}
+
+// Policy lists the elements HTML may keep and their attributes. Elements that aren't listed
+// are removed but their text is kept, except for those whose content isn't text (scripts,
+// styles, frames...), which are removed with it.
+type Policy struct {
+	Elements map[string][]string
+}
+
+// globalAttributes are allowed on every allowed element
+var globalAttributes = []string{"title", "lang", "dir"}
+
+// urlAttributes hold URLs, which must be relative or use one of urlSchemes
+var (
+	urlAttributes = []string{"href", "src", "cite"}
+	urlSchemes    = []string{"http", "https", "mailto", "tel"}
+)
+
+// dropped are the elements removed together with their content
+var dropped = []atom.Atom{
+	atom.Script, atom.Style, atom.Iframe, atom.Frame, atom.Frameset, atom.Object,
+	atom.Embed, atom.Applet, atom.Noscript, atom.Template, atom.Title, atom.Head,
+	atom.Textarea, atom.Select, atom.Svg, atom.Math,
+}
+
+// Built-in policies
+var (
+	Strict = &Policy{}
+
+	Relaxed = &Policy{Elements: map[string][]string{
+		"p": nil, "br": nil, "hr": nil, "div": nil, "span": nil,
+		"h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+		"strong": nil, "b": nil, "em": nil, "i": nil, "u": nil, "s": nil, "del": nil,
+		"ins": nil, "sub": nil, "sup": nil, "mark": nil, "small": nil, "abbr": nil,
+		"blockquote": {"cite"}, "pre": nil, "code": nil,
+		"ul": nil, "ol": {"start", "reversed"}, "li": nil,
+		"a":      {"href"},
+		"img":    {"src", "alt", "width", "height"},
+		"figure": nil, "figcaption": nil,
+		"table": nil, "caption": nil, "thead": nil, "tbody": nil, "tfoot": nil, "tr": nil,
+		"th": {"colspan", "rowspan", "scope"}, "td": {"colspan", "rowspan"},
+	}}
+)
+
+// policies maps the policy names to their policies
+var policies = map[string]*Policy{
+	PolicyStrict:  Strict,
+	PolicyRelaxed: Relaxed,
+}
+
+// Sanitize returns the HTML with only the elements and attributes the policy allows.
+// The result is well-formed: unclosed elements are closed.
+func (p *Policy) Sanitize(input string) string {
+	if input == "" {
+		return ""
+	}
+	context := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(input), context)
+	if err != nil {
+		// The parser only fails on read errors, which a string reader doesn't have
+		return html.EscapeString(input)
+	}
+
+	var b strings.Builder
+	for _, node := range nodes {
+		p.render(&b, node)
+	}
+	return b.String()
+}
+
+func (p *Policy) render(b *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		b.WriteString(html.EscapeString(n.Data))
+		return
+	case html.ElementNode:
+	default:
+		// Comments and doctypes
+		return
+	}
+
+	if slices.Contains(dropped, n.DataAtom) {
+		return
+	}
+	allowed, ok := p.Elements[n.Data]
+	if !ok {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			p.render(b, c)
+		}
+		return
+	}
+
+	b.WriteString("<" + n.Data)
+	for _, attr := range n.Attr {
+		if attr.Namespace != "" || !(slices.Contains(allowed, attr.Key) || slices.Contains(globalAttributes, attr.Key)) {
+			continue
+		}
+		if slices.Contains(urlAttributes, attr.Key) && !SafeURL(attr.Val) {
+			continue
+		}
+		b.WriteString(" " + attr.Key + `="` + html.EscapeString(attr.Val) + `"`)
+	}
+	if n.DataAtom == atom.A {
+		// Links can't control the page that opened them
+		b.WriteString(` rel="noopener noreferrer nofollow"`)
+	}
+	b.WriteString(">")
+
+	if isVoid(n.DataAtom) {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		p.render(b, c)
+	}
+	b.WriteString("</" + n.Data + ">")
+}
+
+// isVoid reports whether an element has no content and no end tag
+func isVoid(a atom.Atom) bool {
+	return a == atom.Br || a == atom.Hr || a == atom.Img
+}
+
+// SafeURL reports whether a URL is relative or uses an allowed scheme (http, https, mailto
+// or tel), e.g. to check the URLs of fields that aren't HTML
+func SafeURL(value string) bool {
+	// Browsers ignore whitespace and control characters in schemes ("java\tscript:")
+	cleaned := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, value)
+	u, err := url.Parse(cleaned)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "" || slices.Contains(urlSchemes, strings.ToLower(u.Scheme))
+}
+
+// Sanitizer sanitizes fields with the policy configured for them or the default policy. It
+// is safe for concurrent use.
+type Sanitizer struct {
+	mu       sync.RWMutex
+	fallback string
+	fields   map[string]string
+}
+
+// NewSanitizer creates a sanitizer with a default policy
+func NewSanitizer(policy string) *Sanitizer {
+	return &Sanitizer{fallback: policy, fields: make(map[string]string)}
+}
+
+// Default is the sanitizer of the modules; the server sets its policies from HTML_POLICY,
+// the html_policy setting and HTML_FIELD_POLICIES
+var Default = NewSanitizer(PolicyRelaxed)
+
+// HTML sanitizes the value of a field with the default sanitizer
+func HTML(field, value string) string {
+	return Default.HTML(field, value)
+}
+
+// SetPolicy changes the policy of the fields without their own
+func (s *Sanitizer) SetPolicy(policy string) error {
+	if _, ok := policies[policy]; !ok {
+		return fmt.Errorf("unknown HTML policy %q (strict or relaxed)", policy)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fallback = policy
+	return nil
+}
+
+// SetFieldPolicies replaces the policies of single fields, given as field=policy, e.g.
+// "pages.html=strict"
+func (s *Sanitizer) SetFieldPolicies(entries []string) error {
+	fields, err := ParseFieldPolicies(entries)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fields = fields
+	return nil
+}
+
+// HTML sanitizes the value of a field, e.g. "pages.html"
+func (s *Sanitizer) HTML(field, value string) string {
+	s.mu.RLock()
+	name, ok := s.fields[field]
+	if !ok {
+		name = s.fallback
+	}
+	s.mu.RUnlock()
+
+	policy, ok := policies[name]
+	if !ok {
+		policy = Strict
+	}
+	return policy.Sanitize(value)
+}
+
+// ParseFieldPolicies parses field=policy entries
+func ParseFieldPolicies(entries []string) (map[string]string, error) {
+	fields := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		field, policy, ok := strings.Cut(entry, "=")
+		field, policy = strings.TrimSpace(field), strings.TrimSpace(policy)
+		if !ok || field == "" {
+			return nil, fmt.Errorf("invalid field policy %q (field=policy)", entry)
+		}
+		if _, ok := policies[policy]; !ok {
+			return nil, fmt.Errorf("unknown HTML policy %q for %s (strict or relaxed)", policy, field)
+		}
+		fields[field] = policy
+	}
+	return fields, nil
+}
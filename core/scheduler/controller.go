@@ -2,6 +2,7 @@ package scheduler
 
 import (
 	"base/core/router"
+	"base/core/translation"
 	"net/http"
 )
 
@@ -62,11 +63,11 @@ func (c *SchedulerController) GetTasks(ctx *router.Context) error {
 		}
 
 		if task.LastRun != nil {
-			taskInfo["last_run"] = task.LastRun.Format("2006-01-02 15:04:05")
+			taskInfo["last_run"] = translation.FormatDateTime(ctx, *task.LastRun)
 		}
 
 		if task.NextRun != nil {
-			taskInfo["next_run"] = task.NextRun.Format("2006-01-02 15:04:05")
+			taskInfo["next_run"] = translation.FormatDateTime(ctx, *task.NextRun)
 		}
 
 		taskList = append(taskList, taskInfo)
@@ -109,11 +110,11 @@ func (c *SchedulerController) GetTask(ctx *router.Context) error {
 	}
 
 	if task.LastRun != nil {
-		taskInfo["last_run"] = task.LastRun.Format("2006-01-02 15:04:05")
+		taskInfo["last_run"] = translation.FormatDateTime(ctx, *task.LastRun)
 	}
 
 	if task.NextRun != nil {
-		taskInfo["next_run"] = task.NextRun.Format("2006-01-02 15:04:05")
+		taskInfo["next_run"] = translation.FormatDateTime(ctx, *task.NextRun)
 	}
 
 	ctx.JSON(http.StatusOK, map[string]interface{}{
@@ -0,0 +1,90 @@
+// Package security defines the security events: logins, password and role changes and
+// permission grants, emitted on the security.* namespace of the emitter. The securitylog
+// module stores them for admins and forwards them to a SIEM.
+package security
+
+import (
+	"encoding/json"
+	"time"
+
+	"base/core/emitter"
+	"base/core/router"
+)
+
+// Security events, emitted with an *Event
+const (
+	LoginSucceededEvent    = "security.login_succeeded"
+	LoginFailedEvent       = "security.login_failed"
+	PasswordChangedEvent   = "security.password_changed"
+	RoleChangedEvent       = "security.role_changed"
+	PermissionGrantedEvent = "security.permission_granted"
+	APIKeyCreatedEvent     = "security.api_key_created"
+)
+
+// Events are all the security events
+var Events = []string{
+	LoginSucceededEvent,
+	LoginFailedEvent,
+	PasswordChangedEvent,
+	RoleChangedEvent,
+	PermissionGrantedEvent,
+	APIKeyCreatedEvent,
+}
+
+// Event is a security event
+type Event struct {
+	Id        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+
+	Type string `json:"type" gorm:"size:64;index"` // One of Events
+
+	// User the event is about, 0 when unknown (e.g. a failed login for an unknown email), and
+	// the identifier given for them
+	UserId uint   `json:"user_id" gorm:"index"`
+	Email  string `json:"email,omitempty"`
+
+	// User who acted, when not the user the event is about (e.g. an admin changing a role)
+	ActorId uint `json:"actor_id,omitempty" gorm:"index"`
+
+	IpAddress string `json:"ip_address" gorm:"index"`
+	UserAgent string `json:"user_agent"`
+
+	// Details of the event, e.g. the old and new role or the reason of a failure
+	Metadata json.RawMessage `json:"metadata,omitempty" gorm:"type:json"`
+}
+
+// TableName returns the table name for the Event model
+func (e *Event) TableName() string {
+	return "security_events"
+}
+
+// Failed reports whether the event is a failure, which SIEMs get as a warning
+func (e *Event) Failed() bool {
+	return e.Type == LoginFailedEvent
+}
+
+// Emit emits a security event of a request. The client IP and user agent are taken from the
+// request, and the authenticated user is the actor when the event is about someone else.
+func Emit(e *emitter.Emitter, ctx *router.Context, eventType string, userId uint, email string, metadata map[string]any) {
+	if e == nil {
+		return
+	}
+
+	event := &Event{
+		Type:   eventType,
+		UserId: userId,
+		Email:  email,
+	}
+	if ctx != nil {
+		event.IpAddress = ctx.ClientIP()
+		event.UserAgent = ctx.GetHeader("User-Agent")
+		if actorId := ctx.GetUint("user_id"); actorId != userId {
+			event.ActorId = actorId
+		}
+	}
+	if metadata != nil {
+		event.Metadata, _ = json.Marshal(metadata)
+	}
+
+	e.Emit(eventType, event)
+}
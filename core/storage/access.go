@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Ways attachments are reached, recorded as the Via of their accesses
+const (
+	AccessDownload = "download" // The stored file, through the API
+	AccessOriginal = "original" // The file as uploaded, before conversion, through the API
+)
+
+// AttachmentAccess is a download of an attachment, kept for auditing. The model, field and
+// filename are copied, so the trail outlives replaced and deleted attachments.
+type AttachmentAccess struct {
+	Id           uint      `json:"id" gorm:"primaryKey"`
+	AttachmentId uint      `json:"attachment_id" gorm:"index"`
+	ModelType    string    `json:"model_type" gorm:"index:idx_attachment_accesses_model,priority:1"`
+	ModelId      uint      `json:"model_id" gorm:"index:idx_attachment_accesses_model,priority:2"`
+	Field        string    `json:"field"`
+	Filename     string    `json:"filename"`
+	UserId       uint      `json:"user_id" gorm:"index"`
+	Via          string    `json:"via"` // How the file was reached, e.g. AccessDownload
+	IpAddress    string    `json:"ip_address"`
+	UserAgent    string    `json:"user_agent"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// RecordAccess adds a download of an attachment to the audit trail and counts it on the
+// attachment. The access gives the user, the way and the client.
+func (as *ActiveStorage) RecordAccess(ctx context.Context, attachment *Attachment, access *AttachmentAccess) error {
+	access.AttachmentId = attachment.Id
+	access.ModelType = attachment.ModelType
+	access.ModelId = attachment.ModelId
+	access.Field = attachment.Field
+	access.Filename = attachment.Filename
+
+	return as.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(access).Error; err != nil {
+			return err
+		}
+		return tx.Model(&Attachment{}).Where("id = ?", attachment.Id).
+			UpdateColumn("download_count", gorm.Expr("download_count + ?", 1)).Error
+	})
+}
+
+// Accesses returns a page of the accesses to the attachments of a model, newest first, and
+// their total
+func (as *ActiveStorage) Accesses(ctx context.Context, model Attachable, offset, limit int) ([]*AttachmentAccess, int64, error) {
+	query := as.db.WithContext(ctx).Model(&AttachmentAccess{}).
+		Where("model_type = ? AND model_id = ?", model.GetModelName(), model.GetId())
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var accesses []*AttachmentAccess
+	if err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&accesses).Error; err != nil {
+		return nil, 0, err
+	}
+	return accesses, total, nil
+}
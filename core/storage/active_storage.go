@@ -1,11 +1,16 @@
 package storage
 
 import (
+	"cmp"
+	"context"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -66,19 +71,39 @@ func NewActiveStorage(db *gorm.DB, config Config) (*ActiveStorage, error) {
 		provider:       provider,
 		defaultPath:    storagePath,
 		configs:        make(map[string]map[string]AttachmentConfig),
-		imageProcessor: NewImageProcessor(85), // 85% quality for WebP (will be overridden by settings)
-		videoConverter: NewVideoConverter(23), // CRF 23 for WebM (will be overridden by settings)
-		audioConverter: NewAudioConverter(96), // 96 kbps for audio (will be overridden by settings)
+		imageProcessor: NewImageProcessor(85), // 85% quality for WebP (overridden by settings and conversion policies)
+		videoConverter: NewVideoConverter(23), // CRF 23 for WebM (overridden by settings and conversion policies)
+		audioConverter: NewAudioConverter(96), // 96 kbps for audio (overridden by settings and conversion policies)
 	}
 
-	// Auto-migrate the Attachment model
-	if err := db.AutoMigrate(&Attachment{}); err != nil {
+	// Auto-migrate the Attachment model and its access trail
+	if err := db.AutoMigrate(&Attachment{}, &AttachmentAccess{}); err != nil {
 		return nil, fmt.Errorf("failed to migrate attachments table: %w", err)
 	}
 
 	return as, nil
 }
 
+// defaultMaxFileSize is the size cap of attachments registered without a MaxFileSize until
+// SetDefaultMaxFileSize is called
+const defaultMaxFileSize = 100 << 20 // 100MB
+
+var maxFileSize atomic.Int64
+
+// SetDefaultMaxFileSize sets the size cap, in bytes, of attachments registered without a
+// MaxFileSize; it applies to the next uploads
+func SetDefaultMaxFileSize(bytes int64) {
+	maxFileSize.Store(bytes)
+}
+
+// DefaultMaxFileSize returns the size cap of attachments registered without a MaxFileSize
+func DefaultMaxFileSize() int64 {
+	if bytes := maxFileSize.Load(); bytes > 0 {
+		return bytes
+	}
+	return defaultMaxFileSize
+}
+
 func (as *ActiveStorage) RegisterAttachment(modelName string, config AttachmentConfig) {
 	if as.configs[modelName] == nil {
 		as.configs[modelName] = make(map[string]AttachmentConfig)
@@ -86,7 +111,7 @@ func (as *ActiveStorage) RegisterAttachment(modelName string, config AttachmentC
 	as.configs[modelName][config.Field] = config
 }
 
-func (as *ActiveStorage) Attach(model Attachable, field string, file *multipart.FileHeader) (*Attachment, error) {
+func (as *ActiveStorage) Attach(ctx context.Context, model Attachable, field string, file *multipart.FileHeader) (*Attachment, error) {
 	// Get config for model
 	config, err := as.getConfig(model.GetModelName(), field)
 	if err != nil {
@@ -94,21 +119,34 @@ func (as *ActiveStorage) Attach(model Attachable, field string, file *multipart.
 	}
 
 	// Validate file
-	if err := as.validateFile(file, config); err != nil {
+	head, err := sniff(file)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateFile(file.Filename, file.Size, head, config); err != nil {
 		return nil, err
 	}
 
-	// Get media conversion settings from database
-	convertImages := as.getSettingBool("media_convert_images", true)
-	convertVideos := as.getSettingBool("media_convert_videos", true)
-	convertAudio := as.getSettingBool("media_convert_audio", true)
-	keepOriginal := as.getSettingBool("media_keep_original", false)
+	// Get the converters of the field's conversion policy
+	images, videos, audio, keepOriginal := as.converters(ctx, config.Conversion)
 
-	// Try to convert images to WebP (if enabled)
+	// Report the conversion, with the progress of videos, to the client of the upload
+	progress := ProgressFromContext(ctx)
+	progress.SetFilename(file.Filename)
+	if (images != nil && images.IsImageFile(file.Filename)) ||
+		(videos != nil && videos.IsVideoFile(file.Filename)) ||
+		(audio != nil && audio.IsAudioFile(file.Filename)) {
+		progress.Report(StageConverting, -1)
+	}
+	if videos != nil && progress != nil {
+		videos.OnProgress = func(percent int) { progress.Report(StageConverting, percent) }
+	}
+
+	// Try to convert images to their output format (if enabled)
 	var convertedData []byte
 	var convertedFilename string
-	if convertImages && as.imageProcessor != nil {
-		convertedData, convertedFilename, err = as.imageProcessor.ConvertToWebP(file)
+	if images != nil {
+		convertedData, convertedFilename, err = images.Convert(file)
 		if err != nil {
 			// If conversion fails, just use original file
 			convertedData = nil
@@ -116,8 +154,8 @@ func (as *ActiveStorage) Attach(model Attachable, field string, file *multipart.
 	}
 
 	// If not converted to image, try video conversion to WebM (if enabled)
-	if convertedData == nil && convertVideos && as.videoConverter != nil {
-		convertedData, convertedFilename, err = as.videoConverter.ConvertToWebM(file)
+	if convertedData == nil && videos != nil {
+		convertedData, convertedFilename, err = videos.ConvertToWebM(file)
 		if err != nil {
 			// If conversion fails, just use original file
 			convertedData = nil
@@ -125,18 +163,19 @@ func (as *ActiveStorage) Attach(model Attachable, field string, file *multipart.
 	}
 
 	// If not converted to image or video, try audio conversion to Opus (if enabled)
-	if convertedData == nil && convertAudio && as.audioConverter != nil {
-		convertedData, convertedFilename, err = as.audioConverter.ConvertToOpus(file)
+	if convertedData == nil && audio != nil {
+		convertedData, convertedFilename, err = audio.ConvertToOpus(file)
 		if err != nil {
 			// If conversion fails, just use original file
 			convertedData = nil
 		}
 	}
 
-	// If file was converted and keep original is enabled, upload original too
-	if convertedData != nil && keepOriginal {
+	// If file was converted and keep original is enabled, upload original too. Originals
+	// without an attachment field are only stored next to the converted files.
+	if convertedData != nil && keepOriginal && config.Conversion.OriginalField == "" {
 		originalPath := filepath.Join(config.Path, model.GetModelName(), field, "originals")
-		_, err = as.provider.Upload(file, UploadConfig{
+		_, err = as.provider.Upload(ctx, file, UploadConfig{
 			AllowedExtensions: config.AllowedExtensions,
 			MaxFileSize:       config.MaxFileSize,
 			UploadPath:        originalPath,
@@ -147,6 +186,11 @@ func (as *ActiveStorage) Attach(model Attachable, field string, file *multipart.
 		}
 	}
 
+	// Nobody waits for the upload of a cancelled request
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// Use converted file if available
 	finalFile := file
 	if convertedData != nil {
@@ -168,15 +212,16 @@ func (as *ActiveStorage) Attach(model Attachable, field string, file *multipart.
 	}
 
 	// Upload file using provider (with converted data if available)
+	progress.Report(StageStoring, -1)
 	var result *UploadResult
 	if convertedData != nil {
-		result, err = as.provider.UploadBytes(convertedData, finalFile.Filename, UploadConfig{
+		result, err = as.provider.UploadBytes(ctx, convertedData, finalFile.Filename, UploadConfig{
 			AllowedExtensions: config.AllowedExtensions,
 			MaxFileSize:       config.MaxFileSize,
 			UploadPath:        filepath.Join(config.Path, model.GetModelName(), field),
 		})
 	} else {
-		result, err = as.provider.Upload(finalFile, UploadConfig{
+		result, err = as.provider.Upload(ctx, finalFile, UploadConfig{
 			AllowedExtensions: config.AllowedExtensions,
 			MaxFileSize:       config.MaxFileSize,
 			UploadPath:        filepath.Join(config.Path, model.GetModelName(), field),
@@ -192,20 +237,216 @@ func (as *ActiveStorage) Attach(model Attachable, field string, file *multipart.
 	attachment.URL = as.provider.GetURL(result.Path)
 
 	// Save attachment record
-	if err := as.db.Create(attachment).Error; err != nil {
+	if err := as.db.WithContext(ctx).Create(attachment).Error; err != nil {
+		// Try to delete uploaded file if record creation fails
+		_ = as.provider.Delete(context.WithoutCancel(ctx), result.Path)
+		return nil, err
+	}
+
+	// Attach the original to its field, dropping the converted file if that fails
+	if convertedData != nil && keepOriginal && config.Conversion.OriginalField != "" {
+		if err := as.attachOriginal(ctx, model, config.Conversion.OriginalField, file, head); err != nil {
+			_ = as.Delete(context.WithoutCancel(ctx), attachment)
+			return nil, fmt.Errorf("failed to keep original: %w", err)
+		}
+	}
+
+	return attachment, nil
+}
+
+// attachOriginal stores an upload as is and attaches it to the original field of a model
+func (as *ActiveStorage) attachOriginal(ctx context.Context, model Attachable, field string, file *multipart.FileHeader, head []byte) error {
+	config, err := as.getConfig(model.GetModelName(), field)
+	if err != nil {
+		return err
+	}
+	if err := validateFile(file.Filename, file.Size, head, config); err != nil {
+		return err
+	}
+
+	result, err := as.provider.Upload(ctx, file, UploadConfig{
+		AllowedExtensions: config.AllowedExtensions,
+		MaxFileSize:       config.MaxFileSize,
+		UploadPath:        filepath.Join(config.Path, model.GetModelName(), field),
+	})
+	if err != nil {
+		return err
+	}
+
+	attachment := &Attachment{
+		ModelType: model.GetModelName(),
+		ModelId:   model.GetId(),
+		Field:     field,
+		Filename:  file.Filename,
+		Path:      result.Path,
+		Size:      file.Size,
+		URL:       as.provider.GetURL(result.Path),
+	}
+	if err := as.db.WithContext(ctx).Create(attachment).Error; err != nil {
+		// Try to delete uploaded file if record creation fails
+		_ = as.provider.Delete(context.WithoutCancel(ctx), result.Path)
+		return err
+	}
+	return nil
+}
+
+// converters returns the converters a conversion policy uses, nil for the media kinds it
+// doesn't convert, and whether it keeps the originals of converted uploads
+func (as *ActiveStorage) converters(ctx context.Context, policy ConversionPolicy) (*ImageProcessor, *VideoConverter, *AudioConverter, bool) {
+	// Get media conversion settings from database
+	convertImages := as.getSettingBool(ctx, "media_convert_images", true)
+	convertVideos := as.getSettingBool(ctx, "media_convert_videos", true)
+	convertAudio := as.getSettingBool(ctx, "media_convert_audio", true)
+	keepOriginal := as.getSettingBool(ctx, "media_keep_original", false)
+	if policy.KeepOriginal != nil {
+		keepOriginal = *policy.KeepOriginal
+	}
+
+	// Settings are read on every upload, so changes apply without a restart; values out of
+	// range fall back to the defaults
+	imageQuality := as.getSettingIntInRange(ctx, "media_image_quality", as.imageProcessor.Quality, 1, 100)
+	videoQuality := as.getSettingIntInRange(ctx, "media_video_quality", as.videoConverter.Quality, 0, 51)
+	audioBitrate := as.getSettingIntInRange(ctx, "media_audio_bitrate", as.audioConverter.Bitrate, 6, 510)
+	imageFormat := as.getSettingString(ctx, "media_image_format", FormatWebP)
+
+	switch policy.Format {
+	case FormatWebP, FormatAVIF, FormatJPEG:
+		convertImages, convertVideos, convertAudio = true, false, false
+		imageQuality = cmp.Or(policy.Quality, imageQuality)
+		imageFormat = policy.Format
+	case FormatWebM:
+		convertImages, convertVideos, convertAudio = false, true, false
+		videoQuality = cmp.Or(policy.Quality, videoQuality)
+	case FormatOpus:
+		convertImages, convertVideos, convertAudio = false, false, true
+		audioBitrate = cmp.Or(policy.Quality, audioBitrate)
+	case FormatOriginal:
+		convertImages, convertVideos, convertAudio = false, false, false
+	}
+
+	var images *ImageProcessor
+	var videos *VideoConverter
+	var audio *AudioConverter
+	if convertImages {
+		images = NewImageProcessor(imageQuality)
+		images.Format = imageFormat
+		images.Effort = policy.Effort
+	}
+	if convertVideos {
+		videos = NewVideoConverter(videoQuality)
+	}
+	if convertAudio {
+		audio = NewAudioConverter(audioBitrate)
+	}
+	return images, videos, audio, keepOriginal
+}
+
+// AttachBytes stores generated content (e.g. a rendered PDF) as an attachment. Unlike
+// Attach, the content is stored as is, without media conversion.
+func (as *ActiveStorage) AttachBytes(ctx context.Context, model Attachable, field string, data []byte, filename string) (*Attachment, error) {
+	config, err := as.getConfig(model.GetModelName(), field)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateFile(filename, int64(len(data)), data[:min(len(data), sniffLength)], config); err != nil {
+		return nil, err
+	}
+
+	result, err := as.provider.UploadBytes(ctx, data, filename, UploadConfig{
+		AllowedExtensions: config.AllowedExtensions,
+		MaxFileSize:       config.MaxFileSize,
+		UploadPath:        filepath.Join(config.Path, model.GetModelName(), field),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := &Attachment{
+		ModelType: model.GetModelName(),
+		ModelId:   model.GetId(),
+		Field:     field,
+		Filename:  filename,
+		Path:      result.Path,
+		Size:      int64(len(data)),
+		URL:       as.provider.GetURL(result.Path),
+	}
+	if err := as.db.WithContext(ctx).Create(attachment).Error; err != nil {
 		// Try to delete uploaded file if record creation fails
-		_ = as.provider.Delete(result.Path)
+		_ = as.provider.Delete(context.WithoutCancel(ctx), result.Path)
 		return nil, err
 	}
 
 	return attachment, nil
 }
 
-func (as *ActiveStorage) Delete(attachment *Attachment) error {
-	if err := as.provider.Delete(attachment.Path); err != nil {
+func (as *ActiveStorage) Delete(ctx context.Context, attachment *Attachment) error {
+	if err := ctx.Err(); err != nil {
 		return err
 	}
-	return as.db.Delete(attachment).Error
+	if err := as.provider.Delete(ctx, attachment.Path); err != nil {
+		return err
+	}
+	// The file is gone, so its record goes too even if the request is cancelled meanwhile
+	return as.db.WithContext(context.WithoutCancel(ctx)).Delete(attachment).Error
+}
+
+// CopyAttachments gives a record copies of the attachments of another record of the model,
+// e.g. the record it is a copy of, in tx. The files are copied too, so deleting either
+// attachment leaves the other one intact; if the copy fails, the copied files are deleted.
+func (as *ActiveStorage) CopyAttachments(tx *gorm.DB, modelType string, from, to uint) (err error) {
+	var attachments []*Attachment
+	if err := tx.Where("model_type = ? AND model_id = ?", modelType, from).Order("id").Find(&attachments).Error; err != nil {
+		return err
+	}
+
+	var copied []string
+	defer func() {
+		if err != nil {
+			for _, path := range copied {
+				_ = as.provider.Delete(context.WithoutCancel(tx.Statement.Context), path)
+			}
+		}
+	}()
+
+	for _, attachment := range attachments {
+		data, err := as.read(tx.Statement.Context, attachment)
+		if err != nil {
+			return err
+		}
+		result, err := as.provider.UploadBytes(tx.Statement.Context, data, attachment.Filename, UploadConfig{
+			UploadPath: filepath.Dir(attachment.Path),
+		})
+		if err != nil {
+			return err
+		}
+		copied = append(copied, result.Path)
+
+		attachment.Id = 0
+		attachment.ModelId = to
+		attachment.Path = result.Path
+		attachment.URL = as.provider.GetURL(result.Path)
+		attachment.DownloadCount = 0
+		attachment.CreatedAt, attachment.UpdatedAt = time.Time{}, time.Time{}
+		if err := tx.Create(attachment).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// read returns the content of the stored file of an attachment
+func (as *ActiveStorage) read(ctx context.Context, attachment *Attachment) ([]byte, error) {
+	file, err := as.provider.Open(ctx, attachment.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// Open opens the stored file of an attachment for reading
+func (as *ActiveStorage) Open(ctx context.Context, attachment *Attachment) (io.ReadCloser, error) {
+	return as.provider.Open(ctx, attachment.Path)
 }
 
 // GetProvider returns the storage provider (for internal use)
@@ -223,13 +464,16 @@ func (as *ActiveStorage) getConfig(modelName, field string) (AttachmentConfig, e
 	if !ok {
 		return AttachmentConfig{}, fmt.Errorf("no attachment config found for field %s in model %s", field, modelName)
 	}
+	if config.MaxFileSize == 0 {
+		config.MaxFileSize = DefaultMaxFileSize()
+	}
 
 	return config, nil
 }
 
-func (as *ActiveStorage) LoadAttachment(model Attachable, field string) (*Attachment, error) {
+func (as *ActiveStorage) LoadAttachment(ctx context.Context, model Attachable, field string) (*Attachment, error) {
 	var attachment Attachment
-	err := as.db.Where("model_type = ? AND model_id = ? AND field = ?",
+	err := as.db.WithContext(ctx).Where("model_type = ? AND model_id = ? AND field = ?",
 		model.GetModelName(), model.GetId(), field).First(&attachment).Error
 	if err != nil {
 		return nil, err
@@ -241,26 +485,47 @@ func (as *ActiveStorage) LoadAttachment(model Attachable, field string) (*Attach
 	return &attachment, nil
 }
 
-func (as *ActiveStorage) validateFile(file *multipart.FileHeader, config AttachmentConfig) error {
-	if file.Size > config.MaxFileSize {
-		return fmt.Errorf("file size exceeds maximum allowed size of %d bytes", config.MaxFileSize)
+// getSettingInt retrieves an integer setting from the database
+func (as *ActiveStorage) getSettingInt(ctx context.Context, key string, defaultValue int) int {
+	type Settings struct {
+		ValueInt int `gorm:"column:value_int"`
 	}
+	var setting Settings
+	if err := as.db.WithContext(ctx).Table("settings").Select("value_int").Where("setting_key = ?", key).First(&setting).Error; err != nil {
+		return defaultValue
+	}
+	return setting.ValueInt
+}
 
-	ext := strings.ToLower(filepath.Ext(file.Filename))
-	if len(config.AllowedExtensions) > 0 && !strings.Contains(strings.Join(config.AllowedExtensions, ","), ext) {
-		return fmt.Errorf("file extension %s is not allowed", ext)
+// getSettingIntInRange retrieves an integer setting from the database, or the default when
+// it's outside [low, high]
+func (as *ActiveStorage) getSettingIntInRange(ctx context.Context, key string, defaultValue, low, high int) int {
+	value := as.getSettingInt(ctx, key, defaultValue)
+	if value < low || value > high {
+		return defaultValue
 	}
+	return value
+}
 
-	return nil
+// getSettingString retrieves a string setting from the database
+func (as *ActiveStorage) getSettingString(ctx context.Context, key string, defaultValue string) string {
+	type Settings struct {
+		ValueString string `gorm:"column:value_string"`
+	}
+	var setting Settings
+	if err := as.db.WithContext(ctx).Table("settings").Select("value_string").Where("setting_key = ?", key).First(&setting).Error; err != nil || setting.ValueString == "" {
+		return defaultValue
+	}
+	return strings.ToLower(strings.TrimSpace(setting.ValueString))
 }
 
 // getSettingBool retrieves a boolean setting from the database
-func (as *ActiveStorage) getSettingBool(key string, defaultValue bool) bool {
+func (as *ActiveStorage) getSettingBool(ctx context.Context, key string, defaultValue bool) bool {
 	type Settings struct {
 		ValueBool bool `gorm:"column:value_bool"`
 	}
 	var setting Settings
-	if err := as.db.Table("settings").Select("value_bool").Where("setting_key = ?", key).First(&setting).Error; err != nil {
+	if err := as.db.WithContext(ctx).Table("settings").Select("value_bool").Where("setting_key = ?", key).First(&setting).Error; err != nil {
 		return defaultValue
 	}
 	return setting.ValueBool
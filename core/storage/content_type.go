@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidFile is returned for uploads that are too large, have an extension that isn't
+// allowed, or whose content doesn't match their extension
+var ErrInvalidFile = errors.New("invalid file")
+
+// sniffLength is the number of leading bytes used to detect the content type of a file
+const sniffLength = 512
+
+// extensionContentTypes are the content types files with an extension may have. Files with
+// other extensions are only checked against the allowed extensions.
+var extensionContentTypes = map[string][]string{
+	".jpg":  {"image/jpeg"},
+	".jpeg": {"image/jpeg"},
+	".png":  {"image/png"},
+	".gif":  {"image/gif"},
+	".webp": {"image/webp"},
+	".bmp":  {"image/bmp"},
+	".heic": {"image/heic", "image/heif"},
+	".heif": {"image/heic", "image/heif"},
+	".avif": {"image/avif"},
+	".mp4":  {"video/mp4"},
+	".m4v":  {"video/mp4"},
+	".mov":  {"video/quicktime", "video/mp4"},
+	".avi":  {"video/avi"},
+	".mkv":  {"video/webm"}, // Matroska and WebM share the EBML header
+	".webm": {"video/webm"},
+	".mp3":  {"audio/mpeg"},
+	".m4a":  {"audio/mp4", "video/mp4"},
+	".wav":  {"audio/wave"},
+	".flac": {"audio/flac"},
+	".ogg":  {"application/ogg"},
+	".opus": {"application/ogg"},
+	".pdf":  {"application/pdf"},
+	".txt":  {"text/plain"},
+	".csv":  {"text/plain"},
+}
+
+// ftypBrands are the content types of ISO base media files (MP4, QuickTime, HEIF) by brand;
+// other brands are MP4 video
+var ftypBrands = map[string]string{
+	"heic": "image/heic",
+	"heix": "image/heic",
+	"hevc": "image/heic",
+	"hevx": "image/heic",
+	"mif1": "image/heif",
+	"msf1": "image/heif",
+	"heif": "image/heif",
+	"avif": "image/avif",
+	"avis": "image/avif",
+	"qt  ": "video/quicktime",
+	"M4A ": "audio/mp4",
+	"M4B ": "audio/mp4",
+}
+
+// DetectContentType returns the MIME type of file content, without parameters, from its
+// leading bytes. It extends http.DetectContentType with HEIF, AVIF, QuickTime and FLAC.
+func DetectContentType(data []byte) string {
+	if len(data) >= 12 && string(data[4:8]) == "ftyp" {
+		if contentType, ok := ftypBrands[string(data[8:12])]; ok {
+			return contentType
+		}
+		// Files with a generic major brand list their format among the compatible brands
+		size := min(int(data[0])<<24|int(data[1])<<16|int(data[2])<<8|int(data[3]), len(data))
+		for i := 16; i+4 <= size; i += 4 {
+			if contentType, ok := ftypBrands[string(data[i:i+4])]; ok && strings.HasPrefix(contentType, "image/") {
+				return contentType
+			}
+		}
+		return "video/mp4"
+	}
+	if bytes.HasPrefix(data, []byte("fLaC")) {
+		return "audio/flac"
+	}
+
+	contentType, _, _ := strings.Cut(http.DetectContentType(data), ";")
+	return contentType
+}
+
+// sniff reads the leading bytes of an uploaded file
+func sniff(file *multipart.FileHeader) ([]byte, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer src.Close()
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(src, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return head[:n], nil
+}
+
+// validateFile checks the size and extension of a file against the attachment config, and
+// that its content, given by its leading bytes, is what the extension says
+func validateFile(filename string, size int64, head []byte, config AttachmentConfig) error {
+	if size > config.MaxFileSize {
+		return fmt.Errorf("%w: file size exceeds maximum allowed size of %d bytes", ErrInvalidFile, config.MaxFileSize)
+	}
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	if len(config.AllowedExtensions) > 0 && !slices.ContainsFunc(config.AllowedExtensions, func(allowed string) bool {
+		return strings.EqualFold(allowed, ext)
+	}) {
+		return fmt.Errorf("%w: file extension %s is not allowed", ErrInvalidFile, ext)
+	}
+
+	contentTypes, ok := extensionContentTypes[ext]
+	if !ok {
+		return nil
+	}
+	if contentType := DetectContentType(head); !slices.Contains(contentTypes, contentType) {
+		return fmt.Errorf("%w: file content (%s) doesn't match extension %s", ErrInvalidFile, contentType, ext)
+	}
+	return nil
+}
@@ -2,14 +2,20 @@ package storage
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"image"
 	"image/jpeg"
 	"image/png"
 	"io"
 	"mime/multipart"
+	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/kolesa-team/go-webp/encoder"
 	"github.com/kolesa-team/go-webp/webp"
@@ -20,9 +26,17 @@ import (
 
 // ImageProcessor handles image conversion operations
 type ImageProcessor struct {
-	Quality int // WebP quality (0-100)
+	Quality int    // Output quality (0-100)
+	Format  string // Output format: FormatWebP (the default), FormatAVIF or FormatJPEG
+	Effort  int    // Encoding effort (1-6, higher is slower and smaller); 0 uses 4
 }
 
+// defaultImageEffort is the effort of processors without one, the default WebP method
+const defaultImageEffort = 4
+
+// errAVIFUnavailable is returned when ffmpeg or its AV1 encoders aren't installed
+var errAVIFUnavailable = errors.New("avif encoding requires ffmpeg with libaom-av1 or libsvtav1")
+
 // NewImageProcessor creates a new image processor
 func NewImageProcessor(quality int) *ImageProcessor {
 	if quality <= 0 || quality > 100 {
@@ -45,6 +59,181 @@ func (ip *ImageProcessor) IsImageFile(filename string) bool {
 	return false
 }
 
+// Convert converts an image file to the format of the processor and returns the bytes and
+// new filename. AVIF falls back to WebP when ffmpeg can't encode it or the image has
+// transparency, and WebP to JPEG when its encoder fails. Files that aren't images or
+// already have the output format give nil bytes (the original is used).
+func (ip *ImageProcessor) Convert(file *multipart.FileHeader) ([]byte, string, error) {
+	if !ip.IsImageFile(file.Filename) || imageFormatOf(file.Filename) == ip.format() {
+		return nil, file.Filename, nil
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read file: %w", err)
+	}
+
+	img, err := ip.decodeImage(bytes.NewReader(data), file.Filename)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	encoded, format, err := ip.encode(img)
+	if err != nil {
+		return nil, "", err
+	}
+	if format == imageFormatOf(file.Filename) {
+		return nil, file.Filename, nil // The fallback is the format of the upload
+	}
+
+	newExt := "." + format
+	if format == FormatJPEG {
+		newExt = ".jpg"
+	}
+	return encoded, strings.TrimSuffix(file.Filename, filepath.Ext(file.Filename)) + newExt, nil
+}
+
+// format returns the output format of the processor
+func (ip *ImageProcessor) format() string {
+	switch ip.Format {
+	case FormatAVIF, FormatJPEG:
+		return ip.Format
+	default:
+		return FormatWebP
+	}
+}
+
+// effort returns the encoding effort of the processor, between 1 and 6
+func (ip *ImageProcessor) effort() int {
+	if ip.Effort <= 0 {
+		return defaultImageEffort
+	}
+	return min(ip.Effort, 6)
+}
+
+// encode encodes an image in the format of the processor, or the first fallback that works,
+// and returns the bytes and the format used
+func (ip *ImageProcessor) encode(img image.Image) ([]byte, string, error) {
+	format := ip.format()
+	if format == FormatAVIF {
+		if opaque, ok := img.(interface{ Opaque() bool }); ok && !opaque.Opaque() {
+			format = FormatWebP // AVIF is encoded without its alpha channel
+		} else if data, err := ip.encodeAVIF(img); err == nil {
+			return data, FormatAVIF, nil
+		} else {
+			format = FormatWebP
+		}
+	}
+	if format == FormatWebP {
+		if data, err := ip.encodeWebP(img); err == nil {
+			return data, FormatWebP, nil
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: ip.Quality}); err != nil {
+		return nil, "", fmt.Errorf("failed to encode to jpeg: %w", err)
+	}
+	return buf.Bytes(), FormatJPEG, nil
+}
+
+// encodeWebP encodes an image as lossy WebP
+func (ip *ImageProcessor) encodeWebP(img image.Image) ([]byte, error) {
+	options, err := encoder.NewLossyEncoderOptions(encoder.PresetDefault, float32(ip.Quality))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create encoder options: %w", err)
+	}
+	options.Method = ip.effort()
+
+	var buf bytes.Buffer
+	if err := webp.Encode(&buf, img, options); err != nil {
+		return nil, fmt.Errorf("failed to encode to webp: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// av1Encoder returns the AV1 encoder of the installed ffmpeg, or "" without one
+var av1Encoder = sync.OnceValue(func() string {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return ""
+	}
+	output, err := exec.Command("ffmpeg", "-hide_banner", "-encoders").Output()
+	if err != nil {
+		return ""
+	}
+	for _, name := range []string{"libaom-av1", "libsvtav1"} {
+		if bytes.Contains(output, []byte(name)) {
+			return name
+		}
+	}
+	return ""
+})
+
+// encodeAVIF encodes an image as AVIF with ffmpeg. The quality maps to a CRF of 63 (0) to 0
+// (100), and the effort to the speed of the encoder.
+func (ip *ImageProcessor) encodeAVIF(img image.Image) ([]byte, error) {
+	codec := av1Encoder()
+	if codec == "" {
+		return nil, errAVIFUnavailable
+	}
+
+	// The image is handed over as PNG, so ffmpeg gets every format Go decodes (e.g. HEIC)
+	tmpInput, err := os.CreateTemp("", "image-input-*.png")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp input file: %w", err)
+	}
+	defer os.Remove(tmpInput.Name())
+	defer tmpInput.Close()
+	if err := png.Encode(tmpInput, img); err != nil {
+		return nil, fmt.Errorf("failed to write temp file: %w", err)
+	}
+	tmpInput.Close()
+
+	tmpOutput, err := os.CreateTemp("", "image-output-*.avif")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp output file: %w", err)
+	}
+	defer os.Remove(tmpOutput.Name())
+	tmpOutput.Close()
+
+	crf := (100 - ip.Quality) * 63 / 100
+	args := []string{"-i", tmpInput.Name(), "-c:v", codec, "-crf", fmt.Sprintf("%d", crf), "-b:v", "0"}
+	if codec == "libaom-av1" {
+		args = append(args, "-still-picture", "1", "-cpu-used", fmt.Sprintf("%d", 8-ip.effort())) // 2 (slow) to 7
+	} else {
+		args = append(args, "-preset", fmt.Sprintf("%d", 13-2*ip.effort())) // 1 (slow) to 11
+	}
+	args = append(args, "-pix_fmt", "yuv420p", "-frames:v", "1", "-y", tmpOutput.Name())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ffmpeg conversion failed: %w, stderr: %s", err, stderr.String())
+	}
+
+	return os.ReadFile(tmpOutput.Name())
+}
+
+// imageFormatOf returns the image format of a filename, e.g. FormatJPEG for photo.JPG
+func imageFormatOf(filename string) string {
+	switch ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), ".")); ext {
+	case "jpg", "jpeg":
+		return FormatJPEG
+	default:
+		return ext
+	}
+}
+
 // ConvertToWebP converts an image file to WebP format and returns the bytes and new filename
 func (ip *ImageProcessor) ConvertToWebP(file *multipart.FileHeader) ([]byte, string, error) {
 	// Check if it's an image file
@@ -77,21 +266,16 @@ func (ip *ImageProcessor) ConvertToWebP(file *multipart.FileHeader) ([]byte, str
 	}
 
 	// Encode to WebP
-	var buf bytes.Buffer
-	options, err := encoder.NewLossyEncoderOptions(encoder.PresetDefault, float32(ip.Quality))
+	encoded, err := ip.encodeWebP(img)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to create encoder options: %w", err)
-	}
-
-	if err := webp.Encode(&buf, img, options); err != nil {
-		return nil, "", fmt.Errorf("failed to encode to webp: %w", err)
+		return nil, "", err
 	}
 
 	// Create new filename with .webp extension
 	ext := filepath.Ext(file.Filename)
 	newFilename := strings.TrimSuffix(file.Filename, ext) + ".webp"
 
-	return buf.Bytes(), newFilename, nil
+	return encoded, newFilename, nil
 }
 
 // decodeImage decodes an image from a reader based on file extension
@@ -138,19 +322,14 @@ func (ip *ImageProcessor) ConvertToWebPBytes(data []byte, originalFilename strin
 	}
 
 	// Encode to WebP
-	var buf bytes.Buffer
-	options, err := encoder.NewLossyEncoderOptions(encoder.PresetDefault, float32(ip.Quality))
+	encoded, err := ip.encodeWebP(img)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to create encoder options: %w", err)
-	}
-
-	if err := webp.Encode(&buf, img, options); err != nil {
-		return nil, "", fmt.Errorf("failed to encode to webp: %w", err)
+		return nil, "", err
 	}
 
 	// Create new filename
 	ext := filepath.Ext(originalFilename)
 	newFilename := strings.TrimSuffix(originalFilename, ext) + ".webp"
 
-	return buf.Bytes(), newFilename, nil
+	return encoded, newFilename, nil
 }
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -31,7 +32,11 @@ func NewLocalProvider(config LocalConfig) (Provider, error) {
 	}, nil
 }
 
-func (p *localProvider) Upload(file *multipart.FileHeader, config UploadConfig) (*UploadResult, error) {
+func (p *localProvider) Upload(ctx context.Context, file *multipart.FileHeader, config UploadConfig) (*UploadResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// Create upload directory
 	uploadPath := filepath.Join(p.basePath, config.UploadPath)
 	if err := os.MkdirAll(uploadPath, os.ModePerm); err != nil {
@@ -70,7 +75,11 @@ func (p *localProvider) Upload(file *multipart.FileHeader, config UploadConfig)
 	}, nil
 }
 
-func (p *localProvider) UploadBytes(data []byte, filename string, config UploadConfig) (*UploadResult, error) {
+func (p *localProvider) UploadBytes(ctx context.Context, data []byte, filename string, config UploadConfig) (*UploadResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// Create upload directory
 	uploadPath := filepath.Join(p.basePath, config.UploadPath)
 	if err := os.MkdirAll(uploadPath, os.ModePerm); err != nil {
@@ -95,11 +104,21 @@ func (p *localProvider) UploadBytes(data []byte, filename string, config UploadC
 	}, nil
 }
 
-func (p *localProvider) Delete(path string) error {
+func (p *localProvider) Delete(ctx context.Context, path string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	fullPath := filepath.Join(p.basePath, path)
 	return os.Remove(fullPath)
 }
 
+func (p *localProvider) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return os.Open(filepath.Join(p.basePath, path))
+}
+
 func (p *localProvider) GetURL(path string) string {
 	return fmt.Sprintf("%s/%s", p.baseURL, path)
 }
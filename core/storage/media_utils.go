@@ -21,7 +21,7 @@ func DetectMediaType(filename string) MediaType {
 	ext := strings.ToLower(filepath.Ext(filename))
 
 	// Image formats
-	imageExts := []string{".jpg", ".jpeg", ".png", ".gif", ".webp", ".bmp", ".tiff", ".tif", ".svg"}
+	imageExts := []string{".jpg", ".jpeg", ".png", ".gif", ".webp", ".avif", ".bmp", ".tiff", ".tif", ".svg"}
 	for _, e := range imageExts {
 		if ext == e {
 			return MediaTypeImage
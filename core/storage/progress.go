@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// UploadProgressEvent is emitted with an *UploadProgress as an upload is received, converted
+// and stored
+const UploadProgressEvent = "storage.upload.progress"
+
+// Stages of an upload
+const (
+	StageReceiving  = "receiving"  // The request body is read
+	StageConverting = "converting" // The file is converted, see ConversionPolicy
+	StageStoring    = "storing"    // The file is written to the provider
+	StageDone       = "done"
+	StageFailed     = "failed"
+)
+
+// progressStep is the change in percent below which progress within a stage isn't reported
+const progressStep = 5
+
+// UploadProgress is the progress of an upload, see ProgressReporter
+type UploadProgress struct {
+	UploadId string `json:"upload_id"` // Chosen by the client
+	UserId   uint   `json:"user_id"`
+	Filename string `json:"filename,omitempty"`
+	Stage    string `json:"stage"`
+	Percent  int    `json:"percent"` // Of the stage, -1 when unknown
+	Bytes    int64  `json:"bytes,omitempty"`
+	Total    int64  `json:"total,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ProgressReporter reports the progress of an upload. Progress within a stage is reported in
+// steps of 5 percent. A nil reporter reports nothing, so its methods can be called on the
+// result of ProgressFromContext without checks.
+type ProgressReporter struct {
+	mu       sync.Mutex
+	report   func(*UploadProgress)
+	progress UploadProgress
+}
+
+// NewProgressReporter creates a reporter passing the progress of the upload to report, e.g. to
+// emit UploadProgressEvent
+func NewProgressReporter(uploadId string, userId uint, report func(*UploadProgress)) *ProgressReporter {
+	return &ProgressReporter{
+		report:   report,
+		progress: UploadProgress{UploadId: uploadId, UserId: userId, Percent: -1},
+	}
+}
+
+// SetFilename sets the name of the uploaded file, reported from then on
+func (r *ProgressReporter) SetFilename(filename string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.progress.Filename = filename
+}
+
+// Report reports the percent of a stage, -1 when it's unknown
+func (r *ProgressReporter) Report(stage string, percent int) {
+	r.update(stage, percent, 0, 0)
+}
+
+// Finish reports that the upload is done, or failed with err
+func (r *ProgressReporter) Finish(err error) {
+	if r == nil {
+		return
+	}
+	if err != nil {
+		r.mu.Lock()
+		r.progress.Error = err.Error()
+		r.mu.Unlock()
+		r.update(StageFailed, -1, 0, 0)
+		return
+	}
+	r.update(StageDone, 100, 0, 0)
+}
+
+// Reader returns body counting the bytes read from it as StageReceiving of total bytes, e.g.
+// the Content-Length of the request; total is -1 when it's unknown
+func (r *ProgressReporter) Reader(body io.ReadCloser, total int64) io.ReadCloser {
+	if r == nil {
+		return body
+	}
+	return &progressReader{ReadCloser: body, reporter: r, total: total}
+}
+
+// update reports progress when the stage changes or the percent moved by a step
+func (r *ProgressReporter) update(stage string, percent int, bytes, total int64) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	previous := r.progress
+	if stage == previous.Stage && percent != 100 && (percent < 0 || percent-previous.Percent < progressStep) {
+		r.mu.Unlock()
+		return
+	}
+	r.progress.Stage, r.progress.Percent, r.progress.Bytes, r.progress.Total = stage, percent, bytes, total
+	progress := r.progress
+	r.mu.Unlock()
+
+	r.report(&progress)
+}
+
+// progressReader reports the bytes read from a request body
+type progressReader struct {
+	io.ReadCloser
+	reporter *ProgressReporter
+	read     int64
+	total    int64
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.ReadCloser.Read(b)
+	p.read += int64(n)
+	percent := -1
+	if p.total > 0 {
+		percent = int(min(p.read*100/p.total, 100))
+	}
+	if n > 0 || err == io.EOF {
+		p.reporter.update(StageReceiving, percent, p.read, max(p.total, 0))
+	}
+	return n, err
+}
+
+type progressKey struct{}
+
+// WithProgress returns a context whose uploads are reported to r
+func WithProgress(ctx context.Context, r *ProgressReporter) context.Context {
+	return context.WithValue(ctx, progressKey{}, r)
+}
+
+// ProgressFromContext returns the reporter of the uploads of a context, or nil
+func ProgressFromContext(ctx context.Context) *ProgressReporter {
+	r, _ := ctx.Value(progressKey{}).(*ProgressReporter)
+	return r
+}
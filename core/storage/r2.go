@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"strings"
 
@@ -70,7 +71,7 @@ func NewR2Provider(config R2Config) (Provider, error) {
 	}, nil
 }
 
-func (p *r2Provider) Upload(file *multipart.FileHeader, config UploadConfig) (*UploadResult, error) {
+func (p *r2Provider) Upload(ctx context.Context, file *multipart.FileHeader, config UploadConfig) (*UploadResult, error) {
 	// Open source file
 	src, err := file.Open()
 	if err != nil {
@@ -83,7 +84,7 @@ func (p *r2Provider) Upload(file *multipart.FileHeader, config UploadConfig) (*U
 	key := fmt.Sprintf("%s/%s", config.UploadPath, filename)
 
 	// Upload to R2
-	_, err = p.client.PutObject(context.Background(), &s3.PutObjectInput{
+	_, err = p.client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket:      aws.String(p.bucket),
 		Key:         aws.String(key),
 		Body:        src,
@@ -101,7 +102,7 @@ func (p *r2Provider) Upload(file *multipart.FileHeader, config UploadConfig) (*U
 	}, nil
 }
 
-func (p *r2Provider) UploadBytes(data []byte, filename string, config UploadConfig) (*UploadResult, error) {
+func (p *r2Provider) UploadBytes(ctx context.Context, data []byte, filename string, config UploadConfig) (*UploadResult, error) {
 	// Generate unique filename
 	uniqueFilename := generateUniqueFilename(filename)
 	key := fmt.Sprintf("%s/%s", config.UploadPath, uniqueFilename)
@@ -110,12 +111,14 @@ func (p *r2Provider) UploadBytes(data []byte, filename string, config UploadConf
 	contentType := "application/octet-stream"
 	if strings.HasSuffix(strings.ToLower(filename), ".webp") {
 		contentType = "image/webp"
+	} else if strings.HasSuffix(strings.ToLower(filename), ".avif") {
+		contentType = "image/avif"
 	} else if strings.HasSuffix(strings.ToLower(filename), ".webm") {
 		contentType = "video/webm"
 	}
 
 	// Upload to R2
-	_, err := p.client.PutObject(context.Background(), &s3.PutObjectInput{
+	_, err := p.client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket:      aws.String(p.bucket),
 		Key:         aws.String(key),
 		Body:        bytes.NewReader(data),
@@ -132,14 +135,25 @@ func (p *r2Provider) UploadBytes(data []byte, filename string, config UploadConf
 	}, nil
 }
 
-func (p *r2Provider) Delete(path string) error {
-	_, err := p.client.DeleteObject(context.Background(), &s3.DeleteObjectInput{
+func (p *r2Provider) Delete(ctx context.Context, path string) error {
+	_, err := p.client.DeleteObject(ctx, &s3.DeleteObjectInput{
 		Bucket: aws.String(p.bucket),
 		Key:    aws.String(path),
 	})
 	return err
 }
 
+func (p *r2Provider) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	output, err := p.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(p.bucket),
+		Key:    aws.String(path),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download from R2: %w", err)
+	}
+	return output.Body, nil
+}
+
 func (p *r2Provider) GetURL(path string) string {
 	// Always prefer CDN for R2 storage
 	if p.cdn != "" {
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"mime/multipart"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -74,7 +75,7 @@ func NewS3Provider(config S3Config) (Provider, error) {
 	}, nil
 }
 
-func (p *s3Provider) Upload(file *multipart.FileHeader, config UploadConfig) (*UploadResult, error) {
+func (p *s3Provider) Upload(ctx context.Context, file *multipart.FileHeader, config UploadConfig) (*UploadResult, error) {
 	// Open source file
 	src, err := file.Open()
 	if err != nil {
@@ -88,7 +89,7 @@ func (p *s3Provider) Upload(file *multipart.FileHeader, config UploadConfig) (*U
 
 	// Upload to S3
 	// Note: ACL is deprecated - use bucket policies instead for access control
-	_, err = p.client.PutObject(context.Background(), &s3.PutObjectInput{
+	_, err = p.client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket: aws.String(p.bucket),
 		Key:    aws.String(key),
 		Body:   src,
@@ -104,14 +105,14 @@ func (p *s3Provider) Upload(file *multipart.FileHeader, config UploadConfig) (*U
 	}, nil
 }
 
-func (p *s3Provider) UploadBytes(data []byte, filename string, config UploadConfig) (*UploadResult, error) {
+func (p *s3Provider) UploadBytes(ctx context.Context, data []byte, filename string, config UploadConfig) (*UploadResult, error) {
 	// Generate unique filename
 	uniqueFilename := generateUniqueFilename(filename)
 	key := fmt.Sprintf("%s/%s", config.UploadPath, uniqueFilename)
 
 	// Upload to S3
 	// Note: ACL is deprecated - use bucket policies instead for access control
-	_, err := p.client.PutObject(context.Background(), &s3.PutObjectInput{
+	_, err := p.client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket: aws.String(p.bucket),
 		Key:    aws.String(key),
 		Body:   bytes.NewReader(data),
@@ -127,14 +128,25 @@ func (p *s3Provider) UploadBytes(data []byte, filename string, config UploadConf
 	}, nil
 }
 
-func (p *s3Provider) Delete(path string) error {
-	_, err := p.client.DeleteObject(context.Background(), &s3.DeleteObjectInput{
+func (p *s3Provider) Delete(ctx context.Context, path string) error {
+	_, err := p.client.DeleteObject(ctx, &s3.DeleteObjectInput{
 		Bucket: aws.String(p.bucket),
 		Key:    aws.String(path),
 	})
 	return err
 }
 
+func (p *s3Provider) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	output, err := p.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(p.bucket),
+		Key:    aws.String(path),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download from S3: %w", err)
+	}
+	return output.Body, nil
+}
+
 func (p *s3Provider) GetURL(path string) string {
 	return fmt.Sprintf("https://%s/%s/%s", p.endpoint, p.bucket, path)
 }
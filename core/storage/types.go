@@ -1,9 +1,11 @@
 package storage
 
 import (
+	"context"
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"net/textproto"
 	"os"
@@ -15,16 +17,17 @@ import (
 
 // Attachment represents a file attachment
 type Attachment struct {
-	Id        uint      `json:"id" gorm:"primaryKey"`
-	ModelType string    `json:"model_type" gorm:"index"`
-	ModelId   uint      `json:"model_id" gorm:"index"`
-	Field     string    `json:"field" gorm:"index"`
-	Filename  string    `json:"filename"`
-	Path      string    `json:"path"`
-	Size      int64     `json:"size"`
-	URL       string    `json:"url"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	Id            uint      `json:"id" gorm:"primaryKey"`
+	ModelType     string    `json:"model_type" gorm:"index"`
+	ModelId       uint      `json:"model_id" gorm:"index"`
+	Field         string    `json:"field" gorm:"index"`
+	Filename      string    `json:"filename"`
+	Path          string    `json:"path"`
+	Size          int64     `json:"size"`
+	URL           string    `json:"url"`
+	DownloadCount int64     `json:"download_count,omitempty" gorm:"not null;default:0"` // Counted by RecordAccess
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
 
 // Value implements the driver.Valuer interface
@@ -75,8 +78,29 @@ type AttachmentConfig struct {
 	Field             string
 	Path              string
 	AllowedExtensions []string
-	MaxFileSize       int64
+	MaxFileSize       int64 // Bytes; zero follows the upload_max_size setting (see SetDefaultMaxFileSize)
 	Multiple          bool
+	Conversion        ConversionPolicy // How uploads are converted; the zero value follows the media settings
+}
+
+// Conversion target formats of a ConversionPolicy
+const (
+	FormatWebP     = "webp"     // Convert images to WebP, store other files as uploaded
+	FormatAVIF     = "avif"     // Convert images to AVIF (WebP without an AV1 encoder), store other files as uploaded
+	FormatJPEG     = "jpeg"     // Convert images to JPEG, store other files as uploaded
+	FormatWebM     = "webm"     // Convert videos to WebM, store other files as uploaded
+	FormatOpus     = "opus"     // Convert audio to Opus, store other files as uploaded
+	FormatOriginal = "original" // Store all files as uploaded
+)
+
+// ConversionPolicy controls the conversion of the uploads of an attachment field. Unset
+// options follow the media_* settings.
+type ConversionPolicy struct {
+	Format        string // Target format, e.g. FormatWebP; empty converts what the settings enable
+	Quality       int    // Quality of the target format: WebP, AVIF or JPEG quality (1-100), WebM CRF (1-51) or Opus kbps
+	Effort        int    // Image encoding effort (1-6, higher is slower and smaller); 0 uses 4
+	KeepOriginal  *bool  // Whether converted uploads are also stored as uploaded
+	OriginalField string // Attachment field, registered for the same model, the kept originals are attached to
 }
 
 // Config holds storage service configuration
@@ -101,10 +125,11 @@ type Attachable interface {
 
 // Provider interface for storage providers
 type Provider interface {
-	Upload(file *multipart.FileHeader, config UploadConfig) (*UploadResult, error)
-	UploadBytes(data []byte, filename string, config UploadConfig) (*UploadResult, error)
-	Delete(path string) error
+	Upload(ctx context.Context, file *multipart.FileHeader, config UploadConfig) (*UploadResult, error)
+	UploadBytes(ctx context.Context, data []byte, filename string, config UploadConfig) (*UploadResult, error)
+	Delete(ctx context.Context, path string) error
 	GetURL(path string) string
+	Open(ctx context.Context, path string) (io.ReadCloser, error)
 }
 
 // ActiveStorage handles file storage operations
@@ -1,20 +1,26 @@
 package storage
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 // VideoConverter handles video conversion operations
 type VideoConverter struct {
-	Quality int // CRF quality (0-51, lower is better)
+	Quality    int               // CRF quality (0-51, lower is better)
+	OnProgress func(percent int) // Called with the percent converted so far (optional)
 }
 
 // NewVideoConverter creates a new video converter
@@ -90,21 +96,36 @@ func (vc *VideoConverter) ConvertToWebM(file *multipart.FileHeader) ([]byte, str
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "ffmpeg",
+	args := []string{
 		"-i", tmpInput.Name(),
 		"-c:v", "libvpx-vp9", // VP9 codec
 		"-crf", fmt.Sprintf("%d", vc.Quality),
 		"-b:v", "0",
 		"-c:a", "libopus", // Opus audio codec
 		"-y", // Overwrite output file
-		tmpOutput.Name(),
-	)
+	}
+	if vc.OnProgress != nil {
+		args = append(args, "-progress", "pipe:1", "-nostats") // Progress as key=value lines on stdout
+	}
+	cmd := exec.CommandContext(ctx, "ffmpeg", append(args, tmpOutput.Name())...)
 
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
+	progress := &ffmpegProgress{onProgress: vc.OnProgress}
+	cmd.Stderr = progress
 
-	if err := cmd.Run(); err != nil {
-		return nil, "", fmt.Errorf("ffmpeg conversion failed: %w, stderr: %s", err, stderr.String())
+	if vc.OnProgress != nil {
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read ffmpeg progress: %w", err)
+		}
+		if err := cmd.Start(); err != nil {
+			return nil, "", fmt.Errorf("ffmpeg conversion failed: %w", err)
+		}
+		progress.scan(stdout)
+		if err := cmd.Wait(); err != nil {
+			return nil, "", fmt.Errorf("ffmpeg conversion failed: %w, stderr: %s", err, progress.String())
+		}
+	} else if err := cmd.Run(); err != nil {
+		return nil, "", fmt.Errorf("ffmpeg conversion failed: %w, stderr: %s", err, progress.String())
 	}
 
 	// Read converted file
@@ -119,3 +140,58 @@ func (vc *VideoConverter) ConvertToWebM(file *multipart.FileHeader) ([]byte, str
 
 	return data, newFilename, nil
 }
+
+// durationPattern matches the duration ffmpeg prints for its input, e.g. Duration: 00:01:02.50
+var durationPattern = regexp.MustCompile(`Duration: (\d+):(\d{2}):(\d{2}(?:\.\d+)?)`)
+
+// ffmpegProgress keeps the stderr of ffmpeg, which prints the duration of the input, and
+// turns the progress ffmpeg writes with -progress into percents of that duration
+type ffmpegProgress struct {
+	mu         sync.Mutex
+	stderr     bytes.Buffer
+	duration   time.Duration
+	onProgress func(percent int)
+}
+
+func (p *ffmpegProgress) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stderr.Write(b)
+	if p.duration == 0 {
+		if match := durationPattern.FindSubmatch(p.stderr.Bytes()); match != nil {
+			hours, _ := strconv.Atoi(string(match[1]))
+			minutes, _ := strconv.Atoi(string(match[2]))
+			seconds, _ := strconv.ParseFloat(string(match[3]), 64)
+			p.duration = time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute +
+				time.Duration(seconds*float64(time.Second))
+		}
+	}
+	return len(b), nil
+}
+
+// String returns the stderr of ffmpeg
+func (p *ffmpegProgress) String() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stderr.String()
+}
+
+// scan reads the progress lines of ffmpeg until it exits. out_time_us is the position of the
+// output; the conversion is only reported as done (100) once ffmpeg succeeded.
+func (p *ffmpegProgress) scan(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		value, ok := strings.CutPrefix(scanner.Text(), "out_time_us=")
+		if !ok {
+			continue
+		}
+		position, err := strconv.ParseInt(value, 10, 64)
+		p.mu.Lock()
+		duration := p.duration
+		p.mu.Unlock()
+		if err != nil || duration <= 0 {
+			continue
+		}
+		p.onProgress(int(min(time.Duration(position)*time.Microsecond*100/duration, 99)))
+	}
+}
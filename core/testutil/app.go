@@ -0,0 +1,293 @@
+// Package testutil starts the application against an in-memory SQLite database, temporary
+// storage and a capturing email sender, so modules can be tested through their routes
+// without copying the wiring of main.go:
+//
+//	func TestCreateProduct(t *testing.T) {
+//		app := testutil.New(t, testutil.Options{})
+//		admin := app.CreateUser("Super Admin")
+//
+//		res := app.As(admin).POST("/api/products", map[string]any{"name": "Mug"})
+//		res.AssertStatus(http.StatusCreated)
+//	}
+//
+// The application imports every module, so tests using it must be in an external test
+// package (package products_test). New sets the environment with t.Setenv, so those tests
+// can't run in parallel.
+package testutil
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	appmodules "base/app"
+	_ "base/app/migrations"
+	coremodules "base/core/app"
+	"base/core/app/authorization"
+	"base/core/config"
+	"base/core/crud"
+	"base/core/database"
+	"base/core/email"
+	"base/core/emitter"
+	"base/core/features"
+	"base/core/locks"
+	"base/core/logger"
+	"base/core/module"
+	"base/core/pdf"
+	"base/core/router"
+	"base/core/router/middleware"
+	"base/core/sanitize"
+	"base/core/storage"
+	"base/core/translation"
+
+	"go.uber.org/zap/zaptest"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// APIKey is the API key the test application requires and its clients send
+const APIKey = "test-api-key"
+
+// databases numbers the in-memory databases, so every application gets its own
+var databases atomic.Int64
+
+// Options customizes the test application
+type Options struct {
+	// Modules provides the app modules to start; nil starts those of app/init.go
+	Modules module.AppModuleProvider
+
+	// Env sets additional environment variables before the configuration is read, e.g.
+	// RESPONSE_ENVELOPE or MIDDLEWARE_AUTH_SKIP_PATHS
+	Env map[string]string
+}
+
+// App is an application started for a test
+type App struct {
+	Config  *config.Config
+	DB      *gorm.DB
+	Router  *router.Router
+	Emitter *emitter.Emitter
+	Storage *storage.ActiveStorage
+	Emails  *email.DevSender // Captures the sent emails, see Emails.List
+	Logger  logger.Logger    // Writes to the test log
+
+	t       testing.TB
+	queries atomic.Int64 // Statements run on DB, see CountQueries
+}
+
+// New starts the core modules and the app modules, and stops the application when the test
+// ends. It fails the test if the application can't start.
+func New(t testing.TB, options Options) *App {
+	t.Helper()
+
+	dir := t.TempDir()
+	env := map[string]string{
+		"ENV":                           "test",
+		"LOG_LEVEL":                     "warn",
+		"API_KEY":                       APIKey,
+		"JWT_SECRET":                    "test-jwt-secret",
+		"DB_DRIVER":                     "sqlite",
+		"DB_PATH":                       dir + "/unused.db", // The in-memory database is opened below
+		"STORAGE_PROVIDER":              "local",
+		"STORAGE_PATH":                  dir + "/storage",
+		"STORAGE_BASE_URL":              "http://localhost/storage",
+		"EMAIL_PROVIDER":                "dev",
+		"EMAIL_DEV_PATH":                dir + "/emails",
+		"REPORTS_PATH":                  dir + "/reports",
+		"REQUEST_LOG_ENABLED":           "false",
+		"SWAGGER_ENABLED":               "false",
+		"GRPC_ENABLED":                  "false",
+		"WS_ENABLED":                    "false",
+		"MIDDLEWARE_API_KEY_ENABLED":    "true",
+		"MIDDLEWARE_AUTH_ENABLED":       "true",
+		"MIDDLEWARE_RATE_LIMIT_ENABLED": "false",
+		"MIDDLEWARE_LOGGING_ENABLED":    "false",
+	}
+	for key, value := range options.Env {
+		env[key] = value
+	}
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+
+	cfg := config.NewConfig()
+	if errs := cfg.Validate(); len(errs) > 0 {
+		t.Fatalf("testutil: invalid configuration: %v", errs)
+	}
+
+	app := &App{
+		Config:  cfg,
+		Emitter: emitter.New(),
+		Logger:  logger.NewLoggerFromZap(zaptest.NewLogger(t)),
+		t:       t,
+	}
+
+	db, err := openDatabase(cfg, app.Logger)
+	if err != nil {
+		t.Fatalf("testutil: %v", err)
+	}
+	app.DB = db
+	app.countQueries()
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	// Globals read by the modules and the authentication extension
+	database.DB = db
+	features.Flags.SetDB(db)
+	module.ResetModules()
+
+	if _, err := database.NewMigrator(db).Migrate(); err != nil {
+		t.Fatalf("testutil: failed to apply the migrations: %v", err)
+	}
+
+	app.Storage, err = storage.NewActiveStorage(db, storage.Config{
+		Provider: cfg.StorageProvider,
+		Path:     cfg.StoragePath,
+		BaseURL:  cfg.StorageBaseURL,
+	})
+	if err != nil {
+		t.Fatalf("testutil: failed to initialize storage: %v", err)
+	}
+	app.Emails, err = email.NewDevSender(cfg)
+	if err != nil {
+		t.Fatalf("testutil: failed to initialize email: %v", err)
+	}
+
+	app.initRouter()
+	app.startModules(options.Modules)
+	return app
+}
+
+// openDatabase opens a new in-memory SQLite database; it lives until its last connection
+// is closed
+func openDatabase(cfg *config.Config, log logger.Logger) (*gorm.DB, error) {
+	queryLog := database.NewQueryLogger(cfg.DBSlowQueryThreshold)
+	queryLog.SetLogger(log)
+
+	dsn := fmt.Sprintf("file:testutil-%d?mode=memory&cache=shared&_busy_timeout=5000", databases.Add(1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: queryLog})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the database: %w", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxIdleConns(1)
+	sqlDB.SetConnMaxLifetime(0)
+	sqlDB.SetConnMaxIdleTime(0)
+	return db, nil
+}
+
+// initRouter creates the router with the middleware of the server that affects responses
+func (app *App) initRouter() {
+	app.Router = router.New()
+	app.Router.SetEnvelope(router.EnvelopeConfig{
+		Mode:      router.EnvelopeMode(app.Config.ResponseEnvelope),
+		SkipPaths: app.Config.ResponseEnvelopeSkipPaths,
+	})
+	if err := app.Router.SetProxies(router.ProxyConfig{
+		TrustedProxies: app.Config.TrustedProxies,
+		Headers:        app.Config.ClientIPHeaders,
+	}); err != nil {
+		app.t.Fatalf("testutil: invalid TRUSTED_PROXIES: %v", err)
+	}
+
+	// Responses cached by an earlier test would be served from another database
+	router.Responses.Configure(app.Config.ResponseCacheTTL)
+
+	// Locks taken by an earlier test would lock the records of this one
+	locks.Default.Configure(app.Config.LockTTL, app.DB, app.Emitter)
+
+	// Copies of records get the translations and attachments of the originals, in this
+	// test's storage
+	crud.RegisterCopier("translations", translation.CopyFields)
+	crud.RegisterCopier("attachments", app.Storage.CopyAttachments)
+
+	if err := sanitize.Default.SetPolicy(app.Config.HTMLPolicy); err != nil {
+		app.t.Fatalf("testutil: invalid HTML_POLICY: %v", err)
+	}
+	if err := sanitize.Default.SetFieldPolicies(app.Config.HTMLFieldPolicies); err != nil {
+		app.t.Fatalf("testutil: invalid HTML_FIELD_POLICIES: %v", err)
+	}
+
+	// Upload limits changed by an earlier test would apply to this one
+	router.SetMultipartMemory(app.Config.MultipartMemory)
+	storage.SetDefaultMaxFileSize(app.Config.UploadMaxSize)
+
+	app.Router.Use(middleware.RequestId())
+	app.Router.Use(middleware.QueryStats(true))
+	app.Router.Use(translation.LocaleMiddleware(translation.LocaleConfig{
+		Supported: app.Config.SupportedLocales,
+	}))
+	middleware.ApplyConfigurableMiddleware(app.Router, &app.Config.Middleware)
+
+	// Debug reports for admins, so tests can see why a list misses a record
+	authService := authorization.NewAuthorizationService(app.DB)
+	app.Router.Use(middleware.Explain(middleware.ExplainConfig{
+		IsAdmin: func(c *router.Context) bool {
+			userId, err := authorization.GetUserIdFromContext(c)
+			if err != nil {
+				return false
+			}
+			isAdmin, err := authService.HasRole(c.Request.Context(), userId, authorization.AdminRoles...)
+			return err == nil && isAdmin
+		},
+	}))
+}
+
+// startModules starts the core modules and then the app modules, like the server does
+func (app *App) startModules(provider module.AppModuleProvider) {
+	deps := module.Dependencies{
+		DB:          app.DB,
+		Tx:          database.NewTxManager(app.DB),
+		Router:      app.Router.Group("/api"),
+		Logger:      app.Logger,
+		Emitter:     app.Emitter,
+		Storage:     app.Storage,
+		EmailSender: app.Emails,
+		Config:      app.Config,
+		Features:    features.Flags,
+		PDF:         pdf.NewService(app.Config, app.Logger),
+	}
+
+	// The core modules start in no particular order and the users module seeds its default
+	// user with a role, so the roles are created first
+	if err := authorization.NewAuthorizationModule(app.DB, nil, app.Logger, nil).Migrate(); err != nil {
+		app.t.Fatalf("testutil: failed to create the roles: %v", err)
+	}
+
+	initializer := module.NewInitializer(app.Logger)
+	orchestrator := module.NewCoreOrchestrator(initializer, coremodules.NewCoreModules(appmodules.GetSearchRegistry()))
+	if _, err := orchestrator.InitializeCoreModules(deps); err != nil {
+		app.t.Fatalf("testutil: failed to initialize the core modules: %v", err)
+	}
+
+	authService := authorization.NewAuthorizationService(app.DB)
+	app.Router.Use(func(next router.HandlerFunc) router.HandlerFunc {
+		return func(c *router.Context) error {
+			c.Set("authorization_service", authService)
+			return next(c)
+		}
+	})
+
+	if provider == nil {
+		provider = appmodules.NewAppModules()
+	}
+	initializer.Initialize(provider.GetAppModules(deps), deps)
+
+	for _, status := range module.GetModuleStatuses() {
+		if status.Status == module.ModuleStatusFailed {
+			app.t.Fatalf("testutil: module %s failed to %s: %s", status.Name, status.FailedStep, status.Error)
+		}
+	}
+}
+
+// ServeHTTP serves a request with the application's router
+func (app *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	app.Router.ServeHTTP(w, r)
+}
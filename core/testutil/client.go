@@ -0,0 +1,123 @@
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+
+	"base/core/app/users"
+)
+
+// Client makes requests to the test application; the zero value isn't usable, use App.Client
+// or App.As
+type Client struct {
+	app    *App
+	header http.Header
+}
+
+// Client returns a client that sends the API key but isn't authenticated
+func (app *App) Client() *Client {
+	header := make(http.Header)
+	header.Set("X-Api-Key", APIKey)
+	return &Client{app: app, header: header}
+}
+
+// As returns a client authenticated as the user
+func (app *App) As(user *users.User) *Client {
+	return app.Client().WithHeader("Authorization", "Bearer "+app.Token(user))
+}
+
+// WithHeader returns a copy of the client that sends a header with every request
+func (c *Client) WithHeader(key, value string) *Client {
+	header := c.header.Clone()
+	header.Set(key, value)
+	return &Client{app: c.app, header: header}
+}
+
+// GET sends a GET request
+func (c *Client) GET(path string) *Response {
+	return c.Do(http.MethodGet, path, nil)
+}
+
+// POST sends a POST request with a JSON body
+func (c *Client) POST(path string, body any) *Response {
+	return c.Do(http.MethodPost, path, body)
+}
+
+// PUT sends a PUT request with a JSON body
+func (c *Client) PUT(path string, body any) *Response {
+	return c.Do(http.MethodPut, path, body)
+}
+
+// PATCH sends a PATCH request with a JSON body
+func (c *Client) PATCH(path string, body any) *Response {
+	return c.Do(http.MethodPatch, path, body)
+}
+
+// DELETE sends a DELETE request
+func (c *Client) DELETE(path string) *Response {
+	return c.Do(http.MethodDelete, path, nil)
+}
+
+// Do sends a request and records the response. A body that is an io.Reader or []byte is
+// sent as is; any other body is encoded as JSON.
+func (c *Client) Do(method, path string, body any) *Response {
+	c.app.t.Helper()
+
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case io.Reader:
+		reader = b
+	case []byte:
+		reader = bytes.NewReader(b)
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			c.app.t.Fatalf("testutil: failed to encode the request body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header = c.header.Clone()
+	if body != nil && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	recorder := httptest.NewRecorder()
+	c.app.ServeHTTP(recorder, req)
+	return &Response{ResponseRecorder: recorder, app: c.app}
+}
+
+// Response is a recorded response
+type Response struct {
+	*httptest.ResponseRecorder
+	app *App
+}
+
+// AssertStatus fails the test, showing the body, if the response doesn't have the status
+func (r *Response) AssertStatus(status int) *Response {
+	r.app.t.Helper()
+	if r.Code != status {
+		r.app.t.Fatalf("testutil: expected status %d, got %d: %s", status, r.Code, r.Body.String())
+	}
+	return r
+}
+
+// Decode decodes the JSON body into v, failing the test if it isn't valid
+func (r *Response) Decode(v any) {
+	r.app.t.Helper()
+	if err := json.Unmarshal(r.Body.Bytes(), v); err != nil {
+		r.app.t.Fatalf("testutil: failed to decode the response body: %v: %s", err, r.Body.String())
+	}
+}
+
+// QueryCount returns the number of database queries the request ran
+func (r *Response) QueryCount() int {
+	count, _ := strconv.Atoi(r.Header().Get("X-DB-Query-Count"))
+	return count
+}
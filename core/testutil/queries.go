@@ -0,0 +1,35 @@
+package testutil
+
+import "gorm.io/gorm"
+
+// CountQueries returns the number of statements run on the database while fn runs. Tests use
+// it to check that list endpoints load their relationships in batches, not per row:
+//
+//	before := app.CountQueries(func() { app.As(admin).GET("/api/users?limit=5") })
+//	app.CreateUser("Employee") // ... more rows
+//	after := app.CountQueries(func() { app.As(admin).GET("/api/users?limit=50") })
+//	if after != before {
+//		t.Errorf("listing users runs %d queries for 5 rows and %d for 50", before, after)
+//	}
+func (app *App) CountQueries(fn func()) int {
+	start := app.queries.Load()
+	fn()
+	return int(app.queries.Load() - start)
+}
+
+// countQueries registers callbacks counting the statements run on the database
+func (app *App) countQueries() {
+	count := func(db *gorm.DB) {
+		if db.Error == nil {
+			app.queries.Add(1)
+		}
+	}
+
+	callback := app.DB.Callback()
+	callback.Query().After("gorm:query").Register("testutil:count_queries", count)
+	callback.Create().After("gorm:create").Register("testutil:count_queries", count)
+	callback.Update().After("gorm:update").Register("testutil:count_queries", count)
+	callback.Delete().After("gorm:delete").Register("testutil:count_queries", count)
+	callback.Row().After("gorm:row").Register("testutil:count_queries", count)
+	callback.Raw().After("gorm:raw").Register("testutil:count_queries", count)
+}
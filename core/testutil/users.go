@@ -0,0 +1,35 @@
+package testutil
+
+import (
+	appmodules "base/app"
+	"base/core/app/users"
+	"base/core/factory"
+	"base/core/types"
+)
+
+// UserPassword is the password of the users created by CreateUser
+const UserPassword = factory.DefaultPassword
+
+// CreateUser creates a user with a role, given by name (e.g. "Super Admin" or "Viewer"),
+// and a unique email address and username. It fails the test if the role doesn't exist.
+// Use the factory package for other models or more control.
+func (app *App) CreateUser(role string) *users.User {
+	app.t.Helper()
+
+	user, err := factory.User().WithRole(role).Create(app.DB)
+	if err != nil {
+		app.t.Fatalf("testutil: failed to create a user: %v", err)
+	}
+	return user
+}
+
+// Token returns a JWT for the user, like the one login returns
+func (app *App) Token(user *users.User) string {
+	app.t.Helper()
+
+	token, err := types.GenerateJWT(user.Id, appmodules.Extend(user.Id))
+	if err != nil {
+		app.t.Fatalf("testutil: failed to generate a token: %v", err)
+	}
+	return token
+}
@@ -3,8 +3,12 @@ package translation
 import (
 	"base/core/router"
 	"base/core/storage"
+	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 type TranslationController struct {
@@ -42,6 +46,14 @@ func (c *TranslationController) Routes(router *router.RouterGroup) {
 	router.GET("/translations/by-id/:id", c.Get)
 	router.PUT("/translations/by-id/:id", c.Update)
 	router.DELETE("/translations/by-id/:id", c.Delete)
+
+	// UI and API messages per locale - after all static /translations/... routes
+	router.GET("/translations/:locale", c.GetMessages)
+	router.PUT("/translations/:locale", c.PutMessages)
+	router.DELETE("/translations/:locale", c.DeleteMessages)
+	router.GET("/translations/:locale/missing", c.MissingMessages)
+	router.GET("/translations/:locale/export", c.ExportMessages)
+	router.POST("/translations/:locale/import", c.ImportMessages)
 }
 
 // List godoc
@@ -58,23 +70,11 @@ func (c *TranslationController) Routes(router *router.RouterGroup) {
 // @Failure 500 {object} types.ErrorResponse
 // @Router /translations [get]
 func (c *TranslationController) List(ctx *router.Context) error {
-	var page, limit *int
 	var modelId *uint
 
-	if pageStr := ctx.Query("page"); pageStr != "" {
-		if pageNum, err := strconv.Atoi(pageStr); err == nil && pageNum > 0 {
-			page = &pageNum
-		} else {
-			return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid page number"})
-		}
-	}
-
-	if limitStr := ctx.Query("limit"); limitStr != "" {
-		if limitNum, err := strconv.Atoi(limitStr); err == nil && limitNum > 0 {
-			limit = &limitNum
-		} else {
-			return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid limit number"})
-		}
+	params, err := ctx.ListParams()
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 	}
 
 	// Handle model_id filter
@@ -90,7 +90,7 @@ func (c *TranslationController) List(ctx *router.Context) error {
 	// Get model filter
 	model := ctx.Query("model")
 
-	paginatedResponse, err := c.Service.GetAll(page, limit, model, modelId)
+	paginatedResponse, err := c.Service.GetAll(ctx.Request.Context(), params.Page, params.Limit, model, modelId)
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch translations: " + err.Error()})
 	}
@@ -117,7 +117,7 @@ func (c *TranslationController) Get(ctx *router.Context) error {
 		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid translation ID"})
 	}
 
-	translation, err := c.Service.GetByID(uint(id))
+	translation, err := c.Service.GetByID(ctx.Request.Context(), uint(id))
 	if err != nil {
 		if err.Error() == "translation not found" {
 			return ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
@@ -147,7 +147,7 @@ func (c *TranslationController) Create(ctx *router.Context) error {
 		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request data: " + err.Error()})
 	}
 
-	translation, err := c.Service.Create(&request)
+	translation, err := c.Service.Create(ctx.Request.Context(), &request)
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create translation: " + err.Error()})
 	}
@@ -182,7 +182,7 @@ func (c *TranslationController) Update(ctx *router.Context) error {
 	}
 
 	request.Id = uint(id)
-	translation, err := c.Service.Update(&request)
+	translation, err := c.Service.Update(ctx.Request.Context(), &request)
 	if err != nil {
 		if err.Error() == "translation not found" {
 			return ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
@@ -212,7 +212,7 @@ func (c *TranslationController) Delete(ctx *router.Context) error {
 		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid translation ID"})
 	}
 
-	err = c.Service.Delete(uint(id))
+	err = c.Service.Delete(ctx.Request.Context(), uint(id))
 	if err != nil {
 		if err.Error() == "translation not found" {
 			return ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
@@ -243,7 +243,7 @@ func (c *TranslationController) BulkUpdate(ctx *router.Context) error {
 		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request data: " + err.Error()})
 	}
 
-	err := c.Service.BulkUpdate(&request)
+	err := c.Service.BulkUpdate(ctx.Request.Context(), &request)
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update translations: " + err.Error()})
 	}
@@ -272,7 +272,7 @@ func (c *TranslationController) GetForModel(ctx *router.Context) error {
 		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid model ID"})
 	}
 
-	translations, err := c.Service.GetTranslationsForModel(model, uint(modelId), "")
+	translations, err := c.Service.GetTranslationsForModel(ctx.Request.Context(), model, uint(modelId), "")
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch translations: " + err.Error()})
 	}
@@ -303,7 +303,7 @@ func (c *TranslationController) GetForModelAndLanguage(ctx *router.Context) erro
 		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid model ID"})
 	}
 
-	translations, err := c.Service.GetTranslationsForModel(model, uint(modelId), language)
+	translations, err := c.Service.GetTranslationsForModel(ctx.Request.Context(), model, uint(modelId), language)
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch translations: " + err.Error()})
 	}
@@ -321,10 +321,253 @@ func (c *TranslationController) GetForModelAndLanguage(ctx *router.Context) erro
 // @Failure 500 {object} types.ErrorResponse
 // @Router /translations/languages [get]
 func (c *TranslationController) GetSupportedLanguages(ctx *router.Context) error {
-	languages, err := c.Service.GetSupportedLanguages()
+	languages, err := c.Service.GetSupportedLanguages(ctx.Request.Context())
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch supported languages: " + err.Error()})
 	}
 
 	return ctx.JSON(http.StatusOK, languages)
 }
+
+// localeParam returns the normalized :locale parameter
+func localeParam(ctx *router.Context) (string, bool) {
+	locale := NormalizeLocale(ctx.Param("locale"))
+	return locale, localePattern.MatchString(locale)
+}
+
+// GetMessages godoc
+// @Summary Get the messages of a locale
+// @Description Get the UI and API message translations of a locale
+// @Tags Core/Translations
+// @Security ApiKeyAuth
+// @Produce json
+// @Param locale path string true "Locale, e.g. de or pt-BR"
+// @Success 200 {object} translation.LocaleMessagesResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /translations/{locale} [get]
+func (c *TranslationController) GetMessages(ctx *router.Context) error {
+	locale, ok := localeParam(ctx)
+	if !ok {
+		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid locale"})
+	}
+
+	messages, err := c.Service.GetMessages(ctx.Request.Context(), locale)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch messages: " + err.Error()})
+	}
+
+	return ctx.JSON(http.StatusOK, LocaleMessagesResponse{Locale: locale, Messages: messages})
+}
+
+// PutMessages godoc
+// @Summary Update the messages of a locale
+// @Description Create or update messages of a locale from a key -> message object. An empty message removes the key; with replace=true keys that aren't sent are removed too.
+// @Tags Core/Translations
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param locale path string true "Locale, e.g. de or pt-BR"
+// @Param replace query bool false "Remove messages that aren't in the request"
+// @Param messages body map[string]string true "Messages by key"
+// @Success 200 {object} translation.MessagesResult
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /translations/{locale} [put]
+func (c *TranslationController) PutMessages(ctx *router.Context) error {
+	locale, ok := localeParam(ctx)
+	if !ok {
+		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid locale"})
+	}
+
+	var messages map[string]string
+	if err := ctx.ShouldBindJSON(&messages); err != nil {
+		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request data: " + err.Error()})
+	}
+
+	result, err := c.Service.SetMessages(ctx.Request.Context(), locale, messages, ctx.Query("replace") == "true")
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update messages: " + err.Error()})
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+// DeleteMessages godoc
+// @Summary Delete the messages of a locale
+// @Description Remove all message translations of a locale
+// @Tags Core/Translations
+// @Security ApiKeyAuth
+// @Produce json
+// @Param locale path string true "Locale, e.g. de or pt-BR"
+// @Success 200 {object} translation.MessagesResult
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /translations/{locale} [delete]
+func (c *TranslationController) DeleteMessages(ctx *router.Context) error {
+	locale, ok := localeParam(ctx)
+	if !ok {
+		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid locale"})
+	}
+
+	deleted, err := c.Service.DeleteMessages(ctx.Request.Context(), locale)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete messages: " + err.Error()})
+	}
+
+	return ctx.JSON(http.StatusOK, MessagesResult{Locale: locale, Deleted: int(deleted)})
+}
+
+// MissingMessages godoc
+// @Summary List missing messages of a locale
+// @Description List the message keys without a translation in a locale: known keys (built-in or translated in another locale) and keys requested since startup
+// @Tags Core/Translations
+// @Security ApiKeyAuth
+// @Produce json
+// @Param locale path string true "Locale, e.g. de or pt-BR"
+// @Success 200 {object} translation.MissingMessagesResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Router /translations/{locale}/missing [get]
+func (c *TranslationController) MissingMessages(ctx *router.Context) error {
+	locale, ok := localeParam(ctx)
+	if !ok {
+		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid locale"})
+	}
+
+	return ctx.JSON(http.StatusOK, c.Service.MissingMessages(locale))
+}
+
+// ExportMessages godoc
+// @Summary Export the messages of a locale
+// @Description Download the messages of a locale as a JSON file (key -> message)
+// @Tags Core/Translations
+// @Security ApiKeyAuth
+// @Produce json
+// @Param locale path string true "Locale, e.g. de or pt-BR"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /translations/{locale}/export [get]
+func (c *TranslationController) ExportMessages(ctx *router.Context) error {
+	locale, ok := localeParam(ctx)
+	if !ok {
+		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid locale"})
+	}
+
+	messages, err := c.Service.GetMessages(ctx.Request.Context(), locale)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch messages: " + err.Error()})
+	}
+
+	data, err := json.MarshalIndent(messages, "", "  ")
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to encode messages: " + err.Error()})
+	}
+
+	ctx.SetHeader("Content-Disposition", fmt.Sprintf("attachment; filename=%q", locale+".json"))
+	return ctx.Data(http.StatusOK, "application/json; charset=utf-8", append(data, '\n'))
+}
+
+// maxImportSize limits the size of imported locale files
+const maxImportSize = 5 << 20
+
+// ImportMessages godoc
+// @Summary Import the messages of a locale
+// @Description Import a locale JSON file, uploaded as the "file" form field or sent as the request body. Nested objects are flattened into dotted keys ({"validation": {"required": "..."}} is validation.required). mode=replace removes messages that aren't in the file.
+// @Tags Core/Translations
+// @Security ApiKeyAuth
+// @Accept json,mpfd
+// @Produce json
+// @Param locale path string true "Locale, e.g. de or pt-BR"
+// @Param mode query string false "merge (default) or replace"
+// @Param file formData file false "Locale JSON file"
+// @Success 200 {object} translation.MessagesResult
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /translations/{locale}/import [post]
+func (c *TranslationController) ImportMessages(ctx *router.Context) error {
+	locale, ok := localeParam(ctx)
+	if !ok {
+		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid locale"})
+	}
+
+	mode := ctx.DefaultQuery("mode", "merge")
+	if mode != "merge" && mode != "replace" {
+		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid mode. Use 'merge' or 'replace'"})
+	}
+
+	var source io.Reader = ctx.Request.Body
+	if strings.HasPrefix(ctx.ContentType(), "multipart/form-data") {
+		header, err := ctx.FormFile("file")
+		if err != nil {
+			return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "Missing file: " + err.Error()})
+		}
+		file, err := header.Open()
+		if err != nil {
+			return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "Failed to read file: " + err.Error()})
+		}
+		defer file.Close()
+		source = file
+	}
+
+	data, err := io.ReadAll(io.LimitReader(source, maxImportSize+1))
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "Failed to read file: " + err.Error()})
+	}
+	if len(data) > maxImportSize {
+		return ctx.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{Error: "File is too large"})
+	}
+
+	messages, err := parseLocaleFile(data)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid locale file: " + err.Error()})
+	}
+
+	result, err := c.Service.SetMessages(ctx.Request.Context(), locale, messages, mode == "replace")
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to import messages: " + err.Error()})
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+// parseLocaleFile reads a locale JSON file. Nested objects become dotted keys and an
+// exported {"locale": ..., "messages": {...}} response is accepted as well.
+func parseLocaleFile(data []byte) (map[string]string, error) {
+	var document map[string]any
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	if nested, ok := document["messages"].(map[string]any); ok {
+		if _, ok := document["locale"].(string); ok && len(document) == 2 {
+			document = nested
+		}
+	}
+
+	messages := make(map[string]string)
+	var flatten func(prefix string, values map[string]any) error
+	flatten = func(prefix string, values map[string]any) error {
+		for key, value := range values {
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			switch v := value.(type) {
+			case string:
+				messages[key] = v
+			case map[string]any:
+				if err := flatten(key, v); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("%s: expected a string or an object", key)
+			}
+		}
+		return nil
+	}
+
+	if err := flatten("", document); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
@@ -0,0 +1,143 @@
+package translation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DateDefaults are the system-wide date settings: the timezone used when a request has
+// none, and the layouts of human-readable dates and times
+type DateDefaults struct {
+	Timezone   *time.Location
+	DateLayout string // Go layout, e.g. "2006-01-02"
+	TimeLayout string // Go layout, e.g. "15:04"
+}
+
+// dateDefaults holds the current DateDefaults
+var dateDefaults atomic.Pointer[DateDefaults]
+
+// GetDateDefaults returns the system-wide date settings
+func GetDateDefaults() DateDefaults {
+	if defaults := dateDefaults.Load(); defaults != nil {
+		return *defaults
+	}
+	return DateDefaults{Timezone: time.UTC, DateLayout: "2006-01-02", TimeLayout: "15:04"}
+}
+
+// SetDateDefaults changes the system-wide date settings from the timezone, date_format
+// and time_format settings, e.g. "Europe/Berlin", "DD.MM.YYYY" and "24h"
+func SetDateDefaults(timezone, dateFormat, timeFormat string) error {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return fmt.Errorf("invalid timezone %q: %w", timezone, err)
+	}
+	dateLayout, err := DateLayout(dateFormat)
+	if err != nil {
+		return err
+	}
+	timeLayout, err := TimeLayout(timeFormat)
+	if err != nil {
+		return err
+	}
+
+	dateDefaults.Store(&DateDefaults{Timezone: location, DateLayout: dateLayout, TimeLayout: timeLayout})
+	return nil
+}
+
+// dateTokens maps date_format tokens to Go layout elements
+var dateTokens = map[string]string{"YYYY": "2006", "MM": "01", "DD": "02"}
+
+// DateLayout converts a date_format setting such as "YYYY-MM-DD", "DD.MM.YYYY" or
+// "MM/DD/YYYY" into a Go layout
+func DateLayout(format string) (string, error) {
+	var separator string
+	for _, candidate := range []string{"-", "/", ".", " "} {
+		if strings.Contains(format, candidate) {
+			separator = candidate
+			break
+		}
+	}
+
+	parts := strings.Split(format, separator)
+	if separator == "" || len(parts) != 3 {
+		return "", fmt.Errorf("invalid date format %q (use YYYY, MM and DD separated by -, /, . or a space)", format)
+	}
+
+	seen := make(map[string]bool)
+	layout := make([]string, len(parts))
+	for i, part := range parts {
+		element, ok := dateTokens[part]
+		if !ok || seen[part] {
+			return "", fmt.Errorf("invalid date format %q (use YYYY, MM and DD separated by -, /, . or a space)", format)
+		}
+		seen[part] = true
+		layout[i] = element
+	}
+	return strings.Join(layout, separator), nil
+}
+
+// TimeLayout converts a time_format setting ("12h" or "24h") into a Go layout
+func TimeLayout(format string) (string, error) {
+	switch format {
+	case "24h":
+		return "15:04", nil
+	case "12h":
+		return "3:04 PM", nil
+	}
+	return "", fmt.Errorf("invalid time format %q (use 12h or 24h)", format)
+}
+
+// LoadTimezone returns the location of an IANA timezone name such as "Europe/Berlin"
+func LoadTimezone(name string) (*time.Location, bool) {
+	if name == "" || name == "Local" {
+		return nil, false
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, false
+	}
+	return location, true
+}
+
+// Timezone returns the timezone of the request (see LocaleMiddleware), or the
+// timezone setting
+func Timezone(ctx context.Context) *time.Location {
+	if ctx != nil {
+		if state, ok := ctx.Value(localeKey{}).(*localeState); ok {
+			if location := state.get().timezone; location != nil {
+				return location
+			}
+		}
+	}
+	return GetDateDefaults().Timezone
+}
+
+// InTimezone returns t in the timezone of the request
+func InTimezone(ctx context.Context, t time.Time) time.Time {
+	return t.In(Timezone(ctx))
+}
+
+// FormatTimestamp formats t as RFC3339 in the timezone of the request. API timestamps
+// stay machine-readable; only their offset follows the user's timezone.
+func FormatTimestamp(ctx context.Context, t time.Time) string {
+	return InTimezone(ctx, t).Format(time.RFC3339)
+}
+
+// FormatDate formats t with the date_format setting in the timezone of the request
+func FormatDate(ctx context.Context, t time.Time) string {
+	return InTimezone(ctx, t).Format(GetDateDefaults().DateLayout)
+}
+
+// FormatTime formats t with the time_format setting in the timezone of the request
+func FormatTime(ctx context.Context, t time.Time) string {
+	return InTimezone(ctx, t).Format(GetDateDefaults().TimeLayout)
+}
+
+// FormatDateTime formats t as a human-readable date and time, e.g. "16.10.2026 14:30"
+func FormatDateTime(ctx context.Context, t time.Time) string {
+	defaults := GetDateDefaults()
+	return InTimezone(ctx, t).Format(defaults.DateLayout + " " + defaults.TimeLayout)
+}
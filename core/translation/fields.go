@@ -0,0 +1,303 @@
+package translation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+// FieldValues holds the translated values of a record: field -> locale -> value.
+// It is also the format of the "translations" object in requests and responses.
+type FieldValues map[string]map[string]string
+
+// Resolve returns the value of a field in the locale, trying the base language ("pt" for
+// "pt-BR") and then the default locale. It reports false when none is translated, in
+// which case the value stored on the record itself should be used.
+func (v FieldValues) Resolve(field, locale string) (string, bool) {
+	values := v[field]
+	if len(values) == 0 {
+		return "", false
+	}
+	for _, candidate := range []string{locale, baseLanguage(locale), DefaultLocale()} {
+		if value, ok := values[candidate]; ok && value != "" {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// FieldStore stores translated field values in the translations table. A row holds one
+// field of one record in one locale: Model is the entity (e.g. "settings"), ModelId the
+// record id, Key the field (e.g. "label") and Language the locale. The value stored on the
+// record itself is the text in the default locale.
+type FieldStore struct {
+	mu sync.RWMutex
+	db *gorm.DB
+}
+
+// Fields is the store used by Localize and the Field type
+var Fields = &FieldStore{}
+
+// SetDB sets the database the store reads and writes
+func (s *FieldStore) SetDB(db *gorm.DB) {
+	s.mu.Lock()
+	s.db = db
+	s.mu.Unlock()
+}
+
+// WithDB returns a store using db, e.g. a transaction
+func (s *FieldStore) WithDB(db *gorm.DB) *FieldStore {
+	return &FieldStore{db: db}
+}
+
+// conn returns the database of the store
+func (s *FieldStore) conn() (*gorm.DB, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.db == nil {
+		return nil, errors.New("translation: field store has no database")
+	}
+	return s.db, nil
+}
+
+// Load returns the translated values of the records with the given ids, by id
+func (s *FieldStore) Load(entity string, ids []uint) (map[uint]FieldValues, error) {
+	result := make(map[uint]FieldValues, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	db, err := s.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []Translation
+	if err := db.Where("model = ? AND model_id IN ?", entity, ids).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		values := result[row.ModelId]
+		if values == nil {
+			values = make(FieldValues)
+			result[row.ModelId] = values
+		}
+		if values[row.Key] == nil {
+			values[row.Key] = make(map[string]string)
+		}
+		values[row.Key][NormalizeLocale(row.Language)] = row.Value
+	}
+	return result, nil
+}
+
+// Get returns the translated values of a record
+func (s *FieldStore) Get(entity string, id uint) (FieldValues, error) {
+	values, err := s.Load(entity, []uint{id})
+	if err != nil {
+		return nil, err
+	}
+	if values[id] == nil {
+		return FieldValues{}, nil
+	}
+	return values[id], nil
+}
+
+// Set stores translated values of a record. Only the given fields and locales are
+// changed; an empty value removes that translation.
+func (s *FieldStore) Set(entity string, id uint, values FieldValues) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	db, err := s.conn()
+	if err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for field, locales := range values {
+			for locale, value := range locales {
+				locale = NormalizeLocale(locale)
+				query := tx.Where("model = ? AND model_id = ? AND `key` = ? AND language = ?", entity, id, field, locale)
+
+				if value == "" {
+					if err := query.Delete(&Translation{}).Error; err != nil {
+						return err
+					}
+					continue
+				}
+
+				var row Translation
+				err := query.First(&row).Error
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					row = Translation{Model: entity, ModelId: id, Key: field, Language: locale, Value: value}
+					if err := tx.Create(&row).Error; err != nil {
+						return err
+					}
+					continue
+				}
+				if err != nil {
+					return err
+				}
+
+				row.Value = value
+				if err := tx.Save(&row).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Delete removes all translated values of a record
+func (s *FieldStore) Delete(entity string, id uint) error {
+	db, err := s.conn()
+	if err != nil {
+		return err
+	}
+	return db.Where("model = ? AND model_id = ?", entity, id).Delete(&Translation{}).Error
+}
+
+// Copy gives a record the translated values of another record of the entity, e.g. the
+// record it is a copy of
+func (s *FieldStore) Copy(entity string, from, to uint) error {
+	values, err := s.Get(entity, from)
+	if err != nil {
+		return err
+	}
+	return s.Set(entity, to, values)
+}
+
+// CopyFields copies the translated values of a record to its copy in tx; it is the
+// translations copier of crud.Copy
+func CopyFields(tx *gorm.DB, entity string, from, to uint) error {
+	return Fields.WithDB(tx).Copy(entity, from, to)
+}
+
+// localePattern matches normalized locales such as "en" and "pt-BR"
+var localePattern = regexp.MustCompile(`^[a-z]{2}(-[A-Z]{2})?$`)
+
+// ValidateFieldValues checks that translations only target the allowed fields and use
+// valid locales. It returns validator.ValidationErrors.
+func ValidateFieldValues(values FieldValues, allowed []string) error {
+	var errs validator.ValidationErrors
+	for field, locales := range values {
+		if !slices.Contains(allowed, field) {
+			errs = append(errs, validator.ValidationError{
+				Field:   "translations." + field,
+				Tag:     "oneof",
+				Value:   field,
+				Param:   strings.Join(allowed, " "),
+				Message: fmt.Sprintf("translations.%s must be one of: %s", field, strings.Join(allowed, " ")),
+			})
+			continue
+		}
+		for locale := range locales {
+			if !localePattern.MatchString(NormalizeLocale(locale)) {
+				errs = append(errs, validator.ValidationError{
+					Field:   "translations." + field,
+					Tag:     "locale",
+					Value:   locale,
+					Message: fmt.Sprintf("translations.%s has an invalid locale %q (use e.g. en or pt-BR)", field, locale),
+				})
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Localize replaces the translatable fields of API responses with their value in the
+// request locale. items is a response (pointer to struct) or a slice of them; each must
+// have an Id field, and fields are matched by their JSON name. Responses with a
+// Translations field of type FieldValues also get all their translated values, for
+// editing. Records without a translation keep the stored (default locale) value.
+func Localize(ctx context.Context, entity string, fields []string, items any) error {
+	responses := collectResponses(reflect.ValueOf(items))
+	if len(responses) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, 0, len(responses))
+	for _, response := range responses {
+		ids = append(ids, uint(response.FieldByName("Id").Uint()))
+	}
+
+	// Without the translation module there is nothing to load
+	if _, err := Fields.conn(); err != nil {
+		return nil
+	}
+	values, err := Fields.Load(entity, ids)
+	if err != nil {
+		return err
+	}
+
+	locale := Locale(ctx)
+	for _, response := range responses {
+		recordValues := values[uint(response.FieldByName("Id").Uint())]
+		if translations := response.FieldByName("Translations"); translations.IsValid() && translations.Type() == reflect.TypeOf(FieldValues{}) {
+			translations.Set(reflect.ValueOf(recordValues))
+		}
+		for _, field := range fields {
+			value, ok := recordValues.Resolve(field, locale)
+			if !ok {
+				continue
+			}
+			if target := fieldByJSONName(response, field); target.IsValid() && target.Kind() == reflect.String {
+				target.SetString(value)
+			}
+		}
+	}
+	return nil
+}
+
+// collectResponses returns the settable response structs with an Id field
+func collectResponses(value reflect.Value) []reflect.Value {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if value.IsNil() {
+			return nil
+		}
+		return collectResponses(value.Elem())
+	case reflect.Slice, reflect.Array:
+		var responses []reflect.Value
+		for i := 0; i < value.Len(); i++ {
+			responses = append(responses, collectResponses(value.Index(i))...)
+		}
+		return responses
+	case reflect.Struct:
+		if !value.CanSet() {
+			return nil
+		}
+		if id := value.FieldByName("Id"); !id.IsValid() || id.Kind() != reflect.Uint {
+			return nil
+		}
+		return []reflect.Value{value}
+	}
+	return nil
+}
+
+// fieldByJSONName returns the struct field with the given JSON name
+func fieldByJSONName(value reflect.Value, name string) reflect.Value {
+	valueType := value.Type()
+	for i := 0; i < valueType.NumField(); i++ {
+		tag, _, _ := strings.Cut(valueType.Field(i).Tag.Get("json"), ",")
+		if tag == name {
+			return value.Field(i)
+		}
+	}
+	return reflect.Value{}
+}
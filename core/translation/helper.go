@@ -1,6 +1,7 @@
 package translation
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 
@@ -34,13 +35,13 @@ func GetTranslatedFields(model interface{}) []string {
 }
 
 // GetTranslationsForModel retrieves all translations for a model instance
-func (h *Helper) GetTranslationsForModel(modelName string, modelId uint, language string) (map[string]string, error) {
-	return h.Service.GetTranslationsForModel(modelName, modelId, language)
+func (h *Helper) GetTranslationsForModel(ctx context.Context, modelName string, modelId uint, language string) (map[string]string, error) {
+	return h.Service.GetTranslationsForModel(ctx, modelName, modelId, language)
 }
 
 // AddTranslatedFieldsToResponse enriches a response struct with translated fields
-func (h *Helper) AddTranslatedFieldsToResponse(response any, modelName string, modelId uint, language string) error {
-	translations, err := h.GetTranslationsForModel(modelName, modelId, language)
+func (h *Helper) AddTranslatedFieldsToResponse(ctx context.Context, response any, modelName string, modelId uint, language string) error {
+	translations, err := h.GetTranslationsForModel(ctx, modelName, modelId, language)
 	if err != nil {
 		return err
 	}
@@ -74,8 +75,8 @@ func (h *Helper) AddTranslatedFieldsToResponse(response any, modelName string, m
 }
 
 // SetTranslation sets or updates a translation for a model field
-func (h *Helper) SetTranslation(modelName string, modelId uint, key, value, language string) error {
-	return h.Service.BulkSetTranslations(modelName, modelId, language, map[string]string{key: value})
+func (h *Helper) SetTranslation(ctx context.Context, modelName string, modelId uint, key, value, language string) error {
+	return h.Service.BulkSetTranslations(ctx, modelName, modelId, language, map[string]string{key: value})
 }
 
 // DeleteTranslationsForModel deletes all translations for a specific model instance
@@ -85,11 +86,11 @@ func (h *Helper) DeleteTranslationsForModel(modelName string, modelId uint) erro
 }
 
 // GetAvailableLanguages returns all languages that have translations for a specific model instance
-func (h *Helper) GetAvailableLanguages(modelName string, modelId uint) ([]string, error) {
-	return h.Service.GetSupportedLanguages()
+func (h *Helper) GetAvailableLanguages(ctx context.Context, modelName string, modelId uint) ([]string, error) {
+	return h.Service.GetSupportedLanguages(ctx)
 }
 
 // BulkSetTranslations sets multiple translations for a model instance in a single transaction
-func (h *Helper) BulkSetTranslations(modelName string, modelId uint, language string, translations map[string]string) error {
-	return h.Service.BulkSetTranslations(modelName, modelId, language, translations)
+func (h *Helper) BulkSetTranslations(ctx context.Context, modelName string, modelId uint, language string, translations map[string]string) error {
+	return h.Service.BulkSetTranslations(ctx, modelName, modelId, language, translations)
 }
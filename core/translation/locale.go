@@ -0,0 +1,294 @@
+package translation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"base/core/router"
+)
+
+// defaultLocale is used when a request doesn't ask for a supported locale
+var defaultLocale atomic.Value
+
+// DefaultLocale returns the locale used when none can be resolved for a request
+func DefaultLocale() string {
+	if locale, ok := defaultLocale.Load().(string); ok && locale != "" {
+		return locale
+	}
+	return "en"
+}
+
+// SetDefaultLocale changes the default locale
+func SetDefaultLocale(locale string) {
+	defaultLocale.Store(NormalizeLocale(locale))
+}
+
+// NormalizeLocale returns a locale in the "pt-BR" form
+func NormalizeLocale(locale string) string {
+	locale = strings.TrimSpace(strings.ReplaceAll(locale, "_", "-"))
+	lang, region, found := strings.Cut(locale, "-")
+	if !found {
+		return strings.ToLower(lang)
+	}
+	return strings.ToLower(lang) + "-" + strings.ToUpper(region)
+}
+
+// baseLanguage returns "pt" for "pt-BR"
+func baseLanguage(locale string) string {
+	lang, _, _ := strings.Cut(locale, "-")
+	return lang
+}
+
+// ParseAcceptLanguage returns the locales of an Accept-Language header, most preferred first
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		locale string
+		q      float64
+	}
+
+	var entries []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil || parsed <= 0 {
+				continue
+			}
+			q = parsed
+		}
+		entries = append(entries, weighted{NormalizeLocale(tag), q})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].q > entries[j].q })
+
+	locales := make([]string, len(entries))
+	for i, entry := range entries {
+		locales[i] = entry.locale
+	}
+	return locales
+}
+
+// Negotiate returns the first requested locale that is supported. "de-AT" matches a
+// supported "de", and "pt" matches a supported "pt-BR".
+func Negotiate(requested []string, supported []string) (string, bool) {
+	for _, locale := range requested {
+		if slices.Contains(supported, locale) {
+			return locale, true
+		}
+		base := baseLanguage(locale)
+		if slices.Contains(supported, base) {
+			return base, true
+		}
+		for _, candidate := range supported {
+			if baseLanguage(candidate) == base {
+				return candidate, true
+			}
+		}
+	}
+	return "", false
+}
+
+// localeKey stores the request preferences in the context
+type localeKey struct{}
+
+// requestPreferences are the locale and timezone of a request
+type requestPreferences struct {
+	locale   string
+	timezone *time.Location // nil for the timezone setting
+}
+
+// localeState resolves the request preferences once, when they are first needed
+type localeState struct {
+	once        sync.Once
+	resolve     func() requestPreferences
+	preferences requestPreferences
+}
+
+func (s *localeState) get() requestPreferences {
+	s.once.Do(func() { s.preferences = s.resolve() })
+	return s.preferences
+}
+
+// WithLocale returns a context carrying the locale
+func WithLocale(ctx context.Context, locale string) context.Context {
+	preferences := requestPreferences{locale: locale}
+	if state, ok := ctx.Value(localeKey{}).(*localeState); ok {
+		preferences.timezone = state.get().timezone
+	}
+	return context.WithValue(ctx, localeKey{}, &localeState{resolve: func() requestPreferences { return preferences }})
+}
+
+// WithTimezone returns a context carrying the timezone, e.g. for background jobs that
+// format dates for a user
+func WithTimezone(ctx context.Context, location *time.Location) context.Context {
+	preferences := requestPreferences{locale: Locale(ctx), timezone: location}
+	return context.WithValue(ctx, localeKey{}, &localeState{resolve: func() requestPreferences { return preferences }})
+}
+
+// Locale returns the locale of the request, or the default locale
+func Locale(ctx context.Context) string {
+	if ctx != nil {
+		if state, ok := ctx.Value(localeKey{}).(*localeState); ok {
+			return state.get().locale
+		}
+	}
+	return DefaultLocale()
+}
+
+// Preferences are the stored preferences of a user
+type Preferences struct {
+	Locale   string
+	Timezone string // IANA name, e.g. "Europe/Berlin"
+}
+
+// TimezoneHeader lets clients send the timezone of the device, e.g. "Europe/Berlin"
+const TimezoneHeader = "X-Timezone"
+
+// LocaleConfig contains the locale middleware configuration
+type LocaleConfig struct {
+	// Supported lists the locales the API responds in; the default locale (see
+	// SetDefaultLocale) is always supported
+	Supported []string
+
+	// UserPreferences returns the preferences of the authenticated user, if any
+	UserPreferences func(*router.Context) Preferences
+}
+
+// LocaleMiddleware resolves the request locale from the Accept-Language header, the
+// user's preference and the default locale, in that order, and the timezone from the
+// X-Timezone header, the user's preference and the timezone setting. Handlers read them
+// with Locale(c) and Timezone(c) and translate with T(c, key). It runs before
+// authentication, so both are resolved when first used. JSON error responses have their
+// "error" message translated and every response carries Content-Language.
+func LocaleMiddleware(config LocaleConfig) router.MiddlewareFunc {
+	supported := make([]string, len(config.Supported))
+	for i, locale := range config.Supported {
+		supported[i] = NormalizeLocale(locale)
+	}
+
+	return func(next router.HandlerFunc) router.HandlerFunc {
+		return func(c *router.Context) error {
+			state := &localeState{}
+			state.resolve = func() requestPreferences {
+				var user Preferences
+				if config.UserPreferences != nil {
+					user = config.UserPreferences(c)
+				}
+				return requestPreferences{
+					locale:   resolveLocale(c.GetHeader("Accept-Language"), user.Locale, supported),
+					timezone: resolveTimezone(c.GetHeader(TimezoneHeader), user.Timezone),
+				}
+			}
+
+			c.WithContext(context.WithValue(c.Context(), localeKey{}, state))
+			writer := &localeWriter{ResponseWriter: c.Writer, state: state}
+			c.Writer = writer
+
+			err := next(c)
+			writer.flush()
+			return err
+		}
+	}
+}
+
+// resolveLocale picks the locale from the Accept-Language header, the user's preferred
+// locale and the default locale
+func resolveLocale(header, preferred string, supported []string) string {
+	fallback := DefaultLocale()
+	available := append(slices.Clone(supported), fallback)
+
+	if header != "" {
+		if locale, ok := Negotiate(ParseAcceptLanguage(header), available); ok {
+			return locale
+		}
+	}
+	if preferred != "" {
+		if locale, ok := Negotiate([]string{NormalizeLocale(preferred)}, available); ok {
+			return locale
+		}
+	}
+	return fallback
+}
+
+// resolveTimezone picks the timezone from the X-Timezone header and the user's preferred
+// timezone; nil means the timezone setting
+func resolveTimezone(header, preferred string) *time.Location {
+	for _, name := range []string{strings.TrimSpace(header), preferred} {
+		if location, ok := LoadTimezone(name); ok {
+			return location
+		}
+	}
+	return nil
+}
+
+// localeWriter adds Content-Language and translates the "error" field of JSON error responses
+type localeWriter struct {
+	router.ResponseWriter
+	state     *localeState
+	buffering bool
+	status    int
+	body      bytes.Buffer
+}
+
+// WriteHeader sets Content-Language and starts buffering JSON error responses
+func (w *localeWriter) WriteHeader(code int) {
+	if w.Written() || w.buffering {
+		return
+	}
+	w.Header().Set("Content-Language", w.state.get().locale)
+	if code >= 400 && strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		w.buffering = true
+		w.status = code
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write buffers error responses and writes everything else directly
+func (w *localeWriter) Write(data []byte) (int, error) {
+	if !w.Written() && !w.buffering {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.buffering {
+		return w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// flush writes a buffered error response with its message translated
+func (w *localeWriter) flush() {
+	if !w.buffering {
+		return
+	}
+	w.buffering = false
+
+	body := w.body.Bytes()
+	var payload map[string]any
+	if err := json.Unmarshal(body, &payload); err == nil {
+		if message, ok := payload["error"].(string); ok {
+			if translated := translateMessage(w.state.get().locale, message); translated != message {
+				payload["error"] = translated
+				if encoded, err := json.Marshal(payload); err == nil {
+					body = append(encoded, '\n')
+				}
+			}
+		}
+	}
+
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(body)
+}
@@ -0,0 +1,287 @@
+package translation
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"base/core/validator"
+
+	"gorm.io/gorm"
+)
+
+// MessagesModel is the Translation.Model value of UI and API messages. Rows with this
+// model (and ModelId 0) override the built-in messages: Key is the message key and
+// Value the translated text.
+const MessagesModel = "messages"
+
+// defaultMessages are the built-in English messages. Message keys that aren't found in
+// any catalog are returned as they are, so plain English text works as a key too.
+var defaultMessages = map[string]string{
+	"validation.required":      "%s is required",
+	"validation.email":         "%s must be a valid email address",
+	"validation.min":           "%s must be at least %s characters long",
+	"validation.max":           "%s must be at most %s characters long",
+	"validation.len":           "%s must be exactly %s characters long",
+	"validation.numeric":       "%s must be a number",
+	"validation.alpha":         "%s must contain only letters",
+	"validation.alphanum":      "%s must contain only letters and numbers",
+	"validation.url":           "%s must be a valid URL",
+	"validation.uuid":          "%s must be a valid UUID",
+	"validation.gte":           "%s must be greater than or equal to %s",
+	"validation.lte":           "%s must be less than or equal to %s",
+	"validation.gt":            "%s must be greater than %s",
+	"validation.lt":            "%s must be less than %s",
+	"validation.oneof":         "%s must be one of: %s",
+	"validation.locale":        "%s must use locales such as en or pt-BR",
+	"validation.timezone":      "%s must be an IANA timezone such as Europe/Berlin",
+	"validation.unique":        "%s is already taken",
+	"validation.range":         "%s must be in the range %s",
+	"validation.slug":          "%s must contain only lowercase letters, digits, '.', '_' or '-'",
+	"validation.cron":          "%s must be a cron expression such as 0 8 * * 1",
+	"validation.exists":        "%s does not exist",
+	"validation.max_size":      "%s must be at most %s",
+	"validation.excluded_with": "%s can't be set together with %s",
+	"validation.required_with": "%s is required with %s",
+	"validation.readonly":      "%s can't be changed here, use %s",
+	"validation.workflow":      "%s can only be %s",
+	"validation.invalid":       "%s is invalid",
+}
+
+// Catalog holds the translated messages per locale. Messages are loaded from the
+// translations table on first use and again after Invalidate.
+type Catalog struct {
+	mu       sync.RWMutex
+	db       *gorm.DB
+	loaded   bool
+	messages map[string]map[string]string // locale -> key -> message
+
+	missingMu sync.Mutex
+	missing   map[string]map[string]int // locale -> key -> lookups without a translation
+}
+
+// maxMissingKeys bounds the keys recorded per locale by RecordMissing
+const maxMissingKeys = 1000
+
+// Messages is the catalog used by T and the locale middleware
+var Messages = &Catalog{}
+
+// SetDB sets the database the catalog loads messages from
+func (c *Catalog) SetDB(db *gorm.DB) {
+	c.mu.Lock()
+	c.db = db
+	c.loaded = false
+	c.mu.Unlock()
+}
+
+// Invalidate makes the catalog reload messages on next use
+func (c *Catalog) Invalidate() {
+	c.mu.Lock()
+	c.loaded = false
+	c.mu.Unlock()
+}
+
+// load reads the message translations if they haven't been loaded yet
+func (c *Catalog) load() {
+	c.mu.RLock()
+	loaded, db := c.loaded, c.db
+	c.mu.RUnlock()
+	if loaded || db == nil {
+		return
+	}
+
+	var rows []Translation
+	if err := db.Where("model = ?", MessagesModel).Find(&rows).Error; err != nil {
+		// The table may not exist yet; keep the built-in messages and retry later
+		return
+	}
+
+	messages := make(map[string]map[string]string)
+	for _, row := range rows {
+		locale := NormalizeLocale(row.Language)
+		if messages[locale] == nil {
+			messages[locale] = make(map[string]string)
+		}
+		messages[locale][row.Key] = row.Value
+	}
+
+	c.mu.Lock()
+	c.messages = messages
+	c.loaded = true
+	c.mu.Unlock()
+}
+
+// Lookup returns the message for a key in the locale, trying the base language ("pt"
+// for "pt-BR") and then the default locale
+func (c *Catalog) Lookup(locale, key string) (string, bool) {
+	c.load()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, candidate := range []string{locale, baseLanguage(locale), DefaultLocale()} {
+		if message, ok := c.messages[candidate][key]; ok && message != "" {
+			return message, true
+		}
+	}
+	if message, ok := defaultMessages[key]; ok {
+		return message, true
+	}
+	return "", false
+}
+
+// Translate returns the message for key in the given locale, formatted with args.
+// Unknown keys are returned as they are (formatted with args).
+func (c *Catalog) Translate(locale, key string, args ...any) string {
+	message, ok := c.Lookup(locale, key)
+	if !ok {
+		message = key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// Has reports whether the locale itself (or its base language) has a message for key,
+// without falling back to the default locale or the built-in messages
+func (c *Catalog) Has(locale, key string) bool {
+	c.load()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, candidate := range []string{locale, baseLanguage(locale)} {
+		if message, ok := c.messages[candidate][key]; ok && message != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Keys returns the message keys of the locale, or of all locales and the built-in
+// messages when locale is empty
+func (c *Catalog) Keys(locale string) []string {
+	c.load()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	if locale == "" {
+		for key := range defaultMessages {
+			seen[key] = true
+		}
+		for _, messages := range c.messages {
+			for key := range messages {
+				seen[key] = true
+			}
+		}
+	} else {
+		for key := range c.messages[locale] {
+			seen[key] = true
+		}
+	}
+
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// RecordMissing counts a lookup of key that had no translation in the locale
+func (c *Catalog) RecordMissing(locale, key string) {
+	c.missingMu.Lock()
+	defer c.missingMu.Unlock()
+
+	if c.missing == nil {
+		c.missing = make(map[string]map[string]int)
+	}
+	keys := c.missing[locale]
+	if keys == nil {
+		keys = make(map[string]int)
+		c.missing[locale] = keys
+	}
+	if _, ok := keys[key]; !ok && len(keys) >= maxMissingKeys {
+		return
+	}
+	keys[key]++
+}
+
+// Missing returns the keys looked up without a translation in the locale since startup,
+// with their lookup counts
+func (c *Catalog) Missing(locale string) map[string]int {
+	c.missingMu.Lock()
+	defer c.missingMu.Unlock()
+
+	result := make(map[string]int, len(c.missing[locale]))
+	for key, count := range c.missing[locale] {
+		if !c.Has(locale, key) {
+			result[key] = count
+		}
+	}
+	return result
+}
+
+// T translates a message key into the locale of the request (see LocaleMiddleware).
+// Keys without a translation in that locale are reported by the missing keys endpoint.
+func T(ctx context.Context, key string, args ...any) string {
+	locale := Locale(ctx)
+	if locale != DefaultLocale() && !Messages.Has(locale, key) {
+		Messages.RecordMissing(locale, key)
+	}
+	return Messages.Translate(locale, key, args...)
+}
+
+// LocalizeValidation returns the validation errors with their messages translated into
+// the locale of the request
+func LocalizeValidation(ctx context.Context, errs validator.ValidationErrors) validator.ValidationErrors {
+	locale := Locale(ctx)
+	localized := make(validator.ValidationErrors, len(errs))
+	for i, e := range errs {
+		localized[i] = e
+		key := "validation." + e.Tag
+		if _, ok := Messages.Lookup(locale, key); !ok {
+			key = "validation.invalid"
+		}
+		if locale != DefaultLocale() && !Messages.Has(locale, key) {
+			Messages.RecordMissing(locale, key)
+		}
+		if e.Param != "" {
+			localized[i].Message = Messages.Translate(locale, key, e.Field, e.Param)
+		} else {
+			localized[i].Message = Messages.Translate(locale, key, e.Field)
+		}
+	}
+	return localized
+}
+
+// Error returns the error message translated into the locale of the request.
+// Validation errors are translated field by field.
+func Error(ctx context.Context, err error) string {
+	var validationErrors validator.ValidationErrors
+	if stderrors.As(err, &validationErrors) {
+		return LocalizeValidation(ctx, validationErrors).Error()
+	}
+	return translateMessage(Locale(ctx), err.Error())
+}
+
+// translateMessage translates a response message. Messages such as "Failed to update
+// item: record not found" are translated in parts when there is no translation for the
+// whole message.
+func translateMessage(locale, message string) string {
+	if translated, ok := Messages.Lookup(locale, message); ok {
+		return translated
+	}
+	if prefix, rest, found := strings.Cut(message, ": "); found {
+		if translated, ok := Messages.Lookup(locale, prefix); ok {
+			return translated + ": " + translateMessage(locale, rest)
+		}
+	}
+	return message
+}
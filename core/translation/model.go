@@ -174,10 +174,19 @@ func NewField(original string) Field {
 	}
 }
 
-// LoadTranslations loads translations from the database using the global translation service
+// LoadTranslations loads the translations of the field from the translations table (see Fields)
 func (f *Field) LoadTranslations(modelName string, modelId uint, fieldName string) error {
-	// This would need to be implemented with a global service instance
-	// For now, we'll implement this as a hook in the GORM callbacks
+	values, err := Fields.Get(modelName, modelId)
+	if err != nil {
+		return err
+	}
+
+	if f.Values == nil {
+		f.Values = make(map[string]string)
+	}
+	for language, value := range values[fieldName] {
+		f.Values[language] = value
+	}
 	return nil
 }
 
@@ -256,6 +265,36 @@ type BulkTranslationRequest struct {
 	Translations map[string]string `json:"translations" binding:"required"` // key -> value mapping
 }
 
+// LocaleMessagesResponse contains the UI and API messages of a locale
+type LocaleMessagesResponse struct {
+	Locale   string            `json:"locale"`
+	Messages map[string]string `json:"messages"` // key -> message
+}
+
+// MessagesResult reports the changes made by a messages update or import
+type MessagesResult struct {
+	Locale    string `json:"locale"`
+	Created   int    `json:"created"`
+	Updated   int    `json:"updated"`
+	Deleted   int    `json:"deleted"`
+	Unchanged int    `json:"unchanged"`
+}
+
+// MissingMessage is a message key without a translation in a locale
+type MissingMessage struct {
+	Key     string `json:"key"`
+	Default string `json:"default"` // message in the default locale
+	Lookups int    `json:"lookups"` // times it was requested in the locale since startup
+	Source  string `json:"source"`  // "catalog" (known key) or "runtime" (only seen in requests)
+}
+
+// MissingMessagesResponse lists the messages a locale doesn't translate
+type MissingMessagesResponse struct {
+	Locale  string           `json:"locale"`
+	Total   int              `json:"total"`
+	Missing []MissingMessage `json:"missing"`
+}
+
 // ToListResponse converts the model to a list response
 func (item *Translation) ToListResponse() *TranslationListResponse {
 	if item == nil {
@@ -23,6 +23,11 @@ func NewTranslationModule(db *gorm.DB, router *router.RouterGroup, log logger.Lo
 	service := NewTranslationService(db, emitter, storage, log)
 	controller := NewTranslationController(service, storage)
 
+	// Messages for T and the locale middleware are loaded from the translations table
+	Messages.SetDB(db)
+	// Translated field values (see Localize) are stored in the same table
+	Fields.SetDB(db)
+
 	m := &Module{
 		DB:         db,
 		Service:    service,
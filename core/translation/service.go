@@ -5,8 +5,10 @@ import (
 	"base/core/logger"
 	"base/core/storage"
 	"base/core/types"
+	"context"
 	"errors"
 	"fmt"
+	"sort"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -28,7 +30,7 @@ func NewTranslationService(db *gorm.DB, emitter *emitter.Emitter, storage *stora
 	}
 }
 
-func (s *TranslationService) GetAll(page *int, limit *int, model string, modelId *uint) (*types.PaginatedResponse, error) {
+func (s *TranslationService) GetAll(ctx context.Context, page *int, limit *int, model string, modelId *uint) (*types.PaginatedResponse, error) {
 	// Default values for pagination
 	currentPage := 1
 	pageSize := 10
@@ -44,7 +46,7 @@ func (s *TranslationService) GetAll(page *int, limit *int, model string, modelId
 	var total int64
 
 	// Build query with filters
-	query := s.DB.Model(&Translation{})
+	query := s.DB.WithContext(ctx).Model(&Translation{})
 	if model != "" {
 		s.Logger.Info("Filtering translations by model", zap.String("model", model))
 		query = query.Where("model = ?", model)
@@ -89,9 +91,9 @@ func (s *TranslationService) GetAll(page *int, limit *int, model string, modelId
 	}, nil
 }
 
-func (s *TranslationService) GetByID(id uint) (*TranslationResponse, error) {
+func (s *TranslationService) GetByID(ctx context.Context, id uint) (*TranslationResponse, error) {
 	var translation Translation
-	if err := s.DB.First(&translation, id).Error; err != nil {
+	if err := s.DB.WithContext(ctx).First(&translation, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("translation not found")
 		}
@@ -102,10 +104,10 @@ func (s *TranslationService) GetByID(id uint) (*TranslationResponse, error) {
 	return translation.ToResponse(), nil
 }
 
-func (s *TranslationService) Create(request *CreateTranslationRequest) (*TranslationResponse, error) {
+func (s *TranslationService) Create(ctx context.Context, request *CreateTranslationRequest) (*TranslationResponse, error) {
 	// Check if translation already exists for this key, model, model_id, and language
 	var existing Translation
-	err := s.DB.Where("`key` = ? AND model = ? AND model_id = ? AND language = ?",
+	err := s.DB.WithContext(ctx).Where("`key` = ? AND model = ? AND model_id = ? AND language = ?",
 		request.Key, request.Model, request.ModelId, request.Language).First(&existing).Error
 
 	if err == nil {
@@ -125,18 +127,19 @@ func (s *TranslationService) Create(request *CreateTranslationRequest) (*Transla
 		Language: request.Language,
 	}
 
-	if err := s.DB.Create(translation).Error; err != nil {
+	if err := s.DB.WithContext(ctx).Create(translation).Error; err != nil {
 		s.Logger.Error("Failed to create translation", zap.Error(err))
 		return nil, err
 	}
 
 	s.Logger.Info("Translation created successfully", zap.Uint("id", translation.Id))
+	Messages.Invalidate()
 	return translation.ToResponse(), nil
 }
 
-func (s *TranslationService) Update(request *UpdateTranslationRequest) (*TranslationResponse, error) {
+func (s *TranslationService) Update(ctx context.Context, request *UpdateTranslationRequest) (*TranslationResponse, error) {
 	var translation Translation
-	if err := s.DB.First(&translation, request.Id).Error; err != nil {
+	if err := s.DB.WithContext(ctx).First(&translation, request.Id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("translation not found")
 		}
@@ -161,18 +164,19 @@ func (s *TranslationService) Update(request *UpdateTranslationRequest) (*Transla
 		translation.Language = request.Language
 	}
 
-	if err := s.DB.Save(&translation).Error; err != nil {
+	if err := s.DB.WithContext(ctx).Save(&translation).Error; err != nil {
 		s.Logger.Error("Failed to update translation", zap.Error(err))
 		return nil, err
 	}
 
 	s.Logger.Info("Translation updated successfully", zap.Uint("id", translation.Id))
+	Messages.Invalidate()
 	return translation.ToResponse(), nil
 }
 
-func (s *TranslationService) Delete(id uint) error {
+func (s *TranslationService) Delete(ctx context.Context, id uint) error {
 	var translation Translation
-	if err := s.DB.First(&translation, id).Error; err != nil {
+	if err := s.DB.WithContext(ctx).First(&translation, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return errors.New("translation not found")
 		}
@@ -180,20 +184,21 @@ func (s *TranslationService) Delete(id uint) error {
 		return err
 	}
 
-	if err := s.DB.Delete(&translation).Error; err != nil {
+	if err := s.DB.WithContext(ctx).Delete(&translation).Error; err != nil {
 		s.Logger.Error("Failed to delete translation", zap.Error(err))
 		return err
 	}
 
 	s.Logger.Info("Translation deleted successfully", zap.Uint("id", id))
+	Messages.Invalidate()
 	return nil
 }
 
-func (s *TranslationService) GetTranslationsForModel(model string, modelId uint, language string) (map[string]string, error) {
+func (s *TranslationService) GetTranslationsForModel(ctx context.Context, model string, modelId uint, language string) (map[string]string, error) {
 	s.Logger.Info("Fetching translations for model", zap.String("model", model), zap.Uint("model_id", modelId), zap.String("language", language))
 
 	var translations []Translation
-	query := s.DB.Where("model = ? AND model_id = ?", model, modelId)
+	query := s.DB.WithContext(ctx).Where("model = ? AND model_id = ?", model, modelId)
 
 	if language != "" {
 		query = query.Where("language = ?", language)
@@ -216,14 +221,14 @@ func (s *TranslationService) GetTranslationsForModel(model string, modelId uint,
 }
 
 // BulkUpdate updates multiple translations for a model at once
-func (s *TranslationService) BulkUpdate(request *BulkTranslationRequest) error {
+func (s *TranslationService) BulkUpdate(ctx context.Context, request *BulkTranslationRequest) error {
 	s.Logger.Info("Starting bulk translation update",
 		zap.String("model", request.Model),
 		zap.Uint("model_id", request.ModelId),
 		zap.String("language", request.Language),
 		zap.Int("count", len(request.Translations)))
 
-	err := s.BulkSetTranslations(request.Model, request.ModelId, request.Language, request.Translations)
+	err := s.BulkSetTranslations(ctx, request.Model, request.ModelId, request.Language, request.Translations)
 	if err != nil {
 		s.Logger.Error("Failed to bulk update translations", zap.Error(err))
 		return err
@@ -234,8 +239,8 @@ func (s *TranslationService) BulkUpdate(request *BulkTranslationRequest) error {
 }
 
 // BulkSetTranslations sets multiple translations for a model instance in a single transaction
-func (s *TranslationService) BulkSetTranslations(modelName string, modelId uint, language string, translations map[string]string) error {
-	tx := s.DB.Begin()
+func (s *TranslationService) BulkSetTranslations(ctx context.Context, modelName string, modelId uint, language string, translations map[string]string) error {
+	tx := s.DB.WithContext(ctx).Begin()
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
@@ -275,24 +280,28 @@ func (s *TranslationService) BulkSetTranslations(modelName string, modelId uint,
 		}
 	}
 
-	return tx.Commit().Error
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+	Messages.Invalidate()
+	return nil
 }
 
 // GetSupportedLanguages returns a list of languages that have translations in the system
-func (s *TranslationService) GetSupportedLanguages() ([]string, error) {
+func (s *TranslationService) GetSupportedLanguages(ctx context.Context) ([]string, error) {
 	s.Logger.Info("Fetching supported languages")
 	var languages []string
-	if err := s.DB.Model(&Translation{}).Distinct("language").Pluck("language", &languages).Error; err != nil {
+	if err := s.DB.WithContext(ctx).Model(&Translation{}).Distinct("language").Pluck("language", &languages).Error; err != nil {
 		return nil, err
 	}
 	return languages, nil
 }
 
 // LoadTranslationsForField loads translations from the database for a specific field
-func (s *TranslationService) LoadTranslationsForField(field *Field, modelName string, modelId uint, fieldName string) error {
+func (s *TranslationService) LoadTranslationsForField(ctx context.Context, field *Field, modelName string, modelId uint, fieldName string) error {
 	// Query translations for this specific field
 	var translations []Translation
-	err := s.DB.Where("model = ? AND model_id = ? AND `key` = ?", modelName, modelId, fieldName).Find(&translations).Error
+	err := s.DB.WithContext(ctx).Where("model = ? AND model_id = ? AND `key` = ?", modelName, modelId, fieldName).Find(&translations).Error
 
 	if err != nil {
 		return err
@@ -310,3 +319,141 @@ func (s *TranslationService) LoadTranslationsForField(field *Field, modelName st
 
 	return nil
 }
+
+// GetMessages returns the UI and API messages of a locale (see MessagesModel)
+func (s *TranslationService) GetMessages(ctx context.Context, locale string) (map[string]string, error) {
+	var rows []Translation
+	if err := s.DB.WithContext(ctx).Where("model = ? AND model_id = 0 AND language = ?", MessagesModel, locale).Find(&rows).Error; err != nil {
+		s.Logger.Error("Failed to fetch messages", zap.String("locale", locale), zap.Error(err))
+		return nil, err
+	}
+
+	messages := make(map[string]string, len(rows))
+	for _, row := range rows {
+		messages[row.Key] = row.Value
+	}
+	return messages, nil
+}
+
+// SetMessages creates or updates messages of a locale. An empty value removes a message;
+// with replace set, messages that aren't in the request are removed too.
+func (s *TranslationService) SetMessages(ctx context.Context, locale string, messages map[string]string, replace bool) (*MessagesResult, error) {
+	result := &MessagesResult{Locale: locale}
+
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing []Translation
+		if err := tx.Where("model = ? AND model_id = 0 AND language = ?", MessagesModel, locale).Find(&existing).Error; err != nil {
+			return err
+		}
+
+		current := make(map[string]*Translation, len(existing))
+		for i := range existing {
+			current[existing[i].Key] = &existing[i]
+		}
+
+		for key, value := range messages {
+			row, found := current[key]
+			switch {
+			case value == "" && found:
+				if err := tx.Delete(row).Error; err != nil {
+					return err
+				}
+				result.Deleted++
+			case value == "":
+			case !found:
+				if err := tx.Create(&Translation{Model: MessagesModel, Language: locale, Key: key, Value: value}).Error; err != nil {
+					return err
+				}
+				result.Created++
+			case row.Value != value:
+				row.Value = value
+				if err := tx.Save(row).Error; err != nil {
+					return err
+				}
+				result.Updated++
+			default:
+				result.Unchanged++
+			}
+		}
+
+		if replace {
+			for key, row := range current {
+				if _, ok := messages[key]; ok {
+					continue
+				}
+				if err := tx.Delete(row).Error; err != nil {
+					return err
+				}
+				result.Deleted++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.Logger.Error("Failed to save messages", zap.String("locale", locale), zap.Error(err))
+		return nil, err
+	}
+
+	s.Logger.Info("Messages saved",
+		zap.String("locale", locale),
+		zap.Int("created", result.Created),
+		zap.Int("updated", result.Updated),
+		zap.Int("deleted", result.Deleted))
+	Messages.Invalidate()
+	return result, nil
+}
+
+// DeleteMessages removes all messages of a locale
+func (s *TranslationService) DeleteMessages(ctx context.Context, locale string) (int64, error) {
+	result := s.DB.WithContext(ctx).Where("model = ? AND model_id = 0 AND language = ?", MessagesModel, locale).Delete(&Translation{})
+	if result.Error != nil {
+		s.Logger.Error("Failed to delete messages", zap.String("locale", locale), zap.Error(result.Error))
+		return 0, result.Error
+	}
+	Messages.Invalidate()
+	return result.RowsAffected, nil
+}
+
+// MissingMessages reports the messages a locale doesn't translate: keys of the built-in
+// messages and of other locales that it lacks, and keys looked up at runtime (see T)
+// without a translation
+func (s *TranslationService) MissingMessages(locale string) *MissingMessagesResponse {
+	response := &MissingMessagesResponse{Locale: locale, Missing: []MissingMessage{}}
+	if locale == DefaultLocale() {
+		// The default locale falls back to the keys themselves
+		return response
+	}
+
+	requested := Messages.Missing(locale)
+	seen := make(map[string]bool)
+
+	for _, key := range Messages.Keys("") {
+		if Messages.Has(locale, key) {
+			continue
+		}
+		seen[key] = true
+		fallback, _ := Messages.Lookup(DefaultLocale(), key)
+		response.Missing = append(response.Missing, MissingMessage{
+			Key:     key,
+			Default: fallback,
+			Lookups: requested[key],
+			Source:  "catalog",
+		})
+	}
+
+	for key, count := range requested {
+		if seen[key] {
+			continue
+		}
+		response.Missing = append(response.Missing, MissingMessage{
+			Key:     key,
+			Default: key,
+			Lookups: count,
+			Source:  "runtime",
+		})
+	}
+
+	sort.Slice(response.Missing, func(i, j int) bool { return response.Missing[i].Key < response.Missing[j].Key })
+	response.Total = len(response.Missing)
+	return response
+}
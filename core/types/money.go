@@ -0,0 +1,88 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in the minor unit of its currency, e.g. cents for USD and yen for
+// JPY. Amounts are whole numbers so sums, discounts and taxes don't pick up the rounding
+// errors of float64; it is stored as a bigint and encoded in JSON as an integer.
+type Money int64
+
+// minorUnits lists the currencies whose minor unit isn't a hundredth (ISO 4217)
+var minorUnits = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// MinorUnits returns the number of decimals of a currency, 2 for most of them
+func MinorUnits(currency string) int {
+	if units, ok := minorUnits[strings.ToUpper(currency)]; ok {
+		return units
+	}
+	return 2
+}
+
+// FromMajor returns the amount of a currency given in major units, e.g. 12.34 USD as 1234,
+// rounded to the nearest minor unit
+func FromMajor(amount float64, currency string) Money {
+	return Money(math.Round(amount * math.Pow10(MinorUnits(currency))))
+}
+
+// ParseMoney parses an amount in major units such as "1234.56" or "1,234.56"
+func ParseMoney(value, currency string) (Money, error) {
+	value = strings.ReplaceAll(strings.TrimSpace(value), ",", "")
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsInf(amount, 0) || math.IsNaN(amount) {
+		return 0, fmt.Errorf("invalid amount: %q", value)
+	}
+	return FromMajor(amount, currency), nil
+}
+
+// Major returns the amount in major units of the currency, e.g. 1234 USD cents as 12.34.
+// Use it for display and for APIs that take decimals, not for calculations.
+func (m Money) Major(currency string) float64 {
+	return float64(m) / math.Pow10(MinorUnits(currency))
+}
+
+// Times returns the amount multiplied by a quantity
+func (m Money) Times(quantity int) Money {
+	return m * Money(quantity)
+}
+
+// Percent returns rate percent of the amount, rounded to the nearest minor unit
+func (m Money) Percent(rate float64) Money {
+	return Money(math.Round(float64(m) * rate / 100))
+}
+
+// Convert returns the amount in another currency at rate units of it per unit of the
+// amount's currency, rounded to the nearest minor unit of the target currency
+func (m Money) Convert(from, to string, rate float64) Money {
+	return FromMajor(m.Major(from)*rate, to)
+}
+
+// Format formats the amount in major units with thousands separators, e.g. 123456 USD
+// cents as "1,234.56" and 123456 JPY as "123,456"
+func (m Money) Format(currency string) string {
+	sign := ""
+	value := int64(m)
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+
+	decimals := MinorUnits(currency)
+	scale := int64(math.Pow10(decimals))
+	units := strconv.FormatInt(value/scale, 10)
+	for i := len(units) - 3; i > 0; i -= 3 {
+		units = units[:i] + "," + units[i:]
+	}
+	if decimals == 0 {
+		return sign + units
+	}
+	return fmt.Sprintf("%s%s.%0*d", sign, units, decimals, value%scale)
+}
@@ -20,6 +20,12 @@ type Pagination struct {
 	Page       int `json:"page"`
 	PageSize   int `json:"page_size"`
 	TotalPages int `json:"total_pages"`
+
+	// Set when the list isn't counted (count=none): whether there is a next page
+	HasMore *bool `json:"has_more,omitempty"`
+
+	// Total is a planner estimate or a recently cached count rather than an exact count
+	Estimated bool `json:"estimated,omitempty"`
 }
 
 // PaginatedResponse represents a paginated response
@@ -18,6 +18,7 @@ type ValidationError struct {
 	Field   string `json:"field"`
 	Tag     string `json:"tag"`
 	Value   string `json:"value"`
+	Param   string `json:"param,omitempty"`
 	Message string `json:"message"`
 }
 
@@ -68,6 +69,7 @@ func (v *Validator) Validate(data interface{}) ValidationErrors {
 				Field:   err.Field(),
 				Tag:     err.Tag(),
 				Value:   fmt.Sprintf("%v", err.Value()),
+				Param:   err.Param(),
 				Message: v.getErrorMessage(err),
 			})
 		}
@@ -90,6 +92,7 @@ func (v *Validator) ValidateVar(field interface{}, tag string) ValidationErrors
 				Field:   err.Field(),
 				Tag:     err.Tag(),
 				Value:   fmt.Sprintf("%v", err.Value()),
+				Param:   err.Param(),
 				Message: v.getErrorMessage(err),
 			})
 		}
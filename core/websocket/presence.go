@@ -0,0 +1,324 @@
+package websocket
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"base/core/router"
+)
+
+// Presence events, emitted with a *PresenceChange when a user opens their first connection
+// to a room and when they close their last one
+const (
+	PresenceJoinEvent  = "presence.join"
+	PresenceLeaveEvent = "presence.leave"
+)
+
+// PresenceRoom is the room of the clients following the presence changes of every room,
+// e.g. for a list of online colleagues
+const PresenceRoom = "presence"
+
+const (
+	// presenceHeartbeat is how often an instance renews the sessions of its connections
+	presenceHeartbeat = 30 * time.Second
+	// presenceTTL is how long a session is kept without a heartbeat, e.g. after its instance
+	// crashed
+	presenceTTL = 3 * presenceHeartbeat
+)
+
+// Session is a connection of a signed-in user to a room
+type Session struct {
+	Id          string    `json:"id"`
+	UserId      uint      `json:"user_id"`
+	Name        string    `json:"name"`
+	Room        string    `json:"room"`
+	Instance    string    `json:"instance"` // Host and process the connection is open on
+	ConnectedAt time.Time `json:"connected_at"`
+	SeenAt      time.Time `json:"seen_at"`
+}
+
+// PresenceStore keeps the sessions of the hubs. The default store keeps those of one
+// instance in memory; clustered deployments share a store, e.g. backed by Redis, so every
+// instance lists the users connected to the others.
+type PresenceStore interface {
+	// Add saves a new session
+	Add(ctx context.Context, session *Session) error
+	// Remove deletes a session
+	Remove(ctx context.Context, id string) error
+	// Touch renews sessions, marking them seen at a time
+	Touch(ctx context.Context, ids []string, at time.Time) error
+	// List returns the sessions seen since a time
+	List(ctx context.Context, since time.Time) ([]*Session, error)
+}
+
+// MemoryPresenceStore keeps sessions in memory
+type MemoryPresenceStore struct {
+	mu       sync.Mutex
+	sessions map[string]*Session
+}
+
+func NewMemoryPresenceStore() *MemoryPresenceStore {
+	return &MemoryPresenceStore{
+		sessions: make(map[string]*Session),
+	}
+}
+
+func (s *MemoryPresenceStore) Add(_ context.Context, session *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored := *session
+	s.sessions[session.Id] = &stored
+	return nil
+}
+
+func (s *MemoryPresenceStore) Remove(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+	return nil
+}
+
+func (s *MemoryPresenceStore) Touch(_ context.Context, ids []string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		if session, ok := s.sessions[id]; ok {
+			session.SeenAt = at
+		}
+	}
+	return nil
+}
+
+func (s *MemoryPresenceStore) List(_ context.Context, since time.Time) ([]*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sessions := make([]*Session, 0, len(s.sessions))
+	for id, session := range s.sessions {
+		if session.SeenAt.Before(since) {
+			delete(s.sessions, id)
+			continue
+		}
+		listed := *session
+		sessions = append(sessions, &listed)
+	}
+	return sessions, nil
+}
+
+// PresenceChange is a user joining or leaving a room
+type PresenceChange struct {
+	UserId uint   `json:"user_id"`
+	Name   string `json:"name"`
+	Room   string `json:"room"`
+}
+
+// OnlineUser is a user with open connections and the rooms they are in, e.g. pages:3 for
+// the viewers of a record
+type OnlineUser struct {
+	UserId      uint      `json:"user_id"`
+	Name        string    `json:"name"`
+	Rooms       []string  `json:"rooms"`
+	ConnectedAt time.Time `json:"connected_at"` // Of their first open connection
+}
+
+// SetPresenceStore replaces the store of the sessions, e.g. with one shared by the
+// instances of a cluster. Call it before clients connect.
+func (h *Hub) SetPresenceStore(store PresenceStore) {
+	h.presence = store
+}
+
+// Online returns the signed-in users with open connections, to a room when it isn't empty,
+// sorted by name
+func (h *Hub) Online(ctx context.Context, room string) ([]*OnlineUser, error) {
+	sessions, err := h.presence.List(ctx, time.Now().Add(-presenceTTL))
+	if err != nil {
+		return nil, err
+	}
+
+	byUser := map[uint]*OnlineUser{}
+	users := []*OnlineUser{}
+	for _, session := range sessions {
+		if room != "" && session.Room != room {
+			continue
+		}
+		user, ok := byUser[session.UserId]
+		if !ok {
+			user = &OnlineUser{UserId: session.UserId, Name: session.Name, Rooms: []string{}, ConnectedAt: session.ConnectedAt}
+			byUser[session.UserId] = user
+			users = append(users, user)
+		}
+		if !slices.Contains(user.Rooms, session.Room) {
+			user.Rooms = append(user.Rooms, session.Room)
+		}
+		if session.ConnectedAt.Before(user.ConnectedAt) {
+			user.ConnectedAt = session.ConnectedAt
+		}
+	}
+	for _, user := range users {
+		sort.Strings(user.Rooms)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].Name != users[j].Name {
+			return users[i].Name < users[j].Name
+		}
+		return users[i].UserId < users[j].UserId
+	})
+	return users, nil
+}
+
+// join adds the session of a client and announces the user when it is their first in the
+// room. The caller holds the mutex.
+func (h *Hub) join(client *Client) {
+	if client.UserId == 0 {
+		return
+	}
+	ctx := context.Background()
+	first := !h.inRoom(ctx, client)
+
+	now := time.Now()
+	session := &Session{
+		Id:          client.session,
+		UserId:      client.UserId,
+		Name:        client.Nickname,
+		Room:        client.Room,
+		Instance:    h.instance,
+		ConnectedAt: now,
+		SeenAt:      now,
+	}
+	if err := h.presence.Add(ctx, session); err != nil {
+		fmt.Printf("Failed to add presence session: %v\n", err)
+		return
+	}
+	h.local[client.session] = true
+
+	if first {
+		h.announce(PresenceJoinEvent, client)
+	}
+}
+
+// leave removes the session of a client and announces the user when it was their last in
+// the room. The caller holds the mutex.
+func (h *Hub) leave(client *Client) {
+	if !h.local[client.session] {
+		return
+	}
+	delete(h.local, client.session)
+	ctx := context.Background()
+	if err := h.presence.Remove(ctx, client.session); err != nil {
+		fmt.Printf("Failed to remove presence session: %v\n", err)
+	}
+
+	if !h.inRoom(ctx, client) {
+		h.announce(PresenceLeaveEvent, client)
+	}
+}
+
+// inRoom reports whether the user of a client has another session in its room
+func (h *Hub) inRoom(ctx context.Context, client *Client) bool {
+	sessions, err := h.presence.List(ctx, time.Now().Add(-presenceTTL))
+	if err != nil {
+		fmt.Printf("Failed to list presence sessions: %v\n", err)
+		return false
+	}
+	for _, session := range sessions {
+		if session.UserId == client.UserId && session.Room == client.Room && session.Id != client.session {
+			return true
+		}
+	}
+	return false
+}
+
+// announce emits a presence event and sends it to the room of the client and to the
+// presence room. The caller holds the mutex.
+func (h *Hub) announce(event string, client *Client) {
+	change := &PresenceChange{UserId: client.UserId, Name: client.Nickname, Room: client.Room}
+	if h.emitter != nil {
+		h.emitter.Emit(event, change)
+	}
+
+	rooms := []string{client.Room}
+	if client.Room != PresenceRoom {
+		rooms = append(rooms, PresenceRoom)
+	}
+	for _, room := range rooms {
+		h.send(room, Message{Type: event, Content: change, Room: room, Nickname: "System"})
+	}
+}
+
+// send writes a message to the clients of a room on this instance. The caller holds the
+// mutex.
+func (h *Hub) send(room string, message Message) {
+	msgBytes, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+	for c := range h.rooms[room] {
+		select {
+		case c.Send <- msgBytes:
+		default:
+			close(c.Send)
+			delete(h.rooms[room], c)
+		}
+	}
+}
+
+// heartbeat renews the sessions of the connections of this instance
+func (h *Hub) heartbeat() {
+	h.mutex.Lock()
+	ids := make([]string, 0, len(h.local))
+	for id := range h.local {
+		ids = append(ids, id)
+	}
+	h.mutex.Unlock()
+
+	if len(ids) == 0 {
+		return
+	}
+	if err := h.presence.Touch(context.Background(), ids, time.Now()); err != nil {
+		fmt.Printf("Failed to renew presence sessions: %v\n", err)
+	}
+}
+
+// PresenceHandler returns the handler of GET /presence
+// @Summary Who is online
+// @Description Get the signed-in users with open WebSocket connections and the rooms they are in, e.g. pages:3 for the viewers of a record; with room, only the users in that room. Clients in the presence room (and in the room itself) get presence.join and presence.leave messages
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Tags Core/Websocket
+// @Produce json
+// @Param room query string false "Only the users in a room, e.g. pages:3"
+// @Success 200 {array} OnlineUser
+// @Failure 500 {object} ErrorResponse
+// @Router /presence [get]
+func PresenceHandler(hub *Hub) router.HandlerFunc {
+	return func(c *router.Context) error {
+		users, err := hub.Online(c.Request.Context(), c.Query("room"))
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch presence: " + err.Error()})
+		}
+		return c.JSON(http.StatusOK, users)
+	}
+}
+
+// newSessionId returns a random id of a connection
+func newSessionId() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf)
+}
+
+// instanceName names this process in sessions
+func instanceName() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
@@ -1,11 +1,14 @@
 package websocket
 
 import (
+	"base/core/emitter"
 	"base/core/router"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
@@ -23,8 +26,11 @@ type Client struct {
 	ID       string
 	Nickname string
 	Room     string
+	UserId   uint // Signed-in user, 0 for anonymous clients
 	Conn     *websocket.Conn
 	Send     chan []byte
+
+	session string // Id of the presence session
 }
 
 // Message represents a message structure
@@ -42,23 +48,42 @@ type Hub struct {
 	register   chan *Client
 	unregister chan *Client
 	mutex      *sync.Mutex
+
+	// Identify returns the name of a signed-in user, shown in presence instead of the
+	// nickname they connect with (optional)
+	Identify func(ctx context.Context, userId uint) string
+
+	presence PresenceStore
+	local    map[string]bool // Presence sessions of the clients of this instance
+	instance string
+	emitter  *emitter.Emitter
 }
 
-// NewHub creates a new Hub instance
-func NewHub() *Hub {
+// NewHub creates a new Hub instance; presence events are emitted with emitter, which may be nil
+func NewHub(emitter *emitter.Emitter) *Hub {
 	return &Hub{
 		rooms:      make(map[string]map[*Client]bool),
 		broadcast:  make(chan []byte),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 		mutex:      &sync.Mutex{},
+		presence:   NewMemoryPresenceStore(),
+		local:      make(map[string]bool),
+		instance:   instanceName(),
+		emitter:    emitter,
 	}
 }
 
 // Run starts the Hub
 func (h *Hub) Run() {
+	ticker := time.NewTicker(presenceHeartbeat)
+	defer ticker.Stop()
+
 	for {
 		select {
+		case <-ticker.C:
+			h.heartbeat()
+
 		case client := <-h.register:
 			h.mutex.Lock()
 			if _, ok := h.rooms[client.Room]; !ok {
@@ -103,6 +128,7 @@ func (h *Hub) Run() {
 					delete(h.rooms[client.Room], c)
 				}
 			}
+			h.join(client)
 			h.mutex.Unlock()
 
 		case client := <-h.unregister:
@@ -111,6 +137,7 @@ func (h *Hub) Run() {
 				if _, ok := h.rooms[client.Room][client]; ok {
 					delete(h.rooms[client.Room], client)
 					close(client.Send)
+					h.leave(client)
 
 					// Send leave message
 					leaveMsg := Message{
@@ -261,8 +288,15 @@ func ServeWs(hub *Hub, c *router.Context) {
 		ID:       c.Query("id"),
 		Nickname: c.Query("nickname"),
 		Room:     c.Query("room"),
+		UserId:   c.GetUint("user_id"),
 		Conn:     conn,
 		Send:     make(chan []byte, 256),
+		session:  newSessionId(),
+	}
+	if client.UserId != 0 && hub.Identify != nil {
+		if name := hub.Identify(c.Request.Context(), client.UserId); name != "" {
+			client.Nickname = name
+		}
 	}
 
 	hub.register <- client
@@ -283,9 +317,51 @@ func (h *Hub) BroadcastMessage(messageType string, content any) {
 	}
 }
 
-// InitWebSocketModule initializes the WebSocket module
-func InitWebSocketModule(router *router.RouterGroup) *Hub {
-	hub := NewHub()
+// BroadcastToRoom sends a message to the clients connected to a room
+func (h *Hub) BroadcastToRoom(room, messageType string, content any) {
+	message := Message{
+		Type:     messageType,
+		Content:  content,
+		Room:     room,
+		Nickname: "System",
+	}
+	if msgBytes, err := json.Marshal(message); err == nil {
+		h.broadcast <- msgBytes
+	}
+}
+
+// SendToUser sends a message to every connection of a signed-in user, whatever its room.
+// Messages to connections that are behind are dropped rather than closing them.
+func (h *Hub) SendToUser(userId uint, messageType string, content any) {
+	message := Message{
+		Type:     messageType,
+		Content:  content,
+		Nickname: "System",
+	}
+	msgBytes, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for _, room := range h.rooms {
+		for client := range room {
+			if client.UserId != userId {
+				continue
+			}
+			select {
+			case client.Send <- msgBytes:
+			default:
+			}
+		}
+	}
+}
+
+// InitWebSocketModule initializes the WebSocket module; presence events are emitted with
+// emitter
+func InitWebSocketModule(router *router.RouterGroup, emitter *emitter.Emitter) *Hub {
+	hub := NewHub(emitter)
 	go hub.Run()
 	SetupWebSocketRoutes(router, hub)
 	return hub
@@ -294,6 +370,7 @@ func InitWebSocketModule(router *router.RouterGroup) *Hub {
 // SetupWebSocketRoutes sets up the WebSocket routes
 func SetupWebSocketRoutes(router *router.RouterGroup, hub *Hub) {
 	router.GET("/ws", WebSocketHandler(hub))
+	router.GET("/presence", PresenceHandler(hub)) // Who is online
 }
 
 // WebSocketHandler returns a router.HandlerFunc for handling WebSocket connections
@@ -14,8 +14,12 @@ require (
 	github.com/swaggo/swag v1.16.6
 	golang.org/x/crypto v0.42.0
 	golang.org/x/image v0.31.0
+	golang.org/x/net v0.44.0
+	google.golang.org/grpc v1.76.0
+	google.golang.org/protobuf v1.36.10
 	gorm.io/driver/sqlite v1.6.0
 	gorm.io/gorm v1.31.0
+	gorm.io/plugin/dbresolver v1.6.2
 )
 
 require (
@@ -78,14 +82,11 @@ require (
 	go.yaml.in/yaml/v3 v3.0.4 // indirect
 	goji.io v2.0.2+incompatible // indirect
 	golang.org/x/mod v0.28.0 // indirect
-	golang.org/x/net v0.44.0 // indirect
 	golang.org/x/oauth2 v0.31.0 // indirect
 	golang.org/x/sync v0.17.0 // indirect
 	golang.org/x/sys v0.36.0 // indirect
 	golang.org/x/tools v0.37.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20251006185510-65f7160b3a87 // indirect
-	google.golang.org/grpc v1.76.0 // indirect
-	google.golang.org/protobuf v1.36.10 // indirect
 )
 
 require (
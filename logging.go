@@ -0,0 +1,87 @@
+package main
+
+import (
+	"base/core/logger"
+	"base/core/router"
+	"net/http"
+)
+
+// loggingRequest changes the global level and the per-module levels. An empty module
+// level removes the override so the module follows the global level again.
+type loggingRequest struct {
+	Level   string            `json:"level"`
+	Modules map[string]string `json:"modules"`
+}
+
+// loggingRoutes registers the admin endpoints for viewing and changing log levels.
+// Changes are kept in memory; the global level is reset by the next runtime config reload.
+func (app *App) loggingRoutes(group *router.RouterGroup) {
+	isAdmin := app.adminCheck()
+
+	status := func(c *router.Context) error {
+		level := ""
+		if leveled, ok := app.logger.(logger.LevelController); ok {
+			level = leveled.Level()
+		}
+		return c.JSON(http.StatusOK, map[string]any{
+			"level":         level,
+			"modules":       logger.ModuleLevels(),
+			"known_modules": logger.Modules(),
+		})
+	}
+
+	group.GET("/_logging", func(c *router.Context) error {
+		if !isAdmin(c) {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "Admin role required"})
+		}
+		return status(c)
+	})
+
+	group.PUT("/_logging", func(c *router.Context) error {
+		if !isAdmin(c) {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "Admin role required"})
+		}
+
+		var req loggingRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request: " + err.Error()})
+		}
+
+		// Validate everything before applying anything
+		if req.Level != "" {
+			if err := logger.ValidateLevel(req.Level); err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+			}
+		}
+		for module, level := range req.Modules {
+			if level == "" {
+				continue
+			}
+			if err := logger.ValidateLevel(level); err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": module + ": " + err.Error()})
+			}
+		}
+
+		if req.Level != "" {
+			leveled, ok := app.logger.(logger.LevelController)
+			if !ok {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "The log level can't be changed"})
+			}
+			if err := leveled.SetLevel(req.Level); err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+			}
+		}
+		for module, level := range req.Modules {
+			if level == "" {
+				logger.ClearModuleLevel(module)
+			} else if err := logger.SetModuleLevel(module, level); err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+			}
+		}
+
+		app.logger.Info("Log levels changed",
+			logger.String("level", req.Level),
+			logger.Any("modules", req.Modules))
+		return status(c)
+	})
+}
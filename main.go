@@ -2,23 +2,36 @@ package main
 
 import (
 	appmodules "base/app"
+	_ "base/app/migrations"
 	coremodules "base/core/app"
 	"base/core/app/authorization"
+	"base/core/app/requestlogs"
 	"base/core/config"
+	"base/core/crud"
 	"base/core/database"
 	"base/core/email"
 	"base/core/emitter"
+	"base/core/features"
+	"base/core/locks"
+	"base/core/graphql"
+	coregrpc "base/core/grpc"
 	"base/core/logger"
 	"base/core/module"
+	"base/core/openapi"
+	"base/core/pdf"
 	"base/core/router"
 	"base/core/router/middleware"
+	"base/core/sanitize"
 	"base/core/storage"
-	_ "base/core/translation"
+	"base/core/translation"
 	"base/core/websocket"
+	"context"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -63,11 +76,17 @@ type App struct {
 	emitter     *emitter.Emitter
 	storage     *storage.ActiveStorage
 	emailSender email.Sender
+	pdf         *pdf.Service
 	wsHub       *websocket.Hub
+	grpcServer  *coregrpc.Server
+	apiDocs     *openapi.Document
 
 	// State
 	running bool
 	verbose bool
+
+	// processEnv records the variables set before .env was loaded; they take precedence on reload
+	processEnv map[string]bool
 }
 
 // New creates a new Base application instance
@@ -94,12 +113,22 @@ func (app *App) Start() error {
 		initRouter().
 		autoDiscoverModules().
 		setupRoutes().
+		setupDocs().
+		startGRPC().
+		watchRuntimeConfig().
 		displayServerInfo().
 		run()
 }
 
 // loadEnvironment loads environment variables
 func (app *App) loadEnvironment() *App {
+	app.processEnv = make(map[string]bool)
+	for _, entry := range os.Environ() {
+		if key, _, found := strings.Cut(entry, "="); found {
+			app.processEnv[key] = true
+		}
+	}
+
 	if err := godotenv.Load(); err != nil {
 		// Non-fatal - continue without .env file
 	}
@@ -109,6 +138,16 @@ func (app *App) loadEnvironment() *App {
 // initConfig initializes configuration
 func (app *App) initConfig() *App {
 	app.config = config.NewConfig()
+
+	// Refuse to start with an invalid configuration, listing every problem at once
+	if errs := app.config.Validate(); len(errs) > 0 {
+		fmt.Printf("\n\033[31mInvalid configuration:\033[0m\n")
+		for _, err := range errs {
+			fmt.Printf("  - %v\n", err)
+		}
+		fmt.Printf("\nUse \"config check\" to re-validate without starting the server.\n\n")
+		os.Exit(1)
+	}
 	return app
 }
 
@@ -117,7 +156,8 @@ func (app *App) initLogger() *App {
 	logConfig := logger.Config{
 		Environment: app.config.Env,
 		LogPath:     "logs",
-		Level:       "debug",
+		Level:       app.config.LogLevel,
+		Sinks:       app.logSinks(),
 	}
 
 	log, err := logger.NewLogger(logConfig)
@@ -126,9 +166,39 @@ func (app *App) initLogger() *App {
 	}
 
 	app.logger = log
+
+	// Per-module overrides from LOG_MODULE_LEVELS; they can be changed at /api/_logging
+	if err := logger.SetModuleLevels(app.config.LogModuleLevels); err != nil {
+		app.logger.Warn("Ignoring LOG_MODULE_LEVELS", logger.String("error", err.Error()))
+	}
 	return app
 }
 
+// logSinks creates the remote log sinks enabled in the configuration
+func (app *App) logSinks() []logger.Sink {
+	options := logger.SinkOptions{
+		BufferSize:    app.config.LogSinkBufferSize,
+		BatchSize:     app.config.LogSinkBatchSize,
+		FlushInterval: app.config.LogSinkFlushInterval,
+	}
+
+	var sinks []logger.Sink
+	if app.config.LogLokiURL != "" {
+		labels := map[string]string{"app": app.config.LogServiceName, "env": app.config.Env}
+		for key, value := range app.config.LogLokiLabels {
+			labels[key] = value
+		}
+		sinks = append(sinks, logger.NewLokiSink(app.config.LogLokiURL, labels, options))
+	}
+	if app.config.LogSyslogAddress != "" {
+		sinks = append(sinks, logger.NewSyslogSink(app.config.LogSyslogNetwork, app.config.LogSyslogAddress, app.config.LogServiceName, options))
+	}
+	if app.config.LogOTLPEndpoint != "" {
+		sinks = append(sinks, logger.NewOTLPSink(app.config.LogOTLPEndpoint, app.config.LogServiceName, app.config.LogOTLPHeaders, options))
+	}
+	return sinks
+}
+
 // initDatabase initializes the database connection
 func (app *App) initDatabase() *App {
 	db, err := database.InitDB(app.config)
@@ -138,11 +208,21 @@ func (app *App) initDatabase() *App {
 	}
 
 	app.db = db
+	db.QueryLog.SetLogger(logger.ForModule(app.logger, "database"))
+	features.Flags.SetDB(db.DB)
 
 	if app.verbose {
 		app.logger.Info("Database connected", logger.String("driver", app.config.DBDriver))
 	}
 
+	// Versioned migrations are applied explicitly with the migrate command
+	if pending, err := database.NewMigrator(db.DB).Pending(); err != nil {
+		app.logger.Warn("Failed to check pending migrations", logger.String("error", err.Error()))
+	} else if len(pending) > 0 {
+		app.logger.Warn("Pending database migrations, run the migrate command to apply them",
+			logger.Int("count", len(pending)))
+	}
+
 	return app
 }
 
@@ -152,18 +232,7 @@ func (app *App) initInfrastructure() *App {
 	app.emitter = emitter.New()
 
 	// Initialize storage
-	storageConfig := storage.Config{
-		Provider:  app.config.StorageProvider,
-		Path:      app.config.StoragePath,
-		BaseURL:   app.config.StorageBaseURL,
-		APIKey:    app.config.StorageAPIKey,
-		APISecret: app.config.StorageAPISecret,
-		Endpoint:  app.config.StorageEndpoint,
-		Bucket:    app.config.StorageBucket,
-		CDN:       app.config.CDN,
-	}
-
-	activeStorage, err := storage.NewActiveStorage(app.db.DB, storageConfig)
+	activeStorage, err := storage.NewActiveStorage(app.db.DB, app.storageConfig())
 	if err != nil {
 		app.logger.Error("Failed to initialize storage", logger.String("error", err.Error()))
 		panic(fmt.Sprintf("Storage initialization failed: %v", err))
@@ -174,6 +243,21 @@ func (app *App) initInfrastructure() *App {
 		app.logger.Info("Storage initialized", logger.String("provider", app.config.StorageProvider))
 	}
 
+	// Initialize PDF rendering
+	app.pdf = pdf.NewService(app.config, app.logger)
+
+	// Policies of the rich-text fields; the default policy is a runtime setting
+	if err := sanitize.Default.SetFieldPolicies(app.config.HTMLFieldPolicies); err != nil {
+		app.logger.Warn("Ignoring HTML_FIELD_POLICIES", logger.String("error", err.Error()))
+	}
+
+	// Copies of records get the translations and attachments of the originals
+	crud.RegisterCopier("translations", translation.CopyFields)
+	crud.RegisterCopier("attachments", app.storage.CopyAttachments)
+
+	// Locks of the records admins are editing
+	locks.Default.Configure(app.config.LockTTL, app.db.DB, app.emitter)
+
 	// Initialize email sender (non-fatal)
 	emailSender, err := email.NewSender(app.config)
 	if err != nil {
@@ -188,9 +272,34 @@ func (app *App) initInfrastructure() *App {
 	return app
 }
 
+// storageConfig returns the storage configuration of the environment
+func (app *App) storageConfig() storage.Config {
+	return storage.Config{
+		Provider:  app.config.StorageProvider,
+		Path:      app.config.StoragePath,
+		BaseURL:   app.config.StorageBaseURL,
+		APIKey:    app.config.StorageAPIKey,
+		APISecret: app.config.StorageAPISecret,
+		Endpoint:  app.config.StorageEndpoint,
+		Bucket:    app.config.StorageBucket,
+		CDN:       app.config.CDN,
+	}
+}
+
 // initRouter initializes the router with middleware
 func (app *App) initRouter() *App {
 	app.router = router.New()
+	app.router.SetEnvelope(router.EnvelopeConfig{
+		Mode:      router.EnvelopeMode(app.config.ResponseEnvelope),
+		SkipPaths: app.config.ResponseEnvelopeSkipPaths,
+	})
+	if err := app.router.SetProxies(router.ProxyConfig{
+		TrustedProxies: app.config.TrustedProxies,
+		Headers:        app.config.ClientIPHeaders,
+	}); err != nil {
+		app.logger.Warn("Ignoring TRUSTED_PROXIES", logger.String("error", err.Error()))
+	}
+	router.Responses.Configure(app.config.ResponseCacheTTL)
 	app.setupMiddleware()
 	app.setupStaticRoutes()
 	app.initWebSocket()
@@ -204,10 +313,51 @@ func (app *App) initRouter() *App {
 
 // setupMiddleware configures all middleware using the new configurable system
 func (app *App) setupMiddleware() {
+	// Request Id and per-request query statistics
+	app.router.Use(middleware.RequestId())
+	app.router.Use(middleware.QueryStats(app.config.DBQueryCountHeader))
+
+	// Request locale (Accept-Language, the user's locale, then default_locale), timezone
+	// (X-Timezone, the user's timezone, then the timezone setting) and translated error messages
+	app.router.Use(translation.LocaleMiddleware(translation.LocaleConfig{
+		Supported: app.config.SupportedLocales,
+		UserPreferences: func(c *router.Context) translation.Preferences {
+			var preferences translation.Preferences
+			userId, err := authorization.GetUserIdFromContext(c)
+			if err != nil {
+				return preferences
+			}
+			app.db.DB.Table("users").Where("id = ?", userId).Select("locale, timezone").Scan(&preferences)
+			return preferences
+		},
+	}))
+
+	// Request log stored in the database, viewable at /api/logs/requests. It runs before
+	// the auth middleware so rejected requests are stored too.
+	if app.config.RequestLogEnabled {
+		recorder := requestlogs.NewRecorder(app.db.DB, requestlogs.RecorderOptions{
+			SampleRate: app.config.RequestLogSampleRate,
+			Retention:  app.config.RequestLogRetention,
+			BodyLimit:  app.config.RequestLogBodyLimit,
+			SkipPaths:  app.config.RequestLogSkipPaths,
+		}, logger.ForModule(app.logger, "requestlogs"))
+		app.router.Use(recorder.Middleware())
+	}
+
 	// Apply configurable middleware system
-	middleware.ApplyConfigurableMiddleware(app.router, &app.config.Middleware)
+	cm := middleware.ApplyConfigurableMiddleware(app.router, &app.config.Middleware)
+
+	// Pick up rate limit changes made at runtime
+	app.emitter.On(config.RuntimeChangedEvent, func(data any) {
+		change, ok := data.(config.RuntimeChange)
+		if ok && (slices.Contains(change.Changed, config.SettingRateLimitRequests) ||
+			slices.Contains(change.Changed, config.SettingRateLimitWindow)) {
+			cm.SetRateLimit(change.Values.RateLimitRequests, change.Values.GetRateLimitDuration())
+		}
+	})
 
 	// Custom request logging middleware (conditional based on config)
+	requestLog := logger.ForModule(app.logger, "router")
 	app.router.Use(func(next router.HandlerFunc) router.HandlerFunc {
 		return func(c *router.Context) error {
 			path := c.Request.URL.Path
@@ -234,12 +384,13 @@ func (app *App) setupMiddleware() {
 				}
 
 				// Log as ERROR for 5xx status codes, WARN for 4xx, INFO for others
+				log := logger.FromContext(c.Context(), requestLog)
 				if status >= 500 {
-					app.logger.Error("Request failed", logFields...)
+					log.Error("Request failed", logFields...)
 				} else if status >= 400 {
-					app.logger.Warn("Request", logFields...)
+					log.Warn("Request", logFields...)
 				} else {
-					app.logger.Info("Request", logFields...)
+					log.Info("Request", logFields...)
 				}
 
 				return err
@@ -250,18 +401,107 @@ func (app *App) setupMiddleware() {
 		}
 	})
 
-	// CORS middleware (conditional based on config)
+	// CORS middleware (conditional based on config): the public API first, then everything else
 	if app.config.Middleware.CORSEnabled {
-		corsOrigins := strings.Split(os.Getenv("CORS_ALLOWED_ORIGINS"), ",")
-		app.router.Use(middleware.CORSMiddleware(corsOrigins))
+		var policies []middleware.CORSPolicy
+		if len(app.config.CORSPublicPaths) > 0 {
+			policies = append(policies, middleware.CORSPolicy{
+				Paths: app.config.CORSPublicPaths,
+				Origins: func() []string {
+					return app.config.Runtime.Get().CORSPublicOrigins
+				},
+				ExposeHeaders: app.config.CORSExposeHeaders,
+				MaxAge:        app.config.CORSMaxAge,
+			})
+		}
+		policies = append(policies, middleware.CORSPolicy{
+			Origins: func() []string {
+				return app.config.Runtime.Get().CORSAllowedOrigins
+			},
+			AllowCredentials: app.config.CORSAllowCredentials,
+			ExposeHeaders:    app.config.CORSExposeHeaders,
+			MaxAge:           app.config.CORSMaxAge,
+		})
+		app.router.Use(middleware.CORS(policies...))
+	}
+
+	// Maintenance mode (MAINTENANCE_MODE or the maintenance_mode setting); runs after auth
+	// so admins can keep working
+	isAdmin := app.adminCheck()
+	app.router.Use(middleware.Maintenance(middleware.MaintenanceConfig{
+		Enabled: func() bool {
+			return app.config.Runtime.Get().MaintenanceMode
+		},
+		AllowPaths:  app.config.MaintenanceAllowPaths,
+		BypassToken: app.config.MaintenanceBypassToken,
+		RetryAfter:  app.config.MaintenanceRetryAfter,
+		IsAdmin:     isAdmin,
+	}))
+
+	// Per-request debug logging (X-Debug-Log with LOG_DEBUG_TOKEN, or "true" for admins)
+	app.router.Use(middleware.DebugLog(middleware.DebugLogConfig{
+		Token:   app.config.LogDebugToken,
+		IsAdmin: isAdmin,
+	}))
+
+	// Debug reports in the response meta for admins (?_debug=1)
+	app.router.Use(middleware.Explain(middleware.ExplainConfig{IsAdmin: isAdmin}))
+}
+
+// adminCheck returns a function reporting whether the authenticated user has an admin role
+func (app *App) adminCheck() func(*router.Context) bool {
+	authService := authorization.NewAuthorizationService(app.db.DB)
+	return func(c *router.Context) bool {
+		userId, err := authorization.GetUserIdFromContext(c)
+		if err != nil {
+			return false
+		}
+		isAdmin, err := authService.HasRole(c.Request.Context(), userId, authorization.AdminRoles...)
+		return err == nil && isAdmin
 	}
 }
 
 // setupStaticRoutes configures static file serving
 func (app *App) setupStaticRoutes() {
-	app.router.Static("/static", "./static")
-	app.router.Static("/storage", "./storage")
-	app.router.Static("/swagger", "./swagger")
+	cache := router.StaticConfig{CacheControl: cacheControl(app.config.StaticCacheMaxAge)}
+	app.router.StaticWithConfig("/static", "./static", cache)
+	if app.config.SwaggerEnabled {
+		app.router.Static("/swagger", "./swagger")
+	}
+
+	// Only the uploads of ./storage are public, never the database or anything else kept there
+	if public := app.storagePublicPaths(); len(public) > 0 {
+		app.router.StaticWithConfig("/storage", "./storage", router.StaticConfig{
+			Allow:        public,
+			CacheControl: cache.CacheControl,
+		})
+	}
+}
+
+// storagePublicPaths returns the paths of ./storage served under /storage: those configured,
+// or the local upload directory when it's below ./storage
+func (app *App) storagePublicPaths() []string {
+	if len(app.config.StoragePublicPaths) > 0 {
+		return app.config.StoragePublicPaths
+	}
+	if app.config.StorageProvider != "local" {
+		return nil
+	}
+
+	uploads, err := filepath.Rel("storage", filepath.Clean(app.config.StoragePath))
+	if err != nil || uploads == "." || strings.HasPrefix(uploads, "..") {
+		return nil
+	}
+	return []string{"/" + filepath.ToSlash(uploads) + "/*"}
+}
+
+// cacheControl returns the Cache-Control header letting browsers cache a file for maxAge,
+// or "" for none
+func cacheControl(maxAge time.Duration) string {
+	if maxAge <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds()))
 }
 
 // initWebSocket initializes the WebSocket hub if enabled
@@ -270,13 +510,50 @@ func (app *App) initWebSocket() {
 		return
 	}
 
-	app.wsHub = websocket.InitWebSocketModule(app.router.Group("/api"))
+	app.wsHub = websocket.InitWebSocketModule(app.router.Group("/api"), app.emitter)
+	app.wsHub.Identify = func(ctx context.Context, userId uint) string {
+		var user struct{ FirstName, LastName, Username string }
+		app.db.DB.WithContext(ctx).Table("users").Where("id = ?", userId).Select("first_name, last_name, username").Scan(&user)
+		if name := strings.TrimSpace(user.FirstName + " " + user.LastName); name != "" {
+			return name
+		}
+		return user.Username
+	}
+	app.forwardLockEvents()
+	app.forwardUploadProgress()
 
 	if app.verbose {
 		app.logger.Info("WebSocket initialized")
 	}
 }
 
+// forwardLockEvents tells the editors connected to the room of a record, e.g. settings:12,
+// and to the room of its resource, e.g. settings, when it is locked and unlocked
+func (app *App) forwardLockEvents() {
+	messages := map[string]string{
+		locks.LockEvent:   "record_locked",
+		locks.UnlockEvent: "record_unlocked",
+	}
+	for event, messageType := range messages {
+		app.emitter.On(event, func(data any) {
+			if lock, ok := data.(*locks.Lock); ok {
+				app.wsHub.BroadcastToRoom(lock.Room(), messageType, lock)
+				app.wsHub.BroadcastToRoom(lock.Resource, messageType, lock)
+			}
+		})
+	}
+}
+
+// forwardUploadProgress sends the progress of uploads to the connections of the user who
+// uploads, as upload_progress messages
+func (app *App) forwardUploadProgress() {
+	app.emitter.On(storage.UploadProgressEvent, func(data any) {
+		if progress, ok := data.(*storage.UploadProgress); ok {
+			app.wsHub.SendToUser(progress.UserId, "upload_progress", progress)
+		}
+	})
+}
+
 // autoDiscoverModules automatically discovers and registers modules
 func (app *App) autoDiscoverModules() *App {
 	app.registerCoreModules()
@@ -305,12 +582,15 @@ func (app *App) registerCoreModules() {
 	// Create dependencies for core modules
 	deps := module.Dependencies{
 		DB:          app.db.DB,
+		Tx:          database.NewTxManager(app.db.DB),
 		Router:      app.router.Group("/api"),
 		Logger:      app.logger,
 		Emitter:     app.emitter,
 		Storage:     app.storage,
 		EmailSender: app.emailSender,
 		Config:      app.config,
+		Features:    features.Flags,
+		PDF:         app.pdf,
 	}
 
 	// Get search registry from app
@@ -330,6 +610,17 @@ func (app *App) registerCoreModules() {
 		app.logger.Info("Core modules registered", logger.Int("count", len(initialized)))
 	}
 
+	// Module startup timings and health, for debugging slow or failing startups
+	module.DiagnosticsRoutes(deps.Router)
+
+	// Read-only GraphQL endpoint; modules register their types in their Init
+	if app.config.GraphQLEnabled {
+		graphql.Routes(deps.Router)
+	}
+
+	// Global and per-module log levels
+	app.loggingRoutes(deps.Router)
+
 	// Add authorization service injection middleware globally
 	app.setupAuthorizationMiddleware()
 }
@@ -339,12 +630,15 @@ func (app *App) discoverAndRegisterAppModules() {
 	// Create dependencies for app modules
 	deps := module.Dependencies{
 		DB:          app.db.DB,
+		Tx:          database.NewTxManager(app.db.DB),
 		Router:      app.router.Group("/api"),
 		Logger:      app.logger,
 		Emitter:     app.emitter,
 		Storage:     app.storage,
 		EmailSender: app.emailSender,
 		Config:      app.config,
+		Features:    features.Flags,
+		PDF:         app.pdf,
 	}
 
 	// Use app module provider (like core modules)
@@ -380,41 +674,98 @@ func (app *App) setupRoutes() *App {
 		})
 	})
 
-	// Swagger documentation - redirect /swagger root to /swagger/index.html
-	app.router.GET("/swagger", func(c *router.Context) error {
-		return c.Redirect(302, "/swagger/index.html")
+	// Readiness check - verifies the database is reachable and reports pool usage
+	app.router.GET("/health/ready", func(c *router.Context) error {
+		sqlDB, err := app.db.DB.DB()
+		if err == nil {
+			ctx, cancel := context.WithTimeout(c.Context(), 2*time.Second)
+			defer cancel()
+			err = sqlDB.PingContext(ctx)
+		}
+		if err != nil {
+			return c.JSON(http.StatusServiceUnavailable, map[string]any{
+				"status": "unavailable",
+				"error":  err.Error(),
+			})
+		}
+
+		pool, _ := database.GetPoolStats(app.db.DB)
+
+		// Modules implementing module.HealthChecker report on their own dependencies
+		ctx, cancel := context.WithTimeout(c.Context(), 2*time.Second)
+		defer cancel()
+		modules, healthy := module.CheckHealth(ctx)
+		if !healthy {
+			return c.JSON(http.StatusServiceUnavailable, map[string]any{
+				"status":   "unavailable",
+				"database": pool,
+				"modules":  modules,
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]any{
+			"status":   "ready",
+			"version":  app.config.Version,
+			"database": pool,
+			"modules":  modules,
+		})
 	})
 
+	// Prometheus metrics - query duration histograms, connection pool statistics and event listeners
+	if app.config.MetricsEnabled {
+		app.router.GET("/metrics", func(c *router.Context) error {
+			c.SetHeader("Content-Type", "text/plain; version=0.0.4")
+			c.Status(http.StatusOK)
+			if err := database.WriteMetrics(c.Writer, app.db.DB, app.db.QueryLog.Metrics()); err != nil {
+				return err
+			}
+			if err := logger.WriteSinkMetrics(c.Writer); err != nil {
+				return err
+			}
+			return app.emitter.Metrics().Write(c.Writer)
+		})
+	}
+
+	// Swagger documentation - redirect /swagger root to /swagger/index.html
+	if app.config.SwaggerEnabled {
+		app.router.GET("/swagger", func(c *router.Context) error {
+			return c.Redirect(302, "/swagger/index.html")
+		})
+	}
+
+	// Captured email viewer for the dev email driver (never exposed in production)
+	if devSender, ok := app.emailSender.(*email.DevSender); ok && !app.config.IsProduction() {
+		email.NewDevController(devSender).Routes(app.router.Group("/dev"))
+
+		if app.verbose {
+			app.logger.Info("Dev email viewer available at /dev/emails")
+		}
+	}
+
 	// Check if public directory exists (production with frontend)
 	if _, err := os.Stat("./public"); err == nil {
 		if app.verbose {
 			app.logger.Info("Serving frontend from ./public")
 		}
 
-		// Serve frontend assets (/_nuxt, /_fonts, etc.)
-		app.router.GET("/_nuxt/*filepath", func(c *router.Context) error {
-			filepath := c.Param("filepath")
-			http.ServeFile(c.Writer, c.Request, "./public/_nuxt/"+filepath)
-			return nil
-		})
-
-		app.router.GET("/_fonts/*filepath", func(c *router.Context) error {
-			filepath := c.Param("filepath")
-			http.ServeFile(c.Writer, c.Request, "./public/_fonts/"+filepath)
-			return nil
-		})
+		// Serve frontend assets (/_nuxt, /_fonts, etc.); their names change with their content
+		assets := router.StaticConfig{CacheControl: "public, max-age=31536000, immutable"}
+		app.router.StaticWithConfig("/_nuxt", "./public/_nuxt", assets)
+		app.router.StaticWithConfig("/_fonts", "./public/_fonts", assets)
 
 		// Serve all other routes with index.html (SPA fallback)
 		app.router.NotFound(func(c *router.Context) error {
-			// If it's an API request, return 404 JSON
-			if strings.HasPrefix(c.Request.URL.Path, "/api") {
+			// If it's an API request, or not a page, return 404 JSON
+			method := c.Request.Method
+			if strings.HasPrefix(c.Request.URL.Path, "/api") || (method != http.MethodGet && method != http.MethodHead) {
 				return c.JSON(404, map[string]any{
 					"error": "Not found",
 				})
 			}
 
-			// Otherwise serve index.html for frontend routing
-			http.ServeFile(c.Writer, c.Request, "./public/index.html")
+			// Otherwise serve index.html for frontend routing, revalidated so deploys show up
+			c.SetHeader("Cache-Control", "no-cache")
+			c.File("./public/index.html")
 			return nil
 		})
 	} else {
@@ -430,17 +781,80 @@ func (app *App) setupRoutes() *App {
 	return app
 }
 
+// setupDocs serves the OpenAPI document of the registered routes at /api/openapi.json; it
+// runs after the other routes are registered
+func (app *App) setupDocs() *App {
+	if !app.config.SwaggerEnabled {
+		return app
+	}
+
+	handler := &openapi.Handler{}
+	app.router.GET("/api/openapi.json", handler.Spec)
+
+	doc, err := app.openAPIDocument()
+	if err == nil {
+		err = handler.SetDocument(doc)
+	}
+	if err != nil {
+		app.logger.Error("Failed to build the OpenAPI document", logger.String("error", err.Error()))
+		return app
+	}
+	app.apiDocs = doc
+	return app
+}
+
+// openAPIDocument assembles the OpenAPI document of the registered routes from the
+// annotations in the source, or in the document written by the openapi command
+func (app *App) openAPIDocument() (*openapi.Document, error) {
+	annotations, err := openapi.LoadAnnotations(".", openapi.DefaultDocument)
+	if err != nil {
+		return nil, err
+	}
+	doc := openapi.Build(app.router.Routes(), annotations)
+	if doc.Info.Version == "" {
+		doc.Info.Version = app.config.Version
+	}
+	return doc, nil
+}
+
+// startGRPC serves the gRPC services the modules registered, on their own port
+func (app *App) startGRPC() *App {
+	if !app.config.GRPCEnabled {
+		return app
+	}
+
+	server, err := coregrpc.NewServer(app.config.GRPCPort, app.config.GRPCAPIKey, logger.ForModule(app.logger, "grpc"))
+	if err == nil {
+		err = server.Start()
+	}
+	if err != nil {
+		app.logger.Error("Failed to start gRPC server", logger.String("error", err.Error()))
+		return app
+	}
+	app.grpcServer = server
+	return app
+}
+
 // displayServerInfo shows server startup information
 func (app *App) displayServerInfo() *App {
 	localIP := app.getLocalIP()
 	port := app.config.ServerPort
+	scheme := "http"
+	if app.serverConfig().TLS() {
+		scheme = "https"
+	}
 
 	fmt.Printf("\n\033[1;32mBase Framework Ready!\033[0m\n\n")
 	fmt.Printf("\033[36mServer URLs:\033[0m\n")
-	fmt.Printf("  Local:   http://localhost%s\n", port)
-	fmt.Printf("  Network: http://%s%s\n\n", localIP, port)
+	fmt.Printf("  Local:   %s://localhost%s\n", scheme, port)
+	fmt.Printf("  Network: %s://%s%s\n\n", scheme, localIP, port)
 	fmt.Printf("\033[36mAPI Documentation:\033[0m\n")
-	fmt.Printf("  Swagger: http://localhost%s/swagger/\n\n", port)
+	if app.config.SwaggerEnabled {
+		fmt.Printf("  Swagger: %s://localhost%s/swagger/\n", scheme, port)
+		fmt.Printf("  OpenAPI: %s://localhost%s/api/openapi.json\n\n", scheme, port)
+	} else {
+		fmt.Printf("  Disabled (SWAGGER_ENABLED=false)\n\n")
+	}
 
 	return app
 }
@@ -462,16 +876,31 @@ func (app *App) getLocalIP() string {
 	return "localhost"
 }
 
+// serverConfig returns the HTTPS and HTTP/2 configuration of the server
+func (app *App) serverConfig() router.ServerConfig {
+	return router.ServerConfig{
+		Addr:             app.config.ServerPort,
+		TLSCertFile:      app.config.TLSCertFile,
+		TLSKeyFile:       app.config.TLSKeyFile,
+		AutocertDomains:  app.config.TLSAutocertDomains,
+		AutocertCacheDir: app.config.TLSAutocertCache,
+		AutocertEmail:    app.config.TLSAutocertEmail,
+		RedirectAddr:     app.config.TLSRedirectAddress,
+		HTTP2:            app.config.ServerHTTP2,
+	}
+}
+
 // run starts the HTTP server
 func (app *App) run() error {
 	app.running = true
 	port := app.config.ServerPort
 
+	server := app.serverConfig()
 	if app.verbose {
-		app.logger.Info("Server starting", logger.String("port", port))
+		app.logger.Info("Server starting", logger.String("port", port), logger.Bool("tls", server.TLS()))
 	}
 
-	err := app.router.Run(port)
+	err := app.router.RunServer(server)
 	if err != nil {
 		// Check if it's an "address already in use" error
 		if strings.Contains(err.Error(), "bind: address already in use") {
@@ -495,6 +924,9 @@ func (app *App) Stop() error {
 	}
 
 	app.logger.Info("Shutting down gracefully...")
+	if app.grpcServer != nil {
+		app.grpcServer.Stop(10 * time.Second)
+	}
 	app.running = false
 	return nil
 }
@@ -503,6 +935,15 @@ func main() {
 	// Initialize the Base application
 	app := New()
 
+	// Run a CLI command instead of the server when one is given
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		if err := app.RunCommand(os.Args[1], os.Args[2:]); err != nil {
+			fmt.Printf("\n\033[31mCommand failed:\033[0m\n%v\n\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Normal application startup
 	if err := app.Start(); err != nil {
 		// Print user-friendly error message instead of panicking
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"base/core/app/settings"
+	"base/core/config"
+	"base/core/logger"
+	"base/core/router"
+	"base/core/sanitize"
+	"base/core/storage"
+	"base/core/translation"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/joho/godotenv"
+)
+
+// watchRuntimeConfig applies runtime settings on startup and reloads the runtime-tunable
+// configuration on SIGHUP or when one of the runtime settings changes
+func (app *App) watchRuntimeConfig() *App {
+	// Log level changes
+	app.emitter.On(config.RuntimeChangedEvent, func(data any) {
+		change, ok := data.(config.RuntimeChange)
+		if !ok || !slices.Contains(change.Changed, config.SettingLogLevel) {
+			return
+		}
+		if leveled, ok := app.logger.(logger.LevelController); ok {
+			if err := leveled.SetLevel(change.Values.LogLevel); err != nil {
+				app.logger.Error("Failed to change log level", logger.String("error", err.Error()))
+			}
+		}
+	})
+
+	// Default locale changes
+	app.emitter.On(config.RuntimeChangedEvent, func(data any) {
+		change, ok := data.(config.RuntimeChange)
+		if ok && slices.Contains(change.Changed, config.SettingDefaultLocale) {
+			translation.SetDefaultLocale(change.Values.DefaultLocale)
+		}
+	})
+
+	// Timezone and date format changes
+	app.emitter.On(config.RuntimeChangedEvent, func(data any) {
+		change, ok := data.(config.RuntimeChange)
+		if !ok || !slices.ContainsFunc(change.Changed, func(key string) bool {
+			return key == config.SettingTimezone || key == config.SettingDateFormat || key == config.SettingTimeFormat
+		}) {
+			return
+		}
+		app.applyDateDefaults(change.Values)
+	})
+
+	// HTML policy changes
+	app.emitter.On(config.RuntimeChangedEvent, func(data any) {
+		change, ok := data.(config.RuntimeChange)
+		if ok && slices.Contains(change.Changed, config.SettingHTMLPolicy) {
+			app.applyHTMLPolicy(change.Values.HTMLPolicy)
+		}
+	})
+
+	// Upload limit changes
+	app.emitter.On(config.RuntimeChangedEvent, func(data any) {
+		change, ok := data.(config.RuntimeChange)
+		if !ok {
+			return
+		}
+		if slices.Contains(change.Changed, config.SettingUploadMaxSize) {
+			storage.SetDefaultMaxFileSize(change.Values.UploadMaxSize)
+		}
+		if slices.Contains(change.Changed, config.SettingMultipartMemory) {
+			router.SetMultipartMemory(change.Values.MultipartMemory)
+		}
+	})
+
+	// Settings changes
+	onSettingsChange := func(data any) {
+		if item, ok := data.(*settings.Settings); ok && settings.IsRuntimeSetting(item.SettingKey) {
+			app.reloadRuntimeConfig("settings", false)
+		}
+	}
+	app.emitter.On(settings.CreateSettingsEvent, onSettingsChange)
+	app.emitter.On(settings.UpdateSettingsEvent, onSettingsChange)
+	app.emitter.On(settings.DeleteSettingsEvent, onSettingsChange)
+
+	// SIGHUP re-reads .env and the environment
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		for range signals {
+			app.reloadRuntimeConfig("signal", true)
+		}
+	}()
+
+	translation.SetDefaultLocale(app.config.Runtime.Get().DefaultLocale)
+	app.applyDateDefaults(app.config.Runtime.Get())
+	app.applyHTMLPolicy(app.config.Runtime.Get().HTMLPolicy)
+	storage.SetDefaultMaxFileSize(app.config.Runtime.Get().UploadMaxSize)
+	router.SetMultipartMemory(app.config.Runtime.Get().MultipartMemory)
+	app.reloadRuntimeConfig("startup", false)
+	return app
+}
+
+// reloadRuntimeConfig recomputes the runtime values from the environment and the settings
+// table, stores them and emits config.RuntimeChangedEvent when anything changed.
+// Invalid values are logged and the current values are kept.
+func (app *App) reloadRuntimeConfig(source string, rereadEnv bool) {
+	values := app.config.RuntimeValues()
+
+	if rereadEnv {
+		// Like on startup, variables set in the process environment win over .env
+		dotenv, err := godotenv.Read()
+		if err != nil && !os.IsNotExist(err) {
+			app.logger.Warn("Failed to reload .env", logger.String("error", err.Error()))
+		}
+		for key, value := range dotenv {
+			if !app.processEnv[key] {
+				os.Setenv(key, value)
+			}
+		}
+
+		cfg := config.NewConfig()
+		if errs := cfg.Validate(); len(errs) > 0 {
+			for _, err := range errs {
+				app.logger.Error("Invalid configuration, reload skipped", logger.String("error", err.Error()))
+			}
+			return
+		}
+
+		// Only the runtime-tunable values are taken over; everything else needs a restart
+		app.config.LogLevel = cfg.LogLevel
+		app.config.CORSAllowedOrigins = cfg.CORSAllowedOrigins
+		app.config.CORSPublicOrigins = cfg.CORSPublicOrigins
+		app.config.MaintenanceMode = cfg.MaintenanceMode
+		app.config.DefaultLocale = cfg.DefaultLocale
+		app.config.Timezone = cfg.Timezone
+		app.config.DateFormat = cfg.DateFormat
+		app.config.TimeFormat = cfg.TimeFormat
+		app.config.HTMLPolicy = cfg.HTMLPolicy
+		app.config.UploadMaxSize = cfg.UploadMaxSize
+		app.config.MultipartMemory = cfg.MultipartMemory
+		app.config.Middleware.RateLimitRequests = cfg.Middleware.RateLimitRequests
+		app.config.Middleware.RateLimitWindow = cfg.Middleware.RateLimitWindow
+		values = cfg.RuntimeValues()
+	}
+
+	if err := settings.ApplyRuntimeOverrides(app.db.DB, &values); err != nil {
+		app.logger.Error("Failed to load runtime settings", logger.String("error", err.Error()))
+		return
+	}
+
+	if err := values.Validate(); err != nil {
+		app.logger.Error("Invalid runtime configuration, keeping current values",
+			logger.String("source", source),
+			logger.String("error", err.Error()))
+		return
+	}
+
+	changed := app.config.Runtime.Set(values)
+	if len(changed) == 0 {
+		return
+	}
+
+	app.logger.Info("Runtime configuration changed",
+		logger.String("source", source),
+		logger.String("changed", strings.Join(changed, ",")))
+
+	app.emitter.Emit(config.RuntimeChangedEvent, config.RuntimeChange{
+		Source:  source,
+		Changed: changed,
+		Values:  values,
+	})
+}
+
+// applyDateDefaults sets the timezone and date formats used to format dates in responses
+func (app *App) applyDateDefaults(values config.RuntimeValues) {
+	if err := translation.SetDateDefaults(values.Timezone, values.DateFormat, values.TimeFormat); err != nil {
+		app.logger.Error("Invalid date settings", logger.String("error", err.Error()))
+	}
+}
+
+// applyHTMLPolicy sets the policy rich-text fields without their own are sanitized with
+func (app *App) applyHTMLPolicy(policy string) {
+	if err := sanitize.Default.SetPolicy(policy); err != nil {
+		app.logger.Error("Invalid HTML policy", logger.String("error", err.Error()))
+	}
+}